@@ -0,0 +1,242 @@
+// Package anomaly flags patterns in leave, task logs and medical expenses that HR may want to
+// look into. Each rule reads the days of a range and returns findings, which the
+// anomaly-detection job keeps in a review queue:
+//
+//	sick-mondays        a user took sick leave on at least SickMondayMin Mondays, and those
+//	                    were at least SickMondayShare of their sick days
+//	over-logging        a user logged more work on a day than OverLoggingRatio of their daily
+//	                    capacity, a finding per day
+//	expense-near-limit  at least NearLimitMin of a user's medical expenses came to at most
+//	                    NearLimitMargin below the most the medical_expense approval policy lets
+//	                    through without approval
+//
+// A finding is something to look at, not proof of anything. Its fingerprint tells findings
+// apart, so a pattern is flagged once and again only when it grows: the last Monday of the sick
+// leave, the day logged or the last expense.
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Rules that flag findings
+const (
+	RuleSickMondays      = "sick-mondays"
+	RuleOverLogging      = "over-logging"
+	RuleExpenseNearLimit = "expense-near-limit"
+)
+
+// Rules lists every rule, in the order they run
+var Rules = []string{RuleSickMondays, RuleOverLogging, RuleExpenseNearLimit}
+
+// Statuses of a finding in the review queue
+const (
+	StatusOpen      = "open"
+	StatusConfirmed = "confirmed"
+	StatusDismissed = "dismissed"
+)
+
+// Statuses lists every status of a finding
+var Statuses = []string{StatusOpen, StatusConfirmed, StatusDismissed}
+
+// Settings are the rules that run and their thresholds
+type Settings struct {
+	Rules            []string // ANOMALY_RULES
+	SickMondayMin    int      // ANOMALY_SICK_MONDAY_MIN, Mondays of sick leave
+	SickMondayShare  float64  // ANOMALY_SICK_MONDAY_SHARE, of the sick days, 0 to 1
+	OverLoggingRatio float64  // ANOMALY_OVER_LOGGING_RATIO, of the daily capacity, 1 is 100%
+	NearLimitMargin  float64  // ANOMALY_NEAR_LIMIT_MARGIN, of the approval limit, 0 to 1
+	NearLimitMin     int      // ANOMALY_NEAR_LIMIT_MIN, expenses
+}
+
+// Finding is a pattern a rule flagged in a user's records
+type Finding struct {
+	Rule        string
+	UserID      int32
+	Start       time.Time // The first day of the pattern
+	End         time.Time // The last day of the pattern
+	Fingerprint string
+	Summary     string // In English, the i18n package translates it
+	Details     any    // The days, logs or expenses behind the finding, as JSON
+}
+
+// SickMondaysDetails are the details of a sick-mondays finding
+type SickMondaysDetails struct {
+	SickDays int      `json:"sickDays"`
+	Mondays  []string `json:"mondays"` // YYYY-MM-DD
+}
+
+// OverLoggingDetails are the details of an over-logging finding
+type OverLoggingDetails struct {
+	Date          string  `json:"date"` // YYYY-MM-DD
+	LoggedDays    float64 `json:"loggedDays"`
+	DailyCapacity float64 `json:"dailyCapacity"`
+}
+
+// ExpenseNearLimitDetails are the details of an expense-near-limit finding
+type ExpenseNearLimitDetails struct {
+	ApprovalLimit float64          `json:"approvalLimit"`
+	Expenses      []NearLimitEntry `json:"expenses"`
+}
+
+// NearLimitEntry is a medical expense just under the approval limit
+type NearLimitEntry struct {
+	ID          int32   `json:"id"`
+	Amount      float64 `json:"amount"`
+	ReceiptDate string  `json:"receiptDate"` // YYYY-MM-DD
+}
+
+// Detect runs the rules of settings over the days from start to end, returning their findings by
+// rule and user
+func Detect(ctx context.Context, q sqlc.Querier, start, end time.Time, settings Settings) ([]Finding, error) {
+	var findings []Finding
+	for _, rule := range settings.Rules {
+		var found []Finding
+		var err error
+		switch rule {
+		case RuleSickMondays:
+			found, err = sickMondays(ctx, q, start, end, settings)
+		case RuleOverLogging:
+			found, err = overLogging(ctx, q, start, end, settings)
+		case RuleExpenseNearLimit:
+			found, err = expensesNearLimit(ctx, q, start, end, settings)
+		default:
+			err = errors.New("unknown rule")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("anomaly rule %s: %w", rule, err)
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+func sickMondays(ctx context.Context, q sqlc.Querier, start, end time.Time, settings Settings) ([]Finding, error) {
+	rows, err := q.ListSickLeaveDays(ctx, sqlc.ListSickLeaveDaysParams{StartDate: date(start), EndDate: date(end)})
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	// The rows come by user, each user's days ending where the next user's begin
+	for i := 0; i < len(rows); {
+		userID := rows[i].UserID
+		sickDays := 0
+		var mondays []time.Time
+		for ; i < len(rows) && rows[i].UserID == userID; i++ {
+			sickDays++
+			if day := rows[i].Date.Time; day.Weekday() == time.Monday {
+				mondays = append(mondays, day)
+			}
+		}
+		if len(mondays) == 0 || len(mondays) < settings.SickMondayMin || float64(len(mondays)) < settings.SickMondayShare*float64(sickDays) {
+			continue
+		}
+		details := SickMondaysDetails{SickDays: sickDays, Mondays: make([]string, len(mondays))}
+		for j, day := range mondays {
+			details.Mondays[j] = day.Format(time.DateOnly)
+		}
+		last := mondays[len(mondays)-1]
+		findings = append(findings, Finding{
+			Rule:        RuleSickMondays,
+			UserID:      userID,
+			Start:       mondays[0],
+			End:         last,
+			Fingerprint: last.Format(time.DateOnly),
+			Summary:     fmt.Sprintf("%d of %d sick days were Mondays", len(mondays), sickDays),
+			Details:     details,
+		})
+	}
+	return findings, nil
+}
+
+func overLogging(ctx context.Context, q sqlc.Querier, start, end time.Time, settings Settings) ([]Finding, error) {
+	rows, err := q.ListOverloggedDays(ctx, sqlc.ListOverloggedDaysParams{StartDate: date(start), EndDate: date(end), MaxRatio: settings.OverLoggingRatio})
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]Finding, 0, len(rows))
+	for _, row := range rows {
+		logged, err := pgconv.ToFloat(row.LoggedDays)
+		if err != nil {
+			return nil, err
+		}
+		capacity, err := pgconv.ToFloat(row.DailyCapacity)
+		if err != nil {
+			return nil, err
+		}
+		day := row.WorkedDate.Time.Format(time.DateOnly)
+		findings = append(findings, Finding{
+			Rule:        RuleOverLogging,
+			UserID:      row.UserID,
+			Start:       row.WorkedDate.Time,
+			End:         row.WorkedDate.Time,
+			Fingerprint: day,
+			Summary:     fmt.Sprintf("Logged %s days of work on %s against a daily capacity of %s", number(logged), day, number(capacity)),
+			Details:     OverLoggingDetails{Date: day, LoggedDays: logged, DailyCapacity: capacity},
+		})
+	}
+	return findings, nil
+}
+
+func expensesNearLimit(ctx context.Context, q sqlc.Querier, start, end time.Time, settings Settings) ([]Finding, error) {
+	rows, err := q.ListExpensesNearApprovalLimit(ctx, sqlc.ListExpensesNearApprovalLimitParams{StartDate: date(start), EndDate: date(end), Margin: settings.NearLimitMargin})
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	// The rows come by user, like those of sickMondays
+	for i := 0; i < len(rows); {
+		first := rows[i]
+		limit, err := pgconv.ToFloat(first.ApprovalLimit)
+		if err != nil {
+			return nil, err
+		}
+		details := ExpenseNearLimitDetails{ApprovalLimit: limit}
+		for ; i < len(rows) && rows[i].UserID == first.UserID; i++ {
+			amount, err := pgconv.ToFloat(rows[i].Amount)
+			if err != nil {
+				return nil, err
+			}
+			details.Expenses = append(details.Expenses, NearLimitEntry{
+				ID:          rows[i].ID,
+				Amount:      amount,
+				ReceiptDate: rows[i].ReceiptDate.Time.Format(time.DateOnly),
+			})
+		}
+		if len(details.Expenses) < max(settings.NearLimitMin, 1) {
+			continue
+		}
+		last := rows[i-1]
+		findings = append(findings, Finding{
+			Rule:        RuleExpenseNearLimit,
+			UserID:      first.UserID,
+			Start:       first.ReceiptDate.Time,
+			End:         last.ReceiptDate.Time,
+			Fingerprint: strconv.Itoa(int(last.ID)),
+			Summary:     fmt.Sprintf("%d medical expenses just under the approval limit of %s baht", len(details.Expenses), number(limit)),
+			Details:     details,
+		})
+	}
+	return findings, nil
+}
+
+func date(t time.Time) pgtype.Date {
+	return pgtype.Date{Time: t, Valid: true}
+}
+
+// number formats a number of days or baht without trailing zeros
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
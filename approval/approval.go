@@ -0,0 +1,374 @@
+// Package approval routes leave, medical expenses, timesheets and quota plan edits through the
+// approvals their policies ask for.
+//
+// A policy lists the steps a kind of request goes through one after another, and the amount up
+// to which a request needs none. A step names who decides it:
+//
+//	manager            the requester's direct manager on the org chart
+//	supervisor         anyone up the requester's reporting line, or the head of their
+//	                   department or of a department above it
+//	department_head    the head of the requester's department or of a department above it
+//	admin              any admin
+//	department:<name>  anyone in the department or a department under it, e.g. department:HR
+//
+// Admins may decide any step, nobody their own request. An approver who is away delegates their
+// approvals for some days, and the delegate then decides whatever the delegator could, recorded
+// as on the delegator's behalf under the delegation. Revoked delegations no longer count. A
+// request keeps the steps it was submitted with, so changing a policy leaves the requests already
+// waiting alone. Kinds without a policy need no approval.
+package approval
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Kinds of request
+const (
+	KindLeave          = "leave"
+	KindMedicalExpense = "medical_expense"
+	KindTimesheet      = "timesheet"
+	KindQuotaPlan      = "quota_plan"
+)
+
+// Kinds lists every kind of request, in the order they are shown
+var Kinds = []string{KindLeave, KindMedicalExpense, KindTimesheet, KindQuotaPlan}
+
+// Statuses of a request
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusCancelled = "cancelled"
+)
+
+// Steps of a policy, see the package doc. StepDepartmentPrefix is followed by a department name.
+const (
+	StepManager          = "manager"
+	StepSupervisor       = "supervisor"
+	StepDepartmentHead   = "department_head"
+	StepAdmin            = "admin"
+	StepDepartmentPrefix = "department:"
+)
+
+var (
+	// ErrNotApprover is returned when someone who may not decide the step a request waits on
+	// tries to
+	ErrNotApprover = errors.New("approval: not an approver of the step the request waits on")
+	// ErrDecided is returned when the request isn't waiting on the step any more, because it was
+	// decided, maybe by someone else a moment before, or cancelled
+	ErrDecided = errors.New("approval: request decided already")
+)
+
+// ValidKind reports whether kind is one of Kinds
+func ValidKind(kind string) bool {
+	return slices.Contains(Kinds, kind)
+}
+
+// ValidStep reports whether step is one of the steps a policy may have
+func ValidStep(step string) bool {
+	switch step {
+	case StepManager, StepSupervisor, StepDepartmentHead, StepAdmin:
+		return true
+	}
+	name, ok := strings.CutPrefix(step, StepDepartmentPrefix)
+	return ok && strings.TrimSpace(name) != ""
+}
+
+// StepLabel describes who decides a step, for messages like "waiting on HR"
+func StepLabel(step string) string {
+	switch step {
+	case StepManager:
+		return "their manager"
+	case StepSupervisor:
+		return "their manager or department head"
+	case StepDepartmentHead:
+		return "their department head"
+	case StepAdmin:
+		return "an admin"
+	}
+	if name, ok := strings.CutPrefix(step, StepDepartmentPrefix); ok {
+		return name
+	}
+	return step
+}
+
+// Step returns the step a request waits on, false once it isn't pending
+func Step(request sqlc.ApprovalRequest) (string, bool) {
+	if request.Status != StatusPending || int(request.CurrentStep) >= len(request.Steps) {
+		return "", false
+	}
+	return request.Steps[request.CurrentStep], true
+}
+
+// Submission is a request to submit for approval
+type Submission struct {
+	Kind        string
+	SubjectID   int32 // The leave log, medical expense, task log or quota plan
+	RequesterID int32
+	Amount      float64 // Days of leave or work, or baht, compared with the auto-approve amount
+	Payload     []byte  // What to apply once approved, for changes held until then
+}
+
+// Submit submits a request under the policy of its kind, returning false when the kind has no
+// policy and so needs no approval. A request of no more than the policy's auto-approve amount is
+// approved as it is submitted.
+func Submit(ctx context.Context, q sqlc.Querier, s Submission) (sqlc.ApprovalRequest, bool, error) {
+	policy, err := q.GetApprovalPolicy(ctx, s.Kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.ApprovalRequest{}, false, nil
+	}
+	if err != nil {
+		return sqlc.ApprovalRequest{}, false, err
+	}
+
+	amount, err := pgconv.FromFloat(s.Amount)
+	if err != nil {
+		return sqlc.ApprovalRequest{}, false, err
+	}
+	status := StatusPending
+	if len(policy.Steps) == 0 || autoApproves(policy, amount) {
+		status = StatusApproved
+	}
+
+	request, err := q.CreateApprovalRequest(ctx, sqlc.CreateApprovalRequestParams{
+		Kind:        s.Kind,
+		SubjectID:   s.SubjectID,
+		RequesterID: s.RequesterID,
+		Amount:      amount,
+		Payload:     s.Payload,
+		Steps:       policy.Steps,
+		Status:      status,
+	})
+	if err != nil {
+		return sqlc.ApprovalRequest{}, false, err
+	}
+	return request, true, nil
+}
+
+// autoApproves reports whether the amount is within the policy's auto-approve amount
+func autoApproves(policy sqlc.ApprovalPolicy, amount pgtype.Numeric) bool {
+	limit, err := pgconv.ToDecimal(policy.AutoApproveMax)
+	if err != nil {
+		return false
+	}
+	value, err := pgconv.ToDecimal(amount)
+	if err != nil {
+		return false
+	}
+	return value.Cmp(limit) <= 0
+}
+
+// CanDecide reports whether approver may decide the step the request waits on, for themselves or
+// for someone who delegated their approvals to them on day. delegation is the delegation they
+// decide under in the latter case, the zero one otherwise.
+func CanDecide(ctx context.Context, q sqlc.Querier, request sqlc.ApprovalRequest, approver sqlc.User, day time.Time) (delegation sqlc.ApprovalDelegation, ok bool, err error) {
+	step, pending := Step(request)
+	if !pending || approver.ID == request.RequesterID {
+		return delegation, false, nil
+	}
+	requester, err := q.GetUser(ctx, request.RequesterID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Only admins decide for users who were deleted since
+		return delegation, approver.UserType == "admin", nil
+	}
+	if err != nil {
+		return delegation, false, err
+	}
+
+	if ok, err := Eligible(ctx, q, step, approver, requester); err != nil || ok {
+		return delegation, ok, err
+	}
+
+	delegations, err := q.ListActiveApprovalDelegations(ctx, sqlc.ListActiveApprovalDelegationsParams{
+		DelegateID: approver.ID,
+		Day:        pgtype.Date{Time: day, Valid: true},
+	})
+	if err != nil {
+		return delegation, false, err
+	}
+	for _, d := range delegations {
+		delegator, err := q.GetUser(ctx, d.DelegatorID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return delegation, false, err
+		}
+		ok, err := Eligible(ctx, q, step, delegator, requester)
+		if err != nil {
+			return delegation, false, err
+		}
+		if ok {
+			return d, true, nil
+		}
+	}
+	return delegation, false, nil
+}
+
+// Eligible reports whether approver may decide the step of requester's requests themselves,
+// delegations left aside
+func Eligible(ctx context.Context, q sqlc.Querier, step string, approver, requester sqlc.User) (bool, error) {
+	if approver.ID == requester.ID {
+		return false, nil
+	}
+	if approver.UserType == "admin" {
+		return true, nil
+	}
+
+	switch step {
+	case StepAdmin:
+		return false, nil
+	case StepManager:
+		assignment, err := q.GetOrgAssignment(ctx, requester.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return assignment.ManagerID.Valid && assignment.ManagerID.Int32 == approver.ID, nil
+	case StepSupervisor:
+		managers, err := ManagerChain(ctx, q, requester.ID)
+		if err != nil {
+			return false, err
+		}
+		if slices.Contains(managers, approver.ID) {
+			return true, nil
+		}
+		return headsDepartmentOf(ctx, q, approver, requester)
+	case StepDepartmentHead:
+		return headsDepartmentOf(ctx, q, approver, requester)
+	}
+
+	name, ok := strings.CutPrefix(step, StepDepartmentPrefix)
+	if !ok || !approver.Department.Valid {
+		return false, nil
+	}
+	if approver.Department.String == name {
+		return true, nil
+	}
+	departments, err := DepartmentChain(ctx, q, approver.Department.String)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(departments, func(d sqlc.Department) bool { return d.Name == name }), nil
+}
+
+// headsDepartmentOf reports whether approver heads requester's department or a department above it
+func headsDepartmentOf(ctx context.Context, q sqlc.Querier, approver, requester sqlc.User) (bool, error) {
+	if !requester.Department.Valid {
+		return false, nil
+	}
+	departments, err := DepartmentChain(ctx, q, requester.Department.String)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(departments, func(d sqlc.Department) bool {
+		return d.HeadUserID.Valid && d.HeadUserID.Int32 == approver.ID
+	}), nil
+}
+
+// Decide approves or rejects the step a request waits on. Rejecting a step rejects the request,
+// approving the last one approves it, and approving any other moves it on to the next. Run it in
+// the transaction that applies what the outcome changes.
+func Decide(ctx context.Context, q sqlc.Querier, request sqlc.ApprovalRequest, approver sqlc.User, approve bool, comment string, day time.Time) (sqlc.ApprovalRequest, error) {
+	if _, pending := Step(request); !pending {
+		return request, ErrDecided
+	}
+	delegation, ok, err := CanDecide(ctx, q, request, approver, day)
+	if err != nil {
+		return request, err
+	}
+	if !ok {
+		return request, ErrNotApprover
+	}
+
+	decision, next, status := StatusRejected, request.CurrentStep, StatusRejected
+	if approve {
+		decision, next, status = StatusApproved, request.CurrentStep+1, StatusPending
+		if int(next) >= len(request.Steps) {
+			status = StatusApproved
+		}
+	}
+
+	advanced, err := q.AdvanceApprovalRequest(ctx, sqlc.AdvanceApprovalRequestParams{
+		NextStep:    next,
+		Status:      status,
+		ID:          request.ID,
+		CurrentStep: request.CurrentStep,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return request, ErrDecided
+	}
+	if err != nil {
+		return request, err
+	}
+
+	_, err = q.CreateApprovalDecision(ctx, sqlc.CreateApprovalDecisionParams{
+		RequestID:    request.ID,
+		Step:         request.CurrentStep,
+		ApproverID:   pgtype.Int4{Int32: approver.ID, Valid: true},
+		OnBehalfOfID: pgtype.Int4{Int32: delegation.DelegatorID, Valid: delegation.ID != 0},
+		DelegationID: pgtype.Int4{Int32: delegation.ID, Valid: delegation.ID != 0},
+		Decision:     decision,
+		Comment:      pgtype.Text{String: comment, Valid: comment != ""},
+	})
+	if err != nil {
+		return request, err
+	}
+	return advanced, nil
+}
+
+// ManagerChain returns who a user reports to, who they report to in turn and so on up to the top
+// of the chart. A reporting cycle, which updates refuse but a chart may still hold, ends the
+// chain where it repeats.
+func ManagerChain(ctx context.Context, q sqlc.Querier, userID int32) ([]int32, error) {
+	var chain []int32
+	for {
+		assignment, err := q.GetOrgAssignment(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return chain, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !assignment.ManagerID.Valid || slices.Contains(chain, assignment.ManagerID.Int32) {
+			return chain, nil
+		}
+		chain = append(chain, assignment.ManagerID.Int32)
+		userID = assignment.ManagerID.Int32
+	}
+}
+
+// DepartmentChain returns the named department and the departments above it, up to the top of
+// the chart, none when the name isn't a department
+func DepartmentChain(ctx context.Context, q sqlc.Querier, name string) ([]sqlc.Department, error) {
+	department, err := q.GetDepartmentByName(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	chain := []sqlc.Department{department}
+	for department.ParentID.Valid {
+		if department, err = q.GetDepartment(ctx, department.ParentID.Int32); err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(chain, func(d sqlc.Department) bool { return d.ID == department.ID }) {
+			break
+		}
+		chain = append(chain, department)
+	}
+	return chain, nil
+}
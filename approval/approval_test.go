@@ -0,0 +1,248 @@
+package approval_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// orgFixture is a small org chart: alice reports to bob, who reports to carol, the head of
+// Engineering. Engineering sits under Operations, headed by dave. erin is in HR, frank is alice's
+// peer and gina an admin.
+type orgFixture struct {
+	store                                      *dbtest.Fake
+	alice, bob, carol, dave, erin, frank, gina sqlc.User
+}
+
+func newOrgFixture(t *testing.T) orgFixture {
+	t.Helper()
+	ctx := context.Background()
+	store := dbtest.NewFake()
+	f := orgFixture{
+		store: store,
+		alice: dbtest.CreateUser(t, store, "alice", "user"),
+		bob:   dbtest.CreateUser(t, store, "bob", "user"),
+		carol: dbtest.CreateUser(t, store, "carol", "user"),
+		dave:  dbtest.CreateUser(t, store, "dave", "user"),
+		erin:  dbtest.CreateUser(t, store, "erin", "user"),
+		frank: dbtest.CreateUser(t, store, "frank", "user"),
+		gina:  dbtest.CreateUser(t, store, "gina", "admin"),
+	}
+
+	operations, err := store.CreateDepartment(ctx, sqlc.CreateDepartmentParams{
+		Name:       "Operations",
+		HeadUserID: pgtype.Int4{Int32: f.dave.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, department := range []sqlc.CreateDepartmentParams{
+		{Name: "Engineering", ParentID: pgtype.Int4{Int32: operations.ID, Valid: true}, HeadUserID: pgtype.Int4{Int32: f.carol.ID, Valid: true}},
+		{Name: "HR"},
+	} {
+		if _, err := store.CreateDepartment(ctx, department); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, member := range []struct {
+		user       *sqlc.User
+		department string
+	}{{&f.alice, "Engineering"}, {&f.bob, "Engineering"}, {&f.frank, "Engineering"}, {&f.erin, "HR"}} {
+		updated, err := store.UpdateUser(ctx, sqlc.UpdateUserParams{
+			ID:         member.user.ID,
+			Username:   member.user.Username,
+			Password:   member.user.Password,
+			UserType:   member.user.UserType,
+			Email:      member.user.Email,
+			Department: pgtype.Text{String: member.department, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		*member.user = updated
+	}
+
+	for _, assignment := range [][2]int32{{f.alice.ID, f.bob.ID}, {f.bob.ID, f.carol.ID}, {f.frank.ID, f.bob.ID}} {
+		if _, err := store.UpsertOrgAssignment(ctx, sqlc.UpsertOrgAssignmentParams{
+			UserID:    assignment[0],
+			ManagerID: pgtype.Int4{Int32: assignment[1], Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+// delegate delegates the delegator's approvals to the delegate from start to end
+func (f orgFixture) delegate(t *testing.T, delegator, delegate sqlc.User, start, end time.Time) sqlc.ApprovalDelegation {
+	t.Helper()
+	delegation, err := f.store.CreateApprovalDelegation(context.Background(), sqlc.CreateApprovalDelegationParams{
+		DelegatorID: delegator.ID,
+		DelegateID:  delegate.ID,
+		StartDate:   pgtype.Date{Time: start, Valid: true},
+		EndDate:     pgtype.Date{Time: end, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delegation
+}
+
+func TestCanDecide(t *testing.T) {
+	day := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	f := newOrgFixture(t)
+	away := f.delegate(t, f.bob, f.erin, day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+	f.delegate(t, f.carol, f.frank, day.AddDate(0, 0, 1), day.AddDate(0, 0, 5))
+	revoked := f.delegate(t, f.dave, f.frank, day, day)
+	if _, err := f.store.RevokeApprovalDelegation(context.Background(), sqlc.RevokeApprovalDelegationParams{ID: revoked.ID}); err != nil {
+		t.Fatal(err)
+	}
+	f.delegate(t, f.alice, f.erin, day, day)
+
+	tests := []struct {
+		name           string
+		step           string
+		requester      sqlc.User
+		approver       sqlc.User
+		want           bool
+		wantDelegation int32
+	}{
+		{name: "manager", step: approval.StepManager, requester: f.alice, approver: f.bob, want: true},
+		{name: "manager's manager on a manager step", step: approval.StepManager, requester: f.alice, approver: f.carol},
+		{name: "peer", step: approval.StepManager, requester: f.alice, approver: f.frank},
+		{name: "supervisor up the chain", step: approval.StepSupervisor, requester: f.alice, approver: f.carol, want: true},
+		{name: "head of a department above", step: approval.StepSupervisor, requester: f.alice, approver: f.dave, want: true},
+		{name: "department head", step: approval.StepDepartmentHead, requester: f.alice, approver: f.carol, want: true},
+		{name: "head of a department above on a department head step", step: approval.StepDepartmentHead, requester: f.alice, approver: f.dave, want: true},
+		{name: "manager who heads nothing", step: approval.StepDepartmentHead, requester: f.alice, approver: f.bob},
+		{name: "named department", step: approval.StepDepartmentPrefix + "HR", requester: f.alice, approver: f.erin, want: true},
+		{name: "outside the named department", step: approval.StepDepartmentPrefix + "HR", requester: f.alice, approver: f.bob},
+		{name: "admin step", step: approval.StepAdmin, requester: f.alice, approver: f.bob},
+		{name: "admin", step: approval.StepManager, requester: f.alice, approver: f.gina, want: true},
+		{name: "self", step: approval.StepSupervisor, requester: f.bob, approver: f.bob},
+		{name: "admin self", step: approval.StepAdmin, requester: f.gina, approver: f.gina},
+		{name: "delegate", step: approval.StepManager, requester: f.alice, approver: f.erin, want: true, wantDelegation: away.ID},
+		{name: "delegation before it starts", step: approval.StepDepartmentHead, requester: f.alice, approver: f.frank},
+		{name: "revoked delegation", step: approval.StepSupervisor, requester: f.bob, approver: f.frank},
+		{name: "delegate deciding their delegator's own request", step: approval.StepManager, requester: f.bob, approver: f.erin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := sqlc.ApprovalRequest{
+				Kind:        approval.KindLeave,
+				RequesterID: tt.requester.ID,
+				Steps:       []string{tt.step},
+				Status:      approval.StatusPending,
+			}
+			delegation, ok, err := approval.CanDecide(context.Background(), f.store, request, tt.approver, day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want || delegation.ID != tt.wantDelegation {
+				t.Errorf("CanDecide = delegation %d, %v, want delegation %d, %v", delegation.ID, ok, tt.wantDelegation, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecideMovesThroughSteps(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	f := newOrgFixture(t)
+	if _, err := f.store.UpsertApprovalPolicy(ctx, sqlc.UpsertApprovalPolicyParams{
+		Kind:  approval.KindMedicalExpense,
+		Steps: []string{approval.StepManager, approval.StepDepartmentPrefix + "HR"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	request, ok, err := approval.Submit(ctx, f.store, approval.Submission{
+		Kind:        approval.KindMedicalExpense,
+		SubjectID:   1,
+		RequesterID: f.alice.ID,
+		Amount:      500,
+	})
+	if err != nil || !ok {
+		t.Fatalf("Submit = %v, %v", ok, err)
+	}
+
+	if _, err := approval.Decide(ctx, f.store, request, f.alice, true, "", day); err != approval.ErrNotApprover {
+		t.Errorf("deciding one's own request: %v, want ErrNotApprover", err)
+	}
+	if _, err := approval.Decide(ctx, f.store, request, f.erin, true, "", day.AddDate(0, 0, 10)); err != approval.ErrNotApprover {
+		t.Errorf("HR deciding the manager step without a delegation: %v, want ErrNotApprover", err)
+	}
+
+	request, err = approval.Decide(ctx, f.store, request, f.bob, true, "", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != approval.StatusPending || request.CurrentStep != 1 {
+		t.Fatalf("after the manager = %s at step %d, want pending at step 1", request.Status, request.CurrentStep)
+	}
+	if _, err := approval.Decide(ctx, f.store, request, f.bob, true, "", day); err != approval.ErrNotApprover {
+		t.Errorf("the manager deciding the HR step: %v, want ErrNotApprover", err)
+	}
+
+	request, err = approval.Decide(ctx, f.store, request, f.erin, true, "", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != approval.StatusApproved {
+		t.Errorf("after HR = %s, want approved", request.Status)
+	}
+	if _, err := approval.Decide(ctx, f.store, request, f.gina, false, "", day); err != approval.ErrDecided {
+		t.Errorf("deciding an approved request: %v, want ErrDecided", err)
+	}
+}
+
+func TestSubmitAutoApproves(t *testing.T) {
+	ctx := context.Background()
+	f := newOrgFixture(t)
+	limit, err := pgconv.FromFloat(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.store.UpsertApprovalPolicy(ctx, sqlc.UpsertApprovalPolicyParams{
+		Kind:           approval.KindMedicalExpense,
+		Steps:          []string{approval.StepManager},
+		AutoApproveMax: limit,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{amount: 999.5, want: approval.StatusApproved},
+		{amount: 1000, want: approval.StatusApproved},
+		{amount: 1000.01, want: approval.StatusPending},
+	}
+	for _, tt := range tests {
+		request, ok, err := approval.Submit(ctx, f.store, approval.Submission{
+			Kind:        approval.KindMedicalExpense,
+			SubjectID:   1,
+			RequesterID: f.alice.ID,
+			Amount:      tt.amount,
+		})
+		if err != nil || !ok {
+			t.Fatalf("Submit(%v) = %v, %v", tt.amount, ok, err)
+		}
+		if request.Status != tt.want {
+			t.Errorf("Submit(%v) = %s, want %s", tt.amount, request.Status, tt.want)
+		}
+	}
+
+	if _, ok, err := approval.Submit(ctx, f.store, approval.Submission{Kind: approval.KindTimesheet, RequesterID: f.alice.ID}); err != nil || ok {
+		t.Errorf("Submit without a policy = %v, %v, want false", ok, err)
+	}
+}
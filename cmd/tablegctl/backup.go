@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kengtableg/pkeng-tableg/db/backup"
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+// backupDatabase runs the backup command: it dumps the database into a directory or S3 bucket
+// and prunes the old dumps there
+func backupDatabase(args []string) {
+	settings := loadConfig()
+
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	target := flags.String("to", settings.Backup.Location, "directory or s3://bucket/prefix to store the backup in")
+	keep := flags.Int("keep", settings.Backup.Retention, "how many backups to keep, 0 keeps them all")
+	flags.Parse(args)
+
+	location, err := backup.Open(*target, storage.S3Config(settings.AWS))
+	if err != nil {
+		log.Fatalf("Error opening backup location: %v", err)
+	}
+
+	name, err := backup.Run(context.Background(), settings.Database.URL, location, *keep)
+	if err != nil {
+		log.Fatalf("Error backing up the database: %v", err)
+	}
+	fmt.Printf("Backed up the database to %s%s\n", location, name)
+}
+
+// restoreDatabase runs the restore command: it replaces the database with a backup, the newest
+// one unless --name picks another. It only lists the backups without --yes, since a restore
+// drops every change made after the backup.
+func restoreDatabase(args []string) {
+	settings := loadConfig()
+
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	target := flags.String("from", settings.Backup.Location, "directory or s3://bucket/prefix holding the backups")
+	name := flags.String("name", "latest", "backup to restore")
+	confirmed := flags.Bool("yes", false, "replace the database with the backup")
+	flags.Parse(args)
+
+	location, err := backup.Open(*target, storage.S3Config(settings.AWS))
+	if err != nil {
+		log.Fatalf("Error opening backup location: %v", err)
+	}
+
+	ctx := context.Background()
+	if *name == "latest" {
+		*name, err = backup.Latest(ctx, location)
+		if err != nil {
+			log.Fatalf("Error finding the latest backup in %s: %v", location, err)
+		}
+	}
+
+	if !*confirmed {
+		names, err := location.List(ctx)
+		if err != nil {
+			log.Fatalf("Error listing backups: %v", err)
+		}
+		fmt.Printf("Backups in %s:\n", location)
+		for _, backupName := range names {
+			fmt.Printf("  %s\n", backupName)
+		}
+		fmt.Printf("Restoring %s replaces everything in the database, run again with --yes to go ahead\n", *name)
+		os.Exit(1)
+	}
+
+	if err := backup.Restore(ctx, settings.Database.URL, location, *name); err != nil {
+		log.Fatalf("Error restoring %s, the database was left as it was: %v", *name, err)
+	}
+	fmt.Printf("Restored the database from %s%s\n", location, *name)
+}
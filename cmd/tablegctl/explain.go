@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryNamePattern matches the name line sqlc starts every query constant with
+var queryNamePattern = regexp.MustCompile(`^-- name: (\w+) :(\w+)`)
+
+// generatedQuery is one query of the generated sqlc code
+type generatedQuery struct {
+	Name string
+	File string
+	SQL  string
+}
+
+// seqScan is a sequential scan that filters its table, so no index serves the filter
+type seqScan struct {
+	Table  string
+	Filter string
+}
+
+// explainHotQueries runs the explain-hot-queries command: it plans every generated sqlc query
+// with sequential scans disabled, so a query still planned with one filters a table no index can
+// serve. It exits with status 1 when it finds such a query.
+func explainHotQueries(args []string) {
+	flags := flag.NewFlagSet("explain-hot-queries", flag.ExitOnError)
+	dir := flags.String("dir", "db/sqlc", "directory of the generated sqlc code")
+	only := flags.String("only", "", "only explain queries whose name contains this")
+	flags.Parse(args)
+
+	queries, err := loadGeneratedQueries(*dir)
+	if err != nil {
+		log.Fatalf("Error reading the generated queries: %v", err)
+	}
+
+	ctx := context.Background()
+	// A connection of its own, since the session setting below must not leak into a pool
+	conn, err := pgx.Connect(ctx, loadConfig().Database.URL)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	var version int
+	if err := conn.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&version); err != nil {
+		log.Fatalf("Error reading the server version: %v", err)
+	}
+	if version < 160000 {
+		log.Fatalf("explain-hot-queries needs PostgreSQL 16 or later for EXPLAIN (GENERIC_PLAN)")
+	}
+	if _, err := conn.Exec(ctx, "SET enable_seqscan = off"); err != nil {
+		log.Fatalf("Error disabling sequential scans: %v", err)
+	}
+
+	explained, flagged := 0, 0
+	for _, query := range queries {
+		if !strings.Contains(query.Name, *only) {
+			continue
+		}
+		explained++
+
+		scans, err := explainSeqScans(ctx, conn, query.SQL)
+		if err != nil {
+			fmt.Printf("%-40s error: %v\n", query.Name, err)
+			continue
+		}
+		if len(scans) == 0 {
+			continue
+		}
+		flagged++
+		for _, scan := range scans {
+			fmt.Printf("%-40s Seq Scan on %s, filter %s (%s)\n", query.Name, scan.Table, scan.Filter, query.File)
+		}
+	}
+
+	fmt.Printf("%d of %d queries scan a table to filter it\n", flagged, explained)
+	if flagged > 0 {
+		os.Exit(1)
+	}
+}
+
+// loadGeneratedQueries reads the query constants out of the generated .sql.go files, skipping
+// :copyfrom queries, which run as COPY rather than as the INSERT they are written as
+func loadGeneratedQueries(dir string) ([]generatedQuery, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql.go"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no generated queries in %s, run the command from the repository root", dir)
+	}
+
+	var queries []generatedQuery
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, value := range spec.(*ast.ValueSpec).Values {
+					literal, ok := value.(*ast.BasicLit)
+					if !ok || literal.Kind != token.STRING {
+						continue
+					}
+					sql, err := strconv.Unquote(literal.Value)
+					if err != nil {
+						return nil, err
+					}
+					match := queryNamePattern.FindStringSubmatch(sql)
+					if match == nil || match[2] == "copyfrom" {
+						continue
+					}
+					queries = append(queries, generatedQuery{Name: match[1], File: filepath.Base(path), SQL: sql})
+				}
+			}
+		}
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
+
+// explainSeqScans plans a query without running it and returns its filtering sequential scans.
+// The query goes over the simple protocol untouched, since its $n placeholders have no values.
+func explainSeqScans(ctx context.Context, conn *pgx.Conn, sql string) ([]seqScan, error) {
+	results, err := conn.PgConn().Exec(ctx, "EXPLAIN (GENERIC_PLAN, FORMAT JSON) "+sql).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != 1 || len(results[0].Rows) != 1 {
+		return nil, fmt.Errorf("unexpected EXPLAIN output")
+	}
+
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(results[0].Rows[0][0], &plans); err != nil {
+		return nil, err
+	}
+
+	var scans []seqScan
+	for _, plan := range plans {
+		plan.Plan.collectSeqScans(&scans)
+	}
+	return scans, nil
+}
+
+// planNode is the part of an EXPLAIN (FORMAT JSON) plan node the audit looks at
+type planNode struct {
+	NodeType string     `json:"Node Type"`
+	Relation string     `json:"Relation Name"`
+	Filter   string     `json:"Filter"`
+	Plans    []planNode `json:"Plans"`
+}
+
+// collectSeqScans appends the filtering sequential scans of the node and its children. Scans
+// without a filter read the whole table on purpose, like an unfiltered listing.
+func (n planNode) collectSeqScans(scans *[]seqScan) {
+	if n.NodeType == "Seq Scan" && n.Filter != "" {
+		*scans = append(*scans, seqScan{Table: n.Relation, Filter: n.Filter})
+	}
+	for _, child := range n.Plans {
+		child.collectSeqScans(scans)
+	}
+}
@@ -1,38 +1,83 @@
+// Command tablegctl runs the operational tasks of a deployment against the database and with
+// the configuration the server uses: migrations, users, tokens, year-end and report exports,
+// seeding, backups and tenants.
 package main
 
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
-	"strconv"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/config"
 	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/tz"
 )
 
+const usage = `Usage: go run ./cmd/tablegctl <command> [flags]
+
+Commands:
+  migrate up|down|status         apply, revert (--steps) or list the migrations
+  user create                    add a user (--username, --email, --type, --password)
+  user reset-password            set a user's password (--username, --password)
+  token                          print the API token of a user (--username)
+  sync-year                      recompute the annual records of a year (--year)
+  close-year                     create next year's annual records and quota plan (--year)
+  export <report>                write a report to a file (--from, --to, --format, --language, --out)
+  seed                           fill the database with a profile (--profile demo|test)
+  check                          report on the quota plan tables
+  create-quotas                  create the quota plans of this year and the next
+  backup | restore               dump the database to a directory or S3, or load a dump back
+  explain-hot-queries            find the queries planned with a sequential scan
+  create-tenant                  add a tenant with its admin user (--slug, --name)
+
+Commands that touch a tenant's data take --tenant with the tenant's slug when MULTI_TENANT is
+set, sync-year and close-year run for every tenant without one.
+Run a command with -h for its flags.`
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run db/dbtools/main.go [check|migrate|create-quotas]")
+		fmt.Println(usage)
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
 
 	switch command {
+	case "migrate":
+		migrateDatabase(os.Args[2:])
+	case "user":
+		userCommand(os.Args[2:])
+	case "token":
+		issueToken(os.Args[2:])
+	case "sync-year":
+		syncYear(os.Args[2:])
+	case "close-year":
+		closeYear(os.Args[2:])
+	case "export":
+		exportReport(os.Args[2:])
 	case "check":
 		checkDatabaseStructure()
-	case "migrate":
-		runMigration()
 	case "create-quotas":
 		createDefaultQuotas()
+	case "seed":
+		seedDatabase(os.Args[2:])
+	case "backup":
+		backupDatabase(os.Args[2:])
+	case "restore":
+		restoreDatabase(os.Args[2:])
+	case "explain-hot-queries":
+		explainHotQueries(os.Args[2:])
+	case "create-tenant":
+		createTenant(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
-		fmt.Println("Usage: go run db/dbtools/main.go [check|migrate|create-quotas]")
+		fmt.Println(usage)
 		os.Exit(1)
 	}
 }
@@ -125,50 +170,16 @@ func checkDatabaseStructure() {
 	}
 }
 
-func runMigration() {
-	// Connect to database
-	database, err := db.New()
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
-	defer database.Close()
-
-	// Read the migration script
-	migrationPath := filepath.Join("db", "migrations", "migrate_to_quota_plans.sql")
-	migrationSQL, err := ioutil.ReadFile(migrationPath)
-	if err != nil {
-		log.Fatalf("Error reading migration file: %v", err)
-	}
-
-	fmt.Printf("Running migration from file: %s\n", migrationPath)
-	fmt.Println("Migration SQL:")
-	fmt.Println(string(migrationSQL))
-
-	// Execute the migration script
-	ctx := context.Background()
-	_, err = database.Pool.Exec(ctx, string(migrationSQL))
-	if err != nil {
-		log.Fatalf("Error executing migration: %v", err)
-	}
-
-	fmt.Println("Migration completed successfully!")
-
-	// Verify the migration
-	checkDatabaseStructure()
-}
-
 func createDefaultQuotas() {
 	// Connect to database
-	database, err := db.New()
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
+	settings := loadConfig()
+	database := connect(settings)
 	defer database.Close()
 
 	ctx := context.Background()
 
 	// Create default quota plans for current year and next year
-	currentYear := time.Now().Year()
+	currentYear := tz.Year(timeZone(settings))
 	years := []int{currentYear, currentYear + 1}
 
 	for _, year := range years {
@@ -179,21 +190,12 @@ func createDefaultQuotas() {
 			continue
 		}
 
-		// Helper function to create Numeric from float
-		createNumeric := func(val float64) pgtype.Numeric {
-			var num pgtype.Numeric
-			num.Valid = true
-			// Convert float to string and then scan into numeric
-			num.Scan(strconv.FormatFloat(val, 'f', 2, 64))
-			return num
-		}
-
 		// Default plan (10 vacation days, 20000 baht medical)
 		defaultPlan, err := database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
 			PlanName:                "Default",
 			Year:                    int32(year),
-			QuotaVacationDay:        createNumeric(10),
-			QuotaMedicalExpenseBaht: createNumeric(20000),
+			QuotaVacationDay:        pgconv.MustFromFloat(10),
+			QuotaMedicalExpenseBaht: pgconv.MustFromFloat(20000),
 		})
 
 		if err != nil {
@@ -206,8 +208,8 @@ func createDefaultQuotas() {
 		standardPlan, err := database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
 			PlanName:                "Standard",
 			Year:                    int32(year),
-			QuotaVacationDay:        createNumeric(15),
-			QuotaMedicalExpenseBaht: createNumeric(30000),
+			QuotaVacationDay:        pgconv.MustFromFloat(15),
+			QuotaMedicalExpenseBaht: pgconv.MustFromFloat(30000),
 		})
 
 		if err != nil {
@@ -220,8 +222,8 @@ func createDefaultQuotas() {
 		execPlan, err := database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
 			PlanName:                "Executive",
 			Year:                    int32(year),
-			QuotaVacationDay:        createNumeric(20),
-			QuotaMedicalExpenseBaht: createNumeric(50000),
+			QuotaVacationDay:        pgconv.MustFromFloat(20),
+			QuotaMedicalExpenseBaht: pgconv.MustFromFloat(50000),
 		})
 
 		if err != nil {
@@ -281,3 +283,69 @@ func createDefaultQuotas() {
 	rowsAffected := result.RowsAffected()
 	fmt.Printf("\nAssigned default quota plan to %d annual records\n", rowsAffected)
 }
+
+// loadConfig reads the configuration for commands that need more than a database connection
+func loadConfig() *config.Config {
+	settings, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	return settings
+}
+
+// timeZone returns TIME_ZONE, which the commands' current year and today are in
+func timeZone(settings *config.Config) *time.Location {
+	location, err := tz.Load(settings.Server.TimeZone)
+	if err != nil {
+		log.Fatalf("Invalid TIME_ZONE: %v", err)
+	}
+	return location
+}
+
+// connect opens the database the server is configured with
+func connect(settings *config.Config) *db.DB {
+	if settings.Database.InMemory() {
+		log.Fatalf("tablegctl needs Postgres, DATABASE_DRIVER is memory")
+	}
+	database, err := db.Connect(settings.Database)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	return database
+}
+
+// tenantContext returns a context bound to the tenant with the given slug. Without MULTI_TENANT
+// there is only the default tenant and the slug must be empty, with it an empty slug is the
+// default tenant too.
+func tenantContext(ctx context.Context, settings *config.Config, database *db.DB, slug string) context.Context {
+	if !settings.Database.MultiTenant {
+		if slug != "" {
+			log.Fatalf("--tenant needs MULTI_TENANT")
+		}
+		return ctx
+	}
+	if slug == "" {
+		return db.WithTenant(ctx, db.DefaultTenantID)
+	}
+	tenant, err := database.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		log.Fatalf("Error finding tenant %q: %v", slug, err)
+	}
+	return db.WithTenant(ctx, tenant.ID)
+}
+
+// forEachTenant runs job for the tenant with the given slug or, without one, for every tenant,
+// like the server's scheduled jobs do. Without MULTI_TENANT it runs once.
+func forEachTenant(ctx context.Context, settings *config.Config, database *db.DB, slug string, job func(ctx context.Context, tenant string)) {
+	if !settings.Database.MultiTenant || slug != "" {
+		job(tenantContext(ctx, settings, database, slug), slug)
+		return
+	}
+	tenants, err := database.ListTenants(ctx)
+	if err != nil {
+		log.Fatalf("Error listing tenants: %v", err)
+	}
+	for _, tenant := range tenants {
+		job(db.WithTenant(ctx, tenant.ID), tenant.Slug)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kengtableg/pkeng-tableg/db/migrations"
+)
+
+// migrateDatabase runs the migrate command: up applies every pending migration, down reverts
+// the last --steps migrations and status lists them
+func migrateDatabase(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert with down")
+	if len(args) == 0 {
+		log.Fatalf("migrate needs up, down or status")
+	}
+	command := args[0]
+	flags.Parse(args[1:])
+
+	database := connect(loadConfig())
+	defer database.Close()
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrations.Up(ctx, database.Pool)
+		if err != nil {
+			log.Fatalf("Error applying migrations: %v", err)
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+
+	case "down":
+		reverted, err := migrations.Down(ctx, database.Pool, *steps)
+		if err != nil {
+			log.Fatalf("Error reverting migrations: %v", err)
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+
+	case "status":
+		statuses, err := migrations.Status(ctx, database.Pool)
+		if err != nil {
+			log.Fatalf("Error reading migration status: %v", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d  %-30s  %s\n", status.Version, status.Name, applied)
+		}
+
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down or status", command)
+	}
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/tz"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedProfile describes the data one seed profile creates
+type seedProfile struct {
+	users      []seedUser
+	categories []seedCategory
+	// logDays is how many days back from yesterday get task logs
+	logDays int
+	// leaveDays is how many working days each regular user takes off in that period
+	leaveDays int
+	// medicalExpenses is how many receipts each regular user files this year
+	medicalExpenses int
+}
+
+type seedUser struct {
+	username string
+	userType string
+}
+
+// seedCategory is a category with its subcategories, tasks go in the categories without children
+type seedCategory struct {
+	name        string
+	description string
+	budgetDay   float64
+	children    []seedCategory
+	tasks       []string
+}
+
+var seedProfiles = map[string]seedProfile{
+	// demo looks like a small team that has used the app for a year
+	"demo": {
+		users: []seedUser{
+			{username: "napat", userType: "admin"},
+			{username: "somchai", userType: "user"},
+			{username: "siriporn", userType: "user"},
+			{username: "anan", userType: "user"},
+			{username: "kanokwan", userType: "user"},
+			{username: "thanawat", userType: "user"},
+		},
+		categories: []seedCategory{
+			{
+				name:        "Product Development",
+				description: "Building and shipping the product",
+				budgetDay:   600,
+				children: []seedCategory{
+					{name: "Backend", tasks: []string{"Payment gateway integration", "Reporting API", "Database index tuning", "Audit log service"}},
+					{name: "Frontend", tasks: []string{"Dashboard redesign", "Leave request form", "Accessibility fixes", "Dark mode"}},
+					{name: "Mobile", tasks: []string{"Push notifications", "Offline mode", "App store release"}},
+				},
+			},
+			{
+				name:        "Operations",
+				description: "Keeping production healthy",
+				budgetDay:   250,
+				children: []seedCategory{
+					{name: "Infrastructure", tasks: []string{"Kubernetes upgrade", "Backup restore drill", "Monitoring alerts"}},
+					{name: "Support", tasks: []string{"Customer escalations", "Bug triage"}},
+				},
+			},
+			{
+				name:        "Internal",
+				description: "Meetings, hiring and learning",
+				children: []seedCategory{
+					{name: "Meetings", tasks: []string{"Sprint planning", "Retrospectives"}},
+					{name: "Training", tasks: []string{"Go workshop", "Security awareness"}},
+				},
+			},
+		},
+		logDays:         365,
+		leaveDays:       8,
+		medicalExpenses: 3,
+	},
+	// test is small and predictable, enough to exercise every page
+	"test": {
+		users: []seedUser{
+			{username: "test-admin", userType: "admin"},
+			{username: "test-user-1", userType: "user"},
+			{username: "test-user-2", userType: "user"},
+		},
+		categories: []seedCategory{
+			{name: "Test Project", budgetDay: 40, children: []seedCategory{
+				{name: "Test Backend", tasks: []string{"Test task A", "Test task B"}},
+				{name: "Test Frontend", tasks: []string{"Test task C"}},
+			}},
+			{name: "Test Internal", tasks: []string{"Test meetings"}},
+		},
+		logDays:         30,
+		leaveDays:       1,
+		medicalExpenses: 1,
+	},
+}
+
+// seedHolidays are the Thai public holidays on a fixed date, by month and day
+var seedHolidays = []struct {
+	month time.Month
+	day   int
+	name  string
+}{
+	{time.January, 1, "New Year's Day"},
+	{time.April, 6, "Chakri Memorial Day"},
+	{time.April, 13, "Songkran Festival"},
+	{time.April, 14, "Songkran Festival"},
+	{time.April, 15, "Songkran Festival"},
+	{time.May, 1, "National Labour Day"},
+	{time.July, 28, "King's Birthday"},
+	{time.August, 12, "Mother's Day"},
+	{time.October, 13, "King Bhumibol Memorial Day"},
+	{time.October, 23, "Chulalongkorn Day"},
+	{time.December, 5, "Father's Day"},
+	{time.December, 10, "Constitution Day"},
+	{time.December, 31, "New Year's Eve"},
+}
+
+// seedTaskStatuses are the ClickUp default statuses, tasks cycle through them
+var seedTaskStatuses = []struct{ status, color string }{
+	{"to do", "#d3d3d3"},
+	{"in progress", "#4194f6"},
+	{"complete", "#6bc950"},
+}
+
+// seedCounts tallies what a seed created, for the summary
+type seedCounts struct {
+	users, holidays, quotaPlans, categories, tasks, taskLogs, leaveLogs, medicalExpenses int
+}
+
+// seedDatabase runs the seed command: it fills an empty database with the data of a profile, all
+// in one transaction so a failed seed leaves nothing behind
+func seedDatabase(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	profileName := flags.String("profile", "demo", "data to create: demo or test")
+	password := flags.String("password", "changeme", "password of every seeded user")
+	flags.Parse(args)
+
+	profile, ok := seedProfiles[*profileName]
+	if !ok {
+		log.Fatalf("Unknown profile %q, expected demo or test", *profileName)
+	}
+
+	settings := loadConfig()
+	database := connect(settings)
+	defer database.Close()
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Error hashing password: %v", err)
+	}
+
+	var counts seedCounts
+	err = database.WithTxContext(context.Background(), func(ctx context.Context, q sqlc.Querier) error {
+		seeder := &seeder{
+			q:              q,
+			profile:        profile,
+			hashedPassword: string(hashedPassword),
+			// A fixed source, so a profile always seeds the same data
+			random: rand.New(rand.NewSource(1)),
+			today:  tz.Today(timeZone(settings)),
+		}
+		err := seeder.seed(ctx)
+		counts = seeder.counts
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Error seeding the %s profile, nothing was written: %v", *profileName, err)
+	}
+
+	fmt.Printf("Seeded the %s profile:\n", *profileName)
+	fmt.Printf("  %d users (password %q)\n", counts.users, *password)
+	fmt.Printf("  %d holidays, %d quota plans\n", counts.holidays, counts.quotaPlans)
+	fmt.Printf("  %d task categories, %d tasks\n", counts.categories, counts.tasks)
+	fmt.Printf("  %d task logs, %d leave logs, %d medical expenses\n", counts.taskLogs, counts.leaveLogs, counts.medicalExpenses)
+}
+
+type seeder struct {
+	q              sqlc.Querier
+	profile        seedProfile
+	hashedPassword string
+	random         *rand.Rand
+	today          time.Time
+	counts         seedCounts
+
+	holidays map[time.Time]bool
+}
+
+func (s *seeder) seed(ctx context.Context) error {
+	// Seeding twice would duplicate the tasks and logs
+	existing, err := s.q.ListTasks(ctx, sqlc.ListTasksParams{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return errors.New("the database already has tasks, seed only fills an empty database")
+	}
+
+	from := s.today.AddDate(0, 0, -s.profile.logDays)
+	if err := s.seedHolidays(ctx, from.Year(), s.today.Year()); err != nil {
+		return fmt.Errorf("holidays: %w", err)
+	}
+	plan, err := s.seedQuotaPlans(ctx)
+	if err != nil {
+		return fmt.Errorf("quota plans: %w", err)
+	}
+	users, err := s.seedUsers(ctx, plan)
+	if err != nil {
+		return fmt.Errorf("users: %w", err)
+	}
+	tasks, err := s.seedCategories(ctx, s.profile.categories, pgtype.Int4{}, users)
+	if err != nil {
+		return fmt.Errorf("task categories: %w", err)
+	}
+
+	for _, user := range users {
+		if user.UserType == "admin" {
+			continue
+		}
+		leave, err := s.seedLeave(ctx, user, from)
+		if err != nil {
+			return fmt.Errorf("leave logs: %w", err)
+		}
+		if err := s.seedTaskLogs(ctx, user, tasks, from, leave); err != nil {
+			return fmt.Errorf("task logs: %w", err)
+		}
+		if err := s.seedMedicalExpenses(ctx, user); err != nil {
+			return fmt.Errorf("medical expenses: %w", err)
+		}
+	}
+
+	// Bring this year's annual records in line with the logs
+	if _, err := s.q.SyncAllAnnualRecordsByYear(ctx, int32(s.today.Year())); err != nil {
+		return fmt.Errorf("annual records: %w", err)
+	}
+	return nil
+}
+
+// seedHolidays adds the public holidays of the years, keeping the ones already there
+func (s *seeder) seedHolidays(ctx context.Context, fromYear, toYear int) error {
+	s.holidays = make(map[time.Time]bool)
+	var missing []sqlc.CreateHolidaysParams
+	for year := fromYear; year <= toYear; year++ {
+		for _, holiday := range seedHolidays {
+			date := time.Date(year, holiday.month, holiday.day, 0, 0, 0, 0, time.UTC)
+			s.holidays[date] = true
+
+			_, err := s.q.GetHolidayByDate(ctx, pgDate(date))
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			missing = append(missing, sqlc.CreateHolidaysParams{
+				Date: pgDate(date),
+				Name: holiday.name,
+			})
+		}
+	}
+
+	created, err := s.q.CreateHolidays(ctx, missing)
+	s.counts.holidays += int(created)
+	return err
+}
+
+// seedQuotaPlans makes sure this year's plans exist and returns the Default one
+func (s *seeder) seedQuotaPlans(ctx context.Context) (sqlc.QuotaPlan, error) {
+	year := int32(s.today.Year())
+	plans := []struct {
+		name     string
+		vacation float64
+		medical  float64
+	}{
+		{"Default", 10, 20000},
+		{"Standard", 15, 30000},
+		{"Executive", 20, 50000},
+	}
+
+	var defaultPlan sqlc.QuotaPlan
+	for _, p := range plans {
+		plan, err := s.q.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{PlanName: p.name, Year: year})
+		if errors.Is(err, pgx.ErrNoRows) {
+			plan, err = s.q.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+				PlanName:                p.name,
+				Year:                    year,
+				QuotaVacationDay:        pgconv.MustFromFloat(p.vacation),
+				QuotaMedicalExpenseBaht: pgconv.MustFromFloat(p.medical),
+			})
+			s.counts.quotaPlans++
+		}
+		if err != nil {
+			return sqlc.QuotaPlan{}, err
+		}
+		if p.name == "Default" {
+			defaultPlan = plan
+		}
+	}
+	return defaultPlan, nil
+}
+
+// seedUsers creates the users of the profile, each with an annual record for this year
+func (s *seeder) seedUsers(ctx context.Context, plan sqlc.QuotaPlan) ([]sqlc.User, error) {
+	users := make([]sqlc.User, 0, len(s.profile.users))
+	for _, u := range s.profile.users {
+		user, err := s.q.CreateUser(ctx, sqlc.CreateUserParams{
+			Username: u.username,
+			Password: s.hashedPassword,
+			UserType: u.userType,
+			Email:    u.username + "@example.com",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", u.username, err)
+		}
+		s.counts.users++
+
+		if _, err := s.q.CreateAnnualRecord(ctx, sqlc.CreateAnnualRecordParams{
+			UserID:                 user.ID,
+			Year:                   plan.Year,
+			QuotaPlanID:            pgtype.Int4{Int32: plan.ID, Valid: true},
+			RolloverVacationDay:    pgconv.MustFromFloat(0),
+			UsedVacationDay:        pgconv.MustFromFloat(0),
+			UsedSickLeaveDay:       pgconv.MustFromFloat(0),
+			WorkedOnHolidayDay:     pgconv.MustFromFloat(0),
+			WorkedDay:              pgconv.MustFromFloat(0),
+			UsedMedicalExpenseBaht: pgconv.MustFromFloat(0),
+		}); err != nil {
+			return nil, fmt.Errorf("annual record of %s: %w", u.username, err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// seedCategories creates the category tree and its tasks, each task assigned to a regular user,
+// and returns the tasks
+func (s *seeder) seedCategories(ctx context.Context, categories []seedCategory, parentID pgtype.Int4, users []sqlc.User) ([]sqlc.Task, error) {
+	var regular []sqlc.User
+	for _, user := range users {
+		if user.UserType != "admin" {
+			regular = append(regular, user)
+		}
+	}
+
+	var tasks []sqlc.Task
+	for _, c := range categories {
+		budget := pgtype.Numeric{}
+		if c.budgetDay > 0 {
+			budget = pgconv.MustFromFloat(c.budgetDay)
+		}
+		category, err := s.q.CreateTaskCategory(ctx, sqlc.CreateTaskCategoryParams{
+			Name:        c.name,
+			ParentID:    parentID,
+			Description: pgtype.Text{String: c.description, Valid: c.description != ""},
+			BudgetDay:   budget,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+		s.counts.categories++
+
+		for _, title := range c.tasks {
+			status := seedTaskStatuses[s.counts.tasks%len(seedTaskStatuses)]
+			task, err := s.q.CreateTask(ctx, sqlc.CreateTaskParams{
+				TaskCategoryID: pgtype.Int4{Int32: category.ID, Valid: true},
+				Title:          pgtype.Text{String: title, Valid: true},
+				Status:         pgtype.Text{String: status.status, Valid: true},
+				StatusColor:    pgtype.Text{String: status.color, Valid: true},
+				DueDate:        pgDate(s.today.AddDate(0, 0, 7+s.random.Intn(60))),
+				Priority:       pgtype.Int4{Int32: int32(1 + s.random.Intn(4)), Valid: true},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", title, err)
+			}
+			s.counts.tasks++
+
+			if len(regular) > 0 {
+				assignee := regular[s.random.Intn(len(regular))]
+				if err := s.q.AssignTask(ctx, sqlc.AssignTaskParams{TaskID: task.ID, UserID: assignee.ID}); err != nil {
+					return nil, fmt.Errorf("assigning %s: %w", title, err)
+				}
+			}
+			tasks = append(tasks, task)
+		}
+
+		children, err := s.seedCategories(ctx, c.children, pgtype.Int4{Int32: category.ID, Valid: true}, users)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, children...)
+	}
+	return tasks, nil
+}
+
+// seedLeave takes a few working days off for the user, split between vacation and sick leave, and
+// returns them
+func (s *seeder) seedLeave(ctx context.Context, user sqlc.User, from time.Time) (map[time.Time]bool, error) {
+	leave := make(map[time.Time]bool)
+	var rows []sqlc.CreateLeaveLogsParams
+	for attempts := 0; len(leave) < s.profile.leaveDays && attempts < 1000; attempts++ {
+		date := from.AddDate(0, 0, s.random.Intn(s.profile.logDays))
+		if !s.workingDay(date) || leave[date] {
+			continue
+		}
+		leave[date] = true
+
+		leaveType, note := "vacation", "Family trip"
+		if len(leave)%3 == 0 {
+			leaveType, note = "sick", "Flu"
+		}
+		rows = append(rows, sqlc.CreateLeaveLogsParams{
+			UserID: user.ID,
+			Type:   leaveType,
+			Date:   pgDate(date),
+			Note:   pgtype.Text{String: note, Valid: true},
+		})
+	}
+
+	created, err := s.q.CreateLeaveLogs(ctx, rows)
+	s.counts.leaveLogs += int(created)
+	return leave, err
+}
+
+// seedTaskLogs fills every working day the user was not on leave with a full day of work, on one
+// task or split between two
+func (s *seeder) seedTaskLogs(ctx context.Context, user sqlc.User, tasks []sqlc.Task, from time.Time, leave map[time.Time]bool) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	var rows []sqlc.CreateTaskLogsParams
+	for date := from; date.Before(s.today); date = date.AddDate(0, 0, 1) {
+		if !s.workingDay(date) || leave[date] {
+			continue
+		}
+
+		split := []float64{1}
+		if s.random.Intn(3) == 0 {
+			split = []float64{0.5, 0.5}
+		}
+		for _, workedDay := range split {
+			task := tasks[s.random.Intn(len(tasks))]
+			rows = append(rows, sqlc.CreateTaskLogsParams{
+				TaskID:          task.ID,
+				WorkedDay:       pgconv.MustFromFloat(workedDay),
+				CreatedByUserID: user.ID,
+				WorkedDate:      pgDate(date),
+				IsWorkOnHoliday: pgtype.Bool{Bool: false, Valid: true},
+			})
+		}
+	}
+
+	created, err := s.q.CreateTaskLogs(ctx, rows)
+	s.counts.taskLogs += int(created)
+	return err
+}
+
+// seedMedicalExpenses files a few receipts for the user earlier this year
+func (s *seeder) seedMedicalExpenses(ctx context.Context, user sqlc.User) error {
+	receipts := []string{"Clinic visit", "Dental cleaning", "Pharmacy", "Eye exam"}
+	startOfYear := time.Date(s.today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	daysSoFar := int(s.today.Sub(startOfYear).Hours()/24) + 1
+
+	for i := 0; i < s.profile.medicalExpenses; i++ {
+		if _, err := s.q.CreateMedicalExpense(ctx, sqlc.CreateMedicalExpenseParams{
+			UserID:      user.ID,
+			Amount:      pgconv.MustFromFloat(float64(300 + s.random.Intn(40)*50)),
+			ReceiptName: pgtype.Text{String: receipts[i%len(receipts)], Valid: true},
+			ReceiptDate: pgDate(startOfYear.AddDate(0, 0, s.random.Intn(daysSoFar))),
+		}); err != nil {
+			return err
+		}
+		s.counts.medicalExpenses++
+	}
+	return nil
+}
+
+// workingDay reports whether the date is a weekday that is not a seeded holiday
+func (s *seeder) workingDay(date time.Time) bool {
+	weekday := date.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday && !s.holidays[date]
+}
+
+func pgDate(date time.Time) pgtype.Date {
+	return pgtype.Date{Time: date, Valid: true}
+}
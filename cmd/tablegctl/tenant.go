@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// createTenant runs the create-tenant command: it adds a tenant and its first admin, the user
+// that creates everything else in it, in one transaction
+func createTenant(args []string) {
+	flags := flag.NewFlagSet("create-tenant", flag.ExitOnError)
+	slug := flags.String("slug", "", "host name label or X-Tenant header value of the tenant")
+	name := flags.String("name", "", "display name of the tenant, the slug when empty")
+	password := flags.String("password", "changeme", "password of the tenant's admin user")
+	flags.Parse(args)
+
+	if *slug == "" {
+		log.Fatalf("create-tenant needs --slug")
+	}
+	if *name == "" {
+		*name = *slug
+	}
+
+	database, err := db.New()
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer database.Close()
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Error hashing password: %v", err)
+	}
+
+	ctx := context.Background()
+	tx, err := database.Pool.Begin(ctx)
+	if err != nil {
+		log.Fatalf("Error starting transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	q := sqlc.New(tx)
+
+	tenant, err := q.CreateTenant(ctx, sqlc.CreateTenantParams{Slug: *slug, Name: *name})
+	if err != nil {
+		log.Fatalf("Error creating tenant: %v", err)
+	}
+
+	// Rows take the tenant of the connection, set for the rest of the transaction only
+	if _, err := tx.Exec(ctx, "SELECT set_config('ngtableg.tenant_id', $1, true)", strconv.Itoa(int(tenant.ID))); err != nil {
+		log.Fatalf("Error switching to the new tenant: %v", err)
+	}
+	admin, err := q.CreateUser(ctx, sqlc.CreateUserParams{
+		Username: "admin",
+		Password: string(hashedPassword),
+		UserType: "admin",
+		Email:    "admin@example.com",
+	})
+	if err != nil {
+		log.Fatalf("Error creating the tenant's admin user: %v", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Fatalf("Error committing tenant: %v", err)
+	}
+	fmt.Printf("Created tenant %q (ID %d) with admin user %q\n", tenant.Slug, tenant.ID, admin.Username)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// userCommand runs the user command: create adds a user and reset-password sets the password of
+// one, printing a generated password when none is given
+func userCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("user needs create or reset-password")
+	}
+	switch args[0] {
+	case "create":
+		createUser(args[1:])
+	case "reset-password":
+		resetPassword(args[1:])
+	default:
+		log.Fatalf("Unknown user command %q, expected create or reset-password", args[0])
+	}
+}
+
+// createUser runs user create
+func createUser(args []string) {
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	username := flags.String("username", "", "username to sign in with")
+	email := flags.String("email", "", "email address of the user")
+	userType := flags.String("type", "user", "user type, admin or user")
+	password := flags.String("password", "", "password of the user, generated when empty")
+	tenant := flags.String("tenant", "", "slug of the tenant the user belongs to")
+	flags.Parse(args)
+
+	*username = strings.TrimSpace(*username)
+	if *username == "" || *email == "" {
+		log.Fatalf("user create needs --username and --email")
+	}
+
+	settings := loadConfig()
+	database := connect(settings)
+	defer database.Close()
+	ctx := tenantContext(context.Background(), settings, database, *tenant)
+
+	plain, hashed := hashPassword(*password)
+	user, err := database.CreateUser(ctx, sqlc.CreateUserParams{
+		Username: *username,
+		Password: hashed,
+		UserType: *userType,
+		Email:    *email,
+	})
+	if err != nil {
+		log.Fatalf("Error creating user: %v", err)
+	}
+
+	fmt.Printf("Created %s user %q (ID %d)\n", user.UserType, user.Username, user.ID)
+	if *password == "" {
+		fmt.Printf("Password: %s\n", plain)
+	}
+}
+
+// resetPassword runs user reset-password
+func resetPassword(args []string) {
+	flags := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+	username := flags.String("username", "", "username of the user")
+	password := flags.String("password", "", "new password, generated when empty")
+	tenant := flags.String("tenant", "", "slug of the tenant the user belongs to")
+	flags.Parse(args)
+
+	if *username == "" {
+		log.Fatalf("user reset-password needs --username")
+	}
+
+	settings := loadConfig()
+	database := connect(settings)
+	defer database.Close()
+	ctx := tenantContext(context.Background(), settings, database, *tenant)
+
+	user, err := database.GetUserByUsername(ctx, *username)
+	if err != nil {
+		log.Fatalf("Error finding user %q: %v", *username, err)
+	}
+
+	plain, hashed := hashPassword(*password)
+	if _, err := database.UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:       user.ID,
+		Username: user.Username,
+		Password: hashed,
+		UserType: user.UserType,
+		Email:    user.Email,
+	}); err != nil {
+		log.Fatalf("Error updating password: %v", err)
+	}
+
+	fmt.Printf("Password of %q reset\n", user.Username)
+	if *password == "" {
+		fmt.Printf("Password: %s\n", plain)
+	}
+}
+
+// hashPassword returns the password, a random one when it is empty, and its bcrypt hash
+func hashPassword(password string) (plain, hashed string) {
+	if password == "" {
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			log.Fatalf("Error generating password: %v", err)
+		}
+		password = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Error hashing password: %v", err)
+	}
+	return password, string(hash)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kengtableg/pkeng-tableg/tz"
+	"github.com/kengtableg/pkeng-tableg/yearend"
+)
+
+// syncYear runs the sync-year command: it recomputes the used leave, expenses and worked days
+// of every annual record of the year from the logs, as the server does when a log changes
+func syncYear(args []string) {
+	flags := flag.NewFlagSet("sync-year", flag.ExitOnError)
+	year := flags.Int("year", 0, "year of the annual records, the current year in TIME_ZONE when 0")
+	tenant := flags.String("tenant", "", "slug of the tenant, every tenant when empty")
+	flags.Parse(args)
+
+	settings := loadConfig()
+	if *year == 0 {
+		*year = tz.Year(timeZone(settings))
+	}
+	database := connect(settings)
+	defer database.Close()
+
+	failed := false
+	forEachTenant(context.Background(), settings, database, *tenant, func(ctx context.Context, slug string) {
+		records, err := database.SyncAllAnnualRecordsByYear(ctx, int32(*year))
+		if err != nil {
+			log.Printf("Error syncing annual records of %d%s: %v", *year, tenantSuffix(slug), err)
+			failed = true
+			return
+		}
+		fmt.Printf("Synced %d annual records of %d%s\n", len(records), *year, tenantSuffix(slug))
+	})
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// closeYear runs the close-year command: it does what the server does on December 31st,
+// creating next year's annual records with this year's unused vacation days rolled over and
+// next year's Default quota plan. It only adds what is missing, so it can run again.
+func closeYear(args []string) {
+	flags := flag.NewFlagSet("close-year", flag.ExitOnError)
+	year := flags.Int("year", 0, "year to close, next year gets the records, the current year in TIME_ZONE when 0")
+	tenant := flags.String("tenant", "", "slug of the tenant, every tenant when empty")
+	flags.Parse(args)
+
+	settings := loadConfig()
+	if *year == 0 {
+		*year = tz.Year(timeZone(settings))
+	}
+	database := connect(settings)
+	defer database.Close()
+
+	failed := false
+	forEachTenant(context.Background(), settings, database, *tenant, func(ctx context.Context, slug string) {
+		result, err := yearend.Close(ctx, database, *year)
+		if err != nil {
+			log.Printf("Error closing %d%s: %v", *year, tenantSuffix(slug), err)
+			failed = true
+		}
+		fmt.Printf("Created %d annual records of %d%s\n", result.Records, result.NextYear, tenantSuffix(slug))
+		if result.QuotaPlan != nil {
+			fmt.Printf("Created quota plan %q of %d (ID %d)\n", result.QuotaPlan.PlanName, result.NextYear, result.QuotaPlan.ID)
+		}
+	})
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// tenantSuffix names the tenant a line of output is about, nothing without one
+func tenantSuffix(slug string) string {
+	if slug == "" {
+		return ""
+	}
+	return " for tenant " + slug
+}
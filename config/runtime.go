@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RuntimeSettings are the settings admins may change while the server runs, by their environment
+// variable names. They are policy, such as the rules of estimates and which pages may call the
+// API. Secrets and how the server listens and reaches its database are only read at startup.
+var RuntimeSettings = []string{
+	"CORS_ORIGINS",
+	"CLICKUP_SYNC_TIME",
+	"CLICKUP_SYNC_TAGS",
+	"TASK_CATEGORY_MAX_DEPTH",
+	"TASK_ESTIMATE_LOCK_AFTER_LOGS",
+	"ESTIMATE_HOURS_PER_DAY",
+	"ESTIMATE_DAYS_PER_POINT",
+	"PAYROLL_UNPAID_LEAVE_TYPES",
+	"ANONYMIZE_DEPARTED_AFTER",
+	"ATTENDANCE_LOCATION_RETENTION",
+	"ANOMALY_RULES",
+	"ANOMALY_LOOKBACK",
+	"ANOMALY_SICK_MONDAY_MIN",
+	"ANOMALY_SICK_MONDAY_SHARE",
+	"ANOMALY_OVER_LOGGING_RATIO",
+	"ANOMALY_NEAR_LIMIT_MARGIN",
+	"ANOMALY_NEAR_LIMIT_MIN",
+}
+
+// IsRuntimeSetting reports whether the setting may be changed while the server runs
+func IsRuntimeSetting(name string) bool {
+	return slices.Contains(RuntimeSettings, name)
+}
+
+// readRuntime reads the runtime settings, keeping those not in r as they are
+func readRuntime(r *reader, config *Config) {
+	config.CORS.Origins = r.list("CORS_ORIGINS", config.CORS.Origins)
+	config.ClickUp.SyncTime = r.bool("CLICKUP_SYNC_TIME", config.ClickUp.SyncTime)
+	config.ClickUp.SyncTags = r.bool("CLICKUP_SYNC_TAGS", config.ClickUp.SyncTags)
+	config.Tasks.CategoryMaxDepth = r.int("TASK_CATEGORY_MAX_DEPTH", config.Tasks.CategoryMaxDepth)
+	config.Tasks.EstimateLockAfterLogs = r.bool("TASK_ESTIMATE_LOCK_AFTER_LOGS", config.Tasks.EstimateLockAfterLogs)
+	config.Tasks.EstimateHoursPerDay = r.float("ESTIMATE_HOURS_PER_DAY", config.Tasks.EstimateHoursPerDay)
+	config.Tasks.EstimateDaysPerPoint = r.float("ESTIMATE_DAYS_PER_POINT", config.Tasks.EstimateDaysPerPoint)
+	config.Payroll.UnpaidLeaveTypes = r.list("PAYROLL_UNPAID_LEAVE_TYPES", config.Payroll.UnpaidLeaveTypes)
+	config.Privacy.AnonymizeDepartedAfter = r.duration("ANONYMIZE_DEPARTED_AFTER", config.Privacy.AnonymizeDepartedAfter)
+	config.Privacy.AttendanceLocationRetention = r.duration("ATTENDANCE_LOCATION_RETENTION", config.Privacy.AttendanceLocationRetention)
+	config.Anomalies.Rules = r.list("ANOMALY_RULES", config.Anomalies.Rules)
+	config.Anomalies.Lookback = r.duration("ANOMALY_LOOKBACK", config.Anomalies.Lookback)
+	config.Anomalies.SickMondayMin = r.int("ANOMALY_SICK_MONDAY_MIN", config.Anomalies.SickMondayMin)
+	config.Anomalies.SickMondayShare = r.float("ANOMALY_SICK_MONDAY_SHARE", config.Anomalies.SickMondayShare)
+	config.Anomalies.OverLoggingRatio = r.float("ANOMALY_OVER_LOGGING_RATIO", config.Anomalies.OverLoggingRatio)
+	config.Anomalies.NearLimitMargin = r.float("ANOMALY_NEAR_LIMIT_MARGIN", config.Anomalies.NearLimitMargin)
+	config.Anomalies.NearLimitMin = r.int("ANOMALY_NEAR_LIMIT_MIN", config.Anomalies.NearLimitMin)
+}
+
+// WithOverrides returns a copy of c with runtime settings replaced by values, given in the
+// format of the environment. The copy is validated like the settings read at startup, and the
+// error lists every invalid value and every setting that can't be changed at runtime.
+func (c *Config) WithOverrides(values map[string]string) (*Config, error) {
+	var errs []error
+	for name := range values {
+		if !IsRuntimeSetting(name) {
+			errs = append(errs, fmt.Errorf("%s can't be changed while the server runs", name))
+		}
+	}
+
+	r := &reader{values: values}
+	config := *c
+	readRuntime(r, &config)
+	if err := errors.Join(append(append(errs, r.errs...), config.Validate())...); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// RuntimeValues returns the runtime settings of c in the format of the environment
+func (c *Config) RuntimeValues() map[string]string {
+	return map[string]string{
+		"CORS_ORIGINS":                  strings.Join(c.CORS.Origins, ","),
+		"CLICKUP_SYNC_TIME":             strconv.FormatBool(c.ClickUp.SyncTime),
+		"CLICKUP_SYNC_TAGS":             strconv.FormatBool(c.ClickUp.SyncTags),
+		"TASK_CATEGORY_MAX_DEPTH":       strconv.Itoa(c.Tasks.CategoryMaxDepth),
+		"TASK_ESTIMATE_LOCK_AFTER_LOGS": strconv.FormatBool(c.Tasks.EstimateLockAfterLogs),
+		"ESTIMATE_HOURS_PER_DAY":        strconv.FormatFloat(c.Tasks.EstimateHoursPerDay, 'f', -1, 64),
+		"ESTIMATE_DAYS_PER_POINT":       strconv.FormatFloat(c.Tasks.EstimateDaysPerPoint, 'f', -1, 64),
+		"PAYROLL_UNPAID_LEAVE_TYPES":    strings.Join(c.Payroll.UnpaidLeaveTypes, ","),
+		"ANONYMIZE_DEPARTED_AFTER":      c.Privacy.AnonymizeDepartedAfter.String(),
+		"ATTENDANCE_LOCATION_RETENTION": c.Privacy.AttendanceLocationRetention.String(),
+		"ANOMALY_RULES":                 strings.Join(c.Anomalies.Rules, ","),
+		"ANOMALY_LOOKBACK":              c.Anomalies.Lookback.String(),
+		"ANOMALY_SICK_MONDAY_MIN":       strconv.Itoa(c.Anomalies.SickMondayMin),
+		"ANOMALY_SICK_MONDAY_SHARE":     strconv.FormatFloat(c.Anomalies.SickMondayShare, 'f', -1, 64),
+		"ANOMALY_OVER_LOGGING_RATIO":    strconv.FormatFloat(c.Anomalies.OverLoggingRatio, 'f', -1, 64),
+		"ANOMALY_NEAR_LIMIT_MARGIN":     strconv.FormatFloat(c.Anomalies.NearLimitMargin, 'f', -1, 64),
+		"ANOMALY_NEAR_LIMIT_MIN":        strconv.Itoa(c.Anomalies.NearLimitMin),
+	}
+}
@@ -0,0 +1,172 @@
+// Package backup dumps the database with pg_dump and restores the dumps with pg_restore.
+//
+// Dumps use the pg_dump custom format and are named ngtableg-<UTC time>.dump, so sorting the names
+// sorts them by age. They are kept in a Location, a local directory or an S3 bucket, which keeps
+// only the newest ones once a retention is set.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+const (
+	namePrefix = "ngtableg-"
+	nameSuffix = ".dump"
+	timeLayout = "20060102T150405Z"
+)
+
+// ErrNoBackups is returned when a location holds no backups to restore
+var ErrNoBackups = errors.New("no backups found")
+
+// Location stores backup files by name
+type Location interface {
+	// Put stores size bytes read from body under name
+	Put(ctx context.Context, name string, body io.ReadSeeker, size int64) error
+	// Get opens the backup stored under name
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the backups, oldest first
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, name string) error
+	String() string
+}
+
+// Open returns the location at target, an s3://bucket/prefix URL signed for the account of s3 or
+// a local directory
+func Open(target string, s3 storage.S3Config) (Location, error) {
+	if target == "" {
+		return nil, errors.New("no backup location, set BACKUP_LOCATION or pass one")
+	}
+	if strings.HasPrefix(target, "s3://") {
+		return newS3Location(target, s3)
+	}
+	return &localLocation{dir: target}, nil
+}
+
+// Run dumps the database at databaseURL into location and then prunes the location down to the
+// newest retention backups. It returns the name of the new backup.
+func Run(ctx context.Context, databaseURL string, location Location, retention int) (string, error) {
+	// Dump to a temporary file first, so a failed pg_dump never leaves a partial backup behind
+	// and the upload knows its size
+	file, err := os.CreateTemp("", "ngtableg-backup-*"+nameSuffix)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// With row security on, a role the tenant policies apply to dumps what they let it see
+	// instead of failing, which is every tenant once its connection is bound to all of them
+	cmd := exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-owner", "--no-privileges", "--enable-row-security", "--dbname", databaseURL)
+	cmd.Env = append(os.Environ(), "PGOPTIONS="+strings.TrimSpace(os.Getenv("PGOPTIONS")+" -c ngtableg.all_tenants=on"))
+	cmd.Stdout = file
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pg_dump: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	name := namePrefix + time.Now().UTC().Format(timeLayout) + nameSuffix
+	if err := location.Put(ctx, name, file, size); err != nil {
+		return "", fmt.Errorf("storing %s in %s: %w", name, location, err)
+	}
+
+	if _, err := Prune(ctx, location, retention); err != nil {
+		return name, fmt.Errorf("pruning old backups: %w", err)
+	}
+	return name, nil
+}
+
+// Prune deletes all but the newest retention backups and returns the deleted names. A retention
+// of 0 keeps everything.
+func Prune(ctx context.Context, location Location, retention int) ([]string, error) {
+	if retention <= 0 {
+		return nil, nil
+	}
+	names, err := location.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) <= retention {
+		return nil, nil
+	}
+
+	var deleted []string
+	for _, name := range names[:len(names)-retention] {
+		if err := location.Delete(ctx, name); err != nil {
+			return deleted, fmt.Errorf("deleting %s: %w", name, err)
+		}
+		deleted = append(deleted, name)
+	}
+	return deleted, nil
+}
+
+// Latest returns the name of the newest backup in location
+func Latest(ctx context.Context, location Location) (string, error) {
+	names, err := location.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", ErrNoBackups
+	}
+	return names[len(names)-1], nil
+}
+
+// Restore replaces the contents of the database at databaseURL with the backup stored under name.
+// The restore runs in a single transaction, so a failure leaves the database as it was.
+func Restore(ctx context.Context, databaseURL string, location Location, name string) error {
+	body, err := location.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("reading %s from %s: %w", name, location, err)
+	}
+	defer body.Close()
+
+	cmd := exec.CommandContext(ctx, "pg_restore", "--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction", "--dbname", databaseURL)
+	cmd.Stdin = body
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pg_restore: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// isBackupName reports whether name is one of ours, so pruning never touches other files
+func isBackupName(name string) bool {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return false
+	}
+	_, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return err == nil
+}
+
+// sortedBackupNames keeps the backup names among names, oldest first
+func sortedBackupNames(names []string) []string {
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if isBackupName(name) {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// localLocation keeps backups as files in a directory. The directory and files are only readable
+// by the owner, the dumps hold salaries and medical expenses.
+type localLocation struct {
+	dir string
+}
+
+func (l *localLocation) Put(ctx context.Context, name string, body io.ReadSeeker, size int64) error {
+	if err := os.MkdirAll(l.dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write under a temporary name and rename, so List never sees a half written backup
+	partial := filepath.Join(l.dir, name+".partial")
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written != size {
+		err = fmt.Errorf("wrote %d of %d bytes", written, size)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return err
+	}
+	return os.Rename(partial, filepath.Join(l.dir, name))
+}
+
+func (l *localLocation) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(l.dir, filepath.Base(name)))
+}
+
+func (l *localLocation) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return sortedBackupNames(names), nil
+}
+
+func (l *localLocation) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(l.dir, filepath.Base(name)))
+}
+
+func (l *localLocation) String() string {
+	return l.dir
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+// s3Location keeps backups as objects under a prefix of an S3 bucket
+type s3Location struct {
+	bucket *storage.S3
+}
+
+// newS3Location parses an s3://bucket/prefix URL, signed for the account of config
+func newS3Location(target string, config storage.S3Config) (*s3Location, error) {
+	bucket, err := storage.NewS3(target, config)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Location{bucket: bucket}, nil
+}
+
+func (s *s3Location) Put(ctx context.Context, name string, body io.ReadSeeker, size int64) error {
+	return s.bucket.Put(ctx, name, body, size, "application/octet-stream")
+}
+
+func (s *s3Location) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.bucket.Get(ctx, name)
+}
+
+func (s *s3Location) List(ctx context.Context) ([]string, error) {
+	keys, err := s.bucket.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// Objects in "subdirectories" of the prefix are not ours
+		if !strings.Contains(key, "/") {
+			names = append(names, key)
+		}
+	}
+	return sortedBackupNames(names), nil
+}
+
+func (s *s3Location) Delete(ctx context.Context, name string) error {
+	return s.bucket.Delete(ctx, name)
+}
+
+func (s *s3Location) String() string {
+	return s.bucket.String()
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+)
+
+// Cache stores encoded query results. Keys start with their group followed by a colon. Each
+// group has a version that Invalidate bumps, and callers put the version in their keys, so a
+// result loaded before an invalidation is never served after it.
+type Cache interface {
+	Version(ctx context.Context, group string) (int64, error)
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Invalidate(ctx context.Context, group string) error
+}
+
+// NewCache returns the cache the settings describe: the Redis at RedisURL, falling back to an
+// in-process cache, or nil when caching is off (TTL 0)
+func NewCache(settings config.Cache) Cache {
+	if settings.TTL == 0 {
+		return nil
+	}
+
+	if settings.RedisURL != "" {
+		cache, err := NewRedisCache(settings.RedisURL)
+		if err == nil {
+			return cache
+		}
+		slog.Warn("Invalid REDIS_URL, using the in-process cache", "error", err)
+	}
+	return NewMemoryCache()
+}
+
+// MemoryCache is an in-process Cache, shared by the requests of one server
+type MemoryCache struct {
+	mu       sync.Mutex
+	versions map[string]int64
+	groups   map[string]map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+var _ Cache = (*MemoryCache)(nil)
+
+// NewMemoryCache creates an empty in-process cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		versions: make(map[string]int64),
+		groups:   make(map[string]map[string]memoryEntry),
+	}
+}
+
+func (c *MemoryCache) Version(ctx context.Context, group string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.versions[group], nil
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group := c.groups[cacheGroup(key)]
+	entry, ok := group[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(group, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := cacheGroup(key)
+	group, ok := c.groups[name]
+	if !ok {
+		group = make(map[string]memoryEntry)
+		c.groups[name] = group
+	}
+	group[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *MemoryCache) Invalidate(ctx context.Context, group string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions[group]++
+	delete(c.groups, group)
+	return nil
+}
+
+// cacheGroup returns the group a key belongs to
+func cacheGroup(key string) string {
+	group, _, _ := strings.Cut(key, ":")
+	return group
+}
@@ -0,0 +1,441 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Cache groups, every write to a group's table invalidates all of its cached reads
+const (
+	cacheGroupQuotaPlans     = "quota_plans"
+	cacheGroupHolidays       = "holidays"
+	cacheGroupTaskCategories = "task_categories"
+	cacheGroupFeatureFlags   = "feature_flags"
+)
+
+// CachedStore serves reads of the reference data nearly every request needs, quota plans,
+// holidays, task categories and feature flags, from a cache and drops them when they are written. Other
+// queries go straight to the wrapped Store.
+//
+// Writes made outside this Store, for example by tablegctl, show up once the TTL runs out.
+type CachedStore struct {
+	Store
+	cache Cache
+	ttl   time.Duration
+}
+
+var _ Store = (*CachedStore)(nil)
+
+// NewCachedStore wraps store with the cache, returning store itself when cache is nil
+func NewCachedStore(store Store, cache Cache, ttl time.Duration) Store {
+	if cache == nil {
+		return store
+	}
+	return &CachedStore{Store: store, cache: cache, ttl: ttl}
+}
+
+// WithTx runs fn in a transaction of the wrapped Store. Reads inside it skip the cache so they
+// see the transaction's own writes, and the groups it wrote are invalidated again after it
+// ends, in case a concurrent read cached the old rows in between.
+func (s *CachedStore) WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	return s.WithTxContext(ctx, func(ctx context.Context, q sqlc.Querier) error {
+		return fn(q)
+	})
+}
+
+// WithTxContext is WithTx for callers that nest transactions
+func (s *CachedStore) WithTxContext(ctx context.Context, fn func(ctx context.Context, q sqlc.Querier) error) error {
+	written := make(map[string]bool)
+	err := s.Store.WithTxContext(ctx, func(ctx context.Context, q sqlc.Querier) error {
+		return fn(ctx, &invalidatingQuerier{Querier: q, store: s, written: written})
+	})
+	for group := range written {
+		s.invalidate(ctx, group)
+	}
+	return err
+}
+
+// cached returns the cached result of a read, loading and storing it on a miss. Errors,
+// including no rows, are never cached, and a broken cache falls back to the database.
+func cached[T any](ctx context.Context, s *CachedStore, group, name string, arg interface{}, load func() (T, error)) (T, error) {
+	version, err := s.cache.Version(ctx, group)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache unavailable", "error", err)
+		return load()
+	}
+	encodedArg, err := json.Marshal(arg)
+	if err != nil {
+		return load()
+	}
+	// Tenants share the group, so a write in one drops the others' reads too
+	tenantID, _ := TenantFromContext(ctx)
+	key := fmt.Sprintf("%s:%d:%d:%s:%s", group, version, tenantID, name, encodedArg)
+
+	if data, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil {
+		if err := s.cache.Set(ctx, key, data, s.ttl); err != nil {
+			slog.WarnContext(ctx, "Error caching query result", "query", name, "error", err)
+		}
+	}
+	return value, nil
+}
+
+// invalidate drops a group's cached reads
+func (s *CachedStore) invalidate(ctx context.Context, group string) {
+	if err := s.cache.Invalidate(ctx, group); err != nil {
+		slog.WarnContext(ctx, "Error invalidating cache group", "group", group, "error", err)
+	}
+}
+
+// invalidated passes a write's result through, invalidating the group when the write succeeded
+func invalidated[T any](ctx context.Context, s *CachedStore, group string, value T, err error) (T, error) {
+	if err == nil {
+		s.invalidate(ctx, group)
+	}
+	return value, err
+}
+
+// Quota plans
+
+func (s *CachedStore) GetQuotaPlan(ctx context.Context, id int32) (sqlc.QuotaPlan, error) {
+	return cached(ctx, s, cacheGroupQuotaPlans, "GetQuotaPlan", id, func() (sqlc.QuotaPlan, error) {
+		return s.Store.GetQuotaPlan(ctx, id)
+	})
+}
+
+func (s *CachedStore) GetQuotaPlanByNameAndYear(ctx context.Context, arg sqlc.GetQuotaPlanByNameAndYearParams) (sqlc.QuotaPlan, error) {
+	return cached(ctx, s, cacheGroupQuotaPlans, "GetQuotaPlanByNameAndYear", arg, func() (sqlc.QuotaPlan, error) {
+		return s.Store.GetQuotaPlanByNameAndYear(ctx, arg)
+	})
+}
+
+func (s *CachedStore) ListQuotaPlans(ctx context.Context) ([]sqlc.QuotaPlan, error) {
+	return cached(ctx, s, cacheGroupQuotaPlans, "ListQuotaPlans", nil, func() ([]sqlc.QuotaPlan, error) {
+		return s.Store.ListQuotaPlans(ctx)
+	})
+}
+
+func (s *CachedStore) ListQuotaPlansByYear(ctx context.Context, year int32) ([]sqlc.QuotaPlan, error) {
+	return cached(ctx, s, cacheGroupQuotaPlans, "ListQuotaPlansByYear", year, func() ([]sqlc.QuotaPlan, error) {
+		return s.Store.ListQuotaPlansByYear(ctx, year)
+	})
+}
+
+func (s *CachedStore) GetQuotaPlansVersion(ctx context.Context) (sqlc.GetQuotaPlansVersionRow, error) {
+	return cached(ctx, s, cacheGroupQuotaPlans, "GetQuotaPlansVersion", nil, func() (sqlc.GetQuotaPlansVersionRow, error) {
+		return s.Store.GetQuotaPlansVersion(ctx)
+	})
+}
+
+func (s *CachedStore) CreateQuotaPlan(ctx context.Context, arg sqlc.CreateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	plan, err := s.Store.CreateQuotaPlan(ctx, arg)
+	return invalidated(ctx, s, cacheGroupQuotaPlans, plan, err)
+}
+
+func (s *CachedStore) UpdateQuotaPlan(ctx context.Context, arg sqlc.UpdateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	plan, err := s.Store.UpdateQuotaPlan(ctx, arg)
+	return invalidated(ctx, s, cacheGroupQuotaPlans, plan, err)
+}
+
+func (s *CachedStore) DeleteQuotaPlan(ctx context.Context, id int32) error {
+	err := s.Store.DeleteQuotaPlan(ctx, id)
+	if err == nil {
+		s.invalidate(ctx, cacheGroupQuotaPlans)
+	}
+	return err
+}
+
+// Holidays
+
+func (s *CachedStore) GetHoliday(ctx context.Context, id int32) (sqlc.Holiday, error) {
+	return cached(ctx, s, cacheGroupHolidays, "GetHoliday", id, func() (sqlc.Holiday, error) {
+		return s.Store.GetHoliday(ctx, id)
+	})
+}
+
+func (s *CachedStore) GetHolidayByDate(ctx context.Context, date pgtype.Date) (sqlc.Holiday, error) {
+	return cached(ctx, s, cacheGroupHolidays, "GetHolidayByDate", date, func() (sqlc.Holiday, error) {
+		return s.Store.GetHolidayByDate(ctx, date)
+	})
+}
+
+func (s *CachedStore) ListHolidays(ctx context.Context, arg sqlc.ListHolidaysParams) ([]sqlc.Holiday, error) {
+	return cached(ctx, s, cacheGroupHolidays, "ListHolidays", arg, func() ([]sqlc.Holiday, error) {
+		return s.Store.ListHolidays(ctx, arg)
+	})
+}
+
+func (s *CachedStore) CountHolidays(ctx context.Context) (int64, error) {
+	return cached(ctx, s, cacheGroupHolidays, "CountHolidays", nil, func() (int64, error) {
+		return s.Store.CountHolidays(ctx)
+	})
+}
+
+func (s *CachedStore) GetHolidaysVersion(ctx context.Context) (sqlc.GetHolidaysVersionRow, error) {
+	return cached(ctx, s, cacheGroupHolidays, "GetHolidaysVersion", nil, func() (sqlc.GetHolidaysVersionRow, error) {
+		return s.Store.GetHolidaysVersion(ctx)
+	})
+}
+
+func (s *CachedStore) ListHolidaysByYear(ctx context.Context, date pgtype.Date) ([]sqlc.Holiday, error) {
+	return cached(ctx, s, cacheGroupHolidays, "ListHolidaysByYear", date, func() ([]sqlc.Holiday, error) {
+		return s.Store.ListHolidaysByYear(ctx, date)
+	})
+}
+
+func (s *CachedStore) CreateHoliday(ctx context.Context, arg sqlc.CreateHolidayParams) (sqlc.Holiday, error) {
+	holiday, err := s.Store.CreateHoliday(ctx, arg)
+	return invalidated(ctx, s, cacheGroupHolidays, holiday, err)
+}
+
+func (s *CachedStore) CreateHolidays(ctx context.Context, arg []sqlc.CreateHolidaysParams) (int64, error) {
+	count, err := s.Store.CreateHolidays(ctx, arg)
+	return invalidated(ctx, s, cacheGroupHolidays, count, err)
+}
+
+func (s *CachedStore) UpdateHoliday(ctx context.Context, arg sqlc.UpdateHolidayParams) (sqlc.Holiday, error) {
+	holiday, err := s.Store.UpdateHoliday(ctx, arg)
+	return invalidated(ctx, s, cacheGroupHolidays, holiday, err)
+}
+
+func (s *CachedStore) DeleteHoliday(ctx context.Context, id int32) error {
+	err := s.Store.DeleteHoliday(ctx, id)
+	if err == nil {
+		s.invalidate(ctx, cacheGroupHolidays)
+	}
+	return err
+}
+
+// Task categories
+
+func (s *CachedStore) GetTaskCategory(ctx context.Context, id int32) (sqlc.TaskCategory, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "GetTaskCategory", id, func() (sqlc.TaskCategory, error) {
+		return s.Store.GetTaskCategory(ctx, id)
+	})
+}
+
+func (s *CachedStore) ListTaskCategories(ctx context.Context, arg sqlc.ListTaskCategoriesParams) ([]sqlc.TaskCategory, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "ListTaskCategories", arg, func() ([]sqlc.TaskCategory, error) {
+		return s.Store.ListTaskCategories(ctx, arg)
+	})
+}
+
+func (s *CachedStore) CountTaskCategories(ctx context.Context, arg sqlc.CountTaskCategoriesParams) (int64, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "CountTaskCategories", arg, func() (int64, error) {
+		return s.Store.CountTaskCategories(ctx, arg)
+	})
+}
+
+func (s *CachedStore) GetTaskCategoriesVersion(ctx context.Context) (sqlc.GetTaskCategoriesVersionRow, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "GetTaskCategoriesVersion", nil, func() (sqlc.GetTaskCategoriesVersionRow, error) {
+		return s.Store.GetTaskCategoriesVersion(ctx)
+	})
+}
+
+func (s *CachedStore) ListRootTaskCategories(ctx context.Context) ([]sqlc.TaskCategory, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "ListRootTaskCategories", nil, func() ([]sqlc.TaskCategory, error) {
+		return s.Store.ListRootTaskCategories(ctx)
+	})
+}
+
+func (s *CachedStore) ListTaskCategoriesByParent(ctx context.Context, parentID pgtype.Int4) ([]sqlc.TaskCategory, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "ListTaskCategoriesByParent", parentID, func() ([]sqlc.TaskCategory, error) {
+		return s.Store.ListTaskCategoriesByParent(ctx, parentID)
+	})
+}
+
+func (s *CachedStore) ListTaskCategoryTree(ctx context.Context, arg sqlc.ListTaskCategoryTreeParams) ([]sqlc.ListTaskCategoryTreeRow, error) {
+	return cached(ctx, s, cacheGroupTaskCategories, "ListTaskCategoryTree", arg, func() ([]sqlc.ListTaskCategoryTreeRow, error) {
+		return s.Store.ListTaskCategoryTree(ctx, arg)
+	})
+}
+
+func (s *CachedStore) CreateTaskCategory(ctx context.Context, arg sqlc.CreateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := s.Store.CreateTaskCategory(ctx, arg)
+	return invalidated(ctx, s, cacheGroupTaskCategories, category, err)
+}
+
+func (s *CachedStore) UpdateTaskCategory(ctx context.Context, arg sqlc.UpdateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := s.Store.UpdateTaskCategory(ctx, arg)
+	return invalidated(ctx, s, cacheGroupTaskCategories, category, err)
+}
+
+func (s *CachedStore) MoveTaskCategory(ctx context.Context, arg sqlc.MoveTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := s.Store.MoveTaskCategory(ctx, arg)
+	return invalidated(ctx, s, cacheGroupTaskCategories, category, err)
+}
+
+func (s *CachedStore) ArchiveTaskCategorySubtree(ctx context.Context, categoryID int32) (int64, error) {
+	count, err := s.Store.ArchiveTaskCategorySubtree(ctx, categoryID)
+	return invalidated(ctx, s, cacheGroupTaskCategories, count, err)
+}
+
+func (s *CachedStore) ReassignTaskCategoryChildren(ctx context.Context, arg sqlc.ReassignTaskCategoryChildrenParams) (int64, error) {
+	count, err := s.Store.ReassignTaskCategoryChildren(ctx, arg)
+	return invalidated(ctx, s, cacheGroupTaskCategories, count, err)
+}
+
+func (s *CachedStore) ReorderTaskCategories(ctx context.Context, categoryIds []int32) (int64, error) {
+	count, err := s.Store.ReorderTaskCategories(ctx, categoryIds)
+	return invalidated(ctx, s, cacheGroupTaskCategories, count, err)
+}
+
+func (s *CachedStore) DeleteTaskCategory(ctx context.Context, id int32) error {
+	err := s.Store.DeleteTaskCategory(ctx, id)
+	if err == nil {
+		s.invalidate(ctx, cacheGroupTaskCategories)
+	}
+	return err
+}
+
+// Feature flags
+
+func (s *CachedStore) ListFeatureFlags(ctx context.Context) ([]sqlc.FeatureFlag, error) {
+	return cached(ctx, s, cacheGroupFeatureFlags, "ListFeatureFlags", nil, func() ([]sqlc.FeatureFlag, error) {
+		return s.Store.ListFeatureFlags(ctx)
+	})
+}
+
+func (s *CachedStore) GetFeatureFlag(ctx context.Context, key string) (sqlc.FeatureFlag, error) {
+	return cached(ctx, s, cacheGroupFeatureFlags, "GetFeatureFlag", key, func() (sqlc.FeatureFlag, error) {
+		return s.Store.GetFeatureFlag(ctx, key)
+	})
+}
+
+func (s *CachedStore) SetFeatureFlag(ctx context.Context, arg sqlc.SetFeatureFlagParams) (sqlc.FeatureFlag, error) {
+	flag, err := s.Store.SetFeatureFlag(ctx, arg)
+	return invalidated(ctx, s, cacheGroupFeatureFlags, flag, err)
+}
+
+func (s *CachedStore) DeleteFeatureFlag(ctx context.Context, key string) (int64, error) {
+	count, err := s.Store.DeleteFeatureFlag(ctx, key)
+	return invalidated(ctx, s, cacheGroupFeatureFlags, count, err)
+}
+
+// invalidatingQuerier is the querier handed to transactions: reads go to the database and
+// writes to cached tables invalidate their group right away and again after the transaction
+type invalidatingQuerier struct {
+	sqlc.Querier
+	store   *CachedStore
+	written map[string]bool
+}
+
+func (q *invalidatingQuerier) wrote(ctx context.Context, group string, err error) {
+	if err == nil {
+		q.written[group] = true
+		q.store.invalidate(ctx, group)
+	}
+}
+
+func (q *invalidatingQuerier) CreateQuotaPlan(ctx context.Context, arg sqlc.CreateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	plan, err := q.Querier.CreateQuotaPlan(ctx, arg)
+	q.wrote(ctx, cacheGroupQuotaPlans, err)
+	return plan, err
+}
+
+func (q *invalidatingQuerier) UpdateQuotaPlan(ctx context.Context, arg sqlc.UpdateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	plan, err := q.Querier.UpdateQuotaPlan(ctx, arg)
+	q.wrote(ctx, cacheGroupQuotaPlans, err)
+	return plan, err
+}
+
+func (q *invalidatingQuerier) DeleteQuotaPlan(ctx context.Context, id int32) error {
+	err := q.Querier.DeleteQuotaPlan(ctx, id)
+	q.wrote(ctx, cacheGroupQuotaPlans, err)
+	return err
+}
+
+func (q *invalidatingQuerier) CreateHoliday(ctx context.Context, arg sqlc.CreateHolidayParams) (sqlc.Holiday, error) {
+	holiday, err := q.Querier.CreateHoliday(ctx, arg)
+	q.wrote(ctx, cacheGroupHolidays, err)
+	return holiday, err
+}
+
+func (q *invalidatingQuerier) CreateHolidays(ctx context.Context, arg []sqlc.CreateHolidaysParams) (int64, error) {
+	count, err := q.Querier.CreateHolidays(ctx, arg)
+	q.wrote(ctx, cacheGroupHolidays, err)
+	return count, err
+}
+
+func (q *invalidatingQuerier) UpdateHoliday(ctx context.Context, arg sqlc.UpdateHolidayParams) (sqlc.Holiday, error) {
+	holiday, err := q.Querier.UpdateHoliday(ctx, arg)
+	q.wrote(ctx, cacheGroupHolidays, err)
+	return holiday, err
+}
+
+func (q *invalidatingQuerier) DeleteHoliday(ctx context.Context, id int32) error {
+	err := q.Querier.DeleteHoliday(ctx, id)
+	q.wrote(ctx, cacheGroupHolidays, err)
+	return err
+}
+
+func (q *invalidatingQuerier) CreateTaskCategory(ctx context.Context, arg sqlc.CreateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := q.Querier.CreateTaskCategory(ctx, arg)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return category, err
+}
+
+func (q *invalidatingQuerier) UpdateTaskCategory(ctx context.Context, arg sqlc.UpdateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := q.Querier.UpdateTaskCategory(ctx, arg)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return category, err
+}
+
+func (q *invalidatingQuerier) MoveTaskCategory(ctx context.Context, arg sqlc.MoveTaskCategoryParams) (sqlc.TaskCategory, error) {
+	category, err := q.Querier.MoveTaskCategory(ctx, arg)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return category, err
+}
+
+func (q *invalidatingQuerier) ArchiveTaskCategorySubtree(ctx context.Context, categoryID int32) (int64, error) {
+	count, err := q.Querier.ArchiveTaskCategorySubtree(ctx, categoryID)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return count, err
+}
+
+func (q *invalidatingQuerier) ReassignTaskCategoryChildren(ctx context.Context, arg sqlc.ReassignTaskCategoryChildrenParams) (int64, error) {
+	count, err := q.Querier.ReassignTaskCategoryChildren(ctx, arg)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return count, err
+}
+
+func (q *invalidatingQuerier) ReorderTaskCategories(ctx context.Context, categoryIds []int32) (int64, error) {
+	count, err := q.Querier.ReorderTaskCategories(ctx, categoryIds)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return count, err
+}
+
+func (q *invalidatingQuerier) DeleteTaskCategory(ctx context.Context, id int32) error {
+	err := q.Querier.DeleteTaskCategory(ctx, id)
+	q.wrote(ctx, cacheGroupTaskCategories, err)
+	return err
+}
+
+func (q *invalidatingQuerier) SetFeatureFlag(ctx context.Context, arg sqlc.SetFeatureFlagParams) (sqlc.FeatureFlag, error) {
+	flag, err := q.Querier.SetFeatureFlag(ctx, arg)
+	q.wrote(ctx, cacheGroupFeatureFlags, err)
+	return flag, err
+}
+
+func (q *invalidatingQuerier) DeleteFeatureFlag(ctx context.Context, key string) (int64, error) {
+	count, err := q.Querier.DeleteFeatureFlag(ctx, key)
+	q.wrote(ctx, cacheGroupFeatureFlags, err)
+	return count, err
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChangeChannel is the channel the change triggers notify on
+const ChangeChannel = "ngtableg_changes"
+
+// instanceSetting is the session setting the change triggers read the origin of a change from
+const instanceSetting = "ngtableg.instance"
+
+// Instance names this process in the changes it makes, so it can tell them from changes made by
+// other server instances and tools
+var Instance = instanceName()
+
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Change is a row change announced by the change triggers once its transaction committed
+type Change struct {
+	// Table is leave_logs, task_logs, medical_expenses, annual_records or tasks
+	Table string `json:"table"`
+	// Op is INSERT, UPDATE or DELETE
+	Op string `json:"op"`
+	ID int32  `json:"id"`
+	// UserID is the user the row belongs to, the creator of a task
+	UserID int32 `json:"user_id"`
+	// Year is the year of the annual record the row counts towards, 0 when it has no date. For
+	// a task it is the year of its due date.
+	Year int32 `json:"year"`
+	// Origin is the Instance that made the change, empty for changes made outside the app
+	Origin string `json:"origin"`
+	// TenantID is the tenant the row belongs to
+	TenantID int32 `json:"tenant_id"`
+}
+
+// ChangeHandler reacts to a single change
+type ChangeHandler func(ctx context.Context, change Change)
+
+// ChangeFeed delivers the changes announced on ChangeChannel to in-process subscribers. It holds
+// one connection out of the pool for LISTEN and reconnects when it drops.
+//
+// Notifications sent while the feed is disconnected are lost, so after every (re)connect the
+// gap handlers run to catch up on whatever was missed.
+type ChangeFeed struct {
+	pool *pgxpool.Pool
+
+	mu          sync.RWMutex
+	handlers    []ChangeHandler
+	gapHandlers []func(ctx context.Context)
+}
+
+// NewChangeFeed creates a feed listening through pool, it starts with Run
+func NewChangeFeed(pool *pgxpool.Pool) *ChangeFeed {
+	return &ChangeFeed{pool: pool}
+}
+
+// Subscribe registers a handler that is called for every change
+func (f *ChangeFeed) Subscribe(handler ChangeHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, handler)
+}
+
+// OnGap registers a handler that is called each time the feed (re)connects, when changes may
+// have been missed
+func (f *ChangeFeed) OnGap(handler func(ctx context.Context)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gapHandlers = append(f.gapHandlers, handler)
+}
+
+// Run listens until ctx is cancelled, reconnecting with a growing delay of up to a minute
+func (f *ChangeFeed) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		connected, err := f.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = time.Second
+		}
+		slog.WarnContext(ctx, "Change feed disconnected, reconnecting", "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > time.Minute {
+			delay = time.Minute
+		}
+	}
+}
+
+// listen holds one LISTEN connection until it fails, reporting whether it got as far as
+// listening
+func (f *ChangeFeed) listen(ctx context.Context) (bool, error) {
+	pooled, err := f.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	// A listening connection must not go back to the pool, so take it out for good
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ChangeChannel); err != nil {
+		return false, err
+	}
+	slog.InfoContext(ctx, "Change feed listening", "channel", ChangeChannel)
+
+	f.mu.RLock()
+	gapHandlers := append([]func(ctx context.Context){}, f.gapHandlers...)
+	f.mu.RUnlock()
+	for _, handler := range gapHandlers {
+		handler(ctx)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var change Change
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			slog.WarnContext(ctx, "Ignoring malformed change", "payload", notification.Payload, "error", err)
+			continue
+		}
+		f.dispatch(ctx, change)
+	}
+}
+
+// dispatch hands the change to every subscriber
+func (f *ChangeFeed) dispatch(ctx context.Context, change Change) {
+	f.mu.RLock()
+	handlers := make([]ChangeHandler, len(f.handlers))
+	copy(handlers, f.handlers)
+	f.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, change)
+	}
+}
@@ -0,0 +1,137 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// importRows is how many rows each benchmark operation writes, about a year of a team's logs
+const importRows = 1000
+
+// errRollBack ends the transaction of a benchmark operation so none of its rows are kept
+var errRollBack = errors.New("roll back benchmark rows")
+
+// benchmarkImport runs insert, which writes rows one INSERT at a time, against copy, which writes
+// them with one COPY. Each operation runs in a transaction that is rolled back, so every one
+// starts from the same tables.
+func benchmarkImport(b *testing.B, database *db.DB, insert, copy func(ctx context.Context, q sqlc.Querier) error) {
+	for _, method := range []struct {
+		name  string
+		write func(ctx context.Context, q sqlc.Querier) error
+	}{{"insert", insert}, {"copy", copy}} {
+		b.Run(method.name, func(b *testing.B) {
+			for b.Loop() {
+				err := database.WithTxContext(context.Background(), func(ctx context.Context, q sqlc.Querier) error {
+					if err := method.write(ctx, q); err != nil {
+						return err
+					}
+					return errRollBack
+				})
+				if !errors.Is(err, errRollBack) {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*importRows), "ns/row")
+		})
+	}
+}
+
+// importDates returns importRows days from 2025, one row each
+func importDates() []pgtype.Date {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	dates := make([]pgtype.Date, importRows)
+	for i := range dates {
+		dates[i] = pgtype.Date{Time: start.AddDate(0, 0, i), Valid: true}
+	}
+	return dates
+}
+
+func createImportUser(b *testing.B, database *db.DB) sqlc.User {
+	b.Helper()
+	user, err := database.CreateUser(context.Background(), sqlc.CreateUserParams{
+		Username: "bench-import",
+		Password: "-",
+		UserType: "user",
+		Email:    "bench-import@example.com",
+	})
+	if err != nil {
+		b.Fatalf("creating the user: %v", err)
+	}
+	return user
+}
+
+func BenchmarkCreateTaskLogs(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	user := createImportUser(b, database)
+	task, err := database.CreateTask(context.Background(), sqlc.CreateTaskParams{Title: pgtype.Text{String: "bench-import", Valid: true}})
+	if err != nil {
+		b.Fatalf("creating the task: %v", err)
+	}
+
+	rows := make([]sqlc.CreateTaskLogsParams, importRows)
+	for i, date := range importDates() {
+		rows[i] = sqlc.CreateTaskLogsParams{TaskID: task.ID, WorkedDay: pgconv.MustFromFloat(0.5), CreatedByUserID: user.ID, WorkedDate: date}
+	}
+	benchmarkImport(b, database, func(ctx context.Context, q sqlc.Querier) error {
+		for _, row := range rows {
+			if _, err := q.CreateTaskLog(ctx, sqlc.CreateTaskLogParams(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(ctx context.Context, q sqlc.Querier) error {
+		_, err := q.CreateTaskLogs(ctx, rows)
+		return err
+	})
+}
+
+func BenchmarkCreateLeaveLogs(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	user := createImportUser(b, database)
+
+	rows := make([]sqlc.CreateLeaveLogsParams, importRows)
+	for i, date := range importDates() {
+		rows[i] = sqlc.CreateLeaveLogsParams{UserID: user.ID, Type: "vacation", Date: date}
+	}
+	benchmarkImport(b, database, func(ctx context.Context, q sqlc.Querier) error {
+		for _, row := range rows {
+			if _, err := q.CreateLeaveLog(ctx, sqlc.CreateLeaveLogParams(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(ctx context.Context, q sqlc.Querier) error {
+		_, err := q.CreateLeaveLogs(ctx, rows)
+		return err
+	})
+}
+
+func BenchmarkCreateHolidays(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+
+	// Holiday dates are unique, which the rollback of every operation keeps free
+	rows := make([]sqlc.CreateHolidaysParams, importRows)
+	for i, date := range importDates() {
+		rows[i] = sqlc.CreateHolidaysParams{Date: date, Name: "bench-import"}
+	}
+	benchmarkImport(b, database, func(ctx context.Context, q sqlc.Querier) error {
+		for _, row := range rows {
+			if _, err := q.CreateHoliday(ctx, sqlc.CreateHolidayParams(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(ctx context.Context, q sqlc.Querier) error {
+		_, err := q.CreateHolidays(ctx, rows)
+		return err
+	})
+}
@@ -0,0 +1,6858 @@
+// Package dbtest helps test handlers and services: Fake is an in-memory db.Store for tests that
+// don't need SQL, and NewPostgres gives integration tests a migrated Postgres in Docker. DoJSON and
+// DecodeJSON drive handlers over HTTP against either.
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Fake keeps users, holidays, quota plans, annual records and their balance events, task
+// categories, tasks with their assignees, tags, custom fields, comments, estimates, estimation
+// sessions, sync history and activities, task logs, leave logs, medical expenses, tags, ClickUp
+// tokens and workspaces, attendance, payroll periods, the org chart, approvals, uploaded files,
+// anonymizations, anomalies, announcements and tenants in memory. The zero value is not usable,
+// create one with NewFake. Soft deleted users, tasks, leave logs and medical expenses move to a
+// separate map until they are purged.
+type Fake struct {
+	mu sync.Mutex
+	tables
+}
+
+// tables are the rows of a Fake, which WithTx copies to roll back to
+type tables struct {
+	nextID            int32
+	tenants           map[int32]sqlc.Tenant
+	users             map[int32]sqlc.User
+	holidays          map[int32]sqlc.Holiday
+	quotaPlans        map[int32]sqlc.QuotaPlan
+	annualRecords     map[int32]sqlc.AnnualRecord
+	balanceEvents     []sqlc.BalanceEvent // In the order recorded, IDs are positions from 1
+	taskCategories    map[int32]sqlc.TaskCategory
+	tasks             map[int32]sqlc.Task
+	taskAssignees     map[taskUserKey]sqlc.TaskAssignee
+	taskTags          map[taskTagKey]sqlc.TaskTag
+	taskActivities    map[int32]sqlc.TaskActivity
+	taskCustomFields  map[taskFieldKey]sqlc.TaskCustomField
+	taskComments      map[int32]sqlc.TaskComment
+	taskEstimates     map[int32]sqlc.TaskEstimate
+	sessions          map[int32]sqlc.EstimationSession
+	participants      map[sessionUserKey]sqlc.EstimationSessionParticipant
+	taskSyncHistory   map[int32]sqlc.TaskSyncHistory
+	clickUpTokens     map[clickUpTokenKey]sqlc.ClickupToken
+	clickUpWorkspaces map[string]sqlc.ClickupWorkspace // By team ID
+	taskLogs          map[int32]sqlc.TaskLog
+	leaveLogs         map[int32]sqlc.LeaveLog
+	medicalExpenses   map[int32]sqlc.MedicalExpense
+	tags              map[int32]sqlc.Tag
+	idempotencyKeys   map[int32]sqlc.IdempotencyKey
+	scheduledJobs     map[string]sqlc.ScheduledJob
+	queuedJobs        map[int32]sqlc.QueuedJob
+	featureFlags      map[string]sqlc.FeatureFlag
+	runtimeSettings   map[string]sqlc.RuntimeSetting
+	notifications     map[int32]sqlc.Notification
+	notificationPrefs map[notificationPrefKey]sqlc.NotificationPreference
+	slackWorkspaces   map[string]sqlc.SlackWorkspace
+	lineLinks         map[int32]sqlc.LineLink
+	pushDevices       map[int32]sqlc.PushDevice
+	webhooks          map[int32]sqlc.Webhook
+	apiTokens         map[int32]sqlc.ApiToken
+	webhookDeliveries map[int32]sqlc.WebhookDelivery
+	reportExports     map[int32]sqlc.ReportExport
+	attendance        map[int32]sqlc.AttendanceSession
+	payrollPeriods    map[int32]sqlc.PayrollPeriod
+	departments       map[int32]sqlc.Department
+	positions         map[int32]sqlc.Position
+	orgAssignments    map[int32]sqlc.OrgAssignment   // By user ID
+	approvalPolicies  map[string]sqlc.ApprovalPolicy // By kind
+	approvalRequests  map[int32]sqlc.ApprovalRequest
+	approvalDecisions map[int32]sqlc.ApprovalDecision
+	delegations       map[int32]sqlc.ApprovalDelegation
+	files             map[int32]sqlc.File
+	languagePrefs     map[int32]sqlc.LanguagePreference
+	timeZonePrefs     map[int32]sqlc.TimeZonePreference
+	anonymizations    map[int32]sqlc.Anonymization
+	anomalies         map[int32]sqlc.Anomaly
+	announcements     map[int32]sqlc.Announcement
+	announcementAcks  map[announcementAckKey]sqlc.AnnouncementAcknowledgment
+
+	deletedUsers           map[int32]sqlc.User
+	deletedTasks           map[int32]sqlc.Task
+	deletedLeaveLogs       map[int32]sqlc.LeaveLog
+	deletedMedicalExpenses map[int32]sqlc.MedicalExpense
+}
+
+var _ db.Store = (*Fake)(nil)
+
+// NewFake creates an empty fake
+func NewFake() *Fake {
+	return &Fake{
+		tables: tables{
+			// Rows written before multi-tenancy went to the default tenant the schema creates
+			nextID: db.DefaultTenantID,
+			tenants: map[int32]sqlc.Tenant{
+				db.DefaultTenantID: {ID: db.DefaultTenantID, Slug: "default", Name: "Default", CreatedAt: now()},
+			},
+			users:             make(map[int32]sqlc.User),
+			holidays:          make(map[int32]sqlc.Holiday),
+			quotaPlans:        make(map[int32]sqlc.QuotaPlan),
+			annualRecords:     make(map[int32]sqlc.AnnualRecord),
+			taskCategories:    make(map[int32]sqlc.TaskCategory),
+			tasks:             make(map[int32]sqlc.Task),
+			taskAssignees:     make(map[taskUserKey]sqlc.TaskAssignee),
+			taskTags:          make(map[taskTagKey]sqlc.TaskTag),
+			taskActivities:    make(map[int32]sqlc.TaskActivity),
+			taskCustomFields:  make(map[taskFieldKey]sqlc.TaskCustomField),
+			taskComments:      make(map[int32]sqlc.TaskComment),
+			taskEstimates:     make(map[int32]sqlc.TaskEstimate),
+			sessions:          make(map[int32]sqlc.EstimationSession),
+			participants:      make(map[sessionUserKey]sqlc.EstimationSessionParticipant),
+			taskSyncHistory:   make(map[int32]sqlc.TaskSyncHistory),
+			clickUpTokens:     make(map[clickUpTokenKey]sqlc.ClickupToken),
+			clickUpWorkspaces: make(map[string]sqlc.ClickupWorkspace),
+			taskLogs:          make(map[int32]sqlc.TaskLog),
+			leaveLogs:         make(map[int32]sqlc.LeaveLog),
+			medicalExpenses:   make(map[int32]sqlc.MedicalExpense),
+			tags:              make(map[int32]sqlc.Tag),
+			idempotencyKeys:   make(map[int32]sqlc.IdempotencyKey),
+			scheduledJobs:     make(map[string]sqlc.ScheduledJob),
+			queuedJobs:        make(map[int32]sqlc.QueuedJob),
+			featureFlags:      make(map[string]sqlc.FeatureFlag),
+			runtimeSettings:   make(map[string]sqlc.RuntimeSetting),
+			notifications:     make(map[int32]sqlc.Notification),
+			notificationPrefs: make(map[notificationPrefKey]sqlc.NotificationPreference),
+			slackWorkspaces:   make(map[string]sqlc.SlackWorkspace),
+			lineLinks:         make(map[int32]sqlc.LineLink),
+			pushDevices:       make(map[int32]sqlc.PushDevice),
+			webhooks:          make(map[int32]sqlc.Webhook),
+			apiTokens:         make(map[int32]sqlc.ApiToken),
+			webhookDeliveries: make(map[int32]sqlc.WebhookDelivery),
+			reportExports:     make(map[int32]sqlc.ReportExport),
+			attendance:        make(map[int32]sqlc.AttendanceSession),
+			payrollPeriods:    make(map[int32]sqlc.PayrollPeriod),
+			departments:       make(map[int32]sqlc.Department),
+			positions:         make(map[int32]sqlc.Position),
+			orgAssignments:    make(map[int32]sqlc.OrgAssignment),
+			// Leave goes to the user's supervisors, as the schema sets it up
+			approvalPolicies: map[string]sqlc.ApprovalPolicy{
+				"leave": {Kind: "leave", Steps: []string{"supervisor"}, UpdatedAt: now(), TenantID: db.DefaultTenantID},
+			},
+			approvalRequests:  make(map[int32]sqlc.ApprovalRequest),
+			approvalDecisions: make(map[int32]sqlc.ApprovalDecision),
+			delegations:       make(map[int32]sqlc.ApprovalDelegation),
+			files:             make(map[int32]sqlc.File),
+			languagePrefs:     make(map[int32]sqlc.LanguagePreference),
+			timeZonePrefs:     make(map[int32]sqlc.TimeZonePreference),
+			anonymizations:    make(map[int32]sqlc.Anonymization),
+			anomalies:         make(map[int32]sqlc.Anomaly),
+			announcements:     make(map[int32]sqlc.Announcement),
+			announcementAcks:  make(map[announcementAckKey]sqlc.AnnouncementAcknowledgment),
+
+			deletedUsers:           make(map[int32]sqlc.User),
+			deletedTasks:           make(map[int32]sqlc.Task),
+			deletedLeaveLogs:       make(map[int32]sqlc.LeaveLog),
+			deletedMedicalExpenses: make(map[int32]sqlc.MedicalExpense),
+		},
+	}
+}
+
+// clone copies the rows, so writes to the copy leave them as they are
+func (t tables) clone() tables {
+	t.tenants = maps.Clone(t.tenants)
+	t.users = maps.Clone(t.users)
+	t.holidays = maps.Clone(t.holidays)
+	t.quotaPlans = maps.Clone(t.quotaPlans)
+	t.annualRecords = maps.Clone(t.annualRecords)
+	t.balanceEvents = slices.Clone(t.balanceEvents)
+	t.taskCategories = maps.Clone(t.taskCategories)
+	t.tasks = maps.Clone(t.tasks)
+	t.taskAssignees = maps.Clone(t.taskAssignees)
+	t.taskTags = maps.Clone(t.taskTags)
+	t.taskActivities = maps.Clone(t.taskActivities)
+	t.taskCustomFields = maps.Clone(t.taskCustomFields)
+	t.taskComments = maps.Clone(t.taskComments)
+	t.taskEstimates = maps.Clone(t.taskEstimates)
+	t.sessions = maps.Clone(t.sessions)
+	t.participants = maps.Clone(t.participants)
+	t.taskSyncHistory = maps.Clone(t.taskSyncHistory)
+	t.clickUpTokens = maps.Clone(t.clickUpTokens)
+	t.clickUpWorkspaces = maps.Clone(t.clickUpWorkspaces)
+	t.taskLogs = maps.Clone(t.taskLogs)
+	t.leaveLogs = maps.Clone(t.leaveLogs)
+	t.medicalExpenses = maps.Clone(t.medicalExpenses)
+	t.tags = maps.Clone(t.tags)
+	t.idempotencyKeys = maps.Clone(t.idempotencyKeys)
+	t.scheduledJobs = maps.Clone(t.scheduledJobs)
+	t.queuedJobs = maps.Clone(t.queuedJobs)
+	t.featureFlags = maps.Clone(t.featureFlags)
+	t.runtimeSettings = maps.Clone(t.runtimeSettings)
+	t.notifications = maps.Clone(t.notifications)
+	t.notificationPrefs = maps.Clone(t.notificationPrefs)
+	t.slackWorkspaces = maps.Clone(t.slackWorkspaces)
+	t.lineLinks = maps.Clone(t.lineLinks)
+	t.pushDevices = maps.Clone(t.pushDevices)
+	t.webhooks = maps.Clone(t.webhooks)
+	t.apiTokens = maps.Clone(t.apiTokens)
+	t.webhookDeliveries = maps.Clone(t.webhookDeliveries)
+	t.reportExports = maps.Clone(t.reportExports)
+	t.attendance = maps.Clone(t.attendance)
+	t.payrollPeriods = maps.Clone(t.payrollPeriods)
+	t.departments = maps.Clone(t.departments)
+	t.positions = maps.Clone(t.positions)
+	t.orgAssignments = maps.Clone(t.orgAssignments)
+	t.approvalPolicies = maps.Clone(t.approvalPolicies)
+	t.approvalRequests = maps.Clone(t.approvalRequests)
+	t.approvalDecisions = maps.Clone(t.approvalDecisions)
+	t.delegations = maps.Clone(t.delegations)
+	t.files = maps.Clone(t.files)
+	t.languagePrefs = maps.Clone(t.languagePrefs)
+	t.timeZonePrefs = maps.Clone(t.timeZonePrefs)
+	t.anonymizations = maps.Clone(t.anonymizations)
+	t.anomalies = maps.Clone(t.anomalies)
+	t.announcements = maps.Clone(t.announcements)
+	t.announcementAcks = maps.Clone(t.announcementAcks)
+	t.deletedUsers = maps.Clone(t.deletedUsers)
+	t.deletedTasks = maps.Clone(t.deletedTasks)
+	t.deletedLeaveLogs = maps.Clone(t.deletedLeaveLogs)
+	t.deletedMedicalExpenses = maps.Clone(t.deletedMedicalExpenses)
+	return t
+}
+
+// WithTx runs fn against the fake and rolls every write back when fn fails or panics, like a
+// transaction. Writes other goroutines make while fn runs are rolled back with them.
+func (f *Fake) WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	return f.WithTxContext(ctx, func(ctx context.Context, q sqlc.Querier) error { return fn(q) })
+}
+
+// WithTxContext runs fn against the fake with the given context, like WithTx
+func (f *Fake) WithTxContext(ctx context.Context, fn func(ctx context.Context, q sqlc.Querier) error) error {
+	f.mu.Lock()
+	saved := f.tables.clone()
+	f.mu.Unlock()
+
+	committed := false
+	defer func() {
+		if !committed {
+			f.mu.Lock()
+			f.tables = saved
+			f.mu.Unlock()
+		}
+	}()
+	if err := fn(ctx, f); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// Users
+
+func (f *Fake) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := sqlc.User{
+		ID:            f.newID(),
+		Username:      arg.Username,
+		Password:      arg.Password,
+		UserType:      arg.UserType,
+		Email:         arg.Email,
+		CreatedAt:     now(),
+		UpdatedAt:     now(),
+		DailyCapacity: arg.DailyCapacity,
+	}
+	if !user.DailyCapacity.Valid {
+		user.DailyCapacity = pgtype.Numeric{Int: big.NewInt(100), Exp: -2, Valid: true}
+	}
+	f.users[user.ID] = user
+	return user, nil
+}
+
+func (f *Fake) GetUser(ctx context.Context, id int32) (sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.users, id)
+}
+
+func (f *Fake) GetUserByUsername(ctx context.Context, username string) (sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.users, func(user sqlc.User) bool { return user.Username == username })
+}
+
+func (f *Fake) GetUserByEmail(ctx context.Context, email string) (sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.users, func(user sqlc.User) bool { return user.Email == email })
+}
+
+func (f *Fake) ListUsers(ctx context.Context, arg sqlc.ListUsersParams) ([]sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := filter(f.users, nil, func(a, b sqlc.User) bool { return a.ID < b.ID })
+	return page(users, arg.RowLimit, arg.RowOffset), nil
+}
+
+func (f *Fake) CountUsers(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.users)), nil
+}
+
+func (f *Fake) UpdateUser(ctx context.Context, arg sqlc.UpdateUserParams) (sqlc.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, err := get(f.users, arg.ID)
+	if err != nil {
+		return user, err
+	}
+	user.Username = arg.Username
+	user.Password = arg.Password
+	user.UserType = arg.UserType
+	user.Email = arg.Email
+	if arg.DailyCapacity.Valid {
+		user.DailyCapacity = arg.DailyCapacity
+	}
+	// An empty department clears it
+	if arg.Department.Valid {
+		user.Department = pgtype.Text{String: arg.Department.String, Valid: arg.Department.String != ""}
+	}
+	user.UpdatedAt = now()
+	f.users[user.ID] = user
+	return user, nil
+}
+
+func (f *Fake) DeleteUser(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	softDelete(f.users, f.deletedUsers, id, func(user *sqlc.User) *pgtype.Timestamptz { return &user.DeletedAt })
+	return nil
+}
+
+func (f *Fake) ListPurgeableUserIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purgeable(f.deletedUsers, deletedBefore, func(user sqlc.User) pgtype.Timestamptz { return user.DeletedAt }), nil
+}
+
+func (f *Fake) PurgeUser(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purge(f.deletedUsers, id), nil
+}
+
+func (f *Fake) RestoreUser(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.anonymizations[id]; ok {
+		return 0, nil
+	}
+	return restore(f.users, f.deletedUsers, id, func(user *sqlc.User) *pgtype.Timestamptz { return &user.DeletedAt }), nil
+}
+
+// Annual records
+
+func (f *Fake) CreateAnnualRecord(ctx context.Context, arg sqlc.CreateAnnualRecordParams) (sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record := sqlc.AnnualRecord{
+		ID:                     f.newID(),
+		UserID:                 arg.UserID,
+		Year:                   arg.Year,
+		QuotaPlanID:            arg.QuotaPlanID,
+		RolloverVacationDay:    arg.RolloverVacationDay,
+		UsedVacationDay:        arg.UsedVacationDay,
+		UsedSickLeaveDay:       arg.UsedSickLeaveDay,
+		WorkedOnHolidayDay:     arg.WorkedOnHolidayDay,
+		WorkedDay:              arg.WorkedDay,
+		UsedMedicalExpenseBaht: arg.UsedMedicalExpenseBaht,
+		CreatedAt:              now(),
+		UpdatedAt:              now(),
+	}
+	f.annualRecords[record.ID] = record
+	return record, nil
+}
+
+func (f *Fake) GetAnnualRecord(ctx context.Context, id int32) (sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.annualRecords, id)
+}
+
+func (f *Fake) GetAnnualRecordByUserAndYear(ctx context.Context, arg sqlc.GetAnnualRecordByUserAndYearParams) (sqlc.GetAnnualRecordByUserAndYearRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, err := f.annualRecord(arg.UserID, arg.Year)
+	if err != nil {
+		return sqlc.GetAnnualRecordByUserAndYearRow{}, err
+	}
+	return f.withQuota(record), nil
+}
+
+func (f *Fake) ListAnnualRecordsByUser(ctx context.Context, userID int32) ([]sqlc.ListAnnualRecordsByUserRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListAnnualRecordsByUserRow{}
+	for _, record := range filter(f.annualRecords,
+		func(r sqlc.AnnualRecord) bool { return r.UserID == userID },
+		func(a, b sqlc.AnnualRecord) bool { return a.Year > b.Year },
+	) {
+		rows = append(rows, sqlc.ListAnnualRecordsByUserRow(f.withQuota(record)))
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListAnnualRecordsByYear(ctx context.Context, year int32) ([]sqlc.ListAnnualRecordsByYearRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListAnnualRecordsByYearRow{}
+	for _, record := range filter(f.annualRecords,
+		func(r sqlc.AnnualRecord) bool { return r.Year == year },
+		func(a, b sqlc.AnnualRecord) bool { return a.UserID < b.UserID },
+	) {
+		rows = append(rows, sqlc.ListAnnualRecordsByYearRow(f.withQuota(record)))
+	}
+	return rows, nil
+}
+
+func (f *Fake) UpdateAnnualRecord(ctx context.Context, arg sqlc.UpdateAnnualRecordParams) (sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, err := f.annualRecord(arg.UserID, arg.Year)
+	if err != nil {
+		return record, err
+	}
+	if stale(arg.ExpectedUpdatedAt, record.UpdatedAt) {
+		return sqlc.AnnualRecord{}, pgx.ErrNoRows
+	}
+	if arg.QuotaPlanID.Valid {
+		record.QuotaPlanID = arg.QuotaPlanID
+	}
+	for _, field := range []struct {
+		value pgtype.Numeric
+		set   *pgtype.Numeric
+	}{
+		{arg.RolloverVacationDay, &record.RolloverVacationDay},
+		{arg.UsedVacationDay, &record.UsedVacationDay},
+		{arg.UsedSickLeaveDay, &record.UsedSickLeaveDay},
+		{arg.WorkedOnHolidayDay, &record.WorkedOnHolidayDay},
+		{arg.WorkedDay, &record.WorkedDay},
+		{arg.UsedMedicalExpenseBaht, &record.UsedMedicalExpenseBaht},
+	} {
+		if field.value.Valid {
+			*field.set = field.value
+		}
+	}
+	record.UpdatedAt = now()
+	f.annualRecords[record.ID] = record
+	return record, nil
+}
+
+func (f *Fake) DeleteAnnualRecord(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.annualRecords, id)
+	return nil
+}
+
+// CreateNextYearAnnualRecords gives every user without a record for the next year one on its
+// Default plan, rolling over the vacation days left of this year plus those earned working on
+// holidays
+func (f *Fake) CreateNextYearAnnualRecords(ctx context.Context, arg sqlc.CreateNextYearAnnualRecordsParams) ([]sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var planID pgtype.Int4
+	if plan, err := first(f.quotaPlans, func(p sqlc.QuotaPlan) bool { return p.Year == arg.NextYear && p.PlanName == "Default" }); err == nil {
+		planID = pgtype.Int4{Int32: plan.ID, Valid: true}
+	}
+	records := []sqlc.AnnualRecord{}
+	for _, user := range filter(f.users, nil, func(a, b sqlc.User) bool { return a.ID < b.ID }) {
+		if _, err := f.annualRecord(user.ID, arg.NextYear); err == nil {
+			continue
+		}
+		rollover := new(big.Rat)
+		if current, err := f.annualRecord(user.ID, arg.ThisYear); err == nil {
+			rollover.Add(decimal(f.withQuota(current).QuotaVacationDay), decimal(current.WorkedOnHolidayDay))
+			rollover.Sub(rollover, decimal(current.UsedVacationDay))
+			if rollover.Sign() < 0 {
+				rollover.SetInt64(0)
+			}
+		}
+		record := sqlc.AnnualRecord{
+			ID:                     f.newID(),
+			UserID:                 user.ID,
+			Year:                   arg.NextYear,
+			QuotaPlanID:            planID,
+			RolloverVacationDay:    numeric(rollover),
+			UsedVacationDay:        numeric(new(big.Rat)),
+			UsedSickLeaveDay:       numeric(new(big.Rat)),
+			WorkedOnHolidayDay:     numeric(new(big.Rat)),
+			WorkedDay:              numeric(new(big.Rat)),
+			UsedMedicalExpenseBaht: numeric(new(big.Rat)),
+			CreatedAt:              now(),
+			UpdatedAt:              now(),
+		}
+		f.annualRecords[record.ID] = record
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// AssignQuotaPlanToAllUsers puts every record of the year on the plan and gives users without one
+// an empty record on it
+func (f *Fake) AssignQuotaPlanToAllUsers(ctx context.Context, arg sqlc.AssignQuotaPlanToAllUsersParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, record := range f.annualRecords {
+		if record.Year == arg.Year {
+			record.QuotaPlanID = arg.QuotaPlanID
+			record.UpdatedAt = now()
+			f.annualRecords[id] = record
+		}
+	}
+	for _, user := range filter(f.users, nil, func(a, b sqlc.User) bool { return a.ID < b.ID }) {
+		if _, err := f.annualRecord(user.ID, arg.Year); err != nil {
+			f.insertAnnualRecord(user.ID, arg.Year, arg.QuotaPlanID)
+		}
+	}
+	return nil
+}
+
+// UpsertAnnualRecordForUser creates the user's record for the year, with zero totals unless given,
+// or puts the one there is on the plan
+func (f *Fake) UpsertAnnualRecordForUser(ctx context.Context, arg sqlc.UpsertAnnualRecordForUserParams) (sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if record, err := f.annualRecord(arg.UserID, arg.Year); err == nil {
+		record.QuotaPlanID = arg.QuotaPlanID
+		record.UpdatedAt = now()
+		f.annualRecords[record.ID] = record
+		return record, nil
+	}
+	record := f.insertAnnualRecord(arg.UserID, arg.Year, arg.QuotaPlanID)
+	for _, field := range []struct {
+		value interface{}
+		set   *pgtype.Numeric
+	}{
+		{arg.RolloverVacationDay, &record.RolloverVacationDay},
+		{arg.UsedVacationDay, &record.UsedVacationDay},
+		{arg.UsedSickLeaveDay, &record.UsedSickLeaveDay},
+		{arg.WorkedOnHolidayDay, &record.WorkedOnHolidayDay},
+		{arg.WorkedDay, &record.WorkedDay},
+		{arg.UsedMedicalExpenseBaht, &record.UsedMedicalExpenseBaht},
+	} {
+		if n, ok := field.value.(pgtype.Numeric); ok && n.Valid {
+			*field.set = n
+		}
+	}
+	f.annualRecords[record.ID] = record
+	return record, nil
+}
+
+func (f *Fake) GetLeaveBalance(ctx context.Context, arg sqlc.GetLeaveBalanceParams) (sqlc.GetLeaveBalanceRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, err := f.annualRecord(arg.UserID, arg.Year)
+	if err != nil {
+		return sqlc.GetLeaveBalanceRow{}, err
+	}
+	plan, hasPlan := f.quotaPlans[record.QuotaPlanID.Int32]
+	row := sqlc.GetLeaveBalanceRow{
+		Year:                    record.Year,
+		QuotaVacationDay:        orZero(plan.QuotaVacationDay),
+		RolloverVacationDay:     orZero(record.RolloverVacationDay),
+		UsedVacationDay:         orZero(record.UsedVacationDay),
+		UsedSickLeaveDay:        orZero(record.UsedSickLeaveDay),
+		QuotaMedicalExpenseBaht: orZero(plan.QuotaMedicalExpenseBaht),
+		UsedMedicalExpenseBaht:  orZero(record.UsedMedicalExpenseBaht),
+	}
+	if hasPlan && record.QuotaPlanID.Valid {
+		row.PlanName = pgtype.Text{String: plan.PlanName, Valid: true}
+	}
+	return row, nil
+}
+
+func (f *Fake) ListLeaveBalancesByYear(ctx context.Context, year int32) ([]sqlc.ListLeaveBalancesByYearRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListLeaveBalancesByYearRow{}
+	for _, record := range f.annualRecords {
+		user, ok := f.users[record.UserID]
+		if !ok || record.Year != year {
+			continue
+		}
+		plan, hasPlan := f.quotaPlans[record.QuotaPlanID.Int32]
+		row := sqlc.ListLeaveBalancesByYearRow{
+			UserID:                  record.UserID,
+			Username:                user.Username,
+			Year:                    record.Year,
+			QuotaVacationDay:        orZero(plan.QuotaVacationDay),
+			RolloverVacationDay:     orZero(record.RolloverVacationDay),
+			UsedVacationDay:         orZero(record.UsedVacationDay),
+			UsedSickLeaveDay:        orZero(record.UsedSickLeaveDay),
+			QuotaMedicalExpenseBaht: orZero(plan.QuotaMedicalExpenseBaht),
+			UsedMedicalExpenseBaht:  orZero(record.UsedMedicalExpenseBaht),
+		}
+		if hasPlan && record.QuotaPlanID.Valid {
+			row.PlanName = pgtype.Text{String: plan.PlanName, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Username < rows[j].Username })
+	return rows, nil
+}
+
+// SyncAnnualRecord sets the used and worked totals of the user's record for the year to what its
+// balance events add up to
+func (f *Fake) SyncAnnualRecord(ctx context.Context, arg sqlc.SyncAnnualRecordParams) (sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, err := f.annualRecord(arg.UserID, arg.Year)
+	if err != nil {
+		return record, err
+	}
+	return f.syncAnnualRecord(record), nil
+}
+
+func (f *Fake) SyncAllAnnualRecordsByYear(ctx context.Context, year int32) ([]sqlc.AnnualRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records := filter(f.annualRecords,
+		func(r sqlc.AnnualRecord) bool { return r.Year == year },
+		func(a, b sqlc.AnnualRecord) bool { return a.ID < b.ID },
+	)
+	for i, record := range records {
+		records[i] = f.syncAnnualRecord(record)
+	}
+	return records, nil
+}
+
+// annualRecord returns the user's record for the year, or pgx.ErrNoRows. Callers must hold the lock.
+func (f *Fake) annualRecord(userID, year int32) (sqlc.AnnualRecord, error) {
+	return first(f.annualRecords, func(r sqlc.AnnualRecord) bool { return r.UserID == userID && r.Year == year })
+}
+
+// insertAnnualRecord keeps a new record with zero totals. Callers must hold the lock.
+func (f *Fake) insertAnnualRecord(userID, year int32, quotaPlanID pgtype.Int4) sqlc.AnnualRecord {
+	zero := numeric(new(big.Rat))
+	record := sqlc.AnnualRecord{
+		ID:                     f.newID(),
+		UserID:                 userID,
+		Year:                   year,
+		QuotaPlanID:            quotaPlanID,
+		RolloverVacationDay:    zero,
+		UsedVacationDay:        zero,
+		UsedSickLeaveDay:       zero,
+		WorkedOnHolidayDay:     zero,
+		WorkedDay:              zero,
+		UsedMedicalExpenseBaht: zero,
+		CreatedAt:              now(),
+		UpdatedAt:              now(),
+	}
+	f.annualRecords[record.ID] = record
+	return record
+}
+
+// withQuota joins a record with the quotas of its plan, NULL without one. Callers must hold the lock.
+func (f *Fake) withQuota(record sqlc.AnnualRecord) sqlc.GetAnnualRecordByUserAndYearRow {
+	row := sqlc.GetAnnualRecordByUserAndYearRow{
+		ID:                     record.ID,
+		UserID:                 record.UserID,
+		Year:                   record.Year,
+		QuotaPlanID:            record.QuotaPlanID,
+		RolloverVacationDay:    record.RolloverVacationDay,
+		UsedVacationDay:        record.UsedVacationDay,
+		UsedSickLeaveDay:       record.UsedSickLeaveDay,
+		WorkedOnHolidayDay:     record.WorkedOnHolidayDay,
+		WorkedDay:              record.WorkedDay,
+		UsedMedicalExpenseBaht: record.UsedMedicalExpenseBaht,
+		CreatedAt:              record.CreatedAt,
+		UpdatedAt:              record.UpdatedAt,
+		TenantID:               record.TenantID,
+	}
+	if plan, ok := f.quotaPlans[record.QuotaPlanID.Int32]; ok && record.QuotaPlanID.Valid {
+		row.QuotaVacationDay = plan.QuotaVacationDay
+		row.QuotaMedicalExpenseBaht = plan.QuotaMedicalExpenseBaht
+	}
+	return row
+}
+
+// syncAnnualRecord sets the totals of a record to its balance events. Callers must hold the lock.
+func (f *Fake) syncAnnualRecord(record sqlc.AnnualRecord) sqlc.AnnualRecord {
+	totals := f.balanceTotals(record.UserID, record.Year, pgtype.Timestamptz{})
+	record.UsedVacationDay = numeric(totals.vacationDay)
+	record.UsedSickLeaveDay = numeric(totals.sickLeaveDay)
+	record.WorkedDay = numeric(totals.workedDay)
+	record.WorkedOnHolidayDay = numeric(totals.workedOnHolidayDay)
+	record.UsedMedicalExpenseBaht = numeric(totals.medicalExpenseBaht)
+	record.UpdatedAt = now()
+	f.annualRecords[record.ID] = record
+	return record
+}
+
+// Balance events
+//
+// Postgres records them with the triggers of db/migrations/000052_balance_events.up.sql, the fake
+// records them in the writes of leave logs, task logs and medical expenses the same way: a change
+// retracts what the row added so far and records what it adds now.
+
+// balance is what balance events add up to, one sum per column
+type balance struct {
+	events                                                                       int64
+	lastEventID                                                                  int64
+	vacationDay, sickLeaveDay, workedDay, workedOnHolidayDay, medicalExpenseBaht *big.Rat
+}
+
+func newBalance() *balance {
+	return &balance{
+		vacationDay:        new(big.Rat),
+		sickLeaveDay:       new(big.Rat),
+		workedDay:          new(big.Rat),
+		workedOnHolidayDay: new(big.Rat),
+		medicalExpenseBaht: new(big.Rat),
+	}
+}
+
+func (b *balance) add(event sqlc.BalanceEvent) {
+	b.events++
+	b.lastEventID = max(b.lastEventID, event.ID)
+	b.vacationDay.Add(b.vacationDay, decimal(event.VacationDay))
+	b.sickLeaveDay.Add(b.sickLeaveDay, decimal(event.SickLeaveDay))
+	b.workedDay.Add(b.workedDay, decimal(event.WorkedDay))
+	b.workedOnHolidayDay.Add(b.workedOnHolidayDay, decimal(event.WorkedOnHolidayDay))
+	b.medicalExpenseBaht.Add(b.medicalExpenseBaht, decimal(event.MedicalExpenseBaht))
+}
+
+// differs reports whether the totals of a record aren't what the events add up to
+func (b *balance) differs(record sqlc.AnnualRecord) bool {
+	return decimal(record.UsedVacationDay).Cmp(b.vacationDay) != 0 ||
+		decimal(record.UsedSickLeaveDay).Cmp(b.sickLeaveDay) != 0 ||
+		decimal(record.WorkedDay).Cmp(b.workedDay) != 0 ||
+		decimal(record.WorkedOnHolidayDay).Cmp(b.workedOnHolidayDay) != 0 ||
+		decimal(record.UsedMedicalExpenseBaht).Cmp(b.medicalExpenseBaht) != 0
+}
+
+func (b *balance) zero() bool {
+	return b.vacationDay.Sign() == 0 && b.sickLeaveDay.Sign() == 0 && b.workedDay.Sign() == 0 &&
+		b.workedOnHolidayDay.Sign() == 0 && b.medicalExpenseBaht.Sign() == 0
+}
+
+// AdjustBalance records the difference between the totals of an annual record and its balance
+// events as an adjustment, nothing when they agree
+func (f *Fake) AdjustBalance(ctx context.Context, arg sqlc.AdjustBalanceParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	record, ok := f.annualRecords[arg.AnnualRecordID]
+	if !ok {
+		return nil
+	}
+	totals := f.balanceTotals(record.UserID, record.Year, pgtype.Timestamptz{})
+	if !totals.differs(record) {
+		return nil
+	}
+	difference := func(total pgtype.Numeric, events *big.Rat) pgtype.Numeric {
+		return numeric(new(big.Rat).Sub(decimal(total), events))
+	}
+	f.recordBalanceEvent(sqlc.BalanceEvent{
+		UserID:             record.UserID,
+		Year:               record.Year,
+		Source:             "annual_records",
+		SourceID:           record.ID,
+		Action:             "adjusted",
+		VacationDay:        difference(record.UsedVacationDay, totals.vacationDay),
+		SickLeaveDay:       difference(record.UsedSickLeaveDay, totals.sickLeaveDay),
+		WorkedDay:          difference(record.WorkedDay, totals.workedDay),
+		WorkedOnHolidayDay: difference(record.WorkedOnHolidayDay, totals.workedOnHolidayDay),
+		MedicalExpenseBaht: difference(record.UsedMedicalExpenseBaht, totals.medicalExpenseBaht),
+		RecordedByUserID:   arg.RecordedByUserID,
+	})
+	return nil
+}
+
+func (f *Fake) GetBalanceTotals(ctx context.Context, arg sqlc.GetBalanceTotalsParams) (sqlc.GetBalanceTotalsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	totals := f.balanceTotals(arg.UserID, arg.Year, arg.AsOf)
+	return sqlc.GetBalanceTotalsRow{
+		Events:             totals.events,
+		LastEventID:        totals.lastEventID,
+		VacationDay:        numeric(totals.vacationDay),
+		SickLeaveDay:       numeric(totals.sickLeaveDay),
+		WorkedDay:          numeric(totals.workedDay),
+		WorkedOnHolidayDay: numeric(totals.workedOnHolidayDay),
+		MedicalExpenseBaht: numeric(totals.medicalExpenseBaht),
+	}, nil
+}
+
+func (f *Fake) ListBalanceDrift(ctx context.Context, year int32) ([]sqlc.ListBalanceDriftRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListBalanceDriftRow{}
+	for _, record := range f.annualRecords {
+		if record.Year != year {
+			continue
+		}
+		totals := f.balanceTotals(record.UserID, year, pgtype.Timestamptz{})
+		if !totals.differs(record) {
+			continue
+		}
+		rows = append(rows, sqlc.ListBalanceDriftRow{
+			ID:                       record.ID,
+			UserID:                   record.UserID,
+			Username:                 f.username(record.UserID),
+			UsedVacationDay:          record.UsedVacationDay,
+			UsedSickLeaveDay:         record.UsedSickLeaveDay,
+			WorkedDay:                record.WorkedDay,
+			WorkedOnHolidayDay:       record.WorkedOnHolidayDay,
+			UsedMedicalExpenseBaht:   record.UsedMedicalExpenseBaht,
+			EventsVacationDay:        numeric(totals.vacationDay),
+			EventsSickLeaveDay:       numeric(totals.sickLeaveDay),
+			EventsWorkedDay:          numeric(totals.workedDay),
+			EventsWorkedOnHolidayDay: numeric(totals.workedOnHolidayDay),
+			EventsMedicalExpenseBaht: numeric(totals.medicalExpenseBaht),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Username < rows[j].Username })
+	return rows, nil
+}
+
+func (f *Fake) ListBalanceEvents(ctx context.Context, arg sqlc.ListBalanceEventsParams) ([]sqlc.BalanceEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := []sqlc.BalanceEvent{}
+	for _, event := range f.balanceEvents {
+		if event.UserID == arg.UserID && event.Year == arg.Year {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// balanceTotals adds up the events of a user's year recorded up to asOf, all of them when it is
+// NULL. Callers must hold the lock.
+func (f *Fake) balanceTotals(userID, year int32, asOf pgtype.Timestamptz) *balance {
+	totals := newBalance()
+	for _, event := range f.balanceEvents {
+		if event.UserID == userID && event.Year == year && (!asOf.Valid || !event.RecordedAt.Time.After(asOf.Time)) {
+			totals.add(event)
+		}
+	}
+	return totals
+}
+
+// recordBalanceEvent appends an event to the ledger, columns left NULL count zero. Callers must
+// hold the lock.
+func (f *Fake) recordBalanceEvent(event sqlc.BalanceEvent) {
+	event.ID = int64(len(f.balanceEvents) + 1)
+	for _, n := range []*pgtype.Numeric{&event.VacationDay, &event.SickLeaveDay, &event.WorkedDay, &event.WorkedOnHolidayDay, &event.MedicalExpenseBaht} {
+		*n = orZero(*n)
+	}
+	event.RecordedAt = now()
+	f.balanceEvents = append(f.balanceEvents, event)
+}
+
+// retractBalance cancels what the events of a row added so far, in each year they added to.
+// Callers must hold the lock.
+func (f *Fake) retractBalance(source string, id int32) {
+	type userYear struct{ userID, year int32 }
+	added := map[userYear]*balance{}
+	for _, event := range f.balanceEvents {
+		if event.Source != source || event.SourceID != id {
+			continue
+		}
+		key := userYear{event.UserID, event.Year}
+		if added[key] == nil {
+			added[key] = newBalance()
+		}
+		added[key].add(event)
+	}
+	keys := slices.SortedFunc(maps.Keys(added), func(a, b userYear) int {
+		if a.year != b.year {
+			return int(a.year - b.year)
+		}
+		return int(a.userID - b.userID)
+	})
+	for _, key := range keys {
+		totals := added[key]
+		if totals.zero() {
+			continue
+		}
+		negate := func(r *big.Rat) pgtype.Numeric { return numeric(new(big.Rat).Neg(r)) }
+		f.recordBalanceEvent(sqlc.BalanceEvent{
+			UserID:             key.userID,
+			Year:               key.year,
+			Source:             source,
+			SourceID:           id,
+			Action:             "retracted",
+			VacationDay:        negate(totals.vacationDay),
+			SickLeaveDay:       negate(totals.sickLeaveDay),
+			WorkedDay:          negate(totals.workedDay),
+			WorkedOnHolidayDay: negate(totals.workedOnHolidayDay),
+			MedicalExpenseBaht: negate(totals.medicalExpenseBaht),
+		})
+	}
+}
+
+// recordLeaveLogBalance records the daily capacity of the user for vacation and sick leave, other
+// leave doesn't count. Callers must hold the lock.
+func (f *Fake) recordLeaveLogBalance(l sqlc.LeaveLog) {
+	if l.Type != "vacation" && l.Type != "sick" {
+		return
+	}
+	user, ok := f.users[l.UserID]
+	if !ok {
+		user = f.deletedUsers[l.UserID]
+	}
+	capacity := user.DailyCapacity
+	if !capacity.Valid {
+		capacity = numeric(big.NewRat(1, 1))
+	}
+	event := sqlc.BalanceEvent{UserID: l.UserID, Year: int32(l.Date.Time.Year()), Source: "leave_logs", SourceID: l.ID, Action: "recorded"}
+	if l.Type == "vacation" {
+		event.VacationDay = capacity
+	} else {
+		event.SickLeaveDay = capacity
+	}
+	f.recordBalanceEvent(event)
+}
+
+// recordTaskLogBalance records the days worked, and those on a holiday. Callers must hold the lock.
+func (f *Fake) recordTaskLogBalance(l sqlc.TaskLog) {
+	if decimal(l.WorkedDay).Sign() == 0 {
+		return
+	}
+	event := sqlc.BalanceEvent{
+		UserID:    l.CreatedByUserID,
+		Year:      int32(l.WorkedDate.Time.Year()),
+		Source:    "task_logs",
+		SourceID:  l.ID,
+		Action:    "recorded",
+		WorkedDay: l.WorkedDay,
+	}
+	if l.IsWorkOnHoliday.Bool {
+		event.WorkedOnHolidayDay = l.WorkedDay
+	}
+	f.recordBalanceEvent(event)
+}
+
+// recordMedicalExpenseBalance records the amount, in the year of the receipt or else of when the
+// expense was recorded. Callers must hold the lock.
+func (f *Fake) recordMedicalExpenseBalance(e sqlc.MedicalExpense) {
+	if decimal(e.Amount).Sign() == 0 {
+		return
+	}
+	year := e.CreatedAt.Time.Year()
+	if e.ReceiptDate.Valid {
+		year = e.ReceiptDate.Time.Year()
+	}
+	f.recordBalanceEvent(sqlc.BalanceEvent{
+		UserID:             e.UserID,
+		Year:               int32(year),
+		Source:             "medical_expenses",
+		SourceID:           e.ID,
+		Action:             "recorded",
+		MedicalExpenseBaht: e.Amount,
+	})
+}
+
+// Holidays
+
+func (f *Fake) CreateHoliday(ctx context.Context, arg sqlc.CreateHolidayParams) (sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	holiday := sqlc.Holiday{
+		ID:        f.newID(),
+		Date:      arg.Date,
+		Name:      arg.Name,
+		Note:      arg.Note,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	f.holidays[holiday.ID] = holiday
+	return holiday, nil
+}
+
+func (f *Fake) CreateHolidays(ctx context.Context, arg []sqlc.CreateHolidaysParams) (int64, error) {
+	for _, row := range arg {
+		f.CreateHoliday(ctx, sqlc.CreateHolidayParams(row))
+	}
+	return int64(len(arg)), nil
+}
+
+func (f *Fake) GetHoliday(ctx context.Context, id int32) (sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.holidays, id)
+}
+
+func (f *Fake) GetHolidayByDate(ctx context.Context, date pgtype.Date) (sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.holidays, func(holiday sqlc.Holiday) bool { return sameDate(holiday.Date, date) })
+}
+
+func (f *Fake) ListHolidays(ctx context.Context, arg sqlc.ListHolidaysParams) ([]sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	holidays := filter(f.holidays, nil, byDate(func(h sqlc.Holiday) pgtype.Date { return h.Date }, false))
+	return page(holidays, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) CountHolidays(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.holidays)), nil
+}
+
+func (f *Fake) GetHolidaysVersion(ctx context.Context) (sqlc.GetHolidaysVersionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count, lastUpdated := version(f.holidays, func(h sqlc.Holiday) pgtype.Timestamptz { return h.UpdatedAt })
+	return sqlc.GetHolidaysVersionRow{RowCount: count, LastUpdated: lastUpdated}, nil
+}
+
+func (f *Fake) ListHolidaysByYear(ctx context.Context, date pgtype.Date) ([]sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.holidays,
+		func(h sqlc.Holiday) bool { return h.Date.Time.Year() == date.Time.Year() },
+		byDate(func(h sqlc.Holiday) pgtype.Date { return h.Date }, false),
+	), nil
+}
+
+func (f *Fake) UpdateHoliday(ctx context.Context, arg sqlc.UpdateHolidayParams) (sqlc.Holiday, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	holiday, err := get(f.holidays, arg.ID)
+	if err != nil {
+		return holiday, err
+	}
+	if arg.Date.Valid {
+		holiday.Date = arg.Date
+	}
+	holiday.Name = arg.Name
+	if arg.Note.Valid {
+		holiday.Note = arg.Note
+	}
+	holiday.UpdatedAt = now()
+	f.holidays[holiday.ID] = holiday
+	return holiday, nil
+}
+
+func (f *Fake) DeleteHoliday(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.holidays, id)
+	return nil
+}
+
+// Quota plans
+
+func (f *Fake) CreateQuotaPlan(ctx context.Context, arg sqlc.CreateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	plan := sqlc.QuotaPlan{
+		ID:                      f.newID(),
+		PlanName:                arg.PlanName,
+		Year:                    arg.Year,
+		QuotaVacationDay:        arg.QuotaVacationDay,
+		QuotaMedicalExpenseBaht: arg.QuotaMedicalExpenseBaht,
+		CreatedByUserID:         arg.CreatedByUserID,
+		CreatedAt:               now(),
+		UpdatedAt:               now(),
+	}
+	f.quotaPlans[plan.ID] = plan
+	return plan, nil
+}
+
+func (f *Fake) GetQuotaPlan(ctx context.Context, id int32) (sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.quotaPlans, id)
+}
+
+func (f *Fake) GetQuotaPlanByNameAndYear(ctx context.Context, arg sqlc.GetQuotaPlanByNameAndYearParams) (sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.quotaPlans, func(plan sqlc.QuotaPlan) bool {
+		return plan.PlanName == arg.PlanName && plan.Year == arg.Year
+	})
+}
+
+func (f *Fake) ListQuotaPlans(ctx context.Context) ([]sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.quotaPlans, nil, func(a, b sqlc.QuotaPlan) bool {
+		if a.Year != b.Year {
+			return a.Year > b.Year
+		}
+		return a.PlanName < b.PlanName
+	}), nil
+}
+
+func (f *Fake) ListQuotaPlansByYear(ctx context.Context, year int32) ([]sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.quotaPlans,
+		func(plan sqlc.QuotaPlan) bool { return plan.Year == year },
+		func(a, b sqlc.QuotaPlan) bool { return a.PlanName < b.PlanName },
+	), nil
+}
+
+func (f *Fake) GetQuotaPlansVersion(ctx context.Context) (sqlc.GetQuotaPlansVersionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count, lastUpdated := version(f.quotaPlans, func(plan sqlc.QuotaPlan) pgtype.Timestamptz { return plan.UpdatedAt })
+	return sqlc.GetQuotaPlansVersionRow{RowCount: count, LastUpdated: lastUpdated}, nil
+}
+
+func (f *Fake) UpdateQuotaPlan(ctx context.Context, arg sqlc.UpdateQuotaPlanParams) (sqlc.QuotaPlan, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	plan, err := get(f.quotaPlans, arg.ID)
+	if err != nil {
+		return plan, err
+	}
+	if stale(arg.ExpectedUpdatedAt, plan.UpdatedAt) {
+		return sqlc.QuotaPlan{}, pgx.ErrNoRows
+	}
+	plan.PlanName = arg.PlanName
+	plan.Year = arg.Year
+	if arg.QuotaVacationDay.Valid {
+		plan.QuotaVacationDay = arg.QuotaVacationDay
+	}
+	if arg.QuotaMedicalExpenseBaht.Valid {
+		plan.QuotaMedicalExpenseBaht = arg.QuotaMedicalExpenseBaht
+	}
+	plan.UpdatedAt = now()
+	f.quotaPlans[plan.ID] = plan
+	return plan, nil
+}
+
+func (f *Fake) DeleteQuotaPlan(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.quotaPlans, id)
+	return nil
+}
+
+// Tasks
+
+func (f *Fake) CreateTask(ctx context.Context, arg sqlc.CreateTaskParams) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task := sqlc.Task{
+		ID:              f.newID(),
+		Url:             arg.Url,
+		TaskCategoryID:  arg.TaskCategoryID,
+		Note:            arg.Note,
+		Title:           arg.Title,
+		Status:          arg.Status,
+		StatusColor:     arg.StatusColor,
+		CreatedAt:       now(),
+		UpdatedAt:       now(),
+		DueDate:         arg.DueDate,
+		Priority:        arg.Priority,
+		ParentTaskID:    arg.ParentTaskID,
+		CreatedByUserID: arg.CreatedByUserID,
+	}
+	f.tasks[task.ID] = task
+	return task, nil
+}
+
+func (f *Fake) ListRecentTasksForUser(ctx context.Context, arg sqlc.ListRecentTasksForUserParams) ([]sqlc.ListRecentTasksForUserRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lastActivity := make(map[int32]pgtype.Timestamptz)
+	for _, l := range f.taskLogs {
+		if l.CreatedByUserID == arg.UserID && l.CreatedAt.Time.After(lastActivity[l.TaskID].Time) {
+			lastActivity[l.TaskID] = l.CreatedAt
+		}
+	}
+	for key, assignee := range f.taskAssignees {
+		if key.userID == arg.UserID && assignee.AssignedAt.Time.After(lastActivity[key.taskID].Time) {
+			lastActivity[key.taskID] = assignee.AssignedAt
+		}
+	}
+	rows := []sqlc.ListRecentTasksForUserRow{}
+	for id, at := range lastActivity {
+		task, ok := f.tasks[id]
+		if !ok {
+			continue
+		}
+		rows = append(rows, sqlc.ListRecentTasksForUserRow{
+			ID:             task.ID,
+			Title:          task.Title,
+			Status:         task.Status,
+			StatusColor:    task.StatusColor,
+			DueDate:        task.DueDate,
+			Priority:       task.Priority,
+			LastActivityAt: at,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].LastActivityAt.Time.Equal(rows[j].LastActivityAt.Time) {
+			return rows[i].LastActivityAt.Time.After(rows[j].LastActivityAt.Time)
+		}
+		return rows[i].ID > rows[j].ID
+	})
+	return page(rows, arg.RowLimit, 0), nil
+}
+
+func (f *Fake) GetTask(ctx context.Context, id int32) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.tasks, id)
+}
+
+func (f *Fake) ListTasks(ctx context.Context, arg sqlc.ListTasksParams) ([]sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// IDs grow with creation time, newest first
+	tasks := filter(f.tasks, nil, func(a, b sqlc.Task) bool { return a.ID > b.ID })
+	return page(tasks, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) UpdateTask(ctx context.Context, arg sqlc.UpdateTaskParams) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, err := get(f.tasks, arg.ID)
+	if err != nil {
+		return task, err
+	}
+	if stale(arg.ExpectedUpdatedAt, task.UpdatedAt) {
+		return sqlc.Task{}, pgx.ErrNoRows
+	}
+	task.Url = arg.Url
+	task.TaskCategoryID = arg.TaskCategoryID
+	task.Note = arg.Note
+	task.Title = arg.Title
+	task.Status = arg.Status
+	task.StatusColor = arg.StatusColor
+	task.DueDate = arg.DueDate
+	task.Priority = arg.Priority
+	task.UpdatedAt = now()
+	f.tasks[task.ID] = task
+	return task, nil
+}
+
+func (f *Fake) DeleteTask(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	softDelete(f.tasks, f.deletedTasks, id, func(task *sqlc.Task) *pgtype.Timestamptz { return &task.DeletedAt })
+	return nil
+}
+
+func (f *Fake) ListPurgeableTaskIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purgeable(f.deletedTasks, deletedBefore, func(task sqlc.Task) pgtype.Timestamptz { return task.DeletedAt }), nil
+}
+
+func (f *Fake) PurgeTask(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purge(f.deletedTasks, id), nil
+}
+
+func (f *Fake) RestoreTask(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return restore(f.tasks, f.deletedTasks, id, func(task *sqlc.Task) *pgtype.Timestamptz { return &task.DeletedAt }), nil
+}
+
+// FindDuplicateTask matches the same URL first, else a title equal after ignoring case, spaces and
+// punctuation, the oldest task first
+func (f *Fake) FindDuplicateTask(ctx context.Context, arg sqlc.FindDuplicateTaskParams) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	title := normalizeTitle(arg.Title)
+	matches := filter(f.tasks,
+		func(t sqlc.Task) bool {
+			return (arg.Url.Valid && t.Url.Valid && t.Url.String == arg.Url.String) || normalizeTitle(t.Title.String) == title
+		},
+		func(a, b sqlc.Task) bool {
+			if sameURL := a.Url == arg.Url; sameURL != (b.Url == arg.Url) {
+				return sameURL
+			}
+			return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+		},
+	)
+	if len(matches) == 0 {
+		return sqlc.Task{}, pgx.ErrNoRows
+	}
+	return matches[0], nil
+}
+
+// normalizeTitle drops spaces and punctuation and lowers the case, as FindDuplicateTask compares
+// titles
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, title)
+}
+
+func (f *Fake) ListTasksByCategory(ctx context.Context, taskCategoryID pgtype.Int4) ([]sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tasks,
+		func(t sqlc.Task) bool { return taskCategoryID.Valid && t.TaskCategoryID == taskCategoryID },
+		func(a, b sqlc.Task) bool { return a.CreatedAt.Time.After(b.CreatedAt.Time) },
+	), nil
+}
+
+func (f *Fake) ListTasksByCategoryWithSubcategories(ctx context.Context, id int32) ([]sqlc.ListTasksByCategoryWithSubcategoriesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subtree := f.categorySubtree(id, true)
+	rows := []sqlc.ListTasksByCategoryWithSubcategoriesRow{}
+	for _, task := range filter(f.tasks,
+		func(t sqlc.Task) bool { return t.TaskCategoryID.Valid && subtree[t.TaskCategoryID.Int32] },
+		func(a, b sqlc.Task) bool { return a.CreatedAt.Time.After(b.CreatedAt.Time) },
+	) {
+		rows = append(rows, sqlc.ListTasksByCategoryWithSubcategoriesRow{Task: task, CategoryName: f.taskCategories[task.TaskCategoryID.Int32].Name})
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListSubtasks(ctx context.Context, parentTaskID pgtype.Int4) ([]sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tasks,
+		func(t sqlc.Task) bool { return parentTaskID.Valid && t.ParentTaskID == parentTaskID },
+		func(a, b sqlc.Task) bool { return a.CreatedAt.Time.Before(b.CreatedAt.Time) },
+	), nil
+}
+
+// ListTaskAncestorIDs returns the task and the tasks above it, up to the top, soft deleted ones
+// included
+func (f *Fake) ListTaskAncestorIDs(ctx context.Context, id int32) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	ids := []int32{}
+	for task, ok := tasks[id]; ok && len(ids) <= len(tasks); task, ok = tasks[task.ParentTaskID.Int32] {
+		ids = append(ids, task.ID)
+		if !task.ParentTaskID.Valid {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func (f *Fake) SearchTasks(ctx context.Context, arg sqlc.SearchTasksParams) ([]sqlc.SearchTasksRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := filter(f.tasks, f.taskSearchMatches(sqlc.CountSearchTasksParams{
+		Status:      arg.Status,
+		CategoryID:  arg.CategoryID,
+		AssigneeID:  arg.AssigneeID,
+		TagID:       arg.TagID,
+		OverdueOnly: arg.OverdueOnly,
+		DueFrom:     arg.DueFrom,
+		DueTo:       arg.DueTo,
+	}), func(a, b sqlc.Task) bool {
+		switch arg.SortBy {
+		case "due_date":
+			if a.DueDate.Valid != b.DueDate.Valid {
+				return a.DueDate.Valid
+			}
+			if !a.DueDate.Time.Equal(b.DueDate.Time) {
+				return a.DueDate.Time.Before(b.DueDate.Time) != arg.SortDesc
+			}
+		case "priority":
+			if a.Priority.Valid != b.Priority.Valid {
+				return a.Priority.Valid
+			}
+			if a.Priority.Int32 != b.Priority.Int32 {
+				return (a.Priority.Int32 < b.Priority.Int32) != arg.SortDesc
+			}
+		case "title":
+			if a.Title.String != b.Title.String {
+				return (a.Title.String < b.Title.String) != arg.SortDesc
+			}
+		case "updated_at":
+			if !a.UpdatedAt.Time.Equal(b.UpdatedAt.Time) {
+				return a.UpdatedAt.Time.Before(b.UpdatedAt.Time) != arg.SortDesc
+			}
+		case "created_at":
+			if !arg.SortDesc && !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+			}
+		}
+		return a.CreatedAt.Time.After(b.CreatedAt.Time)
+	})
+
+	rows := []sqlc.SearchTasksRow{}
+	for _, task := range page(tasks, arg.RowLimit, arg.RowOffset) {
+		row := sqlc.SearchTasksRow{Task: task}
+		if category, ok := f.taskCategories[task.TaskCategoryID.Int32]; ok && task.TaskCategoryID.Valid {
+			row.CategoryName = pgtype.Text{String: category.Name, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (f *Fake) CountSearchTasks(ctx context.Context, arg sqlc.CountSearchTasksParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	matches := f.taskSearchMatches(arg)
+	for _, task := range f.tasks {
+		if matches(task) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// taskSearchMatches applies the filters of the task search. Callers must hold the lock.
+func (f *Fake) taskSearchMatches(arg sqlc.CountSearchTasksParams) func(sqlc.Task) bool {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return func(t sqlc.Task) bool {
+		if arg.Status.Valid && (!t.Status.Valid || !strings.EqualFold(t.Status.String, arg.Status.String)) {
+			return false
+		}
+		if arg.CategoryID.Valid && t.TaskCategoryID != arg.CategoryID {
+			return false
+		}
+		if arg.AssigneeID.Valid {
+			if _, ok := f.taskAssignees[taskUserKey{t.ID, arg.AssigneeID.Int32}]; !ok {
+				return false
+			}
+		}
+		if arg.TagID.Valid {
+			if _, ok := f.taskTags[taskTagKey{t.ID, arg.TagID.Int32}]; !ok {
+				return false
+			}
+		}
+		if arg.OverdueOnly {
+			done := slices.Contains([]string{"complete", "completed", "closed", "done"}, strings.ToLower(t.Status.String))
+			if !t.DueDate.Valid || !t.DueDate.Time.Before(today) || done {
+				return false
+			}
+		}
+		if arg.DueFrom.Valid && (!t.DueDate.Valid || t.DueDate.Time.Before(arg.DueFrom.Time)) {
+			return false
+		}
+		return !arg.DueTo.Valid || (t.DueDate.Valid && !t.DueDate.Time.After(arg.DueTo.Time))
+	}
+}
+
+func (f *Fake) SetTaskParent(ctx context.Context, arg sqlc.SetTaskParentParams) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, err := get(f.tasks, arg.ID)
+	if err != nil {
+		return task, err
+	}
+	task.ParentTaskID = arg.ParentTaskID
+	task.UpdatedAt = now()
+	f.tasks[task.ID] = task
+	return task, nil
+}
+
+// ClickUp links
+
+func (f *Fake) ListClickUpLinkedTasks(ctx context.Context) ([]sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tasks, clickUpLinked, func(a, b sqlc.Task) bool { return a.ID < b.ID }), nil
+}
+
+// ListClickUpLinkedTasksByTeam lists the linked tasks of the workspace, an empty team ID those
+// whose workspace is not known yet
+func (f *Fake) ListClickUpLinkedTasksByTeam(ctx context.Context, teamID string) ([]sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tasks, func(t sqlc.Task) bool {
+		return clickUpLinked(t) && t.ClickupTeamID.String == teamID
+	}, func(a, b sqlc.Task) bool { return a.ID < b.ID }), nil
+}
+
+func (f *Fake) MarkTaskClickUpSynced(ctx context.Context, arg sqlc.MarkTaskClickUpSyncedParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[arg.ID]
+	if !ok {
+		return nil
+	}
+	task.ClickupSyncedAt = syncedAt(arg.RemoteUpdatedAt)
+	if arg.TeamID.Valid {
+		task.ClickupTeamID = arg.TeamID
+	}
+	f.tasks[task.ID] = task
+	return nil
+}
+
+func (f *Fake) ApplyClickUpTaskChanges(ctx context.Context, arg sqlc.ApplyClickUpTaskChangesParams) (sqlc.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, err := get(f.tasks, arg.ID)
+	if err != nil {
+		return task, err
+	}
+	task.Title = arg.Title
+	task.Note = arg.Note
+	task.Status = arg.Status
+	task.StatusColor = arg.StatusColor
+	if arg.TeamID.Valid {
+		task.ClickupTeamID = arg.TeamID
+	}
+	task.UpdatedAt = now()
+	task.ClickupSyncedAt = syncedAt(arg.RemoteUpdatedAt)
+	f.tasks[task.ID] = task
+	return task, nil
+}
+
+// clickUpLinked reports whether the task links to a ClickUp task
+func clickUpLinked(t sqlc.Task) bool {
+	return strings.HasPrefix(t.Url.String, "https://app.clickup.com/t/")
+}
+
+// syncedAt is GREATEST(NOW(), remoteUpdatedAt), a NULL time being ignored
+func syncedAt(remoteUpdatedAt pgtype.Timestamptz) pgtype.Timestamptz {
+	at := now()
+	if remoteUpdatedAt.Valid && remoteUpdatedAt.Time.After(at.Time) {
+		at.Time = remoteUpdatedAt.Time
+	}
+	return at
+}
+
+// Task sync history
+
+func (f *Fake) CreateTaskSyncHistory(ctx context.Context, arg sqlc.CreateTaskSyncHistoryParams) (sqlc.TaskSyncHistory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry := sqlc.TaskSyncHistory{
+		ID:              f.newID(),
+		TaskID:          arg.TaskID,
+		Direction:       arg.Direction,
+		Status:          arg.Status,
+		ChangedFields:   arg.ChangedFields,
+		Message:         arg.Message,
+		RemoteUpdatedAt: arg.RemoteUpdatedAt,
+		CreatedAt:       now(),
+		TenantID:        db.DefaultTenantID,
+	}
+	f.taskSyncHistory[entry.ID] = entry
+	return entry, nil
+}
+
+func (f *Fake) ListTaskSyncHistory(ctx context.Context, arg sqlc.ListTaskSyncHistoryParams) ([]sqlc.TaskSyncHistory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := filter(f.taskSyncHistory,
+		func(h sqlc.TaskSyncHistory) bool { return h.TaskID == arg.TaskID },
+		func(a, b sqlc.TaskSyncHistory) bool {
+			if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Time.After(b.CreatedAt.Time)
+			}
+			return a.ID > b.ID
+		},
+	)
+	return page(history, arg.Limit, 0), nil
+}
+
+// GetClickUpSyncStats counts the linked tasks, soft deleted ones only for the last sync and the
+// errors, as the query does
+func (f *Fake) GetClickUpSyncStats(ctx context.Context, since pgtype.Timestamptz) (sqlc.GetClickUpSyncStatsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var row sqlc.GetClickUpSyncStatsRow
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	for _, t := range tasks {
+		if !clickUpLinked(t) {
+			continue
+		}
+		if t.ClickupSyncedAt.Valid && (!row.LastSyncedAt.Valid || t.ClickupSyncedAt.Time.After(row.LastSyncedAt.Time)) {
+			row.LastSyncedAt = t.ClickupSyncedAt
+		}
+		if !t.DeletedAt.Valid {
+			row.LinkedTasks++
+			if !t.ClickupSyncedAt.Valid || t.UpdatedAt.Time.After(t.ClickupSyncedAt.Time) {
+				row.PendingChanges++
+			}
+		}
+	}
+	for _, h := range f.taskSyncHistory {
+		if t, ok := tasks[h.TaskID]; !ok || !clickUpLinked(t) || h.Status != "error" {
+			continue
+		}
+		if !row.LastErrorAt.Valid || h.CreatedAt.Time.After(row.LastErrorAt.Time) {
+			row.LastErrorAt = h.CreatedAt
+		}
+		if !h.CreatedAt.Time.Before(since.Time) {
+			row.RecentErrors++
+		}
+	}
+	return row, nil
+}
+
+// Task assignees
+
+type taskUserKey struct {
+	taskID int32
+	userID int32
+}
+
+func (f *Fake) AssignTask(ctx context.Context, arg sqlc.AssignTaskParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := taskUserKey{arg.TaskID, arg.UserID}
+	if _, ok := f.taskAssignees[key]; !ok {
+		f.taskAssignees[key] = sqlc.TaskAssignee{
+			TaskID:           arg.TaskID,
+			UserID:           arg.UserID,
+			AssignedByUserID: arg.AssignedByUserID,
+			AssignedAt:       now(),
+		}
+	}
+	return nil
+}
+
+func (f *Fake) UnassignTask(ctx context.Context, arg sqlc.UnassignTaskParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := taskUserKey{arg.TaskID, arg.UserID}
+	if _, ok := f.taskAssignees[key]; !ok {
+		return 0, nil
+	}
+	delete(f.taskAssignees, key)
+	return 1, nil
+}
+
+func (f *Fake) IsTaskAssignee(ctx context.Context, arg sqlc.IsTaskAssigneeParams) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.taskAssignees[taskUserKey{arg.TaskID, arg.UserID}]
+	return ok, nil
+}
+
+func (f *Fake) ListTaskAssignees(ctx context.Context, taskID int32) ([]sqlc.ListTaskAssigneesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskAssigneesRow{}
+	for _, row := range f.taskAssigneeRows([]int32{taskID}) {
+		rows = append(rows, sqlc.ListTaskAssigneesRow(row))
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListTaskAssigneesByTaskIDs(ctx context.Context, taskIds []int32) ([]sqlc.ListTaskAssigneesByTaskIDsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.taskAssigneeRows(taskIds), nil
+}
+
+// taskAssigneeRows returns the assignees of the tasks with their usernames, by task and username.
+// Callers must hold the lock.
+func (f *Fake) taskAssigneeRows(taskIDs []int32) []sqlc.ListTaskAssigneesByTaskIDsRow {
+	rows := []sqlc.ListTaskAssigneesByTaskIDsRow{}
+	for key, assignee := range f.taskAssignees {
+		user, ok := f.users[key.userID]
+		if !ok {
+			user, ok = f.deletedUsers[key.userID]
+		}
+		if ok && slices.Contains(taskIDs, key.taskID) {
+			rows = append(rows, sqlc.ListTaskAssigneesByTaskIDsRow{
+				TaskID:     key.taskID,
+				UserID:     user.ID,
+				Username:   user.Username,
+				AssignedAt: assignee.AssignedAt,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TaskID != rows[j].TaskID {
+			return rows[i].TaskID < rows[j].TaskID
+		}
+		return rows[i].Username < rows[j].Username
+	})
+	return rows
+}
+
+// ListAssigneeCapacity sets the current estimates of each user's open tasks, split evenly between
+// co-assignees, against the working days of the period
+func (f *Fake) ListAssigneeCapacity(ctx context.Context, arg sqlc.ListAssigneeCapacityParams) ([]sqlc.ListAssigneeCapacityRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workingDays := f.workingDays(arg.StartDate, arg.EndDate)
+	leaveDays := f.leaveDays(workingDays)
+
+	coAssignees := map[int32]int64{}
+	for key := range f.taskAssignees {
+		coAssignees[key.taskID]++
+	}
+	openTasks := map[int32]int64{}
+	estimates := map[int32]*big.Rat{}
+	for key := range f.taskAssignees {
+		task, ok := f.tasks[key.taskID]
+		if !ok || slices.Contains([]string{"complete", "completed", "closed", "done"}, strings.ToLower(task.Status.String)) {
+			continue
+		}
+		if task.DueDate.Valid && task.DueDate.Time.After(arg.EndDate.Time) {
+			continue
+		}
+		for _, e := range f.taskEstimates {
+			if e.TaskID != key.taskID || !e.IsCurrent {
+				continue
+			}
+			share := new(big.Rat).Quo(decimal(e.EstimateDay), new(big.Rat).SetInt64(coAssignees[key.taskID]))
+			if estimates[key.userID] == nil {
+				estimates[key.userID] = new(big.Rat)
+			}
+			estimates[key.userID].Add(estimates[key.userID], share)
+			openTasks[key.userID]++
+		}
+	}
+
+	rows := []sqlc.ListAssigneeCapacityRow{}
+	for _, u := range filter(f.users,
+		func(u sqlc.User) bool {
+			return !arg.Department.Valid || f.inDepartment(u.Department, arg.Department.String)
+		},
+		func(a, b sqlc.User) bool { return a.Username < b.Username },
+	) {
+		estimate := estimates[u.ID]
+		if estimate == nil {
+			estimate = new(big.Rat)
+		}
+		rows = append(rows, sqlc.ListAssigneeCapacityRow{
+			UserID:          u.ID,
+			Username:        u.Username,
+			Department:      u.Department,
+			DailyCapacity:   u.DailyCapacity,
+			WorkingDayCount: int64(len(workingDays)),
+			LeaveDayCount:   leaveDays[u.ID],
+			OpenTaskCount:   openTasks[u.ID],
+			EstimateDay:     numeric(estimate),
+		})
+	}
+	return rows, nil
+}
+
+// workingDays returns the weekdays of the period that aren't holidays, as dates. Callers must hold
+// the lock.
+func (f *Fake) workingDays(start, end pgtype.Date) map[string]bool {
+	holidays := map[string]bool{}
+	for _, h := range f.holidays {
+		holidays[h.Date.Time.Format(time.DateOnly)] = true
+	}
+	days := map[string]bool{}
+	for day := start.Time; !day.After(end.Time); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday && !holidays[date] {
+			days[date] = true
+		}
+	}
+	return days
+}
+
+// leaveDays counts the days of each user's leave that fall on one of the working days. Callers must
+// hold the lock.
+func (f *Fake) leaveDays(workingDays map[string]bool) map[int32]int64 {
+	type userDay struct {
+		userID int32
+		date   string
+	}
+	seen := map[userDay]bool{}
+	counts := map[int32]int64{}
+	for _, l := range f.leaveLogs {
+		day := userDay{l.UserID, l.Date.Time.Format(time.DateOnly)}
+		if workingDays[day.date] && !seen[day] {
+			seen[day] = true
+			counts[l.UserID]++
+		}
+	}
+	return counts
+}
+
+// Task custom fields
+
+type taskFieldKey struct {
+	taskID   int32
+	fieldKey string
+}
+
+func (f *Fake) UpsertTaskCustomField(ctx context.Context, arg sqlc.UpsertTaskCustomFieldParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.taskCustomFields[taskFieldKey{arg.TaskID, arg.FieldKey}] = sqlc.TaskCustomField{
+		TaskID:    arg.TaskID,
+		FieldKey:  arg.FieldKey,
+		Value:     arg.Value,
+		UpdatedAt: now(),
+	}
+	return nil
+}
+
+func (f *Fake) DeleteTaskCustomField(ctx context.Context, arg sqlc.DeleteTaskCustomFieldParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.taskCustomFields, taskFieldKey{arg.TaskID, arg.FieldKey})
+	return nil
+}
+
+func (f *Fake) ListTaskCustomFieldsByTaskIDs(ctx context.Context, taskIds []int32) ([]sqlc.TaskCustomField, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fields := []sqlc.TaskCustomField{}
+	for key, field := range f.taskCustomFields {
+		if slices.Contains(taskIds, key.taskID) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].TaskID != fields[j].TaskID {
+			return fields[i].TaskID < fields[j].TaskID
+		}
+		return fields[i].FieldKey < fields[j].FieldKey
+	})
+	return fields, nil
+}
+
+// Task comments
+
+func (f *Fake) CreateTaskComment(ctx context.Context, arg sqlc.CreateTaskCommentParams) (sqlc.TaskComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	comment := sqlc.TaskComment{
+		ID:        f.newID(),
+		TaskID:    arg.TaskID,
+		UserID:    arg.UserID,
+		Body:      arg.Body,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+		TenantID:  db.DefaultTenantID,
+	}
+	f.taskComments[comment.ID] = comment
+	return comment, nil
+}
+
+func (f *Fake) GetTaskComment(ctx context.Context, id int32) (sqlc.TaskComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.taskComments, id)
+}
+
+func (f *Fake) ListTaskCommentsByTask(ctx context.Context, taskID int32) ([]sqlc.ListTaskCommentsByTaskRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskCommentsByTaskRow{}
+	for _, c := range filter(f.taskComments, func(c sqlc.TaskComment) bool { return c.TaskID == taskID }, oldestCommentFirst) {
+		user, err := get(withDeleted(f.users, f.deletedUsers), c.UserID)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, sqlc.ListTaskCommentsByTaskRow{
+			ID:        c.ID,
+			TaskID:    c.TaskID,
+			UserID:    c.UserID,
+			Body:      c.Body,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+			TenantID:  c.TenantID,
+			Username:  user.Username,
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) UpdateTaskComment(ctx context.Context, arg sqlc.UpdateTaskCommentParams) (sqlc.TaskComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	comment, err := get(f.taskComments, arg.ID)
+	if err != nil {
+		return comment, err
+	}
+	comment.Body = arg.Body
+	comment.UpdatedAt = now()
+	f.taskComments[comment.ID] = comment
+	return comment, nil
+}
+
+func (f *Fake) DeleteTaskComment(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.taskComments, id)
+	return nil
+}
+
+func oldestCommentFirst(a, b sqlc.TaskComment) bool {
+	if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+		return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+	}
+	return a.ID < b.ID
+}
+
+// Task estimates
+
+// CreateTaskEstimate adds the task's current estimate, callers supersede the previous one first
+func (f *Fake) CreateTaskEstimate(ctx context.Context, arg sqlc.CreateTaskEstimateParams) (sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	estimate := sqlc.TaskEstimate{
+		ID:              f.newID(),
+		TaskID:          arg.TaskID,
+		EstimateDay:     arg.EstimateDay,
+		Note:            arg.Note,
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		IsCurrent:       true,
+		SupersedesID:    arg.SupersedesID,
+		SessionID:       arg.SessionID,
+		Unit:            arg.Unit,
+		EstimateValue:   arg.EstimateValue,
+		Confidence:      arg.Confidence,
+		TenantID:        db.DefaultTenantID,
+	}
+	f.taskEstimates[estimate.ID] = estimate
+	return estimate, nil
+}
+
+func (f *Fake) GetTaskEstimate(ctx context.Context, id int32) (sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.taskEstimates, id)
+}
+
+func (f *Fake) GetCurrentTaskEstimate(ctx context.Context, taskID int32) (sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.currentEstimate(taskID)
+}
+
+// ListTaskEstimatesByTask lists the estimates of the task, newest first, leaving out the votes of
+// estimation sessions
+func (f *Fake) ListTaskEstimatesByTask(ctx context.Context, taskID int32) ([]sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskEstimates, func(e sqlc.TaskEstimate) bool { return e.TaskID == taskID && !e.IsVote }, newestEstimateFirst), nil
+}
+
+func (f *Fake) ListTaskEstimatesByUser(ctx context.Context, arg sqlc.ListTaskEstimatesByUserParams) ([]sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	estimates := filter(f.taskEstimates,
+		func(e sqlc.TaskEstimate) bool { return e.CreatedByUserID == arg.CreatedByUserID && !e.IsVote },
+		newestEstimateFirst,
+	)
+	return page(estimates, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) CountTaskEstimatesByUser(ctx context.Context, createdByUserID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, e := range f.taskEstimates {
+		if e.CreatedByUserID == createdByUserID && !e.IsVote {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetTaskEstimateRollup sums the current estimates of the task and every task under it, soft
+// deleted ones too
+func (f *Fake) GetTaskEstimateRollup(ctx context.Context, taskID int32) (sqlc.GetTaskEstimateRollupRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	subtasks := map[int32]bool{}
+	if _, ok := tasks[taskID]; ok {
+		subtasks[taskID] = true
+	}
+	// Add the children of the tasks found until a pass finds no more
+	for found := true; found; {
+		found = false
+		for _, t := range tasks {
+			if t.ParentTaskID.Valid && subtasks[t.ParentTaskID.Int32] && !subtasks[t.ID] {
+				subtasks[t.ID], found = true, true
+			}
+		}
+	}
+
+	row := sqlc.GetTaskEstimateRollupRow{}
+	total := new(big.Rat)
+	for _, e := range f.taskEstimates {
+		if e.IsCurrent && subtasks[e.TaskID] {
+			row.EstimatedTaskCount++
+			total.Add(total, decimal(e.EstimateDay))
+		}
+	}
+	row.EstimateDay = numeric(total)
+	return row, nil
+}
+
+// ListTaskEstimateVariance sets the current estimate of each task worked on in the period against
+// everything logged on it up to the end of the period
+func (f *Fake) ListTaskEstimateVariance(ctx context.Context, arg sqlc.ListTaskEstimateVarianceParams) ([]sqlc.ListTaskEstimateVarianceRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	users := withDeleted(f.users, f.deletedUsers)
+	rows := []sqlc.ListTaskEstimateVarianceRow{}
+	for _, e := range filter(f.taskEstimates,
+		func(e sqlc.TaskEstimate) bool { return e.IsCurrent },
+		func(a, b sqlc.TaskEstimate) bool {
+			if a.TaskID != b.TaskID {
+				return a.TaskID < b.TaskID
+			}
+			return a.ID < b.ID
+		},
+	) {
+		task, taskFound := tasks[e.TaskID]
+		user, userFound := users[e.CreatedByUserID]
+		if !taskFound || !userFound {
+			continue
+		}
+		logged := new(big.Rat)
+		workedInPeriod := false
+		for _, l := range f.taskLogs {
+			if l.TaskID != e.TaskID {
+				continue
+			}
+			if !l.WorkedDate.Time.After(arg.EndDate.Time) {
+				logged.Add(logged, decimal(l.WorkedDay))
+			}
+			workedInPeriod = workedInPeriod || between(l.WorkedDate, arg.StartDate, arg.EndDate)
+		}
+		if !workedInPeriod {
+			continue
+		}
+		rows = append(rows, sqlc.ListTaskEstimateVarianceRow{
+			TaskID:              task.ID,
+			Title:               task.Title,
+			Status:              task.Status,
+			EstimateID:          e.ID,
+			EstimateDay:         e.EstimateDay,
+			Unit:                e.Unit,
+			EstimateValue:       e.EstimateValue,
+			Confidence:          e.Confidence,
+			EstimatedByUserID:   user.ID,
+			EstimatedByUsername: user.Username,
+			LoggedDay:           numeric(logged),
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) UpdateTaskEstimate(ctx context.Context, arg sqlc.UpdateTaskEstimateParams) (sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	estimate, err := get(f.taskEstimates, arg.ID)
+	if err != nil {
+		return estimate, err
+	}
+	estimate.EstimateDay = arg.EstimateDay
+	estimate.Note = arg.Note
+	estimate.Unit = arg.Unit
+	estimate.EstimateValue = arg.EstimateValue
+	estimate.Confidence = arg.Confidence
+	f.taskEstimates[estimate.ID] = estimate
+	return estimate, nil
+}
+
+func (f *Fake) SupersedeTaskEstimate(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if estimate, ok := f.taskEstimates[id]; ok {
+		estimate.IsCurrent = false
+		estimate.SupersededAt = now()
+		f.taskEstimates[id] = estimate
+	}
+	return nil
+}
+
+func (f *Fake) RestoreTaskEstimate(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if estimate, ok := f.taskEstimates[id]; ok {
+		estimate.IsCurrent = true
+		estimate.SupersededAt = pgtype.Timestamptz{}
+		f.taskEstimates[id] = estimate
+	}
+	return nil
+}
+
+func (f *Fake) DeleteTaskEstimate(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.taskEstimates, id)
+	return nil
+}
+
+// currentEstimate returns the task's current estimate, callers must hold the lock
+func (f *Fake) currentEstimate(taskID int32) (sqlc.TaskEstimate, error) {
+	return first(f.taskEstimates, func(e sqlc.TaskEstimate) bool { return e.TaskID == taskID && e.IsCurrent })
+}
+
+func newestEstimateFirst(a, b sqlc.TaskEstimate) bool {
+	if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+		return a.CreatedAt.Time.After(b.CreatedAt.Time)
+	}
+	return a.ID > b.ID
+}
+
+// Estimation sessions
+
+type sessionUserKey struct {
+	sessionID int32
+	userID    int32
+}
+
+func (f *Fake) CreateEstimationSession(ctx context.Context, arg sqlc.CreateEstimationSessionParams) (sqlc.EstimationSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session := sqlc.EstimationSession{
+		ID:              f.newID(),
+		TaskID:          arg.TaskID,
+		Status:          "open",
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		TenantID:        db.DefaultTenantID,
+	}
+	f.sessions[session.ID] = session
+	return session, nil
+}
+
+func (f *Fake) GetEstimationSession(ctx context.Context, id int32) (sqlc.EstimationSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.sessions, id)
+}
+
+func (f *Fake) ListEstimationSessionsByTask(ctx context.Context, taskID int32) ([]sqlc.EstimationSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.sessions, func(s sqlc.EstimationSession) bool { return s.TaskID == taskID }, func(a, b sqlc.EstimationSession) bool {
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.After(b.CreatedAt.Time)
+		}
+		return a.ID > b.ID
+	}), nil
+}
+
+// ListEstimationSessionsAwaitingVote lists the open sessions the user takes part in without having
+// voted yet, the oldest first
+func (f *Fake) ListEstimationSessionsAwaitingVote(ctx context.Context, userID int32) ([]sqlc.ListEstimationSessionsAwaitingVoteRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	rows := []sqlc.ListEstimationSessionsAwaitingVoteRow{}
+	for _, s := range filter(f.sessions, func(s sqlc.EstimationSession) bool {
+		_, participating := f.participants[sessionUserKey{s.ID, userID}]
+		_, voted := f.vote(s.ID, userID)
+		return s.Status == "open" && participating && !voted
+	}, func(a, b sqlc.EstimationSession) bool {
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+		}
+		return a.ID < b.ID
+	}) {
+		task, ok := tasks[s.TaskID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, sqlc.ListEstimationSessionsAwaitingVoteRow{
+			ID:        s.ID,
+			TaskID:    s.TaskID,
+			TaskTitle: task.Title,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+// CloseEstimationSession closes an open session, and finds no row when it is closed already
+func (f *Fake) CloseEstimationSession(ctx context.Context, id int32) (sqlc.EstimationSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, ok := f.sessions[id]
+	if !ok || session.Status != "open" {
+		return sqlc.EstimationSession{}, pgx.ErrNoRows
+	}
+	session.Status = "closed"
+	session.ClosedAt = now()
+	f.sessions[id] = session
+	return session, nil
+}
+
+func (f *Fake) AddEstimationSessionParticipant(ctx context.Context, arg sqlc.AddEstimationSessionParticipantParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := sessionUserKey{arg.SessionID, arg.UserID}
+	if _, ok := f.participants[key]; !ok {
+		f.participants[key] = sqlc.EstimationSessionParticipant{SessionID: arg.SessionID, UserID: arg.UserID, TenantID: db.DefaultTenantID}
+	}
+	return nil
+}
+
+// ListEstimationSessionParticipants lists the participants by username, with their vote when they
+// have voted
+func (f *Fake) ListEstimationSessionParticipants(ctx context.Context, sessionID int32) ([]sqlc.ListEstimationSessionParticipantsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := withDeleted(f.users, f.deletedUsers)
+	rows := []sqlc.ListEstimationSessionParticipantsRow{}
+	for key := range f.participants {
+		user, ok := users[key.userID]
+		if key.sessionID != sessionID || !ok {
+			continue
+		}
+		row := sqlc.ListEstimationSessionParticipantsRow{UserID: user.ID, Username: user.Username}
+		if vote, ok := f.vote(sessionID, user.ID); ok {
+			row.EstimateID = pgtype.Int4{Int32: vote.ID, Valid: true}
+			row.EstimateDay = vote.EstimateDay
+			row.Note = vote.Note
+			row.VotedAt = vote.CreatedAt
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Username < rows[j].Username })
+	return rows, nil
+}
+
+// UpsertEstimationVote stores the participant's hidden vote in the session, or replaces it
+func (f *Fake) UpsertEstimationVote(ctx context.Context, arg sqlc.UpsertEstimationVoteParams) (sqlc.TaskEstimate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	vote, ok := f.vote(arg.SessionID.Int32, arg.CreatedByUserID)
+	if !ok || !arg.SessionID.Valid {
+		vote = sqlc.TaskEstimate{
+			ID:              f.newID(),
+			TaskID:          arg.TaskID,
+			CreatedByUserID: arg.CreatedByUserID,
+			SessionID:       arg.SessionID,
+			IsVote:          true,
+			Unit:            "days",
+			TenantID:        db.DefaultTenantID,
+		}
+	}
+	vote.EstimateDay = arg.EstimateDay
+	vote.Note = arg.Note
+	vote.CreatedAt = now()
+	f.taskEstimates[vote.ID] = vote
+	return vote, nil
+}
+
+// vote returns the user's vote in the session, callers must hold the lock
+func (f *Fake) vote(sessionID, userID int32) (sqlc.TaskEstimate, bool) {
+	vote, err := first(f.taskEstimates, func(e sqlc.TaskEstimate) bool {
+		return e.IsVote && e.SessionID.Valid && e.SessionID.Int32 == sessionID && e.CreatedByUserID == userID
+	})
+	return vote, err == nil
+}
+
+// Task categories
+
+func (f *Fake) CreateTaskCategory(ctx context.Context, arg sqlc.CreateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// New categories go after their existing siblings
+	sortOrder := int32(0)
+	for _, c := range f.taskCategories {
+		if c.ParentID == arg.ParentID {
+			sortOrder = max(sortOrder, c.SortOrder+1)
+		}
+	}
+	category := sqlc.TaskCategory{
+		ID:          f.newID(),
+		Name:        arg.Name,
+		ParentID:    arg.ParentID,
+		Description: arg.Description,
+		CreatedAt:   now(),
+		UpdatedAt:   now(),
+		BudgetDay:   arg.BudgetDay,
+		Department:  arg.Department,
+		SortOrder:   sortOrder,
+	}
+	f.taskCategories[category.ID] = category
+	return category, nil
+}
+
+func (f *Fake) GetTaskCategory(ctx context.Context, id int32) (sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.taskCategories, id)
+}
+
+func (f *Fake) ListTaskCategories(ctx context.Context, arg sqlc.ListTaskCategoriesParams) ([]sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	visible := f.visibleCategories(arg.AllDepartments, arg.Department)
+	categories := filter(f.taskCategories, func(c sqlc.TaskCategory) bool { return visible[c.ID] }, bySortOrder)
+	return page(categories, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) CountTaskCategories(ctx context.Context, arg sqlc.CountTaskCategoriesParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.visibleCategories(arg.AllDepartments, arg.Department))), nil
+}
+
+func (f *Fake) GetTaskCategoriesVersion(ctx context.Context) (sqlc.GetTaskCategoriesVersionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count, lastUpdated := version(f.taskCategories, func(c sqlc.TaskCategory) pgtype.Timestamptz { return c.UpdatedAt })
+	return sqlc.GetTaskCategoriesVersionRow{RowCount: count, LastUpdated: lastUpdated}, nil
+}
+
+func (f *Fake) ListTaskCategoriesByParent(ctx context.Context, parentID pgtype.Int4) ([]sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskCategories,
+		func(c sqlc.TaskCategory) bool { return parentID.Valid && c.ParentID == parentID && !c.ArchivedAt.Valid },
+		bySortOrder,
+	), nil
+}
+
+func (f *Fake) ListRootTaskCategories(ctx context.Context) ([]sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskCategories,
+		func(c sqlc.TaskCategory) bool { return !c.ParentID.Valid && !c.ArchivedAt.Valid },
+		bySortOrder,
+	), nil
+}
+
+// ListTaskCategoryTree lists the categories that aren't archived, under a parent that isn't either,
+// parents before children and siblings in sort order
+func (f *Fake) ListTaskCategoryTree(ctx context.Context, arg sqlc.ListTaskCategoryTreeParams) ([]sqlc.ListTaskCategoryTreeRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskCategoryTreeRow{}
+	var walk func(parentID pgtype.Int4, depth int32, path []string, department pgtype.Text)
+	walk = func(parentID pgtype.Int4, depth int32, path []string, department pgtype.Text) {
+		children := filter(f.taskCategories,
+			func(c sqlc.TaskCategory) bool { return c.ParentID == parentID && !c.ArchivedAt.Valid },
+			bySortOrder,
+		)
+		for _, c := range children {
+			// A cycle would never end, the depth can't pass the number of categories
+			if int(depth) > len(f.taskCategories) {
+				return
+			}
+			effective := c.Department
+			if !effective.Valid {
+				effective = department
+			}
+			childPath := append(slices.Clone(path), c.Name)
+			if arg.AllDepartments || !effective.Valid || (arg.Department.Valid && effective.String == arg.Department.String) {
+				rows = append(rows, sqlc.ListTaskCategoryTreeRow{
+					ID:          c.ID,
+					Name:        c.Name,
+					ParentID:    c.ParentID,
+					Description: c.Description,
+					CreatedAt:   c.CreatedAt,
+					UpdatedAt:   c.UpdatedAt,
+					Depth:       depth,
+					Path:        childPath,
+					Department:  effective,
+					SortOrder:   c.SortOrder,
+				})
+			}
+			walk(pgtype.Int4{Int32: c.ID, Valid: true}, depth+1, childPath, effective)
+		}
+	}
+	walk(pgtype.Int4{}, 0, nil, pgtype.Text{})
+	return rows, nil
+}
+
+// ListTaskCategoryEffort rolls the days logged in the period and the current estimates of the
+// tasks worked on in it up over each category's subtree
+func (f *Fake) ListTaskCategoryEffort(ctx context.Context, arg sqlc.ListTaskCategoryEffortParams) ([]sqlc.ListTaskCategoryEffortRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskCategoryEffortRow{}
+	for _, c := range filter(f.taskCategories,
+		func(c sqlc.TaskCategory) bool { return !c.ArchivedAt.Valid },
+		func(a, b sqlc.TaskCategory) bool { return a.Name < b.Name },
+	) {
+		subtree := f.categorySubtree(c.ID, false)
+		worked := new(big.Rat)
+		loggedTasks := map[int32]bool{}
+		for _, l := range f.taskLogs {
+			// Deleted tasks are left out, their logs stay in f.taskLogs
+			task, ok := f.tasks[l.TaskID]
+			if ok && task.TaskCategoryID.Valid && subtree[task.TaskCategoryID.Int32] && between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+				worked.Add(worked, decimal(l.WorkedDay))
+				loggedTasks[l.TaskID] = true
+			}
+		}
+		estimated := new(big.Rat)
+		for _, e := range f.taskEstimates {
+			if e.IsCurrent && loggedTasks[e.TaskID] {
+				estimated.Add(estimated, decimal(e.EstimateDay))
+			}
+		}
+		rows = append(rows, sqlc.ListTaskCategoryEffortRow{
+			ID:          c.ID,
+			Name:        c.Name,
+			ParentID:    c.ParentID,
+			BudgetDay:   c.BudgetDay,
+			TaskCount:   int64(len(loggedTasks)),
+			WorkedDay:   numeric(worked),
+			EstimateDay: numeric(estimated),
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) CountTaskCategoryUsage(ctx context.Context, categoryID int32) (sqlc.CountTaskCategoryUsageRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var row sqlc.CountTaskCategoryUsageRow
+	for _, c := range f.taskCategories {
+		if c.ParentID.Valid && c.ParentID.Int32 == categoryID {
+			row.ChildCount++
+		}
+	}
+	for _, t := range withDeleted(f.tasks, f.deletedTasks) {
+		if t.TaskCategoryID.Valid && t.TaskCategoryID.Int32 == categoryID {
+			row.TaskCount++
+		}
+	}
+	return row, nil
+}
+
+func (f *Fake) UpdateTaskCategory(ctx context.Context, arg sqlc.UpdateTaskCategoryParams) (sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	category, err := get(f.taskCategories, arg.ID)
+	if err != nil {
+		return category, err
+	}
+	category.Name = arg.Name
+	category.ParentID = arg.ParentID
+	if arg.Description.Valid {
+		category.Description = arg.Description
+	}
+	category.BudgetDay = arg.BudgetDay
+	category.Department = arg.Department
+	category.UpdatedAt = now()
+	f.taskCategories[category.ID] = category
+	return category, nil
+}
+
+// LockTaskCategories does nothing, the fake has no row locks
+func (f *Fake) LockTaskCategories(ctx context.Context) error {
+	return nil
+}
+
+func (f *Fake) MoveTaskCategory(ctx context.Context, arg sqlc.MoveTaskCategoryParams) (sqlc.TaskCategory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	category, err := get(f.taskCategories, arg.ID)
+	if err != nil {
+		return category, err
+	}
+	category.ParentID = arg.ParentID
+	category.UpdatedAt = now()
+	f.taskCategories[category.ID] = category
+	return category, nil
+}
+
+// ReorderTaskCategories sets the sort order of the categories to their position in the list
+func (f *Fake) ReorderTaskCategories(ctx context.Context, categoryIds []int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var updated int64
+	for position, id := range categoryIds {
+		category, ok := f.taskCategories[id]
+		if !ok {
+			continue
+		}
+		category.SortOrder = int32(position)
+		category.UpdatedAt = now()
+		f.taskCategories[id] = category
+		updated++
+	}
+	return updated, nil
+}
+
+func (f *Fake) ArchiveTaskCategorySubtree(ctx context.Context, categoryID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var archived int64
+	for id := range f.categorySubtree(categoryID, true) {
+		category := f.taskCategories[id]
+		if category.ArchivedAt.Valid {
+			continue
+		}
+		category.ArchivedAt = now()
+		category.UpdatedAt = now()
+		f.taskCategories[id] = category
+		archived++
+	}
+	return archived, nil
+}
+
+func (f *Fake) ReassignTaskCategoryChildren(ctx context.Context, arg sqlc.ReassignTaskCategoryChildrenParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var moved int64
+	for id, category := range f.taskCategories {
+		if category.ParentID.Valid && category.ParentID.Int32 == arg.CategoryID {
+			category.ParentID = pgtype.Int4{Int32: arg.TargetID, Valid: true}
+			category.UpdatedAt = now()
+			f.taskCategories[id] = category
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// ReassignTaskCategoryTasks moves the tasks of a category, soft deleted ones too, to another
+func (f *Fake) ReassignTaskCategoryTasks(ctx context.Context, arg sqlc.ReassignTaskCategoryTasksParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var moved int64
+	for _, tasks := range []map[int32]sqlc.Task{f.tasks, f.deletedTasks} {
+		for id, task := range tasks {
+			if task.TaskCategoryID.Valid && task.TaskCategoryID.Int32 == arg.CategoryID {
+				task.TaskCategoryID = pgtype.Int4{Int32: arg.TargetID, Valid: true}
+				task.UpdatedAt = now()
+				tasks[id] = task
+				moved++
+			}
+		}
+	}
+	return moved, nil
+}
+
+func (f *Fake) DeleteTaskCategory(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.taskCategories, id)
+	return nil
+}
+
+// categorySubtree returns the IDs of a category and those under it, archived ones only when
+// withArchived is set. Callers must hold the lock.
+func (f *Fake) categorySubtree(id int32, withArchived bool) map[int32]bool {
+	subtree := map[int32]bool{}
+	root, ok := f.taskCategories[id]
+	if !ok || (root.ArchivedAt.Valid && !withArchived) {
+		return subtree
+	}
+	subtree[id] = true
+	for grown := true; grown; {
+		grown = false
+		for _, c := range f.taskCategories {
+			if !subtree[c.ID] && c.ParentID.Valid && subtree[c.ParentID.Int32] && (withArchived || !c.ArchivedAt.Valid) {
+				subtree[c.ID] = true
+				grown = true
+			}
+		}
+	}
+	return subtree
+}
+
+// visibleCategories returns the IDs of the categories that aren't archived and are visible to the
+// department, a category taking the department of its nearest scoped ancestor. Callers must hold
+// the lock.
+func (f *Fake) visibleCategories(allDepartments bool, department pgtype.Text) map[int32]bool {
+	visible := map[int32]bool{}
+	for id, c := range f.taskCategories {
+		if c.ArchivedAt.Valid {
+			continue
+		}
+		effective := c.Department
+		for seen := 0; !effective.Valid && c.ParentID.Valid && seen <= len(f.taskCategories); seen++ {
+			parent, ok := f.taskCategories[c.ParentID.Int32]
+			if !ok {
+				break
+			}
+			c, effective = parent, parent.Department
+		}
+		if allDepartments || !effective.Valid || (department.Valid && effective.String == department.String) {
+			visible[id] = true
+		}
+	}
+	return visible
+}
+
+// bySortOrder orders sibling categories as the listings do, by sort order and then name
+func bySortOrder(a, b sqlc.TaskCategory) bool {
+	if a.SortOrder != b.SortOrder {
+		return a.SortOrder < b.SortOrder
+	}
+	return a.Name < b.Name
+}
+
+// Task activities
+
+func (f *Fake) CreateTaskActivity(ctx context.Context, arg sqlc.CreateTaskActivityParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	activity := sqlc.TaskActivity{
+		ID:           f.newID(),
+		TaskID:       arg.TaskID,
+		UserID:       arg.UserID,
+		ActivityType: arg.ActivityType,
+		OldValue:     arg.OldValue,
+		NewValue:     arg.NewValue,
+		ReferenceID:  arg.ReferenceID,
+		CreatedAt:    now(),
+		RequestID:    arg.RequestID,
+	}
+	f.taskActivities[activity.ID] = activity
+	return nil
+}
+
+func (f *Fake) CountTaskActivities(ctx context.Context, taskID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, a := range f.taskActivities {
+		if a.TaskID == taskID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListTaskActivities lists the newest first, with the body of the comment an activity added
+func (f *Fake) ListTaskActivities(ctx context.Context, arg sqlc.ListTaskActivitiesParams) ([]sqlc.ListTaskActivitiesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	activities := filter(f.taskActivities, func(a sqlc.TaskActivity) bool { return a.TaskID == arg.TaskID }, func(a, b sqlc.TaskActivity) bool {
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.After(b.CreatedAt.Time)
+		}
+		return a.ID > b.ID
+	})
+	rows := []sqlc.ListTaskActivitiesRow{}
+	for _, a := range page(activities, arg.Limit, arg.Offset) {
+		row := sqlc.ListTaskActivitiesRow{
+			ID:           a.ID,
+			TaskID:       a.TaskID,
+			UserID:       a.UserID,
+			ActivityType: a.ActivityType,
+			OldValue:     a.OldValue,
+			NewValue:     a.NewValue,
+			ReferenceID:  a.ReferenceID,
+			CreatedAt:    a.CreatedAt,
+			RequestID:    a.RequestID,
+		}
+		if a.UserID.Valid {
+			if name := f.username(a.UserID.Int32); name != "" {
+				row.Username = pgtype.Text{String: name, Valid: true}
+			}
+		}
+		if comment, ok := f.taskComments[a.ReferenceID.Int32]; ok && a.ActivityType == "comment_added" && a.ReferenceID.Valid {
+			row.CommentBody = pgtype.Text{String: comment.Body, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Task logs
+
+func (f *Fake) CreateTaskLog(ctx context.Context, arg sqlc.CreateTaskLogParams) (sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	taskLog := sqlc.TaskLog{
+		ID:              f.newID(),
+		TaskID:          arg.TaskID,
+		WorkedDay:       arg.WorkedDay,
+		CreatedByUserID: arg.CreatedByUserID,
+		WorkedDate:      arg.WorkedDate,
+		CreatedAt:       now(),
+		IsWorkOnHoliday: arg.IsWorkOnHoliday,
+	}
+	f.taskLogs[taskLog.ID] = taskLog
+	f.recordTaskLogBalance(taskLog)
+	return taskLog, nil
+}
+
+func (f *Fake) CreateTaskLogs(ctx context.Context, arg []sqlc.CreateTaskLogsParams) (int64, error) {
+	for _, row := range arg {
+		f.CreateTaskLog(ctx, sqlc.CreateTaskLogParams(row))
+	}
+	return int64(len(arg)), nil
+}
+
+func (f *Fake) GetTaskLog(ctx context.Context, id int32) (sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.taskLogs, id)
+}
+
+func (f *Fake) ListTaskLogsByTask(ctx context.Context, taskID int32) ([]sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return l.TaskID == taskID },
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	), nil
+}
+
+func (f *Fake) ListTaskLogsByUser(ctx context.Context, arg sqlc.ListTaskLogsByUserParams) ([]sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	taskLogs := filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return l.CreatedByUserID == arg.CreatedByUserID },
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	)
+	return page(taskLogs, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) ListTaskLogsByDateRange(ctx context.Context, arg sqlc.ListTaskLogsByDateRangeParams) ([]sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return between(l.WorkedDate, arg.WorkedDate, arg.WorkedDate_2) },
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	), nil
+}
+
+func (f *Fake) ListTaskLogsByUserAndDateRange(ctx context.Context, arg sqlc.ListTaskLogsByUserAndDateRangeParams) ([]sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool {
+			return l.CreatedByUserID == arg.CreatedByUserID && between(l.WorkedDate, arg.WorkedDate, arg.WorkedDate_2)
+		},
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	), nil
+}
+
+func (f *Fake) ListTimesheetEntries(ctx context.Context, arg sqlc.ListTimesheetEntriesParams) ([]sqlc.ListTimesheetEntriesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTimesheetEntriesRow{}
+	for _, l := range f.taskLogs {
+		user, ok := f.reportUser(l.CreatedByUserID, arg.UserID, arg.Department)
+		if !ok || !between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		task, ok := f.tasks[l.TaskID]
+		if !ok {
+			task = f.deletedTasks[l.TaskID]
+		}
+		rows = append(rows, sqlc.ListTimesheetEntriesRow{
+			ID:              l.ID,
+			WorkedDate:      l.WorkedDate,
+			UserID:          user.ID,
+			Username:        user.Username,
+			Department:      user.Department,
+			TaskID:          l.TaskID,
+			TaskTitle:       task.Title,
+			WorkedDay:       l.WorkedDay,
+			IsWorkOnHoliday: l.IsWorkOnHoliday,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Username != rows[j].Username {
+			return rows[i].Username < rows[j].Username
+		}
+		if rows[i].UserID != rows[j].UserID {
+			return rows[i].UserID < rows[j].UserID
+		}
+		if !rows[i].WorkedDate.Time.Equal(rows[j].WorkedDate.Time) {
+			return rows[i].WorkedDate.Time.Before(rows[j].WorkedDate.Time)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows, nil
+}
+
+func (f *Fake) ListUnloggedDays(ctx context.Context, arg sqlc.ListUnloggedDaysParams) ([]pgtype.Date, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[arg.UserID]
+	if !ok {
+		return []pgtype.Date{}, nil
+	}
+	busy := make(map[string]bool)
+	for _, h := range f.holidays {
+		busy[h.Date.Time.Format(time.DateOnly)] = true
+	}
+	for _, l := range f.taskLogs {
+		if l.CreatedByUserID == arg.UserID {
+			busy[l.WorkedDate.Time.Format(time.DateOnly)] = true
+		}
+	}
+	for _, l := range f.leaveLogs {
+		if l.UserID == arg.UserID {
+			busy[l.Date.Time.Format(time.DateOnly)] = true
+		}
+	}
+	joined := user.CreatedAt.Time.Format(time.DateOnly)
+
+	days := []pgtype.Date{}
+	for day := arg.EndDate.Time; !day.Before(arg.StartDate.Time); day = day.AddDate(0, 0, -1) {
+		date := day.Format(time.DateOnly)
+		if date < joined {
+			break
+		}
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday && !busy[date] {
+			days = append(days, pgtype.Date{Time: day, Valid: true})
+		}
+	}
+	return days, nil
+}
+
+func (f *Fake) SumTaskLogWorkedDaysForDate(ctx context.Context, arg sqlc.SumTaskLogWorkedDaysForDateParams) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := 0.0
+	for _, l := range f.taskLogs {
+		if l.CreatedByUserID != arg.UserID || !sameDate(l.WorkedDate, arg.WorkedDate) || l.ID == arg.ExcludeID {
+			continue
+		}
+		if days, err := l.WorkedDay.Float64Value(); err == nil && days.Valid {
+			total += days.Float64
+		}
+	}
+	return total, nil
+}
+
+func (f *Fake) UpdateTaskLog(ctx context.Context, arg sqlc.UpdateTaskLogParams) (sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	taskLog, err := get(f.taskLogs, arg.ID)
+	if err != nil {
+		return taskLog, err
+	}
+	changed := decimal(taskLog.WorkedDay).Cmp(decimal(arg.WorkedDay)) != 0 ||
+		!sameDate(taskLog.WorkedDate, arg.WorkedDate) || taskLog.IsWorkOnHoliday != arg.IsWorkOnHoliday
+	taskLog.WorkedDay = arg.WorkedDay
+	taskLog.WorkedDate = arg.WorkedDate
+	taskLog.IsWorkOnHoliday = arg.IsWorkOnHoliday
+	f.taskLogs[taskLog.ID] = taskLog
+	if changed {
+		f.retractBalance("task_logs", taskLog.ID)
+		f.recordTaskLogBalance(taskLog)
+	}
+	return taskLog, nil
+}
+
+func (f *Fake) DeleteTaskLog(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.taskLogs[id]; ok {
+		f.retractBalance("task_logs", id)
+	}
+	delete(f.taskLogs, id)
+	return nil
+}
+
+func (f *Fake) HasTaskLogs(ctx context.Context, taskID int32) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, err := first(f.taskLogs, func(l sqlc.TaskLog) bool { return l.TaskID == taskID })
+	return err == nil, nil
+}
+
+func (f *Fake) CountTaskLogsByUser(ctx context.Context, arg sqlc.CountTaskLogsByUserParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	matches := taskLogMatches(arg)
+	for _, l := range f.taskLogs {
+		if matches(l) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// taskLogMatches applies the filters the listings of a user's task logs share
+func taskLogMatches(arg sqlc.CountTaskLogsByUserParams) func(sqlc.TaskLog) bool {
+	return func(l sqlc.TaskLog) bool {
+		return l.CreatedByUserID == arg.UserID &&
+			(!arg.TaskID.Valid || l.TaskID == arg.TaskID.Int32) &&
+			(!arg.WorkedFrom.Valid || !l.WorkedDate.Time.Before(arg.WorkedFrom.Time)) &&
+			(!arg.WorkedTo.Valid || !l.WorkedDate.Time.After(arg.WorkedTo.Time))
+	}
+}
+
+// ListTaskLogDailyTotals sums the logs of the task and all its subtasks per day, with the running
+// total
+func (f *Fake) ListTaskLogDailyTotals(ctx context.Context, taskID int32) ([]sqlc.ListTaskLogDailyTotalsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	subtasks := map[int32]bool{}
+	if _, ok := tasks[taskID]; ok {
+		subtasks[taskID] = true
+	}
+	for grown := true; grown; {
+		grown = false
+		for _, t := range tasks {
+			if !subtasks[t.ID] && t.ParentTaskID.Valid && subtasks[t.ParentTaskID.Int32] {
+				subtasks[t.ID] = true
+				grown = true
+			}
+		}
+	}
+
+	worked := map[time.Time]*big.Rat{}
+	for _, l := range f.taskLogs {
+		if !subtasks[l.TaskID] {
+			continue
+		}
+		if worked[l.WorkedDate.Time] == nil {
+			worked[l.WorkedDate.Time] = new(big.Rat)
+		}
+		worked[l.WorkedDate.Time].Add(worked[l.WorkedDate.Time], decimal(l.WorkedDay))
+	}
+	rows := []sqlc.ListTaskLogDailyTotalsRow{}
+	cumulative := new(big.Rat)
+	for _, day := range slices.SortedFunc(maps.Keys(worked), time.Time.Compare) {
+		cumulative.Add(cumulative, worked[day])
+		rows = append(rows, sqlc.ListTaskLogDailyTotalsRow{
+			WorkedDate:    pgtype.Date{Time: day, Valid: true},
+			WorkedDay:     numeric(worked[day]),
+			CumulativeDay: numeric(cumulative),
+		})
+	}
+	return rows, nil
+}
+
+// ListTaskLogsWithDetailsByUser pages through a user's logs in sort_by order, latest worked date
+// first otherwise
+func (f *Fake) ListTaskLogsWithDetailsByUser(ctx context.Context, arg sqlc.ListTaskLogsWithDetailsByUserParams) ([]sqlc.ListTaskLogsWithDetailsByUserRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	taskLogs := filter(f.taskLogs,
+		taskLogMatches(sqlc.CountTaskLogsByUserParams{
+			UserID:     arg.UserID,
+			TaskID:     arg.TaskID,
+			WorkedFrom: arg.WorkedFrom,
+			WorkedTo:   arg.WorkedTo,
+		}),
+		func(a, b sqlc.TaskLog) bool {
+			switch {
+			case arg.SortBy == "worked_date" && !arg.SortDesc && !a.WorkedDate.Time.Equal(b.WorkedDate.Time):
+				return a.WorkedDate.Time.Before(b.WorkedDate.Time)
+			case arg.SortBy == "worked_day":
+				if c := decimal(a.WorkedDay).Cmp(decimal(b.WorkedDay)); c != 0 {
+					return (c < 0) != arg.SortDesc
+				}
+			case arg.SortBy == "created_at" && !a.CreatedAt.Time.Equal(b.CreatedAt.Time):
+				return a.CreatedAt.Time.Before(b.CreatedAt.Time) != arg.SortDesc
+			}
+			return a.WorkedDate.Time.After(b.WorkedDate.Time)
+		},
+	)
+
+	rows := []sqlc.ListTaskLogsWithDetailsByUserRow{}
+	for _, row := range f.taskLogDetails(page(taskLogs, arg.RowLimit, arg.RowOffset)) {
+		rows = append(rows, sqlc.ListTaskLogsWithDetailsByUserRow(row))
+	}
+	return rows, nil
+}
+
+// ListTaskLogsWithDetailsByUserAfter pages through a user's logs newest first, starting after the
+// (created_at, id) cursor when given
+func (f *Fake) ListTaskLogsWithDetailsByUserAfter(ctx context.Context, arg sqlc.ListTaskLogsWithDetailsByUserAfterParams) ([]sqlc.ListTaskLogsWithDetailsByUserAfterRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	matches := taskLogMatches(sqlc.CountTaskLogsByUserParams{
+		UserID:     arg.UserID,
+		TaskID:     arg.TaskID,
+		WorkedFrom: arg.WorkedFrom,
+		WorkedTo:   arg.WorkedTo,
+	})
+	newerFirst := func(a, b sqlc.TaskLog) bool {
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.After(b.CreatedAt.Time)
+		}
+		return a.ID > b.ID
+	}
+	cursor := sqlc.TaskLog{ID: arg.CursorID, CreatedAt: arg.CursorCreatedAt}
+	taskLogs := filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return matches(l) && (!arg.CursorCreatedAt.Valid || newerFirst(cursor, l)) },
+		newerFirst,
+	)
+
+	rows := []sqlc.ListTaskLogsWithDetailsByUserAfterRow{}
+	for _, row := range f.taskLogDetails(page(taskLogs, arg.RowLimit, 0)) {
+		rows = append(rows, sqlc.ListTaskLogsWithDetailsByUserAfterRow(row))
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListTaskLogsWithDetailsByTask(ctx context.Context, taskID int32) ([]sqlc.ListTaskLogsWithDetailsByTaskRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.taskLogDetails(filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return l.TaskID == taskID },
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	)), nil
+}
+
+func (f *Fake) ListTaskLogsWithDetailsByUserAndDateRange(ctx context.Context, arg sqlc.ListTaskLogsWithDetailsByUserAndDateRangeParams) ([]sqlc.ListTaskLogsWithDetailsByUserAndDateRangeRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskLogsWithDetailsByUserAndDateRangeRow{}
+	for _, row := range f.taskLogDetails(filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool {
+			if l.CreatedByUserID != arg.CreatedByUserID || !between(l.WorkedDate, arg.WorkedDate, arg.WorkedDate_2) {
+				return false
+			}
+			_, tagged := f.taskTags[taskTagKey{l.TaskID, arg.TagID.Int32}]
+			return !arg.TagID.Valid || tagged
+		},
+		byDate(func(l sqlc.TaskLog) pgtype.Date { return l.WorkedDate }, true),
+	)) {
+		rows = append(rows, sqlc.ListTaskLogsWithDetailsByUserAndDateRangeRow(row))
+	}
+	return rows, nil
+}
+
+// taskLogDetails joins logs with the titles of their tasks and the names of who logged them, soft
+// deleted ones included, in the order given. Callers must hold the lock.
+func (f *Fake) taskLogDetails(taskLogs []sqlc.TaskLog) []sqlc.ListTaskLogsWithDetailsByTaskRow {
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	users := withDeleted(f.users, f.deletedUsers)
+	rows := []sqlc.ListTaskLogsWithDetailsByTaskRow{}
+	for _, l := range taskLogs {
+		task, ok := tasks[l.TaskID]
+		user, found := users[l.CreatedByUserID]
+		if !ok || !found {
+			continue
+		}
+		rows = append(rows, sqlc.ListTaskLogsWithDetailsByTaskRow{
+			ID:              l.ID,
+			TaskID:          l.TaskID,
+			WorkedDay:       l.WorkedDay,
+			CreatedByUserID: l.CreatedByUserID,
+			WorkedDate:      l.WorkedDate,
+			CreatedAt:       l.CreatedAt,
+			IsWorkOnHoliday: l.IsWorkOnHoliday,
+			TenantID:        l.TenantID,
+			TaskTitle:       task.Title,
+			Username:        user.Username,
+		})
+	}
+	return rows
+}
+
+// ListUserUtilization sets the days each user logged in the period against the working days they
+// had, leave left out
+func (f *Fake) ListUserUtilization(ctx context.Context, arg sqlc.ListUserUtilizationParams) ([]sqlc.ListUserUtilizationRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workingDays := f.workingDays(arg.StartDate, arg.EndDate)
+	leaveDays := f.leaveDays(workingDays)
+	worked := map[int32]*big.Rat{}
+	for _, l := range f.taskLogs {
+		if !between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		if worked[l.CreatedByUserID] == nil {
+			worked[l.CreatedByUserID] = new(big.Rat)
+		}
+		worked[l.CreatedByUserID].Add(worked[l.CreatedByUserID], decimal(l.WorkedDay))
+	}
+
+	rows := []sqlc.ListUserUtilizationRow{}
+	for _, u := range filter(f.users, func(u sqlc.User) bool {
+		return (!arg.UserID.Valid || u.ID == arg.UserID.Int32) &&
+			(!arg.Department.Valid || f.inDepartment(u.Department, arg.Department.String))
+	}, func(a, b sqlc.User) bool { return a.Username < b.Username }) {
+		total := worked[u.ID]
+		if total == nil {
+			total = new(big.Rat)
+		}
+		rows = append(rows, sqlc.ListUserUtilizationRow{
+			UserID:          u.ID,
+			Username:        u.Username,
+			Department:      u.Department,
+			DailyCapacity:   u.DailyCapacity,
+			WorkingDayCount: int64(len(workingDays)),
+			LeaveDayCount:   leaveDays[u.ID],
+			WorkedDay:       numeric(total),
+		})
+	}
+	return rows, nil
+}
+
+// Leave logs
+
+func (f *Fake) CreateLeaveLog(ctx context.Context, arg sqlc.CreateLeaveLogParams) (sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLog := sqlc.LeaveLog{
+		ID:        f.newID(),
+		UserID:    arg.UserID,
+		Type:      arg.Type,
+		Date:      arg.Date,
+		Note:      arg.Note,
+		CreatedAt: now(),
+	}
+	leaveLog.UpdatedAt = leaveLog.CreatedAt
+	f.leaveLogs[leaveLog.ID] = leaveLog
+	f.recordLeaveLogBalance(leaveLog)
+	return leaveLog, nil
+}
+
+func (f *Fake) CreateLeaveLogs(ctx context.Context, arg []sqlc.CreateLeaveLogsParams) (int64, error) {
+	for _, row := range arg {
+		f.CreateLeaveLog(ctx, sqlc.CreateLeaveLogParams(row))
+	}
+	return int64(len(arg)), nil
+}
+
+func (f *Fake) GetLeaveLog(ctx context.Context, id int32) (sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.leaveLogs, id)
+}
+
+func (f *Fake) CountLeaveLogsForDate(ctx context.Context, arg sqlc.CountLeaveLogsForDateParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, l := range f.leaveLogs {
+		if l.UserID == arg.UserID && sameDate(l.Date, arg.Date) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *Fake) ListLeaveLogsByUser(ctx context.Context, arg sqlc.ListLeaveLogsByUserParams) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLogs := filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool { return l.UserID == arg.UserID },
+		byDate(func(l sqlc.LeaveLog) pgtype.Date { return l.Date }, true),
+	)
+	return page(leaveLogs, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) ListLeaveLogsByType(ctx context.Context, arg sqlc.ListLeaveLogsByTypeParams) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLogs := filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool { return l.UserID == arg.UserID && l.Type == arg.Type },
+		byDate(func(l sqlc.LeaveLog) pgtype.Date { return l.Date }, true),
+	)
+	return page(leaveLogs, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) ListLeaveLogsByDateRange(ctx context.Context, arg sqlc.ListLeaveLogsByDateRangeParams) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool { return l.UserID == arg.UserID && between(l.Date, arg.Date, arg.Date_2) },
+		byDate(func(l sqlc.LeaveLog) pgtype.Date { return l.Date }, true),
+	), nil
+}
+
+func (f *Fake) ListLeaveLogsWithUsername(ctx context.Context, arg sqlc.ListLeaveLogsWithUsernameParams) ([]sqlc.ListLeaveLogsWithUsernameRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLogs := filter(f.leaveLogs,
+		leaveLogMatches(sqlc.CountLeaveLogsParams{
+			UserID:   arg.UserID,
+			Type:     arg.Type,
+			Year:     arg.Year,
+			DateFrom: arg.DateFrom,
+			DateTo:   arg.DateTo,
+		}),
+		func(a, b sqlc.LeaveLog) bool {
+			switch {
+			case arg.SortBy == "created_at" && !a.CreatedAt.Time.Equal(b.CreatedAt.Time):
+				return a.CreatedAt.Time.Before(b.CreatedAt.Time) != arg.SortDesc
+			case arg.SortBy == "type" && a.Type != b.Type:
+				return (a.Type < b.Type) != arg.SortDesc
+			case arg.SortBy == "date" && !arg.SortDesc && !a.Date.Time.Equal(b.Date.Time):
+				return a.Date.Time.Before(b.Date.Time)
+			}
+			if !a.Date.Time.Equal(b.Date.Time) {
+				return a.Date.Time.After(b.Date.Time)
+			}
+			return a.ID > b.ID
+		},
+	)
+
+	rows := []sqlc.ListLeaveLogsWithUsernameRow{}
+	for _, l := range page(leaveLogs, arg.RowLimit, arg.RowOffset) {
+		rows = append(rows, sqlc.ListLeaveLogsWithUsernameRow{
+			ID:        l.ID,
+			UserID:    l.UserID,
+			Type:      l.Type,
+			Date:      l.Date,
+			Note:      l.Note,
+			CreatedAt: l.CreatedAt,
+			UpdatedAt: l.UpdatedAt,
+			Username:  f.username(l.UserID),
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) CountLeaveLogs(ctx context.Context, arg sqlc.CountLeaveLogsParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	matches := leaveLogMatches(arg)
+	for _, l := range f.leaveLogs {
+		if matches(l) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// leaveLogMatches applies the filters the leave log listings share
+func leaveLogMatches(arg sqlc.CountLeaveLogsParams) func(sqlc.LeaveLog) bool {
+	return func(l sqlc.LeaveLog) bool {
+		return (!arg.UserID.Valid || l.UserID == arg.UserID.Int32) &&
+			(!arg.Type.Valid || l.Type == arg.Type.String) &&
+			(!arg.Year.Valid || l.Date.Time.Year() == int(arg.Year.Int32)) &&
+			(!arg.DateFrom.Valid || !l.Date.Time.Before(arg.DateFrom.Time)) &&
+			(!arg.DateTo.Valid || !l.Date.Time.After(arg.DateTo.Time))
+	}
+}
+
+func (f *Fake) SummarizeLeaveLogs(ctx context.Context, arg sqlc.SummarizeLeaveLogsParams) ([]sqlc.SummarizeLeaveLogsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type key struct {
+		userID    int32
+		leaveType string
+	}
+	counts := make(map[key]int64)
+	for _, l := range f.leaveLogs {
+		if l.Date.Time.Year() == int(arg.Year) && (!arg.UserID.Valid || l.UserID == arg.UserID.Int32) {
+			counts[key{l.UserID, l.Type}]++
+		}
+	}
+
+	rows := []sqlc.SummarizeLeaveLogsRow{}
+	for k, count := range counts {
+		rows = append(rows, sqlc.SummarizeLeaveLogsRow{UserID: k.userID, Username: f.username(k.userID), Type: k.leaveType, DayCount: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Username != rows[j].Username {
+			return rows[i].Username < rows[j].Username
+		}
+		if rows[i].UserID != rows[j].UserID {
+			return rows[i].UserID < rows[j].UserID
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows, nil
+}
+
+func (f *Fake) SummarizeLeaveLogsByDateRange(ctx context.Context, arg sqlc.SummarizeLeaveLogsByDateRangeParams) ([]sqlc.SummarizeLeaveLogsByDateRangeRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type key struct {
+		userID    int32
+		leaveType string
+	}
+	counts := make(map[key]int64)
+	for _, l := range f.leaveLogs {
+		if _, ok := f.reportUser(l.UserID, arg.UserID, arg.Department); ok && between(l.Date, arg.StartDate, arg.EndDate) {
+			counts[key{l.UserID, l.Type}]++
+		}
+	}
+
+	rows := []sqlc.SummarizeLeaveLogsByDateRangeRow{}
+	for k, count := range counts {
+		user, _ := f.reportUser(k.userID, pgtype.Int4{}, pgtype.Text{})
+		rows = append(rows, sqlc.SummarizeLeaveLogsByDateRangeRow{UserID: k.userID, Username: user.Username, Department: user.Department, Type: k.leaveType, DayCount: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Username != rows[j].Username {
+			return rows[i].Username < rows[j].Username
+		}
+		if rows[i].UserID != rows[j].UserID {
+			return rows[i].UserID < rows[j].UserID
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows, nil
+}
+
+// reportUser returns a live or soft deleted user, as a join on users would, and whether a report
+// narrowed to a user or a department, and those under it, covers them
+func (f *Fake) reportUser(id int32, userID pgtype.Int4, department pgtype.Text) (sqlc.User, bool) {
+	user, ok := f.users[id]
+	if !ok {
+		user = f.deletedUsers[id]
+	}
+	if userID.Valid && id != userID.Int32 {
+		return user, false
+	}
+	if department.Valid && !f.inDepartment(user.Department, department.String) {
+		return user, false
+	}
+	return user, true
+}
+
+// username returns the name of a live or soft deleted user, as a join on users would
+func (f *Fake) username(id int32) string {
+	if user, ok := f.users[id]; ok {
+		return user.Username
+	}
+	return f.deletedUsers[id].Username
+}
+
+func (f *Fake) UpdateLeaveLog(ctx context.Context, arg sqlc.UpdateLeaveLogParams) (sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLog, err := get(f.leaveLogs, arg.ID)
+	if err != nil {
+		return leaveLog, err
+	}
+	if stale(arg.ExpectedUpdatedAt, leaveLog.UpdatedAt) {
+		return sqlc.LeaveLog{}, pgx.ErrNoRows
+	}
+	changed := leaveLog.Type != arg.Type || !sameDate(leaveLog.Date, arg.Date)
+	leaveLog.Type = arg.Type
+	leaveLog.Date = arg.Date
+	leaveLog.Note = arg.Note
+	leaveLog.UpdatedAt = now()
+	f.leaveLogs[leaveLog.ID] = leaveLog
+	if changed {
+		f.retractBalance("leave_logs", leaveLog.ID)
+		f.recordLeaveLogBalance(leaveLog)
+	}
+	return leaveLog, nil
+}
+
+func (f *Fake) DeleteLeaveLog(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.leaveLogs[id]; ok {
+		f.retractBalance("leave_logs", id)
+	}
+	softDelete(f.leaveLogs, f.deletedLeaveLogs, id, func(l *sqlc.LeaveLog) *pgtype.Timestamptz { return &l.DeletedAt })
+	return nil
+}
+
+func (f *Fake) ListPurgeableLeaveLogIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purgeable(f.deletedLeaveLogs, deletedBefore, func(l sqlc.LeaveLog) pgtype.Timestamptz { return l.DeletedAt }), nil
+}
+
+func (f *Fake) PurgeLeaveLog(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purge(f.deletedLeaveLogs, id), nil
+}
+
+func (f *Fake) RestoreLeaveLog(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return restore(f.leaveLogs, f.deletedLeaveLogs, id, func(leaveLog *sqlc.LeaveLog) *pgtype.Timestamptz { return &leaveLog.DeletedAt }), nil
+}
+
+// ListLeaveLogsAfter pages through the leave logs newest first, starting after the cursor when
+// one is given
+func (f *Fake) ListLeaveLogsAfter(ctx context.Context, arg sqlc.ListLeaveLogsAfterParams) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	matches := leaveLogMatches(sqlc.CountLeaveLogsParams{
+		UserID:   arg.UserID,
+		Type:     arg.Type,
+		Year:     arg.Year,
+		DateFrom: arg.DateFrom,
+		DateTo:   arg.DateTo,
+	})
+	newerFirst := func(a, b sqlc.LeaveLog) bool {
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.After(b.CreatedAt.Time)
+		}
+		return a.ID > b.ID
+	}
+	cursor := sqlc.LeaveLog{ID: arg.CursorID, CreatedAt: arg.CursorCreatedAt}
+	leaveLogs := filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool { return matches(l) && (!arg.CursorCreatedAt.Valid || newerFirst(cursor, l)) },
+		newerFirst,
+	)
+	return page(leaveLogs, arg.RowLimit, 0), nil
+}
+
+// ListLeaveLogsByYear lists the user's leave in the year of arg.Date, latest first
+func (f *Fake) ListLeaveLogsByYear(ctx context.Context, arg sqlc.ListLeaveLogsByYearParams) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool {
+			return l.UserID == arg.UserID && l.Date.Time.Year() == arg.Date.Time.Year()
+		},
+		byDate(func(l sqlc.LeaveLog) pgtype.Date { return l.Date }, true),
+	), nil
+}
+
+// Medical expenses
+
+func (f *Fake) CreateMedicalExpense(ctx context.Context, arg sqlc.CreateMedicalExpenseParams) (sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expense := sqlc.MedicalExpense{
+		ID:          f.newID(),
+		UserID:      arg.UserID,
+		Amount:      arg.Amount,
+		ReceiptName: arg.ReceiptName,
+		ReceiptDate: arg.ReceiptDate,
+		Note:        arg.Note,
+		CreatedAt:   now(),
+	}
+	f.medicalExpenses[expense.ID] = expense
+	f.recordMedicalExpenseBalance(expense)
+	return expense, nil
+}
+
+func (f *Fake) GetMedicalExpense(ctx context.Context, id int32) (sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.medicalExpenses, id)
+}
+
+func (f *Fake) ListMedicalExpensesByUser(ctx context.Context, arg sqlc.ListMedicalExpensesByUserParams) ([]sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expenses := filter(f.medicalExpenses,
+		func(e sqlc.MedicalExpense) bool { return e.UserID == arg.UserID },
+		byDate(func(e sqlc.MedicalExpense) pgtype.Date { return e.ReceiptDate }, true),
+	)
+	return page(expenses, arg.Limit, arg.Offset), nil
+}
+
+func (f *Fake) CountMedicalExpensesByUser(ctx context.Context, userID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, e := range f.medicalExpenses {
+		if e.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *Fake) ListMedicalExpensesByYear(ctx context.Context, arg sqlc.ListMedicalExpensesByYearParams) ([]sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.medicalExpenses,
+		func(e sqlc.MedicalExpense) bool {
+			return e.UserID == arg.UserID && e.ReceiptDate.Valid && int32(e.ReceiptDate.Time.Year()) == arg.Year
+		},
+		byDate(func(e sqlc.MedicalExpense) pgtype.Date { return e.ReceiptDate }, true),
+	), nil
+}
+
+func (f *Fake) ListMedicalExpensesByDateRange(ctx context.Context, arg sqlc.ListMedicalExpensesByDateRangeParams) ([]sqlc.ListMedicalExpensesByDateRangeRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListMedicalExpensesByDateRangeRow{}
+	for _, e := range f.medicalExpenses {
+		user, ok := f.reportUser(e.UserID, arg.UserID, arg.Department)
+		if !ok || !between(e.ReceiptDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		rows = append(rows, sqlc.ListMedicalExpensesByDateRangeRow{
+			ID:          e.ID,
+			ReceiptDate: e.ReceiptDate,
+			UserID:      e.UserID,
+			Username:    user.Username,
+			Department:  user.Department,
+			ReceiptName: e.ReceiptName,
+			Amount:      e.Amount,
+			Note:        e.Note,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Username != rows[j].Username {
+			return rows[i].Username < rows[j].Username
+		}
+		if rows[i].UserID != rows[j].UserID {
+			return rows[i].UserID < rows[j].UserID
+		}
+		if !rows[i].ReceiptDate.Time.Equal(rows[j].ReceiptDate.Time) {
+			return rows[i].ReceiptDate.Time.Before(rows[j].ReceiptDate.Time)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows, nil
+}
+
+func (f *Fake) UpdateMedicalExpense(ctx context.Context, arg sqlc.UpdateMedicalExpenseParams) (sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expense, err := get(f.medicalExpenses, arg.ID)
+	if err != nil {
+		return expense, err
+	}
+	changed := decimal(expense.Amount).Cmp(decimal(arg.Amount)) != 0 || expense.ReceiptDate != arg.ReceiptDate
+	expense.Amount = arg.Amount
+	expense.ReceiptName = arg.ReceiptName
+	expense.ReceiptDate = arg.ReceiptDate
+	expense.Note = arg.Note
+	f.medicalExpenses[expense.ID] = expense
+	if changed {
+		f.retractBalance("medical_expenses", expense.ID)
+		f.recordMedicalExpenseBalance(expense)
+	}
+	return expense, nil
+}
+
+func (f *Fake) DeleteMedicalExpense(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.medicalExpenses[id]; ok {
+		f.retractBalance("medical_expenses", id)
+	}
+	softDelete(f.medicalExpenses, f.deletedMedicalExpenses, id, func(e *sqlc.MedicalExpense) *pgtype.Timestamptz { return &e.DeletedAt })
+	return nil
+}
+
+func (f *Fake) ListPurgeableMedicalExpenseIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purgeable(f.deletedMedicalExpenses, deletedBefore, func(e sqlc.MedicalExpense) pgtype.Timestamptz { return e.DeletedAt }), nil
+}
+
+func (f *Fake) PurgeMedicalExpense(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return purge(f.deletedMedicalExpenses, id), nil
+}
+
+func (f *Fake) RestoreMedicalExpense(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return restore(f.medicalExpenses, f.deletedMedicalExpenses, id, func(expense *sqlc.MedicalExpense) *pgtype.Timestamptz { return &expense.DeletedAt }), nil
+}
+
+// Tags
+
+func (f *Fake) CreateTag(ctx context.Context, arg sqlc.CreateTagParams) (sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tag := sqlc.Tag{
+		ID:        f.newID(),
+		Name:      arg.Name,
+		Color:     arg.Color,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	f.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (f *Fake) GetTag(ctx context.Context, id int32) (sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.tags, id)
+}
+
+func (f *Fake) ListTags(ctx context.Context) ([]sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tags, nil, func(a, b sqlc.Tag) bool { return a.Name < b.Name }), nil
+}
+
+func (f *Fake) UpdateTag(ctx context.Context, arg sqlc.UpdateTagParams) (sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tag, err := get(f.tags, arg.ID)
+	if err != nil {
+		return tag, err
+	}
+	tag.Name = arg.Name
+	tag.Color = arg.Color
+	tag.UpdatedAt = now()
+	f.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (f *Fake) DeleteTag(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.tags, id)
+	for key := range f.taskTags {
+		if key.tagID == id {
+			delete(f.taskTags, key)
+		}
+	}
+	return nil
+}
+
+// UpsertTagByName returns the tag with the name, creating it with the color when there is none
+func (f *Fake) UpsertTagByName(ctx context.Context, arg sqlc.UpsertTagByNameParams) (sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if tag, err := first(f.tags, func(t sqlc.Tag) bool { return t.Name == arg.Name }); err == nil {
+		return tag, nil
+	}
+	tag := sqlc.Tag{
+		ID:        f.newID(),
+		Name:      arg.Name,
+		Color:     arg.Color,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	f.tags[tag.ID] = tag
+	return tag, nil
+}
+
+type taskTagKey struct {
+	taskID int32
+	tagID  int32
+}
+
+func (f *Fake) AddTaskTag(ctx context.Context, arg sqlc.AddTaskTagParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := taskTagKey{arg.TaskID, arg.TagID}
+	if _, ok := f.taskTags[key]; !ok {
+		f.taskTags[key] = sqlc.TaskTag{TaskID: arg.TaskID, TagID: arg.TagID, CreatedAt: now()}
+	}
+	return nil
+}
+
+func (f *Fake) RemoveTaskTag(ctx context.Context, arg sqlc.RemoveTaskTagParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := taskTagKey{arg.TaskID, arg.TagID}
+	if _, ok := f.taskTags[key]; !ok {
+		return 0, nil
+	}
+	delete(f.taskTags, key)
+	return 1, nil
+}
+
+func (f *Fake) ListTaskTags(ctx context.Context, taskID int32) ([]sqlc.Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tags,
+		func(t sqlc.Tag) bool {
+			_, ok := f.taskTags[taskTagKey{taskID, t.ID}]
+			return ok
+		},
+		func(a, b sqlc.Tag) bool { return a.Name < b.Name },
+	), nil
+}
+
+func (f *Fake) ListTaskTagsByTaskIDs(ctx context.Context, taskIds []int32) ([]sqlc.ListTaskTagsByTaskIDsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTaskTagsByTaskIDsRow{}
+	for key := range f.taskTags {
+		tag, ok := f.tags[key.tagID]
+		if ok && slices.Contains(taskIds, key.taskID) {
+			rows = append(rows, sqlc.ListTaskTagsByTaskIDsRow{TaskID: key.taskID, ID: tag.ID, Name: tag.Name, Color: tag.Color})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TaskID != rows[j].TaskID {
+			return rows[i].TaskID < rows[j].TaskID
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows, nil
+}
+
+// ListTagWorkedDays sums the days logged in the period on the tasks of each tag, leaving out tags
+// with none, most worked first
+func (f *Fake) ListTagWorkedDays(ctx context.Context, arg sqlc.ListTagWorkedDaysParams) ([]sqlc.ListTagWorkedDaysRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListTagWorkedDaysRow{}
+	worked := map[int32]*big.Rat{}
+	for _, tag := range f.tags {
+		total := new(big.Rat)
+		tasks := map[int32]bool{}
+		for _, l := range f.taskLogs {
+			if _, tagged := f.taskTags[taskTagKey{l.TaskID, tag.ID}]; tagged && between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+				total.Add(total, decimal(l.WorkedDay))
+				tasks[l.TaskID] = true
+			}
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		worked[tag.ID] = total
+		rows = append(rows, sqlc.ListTagWorkedDaysRow{
+			ID:        tag.ID,
+			Name:      tag.Name,
+			Color:     tag.Color,
+			TaskCount: int64(len(tasks)),
+			WorkedDay: numeric(total),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if c := worked[rows[i].ID].Cmp(worked[rows[j].ID]); c != 0 {
+			return c > 0
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows, nil
+}
+
+// Idempotency keys
+
+func (f *Fake) ClaimIdempotencyKey(ctx context.Context, arg sqlc.ClaimIdempotencyKeyParams) (sqlc.IdempotencyKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, err := first(f.idempotencyKeys, func(key sqlc.IdempotencyKey) bool {
+		return key.UserID == arg.UserID && key.Key == arg.Key
+	})
+	if err == nil && !key.CreatedAt.Time.Before(arg.ExpiredBefore.Time) {
+		return sqlc.IdempotencyKey{}, pgx.ErrNoRows
+	}
+	if err != nil {
+		key = sqlc.IdempotencyKey{ID: f.newID(), UserID: arg.UserID, Key: arg.Key}
+	}
+	key.RequestHash = arg.RequestHash
+	key.StatusCode, key.ContentType, key.ResponseBody = pgtype.Int4{}, pgtype.Text{}, nil
+	key.CreatedAt = now()
+	f.idempotencyKeys[key.ID] = key
+	return key, nil
+}
+
+func (f *Fake) GetIdempotencyKey(ctx context.Context, arg sqlc.GetIdempotencyKeyParams) (sqlc.IdempotencyKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.idempotencyKeys, func(key sqlc.IdempotencyKey) bool {
+		return key.UserID == arg.UserID && key.Key == arg.Key
+	})
+}
+
+func (f *Fake) CompleteIdempotencyKey(ctx context.Context, arg sqlc.CompleteIdempotencyKeyParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, ok := f.idempotencyKeys[arg.ID]
+	if !ok {
+		return nil
+	}
+	key.StatusCode, key.ContentType, key.ResponseBody = arg.StatusCode, arg.ContentType, arg.ResponseBody
+	f.idempotencyKeys[key.ID] = key
+	return nil
+}
+
+func (f *Fake) DeleteIdempotencyKey(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.idempotencyKeys, id)
+	return nil
+}
+
+func (f *Fake) DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for id, key := range f.idempotencyKeys {
+		if key.CreatedAt.Time.Before(createdAt.Time) {
+			delete(f.idempotencyKeys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Scheduled jobs
+
+func (f *Fake) RegisterScheduledJob(ctx context.Context, arg sqlc.RegisterScheduledJobParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[arg.Name]
+	if !ok {
+		job = sqlc.ScheduledJob{Name: arg.Name, NextRunAt: arg.NextRunAt}
+	} else if job.Schedule == arg.Schedule {
+		return nil
+	} else if arg.NextRunAt.Time.Before(job.NextRunAt.Time) {
+		job.NextRunAt = arg.NextRunAt
+	}
+	job.Schedule = arg.Schedule
+	job.UpdatedAt = now()
+	f.scheduledJobs[job.Name] = job
+	return nil
+}
+
+func (f *Fake) ListScheduledJobs(ctx context.Context) ([]sqlc.ScheduledJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs := []sqlc.ScheduledJob{}
+	for _, job := range f.scheduledJobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+func (f *Fake) GetScheduledJob(ctx context.Context, name string) (sqlc.ScheduledJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[name]
+	if !ok {
+		return job, pgx.ErrNoRows
+	}
+	return job, nil
+}
+
+func (f *Fake) ClaimScheduledJob(ctx context.Context, arg sqlc.ClaimScheduledJobParams) (sqlc.ScheduledJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[arg.Name]
+	if !ok || job.Paused || job.NextRunAt.Time.After(time.Now()) {
+		return sqlc.ScheduledJob{}, pgx.ErrNoRows
+	}
+	job.NextRunAt, job.LastRunBy = arg.NextRunAt, arg.RunBy
+	job.LastStartedAt, job.UpdatedAt = now(), now()
+	f.scheduledJobs[job.Name] = job
+	return job, nil
+}
+
+func (f *Fake) FinishScheduledJob(ctx context.Context, arg sqlc.FinishScheduledJobParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[arg.Name]
+	if !ok {
+		return nil
+	}
+	job.LastError = arg.LastError
+	job.LastFinishedAt, job.UpdatedAt = now(), now()
+	f.scheduledJobs[job.Name] = job
+	return nil
+}
+
+func (f *Fake) SetScheduledJobPaused(ctx context.Context, arg sqlc.SetScheduledJobPausedParams) (sqlc.ScheduledJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[arg.Name]
+	if !ok {
+		return job, pgx.ErrNoRows
+	}
+	job.Paused = arg.Paused
+	if arg.NextRunAt.Valid {
+		job.NextRunAt = arg.NextRunAt
+	}
+	job.UpdatedAt = now()
+	f.scheduledJobs[job.Name] = job
+	return job, nil
+}
+
+func (f *Fake) TriggerScheduledJob(ctx context.Context, name string) (sqlc.ScheduledJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.scheduledJobs[name]
+	if !ok {
+		return job, pgx.ErrNoRows
+	}
+	job.NextRunAt, job.UpdatedAt = now(), now()
+	f.scheduledJobs[name] = job
+	return job, nil
+}
+
+// Queued jobs
+
+func (f *Fake) EnqueueJob(ctx context.Context, arg sqlc.EnqueueJobParams) (sqlc.QueuedJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	runAt := arg.RunAt
+	if !runAt.Valid {
+		runAt = now()
+	}
+	job := sqlc.QueuedJob{
+		ID:          f.newID(),
+		Kind:        arg.Kind,
+		Payload:     arg.Payload,
+		Status:      "pending",
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now(),
+		UpdatedAt:   now(),
+		RequestID:   arg.RequestID,
+		TenantID:    db.DefaultTenantID,
+	}
+	f.queuedJobs[job.ID] = job
+	return job, nil
+}
+
+func (f *Fake) ClaimQueuedJobs(ctx context.Context, arg sqlc.ClaimQueuedJobsParams) ([]sqlc.QueuedJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := time.Now()
+	due := filter(f.queuedJobs, func(job sqlc.QueuedJob) bool {
+		return job.Status == "pending" && !job.RunAt.Time.After(current) ||
+			job.Status == "running" && job.LockedAt.Time.Before(arg.StaleBefore.Time)
+	}, func(a, b sqlc.QueuedJob) bool { return a.RunAt.Time.Before(b.RunAt.Time) })
+
+	claimed := page(due, arg.RowLimit, 0)
+	for i, job := range claimed {
+		job.Status = "running"
+		job.Attempts++
+		job.LockedBy, job.LockedAt = arg.LockedBy, now()
+		job.UpdatedAt = now()
+		f.queuedJobs[job.ID] = job
+		claimed[i] = job
+	}
+	return claimed, nil
+}
+
+func (f *Fake) CompleteQueuedJob(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if job, ok := f.queuedJobs[id]; ok {
+		job.Status = "done"
+		job.LockedBy, job.LockedAt = pgtype.Text{}, pgtype.Timestamptz{}
+		job.UpdatedAt = now()
+		f.queuedJobs[id] = job
+	}
+	return nil
+}
+
+func (f *Fake) RetryQueuedJob(ctx context.Context, arg sqlc.RetryQueuedJobParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if job, ok := f.queuedJobs[arg.ID]; ok {
+		job.Status, job.RunAt, job.LastError = "pending", arg.RunAt, arg.LastError
+		job.LockedBy, job.LockedAt = pgtype.Text{}, pgtype.Timestamptz{}
+		job.UpdatedAt = now()
+		f.queuedJobs[arg.ID] = job
+	}
+	return nil
+}
+
+func (f *Fake) FailQueuedJob(ctx context.Context, arg sqlc.FailQueuedJobParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if job, ok := f.queuedJobs[arg.ID]; ok {
+		job.Status, job.LastError = "dead", arg.LastError
+		job.LockedBy, job.LockedAt = pgtype.Text{}, pgtype.Timestamptz{}
+		job.UpdatedAt = now()
+		f.queuedJobs[arg.ID] = job
+	}
+	return nil
+}
+
+func (f *Fake) GetQueuedJob(ctx context.Context, id int32) (sqlc.QueuedJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return get(f.queuedJobs, id)
+}
+
+func (f *Fake) ListQueuedJobs(ctx context.Context, arg sqlc.ListQueuedJobsParams) ([]sqlc.QueuedJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs := filter(f.queuedJobs, func(job sqlc.QueuedJob) bool { return job.Status == arg.Status },
+		func(a, b sqlc.QueuedJob) bool { return a.ID > b.ID })
+	return page(jobs, arg.RowLimit, arg.RowOffset), nil
+}
+
+func (f *Fake) CountQueuedJobs(ctx context.Context, status string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs := filter(f.queuedJobs, func(job sqlc.QueuedJob) bool { return job.Status == status },
+		func(a, b sqlc.QueuedJob) bool { return a.ID < b.ID })
+	return int64(len(jobs)), nil
+}
+
+func (f *Fake) RequeueQueuedJob(ctx context.Context, id int32) (sqlc.QueuedJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.queuedJobs[id]
+	if !ok || job.Status != "dead" {
+		return sqlc.QueuedJob{}, pgx.ErrNoRows
+	}
+	job.Status, job.Attempts = "pending", 0
+	job.RunAt, job.UpdatedAt = now(), now()
+	f.queuedJobs[id] = job
+	return job, nil
+}
+
+func (f *Fake) DeleteFinishedQueuedJobs(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for id, job := range f.queuedJobs {
+		if job.Status == "done" && job.UpdatedAt.Time.Before(updatedAt.Time) {
+			delete(f.queuedJobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Feature flags
+
+func (f *Fake) ListFeatureFlags(ctx context.Context) ([]sqlc.FeatureFlag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	flags := []sqlc.FeatureFlag{}
+	for _, flag := range f.featureFlags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+func (f *Fake) GetFeatureFlag(ctx context.Context, key string) (sqlc.FeatureFlag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	flag, ok := f.featureFlags[key]
+	if !ok {
+		return flag, pgx.ErrNoRows
+	}
+	return flag, nil
+}
+
+func (f *Fake) SetFeatureFlag(ctx context.Context, arg sqlc.SetFeatureFlagParams) (sqlc.FeatureFlag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	flag := sqlc.FeatureFlag{
+		Key:         arg.Key,
+		Description: arg.Description,
+		Enabled:     arg.Enabled,
+		UserTypes:   arg.UserTypes,
+		Departments: arg.Departments,
+		UserIds:     arg.UserIds,
+		UpdatedAt:   now(),
+	}
+	f.featureFlags[flag.Key] = flag
+	return flag, nil
+}
+
+func (f *Fake) DeleteFeatureFlag(ctx context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.featureFlags[key]; !ok {
+		return 0, nil
+	}
+	delete(f.featureFlags, key)
+	return 1, nil
+}
+
+// Runtime settings
+
+func (f *Fake) ListRuntimeSettings(ctx context.Context) ([]sqlc.RuntimeSetting, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	settings := []sqlc.RuntimeSetting{}
+	for _, setting := range f.runtimeSettings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+func (f *Fake) SetRuntimeSetting(ctx context.Context, arg sqlc.SetRuntimeSettingParams) (sqlc.RuntimeSetting, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	setting := sqlc.RuntimeSetting{
+		Key:       arg.Key,
+		Value:     arg.Value,
+		UpdatedBy: arg.UpdatedBy,
+		UpdatedAt: now(),
+	}
+	f.runtimeSettings[setting.Key] = setting
+	return setting, nil
+}
+
+func (f *Fake) DeleteRuntimeSetting(ctx context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.runtimeSettings[key]; !ok {
+		return 0, nil
+	}
+	delete(f.runtimeSettings, key)
+	return 1, nil
+}
+
+// Notifications
+
+// notificationPrefKey is the primary key of notification_preferences
+type notificationPrefKey struct {
+	userID int32
+	kind   string
+}
+
+func (f *Fake) CreateNotification(ctx context.Context, arg sqlc.CreateNotificationParams) (sqlc.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	notification := sqlc.Notification{
+		ID:        f.newID(),
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+		Recipient: arg.Recipient,
+		Subject:   arg.Subject,
+		Body:      arg.Body,
+		Status:    "pending",
+		CreatedAt: now(),
+		Channel:   arg.Channel,
+		TenantID:  db.DefaultTenantID,
+	}
+	f.notifications[notification.ID] = notification
+	return notification, nil
+}
+
+func (f *Fake) GetNotification(ctx context.Context, id int32) (sqlc.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return get(f.notifications, id)
+}
+
+func (f *Fake) MarkNotificationSent(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	notification, ok := f.notifications[id]
+	if !ok {
+		return nil
+	}
+	notification.Status = "sent"
+	notification.LastError = pgtype.Text{}
+	notification.SentAt = now()
+	f.notifications[id] = notification
+	return nil
+}
+
+func (f *Fake) SetNotificationError(ctx context.Context, arg sqlc.SetNotificationErrorParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	notification, ok := f.notifications[arg.ID]
+	if !ok {
+		return nil
+	}
+	notification.LastError = arg.LastError
+	f.notifications[arg.ID] = notification
+	return nil
+}
+
+func (f *Fake) ListNotificationPreferences(ctx context.Context, userID int32) ([]sqlc.NotificationPreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prefs := []sqlc.NotificationPreference{}
+	for key, pref := range f.notificationPrefs {
+		if key.userID == userID {
+			prefs = append(prefs, pref)
+		}
+	}
+	sort.Slice(prefs, func(i, j int) bool { return prefs[i].Kind < prefs[j].Kind })
+	return prefs, nil
+}
+
+func (f *Fake) SetNotificationPreference(ctx context.Context, arg sqlc.SetNotificationPreferenceParams) (sqlc.NotificationPreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pref := sqlc.NotificationPreference{
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+		Email:     arg.Email,
+		UpdatedAt: now(),
+		Line:      arg.Line,
+		TenantID:  db.DefaultTenantID,
+		Push:      arg.Push,
+	}
+	f.notificationPrefs[notificationPrefKey{userID: arg.UserID, kind: arg.Kind}] = pref
+	return pref, nil
+}
+
+// Language preferences
+
+func (f *Fake) GetLanguagePreference(ctx context.Context, userID int32) (sqlc.LanguagePreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.languagePrefs, userID)
+}
+
+func (f *Fake) SetLanguagePreference(ctx context.Context, arg sqlc.SetLanguagePreferenceParams) (sqlc.LanguagePreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pref := sqlc.LanguagePreference{
+		UserID:    arg.UserID,
+		Language:  arg.Language,
+		UpdatedAt: now(),
+		TenantID:  db.DefaultTenantID,
+	}
+	f.languagePrefs[arg.UserID] = pref
+	return pref, nil
+}
+
+// Time zone preferences
+
+func (f *Fake) GetTimeZonePreference(ctx context.Context, userID int32) (sqlc.TimeZonePreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.timeZonePrefs, userID)
+}
+
+func (f *Fake) SetTimeZonePreference(ctx context.Context, arg sqlc.SetTimeZonePreferenceParams) (sqlc.TimeZonePreference, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pref := sqlc.TimeZonePreference{
+		UserID:    arg.UserID,
+		TimeZone:  arg.TimeZone,
+		UpdatedAt: now(),
+		TenantID:  db.DefaultTenantID,
+	}
+	f.timeZonePrefs[arg.UserID] = pref
+	return pref, nil
+}
+
+// Slack workspaces
+
+func (f *Fake) GetSlackWorkspace(ctx context.Context, teamID string) (sqlc.SlackWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspace, ok := f.slackWorkspaces[teamID]
+	if !ok {
+		return sqlc.SlackWorkspace{}, pgx.ErrNoRows
+	}
+	return workspace, nil
+}
+
+func (f *Fake) ListSlackWorkspaces(ctx context.Context) ([]sqlc.SlackWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspaces := []sqlc.SlackWorkspace{}
+	for _, workspace := range f.slackWorkspaces {
+		workspaces = append(workspaces, workspace)
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Name != workspaces[j].Name {
+			return workspaces[i].Name < workspaces[j].Name
+		}
+		return workspaces[i].TeamID < workspaces[j].TeamID
+	})
+	return workspaces, nil
+}
+
+func (f *Fake) UpsertSlackWorkspace(ctx context.Context, arg sqlc.UpsertSlackWorkspaceParams) (sqlc.SlackWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspace, ok := f.slackWorkspaces[arg.TeamID]
+	if !ok {
+		workspace = sqlc.SlackWorkspace{TeamID: arg.TeamID, CreatedAt: now(), TenantID: db.DefaultTenantID}
+	}
+	workspace.Name = arg.Name
+	workspace.BotToken = arg.BotToken
+	workspace.ApprovalsChannel = arg.ApprovalsChannel
+	workspace.OutTodayChannel = arg.OutTodayChannel
+	workspace.OutTodayHour = arg.OutTodayHour
+	workspace.NotifyUsers = arg.NotifyUsers
+	workspace.ConnectedByUserID = arg.ConnectedByUserID
+	workspace.UpdatedAt = now()
+	f.slackWorkspaces[arg.TeamID] = workspace
+	return workspace, nil
+}
+
+func (f *Fake) MarkSlackOutTodayPosted(ctx context.Context, arg sqlc.MarkSlackOutTodayPostedParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspace, ok := f.slackWorkspaces[arg.TeamID]
+	if !ok {
+		return nil
+	}
+	workspace.OutTodayPostedOn = arg.OutTodayPostedOn
+	f.slackWorkspaces[arg.TeamID] = workspace
+	return nil
+}
+
+func (f *Fake) DeleteSlackWorkspace(ctx context.Context, teamID string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.slackWorkspaces[teamID]; !ok {
+		return 0, nil
+	}
+	delete(f.slackWorkspaces, teamID)
+	return 1, nil
+}
+
+// LINE links
+
+func (f *Fake) CreateLineLinkCode(ctx context.Context, arg sqlc.CreateLineLinkCodeParams) (sqlc.LineLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.lineLinks[arg.UserID]
+	if !ok {
+		link = sqlc.LineLink{UserID: arg.UserID, CreatedAt: now(), TenantID: db.DefaultTenantID}
+	}
+	link.LinkCode = arg.LinkCode
+	link.LinkCodeExpiresAt = arg.LinkCodeExpiresAt
+	f.lineLinks[arg.UserID] = link
+	return link, nil
+}
+
+func (f *Fake) GetLineLink(ctx context.Context, userID int32) (sqlc.LineLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.lineLinks, userID)
+}
+
+func (f *Fake) LinkLineAccount(ctx context.Context, arg sqlc.LinkLineAccountParams) (sqlc.LineLink, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for userID, link := range f.lineLinks {
+		if !link.LinkCode.Valid || link.LinkCode != arg.LinkCode || !link.LinkCodeExpiresAt.Time.After(time.Now()) {
+			continue
+		}
+		link.LineUserID = arg.LineUserID
+		link.LinkCode = pgtype.Text{}
+		link.LinkCodeExpiresAt = pgtype.Timestamptz{}
+		link.LinkedAt = now()
+		f.lineLinks[userID] = link
+		return link, nil
+	}
+	return sqlc.LineLink{}, pgx.ErrNoRows
+}
+
+func (f *Fake) DeleteLineLink(ctx context.Context, userID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.lineLinks[userID]; !ok {
+		return 0, nil
+	}
+	delete(f.lineLinks, userID)
+	return 1, nil
+}
+
+func (f *Fake) DeleteLineLinksByLineUserID(ctx context.Context, lineUserID pgtype.Text) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for userID, link := range f.lineLinks {
+		if link.LineUserID.Valid && link.LineUserID == lineUserID {
+			delete(f.lineLinks, userID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Push devices
+
+func (f *Fake) RegisterPushDevice(ctx context.Context, arg sqlc.RegisterPushDeviceParams) (sqlc.PushDevice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, d := range f.pushDevices {
+		if d.Token == arg.Token {
+			d.UserID, d.Platform, d.LastSeenAt = arg.UserID, arg.Platform, now()
+			f.pushDevices[id] = d
+			return d, nil
+		}
+	}
+	at := now()
+	d := sqlc.PushDevice{
+		ID:         f.newID(),
+		UserID:     arg.UserID,
+		Token:      arg.Token,
+		Platform:   arg.Platform,
+		CreatedAt:  at,
+		LastSeenAt: at,
+		TenantID:   db.DefaultTenantID,
+	}
+	f.pushDevices[d.ID] = d
+	return d, nil
+}
+
+func (f *Fake) ListPushDevicesByUser(ctx context.Context, userID int32) ([]sqlc.PushDevice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.pushDevices,
+		func(d sqlc.PushDevice) bool { return d.UserID == userID },
+		func(a, b sqlc.PushDevice) bool {
+			if !a.LastSeenAt.Time.Equal(b.LastSeenAt.Time) {
+				return a.LastSeenAt.Time.After(b.LastSeenAt.Time)
+			}
+			return a.ID > b.ID
+		}), nil
+}
+
+func (f *Fake) DeletePushDevice(ctx context.Context, arg sqlc.DeletePushDeviceParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if d, ok := f.pushDevices[arg.ID]; !ok || d.UserID != arg.UserID {
+		return 0, nil
+	}
+	delete(f.pushDevices, arg.ID)
+	return 1, nil
+}
+
+func (f *Fake) DeletePushDeviceByToken(ctx context.Context, token string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, d := range f.pushDevices {
+		if d.Token == token {
+			delete(f.pushDevices, id)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// Webhooks
+
+func (f *Fake) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	webhook := sqlc.Webhook{
+		ID:              f.newID(),
+		Url:             arg.Url,
+		Secret:          arg.Secret,
+		Events:          arg.Events,
+		Description:     arg.Description,
+		Active:          arg.Active,
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		UpdatedAt:       now(),
+		TenantID:        db.DefaultTenantID,
+	}
+	f.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (f *Fake) GetWebhook(ctx context.Context, id int32) (sqlc.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.webhooks, id)
+}
+
+func (f *Fake) ListWebhooks(ctx context.Context) ([]sqlc.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.webhooks, nil, func(a, b sqlc.Webhook) bool { return a.ID < b.ID }), nil
+}
+
+func (f *Fake) UpdateWebhook(ctx context.Context, arg sqlc.UpdateWebhookParams) (sqlc.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	webhook, ok := f.webhooks[arg.ID]
+	if !ok {
+		return sqlc.Webhook{}, pgx.ErrNoRows
+	}
+	webhook.Url = arg.Url
+	webhook.Events = arg.Events
+	webhook.Description = arg.Description
+	webhook.Active = arg.Active
+	webhook.UpdatedAt = now()
+	f.webhooks[arg.ID] = webhook
+	return webhook, nil
+}
+
+func (f *Fake) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.webhooks[id]; !ok {
+		return 0, nil
+	}
+	delete(f.webhooks, id)
+	for deliveryID, delivery := range f.webhookDeliveries {
+		if delivery.WebhookID == id {
+			delete(f.webhookDeliveries, deliveryID)
+		}
+	}
+	return 1, nil
+}
+
+func (f *Fake) CreateWebhookDelivery(ctx context.Context, arg sqlc.CreateWebhookDeliveryParams) (sqlc.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delivery := sqlc.WebhookDelivery{
+		ID:        f.newID(),
+		WebhookID: arg.WebhookID,
+		Event:     arg.Event,
+		Payload:   arg.Payload,
+		Status:    "pending",
+		CreatedAt: now(),
+		TenantID:  db.DefaultTenantID,
+	}
+	f.webhookDeliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+func (f *Fake) GetWebhookDelivery(ctx context.Context, id int32) (sqlc.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.webhookDeliveries, id)
+}
+
+func (f *Fake) ListWebhookDeliveries(ctx context.Context, arg sqlc.ListWebhookDeliveriesParams) ([]sqlc.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	deliveries := filter(f.webhookDeliveries, func(delivery sqlc.WebhookDelivery) bool { return delivery.WebhookID == arg.WebhookID },
+		func(a, b sqlc.WebhookDelivery) bool { return a.ID > b.ID })
+	return page(deliveries, arg.RowLimit, arg.RowOffset), nil
+}
+
+func (f *Fake) CountWebhookDeliveries(ctx context.Context, webhookID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int64
+	for _, delivery := range f.webhookDeliveries {
+		if delivery.WebhookID == webhookID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *Fake) RecordWebhookDeliveryAttempt(ctx context.Context, arg sqlc.RecordWebhookDeliveryAttemptParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delivery, ok := f.webhookDeliveries[arg.ID]
+	if !ok {
+		return nil
+	}
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.ResponseStatus = arg.ResponseStatus
+	delivery.LastError = arg.LastError
+	delivery.DeliveredAt = pgtype.Timestamptz{}
+	if arg.Status == "delivered" {
+		delivery.DeliveredAt = now()
+	}
+	f.webhookDeliveries[arg.ID] = delivery
+	return nil
+}
+
+func (f *Fake) RequeueWebhookDelivery(ctx context.Context, id int32) (sqlc.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delivery, ok := f.webhookDeliveries[id]
+	if !ok {
+		return sqlc.WebhookDelivery{}, pgx.ErrNoRows
+	}
+	delivery.Status = "pending"
+	delivery.Attempts = 0
+	f.webhookDeliveries[id] = delivery
+	return delivery, nil
+}
+
+func (f *Fake) DeleteWebhookDeliveriesBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for id, delivery := range f.webhookDeliveries {
+		if delivery.CreatedAt.Time.Before(createdAt.Time) {
+			delete(f.webhookDeliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// API tokens
+
+func (f *Fake) CreateAPIToken(ctx context.Context, arg sqlc.CreateAPITokenParams) (sqlc.ApiToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token := sqlc.ApiToken{
+		ID:              f.newID(),
+		Name:            arg.Name,
+		TokenHash:       arg.TokenHash,
+		Scopes:          arg.Scopes,
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		TenantID:        db.DefaultTenantID,
+	}
+	f.apiTokens[token.ID] = token
+	return token, nil
+}
+
+func (f *Fake) GetAPIToken(ctx context.Context, id int32) (sqlc.ApiToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.apiTokens, id)
+}
+
+func (f *Fake) GetAPITokenByHash(ctx context.Context, tokenHash string) (sqlc.ApiToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.apiTokens, func(t sqlc.ApiToken) bool { return t.TokenHash == tokenHash && !t.RevokedAt.Valid })
+}
+
+func (f *Fake) ListAPITokens(ctx context.Context) ([]sqlc.ApiToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.apiTokens, nil, func(a, b sqlc.ApiToken) bool { return a.ID < b.ID }), nil
+}
+
+func (f *Fake) RevokeAPIToken(ctx context.Context, id int32) (sqlc.ApiToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token, ok := f.apiTokens[id]
+	if !ok || token.RevokedAt.Valid {
+		return sqlc.ApiToken{}, pgx.ErrNoRows
+	}
+	token.RevokedAt = now()
+	f.apiTokens[id] = token
+	return token, nil
+}
+
+func (f *Fake) TouchAPIToken(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if token, ok := f.apiTokens[id]; ok {
+		token.LastUsedAt = now()
+		f.apiTokens[id] = token
+	}
+	return nil
+}
+
+// Report exports
+
+func (f *Fake) CreateReportExport(ctx context.Context, arg sqlc.CreateReportExportParams) (sqlc.ReportExport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	export := sqlc.ReportExport{
+		ID:              f.newID(),
+		ReportName:      arg.ReportName,
+		Format:          arg.Format,
+		FromDate:        arg.FromDate,
+		ToDate:          arg.ToDate,
+		UserID:          arg.UserID,
+		Department:      arg.Department,
+		Status:          "pending",
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		Language:        arg.Language,
+		TenantID:        db.DefaultTenantID,
+	}
+	f.reportExports[export.ID] = export
+	return export, nil
+}
+
+func (f *Fake) GetReportExport(ctx context.Context, id int32) (sqlc.ReportExport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.reportExports, id)
+}
+
+func (f *Fake) CompleteReportExport(ctx context.Context, arg sqlc.CompleteReportExportParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	export, ok := f.reportExports[arg.ID]
+	if !ok {
+		return nil
+	}
+	export.Status = "done"
+	export.FileName = arg.FileName
+	export.Content = arg.Content
+	export.Error = pgtype.Text{}
+	export.CompletedAt = now()
+	f.reportExports[arg.ID] = export
+	return nil
+}
+
+func (f *Fake) FailReportExport(ctx context.Context, arg sqlc.FailReportExportParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	export, ok := f.reportExports[arg.ID]
+	if !ok {
+		return nil
+	}
+	export.Status = "failed"
+	export.Error = arg.Error
+	export.CompletedAt = now()
+	f.reportExports[arg.ID] = export
+	return nil
+}
+
+func (f *Fake) DeleteReportExportsBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for id, export := range f.reportExports {
+		if export.CreatedAt.Time.Before(createdAt.Time) {
+			delete(f.reportExports, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Attendance
+
+func (f *Fake) CreateAttendanceSession(ctx context.Context, arg sqlc.CreateAttendanceSessionParams) (sqlc.AttendanceSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := first(f.attendance, openSession(arg.UserID)); err == nil {
+		return sqlc.AttendanceSession{}, errors.New(`duplicate key value violates unique constraint "idx_attendance_sessions_open"`)
+	}
+	session := sqlc.AttendanceSession{
+		ID:               f.newID(),
+		UserID:           arg.UserID,
+		WorkDate:         arg.WorkDate,
+		CheckInAt:        now(),
+		CheckInIp:        arg.CheckInIp,
+		CheckInLatitude:  arg.CheckInLatitude,
+		CheckInLongitude: arg.CheckInLongitude,
+		Note:             arg.Note,
+		TenantID:         db.DefaultTenantID,
+	}
+	f.attendance[session.ID] = session
+	return session, nil
+}
+
+func (f *Fake) CloseAttendanceSession(ctx context.Context, arg sqlc.CloseAttendanceSessionParams) (sqlc.AttendanceSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session, err := first(f.attendance, openSession(arg.UserID))
+	if err != nil {
+		return session, err
+	}
+	session.CheckOutAt = now()
+	session.CheckOutIp = arg.CheckOutIp
+	session.CheckOutLatitude = arg.CheckOutLatitude
+	session.CheckOutLongitude = arg.CheckOutLongitude
+	f.attendance[session.ID] = session
+	return session, nil
+}
+
+func (f *Fake) GetOpenAttendanceSession(ctx context.Context, userID int32) (sqlc.AttendanceSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.attendance, openSession(userID))
+}
+
+func (f *Fake) ListAttendanceSessionsByUser(ctx context.Context, arg sqlc.ListAttendanceSessionsByUserParams) ([]sqlc.AttendanceSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.attendance,
+		func(s sqlc.AttendanceSession) bool {
+			return s.UserID == arg.UserID && between(s.WorkDate, arg.DateFrom, arg.DateTo)
+		},
+		func(a, b sqlc.AttendanceSession) bool { return a.CheckInAt.Time.After(b.CheckInAt.Time) },
+	), nil
+}
+
+func (f *Fake) ListDailyAttendance(ctx context.Context, arg sqlc.ListDailyAttendanceParams) ([]sqlc.ListDailyAttendanceRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := filter(f.users,
+		func(u sqlc.User) bool {
+			return !arg.Department.Valid || f.inDepartment(u.Department, arg.Department.String)
+		},
+		func(a, b sqlc.User) bool { return a.Username < b.Username },
+	)
+	rows := []sqlc.ListDailyAttendanceRow{}
+	for _, u := range users {
+		row := sqlc.ListDailyAttendanceRow{UserID: u.ID, Username: u.Username, Department: u.Department}
+		for _, s := range f.attendance {
+			if s.UserID != u.ID || !sameDate(s.WorkDate, arg.WorkDate) {
+				continue
+			}
+			row.Sessions++
+			row.Hours += sessionHours(s)
+			if !row.FirstCheckInAt.Valid || s.CheckInAt.Time.Before(row.FirstCheckInAt.Time) {
+				row.FirstCheckInAt = s.CheckInAt
+			}
+			if s.CheckOutAt.Valid && (!row.LastCheckOutAt.Valid || s.CheckOutAt.Time.After(row.LastCheckOutAt.Time)) {
+				row.LastCheckOutAt = s.CheckOutAt
+			}
+			row.CheckedIn = row.CheckedIn || !s.CheckOutAt.Valid
+		}
+		if leaveLog, err := first(f.leaveLogs, func(l sqlc.LeaveLog) bool {
+			return l.UserID == u.ID && sameDate(l.Date, arg.WorkDate)
+		}); err == nil {
+			row.LeaveType = leaveLog.Type
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (f *Fake) SumAttendanceHoursForDate(ctx context.Context, arg sqlc.SumAttendanceHoursForDateParams) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var hours float64
+	for _, s := range f.attendance {
+		if s.UserID == arg.UserID && sameDate(s.WorkDate, arg.WorkDate) {
+			hours += sessionHours(s)
+		}
+	}
+	return hours, nil
+}
+
+// openSession matches the session a user is checked in to
+func openSession(userID int32) func(sqlc.AttendanceSession) bool {
+	return func(s sqlc.AttendanceSession) bool { return s.UserID == userID && !s.CheckOutAt.Valid }
+}
+
+// sessionHours returns how long a session lasted, up to now while it is open
+func sessionHours(s sqlc.AttendanceSession) float64 {
+	end := time.Now()
+	if s.CheckOutAt.Valid {
+		end = s.CheckOutAt.Time
+	}
+	return end.Sub(s.CheckInAt.Time).Hours()
+}
+
+// Payroll periods
+
+func (f *Fake) CreatePayrollPeriod(ctx context.Context, arg sqlc.CreatePayrollPeriodParams) (sqlc.PayrollPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := first(f.payrollPeriods, func(p sqlc.PayrollPeriod) bool { return p.Period == arg.Period }); err == nil {
+		return sqlc.PayrollPeriod{}, errors.New(`duplicate key value violates unique constraint "idx_payroll_periods_period"`)
+	}
+	period := sqlc.PayrollPeriod{
+		ID:              f.newID(),
+		Period:          arg.Period,
+		StartDate:       arg.StartDate,
+		EndDate:         arg.EndDate,
+		Status:          "open",
+		CreatedByUserID: arg.CreatedByUserID,
+		CreatedAt:       now(),
+		TenantID:        db.DefaultTenantID,
+	}
+	f.payrollPeriods[period.ID] = period
+	return period, nil
+}
+
+func (f *Fake) GetPayrollPeriod(ctx context.Context, period string) (sqlc.PayrollPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.payrollPeriods, func(p sqlc.PayrollPeriod) bool { return p.Period == period })
+}
+
+func (f *Fake) ListPayrollPeriods(ctx context.Context) ([]sqlc.PayrollPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.payrollPeriods, nil, byDate(func(p sqlc.PayrollPeriod) pgtype.Date { return p.StartDate }, true)), nil
+}
+
+func (f *Fake) LockPayrollPeriod(ctx context.Context, arg sqlc.LockPayrollPeriodParams) (sqlc.PayrollPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	period, ok := f.payrollPeriods[arg.ID]
+	if !ok || period.Status != "open" {
+		return sqlc.PayrollPeriod{}, pgx.ErrNoRows
+	}
+	period.Status = "locked"
+	period.FileName = arg.FileName
+	period.Content = arg.Content
+	period.LockedByUserID = arg.LockedByUserID
+	period.LockedAt = now()
+	f.payrollPeriods[arg.ID] = period
+	return period, nil
+}
+
+func (f *Fake) UnlockPayrollPeriod(ctx context.Context, id int32) (sqlc.PayrollPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	period, ok := f.payrollPeriods[id]
+	if !ok || period.Status != "locked" {
+		return sqlc.PayrollPeriod{}, pgx.ErrNoRows
+	}
+	period.Status = "open"
+	period.FileName = pgtype.Text{}
+	period.Content = nil
+	period.LockedByUserID = pgtype.Int4{}
+	period.LockedAt = pgtype.Timestamptz{}
+	f.payrollPeriods[id] = period
+	return period, nil
+}
+
+func (f *Fake) SummarizePayroll(ctx context.Context, arg sqlc.SummarizePayrollParams) ([]sqlc.SummarizePayrollRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := filter(f.users, nil, func(a, b sqlc.User) bool {
+		return a.Username < b.Username || a.Username == b.Username && a.ID < b.ID
+	})
+	rows := make([]sqlc.SummarizePayrollRow, 0, len(users))
+	for _, u := range users {
+		row := sqlc.SummarizePayrollRow{UserID: u.ID, Username: u.Username, Department: u.Department}
+		for _, l := range f.leaveLogs {
+			if l.UserID != u.ID || !between(l.Date, arg.StartDate, arg.EndDate) {
+				continue
+			}
+			if slices.Contains(arg.UnpaidLeaveTypes, l.Type) {
+				row.UnpaidLeaveDays++
+			} else {
+				row.PaidLeaveDays++
+			}
+		}
+		for _, l := range f.taskLogs {
+			if l.CreatedByUserID != u.ID || !between(l.WorkedDate, arg.StartDate, arg.EndDate) || !l.IsWorkOnHoliday.Bool {
+				continue
+			}
+			if days, err := l.WorkedDay.Float64Value(); err == nil && days.Valid {
+				row.HolidayWorkDays += days.Float64
+			}
+		}
+		hours := map[string]float64{}
+		for _, s := range f.attendance {
+			if s.UserID != u.ID || !s.CheckOutAt.Valid || !between(s.WorkDate, arg.StartDate, arg.EndDate) {
+				continue
+			}
+			if _, err := first(f.holidays, func(h sqlc.Holiday) bool { return sameDate(h.Date, s.WorkDate) }); err == nil {
+				continue
+			}
+			hours[s.WorkDate.Time.Format(time.DateOnly)] += sessionHours(s)
+		}
+		capacity := 1.0
+		if value, err := u.DailyCapacity.Float64Value(); err == nil && value.Valid {
+			capacity = value.Float64
+		}
+		for _, h := range hours {
+			row.OvertimeHours += max(h-arg.HoursPerDay*capacity, 0)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Org chart
+
+func (f *Fake) CreateDepartment(ctx context.Context, arg sqlc.CreateDepartmentParams) (sqlc.Department, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := first(f.departments, func(d sqlc.Department) bool { return d.Name == arg.Name }); err == nil {
+		return sqlc.Department{}, errors.New(`duplicate key value violates unique constraint "idx_departments_name"`)
+	}
+	department := sqlc.Department{
+		ID:         f.newID(),
+		Name:       arg.Name,
+		ParentID:   arg.ParentID,
+		HeadUserID: arg.HeadUserID,
+		CreatedAt:  now(),
+		UpdatedAt:  now(),
+		TenantID:   db.DefaultTenantID,
+	}
+	f.departments[department.ID] = department
+	return department, nil
+}
+
+func (f *Fake) GetDepartment(ctx context.Context, id int32) (sqlc.Department, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.departments, id)
+}
+
+func (f *Fake) GetDepartmentByName(ctx context.Context, name string) (sqlc.Department, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.departments, func(d sqlc.Department) bool { return d.Name == name })
+}
+
+func (f *Fake) ListDepartments(ctx context.Context) ([]sqlc.Department, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.departments, nil, func(a, b sqlc.Department) bool { return a.Name < b.Name }), nil
+}
+
+func (f *Fake) UpdateDepartment(ctx context.Context, arg sqlc.UpdateDepartmentParams) (sqlc.Department, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	department, ok := f.departments[arg.ID]
+	if !ok {
+		return sqlc.Department{}, pgx.ErrNoRows
+	}
+	if _, err := first(f.departments, func(d sqlc.Department) bool { return d.Name == arg.Name && d.ID != arg.ID }); err == nil {
+		return sqlc.Department{}, errors.New(`duplicate key value violates unique constraint "idx_departments_name"`)
+	}
+	department.Name = arg.Name
+	department.ParentID = arg.ParentID
+	department.HeadUserID = arg.HeadUserID
+	department.UpdatedAt = now()
+	f.departments[arg.ID] = department
+	return department, nil
+}
+
+func (f *Fake) DeleteDepartment(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.departments, id)
+	for positionID, p := range f.positions {
+		if p.DepartmentID.Valid && p.DepartmentID.Int32 == id {
+			p.DepartmentID = pgtype.Int4{}
+			f.positions[positionID] = p
+		}
+	}
+	return nil
+}
+
+func (f *Fake) CountDepartmentMembers(ctx context.Context, department string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(filter(f.users,
+		func(u sqlc.User) bool { return u.Department.Valid && u.Department.String == department },
+		func(a, b sqlc.User) bool { return a.ID < b.ID },
+	))), nil
+}
+
+func (f *Fake) CountSubDepartments(ctx context.Context, parentID pgtype.Int4) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(filter(f.departments,
+		func(d sqlc.Department) bool { return parentID.Valid && d.ParentID == parentID },
+		func(a, b sqlc.Department) bool { return a.ID < b.ID },
+	))), nil
+}
+
+func (f *Fake) RenameUserDepartments(ctx context.Context, arg sqlc.RenameUserDepartmentsParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, users := range []map[int32]sqlc.User{f.users, f.deletedUsers} {
+		for id, u := range users {
+			if u.Department.Valid && u.Department.String == arg.OldName {
+				u.Department = pgtype.Text{String: arg.NewName, Valid: true}
+				u.UpdatedAt = now()
+				users[id] = u
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Fake) RenameTaskCategoryDepartments(ctx context.Context, arg sqlc.RenameTaskCategoryDepartmentsParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, category := range f.taskCategories {
+		if category.Department.Valid && category.Department.String == arg.OldName {
+			category.Department = pgtype.Text{String: arg.NewName, Valid: true}
+			category.UpdatedAt = now()
+			f.taskCategories[id] = category
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListLeaveCalendar(ctx context.Context, arg sqlc.ListLeaveCalendarParams) ([]sqlc.ListLeaveCalendarRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	leaveLogs := filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool {
+			_, live := f.users[l.UserID]
+			_, covered := f.reportUser(l.UserID, pgtype.Int4{}, arg.Department)
+			return live && covered && between(l.Date, arg.DateFrom, arg.DateTo)
+		},
+		func(a, b sqlc.LeaveLog) bool {
+			if !a.Date.Time.Equal(b.Date.Time) {
+				return a.Date.Time.Before(b.Date.Time)
+			}
+			if f.username(a.UserID) != f.username(b.UserID) {
+				return f.username(a.UserID) < f.username(b.UserID)
+			}
+			return a.ID < b.ID
+		},
+	)
+	rows := []sqlc.ListLeaveCalendarRow{}
+	for _, l := range leaveLogs {
+		user := f.users[l.UserID]
+		rows = append(rows, sqlc.ListLeaveCalendarRow{
+			ID:         l.ID,
+			UserID:     l.UserID,
+			Username:   user.Username,
+			Department: user.Department,
+			Type:       l.Type,
+			Date:       l.Date,
+			Note:       l.Note,
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) CreatePosition(ctx context.Context, arg sqlc.CreatePositionParams) (sqlc.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	position := sqlc.Position{
+		ID:           f.newID(),
+		Title:        arg.Title,
+		DepartmentID: arg.DepartmentID,
+		CreatedAt:    now(),
+		UpdatedAt:    now(),
+		TenantID:     db.DefaultTenantID,
+	}
+	f.positions[position.ID] = position
+	return position, nil
+}
+
+func (f *Fake) GetPosition(ctx context.Context, id int32) (sqlc.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.positions, id)
+}
+
+func (f *Fake) ListPositions(ctx context.Context) ([]sqlc.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.positions, nil, func(a, b sqlc.Position) bool {
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+func (f *Fake) UpdatePosition(ctx context.Context, arg sqlc.UpdatePositionParams) (sqlc.Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	position, ok := f.positions[arg.ID]
+	if !ok {
+		return sqlc.Position{}, pgx.ErrNoRows
+	}
+	position.Title = arg.Title
+	position.DepartmentID = arg.DepartmentID
+	position.UpdatedAt = now()
+	f.positions[arg.ID] = position
+	return position, nil
+}
+
+func (f *Fake) DeletePosition(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.positions, id)
+	for userID, a := range f.orgAssignments {
+		if a.PositionID.Valid && a.PositionID.Int32 == id {
+			a.PositionID = pgtype.Int4{}
+			f.orgAssignments[userID] = a
+		}
+	}
+	return nil
+}
+
+func (f *Fake) GetOrgAssignment(ctx context.Context, userID int32) (sqlc.OrgAssignment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.orgAssignments, userID)
+}
+
+func (f *Fake) ManagesAnyone(ctx context.Context, userID int32) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for reportID, a := range f.orgAssignments {
+		if _, live := f.users[reportID]; live && a.ManagerID.Valid && a.ManagerID.Int32 == userID {
+			return true, nil
+		}
+	}
+	for _, d := range f.departments {
+		if d.HeadUserID.Valid && d.HeadUserID.Int32 == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *Fake) UpsertOrgAssignment(ctx context.Context, arg sqlc.UpsertOrgAssignmentParams) (sqlc.OrgAssignment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assignment := sqlc.OrgAssignment{
+		UserID:     arg.UserID,
+		PositionID: arg.PositionID,
+		ManagerID:  arg.ManagerID,
+		UpdatedAt:  now(),
+		TenantID:   db.DefaultTenantID,
+	}
+	f.orgAssignments[arg.UserID] = assignment
+	return assignment, nil
+}
+
+func (f *Fake) ListOrgChartMembers(ctx context.Context) ([]sqlc.ListOrgChartMembersRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := filter(f.users, nil, func(a, b sqlc.User) bool {
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.ID < b.ID
+	})
+	rows := []sqlc.ListOrgChartMembersRow{}
+	for _, u := range users {
+		row := sqlc.ListOrgChartMembersRow{UserID: u.ID, Username: u.Username, Department: u.Department}
+		if a, ok := f.orgAssignments[u.ID]; ok {
+			row.PositionID = a.PositionID
+			row.ManagerID = a.ManagerID
+			if p, ok := f.positions[a.PositionID.Int32]; ok && a.PositionID.Valid {
+				row.PositionTitle = pgtype.Text{String: p.Title, Valid: true}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Approvals
+
+func (f *Fake) ListApprovalPolicies(ctx context.Context) ([]sqlc.ApprovalPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policies := []sqlc.ApprovalPolicy{}
+	for _, p := range f.approvalPolicies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Kind < policies[j].Kind })
+	return policies, nil
+}
+
+func (f *Fake) GetApprovalPolicy(ctx context.Context, kind string) (sqlc.ApprovalPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policy, ok := f.approvalPolicies[kind]
+	if !ok {
+		return policy, pgx.ErrNoRows
+	}
+	return policy, nil
+}
+
+func (f *Fake) UpsertApprovalPolicy(ctx context.Context, arg sqlc.UpsertApprovalPolicyParams) (sqlc.ApprovalPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	policy, ok := f.approvalPolicies[arg.Kind]
+	if !ok {
+		policy = sqlc.ApprovalPolicy{ID: f.newID(), Kind: arg.Kind, TenantID: db.DefaultTenantID}
+	}
+	policy.Steps = arg.Steps
+	policy.AutoApproveMax = arg.AutoApproveMax
+	policy.UpdatedAt = now()
+	f.approvalPolicies[arg.Kind] = policy
+	return policy, nil
+}
+
+func (f *Fake) DeleteApprovalPolicy(ctx context.Context, kind string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.approvalPolicies, kind)
+	return nil
+}
+
+func (f *Fake) CreateApprovalRequest(ctx context.Context, arg sqlc.CreateApprovalRequestParams) (sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	request := sqlc.ApprovalRequest{
+		ID:          f.newID(),
+		Kind:        arg.Kind,
+		SubjectID:   arg.SubjectID,
+		RequesterID: arg.RequesterID,
+		Amount:      arg.Amount,
+		Payload:     arg.Payload,
+		Steps:       arg.Steps,
+		Status:      arg.Status,
+		CreatedAt:   now(),
+		UpdatedAt:   now(),
+		TenantID:    db.DefaultTenantID,
+	}
+	if arg.Status != "pending" {
+		request.DecidedAt = now()
+	}
+	f.approvalRequests[request.ID] = request
+	return request, nil
+}
+
+func (f *Fake) GetApprovalRequest(ctx context.Context, id int32) (sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.approvalRequests, id)
+}
+
+func (f *Fake) GetPendingApprovalRequestBySubject(ctx context.Context, arg sqlc.GetPendingApprovalRequestBySubjectParams) (sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	requests := filter(f.approvalRequests,
+		func(r sqlc.ApprovalRequest) bool {
+			return r.Kind == arg.Kind && r.SubjectID == arg.SubjectID && r.Status == "pending"
+		},
+		func(a, b sqlc.ApprovalRequest) bool { return a.ID > b.ID },
+	)
+	if len(requests) == 0 {
+		return sqlc.ApprovalRequest{}, pgx.ErrNoRows
+	}
+	return requests[0], nil
+}
+
+func (f *Fake) ListPendingApprovalRequests(ctx context.Context) ([]sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalRequests,
+		func(r sqlc.ApprovalRequest) bool { return r.Status == "pending" },
+		func(a, b sqlc.ApprovalRequest) bool {
+			if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (f *Fake) ListApprovalRequestsByRequester(ctx context.Context, requesterID int32) ([]sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalRequests,
+		func(r sqlc.ApprovalRequest) bool { return r.RequesterID == requesterID },
+		func(a, b sqlc.ApprovalRequest) bool {
+			if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Time.After(b.CreatedAt.Time)
+			}
+			return a.ID > b.ID
+		},
+	), nil
+}
+
+func (f *Fake) AdvanceApprovalRequest(ctx context.Context, arg sqlc.AdvanceApprovalRequestParams) (sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	request, ok := f.approvalRequests[arg.ID]
+	if !ok || request.Status != "pending" || request.CurrentStep != arg.CurrentStep {
+		return sqlc.ApprovalRequest{}, pgx.ErrNoRows
+	}
+	request.CurrentStep = arg.NextStep
+	request.Status = arg.Status
+	request.DecidedAt = pgtype.Timestamptz{}
+	if arg.Status != "pending" {
+		request.DecidedAt = now()
+	}
+	request.UpdatedAt = now()
+	f.approvalRequests[arg.ID] = request
+	return request, nil
+}
+
+func (f *Fake) CancelApprovalRequests(ctx context.Context, arg sqlc.CancelApprovalRequestsParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, r := range f.approvalRequests {
+		if r.Kind == arg.Kind && r.SubjectID == arg.SubjectID && r.Status == "pending" {
+			r.Status = "cancelled"
+			r.DecidedAt = now()
+			r.UpdatedAt = now()
+			f.approvalRequests[id] = r
+		}
+	}
+	return nil
+}
+
+func (f *Fake) CreateApprovalDecision(ctx context.Context, arg sqlc.CreateApprovalDecisionParams) (sqlc.ApprovalDecision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	decision := sqlc.ApprovalDecision{
+		ID:           f.newID(),
+		RequestID:    arg.RequestID,
+		Step:         arg.Step,
+		ApproverID:   arg.ApproverID,
+		OnBehalfOfID: arg.OnBehalfOfID,
+		Decision:     arg.Decision,
+		Comment:      arg.Comment,
+		CreatedAt:    now(),
+		TenantID:     db.DefaultTenantID,
+		DelegationID: arg.DelegationID,
+	}
+	f.approvalDecisions[decision.ID] = decision
+	return decision, nil
+}
+
+func (f *Fake) ListApprovalDecisions(ctx context.Context, requestID int32) ([]sqlc.ApprovalDecision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalDecisions,
+		func(d sqlc.ApprovalDecision) bool { return d.RequestID == requestID },
+		func(a, b sqlc.ApprovalDecision) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) ListApprovalDecisionsByDelegation(ctx context.Context, delegationID pgtype.Int4) ([]sqlc.ApprovalDecision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalDecisions,
+		func(d sqlc.ApprovalDecision) bool { return d.DelegationID.Valid && d.DelegationID == delegationID },
+		func(a, b sqlc.ApprovalDecision) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) CreateApprovalDelegation(ctx context.Context, arg sqlc.CreateApprovalDelegationParams) (sqlc.ApprovalDelegation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delegation := sqlc.ApprovalDelegation{
+		ID:          f.newID(),
+		DelegatorID: arg.DelegatorID,
+		DelegateID:  arg.DelegateID,
+		StartDate:   arg.StartDate,
+		EndDate:     arg.EndDate,
+		CreatedAt:   now(),
+		TenantID:    db.DefaultTenantID,
+		CreatedByID: arg.CreatedByID,
+	}
+	f.delegations[delegation.ID] = delegation
+	return delegation, nil
+}
+
+func (f *Fake) GetApprovalDelegation(ctx context.Context, id int32) (sqlc.ApprovalDelegation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.delegations, id)
+}
+
+func (f *Fake) ListApprovalDelegationsByUser(ctx context.Context, userID int32) ([]sqlc.ApprovalDelegation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.delegations,
+		func(d sqlc.ApprovalDelegation) bool { return d.DelegatorID == userID || d.DelegateID == userID },
+		func(a, b sqlc.ApprovalDelegation) bool {
+			if !a.StartDate.Time.Equal(b.StartDate.Time) {
+				return a.StartDate.Time.After(b.StartDate.Time)
+			}
+			return a.ID > b.ID
+		},
+	), nil
+}
+
+func (f *Fake) ListActiveApprovalDelegations(ctx context.Context, arg sqlc.ListActiveApprovalDelegationsParams) ([]sqlc.ApprovalDelegation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.delegations,
+		func(d sqlc.ApprovalDelegation) bool {
+			return d.DelegateID == arg.DelegateID && between(arg.Day, d.StartDate, d.EndDate) && !d.RevokedAt.Valid
+		},
+		func(a, b sqlc.ApprovalDelegation) bool {
+			if a.DelegatorID != b.DelegatorID {
+				return a.DelegatorID < b.DelegatorID
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (f *Fake) RevokeApprovalDelegation(ctx context.Context, arg sqlc.RevokeApprovalDelegationParams) (sqlc.ApprovalDelegation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delegation, ok := f.delegations[arg.ID]
+	if !ok || delegation.RevokedAt.Valid {
+		return sqlc.ApprovalDelegation{}, pgx.ErrNoRows
+	}
+	delegation.RevokedAt = now()
+	delegation.RevokedByID = arg.RevokedByID
+	f.delegations[delegation.ID] = delegation
+	return delegation, nil
+}
+
+// Files
+
+func (f *Fake) CreateFile(ctx context.Context, arg sqlc.CreateFileParams) (sqlc.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file := sqlc.File{
+		ID:          f.newID(),
+		OwnerID:     arg.OwnerID,
+		Purpose:     arg.Purpose,
+		SubjectID:   arg.SubjectID,
+		StorageKey:  arg.StorageKey,
+		FileName:    arg.FileName,
+		ContentType: arg.ContentType,
+		Size:        arg.Size,
+		Sha256:      arg.Sha256,
+		ScannedAt:   arg.ScannedAt,
+		CreatedAt:   now(),
+		TenantID:    db.DefaultTenantID,
+	}
+	f.files[file.ID] = file
+	return file, nil
+}
+
+func (f *Fake) GetFile(ctx context.Context, id int32) (sqlc.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.files, id)
+}
+
+func (f *Fake) ListFilesBySubject(ctx context.Context, arg sqlc.ListFilesBySubjectParams) ([]sqlc.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.files,
+		func(file sqlc.File) bool { return file.Purpose == arg.Purpose && file.SubjectID == arg.SubjectID },
+		func(a, b sqlc.File) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) DeleteFile(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.files, id)
+	return nil
+}
+
+func (f *Fake) ListOrphanedFiles(ctx context.Context, limit int32) ([]sqlc.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	orphaned := filter(f.files, func(file sqlc.File) bool {
+		var live, deleted bool
+		switch file.Purpose {
+		case "medical_receipt":
+			_, live = f.medicalExpenses[file.SubjectID]
+			_, deleted = f.deletedMedicalExpenses[file.SubjectID]
+		case "sick_leave_certificate":
+			_, live = f.leaveLogs[file.SubjectID]
+			_, deleted = f.deletedLeaveLogs[file.SubjectID]
+		case "avatar":
+			_, live = f.users[file.SubjectID]
+			_, deleted = f.deletedUsers[file.SubjectID]
+		default:
+			return false
+		}
+		return !live && !deleted
+	}, func(a, b sqlc.File) bool { return a.ID < b.ID })
+	return page(orphaned, limit, 0), nil
+}
+
+// ClickUp tokens
+
+type clickUpTokenKey struct {
+	userID int32
+	teamID string
+}
+
+func (f *Fake) UpsertClickUpToken(ctx context.Context, arg sqlc.UpsertClickUpTokenParams) (sqlc.ClickupToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := clickUpTokenKey{arg.UserID, arg.TeamID}
+	token, ok := f.clickUpTokens[key]
+	if !ok {
+		token = sqlc.ClickupToken{UserID: arg.UserID, TeamID: arg.TeamID, CreatedAt: now()}
+	}
+	token.AccessToken = arg.AccessToken
+	token.TokenType = arg.TokenType
+	token.UpdatedAt = now()
+	f.clickUpTokens[key] = token
+	return token, nil
+}
+
+// GetClickUpToken returns the token the user connected last
+func (f *Fake) GetClickUpToken(ctx context.Context, userID int32) (sqlc.ClickupToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var latest sqlc.ClickupToken
+	found := false
+	for key, token := range f.clickUpTokens {
+		if key.userID == userID && (!found || token.UpdatedAt.Time.After(latest.UpdatedAt.Time)) {
+			latest, found = token, true
+		}
+	}
+	if !found {
+		return latest, pgx.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (f *Fake) GetClickUpTokenForTeam(ctx context.Context, arg sqlc.GetClickUpTokenForTeamParams) (sqlc.ClickupToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token, ok := f.clickUpTokens[clickUpTokenKey{arg.UserID, arg.TeamID}]
+	if !ok {
+		return token, pgx.ErrNoRows
+	}
+	return token, nil
+}
+
+func (f *Fake) ListClickUpTokensByUser(ctx context.Context, userID int32) ([]sqlc.ClickupToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tokens := []sqlc.ClickupToken{}
+	for key, token := range f.clickUpTokens {
+		if key.userID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].TeamID < tokens[j].TeamID })
+	return tokens, nil
+}
+
+func (f *Fake) DeleteClickUpToken(ctx context.Context, userID int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var deleted int64
+	for key := range f.clickUpTokens {
+		if key.userID == userID {
+			delete(f.clickUpTokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ClickUp workspaces
+
+func (f *Fake) GetClickUpWorkspace(ctx context.Context, teamID string) (sqlc.ClickupWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspace, ok := f.clickUpWorkspaces[teamID]
+	if !ok {
+		return workspace, pgx.ErrNoRows
+	}
+	return workspace, nil
+}
+
+func (f *Fake) ListClickUpWorkspaces(ctx context.Context) ([]sqlc.ClickupWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspaces := slices.Collect(maps.Values(f.clickUpWorkspaces))
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Name != workspaces[j].Name {
+			return workspaces[i].Name < workspaces[j].Name
+		}
+		return workspaces[i].TeamID < workspaces[j].TeamID
+	})
+	return workspaces, nil
+}
+
+// UpsertClickUpWorkspace adds the workspace, or renames it and makes the user the one whose token
+// syncs it
+func (f *Fake) UpsertClickUpWorkspace(ctx context.Context, arg sqlc.UpsertClickUpWorkspaceParams) (sqlc.ClickupWorkspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	workspace, ok := f.clickUpWorkspaces[arg.TeamID]
+	if !ok {
+		workspace = sqlc.ClickupWorkspace{TeamID: arg.TeamID, CreatedAt: now(), TenantID: db.DefaultTenantID}
+	}
+	workspace.Name = arg.Name
+	workspace.ConnectedByUserID = arg.ConnectedByUserID
+	workspace.UpdatedAt = now()
+	f.clickUpWorkspaces[arg.TeamID] = workspace
+	return workspace, nil
+}
+
+// Tenants
+
+func (f *Fake) CreateTenant(ctx context.Context, arg sqlc.CreateTenantParams) (sqlc.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tenant := sqlc.Tenant{
+		ID:        f.newID(),
+		Slug:      arg.Slug,
+		Name:      arg.Name,
+		CreatedAt: now(),
+	}
+	f.tenants[tenant.ID] = tenant
+	return tenant, nil
+}
+
+func (f *Fake) GetTenantBySlug(ctx context.Context, slug string) (sqlc.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return first(f.tenants, func(t sqlc.Tenant) bool { return t.Slug == slug })
+}
+
+func (f *Fake) ListTenants(ctx context.Context) ([]sqlc.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.tenants, nil, func(a, b sqlc.Tenant) bool { return a.ID < b.ID }), nil
+}
+
+// Personal data
+
+func (f *Fake) ExportLeaveLogs(ctx context.Context, userID int32) ([]sqlc.LeaveLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(withDeleted(f.leaveLogs, f.deletedLeaveLogs),
+		func(l sqlc.LeaveLog) bool { return l.UserID == userID },
+		func(a, b sqlc.LeaveLog) bool {
+			if !a.Date.Time.Equal(b.Date.Time) {
+				return a.Date.Time.Before(b.Date.Time)
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (f *Fake) ExportMedicalExpenses(ctx context.Context, userID int32) ([]sqlc.MedicalExpense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(withDeleted(f.medicalExpenses, f.deletedMedicalExpenses),
+		func(e sqlc.MedicalExpense) bool { return e.UserID == userID },
+		func(a, b sqlc.MedicalExpense) bool {
+			if !a.ReceiptDate.Time.Equal(b.ReceiptDate.Time) {
+				return a.ReceiptDate.Time.Before(b.ReceiptDate.Time)
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (f *Fake) ExportTaskLogs(ctx context.Context, createdByUserID int32) ([]sqlc.TaskLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskLogs,
+		func(l sqlc.TaskLog) bool { return l.CreatedByUserID == createdByUserID },
+		func(a, b sqlc.TaskLog) bool {
+			if !a.WorkedDate.Time.Equal(b.WorkedDate.Time) {
+				return a.WorkedDate.Time.Before(b.WorkedDate.Time)
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (f *Fake) ExportAttendanceSessions(ctx context.Context, userID int32) ([]sqlc.AttendanceSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.attendance,
+		func(s sqlc.AttendanceSession) bool { return s.UserID == userID },
+		func(a, b sqlc.AttendanceSession) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) ExportNotifications(ctx context.Context, userID int32) ([]sqlc.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.notifications,
+		func(n sqlc.Notification) bool { return n.UserID == userID },
+		func(a, b sqlc.Notification) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) ExportTaskComments(ctx context.Context, userID int32) ([]sqlc.TaskComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.taskComments, func(c sqlc.TaskComment) bool { return c.UserID == userID }, oldestCommentFirst), nil
+}
+
+func (f *Fake) ExportApprovalRequests(ctx context.Context, requesterID int32) ([]sqlc.ApprovalRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalRequests,
+		func(r sqlc.ApprovalRequest) bool { return r.RequesterID == requesterID },
+		func(a, b sqlc.ApprovalRequest) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) ExportApprovalDecisions(ctx context.Context, approverID pgtype.Int4) ([]sqlc.ApprovalDecision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.approvalDecisions,
+		func(d sqlc.ApprovalDecision) bool { return d.ApproverID == approverID || d.OnBehalfOfID == approverID },
+		func(a, b sqlc.ApprovalDecision) bool { return a.ID < b.ID },
+	), nil
+}
+
+func (f *Fake) ListFilesOfUser(ctx context.Context, userID int32) ([]sqlc.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expenses := withDeleted(f.medicalExpenses, f.deletedMedicalExpenses)
+	leaveLogs := withDeleted(f.leaveLogs, f.deletedLeaveLogs)
+	return filter(f.files, func(file sqlc.File) bool {
+		if file.OwnerID.Valid && file.OwnerID.Int32 == userID {
+			return true
+		}
+		switch file.Purpose {
+		case "avatar":
+			return file.SubjectID == userID
+		case "medical_receipt":
+			e, ok := expenses[file.SubjectID]
+			return ok && e.UserID == userID
+		case "sick_leave_certificate":
+			l, ok := leaveLogs[file.SubjectID]
+			return ok && l.UserID == userID
+		}
+		return false
+	}, func(a, b sqlc.File) bool { return a.ID < b.ID }), nil
+}
+
+func (f *Fake) GetAnonymization(ctx context.Context, userID int32) (sqlc.Anonymization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.anonymizations, userID)
+}
+
+func (f *Fake) ListUsersToAnonymize(ctx context.Context, arg sqlc.ListUsersToAnonymizeParams) ([]int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []int32{}
+	for _, id := range purgeable(f.deletedUsers, arg.DeletedBefore, func(user sqlc.User) pgtype.Timestamptz { return user.DeletedAt }) {
+		if _, ok := f.anonymizations[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return page(ids, arg.RowLimit, 0), nil
+}
+
+func (f *Fake) AnonymizeUser(ctx context.Context, arg sqlc.AnonymizeUserParams) (sqlc.Anonymization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.deletedUsers[arg.UserID]
+	if !ok {
+		return sqlc.Anonymization{}, pgx.ErrNoRows
+	}
+	name := "anonymized-" + strconv.Itoa(int(user.ID))
+	user.Username = name
+	user.Email = name + "@anonymized.invalid"
+	user.Password = ""
+	user.UpdatedAt = now()
+	f.deletedUsers[user.ID] = user
+
+	anonymization := sqlc.Anonymization{
+		UserID:       user.ID,
+		AnonymizedBy: arg.AnonymizedBy,
+		AnonymizedAt: now(),
+		TenantID:     db.DefaultTenantID,
+	}
+	f.anonymizations[user.ID] = anonymization
+	return anonymization, nil
+}
+
+func (f *Fake) ScrubUserPersonalData(ctx context.Context, userID int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, logs := range []map[int32]sqlc.LeaveLog{f.leaveLogs, f.deletedLeaveLogs} {
+		for id, l := range logs {
+			if l.UserID == userID {
+				l.Note = pgtype.Text{}
+				logs[id] = l
+			}
+		}
+	}
+	for _, expenses := range []map[int32]sqlc.MedicalExpense{f.medicalExpenses, f.deletedMedicalExpenses} {
+		for id, e := range expenses {
+			if e.UserID == userID {
+				e.ReceiptName, e.Note = pgtype.Text{}, pgtype.Text{}
+				expenses[id] = e
+			}
+		}
+	}
+	for id, s := range f.attendance {
+		if s.UserID == userID {
+			s = clearLocation(s)
+			s.Note = pgtype.Text{}
+			f.attendance[id] = s
+		}
+	}
+	for id, n := range f.notifications {
+		if n.UserID == userID {
+			delete(f.notifications, id)
+		}
+	}
+	for key := range f.notificationPrefs {
+		if key.userID == userID {
+			delete(f.notificationPrefs, key)
+		}
+	}
+	for id, k := range f.idempotencyKeys {
+		if k.UserID == userID {
+			delete(f.idempotencyKeys, id)
+		}
+	}
+	for id, d := range f.delegations {
+		if d.DelegatorID == userID || d.DelegateID == userID {
+			delete(f.delegations, id)
+		}
+	}
+	for id, d := range f.pushDevices {
+		if d.UserID == userID {
+			delete(f.pushDevices, id)
+		}
+	}
+	delete(f.languagePrefs, userID)
+	delete(f.timeZonePrefs, userID)
+	delete(f.lineLinks, userID)
+	delete(f.orgAssignments, userID)
+	return nil
+}
+
+func (f *Fake) ClearAttendanceLocations(ctx context.Context, checkedInBefore pgtype.Timestamptz) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var cleared int64
+	for id, s := range f.attendance {
+		located := s.CheckInIp.Valid || s.CheckInLatitude.Valid || s.CheckOutIp.Valid || s.CheckOutLatitude.Valid
+		if located && s.CheckInAt.Time.Before(checkedInBefore.Time) {
+			f.attendance[id] = clearLocation(s)
+			cleared++
+		}
+	}
+	return cleared, nil
+}
+
+// Anomalies
+
+func (f *Fake) ListSickLeaveDays(ctx context.Context, arg sqlc.ListSickLeaveDaysParams) ([]sqlc.ListSickLeaveDaysRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	logs := filter(f.leaveLogs,
+		func(l sqlc.LeaveLog) bool {
+			_, live := f.users[l.UserID]
+			return live && l.Type == "sick" && between(l.Date, arg.StartDate, arg.EndDate)
+		},
+		func(a, b sqlc.LeaveLog) bool {
+			if a.UserID != b.UserID {
+				return a.UserID < b.UserID
+			}
+			return a.Date.Time.Before(b.Date.Time)
+		},
+	)
+	rows := make([]sqlc.ListSickLeaveDaysRow, len(logs))
+	for i, l := range logs {
+		rows[i] = sqlc.ListSickLeaveDaysRow{UserID: l.UserID, Date: l.Date}
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListOverloggedDays(ctx context.Context, arg sqlc.ListOverloggedDaysParams) ([]sqlc.ListOverloggedDaysRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type day struct {
+		userID int32
+		date   time.Time
+	}
+	logged := map[day]float64{}
+	for _, l := range f.taskLogs {
+		if _, live := f.users[l.CreatedByUserID]; !live || !between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		if days, err := l.WorkedDay.Float64Value(); err == nil && days.Valid {
+			logged[day{l.CreatedByUserID, l.WorkedDate.Time}] += days.Float64
+		}
+	}
+	rows := []sqlc.ListOverloggedDaysRow{}
+	for d, days := range logged {
+		user := f.users[d.userID]
+		capacity, err := user.DailyCapacity.Float64Value()
+		if err != nil || !capacity.Valid || days <= capacity.Float64*arg.MaxRatio {
+			continue
+		}
+		rows = append(rows, sqlc.ListOverloggedDaysRow{
+			UserID:        d.userID,
+			WorkedDate:    pgtype.Date{Time: d.date, Valid: true},
+			LoggedDays:    pgconv.MustFromFloat(days),
+			DailyCapacity: user.DailyCapacity,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].UserID != rows[j].UserID {
+			return rows[i].UserID < rows[j].UserID
+		}
+		return rows[i].WorkedDate.Time.Before(rows[j].WorkedDate.Time)
+	})
+	return rows, nil
+}
+
+func (f *Fake) ListExpensesNearApprovalLimit(ctx context.Context, arg sqlc.ListExpensesNearApprovalLimitParams) ([]sqlc.ListExpensesNearApprovalLimitRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListExpensesNearApprovalLimitRow{}
+	policy, ok := f.approvalPolicies["medical_expense"]
+	if !ok || !policy.AutoApproveMax.Valid {
+		return rows, nil
+	}
+	limit, err := policy.AutoApproveMax.Float64Value()
+	if err != nil {
+		return nil, err
+	}
+	expenses := filter(f.medicalExpenses,
+		func(e sqlc.MedicalExpense) bool {
+			_, live := f.users[e.UserID]
+			if !live || !between(e.ReceiptDate, arg.StartDate, arg.EndDate) {
+				return false
+			}
+			amount, err := e.Amount.Float64Value()
+			return err == nil && amount.Float64 <= limit.Float64 && amount.Float64 >= limit.Float64*(1-arg.Margin)
+		},
+		func(a, b sqlc.MedicalExpense) bool {
+			if a.UserID != b.UserID {
+				return a.UserID < b.UserID
+			}
+			if !a.ReceiptDate.Time.Equal(b.ReceiptDate.Time) {
+				return a.ReceiptDate.Time.Before(b.ReceiptDate.Time)
+			}
+			return a.ID < b.ID
+		},
+	)
+	for _, e := range expenses {
+		rows = append(rows, sqlc.ListExpensesNearApprovalLimitRow{
+			ID:            e.ID,
+			UserID:        e.UserID,
+			Amount:        e.Amount,
+			ReceiptDate:   e.ReceiptDate,
+			ApprovalLimit: policy.AutoApproveMax,
+		})
+	}
+	return rows, nil
+}
+
+func (f *Fake) CreateAnomaly(ctx context.Context, arg sqlc.CreateAnomalyParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := first(f.anomalies, func(a sqlc.Anomaly) bool {
+		return a.Rule == arg.Rule && a.UserID == arg.UserID && a.Fingerprint == arg.Fingerprint
+	}); err == nil {
+		return 0, nil
+	}
+	anomaly := sqlc.Anomaly{
+		ID:          f.newID(),
+		Rule:        arg.Rule,
+		UserID:      arg.UserID,
+		PeriodStart: arg.PeriodStart,
+		PeriodEnd:   arg.PeriodEnd,
+		Fingerprint: arg.Fingerprint,
+		Summary:     arg.Summary,
+		Details:     arg.Details,
+		Status:      "open",
+		CreatedAt:   now(),
+		TenantID:    db.DefaultTenantID,
+	}
+	f.anomalies[anomaly.ID] = anomaly
+	return 1, nil
+}
+
+func (f *Fake) GetAnomaly(ctx context.Context, id int32) (sqlc.Anomaly, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.anomalies, id)
+}
+
+func (f *Fake) ListAnomalies(ctx context.Context, arg sqlc.ListAnomaliesParams) ([]sqlc.Anomaly, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return page(f.anomaliesMatching(arg.Status, arg.Rule, arg.UserID), arg.RowLimit, arg.RowOffset), nil
+}
+
+func (f *Fake) CountAnomalies(ctx context.Context, arg sqlc.CountAnomaliesParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.anomaliesMatching(arg.Status, arg.Rule, arg.UserID))), nil
+}
+
+// anomaliesMatching returns the findings of a status, rule and user, those given, newest first.
+// Callers must hold the lock.
+func (f *Fake) anomaliesMatching(status, rule pgtype.Text, userID pgtype.Int4) []sqlc.Anomaly {
+	return filter(f.anomalies,
+		func(a sqlc.Anomaly) bool {
+			return (!status.Valid || a.Status == status.String) &&
+				(!rule.Valid || a.Rule == rule.String) &&
+				(!userID.Valid || a.UserID == userID.Int32)
+		},
+		func(a, b sqlc.Anomaly) bool {
+			if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Time.After(b.CreatedAt.Time)
+			}
+			return a.ID > b.ID
+		},
+	)
+}
+
+func (f *Fake) ReviewAnomaly(ctx context.Context, arg sqlc.ReviewAnomalyParams) (sqlc.Anomaly, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	anomaly, err := get(f.anomalies, arg.ID)
+	if err != nil {
+		return anomaly, err
+	}
+	anomaly.Status = arg.Status
+	anomaly.ReviewedBy = arg.ReviewedBy
+	anomaly.ReviewedAt = now()
+	anomaly.ReviewNote = arg.ReviewNote
+	f.anomalies[anomaly.ID] = anomaly
+	return anomaly, nil
+}
+
+// Announcements
+
+// announcementAckKey is the primary key of announcement_acknowledgments
+type announcementAckKey struct {
+	announcementID int32
+	userID         int32
+}
+
+func (f *Fake) CreateAnnouncement(ctx context.Context, arg sqlc.CreateAnnouncementParams) (sqlc.Announcement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	announcement := sqlc.Announcement{
+		ID:                     f.newID(),
+		Title:                  arg.Title,
+		Body:                   arg.Body,
+		UserTypes:              arg.UserTypes,
+		Departments:            arg.Departments,
+		UserIds:                arg.UserIds,
+		RequiresAcknowledgment: arg.RequiresAcknowledgment,
+		PublishAt:              arg.PublishAt,
+		ExpireAt:               arg.ExpireAt,
+		CreatedBy:              arg.CreatedBy,
+		CreatedAt:              now(),
+		UpdatedAt:              now(),
+		TenantID:               db.DefaultTenantID,
+	}
+	if !announcement.PublishAt.Valid {
+		announcement.PublishAt = announcement.CreatedAt
+	}
+	f.announcements[announcement.ID] = announcement
+	return announcement, nil
+}
+
+func (f *Fake) GetAnnouncement(ctx context.Context, id int32) (sqlc.Announcement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return get(f.announcements, id)
+}
+
+func (f *Fake) ListAnnouncements(ctx context.Context, arg sqlc.ListAnnouncementsParams) ([]sqlc.Announcement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return page(filter(f.announcements, nil, latestAnnouncementFirst), arg.RowLimit, arg.RowOffset), nil
+}
+
+func (f *Fake) CountAnnouncements(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.announcements)), nil
+}
+
+func (f *Fake) ListPublishedAnnouncements(ctx context.Context, at pgtype.Timestamptz) ([]sqlc.Announcement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return filter(f.announcements, func(a sqlc.Announcement) bool {
+		return !a.PublishAt.Time.After(at.Time) && (!a.ExpireAt.Valid || a.ExpireAt.Time.After(at.Time))
+	}, latestAnnouncementFirst), nil
+}
+
+func latestAnnouncementFirst(a, b sqlc.Announcement) bool {
+	if !a.PublishAt.Time.Equal(b.PublishAt.Time) {
+		return a.PublishAt.Time.After(b.PublishAt.Time)
+	}
+	return a.ID > b.ID
+}
+
+func (f *Fake) UpdateAnnouncement(ctx context.Context, arg sqlc.UpdateAnnouncementParams) (sqlc.Announcement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	announcement, ok := f.announcements[arg.ID]
+	if !ok {
+		return sqlc.Announcement{}, pgx.ErrNoRows
+	}
+	announcement.Title = arg.Title
+	announcement.Body = arg.Body
+	announcement.UserTypes = arg.UserTypes
+	announcement.Departments = arg.Departments
+	announcement.UserIds = arg.UserIds
+	announcement.RequiresAcknowledgment = arg.RequiresAcknowledgment
+	announcement.PublishAt = arg.PublishAt
+	announcement.ExpireAt = arg.ExpireAt
+	announcement.UpdatedAt = now()
+	f.announcements[announcement.ID] = announcement
+	return announcement, nil
+}
+
+func (f *Fake) DeleteAnnouncement(ctx context.Context, id int32) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.announcements[id]; !ok {
+		return 0, nil
+	}
+	delete(f.announcements, id)
+	for key := range f.announcementAcks {
+		if key.announcementID == id {
+			delete(f.announcementAcks, key)
+		}
+	}
+	return 1, nil
+}
+
+func (f *Fake) AcknowledgeAnnouncement(ctx context.Context, arg sqlc.AcknowledgeAnnouncementParams) (sqlc.AnnouncementAcknowledgment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := announcementAckKey{announcementID: arg.AnnouncementID, userID: arg.UserID}
+	if ack, ok := f.announcementAcks[key]; ok {
+		return ack, nil
+	}
+	ack := sqlc.AnnouncementAcknowledgment{
+		AnnouncementID: arg.AnnouncementID,
+		UserID:         arg.UserID,
+		AcknowledgedAt: now(),
+		TenantID:       db.DefaultTenantID,
+	}
+	f.announcementAcks[key] = ack
+	return ack, nil
+}
+
+func (f *Fake) ListAnnouncementAcknowledgments(ctx context.Context, announcementID int32) ([]sqlc.ListAnnouncementAcknowledgmentsRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := []sqlc.ListAnnouncementAcknowledgmentsRow{}
+	for key, ack := range f.announcementAcks {
+		user, live := f.users[key.userID]
+		if key.announcementID != announcementID || !live {
+			continue
+		}
+		rows = append(rows, sqlc.ListAnnouncementAcknowledgmentsRow{UserID: user.ID, Username: user.Username, AcknowledgedAt: ack.AcknowledgedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].AcknowledgedAt.Time.Equal(rows[j].AcknowledgedAt.Time) {
+			return rows[i].AcknowledgedAt.Time.Before(rows[j].AcknowledgedAt.Time)
+		}
+		return rows[i].UserID < rows[j].UserID
+	})
+	return rows, nil
+}
+
+func (f *Fake) ListAnnouncementAcknowledgmentsByUser(ctx context.Context, userID int32) ([]sqlc.AnnouncementAcknowledgment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	acks := []sqlc.AnnouncementAcknowledgment{}
+	for key, ack := range f.announcementAcks {
+		if key.userID == userID {
+			acks = append(acks, ack)
+		}
+	}
+	sort.Slice(acks, func(i, j int) bool { return acks[i].AcknowledgedAt.Time.Before(acks[j].AcknowledgedAt.Time) })
+	return acks, nil
+}
+
+// Year in review
+
+func (f *Fake) SummarizeLeaveByMonth(ctx context.Context, arg sqlc.SummarizeLeaveByMonthParams) ([]sqlc.SummarizeLeaveByMonthRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type monthType struct {
+		month int32
+		typ   string
+	}
+	counts := map[monthType]int64{}
+	for _, l := range f.leaveLogs {
+		if l.UserID == arg.UserID && between(l.Date, arg.StartDate, arg.EndDate) {
+			counts[monthType{int32(l.Date.Time.Month()), l.Type}]++
+		}
+	}
+	rows := make([]sqlc.SummarizeLeaveByMonthRow, 0, len(counts))
+	for k, count := range counts {
+		rows = append(rows, sqlc.SummarizeLeaveByMonthRow{Month: k.month, Type: k.typ, DayCount: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Month != rows[j].Month {
+			return rows[i].Month < rows[j].Month
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows, nil
+}
+
+// ListTaskCategoryWorkByUser groups the work by the category of its task
+func (f *Fake) ListTaskCategoryWorkByUser(ctx context.Context, arg sqlc.ListTaskCategoryWorkByUserParams) ([]sqlc.ListTaskCategoryWorkByUserRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tasks := withDeleted(f.tasks, f.deletedTasks)
+	worked := map[pgtype.Int4]float64{}
+	for _, l := range f.taskLogs {
+		task, ok := tasks[l.TaskID]
+		if !ok || l.CreatedByUserID != arg.UserID || !between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		if days, err := l.WorkedDay.Float64Value(); err == nil && days.Valid {
+			worked[task.TaskCategoryID] += days.Float64
+		}
+	}
+	rows := make([]sqlc.ListTaskCategoryWorkByUserRow, 0, len(worked))
+	for categoryID, days := range worked {
+		row := sqlc.ListTaskCategoryWorkByUserRow{TaskCategoryID: categoryID, WorkedDay: pgconv.MustFromFloat(days)}
+		if category, ok := f.taskCategories[categoryID.Int32]; ok && categoryID.Valid {
+			row.CategoryName = pgtype.Text{String: category.Name, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, _ := rows[i].WorkedDay.Float64Value()
+		b, _ := rows[j].WorkedDay.Float64Value()
+		if a.Float64 != b.Float64 {
+			return a.Float64 > b.Float64
+		}
+		if rows[i].CategoryName != rows[j].CategoryName {
+			return rows[i].CategoryName.String < rows[j].CategoryName.String
+		}
+		return rows[i].TaskCategoryID.Int32 < rows[j].TaskCategoryID.Int32
+	})
+	return rows, nil
+}
+
+func (f *Fake) ListHolidayWorkByUser(ctx context.Context, arg sqlc.ListHolidayWorkByUserParams) ([]sqlc.ListHolidayWorkByUserRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	worked := map[time.Time]float64{}
+	for _, l := range f.taskLogs {
+		if l.CreatedByUserID != arg.UserID || !l.IsWorkOnHoliday.Bool || !between(l.WorkedDate, arg.StartDate, arg.EndDate) {
+			continue
+		}
+		if days, err := l.WorkedDay.Float64Value(); err == nil && days.Valid {
+			worked[l.WorkedDate.Time] += days.Float64
+		}
+	}
+	rows := make([]sqlc.ListHolidayWorkByUserRow, 0, len(worked))
+	for date, days := range worked {
+		row := sqlc.ListHolidayWorkByUserRow{
+			WorkedDate: pgtype.Date{Time: date, Valid: true},
+			WorkedDay:  pgconv.MustFromFloat(days),
+		}
+		if holiday, err := first(f.holidays, func(h sqlc.Holiday) bool { return sameDate(h.Date, row.WorkedDate) }); err == nil {
+			row.HolidayName = pgtype.Text{String: holiday.Name, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].WorkedDate.Time.Before(rows[j].WorkedDate.Time) })
+	return rows, nil
+}
+
+// clearLocation drops the IPs and coordinates of a check-in and its check-out
+func clearLocation(s sqlc.AttendanceSession) sqlc.AttendanceSession {
+	s.CheckInIp, s.CheckOutIp = pgtype.Text{}, pgtype.Text{}
+	s.CheckInLatitude, s.CheckInLongitude = pgtype.Float8{}, pgtype.Float8{}
+	s.CheckOutLatitude, s.CheckOutLongitude = pgtype.Float8{}, pgtype.Float8{}
+	return s
+}
+
+// withDeleted returns the live rows and the soft deleted ones, as a query not filtering on
+// deleted_at sees them
+func withDeleted[T any](rows, deleted map[int32]T) map[int32]T {
+	all := make(map[int32]T, len(rows)+len(deleted))
+	maps.Copy(all, rows)
+	maps.Copy(all, deleted)
+	return all
+}
+
+// inDepartment reports whether a department name is the department or one under it, as
+// department_and_below does. Callers must hold the lock.
+func (f *Fake) inDepartment(name pgtype.Text, department string) bool {
+	if !name.Valid {
+		return false
+	}
+	if name.String == department {
+		return true
+	}
+	d, err := first(f.departments, func(d sqlc.Department) bool { return d.Name == name.String })
+	// Walk up to the top, a chart with a cycle stops once every department has been seen
+	for seen := 0; err == nil && d.ParentID.Valid && seen <= len(f.departments); seen++ {
+		if d, err = get(f.departments, d.ParentID.Int32); err == nil && d.Name == department {
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns the next row ID, callers must hold the lock. IDs are shared by all tables.
+func (f *Fake) newID() int32 {
+	f.nextID++
+	return f.nextID
+}
+
+// get returns the row with the ID, or pgx.ErrNoRows like the generated :one queries
+func get[T any](rows map[int32]T, id int32) (T, error) {
+	row, ok := rows[id]
+	if !ok {
+		return row, pgx.ErrNoRows
+	}
+	return row, nil
+}
+
+// first returns the matching row with the lowest ID, or pgx.ErrNoRows
+func first[T any](rows map[int32]T, match func(T) bool) (T, error) {
+	var found T
+	foundID := int32(-1)
+	for id, row := range rows {
+		if match(row) && (foundID < 0 || id < foundID) {
+			found, foundID = row, id
+		}
+	}
+	if foundID < 0 {
+		return found, pgx.ErrNoRows
+	}
+	return found, nil
+}
+
+// filter returns the rows matching keep, all of them when keep is nil, ordered by less
+func filter[T any](rows map[int32]T, keep func(T) bool, less func(a, b T) bool) []T {
+	result := []T{}
+	for _, row := range rows {
+		if keep == nil || keep(row) {
+			result = append(result, row)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
+
+// page applies LIMIT and OFFSET to sorted rows
+func page[T any](rows []T, limit, offset int32) []T {
+	if int(offset) >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if limit >= 0 && int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// byDate orders rows by a date column, newest first when desc is set
+func byDate[T any](date func(T) pgtype.Date, desc bool) func(a, b T) bool {
+	return func(a, b T) bool {
+		if desc {
+			return date(a).Time.After(date(b).Time)
+		}
+		return date(a).Time.Before(date(b).Time)
+	}
+}
+
+// version returns the row count and newest updated_at of a table, like the generated Version queries
+func version[T any](rows map[int32]T, updatedAt func(T) pgtype.Timestamptz) (int64, pgtype.Timestamptz) {
+	var newest pgtype.Timestamptz
+	for _, row := range rows {
+		if t := updatedAt(row); t.Valid && (!newest.Valid || t.Time.After(newest.Time)) {
+			newest = t
+		}
+	}
+	return int64(len(rows)), newest
+}
+
+// between reports whether date falls in the inclusive range, like SQL BETWEEN
+func between(date, from, to pgtype.Date) bool {
+	return date.Valid && !date.Time.Before(from.Time) && !date.Time.After(to.Time)
+}
+
+// softDelete sets deleted_at on a row and moves it to the deleted rows, out of reach of reads
+func softDelete[T any](rows, deleted map[int32]T, id int32, deletedAt func(*T) *pgtype.Timestamptz) {
+	row, ok := rows[id]
+	if !ok {
+		return
+	}
+	*deletedAt(&row) = now()
+	deleted[id] = row
+	delete(rows, id)
+}
+
+// purgeable returns the IDs of the rows deleted before the cutoff, oldest deletion first
+func purgeable[T any](deleted map[int32]T, before pgtype.Timestamptz, deletedAt func(T) pgtype.Timestamptz) []int32 {
+	ids := []int32{}
+	for id, row := range deleted {
+		if deletedAt(row).Time.Before(before.Time) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return deletedAt(deleted[ids[i]]).Time.Before(deletedAt(deleted[ids[j]]).Time)
+	})
+	return ids
+}
+
+// purge removes a deleted row for good and returns the number of rows removed
+func purge[T any](deleted map[int32]T, id int32) int64 {
+	if _, ok := deleted[id]; !ok {
+		return 0
+	}
+	delete(deleted, id)
+	return 1
+}
+
+// restore moves a deleted row back to the live rows, clearing deleted_at, and returns the number
+// of rows restored
+func restore[T any](rows, deleted map[int32]T, id int32, deletedAt func(*T) *pgtype.Timestamptz) int64 {
+	row, ok := deleted[id]
+	if !ok {
+		return 0
+	}
+	*deletedAt(&row) = pgtype.Timestamptz{}
+	rows[id] = row
+	delete(deleted, id)
+	return 1
+}
+
+// stale reports whether an update made against expected would match no row, like the
+// expected_updated_at check of the generated queries
+func stale(expected, current pgtype.Timestamptz) bool {
+	return expected.Valid && !expected.Time.Equal(current.Time)
+}
+
+func sameDate(a, b pgtype.Date) bool {
+	return a.Valid && b.Valid && a.Time.Equal(b.Time)
+}
+
+// decimal returns a NUMERIC as an exact decimal, NULL as zero
+func decimal(n pgtype.Numeric) *big.Rat {
+	r, err := pgconv.ToDecimal(n)
+	if err != nil {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// numeric returns an exact decimal as a NUMERIC with the two decimal places of the totals columns
+func numeric(r *big.Rat) pgtype.Numeric {
+	n, _ := pgconv.FromString(r.FloatString(2))
+	return n
+}
+
+// orZero returns NULL as zero, like COALESCE(n, 0)
+func orZero(n pgtype.Numeric) pgtype.Numeric {
+	if n.Valid {
+		return n
+	}
+	return numeric(new(big.Rat))
+}
+
+func now() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+}
@@ -0,0 +1,36 @@
+package dbtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	store := NewFake()
+	errAbort := errors.New("abort")
+
+	err := store.WithTx(t.Context(), func(q sqlc.Querier) error {
+		if _, err := q.CreateUser(t.Context(), sqlc.CreateUserParams{Username: "alice", UserType: "user"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx() error = %v, want %v", err, errAbort)
+	}
+	if _, err := store.GetUserByUsername(t.Context(), "alice"); err == nil {
+		t.Error("the user created by the failed transaction was kept")
+	}
+
+	if err := store.WithTx(t.Context(), func(q sqlc.Querier) error {
+		_, err := q.CreateUser(t.Context(), sqlc.CreateUserParams{Username: "bob", UserType: "user"})
+		return err
+	}); err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if _, err := store.GetUserByUsername(t.Context(), "bob"); err != nil {
+		t.Errorf("GetUserByUsername() error = %v, want the committed user", err)
+	}
+}
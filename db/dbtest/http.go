@@ -0,0 +1,72 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// DoJSON sends a request to handler and returns the recorded response. body is encoded as JSON
+// unless it is nil, and token, when not empty, goes in the Authorization header as a bearer
+// token.
+func DoJSON(tb testing.TB, handler http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	tb.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			tb.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// DecodeJSON decodes the body of a recorded response into v, failing the test when the status
+// is not want
+func DecodeJSON(tb testing.TB, recorder *httptest.ResponseRecorder, want int, v interface{}) {
+	tb.Helper()
+
+	if recorder.Code != want {
+		tb.Fatalf("status %d, want %d: %s", recorder.Code, want, recorder.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		tb.Fatalf("decoding response %q: %v", recorder.Body.String(), err)
+	}
+}
+
+// CreateUser adds a user for a test to act as or about
+func CreateUser(tb testing.TB, store db.Store, username, userType string) sqlc.User {
+	tb.Helper()
+
+	user, err := store.CreateUser(context.Background(), sqlc.CreateUserParams{
+		Username: username,
+		Password: "-",
+		UserType: userType,
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		tb.Fatalf("creating user %s: %v", username, err)
+	}
+	return user
+}
@@ -0,0 +1,210 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/migrations"
+)
+
+// PostgresImage is the Docker image the integration database runs
+const PostgresImage = "postgres:16-alpine"
+
+// postgresStartTimeout bounds how long a new container may take to accept connections
+const postgresStartTimeout = time.Minute
+
+// Postgres is a throwaway database in a Docker container, with every migration applied
+type Postgres struct {
+	// URL is the connection string of the database
+	URL string
+
+	containerID string
+}
+
+// StartPostgres runs a Postgres container and migrates it. When TEST_DATABASE_URL is set that
+// database is migrated and used instead, and Stop leaves it alone. It must be a database kept
+// for tests, Reset empties it.
+//
+// Starting a container takes seconds, so a package usually starts one in TestMain and calls
+// Reset between tests, or uses NewPostgres which shares one container per test binary.
+func StartPostgres(ctx context.Context) (*Postgres, error) {
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		postgres := &Postgres{URL: url}
+		return postgres, postgres.migrate(ctx)
+	}
+
+	containerID, err := docker(ctx, "run", "--detach", "--rm",
+		"--env", "POSTGRES_PASSWORD=postgres",
+		"--env", "POSTGRES_DB=ngtableg_test",
+		"--publish", "127.0.0.1::5432",
+		PostgresImage)
+	if err != nil {
+		return nil, err
+	}
+	postgres := &Postgres{containerID: containerID}
+
+	address, err := docker(ctx, "port", containerID, "5432/tcp")
+	if err != nil {
+		postgres.Stop()
+		return nil, err
+	}
+	// docker port prints one line per address family, the first is the IPv4 one we bound
+	address = strings.SplitN(address, "\n", 2)[0]
+	postgres.URL = fmt.Sprintf("postgres://postgres:postgres@%s/ngtableg_test?sslmode=disable", address)
+
+	if err := postgres.waitReady(ctx); err != nil {
+		postgres.Stop()
+		return nil, err
+	}
+	if err := postgres.migrate(ctx); err != nil {
+		postgres.Stop()
+		return nil, err
+	}
+	return postgres, nil
+}
+
+// Stop removes the container, a no-op for a TEST_DATABASE_URL database
+func (p *Postgres) Stop() error {
+	if p.containerID == "" {
+		return nil
+	}
+	_, err := docker(context.Background(), "rm", "--force", p.containerID)
+	return err
+}
+
+// Open connects to the database. The caller closes the returned DB.
+func (p *Postgres) Open() (*db.DB, error) {
+	return db.Open(p.URL)
+}
+
+// Reset empties every table except the migration history and restarts the ids, so each test
+// starts from the bare schema with only the default tenant
+func (p *Postgres) Reset(ctx context.Context, database *db.DB) error {
+	_, err := database.Pool.Exec(ctx, `
+		DO $$
+		DECLARE
+			tables TEXT;
+		BEGIN
+			SELECT string_agg(format('%I.%I', schemaname, tablename), ', ')
+			INTO tables
+			FROM pg_tables
+			WHERE schemaname = 'public' AND tablename <> 'schema_migrations';
+
+			IF tables IS NOT NULL THEN
+				EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+			END IF;
+
+			INSERT INTO tenants (slug, name) VALUES ('default', 'Default');
+		END $$`)
+	return err
+}
+
+// waitReady polls until the server accepts connections. The image restarts Postgres once after
+// initialising the database, and only listens on TCP after that restart.
+func (p *Postgres) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, postgresStartTimeout)
+	defer cancel()
+
+	for {
+		conn, err := pgx.Connect(ctx, p.URL)
+		if err == nil {
+			err = conn.Ping(ctx)
+			conn.Close(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("postgres container did not start: %w", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func (p *Postgres) migrate(ctx context.Context) error {
+	database, err := p.Open()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if _, err := migrations.Up(ctx, database.Pool); err != nil {
+		return fmt.Errorf("migrating the test database: %w", err)
+	}
+	return nil
+}
+
+// docker runs a docker command and returns its trimmed output
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+var (
+	sharedOnce     sync.Once
+	sharedPostgres *Postgres
+	sharedErr      error
+)
+
+// NewPostgres returns a connection to an empty, migrated database for one test. All tests of a
+// test binary share one container, which is reset before each test, so tests using it must not
+// run in parallel. The test is skipped when neither Docker nor TEST_DATABASE_URL is available.
+//
+// The shared container is not removed when the binary exits, call StopShared from TestMain for
+// that; containers left behind are removed by Docker when they stop, since they run with --rm.
+func NewPostgres(tb testing.TB) *db.DB {
+	tb.Helper()
+
+	sharedOnce.Do(func() {
+		if os.Getenv("TEST_DATABASE_URL") == "" {
+			if _, err := exec.LookPath("docker"); err != nil {
+				sharedErr = errDockerMissing
+				return
+			}
+		}
+		sharedPostgres, sharedErr = StartPostgres(context.Background())
+	})
+	if sharedErr == errDockerMissing {
+		tb.Skip("integration test needs Docker or TEST_DATABASE_URL")
+	}
+	if sharedErr != nil {
+		tb.Fatalf("starting the test database: %v", sharedErr)
+	}
+
+	database, err := sharedPostgres.Open()
+	if err != nil {
+		tb.Fatalf("connecting to the test database: %v", err)
+	}
+	tb.Cleanup(database.Close)
+
+	if err := sharedPostgres.Reset(context.Background(), database); err != nil {
+		tb.Fatalf("resetting the test database: %v", err)
+	}
+	return database
+}
+
+// StopShared removes the container NewPostgres started, if any
+func StopShared() error {
+	if sharedPostgres == nil {
+		return nil
+	}
+	return sharedPostgres.Stop()
+}
+
+var errDockerMissing = fmt.Errorf("docker not found")
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaderPingTimeout bounds the check that the leader's connection, and with it the lock, is
+// still alive
+const leaderPingTimeout = 5 * time.Second
+
+// LeaderElection elects one of several server instances, the one holding a session advisory
+// lock. The lock is held on a connection taken out of the pool, Postgres releases it when that
+// connection closes, so another instance takes over at its next check when the leader dies.
+type LeaderElection struct {
+	pool   *pgxpool.Pool
+	lockID int64
+
+	mu   sync.Mutex
+	conn *pgx.Conn // Holding the lock, nil while another instance leads
+}
+
+// NewLeaderElection creates an election over the advisory lock with the given key
+func NewLeaderElection(pool *pgxpool.Pool, lockID int64) *LeaderElection {
+	return &LeaderElection{pool: pool, lockID: lockID}
+}
+
+// IsLeader reports whether this instance leads, trying to take the lock when it doesn't
+func (l *LeaderElection) IsLeader(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		pingCtx, cancel := context.WithTimeout(ctx, leaderPingTimeout)
+		err := l.conn.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return true
+		}
+		slog.WarnContext(ctx, "Lost leadership, the connection holding the lock failed", "instance", Instance, "error", err)
+		l.conn.Close(context.Background())
+		l.conn = nil
+	}
+
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Error running for leader", "error", err)
+		return false
+	}
+	var locked bool
+	if err := pooled.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", l.lockID).Scan(&locked); err != nil || !locked {
+		if err != nil {
+			slog.WarnContext(ctx, "Error running for leader", "error", err)
+		}
+		pooled.Release()
+		return false
+	}
+	// The lock lives as long as the connection, which must not go back to the pool
+	l.conn = pooled.Hijack()
+	slog.InfoContext(ctx, "Elected leader", "instance", Instance)
+	return true
+}
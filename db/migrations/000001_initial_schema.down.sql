@@ -0,0 +1,12 @@
+-- Revert the base schema, dropping every table and its data
+
+DROP TABLE IF EXISTS leave_logs;
+DROP TABLE IF EXISTS medical_expenses;
+DROP TABLE IF EXISTS task_logs;
+DROP TABLE IF EXISTS task_estimates;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS task_categories;
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS annual_records;
+DROP TABLE IF EXISTS quota_plans;
+DROP TABLE IF EXISTS users;
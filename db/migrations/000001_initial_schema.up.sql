@@ -1,6 +1,6 @@
--- PostgreSQL schema for P'Keng TableG
+-- Migration script to create the base schema, existing databases keep their tables
 
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     username VARCHAR(255) NOT NULL UNIQUE,
     password VARCHAR(255) NOT NULL,
@@ -10,24 +10,36 @@ CREATE TABLE users (
     updated_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE annual_records (
+-- Quota plans replace the per-record quota columns
+CREATE TABLE IF NOT EXISTS quota_plans (
+    id SERIAL PRIMARY KEY,
+    plan_name VARCHAR(255) NOT NULL,
+    year INTEGER NOT NULL,
+    quota_vacation_day DECIMAL(5,2) DEFAULT 0,
+    quota_medical_expense_baht DECIMAL(10,2) DEFAULT 0,
+    created_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(plan_name, year)
+);
+
+CREATE TABLE IF NOT EXISTS annual_records (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id),
     year INTEGER NOT NULL,
+    quota_plan_id INTEGER REFERENCES quota_plans(id),
     rollover_vacation_day DECIMAL(5,2) DEFAULT 0,
-    quota_vacation_day DECIMAL(5,2) DEFAULT 0,
     used_vacation_day DECIMAL(5,2) DEFAULT 0,
     used_sick_leave_day DECIMAL(5,2) DEFAULT 0,
     worked_on_holiday_day DECIMAL(5,2) DEFAULT 0,
     worked_day DECIMAL(5,2) DEFAULT 0,
-    quota_medical_expense_baht DECIMAL(10,2) DEFAULT 0,
     used_medical_expense_baht DECIMAL(10,2) DEFAULT 0,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
     UNIQUE(user_id, year)
 );
 
-CREATE TABLE holidays (
+CREATE TABLE IF NOT EXISTS holidays (
     id SERIAL PRIMARY KEY,
     date DATE NOT NULL UNIQUE,
     name VARCHAR(255) NOT NULL,
@@ -35,7 +47,7 @@ CREATE TABLE holidays (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE task_categories (
+CREATE TABLE IF NOT EXISTS task_categories (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     parent_id INTEGER REFERENCES task_categories(id),
@@ -44,7 +56,7 @@ CREATE TABLE task_categories (
     updated_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE tasks (
+CREATE TABLE IF NOT EXISTS tasks (
     id SERIAL PRIMARY KEY,
     url TEXT,
     task_category_id INTEGER REFERENCES task_categories(id),
@@ -56,7 +68,7 @@ CREATE TABLE tasks (
     updated_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE task_estimates (
+CREATE TABLE IF NOT EXISTS task_estimates (
     id SERIAL PRIMARY KEY,
     task_id INTEGER NOT NULL REFERENCES tasks(id),
     estimate_day DECIMAL(5,2) NOT NULL,
@@ -65,7 +77,7 @@ CREATE TABLE task_estimates (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE task_logs (
+CREATE TABLE IF NOT EXISTS task_logs (
     id SERIAL PRIMARY KEY,
     task_id INTEGER NOT NULL REFERENCES tasks(id),
     worked_day DECIMAL(5,2) NOT NULL,
@@ -75,7 +87,7 @@ CREATE TABLE task_logs (
     is_work_on_holiday BOOLEAN DEFAULT FALSE
 );
 
-CREATE TABLE medical_expenses (
+CREATE TABLE IF NOT EXISTS medical_expenses (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id),
     amount DECIMAL(10,2) NOT NULL,
@@ -85,7 +97,7 @@ CREATE TABLE medical_expenses (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
-CREATE TABLE leave_logs (
+CREATE TABLE IF NOT EXISTS leave_logs (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id),
     type VARCHAR(50) NOT NULL,
@@ -95,12 +107,14 @@ CREATE TABLE leave_logs (
 );
 
 -- Create indexes for foreign keys
-CREATE INDEX idx_annual_records_user_id ON annual_records(user_id);
-CREATE INDEX idx_task_categories_parent_id ON task_categories(parent_id);
-CREATE INDEX idx_tasks_task_category_id ON tasks(task_category_id);
-CREATE INDEX idx_task_estimates_task_id ON task_estimates(task_id);
-CREATE INDEX idx_task_estimates_created_by_user_id ON task_estimates(created_by_user_id);
-CREATE INDEX idx_task_logs_task_id ON task_logs(task_id);
-CREATE INDEX idx_task_logs_created_by_user_id ON task_logs(created_by_user_id);
-CREATE INDEX idx_medical_expenses_user_id ON medical_expenses(user_id);
-CREATE INDEX idx_leave_logs_user_id ON leave_logs(user_id); 
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_annual_records_user_id ON annual_records(user_id);
+CREATE INDEX IF NOT EXISTS idx_annual_records_quota_plan_id ON annual_records(quota_plan_id);
+CREATE INDEX IF NOT EXISTS idx_quota_plans_created_by_user_id ON quota_plans(created_by_user_id);
+CREATE INDEX IF NOT EXISTS idx_task_categories_parent_id ON task_categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_task_category_id ON tasks(task_category_id);
+CREATE INDEX IF NOT EXISTS idx_task_estimates_task_id ON task_estimates(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_estimates_created_by_user_id ON task_estimates(created_by_user_id);
+CREATE INDEX IF NOT EXISTS idx_task_logs_task_id ON task_logs(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_logs_created_by_user_id ON task_logs(created_by_user_id);
+CREATE INDEX IF NOT EXISTS idx_medical_expenses_user_id ON medical_expenses(user_id);
+CREATE INDEX IF NOT EXISTS idx_leave_logs_user_id ON leave_logs(user_id); 
\ No newline at end of file
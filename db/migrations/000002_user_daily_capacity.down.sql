@@ -0,0 +1,3 @@
+-- Revert per-user daily capacity
+
+ALTER TABLE users DROP COLUMN IF EXISTS daily_capacity;
@@ -0,0 +1,5 @@
+-- Migration script to add per-user daily capacity for part-time staff
+
+-- 1. Add daily_capacity column to users (full-time staff default to 1 day per day)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS daily_capacity DECIMAL(3,2) NOT NULL DEFAULT 1.00
+    CHECK (daily_capacity > 0 AND daily_capacity <= 1);
@@ -0,0 +1,4 @@
+-- Revert two-way ClickUp task sync
+
+DROP TABLE IF EXISTS task_sync_history;
+ALTER TABLE tasks DROP COLUMN IF EXISTS clickup_synced_at;
@@ -0,0 +1,19 @@
+-- Migration script to support two-way ClickUp task sync
+
+-- 1. Track when each task was last reconciled with ClickUp
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS clickup_synced_at TIMESTAMPTZ;
+
+-- 2. Create the task_sync_history table
+CREATE TABLE IF NOT EXISTS task_sync_history (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    direction VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    changed_fields TEXT,
+    message TEXT,
+    remote_updated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- 3. Create an index for task_id
+CREATE INDEX IF NOT EXISTS idx_task_sync_history_task_id ON task_sync_history(task_id);
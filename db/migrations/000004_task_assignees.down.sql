@@ -0,0 +1,3 @@
+-- Revert task assignment
+
+DROP TABLE IF EXISTS task_assignees;
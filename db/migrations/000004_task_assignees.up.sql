@@ -0,0 +1,13 @@
+-- Migration script to add task assignment to internal users
+
+-- 1. Create the task_assignees table
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by_user_id INTEGER REFERENCES users(id),
+    assigned_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+-- 2. Create an index for user_id to list a user's tasks
+CREATE INDEX IF NOT EXISTS idx_task_assignees_user_id ON task_assignees(user_id);
@@ -0,0 +1,8 @@
+-- Migration script to add due dates and priority to tasks
+
+-- 1. Add due_date and priority columns to tasks (priority follows ClickUp: 1 urgent to 4 low)
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date DATE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority INTEGER CHECK (priority BETWEEN 1 AND 4);
+
+-- 2. Create an index for due_date to find overdue tasks
+CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
@@ -0,0 +1,4 @@
+-- Revert task comments and the task activity feed
+
+DROP TABLE IF EXISTS task_activities;
+DROP TABLE IF EXISTS task_comments;
@@ -0,0 +1,27 @@
+-- Migration script to add task comments and the task activity feed
+
+-- 1. Create the task_comments table
+CREATE TABLE IF NOT EXISTS task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- 2. Create the task_activities table
+CREATE TABLE IF NOT EXISTS task_activities (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id),
+    activity_type VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    reference_id INTEGER,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- 3. Create indexes for task_id
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_activities_task_id ON task_activities(task_id);
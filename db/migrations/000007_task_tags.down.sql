@@ -0,0 +1,4 @@
+-- Revert task tags
+
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS tags;
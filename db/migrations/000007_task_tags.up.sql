@@ -0,0 +1,21 @@
+-- Migration script to add tags and the task_tags join table
+
+-- 1. Create the tags table
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    color VARCHAR(20),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- 2. Create the task_tags table
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (task_id, tag_id)
+);
+
+-- 3. Create an index for looking up tasks by tag
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags(tag_id);
@@ -0,0 +1,7 @@
+-- Migration script to add parent/subtask relationships to tasks
+
+-- 1. Add parent_task_id to tasks; subtasks become top-level tasks when their parent is deleted
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL;
+
+-- 2. Create an index for listing subtasks
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
@@ -0,0 +1,3 @@
+-- Revert task ownership
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS created_by_user_id;
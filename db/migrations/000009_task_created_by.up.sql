@@ -0,0 +1,4 @@
+-- Migration script to record who created each task
+
+-- 1. Add created_by_user_id to tasks; existing tasks stay without an owner
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS created_by_user_id INTEGER REFERENCES users(id);
@@ -0,0 +1,3 @@
+-- Revert task category archiving
+
+ALTER TABLE task_categories DROP COLUMN IF EXISTS archived_at;
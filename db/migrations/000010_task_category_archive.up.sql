@@ -0,0 +1,4 @@
+-- Migration script to let task categories be archived instead of deleted
+
+-- 1. Add archived_at to task_categories; archived categories are hidden from listings
+ALTER TABLE task_categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
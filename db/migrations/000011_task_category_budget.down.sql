@@ -0,0 +1,3 @@
+-- Revert task category budgets
+
+ALTER TABLE task_categories DROP COLUMN IF EXISTS budget_day;
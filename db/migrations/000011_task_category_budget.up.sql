@@ -0,0 +1,4 @@
+-- Migration script to give task categories an optional effort budget
+
+-- 1. Add budget_day to task_categories, in days for the category and its subcategories
+ALTER TABLE task_categories ADD COLUMN IF NOT EXISTS budget_day DECIMAL(7,2);
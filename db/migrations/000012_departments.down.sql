@@ -0,0 +1,4 @@
+-- Revert departments
+
+ALTER TABLE task_categories DROP COLUMN IF EXISTS department;
+ALTER TABLE users DROP COLUMN IF EXISTS department;
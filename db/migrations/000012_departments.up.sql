@@ -0,0 +1,7 @@
+-- Migration script to scope task categories to departments
+
+-- 1. Add department to users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS department VARCHAR(100);
+
+-- 2. Add department to task_categories; subcategories inherit it from their nearest scoped ancestor
+ALTER TABLE task_categories ADD COLUMN IF NOT EXISTS department VARCHAR(100);
@@ -0,0 +1,3 @@
+-- Revert manual task category order
+
+ALTER TABLE task_categories DROP COLUMN IF EXISTS sort_order;
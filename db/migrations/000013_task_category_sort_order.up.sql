@@ -0,0 +1,15 @@
+-- Migration script to let task categories be ordered manually
+
+-- 1. Add sort_order to task_categories
+ALTER TABLE task_categories ADD COLUMN IF NOT EXISTS sort_order INTEGER NOT NULL DEFAULT 0 CHECK (sort_order >= 0);
+
+-- 2. Number existing siblings alphabetically so the current order is kept, unless they were
+--    already ordered by hand
+UPDATE task_categories tc
+SET sort_order = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY name) - 1 AS position
+    FROM task_categories
+) ordered
+WHERE tc.id = ordered.id
+  AND NOT EXISTS (SELECT 1 FROM task_categories WHERE sort_order > 0);
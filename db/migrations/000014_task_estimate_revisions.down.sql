@@ -0,0 +1,6 @@
+-- Revert task estimate revisions
+
+DROP INDEX IF EXISTS idx_task_estimates_current;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS superseded_at;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS supersedes_id;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS is_current;
@@ -0,0 +1,27 @@
+-- Migration script to track task estimates as revisions with one current estimate per task
+
+-- 1. Add the revision columns to task_estimates
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS is_current BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS supersedes_id INTEGER REFERENCES task_estimates(id) ON DELETE SET NULL;
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMPTZ;
+
+-- 2. Chain existing estimates of each task by creation time, unless they are already chained
+WITH ordered AS (
+    SELECT
+        id,
+        LAG(id) OVER (PARTITION BY task_id ORDER BY created_at, id) AS previous_id,
+        LEAD(created_at) OVER (PARTITION BY task_id ORDER BY created_at, id) AS next_created_at,
+        ROW_NUMBER() OVER (PARTITION BY task_id ORDER BY created_at DESC, id DESC) AS newest_rank
+    FROM task_estimates
+)
+UPDATE task_estimates te
+SET
+    supersedes_id = ordered.previous_id,
+    superseded_at = ordered.next_created_at,
+    is_current = ordered.newest_rank = 1
+FROM ordered
+WHERE te.id = ordered.id
+  AND NOT EXISTS (SELECT 1 FROM task_estimates WHERE is_current);
+
+-- 3. Allow only one current estimate per task
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_estimates_current ON task_estimates(task_id) WHERE is_current;
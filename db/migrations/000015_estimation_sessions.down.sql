@@ -0,0 +1,8 @@
+-- Revert estimation sessions, dropping the votes cast in them
+
+DELETE FROM task_estimates WHERE is_vote;
+DROP INDEX IF EXISTS idx_task_estimates_session_vote;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS is_vote;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS session_id;
+DROP TABLE IF EXISTS estimation_session_participants;
+DROP TABLE IF EXISTS estimation_sessions;
@@ -0,0 +1,26 @@
+-- Migration script to collect planning estimates from several users in estimation sessions
+
+-- 1. Create the estimation_sessions table
+CREATE TABLE IF NOT EXISTS estimation_sessions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+-- 2. Create the estimation_session_participants table
+CREATE TABLE IF NOT EXISTS estimation_session_participants (
+    session_id INTEGER NOT NULL REFERENCES estimation_sessions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (session_id, user_id)
+);
+
+-- 3. Store votes and agreed estimates in task_estimates with their session
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS session_id INTEGER REFERENCES estimation_sessions(id) ON DELETE SET NULL;
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS is_vote BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 4. Add indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_estimates_session_vote ON task_estimates(session_id, created_by_user_id) WHERE is_vote;
+CREATE INDEX IF NOT EXISTS idx_estimation_sessions_task_id ON estimation_sessions(task_id);
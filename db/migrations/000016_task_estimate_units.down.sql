@@ -0,0 +1,5 @@
+-- Revert estimate units and confidence levels, estimate_day already holds every estimate in days
+
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS confidence;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS estimate_value;
+ALTER TABLE task_estimates DROP COLUMN IF EXISTS unit;
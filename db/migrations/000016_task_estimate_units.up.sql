@@ -0,0 +1,9 @@
+-- Migration script to let estimates be given in hours or story points with a confidence level
+
+-- 1. Add the unit, the estimate in that unit and the confidence to task_estimates
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS unit VARCHAR(10) NOT NULL DEFAULT 'days' CHECK (unit IN ('days', 'hours', 'points'));
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS estimate_value DECIMAL(7,2);
+ALTER TABLE task_estimates ADD COLUMN IF NOT EXISTS confidence VARCHAR(10) CHECK (confidence IN ('low', 'medium', 'high'));
+
+-- 2. Existing estimates were given in days
+UPDATE task_estimates SET estimate_value = estimate_day WHERE estimate_value IS NULL;
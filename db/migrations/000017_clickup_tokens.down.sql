@@ -0,0 +1,3 @@
+-- Revert stored ClickUp OAuth tokens
+
+DROP TABLE IF EXISTS clickup_tokens;
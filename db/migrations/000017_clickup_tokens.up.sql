@@ -0,0 +1,10 @@
+-- Migration script to store each user's ClickUp OAuth token
+
+-- 1. Create the clickup_tokens table, tokens are encrypted by the application
+CREATE TABLE IF NOT EXISTS clickup_tokens (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    access_token TEXT NOT NULL,
+    token_type VARCHAR(20) NOT NULL DEFAULT 'Bearer',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Revert ClickUp custom field values on local tasks
+
+DROP TABLE IF EXISTS task_custom_fields;
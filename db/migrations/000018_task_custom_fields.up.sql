@@ -0,0 +1,10 @@
+-- Migration script to keep ClickUp custom field values on local tasks
+
+-- 1. Create the task_custom_fields table
+CREATE TABLE IF NOT EXISTS task_custom_fields (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_key VARCHAR(100) NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (task_id, field_key)
+);
@@ -0,0 +1,14 @@
+-- Revert multiple ClickUp workspaces, keeping one token per user
+
+DROP INDEX IF EXISTS idx_tasks_clickup_team_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS clickup_team_id;
+
+DELETE FROM clickup_tokens ct
+USING clickup_tokens newer
+WHERE ct.user_id = newer.user_id
+  AND (ct.updated_at, ct.team_id) < (newer.updated_at, newer.team_id);
+ALTER TABLE clickup_tokens DROP CONSTRAINT IF EXISTS clickup_tokens_pkey;
+ALTER TABLE clickup_tokens DROP COLUMN IF EXISTS team_id;
+ALTER TABLE clickup_tokens ADD PRIMARY KEY (user_id);
+
+DROP TABLE IF EXISTS clickup_workspaces;
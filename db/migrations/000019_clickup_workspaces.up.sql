@@ -0,0 +1,19 @@
+-- Migration script to support connecting more than one ClickUp workspace
+
+-- 1. Create the clickup_workspaces table
+CREATE TABLE IF NOT EXISTS clickup_workspaces (
+    team_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    connected_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- 2. Store tokens per user and workspace, existing tokens keep an empty workspace
+ALTER TABLE clickup_tokens ADD COLUMN IF NOT EXISTS team_id VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE clickup_tokens DROP CONSTRAINT IF EXISTS clickup_tokens_pkey;
+ALTER TABLE clickup_tokens ADD PRIMARY KEY (user_id, team_id);
+
+-- 3. Remember the workspace of each linked task, filled in on the next sync
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS clickup_team_id VARCHAR(50);
+CREATE INDEX IF NOT EXISTS idx_tasks_clickup_team_id ON tasks(clickup_team_id);
@@ -0,0 +1,5 @@
+-- Revert the keyset pagination indexes
+
+DROP INDEX IF EXISTS idx_leave_logs_created_at;
+DROP INDEX IF EXISTS idx_leave_logs_user_created_at;
+DROP INDEX IF EXISTS idx_task_logs_user_created_at;
@@ -0,0 +1,5 @@
+-- Indexes backing the (created_at, id) keyset pagination of task and leave logs
+
+CREATE INDEX IF NOT EXISTS idx_task_logs_user_created_at ON task_logs(created_by_user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_leave_logs_user_created_at ON leave_logs(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_leave_logs_created_at ON leave_logs(created_at DESC, id DESC);
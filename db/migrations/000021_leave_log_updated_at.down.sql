@@ -0,0 +1,3 @@
+-- Revert the leave log updated_at column
+
+ALTER TABLE leave_logs DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,4 @@
+-- Track when a leave log last changed, used to detect concurrent edits
+
+ALTER TABLE leave_logs ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+UPDATE leave_logs SET updated_at = created_at WHERE created_at IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Revert soft delete, purge deleted rows first or they come back as live ones
+
+DROP INDEX IF EXISTS idx_users_email_live;
+DROP INDEX IF EXISTS idx_users_username_live;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE medical_expenses DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE leave_logs DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
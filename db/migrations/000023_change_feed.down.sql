@@ -0,0 +1,11 @@
+-- Stop announcing changes on the ngtableg_changes channel
+
+DROP TRIGGER IF EXISTS annual_records_notify_update ON annual_records;
+DROP TRIGGER IF EXISTS annual_records_notify_insert_delete ON annual_records;
+DROP TRIGGER IF EXISTS medical_expenses_notify_update ON medical_expenses;
+DROP TRIGGER IF EXISTS medical_expenses_notify_insert_delete ON medical_expenses;
+DROP TRIGGER IF EXISTS task_logs_notify_update ON task_logs;
+DROP TRIGGER IF EXISTS task_logs_notify_insert_delete ON task_logs;
+DROP TRIGGER IF EXISTS leave_logs_notify_update ON leave_logs;
+DROP TRIGGER IF EXISTS leave_logs_notify_insert_delete ON leave_logs;
+DROP FUNCTION IF EXISTS notify_change();
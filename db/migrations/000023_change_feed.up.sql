@@ -0,0 +1,51 @@
+-- Announce changes to the tables annual records are computed from, and to annual records
+-- themselves, on the ngtableg_changes channel. Notifications are only delivered once the
+-- transaction commits, so listeners never see a change that was rolled back.
+--
+-- The trigger arguments name the user and date columns of the table, 'year' for a table that
+-- stores the year itself. An update that moves a row to another user or year announces both.
+
+CREATE OR REPLACE FUNCTION notify_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    FOREACH changed IN ARRAY (CASE TG_OP
+        WHEN 'INSERT' THEN ARRAY[to_jsonb(NEW)]
+        WHEN 'DELETE' THEN ARRAY[to_jsonb(OLD)]
+        ELSE ARRAY[to_jsonb(OLD), to_jsonb(NEW)]
+    END)
+    LOOP
+        -- Postgres drops identical notifications within one transaction
+        PERFORM pg_notify('ngtableg_changes', jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'id', changed->'id',
+            'user_id', changed->TG_ARGV[0],
+            'year', CASE WHEN TG_ARGV[1] = 'year' THEN (changed->>'year')::int
+                         ELSE EXTRACT(YEAR FROM (changed->>TG_ARGV[1])::date)::int END,
+            'origin', current_setting('ngtableg.instance', true)
+        )::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER leave_logs_notify_insert_delete AFTER INSERT OR DELETE ON leave_logs
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'date');
+CREATE TRIGGER leave_logs_notify_update AFTER UPDATE ON leave_logs
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'date');
+
+CREATE TRIGGER task_logs_notify_insert_delete AFTER INSERT OR DELETE ON task_logs
+    FOR EACH ROW EXECUTE FUNCTION notify_change('created_by_user_id', 'worked_date');
+CREATE TRIGGER task_logs_notify_update AFTER UPDATE ON task_logs
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('created_by_user_id', 'worked_date');
+
+CREATE TRIGGER medical_expenses_notify_insert_delete AFTER INSERT OR DELETE ON medical_expenses
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'receipt_date');
+CREATE TRIGGER medical_expenses_notify_update AFTER UPDATE ON medical_expenses
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'receipt_date');
+
+CREATE TRIGGER annual_records_notify_insert_delete AFTER INSERT OR DELETE ON annual_records
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'year');
+CREATE TRIGGER annual_records_notify_update AFTER UPDATE ON annual_records
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'year');
@@ -0,0 +1,5 @@
+-- Revert the hot path indexes
+
+DROP INDEX IF EXISTS idx_medical_expenses_user_receipt_date;
+DROP INDEX IF EXISTS idx_leave_logs_user_date;
+DROP INDEX IF EXISTS idx_task_logs_user_worked_date;
@@ -0,0 +1,6 @@
+-- Indexes for the per-user date filters of the balance sync and the log listings. annual_records
+-- already has one on (user_id, year) through its UNIQUE constraint.
+
+CREATE INDEX IF NOT EXISTS idx_task_logs_user_worked_date ON task_logs(created_by_user_id, worked_date);
+CREATE INDEX IF NOT EXISTS idx_leave_logs_user_date ON leave_logs(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_medical_expenses_user_receipt_date ON medical_expenses(user_id, receipt_date);
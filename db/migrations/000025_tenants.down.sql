@@ -0,0 +1,59 @@
+-- Rollback script for row-level multi-tenancy. Rows of every tenant are kept and merged, so
+-- names duplicated across tenants make the restored unique constraints fail.
+
+CREATE OR REPLACE FUNCTION notify_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    FOREACH changed IN ARRAY (CASE TG_OP
+        WHEN 'INSERT' THEN ARRAY[to_jsonb(NEW)]
+        WHEN 'DELETE' THEN ARRAY[to_jsonb(OLD)]
+        ELSE ARRAY[to_jsonb(OLD), to_jsonb(NEW)]
+    END)
+    LOOP
+        -- Postgres drops identical notifications within one transaction
+        PERFORM pg_notify('ngtableg_changes', jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'id', changed->'id',
+            'user_id', changed->TG_ARGV[0],
+            'year', CASE WHEN TG_ARGV[1] = 'year' THEN (changed->>'year')::int
+                         ELSE EXTRACT(YEAR FROM (changed->>TG_ARGV[1])::date)::int END,
+            'origin', current_setting('ngtableg.instance', true)
+        )::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_users_username_live;
+DROP INDEX IF EXISTS idx_users_email_live;
+CREATE UNIQUE INDEX idx_users_username_live ON users(username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_users_email_live ON users(email) WHERE deleted_at IS NULL;
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_tenant_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
+ALTER TABLE holidays DROP CONSTRAINT IF EXISTS holidays_tenant_date_key;
+ALTER TABLE holidays ADD CONSTRAINT holidays_date_key UNIQUE (date);
+ALTER TABLE quota_plans DROP CONSTRAINT IF EXISTS quota_plans_tenant_plan_name_year_key;
+ALTER TABLE quota_plans ADD CONSTRAINT quota_plans_plan_name_year_key UNIQUE (plan_name, year);
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'quota_plans', 'annual_records', 'holidays', 'task_categories', 'tasks',
+        'task_assignees', 'task_comments', 'task_activities', 'tags', 'task_tags',
+        'task_custom_fields', 'task_sync_history', 'clickup_tokens', 'clickup_workspaces',
+        'estimation_sessions', 'estimation_session_participants', 'task_estimates', 'task_logs',
+        'medical_expenses', 'leave_logs'
+    ]
+    LOOP
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS tenant_id', t);
+    END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS current_tenant_id();
+DROP TABLE IF EXISTS tenants;
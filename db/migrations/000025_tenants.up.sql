@@ -0,0 +1,87 @@
+-- Migration script for row-level multi-tenancy: every row belongs to a tenant, one small company
+-- served by a shared deployment. Existing rows go to the default tenant. Isolation only holds
+-- for a server connecting as a role that neither owns the tables nor bypasses row-level security.
+
+-- 1. Tenants, resolved from the request by slug
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE, -- Host name label or X-Tenant header value
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO tenants (id, slug, name) VALUES (1, 'default', 'Default')
+ON CONFLICT (id) DO NOTHING;
+SELECT setval('tenants_id_seq', GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+-- 2. The tenant a connection is bound to, NULL for tools and schedulers working on every tenant
+CREATE OR REPLACE FUNCTION current_tenant_id() RETURNS INTEGER AS $$
+    SELECT NULLIF(current_setting('ngtableg.tenant_id', true), '')::INTEGER
+$$ LANGUAGE sql STABLE;
+
+-- 3. A tenant on every row, the connection's tenant for new rows
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'quota_plans', 'annual_records', 'holidays', 'task_categories', 'tasks',
+        'task_assignees', 'task_comments', 'task_activities', 'tags', 'task_tags',
+        'task_custom_fields', 'task_sync_history', 'clickup_tokens', 'clickup_workspaces',
+        'estimation_sessions', 'estimation_session_participants', 'task_estimates', 'task_logs',
+        'medical_expenses', 'leave_logs'
+    ]
+    LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL
+            DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I(tenant_id)', 'idx_' || t || '_tenant_id', t);
+
+        -- Rows of other tenants are invisible and can't be written. The policy binds every role
+        -- but the owner, so tools and migrations run as the owner still see every tenant, and
+        -- COPY, which refuses tables with row-level security, keeps working for them.
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+            WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())', t);
+    END LOOP;
+END $$;
+
+-- 4. Names only need to be unique within a tenant
+ALTER TABLE quota_plans DROP CONSTRAINT IF EXISTS quota_plans_plan_name_year_key;
+ALTER TABLE quota_plans ADD CONSTRAINT quota_plans_tenant_plan_name_year_key UNIQUE (tenant_id, plan_name, year);
+ALTER TABLE holidays DROP CONSTRAINT IF EXISTS holidays_date_key;
+ALTER TABLE holidays ADD CONSTRAINT holidays_tenant_date_key UNIQUE (tenant_id, date);
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_tenant_name_key UNIQUE (tenant_id, name);
+DROP INDEX IF EXISTS idx_users_username_live;
+DROP INDEX IF EXISTS idx_users_email_live;
+CREATE UNIQUE INDEX idx_users_username_live ON users(tenant_id, username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_users_email_live ON users(tenant_id, email) WHERE deleted_at IS NULL;
+
+-- 5. Announce the tenant of a change, so listeners sync it in that tenant
+CREATE OR REPLACE FUNCTION notify_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    FOREACH changed IN ARRAY (CASE TG_OP
+        WHEN 'INSERT' THEN ARRAY[to_jsonb(NEW)]
+        WHEN 'DELETE' THEN ARRAY[to_jsonb(OLD)]
+        ELSE ARRAY[to_jsonb(OLD), to_jsonb(NEW)]
+    END)
+    LOOP
+        -- Postgres drops identical notifications within one transaction
+        PERFORM pg_notify('ngtableg_changes', jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'id', changed->'id',
+            'user_id', changed->TG_ARGV[0],
+            'year', CASE WHEN TG_ARGV[1] = 'year' THEN (changed->>'year')::int
+                         ELSE EXTRACT(YEAR FROM (changed->>TG_ARGV[1])::date)::int END,
+            'tenant_id', changed->'tenant_id',
+            'origin', current_setting('ngtableg.instance', true)
+        )::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
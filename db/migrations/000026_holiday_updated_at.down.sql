@@ -0,0 +1,3 @@
+-- Revert the holiday updated_at column
+
+ALTER TABLE holidays DROP COLUMN IF EXISTS updated_at;
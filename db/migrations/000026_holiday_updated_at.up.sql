@@ -0,0 +1,4 @@
+-- Track when a holiday last changed, used for the ETag of the holiday listings
+
+ALTER TABLE holidays ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+UPDATE holidays SET updated_at = created_at WHERE created_at IS NOT NULL;
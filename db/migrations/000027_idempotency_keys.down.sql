@@ -0,0 +1,3 @@
+-- Revert the idempotency keys
+
+DROP TABLE IF EXISTS idempotency_keys;
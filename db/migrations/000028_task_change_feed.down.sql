@@ -0,0 +1,4 @@
+-- Stop announcing task changes
+
+DROP TRIGGER IF EXISTS tasks_notify_update ON tasks;
+DROP TRIGGER IF EXISTS tasks_notify_insert_delete ON tasks;
@@ -0,0 +1,8 @@
+-- Announce task changes on the ngtableg_changes channel too, for the live updates of
+-- GET /api/events. The user is the task's creator and the year that of its due date, tasks
+-- don't count towards annual records.
+
+CREATE TRIGGER tasks_notify_insert_delete AFTER INSERT OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_change('created_by_user_id', 'due_date');
+CREATE TRIGGER tasks_notify_update AFTER UPDATE ON tasks
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('created_by_user_id', 'due_date');
@@ -0,0 +1,3 @@
+-- Revert the scheduled jobs
+
+DROP TABLE IF EXISTS scheduled_jobs;
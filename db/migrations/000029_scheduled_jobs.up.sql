@@ -0,0 +1,17 @@
+-- The state of the background jobs, shared by every server instance so a restart or another
+-- instance picks up where the last run left off. Jobs run once for all tenants, looping over
+-- them, so the table belongs to none.
+
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    name VARCHAR(100) PRIMARY KEY,
+    schedule TEXT NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_started_at TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    -- NULL when the last run succeeded
+    last_error TEXT,
+    -- The server instance of the last run
+    last_run_by TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Revert the job queue
+
+DROP TABLE IF EXISTS queued_jobs;
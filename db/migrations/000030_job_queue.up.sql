@@ -0,0 +1,30 @@
+-- Side effects, such as ClickUp time entries, queued to run in the background with retries.
+-- Jobs that used up their attempts stay as dead letters until an admin retries them.
+
+CREATE TABLE IF NOT EXISTS queued_jobs (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    -- pending, running, done or dead
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    -- The server instance running the job, and since when
+    locked_by TEXT,
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- Workers look for due pending jobs, admins list them by status
+CREATE INDEX IF NOT EXISTS idx_queued_jobs_status_run_at ON queued_jobs(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_queued_jobs_tenant_id ON queued_jobs(tenant_id);
+
+ALTER TABLE queued_jobs ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON queued_jobs;
+CREATE POLICY tenant_isolation ON queued_jobs
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
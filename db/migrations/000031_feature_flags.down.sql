@@ -0,0 +1,3 @@
+-- Revert the feature flags
+
+DROP TABLE IF EXISTS feature_flags;
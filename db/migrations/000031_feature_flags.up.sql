@@ -0,0 +1,15 @@
+-- Feature flags, which turn features on for everyone or for groups of users so new handlers can
+-- be launched to a few first. The maintenance flag holds every write while it is on. Flags are
+-- set for the whole deployment, so the table belongs to no tenant.
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    -- On for everyone
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Otherwise on for the users of these types or departments, and these users
+    user_types TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    user_ids INTEGER[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
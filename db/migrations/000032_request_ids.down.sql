@@ -0,0 +1,4 @@
+-- Revert the request ID columns
+
+ALTER TABLE queued_jobs DROP COLUMN IF EXISTS request_id;
+ALTER TABLE task_activities DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,5 @@
+-- Record the X-Request-ID of the request behind task activities and queued jobs, so a failure a
+-- user reports can be followed from the request to what it changed and queued
+
+ALTER TABLE task_activities ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
+ALTER TABLE queued_jobs ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
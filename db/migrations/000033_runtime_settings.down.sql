@@ -0,0 +1,3 @@
+-- Revert the runtime settings
+
+DROP TABLE IF EXISTS runtime_settings;
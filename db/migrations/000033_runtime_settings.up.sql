@@ -0,0 +1,11 @@
+-- Runtime settings, which admins change through the API instead of the environment. Each row
+-- overrides the setting of the same environment variable name until it is deleted. Settings
+-- apply to the whole deployment, so the table belongs to no tenant.
+
+CREATE TABLE IF NOT EXISTS runtime_settings (
+    key VARCHAR(100) PRIMARY KEY,
+    -- In the format of the environment variable, e.g. "true" or "https://a.example,https://b.example"
+    value TEXT NOT NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
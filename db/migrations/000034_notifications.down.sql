@@ -0,0 +1,4 @@
+-- Revert the notifications
+
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,3 @@
+-- Revert the Slack workspaces
+
+DROP TABLE IF EXISTS slack_workspaces;
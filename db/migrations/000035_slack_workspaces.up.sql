@@ -0,0 +1,30 @@
+-- Slack workspaces the Slack app is installed in, with the bot token it posts with and where it
+-- posts: leave users record for themselves goes to the approvals channel with approve and reject
+-- buttons, and who is out goes to the out today channel once a day at out_today_hour.
+
+CREATE TABLE IF NOT EXISTS slack_workspaces (
+    team_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    bot_token TEXT NOT NULL, -- AES-GCM encrypted and base64 encoded
+    -- Channel IDs, nothing is posted when empty
+    approvals_channel VARCHAR(50) NOT NULL DEFAULT '',
+    out_today_channel VARCHAR(50) NOT NULL DEFAULT '',
+    -- In the server's time zone
+    out_today_hour SMALLINT NOT NULL DEFAULT 9 CHECK (out_today_hour BETWEEN 0 AND 23),
+    -- The last day who is out was posted, so each day is posted once
+    out_today_posted_on DATE,
+    -- DM users when their leave is approved or rejected
+    notify_users BOOLEAN NOT NULL DEFAULT TRUE,
+    connected_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_slack_workspaces_tenant_id ON slack_workspaces(tenant_id);
+
+ALTER TABLE slack_workspaces ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON slack_workspaces;
+CREATE POLICY tenant_isolation ON slack_workspaces
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
@@ -0,0 +1,9 @@
+-- Revert LINE notifications
+
+DROP TABLE IF EXISTS line_links;
+
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS line;
+
+DELETE FROM notifications WHERE channel <> 'email';
+ALTER TABLE notifications DROP COLUMN IF EXISTS channel;
+ALTER TABLE notifications RENAME COLUMN recipient TO email;
@@ -0,0 +1,27 @@
+-- Notifications go out over LINE as well as email. Each channel gets its own row in the outbox,
+-- addressed to the user's email or LINE user ID, and users turn each channel off by kind.
+
+ALTER TABLE notifications RENAME COLUMN email TO recipient;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'email';
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS line BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- The LINE accounts of users. A user asks for a link code and sends it to the LINE bot, whose
+-- webhook then stores the LINE user ID it came from.
+CREATE TABLE IF NOT EXISTS line_links (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    line_user_id VARCHAR(64), -- NULL until the code is sent to the bot
+    link_code VARCHAR(16) UNIQUE,
+    link_code_expires_at TIMESTAMPTZ,
+    linked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_line_links_tenant_id ON line_links(tenant_id);
+
+ALTER TABLE line_links ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON line_links;
+CREATE POLICY tenant_isolation ON line_links
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
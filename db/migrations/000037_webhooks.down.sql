@@ -0,0 +1,4 @@
+-- Revert the webhooks
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,3 @@
+-- Revert the report exports
+
+DROP TABLE IF EXISTS report_exports;
@@ -0,0 +1,30 @@
+-- Reports exported in the background, for periods too long to render while the request waits.
+-- The queued job renders the file into content, which the user then downloads.
+
+CREATE TABLE IF NOT EXISTS report_exports (
+    id SERIAL PRIMARY KEY,
+    report_name VARCHAR(50) NOT NULL, -- leave, timesheet, utilization or medical-expenses
+    format VARCHAR(10) NOT NULL, -- xlsx or pdf
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- The one user it covers, everyone when NULL
+    department VARCHAR(100),
+    -- pending until the job rendered the file, failed when it couldn't
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_name VARCHAR(255),
+    content BYTEA,
+    error TEXT,
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_exports_created_at ON report_exports(created_at);
+CREATE INDEX IF NOT EXISTS idx_report_exports_tenant_id ON report_exports(tenant_id);
+
+ALTER TABLE report_exports ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON report_exports;
+CREATE POLICY tenant_isolation ON report_exports
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
@@ -0,0 +1,3 @@
+-- Revert attendance
+
+DROP TABLE IF EXISTS attendance_sessions;
@@ -0,0 +1,30 @@
+-- Attendance for the departments that track presence as well as tasks. A user checks in and
+-- later out, with the address and, when the browser shares it, the location of each, and may
+-- check in again the same day. Only one session of a user is open at a time.
+
+CREATE TABLE IF NOT EXISTS attendance_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    work_date DATE NOT NULL, -- The day of the check-in
+    check_in_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    check_in_ip VARCHAR(45),
+    check_in_latitude DOUBLE PRECISION,
+    check_in_longitude DOUBLE PRECISION,
+    check_out_at TIMESTAMPTZ, -- NULL while checked in
+    check_out_ip VARCHAR(45),
+    check_out_latitude DOUBLE PRECISION,
+    check_out_longitude DOUBLE PRECISION,
+    note TEXT,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_sessions_open ON attendance_sessions(user_id) WHERE check_out_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_attendance_sessions_user_date ON attendance_sessions(user_id, work_date);
+CREATE INDEX IF NOT EXISTS idx_attendance_sessions_work_date ON attendance_sessions(work_date);
+CREATE INDEX IF NOT EXISTS idx_attendance_sessions_tenant_id ON attendance_sessions(tenant_id);
+
+ALTER TABLE attendance_sessions ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON attendance_sessions;
+CREATE POLICY tenant_isolation ON attendance_sessions
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
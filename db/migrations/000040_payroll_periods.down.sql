@@ -0,0 +1,3 @@
+-- Revert payroll periods
+
+DROP TABLE IF EXISTS payroll_periods;
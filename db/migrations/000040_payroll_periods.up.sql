@@ -0,0 +1,28 @@
+-- Pay periods for the payroll export. A period is previewed while open, and locking it stores
+-- the file sent to the payroll vendor, so exporting it again gives the same file however the
+-- leave and work logged in it change afterwards. Unlocking throws the file away.
+
+CREATE TABLE IF NOT EXISTS payroll_periods (
+    id SERIAL PRIMARY KEY,
+    period VARCHAR(7) NOT NULL, -- YYYY-MM, the month paid
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open or locked
+    file_name VARCHAR(255),
+    content BYTEA, -- The export, while locked
+    locked_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    locked_at TIMESTAMPTZ,
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (end_date >= start_date)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payroll_periods_period ON payroll_periods(tenant_id, period);
+CREATE INDEX IF NOT EXISTS idx_payroll_periods_tenant_id ON payroll_periods(tenant_id);
+
+ALTER TABLE payroll_periods ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON payroll_periods;
+CREATE POLICY tenant_isolation ON payroll_periods
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
@@ -0,0 +1,6 @@
+-- Revert the org chart
+
+DROP FUNCTION IF EXISTS department_and_below(TEXT);
+DROP TABLE IF EXISTS org_assignments;
+DROP TABLE IF EXISTS positions;
+DROP TABLE IF EXISTS departments;
@@ -0,0 +1,80 @@
+-- The org chart: departments nested under their parent with a head, positions, and who each
+-- user holds a position as and reports to. users.department keeps naming the user's department,
+-- so the queries filtering and grouping by it are unchanged, and departments lists the names it
+-- may hold. The departments users are in already are added at the top of the chart.
+
+CREATE TABLE IF NOT EXISTS departments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL, -- What users.department holds
+    parent_id INTEGER REFERENCES departments(id), -- NULL at the top of the chart
+    head_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (parent_id <> id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_departments_name ON departments(tenant_id, name);
+CREATE INDEX IF NOT EXISTS idx_departments_parent_id ON departments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_departments_tenant_id ON departments(tenant_id);
+
+CREATE TABLE IF NOT EXISTS positions (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL, -- NULL when any department has it
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_positions_title ON positions(tenant_id, COALESCE(department_id, 0), title);
+CREATE INDEX IF NOT EXISTS idx_positions_tenant_id ON positions(tenant_id);
+
+-- A user's place on the chart, a row only once one is set
+CREATE TABLE IF NOT EXISTS org_assignments (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    position_id INTEGER REFERENCES positions(id) ON DELETE SET NULL,
+    manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who they report to
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (manager_id <> user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_assignments_manager_id ON org_assignments(manager_id);
+CREATE INDEX IF NOT EXISTS idx_org_assignments_tenant_id ON org_assignments(tenant_id);
+
+ALTER TABLE departments ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON departments;
+CREATE POLICY tenant_isolation ON departments
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE positions ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON positions;
+CREATE POLICY tenant_isolation ON positions
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE org_assignments ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON org_assignments;
+CREATE POLICY tenant_isolation ON org_assignments
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+INSERT INTO departments (name, tenant_id)
+SELECT DISTINCT department, tenant_id FROM users
+WHERE department IS NOT NULL AND department <> ''
+ON CONFLICT DO NOTHING;
+
+-- The name of a department and of every department under it, for filters that take in the
+-- sub-departments. A name that isn't a department only matches itself.
+CREATE OR REPLACE FUNCTION department_and_below(department TEXT) RETURNS SETOF TEXT AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id, name FROM departments WHERE name = department
+        UNION
+        SELECT d.id, d.name FROM departments d JOIN tree ON d.parent_id = tree.id
+    )
+    SELECT name::TEXT FROM tree
+    UNION
+    SELECT department
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,6 @@
+-- Revert approvals
+
+DROP TABLE IF EXISTS approval_delegations;
+DROP TABLE IF EXISTS approval_decisions;
+DROP TABLE IF EXISTS approval_requests;
+DROP TABLE IF EXISTS approval_policies;
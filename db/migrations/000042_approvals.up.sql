@@ -0,0 +1,98 @@
+-- Approvals of leave, medical expenses, timesheets and quota plan edits. A policy lists the steps
+-- a kind of request goes through, e.g. the user's manager then HR, and the amount up to which it
+-- is approved without any. A request copies the steps of its policy when submitted, so changing
+-- the policy leaves the requests already waiting alone. Approvers delegate their steps to
+-- someone else while they are away. Leave keeps going to anyone up the user's reporting line or
+-- the heads of their department, who decided it before.
+
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL, -- leave, medical_expense, timesheet or quota_plan
+    steps TEXT[] NOT NULL, -- manager, supervisor, department_head, admin or department:<name>
+    auto_approve_max NUMERIC(12,2), -- Requests of up to this amount need no approval
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_policies_kind ON approval_policies(tenant_id, kind);
+CREATE INDEX IF NOT EXISTS idx_approval_policies_tenant_id ON approval_policies(tenant_id);
+
+CREATE TABLE IF NOT EXISTS approval_requests (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    subject_id INTEGER NOT NULL, -- The leave log, medical expense, task log or quota plan
+    requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(12,2) NOT NULL DEFAULT 0, -- Days of leave or work, or baht
+    payload JSONB, -- The change applied once approved, for quota plan edits
+    steps TEXT[] NOT NULL,
+    current_step INTEGER NOT NULL DEFAULT 0, -- Index into steps of the step waiting
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected or cancelled
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_requests_subject ON approval_requests(kind, subject_id);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_pending ON approval_requests(created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_approval_requests_requester ON approval_requests(requester_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_tenant_id ON approval_requests(tenant_id);
+
+CREATE TABLE IF NOT EXISTS approval_decisions (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES approval_requests(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL,
+    approver_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    on_behalf_of_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who delegated the step
+    decision VARCHAR(20) NOT NULL, -- approved or rejected
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_decisions_request_id ON approval_decisions(request_id);
+CREATE INDEX IF NOT EXISTS idx_approval_decisions_tenant_id ON approval_decisions(tenant_id);
+
+CREATE TABLE IF NOT EXISTS approval_delegations (
+    id SERIAL PRIMARY KEY,
+    delegator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (end_date >= start_date),
+    CHECK (delegate_id <> delegator_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegate ON approval_delegations(delegate_id, start_date, end_date);
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_delegator_id ON approval_delegations(delegator_id);
+CREATE INDEX IF NOT EXISTS idx_approval_delegations_tenant_id ON approval_delegations(tenant_id);
+
+ALTER TABLE approval_policies ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON approval_policies;
+CREATE POLICY tenant_isolation ON approval_policies
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE approval_requests ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON approval_requests;
+CREATE POLICY tenant_isolation ON approval_requests
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE approval_decisions ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON approval_decisions;
+CREATE POLICY tenant_isolation ON approval_decisions
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE approval_delegations ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON approval_delegations;
+CREATE POLICY tenant_isolation ON approval_delegations
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+INSERT INTO approval_policies (kind, steps, tenant_id)
+SELECT 'leave', '{supervisor}', id FROM tenants
+ON CONFLICT DO NOTHING;
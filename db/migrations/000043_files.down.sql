@@ -0,0 +1,3 @@
+-- Revert files, the stored files themselves stay in FILES_LOCATION
+
+DROP TABLE IF EXISTS files;
@@ -0,0 +1,29 @@
+-- Files users upload: medical receipts, sick leave certificates and avatars. The file itself is
+-- kept in the storage FILES_LOCATION names under storage_key, the row says whose it is and what
+-- it belongs to. subject_id is the medical expense, leave log or user, by purpose, so there is
+-- no foreign key; the file-cleanup job deletes the files whose subject is gone.
+
+CREATE TABLE IF NOT EXISTS files (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who uploaded it
+    purpose VARCHAR(30) NOT NULL, -- medical_receipt, sick_leave_certificate or avatar
+    subject_id INTEGER NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    scanned_at TIMESTAMPTZ, -- When the virus scanner passed it, NULL without FILE_SCAN_COMMAND
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_files_subject ON files(purpose, subject_id);
+CREATE INDEX IF NOT EXISTS idx_files_owner_id ON files(owner_id);
+CREATE INDEX IF NOT EXISTS idx_files_tenant_id ON files(tenant_id);
+
+ALTER TABLE files ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON files;
+CREATE POLICY tenant_isolation ON files
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
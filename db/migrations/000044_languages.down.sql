@@ -0,0 +1,5 @@
+-- Revert languages
+
+ALTER TABLE report_exports DROP COLUMN IF EXISTS language;
+
+DROP TABLE IF EXISTS language_preferences;
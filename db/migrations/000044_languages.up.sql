@@ -0,0 +1,20 @@
+-- The language users get notifications in, which go out without a request; API responses follow
+-- the Accept-Language header instead. Users without a row get DEFAULT_LANGUAGE.
+
+CREATE TABLE IF NOT EXISTS language_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    language VARCHAR(5) NOT NULL, -- en or th
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_language_preferences_tenant_id ON language_preferences(tenant_id);
+
+ALTER TABLE language_preferences ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON language_preferences;
+CREATE POLICY tenant_isolation ON language_preferences
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+-- A report exported in the background is labelled in the language it was asked for in
+ALTER TABLE report_exports ADD COLUMN IF NOT EXISTS language VARCHAR(5) NOT NULL DEFAULT 'en';
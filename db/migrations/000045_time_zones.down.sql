@@ -0,0 +1,3 @@
+-- Revert time zones
+
+DROP TABLE IF EXISTS time_zone_preferences;
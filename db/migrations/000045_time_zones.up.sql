@@ -0,0 +1,17 @@
+-- The time zone users who work away from the company pick, which their today, on the dashboard
+-- and when they check in, follows. Users without a row are in TIME_ZONE.
+
+CREATE TABLE IF NOT EXISTS time_zone_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    time_zone VARCHAR(64) NOT NULL, -- An IANA zone such as Asia/Bangkok
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_zone_preferences_tenant_id ON time_zone_preferences(tenant_id);
+
+ALTER TABLE time_zone_preferences ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON time_zone_preferences;
+CREATE POLICY tenant_isolation ON time_zone_preferences
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
@@ -0,0 +1,3 @@
+-- Revert privacy
+
+DROP TABLE IF EXISTS anonymizations;
@@ -0,0 +1,18 @@
+-- Departed users whose personal data was scrubbed under the PDPA, by an admin or by the
+-- privacy-retention job ANONYMIZE_DEPARTED_AFTER after they left. Their user row and logs stay,
+-- without names, notes, locations or files, so aggregate statistics keep counting them.
+
+CREATE TABLE IF NOT EXISTS anonymizations (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    anonymized_by INTEGER REFERENCES users(id) ON DELETE SET NULL, -- NULL when the job did it
+    anonymized_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anonymizations_tenant_id ON anonymizations(tenant_id);
+
+ALTER TABLE anonymizations ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON anonymizations;
+CREATE POLICY tenant_isolation ON anonymizations
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
@@ -0,0 +1,3 @@
+-- Revert anomalies
+
+DROP TABLE IF EXISTS anomalies;
@@ -0,0 +1,30 @@
+-- Patterns in leave, task logs and medical expenses the anomaly-detection job flagged for HR to
+-- review, see the anomaly package. A finding is flagged once per rule, user and fingerprint, so
+-- a dismissed one stays dismissed until the pattern grows.
+
+CREATE TABLE IF NOT EXISTS anomalies (
+    id SERIAL PRIMARY KEY,
+    rule VARCHAR(50) NOT NULL, -- sick-mondays, over-logging or expense-near-limit
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL, -- The days the pattern was seen in
+    period_end DATE NOT NULL,
+    fingerprint VARCHAR(100) NOT NULL, -- What makes a finding new, such as the last day of the pattern
+    summary TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}', -- The days, logs or expenses behind the finding
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, confirmed or dismissed
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_anomalies_fingerprint ON anomalies(tenant_id, rule, user_id, fingerprint);
+CREATE INDEX IF NOT EXISTS idx_anomalies_status ON anomalies(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_anomalies_tenant_id ON anomalies(tenant_id);
+
+ALTER TABLE anomalies ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON anomalies;
+CREATE POLICY tenant_isolation ON anomalies
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
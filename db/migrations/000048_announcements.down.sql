@@ -0,0 +1,4 @@
+-- Revert announcements
+
+DROP TABLE IF EXISTS announcement_acknowledgments;
+DROP TABLE IF EXISTS announcements;
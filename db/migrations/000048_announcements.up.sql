@@ -0,0 +1,46 @@
+-- Announcements shown on the dashboard of the users they are meant for while they are published.
+-- Policy notices ask each of them to acknowledge having read them.
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    -- For everyone when all three are empty, otherwise for the users of these types or
+    -- departments, or the departments under them, and these users
+    user_types TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    user_ids INTEGER[] NOT NULL DEFAULT '{}',
+    requires_acknowledgment BOOLEAN NOT NULL DEFAULT FALSE,
+    publish_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expire_at TIMESTAMPTZ, -- NULL until it is taken down
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_publish_at ON announcements(publish_at);
+CREATE INDEX IF NOT EXISTS idx_announcements_tenant_id ON announcements(tenant_id);
+
+CREATE TABLE IF NOT EXISTS announcement_acknowledgments (
+    announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    acknowledged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_acknowledgments_user_id ON announcement_acknowledgments(user_id);
+CREATE INDEX IF NOT EXISTS idx_announcement_acknowledgments_tenant_id ON announcement_acknowledgments(tenant_id);
+
+ALTER TABLE announcements ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON announcements;
+CREATE POLICY tenant_isolation ON announcements
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+ALTER TABLE announcement_acknowledgments ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON announcement_acknowledgments;
+CREATE POLICY tenant_isolation ON announcement_acknowledgments
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
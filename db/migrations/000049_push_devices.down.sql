@@ -0,0 +1,7 @@
+-- Revert push notifications
+
+DROP TABLE IF EXISTS push_devices;
+
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS push;
+
+DELETE FROM notifications WHERE channel = 'push';
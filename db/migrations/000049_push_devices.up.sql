@@ -0,0 +1,27 @@
+-- Notifications are pushed to the mobile app over Firebase Cloud Messaging as well. The app
+-- registers the FCM token of each device users sign in on, and users turn the push channel off
+-- by kind like the others.
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS push BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- The devices of users, by FCM registration token. A token registered again moves to the user
+-- who registered it, as when someone else signs in on the phone.
+CREATE TABLE IF NOT EXISTS push_devices (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    platform VARCHAR(20) NOT NULL, -- android, ios or web
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- When the app last registered it
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE (tenant_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_user_id ON push_devices(user_id);
+CREATE INDEX IF NOT EXISTS idx_push_devices_tenant_id ON push_devices(tenant_id);
+
+ALTER TABLE push_devices ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON push_devices;
+CREATE POLICY tenant_isolation ON push_devices
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
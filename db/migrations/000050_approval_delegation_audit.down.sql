@@ -0,0 +1,9 @@
+-- Revert the audit trail of approval delegations, deleting the revoked ones
+
+ALTER TABLE approval_decisions DROP COLUMN IF EXISTS delegation_id;
+
+DELETE FROM approval_delegations WHERE revoked_at IS NOT NULL;
+
+ALTER TABLE approval_delegations DROP COLUMN IF EXISTS revoked_by_id;
+ALTER TABLE approval_delegations DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE approval_delegations DROP COLUMN IF EXISTS created_by_id;
@@ -0,0 +1,13 @@
+-- Delegations keep who created them and, once revoked, who revoked them and when, instead of
+-- being deleted, and decisions name the delegation they were made under, so who decided what for
+-- whom while they were away can be traced afterwards.
+
+ALTER TABLE approval_delegations ADD COLUMN IF NOT EXISTS created_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE approval_delegations ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+ALTER TABLE approval_delegations ADD COLUMN IF NOT EXISTS revoked_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE approval_delegations SET created_by_id = delegator_id WHERE created_by_id IS NULL;
+
+ALTER TABLE approval_decisions ADD COLUMN IF NOT EXISTS delegation_id INTEGER REFERENCES approval_delegations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_approval_decisions_delegation_id ON approval_decisions(delegation_id) WHERE delegation_id IS NOT NULL;
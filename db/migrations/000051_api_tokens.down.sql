@@ -0,0 +1,3 @@
+-- Revert API tokens
+
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,24 @@
+-- Internal services such as the chatbot call the API with tokens admins create for them, each
+-- allowed only the scopes it was given. Only the SHA-256 of a token is kept, the token itself is
+-- shown once when it is created.
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL, -- What uses it, e.g. the chatbot
+    token_hash VARCHAR(64) NOT NULL, -- Hex SHA-256 of the token
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- e.g. availability
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ, -- Revoked tokens are kept for the audit trail and no longer work
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE (tenant_id, token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_tenant_id ON api_tokens(tenant_id);
+
+ALTER TABLE api_tokens ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON api_tokens;
+CREATE POLICY tenant_isolation ON api_tokens
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
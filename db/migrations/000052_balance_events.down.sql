@@ -0,0 +1,16 @@
+-- Revert balance events
+
+DROP TRIGGER IF EXISTS leave_logs_balance_event ON leave_logs;
+DROP TRIGGER IF EXISTS leave_logs_balance_event_update ON leave_logs;
+DROP TRIGGER IF EXISTS task_logs_balance_event ON task_logs;
+DROP TRIGGER IF EXISTS task_logs_balance_event_update ON task_logs;
+DROP TRIGGER IF EXISTS medical_expenses_balance_event ON medical_expenses;
+DROP TRIGGER IF EXISTS medical_expenses_balance_event_update ON medical_expenses;
+
+DROP FUNCTION IF EXISTS record_leave_log_balance_event();
+DROP FUNCTION IF EXISTS record_task_log_balance_event();
+DROP FUNCTION IF EXISTS record_medical_expense_balance_event();
+DROP FUNCTION IF EXISTS retract_balance_events(TEXT, INTEGER);
+
+DROP TABLE IF EXISTS balance_events;
+DROP FUNCTION IF EXISTS forbid_balance_event_change();
@@ -0,0 +1,172 @@
+-- Balance events are the ledger annual records are derived from. Whatever a row of leave_logs,
+-- task_logs or medical_expenses adds to a user's totals for a year is recorded by a trigger, in
+-- the transaction writing the row, whichever code or tool writes it. Changing or deleting the row
+-- retracts what it added so far and records it again when it still counts. Admins' edits of the
+-- totals are recorded as adjustments.
+--
+-- Events are never changed or deleted, so adding them up to any event replays the totals as they
+-- were then, and syncing an annual record is summing its events. Leave counts the user's daily
+-- capacity when it was recorded; a later change of capacity applies to leave recorded after it.
+
+CREATE TABLE IF NOT EXISTS balance_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL, -- No foreign key, the ledger outlives purged users
+    year INTEGER NOT NULL,
+    source VARCHAR(30) NOT NULL, -- leave_logs, task_logs, medical_expenses or annual_records
+    source_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL, -- recorded, retracted or adjusted
+    vacation_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    sick_leave_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    worked_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    worked_on_holiday_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    medical_expense_baht DECIMAL(12,2) NOT NULL DEFAULT 0,
+    recorded_by_user_id INTEGER, -- The admin of an adjustment
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_events_user_year ON balance_events(user_id, year, id);
+CREATE INDEX IF NOT EXISTS idx_balance_events_source ON balance_events(source, source_id);
+CREATE INDEX IF NOT EXISTS idx_balance_events_tenant_id ON balance_events(tenant_id);
+
+ALTER TABLE balance_events ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON balance_events;
+CREATE POLICY tenant_isolation ON balance_events
+    USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+    WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id());
+
+CREATE OR REPLACE FUNCTION forbid_balance_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'balance events are never changed or deleted, record another one instead';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER balance_events_immutable BEFORE UPDATE OR DELETE ON balance_events
+    FOR EACH ROW EXECUTE FUNCTION forbid_balance_event_change();
+
+-- retract_balance_events cancels what the events of a row added so far, in each year they added
+-- to, and does nothing for a row that adds nothing anymore
+CREATE OR REPLACE FUNCTION retract_balance_events(event_source TEXT, event_source_id INTEGER) RETURNS void AS $$
+    INSERT INTO balance_events (
+        user_id, year, source, source_id, action, vacation_day, sick_leave_day, worked_day,
+        worked_on_holiday_day, medical_expense_baht, tenant_id
+    )
+    SELECT user_id, year, source, source_id, 'retracted', -SUM(vacation_day), -SUM(sick_leave_day),
+        -SUM(worked_day), -SUM(worked_on_holiday_day), -SUM(medical_expense_baht), tenant_id
+    FROM balance_events
+    WHERE source = event_source AND source_id = event_source_id
+    GROUP BY user_id, year, source, source_id, tenant_id
+    HAVING SUM(vacation_day) <> 0 OR SUM(sick_leave_day) <> 0 OR SUM(worked_day) <> 0
+        OR SUM(worked_on_holiday_day) <> 0 OR SUM(medical_expense_baht) <> 0
+    ORDER BY year;
+$$ LANGUAGE sql;
+
+-- Vacation and sick leave count the user's daily capacity, other leave doesn't count
+CREATE OR REPLACE FUNCTION record_leave_log_balance_event() RETURNS trigger AS $$
+DECLARE
+    capacity DECIMAL;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('leave_logs', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL AND NEW.type IN ('vacation', 'sick') THEN
+        SELECT COALESCE(daily_capacity, 1) INTO capacity FROM users WHERE id = NEW.user_id;
+        INSERT INTO balance_events (user_id, year, source, source_id, action, vacation_day, sick_leave_day, tenant_id)
+        VALUES (NEW.user_id, EXTRACT(YEAR FROM NEW.date), 'leave_logs', NEW.id, 'recorded',
+            CASE WHEN NEW.type = 'vacation' THEN capacity ELSE 0 END,
+            CASE WHEN NEW.type = 'sick' THEN capacity ELSE 0 END,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_task_log_balance_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('task_logs', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.worked_day <> 0 THEN
+        INSERT INTO balance_events (user_id, year, source, source_id, action, worked_day, worked_on_holiday_day, tenant_id)
+        VALUES (NEW.created_by_user_id, EXTRACT(YEAR FROM NEW.worked_date), 'task_logs', NEW.id, 'recorded',
+            NEW.worked_day,
+            CASE WHEN NEW.is_work_on_holiday THEN NEW.worked_day ELSE 0 END,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- An expense without a receipt date counts in the year it was recorded
+CREATE OR REPLACE FUNCTION record_medical_expense_balance_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('medical_expenses', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL AND NEW.amount <> 0 THEN
+        INSERT INTO balance_events (user_id, year, source, source_id, action, medical_expense_baht, tenant_id)
+        VALUES (NEW.user_id, EXTRACT(YEAR FROM COALESCE(NEW.receipt_date, NEW.created_at)), 'medical_expenses', NEW.id, 'recorded',
+            NEW.amount,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER leave_logs_balance_event AFTER INSERT OR DELETE ON leave_logs
+    FOR EACH ROW EXECUTE FUNCTION record_leave_log_balance_event();
+CREATE TRIGGER leave_logs_balance_event_update AFTER UPDATE ON leave_logs
+    FOR EACH ROW WHEN ((OLD.user_id, OLD.type, OLD.date, OLD.deleted_at) IS DISTINCT FROM (NEW.user_id, NEW.type, NEW.date, NEW.deleted_at))
+    EXECUTE FUNCTION record_leave_log_balance_event();
+
+CREATE TRIGGER task_logs_balance_event AFTER INSERT OR DELETE ON task_logs
+    FOR EACH ROW EXECUTE FUNCTION record_task_log_balance_event();
+CREATE TRIGGER task_logs_balance_event_update AFTER UPDATE ON task_logs
+    FOR EACH ROW WHEN ((OLD.created_by_user_id, OLD.worked_day, OLD.worked_date, OLD.is_work_on_holiday) IS DISTINCT FROM (NEW.created_by_user_id, NEW.worked_day, NEW.worked_date, NEW.is_work_on_holiday))
+    EXECUTE FUNCTION record_task_log_balance_event();
+
+CREATE TRIGGER medical_expenses_balance_event AFTER INSERT OR DELETE ON medical_expenses
+    FOR EACH ROW EXECUTE FUNCTION record_medical_expense_balance_event();
+CREATE TRIGGER medical_expenses_balance_event_update AFTER UPDATE ON medical_expenses
+    FOR EACH ROW WHEN ((OLD.user_id, OLD.amount, OLD.receipt_date, OLD.deleted_at) IS DISTINCT FROM (NEW.user_id, NEW.amount, NEW.receipt_date, NEW.deleted_at))
+    EXECUTE FUNCTION record_medical_expense_balance_event();
+
+-- Start the ledger with the rows there are, as of when they were written
+INSERT INTO balance_events (user_id, year, source, source_id, action, vacation_day, sick_leave_day, recorded_at, tenant_id)
+SELECT ll.user_id, EXTRACT(YEAR FROM ll.date), 'leave_logs', ll.id, 'recorded',
+    CASE WHEN ll.type = 'vacation' THEN COALESCE(u.daily_capacity, 1) ELSE 0 END,
+    CASE WHEN ll.type = 'sick' THEN COALESCE(u.daily_capacity, 1) ELSE 0 END,
+    COALESCE(ll.created_at, NOW()), ll.tenant_id
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL AND ll.type IN ('vacation', 'sick')
+ORDER BY ll.created_at, ll.id;
+
+INSERT INTO balance_events (user_id, year, source, source_id, action, worked_day, worked_on_holiday_day, recorded_at, tenant_id)
+SELECT created_by_user_id, EXTRACT(YEAR FROM worked_date), 'task_logs', id, 'recorded',
+    worked_day, CASE WHEN is_work_on_holiday THEN worked_day ELSE 0 END,
+    COALESCE(created_at, NOW()), tenant_id
+FROM task_logs
+WHERE worked_day <> 0
+ORDER BY created_at, id;
+
+INSERT INTO balance_events (user_id, year, source, source_id, action, medical_expense_baht, recorded_at, tenant_id)
+SELECT user_id, EXTRACT(YEAR FROM COALESCE(receipt_date, created_at)), 'medical_expenses', id, 'recorded',
+    amount, COALESCE(created_at, NOW()), tenant_id
+FROM medical_expenses
+WHERE deleted_at IS NULL AND amount <> 0
+ORDER BY created_at, id;
+
+-- Used medical expense baht were kept by hand until now rather than summed up from the expenses,
+-- so what a record holds beyond its expenses is kept as an adjustment
+INSERT INTO balance_events (user_id, year, source, source_id, action, medical_expense_baht, tenant_id)
+SELECT ar.user_id, ar.year, 'annual_records', ar.id, 'adjusted',
+    COALESCE(ar.used_medical_expense_baht, 0) - COALESCE(expenses.baht, 0), ar.tenant_id
+FROM annual_records ar
+LEFT JOIN (
+    SELECT user_id, year, SUM(medical_expense_baht) AS baht
+    FROM balance_events
+    GROUP BY user_id, year
+) expenses ON expenses.user_id = ar.user_id AND expenses.year = ar.year
+WHERE COALESCE(ar.used_medical_expense_baht, 0) <> COALESCE(expenses.baht, 0)
+ORDER BY ar.id;
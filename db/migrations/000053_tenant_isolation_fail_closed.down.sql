@@ -0,0 +1,18 @@
+-- Rollback script for fail-closed tenant isolation, a connection bound to no tenant sees every
+-- tenant again
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOR t IN SELECT tablename FROM pg_policies WHERE schemaname = current_schema() AND policyname = 'tenant_isolation'
+    LOOP
+        EXECUTE format('DROP POLICY IF EXISTS all_tenants ON %I', t);
+        EXECUTE format('DROP POLICY tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())
+            WITH CHECK (current_tenant_id() IS NULL OR tenant_id = current_tenant_id())', t);
+    END LOOP;
+END $$;
+
+DROP FUNCTION IF EXISTS all_tenants();
@@ -0,0 +1,28 @@
+-- A connection bound to no tenant sees no tenant's rows. The tenant_isolation policies let it see
+-- every tenant, so a query the server ran outside a request's tenant read and wrote them all.
+-- Jobs that span the tenants now ask for it by setting ngtableg.all_tenants, which the
+-- all_tenants policy reads. Migrations and the table owner are exempt from row-level security
+-- as before, and admin tools that connect as another role need BYPASSRLS or that setting.
+
+-- 1. Whether the connection asked to see every tenant
+CREATE OR REPLACE FUNCTION all_tenants() RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('ngtableg.all_tenants', true), '') = 'on'
+$$ LANGUAGE sql STABLE;
+
+-- 2. Only the connection's tenant, and every tenant only when asked for
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOR t IN SELECT tablename FROM pg_policies WHERE schemaname = current_schema() AND policyname = 'tenant_isolation'
+    LOOP
+        EXECUTE format('DROP POLICY tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (tenant_id = current_tenant_id())
+            WITH CHECK (tenant_id = current_tenant_id())', t);
+        EXECUTE format('DROP POLICY IF EXISTS all_tenants ON %I', t);
+        EXECUTE format('CREATE POLICY all_tenants ON %I
+            USING (all_tenants())
+            WITH CHECK (all_tenants())', t);
+    END LOOP;
+END $$;
@@ -0,0 +1,134 @@
+// Package pgconv converts between Go numbers and the pgtype.Numeric used for DECIMAL columns.
+//
+// Conversions go through the decimal text of a value, never through fmt rounding, so a float
+// keeps every digit it has and a decimal string is stored exactly. Postgres rounds the value to
+// the scale of the column. Values that are not finite numbers are reported as errors instead of
+// being stored as NULL.
+package pgconv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	// ErrNull is returned when converting a NULL numeric to a Go number
+	ErrNull = errors.New("pgconv: numeric is NULL")
+	// ErrNotFinite is returned for NaN and infinite values, which DECIMAL columns can't hold
+	ErrNotFinite = errors.New("pgconv: number is not finite")
+)
+
+// decimalPattern matches a decimal number with an optional sign, fraction and exponent, e.g.
+// -12.50 or 1.5e3
+var decimalPattern = regexp.MustCompile(`^([+-]?)(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+
+// FromFloat converts f to a numeric using the shortest decimal that round-trips to f
+func FromFloat(f float64) (pgtype.Numeric, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return pgtype.Numeric{}, ErrNotFinite
+	}
+	return FromString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// MustFromFloat is FromFloat for constants, it panics when f is not finite
+func MustFromFloat(f float64) pgtype.Numeric {
+	n, err := FromFloat(f)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// FromString parses a decimal number like "1500.75" or "1.5e3" exactly
+func FromString(s string) (pgtype.Numeric, error) {
+	match := decimalPattern.FindStringSubmatch(s)
+	if match == nil || match[2]+match[3] == "" {
+		return pgtype.Numeric{}, fmt.Errorf("pgconv: %q is not a decimal number", s)
+	}
+
+	digits, ok := new(big.Int).SetString(match[1]+match[2]+match[3], 10)
+	if !ok {
+		return pgtype.Numeric{}, fmt.Errorf("pgconv: %q is not a decimal number", s)
+	}
+	exp := -int64(len(match[3]))
+	if match[4] != "" {
+		shift, err := strconv.ParseInt(match[4], 10, 32)
+		if err != nil {
+			return pgtype.Numeric{}, fmt.Errorf("pgconv: exponent of %q out of range", s)
+		}
+		exp += shift
+	}
+	// MinInt32 itself is out too, its magnitude doesn't fit an int32
+	if exp <= math.MinInt32 || exp > math.MaxInt32 {
+		return pgtype.Numeric{}, fmt.Errorf("pgconv: exponent of %q out of range", s)
+	}
+	return pgtype.Numeric{Int: digits, Exp: int32(exp), Valid: true}, nil
+}
+
+// ToDecimal returns the exact value of n, for sums and comparisons that must not drift the way
+// float64 does
+func ToDecimal(n pgtype.Numeric) (*big.Rat, error) {
+	if !n.Valid {
+		return nil, ErrNull
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return nil, ErrNotFinite
+	}
+
+	value := new(big.Rat)
+	if n.Int != nil {
+		value.SetInt(n.Int)
+	}
+	if n.Exp != 0 {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs(int64(n.Exp))), nil)
+		if n.Exp > 0 {
+			value.Mul(value, new(big.Rat).SetInt(scale))
+		} else {
+			value.Quo(value, new(big.Rat).SetInt(scale))
+		}
+	}
+	return value, nil
+}
+
+// ToFloat returns the float64 nearest to n
+func ToFloat(n pgtype.Numeric) (float64, error) {
+	value, err := ToDecimal(n)
+	if err != nil {
+		return 0, err
+	}
+	f, _ := value.Float64()
+	return f, nil
+}
+
+// Converter converts several floats in a row and keeps the first error, so the fields of a
+// params struct can be converted inline and checked once with Err
+type Converter struct {
+	err error
+}
+
+// FromFloat is FromFloat, returning an invalid numeric and recording the error on failure
+func (c *Converter) FromFloat(f float64) pgtype.Numeric {
+	n, err := FromFloat(f)
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return n
+}
+
+// Err returns the first error of the conversions so far
+func (c *Converter) Err() error {
+	return c.err
+}
+
+func abs(exp int64) int64 {
+	if exp < 0 {
+		return -exp
+	}
+	return exp
+}
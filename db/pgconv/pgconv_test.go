@@ -0,0 +1,247 @@
+package pgconv_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+)
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		in      float64
+		digits  string
+		exp     int32
+		wantErr error
+	}{
+		{in: 0, digits: "0", exp: 0},
+		{in: 1500.75, digits: "150075", exp: -2},
+		{in: -12.5, digits: "-125", exp: -1},
+		{in: 0.1, digits: "1", exp: -1},
+		{in: 1e21, digits: "1000000000000000000000", exp: 0},
+		{in: 0.000001, digits: "1", exp: -6},
+		{in: math.NaN(), wantErr: pgconv.ErrNotFinite},
+		{in: math.Inf(1), wantErr: pgconv.ErrNotFinite},
+		{in: math.Inf(-1), wantErr: pgconv.ErrNotFinite},
+	}
+	for _, tt := range tests {
+		n, err := pgconv.FromFloat(tt.in)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("FromFloat(%v) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if n.Valid {
+				t.Errorf("FromFloat(%v) = valid numeric, want invalid", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FromFloat(%v) error = %v", tt.in, err)
+			continue
+		}
+		assertNumeric(t, "FromFloat", tt.in, n, tt.digits, tt.exp)
+	}
+}
+
+func TestMustFromFloatPanicsOnNaN(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustFromFloat(NaN) didn't panic")
+		}
+	}()
+	pgconv.MustFromFloat(math.NaN())
+}
+
+func TestFromString(t *testing.T) {
+	tests := []struct {
+		in      string
+		digits  string
+		exp     int32
+		wantErr bool
+	}{
+		{in: "1500.75", digits: "150075", exp: -2},
+		{in: "-12.50", digits: "-1250", exp: -2},
+		{in: "+3", digits: "3", exp: 0},
+		{in: ".5", digits: "5", exp: -1},
+		{in: "5.", digits: "5", exp: 0},
+		{in: "007", digits: "7", exp: 0},
+		// Exponents move the scale, a negative scale is kept as a positive exponent
+		{in: "1.5e3", digits: "15", exp: 2},
+		{in: "1.5E+3", digits: "15", exp: 2},
+		{in: "25e-4", digits: "25", exp: -4},
+		{in: "-2.5e-1", digits: "-25", exp: -2},
+		{in: "12e10", digits: "12", exp: 10},
+		{in: "123456789012345678901234567890.123", digits: "123456789012345678901234567890123", exp: -3},
+		// Garbage
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "e5", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1,000", wantErr: true},
+		{in: " 1", wantErr: true},
+		{in: "0x10", wantErr: true},
+		{in: "1e", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "Infinity", wantErr: true},
+		{in: "1e99999999999", wantErr: true},
+		{in: "1e2147483647", digits: "1", exp: math.MaxInt32},
+		{in: "0.1e2147483647", digits: "1", exp: math.MaxInt32 - 1},
+		{in: "1e2147483648", wantErr: true},
+		{in: "1e-2147483647", digits: "1", exp: math.MinInt32 + 1},
+		{in: "1e-2147483648", wantErr: true},
+		{in: "0.1e-2147483647", wantErr: true},
+	}
+	for _, tt := range tests {
+		n, err := pgconv.FromString(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("FromString(%q) = %v e%d, want an error", tt.in, n.Int, n.Exp)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FromString(%q) error = %v", tt.in, err)
+			continue
+		}
+		assertNumeric(t, "FromString", tt.in, n, tt.digits, tt.exp)
+	}
+}
+
+func TestToDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      pgtype.Numeric
+		want    string // As big.Rat.RatString
+		wantErr error
+	}{
+		{name: "integer", in: numeric("42", 0), want: "42"},
+		{name: "fraction", in: numeric("150075", -2), want: "60030/40"},
+		{name: "positive exponent", in: numeric("15", 2), want: "1500"},
+		{name: "negative", in: numeric("-125", -1), want: "-25/2"},
+		{name: "nil digits", in: pgtype.Numeric{Valid: true}, want: "0"},
+		{name: "null", in: pgtype.Numeric{}, wantErr: pgconv.ErrNull},
+		{name: "NaN", in: pgtype.Numeric{NaN: true, Valid: true}, wantErr: pgconv.ErrNotFinite},
+		{name: "infinity", in: pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}, wantErr: pgconv.ErrNotFinite},
+		{name: "negative infinity", in: pgtype.Numeric{InfinityModifier: pgtype.NegativeInfinity, Valid: true}, wantErr: pgconv.ErrNotFinite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pgconv.ToDecimal(tt.in)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ToDecimal() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToDecimal() error = %v", err)
+			}
+			want, _ := new(big.Rat).SetString(tt.want)
+			if got.Cmp(want) != 0 {
+				t.Errorf("ToDecimal() = %s, want %s", got.RatString(), want.RatString())
+			}
+		})
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	// Floats come back as the same float
+	for _, f := range []float64{0, 1, -1, 0.1, 0.2, 0.3, 1.0 / 3, 1500.75, -12.5, 1e-9, 123456.789, math.MaxFloat64, math.SmallestNonzeroFloat64} {
+		n, err := pgconv.FromFloat(f)
+		if err != nil {
+			t.Errorf("FromFloat(%v) error = %v", f, err)
+			continue
+		}
+		got, err := pgconv.ToFloat(n)
+		if err != nil {
+			t.Errorf("ToFloat(FromFloat(%v)) error = %v", f, err)
+			continue
+		}
+		if got != f {
+			t.Errorf("ToFloat(FromFloat(%v)) = %v", f, got)
+		}
+	}
+
+	// Decimal strings are kept exactly, where float64 would drift
+	for _, s := range []string{"0.1", "1500.75", "-0.000001", "99999999999999999.99", "1.5e3"} {
+		n, err := pgconv.FromString(s)
+		if err != nil {
+			t.Errorf("FromString(%q) error = %v", s, err)
+			continue
+		}
+		got, err := pgconv.ToDecimal(n)
+		if err != nil {
+			t.Errorf("ToDecimal(FromString(%q)) error = %v", s, err)
+			continue
+		}
+		want, _ := new(big.Rat).SetString(s)
+		if got.Cmp(want) != 0 {
+			t.Errorf("ToDecimal(FromString(%q)) = %s, want %s", s, got.RatString(), want.RatString())
+		}
+	}
+
+	// Summing exact decimals doesn't drift, 0.1 + 0.2 is 0.3
+	sum := new(big.Rat)
+	for _, s := range []string{"0.1", "0.2"} {
+		n, _ := pgconv.FromString(s)
+		value, _ := pgconv.ToDecimal(n)
+		sum.Add(sum, value)
+	}
+	if want := big.NewRat(3, 10); sum.Cmp(want) != 0 {
+		t.Errorf("0.1 + 0.2 = %s, want %s", sum.RatString(), want.RatString())
+	}
+}
+
+func TestToFloatErrors(t *testing.T) {
+	if _, err := pgconv.ToFloat(pgtype.Numeric{}); !errors.Is(err, pgconv.ErrNull) {
+		t.Errorf("ToFloat(NULL) error = %v, want %v", err, pgconv.ErrNull)
+	}
+	if _, err := pgconv.ToFloat(pgtype.Numeric{NaN: true, Valid: true}); !errors.Is(err, pgconv.ErrNotFinite) {
+		t.Errorf("ToFloat(NaN) error = %v, want %v", err, pgconv.ErrNotFinite)
+	}
+}
+
+func TestConverterErr(t *testing.T) {
+	var c pgconv.Converter
+	first := c.FromFloat(1.5)
+	if c.Err() != nil {
+		t.Fatalf("Err() = %v after a finite float", c.Err())
+	}
+	assertNumeric(t, "Converter.FromFloat", 1.5, first, "15", -1)
+
+	if n := c.FromFloat(math.NaN()); n.Valid {
+		t.Error("Converter.FromFloat(NaN) = valid numeric, want invalid")
+	}
+	if !errors.Is(c.Err(), pgconv.ErrNotFinite) {
+		t.Fatalf("Err() = %v, want %v", c.Err(), pgconv.ErrNotFinite)
+	}
+
+	// Later conversions still convert and keep the first error
+	later := c.FromFloat(2)
+	assertNumeric(t, "Converter.FromFloat", 2.0, later, "2", 0)
+	c.FromFloat(math.Inf(1))
+	if !errors.Is(c.Err(), pgconv.ErrNotFinite) {
+		t.Errorf("Err() = %v, want the first error", c.Err())
+	}
+}
+
+func numeric(digits string, exp int32) pgtype.Numeric {
+	n, _ := new(big.Int).SetString(digits, 10)
+	return pgtype.Numeric{Int: n, Exp: exp, Valid: true}
+}
+
+func assertNumeric(t *testing.T, fn string, in any, n pgtype.Numeric, digits string, exp int32) {
+	t.Helper()
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite {
+		t.Errorf("%s(%v) = %+v, want a finite numeric", fn, in, n)
+		return
+	}
+	if n.Int.String() != digits || n.Exp != exp {
+		t.Errorf("%s(%v) = %se%d, want %se%d", fn, in, n.Int, n.Exp, digits, exp)
+	}
+}
@@ -0,0 +1,74 @@
+-- name: AcknowledgeAnnouncement :one
+-- Records that a user read a notice, keeping the time of the first acknowledgment
+INSERT INTO announcement_acknowledgments (announcement_id, user_id)
+VALUES (@announcement_id, @user_id)
+ON CONFLICT (announcement_id, user_id) DO UPDATE SET acknowledged_at = announcement_acknowledgments.acknowledged_at
+RETURNING *;
+
+-- name: CountAnnouncements :one
+SELECT COUNT(*) FROM announcements;
+
+-- name: CreateAnnouncement :one
+INSERT INTO announcements (
+  title,
+  body,
+  user_types,
+  departments,
+  user_ids,
+  requires_acknowledgment,
+  publish_at,
+  expire_at,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: DeleteAnnouncement :execrows
+DELETE FROM announcements
+WHERE id = $1;
+
+-- name: GetAnnouncement :one
+SELECT * FROM announcements
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAnnouncementAcknowledgments :many
+-- The live users who acknowledged a notice, in the order they did
+SELECT a.user_id, u.username, a.acknowledged_at
+FROM announcement_acknowledgments a
+JOIN users u ON u.id = a.user_id
+WHERE a.announcement_id = $1 AND u.deleted_at IS NULL
+ORDER BY a.acknowledged_at, a.user_id;
+
+-- name: ListAnnouncementAcknowledgmentsByUser :many
+SELECT * FROM announcement_acknowledgments
+WHERE user_id = $1
+ORDER BY acknowledged_at;
+
+-- name: ListAnnouncements :many
+-- Every announcement, published or not, the latest to publish first
+SELECT * FROM announcements
+ORDER BY publish_at DESC, id DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: ListPublishedAnnouncements :many
+-- The announcements published at a time and not yet expired, whoever they are for, the latest
+-- first
+SELECT * FROM announcements
+WHERE publish_at <= @at AND (expire_at IS NULL OR expire_at > @at)
+ORDER BY publish_at DESC, id DESC;
+
+-- name: UpdateAnnouncement :one
+UPDATE announcements
+SET title = $2,
+    body = $3,
+    user_types = $4,
+    departments = $5,
+    user_ids = $6,
+    requires_acknowledgment = $7,
+    publish_at = $8,
+    expire_at = $9,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -40,6 +40,7 @@ WHERE ar.year = @year
 ORDER BY ar.user_id;
 
 -- name: UpdateAnnualRecord :one
+-- Matches no row when expected_updated_at is set and the record changed since, so concurrent edits conflict
 UPDATE annual_records
 SET 
   quota_plan_id = COALESCE(@quota_plan_id, quota_plan_id),
@@ -51,6 +52,7 @@ SET
   used_medical_expense_baht = COALESCE(@used_medical_expense_baht, used_medical_expense_baht),
   updated_at = NOW()
 WHERE user_id = @user_id AND year = @year
+  AND (sqlc.narg(expected_updated_at)::TIMESTAMPTZ IS NULL OR updated_at = sqlc.narg(expected_updated_at)::TIMESTAMPTZ)
 RETURNING *;
 
 -- name: DeleteAnnualRecord :exec
@@ -59,7 +61,7 @@ WHERE id = @id;
 
 -- name: CreateNextYearAnnualRecords :many
 WITH user_list AS (
-    SELECT id FROM users
+    SELECT id FROM users WHERE deleted_at IS NULL
 ),
 default_quota_plan AS (
     SELECT id 
@@ -130,7 +132,7 @@ RETURNING *;
 
 -- name: AssignQuotaPlanToAllUsers :exec
 WITH user_list AS (
-    SELECT id FROM users
+    SELECT id FROM users WHERE deleted_at IS NULL
 )
 -- Update existing records
 UPDATE annual_records
@@ -193,4 +195,39 @@ VALUES (
 ON CONFLICT (user_id, year) DO UPDATE SET
     quota_plan_id = @quota_plan_id,
     updated_at = NOW()
-RETURNING *; 
\ No newline at end of file
+RETURNING *; 
+
+-- name: GetLeaveBalance :one
+-- A user's leave and medical expense balances in a year, from their annual record and its quota plan
+SELECT
+  ar.year,
+  qp.plan_name,
+  COALESCE(qp.quota_vacation_day, 0)::DECIMAL AS quota_vacation_day,
+  COALESCE(ar.rollover_vacation_day, 0)::DECIMAL AS rollover_vacation_day,
+  COALESCE(ar.used_vacation_day, 0)::DECIMAL AS used_vacation_day,
+  COALESCE(ar.used_sick_leave_day, 0)::DECIMAL AS used_sick_leave_day,
+  COALESCE(qp.quota_medical_expense_baht, 0)::DECIMAL AS quota_medical_expense_baht,
+  COALESCE(ar.used_medical_expense_baht, 0)::DECIMAL AS used_medical_expense_baht
+FROM annual_records ar
+LEFT JOIN quota_plans qp ON qp.id = ar.quota_plan_id
+WHERE ar.user_id = @user_id AND ar.year = @year
+LIMIT 1;
+
+-- name: ListLeaveBalancesByYear :many
+-- Every user's leave and medical expense balances in a year, by username
+SELECT
+  ar.user_id,
+  u.username,
+  ar.year,
+  qp.plan_name,
+  COALESCE(qp.quota_vacation_day, 0)::DECIMAL AS quota_vacation_day,
+  COALESCE(ar.rollover_vacation_day, 0)::DECIMAL AS rollover_vacation_day,
+  COALESCE(ar.used_vacation_day, 0)::DECIMAL AS used_vacation_day,
+  COALESCE(ar.used_sick_leave_day, 0)::DECIMAL AS used_sick_leave_day,
+  COALESCE(qp.quota_medical_expense_baht, 0)::DECIMAL AS quota_medical_expense_baht,
+  COALESCE(ar.used_medical_expense_baht, 0)::DECIMAL AS used_medical_expense_baht
+FROM annual_records ar
+JOIN users u ON u.id = ar.user_id AND u.deleted_at IS NULL
+LEFT JOIN quota_plans qp ON qp.id = ar.quota_plan_id
+WHERE ar.year = @year
+ORDER BY u.username;
@@ -1,63 +1,49 @@
--- name: SyncAnnualRecordVacationDays :one
--- This query synchronizes the used vacation days and sick leave days for a specific user and year
-WITH vacation_days AS (
-    SELECT 
-        SUM(CASE WHEN ll.type = 'vacation' THEN 1 ELSE 0 END) AS vacation_count,
-        SUM(CASE WHEN ll.type = 'sick' THEN 1 ELSE 0 END) AS sick_count
-    FROM leave_logs ll
-    WHERE ll.user_id = @user_id AND EXTRACT(YEAR FROM ll.date) = @year
+-- name: SyncAnnualRecord :one
+-- Derives the used and worked totals of a user's annual record for a year from its balance events
+WITH totals AS (
+    SELECT
+        SUM(vacation_day) AS vacation_days,
+        SUM(sick_leave_day) AS sick_leave_days,
+        SUM(worked_day) AS worked_days,
+        SUM(worked_on_holiday_day) AS worked_on_holiday_days,
+        SUM(medical_expense_baht) AS medical_expense_baht
+    FROM balance_events
+    WHERE user_id = @user_id AND year = @year
 )
 UPDATE annual_records ar
-SET 
-    used_vacation_day = COALESCE((SELECT vacation_count FROM vacation_days), 0),
-    used_sick_leave_day = COALESCE((SELECT sick_count FROM vacation_days), 0),
-    updated_at = NOW()
-WHERE ar.user_id = @user_id AND ar.year = @year
-RETURNING *;
-
--- name: SyncAnnualRecordWorkDays :one
--- This query synchronizes the worked days and worked on holiday days for a specific user and year
-WITH work_days AS (
-    SELECT 
-        SUM(tl.worked_day) AS total_worked_days,
-        SUM(CASE WHEN tl.is_work_on_holiday = true THEN tl.worked_day ELSE 0 END) AS holiday_worked_days
-    FROM task_logs tl
-    WHERE tl.created_by_user_id = @user_id AND EXTRACT(YEAR FROM tl.worked_date) = @year
-)
-UPDATE annual_records ar
-SET 
-    worked_day = COALESCE((SELECT total_worked_days FROM work_days), 0),
-    worked_on_holiday_day = COALESCE((SELECT holiday_worked_days FROM work_days), 0),
+SET
+    used_vacation_day = COALESCE((SELECT vacation_days FROM totals), 0),
+    used_sick_leave_day = COALESCE((SELECT sick_leave_days FROM totals), 0),
+    worked_day = COALESCE((SELECT worked_days FROM totals), 0),
+    worked_on_holiday_day = COALESCE((SELECT worked_on_holiday_days FROM totals), 0),
+    used_medical_expense_baht = COALESCE((SELECT medical_expense_baht FROM totals), 0),
     updated_at = NOW()
 WHERE ar.user_id = @user_id AND ar.year = @year
 RETURNING *;
 
 -- name: SyncAllAnnualRecordsByYear :many
--- This query synchronizes all annual records for a specific year
-WITH user_stats AS (
-    SELECT 
-        u.id AS user_id,
-        COALESCE(SUM(CASE WHEN ll.type = 'vacation' THEN 1 ELSE 0 END), 0) AS vacation_days,
-        COALESCE(SUM(CASE WHEN ll.type = 'sick' THEN 1 ELSE 0 END), 0) AS sick_days,
-        COALESCE((SELECT SUM(tl.worked_day) 
-                  FROM task_logs tl 
-                  WHERE tl.created_by_user_id = u.id 
-                  AND EXTRACT(YEAR FROM tl.worked_date) = @year), 0) AS total_worked_days,
-        COALESCE((SELECT SUM(CASE WHEN tl.is_work_on_holiday = true THEN tl.worked_day ELSE 0 END) 
-                  FROM task_logs tl 
-                  WHERE tl.created_by_user_id = u.id 
-                  AND EXTRACT(YEAR FROM tl.worked_date) = @year), 0) AS holiday_worked_days
-    FROM users u
-    LEFT JOIN leave_logs ll ON u.id = ll.user_id AND EXTRACT(YEAR FROM ll.date) = @year
-    GROUP BY u.id
+-- Derives the used and worked totals of every annual record of a year from the balance events
+WITH totals AS (
+    SELECT
+        ar.id AS annual_record_id,
+        COALESCE(SUM(be.vacation_day), 0) AS vacation_days,
+        COALESCE(SUM(be.sick_leave_day), 0) AS sick_leave_days,
+        COALESCE(SUM(be.worked_day), 0) AS worked_days,
+        COALESCE(SUM(be.worked_on_holiday_day), 0) AS worked_on_holiday_days,
+        COALESCE(SUM(be.medical_expense_baht), 0) AS medical_expense_baht
+    FROM annual_records ar
+    LEFT JOIN balance_events be ON be.user_id = ar.user_id AND be.year = ar.year
+    WHERE ar.year = @year
+    GROUP BY ar.id
 )
 UPDATE annual_records ar
-SET 
-    used_vacation_day = us.vacation_days,
-    used_sick_leave_day = us.sick_days,
-    worked_day = us.total_worked_days,
-    worked_on_holiday_day = us.holiday_worked_days,
+SET
+    used_vacation_day = t.vacation_days,
+    used_sick_leave_day = t.sick_leave_days,
+    worked_day = t.worked_days,
+    worked_on_holiday_day = t.worked_on_holiday_days,
+    used_medical_expense_baht = t.medical_expense_baht,
     updated_at = NOW()
-FROM user_stats us
-WHERE ar.user_id = us.user_id AND ar.year = @year
-RETURNING *; 
\ No newline at end of file
+FROM totals t
+WHERE ar.id = t.annual_record_id
+RETURNING ar.*;
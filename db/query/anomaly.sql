@@ -0,0 +1,64 @@
+-- name: ListSickLeaveDays :many
+-- The sick leave of live users on the days of a range, by user and day
+SELECT l.user_id, l.date FROM leave_logs l
+JOIN users u ON u.id = l.user_id
+WHERE l.type = 'sick' AND l.deleted_at IS NULL AND u.deleted_at IS NULL
+  AND l.date BETWEEN @start_date AND @end_date
+ORDER BY l.user_id, l.date;
+
+-- name: ListOverloggedDays :many
+-- The days of a range live users logged more work on than max_ratio of their daily capacity
+SELECT tl.created_by_user_id AS user_id, tl.worked_date,
+       SUM(tl.worked_day)::NUMERIC AS logged_days, u.daily_capacity
+FROM task_logs tl
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE u.deleted_at IS NULL AND tl.worked_date BETWEEN @start_date AND @end_date
+GROUP BY tl.created_by_user_id, tl.worked_date, u.daily_capacity
+HAVING SUM(tl.worked_day)::FLOAT8 > u.daily_capacity::FLOAT8 * @max_ratio::FLOAT8
+ORDER BY tl.created_by_user_id, tl.worked_date;
+
+-- name: ListExpensesNearApprovalLimit :many
+-- The medical expenses of live users dated in a range whose amount is at most margin, a fraction,
+-- below the most that needs no approval
+SELECT e.id, e.user_id, e.amount, e.receipt_date, p.auto_approve_max::NUMERIC AS approval_limit
+FROM medical_expenses e
+JOIN users u ON u.id = e.user_id
+JOIN approval_policies p ON p.kind = 'medical_expense' AND p.auto_approve_max IS NOT NULL
+WHERE e.deleted_at IS NULL AND u.deleted_at IS NULL
+  AND e.receipt_date BETWEEN @start_date AND @end_date
+  AND e.amount <= p.auto_approve_max
+  AND e.amount::FLOAT8 >= p.auto_approve_max::FLOAT8 * (1 - @margin::FLOAT8)
+ORDER BY e.user_id, e.receipt_date, e.id;
+
+-- name: CreateAnomaly :execrows
+-- Flags a finding, unless the same one was flagged before
+INSERT INTO anomalies (rule, user_id, period_start, period_end, fingerprint, summary, details)
+VALUES (@rule, @user_id, @period_start, @period_end, @fingerprint, @summary, @details)
+ON CONFLICT (tenant_id, rule, user_id, fingerprint) DO NOTHING;
+
+-- name: GetAnomaly :one
+SELECT * FROM anomalies
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAnomalies :many
+-- The review queue, newest first, narrowed to a status, rule and user when they are given
+SELECT * FROM anomalies
+WHERE (sqlc.narg(status)::TEXT IS NULL OR status = sqlc.narg(status)::TEXT)
+  AND (sqlc.narg(rule)::TEXT IS NULL OR rule = sqlc.narg(rule)::TEXT)
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR user_id = sqlc.narg(user_id)::INTEGER)
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: CountAnomalies :one
+SELECT COUNT(*) FROM anomalies
+WHERE (sqlc.narg(status)::TEXT IS NULL OR status = sqlc.narg(status)::TEXT)
+  AND (sqlc.narg(rule)::TEXT IS NULL OR rule = sqlc.narg(rule)::TEXT)
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR user_id = sqlc.narg(user_id)::INTEGER);
+
+-- name: ReviewAnomaly :one
+-- Confirms or dismisses a finding, or opens it again, recording who did it
+UPDATE anomalies
+SET status = @status, reviewed_by = @reviewed_by, reviewed_at = NOW(), review_note = @review_note
+WHERE id = @id
+RETURNING *;
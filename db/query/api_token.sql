@@ -0,0 +1,34 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+  name,
+  token_hash,
+  scopes,
+  created_by_user_id
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetAPIToken :one
+SELECT * FROM api_tokens
+WHERE id = $1 LIMIT 1;
+
+-- name: GetAPITokenByHash :one
+-- Finds the token a service called with, unless it was revoked
+SELECT * FROM api_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL LIMIT 1;
+
+-- name: ListAPITokens :many
+SELECT * FROM api_tokens
+ORDER BY id;
+
+-- name: RevokeAPIToken :one
+UPDATE api_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1;
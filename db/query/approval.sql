@@ -0,0 +1,144 @@
+-- name: ListApprovalPolicies :many
+SELECT * FROM approval_policies
+ORDER BY kind;
+
+-- name: GetApprovalPolicy :one
+SELECT * FROM approval_policies
+WHERE kind = $1 LIMIT 1;
+
+-- name: UpsertApprovalPolicy :one
+-- Sets the steps a kind of request goes through and the amount approved without any
+INSERT INTO approval_policies (
+  kind,
+  steps,
+  auto_approve_max
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (tenant_id, kind) DO UPDATE
+SET steps = EXCLUDED.steps, auto_approve_max = EXCLUDED.auto_approve_max, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteApprovalPolicy :exec
+-- Stops requests of the kind needing approval, leaving the ones already waiting as they are
+DELETE FROM approval_policies
+WHERE kind = $1;
+
+-- name: CreateApprovalRequest :one
+-- Submits a request, decided at once when it is submitted as approved
+INSERT INTO approval_requests (
+  kind,
+  subject_id,
+  requester_id,
+  amount,
+  payload,
+  steps,
+  status,
+  decided_at
+) VALUES (
+  @kind, @subject_id, @requester_id, @amount, @payload, @steps, @status,
+  CASE WHEN @status::TEXT <> 'pending' THEN NOW() END
+)
+RETURNING *;
+
+-- name: GetApprovalRequest :one
+SELECT * FROM approval_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPendingApprovalRequestBySubject :one
+-- The request waiting on a leave log, medical expense, task log or quota plan
+SELECT * FROM approval_requests
+WHERE kind = $1 AND subject_id = $2 AND status = 'pending'
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListPendingApprovalRequests :many
+-- Every request waiting on a decision, oldest first
+SELECT * FROM approval_requests
+WHERE status = 'pending'
+ORDER BY created_at, id;
+
+-- name: ListApprovalRequestsByRequester :many
+SELECT * FROM approval_requests
+WHERE requester_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: AdvanceApprovalRequest :one
+-- Moves a request on from the step it waits on, failing with no rows when someone else decided
+-- that step first
+UPDATE approval_requests
+SET
+  current_step = @next_step,
+  status = @status,
+  decided_at = CASE WHEN @status::TEXT <> 'pending' THEN NOW() END,
+  updated_at = NOW()
+WHERE id = @id AND status = 'pending' AND current_step = @current_step
+RETURNING *;
+
+-- name: CancelApprovalRequests :exec
+-- Cancels what waits on a leave log, medical expense, task log or quota plan that went away
+UPDATE approval_requests
+SET status = 'cancelled', decided_at = NOW(), updated_at = NOW()
+WHERE kind = $1 AND subject_id = $2 AND status = 'pending';
+
+-- name: CreateApprovalDecision :one
+INSERT INTO approval_decisions (
+  request_id,
+  step,
+  approver_id,
+  on_behalf_of_id,
+  delegation_id,
+  decision,
+  comment
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListApprovalDecisions :many
+SELECT * FROM approval_decisions
+WHERE request_id = $1
+ORDER BY created_at, id;
+
+-- name: ListApprovalDecisionsByDelegation :many
+-- The decisions a delegate made under a delegation, for its audit trail
+SELECT * FROM approval_decisions
+WHERE delegation_id = $1
+ORDER BY created_at, id;
+
+-- name: CreateApprovalDelegation :one
+INSERT INTO approval_delegations (
+  delegator_id,
+  delegate_id,
+  start_date,
+  end_date,
+  created_by_id
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetApprovalDelegation :one
+SELECT * FROM approval_delegations
+WHERE id = $1 LIMIT 1;
+
+-- name: ListApprovalDelegationsByUser :many
+-- The delegations a user gave or was given, revoked ones too, latest first
+SELECT * FROM approval_delegations
+WHERE delegator_id = $1 OR delegate_id = $1
+ORDER BY start_date DESC, id DESC;
+
+-- name: ListActiveApprovalDelegations :many
+-- The delegations the user decides under on the day, revoked ones left out
+SELECT * FROM approval_delegations
+WHERE delegate_id = @delegate_id AND @day::DATE BETWEEN start_date AND end_date
+  AND revoked_at IS NULL
+ORDER BY delegator_id, id;
+
+-- name: RevokeApprovalDelegation :one
+-- Ends a delegation, keeping it for the audit trail. Fails with no rows when it was revoked
+-- already.
+UPDATE approval_delegations
+SET revoked_at = NOW(), revoked_by_id = @revoked_by_id
+WHERE id = @id AND revoked_at IS NULL
+RETURNING *;
@@ -0,0 +1,66 @@
+-- name: CreateAttendanceSession :one
+-- Checks a user in, failing on idx_attendance_sessions_open when they already are
+INSERT INTO attendance_sessions (
+  user_id,
+  work_date,
+  check_in_ip,
+  check_in_latitude,
+  check_in_longitude,
+  note
+) VALUES (
+  @user_id, @work_date, sqlc.narg(check_in_ip), sqlc.narg(check_in_latitude), sqlc.narg(check_in_longitude), sqlc.narg(note)
+) RETURNING *;
+
+-- name: CloseAttendanceSession :one
+-- Checks a user out of their open session, no rows when they aren't checked in
+UPDATE attendance_sessions
+SET
+  check_out_at = NOW(),
+  check_out_ip = sqlc.narg(check_out_ip),
+  check_out_latitude = sqlc.narg(check_out_latitude),
+  check_out_longitude = sqlc.narg(check_out_longitude)
+WHERE user_id = @user_id AND check_out_at IS NULL
+RETURNING *;
+
+-- name: GetOpenAttendanceSession :one
+-- The session a user is checked in to
+SELECT * FROM attendance_sessions
+WHERE user_id = @user_id AND check_out_at IS NULL
+LIMIT 1;
+
+-- name: ListAttendanceSessionsByUser :many
+-- A user's sessions on the days of a date range, the latest first
+SELECT * FROM attendance_sessions
+WHERE user_id = @user_id AND work_date BETWEEN @date_from AND @date_to
+ORDER BY check_in_at DESC;
+
+-- name: ListDailyAttendance :many
+-- Every user's attendance on a day, of one department when given: their first check-in, last
+-- check-out and hours present, counting open sessions up to now, and the leave they took
+SELECT
+  u.id AS user_id,
+  u.username,
+  u.department,
+  MIN(a.check_in_at)::TIMESTAMPTZ AS first_check_in_at,
+  MAX(a.check_out_at)::TIMESTAMPTZ AS last_check_out_at,
+  COUNT(a.id) AS sessions,
+  COALESCE(BOOL_OR(a.id IS NOT NULL AND a.check_out_at IS NULL), FALSE)::BOOLEAN AS checked_in,
+  (COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(a.check_out_at, NOW()) - a.check_in_at)), 0) / 3600)::float8 AS hours,
+  COALESCE((
+    SELECT ll.type FROM leave_logs ll
+    WHERE ll.user_id = u.id AND ll.date = @work_date AND ll.deleted_at IS NULL
+    ORDER BY ll.id
+    LIMIT 1
+  ), '')::TEXT AS leave_type
+FROM users u
+LEFT JOIN attendance_sessions a ON a.user_id = u.id AND a.work_date = @work_date
+WHERE u.deleted_at IS NULL
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+GROUP BY u.id
+ORDER BY u.username;
+
+-- name: SumAttendanceHoursForDate :one
+-- The hours a user was checked in on a day, counting an open session up to now
+SELECT (COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(check_out_at, NOW()) - check_in_at)), 0) / 3600)::float8 AS hours
+FROM attendance_sessions
+WHERE user_id = @user_id AND work_date = @work_date;
@@ -0,0 +1,98 @@
+-- name: AdjustBalance :exec
+-- Records the difference between the totals of an annual record and what its balance events add
+-- up to, so the record keeps the totals an admin set when it is next synced. Nothing is recorded
+-- when they agree.
+INSERT INTO balance_events (
+  user_id,
+  year,
+  source,
+  source_id,
+  action,
+  vacation_day,
+  sick_leave_day,
+  worked_day,
+  worked_on_holiday_day,
+  medical_expense_baht,
+  recorded_by_user_id,
+  tenant_id
+)
+SELECT
+  ar.user_id,
+  ar.year,
+  'annual_records',
+  ar.id,
+  'adjusted',
+  COALESCE(ar.used_vacation_day, 0) - COALESCE(SUM(be.vacation_day), 0),
+  COALESCE(ar.used_sick_leave_day, 0) - COALESCE(SUM(be.sick_leave_day), 0),
+  COALESCE(ar.worked_day, 0) - COALESCE(SUM(be.worked_day), 0),
+  COALESCE(ar.worked_on_holiday_day, 0) - COALESCE(SUM(be.worked_on_holiday_day), 0),
+  COALESCE(ar.used_medical_expense_baht, 0) - COALESCE(SUM(be.medical_expense_baht), 0),
+  sqlc.narg(recorded_by_user_id)::INTEGER,
+  ar.tenant_id
+FROM annual_records ar
+LEFT JOIN balance_events be ON be.user_id = ar.user_id AND be.year = ar.year
+WHERE ar.id = @annual_record_id
+GROUP BY ar.id
+HAVING COALESCE(ar.used_vacation_day, 0) <> COALESCE(SUM(be.vacation_day), 0)
+  OR COALESCE(ar.used_sick_leave_day, 0) <> COALESCE(SUM(be.sick_leave_day), 0)
+  OR COALESCE(ar.worked_day, 0) <> COALESCE(SUM(be.worked_day), 0)
+  OR COALESCE(ar.worked_on_holiday_day, 0) <> COALESCE(SUM(be.worked_on_holiday_day), 0)
+  OR COALESCE(ar.used_medical_expense_baht, 0) <> COALESCE(SUM(be.medical_expense_baht), 0);
+
+-- name: GetBalanceTotals :one
+-- Replays the balance events of a user's year recorded up to as_of, all of them when it is NULL
+SELECT
+  COUNT(*) AS events,
+  COALESCE(MAX(id), 0)::BIGINT AS last_event_id,
+  COALESCE(SUM(vacation_day), 0)::DECIMAL AS vacation_day,
+  COALESCE(SUM(sick_leave_day), 0)::DECIMAL AS sick_leave_day,
+  COALESCE(SUM(worked_day), 0)::DECIMAL AS worked_day,
+  COALESCE(SUM(worked_on_holiday_day), 0)::DECIMAL AS worked_on_holiday_day,
+  COALESCE(SUM(medical_expense_baht), 0)::DECIMAL AS medical_expense_baht
+FROM balance_events
+WHERE user_id = @user_id AND year = @year
+  AND (sqlc.narg(as_of)::TIMESTAMPTZ IS NULL OR recorded_at <= sqlc.narg(as_of)::TIMESTAMPTZ);
+
+-- name: ListBalanceDrift :many
+-- Annual records of a year whose totals aren't what their balance events add up to
+SELECT
+  ar.id,
+  ar.user_id,
+  u.username,
+  ar.used_vacation_day,
+  ar.used_sick_leave_day,
+  ar.worked_day,
+  ar.worked_on_holiday_day,
+  ar.used_medical_expense_baht,
+  COALESCE(t.vacation_day, 0)::DECIMAL AS events_vacation_day,
+  COALESCE(t.sick_leave_day, 0)::DECIMAL AS events_sick_leave_day,
+  COALESCE(t.worked_day, 0)::DECIMAL AS events_worked_day,
+  COALESCE(t.worked_on_holiday_day, 0)::DECIMAL AS events_worked_on_holiday_day,
+  COALESCE(t.medical_expense_baht, 0)::DECIMAL AS events_medical_expense_baht
+FROM annual_records ar
+JOIN users u ON u.id = ar.user_id
+LEFT JOIN (
+  SELECT
+    be.user_id,
+    SUM(be.vacation_day) AS vacation_day,
+    SUM(be.sick_leave_day) AS sick_leave_day,
+    SUM(be.worked_day) AS worked_day,
+    SUM(be.worked_on_holiday_day) AS worked_on_holiday_day,
+    SUM(be.medical_expense_baht) AS medical_expense_baht
+  FROM balance_events be
+  WHERE be.year = @year
+  GROUP BY be.user_id
+) t ON t.user_id = ar.user_id
+WHERE ar.year = @year
+  AND (COALESCE(ar.used_vacation_day, 0) <> COALESCE(t.vacation_day, 0)
+    OR COALESCE(ar.used_sick_leave_day, 0) <> COALESCE(t.sick_leave_day, 0)
+    OR COALESCE(ar.worked_day, 0) <> COALESCE(t.worked_day, 0)
+    OR COALESCE(ar.worked_on_holiday_day, 0) <> COALESCE(t.worked_on_holiday_day, 0)
+    OR COALESCE(ar.used_medical_expense_baht, 0) <> COALESCE(t.medical_expense_baht, 0))
+ORDER BY u.username;
+
+-- name: ListBalanceEvents :many
+-- The ledger of a user's year, in the order it was recorded
+SELECT * FROM balance_events
+WHERE user_id = @user_id AND year = @year
+ORDER BY id;
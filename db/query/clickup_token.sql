@@ -0,0 +1,33 @@
+-- name: GetClickUpToken :one
+-- Returns the most recently connected token of the user
+SELECT * FROM clickup_tokens
+WHERE user_id = $1
+ORDER BY updated_at DESC
+LIMIT 1;
+
+-- name: GetClickUpTokenForTeam :one
+SELECT * FROM clickup_tokens
+WHERE user_id = $1 AND team_id = $2 LIMIT 1;
+
+-- name: ListClickUpTokensByUser :many
+SELECT * FROM clickup_tokens
+WHERE user_id = $1
+ORDER BY team_id;
+
+-- name: UpsertClickUpToken :one
+INSERT INTO clickup_tokens (
+  user_id,
+  team_id,
+  access_token,
+  token_type
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (user_id, team_id) DO UPDATE SET
+  access_token = EXCLUDED.access_token,
+  token_type = EXCLUDED.token_type,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteClickUpToken :execrows
+DELETE FROM clickup_tokens
+WHERE user_id = $1;
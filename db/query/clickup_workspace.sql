@@ -0,0 +1,21 @@
+-- name: GetClickUpWorkspace :one
+SELECT * FROM clickup_workspaces
+WHERE team_id = $1 LIMIT 1;
+
+-- name: ListClickUpWorkspaces :many
+SELECT * FROM clickup_workspaces
+ORDER BY name, team_id;
+
+-- name: UpsertClickUpWorkspace :one
+-- The latest user to connect the workspace becomes the one whose token syncs it
+INSERT INTO clickup_workspaces (
+  team_id,
+  name,
+  connected_by_user_id
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (team_id) DO UPDATE SET
+  name = EXCLUDED.name,
+  connected_by_user_id = EXCLUDED.connected_by_user_id,
+  updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,63 @@
+-- name: CreateDepartment :one
+INSERT INTO departments (
+  name,
+  parent_id,
+  head_user_id
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetDepartment :one
+SELECT * FROM departments
+WHERE id = $1 LIMIT 1;
+
+-- name: GetDepartmentByName :one
+SELECT * FROM departments
+WHERE name = $1 LIMIT 1;
+
+-- name: ListDepartments :many
+SELECT * FROM departments
+ORDER BY name;
+
+-- name: UpdateDepartment :one
+UPDATE departments
+SET name = $2, parent_id = $3, head_user_id = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDepartment :exec
+DELETE FROM departments
+WHERE id = $1;
+
+-- name: CountDepartmentMembers :one
+-- The live users in a department, not counting those of the departments under it
+SELECT COUNT(*) FROM users
+WHERE department = @department::TEXT AND deleted_at IS NULL;
+
+-- name: CountSubDepartments :one
+SELECT COUNT(*) FROM departments
+WHERE parent_id = $1;
+
+-- name: RenameUserDepartments :exec
+-- Moves the users of a department to its new name, deleted users too
+UPDATE users
+SET department = @new_name::TEXT, updated_at = NOW()
+WHERE department = @old_name::TEXT;
+
+-- name: RenameTaskCategoryDepartments :exec
+-- Moves the task categories scoped to a department to its new name
+UPDATE task_categories
+SET department = @new_name::TEXT, updated_at = NOW()
+WHERE department = @old_name::TEXT;
+
+-- name: ListLeaveCalendar :many
+-- Leave in a date range with who takes it, for everyone or one department and those under it,
+-- by date
+SELECT ll.id, ll.user_id, u.username, u.department, ll.type, ll.date, ll.note
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL AND u.deleted_at IS NULL
+  AND ll.date BETWEEN @date_from::DATE AND @date_to::DATE
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+ORDER BY ll.date, u.username, ll.id;
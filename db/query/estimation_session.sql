@@ -0,0 +1,60 @@
+-- name: AddEstimationSessionParticipant :exec
+INSERT INTO estimation_session_participants (
+  session_id,
+  user_id
+) VALUES (
+  $1, $2
+) ON CONFLICT (session_id, user_id) DO NOTHING;
+
+-- name: CloseEstimationSession :one
+UPDATE estimation_sessions
+SET 
+  status = 'closed',
+  closed_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: CreateEstimationSession :one
+INSERT INTO estimation_sessions (
+  task_id,
+  created_by_user_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetEstimationSession :one
+SELECT * FROM estimation_sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListEstimationSessionParticipants :many
+-- Participants of a session with their vote, if they have voted
+SELECT
+  p.user_id,
+  u.username,
+  te.id AS estimate_id,
+  te.estimate_day,
+  te.note,
+  te.created_at AS voted_at
+FROM estimation_session_participants p
+JOIN users u ON u.id = p.user_id
+LEFT JOIN task_estimates te ON te.session_id = p.session_id AND te.created_by_user_id = p.user_id AND te.is_vote
+WHERE p.session_id = $1
+ORDER BY u.username;
+
+-- name: ListEstimationSessionsByTask :many
+SELECT * FROM estimation_sessions
+WHERE task_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListEstimationSessionsAwaitingVote :many
+-- Open sessions the user takes part in without having voted yet, the oldest first
+SELECT es.id, es.task_id, t.title AS task_title, es.created_at
+FROM estimation_sessions es
+JOIN estimation_session_participants p ON p.session_id = es.id AND p.user_id = @user_id
+JOIN tasks t ON t.id = es.task_id
+WHERE es.status = 'open'
+  AND NOT EXISTS (
+    SELECT 1 FROM task_estimates te
+    WHERE te.session_id = es.id AND te.created_by_user_id = @user_id AND te.is_vote
+  )
+ORDER BY es.created_at, es.id;
@@ -0,0 +1,32 @@
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags
+ORDER BY key;
+
+-- name: GetFeatureFlag :one
+SELECT * FROM feature_flags
+WHERE key = $1 LIMIT 1;
+
+-- name: SetFeatureFlag :one
+-- Creates the flag or replaces its settings
+INSERT INTO feature_flags (
+  key,
+  description,
+  enabled,
+  user_types,
+  departments,
+  user_ids
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (key) DO UPDATE SET
+  description = EXCLUDED.description,
+  enabled = EXCLUDED.enabled,
+  user_types = EXCLUDED.user_types,
+  departments = EXCLUDED.departments,
+  user_ids = EXCLUDED.user_ids,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags
+WHERE key = $1;
@@ -0,0 +1,39 @@
+-- name: CreateFile :one
+INSERT INTO files (
+  owner_id,
+  purpose,
+  subject_id,
+  storage_key,
+  file_name,
+  content_type,
+  size,
+  sha256,
+  scanned_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetFile :one
+SELECT * FROM files
+WHERE id = $1 LIMIT 1;
+
+-- name: ListFilesBySubject :many
+-- Lists the files of a medical expense, leave log or user, oldest first
+SELECT * FROM files
+WHERE purpose = $1 AND subject_id = $2
+ORDER BY created_at, id;
+
+-- name: DeleteFile :exec
+DELETE FROM files
+WHERE id = $1;
+
+-- name: ListOrphanedFiles :many
+-- Lists files whose medical expense, leave log or user is gone. Soft deleted ones keep their
+-- files until they are purged.
+SELECT * FROM files
+WHERE (purpose = 'medical_receipt' AND NOT EXISTS (SELECT 1 FROM medical_expenses WHERE medical_expenses.id = files.subject_id))
+   OR (purpose = 'sick_leave_certificate' AND NOT EXISTS (SELECT 1 FROM leave_logs WHERE leave_logs.id = files.subject_id))
+   OR (purpose = 'avatar' AND NOT EXISTS (SELECT 1 FROM users WHERE users.id = files.subject_id))
+ORDER BY id
+LIMIT $1;
@@ -7,6 +7,16 @@ INSERT INTO holidays (
   $1, $2, $3
 ) RETURNING *;
 
+-- name: CreateHolidays :copyfrom
+-- Inserts many holidays in one COPY, for imports
+INSERT INTO holidays (
+  date,
+  name,
+  note
+) VALUES (
+  $1, $2, $3
+);
+
 -- name: GetHoliday :one
 SELECT * FROM holidays
 WHERE id = $1 LIMIT 1;
@@ -21,6 +31,13 @@ ORDER BY date
 LIMIT $1
 OFFSET $2;
 
+-- name: CountHolidays :one
+SELECT COUNT(*) FROM holidays;
+
+-- name: GetHolidaysVersion :one
+-- Changes whenever a holiday is added, edited or deleted, for the ETag of the holiday listings
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM holidays;
+
 -- name: ListHolidaysByYear :many
 SELECT * FROM holidays
 WHERE EXTRACT(YEAR FROM date) = $1
@@ -31,7 +48,8 @@ UPDATE holidays
 SET 
   date = COALESCE($2, date),
   name = COALESCE($3, name),
-  note = COALESCE($4, note)
+  note = COALESCE($4, note),
+  updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
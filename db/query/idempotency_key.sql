@@ -0,0 +1,41 @@
+-- name: ClaimIdempotencyKey :one
+-- Records that a request with the key is running. Returns no row while the key belongs to a
+-- request made since expired_before, older ones are taken over.
+INSERT INTO idempotency_keys (
+  user_id,
+  key,
+  request_hash
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (tenant_id, user_id, key) DO UPDATE
+SET
+  request_hash = EXCLUDED.request_hash,
+  status_code = NULL,
+  content_type = NULL,
+  response_body = NULL,
+  created_at = NOW()
+WHERE idempotency_keys.created_at < sqlc.arg(expired_before)::TIMESTAMPTZ
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 LIMIT 1;
+
+-- name: CompleteIdempotencyKey :exec
+-- Stores the response replayed to retries with the key
+UPDATE idempotency_keys
+SET
+  status_code = $2,
+  content_type = $3,
+  response_body = $4
+WHERE id = $1;
+
+-- name: DeleteIdempotencyKey :exec
+-- Releases the key of a request that failed, so a retry runs it again
+DELETE FROM idempotency_keys
+WHERE id = $1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
@@ -0,0 +1,15 @@
+-- name: GetLanguagePreference :one
+SELECT * FROM language_preferences
+WHERE user_id = $1 LIMIT 1;
+
+-- name: SetLanguagePreference :one
+INSERT INTO language_preferences (
+  user_id,
+  language
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  language = EXCLUDED.language,
+  updated_at = NOW()
+RETURNING *;
@@ -8,43 +8,151 @@ INSERT INTO leave_logs (
   $1, $2, $3, $4
 ) RETURNING *;
 
+-- name: CreateLeaveLogs :copyfrom
+-- Inserts many leave logs in one COPY, for imports
+INSERT INTO leave_logs (
+  user_id,
+  type,
+  date,
+  note
+) VALUES (
+  $1, $2, $3, $4
+);
+
 -- name: GetLeaveLog :one
 SELECT * FROM leave_logs
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
+
+-- name: CountLeaveLogsForDate :one
+-- Counts a user's leave on one date, used to check the daily capacity
+SELECT COUNT(*) FROM leave_logs
+WHERE user_id = $1 AND date = $2 AND deleted_at IS NULL;
 
 -- name: ListLeaveLogsByUser :many
 SELECT * FROM leave_logs
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY date DESC
 LIMIT $2
 OFFSET $3;
 
 -- name: ListLeaveLogsByType :many
 SELECT * FROM leave_logs
-WHERE user_id = $1 AND type = $2
+WHERE user_id = $1 AND type = $2 AND deleted_at IS NULL
 ORDER BY date DESC
 LIMIT $3
 OFFSET $4;
 
 -- name: ListLeaveLogsByDateRange :many
 SELECT * FROM leave_logs
-WHERE user_id = $1 AND date BETWEEN $2 AND $3
+WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND deleted_at IS NULL
 ORDER BY date DESC;
 
 -- name: ListLeaveLogsByYear :many
 SELECT * FROM leave_logs
-WHERE user_id = $1 AND EXTRACT(YEAR FROM date) = $2
+WHERE user_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND deleted_at IS NULL
 ORDER BY date DESC;
 
+-- name: ListLeaveLogsWithUsername :many
+-- Page of leave logs with their user's name, narrowed by whichever of user, type, year and date
+-- range are given, in sort_by order and otherwise newest date first
+SELECT ll.id, ll.user_id, ll.type, ll.date, ll.note, ll.created_at, ll.updated_at, u.username
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR ll.user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(type)::TEXT IS NULL OR ll.type = sqlc.narg(type)::TEXT)
+  AND (sqlc.narg(year)::INTEGER IS NULL OR EXTRACT(YEAR FROM ll.date) = sqlc.narg(year)::INTEGER)
+  AND (sqlc.narg(date_from)::DATE IS NULL OR ll.date >= sqlc.narg(date_from)::DATE)
+  AND (sqlc.narg(date_to)::DATE IS NULL OR ll.date <= sqlc.narg(date_to)::DATE)
+ORDER BY
+  CASE WHEN @sort_by::TEXT = 'date' AND NOT @sort_desc::BOOLEAN THEN ll.date END ASC,
+  CASE WHEN @sort_by::TEXT = 'created_at' AND NOT @sort_desc::BOOLEAN THEN ll.created_at END ASC,
+  CASE WHEN @sort_by::TEXT = 'created_at' AND @sort_desc::BOOLEAN THEN ll.created_at END DESC,
+  CASE WHEN @sort_by::TEXT = 'type' AND NOT @sort_desc::BOOLEAN THEN ll.type END ASC,
+  CASE WHEN @sort_by::TEXT = 'type' AND @sort_desc::BOOLEAN THEN ll.type END DESC,
+  ll.date DESC, ll.id DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: CountLeaveLogs :one
+-- Counts the leave logs ListLeaveLogsWithUsername and ListLeaveLogsAfter page through
+SELECT COUNT(*) FROM leave_logs
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(type)::TEXT IS NULL OR type = sqlc.narg(type)::TEXT)
+  AND (sqlc.narg(year)::INTEGER IS NULL OR EXTRACT(YEAR FROM date) = sqlc.narg(year)::INTEGER)
+  AND (sqlc.narg(date_from)::DATE IS NULL OR date >= sqlc.narg(date_from)::DATE)
+  AND (sqlc.narg(date_to)::DATE IS NULL OR date <= sqlc.narg(date_to)::DATE);
+
+-- name: SummarizeLeaveLogs :many
+-- Leave days per user and type in a year, for everyone or one user when given
+SELECT ll.user_id, u.username, ll.type, COUNT(*) AS day_count
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND ll.date >= make_date(@year::INTEGER, 1, 1)
+  AND ll.date < make_date(@year::INTEGER + 1, 1, 1)
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR ll.user_id = sqlc.narg(user_id)::INTEGER)
+GROUP BY ll.user_id, u.username, ll.type
+ORDER BY u.username, ll.user_id, ll.type;
+
+-- name: ListLeaveLogsAfter :many
+-- Keyset page of leave logs, newest first, starting after the (created_at, id) cursor when given
+SELECT * FROM leave_logs
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(type)::TEXT IS NULL OR type = sqlc.narg(type)::TEXT)
+  AND (sqlc.narg(year)::INTEGER IS NULL OR EXTRACT(YEAR FROM date) = sqlc.narg(year)::INTEGER)
+  AND (sqlc.narg(date_from)::DATE IS NULL OR date >= sqlc.narg(date_from)::DATE)
+  AND (sqlc.narg(date_to)::DATE IS NULL OR date <= sqlc.narg(date_to)::DATE)
+  AND (sqlc.narg(cursor_created_at)::TIMESTAMPTZ IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::TIMESTAMPTZ, @cursor_id::INTEGER))
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit;
+
 -- name: UpdateLeaveLog :one
+-- Matches no row when expected_updated_at is set and the leave log changed since, so concurrent edits conflict
 UPDATE leave_logs
 SET 
-  type = $2,
-  date = $3,
-  note = $4
-WHERE id = $1
+  type = @type,
+  date = @date,
+  note = @note,
+  updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL
+  AND (sqlc.narg(expected_updated_at)::TIMESTAMPTZ IS NULL OR updated_at = sqlc.narg(expected_updated_at)::TIMESTAMPTZ)
 RETURNING *;
 
 -- name: DeleteLeaveLog :exec
+-- Soft deletes the leave log, the row stays until PurgeLeaveLog removes it
+UPDATE leave_logs
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListPurgeableLeaveLogIDs :many
+-- Leave logs deleted before the cutoff, oldest deletion first
+SELECT id FROM leave_logs
+WHERE deleted_at IS NOT NULL AND deleted_at < @deleted_before::TIMESTAMPTZ
+ORDER BY deleted_at;
+
+-- name: PurgeLeaveLog :execrows
+-- Removes a soft deleted leave log for good, live ones are never touched
 DELETE FROM leave_logs
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: RestoreLeaveLog :execrows
+-- Undoes DeleteLeaveLog
+UPDATE leave_logs
+SET deleted_at = NULL
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: SummarizeLeaveLogsByDateRange :many
+-- Leave days per user and type in a date range, for everyone, one user or one department
+SELECT ll.user_id, u.username, u.department, ll.type, COUNT(*) AS day_count
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND ll.date BETWEEN @start_date::DATE AND @end_date::DATE
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR ll.user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+GROUP BY ll.user_id, u.username, u.department, ll.type
+ORDER BY u.username, ll.user_id, ll.type;
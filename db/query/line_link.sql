@@ -0,0 +1,36 @@
+-- name: CreateLineLinkCode :one
+-- A new code replaces the last one, the account linked before stays until the code is used
+INSERT INTO line_links (
+  user_id,
+  link_code,
+  link_code_expires_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (user_id) DO UPDATE SET
+  link_code = EXCLUDED.link_code,
+  link_code_expires_at = EXCLUDED.link_code_expires_at
+RETURNING *;
+
+-- name: DeleteLineLink :execrows
+DELETE FROM line_links
+WHERE user_id = $1;
+
+-- name: DeleteLineLinksByLineUserID :execrows
+-- Unlinks a LINE account from every user, such as when it blocks the bot
+DELETE FROM line_links
+WHERE line_user_id = $1;
+
+-- name: GetLineLink :one
+SELECT * FROM line_links
+WHERE user_id = $1 LIMIT 1;
+
+-- name: LinkLineAccount :one
+-- Links the LINE account a code was sent from, while the code hasn't expired
+UPDATE line_links
+SET
+  line_user_id = $2,
+  link_code = NULL,
+  link_code_expires_at = NULL,
+  linked_at = NOW()
+WHERE link_code = $1 AND link_code_expires_at > NOW()
+RETURNING *;
@@ -11,18 +11,22 @@ INSERT INTO medical_expenses (
 
 -- name: GetMedicalExpense :one
 SELECT * FROM medical_expenses
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListMedicalExpensesByUser :many
 SELECT * FROM medical_expenses
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY receipt_date DESC
 LIMIT $2
 OFFSET $3;
 
+-- name: CountMedicalExpensesByUser :one
+SELECT COUNT(*) FROM medical_expenses
+WHERE user_id = $1 AND deleted_at IS NULL;
+
 -- name: ListMedicalExpensesByYear :many
 SELECT * FROM medical_expenses
-WHERE user_id = $1 AND EXTRACT(YEAR FROM receipt_date) = $2
+WHERE user_id = $1 AND EXTRACT(YEAR FROM receipt_date) = sqlc.arg(year)::int AND deleted_at IS NULL
 ORDER BY receipt_date DESC;
 
 -- name: UpdateMedicalExpense :one
@@ -32,9 +36,39 @@ SET
   receipt_name = $3,
   receipt_date = $4,
   note = $5
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteMedicalExpense :exec
+-- Soft deletes the medical expense, the row stays until PurgeMedicalExpense removes it
+UPDATE medical_expenses
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListPurgeableMedicalExpenseIDs :many
+-- Medical expenses deleted before the cutoff, oldest deletion first
+SELECT id FROM medical_expenses
+WHERE deleted_at IS NOT NULL AND deleted_at < @deleted_before::TIMESTAMPTZ
+ORDER BY deleted_at;
+
+-- name: PurgeMedicalExpense :execrows
+-- Removes a soft deleted medical expense for good, live ones are never touched
 DELETE FROM medical_expenses
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: RestoreMedicalExpense :execrows
+-- Undoes DeleteMedicalExpense
+UPDATE medical_expenses
+SET deleted_at = NULL
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: ListMedicalExpensesByDateRange :many
+-- Medical expenses with a receipt date in a date range and who claimed them, for everyone, one user or one department
+SELECT me.id, me.receipt_date, me.user_id, u.username, u.department, me.receipt_name, me.amount, me.note
+FROM medical_expenses me
+JOIN users u ON u.id = me.user_id
+WHERE me.deleted_at IS NULL
+  AND me.receipt_date BETWEEN @start_date::DATE AND @end_date::DATE
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR me.user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+ORDER BY u.username, me.user_id, me.receipt_date, me.id;
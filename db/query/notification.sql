@@ -0,0 +1,52 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+  user_id,
+  kind,
+  channel,
+  recipient,
+  subject,
+  body
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetNotification :one
+SELECT * FROM notifications
+WHERE id = $1 LIMIT 1;
+
+-- name: MarkNotificationSent :exec
+UPDATE notifications
+SET
+  status = 'sent',
+  last_error = NULL,
+  sent_at = NOW()
+WHERE id = $1;
+
+-- name: SetNotificationError :exec
+-- Records why the last try to send a notification failed
+UPDATE notifications
+SET last_error = $2
+WHERE id = $1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY kind;
+
+-- name: SetNotificationPreference :one
+INSERT INTO notification_preferences (
+  user_id,
+  kind,
+  email,
+  line,
+  push
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, kind) DO UPDATE SET
+  email = EXCLUDED.email,
+  line = EXCLUDED.line,
+  push = EXCLUDED.push,
+  updated_at = NOW()
+RETURNING *;
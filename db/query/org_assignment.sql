@@ -0,0 +1,35 @@
+-- name: GetOrgAssignment :one
+SELECT * FROM org_assignments
+WHERE user_id = $1 LIMIT 1;
+
+-- name: UpsertOrgAssignment :one
+-- Sets the position a user holds and who they report to
+INSERT INTO org_assignments (
+  user_id,
+  position_id,
+  manager_id
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET position_id = EXCLUDED.position_id, manager_id = EXCLUDED.manager_id, updated_at = NOW()
+RETURNING *;
+
+-- name: ManagesAnyone :one
+-- Whether a user has someone reporting to them or heads a department, and so decides approvals
+SELECT EXISTS (
+  SELECT 1 FROM org_assignments oa
+  JOIN users u ON u.id = oa.user_id AND u.deleted_at IS NULL
+  WHERE oa.manager_id = @user_id::INTEGER
+) OR EXISTS (
+  SELECT 1 FROM departments WHERE head_user_id = @user_id::INTEGER
+) AS manages;
+
+-- name: ListOrgChartMembers :many
+-- Every live user with their department, position and who they report to, by username
+SELECT u.id AS user_id, u.username, u.department, oa.position_id, p.title AS position_title, oa.manager_id
+FROM users u
+LEFT JOIN org_assignments oa ON oa.user_id = u.id
+LEFT JOIN positions p ON p.id = oa.position_id
+WHERE u.deleted_at IS NULL
+ORDER BY u.username, u.id;
@@ -0,0 +1,71 @@
+-- name: CreatePayrollPeriod :one
+INSERT INTO payroll_periods (
+  period,
+  start_date,
+  end_date,
+  created_by_user_id
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetPayrollPeriod :one
+SELECT * FROM payroll_periods
+WHERE period = $1 LIMIT 1;
+
+-- name: ListPayrollPeriods :many
+-- The periods, the latest first
+SELECT * FROM payroll_periods
+ORDER BY start_date DESC;
+
+-- name: LockPayrollPeriod :one
+-- Locks an open period with its export, no rows when it is already locked
+UPDATE payroll_periods
+SET status = 'locked', file_name = $2, content = $3, locked_by_user_id = $4, locked_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: UnlockPayrollPeriod :one
+-- Reopens a locked period, throwing its export away, no rows when it isn't locked
+UPDATE payroll_periods
+SET status = 'open', file_name = NULL, content = NULL, locked_by_user_id = NULL, locked_at = NULL
+WHERE id = $1 AND status = 'locked'
+RETURNING *;
+
+-- name: SummarizePayroll :many
+-- What payroll needs of every live user in a period: their days of paid and unpaid leave, the
+-- days they logged as work on a holiday, and their overtime, the hours checked in beyond a full
+-- day of their capacity on days that aren't holidays, counting the sessions they checked out of
+SELECT
+  u.id AS user_id,
+  u.username,
+  u.department,
+  (
+    SELECT COUNT(*) FROM leave_logs ll
+    WHERE ll.user_id = u.id AND ll.date BETWEEN @start_date AND @end_date AND ll.deleted_at IS NULL
+      AND NOT ll.type = ANY(@unpaid_leave_types::TEXT[])
+  ) AS paid_leave_days,
+  (
+    SELECT COUNT(*) FROM leave_logs ll
+    WHERE ll.user_id = u.id AND ll.date BETWEEN @start_date AND @end_date AND ll.deleted_at IS NULL
+      AND ll.type = ANY(@unpaid_leave_types::TEXT[])
+  ) AS unpaid_leave_days,
+  (
+    SELECT COALESCE(SUM(tl.worked_day), 0) FROM task_logs tl
+    WHERE tl.created_by_user_id = u.id AND tl.worked_date BETWEEN @start_date AND @end_date
+      AND tl.is_work_on_holiday
+  )::float8 AS holiday_work_days,
+  (
+    SELECT COALESCE(SUM(GREATEST(d.hours - @hours_per_day::float8 * u.daily_capacity::float8, 0)), 0)
+    FROM (
+      SELECT SUM(EXTRACT(EPOCH FROM a.check_out_at - a.check_in_at)) / 3600 AS hours
+      FROM attendance_sessions a
+      WHERE a.user_id = u.id AND a.work_date BETWEEN @start_date AND @end_date
+        AND a.check_out_at IS NOT NULL
+        AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = a.work_date)
+      GROUP BY a.work_date
+    ) d
+  )::float8 AS overtime_hours
+FROM users u
+WHERE u.deleted_at IS NULL
+ORDER BY u.username, u.id;
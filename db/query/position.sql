@@ -0,0 +1,27 @@
+-- name: CreatePosition :one
+INSERT INTO positions (
+  title,
+  department_id
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: GetPosition :one
+SELECT * FROM positions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListPositions :many
+SELECT * FROM positions
+ORDER BY title, id;
+
+-- name: UpdatePosition :one
+UPDATE positions
+SET title = $2, department_id = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePosition :exec
+-- Deletes a position, the users holding it are left without one
+DELETE FROM positions
+WHERE id = $1;
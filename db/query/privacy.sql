@@ -0,0 +1,130 @@
+-- name: ExportLeaveLogs :many
+-- Every leave log of a user, deleted ones too, for their data export
+SELECT * FROM leave_logs
+WHERE user_id = $1
+ORDER BY date, id;
+
+-- name: ExportMedicalExpenses :many
+-- Every medical expense of a user, deleted ones too, for their data export
+SELECT * FROM medical_expenses
+WHERE user_id = $1
+ORDER BY receipt_date, id;
+
+-- name: ExportTaskLogs :many
+-- Every task log a user created, for their data export
+SELECT * FROM task_logs
+WHERE created_by_user_id = $1
+ORDER BY worked_date, id;
+
+-- name: ExportAttendanceSessions :many
+-- Every attendance session of a user, for their data export
+SELECT * FROM attendance_sessions
+WHERE user_id = $1
+ORDER BY check_in_at, id;
+
+-- name: ExportNotifications :many
+-- Every notification sent or queued for a user, for their data export
+SELECT * FROM notifications
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: ExportTaskComments :many
+-- Every task comment a user wrote, for their data export
+SELECT * FROM task_comments
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: ExportApprovalRequests :many
+-- Every approval request a user submitted, for their data export
+SELECT * FROM approval_requests
+WHERE requester_id = $1
+ORDER BY created_at, id;
+
+-- name: ExportApprovalDecisions :many
+-- Every approval decision a user made, themselves or for someone who delegated to them
+SELECT * FROM approval_decisions
+WHERE approver_id = $1 OR on_behalf_of_id = $1
+ORDER BY created_at, id;
+
+-- name: ListFilesOfUser :many
+-- The files a user uploaded and those of their medical expenses, leave logs and avatar
+SELECT * FROM files
+WHERE owner_id = @user_id
+   OR (purpose = 'avatar' AND subject_id = @user_id)
+   OR (purpose = 'medical_receipt' AND subject_id IN (SELECT id FROM medical_expenses WHERE user_id = @user_id))
+   OR (purpose = 'sick_leave_certificate' AND subject_id IN (SELECT id FROM leave_logs WHERE user_id = @user_id))
+ORDER BY id;
+
+-- name: GetAnonymization :one
+SELECT * FROM anonymizations
+WHERE user_id = $1 LIMIT 1;
+
+-- name: ListUsersToAnonymize :many
+-- Users deleted before the cutoff whose personal data is still there, oldest deletion first
+SELECT u.id FROM users u
+WHERE u.deleted_at IS NOT NULL AND u.deleted_at < @deleted_before::TIMESTAMPTZ
+  AND NOT EXISTS (SELECT 1 FROM anonymizations a WHERE a.user_id = u.id)
+ORDER BY u.deleted_at
+LIMIT @row_limit;
+
+-- name: AnonymizeUser :one
+-- Replaces the name, email and password of a deleted user, keeping their type, department and
+-- capacity for statistics, and records who did it. Live users are never touched.
+WITH anonymized AS (
+  UPDATE users
+  SET
+    username = 'anonymized-' || users.id,
+    email = 'anonymized-' || users.id || '@anonymized.invalid',
+    password = '',
+    updated_at = NOW()
+  WHERE users.id = @user_id AND users.deleted_at IS NOT NULL
+  RETURNING users.id
+)
+INSERT INTO anonymizations (user_id, anonymized_by)
+SELECT id, sqlc.narg(anonymized_by)::INTEGER FROM anonymized
+ON CONFLICT (user_id) DO UPDATE SET
+  anonymized_by = EXCLUDED.anonymized_by,
+  anonymized_at = NOW()
+RETURNING *;
+
+-- name: ScrubUserPersonalData :exec
+-- Clears the notes, receipt names and check-in locations of a user's logs, keeping their dates
+-- and amounts, and deletes their notifications, preferences, links, devices and tokens
+WITH leave_notes AS (
+  UPDATE leave_logs SET note = NULL WHERE user_id = @user_id
+), expense_notes AS (
+  UPDATE medical_expenses SET receipt_name = NULL, note = NULL WHERE user_id = @user_id
+), locations AS (
+  UPDATE attendance_sessions
+  SET check_in_ip = NULL, check_in_latitude = NULL, check_in_longitude = NULL,
+      check_out_ip = NULL, check_out_latitude = NULL, check_out_longitude = NULL, note = NULL
+  WHERE user_id = @user_id
+), sent AS (
+  DELETE FROM notifications WHERE user_id = @user_id
+), notification_prefs AS (
+  DELETE FROM notification_preferences WHERE user_id = @user_id
+), language_prefs AS (
+  DELETE FROM language_preferences WHERE user_id = @user_id
+), time_zone_prefs AS (
+  DELETE FROM time_zone_preferences WHERE user_id = @user_id
+), line AS (
+  DELETE FROM line_links WHERE user_id = @user_id
+), devices AS (
+  DELETE FROM push_devices WHERE user_id = @user_id
+), clickup AS (
+  DELETE FROM clickup_tokens WHERE user_id = @user_id
+), idempotency AS (
+  DELETE FROM idempotency_keys WHERE user_id = @user_id
+), delegations AS (
+  DELETE FROM approval_delegations WHERE delegator_id = @user_id OR delegate_id = @user_id
+)
+DELETE FROM org_assignments WHERE user_id = @user_id;
+
+-- name: ClearAttendanceLocations :execrows
+-- Clears the IPs and coordinates of check-ins before the cutoff, keeping their times
+UPDATE attendance_sessions
+SET check_in_ip = NULL, check_in_latitude = NULL, check_in_longitude = NULL,
+    check_out_ip = NULL, check_out_latitude = NULL, check_out_longitude = NULL
+WHERE check_in_at < @checked_in_before::TIMESTAMPTZ
+  AND (check_in_ip IS NOT NULL OR check_in_latitude IS NOT NULL
+    OR check_out_ip IS NOT NULL OR check_out_latitude IS NOT NULL);
@@ -0,0 +1,28 @@
+-- name: DeletePushDevice :execrows
+DELETE FROM push_devices
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeletePushDeviceByToken :execrows
+-- Forgets a token FCM no longer delivers to, such as of an app that was uninstalled
+DELETE FROM push_devices
+WHERE token = $1;
+
+-- name: ListPushDevicesByUser :many
+SELECT * FROM push_devices
+WHERE user_id = $1
+ORDER BY last_seen_at DESC, id DESC;
+
+-- name: RegisterPushDevice :one
+-- Keeps a device's token for the user, moving it from whoever registered it before
+INSERT INTO push_devices (
+  user_id,
+  token,
+  platform
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (tenant_id, token) DO UPDATE SET
+  user_id = EXCLUDED.user_id,
+  platform = EXCLUDED.platform,
+  last_seen_at = NOW()
+RETURNING *;
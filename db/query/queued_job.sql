@@ -0,0 +1,94 @@
+-- name: EnqueueJob :one
+INSERT INTO queued_jobs (
+  kind,
+  payload,
+  max_attempts,
+  run_at,
+  request_id
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ClaimQueuedJobs :many
+-- Takes up to row_limit jobs for a worker: pending ones that are due, and running ones locked
+-- before stale_before, whose server stopped mid-run. SKIP LOCKED lets several servers claim at
+-- once without taking the same job.
+UPDATE queued_jobs
+SET
+  status = 'running',
+  attempts = attempts + 1,
+  locked_by = sqlc.arg(locked_by),
+  locked_at = NOW(),
+  updated_at = NOW()
+WHERE id IN (
+  SELECT id FROM queued_jobs
+  WHERE (queued_jobs.status = 'pending' AND run_at <= NOW())
+     OR (queued_jobs.status = 'running' AND locked_at < sqlc.arg(stale_before)::TIMESTAMPTZ)
+  ORDER BY run_at
+  LIMIT sqlc.arg(row_limit)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteQueuedJob :exec
+UPDATE queued_jobs
+SET
+  status = 'done',
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: RetryQueuedJob :exec
+-- Puts a failed job back to run again at run_at
+UPDATE queued_jobs
+SET
+  status = 'pending',
+  run_at = $2,
+  last_error = $3,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailQueuedJob :exec
+-- Gives up on a job, leaving it as a dead letter
+UPDATE queued_jobs
+SET
+  status = 'dead',
+  last_error = $2,
+  locked_by = NULL,
+  locked_at = NULL,
+  updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetQueuedJob :one
+SELECT * FROM queued_jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListQueuedJobs :many
+SELECT * FROM queued_jobs
+WHERE status = @status
+ORDER BY id DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: CountQueuedJobs :one
+SELECT COUNT(*) FROM queued_jobs
+WHERE status = $1;
+
+-- name: RequeueQueuedJob :one
+-- Gives a dead job a fresh set of attempts, starting now
+UPDATE queued_jobs
+SET
+  status = 'pending',
+  attempts = 0,
+  run_at = NOW(),
+  updated_at = NOW()
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: DeleteFinishedQueuedJobs :execrows
+DELETE FROM queued_jobs
+WHERE status = 'done' AND updated_at < $1;
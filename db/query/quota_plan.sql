@@ -27,7 +27,12 @@ SELECT * FROM quota_plans
 WHERE year = @year
 ORDER BY plan_name;
 
+-- name: GetQuotaPlansVersion :one
+-- Changes whenever a quota plan is added, edited or deleted, for the ETag of the quota plan listings
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM quota_plans;
+
 -- name: UpdateQuotaPlan :one
+-- Matches no row when expected_updated_at is set and the plan changed since, so concurrent edits conflict
 UPDATE quota_plans
 SET 
   plan_name = COALESCE(@plan_name, plan_name),
@@ -36,6 +41,7 @@ SET
   quota_medical_expense_baht = COALESCE(@quota_medical_expense_baht, quota_medical_expense_baht),
   updated_at = NOW()
 WHERE id = @id
+  AND (sqlc.narg(expected_updated_at)::TIMESTAMPTZ IS NULL OR updated_at = sqlc.narg(expected_updated_at)::TIMESTAMPTZ)
 RETURNING *;
 
 -- name: DeleteQuotaPlan :exec
@@ -0,0 +1,34 @@
+-- name: CreateReportExport :one
+INSERT INTO report_exports (
+  report_name,
+  format,
+  from_date,
+  to_date,
+  user_id,
+  department,
+  created_by_user_id,
+  language
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetReportExport :one
+SELECT * FROM report_exports
+WHERE id = $1 LIMIT 1;
+
+-- name: CompleteReportExport :exec
+-- Stores the file an export job rendered
+UPDATE report_exports
+SET status = 'done', file_name = $2, content = $3, error = NULL, completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailReportExport :exec
+UPDATE report_exports
+SET status = 'failed', error = $2, completed_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteReportExportsBefore :execrows
+-- Deletes the exports made before a time, done or not
+DELETE FROM report_exports
+WHERE created_at < $1;
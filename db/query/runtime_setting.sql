@@ -0,0 +1,22 @@
+-- name: ListRuntimeSettings :many
+SELECT * FROM runtime_settings
+ORDER BY key;
+
+-- name: SetRuntimeSetting :one
+-- Creates the override or replaces its value
+INSERT INTO runtime_settings (
+  key,
+  value,
+  updated_by
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (key) DO UPDATE SET
+  value = EXCLUDED.value,
+  updated_by = EXCLUDED.updated_by,
+  updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteRuntimeSetting :execrows
+DELETE FROM runtime_settings
+WHERE key = $1;
@@ -0,0 +1,64 @@
+-- name: RegisterScheduledJob :exec
+-- Adds a job, or updates the schedule of a known one whose schedule changed, keeping whichever
+-- next run comes first
+INSERT INTO scheduled_jobs (
+  name,
+  schedule,
+  next_run_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (name) DO UPDATE
+SET
+  schedule = EXCLUDED.schedule,
+  next_run_at = LEAST(scheduled_jobs.next_run_at, EXCLUDED.next_run_at),
+  updated_at = NOW()
+WHERE scheduled_jobs.schedule <> EXCLUDED.schedule;
+
+-- name: ListScheduledJobs :many
+SELECT * FROM scheduled_jobs
+ORDER BY name;
+
+-- name: GetScheduledJob :one
+SELECT * FROM scheduled_jobs
+WHERE name = $1 LIMIT 1;
+
+-- name: ClaimScheduledJob :one
+-- Starts a due run, moving the next run on. Returns no row when the job is paused or not due,
+-- for instance because another instance claimed it first.
+UPDATE scheduled_jobs
+SET
+  next_run_at = sqlc.arg(next_run_at),
+  last_started_at = NOW(),
+  last_run_by = sqlc.arg(run_by),
+  updated_at = NOW()
+WHERE name = sqlc.arg(name) AND NOT paused AND next_run_at <= NOW()
+RETURNING *;
+
+-- name: FinishScheduledJob :exec
+UPDATE scheduled_jobs
+SET
+  last_finished_at = NOW(),
+  last_error = $2,
+  updated_at = NOW()
+WHERE name = $1;
+
+-- name: SetScheduledJobPaused :one
+-- Pauses or resumes a job. Resuming passes the next run, so the runs missed while paused are
+-- skipped rather than caught up on.
+UPDATE scheduled_jobs
+SET
+  paused = sqlc.arg(paused),
+  next_run_at = COALESCE(sqlc.narg(next_run_at), next_run_at),
+  updated_at = NOW()
+WHERE name = sqlc.arg(name)
+RETURNING *;
+
+-- name: TriggerScheduledJob :one
+-- Makes a job due now
+UPDATE scheduled_jobs
+SET
+  next_run_at = NOW(),
+  updated_at = NOW()
+WHERE name = $1
+RETURNING *;
@@ -0,0 +1,41 @@
+-- name: DeleteSlackWorkspace :execrows
+DELETE FROM slack_workspaces
+WHERE team_id = $1;
+
+-- name: GetSlackWorkspace :one
+SELECT * FROM slack_workspaces
+WHERE team_id = $1 LIMIT 1;
+
+-- name: ListSlackWorkspaces :many
+SELECT * FROM slack_workspaces
+ORDER BY name, team_id;
+
+-- name: MarkSlackOutTodayPosted :exec
+-- Records the day who is out was posted to a workspace
+UPDATE slack_workspaces
+SET out_today_posted_on = $2
+WHERE team_id = $1;
+
+-- name: UpsertSlackWorkspace :one
+-- Connecting a workspace again replaces its token and settings
+INSERT INTO slack_workspaces (
+  team_id,
+  name,
+  bot_token,
+  approvals_channel,
+  out_today_channel,
+  out_today_hour,
+  notify_users,
+  connected_by_user_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) ON CONFLICT (team_id) DO UPDATE SET
+  name = EXCLUDED.name,
+  bot_token = EXCLUDED.bot_token,
+  approvals_channel = EXCLUDED.approvals_channel,
+  out_today_channel = EXCLUDED.out_today_channel,
+  out_today_hour = EXCLUDED.out_today_hour,
+  notify_users = EXCLUDED.notify_users,
+  connected_by_user_id = EXCLUDED.connected_by_user_id,
+  updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,76 @@
+-- name: CreateTag :one
+INSERT INTO tags (
+  name,
+  color
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetTag :one
+SELECT * FROM tags
+WHERE id = $1 LIMIT 1;
+
+-- name: ListTags :many
+SELECT * FROM tags
+ORDER BY name;
+
+-- name: UpdateTag :one
+UPDATE tags
+SET 
+  name = $2,
+  color = $3,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE FROM tags
+WHERE id = $1;
+
+-- name: UpsertTagByName :one
+INSERT INTO tags (
+  name,
+  color
+) VALUES (
+  $1, $2
+) ON CONFLICT (tenant_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddTaskTag :exec
+INSERT INTO task_tags (
+  task_id,
+  tag_id
+) VALUES (
+  $1, $2
+) ON CONFLICT (task_id, tag_id) DO NOTHING;
+
+-- name: RemoveTaskTag :execrows
+DELETE FROM task_tags
+WHERE task_id = $1 AND tag_id = $2;
+
+-- name: ListTaskTags :many
+SELECT tg.* FROM tags tg
+JOIN task_tags tt ON tt.tag_id = tg.id
+WHERE tt.task_id = $1
+ORDER BY tg.name;
+
+-- name: ListTaskTagsByTaskIDs :many
+SELECT tt.task_id, tg.id, tg.name, tg.color
+FROM task_tags tt
+JOIN tags tg ON tg.id = tt.tag_id
+WHERE tt.task_id = ANY(@task_ids::int[])
+ORDER BY tt.task_id, tg.name;
+
+-- name: ListTagWorkedDays :many
+SELECT
+  tg.id,
+  tg.name,
+  tg.color,
+  COUNT(DISTINCT tl.task_id) AS task_count,
+  SUM(tl.worked_day)::DECIMAL AS worked_day
+FROM tags tg
+JOIN task_tags tt ON tt.tag_id = tg.id
+JOIN task_logs tl ON tl.task_id = tt.task_id
+WHERE tl.worked_date BETWEEN @start_date AND @end_date
+GROUP BY tg.id, tg.name, tg.color
+ORDER BY worked_day DESC, tg.name;
@@ -5,24 +5,38 @@ INSERT INTO tasks (
   note,
   title,
   status,
-  status_color
+  status_color,
+  due_date,
+  priority,
+  parent_task_id,
+  created_by_user_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
+-- name: FindDuplicateTask :one
+-- Matches the same ClickUp URL, or a title equal after ignoring case, spaces and punctuation
+SELECT * FROM tasks
+WHERE deleted_at IS NULL
+  AND ((sqlc.narg(url)::TEXT IS NOT NULL AND url = sqlc.narg(url)::TEXT)
+    OR LOWER(REGEXP_REPLACE(COALESCE(title, ''), '[[:space:][:punct:]]+', '', 'g')) = LOWER(REGEXP_REPLACE(@title::TEXT, '[[:space:][:punct:]]+', '', 'g')))
+ORDER BY url IS NOT DISTINCT FROM sqlc.narg(url)::TEXT DESC, created_at
+LIMIT 1;
+
 -- name: GetTask :one
 SELECT * FROM tasks
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListTasks :many
 SELECT * FROM tasks
+WHERE deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2;
 
 -- name: ListTasksByCategory :many
 SELECT * FROM tasks
-WHERE task_category_id = $1
+WHERE task_category_id = $1 AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: ListTasksByCategoryWithSubcategories :many
@@ -34,23 +48,143 @@ WITH RECURSIVE subcategories AS (
   SELECT tc.id FROM task_categories tc
   JOIN subcategories sc ON tc.parent_id = sc.id
 )
-SELECT t.* FROM tasks t
-WHERE t.task_category_id IN (SELECT sc.id FROM subcategories sc)
+SELECT sqlc.embed(t), c.name AS category_name
+FROM tasks t
+JOIN task_categories c ON c.id = t.task_category_id
+WHERE t.task_category_id IN (SELECT sc.id FROM subcategories sc) AND t.deleted_at IS NULL
 ORDER BY t.created_at DESC;
 
+-- name: ListSubtasks :many
+SELECT * FROM tasks
+WHERE parent_task_id = $1 AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: ListTaskAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+  SELECT t.id, t.parent_task_id FROM tasks t WHERE t.id = $1
+  UNION ALL
+  SELECT t.id, t.parent_task_id FROM tasks t
+  JOIN ancestors a ON t.id = a.parent_task_id
+)
+SELECT ancestors.id FROM ancestors;
+
+-- name: SearchTasks :many
+SELECT sqlc.embed(t), c.name AS category_name
+FROM tasks t
+LEFT JOIN task_categories c ON c.id = t.task_category_id
+WHERE t.deleted_at IS NULL
+  AND (sqlc.narg(status)::TEXT IS NULL OR LOWER(t.status) = LOWER(sqlc.narg(status)::TEXT))
+  AND (sqlc.narg(category_id)::INTEGER IS NULL OR t.task_category_id = sqlc.narg(category_id)::INTEGER)
+  AND (sqlc.narg(assignee_id)::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_assignees ta
+    WHERE ta.task_id = t.id AND ta.user_id = sqlc.narg(assignee_id)::INTEGER
+  ))
+  AND (sqlc.narg(tag_id)::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = t.id AND tt.tag_id = sqlc.narg(tag_id)::INTEGER
+  ))
+  AND (NOT @overdue_only::BOOLEAN OR (
+    t.due_date < CURRENT_DATE
+    AND LOWER(COALESCE(t.status, '')) NOT IN ('complete', 'completed', 'closed', 'done')
+  ))
+  AND (sqlc.narg(due_from)::DATE IS NULL OR t.due_date >= sqlc.narg(due_from)::DATE)
+  AND (sqlc.narg(due_to)::DATE IS NULL OR t.due_date <= sqlc.narg(due_to)::DATE)
+ORDER BY
+  CASE WHEN @sort_by::TEXT = 'due_date' AND NOT @sort_desc::BOOLEAN THEN t.due_date END ASC NULLS LAST,
+  CASE WHEN @sort_by::TEXT = 'due_date' AND @sort_desc::BOOLEAN THEN t.due_date END DESC NULLS LAST,
+  CASE WHEN @sort_by::TEXT = 'priority' AND NOT @sort_desc::BOOLEAN THEN t.priority END ASC NULLS LAST,
+  CASE WHEN @sort_by::TEXT = 'priority' AND @sort_desc::BOOLEAN THEN t.priority END DESC NULLS LAST,
+  CASE WHEN @sort_by::TEXT = 'title' AND NOT @sort_desc::BOOLEAN THEN t.title END ASC,
+  CASE WHEN @sort_by::TEXT = 'title' AND @sort_desc::BOOLEAN THEN t.title END DESC,
+  CASE WHEN @sort_by::TEXT = 'updated_at' AND NOT @sort_desc::BOOLEAN THEN t.updated_at END ASC,
+  CASE WHEN @sort_by::TEXT = 'updated_at' AND @sort_desc::BOOLEAN THEN t.updated_at END DESC,
+  CASE WHEN @sort_by::TEXT = 'created_at' AND NOT @sort_desc::BOOLEAN THEN t.created_at END ASC,
+  t.created_at DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: CountSearchTasks :one
+-- Counts the tasks SearchTasks pages through
+SELECT COUNT(*)
+FROM tasks t
+WHERE t.deleted_at IS NULL
+  AND (sqlc.narg(status)::TEXT IS NULL OR LOWER(t.status) = LOWER(sqlc.narg(status)::TEXT))
+  AND (sqlc.narg(category_id)::INTEGER IS NULL OR t.task_category_id = sqlc.narg(category_id)::INTEGER)
+  AND (sqlc.narg(assignee_id)::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_assignees ta
+    WHERE ta.task_id = t.id AND ta.user_id = sqlc.narg(assignee_id)::INTEGER
+  ))
+  AND (sqlc.narg(tag_id)::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = t.id AND tt.tag_id = sqlc.narg(tag_id)::INTEGER
+  ))
+  AND (NOT @overdue_only::BOOLEAN OR (
+    t.due_date < CURRENT_DATE
+    AND LOWER(COALESCE(t.status, '')) NOT IN ('complete', 'completed', 'closed', 'done')
+  ))
+  AND (sqlc.narg(due_from)::DATE IS NULL OR t.due_date >= sqlc.narg(due_from)::DATE)
+  AND (sqlc.narg(due_to)::DATE IS NULL OR t.due_date <= sqlc.narg(due_to)::DATE);
+
 -- name: UpdateTask :one
+-- Matches no row when expected_updated_at is set and the task changed since, so concurrent edits conflict
+UPDATE tasks
+SET 
+  url = @url,
+  task_category_id = @task_category_id,
+  note = @note,
+  title = @title,
+  status = @status,
+  status_color = @status_color,
+  due_date = @due_date,
+  priority = @priority,
+  updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL
+  AND (sqlc.narg(expected_updated_at)::TIMESTAMPTZ IS NULL OR updated_at = sqlc.narg(expected_updated_at)::TIMESTAMPTZ)
+RETURNING *;
+
+-- name: SetTaskParent :one
 UPDATE tasks
 SET 
-  url = $2,
-  task_category_id = $3,
-  note = $4,
-  title = $5,
-  status = $6,
-  status_color = $7,
+  parent_task_id = $2,
   updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
 -- name: DeleteTask :exec
+-- Soft deletes the task, the row stays until PurgeTask removes it
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListPurgeableTaskIDs :many
+-- Tasks deleted before the cutoff, oldest deletion first
+SELECT id FROM tasks
+WHERE deleted_at IS NOT NULL AND deleted_at < @deleted_before::TIMESTAMPTZ
+ORDER BY deleted_at;
+
+-- name: PurgeTask :execrows
+-- Removes a soft deleted task for good, live ones are never touched
 DELETE FROM tasks
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: RestoreTask :execrows
+-- Undoes DeleteTask
+UPDATE tasks
+SET deleted_at = NULL
+WHERE id = @id AND deleted_at IS NOT NULL;
+ 
+
+-- name: ListRecentTasksForUser :many
+-- Tasks a user was assigned to or logged work on, the latest first
+WITH activity AS (
+  SELECT ta.task_id, ta.assigned_at AS at FROM task_assignees ta WHERE ta.user_id = @user_id
+  UNION ALL
+  SELECT tl.task_id, tl.created_at FROM task_logs tl WHERE tl.created_by_user_id = @user_id
+)
+SELECT t.id, t.title, t.status, t.status_color, t.due_date, t.priority, MAX(a.at)::TIMESTAMPTZ AS last_activity_at
+FROM activity a
+JOIN tasks t ON t.id = a.task_id
+WHERE t.deleted_at IS NULL
+GROUP BY t.id
+ORDER BY last_activity_at DESC, t.id DESC
+LIMIT @row_limit;
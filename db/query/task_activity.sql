@@ -0,0 +1,37 @@
+-- name: CreateTaskActivity :exec
+INSERT INTO task_activities (
+  task_id,
+  user_id,
+  activity_type,
+  old_value,
+  new_value,
+  reference_id,
+  request_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListTaskActivities :many
+SELECT
+  a.id,
+  a.task_id,
+  a.user_id,
+  u.username,
+  a.activity_type,
+  a.old_value,
+  a.new_value,
+  a.reference_id,
+  c.body AS comment_body,
+  a.created_at,
+  a.request_id
+FROM task_activities a
+LEFT JOIN users u ON u.id = a.user_id
+LEFT JOIN task_comments c ON a.activity_type = 'comment_added' AND c.id = a.reference_id
+WHERE a.task_id = $1
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountTaskActivities :one
+SELECT COUNT(*) FROM task_activities
+WHERE task_id = $1;
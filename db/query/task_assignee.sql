@@ -0,0 +1,74 @@
+-- name: AssignTask :exec
+INSERT INTO task_assignees (
+  task_id,
+  user_id,
+  assigned_by_user_id
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (task_id, user_id) DO NOTHING;
+
+-- name: UnassignTask :execrows
+DELETE FROM task_assignees
+WHERE task_id = $1 AND user_id = $2;
+
+-- name: IsTaskAssignee :one
+SELECT EXISTS (
+  SELECT 1 FROM task_assignees
+  WHERE task_id = $1 AND user_id = $2
+) AS is_assignee;
+
+-- name: ListTaskAssignees :many
+SELECT ta.task_id, u.id AS user_id, u.username, ta.assigned_at
+FROM task_assignees ta
+JOIN users u ON u.id = ta.user_id
+WHERE ta.task_id = $1
+ORDER BY u.username;
+
+-- name: ListTaskAssigneesByTaskIDs :many
+SELECT ta.task_id, u.id AS user_id, u.username, ta.assigned_at
+FROM task_assignees ta
+JOIN users u ON u.id = ta.user_id
+WHERE ta.task_id = ANY(@task_ids::int[])
+ORDER BY ta.task_id, u.username;
+
+-- name: ListAssigneeCapacity :many
+-- Current estimates of open tasks per user, split evenly between co-assignees, against the working days in the period
+WITH working_days AS (
+  SELECT d::DATE AS day
+  FROM generate_series(@start_date::DATE, @end_date::DATE, INTERVAL '1 day') AS d
+  WHERE EXTRACT(ISODOW FROM d) < 6
+    AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = d::DATE)
+), open_estimates AS (
+  SELECT
+    ta.user_id,
+    COUNT(*) AS open_task_count,
+    SUM(te.estimate_day / (SELECT COUNT(*) FROM task_assignees co WHERE co.task_id = ta.task_id)) AS estimate_day
+  FROM task_assignees ta
+  JOIN tasks t ON t.id = ta.task_id
+  JOIN task_estimates te ON te.task_id = t.id AND te.is_current
+  WHERE t.deleted_at IS NULL
+    AND LOWER(COALESCE(t.status, '')) NOT IN ('complete', 'completed', 'closed', 'done')
+    AND (t.due_date IS NULL OR t.due_date <= @end_date::DATE)
+  GROUP BY ta.user_id
+), leave_days AS (
+  SELECT ll.user_id, COUNT(DISTINCT ll.date) AS leave_day_count
+  FROM leave_logs ll
+  JOIN working_days wd ON wd.day = ll.date
+  WHERE ll.deleted_at IS NULL
+  GROUP BY ll.user_id
+)
+SELECT
+  u.id AS user_id,
+  u.username,
+  u.department,
+  u.daily_capacity,
+  (SELECT COUNT(*) FROM working_days) AS working_day_count,
+  COALESCE(ld.leave_day_count, 0)::BIGINT AS leave_day_count,
+  COALESCE(oe.open_task_count, 0)::BIGINT AS open_task_count,
+  COALESCE(oe.estimate_day, 0)::DECIMAL AS estimate_day
+FROM users u
+LEFT JOIN open_estimates oe ON oe.user_id = u.id
+LEFT JOIN leave_days ld ON ld.user_id = u.id
+WHERE u.deleted_at IS NULL
+  AND (sqlc.narg('department')::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg('department')::TEXT)))
+ORDER BY u.username;
@@ -1,10 +1,33 @@
+-- name: ArchiveTaskCategorySubtree :execrows
+WITH RECURSIVE subtree AS (
+  SELECT tc.id FROM task_categories tc WHERE tc.id = @category_id::INTEGER
+  UNION ALL
+  SELECT c.id FROM task_categories c
+  JOIN subtree s ON c.parent_id = s.id
+)
+UPDATE task_categories
+SET 
+  archived_at = NOW(),
+  updated_at = NOW()
+WHERE id IN (SELECT s.id FROM subtree s) AND archived_at IS NULL;
+
+-- name: CountTaskCategoryUsage :one
+SELECT
+  (SELECT COUNT(*) FROM task_categories c WHERE c.parent_id = @category_id::INTEGER) AS child_count,
+  (SELECT COUNT(*) FROM tasks t WHERE t.task_category_id = @category_id::INTEGER) AS task_count;
+
 -- name: CreateTaskCategory :one
 INSERT INTO task_categories (
   name,
   parent_id,
-  description
+  description,
+  budget_day,
+  department,
+  sort_order
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5,
+  -- New categories go after their existing siblings
+  COALESCE((SELECT MAX(s.sort_order) + 1 FROM task_categories s WHERE s.parent_id IS NOT DISTINCT FROM $2), 0)
 ) RETURNING *;
 
 -- name: GetTaskCategory :one
@@ -12,20 +35,175 @@ SELECT * FROM task_categories
 WHERE id = $1 LIMIT 1;
 
 -- name: ListTaskCategories :many
-SELECT * FROM task_categories
-ORDER BY name
-LIMIT $1
-OFFSET $2;
+-- Categories visible to a department; a category inherits the department of its nearest scoped ancestor
+WITH RECURSIVE scoped AS (
+  SELECT tc.id, tc.department
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL
+  UNION ALL
+  SELECT c.id, COALESCE(c.department, scoped.department)
+  FROM task_categories c
+  JOIN scoped ON c.parent_id = scoped.id
+)
+SELECT task_categories.* FROM task_categories
+JOIN scoped ON scoped.id = task_categories.id
+WHERE task_categories.archived_at IS NULL
+  AND (@all_departments::BOOLEAN OR scoped.department IS NULL OR scoped.department = sqlc.narg(department)::TEXT)
+ORDER BY task_categories.sort_order, task_categories.name
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CountTaskCategories :one
+-- Counts the categories ListTaskCategories pages through
+WITH RECURSIVE scoped AS (
+  SELECT tc.id, tc.department
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL
+  UNION ALL
+  SELECT c.id, COALESCE(c.department, scoped.department)
+  FROM task_categories c
+  JOIN scoped ON c.parent_id = scoped.id
+)
+SELECT COUNT(*) FROM task_categories
+JOIN scoped ON scoped.id = task_categories.id
+WHERE task_categories.archived_at IS NULL
+  AND (@all_departments::BOOLEAN OR scoped.department IS NULL OR scoped.department = sqlc.narg(department)::TEXT);
+
+-- name: GetTaskCategoriesVersion :one
+-- Changes whenever a task category is added, edited, moved, archived or deleted, for the ETag of
+-- the category listings
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM task_categories;
 
 -- name: ListTaskCategoriesByParent :many
 SELECT * FROM task_categories
-WHERE parent_id = $1
-ORDER BY name;
+WHERE parent_id = $1 AND archived_at IS NULL
+ORDER BY sort_order, name;
 
 -- name: ListRootTaskCategories :many
 SELECT * FROM task_categories
-WHERE parent_id IS NULL
-ORDER BY name;
+WHERE parent_id IS NULL AND archived_at IS NULL
+ORDER BY sort_order, name;
+
+-- name: ListTaskCategoryEffort :many
+-- Logged days, current estimates and budget of every category, rolled up over its subtree
+WITH RECURSIVE closure AS (
+  SELECT tc.id AS ancestor_id, tc.id AS category_id
+  FROM task_categories tc
+  WHERE tc.archived_at IS NULL
+  UNION ALL
+  SELECT closure.ancestor_id, c.id
+  FROM task_categories c
+  JOIN closure ON c.parent_id = closure.category_id
+  WHERE c.archived_at IS NULL
+), logged AS (
+  SELECT
+    closure.ancestor_id,
+    COUNT(DISTINCT tl.task_id) AS task_count,
+    SUM(tl.worked_day) AS worked_day
+  FROM closure
+  JOIN tasks t ON t.task_category_id = closure.category_id
+  JOIN task_logs tl ON tl.task_id = t.id
+  WHERE t.deleted_at IS NULL AND tl.worked_date BETWEEN @start_date AND @end_date
+  GROUP BY closure.ancestor_id
+), current_estimates AS (
+  SELECT te.task_id, te.estimate_day
+  FROM task_estimates te
+  WHERE te.is_current
+), estimated AS (
+  -- Only tasks worked on in the period, so estimates and logs cover the same tasks
+  SELECT
+    closure.ancestor_id,
+    SUM(current_estimates.estimate_day) AS estimate_day
+  FROM closure
+  JOIN tasks t ON t.task_category_id = closure.category_id
+  JOIN current_estimates ON current_estimates.task_id = t.id
+  WHERE t.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM task_logs tl
+    WHERE tl.task_id = t.id AND tl.worked_date BETWEEN @start_date AND @end_date
+  )
+  GROUP BY closure.ancestor_id
+)
+SELECT
+  tc.id,
+  tc.name,
+  tc.parent_id,
+  tc.budget_day,
+  COALESCE(logged.task_count, 0)::BIGINT AS task_count,
+  COALESCE(logged.worked_day, 0)::DECIMAL AS worked_day,
+  COALESCE(estimated.estimate_day, 0)::DECIMAL AS estimate_day
+FROM task_categories tc
+LEFT JOIN logged ON logged.ancestor_id = tc.id
+LEFT JOIN estimated ON estimated.ancestor_id = tc.id
+WHERE tc.archived_at IS NULL
+ORDER BY tc.name;
+
+-- name: ListTaskCategoryTree :many
+-- Every category with its depth, the names from the root down and its effective department,
+-- parents before children. Categories scoped to other departments are left out unless all_departments is set.
+WITH RECURSIVE tree AS (
+  SELECT tc.id, tc.name, tc.parent_id, tc.description, tc.created_at, tc.updated_at,
+    0 AS depth, ARRAY[tc.name::TEXT] AS path, tc.department, tc.sort_order,
+    ARRAY[LPAD(tc.sort_order::TEXT, 10, '0') || tc.name::TEXT] AS sort_path
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL AND tc.archived_at IS NULL
+  UNION ALL
+  SELECT c.id, c.name, c.parent_id, c.description, c.created_at, c.updated_at,
+    tree.depth + 1, tree.path || c.name::TEXT, COALESCE(c.department, tree.department), c.sort_order,
+    tree.sort_path || (LPAD(c.sort_order::TEXT, 10, '0') || c.name::TEXT)
+  FROM task_categories c
+  JOIN tree ON c.parent_id = tree.id
+  WHERE c.archived_at IS NULL
+)
+SELECT
+  tree.id,
+  tree.name,
+  tree.parent_id,
+  tree.description,
+  tree.created_at,
+  tree.updated_at,
+  tree.depth::INTEGER AS depth,
+  tree.path::TEXT[] AS path,
+  tree.department,
+  tree.sort_order
+FROM tree
+WHERE @all_departments::BOOLEAN OR tree.department IS NULL OR tree.department = sqlc.narg(department)::TEXT
+ORDER BY tree.sort_path;
+
+-- name: LockTaskCategories :exec
+-- Locks every category until the transaction ends, so changes to the tree checked against it
+-- run one after another
+SELECT id FROM task_categories FOR UPDATE;
+
+-- name: MoveTaskCategory :one
+UPDATE task_categories
+SET 
+  parent_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReassignTaskCategoryChildren :execrows
+UPDATE task_categories
+SET 
+  parent_id = @target_id::INTEGER,
+  updated_at = NOW()
+WHERE parent_id = @category_id::INTEGER;
+
+-- name: ReassignTaskCategoryTasks :execrows
+UPDATE tasks
+SET 
+  task_category_id = @target_id::INTEGER,
+  updated_at = NOW()
+WHERE task_category_id = @category_id::INTEGER;
+
+-- name: ReorderTaskCategories :execrows
+-- Sets the sort order of the given categories to their position in the list
+UPDATE task_categories tc
+SET 
+  sort_order = o.position - 1,
+  updated_at = NOW()
+FROM UNNEST(@category_ids::INTEGER[]) WITH ORDINALITY AS o(id, position)
+WHERE tc.id = o.id;
 
 -- name: UpdateTaskCategory :one
 UPDATE task_categories
@@ -33,6 +211,8 @@ SET
   name = COALESCE($2, name),
   parent_id = $3,
   description = COALESCE($4, description),
+  budget_day = $5,
+  department = $6,
   updated_at = NOW()
 WHERE id = $1
 RETURNING *;
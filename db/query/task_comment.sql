@@ -0,0 +1,31 @@
+-- name: CreateTaskComment :one
+INSERT INTO task_comments (
+  task_id,
+  user_id,
+  body
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetTaskComment :one
+SELECT * FROM task_comments
+WHERE id = $1 LIMIT 1;
+
+-- name: ListTaskCommentsByTask :many
+SELECT c.*, u.username
+FROM task_comments c
+JOIN users u ON u.id = c.user_id
+WHERE c.task_id = $1
+ORDER BY c.created_at;
+
+-- name: UpdateTaskComment :one
+UPDATE task_comments
+SET 
+  body = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTaskComment :exec
+DELETE FROM task_comments
+WHERE id = $1;
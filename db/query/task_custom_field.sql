@@ -0,0 +1,19 @@
+-- name: UpsertTaskCustomField :exec
+INSERT INTO task_custom_fields (
+  task_id,
+  field_key,
+  value
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (task_id, field_key) DO UPDATE SET
+  value = EXCLUDED.value,
+  updated_at = NOW();
+
+-- name: DeleteTaskCustomField :exec
+DELETE FROM task_custom_fields
+WHERE task_id = $1 AND field_key = $2;
+
+-- name: ListTaskCustomFieldsByTaskIDs :many
+SELECT * FROM task_custom_fields
+WHERE task_id = ANY(@task_ids::int[])
+ORDER BY task_id, field_key;
@@ -3,9 +3,15 @@ INSERT INTO task_estimates (
   task_id,
   estimate_day,
   note,
-  created_by_user_id
+  created_by_user_id,
+  supersedes_id,
+  session_id,
+  unit,
+  estimate_value,
+  confidence,
+  is_current
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE
 ) RETURNING *;
 
 -- name: GetTaskEstimate :one
@@ -14,24 +20,112 @@ WHERE id = $1 LIMIT 1;
 
 -- name: ListTaskEstimatesByTask :many
 SELECT * FROM task_estimates
-WHERE task_id = $1
+WHERE task_id = $1 AND NOT is_vote
 ORDER BY created_at DESC;
 
+-- name: GetCurrentTaskEstimate :one
+SELECT * FROM task_estimates
+WHERE task_id = $1 AND is_current
+LIMIT 1;
+
+-- name: GetTaskEstimateRollup :one
+-- Sums the current estimate of a task and each of its subtasks
+WITH RECURSIVE subtasks AS (
+  SELECT t.id FROM tasks t WHERE t.id = @task_id
+  UNION ALL
+  SELECT t.id FROM tasks t
+  JOIN subtasks s ON t.parent_task_id = s.id
+), current_estimates AS (
+  SELECT te.task_id, te.estimate_day
+  FROM task_estimates te
+  WHERE te.task_id IN (SELECT s.id FROM subtasks s) AND te.is_current
+)
+SELECT
+  COUNT(*) AS estimated_task_count,
+  COALESCE(SUM(current_estimates.estimate_day), 0)::DECIMAL AS estimate_day
+FROM current_estimates;
+
 -- name: ListTaskEstimatesByUser :many
 SELECT * FROM task_estimates
-WHERE created_by_user_id = $1
+WHERE created_by_user_id = $1 AND NOT is_vote
 ORDER BY created_at DESC
 LIMIT $2
 OFFSET $3;
 
+-- name: CountTaskEstimatesByUser :one
+SELECT COUNT(*) FROM task_estimates
+WHERE created_by_user_id = $1 AND NOT is_vote;
+
+-- name: ListTaskEstimateVariance :many
+-- Tasks with a current estimate and work logged in the period, with everything logged up to the end date
+SELECT
+  t.id AS task_id,
+  t.title,
+  t.status,
+  te.id AS estimate_id,
+  te.estimate_day,
+  te.unit,
+  te.estimate_value,
+  te.confidence,
+  te.created_by_user_id AS estimated_by_user_id,
+  u.username AS estimated_by_username,
+  COALESCE(SUM(tl.worked_day), 0)::DECIMAL AS logged_day
+FROM task_estimates te
+JOIN tasks t ON t.id = te.task_id
+JOIN users u ON u.id = te.created_by_user_id
+JOIN task_logs tl ON tl.task_id = t.id AND tl.worked_date <= @end_date
+WHERE te.is_current
+  AND EXISTS (
+    SELECT 1 FROM task_logs p
+    WHERE p.task_id = t.id AND p.worked_date BETWEEN @start_date AND @end_date
+  )
+GROUP BY t.id, t.title, t.status, te.id, te.estimate_day, te.unit, te.estimate_value, te.confidence, te.created_by_user_id, u.username
+ORDER BY t.id;
+
+-- name: RestoreTaskEstimate :exec
+-- Makes a superseded estimate current again, used when its successor is deleted
+UPDATE task_estimates
+SET 
+  is_current = TRUE,
+  superseded_at = NULL
+WHERE id = $1;
+
+-- name: SupersedeTaskEstimate :exec
+UPDATE task_estimates
+SET 
+  is_current = FALSE,
+  superseded_at = NOW()
+WHERE id = $1;
+
 -- name: UpdateTaskEstimate :one
 UPDATE task_estimates
 SET 
   estimate_day = $2,
-  note = $3
+  note = $3,
+  unit = $4,
+  estimate_value = $5,
+  confidence = $6
 WHERE id = $1
 RETURNING *;
 
+-- name: UpsertEstimationVote :one
+-- Stores or replaces a participant's hidden vote in an estimation session
+INSERT INTO task_estimates (
+  task_id,
+  estimate_day,
+  note,
+  created_by_user_id,
+  session_id,
+  is_vote
+) VALUES (
+  $1, $2, $3, $4, $5, TRUE
+) ON CONFLICT (session_id, created_by_user_id) WHERE is_vote
+DO UPDATE SET
+  estimate_day = EXCLUDED.estimate_day,
+  note = EXCLUDED.note,
+  created_at = NOW()
+RETURNING *;
+
 -- name: DeleteTaskEstimate :exec
 DELETE FROM task_estimates
 WHERE id = $1; 
\ No newline at end of file
@@ -9,10 +9,28 @@ INSERT INTO task_logs (
   $1, $2, $3, $4, $5
 ) RETURNING *;
 
+-- name: CreateTaskLogs :copyfrom
+-- Inserts many task logs in one COPY, for imports
+INSERT INTO task_logs (
+  task_id,
+  worked_day,
+  created_by_user_id,
+  worked_date,
+  is_work_on_holiday
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
 -- name: GetTaskLog :one
 SELECT * FROM task_logs
 WHERE id = $1 LIMIT 1;
 
+-- name: HasTaskLogs :one
+SELECT EXISTS (
+  SELECT 1 FROM task_logs
+  WHERE task_id = $1
+) AS has_logs;
+
 -- name: ListTaskLogsByTask :many
 SELECT * FROM task_logs
 WHERE task_id = $1
@@ -46,4 +64,151 @@ RETURNING *;
 
 -- name: DeleteTaskLog :exec
 DELETE FROM task_logs
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = $1; 
+
+-- name: ListTaskLogsWithDetailsByUser :many
+-- Page of a user's logs, narrowed by task and worked date range when given, in sort_by order and
+-- otherwise latest worked date first
+SELECT tl.*, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = @user_id
+  AND (sqlc.narg(task_id)::INTEGER IS NULL OR tl.task_id = sqlc.narg(task_id)::INTEGER)
+  AND (sqlc.narg(worked_from)::DATE IS NULL OR tl.worked_date >= sqlc.narg(worked_from)::DATE)
+  AND (sqlc.narg(worked_to)::DATE IS NULL OR tl.worked_date <= sqlc.narg(worked_to)::DATE)
+ORDER BY
+  CASE WHEN @sort_by::TEXT = 'worked_date' AND NOT @sort_desc::BOOLEAN THEN tl.worked_date END ASC,
+  CASE WHEN @sort_by::TEXT = 'worked_day' AND NOT @sort_desc::BOOLEAN THEN tl.worked_day END ASC,
+  CASE WHEN @sort_by::TEXT = 'worked_day' AND @sort_desc::BOOLEAN THEN tl.worked_day END DESC,
+  CASE WHEN @sort_by::TEXT = 'created_at' AND NOT @sort_desc::BOOLEAN THEN tl.created_at END ASC,
+  CASE WHEN @sort_by::TEXT = 'created_at' AND @sort_desc::BOOLEAN THEN tl.created_at END DESC,
+  tl.worked_date DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: CountTaskLogsByUser :one
+-- Counts the logs ListTaskLogsWithDetailsByUser and ListTaskLogsWithDetailsByUserAfter page through
+SELECT COUNT(*) FROM task_logs tl
+WHERE tl.created_by_user_id = @user_id
+  AND (sqlc.narg(task_id)::INTEGER IS NULL OR tl.task_id = sqlc.narg(task_id)::INTEGER)
+  AND (sqlc.narg(worked_from)::DATE IS NULL OR tl.worked_date >= sqlc.narg(worked_from)::DATE)
+  AND (sqlc.narg(worked_to)::DATE IS NULL OR tl.worked_date <= sqlc.narg(worked_to)::DATE);
+
+-- name: ListTaskLogsWithDetailsByTask :many
+SELECT tl.*, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.task_id = $1
+ORDER BY tl.worked_date DESC;
+
+-- name: ListTaskLogsWithDetailsByUserAndDateRange :many
+SELECT tl.*, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = $1 AND tl.worked_date BETWEEN $2 AND $3
+  AND (sqlc.narg(tag_id)::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = tl.task_id AND tt.tag_id = sqlc.narg(tag_id)::INTEGER
+  ))
+ORDER BY tl.worked_date DESC;
+
+-- name: ListTaskLogDailyTotals :many
+-- Includes the logs of all subtasks so effort rolls up to the parent
+WITH RECURSIVE subtasks AS (
+  SELECT t.id FROM tasks t WHERE t.id = @task_id
+  UNION ALL
+  SELECT t.id FROM tasks t
+  JOIN subtasks s ON t.parent_task_id = s.id
+)
+SELECT
+  worked_date,
+  SUM(worked_day)::DECIMAL AS worked_day,
+  SUM(SUM(worked_day)) OVER (ORDER BY worked_date)::DECIMAL AS cumulative_day
+FROM task_logs
+WHERE task_id IN (SELECT s.id FROM subtasks s)
+GROUP BY worked_date
+ORDER BY worked_date;
+
+-- name: SumTaskLogWorkedDaysForDate :one
+-- Sums the days a user logged on one date, leaving out the log being updated
+SELECT COALESCE(SUM(worked_day), 0)::float8 AS total
+FROM task_logs
+WHERE created_by_user_id = sqlc.arg(user_id)
+  AND worked_date = sqlc.arg(worked_date)
+  AND id <> sqlc.arg(exclude_id);
+
+-- name: ListTaskLogsWithDetailsByUserAfter :many
+-- Keyset page of a user's logs, newest first, starting after the (created_at, id) cursor when given
+SELECT tl.*, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = @user_id
+  AND (sqlc.narg(task_id)::INTEGER IS NULL OR tl.task_id = sqlc.narg(task_id)::INTEGER)
+  AND (sqlc.narg(worked_from)::DATE IS NULL OR tl.worked_date >= sqlc.narg(worked_from)::DATE)
+  AND (sqlc.narg(worked_to)::DATE IS NULL OR tl.worked_date <= sqlc.narg(worked_to)::DATE)
+  AND (sqlc.narg(cursor_created_at)::TIMESTAMPTZ IS NULL
+    OR (tl.created_at, tl.id) < (sqlc.narg(cursor_created_at)::TIMESTAMPTZ, @cursor_id::INTEGER))
+ORDER BY tl.created_at DESC, tl.id DESC
+LIMIT @row_limit;
+
+-- name: ListTimesheetEntries :many
+-- Work logged in a date range with its task and who logged it, for everyone, one user or one department
+SELECT tl.id, tl.worked_date, tl.created_by_user_id AS user_id, u.username, u.department, tl.task_id, t.title AS task_title, tl.worked_day, tl.is_work_on_holiday
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.worked_date BETWEEN @start_date::DATE AND @end_date::DATE
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR tl.created_by_user_id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+ORDER BY u.username, tl.created_by_user_id, tl.worked_date, tl.id;
+
+-- name: ListUserUtilization :many
+-- Work logged per user in a date range against the working days they had, for everyone, one user or one department
+WITH working_days AS (
+  SELECT d::DATE AS day
+  FROM generate_series(@start_date::DATE, @end_date::DATE, INTERVAL '1 day') AS d
+  WHERE EXTRACT(ISODOW FROM d) < 6
+    AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = d::DATE)
+), leave_days AS (
+  SELECT ll.user_id, COUNT(DISTINCT ll.date) AS leave_day_count
+  FROM leave_logs ll
+  JOIN working_days wd ON wd.day = ll.date
+  WHERE ll.deleted_at IS NULL
+  GROUP BY ll.user_id
+), worked AS (
+  SELECT tl.created_by_user_id AS user_id, SUM(tl.worked_day) AS worked_day
+  FROM task_logs tl
+  WHERE tl.worked_date BETWEEN @start_date::DATE AND @end_date::DATE
+  GROUP BY tl.created_by_user_id
+)
+SELECT
+  u.id AS user_id,
+  u.username,
+  u.department,
+  u.daily_capacity,
+  (SELECT COUNT(*) FROM working_days) AS working_day_count,
+  COALESCE(ld.leave_day_count, 0)::BIGINT AS leave_day_count,
+  COALESCE(w.worked_day, 0)::DECIMAL AS worked_day
+FROM users u
+LEFT JOIN leave_days ld ON ld.user_id = u.id
+LEFT JOIN worked w ON w.user_id = u.id
+WHERE u.deleted_at IS NULL
+  AND (sqlc.narg(user_id)::INTEGER IS NULL OR u.id = sqlc.narg(user_id)::INTEGER)
+  AND (sqlc.narg(department)::TEXT IS NULL OR u.department IN (SELECT department_and_below(sqlc.narg(department)::TEXT)))
+ORDER BY u.username;
+
+-- name: ListUnloggedDays :many
+-- Working days in a date range, since the user joined, on which they neither logged work nor took leave, the latest first
+SELECT d::DATE AS day
+FROM generate_series(@start_date::DATE, @end_date::DATE, INTERVAL '1 day') AS d
+JOIN users u ON u.id = @user_id
+WHERE EXTRACT(ISODOW FROM d) < 6
+  AND d::DATE >= COALESCE(u.created_at::DATE, @start_date::DATE)
+  AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = d::DATE)
+  AND NOT EXISTS (SELECT 1 FROM task_logs tl WHERE tl.created_by_user_id = @user_id AND tl.worked_date = d::DATE)
+  AND NOT EXISTS (SELECT 1 FROM leave_logs ll WHERE ll.user_id = @user_id AND ll.date = d::DATE AND ll.deleted_at IS NULL)
+ORDER BY day DESC;
@@ -0,0 +1,67 @@
+-- name: ListClickUpLinkedTasks :many
+SELECT * FROM tasks
+WHERE url LIKE 'https://app.clickup.com/t/%' AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: ListClickUpLinkedTasksByTeam :many
+-- An empty team ID lists the linked tasks whose workspace is not known yet
+SELECT * FROM tasks
+WHERE url LIKE 'https://app.clickup.com/t/%' AND deleted_at IS NULL
+  AND COALESCE(clickup_team_id, '') = @team_id::TEXT
+ORDER BY id;
+
+-- name: ApplyClickUpTaskChanges :one
+UPDATE tasks
+SET 
+  title = @title,
+  note = @note,
+  status = @status,
+  status_color = @status_color,
+  clickup_team_id = COALESCE(sqlc.narg(team_id), clickup_team_id),
+  updated_at = NOW(),
+  clickup_synced_at = GREATEST(NOW(), @remote_updated_at::TIMESTAMPTZ)
+WHERE id = @id
+RETURNING *;
+
+-- name: MarkTaskClickUpSynced :exec
+UPDATE tasks
+SET
+  clickup_synced_at = GREATEST(NOW(), @remote_updated_at::TIMESTAMPTZ),
+  clickup_team_id = COALESCE(sqlc.narg(team_id), clickup_team_id)
+WHERE id = @id;
+
+-- name: CreateTaskSyncHistory :one
+INSERT INTO task_sync_history (
+  task_id,
+  direction,
+  status,
+  changed_fields,
+  message,
+  remote_updated_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListTaskSyncHistory :many
+SELECT * FROM task_sync_history
+WHERE task_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: GetClickUpSyncStats :one
+-- Linked tasks changed locally after their last sync still have changes waiting to be pushed
+SELECT
+  (SELECT MAX(clickup_synced_at) FROM tasks
+   WHERE url LIKE 'https://app.clickup.com/t/%')::TIMESTAMPTZ AS last_synced_at,
+  (SELECT MAX(h.created_at) FROM task_sync_history h
+   JOIN tasks t ON t.id = h.task_id
+   WHERE t.url LIKE 'https://app.clickup.com/t/%' AND h.status = 'error')::TIMESTAMPTZ AS last_error_at,
+  (SELECT COUNT(*) FROM task_sync_history h
+   JOIN tasks t ON t.id = h.task_id
+   WHERE t.url LIKE 'https://app.clickup.com/t/%' AND h.status = 'error'
+     AND h.created_at >= @since::TIMESTAMPTZ) AS recent_errors,
+  (SELECT COUNT(*) FROM tasks
+   WHERE url LIKE 'https://app.clickup.com/t/%' AND deleted_at IS NULL) AS linked_tasks,
+  (SELECT COUNT(*) FROM tasks
+   WHERE url LIKE 'https://app.clickup.com/t/%' AND deleted_at IS NULL
+     AND (clickup_synced_at IS NULL OR updated_at > clickup_synced_at)) AS pending_changes;
@@ -0,0 +1,16 @@
+-- name: CreateTenant :one
+INSERT INTO tenants (
+  slug,
+  name
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetTenantBySlug :one
+SELECT * FROM tenants
+WHERE slug = $1 LIMIT 1;
+
+-- name: ListTenants :many
+-- Every tenant, for jobs that run once per tenant
+SELECT * FROM tenants
+ORDER BY id;
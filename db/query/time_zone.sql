@@ -0,0 +1,16 @@
+-- name: GetTimeZonePreference :one
+SELECT * FROM time_zone_preferences
+WHERE user_id = $1 LIMIT 1;
+
+-- name: SetTimeZonePreference :one
+INSERT INTO time_zone_preferences (
+  user_id,
+  time_zone
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  time_zone = EXCLUDED.time_zone,
+  updated_at = NOW()
+RETURNING *;
+
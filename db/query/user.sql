@@ -3,29 +3,35 @@ INSERT INTO users (
   username,
   password,
   user_type,
-  email
+  email,
+  daily_capacity
 ) VALUES (
-  @username, @password, @user_type, @email
+  @username, @password, @user_type, @email, COALESCE(sqlc.narg(daily_capacity)::DECIMAL, 1.00)
 ) RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE id = @id LIMIT 1;
+WHERE id = @id AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByUsername :one
 SELECT * FROM users
-WHERE username = @username LIMIT 1;
+WHERE username = @username AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = @email LIMIT 1;
+WHERE email = @email AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListUsers :many
 SELECT * FROM users
+WHERE deleted_at IS NULL
 ORDER BY id
 LIMIT @row_limit
 OFFSET @row_offset;
 
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE deleted_at IS NULL;
+
 -- name: UpdateUser :one
 UPDATE users
 SET 
@@ -33,10 +39,32 @@ SET
   password = COALESCE(@password, password),
   user_type = COALESCE(@user_type, user_type),
   email = COALESCE(@email, email),
+  daily_capacity = COALESCE(sqlc.narg(daily_capacity)::DECIMAL, daily_capacity),
+  department = NULLIF(COALESCE(sqlc.narg(department)::TEXT, department), ''),
   updated_at = NOW()
-WHERE id = @id
+WHERE id = @id AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteUser :exec
+-- Soft deletes the user, the row stays until PurgeUser removes it
+UPDATE users
+SET deleted_at = NOW()
+WHERE id = @id AND deleted_at IS NULL;
+
+-- name: ListPurgeableUserIDs :many
+-- Users deleted before the cutoff, oldest deletion first
+SELECT id FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at < @deleted_before::TIMESTAMPTZ
+ORDER BY deleted_at;
+
+-- name: PurgeUser :execrows
+-- Removes a soft deleted user for good, live users are never touched
 DELETE FROM users
-WHERE id = @id; 
\ No newline at end of file
+WHERE id = @id AND deleted_at IS NOT NULL;
+
+-- name: RestoreUser :execrows
+-- Undoes DeleteUser. Anonymized users stay deleted, there is nothing of them left to bring back.
+UPDATE users
+SET deleted_at = NULL
+WHERE id = @id AND deleted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM anonymizations a WHERE a.user_id = users.id);
@@ -0,0 +1,85 @@
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE webhook_id = $1;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  url,
+  secret,
+  events,
+  description,
+  active,
+  created_by_user_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  webhook_id,
+  event,
+  payload
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: DeleteWebhookDeliveriesBefore :execrows
+-- Deletes the deliveries made before a time, whatever became of them
+DELETE FROM webhook_deliveries
+WHERE created_at < $1;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = @webhook_id
+ORDER BY id DESC
+LIMIT @row_limit
+OFFSET @row_offset;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+ORDER BY id;
+
+-- name: RecordWebhookDeliveryAttempt :exec
+-- Records how an attempt went, delivered when the receiver answered with a 2xx
+UPDATE webhook_deliveries
+SET
+  status = $2,
+  attempts = attempts + 1,
+  response_status = $3,
+  last_error = $4,
+  delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END
+WHERE id = $1;
+
+-- name: RequeueWebhookDelivery :one
+-- Sends a delivery again with a fresh set of attempts
+UPDATE webhook_deliveries
+SET
+  status = 'pending',
+  attempts = 0
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET
+  url = $2,
+  events = $3,
+  description = $4,
+  active = $5,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,31 @@
+-- name: SummarizeLeaveByMonth :many
+-- A user's leave days in a range per month and type, for their year in review
+SELECT EXTRACT(MONTH FROM ll.date)::INTEGER AS month, ll.type, COUNT(*) AS day_count
+FROM leave_logs ll
+WHERE ll.user_id = @user_id AND ll.deleted_at IS NULL
+  AND ll.date BETWEEN @start_date::DATE AND @end_date::DATE
+GROUP BY EXTRACT(MONTH FROM ll.date), ll.type
+ORDER BY month, ll.type;
+
+-- name: ListTaskCategoryWorkByUser :many
+-- The work a user logged in a range per category of its task, the most first, with the work on
+-- tasks without a category under a NULL category
+SELECT t.task_category_id, tc.name AS category_name, SUM(tl.worked_day)::DECIMAL AS worked_day
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+LEFT JOIN task_categories tc ON tc.id = t.task_category_id
+WHERE tl.created_by_user_id = @user_id
+  AND tl.worked_date BETWEEN @start_date::DATE AND @end_date::DATE
+GROUP BY t.task_category_id, tc.name
+ORDER BY worked_day DESC, tc.name;
+
+-- name: ListHolidayWorkByUser :many
+-- The days in a range a user logged work on a holiday, with the holiday's name when it is one
+-- of the holidays rather than a weekend
+SELECT tl.worked_date, h.name AS holiday_name, SUM(tl.worked_day)::DECIMAL AS worked_day
+FROM task_logs tl
+LEFT JOIN holidays h ON h.date = tl.worked_date
+WHERE tl.created_by_user_id = @user_id AND tl.is_work_on_holiday
+  AND tl.worked_date BETWEEN @start_date::DATE AND @end_date::DATE
+GROUP BY tl.worked_date, h.name
+ORDER BY tl.worked_date;
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisKeyPrefix namespaces the keys of this application in a shared Redis
+const redisKeyPrefix = "ngtableg:cache:"
+
+// redisTimeout bounds dialing and one Redis round trip when the caller's context has no earlier
+// deadline
+const redisTimeout = time.Second
+
+// redisMaxIdle is how many idle connections the cache keeps for reuse
+const redisMaxIdle = 8
+
+// RedisCache is a Cache kept in Redis, shared by every server instance. It speaks just enough
+// of the Redis protocol for GET, SET and INCR over a small pool of connections, each command
+// runs on its own connection so a slow one doesn't hold up the others.
+type RedisCache struct {
+	addr     string
+	username string
+	password string
+	database int
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// redisConn is one connection to Redis with its reply reader
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+var _ Cache = (*RedisCache)(nil)
+
+// NewRedisCache parses a redis://[user:password@]host:port/db URL. The connection is opened on
+// first use.
+func NewRedisCache(rawURL string) (*RedisCache, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported scheme %q, expected redis", parsed.Scheme)
+	}
+
+	cache := &RedisCache{addr: parsed.Host}
+	if parsed.Port() == "" {
+		cache.addr = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		cache.username = parsed.User.Username()
+		cache.password, _ = parsed.User.Password()
+	}
+	if path := strings.Trim(parsed.Path, "/"); path != "" {
+		cache.database, err = strconv.Atoi(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database %q", path)
+		}
+	}
+	return cache, nil
+}
+
+func (c *RedisCache) Version(ctx context.Context, group string) (int64, error) {
+	reply, err := c.do(ctx, "GET", redisKeyPrefix+group+":version")
+	if err != nil || reply == nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(reply.([]byte)), 10, 64)
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", redisKeyPrefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	return reply.([]byte), true, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, "SET", redisKeyPrefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Invalidate bumps the group version, old keys are never read again and expire on their own
+func (c *RedisCache) Invalidate(ctx context.Context, group string) error {
+	_, err := c.do(ctx, "INCR", redisKeyPrefix+group+":version")
+	return err
+}
+
+// do sends one command and returns its reply: nil, []byte, int64 or string. A connection is
+// closed after any error but an error reply so the next command starts clean, and a command
+// that fails on a reused connection, which Redis may have closed while it sat idle, is sent
+// once more on a new one. The cache's commands are safe to repeat.
+func (c *RedisCache) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(redisTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	rc, reused, err := c.get(deadline)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := rc.roundTrip(deadline, args...)
+	if err != nil && reused && !isRedisError(err) {
+		rc.conn.Close()
+		if rc, err = c.connect(deadline); err != nil {
+			return nil, err
+		}
+		reply, err = rc.roundTrip(deadline, args...)
+	}
+	if err != nil && !isRedisError(err) {
+		rc.conn.Close()
+		return nil, err
+	}
+	c.put(rc)
+	return reply, err
+}
+
+// get takes an idle connection or dials a new one, reporting whether it was reused
+func (c *RedisCache) get(deadline time.Time) (*redisConn, bool, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		rc := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return rc, true, nil
+	}
+	c.mu.Unlock()
+
+	rc, err := c.connect(deadline)
+	return rc, false, err
+}
+
+// put returns a healthy connection to the pool, or closes it when the pool is full
+func (c *RedisCache) put(rc *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= redisMaxIdle {
+		rc.conn.Close()
+		return
+	}
+	c.idle = append(c.idle, rc)
+}
+
+// connect dials Redis and authenticates
+func (c *RedisCache) connect(deadline time.Time) (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if c.password != "" {
+		auth := []string{"AUTH", c.password}
+		if c.username != "" {
+			auth = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := rc.roundTrip(deadline, auth...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.database != 0 {
+		if _, err := rc.roundTrip(deadline, "SELECT", strconv.Itoa(c.database)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+func (rc *redisConn) roundTrip(deadline time.Time, args ...string) (interface{}, error) {
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(rc.conn, command.String()); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+// redisError is an error reply from Redis, the connection stays usable after one
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func isRedisError(err error) bool {
+	var redisErr redisError
+	return errors.As(err, &redisErr)
+}
+
+func (rc *redisConn) readReply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		body := make([]byte, size+2)
+		if _, err := io.ReadFull(rc.reader, body); err != nil {
+			return nil, err
+		}
+		return body[:size], nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
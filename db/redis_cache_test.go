@@ -0,0 +1,197 @@
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET and INCR from memory over the Redis protocol
+type fakeRedis struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	values map[string]string
+	conns  []net.Conn
+	dials  int
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{listener: listener, values: map[string]string{}}
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropConnections()
+	})
+	go server.serve()
+	return server
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.dials++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// dropConnections closes every connection the way a restarting Redis would
+func (s *fakeRedis) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		io.WriteString(conn, s.reply(args))
+	}
+}
+
+func (s *fakeRedis) reply(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "INCR":
+		n, err := strconv.ParseInt(s.values[args[1]], 10, 64)
+		if err != nil && s.values[args[1]] != "" {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		s.values[args[1]] = strconv.FormatInt(n+1, 10)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		body := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return nil, err
+		}
+		args[i] = string(body[:size])
+	}
+	return args, nil
+}
+
+func TestRedisCache(t *testing.T) {
+	server := newFakeRedis(t)
+	cache, err := NewRedisCache("redis://" + server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+
+	if _, ok, err := cache.Get(ctx, "report"); ok || err != nil {
+		t.Fatalf("Get() = %v, %v, want a miss", ok, err)
+	}
+	if err := cache.Set(ctx, "report", []byte("cached"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if value, ok, err := cache.Get(ctx, "report"); !ok || err != nil || string(value) != "cached" {
+		t.Fatalf("Get() = %q, %v, %v, want the cached value", value, ok, err)
+	}
+
+	if err := cache.Invalidate(ctx, "reports"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if version, err := cache.Version(ctx, "reports"); version != 1 || err != nil {
+		t.Fatalf("Version() = %d, %v, want 1", version, err)
+	}
+
+	// An error reply leaves the connection usable
+	server.mu.Lock()
+	server.values[redisKeyPrefix+"broken:version"] = "x"
+	server.mu.Unlock()
+	if err := cache.Invalidate(ctx, "broken"); !isRedisError(err) {
+		t.Fatalf("Invalidate() error = %v, want the error reply", err)
+	}
+	if server.dials != 1 {
+		t.Errorf("dials = %d, want every command on the one pooled connection", server.dials)
+	}
+
+	// Commands after Redis dropped the pooled connection go out on a new one
+	server.dropConnections()
+	if value, ok, err := cache.Get(ctx, "report"); !ok || err != nil || string(value) != "cached" {
+		t.Fatalf("Get() after the connection dropped = %q, %v, %v, want the cached value", value, ok, err)
+	}
+	if version, err := cache.Version(ctx, "reports"); version != 1 || err != nil {
+		t.Fatalf("Version() after the connection dropped = %d, %v, want 1", version, err)
+	}
+}
+
+func TestRedisCacheConcurrentCommands(t *testing.T) {
+	server := newFakeRedis(t)
+	cache, err := NewRedisCache("redis://" + server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cache.Invalidate(t.Context(), "reports"); err != nil {
+				t.Errorf("Invalidate() %d error = %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if version, err := cache.Version(t.Context(), "reports"); version != 20 || err != nil {
+		t.Errorf("Version() = %d, %v, want 20", version, err)
+	}
+	if len(cache.idle) > redisMaxIdle {
+		t.Errorf("idle connections = %d, want at most %d", len(cache.idle), redisMaxIdle)
+	}
+}
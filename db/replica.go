@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replicaRetryAfter is how long reads stay on the primary after the replica failed to answer
+const replicaRetryAfter = 30 * time.Second
+
+// replicaQueryPrefixes are the query names sent to the replica: listings, searches and the
+// reporting queries. Everything else, and every query in a transaction, runs on the primary
+// so a request reads its own writes.
+var replicaQueryPrefixes = []string{"List", "Search", "Summarize", "GetClickUpSyncStats", "GetTaskEstimateRollup"}
+
+// replicaRouter sends read-only queries to a replica and the rest to the primary, falling
+// back to the primary while the replica is unreachable
+type replicaRouter struct {
+	primary *pgxpool.Pool
+	replica *pgxpool.Pool
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func (r *replicaRouter) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, sql, args...)
+}
+
+func (r *replicaRouter) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return r.primary.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (r *replicaRouter) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if r.useReplica(sql) {
+		rows, err := r.replica.Query(ctx, sql, args...)
+		if err == nil || !r.replicaFailed(err) {
+			return rows, err
+		}
+	}
+	return r.primary.Query(ctx, sql, args...)
+}
+
+func (r *replicaRouter) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if r.useReplica(sql) {
+		return &fallbackRow{router: r, ctx: ctx, sql: sql, args: args, row: r.replica.QueryRow(ctx, sql, args...)}
+	}
+	return r.primary.QueryRow(ctx, sql, args...)
+}
+
+// useReplica reports whether the query is a read the replica serves and the replica is up
+func (r *replicaRouter) useReplica(sql string) bool {
+	name := queryName(sql)
+	routed := false
+	for _, prefix := range replicaQueryPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			routed = true
+			break
+		}
+	}
+	if !routed {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Now().After(r.downUntil)
+}
+
+// replicaFailed reports whether err means the replica could not be reached, and if so takes
+// it out of rotation for a while. Errors from the query itself are left to the caller.
+func (r *replicaRouter) replicaFailed(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if !errors.As(err, &connectErr) && !errors.As(err, &netErr) && !pgconn.SafeToRetry(err) {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().After(r.downUntil) {
+		slog.Warn("Read replica unavailable, reading from the primary", "retry_after", replicaRetryAfter, "error", err)
+	}
+	r.downUntil = time.Now().Add(replicaRetryAfter)
+	return true
+}
+
+// fallbackRow reruns a single-row query on the primary when the replica fails while scanning
+type fallbackRow struct {
+	router *replicaRouter
+	ctx    context.Context
+	sql    string
+	args   []interface{}
+	row    pgx.Row
+}
+
+func (r *fallbackRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if err == nil || errors.Is(err, pgx.ErrNoRows) || !r.router.replicaFailed(err) {
+		return err
+	}
+	return r.router.primary.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+}
+
+// queryName returns the name sqlc puts at the start of every query, e.g. ListTasks for
+// "-- name: ListTasks :many"
+func queryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
@@ -1,13 +1,38 @@
 -- PostgreSQL schema for P'Keng TableG
 
+-- Every row belongs to a tenant, see db/migrations/000025_tenants.up.sql
+CREATE TABLE tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE, -- Host name label or X-Tenant header value
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO tenants (slug, name) VALUES ('default', 'Default');
+
+-- The tenant a connection is bound to, NULL when it is bound to none
+CREATE FUNCTION current_tenant_id() RETURNS INTEGER AS $$
+    SELECT NULLIF(current_setting('ngtableg.tenant_id', true), '')::INTEGER
+$$ LANGUAGE sql STABLE;
+
+-- Whether the connection asked to see every tenant, for jobs that span them, see
+-- db/migrations/000053_tenant_isolation_fail_closed.up.sql
+CREATE FUNCTION all_tenants() RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('ngtableg.all_tenants', true), '') = 'on'
+$$ LANGUAGE sql STABLE;
+
 CREATE TABLE users (
     id SERIAL PRIMARY KEY,
-    username VARCHAR(255) NOT NULL UNIQUE,
+    username VARCHAR(255) NOT NULL,
     password VARCHAR(255) NOT NULL,
     user_type VARCHAR(50) NOT NULL,
-    email VARCHAR(255) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL,
     created_at TIMESTAMPTZ DEFAULT NOW(),
-    updated_at TIMESTAMPTZ DEFAULT NOW()
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    daily_capacity DECIMAL(3,2) NOT NULL DEFAULT 1.00 CHECK (daily_capacity > 0 AND daily_capacity <= 1),
+    department VARCHAR(100),
+    deleted_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
 );
 
 -- New quota plans table
@@ -20,7 +45,8 @@ CREATE TABLE quota_plans (
     created_by_user_id INTEGER REFERENCES users(id),
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
-    UNIQUE(plan_name, year)
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE(tenant_id, plan_name, year)
 );
 
 CREATE TABLE annual_records (
@@ -36,15 +62,19 @@ CREATE TABLE annual_records (
     used_medical_expense_baht DECIMAL(10,2) DEFAULT 0,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
     UNIQUE(user_id, year)
 );
 
 CREATE TABLE holidays (
     id SERIAL PRIMARY KEY,
-    date DATE NOT NULL UNIQUE,
+    date DATE NOT NULL,
     name VARCHAR(255) NOT NULL,
     note TEXT,
-    created_at TIMESTAMPTZ DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE(tenant_id, date)
 );
 
 CREATE TABLE task_categories (
@@ -53,7 +83,12 @@ CREATE TABLE task_categories (
     parent_id INTEGER REFERENCES task_categories(id),
     description TEXT,
     created_at TIMESTAMPTZ DEFAULT NOW(),
-    updated_at TIMESTAMPTZ DEFAULT NOW()
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    archived_at TIMESTAMPTZ,
+    budget_day DECIMAL(7,2),
+    department VARCHAR(100),
+    sort_order INTEGER NOT NULL DEFAULT 0 CHECK (sort_order >= 0),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
 );
 
 CREATE TABLE tasks (
@@ -65,7 +100,124 @@ CREATE TABLE tasks (
     status TEXT,
     status_color TEXT,
     created_at TIMESTAMPTZ DEFAULT NOW(),
-    updated_at TIMESTAMPTZ DEFAULT NOW()
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    clickup_synced_at TIMESTAMPTZ,
+    due_date DATE,
+    priority INTEGER CHECK (priority BETWEEN 1 AND 4),
+    parent_task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    created_by_user_id INTEGER REFERENCES users(id),
+    clickup_team_id VARCHAR(50), -- ClickUp workspace the linked task belongs to
+    deleted_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE task_assignees (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by_user_id INTEGER REFERENCES users(id),
+    assigned_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE TABLE task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE task_activities (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id),
+    activity_type VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    reference_id INTEGER,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    -- The X-Request-ID of the request that made the change
+    request_id VARCHAR(64),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(20),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE(tenant_id, name)
+);
+
+CREATE TABLE task_tags (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE TABLE task_custom_fields (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_key VARCHAR(100) NOT NULL, -- Local name, e.g. client or billing_code
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (task_id, field_key)
+);
+
+CREATE TABLE task_sync_history (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    direction VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    changed_fields TEXT,
+    message TEXT,
+    remote_updated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE clickup_tokens (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    access_token TEXT NOT NULL, -- AES-GCM encrypted and base64 encoded
+    token_type VARCHAR(20) NOT NULL DEFAULT 'Bearer',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    team_id VARCHAR(50) NOT NULL DEFAULT '', -- ClickUp workspace, empty when unknown
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (user_id, team_id)
+);
+
+CREATE TABLE clickup_workspaces (
+    team_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    connected_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE estimation_sessions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    closed_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE estimation_session_participants (
+    session_id INTEGER NOT NULL REFERENCES estimation_sessions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (session_id, user_id)
 );
 
 CREATE TABLE task_estimates (
@@ -74,7 +226,16 @@ CREATE TABLE task_estimates (
     estimate_day DECIMAL(5,2) NOT NULL,
     note TEXT,
     created_by_user_id INTEGER NOT NULL REFERENCES users(id),
-    created_at TIMESTAMPTZ DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    is_current BOOLEAN NOT NULL DEFAULT FALSE,
+    supersedes_id INTEGER REFERENCES task_estimates(id) ON DELETE SET NULL,
+    superseded_at TIMESTAMPTZ,
+    session_id INTEGER REFERENCES estimation_sessions(id) ON DELETE SET NULL,
+    is_vote BOOLEAN NOT NULL DEFAULT FALSE, -- Hidden planning votes, never current
+    unit VARCHAR(10) NOT NULL DEFAULT 'days' CHECK (unit IN ('days', 'hours', 'points')),
+    estimate_value DECIMAL(7,2), -- The estimate in its own unit, estimate_day holds it converted to days
+    confidence VARCHAR(10) CHECK (confidence IN ('low', 'medium', 'high')),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
 );
 
 CREATE TABLE task_logs (
@@ -84,7 +245,8 @@ CREATE TABLE task_logs (
     created_by_user_id INTEGER NOT NULL REFERENCES users(id),
     worked_date DATE NOT NULL,
     created_at TIMESTAMPTZ DEFAULT NOW(),
-    is_work_on_holiday BOOLEAN DEFAULT FALSE
+    is_work_on_holiday BOOLEAN DEFAULT FALSE,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
 );
 
 CREATE TABLE medical_expenses (
@@ -94,7 +256,512 @@ CREATE TABLE medical_expenses (
     receipt_name VARCHAR(255),
     receipt_date DATE,
     note TEXT,
-    created_at TIMESTAMPTZ DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash TEXT NOT NULL,
+    -- NULL while the first request with the key is still running
+    status_code INTEGER,
+    content_type TEXT,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE (tenant_id, user_id, key)
+);
+
+-- Background job state, see db/migrations/000029_scheduled_jobs.up.sql. Jobs loop over the
+-- tenants, so the table belongs to none.
+CREATE TABLE scheduled_jobs (
+    name VARCHAR(100) PRIMARY KEY,
+    schedule TEXT NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_started_at TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    -- NULL when the last run succeeded
+    last_error TEXT,
+    -- The server instance of the last run
+    last_run_by TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Side effects run in the background with retries, see db/migrations/000030_job_queue.up.sql
+CREATE TABLE queued_jobs (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    -- pending, running, done or dead
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    -- The server instance running the job, and since when
+    locked_by TEXT,
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- The X-Request-ID of the request that queued the job
+    request_id VARCHAR(64),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- Notifications emailed to users, see db/migrations/000034_notifications.up.sql
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    -- The email address or LINE user ID, and the message, as rendered when the event happened
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    -- pending until the mail server or LINE takes it, then sent
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- email or line, see db/migrations/000036_line.up.sql
+    channel VARCHAR(20) NOT NULL DEFAULT 'email',
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- A kind of notification is emailed unless the user has a row turning it off
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    email BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    line BOOLEAN NOT NULL DEFAULT TRUE,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    push BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (user_id, kind)
+);
+
+-- The LINE accounts of users, see db/migrations/000036_line.up.sql
+CREATE TABLE line_links (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    line_user_id VARCHAR(64), -- NULL until the code is sent to the bot
+    link_code VARCHAR(16) UNIQUE,
+    link_code_expires_at TIMESTAMPTZ,
+    linked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- The mobile devices of users notifications are pushed to, see
+-- db/migrations/000049_push_devices.up.sql
+CREATE TABLE push_devices (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL,
+    platform VARCHAR(20) NOT NULL, -- android, ios or web
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE (tenant_id, token)
+);
+
+CREATE INDEX idx_push_devices_user_id ON push_devices(user_id);
+
+-- Slack workspaces the Slack app is installed in, see db/migrations/000035_slack_workspaces.up.sql
+CREATE TABLE slack_workspaces (
+    team_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    bot_token TEXT NOT NULL, -- AES-GCM encrypted and base64 encoded
+    -- Channel IDs, nothing is posted when empty
+    approvals_channel VARCHAR(50) NOT NULL DEFAULT '',
+    out_today_channel VARCHAR(50) NOT NULL DEFAULT '',
+    -- In the server's time zone
+    out_today_hour SMALLINT NOT NULL DEFAULT 9 CHECK (out_today_hour BETWEEN 0 AND 23),
+    -- The last day who is out was posted, so each day is posted once
+    out_today_posted_on DATE,
+    -- DM users when their leave is approved or rejected
+    notify_users BOOLEAN NOT NULL DEFAULT TRUE,
+    connected_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- Webhooks and their deliveries, see db/migrations/000037_webhooks.up.sql
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL, -- Signs the payloads, only shown when the webhook is created
+    -- Event names such as leave_log.created, every event when empty
+    events TEXT[] NOT NULL DEFAULT '{}',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    -- pending until the receiver answers with a 2xx, failed once retrying stopped
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER, -- Of the last attempt, NULL when it got no response
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id DESC);
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+
+-- Tokens internal services call the API with, see db/migrations/000051_api_tokens.up.sql
+CREATE TABLE api_tokens (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL, -- Hex SHA-256 of the token, only shown when it is created
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- e.g. availability
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    UNIQUE (tenant_id, token_hash)
+);
+
+-- The ledger annual records are derived from, see db/migrations/000052_balance_events.up.sql
+CREATE TABLE balance_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL, -- No foreign key, the ledger outlives purged users
+    year INTEGER NOT NULL,
+    source VARCHAR(30) NOT NULL, -- leave_logs, task_logs, medical_expenses or annual_records
+    source_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL, -- recorded, retracted or adjusted
+    vacation_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    sick_leave_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    worked_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    worked_on_holiday_day DECIMAL(7,2) NOT NULL DEFAULT 0,
+    medical_expense_baht DECIMAL(12,2) NOT NULL DEFAULT 0,
+    recorded_by_user_id INTEGER, -- The admin of an adjustment
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_balance_events_user_year ON balance_events(user_id, year, id);
+CREATE INDEX idx_balance_events_source ON balance_events(source, source_id);
+
+-- Reports exported in the background, see db/migrations/000038_report_exports.up.sql
+CREATE TABLE report_exports (
+    id SERIAL PRIMARY KEY,
+    report_name VARCHAR(50) NOT NULL, -- leave, timesheet, utilization or medical-expenses
+    format VARCHAR(10) NOT NULL, -- xlsx or pdf
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE, -- The one user it covers, everyone when NULL
+    department VARCHAR(100),
+    -- pending until the job rendered the file, failed when it couldn't
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_name VARCHAR(255),
+    content BYTEA,
+    error TEXT,
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    -- en or th, see db/migrations/000044_languages.up.sql
+    language VARCHAR(5) NOT NULL DEFAULT 'en',
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_report_exports_created_at ON report_exports(created_at);
+
+-- Check-ins and check-outs, see db/migrations/000039_attendance.up.sql
+CREATE TABLE attendance_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    work_date DATE NOT NULL, -- The day of the check-in
+    check_in_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    check_in_ip VARCHAR(45),
+    check_in_latitude DOUBLE PRECISION,
+    check_in_longitude DOUBLE PRECISION,
+    check_out_at TIMESTAMPTZ, -- NULL while checked in
+    check_out_ip VARCHAR(45),
+    check_out_latitude DOUBLE PRECISION,
+    check_out_longitude DOUBLE PRECISION,
+    note TEXT,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX idx_attendance_sessions_open ON attendance_sessions(user_id) WHERE check_out_at IS NULL;
+CREATE INDEX idx_attendance_sessions_user_date ON attendance_sessions(user_id, work_date);
+CREATE INDEX idx_attendance_sessions_work_date ON attendance_sessions(work_date);
+
+-- Pay periods and the payroll exports locked for them, see
+-- db/migrations/000040_payroll_periods.up.sql
+CREATE TABLE payroll_periods (
+    id SERIAL PRIMARY KEY,
+    period VARCHAR(7) NOT NULL, -- YYYY-MM, the month paid
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open or locked
+    file_name VARCHAR(255),
+    content BYTEA, -- The export, while locked
+    locked_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    locked_at TIMESTAMPTZ,
+    created_by_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (end_date >= start_date)
+);
+
+CREATE UNIQUE INDEX idx_payroll_periods_period ON payroll_periods(tenant_id, period);
+
+-- The org chart, see db/migrations/000041_org_chart.up.sql. users.department names the user's
+-- department.
+CREATE TABLE departments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL, -- What users.department holds
+    parent_id INTEGER REFERENCES departments(id), -- NULL at the top of the chart
+    head_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (parent_id <> id)
+);
+
+CREATE UNIQUE INDEX idx_departments_name ON departments(tenant_id, name);
+CREATE INDEX idx_departments_parent_id ON departments(parent_id);
+
+CREATE TABLE positions (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL, -- NULL when any department has it
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX idx_positions_title ON positions(tenant_id, COALESCE(department_id, 0), title);
+
+-- A user's place on the chart, a row only once one is set
+CREATE TABLE org_assignments (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    position_id INTEGER REFERENCES positions(id) ON DELETE SET NULL,
+    manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who they report to
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    CHECK (manager_id <> user_id)
+);
+
+CREATE INDEX idx_org_assignments_manager_id ON org_assignments(manager_id);
+
+-- The name of a department and of every department under it. A name that isn't a department
+-- only matches itself.
+CREATE FUNCTION department_and_below(department TEXT) RETURNS SETOF TEXT AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id, name FROM departments WHERE name = department
+        UNION
+        SELECT d.id, d.name FROM departments d JOIN tree ON d.parent_id = tree.id
+    )
+    SELECT name::TEXT FROM tree
+    UNION
+    SELECT department
+$$ LANGUAGE sql STABLE;
+
+-- Approvals, see db/migrations/000042_approvals.up.sql. A request copies the steps of its
+-- kind's policy when submitted.
+CREATE TABLE approval_policies (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL, -- leave, medical_expense, timesheet or quota_plan
+    steps TEXT[] NOT NULL, -- manager, supervisor, department_head, admin or department:<name>
+    auto_approve_max NUMERIC(12,2), -- Requests of up to this amount need no approval
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX idx_approval_policies_kind ON approval_policies(tenant_id, kind);
+
+INSERT INTO approval_policies (kind, steps) VALUES ('leave', '{supervisor}');
+
+CREATE TABLE approval_requests (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    subject_id INTEGER NOT NULL, -- The leave log, medical expense, task log or quota plan
+    requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(12,2) NOT NULL DEFAULT 0, -- Days of leave or work, or baht
+    payload JSONB, -- The change applied once approved, for quota plan edits
+    steps TEXT[] NOT NULL,
+    current_step INTEGER NOT NULL DEFAULT 0, -- Index into steps of the step waiting
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected or cancelled
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_approval_requests_subject ON approval_requests(kind, subject_id);
+CREATE INDEX idx_approval_requests_pending ON approval_requests(created_at) WHERE status = 'pending';
+CREATE INDEX idx_approval_requests_requester ON approval_requests(requester_id, created_at DESC);
+
+CREATE TABLE approval_delegations (
+    id SERIAL PRIMARY KEY,
+    delegator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    created_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- The delegator or an admin
+    revoked_at TIMESTAMPTZ, -- Revoked delegations are kept for the audit trail
+    revoked_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (end_date >= start_date),
+    CHECK (delegate_id <> delegator_id)
+);
+
+CREATE INDEX idx_approval_delegations_delegate ON approval_delegations(delegate_id, start_date, end_date);
+CREATE INDEX idx_approval_delegations_delegator_id ON approval_delegations(delegator_id);
+
+CREATE TABLE approval_decisions (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES approval_requests(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL,
+    approver_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    on_behalf_of_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who delegated the step
+    decision VARCHAR(20) NOT NULL, -- approved or rejected
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    delegation_id INTEGER REFERENCES approval_delegations(id) ON DELETE SET NULL -- The delegation it was made under
+);
+
+CREATE INDEX idx_approval_decisions_request_id ON approval_decisions(request_id);
+CREATE INDEX idx_approval_decisions_delegation_id ON approval_decisions(delegation_id) WHERE delegation_id IS NOT NULL;
+
+-- Uploaded receipts, certificates and avatars, see db/migrations/000043_files.up.sql
+CREATE TABLE files (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Who uploaded it
+    purpose VARCHAR(30) NOT NULL, -- medical_receipt, sick_leave_certificate or avatar
+    subject_id INTEGER NOT NULL, -- The medical expense, leave log or user, by purpose
+    storage_key TEXT NOT NULL UNIQUE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    scanned_at TIMESTAMPTZ, -- When the virus scanner passed it, NULL without FILE_SCAN_COMMAND
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_files_subject ON files(purpose, subject_id);
+CREATE INDEX idx_files_owner_id ON files(owner_id);
+
+-- The language users get notifications in, see db/migrations/000044_languages.up.sql
+CREATE TABLE language_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    language VARCHAR(5) NOT NULL, -- en or th
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- The time zone users away from the company are in, see db/migrations/000045_time_zones.up.sql
+CREATE TABLE time_zone_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    time_zone VARCHAR(64) NOT NULL, -- An IANA zone such as Asia/Bangkok
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- Departed users whose personal data was scrubbed, see db/migrations/000046_privacy.up.sql
+CREATE TABLE anonymizations (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    anonymized_by INTEGER REFERENCES users(id) ON DELETE SET NULL, -- NULL when the job did it
+    anonymized_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+-- Patterns flagged for HR to review, see db/migrations/000047_anomalies.up.sql
+CREATE TABLE anomalies (
+    id SERIAL PRIMARY KEY,
+    rule VARCHAR(50) NOT NULL, -- sick-mondays, over-logging or expense-near-limit
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL, -- The days the pattern was seen in
+    period_end DATE NOT NULL,
+    fingerprint VARCHAR(100) NOT NULL, -- What makes a finding new, such as the last day of the pattern
+    summary TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}', -- The days, logs or expenses behind the finding
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, confirmed or dismissed
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    review_note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE UNIQUE INDEX idx_anomalies_fingerprint ON anomalies(tenant_id, rule, user_id, fingerprint);
+CREATE INDEX idx_anomalies_status ON anomalies(status, created_at);
+
+-- Dashboard announcements and who acknowledged them, see
+-- db/migrations/000048_announcements.up.sql
+CREATE TABLE announcements (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    -- For everyone when all three are empty, otherwise for the users of these types or
+    -- departments, or the departments under them, and these users
+    user_types TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    user_ids INTEGER[] NOT NULL DEFAULT '{}',
+    requires_acknowledgment BOOLEAN NOT NULL DEFAULT FALSE,
+    publish_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expire_at TIMESTAMPTZ, -- NULL until it is taken down
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
+);
+
+CREATE INDEX idx_announcements_publish_at ON announcements(publish_at);
+
+CREATE TABLE announcement_acknowledgments (
+    announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    acknowledged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id),
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+CREATE INDEX idx_announcement_acknowledgments_user_id ON announcement_acknowledgments(user_id);
+
+-- Feature flags and the maintenance flag, see db/migrations/000031_feature_flags.up.sql. They
+-- are set for the whole deployment, so the table belongs to no tenant.
+CREATE TABLE feature_flags (
+    key VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    -- On for everyone
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Otherwise on for the users of these types or departments, and these users
+    user_types TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    user_ids INTEGER[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Settings admins override while the server runs, see
+-- db/migrations/000033_runtime_settings.up.sql. They apply to the whole deployment, so the table
+-- belongs to no tenant.
+CREATE TABLE runtime_settings (
+    key VARCHAR(100) PRIMARY KEY,
+    -- In the format of the environment variable, e.g. "true" or "https://a.example,https://b.example"
+    value TEXT NOT NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 CREATE TABLE leave_logs (
@@ -103,7 +770,10 @@ CREATE TABLE leave_logs (
     type VARCHAR(50) NOT NULL,
     date DATE NOT NULL,
     note TEXT,
-    created_at TIMESTAMPTZ DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    tenant_id INTEGER NOT NULL DEFAULT COALESCE(current_tenant_id(), 1) REFERENCES tenants(id)
 );
 
 -- Create indexes for foreign keys
@@ -112,9 +782,211 @@ CREATE INDEX idx_annual_records_quota_plan_id ON annual_records(quota_plan_id);
 CREATE INDEX idx_quota_plans_created_by_user_id ON quota_plans(created_by_user_id);
 CREATE INDEX idx_task_categories_parent_id ON task_categories(parent_id);
 CREATE INDEX idx_tasks_task_category_id ON tasks(task_category_id);
+CREATE INDEX idx_tasks_due_date ON tasks(due_date);
+CREATE INDEX idx_tasks_parent_task_id ON tasks(parent_task_id);
+CREATE INDEX idx_tasks_clickup_team_id ON tasks(clickup_team_id);
 CREATE INDEX idx_task_estimates_task_id ON task_estimates(task_id);
 CREATE INDEX idx_task_estimates_created_by_user_id ON task_estimates(created_by_user_id);
+CREATE UNIQUE INDEX idx_task_estimates_current ON task_estimates(task_id) WHERE is_current;
+CREATE UNIQUE INDEX idx_task_estimates_session_vote ON task_estimates(session_id, created_by_user_id) WHERE is_vote;
+CREATE INDEX idx_estimation_sessions_task_id ON estimation_sessions(task_id);
+CREATE INDEX idx_task_assignees_user_id ON task_assignees(user_id);
+CREATE INDEX idx_task_comments_task_id ON task_comments(task_id);
+CREATE INDEX idx_task_activities_task_id ON task_activities(task_id);
+CREATE INDEX idx_task_tags_tag_id ON task_tags(tag_id);
+CREATE INDEX idx_task_sync_history_task_id ON task_sync_history(task_id);
 CREATE INDEX idx_task_logs_task_id ON task_logs(task_id);
 CREATE INDEX idx_task_logs_created_by_user_id ON task_logs(created_by_user_id);
+CREATE INDEX idx_task_logs_user_created_at ON task_logs(created_by_user_id, created_at DESC, id DESC);
+CREATE INDEX idx_task_logs_user_worked_date ON task_logs(created_by_user_id, worked_date);
 CREATE INDEX idx_medical_expenses_user_id ON medical_expenses(user_id);
-CREATE INDEX idx_leave_logs_user_id ON leave_logs(user_id); 
\ No newline at end of file
+CREATE INDEX idx_medical_expenses_user_receipt_date ON medical_expenses(user_id, receipt_date);
+CREATE INDEX idx_leave_logs_user_id ON leave_logs(user_id);
+CREATE INDEX idx_leave_logs_user_created_at ON leave_logs(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_leave_logs_user_date ON leave_logs(user_id, date);
+CREATE INDEX idx_leave_logs_created_at ON leave_logs(created_at DESC, id DESC); 
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+CREATE INDEX idx_queued_jobs_status_run_at ON queued_jobs(status, run_at);
+CREATE INDEX idx_notifications_user_id ON notifications(user_id);
+
+-- Only live users need a unique username and email, within their tenant
+CREATE UNIQUE INDEX idx_users_username_live ON users(tenant_id, username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_users_email_live ON users(tenant_id, email) WHERE deleted_at IS NULL;
+
+-- Announce changes on the ngtableg_changes channel, see db/migrations/000023_change_feed.up.sql
+CREATE FUNCTION notify_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    FOREACH changed IN ARRAY (CASE TG_OP
+        WHEN 'INSERT' THEN ARRAY[to_jsonb(NEW)]
+        WHEN 'DELETE' THEN ARRAY[to_jsonb(OLD)]
+        ELSE ARRAY[to_jsonb(OLD), to_jsonb(NEW)]
+    END)
+    LOOP
+        -- Postgres drops identical notifications within one transaction
+        PERFORM pg_notify('ngtableg_changes', jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'id', changed->'id',
+            'user_id', changed->TG_ARGV[0],
+            'year', CASE WHEN TG_ARGV[1] = 'year' THEN (changed->>'year')::int
+                         ELSE EXTRACT(YEAR FROM (changed->>TG_ARGV[1])::date)::int END,
+            'tenant_id', changed->'tenant_id',
+            'origin', current_setting('ngtableg.instance', true)
+        )::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER leave_logs_notify_insert_delete AFTER INSERT OR DELETE ON leave_logs
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'date');
+CREATE TRIGGER leave_logs_notify_update AFTER UPDATE ON leave_logs
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'date');
+
+CREATE TRIGGER task_logs_notify_insert_delete AFTER INSERT OR DELETE ON task_logs
+    FOR EACH ROW EXECUTE FUNCTION notify_change('created_by_user_id', 'worked_date');
+CREATE TRIGGER task_logs_notify_update AFTER UPDATE ON task_logs
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('created_by_user_id', 'worked_date');
+
+CREATE TRIGGER medical_expenses_notify_insert_delete AFTER INSERT OR DELETE ON medical_expenses
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'receipt_date');
+CREATE TRIGGER medical_expenses_notify_update AFTER UPDATE ON medical_expenses
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'receipt_date');
+
+CREATE TRIGGER annual_records_notify_insert_delete AFTER INSERT OR DELETE ON annual_records
+    FOR EACH ROW EXECUTE FUNCTION notify_change('user_id', 'year');
+CREATE TRIGGER annual_records_notify_update AFTER UPDATE ON annual_records
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('user_id', 'year');
+
+CREATE TRIGGER tasks_notify_insert_delete AFTER INSERT OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_change('created_by_user_id', 'due_date');
+CREATE TRIGGER tasks_notify_update AFTER UPDATE ON tasks
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_change('created_by_user_id', 'due_date');
+
+-- Record what leave, task logs and medical expenses add to balances, see
+-- db/migrations/000052_balance_events.up.sql
+CREATE FUNCTION forbid_balance_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'balance events are never changed or deleted, record another one instead';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER balance_events_immutable BEFORE UPDATE OR DELETE ON balance_events
+    FOR EACH ROW EXECUTE FUNCTION forbid_balance_event_change();
+
+CREATE FUNCTION retract_balance_events(event_source TEXT, event_source_id INTEGER) RETURNS void AS $$
+    INSERT INTO balance_events (
+        user_id, year, source, source_id, action, vacation_day, sick_leave_day, worked_day,
+        worked_on_holiday_day, medical_expense_baht, tenant_id
+    )
+    SELECT user_id, year, source, source_id, 'retracted', -SUM(vacation_day), -SUM(sick_leave_day),
+        -SUM(worked_day), -SUM(worked_on_holiday_day), -SUM(medical_expense_baht), tenant_id
+    FROM balance_events
+    WHERE source = event_source AND source_id = event_source_id
+    GROUP BY user_id, year, source, source_id, tenant_id
+    HAVING SUM(vacation_day) <> 0 OR SUM(sick_leave_day) <> 0 OR SUM(worked_day) <> 0
+        OR SUM(worked_on_holiday_day) <> 0 OR SUM(medical_expense_baht) <> 0
+    ORDER BY year;
+$$ LANGUAGE sql;
+
+CREATE FUNCTION record_leave_log_balance_event() RETURNS trigger AS $$
+DECLARE
+    capacity DECIMAL;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('leave_logs', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL AND NEW.type IN ('vacation', 'sick') THEN
+        SELECT COALESCE(daily_capacity, 1) INTO capacity FROM users WHERE id = NEW.user_id;
+        INSERT INTO balance_events (user_id, year, source, source_id, action, vacation_day, sick_leave_day, tenant_id)
+        VALUES (NEW.user_id, EXTRACT(YEAR FROM NEW.date), 'leave_logs', NEW.id, 'recorded',
+            CASE WHEN NEW.type = 'vacation' THEN capacity ELSE 0 END,
+            CASE WHEN NEW.type = 'sick' THEN capacity ELSE 0 END,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION record_task_log_balance_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('task_logs', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.worked_day <> 0 THEN
+        INSERT INTO balance_events (user_id, year, source, source_id, action, worked_day, worked_on_holiday_day, tenant_id)
+        VALUES (NEW.created_by_user_id, EXTRACT(YEAR FROM NEW.worked_date), 'task_logs', NEW.id, 'recorded',
+            NEW.worked_day,
+            CASE WHEN NEW.is_work_on_holiday THEN NEW.worked_day ELSE 0 END,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION record_medical_expense_balance_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM retract_balance_events('medical_expenses', OLD.id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.deleted_at IS NULL AND NEW.amount <> 0 THEN
+        INSERT INTO balance_events (user_id, year, source, source_id, action, medical_expense_baht, tenant_id)
+        VALUES (NEW.user_id, EXTRACT(YEAR FROM COALESCE(NEW.receipt_date, NEW.created_at)), 'medical_expenses', NEW.id, 'recorded',
+            NEW.amount,
+            NEW.tenant_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER leave_logs_balance_event AFTER INSERT OR DELETE ON leave_logs
+    FOR EACH ROW EXECUTE FUNCTION record_leave_log_balance_event();
+CREATE TRIGGER leave_logs_balance_event_update AFTER UPDATE ON leave_logs
+    FOR EACH ROW WHEN ((OLD.user_id, OLD.type, OLD.date, OLD.deleted_at) IS DISTINCT FROM (NEW.user_id, NEW.type, NEW.date, NEW.deleted_at))
+    EXECUTE FUNCTION record_leave_log_balance_event();
+
+CREATE TRIGGER task_logs_balance_event AFTER INSERT OR DELETE ON task_logs
+    FOR EACH ROW EXECUTE FUNCTION record_task_log_balance_event();
+CREATE TRIGGER task_logs_balance_event_update AFTER UPDATE ON task_logs
+    FOR EACH ROW WHEN ((OLD.created_by_user_id, OLD.worked_day, OLD.worked_date, OLD.is_work_on_holiday) IS DISTINCT FROM (NEW.created_by_user_id, NEW.worked_day, NEW.worked_date, NEW.is_work_on_holiday))
+    EXECUTE FUNCTION record_task_log_balance_event();
+
+CREATE TRIGGER medical_expenses_balance_event AFTER INSERT OR DELETE ON medical_expenses
+    FOR EACH ROW EXECUTE FUNCTION record_medical_expense_balance_event();
+CREATE TRIGGER medical_expenses_balance_event_update AFTER UPDATE ON medical_expenses
+    FOR EACH ROW WHEN ((OLD.user_id, OLD.amount, OLD.receipt_date, OLD.deleted_at) IS DISTINCT FROM (NEW.user_id, NEW.amount, NEW.receipt_date, NEW.deleted_at))
+    EXECUTE FUNCTION record_medical_expense_balance_event();
+
+-- Rows of other tenants are invisible and can't be written, for every role but the table owner.
+-- A connection bound to no tenant sees none unless it asks for every tenant.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'quota_plans', 'annual_records', 'holidays', 'task_categories', 'tasks',
+        'task_assignees', 'task_comments', 'task_activities', 'tags', 'task_tags',
+        'task_custom_fields', 'task_sync_history', 'clickup_tokens', 'clickup_workspaces',
+        'estimation_sessions', 'estimation_session_participants', 'task_estimates', 'task_logs',
+        'medical_expenses', 'leave_logs', 'idempotency_keys', 'queued_jobs', 'notifications',
+        'notification_preferences', 'line_links', 'slack_workspaces', 'webhooks',
+        'webhook_deliveries', 'report_exports', 'attendance_sessions',
+        'payroll_periods', 'departments', 'positions', 'org_assignments',
+        'approval_policies', 'approval_requests', 'approval_decisions', 'approval_delegations',
+        'files', 'language_preferences', 'time_zone_preferences', 'anonymizations',
+        'anomalies', 'announcements', 'announcement_acknowledgments', 'push_devices',
+        'api_tokens', 'balance_events'
+    ]
+    LOOP
+        EXECUTE format('CREATE INDEX %I ON %I(tenant_id)', 'idx_' || t || '_tenant_id', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (tenant_id = current_tenant_id())
+            WITH CHECK (tenant_id = current_tenant_id())', t);
+        EXECUTE format('CREATE POLICY all_tenants ON %I
+            USING (all_tenants())
+            WITH CHECK (all_tenants())', t);
+    END LOOP;
+END $$;
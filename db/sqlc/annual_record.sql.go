@@ -13,7 +13,7 @@ import (
 
 const assignQuotaPlanToAllUsers = `-- name: AssignQuotaPlanToAllUsers :exec
 WITH user_list AS (
-    SELECT id FROM users
+    SELECT id FROM users WHERE deleted_at IS NULL
 )
 UPDATE annual_records
 SET 
@@ -48,7 +48,7 @@ INSERT INTO annual_records (
   $1, $2, $3, $4, 
   $5, $6, $7, 
   $8, $9
-) RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at
+) RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at, tenant_id
 `
 
 type CreateAnnualRecordParams struct {
@@ -89,13 +89,14 @@ func (q *Queries) CreateAnnualRecord(ctx context.Context, arg CreateAnnualRecord
 		&i.UsedMedicalExpenseBaht,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const createNextYearAnnualRecords = `-- name: CreateNextYearAnnualRecords :many
 WITH user_list AS (
-    SELECT id FROM users
+    SELECT id FROM users WHERE deleted_at IS NULL
 ),
 default_quota_plan AS (
     SELECT id 
@@ -162,7 +163,7 @@ WHERE NOT EXISTS (
     WHERE annual_records.user_id = rollover_calculation.user_id 
     AND annual_records.year = $1
 )
-RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at
+RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at, tenant_id
 `
 
 type CreateNextYearAnnualRecordsParams struct {
@@ -192,6 +193,7 @@ func (q *Queries) CreateNextYearAnnualRecords(ctx context.Context, arg CreateNex
 			&i.UsedMedicalExpenseBaht,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -214,7 +216,7 @@ func (q *Queries) DeleteAnnualRecord(ctx context.Context, id int32) error {
 }
 
 const getAnnualRecord = `-- name: GetAnnualRecord :one
-SELECT id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at FROM annual_records
+SELECT id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at, tenant_id FROM annual_records
 WHERE id = $1 LIMIT 1
 `
 
@@ -234,12 +236,13 @@ func (q *Queries) GetAnnualRecord(ctx context.Context, id int32) (AnnualRecord,
 		&i.UsedMedicalExpenseBaht,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getAnnualRecordByUserAndYear = `-- name: GetAnnualRecordByUserAndYear :one
-SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, qp.quota_vacation_day, qp.quota_medical_expense_baht
+SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, ar.tenant_id, qp.quota_vacation_day, qp.quota_medical_expense_baht
 FROM annual_records ar
 LEFT JOIN quota_plans qp ON ar.quota_plan_id = qp.id
 WHERE ar.user_id = $1 AND ar.year = $2 LIMIT 1
@@ -263,6 +266,7 @@ type GetAnnualRecordByUserAndYearRow struct {
 	UsedMedicalExpenseBaht  pgtype.Numeric     `json:"usedMedicalExpenseBaht"`
 	CreatedAt               pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt               pgtype.Timestamptz `json:"updatedAt"`
+	TenantID                int32              `json:"tenantId"`
 	QuotaVacationDay        pgtype.Numeric     `json:"quotaVacationDay"`
 	QuotaMedicalExpenseBaht pgtype.Numeric     `json:"quotaMedicalExpenseBaht"`
 }
@@ -283,14 +287,64 @@ func (q *Queries) GetAnnualRecordByUserAndYear(ctx context.Context, arg GetAnnua
 		&i.UsedMedicalExpenseBaht,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 		&i.QuotaVacationDay,
 		&i.QuotaMedicalExpenseBaht,
 	)
 	return i, err
 }
 
+const getLeaveBalance = `-- name: GetLeaveBalance :one
+SELECT
+  ar.year,
+  qp.plan_name,
+  COALESCE(qp.quota_vacation_day, 0)::DECIMAL AS quota_vacation_day,
+  COALESCE(ar.rollover_vacation_day, 0)::DECIMAL AS rollover_vacation_day,
+  COALESCE(ar.used_vacation_day, 0)::DECIMAL AS used_vacation_day,
+  COALESCE(ar.used_sick_leave_day, 0)::DECIMAL AS used_sick_leave_day,
+  COALESCE(qp.quota_medical_expense_baht, 0)::DECIMAL AS quota_medical_expense_baht,
+  COALESCE(ar.used_medical_expense_baht, 0)::DECIMAL AS used_medical_expense_baht
+FROM annual_records ar
+LEFT JOIN quota_plans qp ON qp.id = ar.quota_plan_id
+WHERE ar.user_id = $1 AND ar.year = $2
+LIMIT 1
+`
+
+type GetLeaveBalanceParams struct {
+	UserID int32 `json:"userId"`
+	Year   int32 `json:"year"`
+}
+
+type GetLeaveBalanceRow struct {
+	Year                    int32          `json:"year"`
+	PlanName                pgtype.Text    `json:"planName"`
+	QuotaVacationDay        pgtype.Numeric `json:"quotaVacationDay"`
+	RolloverVacationDay     pgtype.Numeric `json:"rolloverVacationDay"`
+	UsedVacationDay         pgtype.Numeric `json:"usedVacationDay"`
+	UsedSickLeaveDay        pgtype.Numeric `json:"usedSickLeaveDay"`
+	QuotaMedicalExpenseBaht pgtype.Numeric `json:"quotaMedicalExpenseBaht"`
+	UsedMedicalExpenseBaht  pgtype.Numeric `json:"usedMedicalExpenseBaht"`
+}
+
+// A user's leave and medical expense balances in a year, from their annual record and its quota plan
+func (q *Queries) GetLeaveBalance(ctx context.Context, arg GetLeaveBalanceParams) (GetLeaveBalanceRow, error) {
+	row := q.db.QueryRow(ctx, getLeaveBalance, arg.UserID, arg.Year)
+	var i GetLeaveBalanceRow
+	err := row.Scan(
+		&i.Year,
+		&i.PlanName,
+		&i.QuotaVacationDay,
+		&i.RolloverVacationDay,
+		&i.UsedVacationDay,
+		&i.UsedSickLeaveDay,
+		&i.QuotaMedicalExpenseBaht,
+		&i.UsedMedicalExpenseBaht,
+	)
+	return i, err
+}
+
 const listAnnualRecordsByUser = `-- name: ListAnnualRecordsByUser :many
-SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, qp.quota_vacation_day, qp.quota_medical_expense_baht
+SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, ar.tenant_id, qp.quota_vacation_day, qp.quota_medical_expense_baht
 FROM annual_records ar
 LEFT JOIN quota_plans qp ON ar.quota_plan_id = qp.id
 WHERE ar.user_id = $1
@@ -310,6 +364,7 @@ type ListAnnualRecordsByUserRow struct {
 	UsedMedicalExpenseBaht  pgtype.Numeric     `json:"usedMedicalExpenseBaht"`
 	CreatedAt               pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt               pgtype.Timestamptz `json:"updatedAt"`
+	TenantID                int32              `json:"tenantId"`
 	QuotaVacationDay        pgtype.Numeric     `json:"quotaVacationDay"`
 	QuotaMedicalExpenseBaht pgtype.Numeric     `json:"quotaMedicalExpenseBaht"`
 }
@@ -336,6 +391,7 @@ func (q *Queries) ListAnnualRecordsByUser(ctx context.Context, userID int32) ([]
 			&i.UsedMedicalExpenseBaht,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 			&i.QuotaVacationDay,
 			&i.QuotaMedicalExpenseBaht,
 		); err != nil {
@@ -350,7 +406,7 @@ func (q *Queries) ListAnnualRecordsByUser(ctx context.Context, userID int32) ([]
 }
 
 const listAnnualRecordsByYear = `-- name: ListAnnualRecordsByYear :many
-SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, qp.quota_vacation_day, qp.quota_medical_expense_baht
+SELECT ar.id, ar.user_id, ar.year, ar.quota_plan_id, ar.rollover_vacation_day, ar.used_vacation_day, ar.used_sick_leave_day, ar.worked_on_holiday_day, ar.worked_day, ar.used_medical_expense_baht, ar.created_at, ar.updated_at, ar.tenant_id, qp.quota_vacation_day, qp.quota_medical_expense_baht
 FROM annual_records ar
 LEFT JOIN quota_plans qp ON ar.quota_plan_id = qp.id
 WHERE ar.year = $1
@@ -370,6 +426,7 @@ type ListAnnualRecordsByYearRow struct {
 	UsedMedicalExpenseBaht  pgtype.Numeric     `json:"usedMedicalExpenseBaht"`
 	CreatedAt               pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt               pgtype.Timestamptz `json:"updatedAt"`
+	TenantID                int32              `json:"tenantId"`
 	QuotaVacationDay        pgtype.Numeric     `json:"quotaVacationDay"`
 	QuotaMedicalExpenseBaht pgtype.Numeric     `json:"quotaMedicalExpenseBaht"`
 }
@@ -396,6 +453,7 @@ func (q *Queries) ListAnnualRecordsByYear(ctx context.Context, year int32) ([]Li
 			&i.UsedMedicalExpenseBaht,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 			&i.QuotaVacationDay,
 			&i.QuotaMedicalExpenseBaht,
 		); err != nil {
@@ -409,6 +467,70 @@ func (q *Queries) ListAnnualRecordsByYear(ctx context.Context, year int32) ([]Li
 	return items, nil
 }
 
+const listLeaveBalancesByYear = `-- name: ListLeaveBalancesByYear :many
+SELECT
+  ar.user_id,
+  u.username,
+  ar.year,
+  qp.plan_name,
+  COALESCE(qp.quota_vacation_day, 0)::DECIMAL AS quota_vacation_day,
+  COALESCE(ar.rollover_vacation_day, 0)::DECIMAL AS rollover_vacation_day,
+  COALESCE(ar.used_vacation_day, 0)::DECIMAL AS used_vacation_day,
+  COALESCE(ar.used_sick_leave_day, 0)::DECIMAL AS used_sick_leave_day,
+  COALESCE(qp.quota_medical_expense_baht, 0)::DECIMAL AS quota_medical_expense_baht,
+  COALESCE(ar.used_medical_expense_baht, 0)::DECIMAL AS used_medical_expense_baht
+FROM annual_records ar
+JOIN users u ON u.id = ar.user_id AND u.deleted_at IS NULL
+LEFT JOIN quota_plans qp ON qp.id = ar.quota_plan_id
+WHERE ar.year = $1
+ORDER BY u.username
+`
+
+type ListLeaveBalancesByYearRow struct {
+	UserID                  int32          `json:"userId"`
+	Username                string         `json:"username"`
+	Year                    int32          `json:"year"`
+	PlanName                pgtype.Text    `json:"planName"`
+	QuotaVacationDay        pgtype.Numeric `json:"quotaVacationDay"`
+	RolloverVacationDay     pgtype.Numeric `json:"rolloverVacationDay"`
+	UsedVacationDay         pgtype.Numeric `json:"usedVacationDay"`
+	UsedSickLeaveDay        pgtype.Numeric `json:"usedSickLeaveDay"`
+	QuotaMedicalExpenseBaht pgtype.Numeric `json:"quotaMedicalExpenseBaht"`
+	UsedMedicalExpenseBaht  pgtype.Numeric `json:"usedMedicalExpenseBaht"`
+}
+
+// Every user's leave and medical expense balances in a year, by username
+func (q *Queries) ListLeaveBalancesByYear(ctx context.Context, year int32) ([]ListLeaveBalancesByYearRow, error) {
+	rows, err := q.db.Query(ctx, listLeaveBalancesByYear, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaveBalancesByYearRow{}
+	for rows.Next() {
+		var i ListLeaveBalancesByYearRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Year,
+			&i.PlanName,
+			&i.QuotaVacationDay,
+			&i.RolloverVacationDay,
+			&i.UsedVacationDay,
+			&i.UsedSickLeaveDay,
+			&i.QuotaMedicalExpenseBaht,
+			&i.UsedMedicalExpenseBaht,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAnnualRecord = `-- name: UpdateAnnualRecord :one
 UPDATE annual_records
 SET 
@@ -421,21 +543,24 @@ SET
   used_medical_expense_baht = COALESCE($7, used_medical_expense_baht),
   updated_at = NOW()
 WHERE user_id = $8 AND year = $9
-RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at
+  AND ($10::TIMESTAMPTZ IS NULL OR updated_at = $10::TIMESTAMPTZ)
+RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at, tenant_id
 `
 
 type UpdateAnnualRecordParams struct {
-	QuotaPlanID            pgtype.Int4    `json:"quotaPlanId"`
-	RolloverVacationDay    pgtype.Numeric `json:"rolloverVacationDay"`
-	UsedVacationDay        pgtype.Numeric `json:"usedVacationDay"`
-	UsedSickLeaveDay       pgtype.Numeric `json:"usedSickLeaveDay"`
-	WorkedOnHolidayDay     pgtype.Numeric `json:"workedOnHolidayDay"`
-	WorkedDay              pgtype.Numeric `json:"workedDay"`
-	UsedMedicalExpenseBaht pgtype.Numeric `json:"usedMedicalExpenseBaht"`
-	UserID                 int32          `json:"userId"`
-	Year                   int32          `json:"year"`
+	QuotaPlanID            pgtype.Int4        `json:"quotaPlanId"`
+	RolloverVacationDay    pgtype.Numeric     `json:"rolloverVacationDay"`
+	UsedVacationDay        pgtype.Numeric     `json:"usedVacationDay"`
+	UsedSickLeaveDay       pgtype.Numeric     `json:"usedSickLeaveDay"`
+	WorkedOnHolidayDay     pgtype.Numeric     `json:"workedOnHolidayDay"`
+	WorkedDay              pgtype.Numeric     `json:"workedDay"`
+	UsedMedicalExpenseBaht pgtype.Numeric     `json:"usedMedicalExpenseBaht"`
+	UserID                 int32              `json:"userId"`
+	Year                   int32              `json:"year"`
+	ExpectedUpdatedAt      pgtype.Timestamptz `json:"expectedUpdatedAt"`
 }
 
+// Matches no row when expected_updated_at is set and the record changed since, so concurrent edits conflict
 func (q *Queries) UpdateAnnualRecord(ctx context.Context, arg UpdateAnnualRecordParams) (AnnualRecord, error) {
 	row := q.db.QueryRow(ctx, updateAnnualRecord,
 		arg.QuotaPlanID,
@@ -447,6 +572,7 @@ func (q *Queries) UpdateAnnualRecord(ctx context.Context, arg UpdateAnnualRecord
 		arg.UsedMedicalExpenseBaht,
 		arg.UserID,
 		arg.Year,
+		arg.ExpectedUpdatedAt,
 	)
 	var i AnnualRecord
 	err := row.Scan(
@@ -462,6 +588,7 @@ func (q *Queries) UpdateAnnualRecord(ctx context.Context, arg UpdateAnnualRecord
 		&i.UsedMedicalExpenseBaht,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -492,7 +619,7 @@ VALUES (
 ON CONFLICT (user_id, year) DO UPDATE SET
     quota_plan_id = $3,
     updated_at = NOW()
-RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at
+RETURNING id, user_id, year, quota_plan_id, rollover_vacation_day, used_vacation_day, used_sick_leave_day, worked_on_holiday_day, worked_day, used_medical_expense_baht, created_at, updated_at, tenant_id
 `
 
 type UpsertAnnualRecordForUserParams struct {
@@ -533,6 +660,7 @@ func (q *Queries) UpsertAnnualRecordForUser(ctx context.Context, arg UpsertAnnua
 		&i.UsedMedicalExpenseBaht,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -11,6 +11,17 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countHolidays = `-- name: CountHolidays :one
+SELECT COUNT(*) FROM holidays
+`
+
+func (q *Queries) CountHolidays(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countHolidays)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createHoliday = `-- name: CreateHoliday :one
 INSERT INTO holidays (
   date,
@@ -18,7 +29,7 @@ INSERT INTO holidays (
   note
 ) VALUES (
   $1, $2, $3
-) RETURNING id, date, name, note, created_at
+) RETURNING id, date, name, note, created_at, updated_at, tenant_id
 `
 
 type CreateHolidayParams struct {
@@ -36,10 +47,18 @@ func (q *Queries) CreateHoliday(ctx context.Context, arg CreateHolidayParams) (H
 		&i.Name,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+type CreateHolidaysParams struct {
+	Date pgtype.Date `json:"date"`
+	Name string      `json:"name"`
+	Note pgtype.Text `json:"note"`
+}
+
 const deleteHoliday = `-- name: DeleteHoliday :exec
 DELETE FROM holidays
 WHERE id = $1
@@ -51,7 +70,7 @@ func (q *Queries) DeleteHoliday(ctx context.Context, id int32) error {
 }
 
 const getHoliday = `-- name: GetHoliday :one
-SELECT id, date, name, note, created_at FROM holidays
+SELECT id, date, name, note, created_at, updated_at, tenant_id FROM holidays
 WHERE id = $1 LIMIT 1
 `
 
@@ -64,12 +83,14 @@ func (q *Queries) GetHoliday(ctx context.Context, id int32) (Holiday, error) {
 		&i.Name,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getHolidayByDate = `-- name: GetHolidayByDate :one
-SELECT id, date, name, note, created_at FROM holidays
+SELECT id, date, name, note, created_at, updated_at, tenant_id FROM holidays
 WHERE date = $1 LIMIT 1
 `
 
@@ -82,12 +103,31 @@ func (q *Queries) GetHolidayByDate(ctx context.Context, date pgtype.Date) (Holid
 		&i.Name,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const getHolidaysVersion = `-- name: GetHolidaysVersion :one
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM holidays
+`
+
+type GetHolidaysVersionRow struct {
+	RowCount    int64              `json:"rowCount"`
+	LastUpdated pgtype.Timestamptz `json:"lastUpdated"`
+}
+
+// Changes whenever a holiday is added, edited or deleted, for the ETag of the holiday listings
+func (q *Queries) GetHolidaysVersion(ctx context.Context) (GetHolidaysVersionRow, error) {
+	row := q.db.QueryRow(ctx, getHolidaysVersion)
+	var i GetHolidaysVersionRow
+	err := row.Scan(&i.RowCount, &i.LastUpdated)
+	return i, err
+}
+
 const listHolidays = `-- name: ListHolidays :many
-SELECT id, date, name, note, created_at FROM holidays
+SELECT id, date, name, note, created_at, updated_at, tenant_id FROM holidays
 ORDER BY date
 LIMIT $1
 OFFSET $2
@@ -113,6 +153,8 @@ func (q *Queries) ListHolidays(ctx context.Context, arg ListHolidaysParams) ([]H
 			&i.Name,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -125,7 +167,7 @@ func (q *Queries) ListHolidays(ctx context.Context, arg ListHolidaysParams) ([]H
 }
 
 const listHolidaysByYear = `-- name: ListHolidaysByYear :many
-SELECT id, date, name, note, created_at FROM holidays
+SELECT id, date, name, note, created_at, updated_at, tenant_id FROM holidays
 WHERE EXTRACT(YEAR FROM date) = $1
 ORDER BY date
 `
@@ -145,6 +187,8 @@ func (q *Queries) ListHolidaysByYear(ctx context.Context, date pgtype.Date) ([]H
 			&i.Name,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -161,9 +205,10 @@ UPDATE holidays
 SET 
   date = COALESCE($2, date),
   name = COALESCE($3, name),
-  note = COALESCE($4, note)
+  note = COALESCE($4, note),
+  updated_at = NOW()
 WHERE id = $1
-RETURNING id, date, name, note, created_at
+RETURNING id, date, name, note, created_at, updated_at, tenant_id
 `
 
 type UpdateHolidayParams struct {
@@ -187,6 +232,8 @@ func (q *Queries) UpdateHoliday(ctx context.Context, arg UpdateHolidayParams) (H
 		&i.Name,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
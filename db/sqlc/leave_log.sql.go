@@ -11,6 +11,56 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countLeaveLogs = `-- name: CountLeaveLogs :one
+SELECT COUNT(*) FROM leave_logs
+WHERE deleted_at IS NULL
+  AND ($1::INTEGER IS NULL OR user_id = $1::INTEGER)
+  AND ($2::TEXT IS NULL OR type = $2::TEXT)
+  AND ($3::INTEGER IS NULL OR EXTRACT(YEAR FROM date) = $3::INTEGER)
+  AND ($4::DATE IS NULL OR date >= $4::DATE)
+  AND ($5::DATE IS NULL OR date <= $5::DATE)
+`
+
+type CountLeaveLogsParams struct {
+	UserID   pgtype.Int4 `json:"userId"`
+	Type     pgtype.Text `json:"type"`
+	Year     pgtype.Int4 `json:"year"`
+	DateFrom pgtype.Date `json:"dateFrom"`
+	DateTo   pgtype.Date `json:"dateTo"`
+}
+
+// Counts the leave logs ListLeaveLogsWithUsername and ListLeaveLogsAfter page through
+func (q *Queries) CountLeaveLogs(ctx context.Context, arg CountLeaveLogsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countLeaveLogs,
+		arg.UserID,
+		arg.Type,
+		arg.Year,
+		arg.DateFrom,
+		arg.DateTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countLeaveLogsForDate = `-- name: CountLeaveLogsForDate :one
+SELECT COUNT(*) FROM leave_logs
+WHERE user_id = $1 AND date = $2 AND deleted_at IS NULL
+`
+
+type CountLeaveLogsForDateParams struct {
+	UserID int32       `json:"userId"`
+	Date   pgtype.Date `json:"date"`
+}
+
+// Counts a user's leave on one date, used to check the daily capacity
+func (q *Queries) CountLeaveLogsForDate(ctx context.Context, arg CountLeaveLogsForDateParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countLeaveLogsForDate, arg.UserID, arg.Date)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createLeaveLog = `-- name: CreateLeaveLog :one
 INSERT INTO leave_logs (
   user_id,
@@ -19,7 +69,7 @@ INSERT INTO leave_logs (
   note
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING id, user_id, type, date, note, created_at
+) RETURNING id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id
 `
 
 type CreateLeaveLogParams struct {
@@ -44,23 +94,35 @@ func (q *Queries) CreateLeaveLog(ctx context.Context, arg CreateLeaveLogParams)
 		&i.Date,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+type CreateLeaveLogsParams struct {
+	UserID int32       `json:"userId"`
+	Type   string      `json:"type"`
+	Date   pgtype.Date `json:"date"`
+	Note   pgtype.Text `json:"note"`
+}
+
 const deleteLeaveLog = `-- name: DeleteLeaveLog :exec
-DELETE FROM leave_logs
-WHERE id = $1
+UPDATE leave_logs
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
+// Soft deletes the leave log, the row stays until PurgeLeaveLog removes it
 func (q *Queries) DeleteLeaveLog(ctx context.Context, id int32) error {
 	_, err := q.db.Exec(ctx, deleteLeaveLog, id)
 	return err
 }
 
 const getLeaveLog = `-- name: GetLeaveLog :one
-SELECT id, user_id, type, date, note, created_at FROM leave_logs
-WHERE id = $1 LIMIT 1
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetLeaveLog(ctx context.Context, id int32) (LeaveLog, error) {
@@ -73,13 +135,81 @@ func (q *Queries) GetLeaveLog(ctx context.Context, id int32) (LeaveLog, error) {
 		&i.Date,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const listLeaveLogsAfter = `-- name: ListLeaveLogsAfter :many
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE deleted_at IS NULL
+  AND ($1::INTEGER IS NULL OR user_id = $1::INTEGER)
+  AND ($2::TEXT IS NULL OR type = $2::TEXT)
+  AND ($3::INTEGER IS NULL OR EXTRACT(YEAR FROM date) = $3::INTEGER)
+  AND ($4::DATE IS NULL OR date >= $4::DATE)
+  AND ($5::DATE IS NULL OR date <= $5::DATE)
+  AND ($6::TIMESTAMPTZ IS NULL
+    OR (created_at, id) < ($6::TIMESTAMPTZ, $7::INTEGER))
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type ListLeaveLogsAfterParams struct {
+	UserID          pgtype.Int4        `json:"userId"`
+	Type            pgtype.Text        `json:"type"`
+	Year            pgtype.Int4        `json:"year"`
+	DateFrom        pgtype.Date        `json:"dateFrom"`
+	DateTo          pgtype.Date        `json:"dateTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int32              `json:"cursorId"`
+	RowLimit        int32              `json:"rowLimit"`
+}
+
+// Keyset page of leave logs, newest first, starting after the (created_at, id) cursor when given
+func (q *Queries) ListLeaveLogsAfter(ctx context.Context, arg ListLeaveLogsAfterParams) ([]LeaveLog, error) {
+	rows, err := q.db.Query(ctx, listLeaveLogsAfter,
+		arg.UserID,
+		arg.Type,
+		arg.Year,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LeaveLog{}
+	for rows.Next() {
+		var i LeaveLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Date,
+			&i.Note,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listLeaveLogsByDateRange = `-- name: ListLeaveLogsByDateRange :many
-SELECT id, user_id, type, date, note, created_at FROM leave_logs
-WHERE user_id = $1 AND date BETWEEN $2 AND $3
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE user_id = $1 AND date BETWEEN $2 AND $3 AND deleted_at IS NULL
 ORDER BY date DESC
 `
 
@@ -105,6 +235,9 @@ func (q *Queries) ListLeaveLogsByDateRange(ctx context.Context, arg ListLeaveLog
 			&i.Date,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -117,8 +250,8 @@ func (q *Queries) ListLeaveLogsByDateRange(ctx context.Context, arg ListLeaveLog
 }
 
 const listLeaveLogsByType = `-- name: ListLeaveLogsByType :many
-SELECT id, user_id, type, date, note, created_at FROM leave_logs
-WHERE user_id = $1 AND type = $2
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE user_id = $1 AND type = $2 AND deleted_at IS NULL
 ORDER BY date DESC
 LIMIT $3
 OFFSET $4
@@ -152,6 +285,9 @@ func (q *Queries) ListLeaveLogsByType(ctx context.Context, arg ListLeaveLogsByTy
 			&i.Date,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -164,8 +300,8 @@ func (q *Queries) ListLeaveLogsByType(ctx context.Context, arg ListLeaveLogsByTy
 }
 
 const listLeaveLogsByUser = `-- name: ListLeaveLogsByUser :many
-SELECT id, user_id, type, date, note, created_at FROM leave_logs
-WHERE user_id = $1
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY date DESC
 LIMIT $2
 OFFSET $3
@@ -193,6 +329,9 @@ func (q *Queries) ListLeaveLogsByUser(ctx context.Context, arg ListLeaveLogsByUs
 			&i.Date,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -205,8 +344,8 @@ func (q *Queries) ListLeaveLogsByUser(ctx context.Context, arg ListLeaveLogsByUs
 }
 
 const listLeaveLogsByYear = `-- name: ListLeaveLogsByYear :many
-SELECT id, user_id, type, date, note, created_at FROM leave_logs
-WHERE user_id = $1 AND EXTRACT(YEAR FROM date) = $2
+SELECT id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id FROM leave_logs
+WHERE user_id = $1 AND EXTRACT(YEAR FROM date) = $2 AND deleted_at IS NULL
 ORDER BY date DESC
 `
 
@@ -231,6 +370,259 @@ func (q *Queries) ListLeaveLogsByYear(ctx context.Context, arg ListLeaveLogsByYe
 			&i.Date,
 			&i.Note,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeaveLogsWithUsername = `-- name: ListLeaveLogsWithUsername :many
+SELECT ll.id, ll.user_id, ll.type, ll.date, ll.note, ll.created_at, ll.updated_at, u.username
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND ($1::INTEGER IS NULL OR ll.user_id = $1::INTEGER)
+  AND ($2::TEXT IS NULL OR ll.type = $2::TEXT)
+  AND ($3::INTEGER IS NULL OR EXTRACT(YEAR FROM ll.date) = $3::INTEGER)
+  AND ($4::DATE IS NULL OR ll.date >= $4::DATE)
+  AND ($5::DATE IS NULL OR ll.date <= $5::DATE)
+ORDER BY
+  CASE WHEN $6::TEXT = 'date' AND NOT $7::BOOLEAN THEN ll.date END ASC,
+  CASE WHEN $6::TEXT = 'created_at' AND NOT $7::BOOLEAN THEN ll.created_at END ASC,
+  CASE WHEN $6::TEXT = 'created_at' AND $7::BOOLEAN THEN ll.created_at END DESC,
+  CASE WHEN $6::TEXT = 'type' AND NOT $7::BOOLEAN THEN ll.type END ASC,
+  CASE WHEN $6::TEXT = 'type' AND $7::BOOLEAN THEN ll.type END DESC,
+  ll.date DESC, ll.id DESC
+LIMIT $8
+OFFSET $9
+`
+
+type ListLeaveLogsWithUsernameParams struct {
+	UserID    pgtype.Int4 `json:"userId"`
+	Type      pgtype.Text `json:"type"`
+	Year      pgtype.Int4 `json:"year"`
+	DateFrom  pgtype.Date `json:"dateFrom"`
+	DateTo    pgtype.Date `json:"dateTo"`
+	SortBy    string      `json:"sortBy"`
+	SortDesc  bool        `json:"sortDesc"`
+	RowLimit  int32       `json:"rowLimit"`
+	RowOffset int32       `json:"rowOffset"`
+}
+
+type ListLeaveLogsWithUsernameRow struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"userId"`
+	Type      string             `json:"type"`
+	Date      pgtype.Date        `json:"date"`
+	Note      pgtype.Text        `json:"note"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	Username  string             `json:"username"`
+}
+
+// Page of leave logs with their user's name, narrowed by whichever of user, type, year and date
+// range are given, in sort_by order and otherwise newest date first
+func (q *Queries) ListLeaveLogsWithUsername(ctx context.Context, arg ListLeaveLogsWithUsernameParams) ([]ListLeaveLogsWithUsernameRow, error) {
+	rows, err := q.db.Query(ctx, listLeaveLogsWithUsername,
+		arg.UserID,
+		arg.Type,
+		arg.Year,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLeaveLogsWithUsernameRow{}
+	for rows.Next() {
+		var i ListLeaveLogsWithUsernameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Date,
+			&i.Note,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableLeaveLogIDs = `-- name: ListPurgeableLeaveLogIDs :many
+SELECT id FROM leave_logs
+WHERE deleted_at IS NOT NULL AND deleted_at < $1::TIMESTAMPTZ
+ORDER BY deleted_at
+`
+
+// Leave logs deleted before the cutoff, oldest deletion first
+func (q *Queries) ListPurgeableLeaveLogIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listPurgeableLeaveLogIDs, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeLeaveLog = `-- name: PurgeLeaveLog :execrows
+DELETE FROM leave_logs
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Removes a soft deleted leave log for good, live ones are never touched
+func (q *Queries) PurgeLeaveLog(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeLeaveLog, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreLeaveLog = `-- name: RestoreLeaveLog :execrows
+UPDATE leave_logs
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Undoes DeleteLeaveLog
+func (q *Queries) RestoreLeaveLog(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreLeaveLog, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const summarizeLeaveLogs = `-- name: SummarizeLeaveLogs :many
+SELECT ll.user_id, u.username, ll.type, COUNT(*) AS day_count
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND ll.date >= make_date($1::INTEGER, 1, 1)
+  AND ll.date < make_date($1::INTEGER + 1, 1, 1)
+  AND ($2::INTEGER IS NULL OR ll.user_id = $2::INTEGER)
+GROUP BY ll.user_id, u.username, ll.type
+ORDER BY u.username, ll.user_id, ll.type
+`
+
+type SummarizeLeaveLogsParams struct {
+	Year   int32       `json:"year"`
+	UserID pgtype.Int4 `json:"userId"`
+}
+
+type SummarizeLeaveLogsRow struct {
+	UserID   int32  `json:"userId"`
+	Username string `json:"username"`
+	Type     string `json:"type"`
+	DayCount int64  `json:"dayCount"`
+}
+
+// Leave days per user and type in a year, for everyone or one user when given
+func (q *Queries) SummarizeLeaveLogs(ctx context.Context, arg SummarizeLeaveLogsParams) ([]SummarizeLeaveLogsRow, error) {
+	rows, err := q.db.Query(ctx, summarizeLeaveLogs, arg.Year, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummarizeLeaveLogsRow{}
+	for rows.Next() {
+		var i SummarizeLeaveLogsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Type,
+			&i.DayCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const summarizeLeaveLogsByDateRange = `-- name: SummarizeLeaveLogsByDateRange :many
+SELECT ll.user_id, u.username, u.department, ll.type, COUNT(*) AS day_count
+FROM leave_logs ll
+JOIN users u ON u.id = ll.user_id
+WHERE ll.deleted_at IS NULL
+  AND ll.date BETWEEN $1::DATE AND $2::DATE
+  AND ($3::INTEGER IS NULL OR ll.user_id = $3::INTEGER)
+  AND ($4::TEXT IS NULL OR u.department IN (SELECT department_and_below($4::TEXT)))
+GROUP BY ll.user_id, u.username, u.department, ll.type
+ORDER BY u.username, ll.user_id, ll.type
+`
+
+type SummarizeLeaveLogsByDateRangeParams struct {
+	StartDate  pgtype.Date `json:"startDate"`
+	EndDate    pgtype.Date `json:"endDate"`
+	UserID     pgtype.Int4 `json:"userId"`
+	Department pgtype.Text `json:"department"`
+}
+
+type SummarizeLeaveLogsByDateRangeRow struct {
+	UserID     int32       `json:"userId"`
+	Username   string      `json:"username"`
+	Department pgtype.Text `json:"department"`
+	Type       string      `json:"type"`
+	DayCount   int64       `json:"dayCount"`
+}
+
+// Leave days per user and type in a date range, for everyone, one user or one department
+func (q *Queries) SummarizeLeaveLogsByDateRange(ctx context.Context, arg SummarizeLeaveLogsByDateRangeParams) ([]SummarizeLeaveLogsByDateRangeRow, error) {
+	rows, err := q.db.Query(ctx, summarizeLeaveLogsByDateRange,
+		arg.StartDate,
+		arg.EndDate,
+		arg.UserID,
+		arg.Department,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummarizeLeaveLogsByDateRangeRow{}
+	for rows.Next() {
+		var i SummarizeLeaveLogsByDateRangeRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Department,
+			&i.Type,
+			&i.DayCount,
 		); err != nil {
 			return nil, err
 		}
@@ -245,26 +637,31 @@ func (q *Queries) ListLeaveLogsByYear(ctx context.Context, arg ListLeaveLogsByYe
 const updateLeaveLog = `-- name: UpdateLeaveLog :one
 UPDATE leave_logs
 SET 
-  type = $2,
-  date = $3,
-  note = $4
-WHERE id = $1
-RETURNING id, user_id, type, date, note, created_at
+  type = $1,
+  date = $2,
+  note = $3,
+  updated_at = NOW()
+WHERE id = $4 AND deleted_at IS NULL
+  AND ($5::TIMESTAMPTZ IS NULL OR updated_at = $5::TIMESTAMPTZ)
+RETURNING id, user_id, type, date, note, created_at, updated_at, deleted_at, tenant_id
 `
 
 type UpdateLeaveLogParams struct {
-	ID   int32       `json:"id"`
-	Type string      `json:"type"`
-	Date pgtype.Date `json:"date"`
-	Note pgtype.Text `json:"note"`
+	Type              string             `json:"type"`
+	Date              pgtype.Date        `json:"date"`
+	Note              pgtype.Text        `json:"note"`
+	ID                int32              `json:"id"`
+	ExpectedUpdatedAt pgtype.Timestamptz `json:"expectedUpdatedAt"`
 }
 
+// Matches no row when expected_updated_at is set and the leave log changed since, so concurrent edits conflict
 func (q *Queries) UpdateLeaveLog(ctx context.Context, arg UpdateLeaveLogParams) (LeaveLog, error) {
 	row := q.db.QueryRow(ctx, updateLeaveLog,
-		arg.ID,
 		arg.Type,
 		arg.Date,
 		arg.Note,
+		arg.ID,
+		arg.ExpectedUpdatedAt,
 	)
 	var i LeaveLog
 	err := row.Scan(
@@ -274,6 +671,9 @@ func (q *Queries) UpdateLeaveLog(ctx context.Context, arg UpdateLeaveLogParams)
 		&i.Date,
 		&i.Note,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -11,6 +11,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countMedicalExpensesByUser = `-- name: CountMedicalExpensesByUser :one
+SELECT COUNT(*) FROM medical_expenses
+WHERE user_id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) CountMedicalExpensesByUser(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countMedicalExpensesByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createMedicalExpense = `-- name: CreateMedicalExpense :one
 INSERT INTO medical_expenses (
   user_id,
@@ -20,7 +32,7 @@ INSERT INTO medical_expenses (
   note
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, user_id, amount, receipt_name, receipt_date, note, created_at
+) RETURNING id, user_id, amount, receipt_name, receipt_date, note, created_at, deleted_at, tenant_id
 `
 
 type CreateMedicalExpenseParams struct {
@@ -48,23 +60,27 @@ func (q *Queries) CreateMedicalExpense(ctx context.Context, arg CreateMedicalExp
 		&i.ReceiptDate,
 		&i.Note,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const deleteMedicalExpense = `-- name: DeleteMedicalExpense :exec
-DELETE FROM medical_expenses
-WHERE id = $1
+UPDATE medical_expenses
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
+// Soft deletes the medical expense, the row stays until PurgeMedicalExpense removes it
 func (q *Queries) DeleteMedicalExpense(ctx context.Context, id int32) error {
 	_, err := q.db.Exec(ctx, deleteMedicalExpense, id)
 	return err
 }
 
 const getMedicalExpense = `-- name: GetMedicalExpense :one
-SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at FROM medical_expenses
-WHERE id = $1 LIMIT 1
+SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at, deleted_at, tenant_id FROM medical_expenses
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetMedicalExpense(ctx context.Context, id int32) (MedicalExpense, error) {
@@ -78,13 +94,79 @@ func (q *Queries) GetMedicalExpense(ctx context.Context, id int32) (MedicalExpen
 		&i.ReceiptDate,
 		&i.Note,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const listMedicalExpensesByDateRange = `-- name: ListMedicalExpensesByDateRange :many
+SELECT me.id, me.receipt_date, me.user_id, u.username, u.department, me.receipt_name, me.amount, me.note
+FROM medical_expenses me
+JOIN users u ON u.id = me.user_id
+WHERE me.deleted_at IS NULL
+  AND me.receipt_date BETWEEN $1::DATE AND $2::DATE
+  AND ($3::INTEGER IS NULL OR me.user_id = $3::INTEGER)
+  AND ($4::TEXT IS NULL OR u.department IN (SELECT department_and_below($4::TEXT)))
+ORDER BY u.username, me.user_id, me.receipt_date, me.id
+`
+
+type ListMedicalExpensesByDateRangeParams struct {
+	StartDate  pgtype.Date `json:"startDate"`
+	EndDate    pgtype.Date `json:"endDate"`
+	UserID     pgtype.Int4 `json:"userId"`
+	Department pgtype.Text `json:"department"`
+}
+
+type ListMedicalExpensesByDateRangeRow struct {
+	ID          int32          `json:"id"`
+	ReceiptDate pgtype.Date    `json:"receiptDate"`
+	UserID      int32          `json:"userId"`
+	Username    string         `json:"username"`
+	Department  pgtype.Text    `json:"department"`
+	ReceiptName pgtype.Text    `json:"receiptName"`
+	Amount      pgtype.Numeric `json:"amount"`
+	Note        pgtype.Text    `json:"note"`
+}
+
+// Medical expenses with a receipt date in a date range and who claimed them, for everyone, one user or one department
+func (q *Queries) ListMedicalExpensesByDateRange(ctx context.Context, arg ListMedicalExpensesByDateRangeParams) ([]ListMedicalExpensesByDateRangeRow, error) {
+	rows, err := q.db.Query(ctx, listMedicalExpensesByDateRange,
+		arg.StartDate,
+		arg.EndDate,
+		arg.UserID,
+		arg.Department,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMedicalExpensesByDateRangeRow{}
+	for rows.Next() {
+		var i ListMedicalExpensesByDateRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReceiptDate,
+			&i.UserID,
+			&i.Username,
+			&i.Department,
+			&i.ReceiptName,
+			&i.Amount,
+			&i.Note,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMedicalExpensesByUser = `-- name: ListMedicalExpensesByUser :many
-SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at FROM medical_expenses
-WHERE user_id = $1
+SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at, deleted_at, tenant_id FROM medical_expenses
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY receipt_date DESC
 LIMIT $2
 OFFSET $3
@@ -113,6 +195,8 @@ func (q *Queries) ListMedicalExpensesByUser(ctx context.Context, arg ListMedical
 			&i.ReceiptDate,
 			&i.Note,
 			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -125,18 +209,18 @@ func (q *Queries) ListMedicalExpensesByUser(ctx context.Context, arg ListMedical
 }
 
 const listMedicalExpensesByYear = `-- name: ListMedicalExpensesByYear :many
-SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at FROM medical_expenses
-WHERE user_id = $1 AND EXTRACT(YEAR FROM receipt_date) = $2
+SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at, deleted_at, tenant_id FROM medical_expenses
+WHERE user_id = $1 AND EXTRACT(YEAR FROM receipt_date) = $2::int AND deleted_at IS NULL
 ORDER BY receipt_date DESC
 `
 
 type ListMedicalExpensesByYearParams struct {
-	UserID      int32       `json:"userId"`
-	ReceiptDate pgtype.Date `json:"receiptDate"`
+	UserID int32 `json:"userId"`
+	Year   int32 `json:"year"`
 }
 
 func (q *Queries) ListMedicalExpensesByYear(ctx context.Context, arg ListMedicalExpensesByYearParams) ([]MedicalExpense, error) {
-	rows, err := q.db.Query(ctx, listMedicalExpensesByYear, arg.UserID, arg.ReceiptDate)
+	rows, err := q.db.Query(ctx, listMedicalExpensesByYear, arg.UserID, arg.Year)
 	if err != nil {
 		return nil, err
 	}
@@ -152,6 +236,8 @@ func (q *Queries) ListMedicalExpensesByYear(ctx context.Context, arg ListMedical
 			&i.ReceiptDate,
 			&i.Note,
 			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -163,6 +249,62 @@ func (q *Queries) ListMedicalExpensesByYear(ctx context.Context, arg ListMedical
 	return items, nil
 }
 
+const listPurgeableMedicalExpenseIDs = `-- name: ListPurgeableMedicalExpenseIDs :many
+SELECT id FROM medical_expenses
+WHERE deleted_at IS NOT NULL AND deleted_at < $1::TIMESTAMPTZ
+ORDER BY deleted_at
+`
+
+// Medical expenses deleted before the cutoff, oldest deletion first
+func (q *Queries) ListPurgeableMedicalExpenseIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listPurgeableMedicalExpenseIDs, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeMedicalExpense = `-- name: PurgeMedicalExpense :execrows
+DELETE FROM medical_expenses
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Removes a soft deleted medical expense for good, live ones are never touched
+func (q *Queries) PurgeMedicalExpense(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeMedicalExpense, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreMedicalExpense = `-- name: RestoreMedicalExpense :execrows
+UPDATE medical_expenses
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Undoes DeleteMedicalExpense
+func (q *Queries) RestoreMedicalExpense(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreMedicalExpense, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateMedicalExpense = `-- name: UpdateMedicalExpense :one
 UPDATE medical_expenses
 SET 
@@ -170,8 +312,8 @@ SET
   receipt_name = $3,
   receipt_date = $4,
   note = $5
-WHERE id = $1
-RETURNING id, user_id, amount, receipt_name, receipt_date, note, created_at
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, user_id, amount, receipt_name, receipt_date, note, created_at, deleted_at, tenant_id
 `
 
 type UpdateMedicalExpenseParams struct {
@@ -199,6 +341,8 @@ func (q *Queries) UpdateMedicalExpense(ctx context.Context, arg UpdateMedicalExp
 		&i.ReceiptDate,
 		&i.Note,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
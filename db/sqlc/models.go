@@ -8,6 +8,29 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Announcement struct {
+	ID                     int32              `json:"id"`
+	Title                  string             `json:"title"`
+	Body                   string             `json:"body"`
+	UserTypes              []string           `json:"userTypes"`
+	Departments            []string           `json:"departments"`
+	UserIds                []int32            `json:"userIds"`
+	RequiresAcknowledgment bool               `json:"requiresAcknowledgment"`
+	PublishAt              pgtype.Timestamptz `json:"publishAt"`
+	ExpireAt               pgtype.Timestamptz `json:"expireAt"`
+	CreatedBy              pgtype.Int4        `json:"createdBy"`
+	CreatedAt              pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt              pgtype.Timestamptz `json:"updatedAt"`
+	TenantID               int32              `json:"tenantId"`
+}
+
+type AnnouncementAcknowledgment struct {
+	AnnouncementID int32              `json:"announcementId"`
+	UserID         int32              `json:"userId"`
+	AcknowledgedAt pgtype.Timestamptz `json:"acknowledgedAt"`
+	TenantID       int32              `json:"tenantId"`
+}
+
 type AnnualRecord struct {
 	ID                     int32              `json:"id"`
 	UserID                 int32              `json:"userId"`
@@ -21,6 +44,197 @@ type AnnualRecord struct {
 	UsedMedicalExpenseBaht pgtype.Numeric     `json:"usedMedicalExpenseBaht"`
 	CreatedAt              pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt              pgtype.Timestamptz `json:"updatedAt"`
+	TenantID               int32              `json:"tenantId"`
+}
+
+type Anomaly struct {
+	ID          int32              `json:"id"`
+	Rule        string             `json:"rule"`
+	UserID      int32              `json:"userId"`
+	PeriodStart pgtype.Date        `json:"periodStart"`
+	PeriodEnd   pgtype.Date        `json:"periodEnd"`
+	Fingerprint string             `json:"fingerprint"`
+	Summary     string             `json:"summary"`
+	Details     []byte             `json:"details"`
+	Status      string             `json:"status"`
+	ReviewedBy  pgtype.Int4        `json:"reviewedBy"`
+	ReviewedAt  pgtype.Timestamptz `json:"reviewedAt"`
+	ReviewNote  pgtype.Text        `json:"reviewNote"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	TenantID    int32              `json:"tenantId"`
+}
+
+type Anonymization struct {
+	UserID       int32              `json:"userId"`
+	AnonymizedBy pgtype.Int4        `json:"anonymizedBy"`
+	AnonymizedAt pgtype.Timestamptz `json:"anonymizedAt"`
+	TenantID     int32              `json:"tenantId"`
+}
+
+type ApiToken struct {
+	ID              int32              `json:"id"`
+	Name            string             `json:"name"`
+	TokenHash       string             `json:"tokenHash"`
+	Scopes          []string           `json:"scopes"`
+	CreatedByUserID pgtype.Int4        `json:"createdByUserId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	LastUsedAt      pgtype.Timestamptz `json:"lastUsedAt"`
+	RevokedAt       pgtype.Timestamptz `json:"revokedAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type ApprovalDecision struct {
+	ID           int32              `json:"id"`
+	RequestID    int32              `json:"requestId"`
+	Step         int32              `json:"step"`
+	ApproverID   pgtype.Int4        `json:"approverId"`
+	OnBehalfOfID pgtype.Int4        `json:"onBehalfOfId"`
+	Decision     string             `json:"decision"`
+	Comment      pgtype.Text        `json:"comment"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	TenantID     int32              `json:"tenantId"`
+	DelegationID pgtype.Int4        `json:"delegationId"`
+}
+
+type ApprovalDelegation struct {
+	ID          int32              `json:"id"`
+	DelegatorID int32              `json:"delegatorId"`
+	DelegateID  int32              `json:"delegateId"`
+	StartDate   pgtype.Date        `json:"startDate"`
+	EndDate     pgtype.Date        `json:"endDate"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	TenantID    int32              `json:"tenantId"`
+	CreatedByID pgtype.Int4        `json:"createdById"`
+	RevokedAt   pgtype.Timestamptz `json:"revokedAt"`
+	RevokedByID pgtype.Int4        `json:"revokedById"`
+}
+
+type ApprovalPolicy struct {
+	ID             int32              `json:"id"`
+	Kind           string             `json:"kind"`
+	Steps          []string           `json:"steps"`
+	AutoApproveMax pgtype.Numeric     `json:"autoApproveMax"`
+	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
+	TenantID       int32              `json:"tenantId"`
+}
+
+type ApprovalRequest struct {
+	ID          int32              `json:"id"`
+	Kind        string             `json:"kind"`
+	SubjectID   int32              `json:"subjectId"`
+	RequesterID int32              `json:"requesterId"`
+	Amount      pgtype.Numeric     `json:"amount"`
+	Payload     []byte             `json:"payload"`
+	Steps       []string           `json:"steps"`
+	CurrentStep int32              `json:"currentStep"`
+	Status      string             `json:"status"`
+	DecidedAt   pgtype.Timestamptz `json:"decidedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	TenantID    int32              `json:"tenantId"`
+}
+
+type AttendanceSession struct {
+	ID                int32              `json:"id"`
+	UserID            int32              `json:"userId"`
+	WorkDate          pgtype.Date        `json:"workDate"`
+	CheckInAt         pgtype.Timestamptz `json:"checkInAt"`
+	CheckInIp         pgtype.Text        `json:"checkInIp"`
+	CheckInLatitude   pgtype.Float8      `json:"checkInLatitude"`
+	CheckInLongitude  pgtype.Float8      `json:"checkInLongitude"`
+	CheckOutAt        pgtype.Timestamptz `json:"checkOutAt"`
+	CheckOutIp        pgtype.Text        `json:"checkOutIp"`
+	CheckOutLatitude  pgtype.Float8      `json:"checkOutLatitude"`
+	CheckOutLongitude pgtype.Float8      `json:"checkOutLongitude"`
+	Note              pgtype.Text        `json:"note"`
+	TenantID          int32              `json:"tenantId"`
+}
+
+type BalanceEvent struct {
+	ID                 int64              `json:"id"`
+	UserID             int32              `json:"userId"`
+	Year               int32              `json:"year"`
+	Source             string             `json:"source"`
+	SourceID           int32              `json:"sourceId"`
+	Action             string             `json:"action"`
+	VacationDay        pgtype.Numeric     `json:"vacationDay"`
+	SickLeaveDay       pgtype.Numeric     `json:"sickLeaveDay"`
+	WorkedDay          pgtype.Numeric     `json:"workedDay"`
+	WorkedOnHolidayDay pgtype.Numeric     `json:"workedOnHolidayDay"`
+	MedicalExpenseBaht pgtype.Numeric     `json:"medicalExpenseBaht"`
+	RecordedByUserID   pgtype.Int4        `json:"recordedByUserId"`
+	RecordedAt         pgtype.Timestamptz `json:"recordedAt"`
+	TenantID           int32              `json:"tenantId"`
+}
+
+type ClickupToken struct {
+	UserID      int32              `json:"userId"`
+	AccessToken string             `json:"accessToken"`
+	TokenType   string             `json:"tokenType"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	TeamID      string             `json:"teamId"`
+	TenantID    int32              `json:"tenantId"`
+}
+
+type ClickupWorkspace struct {
+	TeamID            string             `json:"teamId"`
+	Name              string             `json:"name"`
+	ConnectedByUserID pgtype.Int4        `json:"connectedByUserId"`
+	CreatedAt         pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt         pgtype.Timestamptz `json:"updatedAt"`
+	TenantID          int32              `json:"tenantId"`
+}
+
+type Department struct {
+	ID         int32              `json:"id"`
+	Name       string             `json:"name"`
+	ParentID   pgtype.Int4        `json:"parentId"`
+	HeadUserID pgtype.Int4        `json:"headUserId"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt  pgtype.Timestamptz `json:"updatedAt"`
+	TenantID   int32              `json:"tenantId"`
+}
+
+type EstimationSession struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	Status          string             `json:"status"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	ClosedAt        pgtype.Timestamptz `json:"closedAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type EstimationSessionParticipant struct {
+	SessionID int32 `json:"sessionId"`
+	UserID    int32 `json:"userId"`
+	TenantID  int32 `json:"tenantId"`
+}
+
+type FeatureFlag struct {
+	Key         string             `json:"key"`
+	Description string             `json:"description"`
+	Enabled     bool               `json:"enabled"`
+	UserTypes   []string           `json:"userTypes"`
+	Departments []string           `json:"departments"`
+	UserIds     []int32            `json:"userIds"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type File struct {
+	ID          int32              `json:"id"`
+	OwnerID     pgtype.Int4        `json:"ownerId"`
+	Purpose     string             `json:"purpose"`
+	SubjectID   int32              `json:"subjectId"`
+	StorageKey  string             `json:"storageKey"`
+	FileName    string             `json:"fileName"`
+	ContentType string             `json:"contentType"`
+	Size        int64              `json:"size"`
+	Sha256      string             `json:"sha256"`
+	ScannedAt   pgtype.Timestamptz `json:"scannedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	TenantID    int32              `json:"tenantId"`
 }
 
 type Holiday struct {
@@ -29,6 +243,27 @@ type Holiday struct {
 	Name      string             `json:"name"`
 	Note      pgtype.Text        `json:"note"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type IdempotencyKey struct {
+	ID           int32              `json:"id"`
+	UserID       int32              `json:"userId"`
+	Key          string             `json:"key"`
+	RequestHash  string             `json:"requestHash"`
+	StatusCode   pgtype.Int4        `json:"statusCode"`
+	ContentType  pgtype.Text        `json:"contentType"`
+	ResponseBody []byte             `json:"responseBody"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	TenantID     int32              `json:"tenantId"`
+}
+
+type LanguagePreference struct {
+	UserID    int32              `json:"userId"`
+	Language  string             `json:"language"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
 }
 
 type LeaveLog struct {
@@ -38,6 +273,9 @@ type LeaveLog struct {
 	Date      pgtype.Date        `json:"date"`
 	Note      pgtype.Text        `json:"note"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	DeletedAt pgtype.Timestamptz `json:"deletedAt"`
+	TenantID  int32              `json:"tenantId"`
 }
 
 type MedicalExpense struct {
@@ -48,6 +286,102 @@ type MedicalExpense struct {
 	ReceiptDate pgtype.Date        `json:"receiptDate"`
 	Note        pgtype.Text        `json:"note"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	DeletedAt   pgtype.Timestamptz `json:"deletedAt"`
+	TenantID    int32              `json:"tenantId"`
+}
+
+type LineLink struct {
+	UserID            int32              `json:"userId"`
+	LineUserID        pgtype.Text        `json:"lineUserId"`
+	LinkCode          pgtype.Text        `json:"linkCode"`
+	LinkCodeExpiresAt pgtype.Timestamptz `json:"linkCodeExpiresAt"`
+	LinkedAt          pgtype.Timestamptz `json:"linkedAt"`
+	CreatedAt         pgtype.Timestamptz `json:"createdAt"`
+	TenantID          int32              `json:"tenantId"`
+}
+
+type Notification struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"userId"`
+	Kind      string             `json:"kind"`
+	Recipient string             `json:"recipient"`
+	Subject   string             `json:"subject"`
+	Body      string             `json:"body"`
+	Status    string             `json:"status"`
+	LastError pgtype.Text        `json:"lastError"`
+	SentAt    pgtype.Timestamptz `json:"sentAt"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	Channel   string             `json:"channel"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type NotificationPreference struct {
+	UserID    int32              `json:"userId"`
+	Kind      string             `json:"kind"`
+	Email     bool               `json:"email"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	Line      bool               `json:"line"`
+	TenantID  int32              `json:"tenantId"`
+	Push      bool               `json:"push"`
+}
+
+type OrgAssignment struct {
+	UserID     int32              `json:"userId"`
+	PositionID pgtype.Int4        `json:"positionId"`
+	ManagerID  pgtype.Int4        `json:"managerId"`
+	UpdatedAt  pgtype.Timestamptz `json:"updatedAt"`
+	TenantID   int32              `json:"tenantId"`
+}
+
+type PayrollPeriod struct {
+	ID              int32              `json:"id"`
+	Period          string             `json:"period"`
+	StartDate       pgtype.Date        `json:"startDate"`
+	EndDate         pgtype.Date        `json:"endDate"`
+	Status          string             `json:"status"`
+	FileName        pgtype.Text        `json:"fileName"`
+	Content         []byte             `json:"content"`
+	LockedByUserID  pgtype.Int4        `json:"lockedByUserId"`
+	LockedAt        pgtype.Timestamptz `json:"lockedAt"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type Position struct {
+	ID           int32              `json:"id"`
+	Title        string             `json:"title"`
+	DepartmentID pgtype.Int4        `json:"departmentId"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
+	TenantID     int32              `json:"tenantId"`
+}
+
+type PushDevice struct {
+	ID         int32              `json:"id"`
+	UserID     int32              `json:"userId"`
+	Token      string             `json:"token"`
+	Platform   string             `json:"platform"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+	LastSeenAt pgtype.Timestamptz `json:"lastSeenAt"`
+	TenantID   int32              `json:"tenantId"`
+}
+
+type QueuedJob struct {
+	ID          int32              `json:"id"`
+	Kind        string             `json:"kind"`
+	Payload     []byte             `json:"payload"`
+	Status      string             `json:"status"`
+	Attempts    int32              `json:"attempts"`
+	MaxAttempts int32              `json:"maxAttempts"`
+	RunAt       pgtype.Timestamptz `json:"runAt"`
+	LastError   pgtype.Text        `json:"lastError"`
+	LockedBy    pgtype.Text        `json:"lockedBy"`
+	LockedAt    pgtype.Timestamptz `json:"lockedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	RequestID   pgtype.Text        `json:"requestId"`
+	TenantID    int32              `json:"tenantId"`
 }
 
 type QuotaPlan struct {
@@ -59,20 +393,112 @@ type QuotaPlan struct {
 	CreatedByUserID         pgtype.Int4        `json:"createdByUserId"`
 	CreatedAt               pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt               pgtype.Timestamptz `json:"updatedAt"`
+	TenantID                int32              `json:"tenantId"`
 }
 
-type Task struct {
-	ID             int32              `json:"id"`
-	Url            pgtype.Text        `json:"url"`
-	TaskCategoryID pgtype.Int4        `json:"taskCategoryId"`
-	Note           pgtype.Text        `json:"note"`
-	Title          pgtype.Text        `json:"title"`
-	Status         pgtype.Text        `json:"status"`
-	StatusColor    pgtype.Text        `json:"statusColor"`
-	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+type ReportExport struct {
+	ID              int32              `json:"id"`
+	ReportName      string             `json:"reportName"`
+	Format          string             `json:"format"`
+	FromDate        pgtype.Date        `json:"fromDate"`
+	ToDate          pgtype.Date        `json:"toDate"`
+	UserID          pgtype.Int4        `json:"userId"`
+	Department      pgtype.Text        `json:"department"`
+	Status          string             `json:"status"`
+	FileName        pgtype.Text        `json:"fileName"`
+	Content         []byte             `json:"content"`
+	Error           pgtype.Text        `json:"error"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	CompletedAt     pgtype.Timestamptz `json:"completedAt"`
+	Language        string             `json:"language"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type RuntimeSetting struct {
+	Key       string             `json:"key"`
+	Value     string             `json:"value"`
+	UpdatedBy pgtype.Int4        `json:"updatedBy"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ScheduledJob struct {
+	Name           string             `json:"name"`
+	Schedule       string             `json:"schedule"`
+	Paused         bool               `json:"paused"`
+	NextRunAt      pgtype.Timestamptz `json:"nextRunAt"`
+	LastStartedAt  pgtype.Timestamptz `json:"lastStartedAt"`
+	LastFinishedAt pgtype.Timestamptz `json:"lastFinishedAt"`
+	LastError      pgtype.Text        `json:"lastError"`
+	LastRunBy      pgtype.Text        `json:"lastRunBy"`
 	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
 }
 
+type SlackWorkspace struct {
+	TeamID            string             `json:"teamId"`
+	Name              string             `json:"name"`
+	BotToken          string             `json:"botToken"`
+	ApprovalsChannel  string             `json:"approvalsChannel"`
+	OutTodayChannel   string             `json:"outTodayChannel"`
+	OutTodayHour      int16              `json:"outTodayHour"`
+	OutTodayPostedOn  pgtype.Date        `json:"outTodayPostedOn"`
+	NotifyUsers       bool               `json:"notifyUsers"`
+	ConnectedByUserID pgtype.Int4        `json:"connectedByUserId"`
+	CreatedAt         pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt         pgtype.Timestamptz `json:"updatedAt"`
+	TenantID          int32              `json:"tenantId"`
+}
+
+type Tag struct {
+	ID        int32              `json:"id"`
+	Name      string             `json:"name"`
+	Color     pgtype.Text        `json:"color"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type Task struct {
+	ID              int32              `json:"id"`
+	Url             pgtype.Text        `json:"url"`
+	TaskCategoryID  pgtype.Int4        `json:"taskCategoryId"`
+	Note            pgtype.Text        `json:"note"`
+	Title           pgtype.Text        `json:"title"`
+	Status          pgtype.Text        `json:"status"`
+	StatusColor     pgtype.Text        `json:"statusColor"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	ClickupSyncedAt pgtype.Timestamptz `json:"clickupSyncedAt"`
+	DueDate         pgtype.Date        `json:"dueDate"`
+	Priority        pgtype.Int4        `json:"priority"`
+	ParentTaskID    pgtype.Int4        `json:"parentTaskId"`
+	CreatedByUserID pgtype.Int4        `json:"createdByUserId"`
+	ClickupTeamID   pgtype.Text        `json:"clickupTeamId"`
+	DeletedAt       pgtype.Timestamptz `json:"deletedAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type TaskActivity struct {
+	ID           int32              `json:"id"`
+	TaskID       int32              `json:"taskId"`
+	UserID       pgtype.Int4        `json:"userId"`
+	ActivityType string             `json:"activityType"`
+	OldValue     pgtype.Text        `json:"oldValue"`
+	NewValue     pgtype.Text        `json:"newValue"`
+	ReferenceID  pgtype.Int4        `json:"referenceId"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	RequestID    pgtype.Text        `json:"requestId"`
+	TenantID     int32              `json:"tenantId"`
+}
+
+type TaskAssignee struct {
+	TaskID           int32              `json:"taskId"`
+	UserID           int32              `json:"userId"`
+	AssignedByUserID pgtype.Int4        `json:"assignedByUserId"`
+	AssignedAt       pgtype.Timestamptz `json:"assignedAt"`
+	TenantID         int32              `json:"tenantId"`
+}
+
 type TaskCategory struct {
 	ID          int32              `json:"id"`
 	Name        string             `json:"name"`
@@ -80,6 +506,29 @@ type TaskCategory struct {
 	Description pgtype.Text        `json:"description"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	ArchivedAt  pgtype.Timestamptz `json:"archivedAt"`
+	BudgetDay   pgtype.Numeric     `json:"budgetDay"`
+	Department  pgtype.Text        `json:"department"`
+	SortOrder   int32              `json:"sortOrder"`
+	TenantID    int32              `json:"tenantId"`
+}
+
+type TaskComment struct {
+	ID        int32              `json:"id"`
+	TaskID    int32              `json:"taskId"`
+	UserID    int32              `json:"userId"`
+	Body      string             `json:"body"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type TaskCustomField struct {
+	TaskID    int32              `json:"taskId"`
+	FieldKey  string             `json:"fieldKey"`
+	Value     string             `json:"value"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
 }
 
 type TaskEstimate struct {
@@ -89,6 +538,15 @@ type TaskEstimate struct {
 	Note            pgtype.Text        `json:"note"`
 	CreatedByUserID int32              `json:"createdByUserId"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	IsCurrent       bool               `json:"isCurrent"`
+	SupersedesID    pgtype.Int4        `json:"supersedesId"`
+	SupersededAt    pgtype.Timestamptz `json:"supersededAt"`
+	SessionID       pgtype.Int4        `json:"sessionId"`
+	IsVote          bool               `json:"isVote"`
+	Unit            string             `json:"unit"`
+	EstimateValue   pgtype.Numeric     `json:"estimateValue"`
+	Confidence      pgtype.Text        `json:"confidence"`
+	TenantID        int32              `json:"tenantId"`
 }
 
 type TaskLog struct {
@@ -99,14 +557,79 @@ type TaskLog struct {
 	WorkedDate      pgtype.Date        `json:"workedDate"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
 	IsWorkOnHoliday pgtype.Bool        `json:"isWorkOnHoliday"`
+	TenantID        int32              `json:"tenantId"`
 }
 
-type User struct {
+type TaskSyncHistory struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	Direction       string             `json:"direction"`
+	Status          string             `json:"status"`
+	ChangedFields   pgtype.Text        `json:"changedFields"`
+	Message         pgtype.Text        `json:"message"`
+	RemoteUpdatedAt pgtype.Timestamptz `json:"remoteUpdatedAt"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type TaskTag struct {
+	TaskID    int32              `json:"taskId"`
+	TagID     int32              `json:"tagId"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type Tenant struct {
 	ID        int32              `json:"id"`
-	Username  string             `json:"username"`
-	Password  string             `json:"password"`
-	UserType  string             `json:"userType"`
-	Email     string             `json:"email"`
+	Slug      string             `json:"slug"`
+	Name      string             `json:"name"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type TimeZonePreference struct {
+	UserID    int32              `json:"userId"`
+	TimeZone  string             `json:"timeZone"`
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  int32              `json:"tenantId"`
+}
+
+type User struct {
+	ID            int32              `json:"id"`
+	Username      string             `json:"username"`
+	Password      string             `json:"password"`
+	UserType      string             `json:"userType"`
+	Email         string             `json:"email"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+	DailyCapacity pgtype.Numeric     `json:"dailyCapacity"`
+	Department    pgtype.Text        `json:"department"`
+	DeletedAt     pgtype.Timestamptz `json:"deletedAt"`
+	TenantID      int32              `json:"tenantId"`
+}
+
+type Webhook struct {
+	ID              int32              `json:"id"`
+	Url             string             `json:"url"`
+	Secret          string             `json:"secret"`
+	Events          []string           `json:"events"`
+	Description     string             `json:"description"`
+	Active          bool               `json:"active"`
+	CreatedByUserID pgtype.Int4        `json:"createdByUserId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	TenantID        int32              `json:"tenantId"`
+}
+
+type WebhookDelivery struct {
+	ID             int32              `json:"id"`
+	WebhookID      int32              `json:"webhookId"`
+	Event          string             `json:"event"`
+	Payload        []byte             `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	ResponseStatus pgtype.Int4        `json:"responseStatus"`
+	LastError      pgtype.Text        `json:"lastError"`
+	DeliveredAt    pgtype.Timestamptz `json:"deliveredAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+	TenantID       int32              `json:"tenantId"`
 }
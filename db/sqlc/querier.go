@@ -11,86 +11,551 @@ import (
 )
 
 type Querier interface {
+	// Records that a user read a notice, keeping the time of the first acknowledgment
+	AcknowledgeAnnouncement(ctx context.Context, arg AcknowledgeAnnouncementParams) (AnnouncementAcknowledgment, error)
+	AddEstimationSessionParticipant(ctx context.Context, arg AddEstimationSessionParticipantParams) error
+	AddTaskTag(ctx context.Context, arg AddTaskTagParams) error
+	// Records the difference between the totals of an annual record and what its balance events add
+	// up to, so the record keeps the totals an admin set when it is next synced. Nothing is recorded
+	// when they agree.
+	AdjustBalance(ctx context.Context, arg AdjustBalanceParams) error
+	// Moves a request on from the step it waits on, failing with no rows when someone else decided
+	// that step first
+	AdvanceApprovalRequest(ctx context.Context, arg AdvanceApprovalRequestParams) (ApprovalRequest, error)
+	// Replaces the name, email and password of a deleted user, keeping their type, department and
+	// capacity for statistics, and records who did it. Live users are never touched.
+	AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (Anonymization, error)
+	ApplyClickUpTaskChanges(ctx context.Context, arg ApplyClickUpTaskChangesParams) (Task, error)
+	ArchiveTaskCategorySubtree(ctx context.Context, categoryID int32) (int64, error)
 	// Update existing records
 	AssignQuotaPlanToAllUsers(ctx context.Context, arg AssignQuotaPlanToAllUsersParams) error
+	AssignTask(ctx context.Context, arg AssignTaskParams) error
+	// Cancels what waits on a leave log, medical expense, task log or quota plan that went away
+	CancelApprovalRequests(ctx context.Context, arg CancelApprovalRequestsParams) error
+	// Records that a request with the key is running. Returns no row while the key belongs to a
+	// request made since expired_before, older ones are taken over.
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (IdempotencyKey, error)
+	// Takes up to row_limit jobs for a worker: pending ones that are due, and running ones locked
+	// before stale_before, whose server stopped mid-run. SKIP LOCKED lets several servers claim at
+	// once without taking the same job.
+	ClaimQueuedJobs(ctx context.Context, arg ClaimQueuedJobsParams) ([]QueuedJob, error)
+	// Starts a due run, moving the next run on. Returns no row when the job is paused or not due,
+	// for instance because another instance claimed it first.
+	ClaimScheduledJob(ctx context.Context, arg ClaimScheduledJobParams) (ScheduledJob, error)
+	// Clears the IPs and coordinates of check-ins before the cutoff, keeping their times
+	ClearAttendanceLocations(ctx context.Context, checkedInBefore pgtype.Timestamptz) (int64, error)
+	// Checks a user out of their open session, no rows when they aren't checked in
+	CloseAttendanceSession(ctx context.Context, arg CloseAttendanceSessionParams) (AttendanceSession, error)
+	CloseEstimationSession(ctx context.Context, id int32) (EstimationSession, error)
+	// Stores the response replayed to retries with the key
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	CompleteQueuedJob(ctx context.Context, id int32) error
+	// Stores the file an export job rendered
+	CompleteReportExport(ctx context.Context, arg CompleteReportExportParams) error
+	CountAnnouncements(ctx context.Context) (int64, error)
+	CountAnomalies(ctx context.Context, arg CountAnomaliesParams) (int64, error)
+	// The live users in a department, not counting those of the departments under it
+	CountDepartmentMembers(ctx context.Context, department string) (int64, error)
+	CountHolidays(ctx context.Context) (int64, error)
+	// Counts the leave logs ListLeaveLogsWithUsername and ListLeaveLogsAfter page through
+	CountLeaveLogs(ctx context.Context, arg CountLeaveLogsParams) (int64, error)
+	// Counts a user's leave on one date, used to check the daily capacity
+	CountLeaveLogsForDate(ctx context.Context, arg CountLeaveLogsForDateParams) (int64, error)
+	CountMedicalExpensesByUser(ctx context.Context, userID int32) (int64, error)
+	CountQueuedJobs(ctx context.Context, status string) (int64, error)
+	// Counts the tasks SearchTasks pages through
+	CountSearchTasks(ctx context.Context, arg CountSearchTasksParams) (int64, error)
+	CountSubDepartments(ctx context.Context, parentID pgtype.Int4) (int64, error)
+	CountTaskActivities(ctx context.Context, taskID int32) (int64, error)
+	// Counts the categories ListTaskCategories pages through
+	CountTaskCategories(ctx context.Context, arg CountTaskCategoriesParams) (int64, error)
+	CountTaskCategoryUsage(ctx context.Context, categoryID int32) (CountTaskCategoryUsageRow, error)
+	CountTaskEstimatesByUser(ctx context.Context, createdByUserID int32) (int64, error)
+	// Counts the logs ListTaskLogsWithDetailsByUser and ListTaskLogsWithDetailsByUserAfter page through
+	CountTaskLogsByUser(ctx context.Context, arg CountTaskLogsByUserParams) (int64, error)
+	CountUsers(ctx context.Context) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, webhookID int32) (int64, error)
+	CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error)
+	CreateAnnouncement(ctx context.Context, arg CreateAnnouncementParams) (Announcement, error)
 	CreateAnnualRecord(ctx context.Context, arg CreateAnnualRecordParams) (AnnualRecord, error)
+	// Flags a finding, unless the same one was flagged before
+	CreateAnomaly(ctx context.Context, arg CreateAnomalyParams) (int64, error)
+	CreateApprovalDecision(ctx context.Context, arg CreateApprovalDecisionParams) (ApprovalDecision, error)
+	CreateApprovalDelegation(ctx context.Context, arg CreateApprovalDelegationParams) (ApprovalDelegation, error)
+	// Submits a request, decided at once when it is submitted as approved
+	CreateApprovalRequest(ctx context.Context, arg CreateApprovalRequestParams) (ApprovalRequest, error)
+	// Checks a user in, failing on idx_attendance_sessions_open when they already are
+	CreateAttendanceSession(ctx context.Context, arg CreateAttendanceSessionParams) (AttendanceSession, error)
+	CreateDepartment(ctx context.Context, arg CreateDepartmentParams) (Department, error)
+	CreateEstimationSession(ctx context.Context, arg CreateEstimationSessionParams) (EstimationSession, error)
+	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateHoliday(ctx context.Context, arg CreateHolidayParams) (Holiday, error)
+	// Inserts many holidays in one COPY, for imports
+	CreateHolidays(ctx context.Context, arg []CreateHolidaysParams) (int64, error)
 	CreateLeaveLog(ctx context.Context, arg CreateLeaveLogParams) (LeaveLog, error)
+	// Inserts many leave logs in one COPY, for imports
+	CreateLeaveLogs(ctx context.Context, arg []CreateLeaveLogsParams) (int64, error)
+	// A new code replaces the last one, the account linked before stays until the code is used
+	CreateLineLinkCode(ctx context.Context, arg CreateLineLinkCodeParams) (LineLink, error)
 	CreateMedicalExpense(ctx context.Context, arg CreateMedicalExpenseParams) (MedicalExpense, error)
 	CreateNextYearAnnualRecords(ctx context.Context, arg CreateNextYearAnnualRecordsParams) ([]AnnualRecord, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreatePayrollPeriod(ctx context.Context, arg CreatePayrollPeriodParams) (PayrollPeriod, error)
+	CreatePosition(ctx context.Context, arg CreatePositionParams) (Position, error)
 	CreateQuotaPlan(ctx context.Context, arg CreateQuotaPlanParams) (QuotaPlan, error)
+	CreateReportExport(ctx context.Context, arg CreateReportExportParams) (ReportExport, error)
+	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
 	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
+	CreateTaskActivity(ctx context.Context, arg CreateTaskActivityParams) error
 	CreateTaskCategory(ctx context.Context, arg CreateTaskCategoryParams) (TaskCategory, error)
+	CreateTaskComment(ctx context.Context, arg CreateTaskCommentParams) (TaskComment, error)
 	CreateTaskEstimate(ctx context.Context, arg CreateTaskEstimateParams) (TaskEstimate, error)
 	CreateTaskLog(ctx context.Context, arg CreateTaskLogParams) (TaskLog, error)
+	// Inserts many task logs in one COPY, for imports
+	CreateTaskLogs(ctx context.Context, arg []CreateTaskLogsParams) (int64, error)
+	CreateTaskSyncHistory(ctx context.Context, arg CreateTaskSyncHistoryParams) (TaskSyncHistory, error)
+	CreateTenant(ctx context.Context, arg CreateTenantParams) (Tenant, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteAnnouncement(ctx context.Context, id int32) (int64, error)
 	DeleteAnnualRecord(ctx context.Context, id int32) error
+	// Stops requests of the kind needing approval, leaving the ones already waiting as they are
+	DeleteApprovalPolicy(ctx context.Context, kind string) error
+	DeleteClickUpToken(ctx context.Context, userID int32) (int64, error)
+	DeleteDepartment(ctx context.Context, id int32) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteFeatureFlag(ctx context.Context, key string) (int64, error)
+	DeleteFile(ctx context.Context, id int32) error
+	DeleteFinishedQueuedJobs(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error)
 	DeleteHoliday(ctx context.Context, id int32) error
+	// Releases the key of a request that failed, so a retry runs it again
+	DeleteIdempotencyKey(ctx context.Context, id int32) error
+	// Soft deletes the leave log, the row stays until PurgeLeaveLog removes it
 	DeleteLeaveLog(ctx context.Context, id int32) error
+	DeleteLineLink(ctx context.Context, userID int32) (int64, error)
+	// Unlinks a LINE account from every user, such as when it blocks the bot
+	DeleteLineLinksByLineUserID(ctx context.Context, lineUserID pgtype.Text) (int64, error)
+	// Soft deletes the medical expense, the row stays until PurgeMedicalExpense removes it
 	DeleteMedicalExpense(ctx context.Context, id int32) error
+	// Deletes a position, the users holding it are left without one
+	DeletePosition(ctx context.Context, id int32) error
+	DeletePushDevice(ctx context.Context, arg DeletePushDeviceParams) (int64, error)
+	// Forgets a token FCM no longer delivers to, such as of an app that was uninstalled
+	DeletePushDeviceByToken(ctx context.Context, token string) (int64, error)
 	DeleteQuotaPlan(ctx context.Context, id int32) error
+	// Deletes the exports made before a time, done or not
+	DeleteReportExportsBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteRuntimeSetting(ctx context.Context, key string) (int64, error)
+	DeleteSlackWorkspace(ctx context.Context, teamID string) (int64, error)
+	DeleteTag(ctx context.Context, id int32) error
+	// Soft deletes the task, the row stays until PurgeTask removes it
 	DeleteTask(ctx context.Context, id int32) error
 	DeleteTaskCategory(ctx context.Context, id int32) error
+	DeleteTaskComment(ctx context.Context, id int32) error
+	DeleteTaskCustomField(ctx context.Context, arg DeleteTaskCustomFieldParams) error
 	DeleteTaskEstimate(ctx context.Context, id int32) error
 	DeleteTaskLog(ctx context.Context, id int32) error
+	// Soft deletes the user, the row stays until PurgeUser removes it
 	DeleteUser(ctx context.Context, id int32) error
+	DeleteWebhook(ctx context.Context, id int32) (int64, error)
+	// Deletes the deliveries made before a time, whatever became of them
+	DeleteWebhookDeliveriesBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (QueuedJob, error)
+	// Every approval decision a user made, themselves or for someone who delegated to them
+	ExportApprovalDecisions(ctx context.Context, approverID pgtype.Int4) ([]ApprovalDecision, error)
+	// Every approval request a user submitted, for their data export
+	ExportApprovalRequests(ctx context.Context, requesterID int32) ([]ApprovalRequest, error)
+	// Every attendance session of a user, for their data export
+	ExportAttendanceSessions(ctx context.Context, userID int32) ([]AttendanceSession, error)
+	// Every leave log of a user, deleted ones too, for their data export
+	ExportLeaveLogs(ctx context.Context, userID int32) ([]LeaveLog, error)
+	// Every medical expense of a user, deleted ones too, for their data export
+	ExportMedicalExpenses(ctx context.Context, userID int32) ([]MedicalExpense, error)
+	// Every notification sent or queued for a user, for their data export
+	ExportNotifications(ctx context.Context, userID int32) ([]Notification, error)
+	// Every task comment a user wrote, for their data export
+	ExportTaskComments(ctx context.Context, userID int32) ([]TaskComment, error)
+	// Every task log a user created, for their data export
+	ExportTaskLogs(ctx context.Context, createdByUserID int32) ([]TaskLog, error)
+	// Gives up on a job, leaving it as a dead letter
+	FailQueuedJob(ctx context.Context, arg FailQueuedJobParams) error
+	FailReportExport(ctx context.Context, arg FailReportExportParams) error
+	// Matches the same ClickUp URL, or a title equal after ignoring case, spaces and punctuation
+	FindDuplicateTask(ctx context.Context, arg FindDuplicateTaskParams) (Task, error)
+	FinishScheduledJob(ctx context.Context, arg FinishScheduledJobParams) error
+	GetAPIToken(ctx context.Context, id int32) (ApiToken, error)
+	// Finds the token a service called with, unless it was revoked
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error)
+	GetAnnouncement(ctx context.Context, id int32) (Announcement, error)
 	GetAnnualRecord(ctx context.Context, id int32) (AnnualRecord, error)
 	GetAnnualRecordByUserAndYear(ctx context.Context, arg GetAnnualRecordByUserAndYearParams) (GetAnnualRecordByUserAndYearRow, error)
+	GetAnomaly(ctx context.Context, id int32) (Anomaly, error)
+	GetAnonymization(ctx context.Context, userID int32) (Anonymization, error)
+	GetApprovalDelegation(ctx context.Context, id int32) (ApprovalDelegation, error)
+	GetApprovalPolicy(ctx context.Context, kind string) (ApprovalPolicy, error)
+	GetApprovalRequest(ctx context.Context, id int32) (ApprovalRequest, error)
+	// Replays the balance events of a user's year recorded up to as_of, all of them when it is NULL
+	GetBalanceTotals(ctx context.Context, arg GetBalanceTotalsParams) (GetBalanceTotalsRow, error)
+	// Linked tasks changed locally after their last sync still have changes waiting to be pushed
+	GetClickUpSyncStats(ctx context.Context, since pgtype.Timestamptz) (GetClickUpSyncStatsRow, error)
+	// Returns the most recently connected token of the user
+	GetClickUpToken(ctx context.Context, userID int32) (ClickupToken, error)
+	GetClickUpTokenForTeam(ctx context.Context, arg GetClickUpTokenForTeamParams) (ClickupToken, error)
+	GetClickUpWorkspace(ctx context.Context, teamID string) (ClickupWorkspace, error)
+	GetCurrentTaskEstimate(ctx context.Context, taskID int32) (TaskEstimate, error)
+	GetDepartment(ctx context.Context, id int32) (Department, error)
+	GetDepartmentByName(ctx context.Context, name string) (Department, error)
+	GetEstimationSession(ctx context.Context, id int32) (EstimationSession, error)
+	GetFeatureFlag(ctx context.Context, key string) (FeatureFlag, error)
+	GetFile(ctx context.Context, id int32) (File, error)
 	GetHoliday(ctx context.Context, id int32) (Holiday, error)
 	GetHolidayByDate(ctx context.Context, date pgtype.Date) (Holiday, error)
+	// Changes whenever a holiday is added, edited or deleted, for the ETag of the holiday listings
+	GetHolidaysVersion(ctx context.Context) (GetHolidaysVersionRow, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLanguagePreference(ctx context.Context, userID int32) (LanguagePreference, error)
+	// A user's leave and medical expense balances in a year, from their annual record and its quota plan
+	GetLeaveBalance(ctx context.Context, arg GetLeaveBalanceParams) (GetLeaveBalanceRow, error)
 	GetLeaveLog(ctx context.Context, id int32) (LeaveLog, error)
+	GetLineLink(ctx context.Context, userID int32) (LineLink, error)
 	GetMedicalExpense(ctx context.Context, id int32) (MedicalExpense, error)
+	GetNotification(ctx context.Context, id int32) (Notification, error)
+	// The session a user is checked in to
+	GetOpenAttendanceSession(ctx context.Context, userID int32) (AttendanceSession, error)
+	GetOrgAssignment(ctx context.Context, userID int32) (OrgAssignment, error)
+	GetPayrollPeriod(ctx context.Context, period string) (PayrollPeriod, error)
+	// The request waiting on a leave log, medical expense, task log or quota plan
+	GetPendingApprovalRequestBySubject(ctx context.Context, arg GetPendingApprovalRequestBySubjectParams) (ApprovalRequest, error)
+	GetPosition(ctx context.Context, id int32) (Position, error)
+	GetQueuedJob(ctx context.Context, id int32) (QueuedJob, error)
 	GetQuotaPlan(ctx context.Context, id int32) (QuotaPlan, error)
 	GetQuotaPlanByNameAndYear(ctx context.Context, arg GetQuotaPlanByNameAndYearParams) (QuotaPlan, error)
+	// Changes whenever a quota plan is added, edited or deleted, for the ETag of the quota plan listings
+	GetQuotaPlansVersion(ctx context.Context) (GetQuotaPlansVersionRow, error)
+	GetReportExport(ctx context.Context, id int32) (ReportExport, error)
+	GetScheduledJob(ctx context.Context, name string) (ScheduledJob, error)
+	GetSlackWorkspace(ctx context.Context, teamID string) (SlackWorkspace, error)
+	GetTag(ctx context.Context, id int32) (Tag, error)
 	GetTask(ctx context.Context, id int32) (Task, error)
+	// Changes whenever a task category is added, edited, moved, archived or deleted, for the ETag of
+	// the category listings
+	GetTaskCategoriesVersion(ctx context.Context) (GetTaskCategoriesVersionRow, error)
 	GetTaskCategory(ctx context.Context, id int32) (TaskCategory, error)
+	GetTaskComment(ctx context.Context, id int32) (TaskComment, error)
 	GetTaskEstimate(ctx context.Context, id int32) (TaskEstimate, error)
+	// Sums the current estimate of a task and each of its subtasks
+	GetTaskEstimateRollup(ctx context.Context, taskID int32) (GetTaskEstimateRollupRow, error)
 	GetTaskLog(ctx context.Context, id int32) (TaskLog, error)
+	GetTenantBySlug(ctx context.Context, slug string) (Tenant, error)
+	GetTimeZonePreference(ctx context.Context, userID int32) (TimeZonePreference, error)
 	GetUser(ctx context.Context, id int32) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetWebhook(ctx context.Context, id int32) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int32) (WebhookDelivery, error)
+	HasTaskLogs(ctx context.Context, taskID int32) (bool, error)
+	IsTaskAssignee(ctx context.Context, arg IsTaskAssigneeParams) (bool, error)
+	// Links the LINE account a code was sent from, while the code hasn't expired
+	LinkLineAccount(ctx context.Context, arg LinkLineAccountParams) (LineLink, error)
+	ListAPITokens(ctx context.Context) ([]ApiToken, error)
+	// The delegations the user decides under on the day, revoked ones left out
+	ListActiveApprovalDelegations(ctx context.Context, arg ListActiveApprovalDelegationsParams) ([]ApprovalDelegation, error)
+	// The live users who acknowledged a notice, in the order they did
+	ListAnnouncementAcknowledgments(ctx context.Context, announcementID int32) ([]ListAnnouncementAcknowledgmentsRow, error)
+	ListAnnouncementAcknowledgmentsByUser(ctx context.Context, userID int32) ([]AnnouncementAcknowledgment, error)
+	// Every announcement, published or not, the latest to publish first
+	ListAnnouncements(ctx context.Context, arg ListAnnouncementsParams) ([]Announcement, error)
 	ListAnnualRecordsByUser(ctx context.Context, userID int32) ([]ListAnnualRecordsByUserRow, error)
 	ListAnnualRecordsByYear(ctx context.Context, year int32) ([]ListAnnualRecordsByYearRow, error)
+	// The review queue, newest first, narrowed to a status, rule and user when they are given
+	ListAnomalies(ctx context.Context, arg ListAnomaliesParams) ([]Anomaly, error)
+	ListApprovalDecisions(ctx context.Context, requestID int32) ([]ApprovalDecision, error)
+	// The decisions a delegate made under a delegation, for its audit trail
+	ListApprovalDecisionsByDelegation(ctx context.Context, delegationID pgtype.Int4) ([]ApprovalDecision, error)
+	// The delegations a user gave or was given, revoked ones too, latest first
+	ListApprovalDelegationsByUser(ctx context.Context, delegatorID int32) ([]ApprovalDelegation, error)
+	ListApprovalPolicies(ctx context.Context) ([]ApprovalPolicy, error)
+	ListApprovalRequestsByRequester(ctx context.Context, requesterID int32) ([]ApprovalRequest, error)
+	// Current estimates of open tasks per user, split evenly between co-assignees, against the working days in the period
+	ListAssigneeCapacity(ctx context.Context, arg ListAssigneeCapacityParams) ([]ListAssigneeCapacityRow, error)
+	// A user's sessions on the days of a date range, the latest first
+	ListAttendanceSessionsByUser(ctx context.Context, arg ListAttendanceSessionsByUserParams) ([]AttendanceSession, error)
+	// Annual records of a year whose totals aren't what their balance events add up to
+	ListBalanceDrift(ctx context.Context, year int32) ([]ListBalanceDriftRow, error)
+	// The ledger of a user's year, in the order it was recorded
+	ListBalanceEvents(ctx context.Context, arg ListBalanceEventsParams) ([]BalanceEvent, error)
+	ListClickUpLinkedTasks(ctx context.Context) ([]Task, error)
+	// An empty team ID lists the linked tasks whose workspace is not known yet
+	ListClickUpLinkedTasksByTeam(ctx context.Context, teamID string) ([]Task, error)
+	ListClickUpTokensByUser(ctx context.Context, userID int32) ([]ClickupToken, error)
+	ListClickUpWorkspaces(ctx context.Context) ([]ClickupWorkspace, error)
+	// Every user's attendance on a day, of one department when given: their first check-in, last
+	// check-out and hours present, counting open sessions up to now, and the leave they took
+	ListDailyAttendance(ctx context.Context, arg ListDailyAttendanceParams) ([]ListDailyAttendanceRow, error)
+	ListDepartments(ctx context.Context) ([]Department, error)
+	// Participants of a session with their vote, if they have voted
+	ListEstimationSessionParticipants(ctx context.Context, sessionID int32) ([]ListEstimationSessionParticipantsRow, error)
+	// Open sessions the user takes part in without having voted yet, the oldest first
+	ListEstimationSessionsAwaitingVote(ctx context.Context, userID int32) ([]ListEstimationSessionsAwaitingVoteRow, error)
+	ListEstimationSessionsByTask(ctx context.Context, taskID int32) ([]EstimationSession, error)
+	// The medical expenses of live users dated in a range whose amount is at most margin, a fraction,
+	// below the most that needs no approval
+	ListExpensesNearApprovalLimit(ctx context.Context, arg ListExpensesNearApprovalLimitParams) ([]ListExpensesNearApprovalLimitRow, error)
+	ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error)
+	// Lists the files of a medical expense, leave log or user, oldest first
+	ListFilesBySubject(ctx context.Context, arg ListFilesBySubjectParams) ([]File, error)
+	// The files a user uploaded and those of their medical expenses, leave logs and avatar
+	ListFilesOfUser(ctx context.Context, userID int32) ([]File, error)
+	// The days in a range a user logged work on a holiday, with the holiday's name when it is one
+	// of the holidays rather than a weekend
+	ListHolidayWorkByUser(ctx context.Context, arg ListHolidayWorkByUserParams) ([]ListHolidayWorkByUserRow, error)
 	ListHolidays(ctx context.Context, arg ListHolidaysParams) ([]Holiday, error)
 	ListHolidaysByYear(ctx context.Context, date pgtype.Date) ([]Holiday, error)
+	// Every user's leave and medical expense balances in a year, by username
+	ListLeaveBalancesByYear(ctx context.Context, year int32) ([]ListLeaveBalancesByYearRow, error)
+	// Leave in a date range with who takes it, for everyone or one department and those under it,
+	// by date
+	ListLeaveCalendar(ctx context.Context, arg ListLeaveCalendarParams) ([]ListLeaveCalendarRow, error)
+	// Keyset page of leave logs, newest first, starting after the (created_at, id) cursor when given
+	ListLeaveLogsAfter(ctx context.Context, arg ListLeaveLogsAfterParams) ([]LeaveLog, error)
 	ListLeaveLogsByDateRange(ctx context.Context, arg ListLeaveLogsByDateRangeParams) ([]LeaveLog, error)
 	ListLeaveLogsByType(ctx context.Context, arg ListLeaveLogsByTypeParams) ([]LeaveLog, error)
 	ListLeaveLogsByUser(ctx context.Context, arg ListLeaveLogsByUserParams) ([]LeaveLog, error)
 	ListLeaveLogsByYear(ctx context.Context, arg ListLeaveLogsByYearParams) ([]LeaveLog, error)
+	// Page of leave logs with their user's name, narrowed by whichever of user, type, year and date
+	// range are given, in sort_by order and otherwise newest date first
+	ListLeaveLogsWithUsername(ctx context.Context, arg ListLeaveLogsWithUsernameParams) ([]ListLeaveLogsWithUsernameRow, error)
+	// Medical expenses with a receipt date in a date range and who claimed them, for everyone, one user or one department
+	ListMedicalExpensesByDateRange(ctx context.Context, arg ListMedicalExpensesByDateRangeParams) ([]ListMedicalExpensesByDateRangeRow, error)
 	ListMedicalExpensesByUser(ctx context.Context, arg ListMedicalExpensesByUserParams) ([]MedicalExpense, error)
 	ListMedicalExpensesByYear(ctx context.Context, arg ListMedicalExpensesByYearParams) ([]MedicalExpense, error)
+	ListNotificationPreferences(ctx context.Context, userID int32) ([]NotificationPreference, error)
+	// Every live user with their department, position and who they report to, by username
+	ListOrgChartMembers(ctx context.Context) ([]ListOrgChartMembersRow, error)
+	// Lists files whose medical expense, leave log or user is gone. Soft deleted ones keep their
+	// files until they are purged.
+	ListOrphanedFiles(ctx context.Context, limit int32) ([]File, error)
+	// The days of a range live users logged more work on than max_ratio of their daily capacity
+	ListOverloggedDays(ctx context.Context, arg ListOverloggedDaysParams) ([]ListOverloggedDaysRow, error)
+	// The periods, the latest first
+	ListPayrollPeriods(ctx context.Context) ([]PayrollPeriod, error)
+	// Every request waiting on a decision, oldest first
+	ListPendingApprovalRequests(ctx context.Context) ([]ApprovalRequest, error)
+	ListPositions(ctx context.Context) ([]Position, error)
+	// The announcements published at a time and not yet expired, whoever they are for, the latest
+	// first
+	ListPublishedAnnouncements(ctx context.Context, at pgtype.Timestamptz) ([]Announcement, error)
+	// Leave logs deleted before the cutoff, oldest deletion first
+	ListPurgeableLeaveLogIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error)
+	// Medical expenses deleted before the cutoff, oldest deletion first
+	ListPurgeableMedicalExpenseIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error)
+	// Tasks deleted before the cutoff, oldest deletion first
+	ListPurgeableTaskIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error)
+	// Users deleted before the cutoff, oldest deletion first
+	ListPurgeableUserIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error)
+	ListPushDevicesByUser(ctx context.Context, userID int32) ([]PushDevice, error)
+	ListQueuedJobs(ctx context.Context, arg ListQueuedJobsParams) ([]QueuedJob, error)
 	ListQuotaPlans(ctx context.Context) ([]QuotaPlan, error)
 	ListQuotaPlansByYear(ctx context.Context, year int32) ([]QuotaPlan, error)
+	// Tasks a user was assigned to or logged work on, the latest first
+	ListRecentTasksForUser(ctx context.Context, arg ListRecentTasksForUserParams) ([]ListRecentTasksForUserRow, error)
 	ListRootTaskCategories(ctx context.Context) ([]TaskCategory, error)
+	ListRuntimeSettings(ctx context.Context) ([]RuntimeSetting, error)
+	ListScheduledJobs(ctx context.Context) ([]ScheduledJob, error)
+	// The sick leave of live users on the days of a range, by user and day
+	ListSickLeaveDays(ctx context.Context, arg ListSickLeaveDaysParams) ([]ListSickLeaveDaysRow, error)
+	ListSlackWorkspaces(ctx context.Context) ([]SlackWorkspace, error)
+	ListSubtasks(ctx context.Context, parentTaskID pgtype.Int4) ([]Task, error)
+	ListTagWorkedDays(ctx context.Context, arg ListTagWorkedDaysParams) ([]ListTagWorkedDaysRow, error)
+	ListTags(ctx context.Context) ([]Tag, error)
+	ListTaskActivities(ctx context.Context, arg ListTaskActivitiesParams) ([]ListTaskActivitiesRow, error)
+	ListTaskAncestorIDs(ctx context.Context, id int32) ([]int32, error)
+	ListTaskAssignees(ctx context.Context, taskID int32) ([]ListTaskAssigneesRow, error)
+	ListTaskAssigneesByTaskIDs(ctx context.Context, taskIds []int32) ([]ListTaskAssigneesByTaskIDsRow, error)
+	// Categories visible to a department; a category inherits the department of its nearest scoped ancestor
 	ListTaskCategories(ctx context.Context, arg ListTaskCategoriesParams) ([]TaskCategory, error)
 	ListTaskCategoriesByParent(ctx context.Context, parentID pgtype.Int4) ([]TaskCategory, error)
+	// Logged days, current estimates and budget of every category, rolled up over its subtree
+	ListTaskCategoryEffort(ctx context.Context, arg ListTaskCategoryEffortParams) ([]ListTaskCategoryEffortRow, error)
+	// Every category with its depth, the names from the root down and its effective department,
+	// parents before children. Categories scoped to other departments are left out unless all_departments is set.
+	ListTaskCategoryTree(ctx context.Context, arg ListTaskCategoryTreeParams) ([]ListTaskCategoryTreeRow, error)
+	// The work a user logged in a range per category of its task, the most first, with the work on
+	// tasks without a category under a NULL category
+	ListTaskCategoryWorkByUser(ctx context.Context, arg ListTaskCategoryWorkByUserParams) ([]ListTaskCategoryWorkByUserRow, error)
+	ListTaskCommentsByTask(ctx context.Context, taskID int32) ([]ListTaskCommentsByTaskRow, error)
+	ListTaskCustomFieldsByTaskIDs(ctx context.Context, taskIds []int32) ([]TaskCustomField, error)
+	// Tasks with a current estimate and work logged in the period, with everything logged up to the end date
+	ListTaskEstimateVariance(ctx context.Context, arg ListTaskEstimateVarianceParams) ([]ListTaskEstimateVarianceRow, error)
 	ListTaskEstimatesByTask(ctx context.Context, taskID int32) ([]TaskEstimate, error)
 	ListTaskEstimatesByUser(ctx context.Context, arg ListTaskEstimatesByUserParams) ([]TaskEstimate, error)
+	// Includes the logs of all subtasks so effort rolls up to the parent
+	ListTaskLogDailyTotals(ctx context.Context, taskID int32) ([]ListTaskLogDailyTotalsRow, error)
 	ListTaskLogsByDateRange(ctx context.Context, arg ListTaskLogsByDateRangeParams) ([]TaskLog, error)
 	ListTaskLogsByTask(ctx context.Context, taskID int32) ([]TaskLog, error)
 	ListTaskLogsByUser(ctx context.Context, arg ListTaskLogsByUserParams) ([]TaskLog, error)
 	ListTaskLogsByUserAndDateRange(ctx context.Context, arg ListTaskLogsByUserAndDateRangeParams) ([]TaskLog, error)
+	ListTaskLogsWithDetailsByTask(ctx context.Context, taskID int32) ([]ListTaskLogsWithDetailsByTaskRow, error)
+	// Page of a user's logs, narrowed by task and worked date range when given, in sort_by order and
+	// otherwise latest worked date first
+	ListTaskLogsWithDetailsByUser(ctx context.Context, arg ListTaskLogsWithDetailsByUserParams) ([]ListTaskLogsWithDetailsByUserRow, error)
+	// Keyset page of a user's logs, newest first, starting after the (created_at, id) cursor when given
+	ListTaskLogsWithDetailsByUserAfter(ctx context.Context, arg ListTaskLogsWithDetailsByUserAfterParams) ([]ListTaskLogsWithDetailsByUserAfterRow, error)
+	ListTaskLogsWithDetailsByUserAndDateRange(ctx context.Context, arg ListTaskLogsWithDetailsByUserAndDateRangeParams) ([]ListTaskLogsWithDetailsByUserAndDateRangeRow, error)
+	ListTaskSyncHistory(ctx context.Context, arg ListTaskSyncHistoryParams) ([]TaskSyncHistory, error)
+	ListTaskTags(ctx context.Context, taskID int32) ([]Tag, error)
+	ListTaskTagsByTaskIDs(ctx context.Context, taskIds []int32) ([]ListTaskTagsByTaskIDsRow, error)
 	ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, error)
 	ListTasksByCategory(ctx context.Context, taskCategoryID pgtype.Int4) ([]Task, error)
-	ListTasksByCategoryWithSubcategories(ctx context.Context, id int32) ([]Task, error)
+	ListTasksByCategoryWithSubcategories(ctx context.Context, id int32) ([]ListTasksByCategoryWithSubcategoriesRow, error)
+	// Every tenant, for jobs that run once per tenant
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	// Work logged in a date range with its task and who logged it, for everyone, one user or one department
+	ListTimesheetEntries(ctx context.Context, arg ListTimesheetEntriesParams) ([]ListTimesheetEntriesRow, error)
+	// Working days in a date range, since the user joined, on which they neither logged work nor took leave, the latest first
+	ListUnloggedDays(ctx context.Context, arg ListUnloggedDaysParams) ([]pgtype.Date, error)
+	// Work logged per user in a date range against the working days they had, for everyone, one user or one department
+	ListUserUtilization(ctx context.Context, arg ListUserUtilizationParams) ([]ListUserUtilizationRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
-	// This query synchronizes all annual records for a specific year
-	SyncAllAnnualRecordsByYear(ctx context.Context, year int32) ([]SyncAllAnnualRecordsByYearRow, error)
-	// This query synchronizes the used vacation days and sick leave days for a specific user and year
-	SyncAnnualRecordVacationDays(ctx context.Context, arg SyncAnnualRecordVacationDaysParams) (AnnualRecord, error)
-	// This query synchronizes the worked days and worked on holiday days for a specific user and year
-	SyncAnnualRecordWorkDays(ctx context.Context, arg SyncAnnualRecordWorkDaysParams) (AnnualRecord, error)
+	// Users deleted before the cutoff whose personal data is still there, oldest deletion first
+	ListUsersToAnonymize(ctx context.Context, arg ListUsersToAnonymizeParams) ([]int32, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// Locks an open period with its export, no rows when it is already locked
+	LockPayrollPeriod(ctx context.Context, arg LockPayrollPeriodParams) (PayrollPeriod, error)
+	// Locks every category until the transaction ends, so changes to the tree checked against it
+	// run one after another
+	LockTaskCategories(ctx context.Context) error
+	// Whether a user has someone reporting to them or heads a department, and so decides approvals
+	ManagesAnyone(ctx context.Context, userID int32) (bool, error)
+	MarkNotificationSent(ctx context.Context, id int32) error
+	// Records the day who is out was posted to a workspace
+	MarkSlackOutTodayPosted(ctx context.Context, arg MarkSlackOutTodayPostedParams) error
+	MarkTaskClickUpSynced(ctx context.Context, arg MarkTaskClickUpSyncedParams) error
+	MoveTaskCategory(ctx context.Context, arg MoveTaskCategoryParams) (TaskCategory, error)
+	// Removes a soft deleted leave log for good, live ones are never touched
+	PurgeLeaveLog(ctx context.Context, id int32) (int64, error)
+	// Removes a soft deleted medical expense for good, live ones are never touched
+	PurgeMedicalExpense(ctx context.Context, id int32) (int64, error)
+	// Removes a soft deleted task for good, live ones are never touched
+	PurgeTask(ctx context.Context, id int32) (int64, error)
+	// Removes a soft deleted user for good, live users are never touched
+	PurgeUser(ctx context.Context, id int32) (int64, error)
+	ReassignTaskCategoryChildren(ctx context.Context, arg ReassignTaskCategoryChildrenParams) (int64, error)
+	ReassignTaskCategoryTasks(ctx context.Context, arg ReassignTaskCategoryTasksParams) (int64, error)
+	// Records how an attempt went, delivered when the receiver answered with a 2xx
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) error
+	// Keeps a device's token for the user, moving it from whoever registered it before
+	RegisterPushDevice(ctx context.Context, arg RegisterPushDeviceParams) (PushDevice, error)
+	// Adds a job, or updates the schedule of a known one whose schedule changed, keeping whichever
+	// next run comes first
+	RegisterScheduledJob(ctx context.Context, arg RegisterScheduledJobParams) error
+	RemoveTaskTag(ctx context.Context, arg RemoveTaskTagParams) (int64, error)
+	// Moves the task categories scoped to a department to its new name
+	RenameTaskCategoryDepartments(ctx context.Context, arg RenameTaskCategoryDepartmentsParams) error
+	// Moves the users of a department to its new name, deleted users too
+	RenameUserDepartments(ctx context.Context, arg RenameUserDepartmentsParams) error
+	// Sets the sort order of the given categories to their position in the list
+	ReorderTaskCategories(ctx context.Context, categoryIds []int32) (int64, error)
+	// Gives a dead job a fresh set of attempts, starting now
+	RequeueQueuedJob(ctx context.Context, id int32) (QueuedJob, error)
+	// Sends a delivery again with a fresh set of attempts
+	RequeueWebhookDelivery(ctx context.Context, id int32) (WebhookDelivery, error)
+	// Undoes DeleteLeaveLog
+	RestoreLeaveLog(ctx context.Context, id int32) (int64, error)
+	// Undoes DeleteMedicalExpense
+	RestoreMedicalExpense(ctx context.Context, id int32) (int64, error)
+	// Undoes DeleteTask
+	RestoreTask(ctx context.Context, id int32) (int64, error)
+	// Makes a superseded estimate current again, used when its successor is deleted
+	RestoreTaskEstimate(ctx context.Context, id int32) error
+	// Undoes DeleteUser. Anonymized users stay deleted, there is nothing of them left to bring back.
+	RestoreUser(ctx context.Context, id int32) (int64, error)
+	// Puts a failed job back to run again at run_at
+	RetryQueuedJob(ctx context.Context, arg RetryQueuedJobParams) error
+	// Confirms or dismisses a finding, or opens it again, recording who did it
+	ReviewAnomaly(ctx context.Context, arg ReviewAnomalyParams) (Anomaly, error)
+	RevokeAPIToken(ctx context.Context, id int32) (ApiToken, error)
+	// Ends a delegation, keeping it for the audit trail. Fails with no rows when it was revoked
+	// already.
+	RevokeApprovalDelegation(ctx context.Context, arg RevokeApprovalDelegationParams) (ApprovalDelegation, error)
+	// Clears the notes, receipt names and check-in locations of a user's logs, keeping their dates
+	// and amounts, and deletes their notifications, preferences, links, devices and tokens
+	ScrubUserPersonalData(ctx context.Context, userID int32) error
+	SearchTasks(ctx context.Context, arg SearchTasksParams) ([]SearchTasksRow, error)
+	// Creates the flag or replaces its settings
+	SetFeatureFlag(ctx context.Context, arg SetFeatureFlagParams) (FeatureFlag, error)
+	SetLanguagePreference(ctx context.Context, arg SetLanguagePreferenceParams) (LanguagePreference, error)
+	// Records why the last try to send a notification failed
+	SetNotificationError(ctx context.Context, arg SetNotificationErrorParams) error
+	SetNotificationPreference(ctx context.Context, arg SetNotificationPreferenceParams) (NotificationPreference, error)
+	// Creates the override or replaces its value
+	SetRuntimeSetting(ctx context.Context, arg SetRuntimeSettingParams) (RuntimeSetting, error)
+	// Pauses or resumes a job. Resuming passes the next run, so the runs missed while paused are
+	// skipped rather than caught up on.
+	SetScheduledJobPaused(ctx context.Context, arg SetScheduledJobPausedParams) (ScheduledJob, error)
+	SetTaskParent(ctx context.Context, arg SetTaskParentParams) (Task, error)
+	SetTimeZonePreference(ctx context.Context, arg SetTimeZonePreferenceParams) (TimeZonePreference, error)
+	// The hours a user was checked in on a day, counting an open session up to now
+	SumAttendanceHoursForDate(ctx context.Context, arg SumAttendanceHoursForDateParams) (float64, error)
+	// Sums the days a user logged on one date, leaving out the log being updated
+	SumTaskLogWorkedDaysForDate(ctx context.Context, arg SumTaskLogWorkedDaysForDateParams) (float64, error)
+	// A user's leave days in a range per month and type, for their year in review
+	SummarizeLeaveByMonth(ctx context.Context, arg SummarizeLeaveByMonthParams) ([]SummarizeLeaveByMonthRow, error)
+	// Leave days per user and type in a year, for everyone or one user when given
+	SummarizeLeaveLogs(ctx context.Context, arg SummarizeLeaveLogsParams) ([]SummarizeLeaveLogsRow, error)
+	// Leave days per user and type in a date range, for everyone, one user or one department
+	SummarizeLeaveLogsByDateRange(ctx context.Context, arg SummarizeLeaveLogsByDateRangeParams) ([]SummarizeLeaveLogsByDateRangeRow, error)
+	// What payroll needs of every live user in a period: their days of paid and unpaid leave, the
+	// days they logged as work on a holiday, and their overtime, the hours checked in beyond a full
+	// day of their capacity on days that aren't holidays, counting the sessions they checked out of
+	SummarizePayroll(ctx context.Context, arg SummarizePayrollParams) ([]SummarizePayrollRow, error)
+	SupersedeTaskEstimate(ctx context.Context, id int32) error
+	// Derives the used and worked totals of every annual record of a year from the balance events
+	SyncAllAnnualRecordsByYear(ctx context.Context, year int32) ([]AnnualRecord, error)
+	// Derives the used and worked totals of a user's annual record for a year from its balance events
+	SyncAnnualRecord(ctx context.Context, arg SyncAnnualRecordParams) (AnnualRecord, error)
+	TouchAPIToken(ctx context.Context, id int32) error
+	// Makes a job due now
+	TriggerScheduledJob(ctx context.Context, name string) (ScheduledJob, error)
+	UnassignTask(ctx context.Context, arg UnassignTaskParams) (int64, error)
+	// Reopens a locked period, throwing its export away, no rows when it isn't locked
+	UnlockPayrollPeriod(ctx context.Context, id int32) (PayrollPeriod, error)
+	UpdateAnnouncement(ctx context.Context, arg UpdateAnnouncementParams) (Announcement, error)
+	// Matches no row when expected_updated_at is set and the record changed since, so concurrent edits conflict
 	UpdateAnnualRecord(ctx context.Context, arg UpdateAnnualRecordParams) (AnnualRecord, error)
+	UpdateDepartment(ctx context.Context, arg UpdateDepartmentParams) (Department, error)
 	UpdateHoliday(ctx context.Context, arg UpdateHolidayParams) (Holiday, error)
+	// Matches no row when expected_updated_at is set and the leave log changed since, so concurrent edits conflict
 	UpdateLeaveLog(ctx context.Context, arg UpdateLeaveLogParams) (LeaveLog, error)
 	UpdateMedicalExpense(ctx context.Context, arg UpdateMedicalExpenseParams) (MedicalExpense, error)
+	UpdatePosition(ctx context.Context, arg UpdatePositionParams) (Position, error)
+	// Matches no row when expected_updated_at is set and the plan changed since, so concurrent edits conflict
 	UpdateQuotaPlan(ctx context.Context, arg UpdateQuotaPlanParams) (QuotaPlan, error)
+	UpdateTag(ctx context.Context, arg UpdateTagParams) (Tag, error)
+	// Matches no row when expected_updated_at is set and the task changed since, so concurrent edits conflict
 	UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, error)
 	UpdateTaskCategory(ctx context.Context, arg UpdateTaskCategoryParams) (TaskCategory, error)
+	UpdateTaskComment(ctx context.Context, arg UpdateTaskCommentParams) (TaskComment, error)
 	UpdateTaskEstimate(ctx context.Context, arg UpdateTaskEstimateParams) (TaskEstimate, error)
 	UpdateTaskLog(ctx context.Context, arg UpdateTaskLogParams) (TaskLog, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
 	UpsertAnnualRecordForUser(ctx context.Context, arg UpsertAnnualRecordForUserParams) (AnnualRecord, error)
+	// Sets the steps a kind of request goes through and the amount approved without any
+	UpsertApprovalPolicy(ctx context.Context, arg UpsertApprovalPolicyParams) (ApprovalPolicy, error)
+	UpsertClickUpToken(ctx context.Context, arg UpsertClickUpTokenParams) (ClickupToken, error)
+	// The latest user to connect the workspace becomes the one whose token syncs it
+	UpsertClickUpWorkspace(ctx context.Context, arg UpsertClickUpWorkspaceParams) (ClickupWorkspace, error)
+	// Stores or replaces a participant's hidden vote in an estimation session
+	UpsertEstimationVote(ctx context.Context, arg UpsertEstimationVoteParams) (TaskEstimate, error)
+	// Sets the position a user holds and who they report to
+	UpsertOrgAssignment(ctx context.Context, arg UpsertOrgAssignmentParams) (OrgAssignment, error)
+	// Connecting a workspace again replaces its token and settings
+	UpsertSlackWorkspace(ctx context.Context, arg UpsertSlackWorkspaceParams) (SlackWorkspace, error)
+	UpsertTagByName(ctx context.Context, arg UpsertTagByNameParams) (Tag, error)
+	UpsertTaskCustomField(ctx context.Context, arg UpsertTaskCustomFieldParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -20,7 +20,7 @@ INSERT INTO quota_plans (
   created_by_user_id
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at
+) RETURNING id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id
 `
 
 type CreateQuotaPlanParams struct {
@@ -49,6 +49,7 @@ func (q *Queries) CreateQuotaPlan(ctx context.Context, arg CreateQuotaPlanParams
 		&i.CreatedByUserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -64,7 +65,7 @@ func (q *Queries) DeleteQuotaPlan(ctx context.Context, id int32) error {
 }
 
 const getQuotaPlan = `-- name: GetQuotaPlan :one
-SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at FROM quota_plans
+SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id FROM quota_plans
 WHERE id = $1 LIMIT 1
 `
 
@@ -80,12 +81,13 @@ func (q *Queries) GetQuotaPlan(ctx context.Context, id int32) (QuotaPlan, error)
 		&i.CreatedByUserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getQuotaPlanByNameAndYear = `-- name: GetQuotaPlanByNameAndYear :one
-SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at FROM quota_plans
+SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id FROM quota_plans
 WHERE plan_name = $1 AND year = $2
 LIMIT 1
 `
@@ -107,12 +109,30 @@ func (q *Queries) GetQuotaPlanByNameAndYear(ctx context.Context, arg GetQuotaPla
 		&i.CreatedByUserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const getQuotaPlansVersion = `-- name: GetQuotaPlansVersion :one
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM quota_plans
+`
+
+type GetQuotaPlansVersionRow struct {
+	RowCount    int64              `json:"rowCount"`
+	LastUpdated pgtype.Timestamptz `json:"lastUpdated"`
+}
+
+// Changes whenever a quota plan is added, edited or deleted, for the ETag of the quota plan listings
+func (q *Queries) GetQuotaPlansVersion(ctx context.Context) (GetQuotaPlansVersionRow, error) {
+	row := q.db.QueryRow(ctx, getQuotaPlansVersion)
+	var i GetQuotaPlansVersionRow
+	err := row.Scan(&i.RowCount, &i.LastUpdated)
+	return i, err
+}
+
 const listQuotaPlans = `-- name: ListQuotaPlans :many
-SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at FROM quota_plans
+SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id FROM quota_plans
 ORDER BY year DESC, plan_name
 `
 
@@ -134,6 +154,7 @@ func (q *Queries) ListQuotaPlans(ctx context.Context) ([]QuotaPlan, error) {
 			&i.CreatedByUserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -146,7 +167,7 @@ func (q *Queries) ListQuotaPlans(ctx context.Context) ([]QuotaPlan, error) {
 }
 
 const listQuotaPlansByYear = `-- name: ListQuotaPlansByYear :many
-SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at FROM quota_plans
+SELECT id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id FROM quota_plans
 WHERE year = $1
 ORDER BY plan_name
 `
@@ -169,6 +190,7 @@ func (q *Queries) ListQuotaPlansByYear(ctx context.Context, year int32) ([]Quota
 			&i.CreatedByUserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -189,17 +211,20 @@ SET
   quota_medical_expense_baht = COALESCE($4, quota_medical_expense_baht),
   updated_at = NOW()
 WHERE id = $5
-RETURNING id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at
+  AND ($6::TIMESTAMPTZ IS NULL OR updated_at = $6::TIMESTAMPTZ)
+RETURNING id, plan_name, year, quota_vacation_day, quota_medical_expense_baht, created_by_user_id, created_at, updated_at, tenant_id
 `
 
 type UpdateQuotaPlanParams struct {
-	PlanName                string         `json:"planName"`
-	Year                    int32          `json:"year"`
-	QuotaVacationDay        pgtype.Numeric `json:"quotaVacationDay"`
-	QuotaMedicalExpenseBaht pgtype.Numeric `json:"quotaMedicalExpenseBaht"`
-	ID                      int32          `json:"id"`
+	PlanName                string             `json:"planName"`
+	Year                    int32              `json:"year"`
+	QuotaVacationDay        pgtype.Numeric     `json:"quotaVacationDay"`
+	QuotaMedicalExpenseBaht pgtype.Numeric     `json:"quotaMedicalExpenseBaht"`
+	ID                      int32              `json:"id"`
+	ExpectedUpdatedAt       pgtype.Timestamptz `json:"expectedUpdatedAt"`
 }
 
+// Matches no row when expected_updated_at is set and the plan changed since, so concurrent edits conflict
 func (q *Queries) UpdateQuotaPlan(ctx context.Context, arg UpdateQuotaPlanParams) (QuotaPlan, error) {
 	row := q.db.QueryRow(ctx, updateQuotaPlan,
 		arg.PlanName,
@@ -207,6 +232,7 @@ func (q *Queries) UpdateQuotaPlan(ctx context.Context, arg UpdateQuotaPlanParams
 		arg.QuotaVacationDay,
 		arg.QuotaMedicalExpenseBaht,
 		arg.ID,
+		arg.ExpectedUpdatedAt,
 	)
 	var i QuotaPlan
 	err := row.Scan(
@@ -218,6 +244,7 @@ func (q *Queries) UpdateQuotaPlan(ctx context.Context, arg UpdateQuotaPlanParams
 		&i.CreatedByUserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
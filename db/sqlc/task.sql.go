@@ -11,6 +11,54 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countSearchTasks = `-- name: CountSearchTasks :one
+SELECT COUNT(*)
+FROM tasks t
+WHERE t.deleted_at IS NULL
+  AND ($1::TEXT IS NULL OR LOWER(t.status) = LOWER($1::TEXT))
+  AND ($2::INTEGER IS NULL OR t.task_category_id = $2::INTEGER)
+  AND ($3::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_assignees ta
+    WHERE ta.task_id = t.id AND ta.user_id = $3::INTEGER
+  ))
+  AND ($4::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = t.id AND tt.tag_id = $4::INTEGER
+  ))
+  AND (NOT $5::BOOLEAN OR (
+    t.due_date < CURRENT_DATE
+    AND LOWER(COALESCE(t.status, '')) NOT IN ('complete', 'completed', 'closed', 'done')
+  ))
+  AND ($6::DATE IS NULL OR t.due_date >= $6::DATE)
+  AND ($7::DATE IS NULL OR t.due_date <= $7::DATE)
+`
+
+type CountSearchTasksParams struct {
+	Status      pgtype.Text `json:"status"`
+	CategoryID  pgtype.Int4 `json:"categoryId"`
+	AssigneeID  pgtype.Int4 `json:"assigneeId"`
+	TagID       pgtype.Int4 `json:"tagId"`
+	OverdueOnly bool        `json:"overdueOnly"`
+	DueFrom     pgtype.Date `json:"dueFrom"`
+	DueTo       pgtype.Date `json:"dueTo"`
+}
+
+// Counts the tasks SearchTasks pages through
+func (q *Queries) CountSearchTasks(ctx context.Context, arg CountSearchTasksParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchTasks,
+		arg.Status,
+		arg.CategoryID,
+		arg.AssigneeID,
+		arg.TagID,
+		arg.OverdueOnly,
+		arg.DueFrom,
+		arg.DueTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTask = `-- name: CreateTask :one
 INSERT INTO tasks (
   url,
@@ -18,19 +66,27 @@ INSERT INTO tasks (
   note,
   title,
   status,
-  status_color
+  status_color,
+  due_date,
+  priority,
+  parent_task_id,
+  created_by_user_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6
-) RETURNING id, url, task_category_id, note, title, status, status_color, created_at, updated_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id
 `
 
 type CreateTaskParams struct {
-	Url            pgtype.Text `json:"url"`
-	TaskCategoryID pgtype.Int4 `json:"taskCategoryId"`
-	Note           pgtype.Text `json:"note"`
-	Title          pgtype.Text `json:"title"`
-	Status         pgtype.Text `json:"status"`
-	StatusColor    pgtype.Text `json:"statusColor"`
+	Url             pgtype.Text `json:"url"`
+	TaskCategoryID  pgtype.Int4 `json:"taskCategoryId"`
+	Note            pgtype.Text `json:"note"`
+	Title           pgtype.Text `json:"title"`
+	Status          pgtype.Text `json:"status"`
+	StatusColor     pgtype.Text `json:"statusColor"`
+	DueDate         pgtype.Date `json:"dueDate"`
+	Priority        pgtype.Int4 `json:"priority"`
+	ParentTaskID    pgtype.Int4 `json:"parentTaskId"`
+	CreatedByUserID pgtype.Int4 `json:"createdByUserId"`
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
@@ -41,6 +97,10 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		arg.Title,
 		arg.Status,
 		arg.StatusColor,
+		arg.DueDate,
+		arg.Priority,
+		arg.ParentTaskID,
+		arg.CreatedByUserID,
 	)
 	var i Task
 	err := row.Scan(
@@ -53,23 +113,73 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		&i.StatusColor,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClickupSyncedAt,
+		&i.DueDate,
+		&i.Priority,
+		&i.ParentTaskID,
+		&i.CreatedByUserID,
+		&i.ClickupTeamID,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const deleteTask = `-- name: DeleteTask :exec
-DELETE FROM tasks
-WHERE id = $1
+UPDATE tasks
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
+// Soft deletes the task, the row stays until PurgeTask removes it
 func (q *Queries) DeleteTask(ctx context.Context, id int32) error {
 	_, err := q.db.Exec(ctx, deleteTask, id)
 	return err
 }
 
+const findDuplicateTask = `-- name: FindDuplicateTask :one
+SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id FROM tasks
+WHERE deleted_at IS NULL
+  AND (($1::TEXT IS NOT NULL AND url = $1::TEXT)
+    OR LOWER(REGEXP_REPLACE(COALESCE(title, ''), '[[:space:][:punct:]]+', '', 'g')) = LOWER(REGEXP_REPLACE($2::TEXT, '[[:space:][:punct:]]+', '', 'g')))
+ORDER BY url IS NOT DISTINCT FROM $1::TEXT DESC, created_at
+LIMIT 1
+`
+
+type FindDuplicateTaskParams struct {
+	Url   pgtype.Text `json:"url"`
+	Title string      `json:"title"`
+}
+
+// Matches the same ClickUp URL, or a title equal after ignoring case, spaces and punctuation
+func (q *Queries) FindDuplicateTask(ctx context.Context, arg FindDuplicateTaskParams) (Task, error) {
+	row := q.db.QueryRow(ctx, findDuplicateTask, arg.Url, arg.Title)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.TaskCategoryID,
+		&i.Note,
+		&i.Title,
+		&i.Status,
+		&i.StatusColor,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClickupSyncedAt,
+		&i.DueDate,
+		&i.Priority,
+		&i.ParentTaskID,
+		&i.CreatedByUserID,
+		&i.ClickupTeamID,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
 const getTask = `-- name: GetTask :one
-SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at FROM tasks
-WHERE id = $1 LIMIT 1
+SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id FROM tasks
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetTask(ctx context.Context, id int32) (Task, error) {
@@ -85,12 +195,181 @@ func (q *Queries) GetTask(ctx context.Context, id int32) (Task, error) {
 		&i.StatusColor,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClickupSyncedAt,
+		&i.DueDate,
+		&i.Priority,
+		&i.ParentTaskID,
+		&i.CreatedByUserID,
+		&i.ClickupTeamID,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const listPurgeableTaskIDs = `-- name: ListPurgeableTaskIDs :many
+SELECT id FROM tasks
+WHERE deleted_at IS NOT NULL AND deleted_at < $1::TIMESTAMPTZ
+ORDER BY deleted_at
+`
+
+// Tasks deleted before the cutoff, oldest deletion first
+func (q *Queries) ListPurgeableTaskIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listPurgeableTaskIDs, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentTasksForUser = `-- name: ListRecentTasksForUser :many
+WITH activity AS (
+  SELECT ta.task_id, ta.assigned_at AS at FROM task_assignees ta WHERE ta.user_id = $1
+  UNION ALL
+  SELECT tl.task_id, tl.created_at FROM task_logs tl WHERE tl.created_by_user_id = $1
+)
+SELECT t.id, t.title, t.status, t.status_color, t.due_date, t.priority, MAX(a.at)::TIMESTAMPTZ AS last_activity_at
+FROM activity a
+JOIN tasks t ON t.id = a.task_id
+WHERE t.deleted_at IS NULL
+GROUP BY t.id
+ORDER BY last_activity_at DESC, t.id DESC
+LIMIT $2
+`
+
+type ListRecentTasksForUserParams struct {
+	UserID   int32 `json:"userId"`
+	RowLimit int32 `json:"rowLimit"`
+}
+
+type ListRecentTasksForUserRow struct {
+	ID             int32              `json:"id"`
+	Title          pgtype.Text        `json:"title"`
+	Status         pgtype.Text        `json:"status"`
+	StatusColor    pgtype.Text        `json:"statusColor"`
+	DueDate        pgtype.Date        `json:"dueDate"`
+	Priority       pgtype.Int4        `json:"priority"`
+	LastActivityAt pgtype.Timestamptz `json:"lastActivityAt"`
+}
+
+// Tasks a user was assigned to or logged work on, the latest first
+func (q *Queries) ListRecentTasksForUser(ctx context.Context, arg ListRecentTasksForUserParams) ([]ListRecentTasksForUserRow, error) {
+	rows, err := q.db.Query(ctx, listRecentTasksForUser, arg.UserID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRecentTasksForUserRow{}
+	for rows.Next() {
+		var i ListRecentTasksForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Status,
+			&i.StatusColor,
+			&i.DueDate,
+			&i.Priority,
+			&i.LastActivityAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtasks = `-- name: ListSubtasks :many
+SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id FROM tasks
+WHERE parent_task_id = $1 AND deleted_at IS NULL
+ORDER BY created_at
+`
+
+func (q *Queries) ListSubtasks(ctx context.Context, parentTaskID pgtype.Int4) ([]Task, error) {
+	rows, err := q.db.Query(ctx, listSubtasks, parentTaskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Task{}
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.TaskCategoryID,
+			&i.Note,
+			&i.Title,
+			&i.Status,
+			&i.StatusColor,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClickupSyncedAt,
+			&i.DueDate,
+			&i.Priority,
+			&i.ParentTaskID,
+			&i.CreatedByUserID,
+			&i.ClickupTeamID,
+			&i.DeletedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskAncestorIDs = `-- name: ListTaskAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+  SELECT t.id, t.parent_task_id FROM tasks t WHERE t.id = $1
+  UNION ALL
+  SELECT t.id, t.parent_task_id FROM tasks t
+  JOIN ancestors a ON t.id = a.parent_task_id
+)
+SELECT ancestors.id FROM ancestors
+`
+
+func (q *Queries) ListTaskAncestorIDs(ctx context.Context, id int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listTaskAncestorIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTasks = `-- name: ListTasks :many
-SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at FROM tasks
+SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id FROM tasks
+WHERE deleted_at IS NULL
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2
@@ -120,6 +399,14 @@ func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, e
 			&i.StatusColor,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ClickupSyncedAt,
+			&i.DueDate,
+			&i.Priority,
+			&i.ParentTaskID,
+			&i.CreatedByUserID,
+			&i.ClickupTeamID,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -132,8 +419,8 @@ func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, e
 }
 
 const listTasksByCategory = `-- name: ListTasksByCategory :many
-SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at FROM tasks
-WHERE task_category_id = $1
+SELECT id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id FROM tasks
+WHERE task_category_id = $1 AND deleted_at IS NULL
 ORDER BY created_at DESC
 `
 
@@ -156,6 +443,14 @@ func (q *Queries) ListTasksByCategory(ctx context.Context, taskCategoryID pgtype
 			&i.StatusColor,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ClickupSyncedAt,
+			&i.DueDate,
+			&i.Priority,
+			&i.ParentTaskID,
+			&i.CreatedByUserID,
+			&i.ClickupTeamID,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -176,30 +471,46 @@ WITH RECURSIVE subcategories AS (
   SELECT tc.id FROM task_categories tc
   JOIN subcategories sc ON tc.parent_id = sc.id
 )
-SELECT t.id, t.url, t.task_category_id, t.note, t.title, t.status, t.status_color, t.created_at, t.updated_at FROM tasks t
-WHERE t.task_category_id IN (SELECT sc.id FROM subcategories sc)
+SELECT t.id, t.url, t.task_category_id, t.note, t.title, t.status, t.status_color, t.created_at, t.updated_at, t.clickup_synced_at, t.due_date, t.priority, t.parent_task_id, t.created_by_user_id, t.clickup_team_id, t.deleted_at, t.tenant_id, c.name AS category_name
+FROM tasks t
+JOIN task_categories c ON c.id = t.task_category_id
+WHERE t.task_category_id IN (SELECT sc.id FROM subcategories sc) AND t.deleted_at IS NULL
 ORDER BY t.created_at DESC
 `
 
-func (q *Queries) ListTasksByCategoryWithSubcategories(ctx context.Context, id int32) ([]Task, error) {
+type ListTasksByCategoryWithSubcategoriesRow struct {
+	Task         Task   `json:"task"`
+	CategoryName string `json:"categoryName"`
+}
+
+func (q *Queries) ListTasksByCategoryWithSubcategories(ctx context.Context, id int32) ([]ListTasksByCategoryWithSubcategoriesRow, error) {
 	rows, err := q.db.Query(ctx, listTasksByCategoryWithSubcategories, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Task{}
+	items := []ListTasksByCategoryWithSubcategoriesRow{}
 	for rows.Next() {
-		var i Task
+		var i ListTasksByCategoryWithSubcategoriesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Url,
-			&i.TaskCategoryID,
-			&i.Note,
-			&i.Title,
-			&i.Status,
-			&i.StatusColor,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Task.ID,
+			&i.Task.Url,
+			&i.Task.TaskCategoryID,
+			&i.Task.Note,
+			&i.Task.Title,
+			&i.Task.Status,
+			&i.Task.StatusColor,
+			&i.Task.CreatedAt,
+			&i.Task.UpdatedAt,
+			&i.Task.ClickupSyncedAt,
+			&i.Task.DueDate,
+			&i.Task.Priority,
+			&i.Task.ParentTaskID,
+			&i.Task.CreatedByUserID,
+			&i.Task.ClickupTeamID,
+			&i.Task.DeletedAt,
+			&i.Task.TenantID,
+			&i.CategoryName,
 		); err != nil {
 			return nil, err
 		}
@@ -211,39 +522,223 @@ func (q *Queries) ListTasksByCategoryWithSubcategories(ctx context.Context, id i
 	return items, nil
 }
 
-const updateTask = `-- name: UpdateTask :one
+const purgeTask = `-- name: PurgeTask :execrows
+DELETE FROM tasks
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Removes a soft deleted task for good, live ones are never touched
+func (q *Queries) PurgeTask(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeTask, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTask = `-- name: RestoreTask :execrows
+UPDATE tasks
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Undoes DeleteTask
+func (q *Queries) RestoreTask(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreTask, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const searchTasks = `-- name: SearchTasks :many
+SELECT t.id, t.url, t.task_category_id, t.note, t.title, t.status, t.status_color, t.created_at, t.updated_at, t.clickup_synced_at, t.due_date, t.priority, t.parent_task_id, t.created_by_user_id, t.clickup_team_id, t.deleted_at, t.tenant_id, c.name AS category_name
+FROM tasks t
+LEFT JOIN task_categories c ON c.id = t.task_category_id
+WHERE t.deleted_at IS NULL
+  AND ($1::TEXT IS NULL OR LOWER(t.status) = LOWER($1::TEXT))
+  AND ($2::INTEGER IS NULL OR t.task_category_id = $2::INTEGER)
+  AND ($3::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_assignees ta
+    WHERE ta.task_id = t.id AND ta.user_id = $3::INTEGER
+  ))
+  AND ($4::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = t.id AND tt.tag_id = $4::INTEGER
+  ))
+  AND (NOT $5::BOOLEAN OR (
+    t.due_date < CURRENT_DATE
+    AND LOWER(COALESCE(t.status, '')) NOT IN ('complete', 'completed', 'closed', 'done')
+  ))
+  AND ($6::DATE IS NULL OR t.due_date >= $6::DATE)
+  AND ($7::DATE IS NULL OR t.due_date <= $7::DATE)
+ORDER BY
+  CASE WHEN $8::TEXT = 'due_date' AND NOT $9::BOOLEAN THEN t.due_date END ASC NULLS LAST,
+  CASE WHEN $8::TEXT = 'due_date' AND $9::BOOLEAN THEN t.due_date END DESC NULLS LAST,
+  CASE WHEN $8::TEXT = 'priority' AND NOT $9::BOOLEAN THEN t.priority END ASC NULLS LAST,
+  CASE WHEN $8::TEXT = 'priority' AND $9::BOOLEAN THEN t.priority END DESC NULLS LAST,
+  CASE WHEN $8::TEXT = 'title' AND NOT $9::BOOLEAN THEN t.title END ASC,
+  CASE WHEN $8::TEXT = 'title' AND $9::BOOLEAN THEN t.title END DESC,
+  CASE WHEN $8::TEXT = 'updated_at' AND NOT $9::BOOLEAN THEN t.updated_at END ASC,
+  CASE WHEN $8::TEXT = 'updated_at' AND $9::BOOLEAN THEN t.updated_at END DESC,
+  CASE WHEN $8::TEXT = 'created_at' AND NOT $9::BOOLEAN THEN t.created_at END ASC,
+  t.created_at DESC
+LIMIT $10
+OFFSET $11
+`
+
+type SearchTasksParams struct {
+	Status      pgtype.Text `json:"status"`
+	CategoryID  pgtype.Int4 `json:"categoryId"`
+	AssigneeID  pgtype.Int4 `json:"assigneeId"`
+	TagID       pgtype.Int4 `json:"tagId"`
+	OverdueOnly bool        `json:"overdueOnly"`
+	DueFrom     pgtype.Date `json:"dueFrom"`
+	DueTo       pgtype.Date `json:"dueTo"`
+	SortBy      string      `json:"sortBy"`
+	SortDesc    bool        `json:"sortDesc"`
+	RowLimit    int32       `json:"rowLimit"`
+	RowOffset   int32       `json:"rowOffset"`
+}
+
+type SearchTasksRow struct {
+	Task         Task        `json:"task"`
+	CategoryName pgtype.Text `json:"categoryName"`
+}
+
+func (q *Queries) SearchTasks(ctx context.Context, arg SearchTasksParams) ([]SearchTasksRow, error) {
+	rows, err := q.db.Query(ctx, searchTasks,
+		arg.Status,
+		arg.CategoryID,
+		arg.AssigneeID,
+		arg.TagID,
+		arg.OverdueOnly,
+		arg.DueFrom,
+		arg.DueTo,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchTasksRow{}
+	for rows.Next() {
+		var i SearchTasksRow
+		if err := rows.Scan(
+			&i.Task.ID,
+			&i.Task.Url,
+			&i.Task.TaskCategoryID,
+			&i.Task.Note,
+			&i.Task.Title,
+			&i.Task.Status,
+			&i.Task.StatusColor,
+			&i.Task.CreatedAt,
+			&i.Task.UpdatedAt,
+			&i.Task.ClickupSyncedAt,
+			&i.Task.DueDate,
+			&i.Task.Priority,
+			&i.Task.ParentTaskID,
+			&i.Task.CreatedByUserID,
+			&i.Task.ClickupTeamID,
+			&i.Task.DeletedAt,
+			&i.Task.TenantID,
+			&i.CategoryName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setTaskParent = `-- name: SetTaskParent :one
 UPDATE tasks
 SET 
-  url = $2,
-  task_category_id = $3,
-  note = $4,
-  title = $5,
-  status = $6,
-  status_color = $7,
+  parent_task_id = $2,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, url, task_category_id, note, title, status, status_color, created_at, updated_at
+RETURNING id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id
+`
+
+type SetTaskParentParams struct {
+	ID           int32       `json:"id"`
+	ParentTaskID pgtype.Int4 `json:"parentTaskId"`
+}
+
+func (q *Queries) SetTaskParent(ctx context.Context, arg SetTaskParentParams) (Task, error) {
+	row := q.db.QueryRow(ctx, setTaskParent, arg.ID, arg.ParentTaskID)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.TaskCategoryID,
+		&i.Note,
+		&i.Title,
+		&i.Status,
+		&i.StatusColor,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClickupSyncedAt,
+		&i.DueDate,
+		&i.Priority,
+		&i.ParentTaskID,
+		&i.CreatedByUserID,
+		&i.ClickupTeamID,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateTask = `-- name: UpdateTask :one
+UPDATE tasks
+SET 
+  url = $1,
+  task_category_id = $2,
+  note = $3,
+  title = $4,
+  status = $5,
+  status_color = $6,
+  due_date = $7,
+  priority = $8,
+  updated_at = NOW()
+WHERE id = $9 AND deleted_at IS NULL
+  AND ($10::TIMESTAMPTZ IS NULL OR updated_at = $10::TIMESTAMPTZ)
+RETURNING id, url, task_category_id, note, title, status, status_color, created_at, updated_at, clickup_synced_at, due_date, priority, parent_task_id, created_by_user_id, clickup_team_id, deleted_at, tenant_id
 `
 
 type UpdateTaskParams struct {
-	ID             int32       `json:"id"`
-	Url            pgtype.Text `json:"url"`
-	TaskCategoryID pgtype.Int4 `json:"taskCategoryId"`
-	Note           pgtype.Text `json:"note"`
-	Title          pgtype.Text `json:"title"`
-	Status         pgtype.Text `json:"status"`
-	StatusColor    pgtype.Text `json:"statusColor"`
+	Url               pgtype.Text        `json:"url"`
+	TaskCategoryID    pgtype.Int4        `json:"taskCategoryId"`
+	Note              pgtype.Text        `json:"note"`
+	Title             pgtype.Text        `json:"title"`
+	Status            pgtype.Text        `json:"status"`
+	StatusColor       pgtype.Text        `json:"statusColor"`
+	DueDate           pgtype.Date        `json:"dueDate"`
+	Priority          pgtype.Int4        `json:"priority"`
+	ID                int32              `json:"id"`
+	ExpectedUpdatedAt pgtype.Timestamptz `json:"expectedUpdatedAt"`
 }
 
+// Matches no row when expected_updated_at is set and the task changed since, so concurrent edits conflict
 func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, error) {
 	row := q.db.QueryRow(ctx, updateTask,
-		arg.ID,
 		arg.Url,
 		arg.TaskCategoryID,
 		arg.Note,
 		arg.Title,
 		arg.Status,
 		arg.StatusColor,
+		arg.DueDate,
+		arg.Priority,
+		arg.ID,
+		arg.ExpectedUpdatedAt,
 	)
 	var i Task
 	err := row.Scan(
@@ -256,6 +751,14 @@ func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, e
 		&i.StatusColor,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClickupSyncedAt,
+		&i.DueDate,
+		&i.Priority,
+		&i.ParentTaskID,
+		&i.CreatedByUserID,
+		&i.ClickupTeamID,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -11,24 +11,106 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const archiveTaskCategorySubtree = `-- name: ArchiveTaskCategorySubtree :execrows
+WITH RECURSIVE subtree AS (
+  SELECT tc.id FROM task_categories tc WHERE tc.id = $1::INTEGER
+  UNION ALL
+  SELECT c.id FROM task_categories c
+  JOIN subtree s ON c.parent_id = s.id
+)
+UPDATE task_categories
+SET 
+  archived_at = NOW(),
+  updated_at = NOW()
+WHERE id IN (SELECT s.id FROM subtree s) AND archived_at IS NULL
+`
+
+func (q *Queries) ArchiveTaskCategorySubtree(ctx context.Context, categoryID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, archiveTaskCategorySubtree, categoryID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countTaskCategories = `-- name: CountTaskCategories :one
+WITH RECURSIVE scoped AS (
+  SELECT tc.id, tc.department
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL
+  UNION ALL
+  SELECT c.id, COALESCE(c.department, scoped.department)
+  FROM task_categories c
+  JOIN scoped ON c.parent_id = scoped.id
+)
+SELECT COUNT(*) FROM task_categories
+JOIN scoped ON scoped.id = task_categories.id
+WHERE task_categories.archived_at IS NULL
+  AND ($1::BOOLEAN OR scoped.department IS NULL OR scoped.department = $2::TEXT)
+`
+
+type CountTaskCategoriesParams struct {
+	AllDepartments bool        `json:"allDepartments"`
+	Department     pgtype.Text `json:"department"`
+}
+
+// Counts the categories ListTaskCategories pages through
+func (q *Queries) CountTaskCategories(ctx context.Context, arg CountTaskCategoriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTaskCategories, arg.AllDepartments, arg.Department)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTaskCategoryUsage = `-- name: CountTaskCategoryUsage :one
+SELECT
+  (SELECT COUNT(*) FROM task_categories c WHERE c.parent_id = $1::INTEGER) AS child_count,
+  (SELECT COUNT(*) FROM tasks t WHERE t.task_category_id = $1::INTEGER) AS task_count
+`
+
+type CountTaskCategoryUsageRow struct {
+	ChildCount int64 `json:"childCount"`
+	TaskCount  int64 `json:"taskCount"`
+}
+
+func (q *Queries) CountTaskCategoryUsage(ctx context.Context, categoryID int32) (CountTaskCategoryUsageRow, error) {
+	row := q.db.QueryRow(ctx, countTaskCategoryUsage, categoryID)
+	var i CountTaskCategoryUsageRow
+	err := row.Scan(&i.ChildCount, &i.TaskCount)
+	return i, err
+}
+
 const createTaskCategory = `-- name: CreateTaskCategory :one
 INSERT INTO task_categories (
   name,
   parent_id,
-  description
+  description,
+  budget_day,
+  department,
+  sort_order
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, name, parent_id, description, created_at, updated_at
+  $1, $2, $3, $4, $5,
+  -- New categories go after their existing siblings
+  COALESCE((SELECT MAX(s.sort_order) + 1 FROM task_categories s WHERE s.parent_id IS NOT DISTINCT FROM $2), 0)
+) RETURNING id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id
 `
 
 type CreateTaskCategoryParams struct {
-	Name        string      `json:"name"`
-	ParentID    pgtype.Int4 `json:"parentId"`
-	Description pgtype.Text `json:"description"`
+	Name        string         `json:"name"`
+	ParentID    pgtype.Int4    `json:"parentId"`
+	Description pgtype.Text    `json:"description"`
+	BudgetDay   pgtype.Numeric `json:"budgetDay"`
+	Department  pgtype.Text    `json:"department"`
 }
 
 func (q *Queries) CreateTaskCategory(ctx context.Context, arg CreateTaskCategoryParams) (TaskCategory, error) {
-	row := q.db.QueryRow(ctx, createTaskCategory, arg.Name, arg.ParentID, arg.Description)
+	row := q.db.QueryRow(ctx, createTaskCategory,
+		arg.Name,
+		arg.ParentID,
+		arg.Description,
+		arg.BudgetDay,
+		arg.Department,
+	)
 	var i TaskCategory
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +119,11 @@ func (q *Queries) CreateTaskCategory(ctx context.Context, arg CreateTaskCategory
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArchivedAt,
+		&i.BudgetDay,
+		&i.Department,
+		&i.SortOrder,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -51,8 +138,26 @@ func (q *Queries) DeleteTaskCategory(ctx context.Context, id int32) error {
 	return err
 }
 
+const getTaskCategoriesVersion = `-- name: GetTaskCategoriesVersion :one
+SELECT COUNT(*) AS row_count, MAX(updated_at)::TIMESTAMPTZ AS last_updated FROM task_categories
+`
+
+type GetTaskCategoriesVersionRow struct {
+	RowCount    int64              `json:"rowCount"`
+	LastUpdated pgtype.Timestamptz `json:"lastUpdated"`
+}
+
+// Changes whenever a task category is added, edited, moved, archived or deleted, for the ETag of
+// the category listings
+func (q *Queries) GetTaskCategoriesVersion(ctx context.Context) (GetTaskCategoriesVersionRow, error) {
+	row := q.db.QueryRow(ctx, getTaskCategoriesVersion)
+	var i GetTaskCategoriesVersionRow
+	err := row.Scan(&i.RowCount, &i.LastUpdated)
+	return i, err
+}
+
 const getTaskCategory = `-- name: GetTaskCategory :one
-SELECT id, name, parent_id, description, created_at, updated_at FROM task_categories
+SELECT id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id FROM task_categories
 WHERE id = $1 LIMIT 1
 `
 
@@ -66,14 +171,19 @@ func (q *Queries) GetTaskCategory(ctx context.Context, id int32) (TaskCategory,
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArchivedAt,
+		&i.BudgetDay,
+		&i.Department,
+		&i.SortOrder,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const listRootTaskCategories = `-- name: ListRootTaskCategories :many
-SELECT id, name, parent_id, description, created_at, updated_at FROM task_categories
-WHERE parent_id IS NULL
-ORDER BY name
+SELECT id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id FROM task_categories
+WHERE parent_id IS NULL AND archived_at IS NULL
+ORDER BY sort_order, name
 `
 
 func (q *Queries) ListRootTaskCategories(ctx context.Context) ([]TaskCategory, error) {
@@ -92,6 +202,11 @@ func (q *Queries) ListRootTaskCategories(ctx context.Context) ([]TaskCategory, e
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ArchivedAt,
+			&i.BudgetDay,
+			&i.Department,
+			&i.SortOrder,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -104,19 +219,39 @@ func (q *Queries) ListRootTaskCategories(ctx context.Context) ([]TaskCategory, e
 }
 
 const listTaskCategories = `-- name: ListTaskCategories :many
-SELECT id, name, parent_id, description, created_at, updated_at FROM task_categories
-ORDER BY name
-LIMIT $1
-OFFSET $2
+WITH RECURSIVE scoped AS (
+  SELECT tc.id, tc.department
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL
+  UNION ALL
+  SELECT c.id, COALESCE(c.department, scoped.department)
+  FROM task_categories c
+  JOIN scoped ON c.parent_id = scoped.id
+)
+SELECT task_categories.id, task_categories.name, task_categories.parent_id, task_categories.description, task_categories.created_at, task_categories.updated_at, task_categories.archived_at, task_categories.budget_day, task_categories.department, task_categories.sort_order, task_categories.tenant_id FROM task_categories
+JOIN scoped ON scoped.id = task_categories.id
+WHERE task_categories.archived_at IS NULL
+  AND ($1::BOOLEAN OR scoped.department IS NULL OR scoped.department = $2::TEXT)
+ORDER BY task_categories.sort_order, task_categories.name
+LIMIT $3
+OFFSET $4
 `
 
 type ListTaskCategoriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AllDepartments bool        `json:"allDepartments"`
+	Department     pgtype.Text `json:"department"`
+	Limit          int32       `json:"limit"`
+	Offset         int32       `json:"offset"`
 }
 
+// Categories visible to a department; a category inherits the department of its nearest scoped ancestor
 func (q *Queries) ListTaskCategories(ctx context.Context, arg ListTaskCategoriesParams) ([]TaskCategory, error) {
-	rows, err := q.db.Query(ctx, listTaskCategories, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listTaskCategories,
+		arg.AllDepartments,
+		arg.Department,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +266,11 @@ func (q *Queries) ListTaskCategories(ctx context.Context, arg ListTaskCategories
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ArchivedAt,
+			&i.BudgetDay,
+			&i.Department,
+			&i.SortOrder,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -143,9 +283,9 @@ func (q *Queries) ListTaskCategories(ctx context.Context, arg ListTaskCategories
 }
 
 const listTaskCategoriesByParent = `-- name: ListTaskCategoriesByParent :many
-SELECT id, name, parent_id, description, created_at, updated_at FROM task_categories
-WHERE parent_id = $1
-ORDER BY name
+SELECT id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id FROM task_categories
+WHERE parent_id = $1 AND archived_at IS NULL
+ORDER BY sort_order, name
 `
 
 func (q *Queries) ListTaskCategoriesByParent(ctx context.Context, parentID pgtype.Int4) ([]TaskCategory, error) {
@@ -164,6 +304,190 @@ func (q *Queries) ListTaskCategoriesByParent(ctx context.Context, parentID pgtyp
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ArchivedAt,
+			&i.BudgetDay,
+			&i.Department,
+			&i.SortOrder,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskCategoryEffort = `-- name: ListTaskCategoryEffort :many
+WITH RECURSIVE closure AS (
+  SELECT tc.id AS ancestor_id, tc.id AS category_id
+  FROM task_categories tc
+  WHERE tc.archived_at IS NULL
+  UNION ALL
+  SELECT closure.ancestor_id, c.id
+  FROM task_categories c
+  JOIN closure ON c.parent_id = closure.category_id
+  WHERE c.archived_at IS NULL
+), logged AS (
+  SELECT
+    closure.ancestor_id,
+    COUNT(DISTINCT tl.task_id) AS task_count,
+    SUM(tl.worked_day) AS worked_day
+  FROM closure
+  JOIN tasks t ON t.task_category_id = closure.category_id
+  JOIN task_logs tl ON tl.task_id = t.id
+  WHERE t.deleted_at IS NULL AND tl.worked_date BETWEEN $1 AND $2
+  GROUP BY closure.ancestor_id
+), current_estimates AS (
+  SELECT te.task_id, te.estimate_day
+  FROM task_estimates te
+  WHERE te.is_current
+), estimated AS (
+  -- Only tasks worked on in the period, so estimates and logs cover the same tasks
+  SELECT
+    closure.ancestor_id,
+    SUM(current_estimates.estimate_day) AS estimate_day
+  FROM closure
+  JOIN tasks t ON t.task_category_id = closure.category_id
+  JOIN current_estimates ON current_estimates.task_id = t.id
+  WHERE t.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM task_logs tl
+    WHERE tl.task_id = t.id AND tl.worked_date BETWEEN $1 AND $2
+  )
+  GROUP BY closure.ancestor_id
+)
+SELECT
+  tc.id,
+  tc.name,
+  tc.parent_id,
+  tc.budget_day,
+  COALESCE(logged.task_count, 0)::BIGINT AS task_count,
+  COALESCE(logged.worked_day, 0)::DECIMAL AS worked_day,
+  COALESCE(estimated.estimate_day, 0)::DECIMAL AS estimate_day
+FROM task_categories tc
+LEFT JOIN logged ON logged.ancestor_id = tc.id
+LEFT JOIN estimated ON estimated.ancestor_id = tc.id
+WHERE tc.archived_at IS NULL
+ORDER BY tc.name
+`
+
+type ListTaskCategoryEffortParams struct {
+	StartDate pgtype.Date `json:"startDate"`
+	EndDate   pgtype.Date `json:"endDate"`
+}
+
+type ListTaskCategoryEffortRow struct {
+	ID          int32          `json:"id"`
+	Name        string         `json:"name"`
+	ParentID    pgtype.Int4    `json:"parentId"`
+	BudgetDay   pgtype.Numeric `json:"budgetDay"`
+	TaskCount   int64          `json:"taskCount"`
+	WorkedDay   pgtype.Numeric `json:"workedDay"`
+	EstimateDay pgtype.Numeric `json:"estimateDay"`
+}
+
+// Logged days, current estimates and budget of every category, rolled up over its subtree
+func (q *Queries) ListTaskCategoryEffort(ctx context.Context, arg ListTaskCategoryEffortParams) ([]ListTaskCategoryEffortRow, error) {
+	rows, err := q.db.Query(ctx, listTaskCategoryEffort, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskCategoryEffortRow{}
+	for rows.Next() {
+		var i ListTaskCategoryEffortRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.BudgetDay,
+			&i.TaskCount,
+			&i.WorkedDay,
+			&i.EstimateDay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskCategoryTree = `-- name: ListTaskCategoryTree :many
+WITH RECURSIVE tree AS (
+  SELECT tc.id, tc.name, tc.parent_id, tc.description, tc.created_at, tc.updated_at,
+    0 AS depth, ARRAY[tc.name::TEXT] AS path, tc.department, tc.sort_order,
+    ARRAY[LPAD(tc.sort_order::TEXT, 10, '0') || tc.name::TEXT] AS sort_path
+  FROM task_categories tc
+  WHERE tc.parent_id IS NULL AND tc.archived_at IS NULL
+  UNION ALL
+  SELECT c.id, c.name, c.parent_id, c.description, c.created_at, c.updated_at,
+    tree.depth + 1, tree.path || c.name::TEXT, COALESCE(c.department, tree.department), c.sort_order,
+    tree.sort_path || (LPAD(c.sort_order::TEXT, 10, '0') || c.name::TEXT)
+  FROM task_categories c
+  JOIN tree ON c.parent_id = tree.id
+  WHERE c.archived_at IS NULL
+)
+SELECT
+  tree.id,
+  tree.name,
+  tree.parent_id,
+  tree.description,
+  tree.created_at,
+  tree.updated_at,
+  tree.depth::INTEGER AS depth,
+  tree.path::TEXT[] AS path,
+  tree.department,
+  tree.sort_order
+FROM tree
+WHERE $1::BOOLEAN OR tree.department IS NULL OR tree.department = $2::TEXT
+ORDER BY tree.sort_path
+`
+
+type ListTaskCategoryTreeParams struct {
+	AllDepartments bool        `json:"allDepartments"`
+	Department     pgtype.Text `json:"department"`
+}
+
+type ListTaskCategoryTreeRow struct {
+	ID          int32              `json:"id"`
+	Name        string             `json:"name"`
+	ParentID    pgtype.Int4        `json:"parentId"`
+	Description pgtype.Text        `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	Depth       int32              `json:"depth"`
+	Path        []string           `json:"path"`
+	Department  pgtype.Text        `json:"department"`
+	SortOrder   int32              `json:"sortOrder"`
+}
+
+// Every category with its depth, the names from the root down and its effective department,
+// parents before children. Categories scoped to other departments are left out unless all_departments is set.
+func (q *Queries) ListTaskCategoryTree(ctx context.Context, arg ListTaskCategoryTreeParams) ([]ListTaskCategoryTreeRow, error) {
+	rows, err := q.db.Query(ctx, listTaskCategoryTree, arg.AllDepartments, arg.Department)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskCategoryTreeRow{}
+	for rows.Next() {
+		var i ListTaskCategoryTreeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Depth,
+			&i.Path,
+			&i.Department,
+			&i.SortOrder,
 		); err != nil {
 			return nil, err
 		}
@@ -175,22 +499,130 @@ func (q *Queries) ListTaskCategoriesByParent(ctx context.Context, parentID pgtyp
 	return items, nil
 }
 
+const lockTaskCategories = `-- name: LockTaskCategories :exec
+SELECT id FROM task_categories FOR UPDATE
+`
+
+// Locks every category until the transaction ends, so changes to the tree checked against it
+// run one after another
+func (q *Queries) LockTaskCategories(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockTaskCategories)
+	return err
+}
+
+const moveTaskCategory = `-- name: MoveTaskCategory :one
+UPDATE task_categories
+SET 
+  parent_id = $2,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id
+`
+
+type MoveTaskCategoryParams struct {
+	ID       int32       `json:"id"`
+	ParentID pgtype.Int4 `json:"parentId"`
+}
+
+func (q *Queries) MoveTaskCategory(ctx context.Context, arg MoveTaskCategoryParams) (TaskCategory, error) {
+	row := q.db.QueryRow(ctx, moveTaskCategory, arg.ID, arg.ParentID)
+	var i TaskCategory
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ArchivedAt,
+		&i.BudgetDay,
+		&i.Department,
+		&i.SortOrder,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const reassignTaskCategoryChildren = `-- name: ReassignTaskCategoryChildren :execrows
+UPDATE task_categories
+SET 
+  parent_id = $1::INTEGER,
+  updated_at = NOW()
+WHERE parent_id = $2::INTEGER
+`
+
+type ReassignTaskCategoryChildrenParams struct {
+	TargetID   int32 `json:"targetId"`
+	CategoryID int32 `json:"categoryId"`
+}
+
+func (q *Queries) ReassignTaskCategoryChildren(ctx context.Context, arg ReassignTaskCategoryChildrenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignTaskCategoryChildren, arg.TargetID, arg.CategoryID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reassignTaskCategoryTasks = `-- name: ReassignTaskCategoryTasks :execrows
+UPDATE tasks
+SET 
+  task_category_id = $1::INTEGER,
+  updated_at = NOW()
+WHERE task_category_id = $2::INTEGER
+`
+
+type ReassignTaskCategoryTasksParams struct {
+	TargetID   int32 `json:"targetId"`
+	CategoryID int32 `json:"categoryId"`
+}
+
+func (q *Queries) ReassignTaskCategoryTasks(ctx context.Context, arg ReassignTaskCategoryTasksParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignTaskCategoryTasks, arg.TargetID, arg.CategoryID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reorderTaskCategories = `-- name: ReorderTaskCategories :execrows
+UPDATE task_categories tc
+SET 
+  sort_order = o.position - 1,
+  updated_at = NOW()
+FROM UNNEST($1::INTEGER[]) WITH ORDINALITY AS o(id, position)
+WHERE tc.id = o.id
+`
+
+// Sets the sort order of the given categories to their position in the list
+func (q *Queries) ReorderTaskCategories(ctx context.Context, categoryIds []int32) (int64, error) {
+	result, err := q.db.Exec(ctx, reorderTaskCategories, categoryIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateTaskCategory = `-- name: UpdateTaskCategory :one
 UPDATE task_categories
 SET 
   name = COALESCE($2, name),
   parent_id = $3,
   description = COALESCE($4, description),
+  budget_day = $5,
+  department = $6,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, parent_id, description, created_at, updated_at
+RETURNING id, name, parent_id, description, created_at, updated_at, archived_at, budget_day, department, sort_order, tenant_id
 `
 
 type UpdateTaskCategoryParams struct {
-	ID          int32       `json:"id"`
-	Name        string      `json:"name"`
-	ParentID    pgtype.Int4 `json:"parentId"`
-	Description pgtype.Text `json:"description"`
+	ID          int32          `json:"id"`
+	Name        string         `json:"name"`
+	ParentID    pgtype.Int4    `json:"parentId"`
+	Description pgtype.Text    `json:"description"`
+	BudgetDay   pgtype.Numeric `json:"budgetDay"`
+	Department  pgtype.Text    `json:"department"`
 }
 
 func (q *Queries) UpdateTaskCategory(ctx context.Context, arg UpdateTaskCategoryParams) (TaskCategory, error) {
@@ -199,6 +631,8 @@ func (q *Queries) UpdateTaskCategory(ctx context.Context, arg UpdateTaskCategory
 		arg.Name,
 		arg.ParentID,
 		arg.Description,
+		arg.BudgetDay,
+		arg.Department,
 	)
 	var i TaskCategory
 	err := row.Scan(
@@ -208,6 +642,11 @@ func (q *Queries) UpdateTaskCategory(ctx context.Context, arg UpdateTaskCategory
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ArchivedAt,
+		&i.BudgetDay,
+		&i.Department,
+		&i.SortOrder,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -11,15 +11,33 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countTaskEstimatesByUser = `-- name: CountTaskEstimatesByUser :one
+SELECT COUNT(*) FROM task_estimates
+WHERE created_by_user_id = $1 AND NOT is_vote
+`
+
+func (q *Queries) CountTaskEstimatesByUser(ctx context.Context, createdByUserID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countTaskEstimatesByUser, createdByUserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTaskEstimate = `-- name: CreateTaskEstimate :one
 INSERT INTO task_estimates (
   task_id,
   estimate_day,
   note,
-  created_by_user_id
+  created_by_user_id,
+  supersedes_id,
+  session_id,
+  unit,
+  estimate_value,
+  confidence,
+  is_current
 ) VALUES (
-  $1, $2, $3, $4
-) RETURNING id, task_id, estimate_day, note, created_by_user_id, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE
+) RETURNING id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id
 `
 
 type CreateTaskEstimateParams struct {
@@ -27,6 +45,11 @@ type CreateTaskEstimateParams struct {
 	EstimateDay     pgtype.Numeric `json:"estimateDay"`
 	Note            pgtype.Text    `json:"note"`
 	CreatedByUserID int32          `json:"createdByUserId"`
+	SupersedesID    pgtype.Int4    `json:"supersedesId"`
+	SessionID       pgtype.Int4    `json:"sessionId"`
+	Unit            string         `json:"unit"`
+	EstimateValue   pgtype.Numeric `json:"estimateValue"`
+	Confidence      pgtype.Text    `json:"confidence"`
 }
 
 func (q *Queries) CreateTaskEstimate(ctx context.Context, arg CreateTaskEstimateParams) (TaskEstimate, error) {
@@ -35,6 +58,11 @@ func (q *Queries) CreateTaskEstimate(ctx context.Context, arg CreateTaskEstimate
 		arg.EstimateDay,
 		arg.Note,
 		arg.CreatedByUserID,
+		arg.SupersedesID,
+		arg.SessionID,
+		arg.Unit,
+		arg.EstimateValue,
+		arg.Confidence,
 	)
 	var i TaskEstimate
 	err := row.Scan(
@@ -44,6 +72,15 @@ func (q *Queries) CreateTaskEstimate(ctx context.Context, arg CreateTaskEstimate
 		&i.Note,
 		&i.CreatedByUserID,
 		&i.CreatedAt,
+		&i.IsCurrent,
+		&i.SupersedesID,
+		&i.SupersededAt,
+		&i.SessionID,
+		&i.IsVote,
+		&i.Unit,
+		&i.EstimateValue,
+		&i.Confidence,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -58,8 +95,37 @@ func (q *Queries) DeleteTaskEstimate(ctx context.Context, id int32) error {
 	return err
 }
 
+const getCurrentTaskEstimate = `-- name: GetCurrentTaskEstimate :one
+SELECT id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id FROM task_estimates
+WHERE task_id = $1 AND is_current
+LIMIT 1
+`
+
+func (q *Queries) GetCurrentTaskEstimate(ctx context.Context, taskID int32) (TaskEstimate, error) {
+	row := q.db.QueryRow(ctx, getCurrentTaskEstimate, taskID)
+	var i TaskEstimate
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.EstimateDay,
+		&i.Note,
+		&i.CreatedByUserID,
+		&i.CreatedAt,
+		&i.IsCurrent,
+		&i.SupersedesID,
+		&i.SupersededAt,
+		&i.SessionID,
+		&i.IsVote,
+		&i.Unit,
+		&i.EstimateValue,
+		&i.Confidence,
+		&i.TenantID,
+	)
+	return i, err
+}
+
 const getTaskEstimate = `-- name: GetTaskEstimate :one
-SELECT id, task_id, estimate_day, note, created_by_user_id, created_at FROM task_estimates
+SELECT id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id FROM task_estimates
 WHERE id = $1 LIMIT 1
 `
 
@@ -73,13 +139,52 @@ func (q *Queries) GetTaskEstimate(ctx context.Context, id int32) (TaskEstimate,
 		&i.Note,
 		&i.CreatedByUserID,
 		&i.CreatedAt,
+		&i.IsCurrent,
+		&i.SupersedesID,
+		&i.SupersededAt,
+		&i.SessionID,
+		&i.IsVote,
+		&i.Unit,
+		&i.EstimateValue,
+		&i.Confidence,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const getTaskEstimateRollup = `-- name: GetTaskEstimateRollup :one
+WITH RECURSIVE subtasks AS (
+  SELECT t.id FROM tasks t WHERE t.id = $1
+  UNION ALL
+  SELECT t.id FROM tasks t
+  JOIN subtasks s ON t.parent_task_id = s.id
+), current_estimates AS (
+  SELECT te.task_id, te.estimate_day
+  FROM task_estimates te
+  WHERE te.task_id IN (SELECT s.id FROM subtasks s) AND te.is_current
+)
+SELECT
+  COUNT(*) AS estimated_task_count,
+  COALESCE(SUM(current_estimates.estimate_day), 0)::DECIMAL AS estimate_day
+FROM current_estimates
+`
+
+type GetTaskEstimateRollupRow struct {
+	EstimatedTaskCount int64          `json:"estimatedTaskCount"`
+	EstimateDay        pgtype.Numeric `json:"estimateDay"`
+}
+
+// Sums the current estimate of a task and each of its subtasks
+func (q *Queries) GetTaskEstimateRollup(ctx context.Context, taskID int32) (GetTaskEstimateRollupRow, error) {
+	row := q.db.QueryRow(ctx, getTaskEstimateRollup, taskID)
+	var i GetTaskEstimateRollupRow
+	err := row.Scan(&i.EstimatedTaskCount, &i.EstimateDay)
+	return i, err
+}
+
 const listTaskEstimatesByTask = `-- name: ListTaskEstimatesByTask :many
-SELECT id, task_id, estimate_day, note, created_by_user_id, created_at FROM task_estimates
-WHERE task_id = $1
+SELECT id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id FROM task_estimates
+WHERE task_id = $1 AND NOT is_vote
 ORDER BY created_at DESC
 `
 
@@ -99,6 +204,15 @@ func (q *Queries) ListTaskEstimatesByTask(ctx context.Context, taskID int32) ([]
 			&i.Note,
 			&i.CreatedByUserID,
 			&i.CreatedAt,
+			&i.IsCurrent,
+			&i.SupersedesID,
+			&i.SupersededAt,
+			&i.SessionID,
+			&i.IsVote,
+			&i.Unit,
+			&i.EstimateValue,
+			&i.Confidence,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -111,8 +225,8 @@ func (q *Queries) ListTaskEstimatesByTask(ctx context.Context, taskID int32) ([]
 }
 
 const listTaskEstimatesByUser = `-- name: ListTaskEstimatesByUser :many
-SELECT id, task_id, estimate_day, note, created_by_user_id, created_at FROM task_estimates
-WHERE created_by_user_id = $1
+SELECT id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id FROM task_estimates
+WHERE created_by_user_id = $1 AND NOT is_vote
 ORDER BY created_at DESC
 LIMIT $2
 OFFSET $3
@@ -140,6 +254,93 @@ func (q *Queries) ListTaskEstimatesByUser(ctx context.Context, arg ListTaskEstim
 			&i.Note,
 			&i.CreatedByUserID,
 			&i.CreatedAt,
+			&i.IsCurrent,
+			&i.SupersedesID,
+			&i.SupersededAt,
+			&i.SessionID,
+			&i.IsVote,
+			&i.Unit,
+			&i.EstimateValue,
+			&i.Confidence,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskEstimateVariance = `-- name: ListTaskEstimateVariance :many
+SELECT
+  t.id AS task_id,
+  t.title,
+  t.status,
+  te.id AS estimate_id,
+  te.estimate_day,
+  te.unit,
+  te.estimate_value,
+  te.confidence,
+  te.created_by_user_id AS estimated_by_user_id,
+  u.username AS estimated_by_username,
+  COALESCE(SUM(tl.worked_day), 0)::DECIMAL AS logged_day
+FROM task_estimates te
+JOIN tasks t ON t.id = te.task_id
+JOIN users u ON u.id = te.created_by_user_id
+JOIN task_logs tl ON tl.task_id = t.id AND tl.worked_date <= $1
+WHERE te.is_current
+  AND EXISTS (
+    SELECT 1 FROM task_logs p
+    WHERE p.task_id = t.id AND p.worked_date BETWEEN $2 AND $1
+  )
+GROUP BY t.id, t.title, t.status, te.id, te.estimate_day, te.unit, te.estimate_value, te.confidence, te.created_by_user_id, u.username
+ORDER BY t.id
+`
+
+type ListTaskEstimateVarianceParams struct {
+	EndDate   pgtype.Date `json:"endDate"`
+	StartDate pgtype.Date `json:"startDate"`
+}
+
+type ListTaskEstimateVarianceRow struct {
+	TaskID              int32          `json:"taskId"`
+	Title               pgtype.Text    `json:"title"`
+	Status              pgtype.Text    `json:"status"`
+	EstimateID          int32          `json:"estimateId"`
+	EstimateDay         pgtype.Numeric `json:"estimateDay"`
+	Unit                string         `json:"unit"`
+	EstimateValue       pgtype.Numeric `json:"estimateValue"`
+	Confidence          pgtype.Text    `json:"confidence"`
+	EstimatedByUserID   int32          `json:"estimatedByUserId"`
+	EstimatedByUsername string         `json:"estimatedByUsername"`
+	LoggedDay           pgtype.Numeric `json:"loggedDay"`
+}
+
+// Tasks with a current estimate and work logged in the period, with everything logged up to the end date
+func (q *Queries) ListTaskEstimateVariance(ctx context.Context, arg ListTaskEstimateVarianceParams) ([]ListTaskEstimateVarianceRow, error) {
+	rows, err := q.db.Query(ctx, listTaskEstimateVariance, arg.EndDate, arg.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskEstimateVarianceRow{}
+	for rows.Next() {
+		var i ListTaskEstimateVarianceRow
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.Title,
+			&i.Status,
+			&i.EstimateID,
+			&i.EstimateDay,
+			&i.Unit,
+			&i.EstimateValue,
+			&i.Confidence,
+			&i.EstimatedByUserID,
+			&i.EstimatedByUsername,
+			&i.LoggedDay,
 		); err != nil {
 			return nil, err
 		}
@@ -151,23 +352,119 @@ func (q *Queries) ListTaskEstimatesByUser(ctx context.Context, arg ListTaskEstim
 	return items, nil
 }
 
+const restoreTaskEstimate = `-- name: RestoreTaskEstimate :exec
+UPDATE task_estimates
+SET 
+  is_current = TRUE,
+  superseded_at = NULL
+WHERE id = $1
+`
+
+// Makes a superseded estimate current again, used when its successor is deleted
+func (q *Queries) RestoreTaskEstimate(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, restoreTaskEstimate, id)
+	return err
+}
+
+const supersedeTaskEstimate = `-- name: SupersedeTaskEstimate :exec
+UPDATE task_estimates
+SET 
+  is_current = FALSE,
+  superseded_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SupersedeTaskEstimate(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, supersedeTaskEstimate, id)
+	return err
+}
+
 const updateTaskEstimate = `-- name: UpdateTaskEstimate :one
 UPDATE task_estimates
 SET 
   estimate_day = $2,
-  note = $3
+  note = $3,
+  unit = $4,
+  estimate_value = $5,
+  confidence = $6
 WHERE id = $1
-RETURNING id, task_id, estimate_day, note, created_by_user_id, created_at
+RETURNING id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id
 `
 
 type UpdateTaskEstimateParams struct {
-	ID          int32          `json:"id"`
-	EstimateDay pgtype.Numeric `json:"estimateDay"`
-	Note        pgtype.Text    `json:"note"`
+	ID            int32          `json:"id"`
+	EstimateDay   pgtype.Numeric `json:"estimateDay"`
+	Note          pgtype.Text    `json:"note"`
+	Unit          string         `json:"unit"`
+	EstimateValue pgtype.Numeric `json:"estimateValue"`
+	Confidence    pgtype.Text    `json:"confidence"`
 }
 
 func (q *Queries) UpdateTaskEstimate(ctx context.Context, arg UpdateTaskEstimateParams) (TaskEstimate, error) {
-	row := q.db.QueryRow(ctx, updateTaskEstimate, arg.ID, arg.EstimateDay, arg.Note)
+	row := q.db.QueryRow(ctx, updateTaskEstimate,
+		arg.ID,
+		arg.EstimateDay,
+		arg.Note,
+		arg.Unit,
+		arg.EstimateValue,
+		arg.Confidence,
+	)
+	var i TaskEstimate
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.EstimateDay,
+		&i.Note,
+		&i.CreatedByUserID,
+		&i.CreatedAt,
+		&i.IsCurrent,
+		&i.SupersedesID,
+		&i.SupersededAt,
+		&i.SessionID,
+		&i.IsVote,
+		&i.Unit,
+		&i.EstimateValue,
+		&i.Confidence,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const upsertEstimationVote = `-- name: UpsertEstimationVote :one
+INSERT INTO task_estimates (
+  task_id,
+  estimate_day,
+  note,
+  created_by_user_id,
+  session_id,
+  is_vote
+) VALUES (
+  $1, $2, $3, $4, $5, TRUE
+) ON CONFLICT (session_id, created_by_user_id) WHERE is_vote
+DO UPDATE SET
+  estimate_day = EXCLUDED.estimate_day,
+  note = EXCLUDED.note,
+  created_at = NOW()
+RETURNING id, task_id, estimate_day, note, created_by_user_id, created_at, is_current, supersedes_id, superseded_at, session_id, is_vote, unit, estimate_value, confidence, tenant_id
+`
+
+type UpsertEstimationVoteParams struct {
+	TaskID          int32          `json:"taskId"`
+	EstimateDay     pgtype.Numeric `json:"estimateDay"`
+	Note            pgtype.Text    `json:"note"`
+	CreatedByUserID int32          `json:"createdByUserId"`
+	SessionID       pgtype.Int4    `json:"sessionId"`
+}
+
+// Stores or replaces a participant's hidden vote in an estimation session
+func (q *Queries) UpsertEstimationVote(ctx context.Context, arg UpsertEstimationVoteParams) (TaskEstimate, error) {
+	row := q.db.QueryRow(ctx, upsertEstimationVote,
+		arg.TaskID,
+		arg.EstimateDay,
+		arg.Note,
+		arg.CreatedByUserID,
+		arg.SessionID,
+	)
 	var i TaskEstimate
 	err := row.Scan(
 		&i.ID,
@@ -176,6 +473,15 @@ func (q *Queries) UpdateTaskEstimate(ctx context.Context, arg UpdateTaskEstimate
 		&i.Note,
 		&i.CreatedByUserID,
 		&i.CreatedAt,
+		&i.IsCurrent,
+		&i.SupersedesID,
+		&i.SupersededAt,
+		&i.SessionID,
+		&i.IsVote,
+		&i.Unit,
+		&i.EstimateValue,
+		&i.Confidence,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -11,6 +11,34 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countTaskLogsByUser = `-- name: CountTaskLogsByUser :one
+SELECT COUNT(*) FROM task_logs tl
+WHERE tl.created_by_user_id = $1
+  AND ($2::INTEGER IS NULL OR tl.task_id = $2::INTEGER)
+  AND ($3::DATE IS NULL OR tl.worked_date >= $3::DATE)
+  AND ($4::DATE IS NULL OR tl.worked_date <= $4::DATE)
+`
+
+type CountTaskLogsByUserParams struct {
+	UserID     int32       `json:"userId"`
+	TaskID     pgtype.Int4 `json:"taskId"`
+	WorkedFrom pgtype.Date `json:"workedFrom"`
+	WorkedTo   pgtype.Date `json:"workedTo"`
+}
+
+// Counts the logs ListTaskLogsWithDetailsByUser and ListTaskLogsWithDetailsByUserAfter page through
+func (q *Queries) CountTaskLogsByUser(ctx context.Context, arg CountTaskLogsByUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTaskLogsByUser,
+		arg.UserID,
+		arg.TaskID,
+		arg.WorkedFrom,
+		arg.WorkedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTaskLog = `-- name: CreateTaskLog :one
 INSERT INTO task_logs (
   task_id,
@@ -20,7 +48,7 @@ INSERT INTO task_logs (
   is_work_on_holiday
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday
+) RETURNING id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id
 `
 
 type CreateTaskLogParams struct {
@@ -48,10 +76,19 @@ func (q *Queries) CreateTaskLog(ctx context.Context, arg CreateTaskLogParams) (T
 		&i.WorkedDate,
 		&i.CreatedAt,
 		&i.IsWorkOnHoliday,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+type CreateTaskLogsParams struct {
+	TaskID          int32          `json:"taskId"`
+	WorkedDay       pgtype.Numeric `json:"workedDay"`
+	CreatedByUserID int32          `json:"createdByUserId"`
+	WorkedDate      pgtype.Date    `json:"workedDate"`
+	IsWorkOnHoliday pgtype.Bool    `json:"isWorkOnHoliday"`
+}
+
 const deleteTaskLog = `-- name: DeleteTaskLog :exec
 DELETE FROM task_logs
 WHERE id = $1
@@ -63,7 +100,7 @@ func (q *Queries) DeleteTaskLog(ctx context.Context, id int32) error {
 }
 
 const getTaskLog = `-- name: GetTaskLog :one
-SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday FROM task_logs
+SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id FROM task_logs
 WHERE id = $1 LIMIT 1
 `
 
@@ -78,12 +115,71 @@ func (q *Queries) GetTaskLog(ctx context.Context, id int32) (TaskLog, error) {
 		&i.WorkedDate,
 		&i.CreatedAt,
 		&i.IsWorkOnHoliday,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const hasTaskLogs = `-- name: HasTaskLogs :one
+SELECT EXISTS (
+  SELECT 1 FROM task_logs
+  WHERE task_id = $1
+) AS has_logs
+`
+
+func (q *Queries) HasTaskLogs(ctx context.Context, taskID int32) (bool, error) {
+	row := q.db.QueryRow(ctx, hasTaskLogs, taskID)
+	var has_logs bool
+	err := row.Scan(&has_logs)
+	return has_logs, err
+}
+
+const listTaskLogDailyTotals = `-- name: ListTaskLogDailyTotals :many
+WITH RECURSIVE subtasks AS (
+  SELECT t.id FROM tasks t WHERE t.id = $1
+  UNION ALL
+  SELECT t.id FROM tasks t
+  JOIN subtasks s ON t.parent_task_id = s.id
+)
+SELECT
+  worked_date,
+  SUM(worked_day)::DECIMAL AS worked_day,
+  SUM(SUM(worked_day)) OVER (ORDER BY worked_date)::DECIMAL AS cumulative_day
+FROM task_logs
+WHERE task_id IN (SELECT s.id FROM subtasks s)
+GROUP BY worked_date
+ORDER BY worked_date
+`
+
+type ListTaskLogDailyTotalsRow struct {
+	WorkedDate    pgtype.Date    `json:"workedDate"`
+	WorkedDay     pgtype.Numeric `json:"workedDay"`
+	CumulativeDay pgtype.Numeric `json:"cumulativeDay"`
+}
+
+// Includes the logs of all subtasks so effort rolls up to the parent
+func (q *Queries) ListTaskLogDailyTotals(ctx context.Context, taskID int32) ([]ListTaskLogDailyTotalsRow, error) {
+	rows, err := q.db.Query(ctx, listTaskLogDailyTotals, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskLogDailyTotalsRow{}
+	for rows.Next() {
+		var i ListTaskLogDailyTotalsRow
+		if err := rows.Scan(&i.WorkedDate, &i.WorkedDay, &i.CumulativeDay); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTaskLogsByDateRange = `-- name: ListTaskLogsByDateRange :many
-SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday FROM task_logs
+SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id FROM task_logs
 WHERE worked_date BETWEEN $1 AND $2
 ORDER BY worked_date DESC
 `
@@ -110,6 +206,7 @@ func (q *Queries) ListTaskLogsByDateRange(ctx context.Context, arg ListTaskLogsB
 			&i.WorkedDate,
 			&i.CreatedAt,
 			&i.IsWorkOnHoliday,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -122,7 +219,7 @@ func (q *Queries) ListTaskLogsByDateRange(ctx context.Context, arg ListTaskLogsB
 }
 
 const listTaskLogsByTask = `-- name: ListTaskLogsByTask :many
-SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday FROM task_logs
+SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id FROM task_logs
 WHERE task_id = $1
 ORDER BY worked_date DESC
 `
@@ -144,6 +241,7 @@ func (q *Queries) ListTaskLogsByTask(ctx context.Context, taskID int32) ([]TaskL
 			&i.WorkedDate,
 			&i.CreatedAt,
 			&i.IsWorkOnHoliday,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -156,7 +254,7 @@ func (q *Queries) ListTaskLogsByTask(ctx context.Context, taskID int32) ([]TaskL
 }
 
 const listTaskLogsByUser = `-- name: ListTaskLogsByUser :many
-SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday FROM task_logs
+SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id FROM task_logs
 WHERE created_by_user_id = $1
 ORDER BY worked_date DESC
 LIMIT $2
@@ -186,6 +284,7 @@ func (q *Queries) ListTaskLogsByUser(ctx context.Context, arg ListTaskLogsByUser
 			&i.WorkedDate,
 			&i.CreatedAt,
 			&i.IsWorkOnHoliday,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -198,7 +297,7 @@ func (q *Queries) ListTaskLogsByUser(ctx context.Context, arg ListTaskLogsByUser
 }
 
 const listTaskLogsByUserAndDateRange = `-- name: ListTaskLogsByUserAndDateRange :many
-SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday FROM task_logs
+SELECT id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id FROM task_logs
 WHERE created_by_user_id = $1 AND worked_date BETWEEN $2 AND $3
 ORDER BY worked_date DESC
 `
@@ -226,6 +325,293 @@ func (q *Queries) ListTaskLogsByUserAndDateRange(ctx context.Context, arg ListTa
 			&i.WorkedDate,
 			&i.CreatedAt,
 			&i.IsWorkOnHoliday,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskLogsWithDetailsByTask = `-- name: ListTaskLogsWithDetailsByTask :many
+SELECT tl.id, tl.task_id, tl.worked_day, tl.created_by_user_id, tl.worked_date, tl.created_at, tl.is_work_on_holiday, tl.tenant_id, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.task_id = $1
+ORDER BY tl.worked_date DESC
+`
+
+type ListTaskLogsWithDetailsByTaskRow struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	WorkedDay       pgtype.Numeric     `json:"workedDay"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	WorkedDate      pgtype.Date        `json:"workedDate"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	IsWorkOnHoliday pgtype.Bool        `json:"isWorkOnHoliday"`
+	TenantID        int32              `json:"tenantId"`
+	TaskTitle       pgtype.Text        `json:"taskTitle"`
+	Username        string             `json:"username"`
+}
+
+func (q *Queries) ListTaskLogsWithDetailsByTask(ctx context.Context, taskID int32) ([]ListTaskLogsWithDetailsByTaskRow, error) {
+	rows, err := q.db.Query(ctx, listTaskLogsWithDetailsByTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskLogsWithDetailsByTaskRow{}
+	for rows.Next() {
+		var i ListTaskLogsWithDetailsByTaskRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.WorkedDay,
+			&i.CreatedByUserID,
+			&i.WorkedDate,
+			&i.CreatedAt,
+			&i.IsWorkOnHoliday,
+			&i.TenantID,
+			&i.TaskTitle,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskLogsWithDetailsByUser = `-- name: ListTaskLogsWithDetailsByUser :many
+SELECT tl.id, tl.task_id, tl.worked_day, tl.created_by_user_id, tl.worked_date, tl.created_at, tl.is_work_on_holiday, tl.tenant_id, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = $1
+  AND ($2::INTEGER IS NULL OR tl.task_id = $2::INTEGER)
+  AND ($3::DATE IS NULL OR tl.worked_date >= $3::DATE)
+  AND ($4::DATE IS NULL OR tl.worked_date <= $4::DATE)
+ORDER BY
+  CASE WHEN $5::TEXT = 'worked_date' AND NOT $6::BOOLEAN THEN tl.worked_date END ASC,
+  CASE WHEN $5::TEXT = 'worked_day' AND NOT $6::BOOLEAN THEN tl.worked_day END ASC,
+  CASE WHEN $5::TEXT = 'worked_day' AND $6::BOOLEAN THEN tl.worked_day END DESC,
+  CASE WHEN $5::TEXT = 'created_at' AND NOT $6::BOOLEAN THEN tl.created_at END ASC,
+  CASE WHEN $5::TEXT = 'created_at' AND $6::BOOLEAN THEN tl.created_at END DESC,
+  tl.worked_date DESC
+LIMIT $7
+OFFSET $8
+`
+
+type ListTaskLogsWithDetailsByUserParams struct {
+	UserID     int32       `json:"userId"`
+	TaskID     pgtype.Int4 `json:"taskId"`
+	WorkedFrom pgtype.Date `json:"workedFrom"`
+	WorkedTo   pgtype.Date `json:"workedTo"`
+	SortBy     string      `json:"sortBy"`
+	SortDesc   bool        `json:"sortDesc"`
+	RowLimit   int32       `json:"rowLimit"`
+	RowOffset  int32       `json:"rowOffset"`
+}
+
+type ListTaskLogsWithDetailsByUserRow struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	WorkedDay       pgtype.Numeric     `json:"workedDay"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	WorkedDate      pgtype.Date        `json:"workedDate"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	IsWorkOnHoliday pgtype.Bool        `json:"isWorkOnHoliday"`
+	TenantID        int32              `json:"tenantId"`
+	TaskTitle       pgtype.Text        `json:"taskTitle"`
+	Username        string             `json:"username"`
+}
+
+// Page of a user's logs, narrowed by task and worked date range when given, in sort_by order and
+// otherwise latest worked date first
+func (q *Queries) ListTaskLogsWithDetailsByUser(ctx context.Context, arg ListTaskLogsWithDetailsByUserParams) ([]ListTaskLogsWithDetailsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listTaskLogsWithDetailsByUser,
+		arg.UserID,
+		arg.TaskID,
+		arg.WorkedFrom,
+		arg.WorkedTo,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskLogsWithDetailsByUserRow{}
+	for rows.Next() {
+		var i ListTaskLogsWithDetailsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.WorkedDay,
+			&i.CreatedByUserID,
+			&i.WorkedDate,
+			&i.CreatedAt,
+			&i.IsWorkOnHoliday,
+			&i.TenantID,
+			&i.TaskTitle,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskLogsWithDetailsByUserAfter = `-- name: ListTaskLogsWithDetailsByUserAfter :many
+SELECT tl.id, tl.task_id, tl.worked_day, tl.created_by_user_id, tl.worked_date, tl.created_at, tl.is_work_on_holiday, tl.tenant_id, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = $1
+  AND ($2::INTEGER IS NULL OR tl.task_id = $2::INTEGER)
+  AND ($3::DATE IS NULL OR tl.worked_date >= $3::DATE)
+  AND ($4::DATE IS NULL OR tl.worked_date <= $4::DATE)
+  AND ($5::TIMESTAMPTZ IS NULL
+    OR (tl.created_at, tl.id) < ($5::TIMESTAMPTZ, $6::INTEGER))
+ORDER BY tl.created_at DESC, tl.id DESC
+LIMIT $7
+`
+
+type ListTaskLogsWithDetailsByUserAfterParams struct {
+	UserID          int32              `json:"userId"`
+	TaskID          pgtype.Int4        `json:"taskId"`
+	WorkedFrom      pgtype.Date        `json:"workedFrom"`
+	WorkedTo        pgtype.Date        `json:"workedTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        int32              `json:"cursorId"`
+	RowLimit        int32              `json:"rowLimit"`
+}
+
+type ListTaskLogsWithDetailsByUserAfterRow struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	WorkedDay       pgtype.Numeric     `json:"workedDay"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	WorkedDate      pgtype.Date        `json:"workedDate"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	IsWorkOnHoliday pgtype.Bool        `json:"isWorkOnHoliday"`
+	TenantID        int32              `json:"tenantId"`
+	TaskTitle       pgtype.Text        `json:"taskTitle"`
+	Username        string             `json:"username"`
+}
+
+// Keyset page of a user's logs, newest first, starting after the (created_at, id) cursor when given
+func (q *Queries) ListTaskLogsWithDetailsByUserAfter(ctx context.Context, arg ListTaskLogsWithDetailsByUserAfterParams) ([]ListTaskLogsWithDetailsByUserAfterRow, error) {
+	rows, err := q.db.Query(ctx, listTaskLogsWithDetailsByUserAfter,
+		arg.UserID,
+		arg.TaskID,
+		arg.WorkedFrom,
+		arg.WorkedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskLogsWithDetailsByUserAfterRow{}
+	for rows.Next() {
+		var i ListTaskLogsWithDetailsByUserAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.WorkedDay,
+			&i.CreatedByUserID,
+			&i.WorkedDate,
+			&i.CreatedAt,
+			&i.IsWorkOnHoliday,
+			&i.TenantID,
+			&i.TaskTitle,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskLogsWithDetailsByUserAndDateRange = `-- name: ListTaskLogsWithDetailsByUserAndDateRange :many
+SELECT tl.id, tl.task_id, tl.worked_day, tl.created_by_user_id, tl.worked_date, tl.created_at, tl.is_work_on_holiday, tl.tenant_id, t.title AS task_title, u.username
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.created_by_user_id = $1 AND tl.worked_date BETWEEN $2 AND $3
+  AND ($4::INTEGER IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt
+    WHERE tt.task_id = tl.task_id AND tt.tag_id = $4::INTEGER
+  ))
+ORDER BY tl.worked_date DESC
+`
+
+type ListTaskLogsWithDetailsByUserAndDateRangeParams struct {
+	CreatedByUserID int32       `json:"createdByUserId"`
+	WorkedDate      pgtype.Date `json:"workedDate"`
+	WorkedDate_2    pgtype.Date `json:"workedDate2"`
+	TagID           pgtype.Int4 `json:"tagId"`
+}
+
+type ListTaskLogsWithDetailsByUserAndDateRangeRow struct {
+	ID              int32              `json:"id"`
+	TaskID          int32              `json:"taskId"`
+	WorkedDay       pgtype.Numeric     `json:"workedDay"`
+	CreatedByUserID int32              `json:"createdByUserId"`
+	WorkedDate      pgtype.Date        `json:"workedDate"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	IsWorkOnHoliday pgtype.Bool        `json:"isWorkOnHoliday"`
+	TenantID        int32              `json:"tenantId"`
+	TaskTitle       pgtype.Text        `json:"taskTitle"`
+	Username        string             `json:"username"`
+}
+
+func (q *Queries) ListTaskLogsWithDetailsByUserAndDateRange(ctx context.Context, arg ListTaskLogsWithDetailsByUserAndDateRangeParams) ([]ListTaskLogsWithDetailsByUserAndDateRangeRow, error) {
+	rows, err := q.db.Query(ctx, listTaskLogsWithDetailsByUserAndDateRange,
+		arg.CreatedByUserID,
+		arg.WorkedDate,
+		arg.WorkedDate_2,
+		arg.TagID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTaskLogsWithDetailsByUserAndDateRangeRow{}
+	for rows.Next() {
+		var i ListTaskLogsWithDetailsByUserAndDateRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.WorkedDay,
+			&i.CreatedByUserID,
+			&i.WorkedDate,
+			&i.CreatedAt,
+			&i.IsWorkOnHoliday,
+			&i.TenantID,
+			&i.TaskTitle,
+			&i.Username,
 		); err != nil {
 			return nil, err
 		}
@@ -237,6 +623,219 @@ func (q *Queries) ListTaskLogsByUserAndDateRange(ctx context.Context, arg ListTa
 	return items, nil
 }
 
+const listTimesheetEntries = `-- name: ListTimesheetEntries :many
+SELECT tl.id, tl.worked_date, tl.created_by_user_id AS user_id, u.username, u.department, tl.task_id, t.title AS task_title, tl.worked_day, tl.is_work_on_holiday
+FROM task_logs tl
+JOIN tasks t ON t.id = tl.task_id
+JOIN users u ON u.id = tl.created_by_user_id
+WHERE tl.worked_date BETWEEN $1::DATE AND $2::DATE
+  AND ($3::INTEGER IS NULL OR tl.created_by_user_id = $3::INTEGER)
+  AND ($4::TEXT IS NULL OR u.department IN (SELECT department_and_below($4::TEXT)))
+ORDER BY u.username, tl.created_by_user_id, tl.worked_date, tl.id
+`
+
+type ListTimesheetEntriesParams struct {
+	StartDate  pgtype.Date `json:"startDate"`
+	EndDate    pgtype.Date `json:"endDate"`
+	UserID     pgtype.Int4 `json:"userId"`
+	Department pgtype.Text `json:"department"`
+}
+
+type ListTimesheetEntriesRow struct {
+	ID              int32          `json:"id"`
+	WorkedDate      pgtype.Date    `json:"workedDate"`
+	UserID          int32          `json:"userId"`
+	Username        string         `json:"username"`
+	Department      pgtype.Text    `json:"department"`
+	TaskID          int32          `json:"taskId"`
+	TaskTitle       pgtype.Text    `json:"taskTitle"`
+	WorkedDay       pgtype.Numeric `json:"workedDay"`
+	IsWorkOnHoliday pgtype.Bool    `json:"isWorkOnHoliday"`
+}
+
+// Work logged in a date range with its task and who logged it, for everyone, one user or one department
+func (q *Queries) ListTimesheetEntries(ctx context.Context, arg ListTimesheetEntriesParams) ([]ListTimesheetEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listTimesheetEntries,
+		arg.StartDate,
+		arg.EndDate,
+		arg.UserID,
+		arg.Department,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTimesheetEntriesRow{}
+	for rows.Next() {
+		var i ListTimesheetEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkedDate,
+			&i.UserID,
+			&i.Username,
+			&i.Department,
+			&i.TaskID,
+			&i.TaskTitle,
+			&i.WorkedDay,
+			&i.IsWorkOnHoliday,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnloggedDays = `-- name: ListUnloggedDays :many
+SELECT d::DATE AS day
+FROM generate_series($1::DATE, $2::DATE, INTERVAL '1 day') AS d
+JOIN users u ON u.id = $3
+WHERE EXTRACT(ISODOW FROM d) < 6
+  AND d::DATE >= COALESCE(u.created_at::DATE, $1::DATE)
+  AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = d::DATE)
+  AND NOT EXISTS (SELECT 1 FROM task_logs tl WHERE tl.created_by_user_id = $3 AND tl.worked_date = d::DATE)
+  AND NOT EXISTS (SELECT 1 FROM leave_logs ll WHERE ll.user_id = $3 AND ll.date = d::DATE AND ll.deleted_at IS NULL)
+ORDER BY day DESC
+`
+
+type ListUnloggedDaysParams struct {
+	StartDate pgtype.Date `json:"startDate"`
+	EndDate   pgtype.Date `json:"endDate"`
+	UserID    int32       `json:"userId"`
+}
+
+// Working days in a date range, since the user joined, on which they neither logged work nor took leave, the latest first
+func (q *Queries) ListUnloggedDays(ctx context.Context, arg ListUnloggedDaysParams) ([]pgtype.Date, error) {
+	rows, err := q.db.Query(ctx, listUnloggedDays, arg.StartDate, arg.EndDate, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.Date{}
+	for rows.Next() {
+		var day pgtype.Date
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		items = append(items, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserUtilization = `-- name: ListUserUtilization :many
+WITH working_days AS (
+  SELECT d::DATE AS day
+  FROM generate_series($1::DATE, $2::DATE, INTERVAL '1 day') AS d
+  WHERE EXTRACT(ISODOW FROM d) < 6
+    AND NOT EXISTS (SELECT 1 FROM holidays h WHERE h.date = d::DATE)
+), leave_days AS (
+  SELECT ll.user_id, COUNT(DISTINCT ll.date) AS leave_day_count
+  FROM leave_logs ll
+  JOIN working_days wd ON wd.day = ll.date
+  WHERE ll.deleted_at IS NULL
+  GROUP BY ll.user_id
+), worked AS (
+  SELECT tl.created_by_user_id AS user_id, SUM(tl.worked_day) AS worked_day
+  FROM task_logs tl
+  WHERE tl.worked_date BETWEEN $1::DATE AND $2::DATE
+  GROUP BY tl.created_by_user_id
+)
+SELECT
+  u.id AS user_id,
+  u.username,
+  u.department,
+  u.daily_capacity,
+  (SELECT COUNT(*) FROM working_days) AS working_day_count,
+  COALESCE(ld.leave_day_count, 0)::BIGINT AS leave_day_count,
+  COALESCE(w.worked_day, 0)::DECIMAL AS worked_day
+FROM users u
+LEFT JOIN leave_days ld ON ld.user_id = u.id
+LEFT JOIN worked w ON w.user_id = u.id
+WHERE u.deleted_at IS NULL
+  AND ($3::INTEGER IS NULL OR u.id = $3::INTEGER)
+  AND ($4::TEXT IS NULL OR u.department IN (SELECT department_and_below($4::TEXT)))
+ORDER BY u.username
+`
+
+type ListUserUtilizationParams struct {
+	StartDate  pgtype.Date `json:"startDate"`
+	EndDate    pgtype.Date `json:"endDate"`
+	UserID     pgtype.Int4 `json:"userId"`
+	Department pgtype.Text `json:"department"`
+}
+
+type ListUserUtilizationRow struct {
+	UserID          int32          `json:"userId"`
+	Username        string         `json:"username"`
+	Department      pgtype.Text    `json:"department"`
+	DailyCapacity   pgtype.Numeric `json:"dailyCapacity"`
+	WorkingDayCount int64          `json:"workingDayCount"`
+	LeaveDayCount   int64          `json:"leaveDayCount"`
+	WorkedDay       pgtype.Numeric `json:"workedDay"`
+}
+
+// Work logged per user in a date range against the working days they had, for everyone, one user or one department
+func (q *Queries) ListUserUtilization(ctx context.Context, arg ListUserUtilizationParams) ([]ListUserUtilizationRow, error) {
+	rows, err := q.db.Query(ctx, listUserUtilization,
+		arg.StartDate,
+		arg.EndDate,
+		arg.UserID,
+		arg.Department,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserUtilizationRow{}
+	for rows.Next() {
+		var i ListUserUtilizationRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Department,
+			&i.DailyCapacity,
+			&i.WorkingDayCount,
+			&i.LeaveDayCount,
+			&i.WorkedDay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumTaskLogWorkedDaysForDate = `-- name: SumTaskLogWorkedDaysForDate :one
+SELECT COALESCE(SUM(worked_day), 0)::float8 AS total
+FROM task_logs
+WHERE created_by_user_id = $1
+  AND worked_date = $2
+  AND id <> $3
+`
+
+type SumTaskLogWorkedDaysForDateParams struct {
+	UserID     int32       `json:"userId"`
+	WorkedDate pgtype.Date `json:"workedDate"`
+	ExcludeID  int32       `json:"excludeId"`
+}
+
+// Sums the days a user logged on one date, leaving out the log being updated
+func (q *Queries) SumTaskLogWorkedDaysForDate(ctx context.Context, arg SumTaskLogWorkedDaysForDateParams) (float64, error) {
+	row := q.db.QueryRow(ctx, sumTaskLogWorkedDaysForDate, arg.UserID, arg.WorkedDate, arg.ExcludeID)
+	var total float64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const updateTaskLog = `-- name: UpdateTaskLog :one
 UPDATE task_logs
 SET 
@@ -244,7 +843,7 @@ SET
   worked_date = $3,
   is_work_on_holiday = $4
 WHERE id = $1
-RETURNING id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday
+RETURNING id, task_id, worked_day, created_by_user_id, worked_date, created_at, is_work_on_holiday, tenant_id
 `
 
 type UpdateTaskLogParams struct {
@@ -270,6 +869,7 @@ func (q *Queries) UpdateTaskLog(ctx context.Context, arg UpdateTaskLogParams) (T
 		&i.WorkedDate,
 		&i.CreatedAt,
 		&i.IsWorkOnHoliday,
+		&i.TenantID,
 	)
 	return i, err
 }
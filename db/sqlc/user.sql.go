@@ -7,24 +7,40 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE deleted_at IS NULL
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username,
   password,
   user_type,
-  email
+  email,
+  daily_capacity
 ) VALUES (
-  $1, $2, $3, $4
-) RETURNING id, username, password, user_type, email, created_at, updated_at
+  $1, $2, $3, $4, COALESCE($5::DECIMAL, 1.00)
+) RETURNING id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id
 `
 
 type CreateUserParams struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	UserType string `json:"userType"`
-	Email    string `json:"email"`
+	Username      string         `json:"username"`
+	Password      string         `json:"password"`
+	UserType      string         `json:"userType"`
+	Email         string         `json:"email"`
+	DailyCapacity pgtype.Numeric `json:"dailyCapacity"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -33,6 +49,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Password,
 		arg.UserType,
 		arg.Email,
+		arg.DailyCapacity,
 	)
 	var i User
 	err := row.Scan(
@@ -43,23 +60,29 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DailyCapacity,
+		&i.Department,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users
-WHERE id = $1
+UPDATE users
+SET deleted_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
+// Soft deletes the user, the row stays until PurgeUser removes it
 func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
 	_, err := q.db.Exec(ctx, deleteUser, id)
 	return err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, password, user_type, email, created_at, updated_at FROM users
-WHERE id = $1 LIMIT 1
+SELECT id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id FROM users
+WHERE id = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
@@ -73,13 +96,17 @@ func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DailyCapacity,
+		&i.Department,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, password, user_type, email, created_at, updated_at FROM users
-WHERE email = $1 LIMIT 1
+SELECT id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id FROM users
+WHERE email = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -93,13 +120,17 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DailyCapacity,
+		&i.Department,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, user_type, email, created_at, updated_at FROM users
-WHERE username = $1 LIMIT 1
+SELECT id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id FROM users
+WHERE username = $1 AND deleted_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
@@ -113,12 +144,44 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DailyCapacity,
+		&i.Department,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const listPurgeableUserIDs = `-- name: ListPurgeableUserIDs :many
+SELECT id FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at < $1::TIMESTAMPTZ
+ORDER BY deleted_at
+`
+
+// Users deleted before the cutoff, oldest deletion first
+func (q *Queries) ListPurgeableUserIDs(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listPurgeableUserIDs, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, password, user_type, email, created_at, updated_at FROM users
+SELECT id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id FROM users
+WHERE deleted_at IS NULL
 ORDER BY id
 LIMIT $2
 OFFSET $1
@@ -146,6 +209,10 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Email,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DailyCapacity,
+			&i.Department,
+			&i.DeletedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -157,6 +224,36 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const purgeUser = `-- name: PurgeUser :execrows
+DELETE FROM users
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Removes a soft deleted user for good, live users are never touched
+func (q *Queries) PurgeUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE users
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM anonymizations a WHERE a.user_id = users.id)
+`
+
+// Undoes DeleteUser. Anonymized users stay deleted, there is nothing of them left to bring back.
+func (q *Queries) RestoreUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET 
@@ -164,17 +261,21 @@ SET
   password = COALESCE($2, password),
   user_type = COALESCE($3, user_type),
   email = COALESCE($4, email),
+  daily_capacity = COALESCE($5::DECIMAL, daily_capacity),
+  department = NULLIF(COALESCE($6::TEXT, department), ''),
   updated_at = NOW()
-WHERE id = $5
-RETURNING id, username, password, user_type, email, created_at, updated_at
+WHERE id = $7 AND deleted_at IS NULL
+RETURNING id, username, password, user_type, email, created_at, updated_at, daily_capacity, department, deleted_at, tenant_id
 `
 
 type UpdateUserParams struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	UserType string `json:"userType"`
-	Email    string `json:"email"`
-	ID       int32  `json:"id"`
+	Username      string         `json:"username"`
+	Password      string         `json:"password"`
+	UserType      string         `json:"userType"`
+	Email         string         `json:"email"`
+	DailyCapacity pgtype.Numeric `json:"dailyCapacity"`
+	Department    pgtype.Text    `json:"department"`
+	ID            int32          `json:"id"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -183,6 +284,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.Password,
 		arg.UserType,
 		arg.Email,
+		arg.DailyCapacity,
+		arg.Department,
 		arg.ID,
 	)
 	var i User
@@ -194,6 +297,10 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DailyCapacity,
+		&i.Department,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -0,0 +1,158 @@
+package db_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	// queryHeaderPattern matches the annotation naming a query in db/query, like
+	// -- name: CountHolidays :one
+	queryHeaderPattern = regexp.MustCompile(`(?m)^-- name: (\w+) (:\w+)$`)
+	// generatedHeaderPattern matches the same annotation at the start of a query sqlc generated
+	generatedHeaderPattern = regexp.MustCompile("(?m)^const \\w+ = `-- name: (\\w+) (:\\w+)$")
+	// taskTablePattern matches the tasks table read in a FROM or JOIN, with the word after it
+	// that may be its alias
+	taskTablePattern = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+tasks\b(?:\s+(?:AS\s+)?(\w+))?`)
+	// sqlCommentPattern matches a -- comment up to the end of its line
+	sqlCommentPattern = regexp.MustCompile(`--[^\n]*`)
+)
+
+// deletedTaskReaders are the queries that read tasks without leaving the deleted ones out: they
+// start from a task ID the caller already looked up, or report on work logged before a task was
+// deleted
+var deletedTaskReaders = map[string]bool{
+	"CountTaskCategoryUsage":                    true, // Deleted tasks still hold on to their category
+	"GetClickUpSyncStats":                       true,
+	"GetTaskEstimateRollup":                     true,
+	"ListEstimationSessionsAwaitingVote":        true,
+	"ListTaskAncestorIDs":                       true,
+	"ListTaskCategoryWorkByUser":                true,
+	"ListTaskEstimateVariance":                  true,
+	"ListTaskLogDailyTotals":                    true,
+	"ListTaskLogsWithDetailsByTask":             true,
+	"ListTaskLogsWithDetailsByUser":             true,
+	"ListTaskLogsWithDetailsByUserAfter":        true,
+	"ListTaskLogsWithDetailsByUserAndDateRange": true,
+	"ListTimesheetEntries":                      true,
+}
+
+// sqlKeywords are the words that can follow a table without an alias
+var sqlKeywords = map[string]bool{
+	"where": true, "join": true, "left": true, "right": true, "inner": true, "full": true, "cross": true,
+	"on": true, "using": true, "group": true, "order": true, "limit": true, "union": true, "for": true,
+	"set": true, "returning": true, "and": true, "or": true, "having": true, "window": true,
+}
+
+// TestGeneratedQueriesMatchSources fails when db/sqlc no longer matches db/query, like after an
+// edit of the generated code that running sqlc generate would undo. Change the query in
+// db/query and run sqlc generate instead.
+func TestGeneratedQueriesMatchSources(t *testing.T) {
+	sources := annotations(t, "query/*.sql", queryHeaderPattern)
+	generated := annotations(t, "sqlc/*.sql.go", generatedHeaderPattern)
+
+	for name, source := range sources {
+		// COPY FROM queries are generated as methods in copyfrom.go, without their text
+		if source.command == ":copyfrom" {
+			continue
+		}
+		got, ok := generated[name]
+		switch {
+		case !ok:
+			t.Errorf("%s %s in db/query isn't generated, run sqlc generate", name, source.command)
+		case got.command != source.command:
+			t.Errorf("%s is %s in db/query but generated as %s, run sqlc generate", name, source.command, got.command)
+		}
+	}
+	for name := range generated {
+		if _, ok := sources[name]; !ok {
+			t.Errorf("generated query %s has no source in db/query, run sqlc generate", name)
+		}
+	}
+}
+
+// TestQueriesSkipDeletedTasks fails when a query reads the tasks table without checking
+// deleted_at for each time it reads it, so soft-deleted tasks don't count towards totals. Both
+// db/query and the generated code are checked, as a query may have been edited by hand in the
+// generated code only.
+func TestQueriesSkipDeletedTasks(t *testing.T) {
+	for _, files := range []struct {
+		pattern string
+		header  *regexp.Regexp
+	}{
+		{"query/*.sql", queryHeaderPattern},
+		{"sqlc/*.sql.go", generatedHeaderPattern},
+	} {
+		for name, query := range annotations(t, files.pattern, files.header) {
+			if deletedTaskReaders[name] {
+				continue
+			}
+			text := sqlCommentPattern.ReplaceAllString(query.text, "")
+
+			// Count the reads of tasks per alias, the unaliased ones under ""
+			reads := make(map[string]int)
+			for _, match := range taskTablePattern.FindAllStringSubmatch(text, -1) {
+				alias := match[1]
+				if sqlKeywords[strings.ToLower(alias)] {
+					alias = ""
+				}
+				reads[alias]++
+			}
+			for alias, count := range reads {
+				column := regexp.MustCompile(`(?:^|[^\w.])deleted_at\b`)
+				if alias != "" {
+					column = regexp.MustCompile(`\b` + regexp.QuoteMeta(alias) + `\.deleted_at\b`)
+				}
+				if checks := len(column.FindAllString(text, -1)); checks < count {
+					t.Errorf("%s in %s reads tasks %s %d times but checks deleted_at %d times", name, files.pattern, alias, count, checks)
+				}
+			}
+		}
+	}
+}
+
+// query is a query named in db/query or generated from one
+type query struct {
+	command string
+	text    string
+}
+
+// annotations returns the command and text of every query named in the files matching pattern
+func annotations(t *testing.T, pattern string, header *regexp.Regexp) map[string]query {
+	t.Helper()
+	files, err := filepath.Glob(pattern)
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no files match %s: %v", pattern, err)
+	}
+
+	queries := make(map[string]query)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := string(content)
+		matches := header.FindAllStringSubmatchIndex(text, -1)
+		for i, match := range matches {
+			name, command := text[match[2]:match[3]], text[match[4]:match[5]]
+			if _, ok := queries[name]; ok {
+				t.Errorf("query %s is named twice in %s", name, pattern)
+			}
+
+			// A query runs to the next one, or to the end of its string in generated code
+			end := len(text)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			body := text[match[1]:end]
+			if before, _, ok := strings.Cut(body, "`"); ok {
+				body = before
+			}
+			queries[name] = query{command: command, text: body}
+		}
+	}
+	return queries
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"context"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Store is the data layer used by the server: every generated query plus transactions.
+// DB implements it on PostgreSQL, and tests can substitute an in-memory fake.
+type Store interface {
+	sqlc.Querier
+
+	// WithTx runs fn inside a transaction, committing when it returns nil
+	WithTx(ctx context.Context, fn func(q sqlc.Querier) error) error
+	// WithTxContext is WithTx for nested transactions, see DB.WithTxContext
+	WithTxContext(ctx context.Context, fn func(ctx context.Context, q sqlc.Querier) error) error
+}
+
+var _ Store = (*DB)(nil)
@@ -0,0 +1,164 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// taskLogPage is the page size of the task log listings, the default of GET /api/task-logs
+const taskLogPage = 50
+
+// seedTaskLogs creates a user with logs task logs, spread over tasks new tasks and the days of 2025
+func seedTaskLogs(tb testing.TB, database *db.DB, tasks, logs int) (sqlc.User, []sqlc.Task) {
+	tb.Helper()
+	ctx := context.Background()
+
+	user, err := database.CreateUser(ctx, sqlc.CreateUserParams{
+		Username: "bench",
+		Password: "-",
+		UserType: "user",
+		Email:    "bench@example.com",
+	})
+	if err != nil {
+		tb.Fatalf("creating the user: %v", err)
+	}
+	created := make([]sqlc.Task, tasks)
+	for i := range created {
+		title := pgtype.Text{String: fmt.Sprintf("Task %d", i+1), Valid: true}
+		if created[i], err = database.CreateTask(ctx, sqlc.CreateTaskParams{Title: title}); err != nil {
+			tb.Fatalf("creating task %d: %v", i+1, err)
+		}
+	}
+
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]sqlc.CreateTaskLogsParams, logs)
+	for i := range rows {
+		rows[i] = sqlc.CreateTaskLogsParams{
+			TaskID:          created[i%tasks].ID,
+			WorkedDay:       pgconv.MustFromFloat(0.25),
+			CreatedByUserID: user.ID,
+			WorkedDate:      pgtype.Date{Time: start.AddDate(0, 0, i%365), Valid: true},
+		}
+	}
+	if _, err := database.CreateTaskLogs(ctx, rows); err != nil {
+		tb.Fatalf("copying the task logs: %v", err)
+	}
+	return user, created
+}
+
+// BenchmarkListTaskLogsWithDetailsByUser compares a page of a user's logs with task titles and
+// usernames loaded by the JOIN against the per-row GetTask and GetUser lookups it replaced
+func BenchmarkListTaskLogsWithDetailsByUser(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	user, _ := seedTaskLogs(b, database, 20, 5000)
+	ctx := context.Background()
+
+	b.Run("join", func(b *testing.B) {
+		for b.Loop() {
+			rows, err := database.ListTaskLogsWithDetailsByUser(ctx, sqlc.ListTaskLogsWithDetailsByUserParams{
+				UserID:   user.ID,
+				SortBy:   "worked_date",
+				SortDesc: true,
+				RowLimit: taskLogPage,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(rows) != taskLogPage {
+				b.Fatalf("got %d logs, want %d", len(rows), taskLogPage)
+			}
+		}
+	})
+
+	b.Run("per-row", func(b *testing.B) {
+		for b.Loop() {
+			logs, err := database.ListTaskLogsByUser(ctx, sqlc.ListTaskLogsByUserParams{
+				CreatedByUserID: user.ID,
+				Limit:           taskLogPage,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, log := range logs {
+				if _, err := database.GetTask(ctx, log.TaskID); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := database.GetUser(ctx, log.CreatedByUserID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkListTaskLogsWithDetailsByUserAfter pages through a user's logs by cursor, the cost of
+// a late page staying that of the first
+func BenchmarkListTaskLogsWithDetailsByUserAfter(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	user, _ := seedTaskLogs(b, database, 20, 5000)
+	ctx := context.Background()
+
+	for b.Loop() {
+		params := sqlc.ListTaskLogsWithDetailsByUserAfterParams{UserID: user.ID, RowLimit: taskLogPage}
+		for page := 0; page < 10; page++ {
+			rows, err := database.ListTaskLogsWithDetailsByUserAfter(ctx, params)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(rows) == 0 {
+				b.Fatalf("page %d is empty", page+1)
+			}
+			last := rows[len(rows)-1]
+			params.CursorCreatedAt = last.CreatedAt
+			params.CursorID = last.ID
+		}
+	}
+}
+
+// BenchmarkListTaskLogsWithDetailsByTask lists every log of one task
+func BenchmarkListTaskLogsWithDetailsByTask(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	_, tasks := seedTaskLogs(b, database, 20, 5000)
+	ctx := context.Background()
+
+	for b.Loop() {
+		rows, err := database.ListTaskLogsWithDetailsByTask(ctx, tasks[0].ID)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(rows) != 5000/20 {
+			b.Fatalf("got %d logs, want %d", len(rows), 5000/20)
+		}
+	}
+}
+
+// BenchmarkListTaskLogsWithDetailsByUserAndDateRange lists a user's logs of one month
+func BenchmarkListTaskLogsWithDetailsByUserAndDateRange(b *testing.B) {
+	database := dbtest.NewPostgres(b)
+	user, _ := seedTaskLogs(b, database, 20, 5000)
+	ctx := context.Background()
+
+	params := sqlc.ListTaskLogsWithDetailsByUserAndDateRangeParams{
+		CreatedByUserID: user.ID,
+		WorkedDate:      pgtype.Date{Time: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		WorkedDate_2:    pgtype.Date{Time: time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+	for b.Loop() {
+		rows, err := database.ListTaskLogsWithDetailsByUserAndDateRange(ctx, params)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(rows) == 0 {
+			b.Fatal("no logs in March")
+		}
+	}
+}
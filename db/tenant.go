@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultTenantID is the tenant rows written before multi-tenancy belong to, and the one a
+// single-tenant deployment keeps using
+const DefaultTenantID int32 = 1
+
+// tenantSetting is the session setting the row-level security policies read the connection's
+// tenant from, see db/migrations/000053_tenant_isolation_fail_closed.up.sql
+const tenantSetting = "ngtableg.tenant_id"
+
+// allTenantsSetting is the session setting that lets a connection bound to no tenant see and
+// write every tenant's rows
+const allTenantsSetting = "ngtableg.all_tenants"
+
+// tenantKey is the context key under which WithTenant stores the tenant
+type tenantKey struct{}
+
+// allTenantsKey is the context key under which WithAllTenants marks a context
+type allTenantsKey struct{}
+
+// WithTenant returns a context whose queries only see and write rows of the given tenant
+func WithTenant(ctx context.Context, tenantID int32) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set with WithTenant. With several tenants, queries of a
+// context without one see no rows, unless it comes from WithAllTenants.
+func TenantFromContext(ctx context.Context) (int32, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(int32)
+	return tenantID, ok
+}
+
+// WithAllTenants returns a context whose queries see and write the rows of every tenant, for the
+// few jobs that span them, like claiming queued jobs and deleting expired rows. A tenant set with
+// WithTenant takes precedence.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// tenantBinding is what a connection is bound to: a tenant, every tenant, or neither
+type tenantBinding struct {
+	tenant     string
+	allTenants string
+}
+
+// bindingFromContext returns the binding of the queries of a context
+func bindingFromContext(ctx context.Context) tenantBinding {
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		return tenantBinding{tenant: strconv.Itoa(int(tenantID))}
+	}
+	if all, _ := ctx.Value(allTenantsKey{}).(bool); all {
+		return tenantBinding{allTenants: "on"}
+	}
+	return tenantBinding{}
+}
+
+// tenantBinder binds each pooled connection to the tenant of the context it is acquired with,
+// so the row-level security policies scope every query the Store runs without the generated
+// queries taking a tenant. It remembers each connection's binding to skip the round trip when
+// it is already bound the right way.
+type tenantBinder struct {
+	mu    sync.Mutex
+	bound map[*pgx.Conn]tenantBinding
+}
+
+func newTenantBinder() *tenantBinder {
+	return &tenantBinder{bound: make(map[*pgx.Conn]tenantBinding)}
+}
+
+// install hooks the binder into a pool configuration
+func (b *tenantBinder) install(config *pgxpool.Config) {
+	config.BeforeAcquire = b.beforeAcquire
+	config.BeforeClose = b.forget
+}
+
+// beforeAcquire binds the connection to the context's tenant, to every tenant, or to none. A
+// connection that can't be bound is destroyed rather than handed out bound to another tenant.
+func (b *tenantBinder) beforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	want := bindingFromContext(ctx)
+
+	b.mu.Lock()
+	current, known := b.bound[conn]
+	b.mu.Unlock()
+	// A new connection starts out bound to no tenant
+	if current == want && (known || want == tenantBinding{}) {
+		return true
+	}
+
+	_, err := conn.Exec(ctx, "SELECT set_config($1, $2, false), set_config($3, $4, false)",
+		tenantSetting, want.tenant, allTenantsSetting, want.allTenants)
+	if err != nil {
+		b.forget(conn)
+		return false
+	}
+	b.mu.Lock()
+	b.bound[conn] = want
+	b.mu.Unlock()
+	return true
+}
+
+// forget drops a closed connection
+func (b *tenantBinder) forget(conn *pgx.Conn) {
+	b.mu.Lock()
+	delete(b.bound, conn)
+	b.mu.Unlock()
+}
+
+// CheckTenantIsolation returns an error when the server's database role is exempt from the
+// row-level security policies, as superusers, roles with BYPASSRLS and the owner of the tables
+// are, so tenants would see each other's rows, or when the policies let a connection bound to
+// no tenant see any
+func (db *DB) CheckTenantIsolation(ctx context.Context) error {
+	var superuser, bypassRLS, owner bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT r.rolsuper, r.rolbypassrls, pg_has_role(current_user, c.relowner, 'USAGE')
+		FROM pg_roles r, pg_class c
+		WHERE r.rolname = current_user AND c.oid = 'users'::regclass`).Scan(&superuser, &bypassRLS, &owner)
+	if err != nil {
+		return err
+	}
+	switch {
+	case superuser:
+		return fmt.Errorf("the database role is a superuser, which bypasses row-level security")
+	case bypassRLS:
+		return fmt.Errorf("the database role has BYPASSRLS")
+	case owner:
+		return fmt.Errorf("the database role owns the tables, which exempts it from row-level security")
+	}
+
+	// A connection bound to no tenant must see nothing, whichever tenants have users. The
+	// binding is local to a transaction that is rolled back, leaving the connection as it was.
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, "SELECT set_config($1, '', true), set_config($2, '', true)", tenantSetting, allTenantsSetting); err != nil {
+		return err
+	}
+	var visible bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users)").Scan(&visible); err != nil {
+		return err
+	}
+	if visible {
+		return fmt.Errorf("the row-level security policies show rows to a connection bound to no tenant")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package db_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TestTenantIsolation connects as a role the row-level security policies apply to, like the
+// server with MULTI_TENANT, and checks that a connection bound to no tenant sees no rows
+func TestTenantIsolation(t *testing.T) {
+	owner := dbtest.NewPostgres(t)
+	ctx := t.Context()
+
+	for _, statement := range []string{
+		`DO $$ BEGIN
+			IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'ngtableg_app_test') THEN
+				CREATE ROLE ngtableg_app_test LOGIN PASSWORD 'app';
+			END IF;
+		END $$`,
+		`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO ngtableg_app_test`,
+		`GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO ngtableg_app_test`,
+	} {
+		if _, err := owner.Exec(ctx, statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	user, err := owner.CreateUser(ctx, sqlc.CreateUserParams{Username: "alice", Password: "-", UserType: "user", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appURL, err := url.Parse(owner.Pool.Config().ConnString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	appURL.User = url.UserPassword("ngtableg_app_test", "app")
+	app, err := db.Connect(config.Database{URL: appURL.String(), MultiTenant: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Close)
+
+	if err := app.CheckTenantIsolation(ctx); err != nil {
+		t.Errorf("CheckTenantIsolation() error = %v, want the app role isolated", err)
+	}
+	if err := owner.CheckTenantIsolation(ctx); err == nil {
+		t.Error("CheckTenantIsolation() as the owner succeeded")
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{name: "no tenant", ctx: ctx, want: 0},
+		{name: "default tenant", ctx: db.WithTenant(ctx, db.DefaultTenantID), want: 1},
+		{name: "all tenants", ctx: db.WithAllTenants(ctx), want: 1},
+	}
+	for _, tt := range tests {
+		users, err := app.ListUsers(tt.ctx, sqlc.ListUsersParams{RowLimit: 10})
+		if err != nil {
+			t.Fatalf("%s: ListUsers() error = %v", tt.name, err)
+		}
+		if len(users) != tt.want {
+			t.Errorf("%s: ListUsers() = %d users, want %d", tt.name, len(users), tt.want)
+		}
+	}
+	if _, err := app.CreateUser(ctx, sqlc.CreateUserParams{Username: "mallory", Password: "-", UserType: "user", Email: "mallory@example.com"}); err == nil {
+		t.Error("CreateUser() bound to no tenant succeeded")
+	}
+
+	// A policy letting the unbound connection see rows fails the check
+	if _, err := owner.Exec(ctx, `CREATE POLICY leak_test ON users USING (true)`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { owner.Exec(t.Context(), `DROP POLICY IF EXISTS leak_test ON users`) })
+	if err := app.CheckTenantIsolation(ctx); err == nil {
+		t.Errorf("CheckTenantIsolation() succeeded with user %d visible to no tenant", user.ID)
+	}
+}
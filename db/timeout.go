@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// timeoutDBTX runs every query under a deadline derived from the caller's context, so a
+// query stops when the request is cancelled or runs longer than the timeout
+type timeoutDBTX struct {
+	db      sqlc.DBTX
+	timeout time.Duration
+}
+
+// withQueryTimeout wraps a pool or transaction, a zero timeout only passes the caller's
+// context through
+func withQueryTimeout(db sqlc.DBTX, timeout time.Duration) sqlc.DBTX {
+	if timeout <= 0 {
+		return db
+	}
+	return &timeoutDBTX{db: db, timeout: timeout}
+}
+
+func (t *timeoutDBTX) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.db.Exec(ctx, sql, args...)
+}
+
+// Query keeps the deadline running until the rows are closed, since they are read after it returns
+func (t *timeoutDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+// QueryRow keeps the deadline running until the row is scanned
+func (t *timeoutDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	return &timeoutRow{row: t.db.QueryRow(ctx, sql, args...), cancel: cancel}
+}
+
+func (t *timeoutDBTX) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+type timeoutRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+type timeoutRow struct {
+	row    pgx.Row
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRow) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return r.row.Scan(dest...)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QuerySpan describes one finished query. Parameter values are never included, only how many
+// were passed.
+type QuerySpan struct {
+	Name     string // sqlc query name, empty for hand-written SQL
+	SQL      string // query text with whitespace collapsed
+	Args     int
+	Start    time.Time
+	Duration time.Duration
+	Rows     int64
+	Err      error
+}
+
+// SpanRecorder receives a span for every finished query, with the context the query ran under
+type SpanRecorder func(ctx context.Context, span QuerySpan)
+
+// queryTracer is the pgx tracer of both pools. It logs queries slower than the threshold and
+// hands every query to the span recorder.
+type queryTracer struct {
+	threshold time.Duration
+	recorder  atomic.Pointer[SpanRecorder]
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql   string
+	args  int
+	start time.Time
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, args: len(data.Args), start: time.Now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	span := QuerySpan{
+		Name:     queryName(started.sql),
+		SQL:      redactQuery(started.sql),
+		Args:     started.args,
+		Start:    started.start,
+		Duration: time.Since(started.start),
+		Rows:     data.CommandTag.RowsAffected(),
+		Err:      data.Err,
+	}
+
+	if t.threshold >= 0 && span.Duration >= t.threshold {
+		slog.WarnContext(ctx, "Slow query", "query", span.Name, "duration", span.Duration, "rows", span.Rows, "args", span.Args, "sql", span.SQL)
+	}
+	if recorder := t.recorder.Load(); recorder != nil {
+		(*recorder)(ctx, span)
+	}
+}
+
+// SetSpanRecorder sends a span for every query run through the pools to fn
+func (db *DB) SetSpanRecorder(fn SpanRecorder) {
+	db.tracer.recorder.Store(&fn)
+}
+
+// redactQuery drops the sqlc name comment and collapses whitespace. Values are sent as
+// parameters, so the text itself holds no user data.
+func redactQuery(sql string) string {
+	if strings.HasPrefix(sql, "-- name: ") {
+		if _, rest, ok := strings.Cut(sql, "\n"); ok {
+			sql = rest
+		}
+	}
+	return strings.Join(strings.Fields(sql), " ")
+}
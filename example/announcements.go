@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// Admins post announcements at /api/admin/announcements for everyone or for some user types,
+// departments, with the departments under them, and users, to show from publishAt until expireAt.
+// The dashboard shows the caller's at /api/announcements as a banner. Policy notices ask to be
+// acknowledged, and admins see at /api/admin/announcements/{id}/acknowledgments who did and who
+// still has to.
+
+// announcementUserBatch is how many users at a time are matched against an announcement's
+// audience to find who still has to acknowledge it
+const announcementUserBatch = 100
+
+// AnnouncementRequest is the body of POST /api/admin/announcements and PUT
+// /api/admin/announcements/{id}. An announcement whose lists are all empty is for everyone.
+type AnnouncementRequest struct {
+	Title                  string     `json:"title" validate:"required,max=200"`
+	Body                   string     `json:"body" validate:"required,max=10000"`
+	UserTypes              []string   `json:"userTypes"`
+	Departments            []string   `json:"departments"` // And the departments under them
+	UserIDs                []int32    `json:"userIds"`
+	RequiresAcknowledgment bool       `json:"requiresAcknowledgment"`
+	PublishAt              *time.Time `json:"publishAt"` // Now when left out
+	ExpireAt               *time.Time `json:"expireAt"`  // Shown until taken down when left out
+}
+
+// AnnouncementResponse is an announcement as admins manage it
+type AnnouncementResponse struct {
+	ID                     int32      `json:"id"`
+	Title                  string     `json:"title"`
+	Body                   string     `json:"body"`
+	UserTypes              []string   `json:"userTypes"`
+	Departments            []string   `json:"departments"`
+	UserIDs                []int32    `json:"userIds"`
+	RequiresAcknowledgment bool       `json:"requiresAcknowledgment"`
+	PublishAt              time.Time  `json:"publishAt"`
+	ExpireAt               *time.Time `json:"expireAt"`
+	CreatedBy              *int32     `json:"createdBy"`
+	CreatedAt              time.Time  `json:"createdAt"`
+	UpdatedAt              time.Time  `json:"updatedAt"`
+}
+
+// CurrentAnnouncementResponse is an announcement on the dashboard of the user it is for
+type CurrentAnnouncementResponse struct {
+	ID                     int32      `json:"id"`
+	Title                  string     `json:"title"`
+	Body                   string     `json:"body"`
+	RequiresAcknowledgment bool       `json:"requiresAcknowledgment"`
+	PublishAt              time.Time  `json:"publishAt"`
+	ExpireAt               *time.Time `json:"expireAt"`
+	AcknowledgedAt         *time.Time `json:"acknowledgedAt"` // Null until the user acknowledges it
+}
+
+// AnnouncementAcknowledgmentsResponse is who of the users an announcement is for acknowledged it
+type AnnouncementAcknowledgmentsResponse struct {
+	Acknowledged []AnnouncementAcknowledgmentResponse `json:"acknowledged"` // In the order they did
+	Pending      []AnnouncementAcknowledgmentResponse `json:"pending"`      // By user ID
+}
+
+// AnnouncementAcknowledgmentResponse is a user an announcement is for, and when they acknowledged it
+type AnnouncementAcknowledgmentResponse struct {
+	UserID         int32      `json:"userId"`
+	Username       string     `json:"username"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt"`
+}
+
+func announcementToResponse(a sqlc.Announcement) AnnouncementResponse {
+	return AnnouncementResponse{
+		ID:                     a.ID,
+		Title:                  a.Title,
+		Body:                   a.Body,
+		UserTypes:              nonNil(a.UserTypes),
+		Departments:            nonNil(a.Departments),
+		UserIDs:                nonNil(a.UserIds),
+		RequiresAcknowledgment: a.RequiresAcknowledgment,
+		PublishAt:              a.PublishAt.Time,
+		ExpireAt:               timestamptzPtr(a.ExpireAt),
+		CreatedBy:              int4Ptr(a.CreatedBy),
+		CreatedAt:              a.CreatedAt.Time,
+		UpdatedAt:              a.UpdatedAt.Time,
+	}
+}
+
+func currentAnnouncementToResponse(a sqlc.Announcement, acknowledgedAt pgtype.Timestamptz) CurrentAnnouncementResponse {
+	return CurrentAnnouncementResponse{
+		ID:                     a.ID,
+		Title:                  a.Title,
+		Body:                   a.Body,
+		RequiresAcknowledgment: a.RequiresAcknowledgment,
+		PublishAt:              a.PublishAt.Time,
+		ExpireAt:               timestamptzPtr(a.ExpireAt),
+		AcknowledgedAt:         timestamptzPtr(acknowledgedAt),
+	}
+}
+
+// announcementFor reports whether an announcement is for everyone, or for the user's type, their
+// department or one above it, or the user in particular. departments is the user's department and
+// those above it, see userDepartments.
+func announcementFor(a sqlc.Announcement, user sqlc.User, departments []string) bool {
+	if len(a.UserTypes) == 0 && len(a.Departments) == 0 && len(a.UserIds) == 0 {
+		return true
+	}
+	return slices.Contains(a.UserTypes, user.UserType) ||
+		slices.ContainsFunc(departments, func(d string) bool { return slices.Contains(a.Departments, d) }) ||
+		slices.Contains(a.UserIds, user.ID)
+}
+
+// userDepartments returns the name of the user's department and of those above it in the org
+// chart, none when the user has no department
+func (s *Server) userDepartments(ctx context.Context, user sqlc.User) ([]string, error) {
+	if !user.Department.Valid {
+		return nil, nil
+	}
+	chain, err := approval.DepartmentChain(ctx, s.store, user.Department.String)
+	if err != nil {
+		return nil, err
+	}
+	departments := []string{user.Department.String}
+	for _, d := range chain {
+		if d.Name != user.Department.String {
+			departments = append(departments, d.Name)
+		}
+	}
+	return departments, nil
+}
+
+// currentAnnouncements returns the announcements published now that are for the user
+func (s *Server) currentAnnouncements(ctx context.Context, user sqlc.User) ([]sqlc.Announcement, error) {
+	published, err := s.store.ListPublishedAnnouncements(ctx, pgtype.Timestamptz{Time: time.Now(), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	departments, err := s.userDepartments(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	var announcements []sqlc.Announcement
+	for _, a := range published {
+		if announcementFor(a, user, departments) {
+			announcements = append(announcements, a)
+		}
+	}
+	return announcements, nil
+}
+
+// authorizeAnnouncementAdmin answers 403 to users other than admins and returns the admin
+// otherwise
+func (s *Server) authorizeAnnouncementAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can manage announcements")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// announcementFromPath returns the announcement whose ID is in the path, answering 400 or 404
+// otherwise
+func (s *Server) announcementFromPath(w http.ResponseWriter, r *http.Request) (sqlc.Announcement, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid announcement ID")
+		return sqlc.Announcement{}, false
+	}
+	announcement, err := s.store.GetAnnouncement(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Announcement not found")
+		return announcement, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching announcement: "+err.Error())
+		return announcement, false
+	}
+	return announcement, true
+}
+
+// decodeAnnouncementRequest decodes and checks the body of a create or update, answering 400
+// when it is invalid
+func decodeAnnouncementRequest(w http.ResponseWriter, r *http.Request) (AnnouncementRequest, pgtype.Timestamptz, pgtype.Timestamptz, bool) {
+	var req AnnouncementRequest
+	if !decodeRequest(w, r, &req) {
+		return req, pgtype.Timestamptz{}, pgtype.Timestamptz{}, false
+	}
+	publishAt := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	if req.PublishAt != nil {
+		publishAt.Time = *req.PublishAt
+	}
+	var expireAt pgtype.Timestamptz
+	if req.ExpireAt != nil {
+		if !req.ExpireAt.After(publishAt.Time) {
+			respondWithValidationError(w, validate.Errors{"expireAt": "must be after publishAt"})
+			return req, publishAt, expireAt, false
+		}
+		expireAt = pgtype.Timestamptz{Time: *req.ExpireAt, Valid: true}
+	}
+	return req, publishAt, expireAt, true
+}
+
+// getAnnouncements handles GET /api/announcements, the announcements published now for the
+// current user, the latest first
+func (s *Server) getAnnouncements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	announcements, err := s.currentAnnouncements(ctx, currentUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching announcements: "+err.Error())
+		return
+	}
+	acks, err := s.store.ListAnnouncementAcknowledgmentsByUser(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching acknowledgments: "+err.Error())
+		return
+	}
+	acknowledged := make(map[int32]pgtype.Timestamptz, len(acks))
+	for _, ack := range acks {
+		acknowledged[ack.AnnouncementID] = ack.AcknowledgedAt
+	}
+	response := make([]CurrentAnnouncementResponse, 0, len(announcements))
+	for _, a := range announcements {
+		response = append(response, currentAnnouncementToResponse(a, acknowledged[a.ID]))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// acknowledgeAnnouncement handles POST /api/announcements/{id}/acknowledge. Acknowledging again
+// keeps the time of the first acknowledgment.
+func (s *Server) acknowledgeAnnouncement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid announcement ID")
+		return
+	}
+	announcements, err := s.currentAnnouncements(ctx, currentUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching announcements: "+err.Error())
+		return
+	}
+	// Announcements that aren't published or are for others are as good as missing
+	i := slices.IndexFunc(announcements, func(a sqlc.Announcement) bool { return a.ID == int32(id) })
+	if i < 0 {
+		respondWithError(w, http.StatusNotFound, "Announcement not found")
+		return
+	}
+	announcement := announcements[i]
+	if !announcement.RequiresAcknowledgment {
+		respondWithError(w, http.StatusConflict, "The announcement doesn't ask to be acknowledged")
+		return
+	}
+
+	ack, err := s.store.AcknowledgeAnnouncement(ctx, sqlc.AcknowledgeAnnouncementParams{AnnouncementID: announcement.ID, UserID: currentUser.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving acknowledgment: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, currentAnnouncementToResponse(announcement, ack.AcknowledgedAt))
+}
+
+// listAnnouncements handles GET /api/admin/announcements, published or not, the latest to
+// publish first
+func (s *Server) listAnnouncements(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnnouncementAdmin(w, r); !ok {
+		return
+	}
+
+	limit := 20
+	offset := 0
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 {
+		limit = min(parsed, 100)
+	}
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+
+	announcements, err := s.store.ListAnnouncements(r.Context(), sqlc.ListAnnouncementsParams{
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching announcements: "+err.Error())
+		return
+	}
+	total, err := s.store.CountAnnouncements(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting announcements: "+err.Error())
+		return
+	}
+	response := make([]AnnouncementResponse, 0, len(announcements))
+	for _, a := range announcements {
+		response = append(response, announcementToResponse(a))
+	}
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
+}
+
+// getAnnouncement handles GET /api/admin/announcements/{id}
+func (s *Server) getAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnnouncementAdmin(w, r); !ok {
+		return
+	}
+	announcement, ok := s.announcementFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, announcementToResponse(announcement))
+}
+
+// createAnnouncement handles POST /api/admin/announcements
+func (s *Server) createAnnouncement(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizeAnnouncementAdmin(w, r)
+	if !ok {
+		return
+	}
+	req, publishAt, expireAt, ok := decodeAnnouncementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	announcement, err := s.store.CreateAnnouncement(r.Context(), sqlc.CreateAnnouncementParams{
+		Title:                  req.Title,
+		Body:                   req.Body,
+		UserTypes:              nonNil(req.UserTypes),
+		Departments:            nonNil(req.Departments),
+		UserIds:                nonNil(req.UserIDs),
+		RequiresAcknowledgment: req.RequiresAcknowledgment,
+		PublishAt:              publishAt,
+		ExpireAt:               expireAt,
+		CreatedBy:              pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating announcement: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, announcementToResponse(announcement))
+}
+
+// updateAnnouncement handles PUT /api/admin/announcements/{id}. Acknowledgments made already are
+// kept.
+func (s *Server) updateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnnouncementAdmin(w, r); !ok {
+		return
+	}
+	announcement, ok := s.announcementFromPath(w, r)
+	if !ok {
+		return
+	}
+	req, publishAt, expireAt, ok := decodeAnnouncementRequest(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := s.store.UpdateAnnouncement(r.Context(), sqlc.UpdateAnnouncementParams{
+		ID:                     announcement.ID,
+		Title:                  req.Title,
+		Body:                   req.Body,
+		UserTypes:              nonNil(req.UserTypes),
+		Departments:            nonNil(req.Departments),
+		UserIds:                nonNil(req.UserIDs),
+		RequiresAcknowledgment: req.RequiresAcknowledgment,
+		PublishAt:              publishAt,
+		ExpireAt:               expireAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Announcement not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating announcement: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, announcementToResponse(updated))
+}
+
+// deleteAnnouncement handles DELETE /api/admin/announcements/{id}, with its acknowledgments. Set
+// expireAt instead to take it down and keep them.
+func (s *Server) deleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnnouncementAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid announcement ID")
+		return
+	}
+	deleted, err := s.store.DeleteAnnouncement(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting announcement: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Announcement not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getAnnouncementAcknowledgments handles GET /api/admin/announcements/{id}/acknowledgments: the
+// users who acknowledged the announcement, and those it is for who haven't yet
+func (s *Server) getAnnouncementAcknowledgments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeAnnouncementAdmin(w, r); !ok {
+		return
+	}
+	announcement, ok := s.announcementFromPath(w, r)
+	if !ok {
+		return
+	}
+	acks, err := s.store.ListAnnouncementAcknowledgments(ctx, announcement.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching acknowledgments: "+err.Error())
+		return
+	}
+
+	response := AnnouncementAcknowledgmentsResponse{
+		Acknowledged: make([]AnnouncementAcknowledgmentResponse, 0, len(acks)),
+		Pending:      []AnnouncementAcknowledgmentResponse{},
+	}
+	acknowledged := make(map[int32]bool, len(acks))
+	for _, ack := range acks {
+		acknowledged[ack.UserID] = true
+		response.Acknowledged = append(response.Acknowledged, AnnouncementAcknowledgmentResponse{
+			UserID:         ack.UserID,
+			Username:       ack.Username,
+			AcknowledgedAt: timestamptzPtr(ack.AcknowledgedAt),
+		})
+	}
+	for offset := int32(0); ; offset += announcementUserBatch {
+		users, err := s.store.ListUsers(ctx, sqlc.ListUsersParams{RowLimit: announcementUserBatch, RowOffset: offset})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching users: "+err.Error())
+			return
+		}
+		for _, user := range users {
+			if acknowledged[user.ID] {
+				continue
+			}
+			departments, err := s.userDepartments(ctx, user)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error fetching departments: "+err.Error())
+				return
+			}
+			if announcementFor(announcement, user, departments) {
+				response.Pending = append(response.Pending, AnnouncementAcknowledgmentResponse{UserID: user.ID, Username: user.Username})
+			}
+		}
+		if len(users) < announcementUserBatch {
+			break
+		}
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+// startChangeFeed follows the change feed instead of polling: annual records are synced as soon
+// as a change to their leave, task or medical expense logs commits, and in full whenever the
+// feed reconnects after changes may have been missed. The feed delivers the changes committed
+// to the database by any server instance or tool, it is not started when CHANGE_FEED=off, e.g.
+// behind a connection pooler that doesn't support LISTEN.
+func (s *Server) startChangeFeed() {
+	changeFeed := db.NewChangeFeed(s.database.Pool)
+	changeFeed.Subscribe(s.publishAnnualRecordChange)
+	changeFeed.Subscribe(s.liveEvents.HandleChange)
+	changeFeed.Subscribe(s.publishWebhookChange)
+	changeFeed.OnGap(s.liveEvents.HandleGap)
+	changeFeed.OnGap(func(ctx context.Context) {
+		year := int32(s.thisYear())
+		s.forEachTenant(ctx, func(ctx context.Context) {
+			records, err := s.annualRecords.SyncAllRecordsForYear(ctx, year)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error catching up on annual records after change feed gap", "error", err)
+				return
+			}
+			slog.InfoContext(ctx, "Caught up on annual records after change feed gap", "records", len(records), "year", year)
+		})
+	})
+	go changeFeed.Run(context.Background())
+	slog.Info("Annual record sync following the database change feed")
+}
+
+// publishAnnualRecordChange publishes a change made elsewhere to the in-process event bus.
+// Changes made by this instance were published when they were written, and annual record
+// changes are the result of a sync rather than a reason for one, tasks don't count towards
+// annual records. The sync runs in the tenant of the change.
+func (s *Server) publishAnnualRecordChange(ctx context.Context, change db.Change) {
+	if change.Origin == db.Instance || change.Table == "annual_records" || change.Table == "tasks" || change.Year == 0 {
+		return
+	}
+	if s.config.Database.MultiTenant && change.TenantID != 0 {
+		ctx = db.WithTenant(ctx, change.TenantID)
+	}
+	s.events.Publish(ctx, AnnualRecordChange{UserID: change.UserID, Year: change.Year})
+}
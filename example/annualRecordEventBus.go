@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AnnualRecordChange identifies a user's annual record whose totals are affected by a write
+type AnnualRecordChange struct {
+	UserID int32 `json:"userId"`
+	Year   int32 `json:"year"`
+}
+
+// annualRecordChangeFor returns the change for the annual record covering the given date
+func annualRecordChangeFor(userID int32, date time.Time) AnnualRecordChange {
+	return AnnualRecordChange{UserID: userID, Year: int32(date.Year())}
+}
+
+// AnnualRecordChangeHandler reacts to a single annual record change
+type AnnualRecordChangeHandler func(ctx context.Context, change AnnualRecordChange)
+
+// AnnualRecordEventBus dispatches annual record changes to in-process subscribers.
+// Handlers publish once after a successful write instead of calling the sync service directly.
+type AnnualRecordEventBus struct {
+	mu       sync.RWMutex
+	handlers []AnnualRecordChangeHandler
+}
+
+// NewAnnualRecordEventBus creates a new, empty event bus
+func NewAnnualRecordEventBus() *AnnualRecordEventBus {
+	return &AnnualRecordEventBus{}
+}
+
+// Subscribe registers a handler that is called for every published change
+func (b *AnnualRecordEventBus) Subscribe(handler AnnualRecordChangeHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers the given changes to all subscribers. Duplicate user/year pairs within
+// one call are collapsed, so a write touching the same record twice is handled exactly once.
+func (b *AnnualRecordEventBus) Publish(ctx context.Context, changes ...AnnualRecordChange) {
+	b.mu.RLock()
+	handlers := make([]AnnualRecordChangeHandler, len(b.handlers))
+	copy(handlers, b.handlers)
+	b.mu.RUnlock()
+
+	seen := make(map[AnnualRecordChange]bool, len(changes))
+	for _, change := range changes {
+		if seen[change] {
+			continue
+		}
+		seen[change] = true
+
+		for _, handler := range handlers {
+			handler(ctx, change)
+		}
+	}
+}
@@ -3,14 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
 )
 
-// AnnualRecordSyncService handles the synchronization of annual records with leave logs and task logs
+// AnnualRecordSyncService handles the synchronization of annual records with the balance events
+// recorded for their leave logs, task logs and medical expenses
 type AnnualRecordSyncService struct {
 	store db.Querier
 }
@@ -22,59 +22,27 @@ func NewAnnualRecordSyncService(store db.Querier) *AnnualRecordSyncService {
 	}
 }
 
-// SyncUserRecordForYear synchronizes a specific user's annual record for a given year
+// SyncUserRecordForYear synchronizes a specific user's annual record for a given year, summing up
+// its balance events. The events are recorded in the transactions writing the logs, so a sync that
+// was missed or ran twice can't leave the totals off; the next one gets them right.
 func (s *AnnualRecordSyncService) SyncUserRecordForYear(ctx context.Context, userID int32, year int32) (*db.AnnualRecord, error) {
-	// First, sync the vacation and sick leave days
-	vacationRecord, err := s.store.SyncAnnualRecordVacationDays(ctx, db.SyncAnnualRecordVacationDaysParams{
+	record, err := s.store.SyncAnnualRecord(ctx, db.SyncAnnualRecordParams{
 		UserID: userID,
 		Year:   year,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync vacation days: %v", err)
+		return nil, fmt.Errorf("failed to sync annual record: %v", err)
 	}
-
-	// Then, sync the work days and holiday work days
-	workRecord, err := s.store.SyncAnnualRecordWorkDays(ctx, db.SyncAnnualRecordWorkDaysParams{
-		UserID: userID,
-		Year:   year,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to sync work days: %v", err)
-	}
-
-	// Return the most recently updated record
-	if workRecord.UpdatedAt.Time.After(vacationRecord.UpdatedAt.Time) {
-		return &workRecord, nil
-	}
-	return &vacationRecord, nil
+	return &record, nil
 }
 
-// SyncAllRecordsForYear synchronizes all users' annual records for a given year
+// SyncAllRecordsForYear synchronizes all users' annual records for a given year, rebuilding
+// them from the balance events
 func (s *AnnualRecordSyncService) SyncAllRecordsForYear(ctx context.Context, year int32) ([]db.AnnualRecord, error) {
-	syncedRows, err := s.store.SyncAllAnnualRecordsByYear(ctx, year)
+	records, err := s.store.SyncAllAnnualRecordsByYear(ctx, year)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sync all annual records for year %d: %v", year, err)
 	}
-
-	// Convert SyncAllAnnualRecordsByYearRow to AnnualRecord
-	records := make([]db.AnnualRecord, len(syncedRows))
-	for i, row := range syncedRows {
-		records[i] = db.AnnualRecord{
-			ID:                     row.ID,
-			UserID:                 row.UserID,
-			Year:                   row.Year,
-			QuotaPlanID:            row.QuotaPlanID,
-			RolloverVacationDay:    row.RolloverVacationDay,
-			UsedVacationDay:        row.UsedVacationDay,
-			UsedSickLeaveDay:       row.UsedSickLeaveDay,
-			WorkedOnHolidayDay:     row.WorkedOnHolidayDay,
-			WorkedDay:              row.WorkedDay,
-			UsedMedicalExpenseBaht: row.UsedMedicalExpenseBaht,
-			CreatedAt:              row.CreatedAt,
-			UpdatedAt:              row.UpdatedAt,
-		}
-	}
-
 	return records, nil
 }
 
@@ -108,7 +76,7 @@ func (s *AnnualRecordSyncService) EnsureAnnualRecordExists(ctx context.Context,
 	// Get the default quota plan for the year
 	quotaPlans, err := s.store.ListQuotaPlansByYear(ctx, year)
 	if err != nil || len(quotaPlans) == 0 {
-		log.Printf("No quota plan found for year %d, using default values", year)
+		slog.InfoContext(ctx, "No quota plan found, using default values", "year", year)
 		// Continue with nil quota plan
 	}
 
@@ -132,10 +100,9 @@ func (s *AnnualRecordSyncService) EnsureAnnualRecordExists(ctx context.Context,
 	return &newRecord, nil
 }
 
-// ScheduleYearEndRollover schedules the rollover of vacation days at year-end
-func (s *AnnualRecordSyncService) ScheduleYearEndRollover(ctx context.Context) error {
-	// Get the current year
-	currentYear := int32(time.Now().Year())
+// ScheduleYearEndRollover schedules the rollover of vacation days at year-end, from the current
+// year into the next
+func (s *AnnualRecordSyncService) ScheduleYearEndRollover(ctx context.Context, currentYear int32) error {
 	nextYear := currentYear + 1
 
 	// Create records for the next year with rollover from the current year
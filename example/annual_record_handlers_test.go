@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// annualRecordTotals is the part of an annual record response the tests check
+type annualRecordTotals struct {
+	ID                     int32   `json:"id"`
+	QuotaPlanID            int32   `json:"quotaPlanId"`
+	UserID                 int32   `json:"userId"`
+	Year                   int32   `json:"year"`
+	UsedVacationDay        float64 `json:"usedVacationDay"`
+	UsedSickLeaveDay       float64 `json:"usedSickLeaveDay"`
+	WorkedDay              float64 `json:"workedDay"`
+	UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht"`
+}
+
+// createAnnualRecord creates a quota plan and the user's annual record of the year on it as admin
+func createAnnualRecord(t *testing.T, handler http.Handler, admin, user sqlc.User, year int32) annualRecordTotals {
+	t.Helper()
+	token := tokenFor(admin.Username)
+
+	var plan sqlc.QuotaPlan
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/quota-plans", token, QuotaPlanRequest{
+		PlanName:                "Default",
+		Year:                    year,
+		QuotaVacationDay:        10,
+		QuotaMedicalExpenseBaht: 20000,
+		CreatedByUserID:         admin.ID,
+	}), http.StatusCreated, &plan)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/annual-records", token, AnnualRecordRequest{
+		UserId:      user.ID,
+		Year:        year,
+		QuotaPlanId: plan.ID,
+	}), http.StatusCreated, nil)
+	return annualRecordFor(t, handler, admin, user, year)
+}
+
+// annualRecordFor returns the user's annual record of the year, as admin
+func annualRecordFor(t *testing.T, handler http.Handler, admin, user sqlc.User, year int32) annualRecordTotals {
+	t.Helper()
+
+	var records []annualRecordTotals
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/annual-records?user_id=%d", user.ID), tokenFor(admin.Username), nil), http.StatusOK, &records)
+	for _, record := range records {
+		if record.Year == year {
+			return record
+		}
+	}
+	t.Fatalf("%s has no annual record of %d: %+v", user.Username, year, records)
+	return annualRecordTotals{}
+}
+
+func TestAnnualRecordFollowsLeave(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+	createAnnualRecord(t, handler, admin, user, 2025)
+
+	var vacation, sick LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, LeaveLogRequest{
+		UserID: user.ID, Type: "vacation", Date: "2025-04-14",
+	}), http.StatusCreated, &vacation)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, LeaveLogRequest{
+		UserID: user.ID, Type: "vacation", Date: "2025-04-15",
+	}), http.StatusCreated, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, LeaveLogRequest{
+		UserID: user.ID, Type: "sick", Date: "2025-06-02",
+	}), http.StatusCreated, &sick)
+
+	record := annualRecordFor(t, handler, admin, user, 2025)
+	if record.UsedVacationDay != 2 || record.UsedSickLeaveDay != 1 {
+		t.Errorf("used %v vacation and %v sick days, want 2 and 1", record.UsedVacationDay, record.UsedSickLeaveDay)
+	}
+
+	// Turning the sick day into vacation and deleting a vacation day moves the totals with them
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/leave-logs/%d", sick.ID), token, LeaveLogUpdateRequest{
+		Type: "vacation", Date: "2025-06-02",
+	}), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, fmt.Sprintf("/api/leave-logs/%d", vacation.ID), token, nil), http.StatusOK, nil)
+
+	record = annualRecordFor(t, handler, admin, user, 2025)
+	if record.UsedVacationDay != 2 || record.UsedSickLeaveDay != 0 {
+		t.Errorf("used %v vacation and %v sick days, want 2 and 0", record.UsedVacationDay, record.UsedSickLeaveDay)
+	}
+}
+
+func TestAnnualRecordFollowsLeaveAcrossYears(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+	createAnnualRecord(t, handler, admin, user, 2025)
+	createAnnualRecord(t, handler, admin, user, 2026)
+
+	var leave LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, LeaveLogRequest{
+		UserID: user.ID, Type: "vacation", Date: "2025-12-31",
+	}), http.StatusCreated, &leave)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/leave-logs/%d", leave.ID), token, LeaveLogUpdateRequest{
+		Type: "vacation", Date: "2026-01-02",
+	}), http.StatusOK, nil)
+
+	if record := annualRecordFor(t, handler, admin, user, 2025); record.UsedVacationDay != 0 {
+		t.Errorf("2025 used %v vacation days, want 0", record.UsedVacationDay)
+	}
+	if record := annualRecordFor(t, handler, admin, user, 2026); record.UsedVacationDay != 1 {
+		t.Errorf("2026 used %v vacation days, want 1", record.UsedVacationDay)
+	}
+}
+
+func TestAnnualRecordFollowsTaskLogs(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+	createAnnualRecord(t, handler, admin, user, 2025)
+
+	var task TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: "Support"}), http.StatusCreated, &task)
+	for _, date := range []string{"2025-02-03", "2025-02-04"} {
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-logs", token, TaskLogRequest{
+			TaskID: task.ID, WorkedDay: 0.5, WorkedDate: date,
+		}), http.StatusCreated, nil)
+	}
+
+	if record := annualRecordFor(t, handler, admin, user, 2025); record.WorkedDay != 1 {
+		t.Errorf("worked %v days, want 1", record.WorkedDay)
+	}
+}
+
+func TestAdjustedAnnualRecordKeepsAdjustment(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	record := createAnnualRecord(t, handler, admin, user, 2025)
+
+	// Vacation taken before the system was used is set by hand, leave logged later adds to it
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/annual-records/%d", record.ID), tokenFor(admin.Username), AnnualRecordUpdateRequest{
+		QuotaPlanId:     record.QuotaPlanID,
+		UsedVacationDay: 3,
+	}), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(user.Username), LeaveLogRequest{
+		UserID: user.ID, Type: "vacation", Date: "2025-08-12",
+	}), http.StatusCreated, nil)
+
+	if record := annualRecordFor(t, handler, admin, user, 2025); record.UsedVacationDay != 4 {
+		t.Errorf("used %v vacation days, want 4", record.UsedVacationDay)
+	}
+}
+
+func TestAnnualRecordPermissions(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	record := createAnnualRecord(t, handler, admin, alice, 2025)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/annual-records", tokenFor(alice.Username), AnnualRecordRequest{
+		UserId: alice.ID, Year: 2026, QuotaPlanId: 1,
+	}), http.StatusForbidden, nil)
+
+	path := fmt.Sprintf("/api/annual-records/%d", record.ID)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(alice.Username), nil), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, tokenFor(alice.Username), nil), http.StatusForbidden, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, tokenFor(admin.Username), nil), http.StatusNoContent, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+}
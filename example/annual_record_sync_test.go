@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// forEachStore runs test against the in-memory fake and against Postgres, the balance events of
+// which the triggers of migration 000052 record. The Postgres run is skipped without Docker or
+// TEST_DATABASE_URL.
+func forEachStore(t *testing.T, test func(t *testing.T, store db.Store)) {
+	t.Run("fake", func(t *testing.T) { test(t, dbtest.NewFake()) })
+	t.Run("postgres", func(t *testing.T) { test(t, dbtest.NewPostgres(t)) })
+}
+
+// balanceFixture is a user with an annual record of 2025 on a plan of 10 vacation days and
+// 20,000 baht of medical expenses
+type balanceFixture struct {
+	user   sqlc.User
+	task   sqlc.Task
+	record sqlc.AnnualRecord
+}
+
+func newBalanceFixture(t *testing.T, store db.Store) balanceFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	plan, err := store.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+		PlanName:                "Default",
+		Year:                    2025,
+		QuotaVacationDay:        pgconv.MustFromFloat(10),
+		QuotaMedicalExpenseBaht: pgconv.MustFromFloat(20000),
+	})
+	if err != nil {
+		t.Fatalf("creating the quota plan: %v", err)
+	}
+	record, err := store.CreateAnnualRecord(ctx, sqlc.CreateAnnualRecordParams{
+		UserID:                 user.ID,
+		Year:                   2025,
+		QuotaPlanID:            pgtype.Int4{Int32: plan.ID, Valid: true},
+		RolloverVacationDay:    pgconv.MustFromFloat(2),
+		UsedVacationDay:        pgconv.MustFromFloat(0),
+		UsedSickLeaveDay:       pgconv.MustFromFloat(0),
+		WorkedOnHolidayDay:     pgconv.MustFromFloat(0),
+		WorkedDay:              pgconv.MustFromFloat(0),
+		UsedMedicalExpenseBaht: pgconv.MustFromFloat(0),
+	})
+	if err != nil {
+		t.Fatalf("creating the annual record: %v", err)
+	}
+	task, err := store.CreateTask(ctx, sqlc.CreateTaskParams{Title: pgtype.Text{String: "Support", Valid: true}})
+	if err != nil {
+		t.Fatalf("creating the task: %v", err)
+	}
+	return balanceFixture{user: user, task: task, record: record}
+}
+
+func (f balanceFixture) leave(t *testing.T, store db.Store, leaveType, date string) sqlc.LeaveLog {
+	t.Helper()
+	leave, err := store.CreateLeaveLog(context.Background(), sqlc.CreateLeaveLogParams{
+		UserID: f.user.ID,
+		Type:   leaveType,
+		Date:   day(t, date),
+	})
+	if err != nil {
+		t.Fatalf("creating %s leave on %s: %v", leaveType, date, err)
+	}
+	return leave
+}
+
+func (f balanceFixture) work(t *testing.T, store db.Store, workedDay float64, date string, onHoliday bool) sqlc.TaskLog {
+	t.Helper()
+	log, err := store.CreateTaskLog(context.Background(), sqlc.CreateTaskLogParams{
+		TaskID:          f.task.ID,
+		WorkedDay:       pgconv.MustFromFloat(workedDay),
+		CreatedByUserID: f.user.ID,
+		WorkedDate:      day(t, date),
+		IsWorkOnHoliday: pgtype.Bool{Bool: onHoliday, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("logging %v days on %s: %v", workedDay, date, err)
+	}
+	return log
+}
+
+func (f balanceFixture) expense(t *testing.T, store db.Store, baht float64, date string) sqlc.MedicalExpense {
+	t.Helper()
+	expense, err := store.CreateMedicalExpense(context.Background(), sqlc.CreateMedicalExpenseParams{
+		UserID:      f.user.ID,
+		Amount:      pgconv.MustFromFloat(baht),
+		ReceiptName: pgtype.Text{String: "Clinic", Valid: true},
+		ReceiptDate: day(t, date),
+	})
+	if err != nil {
+		t.Fatalf("creating a %v baht expense on %s: %v", baht, date, err)
+	}
+	return expense
+}
+
+// recordTotals are the totals of an annual record the sync derives from the balance events
+type recordTotals struct {
+	vacation, sick, worked, onHoliday, medical float64
+}
+
+func totalsOf(record sqlc.AnnualRecord) recordTotals {
+	return recordTotals{
+		vacation:  numericToFloat64(record.UsedVacationDay, -1),
+		sick:      numericToFloat64(record.UsedSickLeaveDay, -1),
+		worked:    numericToFloat64(record.WorkedDay, -1),
+		onHoliday: numericToFloat64(record.WorkedOnHolidayDay, -1),
+		medical:   numericToFloat64(record.UsedMedicalExpenseBaht, -1),
+	}
+}
+
+func day(t *testing.T, date string) pgtype.Date {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pgtype.Date{Time: parsed, Valid: true}
+}
+
+func TestSyncUserRecordForYear(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+
+		f.leave(t, store, "vacation", "2025-04-14")
+		f.leave(t, store, "vacation", "2025-04-15")
+		f.leave(t, store, "sick", "2025-06-02")
+		f.leave(t, store, "personal", "2025-06-03") // Counts towards neither
+		f.leave(t, store, "vacation", "2026-01-05") // Another year
+		f.work(t, store, 0.5, "2025-02-03", false)
+		f.work(t, store, 1, "2025-04-13", true)
+		f.expense(t, store, 1200.50, "2025-03-04")
+		f.expense(t, store, 800, "2026-01-10")
+
+		record, err := service.SyncUserRecordForYear(ctx, f.user.ID, 2025)
+		if err != nil {
+			t.Fatalf("SyncUserRecordForYear() error = %v", err)
+		}
+		want := recordTotals{vacation: 2, sick: 1, worked: 1.5, onHoliday: 1, medical: 1200.50}
+		if got := totalsOf(*record); got != want {
+			t.Errorf("synced totals = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestSyncUserRecordForYearFollowsChanges(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+
+		sick := f.leave(t, store, "sick", "2025-06-02")
+		vacation := f.leave(t, store, "vacation", "2025-12-31")
+		log := f.work(t, store, 0.5, "2025-02-03", false)
+		expense := f.expense(t, store, 1000, "2025-03-04")
+
+		// Sick leave turns into vacation, a vacation day moves into the next year, the log is
+		// deleted and the expense corrected
+		if _, err := store.UpdateLeaveLog(ctx, sqlc.UpdateLeaveLogParams{ID: sick.ID, Type: "vacation", Date: sick.Date}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.UpdateLeaveLog(ctx, sqlc.UpdateLeaveLogParams{ID: vacation.ID, Type: "vacation", Date: day(t, "2026-01-02")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteTaskLog(ctx, log.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.UpdateMedicalExpense(ctx, sqlc.UpdateMedicalExpenseParams{
+			ID:          expense.ID,
+			Amount:      pgconv.MustFromFloat(750),
+			ReceiptName: expense.ReceiptName,
+			ReceiptDate: expense.ReceiptDate,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := service.SyncUserRecordForYear(ctx, f.user.ID, 2025)
+		if err != nil {
+			t.Fatalf("SyncUserRecordForYear() error = %v", err)
+		}
+		want := recordTotals{vacation: 1, medical: 750}
+		if got := totalsOf(*record); got != want {
+			t.Errorf("synced totals = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestSyncUserRecordForYearWithoutRecord(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		user := dbtest.CreateUser(t, store, "alice", "user")
+		if _, err := NewAnnualRecordSyncService(store).SyncUserRecordForYear(context.Background(), user.ID, 2025); err == nil {
+			t.Error("SyncUserRecordForYear() of a user without a record succeeded")
+		}
+	})
+}
+
+func TestSyncAllRecordsForYearEndsDrift(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+		f.leave(t, store, "vacation", "2025-04-14")
+
+		// Overwriting the totals without adjusting the balance events leaves the record drifting
+		if _, err := store.UpdateAnnualRecord(ctx, sqlc.UpdateAnnualRecordParams{
+			UserID:          f.user.ID,
+			Year:            2025,
+			QuotaPlanID:     f.record.QuotaPlanID,
+			UsedVacationDay: pgconv.MustFromFloat(9),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		drift, err := store.ListBalanceDrift(ctx, 2025)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drift) != 1 || drift[0].ID != f.record.ID {
+			t.Fatalf("drift = %+v, want the record of %s", drift, f.user.Username)
+		}
+
+		records, err := service.SyncAllRecordsForYear(ctx, 2025)
+		if err != nil {
+			t.Fatalf("SyncAllRecordsForYear() error = %v", err)
+		}
+		if len(records) != 1 || totalsOf(records[0]).vacation != 1 {
+			t.Errorf("synced records = %+v, want one with 1 vacation day", records)
+		}
+		if drift, err = store.ListBalanceDrift(ctx, 2025); err != nil || len(drift) != 0 {
+			t.Errorf("drift after syncing = %+v, %v, want none", drift, err)
+		}
+	})
+}
+
+func TestAdjustedRecordSurvivesSync(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+
+		// Vacation taken before the system was used is set by an admin and adjusts the events
+		err := store.WithTx(ctx, func(q sqlc.Querier) error {
+			record, err := q.UpdateAnnualRecord(ctx, sqlc.UpdateAnnualRecordParams{
+				UserID:          f.user.ID,
+				Year:            2025,
+				QuotaPlanID:     f.record.QuotaPlanID,
+				UsedVacationDay: pgconv.MustFromFloat(3),
+			})
+			if err != nil {
+				return err
+			}
+			return q.AdjustBalance(ctx, sqlc.AdjustBalanceParams{AnnualRecordID: record.ID})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.leave(t, store, "vacation", "2025-08-12")
+
+		record, err := service.SyncUserRecordForYear(ctx, f.user.ID, 2025)
+		if err != nil {
+			t.Fatalf("SyncUserRecordForYear() error = %v", err)
+		}
+		if got := totalsOf(*record).vacation; got != 4 {
+			t.Errorf("used %v vacation days, want 4", got)
+		}
+	})
+}
+
+func TestEnsureAnnualRecordExists(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+
+		existing, err := service.EnsureAnnualRecordExists(ctx, f.user.ID, 2025)
+		if err != nil {
+			t.Fatalf("EnsureAnnualRecordExists() error = %v", err)
+		}
+		if existing.ID != f.record.ID {
+			t.Errorf("record = %d, want the existing %d", existing.ID, f.record.ID)
+		}
+
+		created, err := service.EnsureAnnualRecordExists(ctx, f.user.ID, 2030)
+		if err != nil {
+			t.Fatalf("EnsureAnnualRecordExists() error = %v", err)
+		}
+		if created.Year != 2030 || created.QuotaPlanID.Valid {
+			t.Errorf("created %+v, want a record of 2030 without a plan", created)
+		}
+	})
+}
+
+func TestScheduleYearEndRollover(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+		next, err := store.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+			PlanName:                "Default",
+			Year:                    2026,
+			QuotaVacationDay:        pgconv.MustFromFloat(12),
+			QuotaMedicalExpenseBaht: pgconv.MustFromFloat(20000),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.leave(t, store, "vacation", "2025-04-14")
+		f.leave(t, store, "vacation", "2025-04-15")
+		f.leave(t, store, "vacation", "2025-04-16")
+		f.work(t, store, 1, "2025-04-13", true)
+		if _, err := service.SyncUserRecordForYear(ctx, f.user.ID, 2025); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := service.ScheduleYearEndRollover(ctx, 2025); err != nil {
+			t.Fatalf("ScheduleYearEndRollover() error = %v", err)
+		}
+		record, err := service.GetAnnualRecord(ctx, f.user.ID, 2026)
+		if err != nil {
+			t.Fatalf("GetAnnualRecord() error = %v", err)
+		}
+		// The quota of 10 days and 1 day worked on a holiday, less the 3 days taken
+		if got := numericToFloat64(record.RolloverVacationDay, -1); got != 8 {
+			t.Errorf("rolled over %v vacation days, want 8", got)
+		}
+		if record.QuotaPlanID.Int32 != next.ID || totalsOf(*record) != (recordTotals{}) {
+			t.Errorf("record of 2026 = %+v, want no totals on plan %d", record, next.ID)
+		}
+	})
+}
+
+func TestLeaveBalance(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store db.Store) {
+		ctx := context.Background()
+		f := newBalanceFixture(t, store)
+		service := NewAnnualRecordSyncService(store)
+
+		f.leave(t, store, "vacation", "2025-04-14")
+		f.leave(t, store, "sick", "2025-06-02")
+		f.expense(t, store, 1500, "2025-03-04")
+		if _, err := service.SyncUserRecordForYear(ctx, f.user.ID, 2025); err != nil {
+			t.Fatal(err)
+		}
+
+		balance, err := store.GetLeaveBalance(ctx, sqlc.GetLeaveBalanceParams{UserID: f.user.ID, Year: 2025})
+		if err != nil {
+			t.Fatalf("GetLeaveBalance() error = %v", err)
+		}
+		got := *leaveBalanceToResponse(balance)
+		plan := "Default"
+		want := DashboardBalances{
+			Year:                        2025,
+			QuotaPlan:                   &plan,
+			VacationDays:                12, // The quota and 2 days rolled over
+			UsedVacationDays:            1,
+			RemainingVacationDays:       11,
+			UsedSickLeaveDays:           1,
+			MedicalExpenseQuotaBaht:     20000,
+			UsedMedicalExpenseBaht:      1500,
+			RemainingMedicalExpenseBaht: 18500,
+		}
+		if got.QuotaPlan == nil || *got.QuotaPlan != plan {
+			t.Errorf("quota plan = %v, want %s", got.QuotaPlan, plan)
+		}
+		got.QuotaPlan = want.QuotaPlan
+		if got != want {
+			t.Errorf("balance = %+v, want %+v", got, want)
+		}
+
+		// Replaying the events adds up the same totals, and as of before they were recorded to none
+		totals, err := store.GetBalanceTotals(ctx, sqlc.GetBalanceTotalsParams{UserID: f.user.ID, Year: 2025})
+		if err != nil {
+			t.Fatalf("GetBalanceTotals() error = %v", err)
+		}
+		if totals.Events != 3 || numericToFloat64(totals.MedicalExpenseBaht, -1) != 1500 {
+			t.Errorf("replayed %d events with %v baht, want 3 with 1500", totals.Events, numericToFloat64(totals.MedicalExpenseBaht, -1))
+		}
+		asOf := pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+		before, err := store.GetBalanceTotals(ctx, sqlc.GetBalanceTotalsParams{UserID: f.user.ID, Year: 2025, AsOf: asOf})
+		if err != nil {
+			t.Fatalf("GetBalanceTotals() error = %v", err)
+		}
+		if before.Events != 0 || before.LastEventID != 0 {
+			t.Errorf("replayed %d events up to %d an hour ago, want none", before.Events, before.LastEventID)
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/anomaly"
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/tz"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// The anomaly-detection job runs the rules of the anomaly package over the last ANOMALY_LOOKBACK
+// every night and keeps what they find in a review queue at /api/anomalies, for admins and the
+// members of ANOMALY_REVIEW_DEPARTMENT to confirm or dismiss. The rules and their thresholds are
+// runtime settings, each run reads them. A finding is flagged once, a reviewed one doesn't come
+// back unless the pattern grows.
+
+// AnomalyResponse is a finding in the review queue
+type AnomalyResponse struct {
+	ID          int32           `json:"id"`
+	Rule        string          `json:"rule"`
+	UserID      int32           `json:"userId"`
+	Username    string          `json:"username"`
+	PeriodStart string          `json:"periodStart"` // YYYY-MM-DD
+	PeriodEnd   string          `json:"periodEnd"`   // YYYY-MM-DD
+	Summary     string          `json:"summary"`     // In the language of the response
+	Details     json.RawMessage `json:"details"`     // The days, logs or expenses behind it, by rule
+	Status      string          `json:"status"`
+	ReviewedBy  *int32          `json:"reviewedBy"`
+	ReviewedAt  *time.Time      `json:"reviewedAt"`
+	ReviewNote  *string         `json:"reviewNote"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// AnomalyReviewRequest is the body of POST /api/anomalies/{id}/review. Open puts a finding back
+// in the queue.
+type AnomalyReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=open confirmed dismissed"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+func (s *Server) anomalySettings() anomaly.Settings {
+	settings := s.settings.Config().Anomalies
+	return anomaly.Settings{
+		Rules:            settings.Rules,
+		SickMondayMin:    settings.SickMondayMin,
+		SickMondayShare:  settings.SickMondayShare,
+		OverLoggingRatio: settings.OverLoggingRatio,
+		NearLimitMargin:  settings.NearLimitMargin,
+		NearLimitMin:     settings.NearLimitMin,
+	}
+}
+
+// scheduleAnomalyDetection flags the patterns of the last ANOMALY_LOOKBACK every night
+func (s *Server) scheduleAnomalyDetection() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "anomaly-detection",
+		Description: "Flag sick leave on Mondays, over-logged days and medical expenses just under the approval limit for review",
+		Schedule:    scheduler.MustParse("15 5 * * *"),
+		Run: func(ctx context.Context) error {
+			end := tz.Today(s.timeZone)
+			start := end.Add(-s.settings.Config().Anomalies.Lookback)
+			settings := s.anomalySettings()
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				flagged, err := s.detectAnomalies(ctx, start, end, settings)
+				if err != nil {
+					slog.ErrorContext(ctx, "Error detecting anomalies", "error", err)
+					errs = append(errs, err)
+				}
+				if flagged > 0 {
+					slog.InfoContext(ctx, "Anomalies flagged for review", "anomalies", flagged)
+				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
+
+// detectAnomalies runs the rules over the days from start to end and flags the findings not
+// flagged before, returning how many it flagged
+func (s *Server) detectAnomalies(ctx context.Context, start, end time.Time, settings anomaly.Settings) (int64, error) {
+	findings, err := anomaly.Detect(ctx, s.store, start, end, settings)
+	if err != nil {
+		return 0, err
+	}
+	var flagged int64
+	for _, finding := range findings {
+		details, err := json.Marshal(finding.Details)
+		if err != nil {
+			return flagged, fmt.Errorf("encoding the details of %s for user %d: %w", finding.Rule, finding.UserID, err)
+		}
+		created, err := s.store.CreateAnomaly(ctx, sqlc.CreateAnomalyParams{
+			Rule:        finding.Rule,
+			UserID:      finding.UserID,
+			PeriodStart: pgtype.Date{Time: finding.Start, Valid: true},
+			PeriodEnd:   pgtype.Date{Time: finding.End, Valid: true},
+			Fingerprint: finding.Fingerprint,
+			Summary:     finding.Summary,
+			Details:     details,
+		})
+		if err != nil {
+			return flagged, err
+		}
+		flagged += created
+	}
+	return flagged, nil
+}
+
+// mayReviewAnomalies reports whether a user is an admin or in ANOMALY_REVIEW_DEPARTMENT or a
+// department under it
+func (s *Server) mayReviewAnomalies(ctx context.Context, user sqlc.User) (bool, error) {
+	if user.UserType == "admin" {
+		return true, nil
+	}
+	reviewers := s.config.Anomalies.ReviewDepartment
+	if reviewers == "" || !user.Department.Valid {
+		return false, nil
+	}
+	if user.Department.String == reviewers {
+		return true, nil
+	}
+	chain, err := approval.DepartmentChain(ctx, s.store, user.Department.String)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(chain, func(d sqlc.Department) bool { return d.Name == reviewers }), nil
+}
+
+// authorizeAnomalyReviewer returns the current user when they may review anomalies, answering 401
+// or 403 otherwise
+func (s *Server) authorizeAnomalyReviewer(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	allowed, err := s.mayReviewAnomalies(r.Context(), currentUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching department: "+err.Error())
+		return currentUser, false
+	}
+	if !allowed {
+		respondWithError(w, http.StatusForbidden, "Only administrators and the anomaly review department can review anomalies")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// anomalyFromPath returns the finding named by the id path variable, answering 400 or 404 otherwise
+func (s *Server) anomalyFromPath(w http.ResponseWriter, r *http.Request) (sqlc.Anomaly, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid anomaly ID")
+		return sqlc.Anomaly{}, false
+	}
+	found, err := s.store.GetAnomaly(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Anomaly not found")
+		return found, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching anomaly: "+err.Error())
+		return found, false
+	}
+	return found, true
+}
+
+// anomalyResponses describes findings with the names of their users, their summaries in language
+func (s *Server) anomalyResponses(ctx context.Context, language i18n.Language, anomalies []sqlc.Anomaly) []AnomalyResponse {
+	usernames := map[int32]string{}
+	responses := make([]AnomalyResponse, 0, len(anomalies))
+	for _, a := range anomalies {
+		if _, ok := usernames[a.UserID]; !ok {
+			usernames[a.UserID] = "Unknown"
+			if user, err := s.store.GetUser(ctx, a.UserID); err == nil {
+				usernames[a.UserID] = user.Username
+			}
+		}
+		responses = append(responses, AnomalyResponse{
+			ID:          a.ID,
+			Rule:        a.Rule,
+			UserID:      a.UserID,
+			Username:    usernames[a.UserID],
+			PeriodStart: a.PeriodStart.Time.Format(validate.DateLayout),
+			PeriodEnd:   a.PeriodEnd.Time.Format(validate.DateLayout),
+			Summary:     i18n.Translate(language, a.Summary),
+			Details:     json.RawMessage(a.Details),
+			Status:      a.Status,
+			ReviewedBy:  int4Ptr(a.ReviewedBy),
+			ReviewedAt:  timestamptzPtr(a.ReviewedAt),
+			ReviewNote:  textPtr(a.ReviewNote),
+			CreatedAt:   a.CreatedAt.Time,
+		})
+	}
+	return responses
+}
+
+// getAnomalies handles GET /api/anomalies, the newest first. Only the open findings are listed
+// unless ?status= names another status or is all, ?rule= and ?user_id= narrow them further.
+func (s *Server) getAnomalies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnomalyReviewer(w, r); !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	status := pgtype.Text{String: anomaly.StatusOpen, Valid: true}
+	switch value := query.Get("status"); {
+	case value == "all":
+		status = pgtype.Text{}
+	case value != "":
+		if !slices.Contains(anomaly.Statuses, value) {
+			respondWithValidationError(w, validate.Errors{"status": "must be one of " + strings.Join(anomaly.Statuses, ", ") + " or all"})
+			return
+		}
+		status.String = value
+	}
+	var rule pgtype.Text
+	if value := query.Get("rule"); value != "" {
+		if !slices.Contains(anomaly.Rules, value) {
+			respondWithValidationError(w, validate.Errors{"rule": "must be one of " + strings.Join(anomaly.Rules, ", ")})
+			return
+		}
+		rule = pgtype.Text{String: value, Valid: true}
+	}
+	var userID pgtype.Int4
+	if value := query.Get("user_id"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		userID = pgtype.Int4{Int32: int32(parsed), Valid: true}
+	}
+
+	limit := 20
+	offset := 0
+	if parsed, err := strconv.Atoi(query.Get("limit")); err == nil && parsed > 0 {
+		limit = min(parsed, 100)
+	}
+	if parsed, err := strconv.Atoi(query.Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+
+	anomalies, err := s.store.ListAnomalies(r.Context(), sqlc.ListAnomaliesParams{
+		Status:    status,
+		Rule:      rule,
+		UserID:    userID,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching anomalies: "+err.Error())
+		return
+	}
+	total, err := s.store.CountAnomalies(r.Context(), sqlc.CountAnomaliesParams{Status: status, Rule: rule, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting anomalies: "+err.Error())
+		return
+	}
+	response := s.anomalyResponses(r.Context(), responseLanguage(w), anomalies)
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
+}
+
+// getAnomaly handles GET /api/anomalies/{id}
+func (s *Server) getAnomaly(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAnomalyReviewer(w, r); !ok {
+		return
+	}
+	found, ok := s.anomalyFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, s.anomalyResponses(r.Context(), responseLanguage(w), []sqlc.Anomaly{found})[0])
+}
+
+// reviewAnomaly handles POST /api/anomalies/{id}/review
+func (s *Server) reviewAnomaly(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, ok := s.authorizeAnomalyReviewer(w, r)
+	if !ok {
+		return
+	}
+	found, ok := s.anomalyFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req AnomalyReviewRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	reviewed, err := s.store.ReviewAnomaly(ctx, sqlc.ReviewAnomalyParams{
+		Status:     req.Status,
+		ReviewedBy: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+		ReviewNote: pgtype.Text{String: req.Note, Valid: req.Note != ""},
+		ID:         found.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Anomaly not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reviewing anomaly: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Anomaly reviewed", "anomaly_id", reviewed.ID, "status", reviewed.Status, "by", currentUser.ID)
+	respondWithJSON(w, http.StatusOK, s.anomalyResponses(ctx, responseLanguage(w), []sqlc.Anomaly{reviewed})[0])
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Internal services, such as the chatbot, call the API with a token an admin created for them
+// instead of as a user. A token only opens the endpoints of its scopes; the server keeps its
+// SHA-256 and shows the token itself once, in the response creating it.
+
+// apiTokenPrefix starts every API token, so they are easy to tell apart from other secrets
+const apiTokenPrefix = "tgapi_"
+
+// apiScopeAvailability lets a token look up whether users are available today
+const apiScopeAvailability = "availability"
+
+// apiTokenScopes are the scopes a token can be given
+var apiTokenScopes = []string{apiScopeAvailability}
+
+// APITokenRequest is the body of POST /api/admin/api-tokens
+type APITokenRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"` // What uses it, e.g. the chatbot
+	Scopes []string `json:"scopes"`                           // At least one, e.g. availability
+}
+
+// APITokenResponse is an API token, with the token itself only when it was just created
+type APITokenResponse struct {
+	ID              int32      `json:"id"`
+	Name            string     `json:"name"`
+	Token           string     `json:"token,omitempty"`
+	Scopes          []string   `json:"scopes"`
+	CreatedByUserID *int32     `json:"createdByUserId,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+}
+
+func apiTokenToResponse(token sqlc.ApiToken) APITokenResponse {
+	scopes := token.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APITokenResponse{
+		ID:              token.ID,
+		Name:            token.Name,
+		Scopes:          scopes,
+		CreatedByUserID: int4Ptr(token.CreatedByUserID),
+		CreatedAt:       token.CreatedAt.Time,
+		LastUsedAt:      timestamptzPtr(token.LastUsedAt),
+		RevokedAt:       timestamptzPtr(token.RevokedAt),
+	}
+}
+
+// newAPIToken returns a random token and the hash the server keeps of it
+func newAPIToken() (token, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = apiTokenPrefix + hex.EncodeToString(buf)
+	return token, hashAPIToken(token), nil
+}
+
+// hashAPIToken returns the hex SHA-256 of a token, which is what api_tokens keeps
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authorizeAPIToken answers 401 unless the request carries an API token that wasn't revoked and
+// 403 unless the token has the scope, returning the token otherwise
+func (s *Server) authorizeAPIToken(w http.ResponseWriter, r *http.Request, scope string) (sqlc.ApiToken, bool) {
+	ctx := r.Context()
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(token, apiTokenPrefix) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid API token")
+		return sqlc.ApiToken{}, false
+	}
+	apiToken, err := s.store.GetAPITokenByHash(ctx, hashAPIToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid API token")
+		return apiToken, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching API token: "+err.Error())
+		return apiToken, false
+	}
+	if !slices.Contains(apiToken.Scopes, scope) {
+		respondWithError(w, http.StatusForbidden, "The API token doesn't have the "+scope+" scope")
+		return apiToken, false
+	}
+	if err := s.store.TouchAPIToken(ctx, apiToken.ID); err != nil {
+		slog.WarnContext(ctx, "Error recording the use of an API token", "api_token_id", apiToken.ID, "error", err)
+	}
+	return apiToken, true
+}
+
+// authorizeAPITokenAdmin answers 403 to users other than admins and returns the admin otherwise
+func (s *Server) authorizeAPITokenAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can manage API tokens")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getAPITokens handles GET /api/admin/api-tokens, revoked tokens included
+func (s *Server) getAPITokens(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeAPITokenAdmin(w, r); !ok {
+		return
+	}
+	tokens, err := s.store.ListAPITokens(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching API tokens: "+err.Error())
+		return
+	}
+	response := make([]APITokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, apiTokenToResponse(token))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// createAPIToken handles POST /api/admin/api-tokens. The response is the only one with the token.
+func (s *Server) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, ok := s.authorizeAPITokenAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req APITokenRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if len(req.Scopes) == 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: scopes must have at least one of "+strings.Join(apiTokenScopes, ", "))
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(apiTokenScopes, scope) {
+			respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: unknown scope "+scope+", must be one of "+strings.Join(apiTokenScopes, ", "))
+			return
+		}
+	}
+
+	token, hash, err := newAPIToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating API token: "+err.Error())
+		return
+	}
+	apiToken, err := s.store.CreateAPIToken(ctx, sqlc.CreateAPITokenParams{
+		Name:            req.Name,
+		TokenHash:       hash,
+		Scopes:          slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		CreatedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating API token: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "API token created", "api_token_id", apiToken.ID, "scopes", apiToken.Scopes, "created_by", currentUser.ID)
+	response := apiTokenToResponse(apiToken)
+	response.Token = token
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// revokeAPIToken handles DELETE /api/admin/api-tokens/{id}. The token stops working at once and
+// stays listed as revoked.
+func (s *Server) revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, ok := s.authorizeAPITokenAdmin(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid API token ID")
+		return
+	}
+	if _, err := s.store.GetAPIToken(ctx, int32(id)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error fetching API token: "+err.Error())
+		return
+	}
+	_, err = s.store.RevokeAPIToken(ctx, int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "The API token was revoked already")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error revoking API token: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "API token revoked", "api_token_id", id, "revoked_by", currentUser.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
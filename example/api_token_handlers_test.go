@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+func TestAPITokenAuthorization(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	dbtest.CreateUser(t, store, "alice", "user")
+
+	var created APITokenResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/api-tokens", tokenFor(admin.Username), APITokenRequest{
+		Name:   "chatbot",
+		Scopes: []string{apiScopeAvailability},
+	}), http.StatusCreated, &created)
+	if !strings.HasPrefix(created.Token, apiTokenPrefix) {
+		t.Fatalf("created token %q, want one starting with %s", created.Token, apiTokenPrefix)
+	}
+
+	var revoked APITokenResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/api-tokens", tokenFor(admin.Username), APITokenRequest{
+		Name:   "old chatbot",
+		Scopes: []string{apiScopeAvailability},
+	}), http.StatusCreated, &revoked)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, fmt.Sprintf("/api/admin/api-tokens/%d", revoked.ID), tokenFor(admin.Username), nil), http.StatusNoContent, nil)
+
+	// No scope admins can give yet lacks availability, so store a token with another one
+	unscoped, hash, err := newAPIToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateAPIToken(context.Background(), sqlc.CreateAPITokenParams{
+		Name:      "reports",
+		TokenHash: hash,
+		Scopes:    []string{"reports"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "user token", token: tokenFor(admin.Username), want: http.StatusUnauthorized},
+		{name: "unknown token", token: apiTokenPrefix + "0123456789abcdef", want: http.StatusUnauthorized},
+		{name: "unprefixed token", token: strings.TrimPrefix(created.Token, apiTokenPrefix), want: http.StatusUnauthorized},
+		{name: "revoked token", token: revoked.Token, want: http.StatusUnauthorized},
+		{name: "token without the scope", token: unscoped, want: http.StatusForbidden},
+		{name: "token with the scope", token: created.Token, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability/alice", tt.token, nil), tt.want, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability?usernames=alice", tt.token, nil), tt.want, nil)
+		})
+	}
+}
+
+func TestAPITokenShownOnce(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+
+	recorder := dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/api-tokens", tokenFor(admin.Username), APITokenRequest{
+		Name:   "chatbot",
+		Scopes: []string{apiScopeAvailability},
+	})
+	var created APITokenResponse
+	dbtest.DecodeJSON(t, recorder, http.StatusCreated, &created)
+	if created.Token == "" {
+		t.Fatal("the create response has no token")
+	}
+
+	listed := dbtest.DoJSON(t, handler, http.MethodGet, "/api/admin/api-tokens", tokenFor(admin.Username), nil)
+	var tokens []APITokenResponse
+	dbtest.DecodeJSON(t, listed, http.StatusOK, &tokens)
+	if len(tokens) != 1 || tokens[0].ID != created.ID {
+		t.Fatalf("listed tokens = %+v, want %d", tokens, created.ID)
+	}
+	if strings.Contains(listed.Body.String(), created.Token) || strings.Contains(listed.Body.String(), hashAPIToken(created.Token)) {
+		t.Errorf("the token list reveals the token: %s", listed.Body.String())
+	}
+
+	stored, err := store.GetAPIToken(context.Background(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.TokenHash == created.Token || stored.TokenHash != hashAPIToken(created.Token) {
+		t.Errorf("stored %q, want the SHA-256 of the token", stored.TokenHash)
+	}
+
+	// Only admins manage tokens
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/admin/api-tokens", tokenFor(user.Username), nil), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/api-tokens", tokenFor(user.Username), APITokenRequest{
+		Name:   "mine",
+		Scopes: []string{apiScopeAvailability},
+	}), http.StatusForbidden, nil)
+}
+
+func TestAvailabilityLookups(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	dbtest.CreateUser(t, store, "bob", "user")
+
+	var created APITokenResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/api-tokens", tokenFor(admin.Username), APITokenRequest{
+		Name:   "chatbot",
+		Scopes: []string{apiScopeAvailability},
+	}), http.StatusCreated, &created)
+
+	var before AvailabilityResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability/alice", created.Token, nil), http.StatusOK, &before)
+	if before.Username != "alice" || before.Date == "" || before.Available != (before.Status == availabilityWorking) {
+		t.Fatalf("alice's availability = %+v", before)
+	}
+
+	// Leave on alice's today takes her out, whatever else the day is
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(admin.Username), LeaveLogRequest{
+		UserID: alice.ID,
+		Type:   "sick",
+		Date:   before.Date,
+	}), http.StatusCreated, nil)
+
+	var single AvailabilityResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability/alice", created.Token, nil), http.StatusOK, &single)
+	if single.Status != availabilityOnLeave || single.Available {
+		t.Errorf("alice's availability = %+v, want on leave", single)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability/nobody", created.Token, nil), http.StatusNotFound, nil)
+
+	var bulk AvailabilityListResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability?usernames=bob,nobody,alice,bob", created.Token, nil), http.StatusOK, &bulk)
+	if len(bulk.Users) != 2 || bulk.Users[0].Username != "bob" || bulk.Users[1].Username != "alice" {
+		t.Fatalf("bulk users = %+v, want bob then alice", bulk.Users)
+	}
+	if bulk.Users[1].Status != availabilityOnLeave || bulk.Users[0].Status == availabilityOnLeave {
+		t.Errorf("bulk users = %+v, want only alice on leave", bulk.Users)
+	}
+	if len(bulk.NotFound) != 1 || bulk.NotFound[0] != "nobody" {
+		t.Errorf("not found = %v, want nobody", bulk.NotFound)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/availability", created.Token, nil), http.StatusBadRequest, nil)
+}
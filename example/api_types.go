@@ -0,0 +1,214 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Request and response bodies of the user, annual record, holiday, quota plan, medical expense
+// and leave log handlers. The OpenAPI spec describes them from these definitions, so a field
+// added here shows up in /api/openapi.json without further work.
+
+// UserResponse is the response format for user data
+type UserResponse struct {
+	ID            int32     `json:"id"`
+	Username      string    `json:"username"`
+	UserType      string    `json:"user_type"`
+	Email         string    `json:"email"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	DailyCapacity float64   `json:"daily_capacity"` // Days of work per calendar day, e.g. 0.5 for part-time staff
+	Department    string    `json:"department,omitempty"`
+}
+
+// UserCreateRequest is the request body for creating a user
+type UserCreateRequest struct {
+	Username      string   `json:"username" validate:"required,max=255"`
+	Password      string   `json:"password" validate:"required,max=72"`
+	UserType      string   `json:"userType" validate:"required,max=50"`
+	Email         string   `json:"email" validate:"required,email,max=255"`
+	DailyCapacity *float64 `json:"dailyCapacity" validate:"gt=0,max=1"` // Defaults to 1
+}
+
+// UserUpdateRequest is the request body for updating a user
+type UserUpdateRequest struct {
+	Username      string   `json:"username" validate:"max=255"`
+	Password      string   `json:"password" validate:"max=72"`
+	UserType      string   `json:"user_type" validate:"max=50"`
+	Email         string   `json:"email" validate:"email,max=255"`
+	DailyCapacity *float64 `json:"daily_capacity" validate:"gt=0,max=1"`
+	Department    *string  `json:"department" validate:"max=100"` // One of /api/departments, an empty string clears the department
+}
+
+// LoginRequest is the request body for logging in
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// LoginResponse carries the token to send as "Authorization: Bearer <token>" on later requests
+type LoginResponse struct {
+	Token string       `json:"token"`
+	User  UserResponse `json:"user"`
+}
+
+// ErrorResponse represents an error message. RequestID is the X-Request-ID of the request, for
+// users to quote when they report the failure.
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ValidationErrorResponse answers a request breaking the rules of its body, with what is wrong
+// with each invalid field, keyed by the field's JSON name
+type ValidationErrorResponse struct {
+	Error     string            `json:"error"`
+	Fields    map[string]string `json:"fields"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// ConflictResponse answers an update made against a stale copy of a record, with the record as
+// it is now so the client can merge and retry
+type ConflictResponse struct {
+	Error   string      `json:"error"`
+	Current interface{} `json:"current"`
+}
+
+// AnnualRecordRequest is the request body for creating an annual record
+type AnnualRecordRequest struct {
+	UserId                 int32   `json:"userId" validate:"required"`
+	Year                   int32   `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaPlanId            int32   `json:"quotaPlanId" validate:"required"`
+	RolloverVacationDay    float64 `json:"rolloverVacationDay" validate:"min=0"`
+	UsedVacationDay        float64 `json:"usedVacationDay" validate:"min=0"`
+	UsedSickLeaveDay       float64 `json:"usedSickLeaveDay" validate:"min=0"`
+	WorkedOnHolidayDay     float64 `json:"workedOnHolidayDay" validate:"min=0"`
+	WorkedDay              float64 `json:"workedDay" validate:"min=0"`
+	UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht" validate:"min=0"`
+}
+
+// AnnualRecordUpdateRequest is the request body for updating an annual record
+type AnnualRecordUpdateRequest struct {
+	QuotaPlanId            int32   `json:"quotaPlanId" validate:"required"`
+	RolloverVacationDay    float64 `json:"rolloverVacationDay" validate:"min=0"`
+	UsedVacationDay        float64 `json:"usedVacationDay" validate:"min=0"`
+	UsedSickLeaveDay       float64 `json:"usedSickLeaveDay" validate:"min=0"`
+	WorkedOnHolidayDay     float64 `json:"workedOnHolidayDay" validate:"min=0"`
+	WorkedDay              float64 `json:"workedDay" validate:"min=0"`
+	UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht" validate:"min=0"`
+	// updatedAt of the record the edit is based on, the update fails with 409 when it changed since
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+// AnnualRecordUpsertRequest is the request body for setting a user's annual record of a year
+type AnnualRecordUpsertRequest struct {
+	UserID                 int32   `json:"user_id" validate:"required"`
+	Year                   int32   `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaPlanID            int32   `json:"quota_plan_id" validate:"required"`
+	RolloverVacationDay    float64 `json:"rollover_vacation_day" validate:"min=0"`
+	UsedVacationDay        float64 `json:"used_vacation_day" validate:"min=0"`
+	UsedSickLeaveDay       float64 `json:"used_sick_leave_day" validate:"min=0"`
+	WorkedOnHolidayDay     float64 `json:"worked_on_holiday_day" validate:"min=0"`
+	WorkedDay              float64 `json:"worked_day" validate:"min=0"`
+	UsedMedicalExpenseBaht float64 `json:"used_medical_expense_baht" validate:"min=0"`
+}
+
+// QuotaPlanAssignRequest is the request body for assigning a quota plan to every user
+type QuotaPlanAssignRequest struct {
+	Year        int32 `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaPlanID int32 `json:"quota_plan_id" validate:"required"`
+}
+
+// NextYearAnnualRecordsRequest is the request body for carrying annual records over to a new year
+type NextYearAnnualRecordsRequest struct {
+	ThisYear int32 `json:"this_year" validate:"required,min=2000,max=2100"`
+	NextYear int32 `json:"next_year" validate:"required,min=2000,max=2100"`
+}
+
+// HolidayRequest is the request body for creating or updating a holiday
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required,date"`
+	Name string `json:"name" validate:"required,max=255"`
+	Note string `json:"note"`
+}
+
+// QuotaPlanRequest is the request body for creating a quota plan
+type QuotaPlanRequest struct {
+	PlanName                string  `json:"plan_name" validate:"required,max=255"`
+	Year                    int32   `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaVacationDay        float64 `json:"quota_vacation_day" validate:"min=0"`
+	QuotaMedicalExpenseBaht float64 `json:"quota_medical_expense_baht" validate:"min=0"`
+	CreatedByUserID         int32   `json:"created_by_user_id"`
+}
+
+// QuotaPlanUpdateRequest is the request body for updating a quota plan
+type QuotaPlanUpdateRequest struct {
+	PlanName                string  `json:"plan_name" validate:"required,max=255"`
+	Year                    int32   `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaVacationDay        float64 `json:"quota_vacation_day" validate:"min=0"`
+	QuotaMedicalExpenseBaht float64 `json:"quota_medical_expense_baht" validate:"min=0"`
+	// updated_at of the plan the edit is based on, the update fails with 409 when it changed since
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// MedicalExpenseRequest is the request body for creating a medical expense
+type MedicalExpenseRequest struct {
+	UserID      int32   `json:"user_id" validate:"required"`
+	Amount      float64 `json:"amount" validate:"gt=0"`
+	ReceiptName string  `json:"receipt_name" validate:"max=255"`
+	ReceiptDate string  `json:"receipt_date" validate:"required,date"` // Format: YYYY-MM-DD
+	Note        string  `json:"note"`
+}
+
+// MedicalExpenseUpdateRequest is the request body for updating a medical expense
+type MedicalExpenseUpdateRequest struct {
+	Amount      float64 `json:"amount" validate:"gt=0"`
+	ReceiptName string  `json:"receipt_name" validate:"max=255"`
+	ReceiptDate string  `json:"receipt_date" validate:"required,date"` // Format: YYYY-MM-DD
+	Note        string  `json:"note"`
+}
+
+// LeaveLogRequest is the request body for creating a leave log
+type LeaveLogRequest struct {
+	UserID int32  `json:"user_id" validate:"required"`
+	Type   string `json:"type" validate:"required,oneof=vacation sick personal unpaid other"`
+	Date   string `json:"date" validate:"required,date"`
+	Note   string `json:"note"`
+}
+
+// LeaveLogUpdateRequest is the request body for updating a leave log
+type LeaveLogUpdateRequest struct {
+	Type string `json:"type" validate:"required,oneof=vacation sick personal unpaid other"`
+	Date string `json:"date" validate:"required,date"`
+	Note string `json:"note"`
+	// updated_at of the leave log the edit is based on, the update fails with 409 when it changed since
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// LeaveLogResponse is the response format for a leave log, with the name of its user
+type LeaveLogResponse struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	Username  string             `json:"username"`
+	Type      string             `json:"type"`
+	Date      pgtype.Date        `json:"date"`
+	Note      pgtype.Text        `json:"note"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// newLeaveLogResponse formats a leave log for the response
+func newLeaveLogResponse(log sqlc.LeaveLog, username string) LeaveLogResponse {
+	return LeaveLogResponse{
+		ID:        log.ID,
+		UserID:    log.UserID,
+		Username:  username,
+		Type:      log.Type,
+		Date:      log.Date,
+		Note:      log.Note,
+		CreatedAt: log.CreatedAt,
+		UpdatedAt: log.UpdatedAt,
+	}
+}
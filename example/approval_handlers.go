@@ -0,0 +1,691 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// ApprovalPolicyRequest is the body of PUT /api/admin/approval-policies/{kind}
+type ApprovalPolicyRequest struct {
+	Steps []string `json:"steps" validate:"required,max=10"` // Decided one after another
+	// Requests of up to this many days or baht are approved as they are submitted
+	AutoApproveMax *float64 `json:"autoApproveMax" validate:"min=0"`
+}
+
+// ApprovalPolicyResponse is the approval policy of a kind of request
+type ApprovalPolicyResponse struct {
+	Kind           string    `json:"kind"`
+	Steps          []string  `json:"steps"`
+	AutoApproveMax *float64  `json:"autoApproveMax,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// ApprovalDecisionRequest is the body of POST /api/approvals/{id}/approve and /reject
+type ApprovalDecisionRequest struct {
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+// ApprovalDecisionResponse is the decision of a step of a request
+type ApprovalDecisionResponse struct {
+	Step         int32     `json:"step"`
+	ApproverID   *int32    `json:"approverId,omitempty"`
+	OnBehalfOfID *int32    `json:"onBehalfOfId,omitempty"` // Who delegated the step to the approver
+	Decision     string    `json:"decision"`
+	Comment      string    `json:"comment,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ApprovalRequestResponse is a request for approval
+type ApprovalRequestResponse struct {
+	ID                int32                      `json:"id"`
+	Kind              string                     `json:"kind"`
+	SubjectID         int32                      `json:"subjectId"`
+	Description       string                     `json:"description"`
+	RequesterID       int32                      `json:"requesterId"`
+	RequesterUsername string                     `json:"requesterUsername"`
+	Amount            float64                    `json:"amount"`
+	Steps             []string                   `json:"steps"`
+	CurrentStep       int32                      `json:"currentStep"`
+	WaitingOn         string                     `json:"waitingOn,omitempty"` // Who decides the current step
+	Status            string                     `json:"status"`
+	DecidedAt         *time.Time                 `json:"decidedAt,omitempty"`
+	CreatedAt         time.Time                  `json:"createdAt"`
+	Decisions         []ApprovalDecisionResponse `json:"decisions,omitempty"`
+}
+
+// ApprovalDelegationRequest is the body of POST /api/approval-delegations
+type ApprovalDelegationRequest struct {
+	DelegatorID *int32 `json:"delegatorId"` // Admins only, the current user when left out
+	DelegateID  int32  `json:"delegateId" validate:"required"`
+	StartDate   string `json:"startDate" validate:"required,date"`
+	EndDate     string `json:"endDate" validate:"required,date"`
+}
+
+// ApprovalDelegationResponse is a delegation of someone's approvals for some days
+type ApprovalDelegationResponse struct {
+	ID          int32                                `json:"id"`
+	DelegatorID int32                                `json:"delegatorId"`
+	DelegateID  int32                                `json:"delegateId"`
+	StartDate   string                               `json:"startDate"`
+	EndDate     string                               `json:"endDate"`
+	CreatedByID *int32                               `json:"createdById,omitempty"` // The delegator or an admin
+	CreatedAt   time.Time                            `json:"createdAt"`
+	RevokedByID *int32                               `json:"revokedById,omitempty"`
+	RevokedAt   *time.Time                           `json:"revokedAt,omitempty"` // Revoked delegations no longer count
+	Decisions   []ApprovalDelegationDecisionResponse `json:"decisions,omitempty"` // Made under the delegation
+}
+
+// ApprovalDelegationDecisionResponse is a decision a delegate made under a delegation
+type ApprovalDelegationDecisionResponse struct {
+	RequestID int32     `json:"requestId"`
+	Step      int32     `json:"step"`
+	Decision  string    `json:"decision"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func approvalPolicyToResponse(policy sqlc.ApprovalPolicy) ApprovalPolicyResponse {
+	return ApprovalPolicyResponse{
+		Kind:           policy.Kind,
+		Steps:          policy.Steps,
+		AutoApproveMax: numericPtr(policy.AutoApproveMax),
+		UpdatedAt:      policy.UpdatedAt.Time,
+	}
+}
+
+func approvalDelegationToResponse(delegation sqlc.ApprovalDelegation) ApprovalDelegationResponse {
+	return ApprovalDelegationResponse{
+		ID:          delegation.ID,
+		DelegatorID: delegation.DelegatorID,
+		DelegateID:  delegation.DelegateID,
+		StartDate:   delegation.StartDate.Time.Format(validate.DateLayout),
+		EndDate:     delegation.EndDate.Time.Format(validate.DateLayout),
+		CreatedByID: int4Ptr(delegation.CreatedByID),
+		CreatedAt:   delegation.CreatedAt.Time,
+		RevokedByID: int4Ptr(delegation.RevokedByID),
+		RevokedAt:   timestamptzPtr(delegation.RevokedAt),
+	}
+}
+
+// approvalRequestResponses describes requests with who submitted them and what they are about
+func (s *Server) approvalRequestResponses(ctx context.Context, requests []sqlc.ApprovalRequest) ([]ApprovalRequestResponse, error) {
+	usernames := map[int32]string{}
+	responses := make([]ApprovalRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		subject, err := loadApprovalSubject(ctx, s.store, request)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := usernames[request.RequesterID]; !ok {
+			usernames[request.RequesterID] = "Unknown"
+			if user, err := s.store.GetUser(ctx, request.RequesterID); err == nil {
+				usernames[request.RequesterID] = user.Username
+			}
+		}
+		response := ApprovalRequestResponse{
+			ID:                request.ID,
+			Kind:              request.Kind,
+			SubjectID:         request.SubjectID,
+			Description:       subject.description,
+			RequesterID:       request.RequesterID,
+			RequesterUsername: usernames[request.RequesterID],
+			Amount:            numericToFloat64(request.Amount, 0),
+			Steps:             request.Steps,
+			CurrentStep:       request.CurrentStep,
+			Status:            request.Status,
+			DecidedAt:         timestamptzPtr(request.DecidedAt),
+			CreatedAt:         request.CreatedAt.Time,
+		}
+		if step, ok := approval.Step(request); ok {
+			response.WaitingOn = approval.StepLabel(step)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// authorizeApprovalAdmin answers 401 or 403 unless the request comes from an administrator
+func (s *Server) authorizeApprovalAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can change approval policies")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getApprovalPolicies handles GET /api/admin/approval-policies, by kind
+func (s *Server) getApprovalPolicies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeApprovalAdmin(w, r); !ok {
+		return
+	}
+	policies, err := s.store.ListApprovalPolicies(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching approval policies: "+err.Error())
+		return
+	}
+	response := make([]ApprovalPolicyResponse, len(policies))
+	for i, policy := range policies {
+		response[i] = approvalPolicyToResponse(policy)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// putApprovalPolicy handles PUT /api/admin/approval-policies/{kind}. Requests already waiting
+// keep the steps they were submitted with.
+func (s *Server) putApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeApprovalAdmin(w, r); !ok {
+		return
+	}
+	kind := mux.Vars(r)["kind"]
+	if !approval.ValidKind(kind) {
+		respondWithError(w, http.StatusNotFound, "Unknown kind of approval, must be one of "+strings.Join(approval.Kinds, ", "))
+		return
+	}
+	var req ApprovalPolicyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	errs := validate.Errors{}
+	for i, step := range req.Steps {
+		req.Steps[i] = strings.TrimSpace(step)
+		if !approval.ValidStep(req.Steps[i]) {
+			errs.Add("steps", "must be manager, supervisor, department_head, admin or department:<name>")
+			continue
+		}
+		if name, ok := strings.CutPrefix(req.Steps[i], approval.StepDepartmentPrefix); ok {
+			if _, err := s.store.GetDepartmentByName(ctx, strings.TrimSpace(name)); errors.Is(err, pgx.ErrNoRows) {
+				errs.Add("steps", "department "+name+" not found")
+			} else if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error fetching department: "+err.Error())
+				return
+			}
+		}
+	}
+	if err := errs.Err(); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	var autoApproveMax pgtype.Numeric
+	if req.AutoApproveMax != nil {
+		var err error
+		if autoApproveMax, err = pgconv.FromFloat(*req.AutoApproveMax); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
+			return
+		}
+	}
+	policy, err := s.store.UpsertApprovalPolicy(ctx, sqlc.UpsertApprovalPolicyParams{
+		Kind:           kind,
+		Steps:          req.Steps,
+		AutoApproveMax: autoApproveMax,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving approval policy: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, approvalPolicyToResponse(policy))
+}
+
+// deleteApprovalPolicy handles DELETE /api/admin/approval-policies/{kind}. Requests of the kind
+// need no approval from then on, the ones already waiting can still be decided.
+func (s *Server) deleteApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeApprovalAdmin(w, r); !ok {
+		return
+	}
+	kind := mux.Vars(r)["kind"]
+	if !approval.ValidKind(kind) {
+		respondWithError(w, http.StatusNotFound, "Unknown kind of approval, must be one of "+strings.Join(approval.Kinds, ", "))
+		return
+	}
+	if err := s.store.DeleteApprovalPolicy(r.Context(), kind); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting approval policy: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getApprovalInbox handles GET /api/approvals/inbox, the requests the current user may decide
+// now, for themselves or for whoever delegated to them, oldest first
+func (s *Server) getApprovalInbox(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	pending, err := s.store.ListPendingApprovalRequests(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching approval requests: "+err.Error())
+		return
+	}
+
+	// Delegations count by the days of the delegate's time zone
+	today, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	var inbox []sqlc.ApprovalRequest
+	for _, request := range pending {
+		_, ok, err := approval.CanDecide(ctx, s.store, request, currentUser, today)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error checking approvers: "+err.Error())
+			return
+		}
+		if ok {
+			inbox = append(inbox, request)
+		}
+	}
+	response, err := s.approvalRequestResponses(ctx, inbox)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what requests are about: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getMyApprovalRequests handles GET /api/approvals/mine, what the current user submitted,
+// latest first
+func (s *Server) getMyApprovalRequests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	requests, err := s.store.ListApprovalRequestsByRequester(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching approval requests: "+err.Error())
+		return
+	}
+	response, err := s.approvalRequestResponses(ctx, requests)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what requests are about: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getApprovalRequest handles GET /api/approvals/{id}, a request with its decisions, for its
+// requester, admins, who decided a step of it and who may decide the step it waits on
+func (s *Server) getApprovalRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	request, ok := s.approvalRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+	decisions, err := s.store.ListApprovalDecisions(ctx, request.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching decisions: "+err.Error())
+		return
+	}
+
+	allowed := currentUser.UserType == "admin" || currentUser.ID == request.RequesterID ||
+		slices.ContainsFunc(decisions, func(d sqlc.ApprovalDecision) bool {
+			return d.ApproverID.Valid && d.ApproverID.Int32 == currentUser.ID
+		})
+	if !allowed {
+		today, err := s.userToday(ctx, currentUser.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+			return
+		}
+		if _, allowed, err = approval.CanDecide(ctx, s.store, request, currentUser, today); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error checking approvers: "+err.Error())
+			return
+		}
+	}
+	if !allowed {
+		respondWithError(w, http.StatusForbidden, "You don't have permission to view this request")
+		return
+	}
+
+	responses, err := s.approvalRequestResponses(ctx, []sqlc.ApprovalRequest{request})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what the request is about: "+err.Error())
+		return
+	}
+	response := responses[0]
+	response.Decisions = make([]ApprovalDecisionResponse, len(decisions))
+	for i, d := range decisions {
+		response.Decisions[i] = ApprovalDecisionResponse{
+			Step:         d.Step,
+			ApproverID:   int4Ptr(d.ApproverID),
+			OnBehalfOfID: int4Ptr(d.OnBehalfOfID),
+			Decision:     d.Decision,
+			Comment:      d.Comment.String,
+			CreatedAt:    d.CreatedAt.Time,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// approveApprovalRequest handles POST /api/approvals/{id}/approve
+func (s *Server) approveApprovalRequest(w http.ResponseWriter, r *http.Request) {
+	s.decideApprovalRequest(w, r, true)
+}
+
+// rejectApprovalRequest handles POST /api/approvals/{id}/reject
+func (s *Server) rejectApprovalRequest(w http.ResponseWriter, r *http.Request) {
+	s.decideApprovalRequest(w, r, false)
+}
+
+// decideApprovalRequest approves or rejects the step a request waits on
+func (s *Server) decideApprovalRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	request, ok := s.approvalRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req ApprovalDecisionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	// Describe the request first, rejecting it deletes what it is about
+	response, err := s.approvalRequestResponses(ctx, []sqlc.ApprovalRequest{request})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what the request is about: "+err.Error())
+		return
+	}
+
+	decided, err := s.decideApproval(ctx, request, currentUser, approve, strings.TrimSpace(req.Comment))
+	if errors.Is(err, approval.ErrNotApprover) {
+		respondWithError(w, http.StatusForbidden, "You can't decide the step this request waits on")
+		return
+	}
+	if errors.Is(err, approval.ErrDecided) {
+		respondWithError(w, http.StatusConflict, "The request was decided already")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deciding the request: "+err.Error())
+		return
+	}
+
+	description := response[0].Description
+	if response, err = s.approvalRequestResponses(ctx, []sqlc.ApprovalRequest{decided}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what the request is about: "+err.Error())
+		return
+	}
+	response[0].Description = description
+	respondWithJSON(w, http.StatusOK, response[0])
+}
+
+// approvalRequestFromPath returns the request with the ID of the path, answering 400 or 404
+// when there is none
+func (s *Server) approvalRequestFromPath(w http.ResponseWriter, r *http.Request) (sqlc.ApprovalRequest, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid approval request ID")
+		return sqlc.ApprovalRequest{}, false
+	}
+	request, err := s.store.GetApprovalRequest(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Approval request not found")
+		return request, false
+	}
+	return request, true
+}
+
+// getApprovalDelegations handles GET /api/approval-delegations, the delegations the current
+// user gave or was given, revoked ones too, latest first. Admins see those of the user_id they
+// ask for.
+func (s *Server) getApprovalDelegations(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	userID := currentUser.ID
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		if int32(id) != currentUser.ID && currentUser.UserType != "admin" {
+			respondWithError(w, http.StatusForbidden, "Only admins can view the delegations of other users")
+			return
+		}
+		userID = int32(id)
+	}
+	delegations, err := s.store.ListApprovalDelegationsByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching delegations: "+err.Error())
+		return
+	}
+	response := make([]ApprovalDelegationResponse, len(delegations))
+	for i, delegation := range delegations {
+		response[i] = approvalDelegationToResponse(delegation)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// createApprovalDelegation handles POST /api/approval-delegations. Users delegate their own
+// approvals, admins anyone's.
+func (s *Server) createApprovalDelegation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	var req ApprovalDelegationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	delegatorID := currentUser.ID
+	if req.DelegatorID != nil && *req.DelegatorID != currentUser.ID {
+		if currentUser.UserType != "admin" {
+			respondWithError(w, http.StatusForbidden, "You can only delegate your own approvals")
+			return
+		}
+		delegatorID = *req.DelegatorID
+	}
+
+	errs := validate.Errors{}
+	if _, err := s.store.GetUser(ctx, delegatorID); err != nil {
+		errs.Add("delegatorId", "user not found")
+	}
+	if req.DelegateID == delegatorID {
+		errs.Add("delegateId", "must be someone else")
+	} else if _, err := s.store.GetUser(ctx, req.DelegateID); err != nil {
+		errs.Add("delegateId", "user not found")
+	}
+	startDate, endDate := requestDate(req.StartDate), requestDate(req.EndDate)
+	if endDate.Time.Before(startDate.Time) {
+		errs.Add("endDate", "must not be before startDate")
+	}
+	if err := errs.Err(); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	delegation, err := s.store.CreateApprovalDelegation(ctx, sqlc.CreateApprovalDelegationParams{
+		DelegatorID: delegatorID,
+		DelegateID:  req.DelegateID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		CreatedByID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating delegation: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Approval delegation created", "delegation_id", delegation.ID, "delegator_id", delegatorID, "delegate_id", req.DelegateID, "created_by", currentUser.ID)
+	respondWithJSON(w, http.StatusCreated, approvalDelegationToResponse(delegation))
+}
+
+// getApprovalDelegation handles GET /api/approval-delegations/{id}, a delegation with the
+// decisions made under it, for the delegator, the delegate and admins
+func (s *Server) getApprovalDelegation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	delegation, ok := s.approvalDelegationFromPath(w, r)
+	if !ok {
+		return
+	}
+	if currentUser.UserType != "admin" && currentUser.ID != delegation.DelegatorID && currentUser.ID != delegation.DelegateID {
+		respondWithError(w, http.StatusForbidden, "You don't have permission to view this delegation")
+		return
+	}
+	decisions, err := s.store.ListApprovalDecisionsByDelegation(ctx, pgtype.Int4{Int32: delegation.ID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching decisions: "+err.Error())
+		return
+	}
+	response := approvalDelegationToResponse(delegation)
+	response.Decisions = make([]ApprovalDelegationDecisionResponse, len(decisions))
+	for i, d := range decisions {
+		response.Decisions[i] = ApprovalDelegationDecisionResponse{
+			RequestID: d.RequestID,
+			Step:      d.Step,
+			Decision:  d.Decision,
+			Comment:   d.Comment.String,
+			CreatedAt: d.CreatedAt.Time,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// deleteApprovalDelegation handles DELETE /api/approval-delegations/{id}, for the delegator
+// and admins. The delegation is revoked rather than deleted, so it stays in the audit trail with
+// the decisions made under it.
+func (s *Server) deleteApprovalDelegation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	delegation, ok := s.approvalDelegationFromPath(w, r)
+	if !ok {
+		return
+	}
+	if currentUser.UserType != "admin" && currentUser.ID != delegation.DelegatorID {
+		respondWithError(w, http.StatusForbidden, "You can only delete your own delegations")
+		return
+	}
+	_, err = s.store.RevokeApprovalDelegation(ctx, sqlc.RevokeApprovalDelegationParams{
+		RevokedByID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+		ID:          delegation.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "The delegation was revoked already")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting delegation: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Approval delegation revoked", "delegation_id", delegation.ID, "revoked_by", currentUser.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// approvalDelegationFromPath returns the delegation with the ID of the path, answering 400 or 404
+// when there is none
+func (s *Server) approvalDelegationFromPath(w http.ResponseWriter, r *http.Request) (sqlc.ApprovalDelegation, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delegation ID")
+		return sqlc.ApprovalDelegation{}, false
+	}
+	delegation, err := s.store.GetApprovalDelegation(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Delegation not found")
+		return delegation, false
+	}
+	return delegation, true
+}
+
+// holdQuotaPlanEdit submits an edit of a quota plan for approval when quota plan edits need it,
+// answering 202 with the request, and reports whether it did. An edit that is approved as it is
+// submitted is left to the caller to apply.
+func (s *Server) holdQuotaPlanEdit(w http.ResponseWriter, r *http.Request, id int32, edit QuotaPlanUpdateRequest) bool {
+	ctx := r.Context()
+	if _, err := s.store.GetApprovalPolicy(ctx, approval.KindQuotaPlan); errors.Is(err, pgx.ErrNoRows) {
+		return false
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching approval policy: "+err.Error())
+		return true
+	}
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return true
+	}
+	plan, err := s.store.GetQuotaPlan(ctx, id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Quota plan not found")
+		return true
+	}
+	if edit.UpdatedAt != nil && !plan.UpdatedAt.Time.Equal(*edit.UpdatedAt) {
+		respondWithConflict(w, "Quota plan was changed by someone else", plan)
+		return true
+	}
+	payload, err := json.Marshal(edit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error encoding the edit: "+err.Error())
+		return true
+	}
+
+	request, _, err := approval.Submit(ctx, s.store, approval.Submission{
+		Kind:        approval.KindQuotaPlan,
+		SubjectID:   id,
+		RequesterID: currentUser.ID,
+		Payload:     payload,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error submitting the edit for approval: "+err.Error())
+		return true
+	}
+	if request.Status != approval.StatusPending {
+		return false
+	}
+	response, err := s.approvalRequestResponses(ctx, []sqlc.ApprovalRequest{request})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what the request is about: "+err.Error())
+		return true
+	}
+	respondWithJSON(w, http.StatusAccepted, response[0])
+	return true
+}
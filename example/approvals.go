@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/tz"
+)
+
+// Leave, medical expenses and task logs users record for themselves, and quota plan edits, go
+// through the approval package when an admin set a policy for their kind. Leave, expenses and
+// task logs count from when they are recorded: approving confirms them and rejecting deletes
+// them, like their owner deleting them. A quota plan edit waits instead and is applied once
+// approved. Deleting what a request waits on cancels the request. The requester is notified
+// when their request is approved or rejected, leave as before, medical expenses with
+// expense_decided and the other kinds with approval_decided. Approvers are reminded every
+// weekday morning of the requests that have waited on them for over a day.
+
+// submitApproval submits a record for approval and reports whether it waits on someone. Like
+// the other side effects of a write, a failure is only logged and the record stands.
+func (s *Server) submitApproval(ctx context.Context, submission approval.Submission) (sqlc.ApprovalRequest, bool) {
+	request, ok, err := approval.Submit(ctx, s.store, submission)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error submitting for approval", "kind", submission.Kind, "subject_id", submission.SubjectID, "error", err)
+		return request, false
+	}
+	return request, ok && request.Status == approval.StatusPending
+}
+
+// cancelApprovals cancels the requests waiting on a record that was deleted
+func (s *Server) cancelApprovals(ctx context.Context, kind string, subjectID int32) {
+	err := s.store.CancelApprovalRequests(ctx, sqlc.CancelApprovalRequestsParams{Kind: kind, SubjectID: subjectID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error cancelling approval requests", "kind", kind, "subject_id", subjectID, "error", err)
+	}
+}
+
+// approvalSubject is what a request is about, read before deciding it since rejecting deletes it
+type approvalSubject struct {
+	description string    // e.g. "vacation leave on 2026-03-02"
+	userID      int32     // Whose balances it counts in, 0 for quota plans
+	date        time.Time // The day it counts on
+	leaveLog    *sqlc.LeaveLog
+	expense     *sqlc.MedicalExpense
+}
+
+// loadApprovalSubject reads what a request is about, a description of the kind and ID when it
+// was deleted since
+func loadApprovalSubject(ctx context.Context, q sqlc.Querier, request sqlc.ApprovalRequest) (approvalSubject, error) {
+	subject := approvalSubject{description: fmt.Sprintf("%s #%d", request.Kind, request.SubjectID)}
+	var err error
+	switch request.Kind {
+	case approval.KindLeave:
+		var leaveLog sqlc.LeaveLog
+		if leaveLog, err = q.GetLeaveLog(ctx, request.SubjectID); err == nil {
+			subject = approvalSubject{
+				description: fmt.Sprintf("%s leave on %s", leaveLog.Type, leaveLog.Date.Time.Format("2006-01-02")),
+				userID:      leaveLog.UserID,
+				date:        leaveLog.Date.Time,
+				leaveLog:    &leaveLog,
+			}
+		}
+	case approval.KindMedicalExpense:
+		var expense sqlc.MedicalExpense
+		if expense, err = q.GetMedicalExpense(ctx, request.SubjectID); err == nil {
+			subject = approvalSubject{
+				description: fmt.Sprintf("medical expense of %s baht dated %s", strconv.FormatFloat(numericToFloat64(expense.Amount, 0), 'f', 2, 64), expense.ReceiptDate.Time.Format("2006-01-02")),
+				userID:      expense.UserID,
+				date:        expense.ReceiptDate.Time,
+				expense:     &expense,
+			}
+		}
+	case approval.KindTimesheet:
+		var log sqlc.TaskLog
+		if log, err = q.GetTaskLog(ctx, request.SubjectID); err == nil {
+			subject = approvalSubject{
+				description: fmt.Sprintf("%s days on task #%d on %s", formatDays(numericToFloat64(log.WorkedDay, 0)), log.TaskID, log.WorkedDate.Time.Format("2006-01-02")),
+				userID:      log.CreatedByUserID,
+				date:        log.WorkedDate.Time,
+			}
+		}
+	case approval.KindQuotaPlan:
+		var plan sqlc.QuotaPlan
+		if plan, err = q.GetQuotaPlan(ctx, request.SubjectID); err == nil {
+			subject.description = fmt.Sprintf("edit of quota plan %s", plan.PlanName)
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return subject, nil
+	}
+	return subject, err
+}
+
+// decideApproval approves or rejects the step a request waits on, applying the outcome once the
+// request is decided, and returns the request as it is then. It fails with approval.ErrNotApprover
+// or approval.ErrDecided when the approver can't decide it.
+func (s *Server) decideApproval(ctx context.Context, request sqlc.ApprovalRequest, approver sqlc.User, approve bool, comment string) (sqlc.ApprovalRequest, error) {
+	today, err := s.userToday(ctx, approver.ID)
+	if err != nil {
+		return request, err
+	}
+	var subject approvalSubject
+	decided := request
+	err = s.store.WithTx(ctx, func(q sqlc.Querier) error {
+		var err error
+		if subject, err = loadApprovalSubject(ctx, q, request); err != nil {
+			return err
+		}
+		if decided, err = approval.Decide(ctx, q, request, approver, approve, comment, today); err != nil {
+			return err
+		}
+		switch decided.Status {
+		case approval.StatusRejected:
+			return rejectApprovalSubject(ctx, q, decided)
+		case approval.StatusApproved:
+			return applyApprovalSubject(ctx, q, decided)
+		}
+		return nil
+	})
+	if err != nil {
+		return request, err
+	}
+	slog.InfoContext(ctx, "Approval decided", "request_id", decided.ID, "kind", decided.Kind, "status", decided.Status, "approver_id", approver.ID)
+
+	if decided.Status == approval.StatusPending {
+		return decided, nil
+	}
+	if decided.Status == approval.StatusRejected && subject.userID != 0 {
+		s.events.Publish(ctx, annualRecordChangeFor(subject.userID, subject.date))
+	}
+	switch {
+	case subject.leaveLog != nil:
+		s.notify(ctx, decided.RequesterID, notificationLeaveDecided, leaveDecidedEvent{
+			DecidedBy: approver.Username,
+			Decision:  decided.Status,
+			Type:      subject.leaveLog.Type,
+			Date:      subject.leaveLog.Date.Time.Format("2006-01-02"),
+		})
+	case subject.expense != nil:
+		s.notify(ctx, decided.RequesterID, notificationExpenseDecided, expenseDecidedEvent{
+			DecidedBy:   approver.Username,
+			Decision:    decided.Status,
+			Amount:      strconv.FormatFloat(numericToFloat64(subject.expense.Amount, 0), 'f', 2, 64),
+			ReceiptDate: subject.expense.ReceiptDate.Time.Format("2006-01-02"),
+			Comment:     comment,
+		})
+	default:
+		s.notify(ctx, decided.RequesterID, notificationApprovalDecided, approvalDecidedEvent{
+			DecidedBy: approver.Username,
+			Decision:  decided.Status,
+			Subject:   subject.description,
+			Comment:   comment,
+			Outcome:   approvalOutcome(decided),
+		})
+	}
+	return decided, nil
+}
+
+// approvalOutcome says what deciding a request changed, for its requester
+func approvalOutcome(request sqlc.ApprovalRequest) string {
+	switch {
+	case request.Kind == approval.KindQuotaPlan && request.Status == approval.StatusApproved:
+		return "The edit was applied."
+	case request.Kind == approval.KindQuotaPlan:
+		return "The edit was not applied."
+	case request.Status == approval.StatusRejected:
+		return "It was removed from TableG."
+	}
+	return ""
+}
+
+// rejectApprovalSubject deletes the leave, expense or task log of a rejected request. Quota plan
+// edits were never applied, there is nothing to undo.
+func rejectApprovalSubject(ctx context.Context, q sqlc.Querier, request sqlc.ApprovalRequest) error {
+	switch request.Kind {
+	case approval.KindLeave:
+		return q.DeleteLeaveLog(ctx, request.SubjectID)
+	case approval.KindMedicalExpense:
+		return q.DeleteMedicalExpense(ctx, request.SubjectID)
+	case approval.KindTimesheet:
+		return q.DeleteTaskLog(ctx, request.SubjectID)
+	}
+	return nil
+}
+
+// applyApprovalSubject applies the quota plan edit of an approved request. The other kinds
+// counted from when they were recorded.
+func applyApprovalSubject(ctx context.Context, q sqlc.Querier, request sqlc.ApprovalRequest) error {
+	if request.Kind != approval.KindQuotaPlan {
+		return nil
+	}
+	var edit QuotaPlanUpdateRequest
+	if err := json.Unmarshal(request.Payload, &edit); err != nil {
+		return err
+	}
+	// The edit was checked against the plan when submitted, it wins over changes made since
+	edit.UpdatedAt = nil
+	params, err := quotaPlanUpdateParams(request.SubjectID, edit)
+	if err != nil {
+		return err
+	}
+	_, err = q.UpdateQuotaPlan(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The plan was deleted since
+		return nil
+	}
+	return err
+}
+
+// quotaPlanUpdateParams converts an edit of a quota plan to the update
+func quotaPlanUpdateParams(id int32, params QuotaPlanUpdateRequest) (sqlc.UpdateQuotaPlanParams, error) {
+	var numbers pgconv.Converter
+	planParams := sqlc.UpdateQuotaPlanParams{
+		ID:                      id,
+		PlanName:                params.PlanName,
+		Year:                    params.Year,
+		QuotaVacationDay:        numbers.FromFloat(params.QuotaVacationDay),
+		QuotaMedicalExpenseBaht: numbers.FromFloat(params.QuotaMedicalExpenseBaht),
+		ExpectedUpdatedAt:       expectedUpdatedAt(params.UpdatedAt),
+	}
+	return planParams, numbers.Err()
+}
+
+// approvalReminderAge is how long a request waits on a step before its approvers are reminded
+const approvalReminderAge = 24 * time.Hour
+
+// approvalReminderBatch is how many users are checked for requests waiting on them at a time
+const approvalReminderBatch = 500
+
+// scheduleApprovalReminders reminds approvers of the requests waiting on them every weekday
+// morning
+func (s *Server) scheduleApprovalReminders() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "approval-reminders",
+		Description: "Remind approvers of the requests that have waited on them for over a day",
+		Schedule:    scheduler.MustParse("0 9 * * 1-5"),
+		Run: func(ctx context.Context) error {
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				if err := s.remindApprovers(ctx, time.Now()); err != nil {
+					slog.ErrorContext(ctx, "Error reminding approvers", "error", err)
+					errs = append(errs, err)
+				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
+
+// remindApprovers notifies each user who can decide requests that have waited on their current
+// step for approvalReminderAge by now of how many there are. Admins decide any step, so they are
+// reminded of every request.
+func (s *Server) remindApprovers(ctx context.Context, now time.Time) error {
+	pending, err := s.store.ListPendingApprovalRequests(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching the pending requests: %w", err)
+	}
+	var waiting []sqlc.ApprovalRequest
+	for _, request := range pending {
+		if !request.UpdatedAt.Time.After(now.Add(-approvalReminderAge)) {
+			waiting = append(waiting, request)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+
+	for offset := int32(0); ; offset += approvalReminderBatch {
+		users, err := s.store.ListUsers(ctx, sqlc.ListUsersParams{RowLimit: approvalReminderBatch, RowOffset: offset})
+		if err != nil {
+			return fmt.Errorf("fetching the users: %w", err)
+		}
+		for _, user := range users {
+			if err := s.remindApprover(ctx, user, waiting); err != nil {
+				return err
+			}
+		}
+		if len(users) < approvalReminderBatch {
+			return nil
+		}
+	}
+}
+
+// remindApprover notifies a user of the requests among waiting they can decide, if any
+func (s *Server) remindApprover(ctx context.Context, user sqlc.User, waiting []sqlc.ApprovalRequest) error {
+	// Delegations count by the days of the delegate's time zone
+	location, err := s.userTimeZone(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("fetching the time zone of user %d: %w", user.ID, err)
+	}
+	today := tz.Today(location)
+	var event approvalReminderEvent
+	var since time.Time
+	for _, request := range waiting {
+		_, ok, err := approval.CanDecide(ctx, s.store, request, user, today)
+		if err != nil {
+			return fmt.Errorf("checking the approvers of request %d: %w", request.ID, err)
+		}
+		if !ok {
+			continue
+		}
+		event.Count++
+		if since.IsZero() || request.UpdatedAt.Time.Before(since) {
+			since = request.UpdatedAt.Time
+		}
+	}
+	if event.Count == 0 {
+		return nil
+	}
+	event.Since = since.In(location).Format("2006-01-02")
+	s.notify(ctx, user.ID, notificationApprovalReminder, event)
+	return nil
+}
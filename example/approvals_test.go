@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// reportTo makes the manager the user's manager on the org chart
+func reportTo(t *testing.T, store *dbtest.Fake, user, manager sqlc.User) {
+	t.Helper()
+	if _, err := store.UpsertOrgAssignment(context.Background(), sqlc.UpsertOrgAssignmentParams{
+		UserID:    user.ID,
+		ManagerID: pgtype.Int4{Int32: manager.ID, Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pendingApproval returns the request the user submitted last, failing unless it is pending
+func pendingApproval(t *testing.T, handler http.Handler, user sqlc.User) ApprovalRequestResponse {
+	t.Helper()
+	var mine []ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/approvals/mine", tokenFor(user.Username), nil), http.StatusOK, &mine)
+	if len(mine) == 0 || mine[0].Status != "pending" {
+		t.Fatalf("approval requests = %+v, want the last one pending", mine)
+	}
+	return mine[0]
+}
+
+func TestApprovalDecisions(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	carol := dbtest.CreateUser(t, store, "carol", "user")
+	reportTo(t, store, alice, bob)
+
+	// Leave goes to the user's supervisors
+	var leaveLog LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(alice.Username), LeaveLogRequest{
+		UserID: alice.ID,
+		Type:   "vacation",
+		Date:   "2025-04-14",
+	}), http.StatusCreated, &leaveLog)
+	request := pendingApproval(t, handler, alice)
+
+	approve := fmt.Sprintf("/api/approvals/%d/approve", request.ID)
+	tests := []struct {
+		name string
+		user sqlc.User
+		want int
+	}{
+		{name: "requester", user: alice, want: http.StatusForbidden},
+		{name: "outside the reporting line", user: carol, want: http.StatusForbidden},
+		{name: "manager", user: bob, want: http.StatusOK},
+		{name: "decided already", user: bob, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(tt.user.Username), ApprovalDecisionRequest{}), tt.want, nil)
+		})
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/leave-logs/%d", leaveLog.ID), tokenFor(alice.Username), nil), http.StatusOK, nil)
+}
+
+func TestApprovalDelegation(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	carol := dbtest.CreateUser(t, store, "carol", "user")
+	reportTo(t, store, alice, bob)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(alice.Username), LeaveLogRequest{
+		UserID: alice.ID,
+		Type:   "vacation",
+		Date:   "2025-04-14",
+	}), http.StatusCreated, nil)
+	request := pendingApproval(t, handler, alice)
+	approve := fmt.Sprintf("/api/approvals/%d/approve", request.ID)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(carol.Username), ApprovalDecisionRequest{}), http.StatusForbidden, nil)
+
+	// Users can't delegate someone else's approvals to themselves
+	now := time.Now()
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/approval-delegations", tokenFor(carol.Username), ApprovalDelegationRequest{
+		DelegatorID: &bob.ID,
+		DelegateID:  carol.ID,
+		StartDate:   now.AddDate(0, 0, -1).Format(validate.DateLayout),
+		EndDate:     now.AddDate(0, 0, 1).Format(validate.DateLayout),
+	}), http.StatusForbidden, nil)
+
+	var delegation ApprovalDelegationResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/approval-delegations", tokenFor(bob.Username), ApprovalDelegationRequest{
+		DelegateID: carol.ID,
+		StartDate:  now.AddDate(0, 0, -1).Format(validate.DateLayout),
+		EndDate:    now.AddDate(0, 0, 1).Format(validate.DateLayout),
+	}), http.StatusCreated, &delegation)
+
+	var inbox []ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/approvals/inbox", tokenFor(carol.Username), nil), http.StatusOK, &inbox)
+	if len(inbox) != 1 || inbox[0].ID != request.ID {
+		t.Fatalf("delegate's inbox = %+v, want request %d", inbox, request.ID)
+	}
+
+	var decided ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(carol.Username), ApprovalDecisionRequest{}), http.StatusOK, &decided)
+	if decided.Status != "approved" {
+		t.Errorf("status = %s, want approved", decided.Status)
+	}
+
+	var audited ApprovalDelegationResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/approval-delegations/%d", delegation.ID), tokenFor(bob.Username), nil), http.StatusOK, &audited)
+	if len(audited.Decisions) != 1 || audited.Decisions[0].RequestID != request.ID {
+		t.Errorf("decisions under the delegation = %+v, want the approval of request %d", audited.Decisions, request.ID)
+	}
+
+	var detail ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/approvals/%d", request.ID), tokenFor(alice.Username), nil), http.StatusOK, &detail)
+	if len(detail.Decisions) != 1 || detail.Decisions[0].OnBehalfOfID == nil || *detail.Decisions[0].OnBehalfOfID != bob.ID {
+		t.Errorf("decisions = %+v, want one on behalf of bob", detail.Decisions)
+	}
+}
+
+func TestRejectionDeletesSubject(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	reportTo(t, store, alice, bob)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, "/api/admin/approval-policies/medical_expense", tokenFor(admin.Username), ApprovalPolicyRequest{
+		Steps: []string{"manager"},
+	}), http.StatusOK, nil)
+
+	tests := []struct {
+		name   string
+		create func(t *testing.T) string // Records the subject as alice, returning its path
+	}{
+		{
+			name: "leave",
+			create: func(t *testing.T) string {
+				var leaveLog LeaveLogResponse
+				dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(alice.Username), LeaveLogRequest{
+					UserID: alice.ID,
+					Type:   "vacation",
+					Date:   "2025-04-14",
+				}), http.StatusCreated, &leaveLog)
+				return fmt.Sprintf("/api/leave-logs/%d", leaveLog.ID)
+			},
+		},
+		{
+			name: "medical expense",
+			create: func(t *testing.T) string {
+				var expense sqlc.MedicalExpense
+				dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/medical-expenses", tokenFor(alice.Username), MedicalExpenseRequest{
+					UserID:      alice.ID,
+					Amount:      1200,
+					ReceiptDate: "2025-04-14",
+				}), http.StatusCreated, &expense)
+				return fmt.Sprintf("/api/medical-expenses/%d", expense.ID)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.create(t)
+			request := pendingApproval(t, handler, alice)
+
+			var rejected ApprovalRequestResponse
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, fmt.Sprintf("/api/approvals/%d/reject", request.ID), tokenFor(bob.Username), ApprovalDecisionRequest{
+				Comment: "Not this week",
+			}), http.StatusOK, &rejected)
+			if rejected.Status != "rejected" {
+				t.Errorf("status = %s, want rejected", rejected.Status)
+			}
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+		})
+	}
+}
+
+func TestQuotaPlanEditAppliedOnceApproved(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	editor := dbtest.CreateUser(t, store, "root", "admin")
+	reviewer := dbtest.CreateUser(t, store, "reviewer", "admin")
+	hr := dbtest.CreateUser(t, store, "hana", "user")
+	if _, err := store.CreateDepartment(context.Background(), sqlc.CreateDepartmentParams{Name: "HR"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateUser(context.Background(), sqlc.UpdateUserParams{
+		ID:         hr.ID,
+		Username:   hr.Username,
+		Password:   hr.Password,
+		UserType:   hr.UserType,
+		Email:      hr.Email,
+		Department: pgtype.Text{String: "HR", Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, "/api/admin/approval-policies/quota_plan", tokenFor(editor.Username), ApprovalPolicyRequest{
+		Steps: []string{"admin", "department:HR"},
+	}), http.StatusOK, nil)
+
+	var plan sqlc.QuotaPlan
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/quota-plans", tokenFor(editor.Username), QuotaPlanRequest{
+		PlanName:         "Standard",
+		Year:             2025,
+		QuotaVacationDay: 10,
+	}), http.StatusCreated, &plan)
+
+	var request ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/quota-plans/%d", plan.ID), tokenFor(editor.Username), QuotaPlanUpdateRequest{
+		PlanName:         "Standard",
+		Year:             2025,
+		QuotaVacationDay: 15,
+	}), http.StatusAccepted, &request)
+
+	vacationDays := func() float64 {
+		t.Helper()
+		current, err := store.GetQuotaPlan(context.Background(), plan.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return numericToFloat64(current.QuotaVacationDay, 0)
+	}
+	if days := vacationDays(); days != 10 {
+		t.Fatalf("plan has %v vacation days while the edit waits, want 10", days)
+	}
+
+	approve := fmt.Sprintf("/api/approvals/%d/approve", request.ID)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(editor.Username), ApprovalDecisionRequest{}), http.StatusForbidden, nil)
+
+	var decided ApprovalRequestResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(reviewer.Username), ApprovalDecisionRequest{}), http.StatusOK, &decided)
+	if decided.Status != "pending" || decided.CurrentStep != 1 {
+		t.Fatalf("after the first step = %s at step %d, want pending at step 1", decided.Status, decided.CurrentStep)
+	}
+	if days := vacationDays(); days != 10 {
+		t.Fatalf("plan has %v vacation days before the last step, want 10", days)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, approve, tokenFor(hr.Username), ApprovalDecisionRequest{}), http.StatusOK, &decided)
+	if decided.Status != "approved" {
+		t.Fatalf("after the last step = %s, want approved", decided.Status)
+	}
+	if days := vacationDays(); days != 15 {
+		t.Errorf("plan has %v vacation days once approved, want 15", days)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// AttendanceRequest is the optional body of a check-in or check-out. The location is what the
+// browser's geolocation gives, sent as a pair.
+type AttendanceRequest struct {
+	Latitude  *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude *float64 `json:"longitude" validate:"min=-180,max=180"`
+	Note      string   `json:"note" validate:"max=500"` // Only read on check-in
+}
+
+// AttendanceSessionResponse is one check-in of a user, with its check-out once they left
+type AttendanceSessionResponse struct {
+	ID                int32      `json:"id"`
+	UserID            int32      `json:"userId"`
+	WorkDate          string     `json:"workDate"` // YYYY-MM-DD
+	CheckInAt         time.Time  `json:"checkInAt"`
+	CheckInIP         *string    `json:"checkInIp,omitempty"`
+	CheckInLatitude   *float64   `json:"checkInLatitude,omitempty"`
+	CheckInLongitude  *float64   `json:"checkInLongitude,omitempty"`
+	CheckOutAt        *time.Time `json:"checkOutAt,omitempty"` // Null while checked in
+	CheckOutIP        *string    `json:"checkOutIp,omitempty"`
+	CheckOutLatitude  *float64   `json:"checkOutLatitude,omitempty"`
+	CheckOutLongitude *float64   `json:"checkOutLongitude,omitempty"`
+	Note              *string    `json:"note,omitempty"`
+	Hours             float64    `json:"hours"` // Up to now while checked in
+}
+
+// DailyAttendanceResponse is a user's attendance on a day
+type DailyAttendanceResponse struct {
+	UserID         int32      `json:"userId"`
+	Username       string     `json:"username"`
+	Department     *string    `json:"department,omitempty"`
+	FirstCheckInAt *time.Time `json:"firstCheckInAt,omitempty"`
+	LastCheckOutAt *time.Time `json:"lastCheckOutAt,omitempty"`
+	Sessions       int64      `json:"sessions"`
+	CheckedIn      bool       `json:"checkedIn"`
+	Hours          float64    `json:"hours"`
+	LeaveType      *string    `json:"leaveType,omitempty"` // Set when the user took leave that day
+}
+
+// AttendanceSuggestionResponse is the work the logged in user's attendance suggests they log on
+// a day
+type AttendanceSuggestionResponse struct {
+	Date               string  `json:"date"`
+	Hours              float64 `json:"hours"`              // Checked in, up to now while still checked in
+	DailyCapacity      float64 `json:"dailyCapacity"`      // Days of work the user logs on a full day
+	SuggestedWorkedDay float64 `json:"suggestedWorkedDay"` // Hours over ESTIMATE_HOURS_PER_DAY, to a quarter day and at most the capacity
+	LoggedWorkedDay    float64 `json:"loggedWorkedDay"`    // Already logged on tasks
+	LeaveDay           float64 `json:"leaveDay"`           // Taken by leave
+	RemainingWorkedDay float64 `json:"remainingWorkedDay"` // What is left to log, to prefill a new task log with
+}
+
+// checkIn handles POST /api/attendance/check-in, starting a session for the logged in user
+func (s *Server) checkIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	req, ok := decodeAttendanceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := s.store.GetOpenAttendanceSession(ctx, currentUser.ID); err == nil {
+		respondWithError(w, http.StatusConflict, "Already checked in, check out first")
+		return
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching attendance: "+err.Error())
+		return
+	}
+
+	// The day the user checks in on where they are, not where the server is
+	today, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	session, err := s.store.CreateAttendanceSession(ctx, sqlc.CreateAttendanceSessionParams{
+		UserID:           currentUser.ID,
+		WorkDate:         pgtype.Date{Time: today, Valid: true},
+		CheckInIp:        clientIP(r),
+		CheckInLatitude:  float8(req.Latitude),
+		CheckInLongitude: float8(req.Longitude),
+		Note:             pgtype.Text{String: req.Note, Valid: req.Note != ""},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error checking in: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, attendanceSessionToResponse(session))
+}
+
+// checkOut handles POST /api/attendance/check-out, closing the logged in user's open session
+func (s *Server) checkOut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	req, ok := decodeAttendanceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := s.store.CloseAttendanceSession(ctx, sqlc.CloseAttendanceSessionParams{
+		CheckOutIp:        clientIP(r),
+		CheckOutLatitude:  float8(req.Latitude),
+		CheckOutLongitude: float8(req.Longitude),
+		UserID:            currentUser.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Not checked in")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error checking out: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, attendanceSessionToResponse(session))
+}
+
+// getMyAttendance handles GET /api/attendance/me, listing the logged in user's sessions from
+// the from to the to day, the last 30 days when they are left out
+func (s *Server) getMyAttendance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	to, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	from := to.AddDate(0, 0, -30)
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+			return
+		}
+	}
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	sessions, err := s.store.ListAttendanceSessionsByUser(ctx, sqlc.ListAttendanceSessionsByUserParams{
+		UserID:   currentUser.ID,
+		DateFrom: pgtype.Date{Time: from, Valid: true},
+		DateTo:   pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching attendance: "+err.Error())
+		return
+	}
+
+	response := make([]AttendanceSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, attendanceSessionToResponse(session))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getDailyAttendance handles GET /api/attendance/daily, admin only: everyone's attendance on a
+// day, today when no date is given, of one department when given
+func (s *Server) getDailyAttendance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only admins can view everyone's attendance")
+		return
+	}
+
+	date, ok := s.attendanceDate(w, r, currentUser)
+	if !ok {
+		return
+	}
+	var department pgtype.Text
+	if value := r.URL.Query().Get("department"); value != "" {
+		department = pgtype.Text{String: value, Valid: true}
+	}
+
+	rows, err := s.store.ListDailyAttendance(ctx, sqlc.ListDailyAttendanceParams{
+		WorkDate:   pgtype.Date{Time: date, Valid: true},
+		Department: department,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching attendance: "+err.Error())
+		return
+	}
+
+	response := make([]DailyAttendanceResponse, 0, len(rows))
+	for _, row := range rows {
+		entry := DailyAttendanceResponse{
+			UserID:         row.UserID,
+			Username:       row.Username,
+			Department:     textPtr(row.Department),
+			FirstCheckInAt: timestamptzPtr(row.FirstCheckInAt),
+			LastCheckOutAt: timestamptzPtr(row.LastCheckOutAt),
+			Sessions:       row.Sessions,
+			CheckedIn:      row.CheckedIn,
+			Hours:          math.Round(row.Hours*100) / 100,
+		}
+		if row.LeaveType != "" {
+			entry.LeaveType = &row.LeaveType
+		}
+		response = append(response, entry)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getAttendanceSuggestion handles GET /api/attendance/suggestion, turning the hours the logged
+// in user was checked in on a day, today when no date is given, into the worked day to log
+func (s *Server) getAttendanceSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	date, ok := s.attendanceDate(w, r, currentUser)
+	if !ok {
+		return
+	}
+	day := pgtype.Date{Time: date, Valid: true}
+
+	hours, err := s.store.SumAttendanceHoursForDate(ctx, sqlc.SumAttendanceHoursForDateParams{
+		UserID:   currentUser.ID,
+		WorkDate: day,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching attendance: "+err.Error())
+		return
+	}
+	logged, err := s.store.SumTaskLogWorkedDaysForDate(ctx, sqlc.SumTaskLogWorkedDaysForDateParams{
+		UserID:     currentUser.ID,
+		WorkedDate: day,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
+		return
+	}
+	leaveLogs, err := s.store.CountLeaveLogsForDate(ctx, sqlc.CountLeaveLogsForDateParams{
+		UserID: currentUser.ID,
+		Date:   day,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs: "+err.Error())
+		return
+	}
+
+	capacity := numericToFloat64(currentUser.DailyCapacity, 1.0)
+	suggested := suggestedWorkedDay(hours, s.settings.Config().Tasks.EstimateHoursPerDay, capacity)
+	// Each leave log consumes a full day of capacity, like validateDayLimit counts it
+	leave := float64(leaveLogs) * capacity
+
+	respondWithJSON(w, http.StatusOK, AttendanceSuggestionResponse{
+		Date:               date.Format("2006-01-02"),
+		Hours:              math.Round(hours*100) / 100,
+		DailyCapacity:      capacity,
+		SuggestedWorkedDay: suggested,
+		LoggedWorkedDay:    logged,
+		LeaveDay:           leave,
+		RemainingWorkedDay: math.Max(0, math.Min(suggested, capacity-leave)-logged),
+	})
+}
+
+// suggestedWorkedDay converts hours present into a worked day: a full day is hoursPerDay hours
+// and worth capacity, and the result is rounded to a quarter of a day
+func suggestedWorkedDay(hours, hoursPerDay, capacity float64) float64 {
+	if hoursPerDay <= 0 {
+		return 0
+	}
+	return math.Round(math.Min(hours/hoursPerDay, 1)*capacity*4) / 4
+}
+
+// decodeAttendanceRequest reads the optional body of a check-in or check-out, answering 422 when
+// only half of the location is given
+func decodeAttendanceRequest(w http.ResponseWriter, r *http.Request) (AttendanceRequest, bool) {
+	var req AttendanceRequest
+	if r.ContentLength != 0 {
+		if !decodeRequest(w, r, &req) {
+			return req, false
+		}
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: latitude and longitude go together")
+		return req, false
+	}
+	return req, true
+}
+
+// attendanceDate reads the date query parameter, the user's today when it is left out
+func (s *Server) attendanceDate(w http.ResponseWriter, r *http.Request, user sqlc.User) (time.Time, bool) {
+	value := r.URL.Query().Get("date")
+	if value == "" {
+		today, err := s.userToday(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+			return time.Time{}, false
+		}
+		return today, true
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid date format (should be YYYY-MM-DD)")
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// clientIP returns the address a request came from. X-Forwarded-For is only trusted from a
+// loopback or private peer, a reverse proxy in front of the server, and then its first address
+// is the client's.
+func clientIP(r *http.Request) pgtype.Text {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil {
+		return pgtype.Text{}
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" && (peer.IsLoopback() || peer.IsPrivate()) {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := net.ParseIP(strings.TrimSpace(first)); ip != nil {
+			peer = ip
+		}
+	}
+	return pgtype.Text{String: peer.String(), Valid: true}
+}
+
+func attendanceSessionToResponse(session sqlc.AttendanceSession) AttendanceSessionResponse {
+	end := time.Now()
+	if session.CheckOutAt.Valid {
+		end = session.CheckOutAt.Time
+	}
+	return AttendanceSessionResponse{
+		ID:                session.ID,
+		UserID:            session.UserID,
+		WorkDate:          session.WorkDate.Time.Format("2006-01-02"),
+		CheckInAt:         session.CheckInAt.Time,
+		CheckInIP:         textPtr(session.CheckInIp),
+		CheckInLatitude:   float8Ptr(session.CheckInLatitude),
+		CheckInLongitude:  float8Ptr(session.CheckInLongitude),
+		CheckOutAt:        timestamptzPtr(session.CheckOutAt),
+		CheckOutIP:        textPtr(session.CheckOutIp),
+		CheckOutLatitude:  float8Ptr(session.CheckOutLatitude),
+		CheckOutLongitude: float8Ptr(session.CheckOutLongitude),
+		Note:              textPtr(session.Note),
+		Hours:             math.Round(end.Sub(session.CheckInAt.Time).Hours()*100) / 100,
+	}
+}
+
+// timestamptzPtr converts a nullable timestamp to a pointer for JSON responses
+func timestamptzPtr(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+// float8 converts an optional request number to a nullable float8
+func float8(value *float64) pgtype.Float8 {
+	if value == nil {
+		return pgtype.Float8{}
+	}
+	return pgtype.Float8{Float64: *value, Valid: true}
+}
+
+// float8Ptr converts a nullable float8 to a pointer for JSON responses
+func float8Ptr(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
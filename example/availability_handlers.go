@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// availabilityMaxUsernames caps the usernames of one bulk availability lookup
+const availabilityMaxUsernames = 100
+
+// Availability statuses, from the first that applies to the user's today
+const (
+	availabilityOnLeave = "on_leave"
+	availabilityHoliday = "holiday"
+	availabilityWeekend = "weekend"
+	availabilityWorking = "working"
+)
+
+// AvailabilityResponse is whether a user is in today. It leaves out the type of their leave,
+// which services calling with an API token have no business knowing.
+type AvailabilityResponse struct {
+	Username  string  `json:"username"`
+	Date      string  `json:"date"`              // Today in the user's time zone, YYYY-MM-DD
+	Status    string  `json:"status"`            // on_leave, holiday, weekend or working
+	Available bool    `json:"available"`         // Whether the status is working
+	Holiday   *string `json:"holiday,omitempty"` // The holiday's name when the status is holiday
+}
+
+// AvailabilityListResponse is the response of GET /api/availability
+type AvailabilityListResponse struct {
+	Users    []AvailabilityResponse `json:"users"`    // In the order of the usernames asked for
+	NotFound []string               `json:"notFound"` // The usernames no user has
+}
+
+// getAvailability handles GET /api/availability?usernames=a,b for services with an API token of
+// the availability scope, such as the chatbot
+func (s *Server) getAvailability(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeAPIToken(w, r, apiScopeAvailability); !ok {
+		return
+	}
+
+	var usernames []string
+	for _, username := range strings.Split(r.URL.Query().Get("usernames"), ",") {
+		if username = strings.TrimSpace(username); username != "" && !slices.Contains(usernames, username) {
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		respondWithError(w, http.StatusBadRequest, "usernames is required")
+		return
+	}
+	if len(usernames) > availabilityMaxUsernames {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Too many usernames, at most %d", availabilityMaxUsernames))
+		return
+	}
+
+	response := AvailabilityListResponse{
+		Users:    make([]AvailabilityResponse, 0, len(usernames)),
+		NotFound: []string{},
+	}
+	holidays := map[time.Time]pgtype.Text{}
+	for _, username := range usernames {
+		user, err := s.store.GetUserByUsername(ctx, username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.NotFound = append(response.NotFound, username)
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching user: "+err.Error())
+			return
+		}
+		availability, err := s.availability(ctx, user, holidays)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching availability: "+err.Error())
+			return
+		}
+		response.Users = append(response.Users, availability)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getUserAvailability handles GET /api/availability/{username}, the lookup of one user
+func (s *Server) getUserAvailability(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeAPIToken(w, r, apiScopeAvailability); !ok {
+		return
+	}
+	user, err := s.store.GetUserByUsername(ctx, mux.Vars(r)["username"])
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching user: "+err.Error())
+		return
+	}
+	availability, err := s.availability(ctx, user, map[time.Time]pgtype.Text{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching availability: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, availability)
+}
+
+// availability works out whether a user is in on their today. holidays remembers the holiday of
+// each day looked up, invalid on days without one, so users sharing a today share the lookup.
+func (s *Server) availability(ctx context.Context, user sqlc.User, holidays map[time.Time]pgtype.Text) (AvailabilityResponse, error) {
+	today, err := s.userToday(ctx, user.ID)
+	if err != nil {
+		return AvailabilityResponse{}, err
+	}
+	day := pgtype.Date{Time: today, Valid: true}
+	response := AvailabilityResponse{
+		Username: user.Username,
+		Date:     today.Format(validate.DateLayout),
+		Status:   availabilityWorking,
+	}
+
+	leaveLogs, err := s.store.ListLeaveLogsByDateRange(ctx, sqlc.ListLeaveLogsByDateRangeParams{
+		UserID: user.ID,
+		Date:   day,
+		Date_2: day,
+	})
+	if err != nil {
+		return response, err
+	}
+	holiday, ok := holidays[today]
+	if !ok {
+		found, err := s.store.GetHolidayByDate(ctx, day)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return response, err
+		}
+		holiday = pgtype.Text{String: found.Name, Valid: err == nil}
+		holidays[today] = holiday
+	}
+
+	switch {
+	case len(leaveLogs) > 0:
+		response.Status = availabilityOnLeave
+	case holiday.Valid:
+		response.Status = availabilityHoliday
+		response.Holiday = textPtr(holiday)
+	case today.Weekday() == time.Saturday || today.Weekday() == time.Sunday:
+		response.Status = availabilityWeekend
+	}
+	response.Available = response.Status == availabilityWorking
+	return response, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/db/backup"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+// scheduleDatabaseBackup sets up periodic database backups to BACKUP_LOCATION every
+// BACKUP_INTERVAL (e.g. "24h"), keeping the newest BACKUP_RETENTION of them
+func (s *Server) scheduleDatabaseBackup() {
+	config := s.config.Backup
+	if config.Location == "" || config.Interval == 0 {
+		slog.Info("Database backups not scheduled, BACKUP_LOCATION or BACKUP_INTERVAL is not set")
+		return
+	}
+	location, err := backup.Open(config.Location, storage.S3Config(s.config.AWS))
+	if err != nil {
+		slog.Warn("Database backups not scheduled", "error", err)
+		return
+	}
+
+	databaseURL := s.database.Pool.Config().ConnString()
+	s.scheduler.Register(scheduler.Job{
+		Name:        "database-backup",
+		Description: fmt.Sprintf("Back up the database to %s, keeping the newest %d", location, config.Retention),
+		Schedule:    scheduler.Every(config.Interval),
+		Run: func(ctx context.Context) error {
+			return runScheduledBackup(ctx, databaseURL, location, config.Retention)
+		},
+	})
+}
+
+// runScheduledBackup backs up the database. The scheduler runs it on one instance at a time.
+func runScheduledBackup(ctx context.Context, databaseURL string, location backup.Location, retention int) error {
+	start := time.Now()
+	name, err := backup.Run(ctx, databaseURL, location, retention)
+	if err != nil {
+		return fmt.Errorf("backing up the database: %w", err)
+	}
+	slog.InfoContext(ctx, "Database backup finished", "name", name, "duration", time.Since(start).Round(time.Second))
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// Annual records are derived from balance events, the ledger Postgres triggers write in the
+// transactions recording leave, task logs and medical expenses, see
+// db/migrations/000052_balance_events.up.sql. These routes let admins read the ledger, replay it
+// as of a time, find annual records that drifted from it and rebuild a year from it.
+
+// BalanceEventResponse is what one action added to, or took from, a user's totals for a year
+type BalanceEventResponse struct {
+	ID                 int64     `json:"id"`
+	UserID             int32     `json:"userId"`
+	Year               int32     `json:"year"`
+	Source             string    `json:"source"`   // leave_logs, task_logs, medical_expenses or annual_records
+	SourceID           int32     `json:"sourceId"` // The ID of the row in the source
+	Action             string    `json:"action"`   // recorded, retracted or adjusted
+	VacationDay        float64   `json:"vacationDay"`
+	SickLeaveDay       float64   `json:"sickLeaveDay"`
+	WorkedDay          float64   `json:"workedDay"`
+	WorkedOnHolidayDay float64   `json:"workedOnHolidayDay"`
+	MedicalExpenseBaht float64   `json:"medicalExpenseBaht"`
+	RecordedByUserID   *int32    `json:"recordedByUserId,omitempty"` // The admin of an adjustment
+	RecordedAt         time.Time `json:"recordedAt"`
+}
+
+func balanceEventToResponse(event sqlc.BalanceEvent) BalanceEventResponse {
+	return BalanceEventResponse{
+		ID:                 event.ID,
+		UserID:             event.UserID,
+		Year:               event.Year,
+		Source:             event.Source,
+		SourceID:           event.SourceID,
+		Action:             event.Action,
+		VacationDay:        numericToFloat64(event.VacationDay, 0),
+		SickLeaveDay:       numericToFloat64(event.SickLeaveDay, 0),
+		WorkedDay:          numericToFloat64(event.WorkedDay, 0),
+		WorkedOnHolidayDay: numericToFloat64(event.WorkedOnHolidayDay, 0),
+		MedicalExpenseBaht: numericToFloat64(event.MedicalExpenseBaht, 0),
+		RecordedByUserID:   int4Ptr(event.RecordedByUserID),
+		RecordedAt:         event.RecordedAt.Time,
+	}
+}
+
+// BalanceTotals are the totals of an annual record that balance events add up to
+type BalanceTotals struct {
+	UsedVacationDay        float64 `json:"usedVacationDay"`
+	UsedSickLeaveDay       float64 `json:"usedSickLeaveDay"`
+	WorkedDay              float64 `json:"workedDay"`
+	WorkedOnHolidayDay     float64 `json:"workedOnHolidayDay"`
+	UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht"`
+}
+
+// BalanceReplayResponse is the totals of a user's year replayed from its balance events
+type BalanceReplayResponse struct {
+	UserID      int32      `json:"userId"`
+	Year        int32      `json:"year"`
+	AsOf        *time.Time `json:"asOf,omitempty"` // Events recorded after it are left out
+	Events      int64      `json:"events"`         // How many were replayed
+	LastEventID int64      `json:"lastEventId"`    // 0 when there were none
+	BalanceTotals
+}
+
+// BalanceDriftResponse is an annual record whose totals aren't what its balance events add up to
+type BalanceDriftResponse struct {
+	AnnualRecordID int32         `json:"annualRecordId"`
+	UserID         int32         `json:"userId"`
+	Username       string        `json:"username"`
+	Record         BalanceTotals `json:"record"` // What the annual record holds
+	Events         BalanceTotals `json:"events"` // What its balance events add up to
+}
+
+// BalanceRebuildResponse is the response of POST /api/admin/balance-events/rebuild
+type BalanceRebuildResponse struct {
+	Year    int32 `json:"year"`
+	Records int   `json:"records"` // Annual records rebuilt
+}
+
+// balanceEventQuery reads the user_id and the year, this year unless given, of a balance event
+// route. Only admins get past it.
+func (s *Server) balanceEventQuery(w http.ResponseWriter, r *http.Request, needUser bool) (userID, year int32, ok bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return 0, 0, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can view balance events")
+		return 0, 0, false
+	}
+
+	year = int32(s.thisYear())
+	if value := r.URL.Query().Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid year")
+			return 0, 0, false
+		}
+		year = int32(parsed)
+	}
+	if !needUser {
+		return 0, year, true
+	}
+	value := r.URL.Query().Get("user_id")
+	if value == "" {
+		respondWithError(w, http.StatusBadRequest, "user_id is required")
+		return 0, 0, false
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, 0, false
+	}
+	return int32(parsed), year, true
+}
+
+// getBalanceEvents handles GET /api/admin/balance-events?user_id=1&year=2026, the ledger of a
+// user's year in the order it was recorded
+func (s *Server) getBalanceEvents(w http.ResponseWriter, r *http.Request) {
+	userID, year, ok := s.balanceEventQuery(w, r, true)
+	if !ok {
+		return
+	}
+	events, err := s.store.ListBalanceEvents(r.Context(), sqlc.ListBalanceEventsParams{UserID: userID, Year: year})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching balance events: "+err.Error())
+		return
+	}
+	response := make([]BalanceEventResponse, 0, len(events))
+	for _, event := range events {
+		response = append(response, balanceEventToResponse(event))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// replayBalanceEvents handles GET /api/admin/balance-events/replay?user_id=1&year=2026&as_of=,
+// adding up the events of a user's year recorded up to as_of, all of them unless given. as_of is
+// a time in RFC 3339 or a day, which counts up to its end in the company's time zone. Replaying
+// writes nothing, the same events always add up to the same totals.
+func (s *Server) replayBalanceEvents(w http.ResponseWriter, r *http.Request) {
+	userID, year, ok := s.balanceEventQuery(w, r, true)
+	if !ok {
+		return
+	}
+	var asOf pgtype.Timestamptz
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			day, dayErr := time.ParseInLocation(validate.DateLayout, value, s.timeZone)
+			if dayErr != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid as_of. Use RFC 3339 or YYYY-MM-DD")
+				return
+			}
+			at = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		asOf = pgtype.Timestamptz{Time: at, Valid: true}
+	}
+
+	totals, err := s.store.GetBalanceTotals(r.Context(), sqlc.GetBalanceTotalsParams{UserID: userID, Year: year, AsOf: asOf})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error replaying balance events: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, BalanceReplayResponse{
+		UserID:      userID,
+		Year:        year,
+		AsOf:        timestamptzPtr(asOf),
+		Events:      totals.Events,
+		LastEventID: totals.LastEventID,
+		BalanceTotals: BalanceTotals{
+			UsedVacationDay:        numericToFloat64(totals.VacationDay, 0),
+			UsedSickLeaveDay:       numericToFloat64(totals.SickLeaveDay, 0),
+			WorkedDay:              numericToFloat64(totals.WorkedDay, 0),
+			WorkedOnHolidayDay:     numericToFloat64(totals.WorkedOnHolidayDay, 0),
+			UsedMedicalExpenseBaht: numericToFloat64(totals.MedicalExpenseBaht, 0),
+		},
+	})
+}
+
+// getBalanceDrift handles GET /api/admin/balance-events/drift?year=2026, the annual records of the
+// year whose totals aren't what their balance events add up to, e.g. because a sync is pending
+func (s *Server) getBalanceDrift(w http.ResponseWriter, r *http.Request) {
+	_, year, ok := s.balanceEventQuery(w, r, false)
+	if !ok {
+		return
+	}
+	rows, err := s.store.ListBalanceDrift(r.Context(), year)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching balance drift: "+err.Error())
+		return
+	}
+	response := make([]BalanceDriftResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, BalanceDriftResponse{
+			AnnualRecordID: row.ID,
+			UserID:         row.UserID,
+			Username:       row.Username,
+			Record: BalanceTotals{
+				UsedVacationDay:        numericToFloat64(row.UsedVacationDay, 0),
+				UsedSickLeaveDay:       numericToFloat64(row.UsedSickLeaveDay, 0),
+				WorkedDay:              numericToFloat64(row.WorkedDay, 0),
+				WorkedOnHolidayDay:     numericToFloat64(row.WorkedOnHolidayDay, 0),
+				UsedMedicalExpenseBaht: numericToFloat64(row.UsedMedicalExpenseBaht, 0),
+			},
+			Events: BalanceTotals{
+				UsedVacationDay:        numericToFloat64(row.EventsVacationDay, 0),
+				UsedSickLeaveDay:       numericToFloat64(row.EventsSickLeaveDay, 0),
+				WorkedDay:              numericToFloat64(row.EventsWorkedDay, 0),
+				WorkedOnHolidayDay:     numericToFloat64(row.EventsWorkedOnHolidayDay, 0),
+				UsedMedicalExpenseBaht: numericToFloat64(row.EventsMedicalExpenseBaht, 0),
+			},
+		})
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// rebuildAnnualRecords handles POST /api/admin/balance-events/rebuild?year=2026, deriving every
+// annual record of the year from its balance events again, which ends any drift
+func (s *Server) rebuildAnnualRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, year, ok := s.balanceEventQuery(w, r, false)
+	if !ok {
+		return
+	}
+	records, err := s.annualRecords.SyncAllRecordsForYear(ctx, year)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error rebuilding annual records: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Annual records rebuilt from balance events", "year", year, "records", len(records))
+	respondWithJSON(w, http.StatusOK, BalanceRebuildResponse{Year: year, Records: len(records)})
+}
@@ -0,0 +1,33 @@
+package clickup
+
+import "context"
+
+// ClickUpAPI is the part of the ClickUp API the server uses. Handlers depend on it rather than
+// on *Client, so tests can substitute the in-memory fake from the clickuptest package.
+type ClickUpAPI interface {
+	// Enabled reports whether calls reach ClickUp, tasks are only created locally otherwise
+	Enabled() bool
+
+	CreateTask(ctx context.Context, req CreateTaskRequest) (*ClickUpTask, error)
+	GetTask(ctx context.Context, taskID string) (*ClickUpTask, error)
+	UpdateTask(ctx context.Context, taskID string, req map[string]interface{}) (*ClickUpTask, error)
+	GetTasksByList(ctx context.Context, listID string, opts ListTasksOptions) ([]ClickUpTask, error)
+
+	AddTagToTask(ctx context.Context, taskID, tagName string) error
+	RemoveTagFromTask(ctx context.Context, taskID, tagName string) error
+	SetCustomFieldValue(ctx context.Context, taskID string, field CustomField, text string) error
+	CreateTimeEntry(ctx context.Context, teamID string, req TimeEntryRequest) (*TimeEntry, error)
+
+	GetTeams(ctx context.Context) ([]Team, error)
+	GetSpaces(ctx context.Context, teamID string) ([]Space, error)
+	GetFolders(ctx context.Context, spaceID string) ([]Folder, error)
+	GetFolderlessLists(ctx context.Context, spaceID string) ([]List, error)
+}
+
+var _ ClickUpAPI = (*Client)(nil)
+
+// Enabled reports whether the client has a token. Without one every call returns ErrDisabled,
+// callers check Enabled first and keep the change local.
+func (c *Client) Enabled() bool {
+	return c.APIKey != ""
+}
@@ -0,0 +1,404 @@
+// Package clickuptest provides an in-memory ClickUp fake and an httptest server serving it, so
+// code using the ClickUp client can be exercised without the real API.
+package clickuptest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// Fake is an in-memory implementation of clickup.ClickUpAPI. The zero value is not usable,
+// create one with NewFake.
+type Fake struct {
+	// Disabled makes Enabled report false, like a client without a token
+	Disabled bool
+	// Err, when set, is returned by every call instead of touching the stored data
+	Err error
+	// TaskPageSize, when set, is how many tasks of a list the server answers with a page,
+	// every task fits on the first page otherwise
+	TaskPageSize int
+
+	mu          sync.Mutex
+	nextID      int
+	tasks       map[string]*clickup.ClickUpTask
+	teams       []clickup.Team
+	spaces      map[string][]clickup.Space
+	folders     map[string][]clickup.Folder
+	lists       map[string][]clickup.List
+	timeEntries map[string][]clickup.TimeEntryRequest
+	calls       []string
+}
+
+var _ clickup.ClickUpAPI = (*Fake)(nil)
+
+// NewFake creates an empty fake
+func NewFake() *Fake {
+	return &Fake{
+		tasks:       make(map[string]*clickup.ClickUpTask),
+		spaces:      make(map[string][]clickup.Space),
+		folders:     make(map[string][]clickup.Folder),
+		lists:       make(map[string][]clickup.List),
+		timeEntries: make(map[string][]clickup.TimeEntryRequest),
+	}
+}
+
+// AddTask stores a task as if it existed in ClickUp, filling in the ID and URL when missing
+func (f *Fake) AddTask(task clickup.ClickUpTask) *clickup.ClickUpTask {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if task.ID == "" {
+		task.ID = f.newID()
+	}
+	if task.URL == "" {
+		task.URL = "https://app.clickup.com/t/" + task.ID
+	}
+	if task.DateUpdated.IsZero() {
+		task.DateUpdated = clickup.Timestamp{Time: time.Now().UTC()}
+	}
+	if task.DateCreated.IsZero() {
+		task.DateCreated = task.DateUpdated
+	}
+
+	stored := task
+	f.tasks[task.ID] = &stored
+	return f.copyTask(&stored)
+}
+
+// AddTeam stores a workspace together with its spaces
+func (f *Fake) AddTeam(team clickup.Team, spaces ...clickup.Space) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.teams = append(f.teams, team)
+	f.spaces[team.ID] = append(f.spaces[team.ID], spaces...)
+}
+
+// AddFolder stores a folder, with its lists, in a space
+func (f *Fake) AddFolder(spaceID string, folder clickup.Folder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.folders[spaceID] = append(f.folders[spaceID], folder)
+}
+
+// AddList stores a list directly in a space
+func (f *Fake) AddList(spaceID string, list clickup.List) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lists[spaceID] = append(f.lists[spaceID], list)
+}
+
+// Task returns a copy of a stored task, or nil when there is none
+func (f *Fake) Task(taskID string) *clickup.ClickUpTask {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return nil
+	}
+	return f.copyTask(task)
+}
+
+// TimeEntries returns the time tracked on a task
+func (f *Fake) TimeEntries(taskID string) []clickup.TimeEntryRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]clickup.TimeEntryRequest(nil), f.timeEntries[taskID]...)
+}
+
+// Calls returns the names of the methods called so far, in order
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.calls...)
+}
+
+// Enabled reports whether the fake acts as a configured client
+func (f *Fake) Enabled() bool {
+	return !f.Disabled
+}
+
+// CreateTask stores a new task in the requested list
+func (f *Fake) CreateTask(ctx context.Context, req clickup.CreateTaskRequest) (*clickup.ClickUpTask, error) {
+	if err := f.record("CreateTask"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Parent != "" {
+		if _, ok := f.tasks[req.Parent]; !ok {
+			return nil, notFound("task", req.Parent)
+		}
+	}
+
+	id := f.newID()
+	now := clickup.Timestamp{Time: time.Now().UTC()}
+	task := &clickup.ClickUpTask{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      clickup.Status{Status: req.Status},
+		URL:         "https://app.clickup.com/t/" + id,
+		DateCreated: now,
+		DateUpdated: now,
+		Parent:      req.Parent,
+		List:        clickup.ListRef{ID: req.ListID},
+		ListID:      req.ListID,
+	}
+	f.tasks[id] = task
+	return f.copyTask(task), nil
+}
+
+// GetTask returns a stored task
+func (f *Fake) GetTask(ctx context.Context, taskID string) (*clickup.ClickUpTask, error) {
+	if err := f.record("GetTask"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return nil, notFound("task", taskID)
+	}
+	return f.copyTask(task), nil
+}
+
+// UpdateTask applies the name, description and status of the update to a stored task
+func (f *Fake) UpdateTask(ctx context.Context, taskID string, req map[string]interface{}) (*clickup.ClickUpTask, error) {
+	if err := f.record("UpdateTask"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return nil, notFound("task", taskID)
+	}
+	if name, ok := req["name"].(string); ok {
+		task.Name = name
+	}
+	if description, ok := req["description"].(string); ok {
+		task.Description = description
+	}
+	if status, ok := req["status"].(string); ok {
+		task.Status.Status = status
+	}
+	task.DateUpdated = clickup.Timestamp{Time: time.Now().UTC()}
+	return f.copyTask(task), nil
+}
+
+// GetTasksByList returns the stored tasks of a list, ordered by ID
+func (f *Fake) GetTasksByList(ctx context.Context, listID string, opts clickup.ListTasksOptions) ([]clickup.ClickUpTask, error) {
+	if err := f.record("GetTasksByList"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var tasks []clickup.ClickUpTask
+	for _, task := range f.tasks {
+		if task.ListID != listID || (task.Parent != "" && !opts.Subtasks) {
+			continue
+		}
+		tasks = append(tasks, *f.copyTask(task))
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// AddTagToTask adds a tag to a stored task
+func (f *Fake) AddTagToTask(ctx context.Context, taskID, tagName string) error {
+	if err := f.record("AddTagToTask"); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return notFound("task", taskID)
+	}
+	for _, tag := range task.Tags {
+		if tag.Name == tagName {
+			return nil
+		}
+	}
+	task.Tags = append(task.Tags, clickup.Tag{Name: tagName})
+	return nil
+}
+
+// RemoveTagFromTask removes a tag from a stored task
+func (f *Fake) RemoveTagFromTask(ctx context.Context, taskID, tagName string) error {
+	if err := f.record("RemoveTagFromTask"); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return notFound("task", taskID)
+	}
+	tags := task.Tags[:0]
+	for _, tag := range task.Tags {
+		if tag.Name != tagName {
+			tags = append(tags, tag)
+		}
+	}
+	task.Tags = tags
+	return nil
+}
+
+// SetCustomFieldValue stores a custom field value on a task as text
+func (f *Fake) SetCustomFieldValue(ctx context.Context, taskID string, field clickup.CustomField, text string) error {
+	if err := f.record("SetCustomFieldValue"); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return notFound("task", taskID)
+	}
+
+	// Values are kept as JSON strings, an empty text clears the field as in ClickUp
+	field.Value = nil
+	if text != "" {
+		value, err := json.Marshal(text)
+		if err != nil {
+			return err
+		}
+		field.Value = value
+	}
+	for i := range task.CustomFields {
+		if task.CustomFields[i].ID == field.ID {
+			task.CustomFields[i] = field
+			return nil
+		}
+	}
+	task.CustomFields = append(task.CustomFields, field)
+	return nil
+}
+
+// CreateTimeEntry records time tracked on a task
+func (f *Fake) CreateTimeEntry(ctx context.Context, teamID string, req clickup.TimeEntryRequest) (*clickup.TimeEntry, error) {
+	if err := f.record("CreateTimeEntry"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.tasks[req.TaskID]; !ok {
+		return nil, notFound("task", req.TaskID)
+	}
+	f.timeEntries[req.TaskID] = append(f.timeEntries[req.TaskID], req)
+	return &clickup.TimeEntry{
+		ID:       f.newID(),
+		Start:    clickup.Timestamp{Time: time.UnixMilli(req.Start).UTC()},
+		Duration: strconv.FormatInt(req.Duration, 10),
+	}, nil
+}
+
+// GetTeams returns the stored workspaces
+func (f *Fake) GetTeams(ctx context.Context) ([]clickup.Team, error) {
+	if err := f.record("GetTeams"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]clickup.Team(nil), f.teams...), nil
+}
+
+// GetSpaces returns the stored spaces of a workspace
+func (f *Fake) GetSpaces(ctx context.Context, teamID string) ([]clickup.Space, error) {
+	if err := f.record("GetSpaces"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]clickup.Space(nil), f.spaces[teamID]...), nil
+}
+
+// GetFolders returns the stored folders of a space
+func (f *Fake) GetFolders(ctx context.Context, spaceID string) ([]clickup.Folder, error) {
+	if err := f.record("GetFolders"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]clickup.Folder(nil), f.folders[spaceID]...), nil
+}
+
+// GetFolderlessLists returns the stored lists directly in a space
+func (f *Fake) GetFolderlessLists(ctx context.Context, spaceID string) ([]clickup.List, error) {
+	if err := f.record("GetFolderlessLists"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]clickup.List(nil), f.lists[spaceID]...), nil
+}
+
+// record notes a call and returns the injected error, if any
+func (f *Fake) record(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, method)
+	return f.Err
+}
+
+// newID returns the next task or entry ID, callers must hold the lock
+func (f *Fake) newID() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
+
+// copyTask returns a copy of a task that callers may modify freely
+func (f *Fake) copyTask(task *clickup.ClickUpTask) *clickup.ClickUpTask {
+	copied := *task
+	copied.Tags = append([]clickup.Tag(nil), task.Tags...)
+	copied.CustomFields = append([]clickup.CustomField(nil), task.CustomFields...)
+	return &copied
+}
+
+// notFound returns the error ClickUp answers with for a missing resource
+func notFound(kind, id string) error {
+	return &clickup.APIError{
+		StatusCode: http.StatusNotFound,
+		Body:       fmt.Sprintf(`{"err":"%s %s not found","ECODE":"ITEM_015"}`, kind, id),
+	}
+}
@@ -0,0 +1,171 @@
+package clickuptest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// NewServer starts an httptest server answering the ClickUp API routes the client uses from the
+// fake, in the shapes the real API responds with. Close the server when done.
+func NewServer(fake *Fake) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /team", func(w http.ResponseWriter, r *http.Request) {
+		teams, err := fake.GetTeams(r.Context())
+		respond(w, map[string]interface{}{"teams": nonNil(teams)}, err)
+	})
+	mux.HandleFunc("GET /team/{team_id}/space", func(w http.ResponseWriter, r *http.Request) {
+		spaces, err := fake.GetSpaces(r.Context(), r.PathValue("team_id"))
+		respond(w, map[string]interface{}{"spaces": nonNil(spaces)}, err)
+	})
+	mux.HandleFunc("GET /space/{space_id}/folder", func(w http.ResponseWriter, r *http.Request) {
+		folders, err := fake.GetFolders(r.Context(), r.PathValue("space_id"))
+		respond(w, map[string]interface{}{"folders": nonNil(folders)}, err)
+	})
+	mux.HandleFunc("GET /space/{space_id}/list", func(w http.ResponseWriter, r *http.Request) {
+		lists, err := fake.GetFolderlessLists(r.Context(), r.PathValue("space_id"))
+		respond(w, map[string]interface{}{"lists": nonNil(lists)}, err)
+	})
+
+	// Pages hold TaskPageSize tasks, or every task fits on the first one
+	mux.HandleFunc("GET /list/{list_id}/task", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		tasks, err := fake.GetTasksByList(r.Context(), r.PathValue("list_id"), clickup.ListTasksOptions{
+			Subtasks: r.URL.Query().Get("subtasks") == "true",
+		})
+		size := fake.TaskPageSize
+		if size <= 0 {
+			size = max(len(tasks), 1)
+		}
+		start := min(max(page, 0)*size, len(tasks))
+		end := min(start+size, len(tasks))
+		respond(w, map[string]interface{}{"tasks": nonNil(tasks[start:end]), "last_page": end == len(tasks)}, err)
+	})
+	mux.HandleFunc("POST /list/{list_id}/task", func(w http.ResponseWriter, r *http.Request) {
+		var req clickup.CreateTaskRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		req.ListID = r.PathValue("list_id")
+		task, err := fake.CreateTask(r.Context(), req)
+		respond(w, task, err)
+	})
+
+	mux.HandleFunc("GET /task/{task_id}", func(w http.ResponseWriter, r *http.Request) {
+		task, err := fake.GetTask(r.Context(), r.PathValue("task_id"))
+		respond(w, task, err)
+	})
+	mux.HandleFunc("PUT /task/{task_id}", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if !decode(w, r, &req) {
+			return
+		}
+		task, err := fake.UpdateTask(r.Context(), r.PathValue("task_id"), req)
+		respond(w, task, err)
+	})
+
+	mux.HandleFunc("POST /task/{task_id}/tag/{tag_name}", func(w http.ResponseWriter, r *http.Request) {
+		err := fake.AddTagToTask(r.Context(), r.PathValue("task_id"), r.PathValue("tag_name"))
+		respond(w, struct{}{}, err)
+	})
+	mux.HandleFunc("DELETE /task/{task_id}/tag/{tag_name}", func(w http.ResponseWriter, r *http.Request) {
+		err := fake.RemoveTagFromTask(r.Context(), r.PathValue("task_id"), r.PathValue("tag_name"))
+		respond(w, struct{}{}, err)
+	})
+
+	mux.HandleFunc("POST /task/{task_id}/field/{field_id}", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Value interface{} `json:"value"`
+		}
+		if !decode(w, r, &req) {
+			return
+		}
+		field := clickup.CustomField{ID: r.PathValue("field_id")}
+		err := fake.SetCustomFieldValue(r.Context(), r.PathValue("task_id"), field, fmt.Sprint(req.Value))
+		respond(w, struct{}{}, err)
+	})
+	mux.HandleFunc("DELETE /task/{task_id}/field/{field_id}", func(w http.ResponseWriter, r *http.Request) {
+		field := clickup.CustomField{ID: r.PathValue("field_id")}
+		err := fake.SetCustomFieldValue(r.Context(), r.PathValue("task_id"), field, "")
+		respond(w, struct{}{}, err)
+	})
+
+	mux.HandleFunc("POST /team/{team_id}/time_entries", func(w http.ResponseWriter, r *http.Request) {
+		var req clickup.TimeEntryRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		entry, err := fake.CreateTimeEntry(r.Context(), r.PathValue("team_id"), req)
+		respond(w, map[string]interface{}{"data": entry}, err)
+	})
+
+	// ClickUp rejects requests without a token before looking at the route
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			writeError(w, &clickup.APIError{StatusCode: http.StatusUnauthorized, Body: `{"err":"Token invalid","ECODE":"OAUTH_025"}`})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+// NewClient returns a client talking to the server, without retries or a circuit breaker so
+// failures surface on the first call
+func NewClient(server *httptest.Server) *clickup.Client {
+	client := clickup.NewClient("pk_test_token")
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+	client.Retry = clickup.RetryPolicy{}
+	client.Breaker = nil
+	return client
+}
+
+// respond writes body as JSON, or err in ClickUp's error format
+func respond(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError answers with the status and body of an API error, or a 500 for other errors
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *clickup.APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &clickup.APIError{
+			StatusCode: http.StatusInternalServerError,
+			Body:       fmt.Sprintf(`{"err":%s}`, strconv.Quote(err.Error())),
+		}
+	}
+	if apiErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(apiErr.RetryAfter.Seconds())))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.StatusCode)
+	w.Write([]byte(apiErr.Body))
+}
+
+// decode reads a JSON request body, answering with a 400 when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, &clickup.APIError{StatusCode: http.StatusBadRequest, Body: `{"err":"Invalid JSON body"}`})
+		return false
+	}
+	return true
+}
+
+// nonNil turns a nil slice into an empty one so it encodes as [] like ClickUp's responses
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
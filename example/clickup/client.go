@@ -2,12 +2,19 @@ package clickup
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
 )
 
 // Client is a ClickUp API client
@@ -15,21 +22,61 @@ type Client struct {
 	APIKey     string
 	BaseURL    string
 	HTTPClient *http.Client
-	TokenType  string // "personal" or "oauth"
+	TokenType  string          // "personal" or "oauth"
+	Retry      RetryPolicy     // Zero value means a single attempt
+	Breaker    *CircuitBreaker // Optional, NewClient gives each client its own
 }
 
+// ErrDisabled is returned by every call of a client without an API token, check Enabled first
+var ErrDisabled = errors.New("clickup: integration is disabled, no API token")
+
 // ClickUpTask represents a task in ClickUp
 type ClickUpTask struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Status      Status    `json:"status"`
-	URL         string    `json:"url"`
-	DateCreated time.Time `json:"date_created"`
-	DateUpdated time.Time `json:"date_updated"`
-	ListID      string    `json:"list_id"`
-	FolderID    string    `json:"folder_id"`
-	SpaceID     string    `json:"space_id"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Status       Status        `json:"status"`
+	URL          string        `json:"url"`
+	DateCreated  Timestamp     `json:"date_created"`
+	DateUpdated  Timestamp     `json:"date_updated"`
+	Tags         []Tag         `json:"tags"`
+	Parent       string        `json:"parent"`
+	List         ListRef       `json:"list"`
+	ListID       string        `json:"list_id"`
+	FolderID     string        `json:"folder_id"`
+	SpaceID      string        `json:"space_id"`
+	TeamID       string        `json:"team_id"` // Workspace the task belongs to
+	CustomFields []CustomField `json:"custom_fields"`
+}
+
+// Timestamp is a ClickUp date, which the API sends as a string of Unix milliseconds
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a millisecond timestamp given as a JSON string or number
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid clickup timestamp %q: %w", value, err)
+	}
+
+	t.Time = time.UnixMilli(millis).UTC()
+	return nil
+}
+
+// MarshalJSON encodes the timestamp in the same millisecond format ClickUp uses
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(strconv.FormatInt(t.UnixMilli(), 10))), nil
 }
 
 // Status represents a task status in ClickUp
@@ -40,11 +87,26 @@ type Status struct {
 	Orderindex int    `json:"orderindex"`
 }
 
+// ListRef is the list a task belongs to, as embedded in task responses
+type ListRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Tag represents a tag on a ClickUp task
+type Tag struct {
+	Name    string `json:"name"`
+	TagFg   string `json:"tag_fg"`
+	TagBg   string `json:"tag_bg"`
+	Creator int    `json:"creator"`
+}
+
 // CreateTaskRequest is the request body for creating a task
 type CreateTaskRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Status      string `json:"status,omitempty"`
+	Parent      string `json:"parent,omitempty"` // ClickUp task ID, makes this a subtask
 	ListID      string `json:"list_id"`
 }
 
@@ -65,9 +127,16 @@ func NewClient(apiKey string) *Client {
 			Timeout: time.Second * 30,
 		},
 		TokenType: tokenType,
+		Retry:     DefaultRetryPolicy,
+		Breaker:   NewCircuitBreaker(5, time.Minute),
 	}
 }
 
+// CircuitOpen reports whether the client's circuit breaker is refusing requests
+func (c *Client) CircuitOpen() bool {
+	return c.Breaker != nil && c.Breaker.IsOpen()
+}
+
 // setAuthHeader sets the appropriate Authorization header based on token type
 func (c *Client) setAuthHeader(req *http.Request) {
 	if c.TokenType == "oauth" {
@@ -77,94 +146,100 @@ func (c *Client) setAuthHeader(req *http.Request) {
 	}
 }
 
-// CreateTask creates a new task in ClickUp
-func (c *Client) CreateTask(req CreateTaskRequest) (*ClickUpTask, error) {
-	// If APIKey is empty, we're in disabled mode - just return a fake success
+// send performs a request, retrying rate-limited and transient failures with backoff.
+// POST requests are only retried when ClickUp rate-limited them, since a failed create
+// may still have created the task. Errors from ClickUp are returned as *APIError.
+// Cancelling ctx aborts the request in flight and any wait before a retry. Without a token
+// nothing is sent and ErrDisabled is returned.
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) ([]byte, error) {
 	if c.APIKey == "" {
-		// Return a dummy successful response
-		return &ClickUpTask{
-			ID:          "disabled-123",
-			Name:        req.Name,
-			Description: req.Description,
-			Status: Status{
-				Status: "To Do",
-				Color:  "#d3d3d3",
-			},
-			URL:         "https://app.clickup.com/disabled-integration",
-			DateCreated: time.Now(),
-			DateUpdated: time.Now(),
-			ListID:      req.ListID,
-		}, nil
+		return nil, ErrDisabled
 	}
 
-	url := fmt.Sprintf("%s/list/%s/task", c.BaseURL, req.ListID)
-
-	jsonBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	maxAttempts := c.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	for attempt := 1; ; attempt++ {
+		if c.Breaker != nil {
+			if err := c.Breaker.Allow(); err != nil {
+				return nil, err
+			}
+		}
 
-	c.setAuthHeader(httpReq)
-	httpReq.Header.Set("Content-Type", "application/json")
+		body, err := c.sendOnce(ctx, method, url, payload)
+		if err == nil {
+			if c.Breaker != nil {
+				c.Breaker.RecordSuccess()
+			}
+			return body, nil
+		}
 
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+		// A cancelled caller is not a ClickUp failure
+		if ctx.Err() != nil {
+			if c.Breaker != nil {
+				c.Breaker.Release()
+			}
+			return nil, err
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
+		// Client errors say nothing about the health of ClickUp and are not retried
+		var apiErr *APIError
+		isAPIError := errors.As(err, &apiErr)
+		if isAPIError && !apiErr.IsTransient() {
+			if c.Breaker != nil {
+				c.Breaker.RecordSuccess()
+			}
+			return nil, err
+		}
+		if c.Breaker != nil {
+			c.Breaker.RecordFailure()
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("clickup API returned error: %s", string(body))
-	}
+		retryable := !isAPIError || method != http.MethodPost || apiErr.IsRateLimited()
+		if !retryable || attempt >= maxAttempts {
+			return nil, err
+		}
 
-	var response struct {
-		Task ClickUpTask `json:"task"`
-	}
+		delay := c.Retry.backoff(attempt)
+		if isAPIError && apiErr.RetryAfter > 0 {
+			// Waiting longer than allowed would block the caller, report the error instead
+			if apiErr.RetryAfter > c.Retry.MaxDelay {
+				return nil, err
+			}
+			delay = apiErr.RetryAfter
+		}
 
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		slog.WarnContext(ctx, "ClickUp request failed, retrying", "method", method, "url", url,
+			"attempt", attempt, "max_attempts", maxAttempts, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
-
-	return &response.Task, nil
 }
 
-// GetTask retrieves a task from ClickUp by ID
-func (c *Client) GetTask(taskID string) (*ClickUpTask, error) {
-	// If APIKey is empty, we're in disabled mode - just return a fake success
-	if c.APIKey == "" {
-		// Return a dummy successful response
-		return &ClickUpTask{
-			ID:          taskID,
-			Name:        "Disabled Task",
-			Description: "This is a placeholder task as ClickUp integration is disabled",
-			Status: Status{
-				Status: "To Do",
-				Color:  "#d3d3d3",
-			},
-			URL:         "https://app.clickup.com/disabled-integration",
-			DateCreated: time.Now(),
-			DateUpdated: time.Now(),
-		}, nil
+// sendOnce performs a single request and returns the response body of a successful call
+func (c *Client) sendOnce(ctx context.Context, method, url string, payload []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
 	}
 
-	url := fmt.Sprintf("%s/task/%s", c.BaseURL, taskID)
-
-	httpReq, err := http.NewRequest("GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	c.setAuthHeader(httpReq)
+	logging.SetRequestIDHeader(ctx, httpReq.Header)
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
@@ -177,10 +252,32 @@ func (c *Client) GetTask(taskID string) (*ClickUpTask, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("clickup API returned error: %s", string(body))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+
+	return body, nil
+}
+
+// CreateTask creates a new task in ClickUp
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest) (*ClickUpTask, error) {
+	url := fmt.Sprintf("%s/list/%s/task", c.BaseURL, req.ListID)
+
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	body, err := c.send(ctx, http.MethodPost, url, jsonBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// ClickUp answers with the created task itself
 	var task ClickUpTask
 	if err := json.Unmarshal(body, &task); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -189,63 +286,35 @@ func (c *Client) GetTask(taskID string) (*ClickUpTask, error) {
 	return &task, nil
 }
 
-// UpdateTask updates a task in ClickUp
-func (c *Client) UpdateTask(taskID string, req map[string]interface{}) (*ClickUpTask, error) {
-	// If APIKey is empty, we're in disabled mode - just return a fake success
-	if c.APIKey == "" {
-		// Return a dummy successful response
-		name := "Updated Task"
-		if n, ok := req["name"].(string); ok {
-			name = n
-		}
-
-		desc := "This is a placeholder task as ClickUp integration is disabled"
-		if d, ok := req["description"].(string); ok {
-			desc = d
-		}
-
-		return &ClickUpTask{
-			ID:          taskID,
-			Name:        name,
-			Description: desc,
-			Status: Status{
-				Status: "To Do",
-				Color:  "#d3d3d3",
-			},
-			URL:         "https://app.clickup.com/disabled-integration",
-			DateCreated: time.Now(),
-			DateUpdated: time.Now(),
-		}, nil
-	}
-
+// GetTask retrieves a task from ClickUp by ID
+func (c *Client) GetTask(ctx context.Context, taskID string) (*ClickUpTask, error) {
 	url := fmt.Sprintf("%s/task/%s", c.BaseURL, taskID)
 
-	jsonBody, err := json.Marshal(req)
+	body, err := c.send(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	httpReq, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var task ClickUpTask
+	if err := json.Unmarshal(body, &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	c.setAuthHeader(httpReq)
-	httpReq.Header.Set("Content-Type", "application/json")
+	return &task, nil
+}
 
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// UpdateTask updates a task in ClickUp
+func (c *Client) UpdateTask(ctx context.Context, taskID string, req map[string]interface{}) (*ClickUpTask, error) {
+	url := fmt.Sprintf("%s/task/%s", c.BaseURL, taskID)
 
-	body, err := io.ReadAll(resp.Body)
+	jsonBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("clickup API returned error: %s", string(body))
+	body, err := c.send(ctx, http.MethodPut, url, jsonBody)
+	if err != nil {
+		return nil, err
 	}
 
 	var task ClickUpTask
@@ -256,6 +325,24 @@ func (c *Client) UpdateTask(taskID string, req map[string]interface{}) (*ClickUp
 	return &task, nil
 }
 
+// AddTagToTask adds an existing space tag to a task in ClickUp
+func (c *Client) AddTagToTask(ctx context.Context, taskID, tagName string) error {
+	return c.sendTaskTagRequest(ctx, "POST", taskID, tagName)
+}
+
+// RemoveTagFromTask removes a tag from a task in ClickUp
+func (c *Client) RemoveTagFromTask(ctx context.Context, taskID, tagName string) error {
+	return c.sendTaskTagRequest(ctx, "DELETE", taskID, tagName)
+}
+
+// sendTaskTagRequest calls the task tag endpoint with the given method
+func (c *Client) sendTaskTagRequest(ctx context.Context, method, taskID, tagName string) error {
+	url := fmt.Sprintf("%s/task/%s/tag/%s", c.BaseURL, taskID, neturl.PathEscape(tagName))
+
+	_, err := c.send(ctx, method, url, nil)
+	return err
+}
+
 // ExtractTaskIDFromURL extracts the task ID from a ClickUp task URL
 func ExtractTaskIDFromURL(url string) string {
 	// Expected format: https://app.clickup.com/t/abc123
@@ -0,0 +1,71 @@
+package clickup_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/example/clickup/clickuptest"
+)
+
+func TestClientWithoutTokenReturnsErrDisabled(t *testing.T) {
+	client := clickup.NewClient("")
+	if client.Enabled() {
+		t.Fatal("Enabled() = true without a token")
+	}
+
+	ctx := t.Context()
+	if _, err := client.CreateTask(ctx, clickup.CreateTaskRequest{Name: "x", ListID: "1"}); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("CreateTask() error = %v, want ErrDisabled", err)
+	}
+	if _, err := client.GetTask(ctx, "abc123"); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("GetTask() error = %v, want ErrDisabled", err)
+	}
+	if _, err := client.UpdateTask(ctx, "abc123", map[string]interface{}{"name": "x"}); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("UpdateTask() error = %v, want ErrDisabled", err)
+	}
+	if err := client.AddTagToTask(ctx, "abc123", "urgent"); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("AddTagToTask() error = %v, want ErrDisabled", err)
+	}
+	if _, err := client.GetTasksByList(ctx, "1", clickup.ListTasksOptions{}); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("GetTasksByList() error = %v, want ErrDisabled", err)
+	}
+	if _, err := client.GetTeams(ctx); !errors.Is(err, clickup.ErrDisabled) {
+		t.Errorf("GetTeams() error = %v, want ErrDisabled", err)
+	}
+}
+
+func TestGetTasksByListFollowsPages(t *testing.T) {
+	fake := clickuptest.NewFake()
+	fake.TaskPageSize = 2
+	server := clickuptest.NewServer(fake)
+	defer server.Close()
+
+	created := time.Date(2026, time.March, 2, 9, 30, 0, 123e6, time.UTC)
+	for i := range 5 {
+		fake.AddTask(clickup.ClickUpTask{
+			ID:          string(rune('a' + i)),
+			ListID:      "901",
+			DateCreated: clickup.Timestamp{Time: created},
+			DateUpdated: clickup.Timestamp{Time: created.Add(time.Duration(i) * time.Hour)},
+		})
+	}
+	fake.AddTask(clickup.ClickUpTask{ID: "z", ListID: "902"})
+
+	tasks, err := clickuptest.NewClient(server).GetTasksByList(t.Context(), "901", clickup.ListTasksOptions{})
+	if err != nil {
+		t.Fatalf("GetTasksByList() error = %v", err)
+	}
+	if len(tasks) != 5 {
+		t.Fatalf("GetTasksByList() = %d tasks, want the 5 of the list over 3 pages", len(tasks))
+	}
+	for i, task := range tasks {
+		if task.ID != string(rune('a'+i)) {
+			t.Errorf("tasks[%d].ID = %s, want %c", i, task.ID, 'a'+i)
+		}
+		if !task.DateCreated.Equal(created) || !task.DateUpdated.Equal(created.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("tasks[%d] dates = %v, %v, want the millisecond dates ClickUp sent", i, task.DateCreated, task.DateUpdated)
+		}
+	}
+}
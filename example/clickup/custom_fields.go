@@ -0,0 +1,102 @@
+package clickup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CustomField is a custom field on a ClickUp task together with its value
+type CustomField struct {
+	ID         string                `json:"id"`
+	Name       string                `json:"name"`
+	Type       string                `json:"type"` // e.g. short_text, text, number, drop_down
+	TypeConfig CustomFieldTypeConfig `json:"type_config"`
+	Value      json.RawMessage       `json:"value,omitempty"`
+}
+
+// CustomFieldTypeConfig holds the options of drop-down custom fields
+type CustomFieldTypeConfig struct {
+	Options []CustomFieldOption `json:"options,omitempty"`
+}
+
+// CustomFieldOption is one choice of a drop-down custom field
+type CustomFieldOption struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	OrderIndex int    `json:"orderindex"`
+}
+
+// StringValue returns the field value as text, with drop-down values resolved to the option name.
+// An unset field returns an empty string.
+func (f CustomField) StringValue() string {
+	raw := strings.TrimSpace(string(f.Value))
+	if raw == "" || raw == "null" {
+		return ""
+	}
+
+	if f.Type == "drop_down" {
+		// ClickUp sends the orderindex of the chosen option
+		var index int
+		if err := json.Unmarshal(f.Value, &index); err == nil {
+			for _, option := range f.TypeConfig.Options {
+				if option.OrderIndex == index {
+					return option.Name
+				}
+			}
+		}
+	}
+
+	var text string
+	if err := json.Unmarshal(f.Value, &text); err == nil {
+		return text
+	}
+	return raw
+}
+
+// valueFor converts text to the value ClickUp expects when setting this field
+func (f CustomField) valueFor(text string) (interface{}, error) {
+	switch f.Type {
+	case "drop_down":
+		for _, option := range f.TypeConfig.Options {
+			if strings.EqualFold(option.Name, text) {
+				return option.ID, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not an option of custom field %q", text, f.Name)
+	case "number", "currency":
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q needs a number, got %q", f.Name, text)
+		}
+		return number, nil
+	default:
+		return text, nil
+	}
+}
+
+// SetCustomFieldValue sets a custom field on a task, clearing it when text is empty
+func (c *Client) SetCustomFieldValue(ctx context.Context, taskID string, field CustomField, text string) error {
+	url := fmt.Sprintf("%s/task/%s/field/%s", c.BaseURL, taskID, field.ID)
+
+	if text == "" {
+		_, err := c.send(ctx, http.MethodDelete, url, nil)
+		return err
+	}
+
+	value, err := field.valueFor(text)
+	if err != nil {
+		return err
+	}
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"value": value})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	_, err = c.send(ctx, http.MethodPost, url, jsonBody)
+	return err
+}
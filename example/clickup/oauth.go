@@ -1,14 +1,17 @@
 package clickup
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
 )
 
 // OAuthConfig holds the OAuth configuration
@@ -54,13 +57,11 @@ func (c *OAuth2Client) GetAuthorizationURL(state string) string {
 		params.Add("state", state)
 	}
 
-	authURL := baseURL + "?" + params.Encode()
-	log.Printf("Generated ClickUp authorization URL: %s", authURL)
-	return authURL
+	return baseURL + "?" + params.Encode()
 }
 
 // ExchangeCodeForToken exchanges an authorization code for an access token
-func (c *OAuth2Client) ExchangeCodeForToken(code string) (*TokenResponse, error) {
+func (c *OAuth2Client) ExchangeCodeForToken(ctx context.Context, code string) (*TokenResponse, error) {
 	// ClickUp requires api.clickup.com for API requests
 	tokenURL := "https://api.clickup.com/api/v2/oauth/token"
 
@@ -70,46 +71,36 @@ func (c *OAuth2Client) ExchangeCodeForToken(code string) (*TokenResponse, error)
 	data.Set("code", code)
 	data.Set("grant_type", "authorization_code")
 
-	log.Printf("Exchanging code for token with ClickUp API at: %s", tokenURL)
-	log.Printf("Using client_id: %s", c.Config.ClientID)
-	log.Printf("Using redirect_uri: %s", c.Config.RedirectURI)
+	slog.DebugContext(ctx, "Exchanging ClickUp authorization code", "client_id", c.Config.ClientID, "redirect_uri", c.Config.RedirectURI)
 
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		log.Printf("Error creating token request: %v", err)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	logging.SetRequestIDHeader(ctx, req.Header)
 
-	log.Printf("Sending token request to ClickUp API...")
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		log.Printf("Error sending token request: %v", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body: %v", err)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	log.Printf("ClickUp API response status: %d", resp.StatusCode)
-	log.Printf("ClickUp API response body: %s", string(body))
-
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ClickUp API returned error: %s", string(body))
 	}
 
 	var tokenResp TokenResponse
 	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		log.Printf("Error unmarshaling token response: %v", err)
 		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
 	}
 
-	log.Printf("Successfully obtained ClickUp OAuth token")
 	return &tokenResp, nil
 }
 
@@ -122,5 +113,7 @@ func GetClientFromToken(accessToken string) *Client {
 			Timeout: time.Second * 30,
 		},
 		TokenType: "oauth", // Set the token type to OAuth
+		Retry:     DefaultRetryPolicy,
+		Breaker:   NewCircuitBreaker(5, time.Minute),
 	}
 }
@@ -0,0 +1,178 @@
+package clickup
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling ClickUp while the circuit breaker is open
+var ErrCircuitOpen = errors.New("clickup circuit breaker is open, requests are paused after repeated failures")
+
+// APIError is returned when ClickUp answers with a non-success status
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // How long ClickUp asked us to wait, zero when not given
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("clickup API returned error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// IsRateLimited reports whether ClickUp rejected the request for exceeding the rate limit
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsTransient reports whether the request may succeed when tried again
+func (e *APIError) IsTransient() bool {
+	return e.IsRateLimited() || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryPolicy controls how failed requests are retried with exponential backoff and jitter
+type RetryPolicy struct {
+	MaxAttempts int           // Including the first attempt
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // Upper bound of a single delay, also for Retry-After
+}
+
+// DefaultRetryPolicy is used by clients created with NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Equal jitter keeps at least half the delay while spreading out concurrent clients
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// CircuitBreaker stops calls to ClickUp for a cool-down period after consecutive failures. Once
+// the cool-down has passed it is half-open: one request is let through to probe whether ClickUp
+// has recovered, closing the circuit when it succeeds and opening it again when it fails, while
+// the others are still refused.
+type CircuitBreaker struct {
+	Threshold int           // Consecutive failures that open the circuit
+	Cooldown  time.Duration // How long the circuit stays open
+
+	mu          sync.Mutex
+	failures    int
+	openedUntil time.Time        // Zero while the circuit is closed
+	probing     bool             // The probe of the half-open circuit is in flight
+	now         func() time.Time // time.Now when nil
+}
+
+// NewCircuitBreaker creates a circuit breaker
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// clock returns the current time
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// Allow returns ErrCircuitOpen while the circuit is open, or half-open with its probe in flight.
+// Otherwise the request may go ahead, and its outcome must be reported with RecordSuccess,
+// RecordFailure or Release.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedUntil.IsZero() {
+		return nil
+	}
+	if b.probing || b.clock().Before(b.openedUntil) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// IsOpen reports whether requests are currently being refused, without taking the probe of a
+// half-open circuit
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.openedUntil.IsZero() && (b.probing || b.clock().Before(b.openedUntil))
+}
+
+// RecordSuccess closes the circuit
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openedUntil = time.Time{}
+	b.probing = false
+}
+
+// RecordFailure counts a failure and opens the circuit once the threshold is reached, or again
+// at once when the probe of a half-open circuit failed
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.probing || b.failures >= b.Threshold {
+		b.openedUntil = b.clock().Add(b.Cooldown)
+		b.probing = false
+	}
+}
+
+// Release gives up a request that was allowed without learning anything about ClickUp, such as
+// one its caller cancelled, so the next request can probe instead
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// parseRetryAfter reads how long to wait from Retry-After (seconds or an HTTP date), falling
+// back to ClickUp's X-RateLimit-Reset (Unix seconds)
+func parseRetryAfter(header http.Header) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return maxDuration(time.Until(date), 0)
+		}
+	}
+
+	if value := header.Get("X-RateLimit-Reset"); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return maxDuration(time.Until(time.Unix(reset, 0)), 0)
+		}
+	}
+
+	return 0
+}
+
+// maxDuration returns the larger of a or b
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package clickup
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker opening after 2 failures for a minute, on a clock the test
+// moves with the returned function
+func newTestBreaker() (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	breaker, advance := newTestBreaker()
+
+	// Closed: failures below the threshold don't open it
+	breaker.RecordFailure()
+	if err := breaker.Allow(); err != nil || breaker.IsOpen() {
+		t.Fatalf("Allow() = %v, IsOpen() = %v after one failure, want closed", err, breaker.IsOpen())
+	}
+
+	// Open: refused until the cool-down has passed
+	breaker.RecordFailure()
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) || !breaker.IsOpen() {
+		t.Fatalf("Allow() = %v, IsOpen() = %v at the threshold, want open", err, breaker.IsOpen())
+	}
+	advance(59 * time.Second)
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v during the cool-down, want ErrCircuitOpen", err)
+	}
+
+	// Half-open: IsOpen takes no probe, Allow admits exactly one
+	advance(time.Second)
+	for range 3 {
+		if breaker.IsOpen() {
+			t.Fatal("IsOpen() = true once the cool-down has passed")
+		}
+	}
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() = %v for the probe, want nil", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) || !breaker.IsOpen() {
+		t.Fatalf("Allow() = %v while probing, want ErrCircuitOpen", err)
+	}
+
+	// A failed probe reopens it for another cool-down
+	breaker.RecordFailure()
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v after the probe failed, want ErrCircuitOpen", err)
+	}
+	advance(time.Minute)
+
+	// A released probe lets the next request probe instead
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() = %v for the probe, want nil", err)
+	}
+	breaker.Release()
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() = %v after the probe was released, want nil", err)
+	}
+
+	// A successful probe closes it
+	breaker.RecordSuccess()
+	for range 3 {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Allow() = %v after the probe succeeded, want closed", err)
+		}
+	}
+	breaker.RecordFailure()
+	if breaker.IsOpen() {
+		t.Error("IsOpen() = true after one failure of a closed circuit, want the count reset")
+	}
+}
+
+func TestClientsHaveTheirOwnBreaker(t *testing.T) {
+	first, second := NewClient("pk_1"), GetClientFromToken("oauth-token")
+	if first.Breaker == nil || first.Breaker == second.Breaker || first.Breaker == NewClient("pk_1").Breaker {
+		t.Fatal("clients share a circuit breaker")
+	}
+	for range first.Breaker.Threshold {
+		first.Breaker.RecordFailure()
+	}
+	if !first.CircuitOpen() || second.CircuitOpen() {
+		t.Errorf("CircuitOpen() = %v and %v, want only the failing client's open", first.CircuitOpen(), second.CircuitOpen())
+	}
+}
@@ -0,0 +1,58 @@
+package clickup
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strconv"
+)
+
+// maxTaskPages bounds how many pages GetTasksByList fetches, ClickUp returns up to 100 tasks a page
+const maxTaskPages = 100
+
+// ListTasksOptions filters the tasks returned by GetTasksByList
+type ListTasksOptions struct {
+	Archived      bool // Return archived tasks instead of active ones
+	IncludeClosed bool // Also return tasks with a closed status
+	Subtasks      bool // Also return subtasks
+}
+
+// TaskPage is one page of tasks of a list
+type TaskPage struct {
+	Tasks    []ClickUpTask `json:"tasks"`
+	LastPage bool          `json:"last_page"`
+}
+
+// GetTasksByList returns every task of a list, following the pages until ClickUp reports the
+// last one
+func (c *Client) GetTasksByList(ctx context.Context, listID string, opts ListTasksOptions) ([]ClickUpTask, error) {
+	var tasks []ClickUpTask
+	for page := 0; page < maxTaskPages; page++ {
+		result, err := c.GetTasksPage(ctx, listID, page, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, result.Tasks...)
+		if result.LastPage || len(result.Tasks) == 0 {
+			return tasks, nil
+		}
+	}
+	return nil, fmt.Errorf("list %s has more than %d pages of tasks", listID, maxTaskPages)
+}
+
+// GetTasksPage returns a single page of the tasks of a list, counting pages from 0
+func (c *Client) GetTasksPage(ctx context.Context, listID string, page int, opts ListTasksOptions) (*TaskPage, error) {
+	query := neturl.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("archived", strconv.FormatBool(opts.Archived))
+	query.Set("include_closed", strconv.FormatBool(opts.IncludeClosed))
+	query.Set("subtasks", strconv.FormatBool(opts.Subtasks))
+
+	var result TaskPage
+	url := fmt.Sprintf("%s/list/%s/task?%s", c.BaseURL, listID, query.Encode())
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
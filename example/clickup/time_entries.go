@@ -0,0 +1,60 @@
+package clickup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TimeEntryRequest is the request body for tracking time on a task
+type TimeEntryRequest struct {
+	TaskID      string `json:"tid"`
+	Description string `json:"description,omitempty"`
+	Start       int64  `json:"start"`    // Unix milliseconds
+	Duration    int64  `json:"duration"` // Milliseconds
+	Billable    bool   `json:"billable"`
+}
+
+// TimeEntry is time tracked on a ClickUp task
+type TimeEntry struct {
+	ID       string    `json:"id"`
+	Start    Timestamp `json:"start"`
+	Duration string    `json:"duration"` // Milliseconds, as a string
+}
+
+// NewTimeEntryRequest describes time worked on a task starting at the given time
+func NewTimeEntryRequest(taskID string, start time.Time, duration time.Duration, description string) TimeEntryRequest {
+	return TimeEntryRequest{
+		TaskID:      taskID,
+		Description: description,
+		Start:       start.UnixMilli(),
+		Duration:    duration.Milliseconds(),
+	}
+}
+
+// CreateTimeEntry tracks time on a task in a workspace. The entry is attributed to the owner
+// of the token, so callers should use the acting user's client.
+func (c *Client) CreateTimeEntry(ctx context.Context, teamID string, req TimeEntryRequest) (*TimeEntry, error) {
+	url := fmt.Sprintf("%s/team/%s/time_entries", c.BaseURL, teamID)
+
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	body, err := c.send(ctx, http.MethodPost, url, jsonBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data TimeEntry `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
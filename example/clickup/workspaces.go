@@ -0,0 +1,95 @@
+package clickup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Team is a ClickUp workspace. The API still calls workspaces teams.
+type Team struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	Avatar string `json:"avatar"`
+}
+
+// Space is a space inside a ClickUp workspace
+type Space struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+}
+
+// Folder is a folder inside a space, together with its lists
+type Folder struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden"`
+	Lists  []List `json:"lists"`
+}
+
+// List is a list of tasks, either inside a folder or directly in a space
+type List struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	TaskCount int    `json:"task_count"`
+}
+
+// GetTeams returns the workspaces the token has access to
+func (c *Client) GetTeams(ctx context.Context) ([]Team, error) {
+	var response struct {
+		Teams []Team `json:"teams"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/team", c.BaseURL), &response); err != nil {
+		return nil, err
+	}
+	return response.Teams, nil
+}
+
+// GetSpaces returns the spaces of a workspace that are not archived
+func (c *Client) GetSpaces(ctx context.Context, teamID string) ([]Space, error) {
+	var response struct {
+		Spaces []Space `json:"spaces"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/team/%s/space?archived=false", c.BaseURL, teamID), &response); err != nil {
+		return nil, err
+	}
+	return response.Spaces, nil
+}
+
+// GetFolders returns the folders of a space together with their lists
+func (c *Client) GetFolders(ctx context.Context, spaceID string) ([]Folder, error) {
+	var response struct {
+		Folders []Folder `json:"folders"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/space/%s/folder?archived=false", c.BaseURL, spaceID), &response); err != nil {
+		return nil, err
+	}
+	return response.Folders, nil
+}
+
+// GetFolderlessLists returns the lists that sit directly in a space
+func (c *Client) GetFolderlessLists(ctx context.Context, spaceID string) ([]List, error) {
+	var response struct {
+		Lists []List `json:"lists"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/space/%s/list?archived=false", c.BaseURL, spaceID), &response); err != nil {
+		return nil, err
+	}
+	return response.Lists, nil
+}
+
+// getJSON fetches url and decodes the response into out
+func (c *Client) getJSON(ctx context.Context, url string, out interface{}) error {
+	body, err := c.send(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// ClickUpClients hands out ClickUp clients: the shared one using the configured token, or one
+// acting as a user, or as the user who connected a workspace, with their stored token
+type ClickUpClients struct {
+	settings config.ClickUp
+	shared   clickup.ClickUpAPI
+	override clickup.ClickUpAPI // Replaces every client when set
+
+	// The clients of stored tokens, kept so each token's circuit breaker sees all its calls
+	mu      sync.Mutex
+	byToken map[string]*clickup.Client
+}
+
+// NewClickUpClients creates the ClickUp clients for the given settings. An override, such as the
+// fake from the clickuptest package, is handed out instead of every real client when not nil.
+func NewClickUpClients(settings config.ClickUp, override clickup.ClickUpAPI) *ClickUpClients {
+	return &ClickUpClients{
+		settings: settings,
+		shared:   newSharedClickUpClient(settings),
+		override: override,
+		byToken:  make(map[string]*clickup.Client),
+	}
+}
+
+// newSharedClickUpClient returns a client using CLICKUP_OAUTH_TOKEN, or else CLICKUP_API_TOKEN,
+// disabled when neither is set
+func newSharedClickUpClient(settings config.ClickUp) clickup.ClickUpAPI {
+	// Check if we have an OAuth token first
+	if settings.OAuthToken != "" {
+		slog.Debug("Using the ClickUp OAuth token")
+		// Create a client with the OAuth token - add Bearer prefix
+		return clickup.NewClient("Bearer " + settings.OAuthToken)
+	}
+
+	// Fall back to personal API token
+	if settings.APIToken != "" {
+		slog.Debug("Using the ClickUp personal API token")
+		return clickup.NewClient(settings.APIToken)
+	}
+
+	// No tokens available, the client is disabled and callers keep their changes local
+	slog.Info("ClickUp integration disabled, tasks will only be created locally. Set CLICKUP_OAUTH_TOKEN or CLICKUP_API_TOKEN to enable it")
+	return clickup.NewClient("")
+}
+
+// Shared returns the client using the configured token
+func (c *ClickUpClients) Shared() clickup.ClickUpAPI {
+	if c.override != nil {
+		return c.override
+	}
+	return c.shared
+}
+
+// OAuth returns an OAuth client for the configured ClickUp app
+func (c *ClickUpClients) OAuth() *clickup.OAuth2Client {
+	return clickup.NewOAuth2Client(clickup.OAuthConfig{
+		ClientID:     c.settings.ClientID,
+		ClientSecret: c.settings.ClientSecret,
+		RedirectURI:  c.settings.RedirectURI,
+	})
+}
+
+// ForUser returns a client using the user's own ClickUp token for the workspace, or their latest
+// token when the workspace is unknown or has no token of its own. Falls back to the shared
+// client when the user has not connected their account.
+func (c *ClickUpClients) ForUser(ctx context.Context, store sqlc.Querier, userID int32, teamID string) clickup.ClickUpAPI {
+	if c.override != nil {
+		return c.override
+	}
+
+	var stored sqlc.ClickupToken
+	err := pgx.ErrNoRows
+	if teamID != "" {
+		stored, err = store.GetClickUpTokenForTeam(ctx, sqlc.GetClickUpTokenForTeamParams{
+			UserID: userID,
+			TeamID: teamID,
+		})
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		stored, err = store.GetClickUpToken(ctx, userID)
+	}
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Failed to fetch ClickUp token", "user_id", userID, "error", err)
+		}
+		return c.shared
+	}
+
+	client, err := c.fromToken(stored)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to decrypt ClickUp token", "user_id", userID, "error", err)
+		return c.shared
+	}
+	return client
+}
+
+// ForWorkspace returns a client using the token of the user who connected the workspace, or nil
+// when no such token is stored
+func (c *ClickUpClients) ForWorkspace(ctx context.Context, store sqlc.Querier, teamID string) clickup.ClickUpAPI {
+	if c.override != nil {
+		return c.override
+	}
+
+	workspace, err := store.GetClickUpWorkspace(ctx, teamID)
+	if err != nil || !workspace.ConnectedByUserID.Valid {
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Failed to fetch ClickUp workspace", "team_id", teamID, "error", err)
+		}
+		return nil
+	}
+
+	stored, err := store.GetClickUpTokenForTeam(ctx, sqlc.GetClickUpTokenForTeamParams{
+		UserID: workspace.ConnectedByUserID.Int32,
+		TeamID: teamID,
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Failed to fetch ClickUp token of workspace", "team_id", teamID, "error", err)
+		}
+		return nil
+	}
+
+	client, err := c.fromToken(stored)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to decrypt ClickUp token of workspace", "team_id", teamID, "error", err)
+		return nil
+	}
+	return client
+}
+
+// fromToken decrypts a stored token and returns the client using it
+func (c *ClickUpClients) fromToken(stored sqlc.ClickupToken) (*clickup.Client, error) {
+	accessToken, err := c.decryptToken(stored.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key := stored.TokenType + " " + accessToken
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.byToken[key]; ok {
+		return client, nil
+	}
+	client := clickup.GetClientFromToken(accessToken)
+	if stored.TokenType == clickUpTokenTypePersonal {
+		client = clickup.NewClient(accessToken)
+	}
+	c.byToken[key] = client
+	return client, nil
+}
+
+// tokenKey returns CLICKUP_TOKEN_ENCRYPTION_KEY, the secret stored tokens are encrypted with
+func (c *ClickUpClients) tokenKey() (string, error) {
+	secret := c.settings.TokenEncryptionKey
+	if secret == "" {
+		return "", fmt.Errorf("CLICKUP_TOKEN_ENCRYPTION_KEY is not set, ClickUp tokens cannot be stored")
+	}
+	return secret, nil
+}
+
+// encryptToken seals a token with sealToken
+func (c *ClickUpClients) encryptToken(token string) (string, error) {
+	secret, err := c.tokenKey()
+	if err != nil {
+		return "", err
+	}
+	return sealToken(secret, token)
+}
+
+// decryptToken opens a token sealed by encryptToken
+func (c *ClickUpClients) decryptToken(encrypted string) (string, error) {
+	secret, err := c.tokenKey()
+	if err != nil {
+		return "", err
+	}
+	return openToken(secret, encrypted)
+}
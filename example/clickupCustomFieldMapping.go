@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// clickUpFieldTargetTag maps a ClickUp custom field onto local tags instead of a field value
+const clickUpFieldTargetTag = "tag"
+
+// ClickUpFieldMapping links a ClickUp custom field to a local custom field or to tags,
+// configured with CLICKUP_CUSTOM_FIELDS, e.g. "Client=client,Billing Code=billing_code,Team=tag"
+type ClickUpFieldMapping config.FieldMapping
+
+// clickUpCustomFieldMappings returns the mappings configured in the ClickUp settings
+func clickUpCustomFieldMappings(settings config.ClickUp) []ClickUpFieldMapping {
+	mappings := make([]ClickUpFieldMapping, 0, len(settings.CustomFields))
+	for _, mapping := range settings.CustomFields {
+		mappings = append(mappings, ClickUpFieldMapping(mapping))
+	}
+	return mappings
+}
+
+// matches reports whether the mapping refers to the given ClickUp field
+func (m ClickUpFieldMapping) matches(field clickup.CustomField) bool {
+	return field.ID == m.Field || strings.EqualFold(field.Name, m.Field)
+}
+
+// pullCustomFields copies the mapped ClickUp custom fields onto the local task. Field values
+// replace the local ones, tag values are only added like other pulled tags.
+func (s *ClickUpTaskSyncService) pullCustomFields(ctx context.Context, taskID int32, fields []clickup.CustomField) {
+	mappings := clickUpCustomFieldMappings(s.clients.settings)
+	for _, field := range fields {
+		for _, mapping := range mappings {
+			if !mapping.matches(field) {
+				continue
+			}
+
+			value := strings.TrimSpace(field.StringValue())
+			if mapping.Target == clickUpFieldTargetTag {
+				if value != "" {
+					s.pullTags(ctx, taskID, []clickup.Tag{{Name: value}})
+				}
+				continue
+			}
+
+			if err := saveTaskCustomField(ctx, s.store, taskID, mapping.Target, value); err != nil {
+				slog.WarnContext(ctx, "Failed to store ClickUp field", "field", field.Name, "task_id", taskID, "error", err)
+			}
+		}
+	}
+}
+
+// pushClickUpCustomFields sends changed local custom fields to the linked ClickUp task. Fields
+// without a mapping, or missing on the ClickUp task, stay local only, and so do all of them while
+// the integration is disabled.
+func pushClickUpCustomFields(ctx context.Context, client clickup.ClickUpAPI, mappings []ClickUpFieldMapping, remote *clickup.ClickUpTask, values map[string]string) {
+	if len(mappings) == 0 || !client.Enabled() {
+		return
+	}
+
+	for _, mapping := range mappings {
+		value, ok := values[mapping.Target]
+		if !ok || mapping.Target == clickUpFieldTargetTag {
+			continue
+		}
+
+		pushed := false
+		for _, field := range remote.CustomFields {
+			if !mapping.matches(field) {
+				continue
+			}
+			if err := client.SetCustomFieldValue(ctx, remote.ID, field, strings.TrimSpace(value)); err != nil {
+				// The local value stands even when ClickUp rejects it
+				slog.WarnContext(ctx, "Failed to push field to ClickUp", "field", mapping.Target, "clickup_task_id", remote.ID, "error", err)
+			}
+			pushed = true
+			break
+		}
+		if !pushed {
+			slog.WarnContext(ctx, "ClickUp task has no custom field to push to", "clickup_task_id", remote.ID, "clickup_field", mapping.Field, "field", mapping.Target)
+		}
+	}
+}
+
+// saveTaskCustomField stores a custom field value on a task, removing it when empty
+func saveTaskCustomField(ctx context.Context, store db.Querier, taskID int32, key, value string) error {
+	if key == "" {
+		return nil
+	}
+	if value == "" {
+		return store.DeleteTaskCustomField(ctx, db.DeleteTaskCustomFieldParams{TaskID: taskID, FieldKey: key})
+	}
+	return store.UpsertTaskCustomField(ctx, db.UpsertTaskCustomFieldParams{TaskID: taskID, FieldKey: key, Value: value})
+}
+
+// attachTaskCustomFields fills in the custom fields of each task with a single query
+func (s *Server) attachTaskCustomFields(ctx context.Context, tasks []TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int32, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	rows, err := s.store.ListTaskCustomFieldsByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	fieldsByTask := make(map[int32]map[string]string)
+	for _, row := range rows {
+		if fieldsByTask[row.TaskID] == nil {
+			fieldsByTask[row.TaskID] = make(map[string]string)
+		}
+		fieldsByTask[row.TaskID][row.FieldKey] = row.Value
+	}
+
+	for i := range tasks {
+		if fields, ok := fieldsByTask[tasks[i].ID]; ok {
+			tasks[i].CustomFields = fields
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// ClickUpTaskBackend links local tasks to ClickUp tasks
+type ClickUpTaskBackend struct {
+	store   db.Querier
+	client  clickup.ClickUpAPI
+	clients *ClickUpClients
+}
+
+// NewClickUpTaskBackend creates a ClickUp task backend acting with the given client, one of
+// the clients handed out by clients
+func NewClickUpTaskBackend(store db.Querier, client clickup.ClickUpAPI, clients *ClickUpClients) *ClickUpTaskBackend {
+	return &ClickUpTaskBackend{
+		store:   store,
+		client:  client,
+		clients: clients,
+	}
+}
+
+// Name returns the backend name used in task requests
+func (b *ClickUpTaskBackend) Name() string {
+	return taskBackendClickUp
+}
+
+// Enabled reports whether a ClickUp token is configured
+func (b *ClickUpTaskBackend) Enabled() bool {
+	return b.client != nil && b.client.Enabled()
+}
+
+// CreateTask creates a task in a ClickUp list. A subtask without a list is created in the
+// parent's list.
+func (b *ClickUpTaskBackend) CreateTask(ctx context.Context, req RemoteTaskRequest) (*RemoteTask, error) {
+	listID := req.ContainerID
+	if listID == "" && req.ParentID != "" {
+		parent, err := b.client.GetTask(ctx, req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ClickUp parent task %s: %w", req.ParentID, err)
+		}
+		listID = parent.List.ID
+	}
+
+	created, err := b.client.CreateTask(ctx, clickup.CreateTaskRequest{
+		Name:        req.Title,
+		Description: req.Note,
+		Status:      req.Status,
+		Parent:      req.ParentID,
+		ListID:      listID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newClickUpRemoteTask(created), nil
+}
+
+// UpdateTask sends the title, note, status and mapped custom fields to the ClickUp task
+func (b *ClickUpTaskBackend) UpdateTask(ctx context.Context, remoteID string, req RemoteTaskRequest) (*RemoteTask, error) {
+	updateData := map[string]interface{}{
+		"name":        req.Title,
+		"description": req.Note,
+	}
+	if req.Status != "" {
+		updateData["status"] = req.Status
+	}
+
+	updated, err := b.client.UpdateTask(ctx, remoteID, updateData)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.CustomFields) > 0 {
+		pushClickUpCustomFields(ctx, b.client, clickUpCustomFieldMappings(b.clients.settings), updated, req.CustomFields)
+	}
+	return newClickUpRemoteTask(updated), nil
+}
+
+// ExtractID returns the ClickUp task ID of a task URL
+func (b *ClickUpTaskBackend) ExtractID(url string) string {
+	return clickup.ExtractTaskIDFromURL(url)
+}
+
+// Sync reconciles the task with ClickUp using this backend's client
+func (b *ClickUpTaskBackend) Sync(ctx context.Context, task db.Task) (*TaskSyncResult, error) {
+	return NewClickUpTaskSyncService(b.store, b.clients).syncTask(ctx, b.client, task)
+}
+
+// newClickUpRemoteTask converts a ClickUp task to the backend-neutral format
+func newClickUpRemoteTask(task *clickup.ClickUpTask) *RemoteTask {
+	return &RemoteTask{
+		ID:        task.ID,
+		URL:       task.URL,
+		Title:     task.Name,
+		Note:      task.Description,
+		Status:    task.Status.Status,
+		TeamID:    task.TeamID,
+		UpdatedAt: task.DateUpdated.Time,
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// TaskSyncHistoryResponse is the response format for a task sync history entry
+type TaskSyncHistoryResponse struct {
+	ID              int32      `json:"id"`
+	TaskID          int32      `json:"task_id"`
+	Direction       string     `json:"direction"`
+	Status          string     `json:"status"`
+	ChangedFields   []string   `json:"changed_fields"`
+	Message         string     `json:"message,omitempty"`
+	RemoteUpdatedAt *time.Time `json:"remote_updated_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// syncTask handles the request to sync a single task with ClickUp or the tracker it is linked to,
+// for those who can change the task
+func (s *Server) syncTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	task, err := s.store.GetTask(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	// Syncing overwrites the task with the tracker's copy, so it takes the right to change it
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	// Tasks linked to other trackers are synced by their own backend
+	if backend := s.taskBackendForTask(ctx, s.store, task, 0); backend != nil && backend.Name() != taskBackendClickUp {
+		if !backend.Enabled() {
+			respondWithError(w, http.StatusServiceUnavailable, "Task backend "+backend.Name()+" is disabled")
+			return
+		}
+
+		result, err := backend.Sync(ctx, task)
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, "Error syncing task with "+backend.Name()+": "+err.Error())
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, result)
+		return
+	}
+
+	if !s.taskSync.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	result, err := s.taskSync.SyncTask(ctx, task)
+	if err != nil {
+		respondWithError(w, clickUpErrorStatus(err), "Error syncing task with ClickUp: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// syncAllTasks handles the request to sync every linked task with ClickUp (admin only)
+func (s *Server) syncAllTasks(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can sync all tasks")
+		return
+	}
+
+	if !s.taskSync.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	summary, err := s.taskSync.SyncAllTasks(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error syncing tasks with ClickUp: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// syncWorkspaceTasks handles the request to sync the tasks of one ClickUp workspace (admin only)
+func (s *Server) syncWorkspaceTasks(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can sync workspace tasks")
+		return
+	}
+
+	if !s.taskSync.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	summary, err := s.taskSync.SyncWorkspaceTasks(r.Context(), mux.Vars(r)["team_id"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error syncing workspace tasks with ClickUp: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// importClickUpList handles the request to create local tasks for the tasks of a ClickUp list (admin only)
+func (s *Server) importClickUpList(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can import ClickUp lists")
+		return
+	}
+
+	if !s.taskSync.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	vars := mux.Vars(r)
+	summary, err := s.taskSync.ImportListTasks(r.Context(), vars["team_id"], vars["list_id"], currentUser.ID)
+	if err != nil {
+		respondWithError(w, clickUpErrorStatus(err), "Error importing ClickUp list: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// getClickUpStatus handles the request to report the health of the ClickUp integration (admin only)
+func (s *Server) getClickUpStatus(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can view the integration status")
+		return
+	}
+
+	status, err := s.taskSync.Status(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching ClickUp integration status: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, status)
+}
+
+// clickUpErrorStatus maps a failed ClickUp call to the status reported to our own clients
+func clickUpErrorStatus(err error) int {
+	if errors.Is(err, clickup.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable
+	}
+
+	var apiErr *clickup.APIError
+	if errors.As(err, &apiErr) && apiErr.IsRateLimited() {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadGateway
+}
+
+// getSyncHistory handles the request to list a task's sync history, newest first
+func (s *Server) getSyncHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Parse limit parameter
+	limit := 50
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	entries, err := s.store.ListTaskSyncHistory(ctx, sqlc.ListTaskSyncHistoryParams{
+		TaskID: int32(id),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching sync history: "+err.Error())
+		return
+	}
+
+	response := make([]TaskSyncHistoryResponse, 0, len(entries))
+	for _, entry := range entries {
+		item := TaskSyncHistoryResponse{
+			ID:            entry.ID,
+			TaskID:        entry.TaskID,
+			Direction:     entry.Direction,
+			Status:        entry.Status,
+			ChangedFields: []string{},
+			Message:       entry.Message.String,
+			CreatedAt:     entry.CreatedAt.Time,
+		}
+		if entry.ChangedFields.Valid && entry.ChangedFields.String != "" {
+			item.ChangedFields = strings.Split(entry.ChangedFields.String, ",")
+		}
+		if entry.RemoteUpdatedAt.Valid {
+			remoteUpdatedAt := entry.RemoteUpdatedAt.Time
+			item.RemoteUpdatedAt = &remoteUpdatedAt
+		}
+		response = append(response, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
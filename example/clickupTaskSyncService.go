@@ -0,0 +1,494 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// Sync history directions and statuses
+const (
+	taskSyncDirectionPull = "pull"
+	taskSyncDirectionPush = "push"
+
+	taskSyncStatusSuccess  = "success"
+	taskSyncStatusConflict = "conflict"
+	taskSyncStatusError    = "error"
+)
+
+// TaskSyncResult describes what a sync did for a single task
+type TaskSyncResult struct {
+	TaskID        int32    `json:"task_id"`
+	Direction     string   `json:"direction,omitempty"` // Empty when nothing changed
+	Conflict      bool     `json:"conflict"`
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
+
+// TaskSyncSummary counts the outcomes of syncing linked tasks
+type TaskSyncSummary struct {
+	TeamID    string `json:"team_id,omitempty"` // Workspace the counts are for, empty for all or unknown
+	Total     int    `json:"total"`
+	Pulled    int    `json:"pulled"`
+	Pushed    int    `json:"pushed"`
+	Conflicts int    `json:"conflicts"`
+	Unchanged int    `json:"unchanged"`
+	Failed    int    `json:"failed"`
+	// Per workspace counts when syncing all linked tasks
+	Workspaces []*TaskSyncSummary `json:"workspaces,omitempty"`
+}
+
+// TaskImportSummary counts the outcomes of importing the tasks of a ClickUp list
+type TaskImportSummary struct {
+	ListID   string  `json:"list_id"`
+	Total    int     `json:"total"`
+	Imported int     `json:"imported"`
+	Skipped  int     `json:"skipped"` // Already linked, or a task with the same title exists
+	Failed   int     `json:"failed"`
+	TaskIDs  []int32 `json:"task_ids"` // Local tasks created by the import
+}
+
+// clickUpStatusErrorWindow is how far back the status report counts sync errors
+const clickUpStatusErrorWindow = 24 * time.Hour
+
+// ClickUpIntegrationStatus reports whether the ClickUp integration is working. There is no
+// webhook intake, so the backlog is the local changes waiting for the next sync to push them.
+type ClickUpIntegrationStatus struct {
+	Mode                 string     `json:"mode"` // "connected", or "local_only" when no token is configured
+	TokenType            string     `json:"token_type,omitempty"`
+	TokenValid           bool       `json:"token_valid"`
+	TokenError           string     `json:"token_error,omitempty"`
+	CircuitOpen          bool       `json:"circuit_open"` // Calls to ClickUp are paused after repeated failures
+	ConnectedWorkspaces  int        `json:"connected_workspaces"`
+	LinkedTasks          int64      `json:"linked_tasks"`
+	PendingChanges       int64      `json:"pending_changes"` // Linked tasks with local changes not yet in ClickUp
+	LastSuccessfulSyncAt *time.Time `json:"last_successful_sync_at,omitempty"`
+	LastErrorAt          *time.Time `json:"last_error_at,omitempty"`
+	RecentErrors         int64      `json:"recent_errors"` // Sync errors within the last 24 hours
+}
+
+// ClickUpTaskSyncService keeps local tasks and their linked ClickUp tasks in step
+type ClickUpTaskSyncService struct {
+	store   db.Querier
+	client  clickup.ClickUpAPI
+	clients *ClickUpClients
+}
+
+// NewClickUpTaskSyncService creates a new instance of the ClickUp task sync service, syncing with
+// the shared client unless a workspace has a token of its own
+func NewClickUpTaskSyncService(store db.Querier, clients *ClickUpClients) *ClickUpTaskSyncService {
+	return &ClickUpTaskSyncService{
+		store:   store,
+		client:  clients.Shared(),
+		clients: clients,
+	}
+}
+
+// Enabled reports whether a ClickUp token is configured
+func (s *ClickUpTaskSyncService) Enabled() bool {
+	return s.client != nil && s.client.Enabled()
+}
+
+// CircuitOpen reports whether the shared client's circuit breaker is refusing requests after
+// repeated ClickUp failures
+func (s *ClickUpTaskSyncService) CircuitOpen() bool {
+	client, ok := s.client.(interface{ CircuitOpen() bool })
+	return ok && client.CircuitOpen()
+}
+
+// clientForWorkspace returns the client to sync a workspace with: the token of the user who
+// connected it, or the shared client when the workspace is unknown or has no stored token
+func (s *ClickUpTaskSyncService) clientForWorkspace(ctx context.Context, teamID string) clickup.ClickUpAPI {
+	if teamID != "" {
+		if client := s.clients.ForWorkspace(ctx, s.store, teamID); client != nil {
+			return client
+		}
+	}
+	return s.client
+}
+
+// Status checks the configured token against ClickUp and summarizes the recorded syncs
+func (s *ClickUpTaskSyncService) Status(ctx context.Context) (*ClickUpIntegrationStatus, error) {
+	stats, err := s.store.GetClickUpSyncStats(ctx, pgtype.Timestamptz{Time: time.Now().Add(-clickUpStatusErrorWindow), Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ClickUp sync stats: %w", err)
+	}
+
+	workspaces, err := s.store.ListClickUpWorkspaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClickUp workspaces: %w", err)
+	}
+
+	status := &ClickUpIntegrationStatus{
+		Mode:                "local_only",
+		CircuitOpen:         s.CircuitOpen(),
+		ConnectedWorkspaces: len(workspaces),
+		LinkedTasks:         stats.LinkedTasks,
+		PendingChanges:      stats.PendingChanges,
+		RecentErrors:        stats.RecentErrors,
+	}
+	if stats.LastSyncedAt.Valid {
+		lastSynced := stats.LastSyncedAt.Time
+		status.LastSuccessfulSyncAt = &lastSynced
+	}
+	if stats.LastErrorAt.Valid {
+		lastError := stats.LastErrorAt.Time
+		status.LastErrorAt = &lastError
+	}
+
+	if !s.Enabled() {
+		return status, nil
+	}
+
+	status.Mode = "connected"
+	if client, ok := s.client.(*clickup.Client); ok {
+		status.TokenType = client.TokenType
+	}
+	if _, err := s.client.GetTeams(ctx); err != nil {
+		status.TokenError = err.Error()
+	} else {
+		status.TokenValid = true
+	}
+	return status, nil
+}
+
+// SyncTask reconciles one local task with its ClickUp task. When both sides differ,
+// the side with the newer updated-at wins; a conflict is recorded when both sides
+// were edited since the last sync.
+func (s *ClickUpTaskSyncService) SyncTask(ctx context.Context, task db.Task) (*TaskSyncResult, error) {
+	return s.syncTask(ctx, s.clientForWorkspace(ctx, task.ClickupTeamID.String), task)
+}
+
+// syncTask reconciles one local task using the given client
+func (s *ClickUpTaskSyncService) syncTask(ctx context.Context, client clickup.ClickUpAPI, task db.Task) (*TaskSyncResult, error) {
+	if client == nil || !client.Enabled() {
+		return nil, fmt.Errorf("clickup integration is disabled")
+	}
+
+	clickupTaskID := clickup.ExtractTaskIDFromURL(task.Url.String)
+	if clickupTaskID == "" {
+		return nil, fmt.Errorf("task %d is not linked to a ClickUp task", task.ID)
+	}
+
+	remote, err := client.GetTask(ctx, clickupTaskID)
+	if err != nil {
+		s.recordHistory(ctx, task.ID, taskSyncDirectionPull, taskSyncStatusError, nil, err.Error(), time.Time{})
+		return nil, fmt.Errorf("failed to fetch ClickUp task %s: %w", clickupTaskID, err)
+	}
+
+	// Tags are only added locally, removals are pushed from the task tag endpoints
+	if s.clients.settings.SyncTags {
+		s.pullTags(ctx, task.ID, remote.Tags)
+	}
+	s.pullCustomFields(ctx, task.ID, remote.CustomFields)
+
+	result := &TaskSyncResult{TaskID: task.ID}
+	result.ChangedFields = diffClickUpTask(task, remote)
+	remoteUpdated := remote.DateUpdated.Time
+
+	// Nothing to reconcile, just move the sync marker forward
+	if len(result.ChangedFields) == 0 {
+		if err := s.store.MarkTaskClickUpSynced(ctx, db.MarkTaskClickUpSyncedParams{
+			ID:              task.ID,
+			RemoteUpdatedAt: pgtype.Timestamptz{Time: remoteUpdated, Valid: !remoteUpdated.IsZero()},
+			TeamID:          pgtype.Text{String: remote.TeamID, Valid: remote.TeamID != ""},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to mark task %d as synced: %w", task.ID, err)
+		}
+		return result, nil
+	}
+
+	// Both sides changed since the last sync - resolve by updated-at
+	localUpdated := task.UpdatedAt.Time
+	if task.ClickupSyncedAt.Valid {
+		lastSynced := task.ClickupSyncedAt.Time
+		result.Conflict = localUpdated.After(lastSynced) && remoteUpdated.After(lastSynced)
+	}
+
+	status := taskSyncStatusSuccess
+	if result.Conflict {
+		status = taskSyncStatusConflict
+	}
+
+	if remoteUpdated.After(localUpdated) {
+		result.Direction = taskSyncDirectionPull
+		err = s.pull(ctx, task, remote)
+	} else {
+		result.Direction = taskSyncDirectionPush
+		err = s.push(ctx, client, task, clickupTaskID)
+	}
+
+	if err != nil {
+		s.recordHistory(ctx, task.ID, result.Direction, taskSyncStatusError, result.ChangedFields, err.Error(), remoteUpdated)
+		return nil, err
+	}
+
+	message := ""
+	if result.Conflict {
+		message = fmt.Sprintf("both sides changed since last sync, %s side was newer", map[string]string{
+			taskSyncDirectionPull: "ClickUp",
+			taskSyncDirectionPush: "local",
+		}[result.Direction])
+	}
+	s.recordHistory(ctx, task.ID, result.Direction, status, result.ChangedFields, message, remoteUpdated)
+
+	return result, nil
+}
+
+// SyncAllTasks reconciles every task linked to ClickUp, one workspace at a time so each
+// workspace is synced with its own token. Failures are counted and logged without stopping the run.
+func (s *ClickUpTaskSyncService) SyncAllTasks(ctx context.Context) (*TaskSyncSummary, error) {
+	tasks, err := s.store.ListClickUpLinkedTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClickUp linked tasks: %w", err)
+	}
+
+	// Group by workspace, keeping the workspaces in the order they were first seen
+	var teamIDs []string
+	tasksByTeam := make(map[string][]db.Task)
+	for _, task := range tasks {
+		teamID := task.ClickupTeamID.String
+		if _, ok := tasksByTeam[teamID]; !ok {
+			teamIDs = append(teamIDs, teamID)
+		}
+		tasksByTeam[teamID] = append(tasksByTeam[teamID], task)
+	}
+
+	summary := &TaskSyncSummary{}
+	for _, teamID := range teamIDs {
+		workspaceSummary := s.syncTasks(ctx, teamID, tasksByTeam[teamID])
+		summary.Total += workspaceSummary.Total
+		summary.Pulled += workspaceSummary.Pulled
+		summary.Pushed += workspaceSummary.Pushed
+		summary.Conflicts += workspaceSummary.Conflicts
+		summary.Unchanged += workspaceSummary.Unchanged
+		summary.Failed += workspaceSummary.Failed
+		summary.Workspaces = append(summary.Workspaces, workspaceSummary)
+	}
+
+	return summary, nil
+}
+
+// SyncWorkspaceTasks reconciles the tasks linked to one ClickUp workspace. An empty team ID
+// syncs the linked tasks whose workspace is not known yet.
+func (s *ClickUpTaskSyncService) SyncWorkspaceTasks(ctx context.Context, teamID string) (*TaskSyncSummary, error) {
+	tasks, err := s.store.ListClickUpLinkedTasksByTeam(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ClickUp linked tasks of workspace %s: %w", teamID, err)
+	}
+
+	return s.syncTasks(ctx, teamID, tasks), nil
+}
+
+// ImportListTasks creates a local task linked to each task of a ClickUp list, open or closed,
+// skipping those a local task already stands for. Failures are counted and logged without
+// stopping the import.
+func (s *ClickUpTaskSyncService) ImportListTasks(ctx context.Context, teamID, listID string, userID int32) (*TaskImportSummary, error) {
+	client := s.clientForWorkspace(ctx, teamID)
+	if client == nil || !client.Enabled() {
+		return nil, fmt.Errorf("clickup integration is disabled")
+	}
+
+	remoteTasks, err := client.GetTasksByList(ctx, listID, clickup.ListTasksOptions{IncludeClosed: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tasks of ClickUp list %s: %w", listID, err)
+	}
+
+	summary := &TaskImportSummary{ListID: listID, Total: len(remoteTasks), TaskIDs: []int32{}}
+	for _, remote := range remoteTasks {
+		_, err := s.store.FindDuplicateTask(ctx, db.FindDuplicateTaskParams{
+			Url:   pgtype.Text{String: remote.URL, Valid: remote.URL != ""},
+			Title: remote.Name,
+		})
+		if err == nil {
+			summary.Skipped++
+			continue
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Failed to check for an imported ClickUp task", "clickup_task_id", remote.ID, "error", err)
+			summary.Failed++
+			continue
+		}
+
+		taskID, err := s.importTask(ctx, teamID, listID, remote, userID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to import ClickUp task", "clickup_task_id", remote.ID, "error", err)
+			summary.Failed++
+			continue
+		}
+		summary.Imported++
+		summary.TaskIDs = append(summary.TaskIDs, taskID)
+	}
+
+	return summary, nil
+}
+
+// importTask creates the local task of a ClickUp task, marked as synced with it
+func (s *ClickUpTaskSyncService) importTask(ctx context.Context, teamID, listID string, remote clickup.ClickUpTask, userID int32) (int32, error) {
+	task, err := s.store.CreateTask(ctx, db.CreateTaskParams{
+		Url:             pgtype.Text{String: remote.URL, Valid: remote.URL != ""},
+		Title:           pgtype.Text{String: remote.Name, Valid: remote.Name != ""},
+		Note:            pgtype.Text{String: remote.Description, Valid: remote.Description != ""},
+		Status:          pgtype.Text{String: remote.Status.Status, Valid: remote.Status.Status != ""},
+		StatusColor:     pgtype.Text{String: remote.Status.Color, Valid: remote.Status.Color != ""},
+		CreatedByUserID: pgtype.Int4{Int32: userID, Valid: userID != 0},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if remote.TeamID != "" {
+		teamID = remote.TeamID
+	}
+	err = s.store.MarkTaskClickUpSynced(ctx, db.MarkTaskClickUpSyncedParams{
+		ID:              task.ID,
+		RemoteUpdatedAt: pgtype.Timestamptz{Time: remote.DateUpdated.Time, Valid: !remote.DateUpdated.IsZero()},
+		TeamID:          pgtype.Text{String: teamID, Valid: teamID != ""},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark task %d synced: %w", task.ID, err)
+	}
+
+	s.pullTags(ctx, task.ID, remote.Tags)
+	s.recordHistory(ctx, task.ID, taskSyncDirectionPull, taskSyncStatusSuccess, []string{"title", "note", "status"}, "Imported from ClickUp list "+listID, remote.DateUpdated.Time)
+	return task.ID, nil
+}
+
+// syncTasks reconciles tasks of a single workspace and counts the outcomes
+func (s *ClickUpTaskSyncService) syncTasks(ctx context.Context, teamID string, tasks []db.Task) *TaskSyncSummary {
+	client := s.clientForWorkspace(ctx, teamID)
+
+	summary := &TaskSyncSummary{TeamID: teamID, Total: len(tasks)}
+	for _, task := range tasks {
+		result, err := s.syncTask(ctx, client, task)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to sync task with ClickUp", "task_id", task.ID, "error", err)
+			summary.Failed++
+			continue
+		}
+
+		if result.Conflict {
+			summary.Conflicts++
+		}
+		switch result.Direction {
+		case taskSyncDirectionPull:
+			summary.Pulled++
+		case taskSyncDirectionPush:
+			summary.Pushed++
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	return summary
+}
+
+// pull copies the ClickUp task's name, description and status onto the local task
+func (s *ClickUpTaskSyncService) pull(ctx context.Context, task db.Task, remote *clickup.ClickUpTask) error {
+	_, err := s.store.ApplyClickUpTaskChanges(ctx, db.ApplyClickUpTaskChangesParams{
+		ID:              task.ID,
+		Title:           pgtype.Text{String: remote.Name, Valid: remote.Name != ""},
+		Note:            pgtype.Text{String: remote.Description, Valid: remote.Description != ""},
+		Status:          pgtype.Text{String: remote.Status.Status, Valid: remote.Status.Status != ""},
+		StatusColor:     pgtype.Text{String: remote.Status.Color, Valid: remote.Status.Color != ""},
+		TeamID:          pgtype.Text{String: remote.TeamID, Valid: remote.TeamID != ""},
+		RemoteUpdatedAt: pgtype.Timestamptz{Time: remote.DateUpdated.Time, Valid: !remote.DateUpdated.IsZero()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply ClickUp changes to task %d: %w", task.ID, err)
+	}
+
+	// Status changes pulled from ClickUp show up in the activity feed without a user
+	if remote.Status.Status != "" && !strings.EqualFold(task.Status.String, remote.Status.Status) {
+		recordTaskActivity(ctx, s.store, task.ID, 0, taskActivityStatusChanged, task.Status.String, remote.Status.Status, 0)
+	}
+	return nil
+}
+
+// pullTags makes sure every ClickUp tag on the task also exists on the local task
+func (s *ClickUpTaskSyncService) pullTags(ctx context.Context, taskID int32, tags []clickup.Tag) {
+	for _, remoteTag := range tags {
+		name := strings.TrimSpace(remoteTag.Name)
+		if name == "" {
+			continue
+		}
+
+		tag, err := s.store.UpsertTagByName(ctx, db.UpsertTagByNameParams{
+			Name:  name,
+			Color: pgtype.Text{String: remoteTag.TagBg, Valid: remoteTag.TagBg != ""},
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to create tag from ClickUp", "tag", name, "error", err)
+			continue
+		}
+
+		if err := s.store.AddTaskTag(ctx, db.AddTaskTagParams{TaskID: taskID, TagID: tag.ID}); err != nil {
+			slog.WarnContext(ctx, "Failed to add tag to task", "tag", name, "task_id", taskID, "error", err)
+		}
+	}
+}
+
+// push sends the local task's title, note and status to ClickUp
+func (s *ClickUpTaskSyncService) push(ctx context.Context, client clickup.ClickUpAPI, task db.Task, clickupTaskID string) error {
+	updateData := map[string]interface{}{
+		"name":        task.Title.String,
+		"description": task.Note.String,
+	}
+	if task.Status.Valid && task.Status.String != "" {
+		updateData["status"] = task.Status.String
+	}
+
+	updated, err := client.UpdateTask(ctx, clickupTaskID, updateData)
+	if err != nil {
+		return fmt.Errorf("failed to push task %d to ClickUp: %w", task.ID, err)
+	}
+
+	return s.store.MarkTaskClickUpSynced(ctx, db.MarkTaskClickUpSyncedParams{
+		ID:              task.ID,
+		RemoteUpdatedAt: pgtype.Timestamptz{Time: updated.DateUpdated.Time, Valid: !updated.DateUpdated.IsZero()},
+		TeamID:          pgtype.Text{String: updated.TeamID, Valid: updated.TeamID != ""},
+	})
+}
+
+// recordHistory stores a sync history entry, logging rather than failing on error
+func (s *ClickUpTaskSyncService) recordHistory(ctx context.Context, taskID int32, direction, status string, changedFields []string, message string, remoteUpdated time.Time) {
+	recordTaskSyncHistory(ctx, s.store, taskID, direction, status, changedFields, message, remoteUpdated)
+}
+
+// recordTaskSyncHistory stores a sync history entry for any task backend, logging rather than failing on error
+func recordTaskSyncHistory(ctx context.Context, store db.Querier, taskID int32, direction, status string, changedFields []string, message string, remoteUpdated time.Time) {
+	_, err := store.CreateTaskSyncHistory(ctx, db.CreateTaskSyncHistoryParams{
+		TaskID:          taskID,
+		Direction:       direction,
+		Status:          status,
+		ChangedFields:   pgtype.Text{String: strings.Join(changedFields, ","), Valid: len(changedFields) > 0},
+		Message:         pgtype.Text{String: message, Valid: message != ""},
+		RemoteUpdatedAt: pgtype.Timestamptz{Time: remoteUpdated, Valid: !remoteUpdated.IsZero()},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record sync history", "task_id", taskID, "error", err)
+	}
+}
+
+// diffClickUpTask lists the synced fields that differ between a local and a ClickUp task
+func diffClickUpTask(task db.Task, remote *clickup.ClickUpTask) []string {
+	var changed []string
+	if task.Title.String != remote.Name {
+		changed = append(changed, "title")
+	}
+	if task.Note.String != remote.Description {
+		changed = append(changed, "note")
+	}
+	// ClickUp lower-cases status names, so compare case-insensitively
+	if !strings.EqualFold(task.Status.String, remote.Status.Status) {
+		changed = append(changed, "status")
+	}
+	return changed
+}
@@ -1,70 +1,370 @@
 package main
 
 import (
-	"log"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
 	"github.com/kengtableg/pkeng-tableg/example/clickup"
 )
 
+// oauthStateTTL is how long a user has to finish authorizing in ClickUp
+const oauthStateTTL = 10 * time.Minute
+
+// clickUpTokenTypePersonal marks stored personal API tokens, which are sent without the Bearer prefix
+const clickUpTokenTypePersonal = "personal"
+
 // OAuthState represents a session state for OAuth
 type OAuthState struct {
 	State     string    `json:"state"`
-	CreatedAt time.Time `json:"created_at"`
+	UserID    int32     `json:"user_id"` // The user the token will be stored for
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-var (
-	// In-memory store of active OAuth states (in a real app, use a database)
-	oauthStates = make(map[string]OAuthState)
-	// In-memory store for the OAuth token (in a real app, use a database or secure storage)
-	oauthToken string
-)
+// ClickUpTokenResponse describes the stored ClickUp token of the current user without revealing it
+type ClickUpTokenResponse struct {
+	Connected   bool       `json:"connected"`
+	TokenType   string     `json:"token_type,omitempty"`
+	TokenSuffix string     `json:"token_suffix,omitempty"` // Last characters of the token, to tell tokens apart
+	TeamIDs     []string   `json:"team_ids,omitempty"`     // ClickUp workspaces the token was stored for
+	ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// ClickUpLinkRequest represents the request body for linking a ClickUp account
+type ClickUpLinkRequest struct {
+	APIToken string `json:"api_token,omitempty"` // Personal API token, starts the OAuth flow when empty
+}
+
+// initiateOAuthHandler starts the OAuth flow for the current user and returns the ClickUp
+// authorization URL to send the browser to
+func (s *Server) initiateOAuthHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if !s.config.ClickUp.OAuthEnabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp account linking is not configured, set CLICKUP_CLIENT_ID and CLICKUP_CLIENT_SECRET")
+		return
+	}
+
+	// Refuse to start a flow whose token could not be stored
+	if _, err := s.clickUp.tokenKey(); err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	state, err := s.clickUp.newOAuthState(currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error generating OAuth state: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"authorization_url": s.clickUp.OAuth().GetAuthorizationURL(state),
+		"state":             state,
+	})
+}
+
+// oauthCallbackHandler receives the redirect from ClickUp, verifies the state and stores the
+// exchanged token for the user who started the flow
+func (s *Server) oauthCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	if errParam := query.Get("error"); errParam != "" {
+		respondWithError(w, http.StatusBadRequest, "ClickUp authorization failed: "+errParam)
+		return
+	}
+
+	state, ok := s.clickUp.verifyOAuthState(query.Get("state"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired OAuth state")
+		return
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing authorization code")
+		return
+	}
+
+	token, err := s.clickUp.OAuth().ExchangeCodeForToken(r.Context(), code)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Error exchanging authorization code: "+err.Error())
+		return
+	}
+
+	stored, teamIDs, err := s.storeClickUpToken(ctx, state.UserID, token.AccessToken, token.TokenType)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing ClickUp token: "+err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Linked ClickUp account through OAuth", "user_id", state.UserID, "workspaces", len(teamIDs))
+	respondWithJSON(w, http.StatusOK, newClickUpTokenResponse(stored, token.AccessToken, teamIDs))
+}
+
+// linkClickUpAccountHandler connects the current user's ClickUp account. A personal API token
+// in the body is checked against ClickUp and stored right away; without one the OAuth flow is
+// started as by GET /api/oauth/clickup.
+func (s *Server) linkClickUpAccountHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req ClickUpLinkRequest
+	if r.ContentLength != 0 {
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+	}
+
+	apiToken := strings.TrimSpace(req.APIToken)
+	if apiToken == "" {
+		s.initiateOAuthHandler(w, r)
+		return
+	}
+
+	if _, err := s.clickUp.tokenKey(); err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	// Only store tokens ClickUp accepts
+	if _, err := clickup.NewClient(apiToken).GetTeams(ctx); err != nil {
+		var apiErr *clickup.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			respondWithError(w, http.StatusBadRequest, "ClickUp rejected the API token")
+			return
+		}
+		respondWithError(w, clickUpErrorStatus(err), "Error checking ClickUp API token: "+err.Error())
+		return
+	}
+
+	stored, teamIDs, err := s.storeClickUpToken(ctx, currentUser.ID, apiToken, clickUpTokenTypePersonal)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing ClickUp token: "+err.Error())
+		return
+	}
 
-// getOAuthClient returns a configured OAuth client
-func getOAuthClient() *clickup.OAuth2Client {
-	clientID := os.Getenv("CLICKUP_CLIENT_ID")
-	clientSecret := os.Getenv("CLICKUP_CLIENT_SECRET")
-	redirectURI := os.Getenv("CLICKUP_REDIRECT_URI")
+	slog.InfoContext(ctx, "Linked ClickUp account with a personal token", "user_id", currentUser.ID, "workspaces", len(teamIDs))
+	respondWithJSON(w, http.StatusOK, newClickUpTokenResponse(stored, apiToken, teamIDs))
+}
+
+// storeClickUpToken encrypts and stores a user's token once per workspace it can access. When the
+// workspaces cannot be listed it is stored without one, and still used for tasks of unknown
+// workspaces. Returns the last stored row and the workspace IDs.
+func (s *Server) storeClickUpToken(ctx context.Context, userID int32, accessToken, tokenType string) (sqlc.ClickupToken, []string, error) {
+	var stored sqlc.ClickupToken
+
+	encrypted, err := s.clickUp.encryptToken(accessToken)
+	if err != nil {
+		return stored, nil, fmt.Errorf("failed to encrypt token: %w", err)
+	}
+
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+
+	client := clickup.GetClientFromToken(accessToken)
+	if tokenType == clickUpTokenTypePersonal {
+		client = clickup.NewClient(accessToken)
+	}
+	teams, err := client.GetTeams(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list ClickUp workspaces", "user_id", userID, "error", err)
+	}
+	if len(teams) == 0 {
+		teams = []clickup.Team{{}}
+	}
+
+	var teamIDs []string
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		for _, team := range teams {
+			if team.ID != "" {
+				if _, err := qtx.UpsertClickUpWorkspace(ctx, sqlc.UpsertClickUpWorkspaceParams{
+					TeamID:            team.ID,
+					Name:              team.Name,
+					ConnectedByUserID: pgtype.Int4{Int32: userID, Valid: true},
+				}); err != nil {
+					return fmt.Errorf("failed to store workspace %s: %w", team.ID, err)
+				}
+				teamIDs = append(teamIDs, team.ID)
+			}
+
+			var err error
+			stored, err = qtx.UpsertClickUpToken(ctx, sqlc.UpsertClickUpTokenParams{
+				UserID:      userID,
+				TeamID:      team.ID,
+				AccessToken: encrypted,
+				TokenType:   tokenType,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stored, nil, err
+	}
+	return stored, teamIDs, nil
+}
+
+// getCurrentTokenHandler reports whether the current user has connected their ClickUp account
+func (s *Server) getCurrentTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	tokens, err := s.store.ListClickUpTokensByUser(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching ClickUp token: "+err.Error())
+		return
+	}
+
+	if len(tokens) == 0 {
+		respondWithJSON(w, http.StatusOK, ClickUpTokenResponse{Connected: false})
+		return
+	}
 
-	// Use defaults if environment variables aren't set
-	if clientID == "" {
-		clientID = "P3497LBAUFF512Q0G9WFEXSQDVSZ4P8N"
+	// Describe the most recently stored token
+	latest := tokens[0]
+	var teamIDs []string
+	for _, stored := range tokens {
+		if stored.UpdatedAt.Time.After(latest.UpdatedAt.Time) {
+			latest = stored
+		}
+		if stored.TeamID != "" {
+			teamIDs = append(teamIDs, stored.TeamID)
+		}
 	}
-	if clientSecret == "" {
-		clientSecret = "2YLZYNP2P7PZXGD70SJDIZFWIW9KLK5EX6T2PEVFLXDOXEXW9DDTZEVOZ16EETBK"
+
+	accessToken, err := s.clickUp.decryptToken(latest.AccessToken)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error decrypting ClickUp token: "+err.Error())
+		return
 	}
-	if redirectURI == "" {
-		// Default to localhost for development - must match exactly what's in ClickUp
-		redirectURI = "http://localhost:8080/api/oauth/callback"
+
+	respondWithJSON(w, http.StatusOK, newClickUpTokenResponse(latest, accessToken, teamIDs))
+}
+
+// deleteCurrentTokenHandler disconnects the current user's ClickUp account
+func (s *Server) deleteCurrentTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
-	log.Printf("ClickUp OAuth initialized with client_id: %s, redirect_uri: %s", clientID, redirectURI)
+	removed, err := s.store.DeleteClickUpToken(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting ClickUp token: "+err.Error())
+		return
+	}
 
-	config := clickup.OAuthConfig{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURI:  redirectURI,
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "No ClickUp account is connected")
+		return
 	}
 
-	return clickup.NewOAuth2Client(config)
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
 
-// Temporary placeholder handlers to satisfy the router
-func initiateOAuthHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("initiateOAuthHandler called, but not implemented")
-	respondWithJSON(w, http.StatusOK, map[string]string{"status": "OAuth flow initiated"})
+// newClickUpTokenResponse converts a stored token to its response format
+func newClickUpTokenResponse(stored sqlc.ClickupToken, accessToken string, teamIDs []string) ClickUpTokenResponse {
+	response := ClickUpTokenResponse{
+		Connected:   true,
+		TokenType:   stored.TokenType,
+		TokenSuffix: accessToken[len(accessToken)-Min(4, len(accessToken)):],
+		TeamIDs:     teamIDs,
+	}
+	if stored.CreatedAt.Valid {
+		connectedAt := stored.CreatedAt.Time
+		response.ConnectedAt = &connectedAt
+	}
+	if stored.UpdatedAt.Valid {
+		updatedAt := stored.UpdatedAt.Time
+		response.UpdatedAt = &updatedAt
+	}
+	return response
 }
 
-func oauthCallbackHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("oauthCallbackHandler called, but not implemented")
-	respondWithJSON(w, http.StatusOK, map[string]string{"status": "OAuth callback received"})
+// newOAuthState returns a state for the user that any instance can verify: the user ID, expiry
+// and a random nonce, signed with a key derived from the token encryption key
+func (c *ClickUpClients) newOAuthState(userID int32) (string, error) {
+	secret, err := c.tokenKey()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := fmt.Sprintf("%d.%d.%s", userID, time.Now().Add(oauthStateTTL).Unix(), hex.EncodeToString(nonce))
+	return payload + "." + oauthStateSignature(secret, payload), nil
+}
+
+// verifyOAuthState reports whether a state was signed by newOAuthState and hasn't expired. A
+// state is not remembered, so a replay within its lifetime is stopped by ClickUp accepting each
+// authorization code only once.
+func (c *ClickUpClients) verifyOAuthState(state string) (OAuthState, bool) {
+	secret, err := c.tokenKey()
+	if err != nil || state == "" {
+		return OAuthState{}, false
+	}
+
+	parts := strings.Split(state, ".")
+	if len(parts) != 4 {
+		return OAuthState{}, false
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(oauthStateSignature(secret, payload))) {
+		return OAuthState{}, false
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return OAuthState{}, false
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return OAuthState{}, false
+	}
+	return OAuthState{State: state, UserID: int32(userID), ExpiresAt: time.Unix(unix, 0)}, true
 }
 
-func getCurrentTokenHandler(w http.ResponseWriter, r *http.Request) {
-	log.Printf("getCurrentTokenHandler called, but not implemented")
-	respondWithJSON(w, http.StatusOK, map[string]string{"token": "dummy-token"})
+// oauthStateSignature returns the hex HMAC-SHA256 of a state payload. The key is prefixed so a
+// state signature can never be mistaken for one made with the token key elsewhere.
+func oauthStateSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte("clickup-oauth-state:"+secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // Min returns the smaller of x or y
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+)
+
+func TestOAuthStateVerifiesOnAnotherInstance(t *testing.T) {
+	settings := config.ClickUp{TokenEncryptionKey: "shared-key"}
+	issuer := NewClickUpClients(settings, nil)
+	other := NewClickUpClients(settings, nil)
+
+	state, err := issuer.newOAuthState(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, ok := other.verifyOAuthState(state)
+	if !ok || verified.UserID != 7 {
+		t.Fatalf("verifyOAuthState(%q) = %+v, %v, want user 7", state, verified, ok)
+	}
+
+	parts := strings.Split(state, ".")
+	tampered := "8." + strings.Join(parts[1:], ".")
+	otherKey := NewClickUpClients(config.ClickUp{TokenEncryptionKey: "another-key"}, nil)
+	for name, check := range map[string]func() bool{
+		"tampered user": func() bool { _, ok := other.verifyOAuthState(tampered); return ok },
+		"other key":     func() bool { _, ok := otherKey.verifyOAuthState(state); return ok },
+		"empty":         func() bool { _, ok := other.verifyOAuthState(""); return ok },
+		"expired": func() bool {
+			expired := parts[0] + ".1." + parts[2]
+			_, ok := other.verifyOAuthState(expired + "." + oauthStateSignature("shared-key", expired))
+			return ok
+		},
+	} {
+		if check() {
+			t.Errorf("%s: state accepted", name)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// ClickUpWorkspaceResponse is the response format for a connected ClickUp workspace
+type ClickUpWorkspaceResponse struct {
+	TeamID            string    `json:"team_id"`
+	Name              string    `json:"name"`
+	ConnectedByUserID *int32    `json:"connected_by_user_id,omitempty"` // User whose token syncs the workspace
+	Connected         bool      `json:"connected"`                      // Whether the current user has a token for it
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ClickUpListsResponse is the response format for the lists of a ClickUp space
+type ClickUpListsResponse struct {
+	Folders []clickup.Folder `json:"folders"`
+	Lists   []clickup.List   `json:"lists"` // Lists not inside a folder
+}
+
+// getClickUpWorkspaces lists the connected ClickUp workspaces
+func (s *Server) getClickUpWorkspaces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	workspaces, err := s.store.ListClickUpWorkspaces(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching ClickUp workspaces: "+err.Error())
+		return
+	}
+
+	tokens, err := s.store.ListClickUpTokensByUser(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching ClickUp tokens: "+err.Error())
+		return
+	}
+
+	connected := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		connected[token.TeamID] = true
+	}
+
+	response := make([]ClickUpWorkspaceResponse, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		item := ClickUpWorkspaceResponse{
+			TeamID:    workspace.TeamID,
+			Name:      workspace.Name,
+			Connected: connected[workspace.TeamID],
+			CreatedAt: workspace.CreatedAt.Time,
+			UpdatedAt: workspace.UpdatedAt.Time,
+		}
+		if workspace.ConnectedByUserID.Valid {
+			connectedBy := workspace.ConnectedByUserID.Int32
+			item.ConnectedByUserID = &connectedBy
+		}
+		response = append(response, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getClickUpSpaces lists the spaces of a ClickUp workspace
+func (s *Server) getClickUpSpaces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	teamID := mux.Vars(r)["team_id"]
+	client := s.clickUp.ForUser(ctx, s.store, currentUser.ID, teamID)
+	if !client.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	spaces, err := client.GetSpaces(ctx, teamID)
+	if err != nil {
+		respondWithError(w, clickUpErrorStatus(err), "Error fetching ClickUp spaces: "+err.Error())
+		return
+	}
+	if spaces == nil {
+		spaces = []clickup.Space{}
+	}
+
+	respondWithJSON(w, http.StatusOK, spaces)
+}
+
+// getClickUpLists lists the folders and lists of a space in a ClickUp workspace
+func (s *Server) getClickUpLists(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	client := s.clickUp.ForUser(ctx, s.store, currentUser.ID, vars["team_id"])
+	if !client.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "ClickUp integration is disabled")
+		return
+	}
+
+	folders, err := client.GetFolders(ctx, vars["space_id"])
+	if err != nil {
+		respondWithError(w, clickUpErrorStatus(err), "Error fetching ClickUp folders: "+err.Error())
+		return
+	}
+
+	lists, err := client.GetFolderlessLists(ctx, vars["space_id"])
+	if err != nil {
+		respondWithError(w, clickUpErrorStatus(err), "Error fetching ClickUp lists: "+err.Error())
+		return
+	}
+
+	response := ClickUpListsResponse{Folders: folders, Lists: lists}
+	if response.Folders == nil {
+		response.Folders = []clickup.Folder{}
+	}
+	if response.Lists == nil {
+		response.Lists = []clickup.List{}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// alreadyCompressedTypes are the content types whose bodies are compressed already, such as
+// images, archives and pg_dump backups, which compressing again only slows down
+var alreadyCompressedTypes = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/pdf",
+	"application/octet-stream", "application/vnd.openxmlformats",
+}
+
+// CompressionMiddleware compresses responses of at least minSize bytes with gzip or deflate,
+// whichever the request's Accept-Encoding prefers. Smaller responses, those already encoded and
+// already compressed content types are sent as they are.
+func CompressionMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, preferring the higher
+// quality and gzip on a tie, or returns "" when the client accepts neither
+func negotiateEncoding(header string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if name == "*" {
+			name = "gzip"
+		}
+		if name != "gzip" && name != "deflate" || quality <= 0 {
+			continue
+		}
+		if quality > bestQuality || quality == bestQuality && name == "gzip" {
+			best, bestQuality = name, quality
+		}
+	}
+	return best
+}
+
+// compressWriter holds back the status and the first minSize bytes of a response until it
+// knows whether the response is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	pending []byte
+	started bool
+	encoder encoder // nil while holding back and when sending uncompressed
+}
+
+// encoder is a gzip or zlib writer
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if c.started {
+		if c.encoder != nil {
+			return c.encoder.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+
+	c.pending = append(c.pending, p...)
+	if len(c.pending) >= c.minSize {
+		if err := c.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start sends the status and headers and the bytes held back, compressed when compress is set
+// and the response can be
+func (c *compressWriter) start(compress bool) error {
+	c.started = true
+	header := c.Header()
+	if compress && c.compressible(header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", c.encoding)
+		if c.encoding == "gzip" {
+			c.encoder = gzip.NewWriter(c.ResponseWriter)
+		} else {
+			c.encoder = zlib.NewWriter(c.ResponseWriter)
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+	pending := c.pending
+	c.pending = nil
+	if len(pending) == 0 {
+		return nil
+	}
+	var err error
+	if c.encoder != nil {
+		_, err = c.encoder.Write(pending)
+	} else {
+		_, err = c.ResponseWriter.Write(pending)
+	}
+	return err
+}
+
+// compressible reports whether the response has a body that isn't encoded or compressed yet
+func (c *compressWriter) compressible(header http.Header) bool {
+	if c.status < http.StatusOK || c.status == http.StatusNoContent || c.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	// The events of a stream would wait in the encoder
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, compressed := range alreadyCompressedTypes {
+		if strings.HasPrefix(contentType, compressed) {
+			return false
+		}
+	}
+	return true
+}
+
+// Close sends a response that stayed under minSize as it is and finishes a compressed one
+func (c *compressWriter) Close() error {
+	if !c.started {
+		if c.status == 0 {
+			// The handler wrote nothing, leave the implicit 200 to net/http
+			return nil
+		}
+		return c.start(false)
+	}
+	if c.encoder != nil {
+		return c.encoder.Close()
+	}
+	return nil
+}
+
+// FlushError sends what was written so far, the bytes held back included, for streaming handlers
+// flushing through http.ResponseController
+func (c *compressWriter) FlushError() error {
+	if !c.started {
+		if c.status == 0 {
+			c.status = http.StatusOK
+		}
+		if err := c.start(true); err != nil {
+			return err
+		}
+	}
+	if c.encoder != nil {
+		if err := c.encoder.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
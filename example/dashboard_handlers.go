@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+const (
+	// dashboardUnloggedLookback is how many days before today the dashboard looks for days
+	// without logged work
+	dashboardUnloggedLookback = 14
+	// dashboardRecentTasks caps the recent tasks on the dashboard
+	dashboardRecentTasks = 5
+	// dashboardOutTodayLimit caps the leave listed as out today
+	dashboardOutTodayLimit = 100
+)
+
+// DashboardResponse is everything the home page shows, for the current user
+type DashboardResponse struct {
+	Date                  string                         `json:"date"`          // Today, YYYY-MM-DD
+	Balances              *DashboardBalances             `json:"balances"`      // Of this year, null without an annual record
+	AwaitingVotes         []DashboardAwaitingVote        `json:"awaitingVotes"` // Leave and expenses need no approval, so these are what waits on the user
+	OutToday              []DashboardAbsentee            `json:"outToday"`
+	UnloggedDays          []string                       `json:"unloggedDays"` // Working days of the last two weeks without logged work or leave, latest first
+	RecentTasks           []DashboardRecentTask          `json:"recentTasks"`
+	DelegationSuggestions []ApprovalDelegationSuggestion `json:"delegationSuggestions"` // Coming leave to delegate approvals for, when the user approves anyone
+}
+
+// DashboardBalances is what is left of the user's leave and medical expense quotas this year
+type DashboardBalances struct {
+	Year                        int32   `json:"year"`
+	QuotaPlan                   *string `json:"quotaPlan,omitempty"`
+	VacationDays                float64 `json:"vacationDays"` // Quota and rollover
+	UsedVacationDays            float64 `json:"usedVacationDays"`
+	RemainingVacationDays       float64 `json:"remainingVacationDays"`
+	UsedSickLeaveDays           float64 `json:"usedSickLeaveDays"`
+	MedicalExpenseQuotaBaht     float64 `json:"medicalExpenseQuotaBaht"`
+	UsedMedicalExpenseBaht      float64 `json:"usedMedicalExpenseBaht"`
+	RemainingMedicalExpenseBaht float64 `json:"remainingMedicalExpenseBaht"`
+}
+
+// DashboardAwaitingVote is an open estimation session the user hasn't voted in
+type DashboardAwaitingVote struct {
+	SessionID int32     `json:"sessionId"`
+	TaskID    int32     `json:"taskId"`
+	TaskTitle *string   `json:"taskTitle,omitempty"`
+	OpenedAt  time.Time `json:"openedAt"`
+}
+
+// DashboardAbsentee is someone on leave today
+type DashboardAbsentee struct {
+	UserID   int32  `json:"userId"`
+	Username string `json:"username"`
+	Type     string `json:"type"`
+}
+
+// DashboardRecentTask is a task the user was assigned to or logged work on lately
+type DashboardRecentTask struct {
+	ID             int32     `json:"id"`
+	Title          *string   `json:"title,omitempty"`
+	Status         *string   `json:"status,omitempty"`
+	StatusColor    *string   `json:"statusColor,omitempty"`
+	DueDate        *string   `json:"dueDate,omitempty"`
+	Priority       *int32    `json:"priority,omitempty"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+}
+
+// getDashboard handles GET /api/dashboard, gathering what the home page shows in one response
+func (s *Server) getDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	today, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	response := DashboardResponse{Date: today.Format("2006-01-02")}
+
+	balance, err := s.store.GetLeaveBalance(ctx, sqlc.GetLeaveBalanceParams{UserID: currentUser.ID, Year: int32(today.Year())})
+	switch {
+	case err == nil:
+		response.Balances = leaveBalanceToResponse(balance)
+	case !errors.Is(err, pgx.ErrNoRows):
+		respondWithError(w, http.StatusInternalServerError, "Error fetching balances: "+err.Error())
+		return
+	}
+
+	sessions, err := s.store.ListEstimationSessionsAwaitingVote(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching estimation sessions: "+err.Error())
+		return
+	}
+	response.AwaitingVotes = make([]DashboardAwaitingVote, 0, len(sessions))
+	for _, session := range sessions {
+		response.AwaitingVotes = append(response.AwaitingVotes, DashboardAwaitingVote{
+			SessionID: session.ID,
+			TaskID:    session.TaskID,
+			TaskTitle: textPtr(session.TaskTitle),
+			OpenedAt:  session.CreatedAt.Time,
+		})
+	}
+
+	day := pgtype.Date{Time: today, Valid: true}
+	leaveLogs, err := s.store.ListLeaveLogsWithUsername(ctx, sqlc.ListLeaveLogsWithUsernameParams{
+		DateFrom: day,
+		DateTo:   day,
+		SortBy:   "date",
+		RowLimit: dashboardOutTodayLimit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave: "+err.Error())
+		return
+	}
+	response.OutToday = make([]DashboardAbsentee, 0, len(leaveLogs))
+	for _, leaveLog := range leaveLogs {
+		response.OutToday = append(response.OutToday, DashboardAbsentee{
+			UserID:   leaveLog.UserID,
+			Username: leaveLog.Username,
+			Type:     leaveLog.Type,
+		})
+	}
+
+	// Today isn't over, so it doesn't count as unlogged yet
+	unlogged, err := s.store.ListUnloggedDays(ctx, sqlc.ListUnloggedDaysParams{
+		StartDate: pgtype.Date{Time: today.AddDate(0, 0, -dashboardUnloggedLookback), Valid: true},
+		EndDate:   pgtype.Date{Time: today.AddDate(0, 0, -1), Valid: true},
+		UserID:    currentUser.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching unlogged days: "+err.Error())
+		return
+	}
+	response.UnloggedDays = make([]string, 0, len(unlogged))
+	for _, date := range unlogged {
+		response.UnloggedDays = append(response.UnloggedDays, date.Time.Format("2006-01-02"))
+	}
+
+	tasks, err := s.store.ListRecentTasksForUser(ctx, sqlc.ListRecentTasksForUserParams{
+		UserID:   currentUser.ID,
+		RowLimit: dashboardRecentTasks,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching recent tasks: "+err.Error())
+		return
+	}
+	response.RecentTasks = make([]DashboardRecentTask, 0, len(tasks))
+	for _, task := range tasks {
+		row := DashboardRecentTask{
+			ID:             task.ID,
+			Title:          textPtr(task.Title),
+			Status:         textPtr(task.Status),
+			StatusColor:    textPtr(task.StatusColor),
+			Priority:       int4Ptr(task.Priority),
+			LastActivityAt: task.LastActivityAt.Time,
+		}
+		if task.DueDate.Valid {
+			dueDate := task.DueDate.Time.Format("2006-01-02")
+			row.DueDate = &dueDate
+		}
+		response.RecentTasks = append(response.RecentTasks, row)
+	}
+
+	if response.DelegationSuggestions, err = s.delegationSuggestions(ctx, currentUser.ID, today); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching delegations: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func leaveBalanceToResponse(balance sqlc.GetLeaveBalanceRow) *DashboardBalances {
+	vacationDays := numericToFloat64(balance.QuotaVacationDay, 0) + numericToFloat64(balance.RolloverVacationDay, 0)
+	usedVacationDays := numericToFloat64(balance.UsedVacationDay, 0)
+	medicalQuota := numericToFloat64(balance.QuotaMedicalExpenseBaht, 0)
+	usedMedical := numericToFloat64(balance.UsedMedicalExpenseBaht, 0)
+	return &DashboardBalances{
+		Year:                        balance.Year,
+		QuotaPlan:                   textPtr(balance.PlanName),
+		VacationDays:                vacationDays,
+		UsedVacationDays:            usedVacationDays,
+		RemainingVacationDays:       vacationDays - usedVacationDays,
+		UsedSickLeaveDays:           numericToFloat64(balance.UsedSickLeaveDay, 0),
+		MedicalExpenseQuotaBaht:     medicalQuota,
+		UsedMedicalExpenseBaht:      usedMedical,
+		RemainingMedicalExpenseBaht: medicalQuota - usedMedical,
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// delegationSuggestionDays is how many days ahead of today leave is looked at for delegations to
+// suggest
+const delegationSuggestionDays = 60
+
+// Managers who take leave are suggested to delegate their approvals for it. Their leave of the
+// coming weeks that no delegation of theirs covers is grouped into absences, days off with only
+// weekends between them, each suggested with the delegate to pick: whoever they delegated to
+// last, or their own manager. The dashboard shows the suggestions and accepting one is a POST
+// /api/approval-delegations with its dates.
+
+// ApprovalDelegationSuggestion is an absence of the user without a delegation of their approvals
+type ApprovalDelegationSuggestion struct {
+	StartDate        string  `json:"startDate"` // YYYY-MM-DD
+	EndDate          string  `json:"endDate"`
+	LeaveDays        int     `json:"leaveDays"`                  // The days of leave in it, weekends left out
+	DelegateID       *int32  `json:"delegateId,omitempty"`       // None when the user has no one to suggest
+	DelegateUsername *string `json:"delegateUsername,omitempty"` // Of delegateId
+}
+
+// getApprovalDelegationSuggestions handles GET /api/approval-delegations/suggestions, the
+// absences of the current user to delegate their approvals for, none when they approve no one
+func (s *Server) getApprovalDelegationSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	today, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	suggestions, err := s.delegationSuggestions(ctx, currentUser.ID, today)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching delegations: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, suggestions)
+}
+
+// delegationSuggestions returns the absences from today on that a user who approves others has
+// no delegation for, soonest first
+func (s *Server) delegationSuggestions(ctx context.Context, userID int32, today time.Time) ([]ApprovalDelegationSuggestion, error) {
+	suggestions := []ApprovalDelegationSuggestion{}
+	manages, err := s.store.ManagesAnyone(ctx, userID)
+	if err != nil || !manages {
+		return suggestions, err
+	}
+
+	leaveLogs, err := s.store.ListLeaveLogsByDateRange(ctx, sqlc.ListLeaveLogsByDateRangeParams{
+		UserID: userID,
+		Date:   pgtype.Date{Time: today, Valid: true},
+		Date_2: pgtype.Date{Time: today.AddDate(0, 0, delegationSuggestionDays), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	delegations, err := s.store.ListApprovalDelegationsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	delegations = slices.DeleteFunc(delegations, func(d sqlc.ApprovalDelegation) bool {
+		return d.DelegatorID != userID || d.RevokedAt.Valid
+	})
+
+	var days []time.Time
+	for _, leaveLog := range leaveLogs {
+		covered := slices.ContainsFunc(delegations, func(d sqlc.ApprovalDelegation) bool {
+			return !leaveLog.Date.Time.Before(d.StartDate.Time) && !leaveLog.Date.Time.After(d.EndDate.Time)
+		})
+		if !covered && !slices.ContainsFunc(days, leaveLog.Date.Time.Equal) {
+			days = append(days, leaveLog.Date.Time)
+		}
+	}
+	if len(days) == 0 {
+		return suggestions, nil
+	}
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+
+	delegateID, delegateUsername, err := s.suggestedDelegate(ctx, userID, delegations)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		if n := len(suggestions); n > 0 {
+			last := &suggestions[n-1]
+			end, _ := time.Parse(validate.DateLayout, last.EndDate)
+			if onlyWeekendsBetween(end, day) {
+				last.EndDate = day.Format(validate.DateLayout)
+				last.LeaveDays++
+				continue
+			}
+		}
+		suggestions = append(suggestions, ApprovalDelegationSuggestion{
+			StartDate:        day.Format(validate.DateLayout),
+			EndDate:          day.Format(validate.DateLayout),
+			LeaveDays:        1,
+			DelegateID:       delegateID,
+			DelegateUsername: delegateUsername,
+		})
+	}
+	return suggestions, nil
+}
+
+// suggestedDelegate returns who a user delegated to last, of their delegations, or otherwise
+// their manager, nil when neither is a user any more
+func (s *Server) suggestedDelegate(ctx context.Context, userID int32, delegations []sqlc.ApprovalDelegation) (*int32, *string, error) {
+	latest := slices.Clone(delegations)
+	slices.SortStableFunc(latest, func(a, b sqlc.ApprovalDelegation) int {
+		return b.CreatedAt.Time.Compare(a.CreatedAt.Time)
+	})
+	var candidates []int32
+	for _, d := range latest {
+		candidates = append(candidates, d.DelegateID)
+	}
+	assignment, err := s.store.GetOrgAssignment(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	if assignment.ManagerID.Valid {
+		candidates = append(candidates, assignment.ManagerID.Int32)
+	}
+
+	for _, id := range candidates {
+		user, err := s.store.GetUser(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return &user.ID, &user.Username, nil
+	}
+	return nil, nil, nil
+}
+
+// onlyWeekendsBetween reports whether every day after from and before to is a Saturday or Sunday
+func onlyWeekendsBetween(from, to time.Time) bool {
+	for day := from.AddDate(0, 0, 1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			return false
+		}
+	}
+	return true
+}
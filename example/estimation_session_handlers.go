@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Estimation session statuses
+const (
+	estimationSessionOpen   = "open"
+	estimationSessionClosed = "closed"
+)
+
+// EstimationSessionRequest represents the request body for starting an estimation session
+type EstimationSessionRequest struct {
+	ParticipantIDs []int32 `json:"participant_ids"` // The creator always takes part
+}
+
+// EstimationVoteRequest represents a participant's vote, or the agreed estimate when finalizing
+type EstimationVoteRequest struct {
+	EstimateDay float64 `json:"estimate_day" validate:"gt=0"`
+	Note        string  `json:"note"`
+}
+
+// EstimationParticipantResponse is a participant of an estimation session. Vote values
+// stay hidden until every participant has voted.
+type EstimationParticipantResponse struct {
+	UserID      int32      `json:"user_id"`
+	Username    string     `json:"username"`
+	HasVoted    bool       `json:"has_voted"`
+	EstimateDay *float64   `json:"estimate_day,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	VotedAt     *time.Time `json:"voted_at,omitempty"`
+}
+
+// EstimationSessionResponse is the response format for an estimation session
+type EstimationSessionResponse struct {
+	ID              int32                           `json:"id"`
+	TaskID          int32                           `json:"task_id"`
+	Status          string                          `json:"status"`
+	CreatedByUserID int32                           `json:"created_by_user_id"`
+	CreatedAt       time.Time                       `json:"created_at"`
+	ClosedAt        *time.Time                      `json:"closed_at,omitempty"`
+	Revealed        bool                            `json:"revealed"`
+	MinDay          *float64                        `json:"min_day,omitempty"` // Only once revealed
+	MaxDay          *float64                        `json:"max_day,omitempty"`
+	AverageDay      *float64                        `json:"average_day,omitempty"`
+	Participants    []EstimationParticipantResponse `json:"participants,omitempty"`
+}
+
+func (s *Server) createEstimationSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	var req EstimationSessionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	participantIDs := append([]int32{currentUser.ID}, req.ParticipantIDs...)
+	for _, userID := range req.ParticipantIDs {
+		if _, err := s.store.GetUser(ctx, userID); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Participant not found: "+strconv.Itoa(int(userID)))
+			return
+		}
+	}
+
+	var session sqlc.EstimationSession
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		var err error
+		session, err = qtx.CreateEstimationSession(ctx, sqlc.CreateEstimationSessionParams{
+			TaskID:          int32(taskID),
+			CreatedByUserID: currentUser.ID,
+		})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error creating estimation session: "+err.Error())
+		}
+
+		// Duplicates are ignored by the insert
+		for _, userID := range participantIDs {
+			if err := qtx.AddEstimationSessionParticipant(ctx, sqlc.AddEstimationSessionParticipantParams{
+				SessionID: session.ID,
+				UserID:    userID,
+			}); err != nil {
+				return txError(http.StatusInternalServerError, "Error adding participant: "+err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
+		return
+	}
+
+	s.respondWithEstimationSession(ctx, w, http.StatusCreated, session)
+}
+
+func (s *Server) getTaskEstimationSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	sessions, err := s.store.ListEstimationSessionsByTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching estimation sessions: "+err.Error())
+		return
+	}
+
+	// Participants are only listed on the session itself
+	response := make([]EstimationSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, newEstimationSessionResponse(session))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getEstimationSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid estimation session ID")
+		return
+	}
+
+	session, err := s.store.GetEstimationSession(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Estimation session not found")
+		return
+	}
+
+	s.respondWithEstimationSession(ctx, w, http.StatusOK, session)
+}
+
+func (s *Server) voteInEstimationSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid estimation session ID")
+		return
+	}
+
+	var req EstimationVoteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	session, err := s.store.GetEstimationSession(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Estimation session not found")
+		return
+	}
+
+	if session.Status != estimationSessionOpen {
+		respondWithError(w, http.StatusConflict, "Estimation session is closed")
+		return
+	}
+
+	participants, err := s.store.ListEstimationSessionParticipants(ctx, session.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching participants: "+err.Error())
+		return
+	}
+
+	isParticipant := false
+	for _, participant := range participants {
+		if participant.UserID == currentUser.ID {
+			isParticipant = true
+			break
+		}
+	}
+	if !isParticipant {
+		respondWithError(w, http.StatusForbidden, "Only participants can vote in this estimation session")
+		return
+	}
+
+	// Voting again replaces the earlier vote
+	if _, err := s.store.UpsertEstimationVote(ctx, sqlc.UpsertEstimationVoteParams{
+		TaskID:          session.TaskID,
+		EstimateDay:     estimateDayNumeric(req.EstimateDay),
+		Note:            pgtype.Text{String: req.Note, Valid: req.Note != ""},
+		CreatedByUserID: currentUser.ID,
+		SessionID:       pgtype.Int4{Int32: session.ID, Valid: true},
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error recording vote: "+err.Error())
+		return
+	}
+
+	s.respondWithEstimationSession(ctx, w, http.StatusOK, session)
+}
+
+// finalizeEstimationSession records the agreed estimate as the task's current estimate and
+// closes the session. Only the session creator or an admin can finalize.
+func (s *Server) finalizeEstimationSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid estimation session ID")
+		return
+	}
+
+	var req EstimationVoteRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	session, err := s.store.GetEstimationSession(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Estimation session not found")
+		return
+	}
+
+	if session.CreatedByUserID != currentUser.ID && currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only the session creator or an administrator can finalize the session")
+		return
+	}
+
+	participants, err := s.store.ListEstimationSessionParticipants(ctx, session.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching participants: "+err.Error())
+		return
+	}
+
+	if !allEstimationVotesIn(participants) {
+		respondWithError(w, http.StatusConflict, "Not every participant has voted yet")
+		return
+	}
+
+	var estimate sqlc.TaskEstimate
+	var previous *sqlc.TaskEstimate
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		if _, err := qtx.CloseEstimationSession(ctx, session.ID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return txError(http.StatusConflict, "Estimation session is closed")
+			}
+			return txError(http.StatusInternalServerError, "Error closing estimation session: "+err.Error())
+		}
+
+		var err error
+		estimate, previous, err = insertTaskEstimateRevision(ctx, qtx, session.TaskID, currentUser.ID, estimateInDays(req.EstimateDay), req.Note, pgtype.Int4{Int32: session.ID, Valid: true})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error recording agreed estimate: "+err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
+		return
+	}
+
+	s.recordTaskEstimateRevision(ctx, estimate, previous, currentUser.ID)
+
+	respondWithJSON(w, http.StatusCreated, newTaskEstimateResponse(estimate, currentUser.Username))
+}
+
+// respondWithEstimationSession writes a session with its participants, revealing votes
+// once everyone has voted or the session is closed
+func (s *Server) respondWithEstimationSession(ctx context.Context, w http.ResponseWriter, status int, session sqlc.EstimationSession) {
+	participants, err := s.store.ListEstimationSessionParticipants(ctx, session.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching participants: "+err.Error())
+		return
+	}
+
+	response := newEstimationSessionResponse(session)
+	response.Revealed = session.Status == estimationSessionClosed || allEstimationVotesIn(participants)
+	response.Participants = make([]EstimationParticipantResponse, 0, len(participants))
+
+	var total float64
+	var votes int
+	for _, participant := range participants {
+		item := EstimationParticipantResponse{
+			UserID:   participant.UserID,
+			Username: participant.Username,
+			HasVoted: participant.EstimateID.Valid,
+		}
+
+		if item.HasVoted {
+			votedAt := participant.VotedAt.Time
+			item.VotedAt = &votedAt
+
+			if response.Revealed {
+				estimateDay := numericToFloat64(participant.EstimateDay, 0)
+				item.EstimateDay = &estimateDay
+				item.Note = participant.Note.String
+
+				if response.MinDay == nil || estimateDay < *response.MinDay {
+					response.MinDay = &estimateDay
+				}
+				if response.MaxDay == nil || estimateDay > *response.MaxDay {
+					response.MaxDay = &estimateDay
+				}
+				total += estimateDay
+				votes++
+			}
+		}
+
+		response.Participants = append(response.Participants, item)
+	}
+
+	if votes > 0 {
+		average := total / float64(votes)
+		response.AverageDay = &average
+	}
+
+	respondWithJSON(w, status, response)
+}
+
+// newEstimationSessionResponse converts a session without its participants
+func newEstimationSessionResponse(session sqlc.EstimationSession) EstimationSessionResponse {
+	response := EstimationSessionResponse{
+		ID:              session.ID,
+		TaskID:          session.TaskID,
+		Status:          session.Status,
+		CreatedByUserID: session.CreatedByUserID,
+		CreatedAt:       session.CreatedAt.Time,
+		Revealed:        session.Status == estimationSessionClosed,
+	}
+	if session.ClosedAt.Valid {
+		closedAt := session.ClosedAt.Time
+		response.ClosedAt = &closedAt
+	}
+	return response
+}
+
+// estimationVoteRevealed reports whether the session of a vote shows it to everyone, as
+// respondWithEstimationSession does once everyone has voted or the session is closed
+func (s *Server) estimationVoteRevealed(ctx context.Context, vote sqlc.TaskEstimate) (bool, error) {
+	if !vote.SessionID.Valid {
+		return false, nil
+	}
+	session, err := s.store.GetEstimationSession(ctx, vote.SessionID.Int32)
+	if err != nil {
+		return false, err
+	}
+	if session.Status == estimationSessionClosed {
+		return true, nil
+	}
+	participants, err := s.store.ListEstimationSessionParticipants(ctx, session.ID)
+	if err != nil {
+		return false, err
+	}
+	return allEstimationVotesIn(participants), nil
+}
+
+// allEstimationVotesIn reports whether every participant has voted
+func allEstimationVotesIn(participants []sqlc.ListEstimationSessionParticipantsRow) bool {
+	if len(participants) == 0 {
+		return false
+	}
+	for _, participant := range participants {
+		if !participant.EstimateID.Valid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestEstimationSessionLifecycle(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	aliceToken, bobToken := tokenFor(alice.Username), tokenFor(bob.Username)
+
+	var task TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", aliceToken, TaskRequest{Title: "Migrate the payroll export"}), http.StatusCreated, &task)
+
+	var session EstimationSessionResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, fmt.Sprintf("/api/tasks/%d/estimation-sessions", task.ID), aliceToken, EstimationSessionRequest{
+		ParticipantIDs: []int32{bob.ID},
+	}), http.StatusCreated, &session)
+	if session.Status != "open" || len(session.Participants) != 2 {
+		t.Fatalf("session = %+v, want it open with alice and bob", session)
+	}
+	path := fmt.Sprintf("/api/estimation-sessions/%d", session.ID)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path+"/vote", aliceToken, EstimationVoteRequest{EstimateDay: 2}), http.StatusOK, nil)
+	// Changing a vote replaces it
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path+"/vote", aliceToken, EstimationVoteRequest{EstimateDay: 3}), http.StatusOK, nil)
+
+	// Votes stay hidden and the session can't be finalized until everyone has voted
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, bobToken, nil), http.StatusOK, &session)
+	if session.Revealed || session.AverageDay != nil {
+		t.Errorf("session = %+v, want the votes hidden", session)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path+"/finalize", aliceToken, EstimationVoteRequest{EstimateDay: 4}), http.StatusConflict, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path+"/vote", bobToken, EstimationVoteRequest{EstimateDay: 5}), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, bobToken, nil), http.StatusOK, &session)
+	if !session.Revealed || session.AverageDay == nil || *session.AverageDay != 4 {
+		t.Errorf("session = %+v, want the votes revealed averaging 4 days", session)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path+"/finalize", bobToken, EstimationVoteRequest{EstimateDay: 4}), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path+"/finalize", aliceToken, EstimationVoteRequest{EstimateDay: 4}), http.StatusCreated, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path+"/finalize", aliceToken, EstimationVoteRequest{EstimateDay: 4}), http.StatusConflict, nil)
+
+	// The agreed estimate is the task's current one, the votes aren't among its estimates
+	var current TaskEstimateResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/tasks/%d/estimates/current", task.ID), aliceToken, nil), http.StatusOK, &current)
+	if current.EstimateDay != 4 || !current.IsCurrent {
+		t.Errorf("current estimate = %+v, want the agreed 4 days", current)
+	}
+	var estimates []TaskEstimateResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/tasks/%d/estimates", task.ID), aliceToken, nil), http.StatusOK, &estimates)
+	if len(estimates) != 1 {
+		t.Errorf("estimates = %+v, want only the agreed one", estimates)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+// Holidays, quota plans and task categories change a few times a year, yet the SPA loads them on
+// every page. Their listings carry an ETag built from the table's row count and newest
+// updated_at, read with the table's Version query, and a request whose If-None-Match holds it is
+// answered 304 Not Modified with no body. Cache-Control: no-cache has browsers revalidate on each
+// load instead of guessing how long the copy stays fresh, so edits still show up at once.
+
+// notModified sets the ETag of a reference data listing and reports whether the client's copy is
+// current, in which case it has answered 304 and the handler must stop. vary holds anything else
+// the response depends on, like the caller's department; the URL and tenant are always part of it.
+func notModified(w http.ResponseWriter, r *http.Request, count int64, lastUpdated pgtype.Timestamptz, vary ...any) bool {
+	tenantID, _ := db.TenantFromContext(r.Context())
+	key := fmt.Sprintf("%s|%d|%d|%d|%v", r.URL.RequestURI(), tenantID, count, lastUpdated.Time.UnixMicro(), vary)
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if !etagMatches(strings.Join(r.Header.Values("If-None-Match"), ","), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, ignoring W/ as the weak
+// comparison of RFC 9110 does
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// messagingScope is the OAuth scope of sending messages
+const messagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// defaultTokenURI is where access tokens come from when the credentials don't say
+const defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+// maxBodyLength is the most characters of a notification body sent, well within the 4 KB FCM
+// takes for a whole message
+const maxBodyLength = 1000
+
+// Credentials is the JSON key of a Google service account, as downloaded from the Firebase
+// console
+type Credentials struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"` // PEM
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseCredentials reads a service account key
+func ParseCredentials(data []byte) (Credentials, error) {
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return creds, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return creds, errors.New("invalid service account key: client_email and private_key are required")
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = defaultTokenURI
+	}
+	return creds, nil
+}
+
+// Client is an FCM HTTP v1 API client sending messages as a service account
+type Client struct {
+	BaseURL    string // FCM API URL, e.g. https://fcm.googleapis.com
+	ProjectID  string // Firebase project the messages are sent in
+	HTTPClient *http.Client
+
+	credentials Credentials
+	key         *rsa.PrivateKey
+
+	mu                sync.Mutex
+	accessToken       string
+	accessTokenExpiry time.Time
+}
+
+// APIError is returned when FCM, or Google's token endpoint, answers with a non-success status
+type APIError struct {
+	Path       string
+	StatusCode int
+	Status     string // Google's status, such as NOT_FOUND
+	ErrorCode  string // FCM's error code, such as UNREGISTERED
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("fcm %s returned status %d: %s", e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("fcm %s returned status %d", e.Path, e.StatusCode)
+}
+
+// IsPermanent reports whether the same call will fail the same way, such as for a token of an
+// app that was uninstalled, rather than maybe succeed later, such as when rate limited
+func (e *APIError) IsPermanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests
+}
+
+// IsUnregistered reports whether the registration token no longer works, because the app was
+// uninstalled or the token belongs to another project, so it should be forgotten
+func (e *APIError) IsUnregistered() bool {
+	return e.ErrorCode == "UNREGISTERED" || e.ErrorCode == "SENDER_ID_MISMATCH"
+}
+
+// NewClient creates a client for the service account at the given FCM API URL, sending in
+// projectID, or in the service account's project when it is empty
+func NewClient(baseURL, projectID string, credentials Credentials) (*Client, error) {
+	key, err := parsePrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if projectID == "" {
+		projectID = credentials.ProjectID
+	}
+	if projectID == "" {
+		return nil, errors.New("no Firebase project, the service account key has no project_id")
+	}
+	return &Client{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		ProjectID: projectID,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		credentials: credentials,
+		key:         key,
+	}, nil
+}
+
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("invalid service account key: private_key is not PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid service account key: private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Send shows a notification with a title and body on the device of a registration token
+func (c *Client) Send(ctx context.Context, token, title, body string) error {
+	if runes := []rune(body); len(runes) > maxBodyLength {
+		body = string(runes[:maxBodyLength-1]) + "…"
+	}
+	type notification struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	type message struct {
+		Token        string       `json:"token"`
+		Notification notification `json:"notification"`
+	}
+	jsonBody, err := json.Marshal(struct {
+		Message message `json:"message"`
+	}{Message: message{Token: token, Notification: notification{Title: title, Body: body}}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	accessToken, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+	path := "/v1/projects/" + url.PathEscape(c.ProjectID) + "/messages:send"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	httpReq.Header.Set("Content-Type", "application/json")
+	logging.SetRequestIDHeader(ctx, httpReq.Header)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			// The access token was revoked or has expired early, fetch another next time
+			c.mu.Lock()
+			c.accessToken = ""
+			c.mu.Unlock()
+		}
+		var errorBody struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+				Details []struct {
+					ErrorCode string `json:"errorCode"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &errorBody)
+		apiErr := &APIError{Path: path, StatusCode: resp.StatusCode, Status: errorBody.Error.Status, Message: errorBody.Error.Message}
+		for _, detail := range errorBody.Error.Details {
+			if detail.ErrorCode != "" {
+				apiErr.ErrorCode = detail.ErrorCode
+			}
+		}
+		return apiErr
+	}
+	return nil
+}
+
+// token returns an access token of the service account, fetching a new one a minute before the
+// last one expires
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.accessTokenExpiry.Add(-time.Minute)) {
+		return c.accessToken, nil
+	}
+	assertion, err := c.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.credentials.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	var tokenBody struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &tokenBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || tokenBody.AccessToken == "" {
+		message := tokenBody.ErrorDescription
+		if message == "" {
+			message = tokenBody.Error
+		}
+		return "", &APIError{Path: c.credentials.TokenURI, StatusCode: resp.StatusCode, Status: tokenBody.Error, Message: message}
+	}
+
+	c.accessToken = tokenBody.AccessToken
+	c.accessTokenExpiry = time.Now().Add(time.Duration(tokenBody.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+// assertion returns the JWT the service account signs to ask for an access token, valid for an
+// hour from now
+func (c *Client) assertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": c.credentials.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   c.credentials.ClientEmail,
+		"scope": messagingScope,
+		"aud":   c.credentials.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// featureFlagKeyPattern is what flag keys look like, e.g. leave-approvals
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// FeatureFlagRequest is the body of PUT /api/admin/feature-flags/{key}. A flag that is not
+// enabled for everyone is on for the users matching any of the lists.
+type FeatureFlagRequest struct {
+	Description string   `json:"description" validate:"max=1000"`
+	Enabled     bool     `json:"enabled"`
+	UserTypes   []string `json:"userTypes"`
+	Departments []string `json:"departments"`
+	UserIDs     []int32  `json:"userIds"`
+}
+
+// FeatureFlagResponse is a feature flag as the admin API shows it
+type FeatureFlagResponse struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	UserTypes   []string  `json:"userTypes"`
+	Departments []string  `json:"departments"`
+	UserIDs     []int32   `json:"userIds"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CurrentFeatureFlagsResponse is what GET /api/feature-flags returns, for the frontend to show
+// what the caller may use
+type CurrentFeatureFlagsResponse struct {
+	Flags       []string `json:"flags"`       // The keys of the flags that are on for the caller
+	Maintenance bool     `json:"maintenance"` // Writes are held with 503
+}
+
+func featureFlagToResponse(flag sqlc.FeatureFlag) FeatureFlagResponse {
+	return FeatureFlagResponse{
+		Key:         flag.Key,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		UserTypes:   nonNil(flag.UserTypes),
+		Departments: nonNil(flag.Departments),
+		UserIDs:     nonNil(flag.UserIds),
+		UpdatedAt:   flag.UpdatedAt.Time,
+	}
+}
+
+// nonNil returns an empty slice for nil, so it is sent as [] rather than null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// getCurrentFeatureFlags handles GET /api/feature-flags, listing the flags that are on for the
+// caller and whether the server is under maintenance
+func (s *Server) getCurrentFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	user, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	flags, err := s.featureFlags.EnabledFor(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading feature flags: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, CurrentFeatureFlagsResponse{
+		Flags:       flags,
+		Maintenance: s.config.Server.MaintenanceMode || s.featureFlags.Maintenance(r.Context()),
+	})
+}
+
+// listFeatureFlags handles GET /api/admin/feature-flags
+func (s *Server) listFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "manage feature flags") {
+		return
+	}
+	flags, err := s.store.ListFeatureFlags(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing feature flags: "+err.Error())
+		return
+	}
+	response := make([]FeatureFlagResponse, 0, len(flags))
+	for _, flag := range flags {
+		response = append(response, featureFlagToResponse(flag))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// setFeatureFlag handles PUT /api/admin/feature-flags/{key}, creating the flag or replacing its
+// settings. Enabling the maintenance flag holds every write until it is disabled.
+func (s *Server) setFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "manage feature flags") {
+		return
+	}
+	key := mux.Vars(r)["key"]
+	if !featureFlagKeyPattern.MatchString(key) {
+		respondWithError(w, http.StatusBadRequest, "Invalid flag key, use up to 100 lower case letters, digits, dots, dashes and underscores")
+		return
+	}
+	var req FeatureFlagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	flag, err := s.store.SetFeatureFlag(r.Context(), sqlc.SetFeatureFlagParams{
+		Key:         key,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		UserTypes:   nonNil(req.UserTypes),
+		Departments: nonNil(req.Departments),
+		UserIds:     nonNil(req.UserIDs),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving feature flag: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, featureFlagToResponse(flag))
+}
+
+// deleteFeatureFlag handles DELETE /api/admin/feature-flags/{key}, turning the feature off for
+// everyone
+func (s *Server) deleteFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "manage feature flags") {
+		return
+	}
+	deleted, err := s.store.DeleteFeatureFlag(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting feature flag: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Feature flag not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
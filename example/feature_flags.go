@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// featureMaintenance is the flag that holds every write with 503 while it is enabled, such as
+// during a migration. Only its enabled setting counts, it can't be on for some users.
+const featureMaintenance = "maintenance"
+
+// maintenanceRetryAfter is how long clients are told to wait before sending a held write again
+const maintenanceRetryAfter = time.Minute
+
+// FeatureFlags decides which features are on for a user. The flags are read through the store,
+// which caches them like the other reference data.
+type FeatureFlags struct {
+	store db.Store
+}
+
+// NewFeatureFlags creates the feature flags read from store
+func NewFeatureFlags(store db.Store) *FeatureFlags {
+	return &FeatureFlags{store: store}
+}
+
+// Enabled reports whether a flag is on for the user. A flag that doesn't exist is off, and so is
+// one that can't be read, so a new handler stays dark while the database is unreachable.
+func (f *FeatureFlags) Enabled(ctx context.Context, key string, user sqlc.User) bool {
+	flag, err := f.store.GetFeatureFlag(ctx, key)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Error reading feature flag", "flag", key, "error", err)
+		}
+		return false
+	}
+	return flagEnabledFor(flag, user)
+}
+
+// EnabledFor returns the keys of the flags that are on for the user, in key order
+func (f *FeatureFlags) EnabledFor(ctx context.Context, user sqlc.User) ([]string, error) {
+	flags, err := f.store.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, flag := range flags {
+		if flag.Key != featureMaintenance && flagEnabledFor(flag, user) {
+			keys = append(keys, flag.Key)
+		}
+	}
+	return keys, nil
+}
+
+// Maintenance reports whether the maintenance flag holds writes. A flag that can't be read
+// doesn't, MAINTENANCE_MODE is there for when the database is down.
+func (f *FeatureFlags) Maintenance(ctx context.Context) bool {
+	flag, err := f.store.GetFeatureFlag(ctx, featureMaintenance)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.WarnContext(ctx, "Error reading the maintenance flag", "error", err)
+		}
+		return false
+	}
+	return flag.Enabled
+}
+
+// flagEnabledFor reports whether a flag is on for everyone, or for the user's type, department
+// or the user in particular
+func flagEnabledFor(flag sqlc.FeatureFlag, user sqlc.User) bool {
+	return flag.Enabled ||
+		slices.Contains(flag.UserTypes, user.UserType) ||
+		user.Department.Valid && slices.Contains(flag.Departments, user.Department.String) ||
+		slices.Contains(flag.UserIds, user.ID)
+}
+
+// requireFeature serves a route only to the users its flag is on for. To the others the route
+// answers 404, as if it didn't exist, so new handlers can be launched to a few users first.
+func (s *Server) requireFeature(key string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := getCurrentUserFromRequest(s.store, r)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if !s.featureFlags.Enabled(r.Context(), key, user) {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// maintenanceExemptPaths are the writes that go through in maintenance mode, so an admin can
+// still sign in and turn it off
+var maintenanceExemptPaths = []string{"/api/login", "/api/admin/feature-flags"}
+
+// MaintenanceMiddleware answers every POST, PUT, PATCH and DELETE with 503 and a Retry-After
+// header while MAINTENANCE_MODE is set or the maintenance flag is on. Reads still work.
+func (s *Server) MaintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions || maintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.config.Server.MaintenanceMode || s.featureFlags.Maintenance(r.Context()) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+			respondWithError(w, http.StatusServiceUnavailable, "The server is under maintenance, changes can't be saved right now, try again later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func maintenanceExempt(path string) bool {
+	for _, exempt := range maintenanceExemptPaths {
+		if path == exempt || strings.HasPrefix(path, exempt+"/") {
+			return true
+		}
+	}
+	return false
+}
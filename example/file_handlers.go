@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/storage"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// FileResponse is an uploaded file with a URL to download it from
+type FileResponse struct {
+	ID           int32      `json:"id"`
+	Purpose      string     `json:"purpose"`
+	SubjectID    int32      `json:"subjectId"` // The medical expense, leave log or user, by purpose
+	OwnerID      *int32     `json:"ownerId,omitempty"`
+	FileName     string     `json:"fileName"`
+	ContentType  string     `json:"contentType"`
+	Size         int64      `json:"size"`
+	Sha256       string     `json:"sha256"`
+	ScannedAt    *time.Time `json:"scannedAt,omitempty"` // Left out when no virus scanner is set up
+	CreatedAt    time.Time  `json:"createdAt"`
+	URL          string     `json:"url"`
+	URLExpiresAt time.Time  `json:"urlExpiresAt"`
+}
+
+func (s *Server) fileToResponse(file sqlc.File) (FileResponse, error) {
+	url, expires, err := s.fileURL(file)
+	return FileResponse{
+		ID:           file.ID,
+		Purpose:      file.Purpose,
+		SubjectID:    file.SubjectID,
+		OwnerID:      int4Ptr(file.OwnerID),
+		FileName:     file.FileName,
+		ContentType:  file.ContentType,
+		Size:         file.Size,
+		Sha256:       file.Sha256,
+		ScannedAt:    timestamptzPtr(file.ScannedAt),
+		CreatedAt:    file.CreatedAt.Time,
+		URL:          url,
+		URLExpiresAt: expires,
+	}, err
+}
+
+// uploadFile handles POST /api/files, a multipart form with the purpose, the subjectId and the
+// file. Users upload files of their own expenses, leave and avatar, admins anyone's. A new avatar
+// replaces the old one.
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Leave room for the form fields around the file
+	maxSize := int64(s.config.Files.MaxSize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is larger than "+strconv.FormatInt(maxSize, 10)+" bytes")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Invalid multipart form: "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	errs := validate.Errors{}
+	purpose := r.FormValue("purpose")
+	if _, ok := fileContentTypes[purpose]; !ok {
+		errs.Add("purpose", "must be medical_receipt, sick_leave_certificate or avatar")
+	}
+	subjectID, err := strconv.Atoi(r.FormValue("subjectId"))
+	if err != nil || subjectID <= 0 {
+		errs.Add("subjectId", "must be the ID of the medical expense, leave log or user")
+	}
+	upload, header, err := r.FormFile("file")
+	if err != nil {
+		errs.Add("file", "is required")
+	}
+	if err := errs.Err(); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	defer upload.Close()
+	if header.Size > maxSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is larger than "+strconv.FormatInt(maxSize, 10)+" bytes")
+		return
+	}
+
+	ownerID, err := s.fileSubjectOwner(ctx, purpose, int32(subjectID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, fileSubjects[purpose]+" not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching what the file belongs to: "+err.Error())
+		return
+	}
+	if currentUser.UserType != "admin" && ownerID != currentUser.ID {
+		respondWithError(w, http.StatusForbidden, "You can only upload files of your own expenses, leave and avatar")
+		return
+	}
+	if purpose == filePurposeSickLeaveCertificate {
+		leaveLog, err := s.store.GetLeaveLog(ctx, int32(subjectID))
+		if err == nil && leaveLog.Type != "sick" {
+			respondWithValidationError(w, validate.Errors{"subjectId": "must be sick leave, certificates are for sick leave only"})
+			return
+		}
+	}
+
+	// Check what the file is by its content, hashing it on the way
+	var head [512]byte
+	n, _ := io.ReadFull(upload, head[:])
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !slices.Contains(fileContentTypes[purpose], contentType) {
+		respondWithValidationError(w, validate.Errors{"file": "must be one of " + strings.Join(fileContentTypes[purpose], ", ") + ", not " + contentType})
+		return
+	}
+	if _, err := upload.Seek(0, io.SeekStart); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading the file: "+err.Error())
+		return
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, upload); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading the file: "+err.Error())
+		return
+	}
+
+	var scannedAt pgtype.Timestamptz
+	if s.fileScanner != nil {
+		if _, err := upload.Seek(0, io.SeekStart); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error reading the file: "+err.Error())
+			return
+		}
+		err := s.fileScanner.Scan(ctx, upload)
+		if errors.Is(err, storage.ErrInfected) {
+			slog.WarnContext(ctx, "Infected upload refused", "user_id", currentUser.ID, "file_name", header.Filename, "error", err)
+			respondWithValidationError(w, validate.Errors{"file": "holds a virus"})
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusServiceUnavailable, "Error scanning the file for viruses: "+err.Error())
+			return
+		}
+		scannedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+
+	if _, err := upload.Seek(0, io.SeekStart); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading the file: "+err.Error())
+		return
+	}
+	key := storage.NewKey(purpose, time.Now())
+	if err := s.files.Put(ctx, key, upload, header.Size, contentType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing the file: "+err.Error())
+		return
+	}
+
+	file, err := s.store.CreateFile(ctx, sqlc.CreateFileParams{
+		OwnerID:     pgtype.Int4{Int32: currentUser.ID, Valid: true},
+		Purpose:     purpose,
+		SubjectID:   int32(subjectID),
+		StorageKey:  key,
+		FileName:    uploadFileName(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Sha256:      hex.EncodeToString(hash.Sum(nil)),
+		ScannedAt:   scannedAt,
+	})
+	if err != nil {
+		_ = s.files.Delete(ctx, key)
+		respondWithError(w, http.StatusInternalServerError, "Error saving the file: "+err.Error())
+		return
+	}
+
+	if purpose == filePurposeAvatar {
+		s.removeReplacedAvatars(ctx, file)
+	}
+
+	response, err := s.fileToResponse(file)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error signing the download URL: "+err.Error())
+		return
+	}
+	w.Header().Set("Location", "/api/files/"+strconv.Itoa(int(file.ID)))
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// removeReplacedAvatars deletes the avatars of a user older than the one just uploaded. Like the
+// other side effects of a write, a failure is only logged, the file-cleanup job won't retry it.
+func (s *Server) removeReplacedAvatars(ctx context.Context, avatar sqlc.File) {
+	files, err := s.store.ListFilesBySubject(ctx, sqlc.ListFilesBySubjectParams{Purpose: filePurposeAvatar, SubjectID: avatar.SubjectID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing the replaced avatars", "user_id", avatar.SubjectID, "error", err)
+		return
+	}
+	for _, file := range files {
+		if file.ID == avatar.ID {
+			continue
+		}
+		if err := s.removeFile(ctx, file); err != nil {
+			slog.ErrorContext(ctx, "Error deleting a replaced avatar", "file_id", file.ID, "error", err)
+		}
+	}
+}
+
+// uploadFileName keeps the base name of an uploaded file, as much of it as the column holds
+func uploadFileName(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	name = strings.ToValidUTF8(name, "")
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// getFiles handles GET /api/files?purpose=&subjectId=, the files of a medical expense, leave log
+// or user, oldest first
+func (s *Server) getFiles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	purpose := r.URL.Query().Get("purpose")
+	subjectID, err := strconv.Atoi(r.URL.Query().Get("subjectId"))
+	if _, ok := fileContentTypes[purpose]; !ok || err != nil {
+		respondWithError(w, http.StatusBadRequest, "purpose and subjectId are required")
+		return
+	}
+
+	mayRead, err := s.mayReadSubjectFiles(ctx, currentUser, purpose, int32(subjectID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error checking access to the files: "+err.Error())
+		return
+	}
+	files, err := s.store.ListFilesBySubject(ctx, sqlc.ListFilesBySubjectParams{Purpose: purpose, SubjectID: int32(subjectID)})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching files: "+err.Error())
+		return
+	}
+	response := []FileResponse{}
+	for _, file := range files {
+		// Without access to the subject users still see the files they uploaded
+		if !mayRead && !(file.OwnerID.Valid && file.OwnerID.Int32 == currentUser.ID) {
+			continue
+		}
+		fileResponse, err := s.fileToResponse(file)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error signing the download URL: "+err.Error())
+			return
+		}
+		response = append(response, fileResponse)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getFile handles GET /api/files/{id}
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request) {
+	file, _, ok := s.fileFromPath(w, r)
+	if !ok {
+		return
+	}
+	response, err := s.fileToResponse(file)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error signing the download URL: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// deleteFile handles DELETE /api/files/{id}, for whoever uploaded the file, the owner of what it
+// belongs to and admins
+func (s *Server) deleteFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	file, currentUser, ok := s.fileFromPath(w, r)
+	if !ok {
+		return
+	}
+	mayDelete := currentUser.UserType == "admin" || file.OwnerID.Valid && file.OwnerID.Int32 == currentUser.ID
+	if !mayDelete {
+		ownerID, err := s.fileSubjectOwner(ctx, file.Purpose, file.SubjectID)
+		mayDelete = err == nil && ownerID == currentUser.ID
+	}
+	if !mayDelete {
+		respondWithError(w, http.StatusForbidden, "You can only delete your own files")
+		return
+	}
+	if err := s.removeFile(ctx, file); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting the file: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getFileContent handles GET /api/files/{id}/content, the download URL of files kept in a local
+// directory. The signature in the URL stands in for the bearer token, so <img> and links work.
+func (s *Server) getFileContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid file ID")
+		return
+	}
+	query := r.URL.Query()
+	if !s.validFileSignature(int32(id), query.Get("expires"), query.Get("signature")) {
+		respondWithError(w, http.StatusForbidden, "The download link is invalid or expired")
+		return
+	}
+	file, err := s.store.GetFile(ctx, int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching file: "+err.Error())
+		return
+	}
+	content, err := s.files.Get(ctx, file.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading file: "+err.Error())
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": file.FileName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(s.config.Files.URLExpiry.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		slog.WarnContext(ctx, "Error sending file", "file_id", file.ID, "error", err)
+	}
+}
+
+// getUserAvatar handles GET /api/users/{id}/avatar, redirecting to the download URL of the
+// user's avatar
+func (s *Server) getUserAvatar(w http.ResponseWriter, r *http.Request) {
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	files, err := s.store.ListFilesBySubject(r.Context(), sqlc.ListFilesBySubjectParams{Purpose: filePurposeAvatar, SubjectID: int32(id)})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching avatar: "+err.Error())
+		return
+	}
+	if len(files) == 0 {
+		respondWithError(w, http.StatusNotFound, "The user has no avatar")
+		return
+	}
+	url, _, err := s.fileURL(files[len(files)-1])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error signing the download URL: "+err.Error())
+		return
+	}
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// fileFromPath returns the file whose ID is in the path when the current user may read it,
+// answering 401, 400 or 404 otherwise
+func (s *Server) fileFromPath(w http.ResponseWriter, r *http.Request) (sqlc.File, sqlc.User, bool) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return sqlc.File{}, currentUser, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid file ID")
+		return sqlc.File{}, currentUser, false
+	}
+	file, err := s.store.GetFile(ctx, int32(id))
+	if err == nil {
+		var mayRead bool
+		if mayRead, err = s.mayReadFile(ctx, currentUser, file); err == nil && !mayRead {
+			err = pgx.ErrNoRows
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "File not found")
+		return file, currentUser, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching file: "+err.Error())
+		return file, currentUser, false
+	}
+	return file, currentUser, true
+}
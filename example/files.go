@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+// Users upload medical receipts, sick leave certificates and avatars to /api/files. A file is
+// stored in FILES_LOCATION once FILE_SCAN_COMMAND found no virus in it, and a row in files says
+// who uploaded it and which expense, leave log or user it belongs to. Clients download files
+// from URLs that expire after FILE_URL_EXPIRY: the bucket's own presigned URLs with S3, or
+// /api/files/{id}/content signed by the server with a local directory. The file-cleanup job
+// deletes the files whose expense, leave log or user is gone.
+
+const (
+	filePurposeMedicalReceipt       = "medical_receipt"
+	filePurposeSickLeaveCertificate = "sick_leave_certificate"
+	filePurposeAvatar               = "avatar"
+)
+
+// fileContentTypes are the types each purpose accepts, sniffed from the file rather than taken
+// from the client. Serving nothing but images and PDFs keeps uploads from running scripts.
+var fileContentTypes = map[string][]string{
+	filePurposeMedicalReceipt:       {"application/pdf", "image/jpeg", "image/png"},
+	filePurposeSickLeaveCertificate: {"application/pdf", "image/jpeg", "image/png"},
+	filePurposeAvatar:               {"image/jpeg", "image/png", "image/webp"},
+}
+
+// fileSubjects names what the files of each purpose belong to
+var fileSubjects = map[string]string{
+	filePurposeMedicalReceipt:       "Medical expense",
+	filePurposeSickLeaveCertificate: "Leave log",
+	filePurposeAvatar:               "User",
+}
+
+// fileApprovalKinds are the approvals whose approvers may read a purpose's files, to check the
+// receipt or certificate before they decide
+var fileApprovalKinds = map[string]string{
+	filePurposeMedicalReceipt:       approval.KindMedicalExpense,
+	filePurposeSickLeaveCertificate: approval.KindLeave,
+}
+
+// setUpFileStorage opens FILES_LOCATION and the virus scanner. Without FILE_URL_SECRET download
+// URLs are signed with a random secret, which only this instance knows until it restarts.
+func (s *Server) setUpFileStorage() {
+	files, err := storage.Open(s.config.Files.Location, storage.S3Config(s.config.AWS))
+	if err != nil {
+		fatal("Error opening the file storage", "error", err)
+	}
+	s.files = files
+	if scanner := storage.NewCommandScanner(s.config.Files.ScanCommand); scanner != nil {
+		s.fileScanner = scanner
+	} else {
+		slog.Info("Uploaded files are not scanned for viruses, FILE_SCAN_COMMAND is not set")
+	}
+
+	s.fileURLSecret = []byte(s.config.Files.URLSecret)
+	if _, presigns := files.(storage.Presigner); len(s.fileURLSecret) == 0 && !presigns {
+		s.fileURLSecret = make([]byte, 32)
+		_, _ = rand.Read(s.fileURLSecret)
+		slog.Warn("Signing file download URLs with a generated secret, set FILE_URL_SECRET for URLs that work across instances and restarts")
+	}
+}
+
+// fileSubjectOwner returns the user a file's medical expense, leave log or avatar belongs to,
+// pgx.ErrNoRows when it doesn't exist
+func (s *Server) fileSubjectOwner(ctx context.Context, purpose string, subjectID int32) (int32, error) {
+	switch purpose {
+	case filePurposeMedicalReceipt:
+		expense, err := s.store.GetMedicalExpense(ctx, subjectID)
+		return expense.UserID, err
+	case filePurposeSickLeaveCertificate:
+		leaveLog, err := s.store.GetLeaveLog(ctx, subjectID)
+		return leaveLog.UserID, err
+	case filePurposeAvatar:
+		user, err := s.store.GetUser(ctx, subjectID)
+		return user.ID, err
+	}
+	return 0, pgx.ErrNoRows
+}
+
+// mayReadSubjectFiles reports whether user may read the files of a subject: admins and the
+// subject's owner may, anyone may see avatars, and whoever can decide the pending approval of an
+// expense or leave may see its receipts or certificates
+func (s *Server) mayReadSubjectFiles(ctx context.Context, user sqlc.User, purpose string, subjectID int32) (bool, error) {
+	if user.UserType == "admin" || purpose == filePurposeAvatar {
+		return true, nil
+	}
+	ownerID, err := s.fileSubjectOwner(ctx, purpose, subjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil || ownerID == user.ID {
+		return err == nil, err
+	}
+
+	kind, ok := fileApprovalKinds[purpose]
+	if !ok {
+		return false, nil
+	}
+	request, err := s.store.GetPendingApprovalRequestBySubject(ctx, sqlc.GetPendingApprovalRequestBySubjectParams{Kind: kind, SubjectID: subjectID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	today, err := s.userToday(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	_, ok, err = approval.CanDecide(ctx, s.store, request, user, today)
+	return ok, err
+}
+
+// mayReadFile reports whether user may read a file, theirs or one of a subject they may see
+func (s *Server) mayReadFile(ctx context.Context, user sqlc.User, file sqlc.File) (bool, error) {
+	if file.OwnerID.Valid && file.OwnerID.Int32 == user.ID {
+		return true, nil
+	}
+	return s.mayReadSubjectFiles(ctx, user, file.Purpose, file.SubjectID)
+}
+
+// fileURL returns where a file downloads from and until when
+func (s *Server) fileURL(file sqlc.File) (string, time.Time, error) {
+	expiry := s.config.Files.URLExpiry
+	expires := time.Now().Add(expiry)
+	if presigner, ok := s.files.(storage.Presigner); ok {
+		url, err := presigner.PresignGet(file.StorageKey, file.FileName, expiry)
+		return url, expires, err
+	}
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	return fmt.Sprintf("/api/files/%d/content?expires=%s&signature=%s", file.ID, unix, s.fileSignature(file.ID, unix)), expires, nil
+}
+
+// fileSignature returns the hex HMAC-SHA256 of a file's download URL expiring at expires, a Unix
+// time
+func (s *Server) fileSignature(id int32, expires string) string {
+	mac := hmac.New(sha256.New, s.fileURLSecret)
+	mac.Write([]byte(strconv.Itoa(int(id)) + "." + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validFileSignature reports whether a download URL of a file was signed by the server and hasn't
+// expired
+func (s *Server) validFileSignature(id int32, expires, signature string) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	expected := s.fileSignature(id, expires)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// removeFile deletes a stored file and then its row, so a failure leaves a row to retry with
+// rather than a file nothing points to
+func (s *Server) removeFile(ctx context.Context, file sqlc.File) error {
+	if err := s.files.Delete(ctx, file.StorageKey); err != nil {
+		return fmt.Errorf("deleting %s from %s: %w", file.StorageKey, s.files, err)
+	}
+	return s.store.DeleteFile(ctx, file.ID)
+}
+
+// scheduleFileCleanup deletes the files whose medical expense, leave log or user is gone every
+// day
+func (s *Server) scheduleFileCleanup() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "file-cleanup",
+		Description: "Delete the uploaded files whose medical expense, leave log or user is gone",
+		Schedule:    scheduler.MustParse("15 4 * * *"),
+		Run: func(ctx context.Context) error {
+			// The query spans every tenant
+			ctx = db.WithAllTenants(ctx)
+			deleted := 0
+			for {
+				files, err := s.store.ListOrphanedFiles(ctx, 100)
+				if err != nil {
+					return err
+				}
+				for _, file := range files {
+					if err := s.removeFile(ctx, file); err != nil {
+						return err
+					}
+					deleted++
+				}
+				if len(files) < 100 {
+					break
+				}
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Orphaned files deleted", "files", deleted)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/logging"
+	tablegv1 "github.com/kengtableg/pkeng-tableg/proto/tableg/v1"
+)
+
+// The gRPC services of proto/tableg/v1/tableg.proto, served on GRPC_PORT for internal
+// integrations such as payroll. They read what the HTTP API does, for admins only, from the
+// messages and service stubs generated into proto/tableg/v1 with go generate.
+
+const (
+	// grpcDefaultPageSize is the page size of listings that are asked for none
+	grpcDefaultPageSize = 1000
+	// grpcMaxPageSize caps the page size of listings
+	grpcMaxPageSize = 10000
+)
+
+// GRPCServer returns the server of GRPC_PORT: the gRPC services, run in the tenant of the call
+// like the HTTP API and only for admins
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	var tenants *TenantMiddleware
+	if s.config.Database.MultiTenant {
+		tenants = NewTenantMiddleware(s.store)
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(grpcLogging, grpcRecovery, s.grpcAuthorize(tenants)))
+	server := grpc.NewServer(opts...)
+	tablegv1.RegisterUsersServer(server, &grpcUsers{s: s})
+	tablegv1.RegisterLeaveServer(server, &grpcLeave{s: s})
+	tablegv1.RegisterTaskLogsServer(server, &grpcTaskLogs{s: s})
+	tablegv1.RegisterBalancesServer(server, &grpcBalances{s: s})
+	return server
+}
+
+// grpcLogging gives every call a request ID, from the x-request-id metadata when a proxy sets
+// one, and logs it once it's served like LoggingMiddleware does requests
+func grpcLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	requestID := ""
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDHeader); len(values) > 0 {
+		requestID = values[0]
+	}
+	if !validRequestID.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	resp, err := handler(ctx, req)
+	err = grpcError(err)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "Call served", "method", info.FullMethod, "code", code.String(), "duration", time.Since(start))
+	return resp, err
+}
+
+// grpcError returns the status a method's error is answered with. Errors without one are
+// Internal, with their text like the HTTP API's 500s, unless the call was cancelled or ran out
+// of time.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// grpcRecovery turns a panic in a method into an Internal status rather than a crash
+func grpcRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "Panic in gRPC method", "method", info.FullMethod, "panic", recovered, "stack", string(debug.Stack()))
+			resp, err = nil, status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// grpcAuthorize runs a call in the tenant named by its x-tenant metadata or authority when there
+// are tenants, and lets only admins make it
+func (s *Server) grpcAuthorize(tenants *TenantMiddleware) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		first := func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		if tenants != nil {
+			slug := tenantSlugOf(first(tenantHeader), first(":authority"))
+			tenantID, err := tenants.resolve(ctx, slug)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, status.Errorf(codes.NotFound, "unknown tenant %s", slug)
+			}
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "resolving tenant: %v", err)
+			}
+			ctx = db.WithTenant(ctx, tenantID)
+		}
+
+		currentUser, err := getCurrentUserFromAuthorization(ctx, s.store, first("authorization"))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		if currentUser.UserType != "admin" {
+			return nil, status.Error(codes.PermissionDenied, "only admins can call the gRPC services")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// grpcUsers serves tableg.v1.Users
+type grpcUsers struct {
+	tablegv1.UnimplementedUsersServer
+	s *Server
+}
+
+func (g *grpcUsers) GetUser(ctx context.Context, request *tablegv1.GetUserRequest) (*tablegv1.User, error) {
+	user, err := g.s.store.GetUser(ctx, request.GetId())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "user %d not found", request.GetId())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+func (g *grpcUsers) ListUsers(ctx context.Context, request *tablegv1.ListUsersRequest) (*tablegv1.ListUsersResponse, error) {
+	pageSize, offset, err := grpcPage(request.GetPageSize(), request.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	users, err := g.s.store.ListUsers(ctx, sqlc.ListUsersParams{RowLimit: pageSize, RowOffset: offset})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &tablegv1.ListUsersResponse{NextOffset: grpcNextOffset(len(users), pageSize, offset)}
+	for _, user := range users {
+		response.Users = append(response.Users, userMessage(user))
+	}
+	return response, nil
+}
+
+// grpcLeave serves tableg.v1.Leave
+type grpcLeave struct {
+	tablegv1.UnimplementedLeaveServer
+	s *Server
+}
+
+func (g *grpcLeave) ListLeave(ctx context.Context, request *tablegv1.ListLeaveRequest) (*tablegv1.ListLeaveResponse, error) {
+	from, to, err := grpcPeriod(request.GetFrom(), request.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	pageSize, offset, err := grpcPage(request.GetPageSize(), request.GetOffset())
+	if err != nil {
+		return nil, err
+	}
+	params := sqlc.ListLeaveLogsWithUsernameParams{
+		DateFrom:  from,
+		DateTo:    to,
+		SortBy:    "date",
+		SortDesc:  true,
+		RowLimit:  pageSize,
+		RowOffset: offset,
+	}
+	if userID := request.GetUserId(); userID != 0 {
+		params.UserID = pgtype.Int4{Int32: userID, Valid: true}
+	}
+	if leaveType := request.GetType(); leaveType != "" {
+		params.Type = pgtype.Text{String: leaveType, Valid: true}
+	}
+	leaveLogs, err := g.s.store.ListLeaveLogsWithUsername(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &tablegv1.ListLeaveResponse{NextOffset: grpcNextOffset(len(leaveLogs), pageSize, offset)}
+	for _, leaveLog := range leaveLogs {
+		response.Leave = append(response.Leave, &tablegv1.LeaveLog{
+			Id:       leaveLog.ID,
+			UserId:   leaveLog.UserID,
+			Username: leaveLog.Username,
+			Type:     leaveLog.Type,
+			Date:     leaveLog.Date.Time.Format("2006-01-02"),
+			Note:     leaveLog.Note.String,
+		})
+	}
+	return response, nil
+}
+
+// grpcTaskLogs serves tableg.v1.TaskLogs
+type grpcTaskLogs struct {
+	tablegv1.UnimplementedTaskLogsServer
+	s *Server
+}
+
+func (g *grpcTaskLogs) ListTaskLogs(ctx context.Context, request *tablegv1.ListTaskLogsRequest) (*tablegv1.ListTaskLogsResponse, error) {
+	from, to, err := grpcPeriod(request.GetFrom(), request.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	var taskLogs []sqlc.TaskLog
+	if userID := request.GetUserId(); userID != 0 {
+		taskLogs, err = g.s.store.ListTaskLogsByUserAndDateRange(ctx, sqlc.ListTaskLogsByUserAndDateRangeParams{
+			CreatedByUserID: userID,
+			WorkedDate:      from,
+			WorkedDate_2:    to,
+		})
+	} else {
+		taskLogs, err = g.s.store.ListTaskLogsByDateRange(ctx, sqlc.ListTaskLogsByDateRangeParams{
+			WorkedDate:   from,
+			WorkedDate_2: to,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &tablegv1.ListTaskLogsResponse{}
+	for _, taskLog := range taskLogs {
+		response.TaskLogs = append(response.TaskLogs, &tablegv1.TaskLog{
+			Id:            taskLog.ID,
+			TaskId:        taskLog.TaskID,
+			UserId:        taskLog.CreatedByUserID,
+			Date:          taskLog.WorkedDate.Time.Format("2006-01-02"),
+			WorkedDays:    numericToFloat64(taskLog.WorkedDay, 0),
+			WorkOnHoliday: taskLog.IsWorkOnHoliday.Bool,
+		})
+	}
+	return response, nil
+}
+
+// grpcBalances serves tableg.v1.Balances
+type grpcBalances struct {
+	tablegv1.UnimplementedBalancesServer
+	s *Server
+}
+
+func (g *grpcBalances) GetBalance(ctx context.Context, request *tablegv1.GetBalanceRequest) (*tablegv1.Balance, error) {
+	year := g.s.grpcYear(request.GetYear())
+	user, err := g.s.store.GetUser(ctx, request.GetUserId())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "user %d not found", request.GetUserId())
+	}
+	if err != nil {
+		return nil, err
+	}
+	balance, err := g.s.store.GetLeaveBalance(ctx, sqlc.GetLeaveBalanceParams{UserID: user.ID, Year: year})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "user %d has no annual record for %d", user.ID, year)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return balanceMessage(user.ID, user.Username, leaveBalanceToResponse(balance)), nil
+}
+
+func (g *grpcBalances) ListBalances(ctx context.Context, request *tablegv1.ListBalancesRequest) (*tablegv1.ListBalancesResponse, error) {
+	balances, err := g.s.store.ListLeaveBalancesByYear(ctx, g.s.grpcYear(request.GetYear()))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &tablegv1.ListBalancesResponse{}
+	for _, balance := range balances {
+		response.Balances = append(response.Balances, balanceMessage(balance.UserID, balance.Username, leaveBalanceToResponse(sqlc.GetLeaveBalanceRow{
+			Year:                    balance.Year,
+			PlanName:                balance.PlanName,
+			QuotaVacationDay:        balance.QuotaVacationDay,
+			RolloverVacationDay:     balance.RolloverVacationDay,
+			UsedVacationDay:         balance.UsedVacationDay,
+			UsedSickLeaveDay:        balance.UsedSickLeaveDay,
+			QuotaMedicalExpenseBaht: balance.QuotaMedicalExpenseBaht,
+			UsedMedicalExpenseBaht:  balance.UsedMedicalExpenseBaht,
+		})))
+	}
+	return response, nil
+}
+
+// userMessage returns the tableg.v1.User of a user
+func userMessage(user sqlc.User) *tablegv1.User {
+	return &tablegv1.User{
+		Id:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		UserType:      user.UserType,
+		Department:    user.Department.String,
+		DailyCapacity: numericToFloat64(user.DailyCapacity, 1),
+	}
+}
+
+// balanceMessage returns the tableg.v1.Balance of the balances the dashboard shows
+func balanceMessage(userID int32, username string, balance *DashboardBalances) *tablegv1.Balance {
+	message := &tablegv1.Balance{
+		UserId:                      userID,
+		Username:                    username,
+		Year:                        balance.Year,
+		VacationDays:                balance.VacationDays,
+		UsedVacationDays:            balance.UsedVacationDays,
+		RemainingVacationDays:       balance.RemainingVacationDays,
+		UsedSickLeaveDays:           balance.UsedSickLeaveDays,
+		MedicalExpenseQuotaBaht:     balance.MedicalExpenseQuotaBaht,
+		UsedMedicalExpenseBaht:      balance.UsedMedicalExpenseBaht,
+		RemainingMedicalExpenseBaht: balance.RemainingMedicalExpenseBaht,
+	}
+	if balance.QuotaPlan != nil {
+		message.QuotaPlan = *balance.QuotaPlan
+	}
+	return message
+}
+
+// grpcPeriod reads the required from and to dates of a request
+func grpcPeriod(fromDate, toDate string) (pgtype.Date, pgtype.Date, error) {
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		return pgtype.Date{}, pgtype.Date{}, status.Error(codes.InvalidArgument, "invalid from date (should be YYYY-MM-DD)")
+	}
+	to, err := time.Parse("2006-01-02", toDate)
+	if err != nil {
+		return pgtype.Date{}, pgtype.Date{}, status.Error(codes.InvalidArgument, "invalid to date (should be YYYY-MM-DD)")
+	}
+	if to.Before(from) {
+		return pgtype.Date{}, pgtype.Date{}, status.Error(codes.InvalidArgument, "to must not be before from")
+	}
+	return pgtype.Date{Time: from, Valid: true}, pgtype.Date{Time: to, Valid: true}, nil
+}
+
+// grpcPage checks the page size and offset of a listing
+func grpcPage(pageSize, offset int32) (int32, int32, error) {
+	if pageSize < 0 || pageSize > grpcMaxPageSize {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", grpcMaxPageSize)
+	}
+	if offset < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = grpcDefaultPageSize
+	}
+	return pageSize, offset, nil
+}
+
+// grpcNextOffset is the offset of the page after one of n rows, 0 when it was the last
+func grpcNextOffset(n int, pageSize, offset int32) int32 {
+	if int32(n) < pageSize {
+		return 0
+	}
+	return offset + pageSize
+}
+
+// grpcYear returns the year of a request, the company's current year when it is 0
+func (s *Server) grpcYear(year int32) int32 {
+	if year != 0 {
+		return year
+	}
+	return int32(s.thisYear())
+}
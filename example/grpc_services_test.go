@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	tablegv1 "github.com/kengtableg/pkeng-tableg/proto/tableg/v1"
+)
+
+// grpcTestClient calls the gRPC services of a test server with grpc-go, sending and receiving the
+// messages of proto/tableg/v1/tableg.proto
+type grpcTestClient struct {
+	t    *testing.T
+	conn *grpc.ClientConn
+	file protoreflect.FileDescriptor
+}
+
+// newGRPCTestServer returns the API of a server keeping its data in a dbtest.Fake, the fake, and
+// a client of its gRPC services, served without TLS like GRPC_PORT
+func newGRPCTestServer(t *testing.T) (http.Handler, *dbtest.Fake, *grpcTestClient) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Files.Location = t.TempDir()
+	store := dbtest.NewFake()
+	server := NewServer(cfg, store, nil, nil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := server.GRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///"+listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return server.Handler(), store, &grpcTestClient{t: t, conn: conn, file: tablegv1.File_tableg_v1_tableg_proto}
+}
+
+// method returns the descriptor of a method of a tableg.v1 service, like Users/GetUser
+func (c *grpcTestClient) method(name string) protoreflect.MethodDescriptor {
+	c.t.Helper()
+	serviceName, methodName, _ := strings.Cut(name, "/")
+	service := c.file.Services().ByName(protoreflect.Name(serviceName))
+	if service == nil {
+		c.t.Fatalf("tableg.proto has no service %s", serviceName)
+	}
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		c.t.Fatalf("tableg.proto has no method %s", name)
+	}
+	return method
+}
+
+// message returns a message of the descriptor, filled in from its JSON form
+func (c *grpcTestClient) message(descriptor protoreflect.MessageDescriptor, jsonForm string) *dynamicpb.Message {
+	c.t.Helper()
+	message := dynamicpb.NewMessage(descriptor)
+	if err := protojson.Unmarshal([]byte(jsonForm), message); err != nil {
+		c.t.Fatalf("filling in %s: %v", descriptor.FullName(), err)
+	}
+	return message
+}
+
+// call calls a method, like Users/GetUser, as the user of the token with the request in its JSON
+// form
+func (c *grpcTestClient) call(token, name, request string) (*dynamicpb.Message, error) {
+	c.t.Helper()
+	method := c.method(name)
+	response := dynamicpb.NewMessage(method.Output())
+	ctx := c.t.Context()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	err := c.conn.Invoke(ctx, "/tableg.v1."+name, c.message(method.Input(), request), response)
+	return response, err
+}
+
+// assertCall calls a method and compares its response with want, in its JSON form
+func (c *grpcTestClient) assertCall(token, name, request, want string) {
+	c.t.Helper()
+	response, err := c.call(token, name, request)
+	if err != nil {
+		c.t.Fatalf("%s(%s) error = %v", name, request, err)
+	}
+	if wantMessage := c.message(c.method(name).Output(), want); !proto.Equal(response, wantMessage) {
+		c.t.Errorf("%s(%s) = %v, want %v", name, request, response, wantMessage)
+	}
+}
+
+func TestGRPCServesEveryProtoMethod(t *testing.T) {
+	_, store, client := newGRPCTestServer(t)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+
+	services := client.file.Services()
+	for i := range services.Len() {
+		methods := services.Get(i).Methods()
+		for j := range methods.Len() {
+			name := string(services.Get(i).Name()) + "/" + string(methods.Get(j).Name())
+			if _, err := client.call(tokenFor(admin.Username), name, "{}"); status.Code(err) == codes.Unimplemented {
+				t.Errorf("%s is in tableg.proto but not served: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestGRPCRequiresAdmin(t *testing.T) {
+	_, store, client := newGRPCTestServer(t)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+
+	if _, err := client.call("", "Users/ListUsers", "{}"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ListUsers() without a token error = %v, want Unauthenticated", err)
+	}
+	if _, err := client.call(tokenFor(user.Username), "Users/ListUsers", "{}"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ListUsers() as a user error = %v, want PermissionDenied", err)
+	}
+}
+
+// TestGRPCGeneratedClient calls the services with the client generated from tableg.proto, the
+// way Go integrations do
+func TestGRPCGeneratedClient(t *testing.T) {
+	_, store, client := newGRPCTestServer(t)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+
+	ctx := metadata.AppendToOutgoingContext(t.Context(), "authorization", "Bearer "+tokenFor(admin.Username), "x-request-id", "payroll-42")
+	var header metadata.MD
+	user, err := tablegv1.NewUsersClient(client.conn).GetUser(ctx, &tablegv1.GetUserRequest{Id: admin.ID}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if user.GetUsername() != "root" || user.GetUserType() != "admin" {
+		t.Errorf("GetUser() = %v, want root", user)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "payroll-42" {
+		t.Errorf("x-request-id = %v, want the caller's payroll-42", got)
+	}
+}
+
+func TestGRPCServices(t *testing.T) {
+	handler, store, client := newGRPCTestServer(t)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(admin.Username)
+	createAnnualRecord(t, handler, admin, alice, 2025)
+
+	var leave LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(alice.Username), LeaveLogRequest{
+		UserID: alice.ID, Type: "vacation", Date: "2025-04-14", Note: "Songkran",
+	}), http.StatusCreated, &leave)
+
+	task, err := store.CreateTask(t.Context(), sqlc.CreateTaskParams{Title: pgtype.Text{String: "Payroll export", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskLog, err := store.CreateTaskLog(t.Context(), sqlc.CreateTaskLogParams{
+		TaskID:          task.ID,
+		WorkedDay:       pgconv.MustFromFloat(0.5),
+		CreatedByUserID: alice.ID,
+		WorkedDate:      pgtype.Date{Time: time.Date(2025, time.April, 16, 0, 0, 0, 0, time.UTC), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.assertCall(token, "Users/GetUser", fmt.Sprintf(`{"id": %d}`, alice.ID),
+		fmt.Sprintf(`{"id": %d, "username": "alice", "email": "alice@example.com", "userType": "user", "dailyCapacity": 1}`, alice.ID))
+	client.assertCall(token, "Users/ListUsers", `{"pageSize": 1}`,
+		fmt.Sprintf(`{"users": [{"id": %d, "username": "root", "email": "root@example.com", "userType": "admin", "dailyCapacity": 1}], "nextOffset": 1}`, admin.ID))
+	client.assertCall(token, "Leave/ListLeave", `{"from": "2025-01-01", "to": "2025-12-31"}`,
+		fmt.Sprintf(`{"leave": [{"id": %d, "userId": %d, "username": "alice", "type": "vacation", "date": "2025-04-14", "note": "Songkran"}]}`, leave.ID, alice.ID))
+	client.assertCall(token, "Leave/ListLeave", `{"from": "2025-01-01", "to": "2025-12-31", "type": "sick"}`, `{}`)
+	client.assertCall(token, "TaskLogs/ListTaskLogs", fmt.Sprintf(`{"from": "2025-04-01", "to": "2025-04-30", "userId": %d}`, alice.ID),
+		fmt.Sprintf(`{"taskLogs": [{"id": %d, "taskId": %d, "userId": %d, "date": "2025-04-16", "workedDays": 0.5}]}`, taskLog.ID, task.ID, alice.ID))
+
+	balance := fmt.Sprintf(`{"userId": %d, "username": "alice", "year": 2025, "quotaPlan": "Default",
+		"vacationDays": 10, "usedVacationDays": 1, "remainingVacationDays": 9,
+		"medicalExpenseQuotaBaht": 20000, "remainingMedicalExpenseBaht": 20000}`, alice.ID)
+	client.assertCall(token, "Balances/GetBalance", fmt.Sprintf(`{"userId": %d, "year": 2025}`, alice.ID), balance)
+	client.assertCall(token, "Balances/ListBalances", `{"year": 2025}`, `{"balances": [`+balance+`]}`)
+
+	tests := []struct {
+		name     string
+		method   string
+		request  string
+		wantCode codes.Code
+	}{
+		{name: "unknown user", method: "Users/GetUser", request: `{"id": 9999}`, wantCode: codes.NotFound},
+		{name: "no annual record", method: "Balances/GetBalance", request: fmt.Sprintf(`{"userId": %d, "year": 2024}`, alice.ID), wantCode: codes.NotFound},
+		{name: "bad date", method: "Leave/ListLeave", request: `{"from": "01/01/2025", "to": "2025-12-31"}`, wantCode: codes.InvalidArgument},
+		{name: "period backwards", method: "TaskLogs/ListTaskLogs", request: `{"from": "2025-12-31", "to": "2025-01-01"}`, wantCode: codes.InvalidArgument},
+		{name: "page too large", method: "Users/ListUsers", request: `{"pageSize": 10001}`, wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.call(token, tt.method, tt.request); status.Code(err) != tt.wantCode {
+				t.Errorf("%s(%s) error = %v, want %v", tt.method, tt.request, err, tt.wantCode)
+			}
+		})
+	}
+}
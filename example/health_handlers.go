@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/db/migrations"
+)
+
+// readinessTimeout bounds the database checks of a readiness probe, so a hung pool fails the
+// probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+// ReadinessResponse reports each readiness check, "ok" or what is wrong
+type ReadinessResponse struct {
+	Status     string `json:"status"` // "ready" or "not_ready"
+	Database   string `json:"database"`
+	Migrations string `json:"migrations"`
+	ClickUp    string `json:"clickup"` // "disabled", "ok" or "circuit_open", never fails the probe
+}
+
+// live handles the liveness probe: the process is up and serving requests
+func (s *Server) live(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ready handles the readiness probe: it fails with 503 while the database is unreachable or
+// the schema is behind this build, so traffic goes to other instances. ClickUp is optional
+// and only reported.
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	response := ReadinessResponse{
+		Status:     "ready",
+		Database:   "ok",
+		Migrations: "ok",
+		ClickUp:    "disabled",
+	}
+
+	if s.database == nil {
+		response.Database = "in_memory"
+	} else if err := s.database.Pool.Ping(ctx); err != nil {
+		response.Database = err.Error()
+		response.Migrations = "unknown"
+	} else if pending, err := migrations.Pending(ctx, s.database.Pool); err != nil {
+		response.Migrations = err.Error()
+	} else if len(pending) > 0 {
+		response.Migrations = fmt.Sprintf("%d pending, the oldest is %06d_%s", len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	if s.taskSync.Enabled() {
+		response.ClickUp = "ok"
+		if s.taskSync.CircuitOpen() {
+			response.ClickUp = "circuit_open"
+		}
+	}
+
+	status := http.StatusOK
+	if (response.Database != "ok" && response.Database != "in_memory") || response.Migrations != "ok" {
+		response.Status = "not_ready"
+		status = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, status, response)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// Clients on flaky networks retry requests whose response they never got, which used to create
+// a second leave log or expense. A client that sends an Idempotency-Key header, a value unique to
+// the request such as a UUID, gets the stored response of the first request with that key back
+// instead for IDEMPOTENCY_KEY_TTL, without the handler running again.
+
+const (
+	// idempotencyKeyHeader carries the key of a write request
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader is set to true on responses replayed from an earlier request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength is the length of the key column
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware replays the stored response to a POST, PUT, PATCH or DELETE carrying an
+// Idempotency-Key the same user already sent. Keys are per user, reusing one for a different
+// request is refused with 422, and a retry arriving while the first request still runs gets 409.
+// Requests without the header, or from a caller the handler will refuse anyway, pass through.
+func (s *Server) IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key is longer than 255 characters")
+			return
+		}
+		user, err := getCurrentUserFromRequest(s.store, r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error reading request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r, body)
+
+		claim, err := s.store.ClaimIdempotencyKey(ctx, sqlc.ClaimIdempotencyKeyParams{
+			UserID:        user.ID,
+			Key:           key,
+			RequestHash:   hash,
+			ExpiredBefore: pgtype.Timestamptz{Time: time.Now().Add(-s.config.Server.IdempotencyKeyTTL), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.replayIdempotent(w, r, user.ID, key, hash)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Error claiming idempotency key", "error", err)
+			respondWithError(w, http.StatusInternalServerError, "Error checking Idempotency-Key")
+			return
+		}
+
+		// The outcome is stored even when the client has gone, that is when it retries
+		storeCtx := context.WithoutCancel(ctx)
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				s.releaseIdempotencyKey(storeCtx, claim.ID)
+				panic(recovered)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+
+		// Failures are not replayed, a retry may succeed
+		if recorder.status >= http.StatusInternalServerError {
+			s.releaseIdempotencyKey(storeCtx, claim.ID)
+			return
+		}
+		contentType := w.Header().Get("Content-Type")
+		err = s.store.CompleteIdempotencyKey(storeCtx, sqlc.CompleteIdempotencyKeyParams{
+			ID:           claim.ID,
+			StatusCode:   pgtype.Int4{Int32: int32(recorder.status), Valid: true},
+			ContentType:  pgtype.Text{String: contentType, Valid: contentType != ""},
+			ResponseBody: recorder.body.Bytes(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error storing idempotent response", "error", err)
+			s.releaseIdempotencyKey(storeCtx, claim.ID)
+		}
+	})
+}
+
+// replayIdempotent answers a request whose key is already taken with the stored response
+func (s *Server) replayIdempotent(w http.ResponseWriter, r *http.Request, userID int32, key, hash string) {
+	ctx := r.Context()
+	stored, err := s.store.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{UserID: userID, Key: key})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The first request failed and released the key in between
+		respondWithError(w, http.StatusConflict, "The first request with this Idempotency-Key just failed, retry it")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading idempotency key", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error checking Idempotency-Key")
+		return
+	}
+
+	switch {
+	case stored.RequestHash != hash:
+		respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	case !stored.StatusCode.Valid:
+		respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+	default:
+		if stored.ContentType.Valid {
+			w.Header().Set("Content-Type", stored.ContentType.String)
+		}
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(int(stored.StatusCode.Int32))
+		w.Write(stored.ResponseBody)
+	}
+}
+
+// releaseIdempotencyKey forgets a key whose request failed, so a retry runs it again
+func (s *Server) releaseIdempotencyKey(ctx context.Context, id int32) {
+	if err := s.store.DeleteIdempotencyKey(ctx, id); err != nil {
+		slog.ErrorContext(ctx, "Error releasing idempotency key", "error", err)
+	}
+}
+
+// requestHash identifies a request by its method, URL and body, so a key reused for another
+// request is noticed
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder remembers the status and body a handler answered with while sending them on
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// scheduleIdempotencyKeyCleanup deletes expired idempotency keys every hour
+func (s *Server) scheduleIdempotencyKeyCleanup() {
+	ttl := s.config.Server.IdempotencyKeyTTL
+	s.scheduler.Register(scheduler.Job{
+		Name:        "idempotency-key-cleanup",
+		Description: "Delete the idempotency keys older than IDEMPOTENCY_KEY_TTL",
+		Schedule:    scheduler.Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				deleted, err := s.database.DeleteExpiredIdempotencyKeys(ctx, pgtype.Timestamptz{Time: time.Now().Add(-ttl), Valid: true})
+				if err != nil {
+					slog.ErrorContext(ctx, "Error deleting expired idempotency keys", "error", err)
+					errs = append(errs, err)
+				} else if deleted > 0 {
+					slog.InfoContext(ctx, "Expired idempotency keys deleted", "keys", deleted)
+				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
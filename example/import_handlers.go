@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/kengtableg/pkeng-tableg/hrimport"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// importMaxSize caps the workbook of one import, XLSX or JSON
+const importMaxSize = 20 << 20
+
+// ImportResponse is what POST /api/admin/import found in a workbook, and whether it was written
+type ImportResponse struct {
+	DryRun    bool                          `json:"dryRun"`
+	Committed bool                          `json:"committed"`
+	Rows      map[string]int                `json:"rows"`     // Rows of each sheet
+	Problems  map[string][]hrimport.Problem `json:"problems"` // By sheet, empty when clean
+	// Passwords are the ones generated for users imported without a password, by username. They
+	// are only ever shown here.
+	Passwords map[string]string `json:"passwords,omitempty"`
+}
+
+// importWorkbook handles POST /api/admin/import?dry_run=true, importing users, historical leave,
+// balances and medical expenses from a legacy HR spreadsheet. The workbook is a multipart form's
+// XLSX file, or the same sheets as JSON. A dry run only checks it; otherwise it is written in one
+// transaction when the check finds no problem, and not at all when it does.
+func (s *Server) importWorkbook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can import data")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxSize+64<<10)
+	var workbook hrimport.Workbook
+	problems := hrimport.Problems{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondWithError(w, http.StatusRequestEntityTooLarge, "File is larger than "+strconv.Itoa(importMaxSize)+" bytes")
+				return
+			}
+			respondWithError(w, http.StatusBadRequest, "Invalid multipart form: "+err.Error())
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		upload, _, err := r.FormFile("file")
+		if err != nil {
+			respondWithValidationError(w, validate.Errors{"file": "is required"})
+			return
+		}
+		defer upload.Close()
+		data, err := io.ReadAll(upload)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error reading file: "+err.Error())
+			return
+		}
+		if workbook, problems, err = hrimport.ReadXLSX(data); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid workbook: "+err.Error())
+			return
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&workbook); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		workbook.Numbered()
+	}
+
+	// A workbook with cells that couldn't be read is only checked, for the rest of its problems
+	result, err := hrimport.Import(ctx, s.store, workbook, currentUser.ID, dryRun || len(problems) > 0)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error importing workbook: "+err.Error())
+		return
+	}
+	for sheet, sheetProblems := range result.Problems {
+		problems[sheet] = append(problems[sheet], sheetProblems...)
+	}
+
+	language := responseLanguage(w)
+	for _, sheetProblems := range problems {
+		for i := range sheetProblems {
+			sheetProblems[i].Message = i18n.Translate(language, sheetProblems[i].Message)
+		}
+	}
+	response := ImportResponse{
+		DryRun:    dryRun,
+		Committed: result.Committed,
+		Rows:      result.Rows,
+		Problems:  problems,
+		Passwords: result.Passwords,
+	}
+	if !result.Committed {
+		status := http.StatusOK
+		if len(problems) > 0 {
+			status = http.StatusUnprocessableEntity
+		}
+		respondWithJSON(w, status, response)
+		return
+	}
+
+	for _, userYear := range result.SyncYears {
+		s.events.Publish(ctx, AnnualRecordChange{UserID: userYear.UserID, Year: userYear.Year})
+	}
+	slog.InfoContext(ctx, "Workbook imported", "rows", result.Rows, "imported_by", currentUser.ID)
+	respondWithJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,294 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// Client is a Jira Cloud REST API client authenticating with an account email and API token
+type Client struct {
+	BaseURL    string // Site URL, e.g. https://example.atlassian.net
+	Email      string
+	APIToken   string
+	HTTPClient *http.Client
+}
+
+// Issue is a Jira issue with the fields kept in step with local tasks
+type Issue struct {
+	ID     string      `json:"id"`
+	Key    string      `json:"key"`
+	Fields IssueFields `json:"fields"`
+}
+
+// IssueFields are the fields of an issue we read and write
+type IssueFields struct {
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Status      Status `json:"status"`
+	Updated     Time   `json:"updated"`
+}
+
+// Status is the workflow status of an issue
+type Status struct {
+	Name string `json:"name"`
+}
+
+// Time is a Jira timestamp such as 2024-01-31T09:30:00.000+0000
+type Time struct {
+	time.Time
+}
+
+// jiraTimeLayout is the format Jira uses for issue timestamps
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// UnmarshalJSON parses a Jira timestamp, leaving the time zero when it is empty
+func (t *Time) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	parsed, err := time.Parse(jiraTimeLayout, value)
+	if err != nil {
+		return fmt.Errorf("invalid jira timestamp %q: %w", value, err)
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// issueKeyPattern matches issue keys, a project key and a number like PROJ-123
+var issueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-\d+$`)
+
+// ValidIssueKey reports whether key is an issue key. Keys come from task URLs users enter, so
+// the client only puts valid ones in request paths.
+func ValidIssueKey(key string) bool {
+	return issueKeyPattern.MatchString(key)
+}
+
+// issuePath returns the API path of an issue, or an error when key isn't an issue key
+func issuePath(key string) (string, error) {
+	if !ValidIssueKey(key) {
+		return "", fmt.Errorf("invalid jira issue key %q", key)
+	}
+	return "/rest/api/2/issue/" + url.PathEscape(key), nil
+}
+
+// CreateIssueRequest describes an issue to create
+type CreateIssueRequest struct {
+	ProjectKey  string
+	Summary     string
+	Description string
+	IssueType   string // Defaults to Task, or Sub-task when a parent is given
+	ParentKey   string // Makes the issue a sub-task of the parent
+}
+
+// APIError is returned when Jira answers with a non-success status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("jira API returned error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// NewClient creates a new Jira client. An empty base URL or token gives a disabled client.
+func NewClient(baseURL, email, apiToken string) *Client {
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		Email:    email,
+		APIToken: apiToken,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}
+}
+
+// Enabled reports whether the client has a site and credentials to call
+func (c *Client) Enabled() bool {
+	return c.BaseURL != "" && c.APIToken != ""
+}
+
+// IssueURL returns the browser URL of an issue
+func (c *Client) IssueURL(key string) string {
+	return fmt.Sprintf("%s/browse/%s", c.BaseURL, key)
+}
+
+// CreateIssue creates an issue and returns it as stored by Jira
+func (c *Client) CreateIssue(ctx context.Context, req CreateIssueRequest) (*Issue, error) {
+	issueType := req.IssueType
+	if issueType == "" {
+		issueType = "Task"
+		if req.ParentKey != "" {
+			issueType = "Sub-task"
+		}
+	}
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": req.ProjectKey},
+		"summary":     req.Summary,
+		"description": req.Description,
+		"issuetype":   map[string]string{"name": issueType},
+	}
+	if req.ParentKey != "" {
+		fields["parent"] = map[string]string{"key": req.ParentKey}
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return nil, err
+	}
+
+	// The create response only carries the key, read back the rest
+	return c.GetIssue(ctx, created.Key)
+}
+
+// GetIssue retrieves an issue by key
+func (c *Client) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	path, err := issuePath(key)
+	if err != nil {
+		return nil, err
+	}
+	var issue Issue
+	if err := c.do(ctx, http.MethodGet, path+"?fields=summary,description,status,updated", nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// UpdateIssue sets the summary and description of an issue and moves it to the given status
+// when it differs. An empty status leaves the status alone.
+func (c *Client) UpdateIssue(ctx context.Context, key, summary, description, status string) (*Issue, error) {
+	path, err := issuePath(key)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{
+		"summary":     summary,
+		"description": description,
+	}
+	if err := c.do(ctx, http.MethodPut, path, map[string]interface{}{"fields": fields}, nil); err != nil {
+		return nil, err
+	}
+
+	if status != "" {
+		if err := c.TransitionIssue(ctx, key, status); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.GetIssue(ctx, key)
+}
+
+// TransitionIssue moves an issue to the status with the given name. Jira only allows the
+// transitions of the issue's workflow, so an unreachable status is an error.
+func (c *Client) TransitionIssue(ctx context.Context, key, status string) error {
+	path, err := issuePath(key)
+	if err != nil {
+		return err
+	}
+	issue, err := c.GetIssue(ctx, key)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(issue.Fields.Status.Name, status) {
+		return nil
+	}
+
+	var response struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To Status `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := c.do(ctx, http.MethodGet, path+"/transitions", nil, &response); err != nil {
+		return err
+	}
+
+	for _, transition := range response.Transitions {
+		if strings.EqualFold(transition.To.Name, status) {
+			body := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+			return c.do(ctx, http.MethodPost, path+"/transitions", body, nil)
+		}
+	}
+	return fmt.Errorf("jira issue %s cannot move to status %q", key, status)
+}
+
+// do sends a request to path and decodes the response into out when given
+func (c *Client) do(ctx context.Context, method, path string, payload interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonBody, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.SetBasicAuth(c.Email, c.APIToken)
+	httpReq.Header.Set("Accept", "application/json")
+	logging.SetRequestIDHeader(ctx, httpReq.Header)
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// ExtractIssueKeyFromURL returns the key of an issue URL on the client's site, like
+// https://example.atlassian.net/browse/PROJ-123, or "" for any other URL
+func (c *Client) ExtractIssueKeyFromURL(issueURL string) string {
+	site, err := url.Parse(c.BaseURL)
+	if err != nil || site.Host == "" {
+		return ""
+	}
+	parsed, err := url.Parse(issueURL)
+	if err != nil || !strings.EqualFold(parsed.Host, site.Host) {
+		return ""
+	}
+
+	key, ok := strings.CutPrefix(strings.TrimSuffix(parsed.Path, "/"), strings.TrimSuffix(site.Path, "/")+"/browse/")
+	if !ok || !ValidIssueKey(key) {
+		return ""
+	}
+	return key
+}
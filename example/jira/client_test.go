@@ -0,0 +1,64 @@
+package jira_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/example/jira"
+)
+
+func TestExtractIssueKeyFromURL(t *testing.T) {
+	client := jira.NewClient("https://example.atlassian.net", "bot@example.com", "token")
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.atlassian.net/browse/PROJ-123", want: "PROJ-123"},
+		{url: "https://EXAMPLE.atlassian.net/browse/PAY_2-7/", want: "PAY_2-7"},
+		{url: "https://evil.example.com/browse/PROJ-123", want: ""},
+		{url: "https://example.atlassian.net.evil.com/browse/PROJ-123", want: ""},
+		{url: "https://example.atlassian.net/browse/PROJ-123/../../admin", want: ""},
+		{url: "https://example.atlassian.net/browse/proj-123", want: ""},
+		{url: "https://example.atlassian.net/browse/PROJ-123?x=1#y", want: "PROJ-123"},
+		{url: "https://example.atlassian.net/projects/PROJ", want: ""},
+		{url: "https://app.clickup.com/t/abc123", want: ""},
+	}
+	for _, tt := range tests {
+		if got := client.ExtractIssueKeyFromURL(tt.url); got != tt.want {
+			t.Errorf("ExtractIssueKeyFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidIssueKeysAreNotSent(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{"key": "PROJ-1", "fields": {"status": {"name": "Done"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	client := jira.NewClient(server.URL, "bot@example.com", "token")
+
+	for _, key := range []string{"", "PROJ-1/../../myself", "PROJ-1?expand=x", "10001", "proj-1"} {
+		if _, err := client.GetIssue(t.Context(), key); err == nil {
+			t.Errorf("GetIssue(%q) succeeded", key)
+		}
+		if _, err := client.UpdateIssue(t.Context(), key, "Summary", "", "Done"); err == nil {
+			t.Errorf("UpdateIssue(%q) succeeded", key)
+		}
+		if err := client.TransitionIssue(t.Context(), key, "Done"); err == nil {
+			t.Errorf("TransitionIssue(%q) succeeded", key)
+		}
+	}
+	if len(paths) != 0 {
+		t.Errorf("requests sent for invalid keys: %v", paths)
+	}
+
+	if _, err := client.GetIssue(t.Context(), "PROJ-1"); err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/rest/api/2/issue/PROJ-1" {
+		t.Errorf("paths = %v, want the issue's", paths)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/jira"
+)
+
+// JiraTaskBackend links local tasks to Jira issues
+type JiraTaskBackend struct {
+	store      db.Querier
+	client     *jira.Client
+	projectKey string // Project of new issues when none is given
+}
+
+// NewJiraTaskBackend creates a Jira task backend acting with the given client, creating issues
+// in projectKey unless a request names another project
+func NewJiraTaskBackend(store db.Querier, client *jira.Client, projectKey string) *JiraTaskBackend {
+	return &JiraTaskBackend{
+		store:      store,
+		client:     client,
+		projectKey: projectKey,
+	}
+}
+
+// Name returns the backend name used in task requests
+func (b *JiraTaskBackend) Name() string {
+	return taskBackendJira
+}
+
+// Enabled reports whether a Jira site and token are configured
+func (b *JiraTaskBackend) Enabled() bool {
+	return b.client != nil && b.client.Enabled()
+}
+
+// CreateTask creates an issue in a Jira project, JIRA_PROJECT_KEY when none is given. A subtask
+// is created as a sub-task in the parent's project.
+func (b *JiraTaskBackend) CreateTask(ctx context.Context, req RemoteTaskRequest) (*RemoteTask, error) {
+	projectKey := req.ContainerID
+	if req.ParentID != "" {
+		projectKey, _, _ = strings.Cut(req.ParentID, "-")
+	}
+	if projectKey == "" {
+		projectKey = b.projectKey
+	}
+	if projectKey == "" {
+		return nil, fmt.Errorf("no Jira project given and JIRA_PROJECT_KEY is not set")
+	}
+
+	issue, err := b.client.CreateIssue(ctx, jira.CreateIssueRequest{
+		ProjectKey:  projectKey,
+		Summary:     req.Title,
+		Description: req.Note,
+		ParentKey:   req.ParentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// New issues start in the workflow's first status, move them on when asked to
+	if req.Status != "" && !strings.EqualFold(issue.Fields.Status.Name, req.Status) {
+		if issue, err = b.client.UpdateIssue(ctx, issue.Key, req.Title, req.Note, req.Status); err != nil {
+			return nil, err
+		}
+	}
+	return b.newRemoteTask(issue), nil
+}
+
+// UpdateTask sends the title, note and status to the Jira issue. Custom fields stay local.
+func (b *JiraTaskBackend) UpdateTask(ctx context.Context, remoteID string, req RemoteTaskRequest) (*RemoteTask, error) {
+	issue, err := b.client.UpdateIssue(ctx, remoteID, req.Title, req.Note, req.Status)
+	if err != nil {
+		return nil, err
+	}
+	return b.newRemoteTask(issue), nil
+}
+
+// ExtractID returns the issue key of a Jira issue URL
+func (b *JiraTaskBackend) ExtractID(url string) string {
+	return b.client.ExtractIssueKeyFromURL(url)
+}
+
+// Sync reconciles the task with its Jira issue. As with ClickUp, the side with the newer
+// updated-at wins and a conflict is recorded when both sides changed since the last sync.
+func (b *JiraTaskBackend) Sync(ctx context.Context, task db.Task) (*TaskSyncResult, error) {
+	if !b.Enabled() {
+		return nil, fmt.Errorf("jira integration is disabled")
+	}
+
+	key := b.ExtractID(task.Url.String)
+	if key == "" {
+		return nil, fmt.Errorf("task %d is not linked to a Jira issue", task.ID)
+	}
+
+	issue, err := b.client.GetIssue(ctx, key)
+	if err != nil {
+		recordTaskSyncHistory(ctx, b.store, task.ID, taskSyncDirectionPull, taskSyncStatusError, nil, err.Error(), time.Time{})
+		return nil, fmt.Errorf("failed to fetch Jira issue %s: %w", key, err)
+	}
+	remote := b.newRemoteTask(issue)
+
+	result := &TaskSyncResult{TaskID: task.ID}
+	if task.Title.String != remote.Title {
+		result.ChangedFields = append(result.ChangedFields, "title")
+	}
+	if task.Note.String != remote.Note {
+		result.ChangedFields = append(result.ChangedFields, "note")
+	}
+	if !strings.EqualFold(task.Status.String, remote.Status) {
+		result.ChangedFields = append(result.ChangedFields, "status")
+	}
+
+	remoteUpdated := pgtype.Timestamptz{Time: remote.UpdatedAt, Valid: !remote.UpdatedAt.IsZero()}
+	if len(result.ChangedFields) == 0 {
+		if err := b.store.MarkTaskClickUpSynced(ctx, db.MarkTaskClickUpSyncedParams{ID: task.ID, RemoteUpdatedAt: remoteUpdated}); err != nil {
+			return nil, fmt.Errorf("failed to mark task %d as synced: %w", task.ID, err)
+		}
+		return result, nil
+	}
+
+	localUpdated := task.UpdatedAt.Time
+	if task.ClickupSyncedAt.Valid {
+		lastSynced := task.ClickupSyncedAt.Time
+		result.Conflict = localUpdated.After(lastSynced) && remote.UpdatedAt.After(lastSynced)
+	}
+
+	if remote.UpdatedAt.After(localUpdated) {
+		result.Direction = taskSyncDirectionPull
+		_, err = b.store.ApplyClickUpTaskChanges(ctx, db.ApplyClickUpTaskChangesParams{
+			ID:              task.ID,
+			Title:           pgtype.Text{String: remote.Title, Valid: remote.Title != ""},
+			Note:            pgtype.Text{String: remote.Note, Valid: remote.Note != ""},
+			Status:          pgtype.Text{String: remote.Status, Valid: remote.Status != ""},
+			StatusColor:     task.StatusColor, // Jira has no status colors
+			RemoteUpdatedAt: remoteUpdated,
+		})
+	} else {
+		result.Direction = taskSyncDirectionPush
+		var pushed *jira.Issue
+		pushed, err = b.client.UpdateIssue(ctx, key, task.Title.String, task.Note.String, task.Status.String)
+		if err == nil {
+			err = b.store.MarkTaskClickUpSynced(ctx, db.MarkTaskClickUpSyncedParams{
+				ID:              task.ID,
+				RemoteUpdatedAt: pgtype.Timestamptz{Time: pushed.Fields.Updated.Time, Valid: !pushed.Fields.Updated.IsZero()},
+			})
+		}
+	}
+
+	if err != nil {
+		recordTaskSyncHistory(ctx, b.store, task.ID, result.Direction, taskSyncStatusError, result.ChangedFields, err.Error(), remote.UpdatedAt)
+		return nil, fmt.Errorf("failed to sync task %d with Jira issue %s: %w", task.ID, key, err)
+	}
+
+	status := taskSyncStatusSuccess
+	message := ""
+	if result.Conflict {
+		status = taskSyncStatusConflict
+		message = fmt.Sprintf("both sides changed since last sync, %s side was newer", map[string]string{
+			taskSyncDirectionPull: "Jira",
+			taskSyncDirectionPush: "local",
+		}[result.Direction])
+	}
+	recordTaskSyncHistory(ctx, b.store, task.ID, result.Direction, status, result.ChangedFields, message, remote.UpdatedAt)
+
+	return result, nil
+}
+
+// newRemoteTask converts a Jira issue to the backend-neutral format
+func (b *JiraTaskBackend) newRemoteTask(issue *jira.Issue) *RemoteTask {
+	return &RemoteTask{
+		ID:        issue.Key,
+		URL:       b.client.IssueURL(issue.Key),
+		Title:     issue.Fields.Summary,
+		Note:      issue.Fields.Description,
+		Status:    issue.Fields.Status.Name,
+		UpdatedAt: issue.Fields.Updated.Time,
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// schedulerLockID is the advisory lock key held by the server instance that runs the background
+// jobs
+const schedulerLockID = 7_305_114_203
+
+// listJobs handles GET /api/admin/jobs, listing the background jobs with when they last ran,
+// how that went and when they run next
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "manage background jobs") {
+		return
+	}
+	jobs, err := s.scheduler.Jobs(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing jobs: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, jobs)
+}
+
+// runJob handles POST /api/admin/jobs/{name}/run, running a job as soon as the run still going,
+// if any, finishes. The run is asynchronous, the job's lastFinishedAt tells when it is over.
+func (s *Server) runJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r, http.StatusAccepted, s.scheduler.Trigger)
+}
+
+// pauseJob handles POST /api/admin/jobs/{name}/pause
+func (s *Server) pauseJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r, http.StatusOK, s.scheduler.Pause)
+}
+
+// resumeJob handles POST /api/admin/jobs/{name}/resume
+func (s *Server) resumeJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r, http.StatusOK, s.scheduler.Resume)
+}
+
+// changeJob applies a change to the job named in the path and answers with its state
+func (s *Server) changeJob(w http.ResponseWriter, r *http.Request, status int, change func(ctx context.Context, name string) (scheduler.Status, error)) {
+	if !s.authorizeDeploymentAdmin(w, r, "manage background jobs") {
+		return
+	}
+	job, err := change(r.Context(), mux.Vars(r)["name"])
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		respondWithError(w, http.StatusNotFound, "Job not found")
+	case errors.Is(err, scheduler.ErrPaused):
+		respondWithError(w, http.StatusConflict, "Job is paused, resume it first")
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Error changing job: "+err.Error())
+	default:
+		respondWithJSON(w, status, job)
+	}
+}
+
+// authorizeDeploymentAdmin lets admins through to settings of the whole deployment, such as the
+// background jobs, which run for every tenant. With MULTI_TENANT=true only the admins of the
+// default tenant, who run the deployment, may do what is described.
+func (s *Server) authorizeDeploymentAdmin(w http.ResponseWriter, r *http.Request, action string) bool {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	tenantID, ok := db.TenantFromContext(r.Context())
+	if currentUser.UserType != "admin" || s.config.Database.MultiTenant && ok && tenantID != db.DefaultTenantID {
+		respondWithError(w, http.StatusForbidden, "Only admin users can "+action)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+)
+
+// API messages, and the titles of exported reports, are written in English and translated by
+// the i18n package into the language the client's Accept-Language header prefers,
+// DEFAULT_LANGUAGE when it names neither English nor Thai. A report rendered in the background
+// keeps the language it was asked for in. Notifications go out without a request, they are in
+// the language the user picked at /api/current-user/language instead.
+
+// contentLanguageHeader carries the language of a response, which respondWithError translates
+// messages into
+const contentLanguageHeader = "Content-Language"
+
+// LanguageMiddleware picks the language of every response from the Accept-Language header and
+// sets it as the Content-Language header
+func LanguageMiddleware(fallback i18n.Language) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(contentLanguageHeader, string(i18n.Negotiate(r.Header.Get("Accept-Language"), fallback)))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// responseLanguage returns the language LanguageMiddleware picked for a response, English
+// outside it
+func responseLanguage(w http.ResponseWriter) i18n.Language {
+	language, ok := i18n.Parse(w.Header().Get(contentLanguageHeader))
+	if !ok {
+		return i18n.English
+	}
+	return language
+}
+
+// userLanguage returns the language a user picked, DEFAULT_LANGUAGE until they pick one
+func (s *Server) userLanguage(ctx context.Context, userID int32) (i18n.Language, error) {
+	pref, err := s.store.GetLanguagePreference(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.defaultLanguage(), nil
+	}
+	return i18n.Language(pref.Language), err
+}
+
+// defaultLanguage is DEFAULT_LANGUAGE
+func (s *Server) defaultLanguage() i18n.Language {
+	return i18n.Language(s.config.Server.DefaultLanguage)
+}
+
+// LanguagePreferenceResponse is the language the user gets notifications in
+type LanguagePreferenceResponse struct {
+	Language  string   `json:"language"` // en or th
+	Picked    bool     `json:"picked"`   // False while the user gets DEFAULT_LANGUAGE
+	Languages []string `json:"languages"`
+}
+
+// LanguagePreferenceRequest is the body of PUT /api/current-user/language
+type LanguagePreferenceRequest struct {
+	Language string `json:"language" validate:"required,oneof=en th"`
+}
+
+// getLanguagePreference handles GET /api/current-user/language
+func (s *Server) getLanguagePreference(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	pref, err := s.store.GetLanguagePreference(r.Context(), currentUser.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithJSON(w, http.StatusOK, languagePreferenceResponse(s.defaultLanguage(), false))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching language preference: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, languagePreferenceResponse(i18n.Language(pref.Language), true))
+}
+
+// updateLanguagePreference handles PUT /api/current-user/language
+func (s *Server) updateLanguagePreference(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	var req LanguagePreferenceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	pref, err := s.store.SetLanguagePreference(r.Context(), sqlc.SetLanguagePreferenceParams{UserID: currentUser.ID, Language: req.Language})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving language preference: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, languagePreferenceResponse(i18n.Language(pref.Language), true))
+}
+
+func languagePreferenceResponse(language i18n.Language, picked bool) LanguagePreferenceResponse {
+	languages := make([]string, len(i18n.Languages))
+	for i, l := range i18n.Languages {
+		languages[i] = string(l)
+	}
+	return LanguagePreferenceResponse{Language: string(language), Picked: picked, Languages: languages}
+}
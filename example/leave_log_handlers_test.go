@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestLeaveLogLifecycle(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+
+	var created LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, LeaveLogRequest{
+		UserID: user.ID,
+		Type:   "vacation",
+		Date:   "2025-04-14",
+		Note:   "Songkran",
+	}), http.StatusCreated, &created)
+	if created.Username != "alice" || created.Type != "vacation" || created.Note.String != "Songkran" {
+		t.Errorf("created %+v, want alice's vacation noted Songkran", created)
+	}
+
+	path := fmt.Sprintf("/api/leave-logs/%d", created.ID)
+	var updated LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, token, LeaveLogUpdateRequest{
+		Type: "sick",
+		Date: "2025-04-15",
+	}), http.StatusOK, &updated)
+	if updated.Type != "sick" || updated.Date.Time.Format("2006-01-02") != "2025-04-15" || updated.Note.Valid {
+		t.Errorf("updated %+v, want sick leave on 2025-04-15 without a note", updated)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, token, LeaveLogUpdateRequest{
+		Type: "bogus",
+		Date: "2025-04-15",
+	}), http.StatusUnprocessableEntity, nil)
+
+	var mine Page[LeaveLogResponse]
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/current-user/leave-logs", token, nil), http.StatusOK, &mine)
+	if mine.Total != 1 || mine.Data[0].ID != created.ID {
+		t.Errorf("own leave logs = %+v, want only %d", mine, created.ID)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, token, nil), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, token, nil), http.StatusNotFound, nil)
+}
+
+func TestLeaveLogPermissions(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+
+	// Users record their own leave only, admins anyone's
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(bob.Username), LeaveLogRequest{
+		UserID: alice.ID,
+		Type:   "vacation",
+		Date:   "2025-04-14",
+	}), http.StatusForbidden, nil)
+
+	var created LeaveLogResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(admin.Username), LeaveLogRequest{
+		UserID: alice.ID,
+		Type:   "vacation",
+		Date:   "2025-04-14",
+	}), http.StatusCreated, &created)
+
+	path := fmt.Sprintf("/api/leave-logs/%d", created.ID)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, tokenFor(bob.Username), LeaveLogUpdateRequest{
+		Type: "sick",
+		Date: "2025-04-14",
+	}), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, tokenFor(alice.Username), nil), http.StatusOK, nil)
+
+	// Only admins list everyone's leave
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/leave-logs", tokenFor(alice.Username), nil), http.StatusForbidden, nil)
+	var all Page[LeaveLogResponse]
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/leave-logs", tokenFor(admin.Username), nil), http.StatusOK, &all)
+	if all.Total != 1 {
+		t.Errorf("admin listed %d leave logs, want 1", all.Total)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/leave-logs", "", nil), http.StatusUnauthorized, nil)
+}
+
+func TestLeaveLogValidation(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+
+	tests := []struct {
+		name  string
+		req   LeaveLogRequest
+		field string
+	}{
+		{name: "no type", req: LeaveLogRequest{UserID: user.ID, Date: "2025-04-14"}, field: "type"},
+		{name: "unknown type", req: LeaveLogRequest{UserID: user.ID, Type: "bogus", Date: "2025-04-14"}, field: "type"},
+		{name: "no date", req: LeaveLogRequest{UserID: user.ID, Type: "vacation"}, field: "date"},
+		{name: "bad date", req: LeaveLogRequest{UserID: user.ID, Type: "vacation", Date: "14/04/2025"}, field: "date"},
+		{name: "no user", req: LeaveLogRequest{Type: "vacation", Date: "2025-04-14"}, field: "user_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response ValidationErrorResponse
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", token, tt.req), http.StatusUnprocessableEntity, &response)
+			if _, ok := response.Fields[tt.field]; !ok {
+				t.Errorf("fields = %v, want an error for %s", response.Fields, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// LeaveReportEntry is the leave a user took in a year, by leave type
+type LeaveReportEntry struct {
+	UserID     int32            `json:"user_id"`
+	Username   string           `json:"username"`
+	DaysByType map[string]int64 `json:"days_by_type"`
+	TotalDays  int64            `json:"total_days"`
+}
+
+// getLeaveReport answers the leave taken per user and type in a year, the current one unless
+// year is given. Admins see everyone or the user_id they ask for, other users only themselves.
+func (s *Server) getLeaveReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	year := s.thisYear()
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		year, err = strconv.Atoi(yearParam)
+		if err != nil || year <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid year")
+			return
+		}
+	}
+
+	userID := pgtype.Int4{Int32: currentUser.ID, Valid: true}
+	if currentUser.UserType == "admin" {
+		userID = pgtype.Int4{}
+		if userParam := r.URL.Query().Get("user_id"); userParam != "" {
+			parsedUserID, err := strconv.Atoi(userParam)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+				return
+			}
+			userID = pgtype.Int4{Int32: int32(parsedUserID), Valid: true}
+		}
+	}
+
+	rows, err := s.store.SummarizeLeaveLogs(ctx, sqlc.SummarizeLeaveLogsParams{
+		Year:   int32(year),
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave report: "+err.Error())
+		return
+	}
+
+	// Rows come ordered by user, one per leave type
+	response := []LeaveReportEntry{}
+	for _, row := range rows {
+		if len(response) == 0 || response[len(response)-1].UserID != row.UserID {
+			response = append(response, LeaveReportEntry{
+				UserID:     row.UserID,
+				Username:   row.Username,
+				DaysByType: make(map[string]int64),
+			})
+		}
+		entry := &response[len(response)-1]
+		entry.DaysByType[row.Type] = row.DayCount
+		entry.TotalDays += row.DayCount
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,162 @@
+package line
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// maxTextLength is the most characters a text message may have
+const maxTextLength = 5000
+
+// Client is a LINE Messaging API client acting as one channel's Official Account
+type Client struct {
+	BaseURL    string // Messaging API URL, e.g. https://api.line.me
+	Token      string // Channel access token
+	HTTPClient *http.Client
+}
+
+// APIError is returned when LINE answers with a non-success status
+type APIError struct {
+	Path       string
+	StatusCode int
+	Message    string // LINE's error message, such as "The user hasn't added the LINE Official Account as a friend."
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("line %s returned status %d: %s", e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("line %s returned status %d", e.Path, e.StatusCode)
+}
+
+// IsPermanent reports whether the same call will fail the same way, such as for a user who
+// blocked the account, rather than maybe succeed later, such as when rate limited
+func (e *APIError) IsPermanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests
+}
+
+// NewClient creates a client for the channel access token at the given Messaging API URL
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}
+}
+
+// textMessage is a text message object, the only kind this client sends
+type textMessage struct {
+	Type string `json:"type"` // Always text
+	Text string `json:"text"`
+}
+
+// newTextMessages returns text as a single message, cut to the length LINE accepts
+func newTextMessages(text string) []textMessage {
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength-1]) + "…"
+	}
+	return []textMessage{{Type: "text", Text: text}}
+}
+
+// Push sends a text message to a user, who must have added the account as a friend
+func (c *Client) Push(ctx context.Context, to, text string) error {
+	return c.post(ctx, "/v2/bot/message/push", struct {
+		To       string        `json:"to"`
+		Messages []textMessage `json:"messages"`
+	}{To: to, Messages: newTextMessages(text)})
+}
+
+// Reply answers a webhook event with a text message. The reply token of the event works once,
+// within a minute.
+func (c *Client) Reply(ctx context.Context, replyToken, text string) error {
+	return c.post(ctx, "/v2/bot/message/reply", struct {
+		ReplyToken string        `json:"replyToken"`
+		Messages   []textMessage `json:"messages"`
+	}{ReplyToken: replyToken, Messages: newTextMessages(text)})
+}
+
+// post sends payload as the JSON body of a request to the Messaging API
+func (c *Client) post(ctx context.Context, path string, payload interface{}) error {
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	logging.SetRequestIDHeader(ctx, httpReq.Header)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorBody struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &errorBody)
+		return &APIError{Path: path, StatusCode: resp.StatusCode, Message: errorBody.Message}
+	}
+	return nil
+}
+
+// Webhook is the body of a request LINE sends to the channel's webhook URL
+type Webhook struct {
+	Destination string  `json:"destination"`
+	Events      []Event `json:"events"`
+}
+
+// Event is one webhook event, of the types the server handles: message, follow and unfollow
+type Event struct {
+	Type       string `json:"type"`
+	ReplyToken string `json:"replyToken"` // Not on unfollow events
+	Source     struct {
+		Type   string `json:"type"` // user, group or room
+		UserID string `json:"userId"`
+	} `json:"source"`
+	Message struct {
+		Type string `json:"type"` // text, sticker, image, ...
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// ErrInvalidSignature is returned by VerifySignature for a request LINE didn't sign
+var ErrInvalidSignature = errors.New("invalid LINE signature")
+
+// VerifySignature checks that a webhook request came from LINE, from its X-Line-Signature, the
+// base64 HMAC-SHA256 of the body under the channel secret
+func VerifySignature(channelSecret string, body []byte, signature string) error {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), decoded) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
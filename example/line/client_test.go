@@ -0,0 +1,42 @@
+package line
+
+import (
+	"errors"
+	"testing"
+)
+
+// What LINE sends for the body under the secret:
+// printf '%s' '{"destination":"U0123","events":[]}' | openssl dgst -sha256 -hmac channel-secret -binary | base64
+const (
+	exampleChannelSecret = "channel-secret"
+	exampleBody          = `{"destination":"U0123","events":[]}`
+	exampleSignature     = "KKWVZipXzurV05F8Oii7didtpMflku78t9VQa/OqcqM="
+)
+
+func TestVerifySignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+		wantErr   bool
+	}{
+		{name: "signed by LINE", secret: exampleChannelSecret, body: exampleBody, signature: exampleSignature},
+		{name: "tampered body", secret: exampleChannelSecret, body: `{"destination":"U0124","events":[]}`, signature: exampleSignature, wantErr: true},
+		{name: "other secret", secret: "another-secret", body: exampleBody, signature: exampleSignature, wantErr: true},
+		{name: "malformed base64", secret: exampleChannelSecret, body: exampleBody, signature: "not base64!", wantErr: true},
+		{name: "truncated", secret: exampleChannelSecret, body: exampleBody, signature: exampleSignature[:20], wantErr: true},
+		{name: "no signature", secret: exampleChannelSecret, body: exampleBody, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, []byte(tt.body), tt.signature)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature = %v, want ErrInvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("VerifySignature = %v, want nil", err)
+			}
+		})
+	}
+}
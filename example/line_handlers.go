@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/line"
+)
+
+// maxLineWebhookSize caps the body of a webhook request LINE sends
+const maxLineWebhookSize = 1 << 20
+
+// LineLinkResponse is whether the user's LINE account is linked, and the code to link it while
+// one is pending
+type LineLinkResponse struct {
+	Linked            bool       `json:"linked"`
+	LinkedAt          *time.Time `json:"linkedAt,omitempty"`
+	LinkCode          *string    `json:"linkCode,omitempty"` // Send it to the Official Account in LINE
+	LinkCodeExpiresAt *time.Time `json:"linkCodeExpiresAt,omitempty"`
+}
+
+func lineLinkToResponse(link sqlc.LineLink) LineLinkResponse {
+	response := LineLinkResponse{Linked: link.LineUserID.Valid}
+	if link.LinkedAt.Valid {
+		response.LinkedAt = &link.LinkedAt.Time
+	}
+	if link.LinkCode.Valid && link.LinkCodeExpiresAt.Time.After(time.Now()) {
+		response.LinkCode = &link.LinkCode.String
+		response.LinkCodeExpiresAt = &link.LinkCodeExpiresAt.Time
+	}
+	return response
+}
+
+// authorizeLineUser answers 401 without a signed in user and 503 while LINE is off, and returns
+// the user otherwise
+func (s *Server) authorizeLineUser(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if !s.config.LINE.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "LINE notifications are disabled, set LINE_CHANNEL_SECRET and LINE_CHANNEL_ACCESS_TOKEN")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getLineLink handles GET /api/current-user/line
+func (s *Server) getLineLink(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizeLineUser(w, r)
+	if !ok {
+		return
+	}
+	link, err := s.store.GetLineLink(r.Context(), currentUser.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithJSON(w, http.StatusOK, LineLinkResponse{})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching LINE link: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, lineLinkToResponse(link))
+}
+
+// createLineLinkCode handles POST /api/current-user/line/link-code. The user sends the code to
+// the Official Account in LINE to link the account they send it from, replacing one linked
+// before.
+func (s *Server) createLineLinkCode(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizeLineUser(w, r)
+	if !ok {
+		return
+	}
+	code, err := newLineLinkCode()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating link code: "+err.Error())
+		return
+	}
+	link, err := s.store.CreateLineLinkCode(r.Context(), sqlc.CreateLineLinkCodeParams{
+		UserID:            currentUser.ID,
+		LinkCode:          pgtype.Text{String: code, Valid: true},
+		LinkCodeExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(lineLinkCodeTTL), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving link code: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, lineLinkToResponse(link))
+}
+
+// unlinkLine handles DELETE /api/current-user/line
+func (s *Server) unlinkLine(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizeLineUser(w, r)
+	if !ok {
+		return
+	}
+	deleted, err := s.store.DeleteLineLink(r.Context(), currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unlinking LINE account: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "LINE account not linked")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLineWebhook handles POST /api/line/webhook, the webhook URL of the Messaging API channel,
+// where LINE sends the messages users send to the Official Account
+func (s *Server) handleLineWebhook(w http.ResponseWriter, r *http.Request) {
+	if !s.config.LINE.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "LINE notifications are disabled")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLineWebhookSize))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error reading request body")
+		return
+	}
+	if err := line.VerifySignature(s.config.LINE.ChannelSecret, body, r.Header.Get("X-Line-Signature")); err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	var webhook line.Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// LINE checks the URL with an empty list of events
+	client := s.lineClient()
+	for _, event := range webhook.Events {
+		s.handleLineEvent(r.Context(), client, event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
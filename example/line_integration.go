@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/line"
+	"github.com/kengtableg/pkeng-tableg/queue"
+)
+
+// Notifications are pushed to LINE from the Official Account of a Messaging API channel, to the
+// users who linked their LINE account. A user asks TableG for a link code and sends it to the
+// account in LINE; the webhook gets the message with the user's LINE ID and links the two.
+// Blocking the account unlinks it.
+
+// lineLinkCodeTTL is how long a link code works
+const lineLinkCodeTTL = 15 * time.Minute
+
+// lineLinkCodeAlphabet is what link codes are made of, without the characters read alike, such
+// as 0 and O. It has 32 characters so every byte picks one evenly.
+const lineLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// lineLinkCodeLength is how many characters a link code has
+const lineLinkCodeLength = 8
+
+// lineNotifier pushes notifications to the LINE accounts users linked
+type lineNotifier struct {
+	store  db.Store
+	client *line.Client
+}
+
+func (n lineNotifier) Recipient(ctx context.Context, user sqlc.User) (string, error) {
+	link, err := n.store.GetLineLink(ctx, user.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return link.LineUserID.String, nil
+}
+
+func (n lineNotifier) Send(ctx context.Context, recipient, subject, body string) error {
+	err := n.client.Push(ctx, recipient, subject+"\n\n"+body)
+	var apiErr *line.APIError
+	if errors.As(err, &apiErr) && apiErr.IsPermanent() {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// lineClient returns a client acting as the channel's Official Account
+func (s *Server) lineClient() *line.Client {
+	return line.NewClient(s.config.LINE.APIURL, s.config.LINE.ChannelAccessToken)
+}
+
+// newLineLinkCode returns a random link code
+func newLineLinkCode() (string, error) {
+	buf := make([]byte, lineLinkCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = lineLinkCodeAlphabet[int(buf[i])%len(lineLinkCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// handleLineEvent answers one webhook event: a message with a link code links the account it
+// came from, following the account gets the instructions and blocking it unlinks it
+func (s *Server) handleLineEvent(ctx context.Context, client *line.Client, event line.Event) {
+	if event.Source.Type != "user" || event.Source.UserID == "" {
+		return
+	}
+	lineUserID := pgtype.Text{String: event.Source.UserID, Valid: true}
+
+	var reply string
+	switch event.Type {
+	case "follow":
+		reply = "Welcome to TableG. To get your notifications here, create a link code in TableG and send it in this chat."
+	case "unfollow":
+		if _, err := s.store.DeleteLineLinksByLineUserID(ctx, lineUserID); err != nil {
+			slog.ErrorContext(ctx, "Error unlinking a LINE account", "error", err)
+		}
+		return
+	case "message":
+		if event.Message.Type != "text" {
+			return
+		}
+		reply = s.linkLineAccount(ctx, lineUserID, strings.ToUpper(strings.TrimSpace(event.Message.Text)))
+	default:
+		return
+	}
+
+	if err := client.Reply(ctx, event.ReplyToken, reply); err != nil {
+		slog.WarnContext(ctx, "Error replying to a LINE event", "type", event.Type, "error", err)
+	}
+}
+
+// linkLineAccount links the LINE account to the user who created the code, and returns the reply
+func (s *Server) linkLineAccount(ctx context.Context, lineUserID pgtype.Text, code string) string {
+	if len(code) != lineLinkCodeLength {
+		return "Send the link code from TableG to get your notifications here."
+	}
+	link, err := s.store.LinkLineAccount(ctx, sqlc.LinkLineAccountParams{
+		LinkCode:   pgtype.Text{String: code, Valid: true},
+		LineUserID: lineUserID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "That link code is wrong or has expired. Create a new one in TableG and send it here."
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error linking a LINE account", "error", err)
+		return "TableG couldn't link your account, try again."
+	}
+	slog.InfoContext(ctx, "LINE account linked", "user_id", link.UserID)
+
+	user, err := s.store.GetUser(ctx, link.UserID)
+	if err != nil {
+		return "Linked. Your TableG notifications come here now."
+	}
+	return fmt.Sprintf("Linked to %s. Your TableG notifications come here now.", user.Username)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// Listings are filtered and sorted with the same query parameters:
+//
+//	sort=-created_at                   sorts by a field, descending with the leading -
+//	filter[status]=open                keeps the items whose field is the value
+//	filter[due_date][gte]=2024-01-01   keeps the items whose date is on or after the day, with
+//	                                   gt, lte and lt alike; eq is the same as no operator
+//
+// A filter can also be sent as a plain parameter, status=open, as older clients do. Each listing
+// declares its fields in a listSpec and parseListQuery refuses any other field or operator, and
+// values that don't parse, so only known columns reach a query. Filters and sorting end up as
+// parameters of the listing's sqlc query, which picks its ORDER BY from the field name.
+
+// filterKind is the type of a filterable field's value
+type filterKind int
+
+const (
+	textFilter filterKind = iota
+	intFilter
+	boolFilter
+	dateFilter // also takes the range operators
+)
+
+// listSpec declares what a listing can be filtered and sorted by
+type listSpec struct {
+	Filters map[string]filterKind
+	Sorts   []string
+	// Sort is the order used when none is asked for, like "-created_at"
+	Sort string
+}
+
+// listQuery is the filtering and sorting asked of a listing
+type listQuery struct {
+	SortBy   string
+	SortDesc bool
+	// Sorted reports whether the request asked for an order rather than getting Sort
+	Sorted bool
+
+	values map[string]string
+	ranges map[string]dateRange
+}
+
+// dateRange bounds a date field, an invalid end leaving that side open
+type dateRange struct {
+	from, to pgtype.Date
+}
+
+// parseListQuery reads the filters and sort of a listing from its query parameters
+func parseListQuery(query url.Values, spec listSpec) (listQuery, error) {
+	q := listQuery{values: make(map[string]string), ranges: make(map[string]dateRange)}
+	q.SortBy, q.SortDesc = splitSort(spec.Sort)
+
+	if sort := query.Get("sort"); sort != "" {
+		field, desc := splitSort(sort)
+		if !slices.Contains(spec.Sorts, field) {
+			return q, fmt.Errorf("invalid sort field: %s", field)
+		}
+		q.SortBy, q.SortDesc, q.Sorted = field, desc, true
+	}
+
+	for key, values := range query {
+		value := values[0]
+		field, op, bracketed := parseFilterKey(key)
+		if !bracketed {
+			// Plain parameters are only filters when the listing knows them, the rest are
+			// paging and the like
+			if _, ok := spec.Filters[key]; !ok {
+				continue
+			}
+			field = key
+		}
+
+		kind, ok := spec.Filters[field]
+		if !ok {
+			return q, fmt.Errorf("invalid filter field: %s", field)
+		}
+		if err := q.add(field, op, kind, value); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+// splitSort splits "-field" into the field and whether it sorts descending
+func splitSort(sort string) (string, bool) {
+	field, desc := strings.CutPrefix(sort, "-")
+	return field, desc
+}
+
+// parseFilterKey splits filter[field] and filter[field][op], reporting false for other keys
+func parseFilterKey(key string) (field, op string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "filter[")
+	if !ok || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	field, op, _ = strings.Cut(strings.TrimSuffix(rest, "]"), "][")
+	return field, op, true
+}
+
+// add checks a filter value against its field's type and records it
+func (q *listQuery) add(field, op string, kind filterKind, value string) error {
+	if kind != dateFilter {
+		if op != "" && op != "eq" {
+			return fmt.Errorf("filter %s doesn't take operator %s", field, op)
+		}
+		var err error
+		switch kind {
+		case intFilter:
+			_, err = strconv.ParseInt(value, 10, 32)
+		case boolFilter:
+			_, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for filter %s: %s", field, value)
+		}
+		q.values[field] = value
+		return nil
+	}
+
+	day, err := time.Parse(validate.DateLayout, value)
+	if err != nil {
+		return fmt.Errorf("invalid date for filter %s, expected YYYY-MM-DD: %s", field, value)
+	}
+	r := q.ranges[field]
+	switch op {
+	case "", "eq":
+		r.from, r.to = pgDate(day), pgDate(day)
+	case "gte":
+		r.from = pgDate(day)
+	case "gt":
+		r.from = pgDate(day.AddDate(0, 0, 1))
+	case "lte":
+		r.to = pgDate(day)
+	case "lt":
+		r.to = pgDate(day.AddDate(0, 0, -1))
+	default:
+		return fmt.Errorf("unknown operator for filter %s: %s", field, op)
+	}
+	q.ranges[field] = r
+	return nil
+}
+
+// pgDate wraps a day as a non-NULL date
+func pgDate(day time.Time) pgtype.Date {
+	return pgtype.Date{Time: day, Valid: true}
+}
+
+// text returns a filter's value, NULL when it isn't set
+func (q listQuery) text(field string) pgtype.Text {
+	value, ok := q.values[field]
+	return pgtype.Text{String: value, Valid: ok}
+}
+
+// int4 returns an integer filter's value, NULL when it isn't set
+func (q listQuery) int4(field string) pgtype.Int4 {
+	value, ok := q.values[field]
+	if !ok {
+		return pgtype.Int4{}
+	}
+	n, _ := strconv.ParseInt(value, 10, 32)
+	return pgtype.Int4{Int32: int32(n), Valid: true}
+}
+
+// boolean returns a boolean filter's value, false when it isn't set
+func (q listQuery) boolean(field string) bool {
+	b, _ := strconv.ParseBool(q.values[field])
+	return b
+}
+
+// dates returns the inclusive bounds asked of a date filter, NULL for an open end
+func (q listQuery) dates(field string) (from, to pgtype.Date) {
+	r := q.ranges[field]
+	return r.from, r.to
+}
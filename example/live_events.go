@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+const (
+	// liveEventBuffer is how many events a client may fall behind before it is disconnected
+	liveEventBuffer = 64
+	// liveEventHeartbeat is how often an idle stream sends a comment, so proxies keep it open
+	liveEventHeartbeat = 30 * time.Second
+	// liveEventRetry is how long browsers wait before reconnecting a dropped stream
+	liveEventRetry = 5 * time.Second
+)
+
+// liveEventTypes names the events of the tables the change feed announces
+var liveEventTypes = map[string]string{
+	"leave_logs":       "leave_log",
+	"task_logs":        "task_log",
+	"medical_expenses": "medical_expense",
+	"annual_records":   "annual_record",
+	"tasks":            "task",
+}
+
+// LiveEvent is a change sent to the clients of GET /api/events, which fetch what they show again.
+// Type is leave_log, task_log, medical_expense, annual_record or task, or resync when changes may
+// have been missed and everything should be fetched again.
+type LiveEvent struct {
+	Type   string `json:"type"`
+	Op     string `json:"op,omitempty"` // INSERT, UPDATE or DELETE
+	ID     int32  `json:"id,omitempty"`
+	UserID int32  `json:"userId,omitempty"` // The owner of the row, the creator of a task
+	Year   int32  `json:"year,omitempty"`
+}
+
+// liveEventSubscriber is one open event stream
+type liveEventSubscriber struct {
+	events   chan LiveEvent
+	tenantID int32 // 0 with a single tenant
+	userID   int32
+	admin    bool
+}
+
+// sees reports whether the subscriber may be told about a change: admins hear about every change
+// in their tenant, other users about tasks and their own rows
+func (s *liveEventSubscriber) sees(change db.Change) bool {
+	if s.tenantID != 0 && change.TenantID != s.tenantID {
+		return false
+	}
+	return s.admin || change.Table == "tasks" || change.UserID == s.userID
+}
+
+// LiveEventHub fans the changes of the change feed out to the open event streams
+type LiveEventHub struct {
+	mu          sync.Mutex
+	subscribers map[*liveEventSubscriber]struct{}
+}
+
+// NewLiveEventHub creates a hub without subscribers
+func NewLiveEventHub() *LiveEventHub {
+	return &LiveEventHub{subscribers: make(map[*liveEventSubscriber]struct{})}
+}
+
+func (h *LiveEventHub) subscribe(tenantID, userID int32, admin bool) *liveEventSubscriber {
+	subscriber := &liveEventSubscriber{
+		events:   make(chan LiveEvent, liveEventBuffer),
+		tenantID: tenantID,
+		userID:   userID,
+		admin:    admin,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (h *LiveEventHub) unsubscribe(subscriber *liveEventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(subscriber)
+}
+
+// drop removes a subscriber and closes its events, callers must hold the lock
+func (h *LiveEventHub) drop(subscriber *liveEventSubscriber) {
+	if _, ok := h.subscribers[subscriber]; ok {
+		delete(h.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
+
+// HandleChange sends a change of the change feed to the streams that may see it
+func (h *LiveEventHub) HandleChange(ctx context.Context, change db.Change) {
+	eventType, ok := liveEventTypes[change.Table]
+	if !ok {
+		return
+	}
+	event := LiveEvent{Type: eventType, Op: change.Op, ID: change.ID, UserID: change.UserID, Year: change.Year}
+	h.broadcast(event, func(subscriber *liveEventSubscriber) bool { return subscriber.sees(change) })
+}
+
+// HandleGap tells every stream to fetch everything again, for when the change feed reconnected
+// and may have missed changes
+func (h *LiveEventHub) HandleGap(ctx context.Context) {
+	h.broadcast(LiveEvent{Type: "resync"}, func(*liveEventSubscriber) bool { return true })
+}
+
+// broadcast sends the event without waiting. A subscriber whose buffer is full is disconnected,
+// the browser reconnects it and the client fetches what it shows again.
+func (h *LiveEventHub) broadcast(event LiveEvent, to func(*liveEventSubscriber) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for subscriber := range h.subscribers {
+		if !to(subscriber) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			h.drop(subscriber)
+		}
+	}
+}
+
+// getEvents streams the changes the caller may see as server-sent events until the client goes
+// away. EventSource can't send headers, so the token may come as ?access_token= instead.
+func (s *Server) getEvents(w http.ResponseWriter, r *http.Request) {
+	if s.database == nil || !s.config.Database.ChangeFeed {
+		respondWithError(w, http.StatusServiceUnavailable, "Live updates need the database change feed, which is off")
+		return
+	}
+	if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	user, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	ctx := r.Context()
+	tenantID, _ := db.TenantFromContext(ctx)
+	subscriber := s.liveEvents.subscribe(tenantID, user.ID, user.UserType == "admin")
+	defer s.liveEvents.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", liveEventRetry.Milliseconds())
+
+	controller := http.NewResponseController(w)
+	if err := controller.Flush(); err != nil {
+		slog.ErrorContext(ctx, "Event stream can't be flushed", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(liveEventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-subscriber.events:
+			if !ok {
+				// Fell behind, the browser reconnects
+				return
+			}
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
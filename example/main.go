@@ -3,62 +3,58 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"reflect"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/config"
 	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/migrations"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/logging"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/validate"
+	"github.com/kengtableg/pkeng-tableg/yearend"
 	_ "github.com/lib/pq"
-	"github.com/rs/cors"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// Global database connection
-var database *db.DB
-
-// UserResponse is the response format for user data
-type UserResponse struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	UserType  string    `json:"user_type"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
-
-// ErrorResponse represents an error message
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 func main() {
-	// Parse command line flags
-	migrate := flag.Bool("migrate", false, "Run database migration")
-	flag.Parse()
-
-	// Run migration if flag is set
-	if *migrate {
-		log.Println("Migration not implemented in this version")
-		return
+	// Stop on an invalid setting rather than run with a default the operator didn't ask for
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
+	logging.Setup(cfg.Log)
 
-	// Continue with normal server startup
-	startServer()
+	// Migrations and the other operational tasks are tablegctl commands
+	startServer(cfg)
 }
 
 // User Handlers
 
-func getUsers(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse query parameters
 	limit := 10
@@ -82,7 +78,7 @@ func getUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get users from database
-	users, err := database.ListUsers(ctx, sqlc.ListUsersParams{
+	users, err := s.store.ListUsers(ctx, sqlc.ListUsersParams{
 		RowLimit:  int32(limit),
 		RowOffset: int32(offset),
 	})
@@ -90,6 +86,11 @@ func getUsers(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching users: "+err.Error())
 		return
 	}
+	total, err := s.store.CountUsers(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting users: "+err.Error())
+		return
+	}
 
 	// Convert to response format
 	response := make([]UserResponse, 0, len(users))
@@ -97,11 +98,11 @@ func getUsers(w http.ResponseWriter, r *http.Request) {
 		response = append(response, userToResponse(user))
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
 }
 
-func getUser(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -110,7 +111,7 @@ func getUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := database.GetUser(ctx, int32(id))
+	user, err := s.store.GetUser(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "User not found")
 		return
@@ -119,24 +120,36 @@ func getUser(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, userToResponse(user))
 }
 
-func createUser(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	var params sqlc.CreateUserParams
-
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req UserCreateRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Hash the password with bcrypt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error hashing password")
 		return
 	}
-	params.Password = string(hashedPassword)
 
-	user, err := database.CreateUser(ctx, params)
+	// Leave the capacity NULL unless one is provided, the database defaults it to 1.0
+	var dailyCapacity pgtype.Numeric
+	if req.DailyCapacity != nil {
+		if dailyCapacity, err = pgconv.FromFloat(*req.DailyCapacity); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid daily capacity: "+err.Error())
+			return
+		}
+	}
+
+	user, err := s.store.CreateUser(ctx, sqlc.CreateUserParams{
+		Username:      strings.TrimSpace(req.Username),
+		Password:      string(hashedPassword),
+		UserType:      req.UserType,
+		Email:         req.Email,
+		DailyCapacity: dailyCapacity,
+	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating user: "+err.Error())
 		return
@@ -145,8 +158,8 @@ func createUser(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, userToResponse(user))
 }
 
-func updateUser(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -155,25 +168,50 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-		UserType string `json:"user_type"`
-		Email    string `json:"email"`
+	var params UserUpdateRequest
+	if !decodeRequest(w, r, &params) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
+	// Keep the current capacity unless a new one is provided
+	var dailyCapacity pgtype.Numeric
+	if params.DailyCapacity != nil {
+		if dailyCapacity, err = pgconv.FromFloat(*params.DailyCapacity); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid daily capacity: "+err.Error())
+			return
+		}
 	}
 
-	user, err := database.UpdateUser(ctx, sqlc.UpdateUserParams{
-		ID:       int32(id),
-		Username: params.Username,
-		Password: params.Password,
-		UserType: params.UserType,
-		Email:    params.Email,
+	// Keep the current department unless one is provided, which must be on the org chart
+	var department pgtype.Text
+	if params.Department != nil {
+		department = pgtype.Text{String: strings.TrimSpace(*params.Department), Valid: true}
+	}
+	if department.String != "" {
+		if _, err := s.store.GetDepartmentByName(ctx, department.String); errors.Is(err, pgx.ErrNoRows) {
+			errs := validate.Errors{}
+			errs.Add("department", "must be a department of the org chart")
+			respondWithValidationError(w, errs.Err())
+			return
+		} else if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching department: "+err.Error())
+			return
+		}
+	}
+
+	user, err := s.store.UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:            int32(id),
+		Username:      params.Username,
+		Password:      params.Password,
+		UserType:      params.UserType,
+		Email:         params.Email,
+		DailyCapacity: dailyCapacity,
+		Department:    department,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating user: "+err.Error())
 		return
@@ -182,8 +220,8 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, userToResponse(user))
 }
 
-func deleteUser(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -192,7 +230,7 @@ func deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteUser(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteUser(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting user: "+err.Error())
 		return
 	}
@@ -202,8 +240,8 @@ func deleteUser(w http.ResponseWriter, r *http.Request) {
 
 // Annual Record Handlers
 
-func getAnnualRecords(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getAnnualRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse query parameters
 	userID := r.URL.Query().Get("user_id")
@@ -217,7 +255,7 @@ func getAnnualRecords(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		records, err := database.ListAnnualRecordsByUser(ctx, int32(id))
+		records, err := s.store.ListAnnualRecordsByUser(ctx, int32(id))
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error fetching annual records: "+err.Error())
 			return
@@ -232,7 +270,7 @@ func getAnnualRecords(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		records, err := database.ListAnnualRecordsByYear(ctx, int32(y))
+		records, err := s.store.ListAnnualRecordsByYear(ctx, int32(y))
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error fetching annual records: "+err.Error())
 			return
@@ -244,8 +282,8 @@ func getAnnualRecords(w http.ResponseWriter, r *http.Request) {
 		// This would typically include pagination in a real-world application
 
 		// For now, we'll use a simple approach: query by the current year
-		currentYear := time.Now().Year()
-		records, err := database.ListAnnualRecordsByYear(ctx, int32(currentYear))
+		currentYear := s.thisYear()
+		records, err := s.store.ListAnnualRecordsByYear(ctx, int32(currentYear))
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error fetching annual records: "+err.Error())
 			return
@@ -255,12 +293,12 @@ func getAnnualRecords(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func getAnnualRecord(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getAnnualRecord(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Verify the current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -272,7 +310,7 @@ func getAnnualRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	record, err := database.GetAnnualRecord(ctx, int32(id))
+	record, err := s.store.GetAnnualRecord(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Annual record not found")
 		return
@@ -288,11 +326,11 @@ func getAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, record)
 }
 
-func createAnnualRecord(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createAnnualRecord(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Check if user is admin first
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -304,49 +342,37 @@ func createAnnualRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		UserId                 int32   `json:"userId"`
-		Year                   int32   `json:"year"`
-		QuotaPlanId            int32   `json:"quotaPlanId"`
-		RolloverVacationDay    float64 `json:"rolloverVacationDay"`
-		UsedVacationDay        float64 `json:"usedVacationDay"`
-		UsedSickLeaveDay       float64 `json:"usedSickLeaveDay"`
-		WorkedOnHolidayDay     float64 `json:"workedOnHolidayDay"`
-		WorkedDay              float64 `json:"workedDay"`
-		UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht"`
-	}
+	var req AnnualRecordRequest
 
 	// Decode request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Helper function to create a pgtype.Numeric from float64
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
-
 	// Create quota plan ID pgtype
 	var quotaPlanID pgtype.Int4
 	quotaPlanID.Int32 = req.QuotaPlanId
 	quotaPlanID.Valid = true
 
-	// Insert new record into database
-	if _, err := database.CreateAnnualRecord(ctx, sqlc.CreateAnnualRecordParams{
+	var numbers pgconv.Converter
+	recordParams := sqlc.CreateAnnualRecordParams{
 		UserID:                 req.UserId,
 		Year:                   req.Year,
 		QuotaPlanID:            quotaPlanID,
-		RolloverVacationDay:    newNumeric(req.RolloverVacationDay),
-		UsedVacationDay:        newNumeric(req.UsedVacationDay),
-		UsedSickLeaveDay:       newNumeric(req.UsedSickLeaveDay),
-		WorkedOnHolidayDay:     newNumeric(req.WorkedOnHolidayDay),
-		WorkedDay:              newNumeric(req.WorkedDay),
-		UsedMedicalExpenseBaht: newNumeric(req.UsedMedicalExpenseBaht),
-	}); err != nil {
+		RolloverVacationDay:    numbers.FromFloat(req.RolloverVacationDay),
+		UsedVacationDay:        numbers.FromFloat(req.UsedVacationDay),
+		UsedSickLeaveDay:       numbers.FromFloat(req.UsedSickLeaveDay),
+		WorkedOnHolidayDay:     numbers.FromFloat(req.WorkedOnHolidayDay),
+		WorkedDay:              numbers.FromFloat(req.WorkedDay),
+		UsedMedicalExpenseBaht: numbers.FromFloat(req.UsedMedicalExpenseBaht),
+	}
+	if err := numbers.Err(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
+		return
+	}
+
+	// Insert new record into database
+	if _, err := s.store.CreateAnnualRecord(ctx, recordParams); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating annual record: "+err.Error())
 		return
 	}
@@ -354,12 +380,12 @@ func createAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, map[string]string{"message": "Annual record created successfully"})
 }
 
-func updateAnnualRecord(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateAnnualRecord(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Verify the current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -372,7 +398,7 @@ func updateAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the record first to check permissions
-	record, err := database.GetAnnualRecord(ctx, int32(id))
+	record, err := s.store.GetAnnualRecord(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Annual record not found")
 		return
@@ -385,48 +411,56 @@ func updateAnnualRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		QuotaPlanId            int32   `json:"quotaPlanId"`
-		RolloverVacationDay    float64 `json:"rolloverVacationDay"`
-		UsedVacationDay        float64 `json:"usedVacationDay"`
-		UsedSickLeaveDay       float64 `json:"usedSickLeaveDay"`
-		WorkedOnHolidayDay     float64 `json:"workedOnHolidayDay"`
-		WorkedDay              float64 `json:"workedDay"`
-		UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht"`
-	}
+	var req AnnualRecordUpdateRequest
 
 	// Decode request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Helper function to create a pgtype.Numeric from float64
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
-
 	// Create quota plan ID pgtype
 	var quotaPlanID pgtype.Int4
 	quotaPlanID.Int32 = req.QuotaPlanId
 	quotaPlanID.Valid = true
 
-	// Update the record in the database
-	updatedRecord, err := database.UpdateAnnualRecord(ctx, sqlc.UpdateAnnualRecordParams{
+	var numbers pgconv.Converter
+	recordParams := sqlc.UpdateAnnualRecordParams{
 		UserID:                 record.UserID,
 		Year:                   record.Year,
 		QuotaPlanID:            quotaPlanID,
-		RolloverVacationDay:    newNumeric(req.RolloverVacationDay),
-		UsedVacationDay:        newNumeric(req.UsedVacationDay),
-		UsedSickLeaveDay:       newNumeric(req.UsedSickLeaveDay),
-		WorkedOnHolidayDay:     newNumeric(req.WorkedOnHolidayDay),
-		WorkedDay:              newNumeric(req.WorkedDay),
-		UsedMedicalExpenseBaht: newNumeric(req.UsedMedicalExpenseBaht),
+		RolloverVacationDay:    numbers.FromFloat(req.RolloverVacationDay),
+		UsedVacationDay:        numbers.FromFloat(req.UsedVacationDay),
+		UsedSickLeaveDay:       numbers.FromFloat(req.UsedSickLeaveDay),
+		WorkedOnHolidayDay:     numbers.FromFloat(req.WorkedOnHolidayDay),
+		WorkedDay:              numbers.FromFloat(req.WorkedDay),
+		UsedMedicalExpenseBaht: numbers.FromFloat(req.UsedMedicalExpenseBaht),
+		ExpectedUpdatedAt:      expectedUpdatedAt(req.UpdatedAt),
+	}
+	if err := numbers.Err(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
+		return
+	}
+
+	// Update the record in the database, recording the totals set as an adjustment of the balance
+	// events so syncing the record keeps them
+	var updatedRecord sqlc.AnnualRecord
+	err = s.store.WithTx(ctx, func(q sqlc.Querier) error {
+		var err error
+		if updatedRecord, err = q.UpdateAnnualRecord(ctx, recordParams); err != nil {
+			return err
+		}
+		return q.AdjustBalance(ctx, sqlc.AdjustBalanceParams{
+			RecordedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+			AnnualRecordID:   updatedRecord.ID,
+		})
 	})
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		if current, err := s.store.GetAnnualRecord(ctx, record.ID); err == nil {
+			respondWithConflict(w, "Annual record was changed by someone else", current)
+			return
+		}
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating annual record: "+err.Error())
 		return
@@ -435,12 +469,12 @@ func updateAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, updatedRecord)
 }
 
-func deleteAnnualRecord(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteAnnualRecord(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Verify the current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -453,7 +487,7 @@ func deleteAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the record first to check permissions
-	record, err := database.GetAnnualRecord(ctx, int32(id))
+	record, err := s.store.GetAnnualRecord(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Annual record with ID %d not found", id))
 		return
@@ -466,10 +500,9 @@ func deleteAnnualRecord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log deletion information
-	log.Printf("Deleting annual record ID %d for user %d, year %d", record.ID, record.UserID, record.Year)
+	slog.InfoContext(ctx, "Deleting annual record", "record_id", record.ID, "user_id", record.UserID, "year", record.Year)
 
-	if err := database.DeleteAnnualRecord(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteAnnualRecord(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting annual record: "+err.Error())
 		return
 	}
@@ -477,38 +510,27 @@ func deleteAnnualRecord(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func getUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
-	log.Printf("getUserAnnualRecords handler called with user ID: %s", vars["id"])
-
-	// Log request headers for debugging
-	log.Printf("==== Request Headers ====")
-	for name, values := range r.Header {
-		for _, value := range values {
-			log.Printf("%s: %s", name, value)
-		}
-	}
 
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf("Error: Invalid user ID: %s", vars["id"])
+		slog.WarnContext(ctx, "Invalid user ID", "id", vars["id"])
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
 	// Get the annual records for this user
-	records, err := database.ListAnnualRecordsByUser(ctx, int32(id))
+	records, err := s.store.ListAnnualRecordsByUser(ctx, int32(id))
 	if err != nil {
-		log.Printf("Error fetching annual records: %v", err)
+		slog.ErrorContext(ctx, "Error fetching annual records", "error", err)
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
-	log.Printf("Found %d annual records for user ID %d", len(records), id)
-
 	// If no records found for current year, create one
-	currentYear := time.Now().Year()
+	currentYear := s.thisYear()
 	hasCurrentYearRecord := false
 
 	for _, record := range records {
@@ -519,44 +541,34 @@ func getUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !hasCurrentYearRecord {
-		log.Printf("No record found for current year. Creating one...")
-
-		// Helper function to create pgtype.Numeric
-		newNumeric := func(f float64) pgtype.Numeric {
-			var n pgtype.Numeric
-			n.Valid = true
-			n.Scan(fmt.Sprintf("%.2f", f))
-			return n
-		}
+		slog.InfoContext(ctx, "No annual record for the current year, creating one", "user_id", id, "year", currentYear)
 
 		// Create a quota plan ID pgtype that is NULL
 		var quotaPlanID pgtype.Int4
 		quotaPlanID.Valid = false // This makes it NULL in the database
 
 		// Create a default annual record with NULL quota plan ID
-		newRecord, err := database.UpsertAnnualRecordForUser(ctx, sqlc.UpsertAnnualRecordForUserParams{
+		newRecord, err := s.store.UpsertAnnualRecordForUser(ctx, sqlc.UpsertAnnualRecordForUserParams{
 			UserID:                 int32(id),
 			Year:                   int32(currentYear),
 			QuotaPlanID:            quotaPlanID,
-			RolloverVacationDay:    newNumeric(0),
-			UsedVacationDay:        newNumeric(0),
-			UsedSickLeaveDay:       newNumeric(0),
-			WorkedOnHolidayDay:     newNumeric(0),
-			WorkedDay:              newNumeric(0),
-			UsedMedicalExpenseBaht: newNumeric(0),
+			RolloverVacationDay:    pgconv.MustFromFloat(0),
+			UsedVacationDay:        pgconv.MustFromFloat(0),
+			UsedSickLeaveDay:       pgconv.MustFromFloat(0),
+			WorkedOnHolidayDay:     pgconv.MustFromFloat(0),
+			WorkedDay:              pgconv.MustFromFloat(0),
+			UsedMedicalExpenseBaht: pgconv.MustFromFloat(0),
 		})
 
 		if err != nil {
-			log.Printf("Error creating annual record: %v", err)
+			slog.ErrorContext(ctx, "Error creating annual record", "user_id", id, "error", err)
 		} else {
-			log.Printf("Created annual record ID %d for user %d", newRecord.ID, id)
+			slog.InfoContext(ctx, "Created annual record", "record_id", newRecord.ID, "user_id", id)
 
 			// Fetch records again with the new record
-			records, err = database.ListAnnualRecordsByUser(ctx, int32(id))
+			records, err = s.store.ListAnnualRecordsByUser(ctx, int32(id))
 			if err != nil {
-				log.Printf("Error fetching annual records after creation: %v", err)
-			} else {
-				log.Printf("Retrieved %d records after creation", len(records))
+				slog.ErrorContext(ctx, "Error fetching annual records after creation", "error", err)
 			}
 		}
 	}
@@ -565,24 +577,13 @@ func getUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
 }
 
 // Get annual records for currently logged in user
-func getCurrentUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	log.Printf("getCurrentUserAnnualRecords handler called")
-
-	// Log all headers for debugging
-	log.Printf("==== Request Headers ====")
-	for name, values := range r.Header {
-		for _, value := range values {
-			log.Printf("%s: %s", name, value)
-		}
-	}
+func (s *Server) getCurrentUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Get the Authorization header
 	authHeader := r.Header.Get("Authorization")
-	log.Printf("Auth header: %s", authHeader)
-
 	if authHeader == "" {
-		log.Printf("No authorization token provided")
+		slog.DebugContext(ctx, "No authorization token provided")
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
@@ -590,46 +591,39 @@ func getCurrentUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
 	// Extract the token from the "Bearer <token>" format
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		log.Printf("Invalid authorization format: %s", authHeader)
+		slog.DebugContext(ctx, "Invalid authorization format")
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
-	token := tokenParts[1]
-	log.Printf("Token: %s", token)
-
 	// Extract the username from the token
+	token := tokenParts[1]
 	if !strings.HasPrefix(token, "dummy-token-") {
-		log.Printf("Invalid token format: %s", token)
+		slog.DebugContext(ctx, "Invalid token format")
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
 	username := strings.TrimPrefix(token, "dummy-token-")
-	log.Printf("Username extracted from token: %s", username)
 
 	// Look up the user by username
-	user, err := database.GetUserByUsername(ctx, username)
+	user, err := s.store.GetUserByUsername(ctx, username)
 	if err != nil {
-		log.Printf("Error fetching user by username %s: %v", username, err)
+		slog.WarnContext(ctx, "Error fetching user of token", "username", username, "error", err)
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
-	log.Printf("Found user: ID=%d, Username=%s", user.ID, user.Username)
-
 	// Get the annual records for this user
-	records, err := database.ListAnnualRecordsByUser(ctx, user.ID)
+	records, err := s.store.ListAnnualRecordsByUser(ctx, user.ID)
 	if err != nil {
-		log.Printf("Error fetching annual records: %v", err)
+		slog.ErrorContext(ctx, "Error fetching annual records", "error", err)
 		respondWithJSON(w, http.StatusOK, []interface{}{})
 		return
 	}
 
-	log.Printf("Found %d annual records for user ID %d", len(records), user.ID)
-
 	// Check if there's a record for the current year
-	currentYear := time.Now().Year()
+	currentYear := s.thisYear()
 	hasCurrentYearRecord := false
 
 	for _, record := range records {
@@ -641,44 +635,34 @@ func getCurrentUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
 
 	// If no record for current year, create one
 	if !hasCurrentYearRecord {
-		log.Printf("No record found for current year. Creating one...")
-
-		// Helper function to create pgtype.Numeric
-		newNumeric := func(f float64) pgtype.Numeric {
-			var n pgtype.Numeric
-			n.Valid = true
-			n.Scan(fmt.Sprintf("%.2f", f))
-			return n
-		}
+		slog.InfoContext(ctx, "No annual record for the current year, creating one", "user_id", user.ID, "year", currentYear)
 
 		// Create a quota plan ID pgtype that is NULL (not assigned to any specific plan)
 		var quotaPlanID pgtype.Int4
 		quotaPlanID.Valid = false // This makes it NULL in the database
 
 		// Create a default annual record with NULL quota plan ID
-		newRecord, err := database.UpsertAnnualRecordForUser(ctx, sqlc.UpsertAnnualRecordForUserParams{
+		newRecord, err := s.store.UpsertAnnualRecordForUser(ctx, sqlc.UpsertAnnualRecordForUserParams{
 			UserID:                 user.ID,
 			Year:                   int32(currentYear),
 			QuotaPlanID:            quotaPlanID,
-			RolloverVacationDay:    newNumeric(0),
-			UsedVacationDay:        newNumeric(0),
-			UsedSickLeaveDay:       newNumeric(0),
-			WorkedOnHolidayDay:     newNumeric(0),
-			WorkedDay:              newNumeric(0),
-			UsedMedicalExpenseBaht: newNumeric(0),
+			RolloverVacationDay:    pgconv.MustFromFloat(0),
+			UsedVacationDay:        pgconv.MustFromFloat(0),
+			UsedSickLeaveDay:       pgconv.MustFromFloat(0),
+			WorkedOnHolidayDay:     pgconv.MustFromFloat(0),
+			WorkedDay:              pgconv.MustFromFloat(0),
+			UsedMedicalExpenseBaht: pgconv.MustFromFloat(0),
 		})
 
 		if err != nil {
-			log.Printf("Error creating annual record: %v", err)
+			slog.ErrorContext(ctx, "Error creating annual record", "user_id", user.ID, "error", err)
 		} else {
-			log.Printf("Created annual record ID %d for user %d", newRecord.ID, user.ID)
+			slog.InfoContext(ctx, "Created annual record", "record_id", newRecord.ID, "user_id", user.ID)
 
 			// Fetch records again with the new record
-			records, err = database.ListAnnualRecordsByUser(ctx, user.ID)
+			records, err = s.store.ListAnnualRecordsByUser(ctx, user.ID)
 			if err != nil {
-				log.Printf("Error fetching annual records after creation: %v", err)
-			} else {
-				log.Printf("Retrieved %d records after creation", len(records))
+				slog.ErrorContext(ctx, "Error fetching annual records after creation", "error", err)
 			}
 		}
 	}
@@ -686,51 +670,39 @@ func getCurrentUserAnnualRecords(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, records)
 }
 
-func upsertAnnualRecordForUser(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) upsertAnnualRecordForUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var params struct {
-		UserID                 int32   `json:"user_id"`
-		Year                   int32   `json:"year"`
-		QuotaPlanID            int32   `json:"quota_plan_id"`
-		RolloverVacationDay    float64 `json:"rollover_vacation_day"`
-		UsedVacationDay        float64 `json:"used_vacation_day"`
-		UsedSickLeaveDay       float64 `json:"used_sick_leave_day"`
-		WorkedOnHolidayDay     float64 `json:"worked_on_holiday_day"`
-		WorkedDay              float64 `json:"worked_day"`
-		UsedMedicalExpenseBaht float64 `json:"used_medical_expense_baht"`
-	}
+	var params AnnualRecordUpsertRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	// Helper function to create a pgtype.Numeric from float64
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
-
 	// Create quota plan ID pgtype
 	var quotaPlanID pgtype.Int4
 	quotaPlanID.Int32 = params.QuotaPlanID
 	quotaPlanID.Valid = true
 
-	// Use upsert to create or update record
-	record, err := database.UpsertAnnualRecordForUser(ctx, sqlc.UpsertAnnualRecordForUserParams{
+	var numbers pgconv.Converter
+	recordParams := sqlc.UpsertAnnualRecordForUserParams{
 		UserID:                 params.UserID,
 		Year:                   params.Year,
 		QuotaPlanID:            quotaPlanID,
-		RolloverVacationDay:    newNumeric(params.RolloverVacationDay),
-		UsedVacationDay:        newNumeric(params.UsedVacationDay),
-		UsedSickLeaveDay:       newNumeric(params.UsedSickLeaveDay),
-		WorkedOnHolidayDay:     newNumeric(params.WorkedOnHolidayDay),
-		WorkedDay:              newNumeric(params.WorkedDay),
-		UsedMedicalExpenseBaht: newNumeric(params.UsedMedicalExpenseBaht),
-	})
+		RolloverVacationDay:    numbers.FromFloat(params.RolloverVacationDay),
+		UsedVacationDay:        numbers.FromFloat(params.UsedVacationDay),
+		UsedSickLeaveDay:       numbers.FromFloat(params.UsedSickLeaveDay),
+		WorkedOnHolidayDay:     numbers.FromFloat(params.WorkedOnHolidayDay),
+		WorkedDay:              numbers.FromFloat(params.WorkedDay),
+		UsedMedicalExpenseBaht: numbers.FromFloat(params.UsedMedicalExpenseBaht),
+	}
+	if err := numbers.Err(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
+		return
+	}
+
+	// Use upsert to create or update record
+	record, err := s.store.UpsertAnnualRecordForUser(ctx, recordParams)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error upserting annual record: "+err.Error())
 		return
@@ -739,16 +711,12 @@ func upsertAnnualRecordForUser(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, record)
 }
 
-func assignQuotaPlanToAllUsers(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) assignQuotaPlanToAllUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var params struct {
-		Year        int32 `json:"year"`
-		QuotaPlanID int32 `json:"quota_plan_id"`
-	}
+	var params QuotaPlanAssignRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
@@ -756,7 +724,7 @@ func assignQuotaPlanToAllUsers(w http.ResponseWriter, r *http.Request) {
 	quotaPlanID.Int32 = params.QuotaPlanID
 	quotaPlanID.Valid = true
 
-	err := database.AssignQuotaPlanToAllUsers(ctx, sqlc.AssignQuotaPlanToAllUsersParams{
+	err := s.store.AssignQuotaPlanToAllUsers(ctx, sqlc.AssignQuotaPlanToAllUsersParams{
 		Year:        params.Year,
 		QuotaPlanID: quotaPlanID,
 	})
@@ -768,20 +736,16 @@ func assignQuotaPlanToAllUsers(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Quota plan assigned to all users"})
 }
 
-func createNextYearAnnualRecords(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createNextYearAnnualRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var params struct {
-		ThisYear int32 `json:"this_year"`
-		NextYear int32 `json:"next_year"`
-	}
+	var params NextYearAnnualRecordsRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	records, err := database.CreateNextYearAnnualRecords(ctx, sqlc.CreateNextYearAnnualRecordsParams{
+	records, err := s.store.CreateNextYearAnnualRecords(ctx, sqlc.CreateNextYearAnnualRecordsParams{
 		ThisYear: params.ThisYear,
 		NextYear: params.NextYear,
 	})
@@ -794,21 +758,17 @@ func createNextYearAnnualRecords(w http.ResponseWriter, r *http.Request) {
 }
 
 // Login handler function
-func loginHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var loginRequest struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+	var loginRequest LoginRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&loginRequest); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid login request")
+	if !decodeRequest(w, r, &loginRequest) {
 		return
 	}
 
 	// Find user by username
-	user, err := database.GetUserByUsername(ctx, loginRequest.Username)
+	user, err := s.store.GetUserByUsername(ctx, loginRequest.Username)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid username or password")
 		return
@@ -823,10 +783,7 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Create a response with user info and a dummy token
 	// In a real app, you'd generate a JWT token with claims
-	response := struct {
-		Token string       `json:"token"`
-		User  UserResponse `json:"user"`
-	}{
+	response := LoginResponse{
 		Token: "dummy-token-" + user.Username, // Replace with real JWT token
 		User:  userToResponse(user),
 	}
@@ -838,8 +795,14 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 
 // Holiday Handlers
 
-func getHolidays(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getHolidays(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if version, err := s.store.GetHolidaysVersion(ctx); err != nil {
+		slog.WarnContext(ctx, "Error reading holidays version, answering without an ETag", "error", err)
+	} else if notModified(w, r, version.RowCount, version.LastUpdated) {
+		return
+	}
 
 	// Parse query parameters for pagination
 	limit := 100 // Default to 100 holidays
@@ -862,25 +825,28 @@ func getHolidays(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("Fetching holidays with limit=%d, offset=%d", limit, offset)
-
 	// Get holidays from database with pagination
-	holidays, err := database.ListHolidays(ctx, sqlc.ListHolidaysParams{
+	holidays, err := s.store.ListHolidays(ctx, sqlc.ListHolidaysParams{
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
 	if err != nil {
-		log.Printf("Error fetching holidays: %v", err)
+		slog.ErrorContext(ctx, "Error fetching holidays", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error fetching holidays: "+err.Error())
 		return
 	}
+	total, err := s.store.CountHolidays(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting holidays", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error counting holidays: "+err.Error())
+		return
+	}
 
-	log.Printf("Successfully fetched %d holidays", len(holidays))
-	respondWithJSON(w, http.StatusOK, holidays)
+	respondWithJSON(w, http.StatusOK, newPage(holidays, total, limit, offset))
 }
 
-func getHoliday(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getHoliday(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -889,7 +855,7 @@ func getHoliday(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	holiday, err := database.GetHoliday(ctx, int32(id))
+	holiday, err := s.store.GetHoliday(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Holiday not found")
 		return
@@ -898,31 +864,16 @@ func getHoliday(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, holiday)
 }
 
-func createHoliday(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createHoliday(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var params struct {
-		Date string `json:"date"`
-		Name string `json:"name"`
-		Note string `json:"note"`
-	}
+	var params HolidayRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		log.Printf("Error decoding request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	log.Printf("Creating holiday with params: %+v", params)
-
-	// Parse the date string to pgtype.Date
-	var date pgtype.Date
-	date.Valid = true
-	if err := date.Scan(params.Date); err != nil {
-		log.Printf("Error parsing date: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid date format")
-		return
-	}
+	date := requestDate(params.Date)
 
 	// Create a pgtype.Text for the note
 	var note pgtype.Text
@@ -930,23 +881,23 @@ func createHoliday(w http.ResponseWriter, r *http.Request) {
 	note.String = params.Note
 
 	// Create the holiday with error handling
-	holiday, err := database.CreateHoliday(ctx, sqlc.CreateHolidayParams{
+	holiday, err := s.store.CreateHoliday(ctx, sqlc.CreateHolidayParams{
 		Date: date,
 		Name: params.Name,
 		Note: note,
 	})
 	if err != nil {
-		log.Printf("Error creating holiday in database: %v", err)
+		slog.ErrorContext(ctx, "Error creating holiday", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error creating holiday: "+err.Error())
 		return
 	}
 
-	log.Printf("Holiday created successfully: %+v", holiday)
+	slog.InfoContext(ctx, "Created holiday", "holiday_id", holiday.ID, "date", params.Date)
 	respondWithJSON(w, http.StatusCreated, holiday)
 }
 
-func updateHoliday(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateHoliday(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -955,31 +906,20 @@ func updateHoliday(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params struct {
-		Date string `json:"date"`
-		Name string `json:"name"`
-		Note string `json:"note"`
-	}
+	var params HolidayRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	// Parse the date string to pgtype.Date
-	var date pgtype.Date
-	date.Valid = true
-	if err := date.Scan(params.Date); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format")
-		return
-	}
+	date := requestDate(params.Date)
 
 	// Create a pgtype.Text for the note
 	var note pgtype.Text
 	note.Valid = true
 	note.String = params.Note
 
-	holiday, err := database.UpdateHoliday(ctx, sqlc.UpdateHolidayParams{
+	holiday, err := s.store.UpdateHoliday(ctx, sqlc.UpdateHolidayParams{
 		ID:   int32(id),
 		Date: date,
 		Name: params.Name,
@@ -993,8 +933,8 @@ func updateHoliday(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, holiday)
 }
 
-func deleteHoliday(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteHoliday(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -1003,7 +943,7 @@ func deleteHoliday(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteHoliday(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteHoliday(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting holiday: "+err.Error())
 		return
 	}
@@ -1012,22 +952,12 @@ func deleteHoliday(w http.ResponseWriter, r *http.Request) {
 }
 
 // Handler for getting the current authenticated user
-func getCurrentUser(w http.ResponseWriter, r *http.Request) {
-	log.Printf("getCurrentUser handler called")
-	ctx := context.Background()
-
-	// Log all headers for debugging
-	log.Printf("==== Request Headers ====")
-	for name, values := range r.Header {
-		for _, value := range values {
-			log.Printf("%s: %s", name, value)
-		}
-	}
+func (s *Server) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Get the Authorization header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		log.Printf("No authorization token provided")
 		respondWithError(w, http.StatusUnauthorized, "No authorization token provided")
 		return
 	}
@@ -1035,37 +965,30 @@ func getCurrentUser(w http.ResponseWriter, r *http.Request) {
 	// Extract the token from the "Bearer <token>" format
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		log.Printf("Invalid authorization format: %s", authHeader)
 		respondWithError(w, http.StatusUnauthorized, "Invalid authorization format")
 		return
 	}
 
-	token := tokenParts[1]
-	log.Printf("Token: %s", token)
-
 	// Extract the username from the token
+	token := tokenParts[1]
 	if !strings.HasPrefix(token, "dummy-token-") {
-		log.Printf("Invalid token format: %s", token)
 		respondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
 	}
 
 	username := strings.TrimPrefix(token, "dummy-token-")
-	log.Printf("Username extracted from token: %s", username)
 
 	// Try to find user in database
-	user, err := database.GetUserByUsername(ctx, username)
+	user, err := s.store.GetUserByUsername(ctx, username)
 
 	if err != nil {
-		log.Printf("User not found in database: %v", err)
+		slog.WarnContext(ctx, "User of token not found", "username", username, "error", err)
 		respondWithError(w, http.StatusUnauthorized, "Invalid username or token")
 		return
 	}
 
 	// Return the user from database
-	response := userToResponse(user)
-	log.Printf("Found user in database: %+v", response)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, userToResponse(user))
 }
 
 // Helper Functions
@@ -1082,17 +1005,69 @@ func userToResponse(user sqlc.User) UserResponse {
 	}
 
 	return UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		UserType:  user.UserType,
-		Email:     user.Email,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		UserType:      user.UserType,
+		Email:         user.Email,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		DailyCapacity: numericToFloat64(user.DailyCapacity, 1.0),
+		Department:    user.Department.String,
 	}
 }
 
+// respondWithError answers with an error message, written in English and translated into the
+// language of the response
 func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, ErrorResponse{Error: message})
+	message = i18n.Translate(responseLanguage(w), message)
+	respondWithJSON(w, code, ErrorResponse{Error: message, RequestID: w.Header().Get(requestIDHeader)})
+}
+
+// decodeRequest reads the JSON body of r into dst and checks it against dst's validate tags. On a
+// body that isn't JSON it answers 400, on one breaking a rule 422 with every invalid field, and
+// returns false so the handler stops. Requests that aren't structs, like maps, have no tags and
+// are only decoded.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return false
+	}
+	if reflect.Indirect(reflect.ValueOf(dst)).Kind() != reflect.Struct {
+		return true
+	}
+	if err := validate.Struct(dst); err != nil {
+		respondWithValidationError(w, err)
+		return false
+	}
+	return true
+}
+
+// requestDate converts a date field already checked by the date rule, an empty one to NULL
+func requestDate(value string) pgtype.Date {
+	date, err := time.Parse(validate.DateLayout, value)
+	return pgtype.Date{Time: date, Valid: err == nil}
+}
+
+// respondWithValidationError answers 422 with the fields err, from validate, finds invalid, in
+// the language of the response
+func respondWithValidationError(w http.ResponseWriter, err error) {
+	language := responseLanguage(w)
+	var fields validate.Errors
+	if !errors.As(err, &fields) {
+		fields = validate.Errors{}
+	}
+	translated := make(validate.Errors, len(fields))
+	for field, message := range fields {
+		translated[field] = i18n.Translate(language, message)
+	}
+	if len(fields) > 0 {
+		err = translated
+	}
+	respondWithJSON(w, http.StatusUnprocessableEntity, ValidationErrorResponse{
+		Error:     i18n.Translate(language, "Invalid request: "+err.Error()),
+		Fields:    translated,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -1108,35 +1083,75 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
-// Function to create a default admin user if no admin exists
-func createDefaultAdminUser(ctx context.Context) {
-	// Try to create default admin user directly
-	log.Println("Ensuring default admin user exists...")
+// txResponseError ends a transaction with the given response instead of a plain 500
+type txResponseError struct {
+	code    int
+	payload interface{}
+}
+
+func (e *txResponseError) Error() string {
+	return fmt.Sprintf("transaction aborted with status %d", e.code)
+}
+
+// txError aborts a transaction, answering the request with an error message
+func txError(code int, message string) error {
+	return &txResponseError{code: code, payload: ErrorResponse{Error: message}}
+}
+
+// respondWithTxError answers a request whose transaction failed, using the response the
+// transaction function chose when there is one
+func respondWithTxError(w http.ResponseWriter, err error) {
+	var respErr *txResponseError
+	if errors.As(err, &respErr) {
+		payload := respErr.payload
+		if errResp, ok := payload.(ErrorResponse); ok {
+			errResp.RequestID = w.Header().Get(requestIDHeader)
+			payload = errResp
+		}
+		respondWithJSON(w, respErr.code, payload)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Error running transaction: "+err.Error())
+}
+
+// expectedUpdatedAt is the query argument for the updated_at a client last saw, NULL when the
+// client sent none and the update is applied unconditionally
+func expectedUpdatedAt(seen *time.Time) pgtype.Timestamptz {
+	if seen == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *seen, Valid: true}
+}
+
+// respondWithConflict answers a 409 for an update that lost against a concurrent edit
+func respondWithConflict(w http.ResponseWriter, message string, current interface{}) {
+	respondWithJSON(w, http.StatusConflict, ConflictResponse{Error: message, Current: current})
+}
 
+// Function to create a default admin user if no admin exists
+func createDefaultAdminUser(ctx context.Context, store db.Store, password string) {
 	// Check if the admin user already exists
-	_, err := database.GetUserByUsername(ctx, "admin")
+	_, err := store.GetUserByUsername(ctx, "admin")
 	if err == nil {
-		log.Println("Admin user already exists, skipping default admin creation")
+		slog.DebugContext(ctx, "Admin user already exists, skipping default admin creation")
 		return
 	}
 
-	// Get admin password from environment variable or use a secure default
-	adminPassword := os.Getenv("DEFAULT_ADMIN_PASSWORD")
+	// Use the configured admin password or a secure default
+	adminPassword := password
 	if adminPassword == "" {
 		// Generate a secure password if none provided
 		adminPassword = generateSecurePassword(16)
-		log.Printf("WARNING: Using generated admin password: %s", adminPassword)
-		log.Printf("Please set DEFAULT_ADMIN_PASSWORD env variable for a stable password")
 	}
 
 	// Create a default admin user
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Error hashing default admin password: %v", err)
+		slog.ErrorContext(ctx, "Error hashing default admin password", "error", err)
 		return
 	}
 
-	admin, err := database.CreateUser(ctx, sqlc.CreateUserParams{
+	admin, err := store.CreateUser(ctx, sqlc.CreateUserParams{
 		Username: "admin",
 		Password: string(hashedPassword),
 		UserType: "admin",
@@ -1144,39 +1159,39 @@ func createDefaultAdminUser(ctx context.Context) {
 	})
 
 	if err != nil {
-		log.Printf("Error creating default admin user: %v", err)
+		slog.ErrorContext(ctx, "Error creating default admin user", "error", err)
 	} else {
-		log.Printf("Default admin user created with username: %s", admin.Username)
+		slog.InfoContext(ctx, "Default admin user created", "username", admin.Username)
+		if password == "" {
+			printGeneratedPassword(ctx, admin.Username, adminPassword, "DEFAULT_ADMIN_PASSWORD")
+		}
 	}
 }
 
 // Function to create a default regular user if needed
-func createDefaultRegularUser(ctx context.Context) {
+func createDefaultRegularUser(ctx context.Context, store db.Store, password string) {
 	// Check if the user already exists
-	_, err := database.GetUserByUsername(ctx, "hr_user")
+	_, err := store.GetUserByUsername(ctx, "hr_user")
 	if err == nil {
-		log.Println("HR user already exists, skipping creation")
+		slog.DebugContext(ctx, "HR user already exists, skipping creation")
 		return
 	}
 
-	// Get user password from environment variable or use a secure default
-	userPassword := os.Getenv("DEFAULT_USER_PASSWORD")
+	// Use the configured user password or a secure default
+	userPassword := password
 	if userPassword == "" {
 		// Generate a secure password if none provided
 		userPassword = generateSecurePassword(16)
-		log.Printf("WARNING: Using generated user password: %s", userPassword)
-		log.Printf("Please set DEFAULT_USER_PASSWORD env variable for a stable password")
 	}
 
 	// Create a default HR user
-	log.Println("Creating default HR user...")
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userPassword), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Error hashing default HR user password: %v", err)
+		slog.ErrorContext(ctx, "Error hashing default HR user password", "error", err)
 		return
 	}
 
-	user, err := database.CreateUser(ctx, sqlc.CreateUserParams{
+	user, err := store.CreateUser(ctx, sqlc.CreateUserParams{
 		Username: "hr_user",
 		Password: string(hashedPassword),
 		UserType: "user",
@@ -1184,12 +1199,22 @@ func createDefaultRegularUser(ctx context.Context) {
 	})
 
 	if err != nil {
-		log.Printf("Error creating default HR user: %v", err)
+		slog.ErrorContext(ctx, "Error creating default HR user", "error", err)
 	} else {
-		log.Printf("Default HR user created with username: %s", user.Username)
+		slog.InfoContext(ctx, "Default HR user created", "username", user.Username)
+		if password == "" {
+			printGeneratedPassword(ctx, user.Username, userPassword, "DEFAULT_USER_PASSWORD")
+		}
 	}
 }
 
+// printGeneratedPassword shows the password generated for a default user once, on stderr rather
+// than through slog, so it never reaches log aggregation. Nobody could log in without it.
+func printGeneratedPassword(ctx context.Context, username, password, setting string) {
+	fmt.Fprintf(os.Stderr, "Generated password for the default user %q: %s\nChange it after signing in, or set %s for a stable one.\n", username, password, setting)
+	slog.WarnContext(ctx, "Generated a password for a default user and printed it to stderr", "username", username, "setting", setting)
+}
+
 // Helper function to generate a secure random password
 func generateSecurePassword(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()-_=+"
@@ -1206,38 +1231,38 @@ func generateSecurePassword(length int) string {
 }
 
 // Add quota plan handlers
-func getQuotaPlans(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getQuotaPlans(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	log.Println("getQuotaPlans handler called")
+	if version, err := s.store.GetQuotaPlansVersion(ctx); err != nil {
+		slog.WarnContext(ctx, "Error reading quota plans version, answering without an ETag", "error", err)
+	} else if notModified(w, r, version.RowCount, version.LastUpdated) {
+		return
+	}
 
-	plans, err := database.ListQuotaPlans(ctx)
+	plans, err := s.store.ListQuotaPlans(ctx)
 	if err != nil {
-		log.Printf("Error in getQuotaPlans: %v", err)
+		slog.ErrorContext(ctx, "Error fetching quota plans", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error fetching quota plans: "+err.Error())
 		return
 	}
 
-	log.Printf("Successfully fetched %d quota plans", len(plans))
 	respondWithJSON(w, http.StatusOK, plans)
 }
 
-func getQuotaPlan(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getQuotaPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
-	log.Printf("getQuotaPlan handler called with ID: %s", vars["id"])
-
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf("Error: Invalid quota plan ID: %s", vars["id"])
 		respondWithError(w, http.StatusBadRequest, "Invalid quota plan ID")
 		return
 	}
 
-	plan, err := database.GetQuotaPlan(ctx, int32(id))
+	plan, err := s.store.GetQuotaPlan(ctx, int32(id))
 	if err != nil {
-		log.Printf("Error fetching quota plan: %v", err)
+		slog.DebugContext(ctx, "Error fetching quota plan", "quota_plan_id", id, "error", err)
 		respondWithError(w, http.StatusNotFound, "Quota plan not found")
 		return
 	}
@@ -1245,42 +1270,34 @@ func getQuotaPlan(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, plan)
 }
 
-func createQuotaPlan(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createQuotaPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	var params struct {
-		PlanName                string  `json:"plan_name"`
-		Year                    int32   `json:"year"`
-		QuotaVacationDay        float64 `json:"quota_vacation_day"`
-		QuotaMedicalExpenseBaht float64 `json:"quota_medical_expense_baht"`
-		CreatedByUserID         int32   `json:"created_by_user_id"`
-	}
+	var params QuotaPlanRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &params) {
 		return
 	}
 
-	// Helper function to create a pgtype.Numeric from float64
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
-
 	// Create user ID pgtype
 	var createdByUserID pgtype.Int4
 	createdByUserID.Int32 = params.CreatedByUserID
 	createdByUserID.Valid = true
 
-	plan, err := database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+	var numbers pgconv.Converter
+	planParams := sqlc.CreateQuotaPlanParams{
 		PlanName:                params.PlanName,
 		Year:                    params.Year,
-		QuotaVacationDay:        newNumeric(params.QuotaVacationDay),
-		QuotaMedicalExpenseBaht: newNumeric(params.QuotaMedicalExpenseBaht),
+		QuotaVacationDay:        numbers.FromFloat(params.QuotaVacationDay),
+		QuotaMedicalExpenseBaht: numbers.FromFloat(params.QuotaMedicalExpenseBaht),
 		CreatedByUserID:         createdByUserID,
-	})
+	}
+	if err := numbers.Err(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
+		return
+	}
+
+	plan, err := s.store.CreateQuotaPlan(ctx, planParams)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating quota plan: "+err.Error())
 		return
@@ -1289,8 +1306,8 @@ func createQuotaPlan(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, plan)
 }
 
-func updateQuotaPlan(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateQuotaPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -1299,34 +1316,34 @@ func updateQuotaPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var params struct {
-		PlanName                string  `json:"plan_name"`
-		Year                    int32   `json:"year"`
-		QuotaVacationDay        float64 `json:"quota_vacation_day"`
-		QuotaMedicalExpenseBaht float64 `json:"quota_medical_expense_baht"`
+	var params QuotaPlanUpdateRequest
+
+	if !decodeRequest(w, r, &params) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	// Create the update parameters
+	planParams, err := quotaPlanUpdateParams(int32(id), params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid number: "+err.Error())
 		return
 	}
 
-	// Helper function to create a pgtype.Numeric from float64
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
+	// Hold the edit until it is approved when quota plan edits need approval
+	if s.holdQuotaPlanEdit(w, r, int32(id), params) {
+		return
 	}
 
-	// Create the update parameters
-	plan, err := database.UpdateQuotaPlan(ctx, sqlc.UpdateQuotaPlanParams{
-		ID:                      int32(id),
-		PlanName:                params.PlanName,
-		Year:                    params.Year,
-		QuotaVacationDay:        newNumeric(params.QuotaVacationDay),
-		QuotaMedicalExpenseBaht: newNumeric(params.QuotaMedicalExpenseBaht),
-	})
+	plan, err := s.store.UpdateQuotaPlan(ctx, planParams)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := s.store.GetQuotaPlan(ctx, int32(id))
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Quota plan not found")
+			return
+		}
+		respondWithConflict(w, "Quota plan was changed by someone else", current)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating quota plan: "+err.Error())
 		return
@@ -1335,8 +1352,8 @@ func updateQuotaPlan(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, plan)
 }
 
-func deleteQuotaPlan(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteQuotaPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -1345,16 +1362,17 @@ func deleteQuotaPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteQuotaPlan(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteQuotaPlan(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting quota plan: "+err.Error())
 		return
 	}
+	s.cancelApprovals(ctx, approval.KindQuotaPlan, int32(id))
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func getQuotaPlansByYear(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getQuotaPlansByYear(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	year, err := strconv.Atoi(vars["year"])
@@ -1363,7 +1381,13 @@ func getQuotaPlansByYear(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plans, err := database.ListQuotaPlansByYear(ctx, int32(year))
+	if version, err := s.store.GetQuotaPlansVersion(ctx); err != nil {
+		slog.WarnContext(ctx, "Error reading quota plans version, answering without an ETag", "error", err)
+	} else if notModified(w, r, version.RowCount, version.LastUpdated) {
+		return
+	}
+
+	plans, err := s.store.ListQuotaPlansByYear(ctx, int32(year))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching quota plans: "+err.Error())
 		return
@@ -1373,54 +1397,46 @@ func getQuotaPlansByYear(w http.ResponseWriter, r *http.Request) {
 }
 
 // ensureCurrentYearRecords checks if all users have records for the current year and creates them if needed
-func ensureCurrentYearRecords(ctx context.Context) {
-	currentYear := time.Now().Year()
-	log.Printf("Checking for annual records for the year %d", currentYear)
+func (s *Server) ensureCurrentYearRecords(ctx context.Context) {
+	currentYear := s.thisYear()
+	slog.DebugContext(ctx, "Checking for annual records", "year", currentYear)
 
 	// Get default quota plan for current year
-	defaultQuotaPlan, err := database.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{
+	defaultQuotaPlan, err := s.database.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{
 		PlanName: "Default",
 		Year:     int32(currentYear),
 	})
 
 	if err != nil {
-		log.Printf("Default quota plan for year %d not found. Checking for any plans this year...", currentYear)
+		slog.InfoContext(ctx, "Default quota plan not found, checking for any plans this year", "year", currentYear)
 
 		// Try to find any plan for current year
-		plans, err := database.ListQuotaPlansByYear(ctx, int32(currentYear))
+		plans, err := s.database.ListQuotaPlansByYear(ctx, int32(currentYear))
 		if err != nil || len(plans) == 0 {
-			log.Printf("No quota plans found for year %d. Checking previous year...", currentYear)
+			slog.InfoContext(ctx, "No quota plans found, checking the previous year", "year", currentYear)
 
 			// Get plans from previous year
-			prevYearPlans, err := database.ListQuotaPlansByYear(ctx, int32(currentYear-1))
+			prevYearPlans, err := s.database.ListQuotaPlansByYear(ctx, int32(currentYear-1))
 			if err != nil || len(prevYearPlans) == 0 {
-				log.Printf("No quota plans found for previous year (%d) either. Creating default plan.", currentYear-1)
+				slog.InfoContext(ctx, "No quota plans found for the previous year either, creating a default plan", "year", currentYear-1)
 
 				// Create a default plan for current year
 				var createdByUserID pgtype.Int4
 				createdByUserID.Valid = false
 
-				// Default values
-				newNumeric := func(f float64) pgtype.Numeric {
-					var n pgtype.Numeric
-					n.Valid = true
-					n.Scan(fmt.Sprintf("%.2f", f))
-					return n
-				}
-
-				defaultQuotaPlan, err = database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+				defaultQuotaPlan, err = s.database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
 					PlanName:                "Default",
 					Year:                    int32(currentYear),
-					QuotaVacationDay:        newNumeric(10.0),
-					QuotaMedicalExpenseBaht: newNumeric(20000.0),
+					QuotaVacationDay:        pgconv.MustFromFloat(10.0),
+					QuotaMedicalExpenseBaht: pgconv.MustFromFloat(20000.0),
 					CreatedByUserID:         createdByUserID,
 				})
 
 				if err != nil {
-					log.Printf("Error creating default quota plan: %v", err)
+					slog.ErrorContext(ctx, "Error creating default quota plan", "year", currentYear, "error", err)
 					return
 				}
-				log.Printf("Created default quota plan for year %d", currentYear)
+				slog.InfoContext(ctx, "Created default quota plan", "year", currentYear)
 			} else {
 				// Use the first plan from previous year as a template
 				defaultQuotaPlan = prevYearPlans[0]
@@ -1429,7 +1445,7 @@ func ensureCurrentYearRecords(ctx context.Context) {
 				var createdByUserID pgtype.Int4
 				createdByUserID.Valid = false
 
-				defaultQuotaPlan, err = database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
+				defaultQuotaPlan, err = s.database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
 					PlanName:                defaultQuotaPlan.PlanName,
 					Year:                    int32(currentYear),
 					QuotaVacationDay:        defaultQuotaPlan.QuotaVacationDay,
@@ -1438,10 +1454,10 @@ func ensureCurrentYearRecords(ctx context.Context) {
 				})
 
 				if err != nil {
-					log.Printf("Error creating quota plan for current year: %v", err)
+					slog.ErrorContext(ctx, "Error creating quota plan for the current year", "year", currentYear, "error", err)
 					return
 				}
-				log.Printf("Created quota plan for year %d based on previous year", currentYear)
+				slog.InfoContext(ctx, "Created quota plan based on the previous year", "year", currentYear)
 			}
 		} else {
 			// Use the first available plan for current year
@@ -1456,16 +1472,16 @@ func ensureCurrentYearRecords(ctx context.Context) {
 	}
 
 	// Create records for users who don't have them
-	records, err := database.CreateNextYearAnnualRecords(ctx, params)
+	records, err := s.database.CreateNextYearAnnualRecords(ctx, params)
 	if err != nil {
-		log.Printf("Error creating annual records for year %d: %v", currentYear, err)
+		slog.ErrorContext(ctx, "Error creating annual records", "year", currentYear, "error", err)
 		return
 	}
 
 	if len(records) > 0 {
-		log.Printf("Created %d annual records for year %d", len(records), currentYear)
+		slog.InfoContext(ctx, "Created annual records", "count", len(records), "year", currentYear)
 	} else {
-		log.Printf("All users already have annual records for year %d", currentYear)
+		slog.DebugContext(ctx, "All users already have annual records", "year", currentYear)
 	}
 
 	// Note: We've removed the automatic quota plan assignment that was previously here
@@ -1473,294 +1489,248 @@ func ensureCurrentYearRecords(ctx context.Context) {
 	// assigning the default one every time the server starts
 }
 
-// scheduleNextYearRecordsCreation sets up a scheduled job to create next year records
-func scheduleNextYearRecordsCreation() {
-	go func() {
-		for {
-			// Calculate time until next check (every day at midnight)
-			now := time.Now()
-			nextMidnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-			timeUntilMidnight := nextMidnight.Sub(now)
-
-			log.Printf("Next check for year-end scheduled in %v", timeUntilMidnight)
-
-			// Sleep until next midnight
-			time.Sleep(timeUntilMidnight)
-
-			// Check if it's December 31st
-			now = time.Now()
-			if now.Month() == time.December && now.Day() == 31 {
-				log.Println("It's December 31st - creating next year records")
-
-				ctx := context.Background()
-				thisYear := now.Year()
-				nextYear := thisYear + 1
-
-				// Create next year records for all users
-				params := sqlc.CreateNextYearAnnualRecordsParams{
-					ThisYear: int32(thisYear),
-					NextYear: int32(nextYear),
+// scheduleNextYearRecordsCreation sets up a scheduled job to create next year records at the
+// start of December 31st
+func (s *Server) scheduleNextYearRecordsCreation() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "next-year-records",
+		Description: "Create next year's annual records and default quota plan on December 31st",
+		Schedule:    scheduler.MustParse("0 0 31 12 *"),
+		Run: func(ctx context.Context) error {
+			now := time.Now().In(s.timeZone)
+			thisYear := now.Year()
+			// A run missed while no server was up and caught up on in January is for the year
+			// that just began
+			if now.Month() == time.January {
+				thisYear--
+			}
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				if err := s.createNextYearRecords(ctx, thisYear); err != nil {
+					errs = append(errs, err)
 				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
+
+// createNextYearRecords creates next year's annual records and default quota plan, returning
+// the error when the year could not be closed so the job is recorded as failed
+func (s *Server) createNextYearRecords(ctx context.Context, thisYear int) error {
+	result, err := yearend.Close(ctx, s.database, thisYear)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error closing the year", "year", thisYear, "error", err)
+		return err
+	}
+	slog.InfoContext(ctx, "Created annual records", "count", result.Records, "year", result.NextYear)
+	if result.QuotaPlan != nil {
+		slog.InfoContext(ctx, "Created quota plan", "year", result.NextYear, "plan", result.QuotaPlan.PlanName)
+	}
+	return nil
+}
+
+// schedulePeriodicSync sets up hourly synchronization of annual records, used instead of the
+// change feed when CHANGE_FEED=off
+func (s *Server) schedulePeriodicSync() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "annual-record-sync",
+		Description: "Sync this year's annual records with the leave, task and medical expense logs",
+		Schedule:    scheduler.Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			year := s.thisYear()
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				records, err := s.annualRecords.SyncAllRecordsForYear(ctx, int32(year))
 
-				records, err := database.CreateNextYearAnnualRecords(ctx, params)
 				if err != nil {
-					log.Printf("Error creating next year records: %v", err)
+					slog.ErrorContext(ctx, "Error during periodic annual record sync", "error", err)
+					errs = append(errs, err)
 				} else {
-					log.Printf("Successfully created %d records for year %d", len(records), nextYear)
+					slog.InfoContext(ctx, "Periodic annual record sync finished", "records", len(records))
 				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
 
-				// Look for a default quota plan for next year, and if not found, create one
-				_, err = database.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{
-					PlanName: "Default",
-					Year:     int32(nextYear),
-				})
+// scheduleClickUpTaskSync sets up periodic two-way synchronization of ClickUp linked tasks
+func (s *Server) scheduleClickUpTaskSync() {
+	if !s.taskSync.Enabled() {
+		slog.Info("ClickUp task sync not scheduled, the integration is disabled")
+		return
+	}
 
+	s.scheduler.Register(scheduler.Job{
+		Name:        "clickup-task-sync",
+		Description: "Sync the tasks linked to ClickUp both ways",
+		Schedule:    scheduler.Every(s.config.ClickUp.SyncInterval),
+		Run: func(ctx context.Context) error {
+			var errs []error
+			// Each tenant connects its own workspaces
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				summary, err := s.taskSync.SyncAllTasks(ctx)
 				if err != nil {
-					log.Printf("Default quota plan for year %d not found, creating one...", nextYear)
-
-					// Try to find current year's default plan to use as template
-					currentYearPlan, err := database.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{
-						PlanName: "Default",
-						Year:     int32(thisYear),
-					})
-
-					if err != nil {
-						// If no default plan, get any plan from current year
-						plans, err := database.ListQuotaPlansByYear(ctx, int32(thisYear))
-						if err == nil && len(plans) > 0 {
-							currentYearPlan = plans[0]
-						}
-					}
-
-					// Helper function for creating pgtype.Numeric
-					newNumeric := func(f float64) pgtype.Numeric {
-						var n pgtype.Numeric
-						n.Valid = true
-						n.Scan(fmt.Sprintf("%.2f", f))
-						return n
-					}
-
-					// Create a new plan
-					var createdByUserID pgtype.Int4
-					createdByUserID.Valid = false
-
-					// Use default values or copy from current year plan
-					planName := "Default"
-					quotaVacationDay := newNumeric(10.0)
-					quotaMedicalExpenseBaht := newNumeric(20000.0)
-
-					if err == nil {
-						// Use values from current year plan
-						planName = currentYearPlan.PlanName
-						quotaVacationDay = currentYearPlan.QuotaVacationDay
-						quotaMedicalExpenseBaht = currentYearPlan.QuotaMedicalExpenseBaht
-					}
-
-					_, err = database.CreateQuotaPlan(ctx, sqlc.CreateQuotaPlanParams{
-						PlanName:                planName,
-						Year:                    int32(nextYear),
-						QuotaVacationDay:        quotaVacationDay,
-						QuotaMedicalExpenseBaht: quotaMedicalExpenseBaht,
-						CreatedByUserID:         createdByUserID,
-					})
-
-					if err != nil {
-						log.Printf("Error creating quota plan for next year: %v", err)
-					} else {
-						log.Printf("Successfully created quota plan for year %d", nextYear)
-					}
+					slog.ErrorContext(ctx, "Error during ClickUp task sync", "error", err)
+					errs = append(errs, err)
+					return
 				}
-			}
-		}
-	}()
+				for _, workspace := range summary.Workspaces {
+					slog.InfoContext(ctx, "ClickUp task sync of workspace finished", "team_id", workspace.TeamID, "tasks", workspace.Total,
+						"pulled", workspace.Pulled, "pushed", workspace.Pushed, "conflicts", workspace.Conflicts, "failed", workspace.Failed)
+				}
+				slog.InfoContext(ctx, "ClickUp task sync finished", "tasks", summary.Total,
+					"pulled", summary.Pulled, "pushed", summary.Pushed, "conflicts", summary.Conflicts, "failed", summary.Failed)
+			})
+			return errors.Join(errs...)
+		},
+	})
 }
 
-// schedulePeriodicSync sets up hourly synchronization of annual records
-func schedulePeriodicSync() {
-	go func() {
-		for {
-			// Run every hour
-			time.Sleep(1 * time.Hour)
-
-			log.Printf("Running periodic annual record sync...")
-			ctx := context.Background()
-			year := time.Now().Year()
+// startServer initializes and starts the HTTP server
+func startServer(cfg *config.Config) {
+	ctx := context.Background()
 
-			syncService := NewAnnualRecordSyncService(database)
-			records, err := syncService.SyncAllRecordsForYear(ctx, int32(year))
+	// Connect to Postgres, or keep everything in memory for local development. The database
+	// stays nil in memory, so the server skips everything that needs Postgres itself.
+	var (
+		store    db.Store
+		database *db.DB
+	)
+	if cfg.Database.InMemory() {
+		store = dbtest.NewFake()
+		slog.Warn("Using the in-memory database, nothing is saved")
+	} else {
+		var err error
+		database, err = db.Connect(cfg.Database)
+		if err != nil {
+			fatal("Error connecting to database", "error", err)
+		}
+		defer database.Close()
 
+		// Bring the schema up to date before anything queries it
+		if cfg.Database.MigrateOnStartup {
+			applied, err := migrations.Up(ctx, database.Pool)
 			if err != nil {
-				log.Printf("Error during periodic sync: %v", err)
-			} else {
-				log.Printf("Successfully synced %d annual records during periodic sync", len(records))
+				fatal("Error running database migrations", "error", err)
 			}
+			slog.Info("Applied database migrations on startup", "count", applied)
 		}
-	}()
-	log.Printf("Periodic annual record sync scheduled (hourly)")
-}
 
-// LoggingMiddleware logs all requests
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-		log.Printf("Completed %s %s in %v", r.Method, r.URL.Path, time.Since(start))
-	})
-}
+		// Tenants are only kept apart by row-level security, which some roles are exempt from
+		if cfg.Database.MultiTenant {
+			if err := database.CheckTenantIsolation(ctx); err != nil {
+				fatal("MULTI_TENANT needs a database role that can't see other tenants' rows", "error", err)
+			}
+		}
+		store = database
+	}
 
-// startServer initializes and starts the HTTP server
-func startServer() {
-	var err error
+	// Create default users if they don't exist. With several tenants they go to the default
+	// one, the others get their first admin from tablegctl create-tenant.
+	defaultTenantCtx := db.WithTenant(ctx, db.DefaultTenantID)
+	createDefaultAdminUser(defaultTenantCtx, store, cfg.DefaultUsers.AdminPassword)
+	createDefaultRegularUser(defaultTenantCtx, store, cfg.DefaultUsers.UserPassword)
 
-	// Initialize database connection
-	database, err = db.New()
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
+	server := NewServer(cfg, store, database, nil)
+	server.startBackgroundJobs()
+
+	// Start server
+	port := fmt.Sprintf(":%d", cfg.Server.Port)
+	// Requests run under a context that is cancelled on shutdown, so in-flight queries stop
+	// instead of holding the process open
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:        port,
+		Handler:     server.Handler(),
+		BaseContext: func(net.Listener) context.Context { return shutdownCtx },
+	}
+	httpServers := []*http.Server{httpServer}
+
+	// Serve HTTPS, and HTTP/2 with it, when a certificate is configured
+	if cfg.TLS.Enabled() {
+		certificates, err := newCertificateReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			fatal("Error loading the TLS certificate", "error", err)
+		}
+		httpServer.TLSConfig = newTLSConfig(certificates)
+
+		if cfg.TLS.RedirectPort != 0 {
+			redirectServer := &http.Server{
+				Addr:    fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+				Handler: httpsRedirectHandler(cfg.Server.Port),
+			}
+			httpServers = append(httpServers, redirectServer)
+			go func() {
+				slog.Info("Redirecting HTTP to HTTPS", "port", cfg.TLS.RedirectPort)
+				if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					fatal("Error serving HTTP redirects", "error", err)
+				}
+			}()
+		}
 	}
-	defer database.Close()
 
-	// Create default users if they don't exist
-	ctx := context.Background()
-	createDefaultAdminUser(ctx)
-	createDefaultRegularUser(ctx)
-
-	// Ensure current year records exist
-	ensureCurrentYearRecords(ctx)
-
-	// Schedule next year records creation
-	scheduleNextYearRecordsCreation()
-
-	// Schedule periodic sync
-	schedulePeriodicSync()
-
-	// Set up router
-	r := mux.NewRouter()
-
-	// Apply logging middleware
-	r.Use(LoggingMiddleware)
-
-	// Initialize and register AnnualRecordSyncHandler
-	syncService := NewAnnualRecordSyncService(database)
-	syncHandler := NewAnnualRecordSyncHandler(syncService)
-	syncHandler.RegisterRoutes(r)
-
-	// Routes for user management
-	r.HandleFunc("/api/users", getUsers).Methods("GET")
-	r.HandleFunc("/api/users/{id}", getUser).Methods("GET")
-	r.HandleFunc("/api/users", createUser).Methods("POST")
-	r.HandleFunc("/api/users/{id}", updateUser).Methods("PUT")
-	r.HandleFunc("/api/users/{id}", deleteUser).Methods("DELETE")
-	r.HandleFunc("/api/login", loginHandler).Methods("POST")
-	r.HandleFunc("/api/current-user", getCurrentUser).Methods("GET")
-
-	// Routes for holidays
-	r.HandleFunc("/api/holidays", getHolidays).Methods("GET")
-	r.HandleFunc("/api/holidays/{id}", getHoliday).Methods("GET")
-	r.HandleFunc("/api/holidays", createHoliday).Methods("POST")
-	r.HandleFunc("/api/holidays/{id}", updateHoliday).Methods("PUT")
-	r.HandleFunc("/api/holidays/{id}", deleteHoliday).Methods("DELETE")
-
-	// Routes for annual records
-	r.HandleFunc("/api/annual-records", getAnnualRecords).Methods("GET")
-	r.HandleFunc("/api/annual-records/{id}", getAnnualRecord).Methods("GET")
-	r.HandleFunc("/api/annual-records", createAnnualRecord).Methods("POST")
-	r.HandleFunc("/api/annual-records/{id}", updateAnnualRecord).Methods("PUT")
-	r.HandleFunc("/api/annual-records/{id}", deleteAnnualRecord).Methods("DELETE")
-	r.HandleFunc("/api/users/{user_id}/annual-records", getUserAnnualRecords).Methods("GET")
-	r.HandleFunc("/api/current-user/annual-records", getCurrentUserAnnualRecords).Methods("GET")
-	r.HandleFunc("/api/users/{user_id}/annual-records/current-year", upsertAnnualRecordForUser).Methods("POST")
-	r.HandleFunc("/api/annual-records/quota-plan/{plan_id}/assign-to-all", assignQuotaPlanToAllUsers).Methods("POST")
-	r.HandleFunc("/api/annual-records/create-next-year", createNextYearAnnualRecords).Methods("POST")
-
-	// Routes for quota plans
-	r.HandleFunc("/api/quota-plans", getQuotaPlans).Methods("GET")
-	r.HandleFunc("/api/quota-plans/{id}", getQuotaPlan).Methods("GET")
-	r.HandleFunc("/api/quota-plans", createQuotaPlan).Methods("POST")
-	r.HandleFunc("/api/quota-plans/{id}", updateQuotaPlan).Methods("PUT")
-	r.HandleFunc("/api/quota-plans/{id}", deleteQuotaPlan).Methods("DELETE")
-	r.HandleFunc("/api/quota-plans/year/{year}", getQuotaPlansByYear).Methods("GET")
-
-	// Routes for medical expenses
-	r.HandleFunc("/api/medical-expenses", getMedicalExpenses).Methods("GET")
-	r.HandleFunc("/api/medical-expenses/{id}", getMedicalExpense).Methods("GET")
-	r.HandleFunc("/api/medical-expenses", createMedicalExpense).Methods("POST")
-	r.HandleFunc("/api/medical-expenses/{id}", updateMedicalExpense).Methods("PUT")
-	r.HandleFunc("/api/medical-expenses/{id}", deleteMedicalExpense).Methods("DELETE")
-	r.HandleFunc("/api/current-user/medical-expenses", getCurrentUserMedicalExpenses).Methods("GET")
-
-	// Routes for leave logs
-	r.HandleFunc("/api/leave-logs", getLeaveLogsList).Methods("GET")
-	r.HandleFunc("/api/leave-logs/{id}", getLeaveLog).Methods("GET")
-	r.HandleFunc("/api/leave-logs", createLeaveLog).Methods("POST")
-	r.HandleFunc("/api/leave-logs/{id}", updateLeaveLog).Methods("PUT")
-	r.HandleFunc("/api/leave-logs/{id}", deleteLeaveLog).Methods("DELETE")
-	r.HandleFunc("/api/current-user/leave-logs", getCurrentUserLeaveLogs).Methods("GET")
-
-	// Routes for ClickUp OAuth
-	r.HandleFunc("/api/oauth/clickup", initiateOAuthHandler).Methods("GET")
-	r.HandleFunc("/api/oauth/callback", oauthCallbackHandler).Methods("GET")
-	r.HandleFunc("/api/oauth/token", getCurrentTokenHandler).Methods("GET")
-
-	// Routes for task categories
-	r.HandleFunc("/api/task-categories", getTaskCategories).Methods("GET")
-	r.HandleFunc("/api/task-categories/{id}", getTaskCategory).Methods("GET")
-	r.HandleFunc("/api/task-categories", createTaskCategory).Methods("POST")
-	r.HandleFunc("/api/task-categories/{id}", updateTaskCategory).Methods("PUT")
-	r.HandleFunc("/api/task-categories/{id}", deleteTaskCategory).Methods("DELETE")
-	r.HandleFunc("/api/task-categories/hierarchical", getHierarchicalTaskCategories).Methods("GET")
-
-	// Routes for tasks
-	r.HandleFunc("/api/tasks", getTasks).Methods("GET")
-	r.HandleFunc("/api/tasks/{id}", getTask).Methods("GET")
-	r.HandleFunc("/api/tasks", createTask).Methods("POST")
-	r.HandleFunc("/api/tasks/{id}", updateTask).Methods("PUT")
-	r.HandleFunc("/api/tasks/{id}", deleteTask).Methods("DELETE")
-	r.HandleFunc("/api/categories/{category_id}/tasks", getTasksByCategory).Methods("GET")
-
-	// Routes for task estimates
-	r.HandleFunc("/api/task-estimates", getTaskEstimates).Methods("GET")
-	r.HandleFunc("/api/task-estimates/{id}", getTaskEstimate).Methods("GET")
-	r.HandleFunc("/api/task-estimates", createTaskEstimate).Methods("POST")
-	r.HandleFunc("/api/task-estimates/{id}", updateTaskEstimate).Methods("PUT")
-	r.HandleFunc("/api/task-estimates/{id}", deleteTaskEstimate).Methods("DELETE")
-	r.HandleFunc("/api/tasks/{task_id}/estimates", getTaskEstimatesByTask).Methods("GET")
-
-	// Routes for task logs
-	r.HandleFunc("/api/task-logs/by-date-range", getTaskLogsByDateRange).Methods("GET")
-	r.HandleFunc("/api/task-logs", getTaskLogs).Methods("GET")
-	r.HandleFunc("/api/task-logs/{id}", getTaskLog).Methods("GET")
-	r.HandleFunc("/api/task-logs", createTaskLog).Methods("POST")
-	r.HandleFunc("/api/task-logs/{id}", updateTaskLog).Methods("PUT")
-	r.HandleFunc("/api/task-logs/{id}", deleteTaskLog).Methods("DELETE")
-	r.HandleFunc("/api/tasks/{task_id}/logs", getTaskLogsByTask).Methods("GET")
-
-	// Set up CORS
-	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*", "http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "Content-Length", "Accept", "X-Requested-With", "Origin"},
-		AllowCredentials: true,
-		MaxAge:           86400, // 24 hours
-	}).Handler(r)
+	// Serve the gRPC services on their own port, with TLS when the API has it and without
+	// otherwise
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		var opts []grpc.ServerOption
+		if cfg.TLS.Enabled() {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(httpServer.TLSConfig)))
+		}
+		grpcServer = server.GRPCServer(opts...)
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPCPort))
+		if err != nil {
+			fatal("Error listening for gRPC", "error", err)
+		}
+		go func() {
+			slog.Info("Serving gRPC", "port", cfg.Server.GRPCPort, "tls", cfg.TLS.Enabled())
+			if err := grpcServer.Serve(listener); err != nil {
+				fatal("Error serving gRPC", "error", err)
+			}
+		}()
+	}
 
-	// Start server
-	port := ":8080"
-	// Check for environment variable
-	if envPort := os.Getenv("PORT"); envPort != "" {
-		port = ":" + envPort
+	go func() {
+		<-shutdownCtx.Done()
+		slog.Info("Shutting down server")
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, httpServer := range httpServers {
+			if err := httpServer.Shutdown(timeoutCtx); err != nil {
+				slog.Error("Error shutting down server", "error", err)
+			}
+		}
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+	}()
+
+	slog.Info("Server starting", "port", cfg.Server.Port, "tls", cfg.TLS.Enabled())
+	var err error
+	if cfg.TLS.Enabled() {
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		fatal("Error serving", "error", err)
 	}
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(http.ListenAndServe(port, corsHandler))
 }
 
 // Helper function to get current user from a request
-func getCurrentUserFromRequest(r *http.Request) (sqlc.User, error) {
-	ctx := context.Background()
+func getCurrentUserFromRequest(store sqlc.Querier, r *http.Request) (sqlc.User, error) {
+	return getCurrentUserFromAuthorization(r.Context(), store, r.Header.Get("Authorization"))
+}
+
+// getCurrentUserFromAuthorization returns the user of an Authorization header or the
+// authorization metadata of a gRPC call, "Bearer <token>"
+func getCurrentUserFromAuthorization(ctx context.Context, store sqlc.Querier, authHeader string) (sqlc.User, error) {
 	var emptyUser sqlc.User
 
-	// Get the Authorization header
-	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		return emptyUser, fmt.Errorf("no authorization token provided")
 	}
@@ -1782,7 +1752,7 @@ func getCurrentUserFromRequest(r *http.Request) (sqlc.User, error) {
 	username := strings.TrimPrefix(token, "dummy-token-")
 
 	// Look up the user by username
-	user, err := database.GetUserByUsername(ctx, username)
+	user, err := store.GetUserByUsername(ctx, username)
 	if err != nil {
 		return emptyUser, fmt.Errorf("invalid token - user not found")
 	}
@@ -1793,11 +1763,11 @@ func getCurrentUserFromRequest(r *http.Request) (sqlc.User, error) {
 // Medical Expense Handlers
 
 // Get medical expenses with pagination
-func getMedicalExpenses(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getMedicalExpenses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Check if user is admin
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -1834,35 +1804,40 @@ func getMedicalExpenses(w http.ResponseWriter, r *http.Request) {
 
 	// If we have a specific user ID, query that user's expenses
 	if userId > 0 {
-		expenses, err := database.ListMedicalExpensesByUser(ctx, sqlc.ListMedicalExpensesByUserParams{
+		expenses, err := s.store.ListMedicalExpensesByUser(ctx, sqlc.ListMedicalExpensesByUserParams{
 			UserID: int32(userId),
 			Limit:  int32(limit),
 			Offset: int32(offset),
 		})
 
 		if err != nil {
-			log.Printf("Error fetching medical expenses: %v", err)
+			slog.ErrorContext(ctx, "Error fetching medical expenses", "error", err)
+			respondWithError(w, http.StatusInternalServerError, "Error fetching medical expenses")
+			return
+		}
+		total, err := s.store.CountMedicalExpensesByUser(ctx, int32(userId))
+		if err != nil {
+			slog.ErrorContext(ctx, "Error counting medical expenses", "error", err)
 			respondWithError(w, http.StatusInternalServerError, "Error fetching medical expenses")
 			return
 		}
 
-		respondWithJSON(w, http.StatusOK, expenses)
+		respondWithJSON(w, http.StatusOK, newPage(expenses, total, limit, offset))
 		return
 	}
 
 	// No specific filters, return empty for now as we don't have a method to list all expenses
 	// In a production app, you'd implement a query to fetch all medical expenses with pagination
-	log.Printf("Listing all medical expenses is not implemented, returning empty array")
-	respondWithJSON(w, http.StatusOK, []interface{}{})
+	respondWithJSON(w, http.StatusOK, newPage([]sqlc.MedicalExpense{}, 0, limit, offset))
 }
 
 // Get single medical expense
-func getMedicalExpense(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getMedicalExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -1876,7 +1851,7 @@ func getMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the expense from database
-	expense, err := database.GetMedicalExpense(ctx, int32(id))
+	expense, err := s.store.GetMedicalExpense(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Medical expense not found")
 		return
@@ -1892,26 +1867,19 @@ func getMedicalExpense(w http.ResponseWriter, r *http.Request) {
 }
 
 // Create a new medical expense
-func createMedicalExpense(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createMedicalExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var req struct {
-		UserID      int32   `json:"user_id"`
-		Amount      float64 `json:"amount"`
-		ReceiptName string  `json:"receipt_name"`
-		ReceiptDate string  `json:"receipt_date"` // Format: YYYY-MM-DD
-		Note        string  `json:"note"`
-	}
+	var req MedicalExpenseRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -1921,21 +1889,7 @@ func createMedicalExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse the date
-	var receiptDate pgtype.Date
-	receiptDate.Valid = true
-	if err := receiptDate.Scan(req.ReceiptDate); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
-		return
-	}
-
-	// Helper function for numeric values
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
+	receiptDate := requestDate(req.ReceiptDate)
 
 	// Create text fields
 	var receiptName pgtype.Text
@@ -1946,10 +1900,16 @@ func createMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	note.Valid = true
 	note.String = req.Note
 
+	amount, err := pgconv.FromFloat(req.Amount)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid amount: "+err.Error())
+		return
+	}
+
 	// Create the expense
-	expense, err := database.CreateMedicalExpense(ctx, sqlc.CreateMedicalExpenseParams{
+	expense, err := s.store.CreateMedicalExpense(ctx, sqlc.CreateMedicalExpenseParams{
 		UserID:      req.UserID,
-		Amount:      newNumeric(req.Amount),
+		Amount:      amount,
 		ReceiptName: receiptName,
 		ReceiptDate: receiptDate,
 		Note:        note,
@@ -1960,7 +1920,7 @@ func createMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Extract year from receipt date for updating annual record
-	year := time.Now().Year()
+	year := s.thisYear()
 	if req.ReceiptDate != "" && len(req.ReceiptDate) >= 4 {
 		year, _ = strconv.Atoi(req.ReceiptDate[:4])
 	}
@@ -1968,18 +1928,36 @@ func createMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	// We'd normally update the annual record to reflect the new expense
 	// But due to the complexity of handling pgtype values, we'll skip this for now
 	// In a real implementation, you would update the annual record's used_medical_expense_baht value
-	log.Printf("Created medical expense of %.2f for user %d in year %d", req.Amount, req.UserID, year)
+	slog.InfoContext(ctx, "Created medical expense", "expense_id", expense.ID, "user_id", req.UserID, "year", year)
+
+	// Let the user know when an admin recorded it for them, and submit expenses users recorded
+	// for themselves for approval
+	if expense.UserID != currentUser.ID {
+		s.notify(ctx, expense.UserID, notificationExpenseRecorded, expenseRecordedEvent{
+			RecordedBy:  currentUser.Username,
+			Amount:      strconv.FormatFloat(req.Amount, 'f', 2, 64),
+			ReceiptName: req.ReceiptName,
+			ReceiptDate: expense.ReceiptDate.Time.Format("2006-01-02"),
+		})
+	} else if currentUser.UserType != "admin" {
+		s.submitApproval(ctx, approval.Submission{
+			Kind:        approval.KindMedicalExpense,
+			SubjectID:   expense.ID,
+			RequesterID: currentUser.ID,
+			Amount:      req.Amount,
+		})
+	}
 
 	respondWithJSON(w, http.StatusCreated, expense)
 }
 
 // Update a medical expense
-func updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -1993,7 +1971,7 @@ func updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the existing expense
-	existingExpense, err := database.GetMedicalExpense(ctx, int32(id))
+	existingExpense, err := s.store.GetMedicalExpense(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Medical expense not found")
 		return
@@ -2005,33 +1983,13 @@ func updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Amount      float64 `json:"amount"`
-		ReceiptName string  `json:"receipt_name"`
-		ReceiptDate string  `json:"receipt_date"` // Format: YYYY-MM-DD
-		Note        string  `json:"note"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
+	var req MedicalExpenseUpdateRequest
 
-	// Parse the date
-	var receiptDate pgtype.Date
-	receiptDate.Valid = true
-	if err := receiptDate.Scan(req.ReceiptDate); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Helper function for numeric values
-	newNumeric := func(f float64) pgtype.Numeric {
-		var n pgtype.Numeric
-		n.Valid = true
-		n.Scan(fmt.Sprintf("%.2f", f))
-		return n
-	}
+	receiptDate := requestDate(req.ReceiptDate)
 
 	// Create text fields
 	var receiptName pgtype.Text
@@ -2042,14 +2000,25 @@ func updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	note.Valid = true
 	note.String = req.Note
 
+	amount, err := pgconv.FromFloat(req.Amount)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid amount: "+err.Error())
+		return
+	}
+
 	// Update the expense
-	updatedExpense, err := database.UpdateMedicalExpense(ctx, sqlc.UpdateMedicalExpenseParams{
+	updatedExpense, err := s.store.UpdateMedicalExpense(ctx, sqlc.UpdateMedicalExpenseParams{
 		ID:          int32(id),
-		Amount:      newNumeric(req.Amount),
+		Amount:      amount,
 		ReceiptName: receiptName,
 		ReceiptDate: receiptDate,
 		Note:        note,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Deleted since it was fetched
+		respondWithError(w, http.StatusNotFound, "Medical expense not found")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating medical expense: "+err.Error())
 		return
@@ -2062,12 +2031,12 @@ func updateMedicalExpense(w http.ResponseWriter, r *http.Request) {
 }
 
 // Delete a medical expense
-func deleteMedicalExpense(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteMedicalExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -2081,7 +2050,7 @@ func deleteMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the existing expense
-	existingExpense, err := database.GetMedicalExpense(ctx, int32(id))
+	existingExpense, err := s.store.GetMedicalExpense(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Medical expense not found")
 		return
@@ -2094,10 +2063,11 @@ func deleteMedicalExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete the expense
-	if err := database.DeleteMedicalExpense(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteMedicalExpense(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting medical expense: "+err.Error())
 		return
 	}
+	s.cancelApprovals(ctx, approval.KindMedicalExpense, int32(id))
 
 	// We'd normally update the annual record to reflect the deleted expense
 	// But due to the complexity of handling pgtype values, we'll skip this for now
@@ -2106,29 +2076,17 @@ func deleteMedicalExpense(w http.ResponseWriter, r *http.Request) {
 }
 
 // Get current user's medical expenses with filtering by year
-func getCurrentUserMedicalExpenses(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-
-	log.Printf("==== getCurrentUserMedicalExpenses called ====")
-
-	// Log all headers for debugging
-	log.Printf("Headers:")
-	for name, values := range r.Header {
-		for _, value := range values {
-			log.Printf("%s: %s", name, value)
-		}
-	}
+func (s *Server) getCurrentUserMedicalExpenses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Get the current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
-		log.Printf("Error getting current user: %v", err)
+		slog.DebugContext(ctx, "Error getting current user", "error", err)
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	log.Printf("Current user: ID=%d, Username=%s, Type=%s", currentUser.ID, currentUser.Username, currentUser.UserType)
-
 	// Parse query parameters
 	limit := 50 // Default limit
 	offset := 0 // Default offset
@@ -2152,95 +2110,51 @@ func getCurrentUserMedicalExpenses(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("Query parameters: limit=%d, offset=%d, year=%d", limit, offset, year)
-
 	// If year is specified, filter by year
 	if year > 0 {
-		// The backend API correctly implements the `getCurrentUserMedicalExpenses` function
-		log.Printf("Fetching medical expenses by year=%d for user_id=%d", year, currentUser.ID)
-
-		// Use direct SQL query instead of the generated function which has parameter type issues
-		query := "SELECT id, user_id, amount, receipt_name, receipt_date, note, created_at FROM medical_expenses WHERE user_id = $1 AND EXTRACT(YEAR FROM receipt_date) = $2 ORDER BY receipt_date DESC"
-		rows, err := database.Pool.Query(ctx, query, currentUser.ID, year)
-
+		expenses, err := s.store.ListMedicalExpensesByYear(ctx, sqlc.ListMedicalExpensesByYearParams{
+			UserID: currentUser.ID,
+			Year:   int32(year),
+		})
 		if err != nil {
-			log.Printf("Error fetching medical expenses by year: %v", err)
+			slog.ErrorContext(ctx, "Error fetching medical expenses by year", "error", err)
 			respondWithError(w, http.StatusInternalServerError, "Error fetching medical expenses")
 			return
 		}
-		defer rows.Close()
-
-		// Parse the results manually
-		var expenses []sqlc.MedicalExpense
-		for rows.Next() {
-			var expense sqlc.MedicalExpense
-			if err := rows.Scan(
-				&expense.ID,
-				&expense.UserID,
-				&expense.Amount,
-				&expense.ReceiptName,
-				&expense.ReceiptDate,
-				&expense.Note,
-				&expense.CreatedAt,
-			); err != nil {
-				log.Printf("Error scanning expense row: %v", err)
-				continue
-			}
-			expenses = append(expenses, expense)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Printf("Error iterating expense rows: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Error processing medical expenses")
-			return
-		}
-
-		log.Printf("Found %d medical expenses for user_id=%d and year=%d", len(expenses), currentUser.ID, year)
-		if len(expenses) > 0 {
-			log.Printf("First expense: ID=%d, Amount=%v, ReceiptName=%v",
-				expenses[0].ID,
-				expenses[0].Amount,
-				expenses[0].ReceiptName)
-		}
 
-		respondWithJSON(w, http.StatusOK, expenses)
+		respondWithJSON(w, http.StatusOK, pageOf(expenses, limit, offset))
 		return
 	}
 
 	// No year filter, use pagination
-	log.Printf("Fetching all medical expenses for user_id=%d with limit=%d, offset=%d", currentUser.ID, limit, offset)
 
-	expenses, err := database.ListMedicalExpensesByUser(ctx, sqlc.ListMedicalExpensesByUserParams{
+	expenses, err := s.store.ListMedicalExpensesByUser(ctx, sqlc.ListMedicalExpensesByUserParams{
 		UserID: currentUser.ID,
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
 
 	if err != nil {
-		log.Printf("Error fetching medical expenses: %v", err)
+		slog.ErrorContext(ctx, "Error fetching medical expenses", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error fetching medical expenses")
 		return
 	}
-
-	log.Printf("Found %d medical expenses for user_id=%d", len(expenses), currentUser.ID)
-	if len(expenses) > 0 {
-		log.Printf("First expense: ID=%d, Amount=%v, ReceiptName=%v",
-			expenses[0].ID,
-			expenses[0].Amount,
-			expenses[0].ReceiptName)
+	total, err := s.store.CountMedicalExpensesByUser(ctx, currentUser.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting medical expenses", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error fetching medical expenses")
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, expenses)
+	respondWithJSON(w, http.StatusOK, newPage(expenses, total, limit, offset))
 }
 
 // Leave Log Handlers
 
 // Get leave logs with pagination
-func getLeaveLogsList(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-
+func (s *Server) getLeaveLogsList(w http.ResponseWriter, r *http.Request) {
 	// Check if user is admin
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -2252,106 +2166,16 @@ func getLeaveLogsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
-	limit := 50 // Default limit
-	offset := 0 // Default offset
-	userId := 0 // Optional user filter
-
-	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
-		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-
-	if offsetParam := r.URL.Query().Get("offset"); offsetParam != "" {
-		if parsedOffset, err := strconv.Atoi(offsetParam); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
-	}
-
-	if userIdParam := r.URL.Query().Get("user_id"); userIdParam != "" {
-		if parsedUserId, err := strconv.Atoi(userIdParam); err == nil && parsedUserId > 0 {
-			userId = parsedUserId
-		}
-	}
-
-	// If user_id is provided, filter by that user
-	if userId > 0 {
-		leaveLogs, err := database.ListLeaveLogsByUser(ctx, sqlc.ListLeaveLogsByUserParams{
-			UserID: int32(userId),
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
-
-		if err != nil {
-			log.Printf("Error fetching leave logs: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
-			return
-		}
-
-		// Enrich response with username
-		enrichedLogs := enrichLeaveLogsWithUsername(ctx, leaveLogs)
-		respondWithJSON(w, http.StatusOK, enrichedLogs)
-		return
-	}
-
-	// Return all leave logs with pagination if no user_id is specified
-	// This is a simple approach - in production you would implement a query to fetch all logs with proper pagination
-	users, err := database.ListUsers(ctx, sqlc.ListUsersParams{
-		RowOffset: 0,
-		RowLimit:  100, // Set a reasonable limit
-	})
-	if err != nil {
-		log.Printf("Error fetching users: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
-		return
-	}
-
-	allLogs := []map[string]interface{}{}
-	for _, user := range users {
-		logs, err := database.ListLeaveLogsByUser(ctx, sqlc.ListLeaveLogsByUserParams{
-			UserID: user.ID,
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
-		if err != nil {
-			continue
-		}
-
-		for _, log := range logs {
-			allLogs = append(allLogs, map[string]interface{}{
-				"id":         log.ID,
-				"user_id":    log.UserID,
-				"username":   user.Username,
-				"type":       log.Type,
-				"date":       log.Date,
-				"note":       log.Note,
-				"created_at": log.CreatedAt,
-			})
-		}
-	}
-
-	// Apply pagination to the collected logs
-	start := offset
-	end := offset + limit
-	if start >= len(allLogs) {
-		respondWithJSON(w, http.StatusOK, []interface{}{})
-		return
-	}
-	if end > len(allLogs) {
-		end = len(allLogs)
-	}
-
-	respondWithJSON(w, http.StatusOK, allLogs[start:end])
+	s.listLeaveLogs(w, r, leaveLogListSpec, pgtype.Int4{})
 }
 
 // Get a single leave log
-func getLeaveLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getLeaveLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -2365,7 +2189,7 @@ func getLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the leave log from database
-	leaveLog, err := database.GetLeaveLog(ctx, int32(id))
+	leaveLog, err := s.store.GetLeaveLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Leave log not found")
 		return
@@ -2378,51 +2202,34 @@ func getLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get username
-	user, err := database.GetUser(ctx, leaveLog.UserID)
+	user, err := s.store.GetUser(ctx, leaveLog.UserID)
 	username := "Unknown"
 	if err == nil {
 		username = user.Username
 	}
 
 	// Add username to response
-	enrichedLog := map[string]interface{}{
-		"id":         leaveLog.ID,
-		"user_id":    leaveLog.UserID,
-		"username":   username,
-		"type":       leaveLog.Type,
-		"date":       leaveLog.Date,
-		"note":       leaveLog.Note,
-		"created_at": leaveLog.CreatedAt,
-	}
+	enrichedLog := newLeaveLogResponse(leaveLog, username)
 
 	respondWithJSON(w, http.StatusOK, enrichedLog)
 }
 
 // Create a new leave log
-func createLeaveLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createLeaveLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var req struct {
-		UserID int32  `json:"user_id"`
-		Type   string `json:"type"`
-		Date   string `json:"date"`
-		Note   string `json:"note"`
-	}
+	var req LeaveLogRequest
 
-	// Parse request body
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
-	defer r.Body.Close()
 
 	// Admin can create leave logs for any user, regular users can only create for themselves
 	if currentUser.UserType != "admin" && currentUser.ID != req.UserID {
@@ -2430,28 +2237,7 @@ func createLeaveLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required fields
-	if req.Type == "" {
-		respondWithError(w, http.StatusBadRequest, "Leave type is required")
-		return
-	}
-
-	if req.Date == "" {
-		respondWithError(w, http.StatusBadRequest, "Date is required")
-		return
-	}
-
-	// Parse date
-	date, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
-		return
-	}
-
-	pgDate := pgtype.Date{
-		Time:  date,
-		Valid: true,
-	}
+	pgDate := requestDate(req.Date)
 
 	// Create note field
 	var note pgtype.Text
@@ -2463,7 +2249,7 @@ func createLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the leave log
-	leaveLog, err := database.CreateLeaveLog(ctx, sqlc.CreateLeaveLogParams{
+	leaveLog, err := s.store.CreateLeaveLog(ctx, sqlc.CreateLeaveLogParams{
 		UserID: req.UserID,
 		Type:   req.Type,
 		Date:   pgDate,
@@ -2471,54 +2257,54 @@ func createLeaveLog(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		log.Printf("Error creating leave log: %v", err)
+		slog.ErrorContext(ctx, "Error creating leave log", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error creating leave log")
 		return
 	}
 
 	// Get username
-	user, err := database.GetUser(ctx, leaveLog.UserID)
+	user, err := s.store.GetUser(ctx, leaveLog.UserID)
 	username := "Unknown"
 	if err == nil {
 		username = user.Username
 	}
 
 	// Add username to response
-	enrichedLog := map[string]interface{}{
-		"id":         leaveLog.ID,
-		"user_id":    leaveLog.UserID,
-		"username":   username,
-		"type":       leaveLog.Type,
-		"date":       leaveLog.Date,
-		"note":       leaveLog.Note,
-		"created_at": leaveLog.CreatedAt,
-	}
-
-	// Extract year from date for syncing
-	year := time.Now().Year()
-	if date.Year() > 0 {
-		year = date.Year()
-	}
-
-	// Sync the annual record for this user and year
-	syncService := NewAnnualRecordSyncService(database)
-	_, syncErr := syncService.SyncUserRecordForYear(ctx, leaveLog.UserID, int32(year))
-	if syncErr != nil {
-		log.Printf("Warning: Failed to sync annual record after creating leave log: %v", syncErr)
-	} else {
-		log.Printf("Successfully synced annual record for user %d, year %d after creating leave log", leaveLog.UserID, year)
+	enrichedLog := newLeaveLogResponse(leaveLog, username)
+
+	// Sync the annual record for the leave year
+	s.events.Publish(ctx, annualRecordChangeFor(leaveLog.UserID, pgDate.Time))
+
+	// Let the user know when an admin recorded it for them, and submit leave users recorded for
+	// themselves for approval, posting it to Slack while it waits
+	if leaveLog.UserID != currentUser.ID {
+		s.notify(ctx, leaveLog.UserID, notificationLeaveRecorded, leaveRecordedEvent{
+			RecordedBy: currentUser.Username,
+			Type:       leaveLog.Type,
+			Date:       leaveLog.Date.Time.Format("2006-01-02"),
+			Note:       leaveLog.Note.String,
+		})
+	} else if currentUser.UserType != "admin" {
+		if _, pending := s.submitApproval(ctx, approval.Submission{
+			Kind:        approval.KindLeave,
+			SubjectID:   leaveLog.ID,
+			RequesterID: currentUser.ID,
+			Amount:      1,
+		}); pending {
+			s.requestLeaveApproval(ctx, leaveLog)
+		}
 	}
 
 	respondWithJSON(w, http.StatusCreated, enrichedLog)
 }
 
 // Update an existing leave log
-func updateLeaveLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateLeaveLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -2532,7 +2318,7 @@ func updateLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch existing leave log
-	existingLeaveLog, err := database.GetLeaveLog(ctx, int32(id))
+	existingLeaveLog, err := s.store.GetLeaveLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Leave log not found")
 		return
@@ -2544,42 +2330,13 @@ func updateLeaveLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Type string `json:"type"`
-		Date string `json:"date"`
-		Note string `json:"note"`
-	}
-
-	// Parse request body
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
-	defer r.Body.Close()
-
-	// Validate required fields
-	if req.Type == "" {
-		respondWithError(w, http.StatusBadRequest, "Leave type is required")
-		return
-	}
-
-	if req.Date == "" {
-		respondWithError(w, http.StatusBadRequest, "Date is required")
-		return
-	}
+	var req LeaveLogUpdateRequest
 
-	// Parse date
-	date, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	pgDate := pgtype.Date{
-		Time:  date,
-		Valid: true,
-	}
+	pgDate := requestDate(req.Date)
 
 	// Create note field
 	var note pgtype.Text
@@ -2591,62 +2348,54 @@ func updateLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the leave log
-	updatedLeaveLog, err := database.UpdateLeaveLog(ctx, sqlc.UpdateLeaveLogParams{
-		ID:   int32(id),
-		Type: req.Type,
-		Date: pgDate,
-		Note: note,
+	updatedLeaveLog, err := s.store.UpdateLeaveLog(ctx, sqlc.UpdateLeaveLogParams{
+		ID:                int32(id),
+		Type:              req.Type,
+		Date:              pgDate,
+		Note:              note,
+		ExpectedUpdatedAt: expectedUpdatedAt(req.UpdatedAt),
 	})
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		if current, err := s.store.GetLeaveLog(ctx, int32(id)); err == nil {
+			respondWithConflict(w, "Leave log was changed by someone else", s.enrichLeaveLogsWithUsername(ctx, []sqlc.LeaveLog{current})[0])
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "Leave log not found")
+		return
+	}
 	if err != nil {
-		log.Printf("Error updating leave log: %v", err)
+		slog.ErrorContext(ctx, "Error updating leave log", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error updating leave log")
 		return
 	}
 
 	// Get username
-	user, err := database.GetUser(ctx, updatedLeaveLog.UserID)
+	user, err := s.store.GetUser(ctx, updatedLeaveLog.UserID)
 	username := "Unknown"
 	if err == nil {
 		username = user.Username
 	}
 
 	// Add username to response
-	enrichedLog := map[string]interface{}{
-		"id":         updatedLeaveLog.ID,
-		"user_id":    updatedLeaveLog.UserID,
-		"username":   username,
-		"type":       updatedLeaveLog.Type,
-		"date":       updatedLeaveLog.Date,
-		"note":       updatedLeaveLog.Note,
-		"created_at": updatedLeaveLog.CreatedAt,
-	}
-
-	// Extract year from date for syncing
-	year := time.Now().Year()
-	if updatedLeaveLog.Date.Time.Year() > 0 {
-		year = updatedLeaveLog.Date.Time.Year()
-	}
-
-	// Sync the annual record for this user and year
-	syncService := NewAnnualRecordSyncService(database)
-	_, syncErr := syncService.SyncUserRecordForYear(ctx, updatedLeaveLog.UserID, int32(year))
-	if syncErr != nil {
-		log.Printf("Warning: Failed to sync annual record after updating leave log: %v", syncErr)
-	} else {
-		log.Printf("Successfully synced annual record for user %d, year %d after updating leave log", updatedLeaveLog.UserID, year)
-	}
+	enrichedLog := newLeaveLogResponse(updatedLeaveLog, username)
+
+	// Sync both the previous and the new year in case the leave moved across years
+	s.events.Publish(ctx,
+		annualRecordChangeFor(existingLeaveLog.UserID, existingLeaveLog.Date.Time),
+		annualRecordChangeFor(updatedLeaveLog.UserID, pgDate.Time),
+	)
 
 	respondWithJSON(w, http.StatusOK, enrichedLog)
 }
 
 // Delete a leave log
-func deleteLeaveLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteLeaveLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -2660,7 +2409,7 @@ func deleteLeaveLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch existing leave log
-	existingLeaveLog, err := database.GetLeaveLog(ctx, int32(id))
+	existingLeaveLog, err := s.store.GetLeaveLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Leave log not found")
 		return
@@ -2672,48 +2421,63 @@ func deleteLeaveLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract user ID and year before deletion for syncing afterward
-	userID := existingLeaveLog.UserID
-	year := time.Now().Year()
-	if existingLeaveLog.Date.Time.Year() > 0 {
-		year = existingLeaveLog.Date.Time.Year()
-	}
-
 	// Delete the leave log
-	if err := database.DeleteLeaveLog(ctx, int32(id)); err != nil {
-		log.Printf("Error deleting leave log: %v", err)
+	if err := s.store.DeleteLeaveLog(ctx, int32(id)); err != nil {
+		slog.ErrorContext(ctx, "Error deleting leave log", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error deleting leave log")
 		return
 	}
 
-	// Sync the annual record for this user and year
-	syncService := NewAnnualRecordSyncService(database)
-	_, syncErr := syncService.SyncUserRecordForYear(ctx, userID, int32(year))
-	if syncErr != nil {
-		log.Printf("Warning: Failed to sync annual record after deleting leave log: %v", syncErr)
-	} else {
-		log.Printf("Successfully synced annual record for user %d, year %d after deleting leave log", userID, year)
-	}
+	// Sync the annual record for the year of the deleted leave log
+	s.events.Publish(ctx, annualRecordChangeFor(existingLeaveLog.UserID, existingLeaveLog.Date.Time))
+	s.cancelApprovals(ctx, approval.KindLeave, int32(id))
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Leave log deleted successfully"})
 }
 
 // Get leave logs for the current user
-func getCurrentUserLeaveLogs(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-
+func (s *Server) getCurrentUserLeaveLogs(w http.ResponseWriter, r *http.Request) {
 	// Check if user is authorized
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Parse query parameters
-	limit := 50     // Default limit
-	offset := 0     // Default offset
-	year := 0       // Optional year filter
-	leaveType := "" // Optional type filter
+	s.listLeaveLogs(w, r, currentUserLeaveLogListSpec, pgtype.Int4{Int32: currentUser.ID, Valid: true})
+}
+
+// leaveLogListSpec declares the filters and sort fields of GET /api/leave-logs
+var leaveLogListSpec = listSpec{
+	Filters: map[string]filterKind{
+		"user_id": intFilter,
+		"type":    textFilter,
+		"year":    intFilter,
+		"date":    dateFilter,
+	},
+	Sorts: []string{"date", "created_at", "type"},
+	Sort:  "-date",
+}
+
+// currentUserLeaveLogListSpec is leaveLogListSpec without the user filter, for the logged in
+// user's own logs
+var currentUserLeaveLogListSpec = listSpec{
+	Filters: map[string]filterKind{
+		"type": textFilter,
+		"year": intFilter,
+		"date": dateFilter,
+	},
+	Sorts: leaveLogListSpec.Sorts,
+	Sort:  leaveLogListSpec.Sort,
+}
+
+// listLeaveLogs answers a leave log listing filtered and sorted as the request asks within spec,
+// paged by offset or by cursor. A valid user keeps only that user's logs.
+func (s *Server) listLeaveLogs(w http.ResponseWriter, r *http.Request, spec listSpec, user pgtype.Int4) {
+	ctx := r.Context()
+
+	limit := 50 // Default limit
+	offset := 0 // Default offset
 
 	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
 		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 {
@@ -2727,75 +2491,131 @@ func getCurrentUserLeaveLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
-		if parsedYear, err := strconv.Atoi(yearParam); err == nil && parsedYear > 0 {
-			year = parsedYear
+	query, err := parseListQuery(r.URL.Query(), spec)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := sqlc.CountLeaveLogsParams{
+		UserID: query.int4("user_id"),
+		Type:   query.text("type"),
+		Year:   query.int4("year"),
+	}
+	if user.Valid {
+		filter.UserID = user
+	}
+	filter.DateFrom, filter.DateTo = query.dates("date")
+
+	if cursorRequested(r) {
+		if query.Sorted {
+			respondWithError(w, http.StatusBadRequest, "sort can't be used with cursor, cursor pages are newest first")
+			return
 		}
+		s.getLeaveLogsPage(w, r, filter, limit)
+		return
 	}
 
-	if typeParam := r.URL.Query().Get("type"); typeParam != "" {
-		leaveType = typeParam
+	leaveLogs, err := s.store.ListLeaveLogsWithUsername(ctx, sqlc.ListLeaveLogsWithUsernameParams{
+		UserID:    filter.UserID,
+		Type:      filter.Type,
+		Year:      filter.Year,
+		DateFrom:  filter.DateFrom,
+		DateTo:    filter.DateTo,
+		SortBy:    query.SortBy,
+		SortDesc:  query.SortDesc,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching leave logs", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
+		return
+	}
+	total, err := s.store.CountLeaveLogs(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting leave logs", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
+		return
 	}
 
-	var leaveLogs []sqlc.LeaveLog
-	var err2 error
+	respondWithJSON(w, http.StatusOK, newPage(leaveLogRowsResponse(leaveLogs), total, limit, offset))
+}
 
-	// If type filter is provided
-	if leaveType != "" {
-		leaveLogs, err2 = database.ListLeaveLogsByType(ctx, sqlc.ListLeaveLogsByTypeParams{
-			UserID: currentUser.ID,
-			Type:   leaveType,
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
-	} else {
-		// Otherwise, get all leave logs for the current user
-		leaveLogs, err2 = database.ListLeaveLogsByUser(ctx, sqlc.ListLeaveLogsByUserParams{
-			UserID: currentUser.ID,
-			Limit:  int32(limit),
-			Offset: int32(offset),
-		})
+// getLeaveLogsPage answers a leave log listing paged by cursor, newest logs first, keeping the
+// logs filter matches
+func (s *Server) getLeaveLogsPage(w http.ResponseWriter, r *http.Request, filter sqlc.CountLeaveLogsParams, limit int) {
+	ctx := r.Context()
+
+	cursor, err := decodePageCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params := sqlc.ListLeaveLogsAfterParams{
+		UserID:   filter.UserID,
+		Type:     filter.Type,
+		Year:     filter.Year,
+		DateFrom: filter.DateFrom,
+		DateTo:   filter.DateTo,
+		RowLimit: int32(limit + 1),
 	}
+	params.CursorCreatedAt, params.CursorID = cursor.keysetArgs()
 
-	if err2 != nil {
-		log.Printf("Error fetching leave logs: %v", err2)
+	leaveLogs, err := s.store.ListLeaveLogsAfter(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching leave logs", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
 		return
 	}
 
-	// If year filter is provided, filter the results
-	if year > 0 {
-		var filteredLogs []sqlc.LeaveLog
-		for _, log := range leaveLogs {
-			// Extract year from the date
-			if log.Date.Valid {
-				logYear := log.Date.Time.Year()
-				if logYear == year {
-					filteredLogs = append(filteredLogs, log)
-				}
-			}
-		}
-		leaveLogs = filteredLogs
+	total, err := s.store.CountLeaveLogs(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting leave logs", "error", err)
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave logs")
+		return
+	}
+
+	var next *string
+	if len(leaveLogs) > limit {
+		leaveLogs = leaveLogs[:limit]
+		next = nextCursor(leaveLogs[limit-1].CreatedAt, leaveLogs[limit-1].ID)
 	}
 
-	// Enrich response with username
-	enrichedLogs := enrichLeaveLogsWithUsername(ctx, leaveLogs)
-	respondWithJSON(w, http.StatusOK, enrichedLogs)
+	respondWithJSON(w, http.StatusOK, newCursorPage(s.enrichLeaveLogsWithUsername(ctx, leaveLogs), total, limit, next))
+}
+
+// leaveLogRowsResponse formats leave logs joined with their usernames like
+// enrichLeaveLogsWithUsername does
+func leaveLogRowsResponse(leaveLogs []sqlc.ListLeaveLogsWithUsernameRow) []LeaveLogResponse {
+	response := make([]LeaveLogResponse, 0, len(leaveLogs))
+	for _, log := range leaveLogs {
+		response = append(response, LeaveLogResponse{
+			ID:        log.ID,
+			UserID:    log.UserID,
+			Username:  log.Username,
+			Type:      log.Type,
+			Date:      log.Date,
+			Note:      log.Note,
+			CreatedAt: log.CreatedAt,
+			UpdatedAt: log.UpdatedAt,
+		})
+	}
+	return response
 }
 
 // Helper function to enrich leave logs with username
-func enrichLeaveLogsWithUsername(ctx context.Context, leaveLogs []sqlc.LeaveLog) []map[string]interface{} {
+func (s *Server) enrichLeaveLogsWithUsername(ctx context.Context, leaveLogs []sqlc.LeaveLog) []LeaveLogResponse {
 	// Create a map to store usernames by ID
 	usernames := make(map[int32]string)
 
 	// Create enriched response
-	enrichedLogs := make([]map[string]interface{}, 0, len(leaveLogs))
+	enrichedLogs := make([]LeaveLogResponse, 0, len(leaveLogs))
 
 	for _, log := range leaveLogs {
 		// Get username (either from cache or by querying)
 		username, ok := usernames[log.UserID]
 		if !ok {
-			user, err := database.GetUser(ctx, log.UserID)
+			user, err := s.store.GetUser(ctx, log.UserID)
 			if err == nil {
 				username = user.Username
 				usernames[log.UserID] = username // Cache for future use
@@ -2804,18 +2624,7 @@ func enrichLeaveLogsWithUsername(ctx context.Context, leaveLogs []sqlc.LeaveLog)
 			}
 		}
 
-		// Create enriched log entry
-		enrichedLog := map[string]interface{}{
-			"id":         log.ID,
-			"user_id":    log.UserID,
-			"username":   username,
-			"type":       log.Type,
-			"date":       log.Date,
-			"note":       log.Note,
-			"created_at": log.CreatedAt,
-		}
-
-		enrichedLogs = append(enrichedLogs, enrichedLog)
+		enrichedLogs = append(enrichedLogs, newLeaveLogResponse(log, username))
 	}
 
 	return enrichedLogs
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+)
+
+// NotificationPreferenceResponse is the channels the user gets a kind of notification on
+type NotificationPreferenceResponse struct {
+	Kind        string `json:"kind"` // e.g. leave_recorded
+	Description string `json:"description"`
+	Email       bool   `json:"email"`
+	Line        bool   `json:"line"` // Only once the user linked a LINE account
+	Push        bool   `json:"push"` // Only to the devices the user registered
+}
+
+// NotificationPreferencesRequest is the body of PATCH /api/current-user/notification-preferences,
+// the channels to send each kind in it on. The kinds and channels left out stay as they are.
+type NotificationPreferencesRequest map[string]NotificationChannelsRequest
+
+// NotificationChannelsRequest turns the channels of a kind of notification on or off. A bare
+// true or false turns every channel on or off.
+type NotificationChannelsRequest struct {
+	Email *bool `json:"email"`
+	Line  *bool `json:"line"`
+	Push  *bool `json:"push"`
+}
+
+func (c *NotificationChannelsRequest) UnmarshalJSON(data []byte) error {
+	var all bool
+	if err := json.Unmarshal(data, &all); err == nil {
+		c.Email, c.Line, c.Push = &all, &all, &all
+		return nil
+	}
+	type channels NotificationChannelsRequest
+	return json.Unmarshal(data, (*channels)(c))
+}
+
+// getNotificationPreferences handles GET /api/current-user/notification-preferences
+func (s *Server) getNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	response, err := s.notificationPreferencesResponse(r, currentUser.ID, responseLanguage(w))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading notification preferences: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// updateNotificationPreferences handles PATCH /api/current-user/notification-preferences
+func (s *Server) updateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload, send the channels to turn on or off for each kind as an object")
+		return
+	}
+	for kind := range req {
+		if _, ok := notificationTemplates[kind]; !ok {
+			respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: unknown notification kind "+kind)
+			return
+		}
+	}
+
+	err = s.store.WithTx(r.Context(), func(q sqlc.Querier) error {
+		prefs, err := q.ListNotificationPreferences(r.Context(), currentUser.ID)
+		if err != nil {
+			return err
+		}
+		for kind, channels := range req {
+			params := sqlc.SetNotificationPreferenceParams{UserID: currentUser.ID, Kind: kind, Email: true, Line: true, Push: true}
+			for _, pref := range prefs {
+				if pref.Kind == kind {
+					params.Email, params.Line, params.Push = pref.Email, pref.Line, pref.Push
+				}
+			}
+			if channels.Email != nil {
+				params.Email = *channels.Email
+			}
+			if channels.Line != nil {
+				params.Line = *channels.Line
+			}
+			if channels.Push != nil {
+				params.Push = *channels.Push
+			}
+			if _, err := q.SetNotificationPreference(r.Context(), params); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving notification preferences: "+err.Error())
+		return
+	}
+
+	response, err := s.notificationPreferencesResponse(r, currentUser.ID, responseLanguage(w))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading notification preferences: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// notificationPreferencesResponse lists every kind of notification, on every channel the user
+// didn't turn it off on, described in the language
+func (s *Server) notificationPreferencesResponse(r *http.Request, userID int32, language i18n.Language) ([]NotificationPreferenceResponse, error) {
+	prefs, err := s.store.ListNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]NotificationPreferenceResponse, 0, len(notificationKinds))
+	for _, kind := range notificationKinds {
+		description := i18n.Translate(language, notificationTemplates[kind].description)
+		pref := NotificationPreferenceResponse{Kind: kind, Description: description, Email: true, Line: true, Push: true}
+		for _, row := range prefs {
+			if row.Kind == kind {
+				pref.Email, pref.Line, pref.Push = row.Email, row.Line, row.Push
+			}
+		}
+		response = append(response, pref)
+	}
+	return response, nil
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/template"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/mailer"
+	"github.com/kengtableg/pkeng-tableg/queue"
+)
+
+// Users are notified when someone else changes their records: an admin recording leave or a
+// medical expense for them or resetting their password, an approver deciding on leave, a medical
+// expense or another request of theirs, or a colleague assigning them a task. Approvers are
+// reminded of the requests that have waited on them for over a day. Notifications go out over each channel that is set up, email,
+// LINE and push to the mobile app, to the users with an address there. A notification is
+// rendered and stored in the notifications table, the outbox, once per channel when the change
+// is made, and a queued job sends it, so it goes out once the channel is back when it is down.
+// Users turn each kind off per channel in their notification preferences, and get notifications
+// in the language they picked.
+
+// The kinds of notification
+const (
+	notificationLeaveRecorded    = "leave_recorded"
+	notificationLeaveDecided     = "leave_decided"
+	notificationExpenseRecorded  = "expense_recorded"
+	notificationExpenseDecided   = "expense_decided"
+	notificationTaskAssigned     = "task_assigned"
+	notificationApprovalDecided  = "approval_decided"
+	notificationApprovalReminder = "approval_reminder"
+	notificationPasswordReset    = "password_reset"
+)
+
+// The channels notifications go out over
+const (
+	notificationChannelEmail = "email"
+	notificationChannelLINE  = "line"
+	notificationChannelPush  = "push"
+)
+
+// notificationSent is the status of a notification the channel took
+const notificationSent = "sent"
+
+// notificationKinds are the kinds of notification in the order users see them in their
+// preferences
+var notificationKinds = []string{
+	notificationLeaveRecorded, notificationLeaveDecided, notificationExpenseRecorded, notificationExpenseDecided,
+	notificationTaskAssigned, notificationApprovalDecided, notificationApprovalReminder, notificationPasswordReset,
+}
+
+// notificationChannels are the channels in the order notifications are queued
+var notificationChannels = []string{notificationChannelEmail, notificationChannelLINE, notificationChannelPush}
+
+// notificationJobs are the kinds of the jobs sending notifications, by channel
+var notificationJobs = map[string]string{
+	notificationChannelEmail: jobNotificationEmail,
+	notificationChannelLINE:  jobNotificationLINE,
+	notificationChannelPush:  jobNotificationPush,
+}
+
+// Notifier delivers notifications over a channel
+type Notifier interface {
+	// Recipient returns the user's address on the channel, empty when they have none
+	Recipient(ctx context.Context, user sqlc.User) (string, error)
+	// Send delivers a notification, failing with queue.Permanent when trying again won't help
+	Send(ctx context.Context, recipient, subject, body string) error
+}
+
+// emailNotifier emails notifications to the address of the user's account
+type emailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func (n emailNotifier) Recipient(ctx context.Context, user sqlc.User) (string, error) {
+	return user.Email, nil
+}
+
+func (n emailNotifier) Send(ctx context.Context, recipient, subject, body string) error {
+	err := n.mailer.Send(ctx, mailer.Message{To: recipient, Subject: subject, Body: body})
+	if mailer.IsPermanent(err) {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// leaveRecordedEvent is the data of a leave_recorded notification
+type leaveRecordedEvent struct {
+	RecordedBy string
+	Type       string
+	Date       string
+	Note       string
+}
+
+// leaveDecidedEvent is the data of a leave_decided notification
+type leaveDecidedEvent struct {
+	DecidedBy string
+	Decision  string // approved or rejected
+	Type      string
+	Date      string
+}
+
+// expenseRecordedEvent is the data of an expense_recorded notification
+type expenseRecordedEvent struct {
+	RecordedBy  string
+	Amount      string
+	ReceiptName string
+	ReceiptDate string
+}
+
+// expenseDecidedEvent is the data of an expense_decided notification
+type expenseDecidedEvent struct {
+	DecidedBy   string
+	Decision    string // approved or rejected
+	Amount      string
+	ReceiptDate string
+	Comment     string
+}
+
+// taskAssignedEvent is the data of a task_assigned notification
+type taskAssignedEvent struct {
+	AssignedBy string
+	TaskID     int32
+	TaskTitle  string
+}
+
+// approvalDecidedEvent is the data of an approval_decided notification, for requests other than
+// leave and medical expenses
+type approvalDecidedEvent struct {
+	DecidedBy string
+	Decision  string // approved or rejected
+	Subject   string // What the request is about, e.g. "medical expense of 1200.00 baht dated 2026-03-02"
+	Comment   string
+	Outcome   string // What the decision changed, e.g. "The edit was applied."
+}
+
+// approvalReminderEvent is the data of an approval_reminder notification
+type approvalReminderEvent struct {
+	Count int    // How many requests wait on the approver
+	Since string // The day the one waiting longest reached its current step
+}
+
+// passwordResetEvent is the data of a password_reset notification
+type passwordResetEvent struct {
+	ResetBy string
+}
+
+// notificationData is what the templates render: the user the notification goes to and the event
+type notificationData struct {
+	User  sqlc.User
+	Event any
+}
+
+// notificationTemplate is the message of a kind of notification, the subject being the first
+// line on channels without subjects
+type notificationTemplate struct {
+	description string // Shown with the user's preferences
+	subject     *template.Template
+	body        *template.Template
+}
+
+func newNotificationTemplate(kind, description, subject, body string) notificationTemplate {
+	return notificationTemplate{
+		description: description,
+		subject:     template.Must(template.New(kind + " subject").Parse(subject)),
+		body:        template.Must(template.New(kind + " body").Parse(body)),
+	}
+}
+
+// newTranslatedNotificationTemplate returns a kind of notification in a language other than
+// English. Its templates translate the English words of events, such as approved or vacation,
+// with t.
+func newTranslatedNotificationTemplate(language i18n.Language, kind, subject, body string) notificationTemplate {
+	funcs := template.FuncMap{"t": func(message string) string { return i18n.Translate(language, message) }}
+	return notificationTemplate{
+		subject: template.Must(template.New(kind + " subject").Funcs(funcs).Parse(subject)),
+		body:    template.Must(template.New(kind + " body").Funcs(funcs).Parse(body)),
+	}
+}
+
+var notificationTemplates = map[string]notificationTemplate{
+	notificationLeaveRecorded: newNotificationTemplate(notificationLeaveRecorded,
+		"An admin recorded leave for you",
+		"{{.Event.Type}} leave recorded for {{.Event.Date}}",
+		`Hi {{.User.Username}},
+
+{{.Event.RecordedBy}} recorded {{.Event.Type}} leave for you on {{.Event.Date}}.
+{{- if .Event.Note}}
+
+Note: {{.Event.Note}}
+{{- end}}
+
+Your leave balance in TableG includes it now.
+`),
+	notificationLeaveDecided: newNotificationTemplate(notificationLeaveDecided,
+		"A manager or admin approved or rejected leave you recorded",
+		"Your {{.Event.Type}} leave on {{.Event.Date}} was {{.Event.Decision}}",
+		`Hi {{.User.Username}},
+
+{{.Event.DecidedBy}} {{.Event.Decision}} your {{.Event.Type}} leave on {{.Event.Date}}.
+{{- if eq .Event.Decision "rejected"}}
+
+It no longer counts in your leave balance in TableG.
+{{- end}}
+`),
+	notificationExpenseRecorded: newNotificationTemplate(notificationExpenseRecorded,
+		"An admin recorded a medical expense for you",
+		"Medical expense of {{.Event.Amount}} baht recorded",
+		`Hi {{.User.Username}},
+
+{{.Event.RecordedBy}} recorded a medical expense of {{.Event.Amount}} baht for you
+{{- if .Event.ReceiptName}}, receipt {{.Event.ReceiptName}}{{end}}, dated {{.Event.ReceiptDate}}.
+
+Your medical expense balance in TableG includes it now.
+`),
+	notificationExpenseDecided: newNotificationTemplate(notificationExpenseDecided,
+		"An approver approved or rejected a medical expense you recorded",
+		"Your medical expense of {{.Event.Amount}} baht was {{.Event.Decision}}",
+		`Hi {{.User.Username}},
+
+{{.Event.DecidedBy}} {{.Event.Decision}} your medical expense of {{.Event.Amount}} baht dated {{.Event.ReceiptDate}}.
+{{- if .Event.Comment}}
+
+Comment: {{.Event.Comment}}
+{{- end}}
+{{- if eq .Event.Decision "rejected"}}
+
+It no longer counts in your medical expense balance in TableG.
+{{- end}}
+`),
+	notificationTaskAssigned: newNotificationTemplate(notificationTaskAssigned,
+		"Someone assigned a task to you",
+		"You were assigned to task #{{.Event.TaskID}}{{if .Event.TaskTitle}}, {{.Event.TaskTitle}}{{end}}",
+		`Hi {{.User.Username}},
+
+{{.Event.AssignedBy}} assigned you to task #{{.Event.TaskID}}{{if .Event.TaskTitle}}, {{.Event.TaskTitle}}{{end}}.
+`),
+	notificationApprovalDecided: newNotificationTemplate(notificationApprovalDecided,
+		"An approver approved or rejected your timesheet or quota plan edit",
+		"Your {{.Event.Subject}} was {{.Event.Decision}}",
+		`Hi {{.User.Username}},
+
+{{.Event.DecidedBy}} {{.Event.Decision}} your {{.Event.Subject}}.
+{{- if .Event.Comment}}
+
+Comment: {{.Event.Comment}}
+{{- end}}
+{{- if .Event.Outcome}}
+
+{{.Event.Outcome}}
+{{- end}}
+`),
+	notificationApprovalReminder: newNotificationTemplate(notificationApprovalReminder,
+		"Requests have waited on your approval for over a day",
+		"{{if eq .Event.Count 1}}A request waits{{else}}{{.Event.Count}} requests wait{{end}} on your approval",
+		`Hi {{.User.Username}},
+
+{{if eq .Event.Count 1}}A request has{{else}}{{.Event.Count}} requests have{{end}} waited on your approval{{if gt .Event.Count 1}}, the oldest{{end}} since {{.Event.Since}}.
+
+Approve or reject {{if eq .Event.Count 1}}it{{else}}them{{end}} from your approvals inbox in TableG.
+`),
+	notificationPasswordReset: newNotificationTemplate(notificationPasswordReset,
+		"An admin reset your password",
+		"Your TableG password was reset",
+		`Hi {{.User.Username}},
+
+{{.Event.ResetBy}} reset your TableG password. Ask them for the new one to log in with.
+
+If you didn't ask for this, tell them right away.
+`),
+}
+
+// translatedNotificationTemplates are the kinds of notification in the languages other than
+// English, by language
+var translatedNotificationTemplates = map[i18n.Language]map[string]notificationTemplate{
+	i18n.Thai: {
+		notificationLeaveRecorded: newTranslatedNotificationTemplate(i18n.Thai, notificationLeaveRecorded,
+			"มีการบันทึก{{t .Event.Type}} วันที่ {{.Event.Date}}",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.RecordedBy}} บันทึก{{t .Event.Type}}ให้คุณ วันที่ {{.Event.Date}}
+{{- if .Event.Note}}
+
+หมายเหตุ: {{.Event.Note}}
+{{- end}}
+
+ยอดวันลาของคุณใน TableG นับรวมรายการนี้แล้ว
+`),
+		notificationLeaveDecided: newTranslatedNotificationTemplate(i18n.Thai, notificationLeaveDecided,
+			"ผลการพิจารณา{{t .Event.Type}} วันที่ {{.Event.Date}}: {{t .Event.Decision}}",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.DecidedBy}} {{t .Event.Decision}}{{t .Event.Type}}ของคุณ วันที่ {{.Event.Date}}
+{{- if eq .Event.Decision "rejected"}}
+
+รายการนี้ไม่นับในยอดวันลาของคุณใน TableG แล้ว
+{{- end}}
+`),
+		notificationExpenseRecorded: newTranslatedNotificationTemplate(i18n.Thai, notificationExpenseRecorded,
+			"มีการบันทึกค่ารักษาพยาบาล {{.Event.Amount}} บาท",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.RecordedBy}} บันทึกค่ารักษาพยาบาล {{.Event.Amount}} บาทให้คุณ
+{{- if .Event.ReceiptName}} ใบเสร็จ {{.Event.ReceiptName}}{{end}} ลงวันที่ {{.Event.ReceiptDate}}
+
+ยอดค่ารักษาพยาบาลของคุณใน TableG นับรวมรายการนี้แล้ว
+`),
+		notificationExpenseDecided: newTranslatedNotificationTemplate(i18n.Thai, notificationExpenseDecided,
+			"ผลการพิจารณาค่ารักษาพยาบาล {{.Event.Amount}} บาท: {{t .Event.Decision}}",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.DecidedBy}} {{t .Event.Decision}}ค่ารักษาพยาบาล {{.Event.Amount}} บาทของคุณ ลงวันที่ {{.Event.ReceiptDate}}
+{{- if .Event.Comment}}
+
+ความคิดเห็น: {{.Event.Comment}}
+{{- end}}
+{{- if eq .Event.Decision "rejected"}}
+
+รายการนี้ไม่นับในยอดค่ารักษาพยาบาลของคุณใน TableG แล้ว
+{{- end}}
+`),
+		notificationTaskAssigned: newTranslatedNotificationTemplate(i18n.Thai, notificationTaskAssigned,
+			"คุณได้รับมอบหมายงาน #{{.Event.TaskID}}{{if .Event.TaskTitle}} {{.Event.TaskTitle}}{{end}}",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.AssignedBy}} มอบหมายงาน #{{.Event.TaskID}}{{if .Event.TaskTitle}} {{.Event.TaskTitle}}{{end}} ให้คุณ
+`),
+		notificationApprovalDecided: newTranslatedNotificationTemplate(i18n.Thai, notificationApprovalDecided,
+			"ผลการพิจารณา{{t .Event.Subject}}: {{t .Event.Decision}}",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.DecidedBy}} {{t .Event.Decision}}คำขอของคุณ: {{t .Event.Subject}}
+{{- if .Event.Comment}}
+
+ความคิดเห็น: {{.Event.Comment}}
+{{- end}}
+{{- if .Event.Outcome}}
+
+{{t .Event.Outcome}}
+{{- end}}
+`),
+		notificationApprovalReminder: newTranslatedNotificationTemplate(i18n.Thai, notificationApprovalReminder,
+			"มีคำขอ {{.Event.Count}} รายการรอการอนุมัติจากคุณ",
+			`สวัสดีคุณ {{.User.Username}}
+
+มีคำขอ {{.Event.Count}} รายการรอการอนุมัติจากคุณ{{if gt .Event.Count 1}} รายการที่รอนานที่สุด{{end}}ตั้งแต่วันที่ {{.Event.Since}}
+
+อนุมัติหรือปฏิเสธได้ที่กล่องคำขออนุมัติใน TableG
+`),
+		notificationPasswordReset: newTranslatedNotificationTemplate(i18n.Thai, notificationPasswordReset,
+			"รหัสผ่าน TableG ของคุณถูกรีเซ็ต",
+			`สวัสดีคุณ {{.User.Username}}
+
+{{.Event.ResetBy}} รีเซ็ตรหัสผ่าน TableG ของคุณ กรุณาขอรหัสผ่านใหม่จากผู้ดูแลระบบเพื่อเข้าสู่ระบบ
+
+หากคุณไม่ได้ร้องขอ กรุณาแจ้งผู้ดูแลระบบทันที
+`),
+	},
+}
+
+// notificationTemplateFor returns a kind of notification in the language, English when it has
+// no translation
+func notificationTemplateFor(language i18n.Language, kind string) notificationTemplate {
+	if t, ok := translatedNotificationTemplates[language][kind]; ok {
+		return t
+	}
+	return notificationTemplates[kind]
+}
+
+// render renders the subject and body of a notification
+func (t notificationTemplate) render(data notificationData) (string, string, error) {
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
+
+// notificationJob is the payload of the jobs sending notifications, such as notification.email
+type notificationJob struct {
+	NotificationID int32 `json:"notificationId"`
+}
+
+// notify sends the user a notification of an event over each channel that is set up, unless
+// they turned its kind off there. Like the other side effects of a write, a failure is only
+// logged.
+func (s *Server) notify(ctx context.Context, userID int32, kind string, event any) {
+	if len(s.notifiers) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err := s.queueNotification(ctx, userID, kind, event); err != nil {
+		slog.ErrorContext(ctx, "Error queueing notification", "kind", kind, "user_id", userID, "error", err)
+	}
+}
+
+// queueNotification renders the notification into the outbox once per channel and queues the
+// jobs sending it
+func (s *Server) queueNotification(ctx context.Context, userID int32, kind string, event any) error {
+	pref, err := s.notificationPreference(ctx, userID, kind)
+	if err != nil {
+		return err
+	}
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("fetching the user: %w", err)
+	}
+	language, err := s.userLanguage(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("fetching the user's language: %w", err)
+	}
+	subject, body, err := notificationTemplateFor(language, kind).render(notificationData{User: user, Event: event})
+	if err != nil {
+		return fmt.Errorf("rendering the notification: %w", err)
+	}
+
+	var errs []error
+	for _, channel := range notificationChannels {
+		notifier, ok := s.notifiers[channel]
+		if !ok || !notificationChannelEnabled(pref, channel) {
+			continue
+		}
+		recipient, err := notifier.Recipient(ctx, user)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("finding the %s recipient: %w", channel, err))
+			continue
+		}
+		if recipient == "" {
+			continue
+		}
+		notification, err := s.store.CreateNotification(ctx, sqlc.CreateNotificationParams{
+			UserID:    userID,
+			Kind:      kind,
+			Channel:   channel,
+			Recipient: recipient,
+			Subject:   subject,
+			Body:      body,
+		})
+		if err == nil {
+			_, err = s.queue.Enqueue(ctx, notificationJobs[channel], notificationJob{NotificationID: notification.ID})
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notificationPreference returns which channels the user wants the kind of notification on,
+// every one until they turn it off
+func (s *Server) notificationPreference(ctx context.Context, userID int32, kind string) (sqlc.NotificationPreference, error) {
+	prefs, err := s.store.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return sqlc.NotificationPreference{}, err
+	}
+	for _, pref := range prefs {
+		if pref.Kind == kind {
+			return pref, nil
+		}
+	}
+	return sqlc.NotificationPreference{UserID: userID, Kind: kind, Email: true, Line: true, Push: true}, nil
+}
+
+// notificationChannelEnabled reports whether a preference leaves a channel on
+func notificationChannelEnabled(pref sqlc.NotificationPreference, channel string) bool {
+	switch channel {
+	case notificationChannelEmail:
+		return pref.Email
+	case notificationChannelLINE:
+		return pref.Line
+	case notificationChannelPush:
+		return pref.Push
+	}
+	return false
+}
+
+// sendNotification sends a notification from the outbox over its channel. When the channel
+// takes it but marking it sent fails, the next attempt sends it again; a duplicate beats a lost
+// notification.
+func (s *Server) sendNotification(ctx context.Context, payload json.RawMessage) error {
+	var job notificationJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return queue.Permanent(err)
+	}
+	notification, err := s.store.GetNotification(ctx, job.NotificationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The user was deleted since
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if notification.Status == notificationSent {
+		return nil
+	}
+	notifier, ok := s.notifiers[notification.Channel]
+	if !ok {
+		return queue.Permanent(fmt.Errorf("the %s channel is off, its settings were removed since the notification was queued", notification.Channel))
+	}
+
+	err = notifier.Send(ctx, notification.Recipient, notification.Subject, notification.Body)
+	if err != nil {
+		recordErr := s.store.SetNotificationError(ctx, sqlc.SetNotificationErrorParams{
+			ID:        notification.ID,
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		})
+		if recordErr != nil {
+			slog.WarnContext(ctx, "Error recording why a notification failed", "notification_id", notification.ID, "error", recordErr)
+		}
+		return err
+	}
+	return s.store.MarkNotificationSent(ctx, notification.ID)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+)
+
+// outboxNotifier is an email channel sending nothing; notifications stay in the outbox since the
+// tests run no queue workers
+type outboxNotifier struct{}
+
+func (outboxNotifier) Recipient(ctx context.Context, user sqlc.User) (string, error) {
+	return user.Email, nil
+}
+
+func (outboxNotifier) Send(ctx context.Context, recipient, subject, body string) error {
+	return nil
+}
+
+// newNotifyingTestServer is newTestServer with the server itself and email notifications on
+func newNotifyingTestServer(t *testing.T) (*Server, *dbtest.Fake) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Files.Location = t.TempDir()
+	store := dbtest.NewFake()
+	s := NewServer(cfg, store, nil, nil)
+	s.notifiers[notificationChannelEmail] = outboxNotifier{}
+	return s, store
+}
+
+// notificationsOf returns the notifications the user got of a kind, oldest first
+func notificationsOf(t *testing.T, store *dbtest.Fake, user sqlc.User, kind string) []sqlc.Notification {
+	t.Helper()
+	all, err := store.ExportNotifications(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notifications []sqlc.Notification
+	for _, notification := range all {
+		if notification.Kind == kind {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications
+}
+
+// TestNotificationTemplates renders every kind in every language with an example event
+func TestNotificationTemplates(t *testing.T) {
+	events := map[string]any{
+		notificationLeaveRecorded:    leaveRecordedEvent{RecordedBy: "root", Type: "vacation", Date: "2026-03-02", Note: "Booked by phone"},
+		notificationLeaveDecided:     leaveDecidedEvent{DecidedBy: "bob", Decision: "rejected", Type: "sick", Date: "2026-03-02"},
+		notificationExpenseRecorded:  expenseRecordedEvent{RecordedBy: "root", Amount: "1200.00", ReceiptName: "receipt.pdf", ReceiptDate: "2026-03-02"},
+		notificationExpenseDecided:   expenseDecidedEvent{DecidedBy: "bob", Decision: "rejected", Amount: "1200.00", ReceiptDate: "2026-03-02", Comment: "Not covered"},
+		notificationTaskAssigned:     taskAssignedEvent{AssignedBy: "bob", TaskID: 7, TaskTitle: "Payroll export"},
+		notificationApprovalDecided:  approvalDecidedEvent{DecidedBy: "bob", Decision: "approved", Subject: "edit of quota plan Staff", Outcome: "The edit was applied."},
+		notificationApprovalReminder: approvalReminderEvent{Count: 3, Since: "2026-03-02"},
+		notificationPasswordReset:    passwordResetEvent{ResetBy: "root"},
+	}
+	user := sqlc.User{Username: "alice"}
+	for _, kind := range notificationKinds {
+		event, ok := events[kind]
+		if !ok {
+			t.Errorf("%s has no example event", kind)
+			continue
+		}
+		if notificationTemplates[kind].description == "" {
+			t.Errorf("%s has no description", kind)
+		}
+		for _, language := range []i18n.Language{i18n.English, i18n.Thai} {
+			subject, body, err := notificationTemplateFor(language, kind).render(notificationData{User: user, Event: event})
+			if err != nil {
+				t.Errorf("%s in %s: %v", kind, language, err)
+				continue
+			}
+			if subject == "" || strings.Contains(subject+body, "<no value>") || !strings.Contains(body, "alice") {
+				t.Errorf("%s in %s rendered %q, %q", kind, language, subject, body)
+			}
+		}
+	}
+}
+
+func TestNotificationTemplateWording(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        string
+		event       any
+		wantSubject string
+		wantBody    []string
+		notInBody   []string
+	}{
+		{
+			name:        "expense approved",
+			kind:        notificationExpenseDecided,
+			event:       expenseDecidedEvent{DecidedBy: "bob", Decision: "approved", Amount: "1200.00", ReceiptDate: "2026-03-02"},
+			wantSubject: "Your medical expense of 1200.00 baht was approved",
+			wantBody:    []string{"bob approved your medical expense of 1200.00 baht dated 2026-03-02."},
+			notInBody:   []string{"Comment:", "no longer counts"},
+		},
+		{
+			name:        "expense rejected",
+			kind:        notificationExpenseDecided,
+			event:       expenseDecidedEvent{DecidedBy: "bob", Decision: "rejected", Amount: "1200.00", ReceiptDate: "2026-03-02", Comment: "Not covered"},
+			wantSubject: "Your medical expense of 1200.00 baht was rejected",
+			wantBody:    []string{"Comment: Not covered", "It no longer counts in your medical expense balance"},
+		},
+		{
+			name:        "one request waiting",
+			kind:        notificationApprovalReminder,
+			event:       approvalReminderEvent{Count: 1, Since: "2026-03-02"},
+			wantSubject: "A request waits on your approval",
+			wantBody:    []string{"A request has waited on your approval since 2026-03-02.", "Approve or reject it "},
+		},
+		{
+			name:        "requests waiting",
+			kind:        notificationApprovalReminder,
+			event:       approvalReminderEvent{Count: 3, Since: "2026-03-02"},
+			wantSubject: "3 requests wait on your approval",
+			wantBody:    []string{"3 requests have waited on your approval, the oldest since 2026-03-02.", "Approve or reject them "},
+		},
+		{
+			name:        "password reset",
+			kind:        notificationPasswordReset,
+			event:       passwordResetEvent{ResetBy: "root"},
+			wantSubject: "Your TableG password was reset",
+			wantBody:    []string{"root reset your TableG password."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body, err := notificationTemplates[tt.kind].render(notificationData{User: sqlc.User{Username: "alice"}, Event: tt.event})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body = %q, want it to have %q", body, want)
+				}
+			}
+			for _, unwanted := range tt.notInBody {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("body = %q, want it without %q", body, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestExpenseDecidedNotification(t *testing.T) {
+	s, store := newNotifyingTestServer(t)
+	handler := s.Handler()
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	reportTo(t, store, alice, bob)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, "/api/admin/approval-policies/medical_expense", tokenFor(admin.Username), ApprovalPolicyRequest{
+		Steps: []string{"manager"},
+	}), http.StatusOK, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/medical-expenses", tokenFor(alice.Username), MedicalExpenseRequest{
+		UserID:      alice.ID,
+		Amount:      1200,
+		ReceiptDate: "2025-04-14",
+	}), http.StatusCreated, nil)
+	request := pendingApproval(t, handler, alice)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, fmt.Sprintf("/api/approvals/%d/reject", request.ID), tokenFor(bob.Username), ApprovalDecisionRequest{
+		Comment: "Not covered",
+	}), http.StatusOK, nil)
+
+	notifications := notificationsOf(t, store, alice, notificationExpenseDecided)
+	if len(notifications) != 1 {
+		t.Fatalf("expense_decided notifications = %+v, want one", notifications)
+	}
+	if got, want := notifications[0].Subject, "Your medical expense of 1200.00 baht was rejected"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if !strings.Contains(notifications[0].Body, "Comment: Not covered") {
+		t.Errorf("body = %q, want the comment", notifications[0].Body)
+	}
+	if others := notificationsOf(t, store, alice, notificationApprovalDecided); len(others) != 0 {
+		t.Errorf("approval_decided notifications = %+v, want none for an expense", others)
+	}
+}
+
+func TestRemindApprovers(t *testing.T) {
+	s, store := newNotifyingTestServer(t)
+	handler := s.Handler()
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	frank := dbtest.CreateUser(t, store, "frank", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	carol := dbtest.CreateUser(t, store, "carol", "user")
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	reportTo(t, store, alice, bob)
+	reportTo(t, store, frank, bob)
+
+	for _, user := range []sqlc.User{alice, frank} {
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/leave-logs", tokenFor(user.Username), LeaveLogRequest{
+			UserID: user.ID,
+			Type:   "vacation",
+			Date:   "2025-04-14",
+		}), http.StatusCreated, nil)
+		pendingApproval(t, handler, user)
+	}
+
+	ctx := context.Background()
+	if err := s.remindApprovers(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if reminders := notificationsOf(t, store, bob, notificationApprovalReminder); len(reminders) != 0 {
+		t.Fatalf("reminders = %+v, want none for requests submitted just now", reminders)
+	}
+
+	if err := s.remindApprovers(ctx, time.Now().Add(approvalReminderAge+time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		user sqlc.User
+		want string // The subject of their reminder, none when empty
+	}{
+		{user: bob, want: "2 requests wait on your approval"},
+		{user: admin, want: "2 requests wait on your approval"},
+		{user: alice, want: ""},
+		{user: carol, want: ""},
+	}
+	for _, tt := range tests {
+		reminders := notificationsOf(t, store, tt.user, notificationApprovalReminder)
+		switch {
+		case tt.want == "" && len(reminders) != 0:
+			t.Errorf("%s's reminders = %+v, want none", tt.user.Username, reminders)
+		case tt.want != "" && (len(reminders) != 1 || reminders[0].Subject != tt.want):
+			t.Errorf("%s's reminders = %+v, want %q", tt.user.Username, reminders, tt.want)
+		}
+	}
+}
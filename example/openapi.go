@@ -0,0 +1,496 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// openAPIPath and swaggerUIPath serve the spec of the API and Swagger UI browsing it
+const (
+	openAPIPath   = "/api/openapi.json"
+	swaggerUIPath = "/api/docs"
+)
+
+// apiOperation documents one route in the OpenAPI spec. Request and Response are zero values
+// of the body types, the spec describes them by reflecting over their JSON fields.
+type apiOperation struct {
+	ID       string // operationId of the spec, the name of the handler unless it serves several routes
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Query    []apiParameter
+	Request  any // Request body, nil when there is none
+	Response any // Body of a successful response, nil when there is none
+	Status   int // Status of a successful response, 200 when zero
+	Conflict any // Body of a 409 response that isn't an ErrorResponse
+	Public   bool
+}
+
+// apiParameter is a query parameter of an operation
+type apiParameter struct {
+	Name        string
+	Type        string // string, integer, number or boolean
+	Description string
+}
+
+// queryParam returns a query parameter for the operations table
+func queryParam(name, typ, description string) apiParameter {
+	return apiParameter{Name: name, Type: typ, Description: description}
+}
+
+// Query parameters shared by many listings
+var (
+	limitQuery  = queryParam("limit", "integer", "Number of items to return")
+	offsetQuery = queryParam("offset", "integer", "Number of items to skip")
+	cursorQuery = queryParam("cursor", "string", "Pages by cursor instead of offset when present, an empty value asks for the first page. The response then has next_cursor in place of offset, null on the last page.")
+)
+
+// listParams returns the query parameters of a listing declared by spec: params, with the range
+// operators of each date filter after it, then sort. Filters are documented in their plain form,
+// filter[name]=value works alike.
+func listParams(spec listSpec, params ...apiParameter) []apiParameter {
+	var result []apiParameter
+	for _, p := range params {
+		result = append(result, p)
+		if kind, ok := spec.Filters[p.Name]; ok && kind == dateFilter {
+			result = append(result,
+				queryParam("filter["+p.Name+"][gte]", "string", "Only items on or after this day, YYYY-MM-DD; gt is the same without the day"),
+				queryParam("filter["+p.Name+"][lte]", "string", "Only items on or before this day, YYYY-MM-DD; lt is the same without the day"),
+			)
+		}
+	}
+	sort := fmt.Sprintf("Field to sort by, prefixed with - for descending order: %s. Defaults to %s",
+		strings.Join(spec.Sorts, ", "), spec.Sort)
+	return append(result, queryParam("sort", "string", sort))
+}
+
+// openAPIDocument is the part of an OpenAPI 3.0 document the spec uses
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Security   []map[string][]string                   `json:"security"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]map[string]interface{} `json:"securitySchemes"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    *[]map[string][]string      `json:"security,omitempty"` // An empty list for public operations
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool                      `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// wellKnownSchemas describe types whose JSON differs from their Go fields. The pgtype types
+// encode as their value, or null when not valid.
+var wellKnownSchemas = map[reflect.Type]openAPISchema{
+	reflect.TypeOf(time.Time{}):          {Type: "string", Format: "date-time"},
+	reflect.TypeOf(json.RawMessage{}):    {},
+	reflect.TypeOf(pgtype.Bool{}):        {Type: "boolean", Nullable: true},
+	reflect.TypeOf(pgtype.Date{}):        {Type: "string", Format: "date", Nullable: true},
+	reflect.TypeOf(pgtype.Int4{}):        {Type: "integer", Format: "int32", Nullable: true},
+	reflect.TypeOf(pgtype.Numeric{}):     {Type: "number", Nullable: true},
+	reflect.TypeOf(pgtype.Text{}):        {Type: "string", Nullable: true},
+	reflect.TypeOf(pgtype.Timestamptz{}): {Type: "string", Format: "date-time", Nullable: true},
+}
+
+// jsonMarshaler is implemented by types encoding themselves, whose shape reflection can't see
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// schemaGenerator describes Go types as OpenAPI schemas. Named structs become components the
+// operations refer to, so a type used by many operations is described once.
+type schemaGenerator struct {
+	schemas map[string]*openAPISchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*openAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf returns the schema of values of type t as encoding/json writes them
+func (g *schemaGenerator) schemaOf(t reflect.Type) *openAPISchema {
+	if known, ok := wellKnownSchemas[t]; ok {
+		return &known
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := g.schemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Implements(jsonMarshaler) {
+			return &openAPISchema{}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + g.componentName(t)}
+	default:
+		// Interfaces hold any value
+		return &openAPISchema{}
+	}
+}
+
+// componentName registers the named struct t as a component and returns its name. Types of
+// other packages sharing a name with one already registered get their package as prefix, and
+// an instance of a generic type is named after its type argument, Page[UserResponse] becoming
+// UserResponsePage.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if base, arg, ok := strings.Cut(name, "["); ok {
+		arg = strings.TrimSuffix(arg, "]")
+		name = arg[strings.LastIndex(arg, ".")+1:] + base
+	}
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// Registered before describing the fields, so a type containing itself refers to itself
+	g.schemas[name] = &openAPISchema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+// structSchema describes the JSON fields of struct t, following encoding/json's rules for
+// tags and embedded structs
+func (g *schemaGenerator) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := g.structSchema(field.Type)
+			for embeddedName, property := range embedded.Properties {
+				schema.Properties[embeddedName] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property, required := withValidateRules(g.schemaOf(field.Type), field.Tag.Get("validate"))
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// withValidateRules adds the rules of a field's validate tag to a copy of its schema, reporting
+// whether the field is required. Referenced schemas are shared, so only required applies to them.
+func withValidateRules(schema *openAPISchema, tag string) (*openAPISchema, bool) {
+	if tag == "" {
+		return schema, false
+	}
+
+	constrained := *schema
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		n, _ := strconv.ParseFloat(arg, 64)
+		length := int(n)
+		isString := constrained.Type == "string"
+		switch {
+		case name == "required":
+			required = true
+		case schema.Ref != "":
+		case name == "min" && isString:
+			constrained.MinLength = &length
+		case name == "max" && isString:
+			constrained.MaxLength = &length
+		case name == "min":
+			constrained.Minimum = &n
+		case name == "max":
+			constrained.Maximum = &n
+		case name == "gt":
+			constrained.Minimum, constrained.ExclusiveMinimum = &n, true
+		case name == "oneof":
+			constrained.Enum = strings.Fields(arg)
+		case name == "date", name == "email":
+			constrained.Format = name
+		}
+	}
+	return &constrained, required
+}
+
+// pathParameterPattern matches the variables of a route template, like {id} or {id:[0-9]+}
+var pathParameterPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// stringPathParameters are the path variables that aren't numeric IDs
+var stringPathParameters = map[string]bool{
+	"kind":     true,
+	"space_id": true,
+	"team_id":  true,
+	"username": true,
+}
+
+// newOpenAPIDocument builds the spec of the routes registered on the router. Routes missing
+// from apiOperations still show up, without bodies, and come back as problems along with
+// operations whose route isn't registered, so the two can't drift apart unnoticed.
+func newOpenAPIDocument(router *mux.Router) (*openAPIDocument, []string) {
+	documented := make(map[string]apiOperation, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Method+" "+op.Path] = op
+	}
+
+	var problems []string
+	generator := newSchemaGenerator()
+	paths := make(map[string]map[string]*openAPIOperation)
+	seen := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			key := method + " " + template
+			seen[key] = true
+			op, ok := documented[key]
+			if !ok {
+				problems = append(problems, "route "+key+" is missing from the OpenAPI operations")
+				op = apiOperation{Method: method, Path: template}
+			}
+
+			path := pathParameterPattern.ReplaceAllString(template, "{$1}")
+			if paths[path] == nil {
+				paths[path] = make(map[string]*openAPIOperation)
+			}
+			paths[path][strings.ToLower(method)] = generator.operation(op, template)
+		}
+		return nil
+	})
+
+	for _, op := range apiOperations {
+		if key := op.Method + " " + op.Path; !seen[key] {
+			problems = append(problems, "OpenAPI operation "+key+" has no registered route")
+		}
+	}
+	sort.Strings(problems)
+
+	return &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "TableG API",
+			Version:     "1.0.0",
+			Description: "Log in with POST /api/login and send the token it returns as \"Authorization: Bearer <token>\".",
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+		Paths:    paths,
+		Components: openAPIComponents{
+			Schemas: generator.schemas,
+			SecuritySchemes: map[string]map[string]interface{}{
+				"bearerAuth": {"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, problems
+}
+
+// operation describes one documented route, whose path variables come from its template
+func (g *schemaGenerator) operation(op apiOperation, template string) *openAPIOperation {
+	result := &openAPIOperation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+	if op.Tag != "" {
+		result.Tags = []string{op.Tag}
+	}
+	if op.Public {
+		result.Security = &[]map[string][]string{}
+	}
+
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(template, -1) {
+		schema := &openAPISchema{Type: "integer", Format: "int32"}
+		if stringPathParameters[match[1]] {
+			schema = &openAPISchema{Type: "string"}
+		}
+		result.Parameters = append(result.Parameters, openAPIParameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, param := range op.Query {
+		result.Parameters = append(result.Parameters, openAPIParameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Schema:      &openAPISchema{Type: param.Type},
+		})
+	}
+
+	if op.Request != nil {
+		result.RequestBody = &openAPIRequestBody{Required: true, Content: g.jsonContent(op.Request)}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &openAPIResponse{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = g.jsonContent(op.Response)
+	}
+	result.Responses[fmt.Sprint(status)] = success
+	if op.Request != nil {
+		result.Responses["422"] = &openAPIResponse{Description: http.StatusText(http.StatusUnprocessableEntity), Content: g.jsonContent(ValidationErrorResponse{})}
+	}
+	if op.Conflict != nil {
+		result.Responses["409"] = &openAPIResponse{Description: http.StatusText(http.StatusConflict), Content: g.jsonContent(op.Conflict)}
+	}
+	result.Responses["default"] = &openAPIResponse{Description: "Error", Content: g.jsonContent(ErrorResponse{})}
+	return result
+}
+
+// jsonContent returns the JSON media type of a body shaped like value
+func (g *schemaGenerator) jsonContent(value any) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(value))}}
+}
+
+// RegisterOpenAPIRoutes serves the spec of the routes registered so far on the router, and
+// Swagger UI browsing it. It logs the routes and operations that don't match up.
+func RegisterOpenAPIRoutes(router *mux.Router) {
+	document, problems := newOpenAPIDocument(router)
+	for _, problem := range problems {
+		slog.Warn("OpenAPI spec is out of date", "problem", problem)
+	}
+
+	spec, err := json.Marshal(document)
+	if err != nil {
+		fatal("Error encoding the OpenAPI spec", "error", err)
+	}
+
+	router.HandleFunc(openAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}).Methods("GET")
+	router.HandleFunc(swaggerUIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, swaggerUIPage)
+	}).Methods("GET")
+}
+
+// swaggerUIPage loads Swagger UI from a CDN, pointed at the spec
+var swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>TableG API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "` + openAPIPath + `", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
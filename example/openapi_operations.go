@@ -0,0 +1,669 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/hrimport"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// Bodies of the handlers answering with a map of strings, like {"result": "success"}
+type stringMap = map[string]string
+
+// apiOperations documents every route of the server for the OpenAPI spec. A route added without
+// an entry here is logged at startup and shows up in the spec without bodies.
+var apiOperations = []apiOperation{
+	// Users
+	{ID: "getUsers", Method: "GET", Path: "/api/users", Tag: "Users", Summary: "List users",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[UserResponse]{}},
+	{ID: "getUser", Method: "GET", Path: "/api/users/{id}", Tag: "Users", Summary: "Get a user",
+		Response: UserResponse{}},
+	{ID: "createUser", Method: "POST", Path: "/api/users", Tag: "Users", Summary: "Create a user",
+		Request: UserCreateRequest{}, Response: UserResponse{}, Status: http.StatusCreated},
+	{ID: "updateUser", Method: "PUT", Path: "/api/users/{id}", Tag: "Users", Summary: "Update a user",
+		Request: UserUpdateRequest{}, Response: UserResponse{}},
+	{ID: "deleteUser", Method: "DELETE", Path: "/api/users/{id}", Tag: "Users", Summary: "Delete a user",
+		Status: http.StatusNoContent},
+	{ID: "resetUserPassword", Method: "POST", Path: "/api/users/{id}/reset-password", Tag: "Users", Summary: "Give a user a new random password, the response being the only one with it, and notify them",
+		Response: PasswordResetResponse{}},
+	{ID: "loginHandler", Method: "POST", Path: "/api/login", Tag: "Users", Summary: "Log in",
+		Request: LoginRequest{}, Response: LoginResponse{}, Public: true},
+	{ID: "getCurrentUser", Method: "GET", Path: "/api/current-user", Tag: "Users", Summary: "Get the logged in user",
+		Response: UserResponse{}},
+	{ID: "getDashboard", Method: "GET", Path: "/api/dashboard", Tag: "Users", Summary: "Get what the home page shows for the logged in user: balances, estimation votes waiting on them, who is out today, days without logged work and recent tasks",
+		Response: DashboardResponse{}},
+	{ID: "getNotificationPreferences", Method: "GET", Path: "/api/current-user/notification-preferences", Tag: "Users", Summary: "List the kinds of notification with the channels the logged in user gets them on",
+		Response: []NotificationPreferenceResponse{}},
+	{ID: "updateNotificationPreferences", Method: "PATCH", Path: "/api/current-user/notification-preferences", Tag: "Users", Summary: "Turn notifications of the logged in user on or off by kind and channel",
+		Request: NotificationPreferencesRequest{}, Response: []NotificationPreferenceResponse{}},
+	{ID: "getLanguagePreference", Method: "GET", Path: "/api/current-user/language", Tag: "Users", Summary: "Get the language the logged in user gets notifications in",
+		Response: LanguagePreferenceResponse{}},
+	{ID: "updateLanguagePreference", Method: "PUT", Path: "/api/current-user/language", Tag: "Users", Summary: "Pick the language, en or th, the logged in user gets notifications in",
+		Request: LanguagePreferenceRequest{}, Response: LanguagePreferenceResponse{}},
+	{ID: "getTimeZonePreference", Method: "GET", Path: "/api/current-user/time-zone", Tag: "Users", Summary: "Get the time zone the logged in user's today follows, TIME_ZONE until they pick one",
+		Response: TimeZonePreferenceResponse{}},
+	{ID: "updateTimeZonePreference", Method: "PUT", Path: "/api/current-user/time-zone", Tag: "Users", Summary: "Pick the IANA time zone, such as Asia/Tokyo, the logged in user's today follows",
+		Request: TimeZonePreferenceRequest{}, Response: TimeZonePreferenceResponse{}},
+	{ID: "getDataExport", Method: "GET", Path: "/api/current-user/data-export", Tag: "Users", Summary: "Download everything kept about the logged in user, as JSON or a ZIP of the JSON and their files",
+		Query:    []apiParameter{queryParam("format", "string", "json, the default, or zip")},
+		Response: DataExport{}},
+	{ID: "getYearSummary", Method: "GET", Path: "/api/current-user/year-summary", Tag: "Users", Summary: "Sum up the logged in user's year: vacation by month, top task categories, holiday work and balances, as JSON or a PDF",
+		Query: []apiParameter{
+			queryParam("year", "integer", "The year, this year by default"),
+			queryParam("format", "string", "json, the default, or pdf"),
+		},
+		Response: YearSummaryResponse{}},
+
+	// Holidays
+	{ID: "getHolidays", Method: "GET", Path: "/api/holidays", Tag: "Holidays", Summary: "List holidays",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[sqlc.Holiday]{}},
+	{ID: "getHoliday", Method: "GET", Path: "/api/holidays/{id}", Tag: "Holidays", Summary: "Get a holiday",
+		Response: sqlc.Holiday{}},
+	{ID: "createHoliday", Method: "POST", Path: "/api/holidays", Tag: "Holidays", Summary: "Create a holiday",
+		Request: HolidayRequest{}, Response: sqlc.Holiday{}, Status: http.StatusCreated},
+	{ID: "updateHoliday", Method: "PUT", Path: "/api/holidays/{id}", Tag: "Holidays", Summary: "Update a holiday",
+		Request: HolidayRequest{}, Response: sqlc.Holiday{}},
+	{ID: "deleteHoliday", Method: "DELETE", Path: "/api/holidays/{id}", Tag: "Holidays", Summary: "Delete a holiday",
+		Status: http.StatusNoContent},
+
+	// Annual records
+	{ID: "getAnnualRecords", Method: "GET", Path: "/api/annual-records", Tag: "Annual records", Summary: "List the annual records of a user or a year",
+		Query: []apiParameter{
+			queryParam("user_id", "integer", "Only the records of this user"),
+			queryParam("year", "integer", "Only the records of this year, the current one when neither filter is given"),
+		},
+		Response: []sqlc.ListAnnualRecordsByYearRow{}},
+	{ID: "getAnnualRecord", Method: "GET", Path: "/api/annual-records/{id}", Tag: "Annual records", Summary: "Get an annual record",
+		Response: sqlc.AnnualRecord{}},
+	{ID: "createAnnualRecord", Method: "POST", Path: "/api/annual-records", Tag: "Annual records", Summary: "Create an annual record",
+		Request: AnnualRecordRequest{}, Response: stringMap{}, Status: http.StatusCreated},
+	{ID: "updateAnnualRecord", Method: "PUT", Path: "/api/annual-records/{id}", Tag: "Annual records", Summary: "Update an annual record",
+		Request: AnnualRecordUpdateRequest{}, Response: sqlc.AnnualRecord{}, Conflict: ConflictResponse{}},
+	{ID: "deleteAnnualRecord", Method: "DELETE", Path: "/api/annual-records/{id}", Tag: "Annual records", Summary: "Delete an annual record",
+		Status: http.StatusNoContent},
+	{ID: "getUserAnnualRecords", Method: "GET", Path: "/api/users/{user_id}/annual-records", Tag: "Annual records", Summary: "List the annual records of a user",
+		Response: []sqlc.ListAnnualRecordsByUserRow{}},
+	{ID: "getCurrentUserAnnualRecords", Method: "GET", Path: "/api/current-user/annual-records", Tag: "Annual records", Summary: "List the annual records of the logged in user",
+		Response: []sqlc.ListAnnualRecordsByUserRow{}},
+	{ID: "upsertAnnualRecordForUser", Method: "POST", Path: "/api/users/{user_id}/annual-records/current-year", Tag: "Annual records", Summary: "Create or update a user's annual record of a year",
+		Request: AnnualRecordUpsertRequest{}, Response: sqlc.AnnualRecord{}},
+	{ID: "assignQuotaPlanToAllUsers", Method: "POST", Path: "/api/annual-records/quota-plan/{plan_id}/assign-to-all", Tag: "Annual records", Summary: "Assign a quota plan to every user's record of a year",
+		Request: QuotaPlanAssignRequest{}, Response: stringMap{}},
+	{ID: "createNextYearAnnualRecords", Method: "POST", Path: "/api/annual-records/create-next-year", Tag: "Annual records", Summary: "Carry the annual records of a year over to the next",
+		Request: NextYearAnnualRecordsRequest{}, Response: []sqlc.AnnualRecord{}},
+	{ID: "syncUserRecord", Method: "POST", Path: "/api/annual-records/sync", Tag: "Annual records", Summary: "Get a user's synced annual record of a year",
+		Request: SyncRequest{}, Response: sqlc.AnnualRecord{}},
+	{ID: "syncAllRecords", Method: "POST", Path: "/api/annual-records/sync/all/{year}", Tag: "Annual records", Summary: "Get every synced annual record of a year",
+		Response: []sqlc.AnnualRecord{}},
+	{ID: "ensureAnnualRecord", Method: "POST", Path: "/api/annual-records/ensure/{user_id}/{year}", Tag: "Annual records", Summary: "Create a user's annual record of a year unless it exists",
+		Response: sqlc.AnnualRecord{}},
+	{ID: "scheduleYearEndRollover", Method: "POST", Path: "/api/annual-records/rollover", Tag: "Annual records", Summary: "Schedule the year-end rollover of vacation days",
+		Response: stringMap{}},
+
+	// Quota plans
+	{ID: "getQuotaPlans", Method: "GET", Path: "/api/quota-plans", Tag: "Quota plans", Summary: "List quota plans",
+		Response: []sqlc.QuotaPlan{}},
+	{ID: "getQuotaPlan", Method: "GET", Path: "/api/quota-plans/{id}", Tag: "Quota plans", Summary: "Get a quota plan",
+		Response: sqlc.QuotaPlan{}},
+	{ID: "createQuotaPlan", Method: "POST", Path: "/api/quota-plans", Tag: "Quota plans", Summary: "Create a quota plan",
+		Request: QuotaPlanRequest{}, Response: sqlc.QuotaPlan{}, Status: http.StatusCreated},
+	{ID: "updateQuotaPlan", Method: "PUT", Path: "/api/quota-plans/{id}", Tag: "Quota plans", Summary: "Update a quota plan, or submit the edit for approval and answer 202 with the approval request when quota plan edits need approval",
+		Request: QuotaPlanUpdateRequest{}, Response: sqlc.QuotaPlan{}, Conflict: ConflictResponse{}},
+	{ID: "deleteQuotaPlan", Method: "DELETE", Path: "/api/quota-plans/{id}", Tag: "Quota plans", Summary: "Delete a quota plan",
+		Status: http.StatusNoContent},
+	{ID: "getQuotaPlansByYear", Method: "GET", Path: "/api/quota-plans/year/{year}", Tag: "Quota plans", Summary: "List the quota plans of a year",
+		Response: []sqlc.QuotaPlan{}},
+
+	// Medical expenses
+	{ID: "getMedicalExpenses", Method: "GET", Path: "/api/medical-expenses", Tag: "Medical expenses", Summary: "List the medical expenses of a user",
+		Query:    []apiParameter{limitQuery, offsetQuery, queryParam("user_id", "integer", "User whose expenses to list")},
+		Response: Page[sqlc.MedicalExpense]{}},
+	{ID: "getMedicalExpense", Method: "GET", Path: "/api/medical-expenses/{id}", Tag: "Medical expenses", Summary: "Get a medical expense",
+		Response: sqlc.MedicalExpense{}},
+	{ID: "createMedicalExpense", Method: "POST", Path: "/api/medical-expenses", Tag: "Medical expenses", Summary: "Create a medical expense",
+		Request: MedicalExpenseRequest{}, Response: sqlc.MedicalExpense{}, Status: http.StatusCreated},
+	{ID: "updateMedicalExpense", Method: "PUT", Path: "/api/medical-expenses/{id}", Tag: "Medical expenses", Summary: "Update a medical expense",
+		Request: MedicalExpenseUpdateRequest{}, Response: sqlc.MedicalExpense{}},
+	{ID: "deleteMedicalExpense", Method: "DELETE", Path: "/api/medical-expenses/{id}", Tag: "Medical expenses", Summary: "Delete a medical expense",
+		Status: http.StatusNoContent},
+	{ID: "getCurrentUserMedicalExpenses", Method: "GET", Path: "/api/current-user/medical-expenses", Tag: "Medical expenses", Summary: "List the medical expenses of the logged in user",
+		Query:    []apiParameter{limitQuery, offsetQuery, queryParam("year", "integer", "Only the expenses of this year")},
+		Response: Page[sqlc.MedicalExpense]{}},
+
+	// Leave logs
+	{ID: "getLeaveLogsList", Method: "GET", Path: "/api/leave-logs", Tag: "Leave logs", Summary: "List leave logs",
+		Query: listParams(leaveLogListSpec,
+			limitQuery, offsetQuery, cursorQuery,
+			queryParam("user_id", "integer", "Only the logs of this user"),
+			queryParam("type", "string", "Only logs of this leave type"),
+			queryParam("year", "integer", "Only logs of this year"),
+			queryParam("date", "string", "Only logs of this day, YYYY-MM-DD"),
+		),
+		Response: Page[LeaveLogResponse]{}},
+	{ID: "getLeaveLog", Method: "GET", Path: "/api/leave-logs/{id}", Tag: "Leave logs", Summary: "Get a leave log",
+		Response: LeaveLogResponse{}},
+	{ID: "createLeaveLog", Method: "POST", Path: "/api/leave-logs", Tag: "Leave logs", Summary: "Create a leave log",
+		Request: LeaveLogRequest{}, Response: LeaveLogResponse{}, Status: http.StatusCreated},
+	{ID: "updateLeaveLog", Method: "PUT", Path: "/api/leave-logs/{id}", Tag: "Leave logs", Summary: "Update a leave log",
+		Request: LeaveLogUpdateRequest{}, Response: LeaveLogResponse{}, Conflict: ConflictResponse{}},
+	{ID: "deleteLeaveLog", Method: "DELETE", Path: "/api/leave-logs/{id}", Tag: "Leave logs", Summary: "Delete a leave log",
+		Response: stringMap{}},
+	{ID: "getCurrentUserLeaveLogs", Method: "GET", Path: "/api/current-user/leave-logs", Tag: "Leave logs", Summary: "List the leave logs of the logged in user",
+		Query: listParams(currentUserLeaveLogListSpec,
+			limitQuery, offsetQuery, cursorQuery,
+			queryParam("type", "string", "Only logs of this leave type"),
+			queryParam("year", "integer", "Only logs of this year"),
+			queryParam("date", "string", "Only logs of this day, YYYY-MM-DD"),
+		),
+		Response: Page[LeaveLogResponse]{}},
+	{ID: "getLeaveCalendar", Method: "GET", Path: "/api/leave-calendar", Tag: "Leave logs", Summary: "List who is on leave each day, by date",
+		Query: []apiParameter{
+			queryParam("from", "string", "First day, YYYY-MM-DD, the first of this month by default"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD, the last of this month by default"),
+			queryParam("department", "string", "Only users of this department and those under it"),
+		},
+		Response: []LeaveCalendarEntry{}},
+
+	// ClickUp
+	{ID: "initiateOAuthHandler", Method: "GET", Path: "/api/oauth/clickup", Tag: "ClickUp", Summary: "Start connecting a ClickUp account, answering the authorization URL to open",
+		Response: stringMap{}},
+	{ID: "oauthCallbackHandler", Method: "GET", Path: "/api/oauth/callback", Tag: "ClickUp", Summary: "Finish connecting a ClickUp account",
+		Query: []apiParameter{
+			queryParam("code", "string", "Authorization code from ClickUp"),
+			queryParam("state", "string", "State returned by /api/oauth/clickup"),
+			queryParam("error", "string", "Error from ClickUp when the user declined"),
+		},
+		Response: ClickUpTokenResponse{}, Public: true},
+	{ID: "getCurrentTokenHandler", Method: "GET", Path: "/api/oauth/token", Tag: "ClickUp", Summary: "Get the ClickUp connection of the logged in user",
+		Response: ClickUpTokenResponse{}},
+	{ID: "deleteCurrentTokenHandler", Method: "DELETE", Path: "/api/oauth/token", Tag: "ClickUp", Summary: "Disconnect the ClickUp account of the logged in user",
+		Response: stringMap{}},
+	{ID: "linkClickUpAccountHandler", Method: "POST", Path: "/api/current-user/clickup/link", Tag: "ClickUp", Summary: "Connect a ClickUp account with a personal API token",
+		Request: ClickUpLinkRequest{}, Response: ClickUpTokenResponse{}},
+	{ID: "unlinkClickUpAccount", Method: "DELETE", Path: "/api/current-user/clickup/unlink", Tag: "ClickUp", Summary: "Disconnect the ClickUp account of the logged in user",
+		Response: stringMap{}},
+	{ID: "getClickUpWorkspaces", Method: "GET", Path: "/api/clickup/workspaces", Tag: "ClickUp", Summary: "List the connected ClickUp workspaces",
+		Response: []ClickUpWorkspaceResponse{}},
+	{ID: "getClickUpSpaces", Method: "GET", Path: "/api/clickup/workspaces/{team_id}/spaces", Tag: "ClickUp", Summary: "List the spaces of a ClickUp workspace",
+		Response: []clickup.Space{}},
+	{ID: "getClickUpLists", Method: "GET", Path: "/api/clickup/workspaces/{team_id}/spaces/{space_id}/lists", Tag: "ClickUp", Summary: "List the folders and lists of a ClickUp space",
+		Response: ClickUpListsResponse{}},
+	{ID: "syncTask", Method: "POST", Path: "/api/tasks/{id}/clickup-sync", Tag: "ClickUp", Summary: "Sync a task with ClickUp",
+		Response: TaskSyncResult{}},
+	{ID: "getSyncHistory", Method: "GET", Path: "/api/tasks/{id}/sync-history", Tag: "ClickUp", Summary: "List the ClickUp syncs of a task",
+		Query: []apiParameter{limitQuery}, Response: []TaskSyncHistoryResponse{}},
+	{ID: "syncAllTasks", Method: "POST", Path: "/api/clickup/sync", Tag: "ClickUp", Summary: "Sync every linked task with ClickUp",
+		Response: TaskSyncSummary{}},
+	{ID: "syncWorkspaceTasks", Method: "POST", Path: "/api/clickup/workspaces/{team_id}/sync", Tag: "ClickUp", Summary: "Sync the linked tasks of a ClickUp workspace",
+		Response: TaskSyncSummary{}},
+	{ID: "importClickUpList", Method: "POST", Path: "/api/clickup/workspaces/{team_id}/lists/{list_id}/import", Tag: "ClickUp", Summary: "Import the tasks of a ClickUp list",
+		Response: TaskImportSummary{}},
+	{ID: "getClickUpStatus", Method: "GET", Path: "/api/admin/integrations/clickup/status", Tag: "ClickUp", Summary: "Report whether the ClickUp integration is working",
+		Response: ClickUpIntegrationStatus{}},
+
+	// Slack
+	{ID: "getSlackWorkspaces", Method: "GET", Path: "/api/admin/slack/workspaces", Tag: "Slack", Summary: "List the connected Slack workspaces",
+		Response: []SlackWorkspaceResponse{}},
+	{ID: "connectSlackWorkspace", Method: "POST", Path: "/api/admin/slack/workspaces", Tag: "Slack", Summary: "Connect the Slack workspace of a bot token, or replace its settings",
+		Request: SlackWorkspaceRequest{}, Response: SlackWorkspaceResponse{}},
+	{ID: "disconnectSlackWorkspace", Method: "DELETE", Path: "/api/admin/slack/workspaces/{team_id}", Tag: "Slack", Summary: "Disconnect a Slack workspace",
+		Status: http.StatusNoContent},
+	{ID: "handleSlackInteraction", Method: "POST", Path: "/api/slack/interactions", Tag: "Slack", Summary: "Receive the button clicks of the Slack app, signed by Slack with SLACK_SIGNING_SECRET",
+		Public: true},
+
+	// LINE
+	{ID: "getLineLink", Method: "GET", Path: "/api/current-user/line", Tag: "LINE", Summary: "Tell whether the logged in user's LINE account is linked",
+		Response: LineLinkResponse{}},
+	{ID: "createLineLinkCode", Method: "POST", Path: "/api/current-user/line/link-code", Tag: "LINE", Summary: "Create a code to send to the Official Account in LINE, linking the account it is sent from",
+		Response: LineLinkResponse{}, Status: http.StatusCreated},
+	{ID: "unlinkLine", Method: "DELETE", Path: "/api/current-user/line", Tag: "LINE", Summary: "Unlink the logged in user's LINE account",
+		Status: http.StatusNoContent},
+	{ID: "handleLineWebhook", Method: "POST", Path: "/api/line/webhook", Tag: "LINE", Summary: "Receive the messages users send to the Official Account, signed by LINE with LINE_CHANNEL_SECRET",
+		Public: true},
+
+	// Push notifications
+	{ID: "getPushDevices", Method: "GET", Path: "/api/current-user/devices", Tag: "Push notifications", Summary: "List the devices notifications are pushed to for the logged in user",
+		Response: []PushDeviceResponse{}},
+	{ID: "registerPushDevice", Method: "POST", Path: "/api/current-user/devices", Tag: "Push notifications", Summary: "Register the FCM token of the device the app runs on, moving it from whoever registered it before",
+		Request: PushDeviceRequest{}, Response: PushDeviceResponse{}, Status: http.StatusCreated},
+	{ID: "deletePushDevice", Method: "DELETE", Path: "/api/current-user/devices/{id}", Tag: "Push notifications", Summary: "Stop pushing notifications to a device of the logged in user",
+		Status: http.StatusNoContent},
+
+	// Webhooks
+	{ID: "getWebhooks", Method: "GET", Path: "/api/admin/webhooks", Tag: "Webhooks", Summary: "List the webhooks, without their secrets",
+		Response: []WebhookResponse{}},
+	{ID: "createWebhook", Method: "POST", Path: "/api/admin/webhooks", Tag: "Webhooks", Summary: "Add a webhook, the response being the only one with its secret",
+		Request: WebhookRequest{}, Response: WebhookResponse{}, Status: http.StatusCreated},
+	{ID: "getWebhook", Method: "GET", Path: "/api/admin/webhooks/{id}", Tag: "Webhooks", Summary: "Get a webhook",
+		Response: WebhookResponse{}},
+	{ID: "updateWebhook", Method: "PATCH", Path: "/api/admin/webhooks/{id}", Tag: "Webhooks", Summary: "Change the URL, events, description or activity of a webhook",
+		Request: WebhookUpdateRequest{}, Response: WebhookResponse{}},
+	{ID: "deleteWebhook", Method: "DELETE", Path: "/api/admin/webhooks/{id}", Tag: "Webhooks", Summary: "Delete a webhook and its deliveries",
+		Status: http.StatusNoContent},
+	{ID: "pingWebhook", Method: "POST", Path: "/api/admin/webhooks/{id}/ping", Tag: "Webhooks", Summary: "Send a ping delivery to check the receiver",
+		Response: WebhookDeliveryResponse{}, Status: http.StatusAccepted},
+	{ID: "getWebhookDeliveries", Method: "GET", Path: "/api/admin/webhooks/{id}/deliveries", Tag: "Webhooks", Summary: "List the deliveries of a webhook, newest first",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[WebhookDeliveryResponse]{}},
+	{ID: "getWebhookDelivery", Method: "GET", Path: "/api/admin/webhooks/{id}/deliveries/{delivery_id}", Tag: "Webhooks", Summary: "Get a delivery with its payload and how the receiver answered",
+		Response: WebhookDeliveryResponse{}},
+	{ID: "redeliverWebhookDelivery", Method: "POST", Path: "/api/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver", Tag: "Webhooks", Summary: "Send a delivered or failed delivery again",
+		Response: WebhookDeliveryResponse{}, Status: http.StatusAccepted},
+
+	// API tokens
+	{ID: "getAPITokens", Method: "GET", Path: "/api/admin/api-tokens", Tag: "API tokens", Summary: "List the API tokens of internal services, revoked ones included, without the tokens",
+		Response: []APITokenResponse{}},
+	{ID: "createAPIToken", Method: "POST", Path: "/api/admin/api-tokens", Tag: "API tokens", Summary: "Create an API token with scopes, the response being the only one with the token",
+		Request: APITokenRequest{}, Response: APITokenResponse{}, Status: http.StatusCreated},
+	{ID: "revokeAPIToken", Method: "DELETE", Path: "/api/admin/api-tokens/{id}", Tag: "API tokens", Summary: "Revoke an API token, which stops working at once",
+		Status: http.StatusNoContent},
+	{ID: "getAvailability", Method: "GET", Path: "/api/availability", Tag: "API tokens", Summary: "Look up whether users are in today, on leave, on a holiday or off for the weekend, with an API token of the availability scope",
+		Query:    []apiParameter{queryParam("usernames", "string", "Comma separated usernames, at most 100")},
+		Response: AvailabilityListResponse{}},
+	{ID: "getUserAvailability", Method: "GET", Path: "/api/availability/{username}", Tag: "API tokens", Summary: "Look up whether a user is in today, with an API token of the availability scope",
+		Response: AvailabilityResponse{}},
+
+	// Imports
+	{ID: "importWorkbook", Method: "POST", Path: "/api/admin/import", Tag: "Imports", Summary: "Import users, historical leave, balances and medical expenses from a legacy HR spreadsheet, as the JSON below or an XLSX file of the same sheets in the file field of multipart/form-data. It is written in one transaction only when clean, answering 422 with the problems of each sheet otherwise",
+		Query:   []apiParameter{queryParam("dry_run", "boolean", "Only check the workbook, writing nothing")},
+		Request: hrimport.Workbook{}, Response: ImportResponse{}, Status: http.StatusCreated},
+
+	// Balance events
+	{ID: "getBalanceEvents", Method: "GET", Path: "/api/admin/balance-events", Tag: "Balance events", Summary: "List the leave, task logs, medical expenses and adjustments that made up a user's annual record, in the order they were recorded",
+		Query:    []apiParameter{queryParam("user_id", "integer", "The user"), queryParam("year", "integer", "Defaults to this year")},
+		Response: []BalanceEventResponse{}},
+	{ID: "replayBalanceEvents", Method: "GET", Path: "/api/admin/balance-events/replay", Tag: "Balance events", Summary: "Add up a user's balance events of a year as they were at a time, writing nothing",
+		Query: []apiParameter{queryParam("user_id", "integer", "The user"), queryParam("year", "integer", "Defaults to this year"),
+			queryParam("as_of", "string", "A time in RFC 3339, or a day as YYYY-MM-DD up to its end, defaults to now")},
+		Response: BalanceReplayResponse{}},
+	{ID: "getBalanceDrift", Method: "GET", Path: "/api/admin/balance-events/drift", Tag: "Balance events", Summary: "List the annual records of a year whose totals aren't what their balance events add up to",
+		Query:    []apiParameter{queryParam("year", "integer", "Defaults to this year")},
+		Response: []BalanceDriftResponse{}},
+	{ID: "rebuildAnnualRecords", Method: "POST", Path: "/api/admin/balance-events/rebuild", Tag: "Balance events", Summary: "Derive every annual record of a year from its balance events again",
+		Query:    []apiParameter{queryParam("year", "integer", "Defaults to this year")},
+		Response: BalanceRebuildResponse{}},
+
+	// Payroll
+	{ID: "getPayrollPeriods", Method: "GET", Path: "/api/admin/payroll/periods", Tag: "Payroll", Summary: "List the pay periods, the latest first",
+		Response: []PayrollPeriodResponse{}},
+	{ID: "createPayrollPeriod", Method: "POST", Path: "/api/admin/payroll/periods", Tag: "Payroll", Summary: "Add a pay period, covering its month unless other days are given",
+		Request: PayrollPeriodRequest{}, Response: PayrollPeriodResponse{}, Status: http.StatusCreated},
+	{ID: "lockPayrollPeriod", Method: "POST", Path: "/api/admin/payroll/periods/{period}/lock", Tag: "Payroll", Summary: "Store the export of a pay period as it is now, which is what gets exported from then on",
+		Response: PayrollPeriodResponse{}},
+	{ID: "unlockPayrollPeriod", Method: "POST", Path: "/api/admin/payroll/periods/{period}/unlock", Tag: "Payroll", Summary: "Throw the export of a locked pay period away so it can be corrected",
+		Response: PayrollPeriodResponse{}},
+	{ID: "previewPayroll", Method: "GET", Path: "/api/admin/payroll/preview", Tag: "Payroll", Summary: "Work out each user's paid and unpaid leave, overtime and holiday work in a pay period as of now",
+		Query:    []apiParameter{queryParam("period", "string", "The pay period, YYYY-MM")},
+		Response: PayrollPreviewResponse{}},
+	{ID: "exportPayroll", Method: "GET", Path: "/api/admin/payroll/export", Tag: "Payroll", Summary: "Download the fixed-width payroll file of a locked pay period",
+		Query: []apiParameter{queryParam("period", "string", "The pay period, YYYY-MM")}},
+
+	// Task categories
+	{ID: "getTaskCategories", Method: "GET", Path: "/api/task-categories", Tag: "Task categories", Summary: "List task categories",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[TaskCategoryResponse]{}},
+	{ID: "getTaskCategory", Method: "GET", Path: "/api/task-categories/{id}", Tag: "Task categories", Summary: "Get a task category",
+		Response: TaskCategoryResponse{}},
+	{ID: "createTaskCategory", Method: "POST", Path: "/api/task-categories", Tag: "Task categories", Summary: "Create a task category",
+		Request: TaskCategoryRequest{}, Response: TaskCategoryResponse{}, Status: http.StatusCreated},
+	{ID: "reorderTaskCategories", Method: "POST", Path: "/api/task-categories/reorder", Tag: "Task categories", Summary: "Reorder sibling task categories",
+		Request: TaskCategoryReorderRequest{}, Response: []TaskCategoryResponse{}},
+	{ID: "updateTaskCategory", Method: "PUT", Path: "/api/task-categories/{id}", Tag: "Task categories", Summary: "Update a task category",
+		Request: TaskCategoryRequest{}, Response: TaskCategoryResponse{}},
+	{ID: "deleteTaskCategory", Method: "DELETE", Path: "/api/task-categories/{id}", Tag: "Task categories", Summary: "Delete a task category",
+		Query: []apiParameter{
+			queryParam("mode", "string", "block (default) refuses while tasks use the category, archive keeps it for them, reassign moves them to target_id"),
+			queryParam("target_id", "integer", "Category taking over the tasks when mode is reassign"),
+		},
+		Response: stringMap{}, Conflict: TaskCategoryInUseResponse{}},
+	{ID: "moveTaskCategory", Method: "POST", Path: "/api/task-categories/{id}/move", Tag: "Task categories", Summary: "Move a task category under another parent",
+		Request: TaskCategoryMoveRequest{}, Response: TaskCategoryResponse{}},
+	{ID: "mergeTaskCategory", Method: "POST", Path: "/api/task-categories/{id}/merge", Tag: "Task categories", Summary: "Merge a task category into another",
+		Request: TaskCategoryMergeRequest{}, Response: TaskCategoryMergeResponse{}},
+	{ID: "getHierarchicalTaskCategories", Method: "GET", Path: "/api/task-categories/hierarchical", Tag: "Task categories", Summary: "Get the task category tree",
+		Response: []TaskCategoryResponse{}},
+
+	// Tasks
+	{ID: "getTasks", Method: "GET", Path: "/api/tasks", Tag: "Tasks", Summary: "List tasks",
+		Query: listParams(taskListSpec,
+			limitQuery, offsetQuery,
+			queryParam("status", "string", "Only tasks with this status"),
+			queryParam("category_id", "integer", "Only tasks of this category"),
+			queryParam("tag_id", "integer", "Only tasks with this tag"),
+			queryParam("assignee", "string", "Only tasks assigned to this user ID, or to the logged in user with me"),
+			queryParam("overdue", "boolean", "Only overdue tasks"),
+			queryParam("due_date", "string", "Only tasks due on this day, YYYY-MM-DD"),
+		),
+		Response: Page[TaskResponse]{}},
+	{ID: "getTask", Method: "GET", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Get a task",
+		Response: TaskResponse{}},
+	{ID: "createTask", Method: "POST", Path: "/api/tasks", Tag: "Tasks", Summary: "Create a task",
+		Request: TaskRequest{}, Response: TaskResponse{}, Status: http.StatusCreated, Conflict: DuplicateTaskResponse{}},
+	{ID: "updateTask", Method: "PUT", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Update a task",
+		Request: TaskRequest{}, Response: TaskResponse{}, Conflict: ConflictResponse{}},
+	{ID: "deleteTask", Method: "DELETE", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Delete a task",
+		Response: stringMap{}},
+	{ID: "getTaskAssignees", Method: "GET", Path: "/api/tasks/{id}/assignees", Tag: "Tasks", Summary: "List the assignees of a task",
+		Response: []TaskAssigneeResponse{}},
+	{ID: "assignTask", Method: "POST", Path: "/api/tasks/{id}/assignees", Tag: "Tasks", Summary: "Assign a user to a task",
+		Request: TaskAssigneeRequest{}, Response: []TaskAssigneeResponse{}},
+	{ID: "unassignTask", Method: "DELETE", Path: "/api/tasks/{id}/assignees/{user_id}", Tag: "Tasks", Summary: "Unassign a user from a task",
+		Response: []TaskAssigneeResponse{}},
+	{ID: "getTasksByCategory", Method: "GET", Path: "/api/categories/{category_id}/tasks", Tag: "Tasks", Summary: "List the tasks of a category",
+		Response: []TaskResponse{}},
+	{ID: "getSubtasks", Method: "GET", Path: "/api/tasks/{id}/subtasks", Tag: "Tasks", Summary: "List the subtasks of a task",
+		Response: []TaskResponse{}},
+	{ID: "setTaskParent", Method: "PUT", Path: "/api/tasks/{id}/parent", Tag: "Tasks", Summary: "Set or clear the parent of a task",
+		Request: TaskParentRequest{}, Response: TaskResponse{}},
+	{ID: "getTaskActivity", Method: "GET", Path: "/api/tasks/{id}/activity", Tag: "Tasks", Summary: "List the activity of a task",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[TaskActivityResponse]{}},
+	{ID: "getTaskBurndown", Method: "GET", Path: "/api/tasks/{id}/burndown", Tag: "Tasks", Summary: "Get the burndown of a task",
+		Response: TaskBurndownResponse{}},
+
+	// Tags
+	{ID: "getTags", Method: "GET", Path: "/api/tags", Tag: "Tags", Summary: "List tags",
+		Response: []TagResponse{}},
+	{ID: "createTag", Method: "POST", Path: "/api/tags", Tag: "Tags", Summary: "Create a tag",
+		Request: TagRequest{}, Response: TagResponse{}, Status: http.StatusCreated},
+	{ID: "updateTag", Method: "PUT", Path: "/api/tags/{id}", Tag: "Tags", Summary: "Update a tag",
+		Request: TagRequest{}, Response: TagResponse{}},
+	{ID: "deleteTag", Method: "DELETE", Path: "/api/tags/{id}", Tag: "Tags", Summary: "Delete a tag",
+		Response: stringMap{}},
+	{ID: "getTaskTags", Method: "GET", Path: "/api/tasks/{id}/tags", Tag: "Tags", Summary: "List the tags of a task",
+		Response: []TagResponse{}},
+	{ID: "addTaskTag", Method: "POST", Path: "/api/tasks/{id}/tags", Tag: "Tags", Summary: "Tag a task",
+		Request: TaskTagRequest{}, Response: []TagResponse{}},
+	{ID: "removeTaskTag", Method: "DELETE", Path: "/api/tasks/{id}/tags/{tag_id}", Tag: "Tags", Summary: "Remove a tag from a task",
+		Response: []TagResponse{}},
+
+	// Reports
+	{ID: "getTagReport", Method: "GET", Path: "/api/reports/tags", Tag: "Reports", Summary: "Sum the days logged per tag",
+		Query: []apiParameter{
+			queryParam("start_date", "string", "First day, YYYY-MM-DD"),
+			queryParam("end_date", "string", "Last day, YYYY-MM-DD"),
+		},
+		Response: []TagReportEntry{}},
+	{ID: "getTaskCategoryReport", Method: "GET", Path: "/api/reports/categories", Tag: "Reports", Summary: "Sum the days logged per task category",
+		Query: []apiParameter{
+			queryParam("from", "string", "First day, YYYY-MM-DD"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD"),
+		},
+		Response: []TaskCategoryReportEntry{}},
+	{ID: "getEstimateVarianceReport", Method: "GET", Path: "/api/reports/estimates", Tag: "Reports", Summary: "Compare task estimates with the days logged",
+		Query: []apiParameter{
+			queryParam("from", "string", "First day, YYYY-MM-DD"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD"),
+			queryParam("sort", "string", "variance (default) or task"),
+			queryParam("unit", "string", "days (default), hours or points"),
+		},
+		Response: []EstimateVarianceEntry{}},
+	{ID: "getTeamCapacityReport", Method: "GET", Path: "/api/reports/capacity", Tag: "Reports", Summary: "Compare the capacity of users with the work assigned to them",
+		Query: []apiParameter{
+			queryParam("from", "string", "First day, YYYY-MM-DD"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD"),
+			queryParam("department", "string", "Only users of this department and those under it"),
+		},
+		Response: TeamCapacityResponse{}},
+	{ID: "getLeaveReport", Method: "GET", Path: "/api/reports/leave", Tag: "Reports", Summary: "Sum the leave taken per user and type in a year",
+		Query: []apiParameter{
+			queryParam("year", "integer", "Year of the report, the current one by default"),
+			queryParam("user_id", "integer", "Only this user, admins only"),
+		},
+		Response: []LeaveReportEntry{}},
+	{ID: "exportReport", Method: "GET", Path: "/api/reports/{name}/export", Tag: "Reports",
+		Summary: "Export the leave, timesheet, utilization or medical-expenses report as XLSX or PDF, the file itself for periods of up to REPORT_ASYNC_DAYS and a report export to poll otherwise",
+		Query: []apiParameter{
+			queryParam("format", "string", "xlsx, the default, or pdf"),
+			queryParam("from", "string", "First day, YYYY-MM-DD"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD"),
+			queryParam("user_id", "integer", "Only this user, admins only"),
+			queryParam("department", "string", "Only users of this department and those under it, admins only"),
+			queryParam("async", "boolean", "Render in the background whatever the period"),
+		},
+		Response: ReportExportResponse{}, Status: http.StatusAccepted},
+	{ID: "getReportExport", Method: "GET", Path: "/api/reports/exports/{id}", Tag: "Reports", Summary: "Get a report export of the current user",
+		Response: ReportExportResponse{}},
+	{ID: "downloadReportExport", Method: "GET", Path: "/api/reports/exports/{id}/download", Tag: "Reports", Summary: "Download the file of a report export once it is done"},
+
+	// Task comments
+	{ID: "getTaskComments", Method: "GET", Path: "/api/tasks/{id}/comments", Tag: "Task comments", Summary: "List the comments of a task",
+		Response: []TaskCommentResponse{}},
+	{ID: "createTaskComment", Method: "POST", Path: "/api/tasks/{id}/comments", Tag: "Task comments", Summary: "Comment on a task",
+		Request: TaskCommentRequest{}, Response: TaskCommentResponse{}, Status: http.StatusCreated},
+	{ID: "updateTaskComment", Method: "PUT", Path: "/api/task-comments/{id}", Tag: "Task comments", Summary: "Update a comment",
+		Request: TaskCommentRequest{}, Response: TaskCommentResponse{}},
+	{ID: "deleteTaskComment", Method: "DELETE", Path: "/api/task-comments/{id}", Tag: "Task comments", Summary: "Delete a comment",
+		Response: stringMap{}},
+
+	// Task estimates
+	{ID: "getTaskEstimates", Method: "GET", Path: "/api/task-estimates", Tag: "Task estimates", Summary: "List task estimates",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[TaskEstimateResponse]{}},
+	{ID: "getTaskEstimate", Method: "GET", Path: "/api/task-estimates/{id}", Tag: "Task estimates", Summary: "Get a task estimate",
+		Response: TaskEstimateResponse{}},
+	{ID: "createTaskEstimate", Method: "POST", Path: "/api/task-estimates", Tag: "Task estimates", Summary: "Estimate a task",
+		Request: TaskEstimateRequest{}, Response: TaskEstimateResponse{}, Status: http.StatusCreated},
+	{ID: "updateTaskEstimate", Method: "PUT", Path: "/api/task-estimates/{id}", Tag: "Task estimates", Summary: "Update a task estimate before work is logged",
+		Request: TaskEstimateRequest{}, Response: TaskEstimateResponse{}},
+	{ID: "deleteTaskEstimate", Method: "DELETE", Path: "/api/task-estimates/{id}", Tag: "Task estimates", Summary: "Delete a task estimate",
+		Response: stringMap{}},
+	{ID: "supersedeTaskEstimate", Method: "POST", Path: "/api/task-estimates/{id}/supersede", Tag: "Task estimates", Summary: "Replace the current estimate of a task with a new revision",
+		Request: TaskEstimateRequest{}, Response: TaskEstimateResponse{}, Status: http.StatusCreated},
+	{ID: "getTaskEstimatesByTask", Method: "GET", Path: "/api/tasks/{task_id}/estimates", Tag: "Task estimates", Summary: "List the estimates of a task",
+		Response: []TaskEstimateResponse{}},
+	{ID: "getCurrentTaskEstimate", Method: "GET", Path: "/api/tasks/{task_id}/estimates/current", Tag: "Task estimates", Summary: "Get the current estimate of a task",
+		Response: TaskEstimateResponse{}},
+
+	// Estimation sessions
+	{ID: "getTaskEstimationSessions", Method: "GET", Path: "/api/tasks/{id}/estimation-sessions", Tag: "Estimation sessions", Summary: "List the estimation sessions of a task",
+		Response: []EstimationSessionResponse{}},
+	{ID: "createEstimationSession", Method: "POST", Path: "/api/tasks/{id}/estimation-sessions", Tag: "Estimation sessions", Summary: "Start an estimation session for a task",
+		Request: EstimationSessionRequest{}, Response: EstimationSessionResponse{}, Status: http.StatusCreated},
+	{ID: "getEstimationSession", Method: "GET", Path: "/api/estimation-sessions/{id}", Tag: "Estimation sessions", Summary: "Get an estimation session",
+		Response: EstimationSessionResponse{}},
+	{ID: "voteInEstimationSession", Method: "PUT", Path: "/api/estimation-sessions/{id}/vote", Tag: "Estimation sessions", Summary: "Vote in an estimation session",
+		Request: EstimationVoteRequest{}, Response: EstimationSessionResponse{}},
+	{ID: "finalizeEstimationSession", Method: "POST", Path: "/api/estimation-sessions/{id}/finalize", Tag: "Estimation sessions", Summary: "Close an estimation session with the agreed estimate",
+		Request: EstimationVoteRequest{}, Response: TaskEstimateResponse{}, Status: http.StatusCreated},
+
+	// Task logs
+	{ID: "getTaskLogsByDateRange", Method: "GET", Path: "/api/task-logs/by-date-range", Tag: "Task logs", Summary: "List the task logs of the logged in user between two days",
+		Query: []apiParameter{
+			queryParam("start_date", "string", "First day, YYYY-MM-DD"),
+			queryParam("end_date", "string", "Last day, YYYY-MM-DD"),
+			queryParam("tag_id", "integer", "Only logs of tasks with this tag"),
+		},
+		Response: []TaskLogResponse{}},
+	{ID: "getTaskLogs", Method: "GET", Path: "/api/task-logs", Tag: "Task logs", Summary: "List the task logs of the logged in user",
+		Query: listParams(taskLogListSpec,
+			limitQuery, offsetQuery, cursorQuery,
+			queryParam("task_id", "integer", "Only logs of this task"),
+			queryParam("worked_date", "string", "Only logs worked on this day, YYYY-MM-DD"),
+		),
+		Response: Page[TaskLogResponse]{}},
+	{ID: "getTaskLog", Method: "GET", Path: "/api/task-logs/{id}", Tag: "Task logs", Summary: "Get a task log",
+		Response: TaskLogResponse{}},
+	{ID: "createTaskLog", Method: "POST", Path: "/api/task-logs", Tag: "Task logs", Summary: "Log work on a task",
+		Request: TaskLogRequest{}, Response: TaskLogResponse{}, Status: http.StatusCreated},
+	{ID: "updateTaskLog", Method: "PUT", Path: "/api/task-logs/{id}", Tag: "Task logs", Summary: "Update a task log",
+		Request: TaskLogRequest{}, Response: TaskLogResponse{}},
+	{ID: "deleteTaskLog", Method: "DELETE", Path: "/api/task-logs/{id}", Tag: "Task logs", Summary: "Delete a task log",
+		Response: stringMap{}},
+	{ID: "getTaskLogsByTask", Method: "GET", Path: "/api/tasks/{task_id}/logs", Tag: "Task logs", Summary: "List the logs of a task",
+		Response: []TaskLogResponse{}},
+
+	// Attendance
+	{ID: "checkIn", Method: "POST", Path: "/api/attendance/check-in", Tag: "Attendance", Summary: "Check the logged in user in, recording their IP and, when sent, their location",
+		Request: AttendanceRequest{}, Response: AttendanceSessionResponse{}, Status: http.StatusCreated},
+	{ID: "checkOut", Method: "POST", Path: "/api/attendance/check-out", Tag: "Attendance", Summary: "Check the logged in user out of their open session",
+		Request: AttendanceRequest{}, Response: AttendanceSessionResponse{}},
+	{ID: "getMyAttendance", Method: "GET", Path: "/api/attendance/me", Tag: "Attendance", Summary: "List the sessions of the logged in user, the latest first",
+		Query: []apiParameter{
+			queryParam("from", "string", "First day, YYYY-MM-DD, 30 days ago by default"),
+			queryParam("to", "string", "Last day, YYYY-MM-DD, today in the user's time zone by default"),
+		},
+		Response: []AttendanceSessionResponse{}},
+	{ID: "getDailyAttendance", Method: "GET", Path: "/api/attendance/daily", Tag: "Attendance", Summary: "List everyone's check-ins, hours and leave on a day, admin only",
+		Query: []apiParameter{
+			queryParam("date", "string", "The day, YYYY-MM-DD, today in the user's time zone by default"),
+			queryParam("department", "string", "Only users of this department and those under it"),
+		},
+		Response: []DailyAttendanceResponse{}},
+	{ID: "getAttendanceSuggestion", Method: "GET", Path: "/api/attendance/suggestion", Tag: "Attendance", Summary: "Suggest the worked day to log from the hours the logged in user was checked in",
+		Query:    []apiParameter{queryParam("date", "string", "The day, YYYY-MM-DD, today in the user's time zone by default")},
+		Response: AttendanceSuggestionResponse{}},
+
+	// Org chart
+	{ID: "getDepartments", Method: "GET", Path: "/api/departments", Tag: "Org chart", Summary: "List the departments, by name",
+		Response: []DepartmentResponse{}},
+	{ID: "createDepartment", Method: "POST", Path: "/api/departments", Tag: "Org chart", Summary: "Create a department, admin only",
+		Request: DepartmentRequest{}, Response: DepartmentResponse{}, Status: http.StatusCreated},
+	{ID: "updateDepartment", Method: "PUT", Path: "/api/departments/{id}", Tag: "Org chart", Summary: "Update a department, moving its users and task categories along when it is renamed, admin only",
+		Request: DepartmentRequest{}, Response: DepartmentResponse{}},
+	{ID: "deleteDepartment", Method: "DELETE", Path: "/api/departments/{id}", Tag: "Org chart", Summary: "Delete a department without users or departments under it, admin only",
+		Status: http.StatusNoContent},
+	{ID: "getPositions", Method: "GET", Path: "/api/positions", Tag: "Org chart", Summary: "List the positions, by title",
+		Response: []PositionResponse{}},
+	{ID: "createPosition", Method: "POST", Path: "/api/positions", Tag: "Org chart", Summary: "Create a position, admin only",
+		Request: PositionRequest{}, Response: PositionResponse{}, Status: http.StatusCreated},
+	{ID: "updatePosition", Method: "PUT", Path: "/api/positions/{id}", Tag: "Org chart", Summary: "Update a position, admin only",
+		Request: PositionRequest{}, Response: PositionResponse{}},
+	{ID: "deletePosition", Method: "DELETE", Path: "/api/positions/{id}", Tag: "Org chart", Summary: "Delete a position, leaving the users holding it without one, admin only",
+		Status: http.StatusNoContent},
+	{ID: "getReportingLine", Method: "GET", Path: "/api/users/{id}/reporting", Tag: "Org chart", Summary: "Get the position a user holds and who they report to",
+		Response: ReportingLineResponse{}},
+	{ID: "updateReportingLine", Method: "PUT", Path: "/api/users/{id}/reporting", Tag: "Org chart", Summary: "Set the position a user holds and who they report to, admin only",
+		Request: ReportingLineRequest{}, Response: ReportingLineResponse{}},
+	{ID: "getOrgChart", Method: "GET", Path: "/api/org-chart", Tag: "Org chart", Summary: "Get the departments as a tree with their heads and members",
+		Response: OrgChartResponse{}},
+
+	// Approvals
+	{ID: "getApprovalPolicies", Method: "GET", Path: "/api/admin/approval-policies", Tag: "Approvals", Summary: "List the approval policies, by kind",
+		Response: []ApprovalPolicyResponse{}},
+	{ID: "putApprovalPolicy", Method: "PUT", Path: "/api/admin/approval-policies/{kind}", Tag: "Approvals", Summary: "Set the steps leave, medical_expense, timesheet or quota_plan requests go through, admin only",
+		Request: ApprovalPolicyRequest{}, Response: ApprovalPolicyResponse{}},
+	{ID: "deleteApprovalPolicy", Method: "DELETE", Path: "/api/admin/approval-policies/{kind}", Tag: "Approvals", Summary: "Stop requests of a kind needing approval, admin only",
+		Status: http.StatusNoContent},
+	{ID: "getApprovalInbox", Method: "GET", Path: "/api/approvals/inbox", Tag: "Approvals", Summary: "List the requests the caller may decide now, also for whoever delegated to them, oldest first",
+		Response: []ApprovalRequestResponse{}},
+	{ID: "getMyApprovalRequests", Method: "GET", Path: "/api/approvals/mine", Tag: "Approvals", Summary: "List the requests the caller submitted, latest first",
+		Response: []ApprovalRequestResponse{}},
+	{ID: "getApprovalRequest", Method: "GET", Path: "/api/approvals/{id}", Tag: "Approvals", Summary: "Get a request with its decisions",
+		Response: ApprovalRequestResponse{}},
+	{ID: "approveApprovalRequest", Method: "POST", Path: "/api/approvals/{id}/approve", Tag: "Approvals", Summary: "Approve the step a request waits on, answering 409 when it was decided already",
+		Request: ApprovalDecisionRequest{}, Response: ApprovalRequestResponse{}},
+	{ID: "rejectApprovalRequest", Method: "POST", Path: "/api/approvals/{id}/reject", Tag: "Approvals", Summary: "Reject a request, deleting the leave, expense or task log it is about, answering 409 when it was decided already",
+		Request: ApprovalDecisionRequest{}, Response: ApprovalRequestResponse{}},
+	{ID: "getApprovalDelegations", Method: "GET", Path: "/api/approval-delegations", Tag: "Approvals", Summary: "List the delegations the caller gave or was given, revoked ones too, latest first",
+		Query:    []apiParameter{queryParam("user_id", "integer", "Those of this user instead, admins only")},
+		Response: []ApprovalDelegationResponse{}},
+	{ID: "createApprovalDelegation", Method: "POST", Path: "/api/approval-delegations", Tag: "Approvals", Summary: "Let someone else decide the caller's approvals for some days, admins anyone's",
+		Request: ApprovalDelegationRequest{}, Response: ApprovalDelegationResponse{}, Status: http.StatusCreated},
+	{ID: "getApprovalDelegationSuggestions", Method: "GET", Path: "/api/approval-delegations/suggestions", Tag: "Approvals", Summary: "List the caller's coming leave no delegation covers with who to delegate to, none when they approve no one",
+		Response: []ApprovalDelegationSuggestion{}},
+	{ID: "getApprovalDelegation", Method: "GET", Path: "/api/approval-delegations/{id}", Tag: "Approvals", Summary: "Get a delegation with the decisions made under it, for the delegator, the delegate and admins",
+		Response: ApprovalDelegationResponse{}},
+	{ID: "deleteApprovalDelegation", Method: "DELETE", Path: "/api/approval-delegations/{id}", Tag: "Approvals", Summary: "Revoke a delegation, keeping it for the audit trail, for the delegator and admins",
+		Status: http.StatusNoContent},
+
+	// Files
+	{ID: "uploadFile", Method: "POST", Path: "/api/files", Tag: "Files", Summary: "Upload a medical receipt, sick leave certificate or avatar as multipart/form-data with the fields purpose, subjectId and file, for the caller's own expenses, leave and avatar, admins anyone's",
+		Response: FileResponse{}, Status: http.StatusCreated},
+	{ID: "getFiles", Method: "GET", Path: "/api/files", Tag: "Files", Summary: "List the files of a medical expense, leave log or user, oldest first",
+		Query: []apiParameter{
+			queryParam("purpose", "string", "medical_receipt, sick_leave_certificate or avatar"),
+			queryParam("subjectId", "integer", "The medical expense, leave log or user"),
+		},
+		Response: []FileResponse{}},
+	{ID: "getFile", Method: "GET", Path: "/api/files/{id}", Tag: "Files", Summary: "Get a file with a download URL that expires after FILE_URL_EXPIRY",
+		Response: FileResponse{}},
+	{ID: "deleteFile", Method: "DELETE", Path: "/api/files/{id}", Tag: "Files", Summary: "Delete a file, for whoever uploaded it, the owner of what it belongs to and admins",
+		Status: http.StatusNoContent},
+	{ID: "getFileContent", Method: "GET", Path: "/api/files/{id}/content", Tag: "Files", Summary: "Download a file kept in a local directory, by the signed URL getFile answers with",
+		Query: []apiParameter{
+			queryParam("expires", "integer", "When the URL expires, a Unix time"),
+			queryParam("signature", "string", "The server's signature of the URL"),
+		},
+		Public: true},
+	{ID: "getUserAvatar", Method: "GET", Path: "/api/users/{id}/avatar", Tag: "Files", Summary: "Redirect to the download URL of a user's avatar",
+		Status: http.StatusFound},
+
+	// Announcements
+	{ID: "getAnnouncements", Method: "GET", Path: "/api/announcements", Tag: "Announcements", Summary: "List the announcements published now for the logged in user, the latest first, for the dashboard banner",
+		Response: []CurrentAnnouncementResponse{}},
+	{ID: "acknowledgeAnnouncement", Method: "POST", Path: "/api/announcements/{id}/acknowledge", Tag: "Announcements", Summary: "Acknowledge having read a notice that asks for it",
+		Response: CurrentAnnouncementResponse{}},
+	{ID: "listAnnouncements", Method: "GET", Path: "/api/admin/announcements", Tag: "Announcements", Summary: "List every announcement, published or not, the latest to publish first",
+		Query: []apiParameter{limitQuery, offsetQuery}, Response: Page[AnnouncementResponse]{}},
+	{ID: "createAnnouncement", Method: "POST", Path: "/api/admin/announcements", Tag: "Announcements", Summary: "Post an announcement for everyone or some user types, departments and users",
+		Request: AnnouncementRequest{}, Response: AnnouncementResponse{}, Status: http.StatusCreated},
+	{ID: "getAnnouncement", Method: "GET", Path: "/api/admin/announcements/{id}", Tag: "Announcements", Summary: "Get an announcement",
+		Response: AnnouncementResponse{}},
+	{ID: "updateAnnouncement", Method: "PUT", Path: "/api/admin/announcements/{id}", Tag: "Announcements", Summary: "Update an announcement, keeping its acknowledgments",
+		Request: AnnouncementRequest{}, Response: AnnouncementResponse{}},
+	{ID: "deleteAnnouncement", Method: "DELETE", Path: "/api/admin/announcements/{id}", Tag: "Announcements", Summary: "Delete an announcement and its acknowledgments",
+		Status: http.StatusNoContent},
+	{ID: "getAnnouncementAcknowledgments", Method: "GET", Path: "/api/admin/announcements/{id}/acknowledgments", Tag: "Announcements", Summary: "List who acknowledged an announcement and who it is for that hasn't yet",
+		Response: AnnouncementAcknowledgmentsResponse{}},
+
+	// Anomalies
+	{ID: "getAnomalies", Method: "GET", Path: "/api/anomalies", Tag: "Anomalies", Summary: "List the patterns the anomaly-detection job flagged, newest first, for admins and ANOMALY_REVIEW_DEPARTMENT",
+		Query: []apiParameter{
+			limitQuery, offsetQuery,
+			queryParam("status", "string", "open, the default, confirmed, dismissed or all"),
+			queryParam("rule", "string", "sick-mondays, over-logging or expense-near-limit"),
+			queryParam("user_id", "integer", "Only the findings of this user"),
+		},
+		Response: Page[AnomalyResponse]{}},
+	{ID: "getAnomaly", Method: "GET", Path: "/api/anomalies/{id}", Tag: "Anomalies", Summary: "Get a flagged pattern with what's behind it",
+		Response: AnomalyResponse{}},
+	{ID: "reviewAnomaly", Method: "POST", Path: "/api/anomalies/{id}/review", Tag: "Anomalies", Summary: "Confirm or dismiss a flagged pattern, or open it again",
+		Request: AnomalyReviewRequest{}, Response: AnomalyResponse{}},
+
+	// Administration
+	{ID: "purgeDeleted", Method: "DELETE", Path: "/api/admin/deleted/{kind}", Tag: "Administration", Summary: "Purge soft deleted users, tasks, leave-logs or medical-expenses for good",
+		Query:    []apiParameter{queryParam("before", "string", "Only rows deleted before this day, YYYY-MM-DD")},
+		Response: PurgeResponse{}},
+	{ID: "restoreDeleted", Method: "POST", Path: "/api/admin/deleted/{kind}/{id}/restore", Tag: "Administration", Summary: "Restore a soft deleted user, task, leave-log or medical-expense, which anonymized users can't be",
+		Status: http.StatusNoContent},
+	{ID: "anonymizeUser", Method: "POST", Path: "/api/admin/users/{id}/anonymize", Tag: "Administration", Summary: "Scrub the name, email, notes, locations and files of a deleted user, keeping their leave, work and expenses for statistics",
+		Response: sqlc.Anonymization{}},
+	{ID: "listJobs", Method: "GET", Path: "/api/admin/jobs", Tag: "Administration", Summary: "List the background jobs with their last and next run",
+		Response: []scheduler.Status{}},
+	{ID: "runJob", Method: "POST", Path: "/api/admin/jobs/{name}/run", Tag: "Administration", Summary: "Run a background job now, in the background",
+		Response: scheduler.Status{}, Status: http.StatusAccepted},
+	{ID: "pauseJob", Method: "POST", Path: "/api/admin/jobs/{name}/pause", Tag: "Administration", Summary: "Pause a background job",
+		Response: scheduler.Status{}},
+	{ID: "resumeJob", Method: "POST", Path: "/api/admin/jobs/{name}/resume", Tag: "Administration", Summary: "Resume a paused background job on its schedule",
+		Response: scheduler.Status{}},
+	{ID: "listQueuedJobs", Method: "GET", Path: "/api/admin/queue", Tag: "Administration", Summary: "List the queued jobs in a state, newest first",
+		Query:    []apiParameter{limitQuery, offsetQuery, queryParam("status", "string", "pending, running, done or dead, dead when omitted")},
+		Response: Page[queue.Job]{}},
+	{ID: "getQueuedJob", Method: "GET", Path: "/api/admin/queue/{id}", Tag: "Administration", Summary: "Get a queued job",
+		Response: queue.Job{}},
+	{ID: "retryQueuedJob", Method: "POST", Path: "/api/admin/queue/{id}/retry", Tag: "Administration", Summary: "Run a dead job again with a fresh set of attempts, answering 409 for other jobs",
+		Response: queue.Job{}},
+	{ID: "listFeatureFlags", Method: "GET", Path: "/api/admin/feature-flags", Tag: "Administration", Summary: "List the feature flags",
+		Response: []FeatureFlagResponse{}},
+	{ID: "setFeatureFlag", Method: "PUT", Path: "/api/admin/feature-flags/{key}", Tag: "Administration", Summary: "Create a feature flag or replace its settings, the maintenance flag holds every write while enabled",
+		Request: FeatureFlagRequest{}, Response: FeatureFlagResponse{}},
+	{ID: "deleteFeatureFlag", Method: "DELETE", Path: "/api/admin/feature-flags/{key}", Tag: "Administration", Summary: "Delete a feature flag, turning its feature off for everyone",
+		Status: http.StatusNoContent},
+	{ID: "getCurrentFeatureFlags", Method: "GET", Path: "/api/feature-flags", Tag: "Administration", Summary: "List the feature flags that are on for the caller and whether writes are held for maintenance",
+		Response: CurrentFeatureFlagsResponse{}},
+	{ID: "getRuntimeSettings", Method: "GET", Path: "/api/admin/settings", Tag: "Administration", Summary: "List the settings that can be changed while the server runs, with their values and defaults",
+		Response: []RuntimeSettingResponse{}},
+	{ID: "updateRuntimeSettings", Method: "PATCH", Path: "/api/admin/settings", Tag: "Administration", Summary: "Override runtime settings by environment variable name, null goes back to the environment",
+		Request: RuntimeSettingsRequest{}, Response: []RuntimeSettingResponse{}},
+
+	// Live updates
+	{ID: "getEvents", Method: "GET", Path: "/api/events", Tag: "Live updates", Summary: "Stream the changes the caller may see as server-sent events, each event's data being one of these, answering 503 without the change feed",
+		Query:    []apiParameter{queryParam("access_token", "string", "The bearer token, for EventSource which can't send headers")},
+		Response: LiveEvent{}},
+
+	// Health
+	{ID: "live", Method: "GET", Path: "/healthz", Tag: "Health", Summary: "Report that the server is running",
+		Response: stringMap{}, Public: true},
+	{ID: "ready", Method: "GET", Path: "/readyz", Tag: "Health", Summary: "Report whether the server can serve requests, answering 503 when it can't",
+		Response: ReadinessResponse{}, Public: true},
+}
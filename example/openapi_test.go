@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+// TestOpenAPIMatchesRoutes fails when a registered route has no OpenAPI operation, or an
+// operation in apiOperations has no registered route
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	cfg := config.Default()
+	cfg.Files.Location = t.TempDir()
+	router := mux.NewRouter()
+	NewServer(cfg, dbtest.NewFake(), nil, nil).RegisterRoutes(router)
+
+	document, problems := newOpenAPIDocument(router)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	if len(document.Paths) == 0 {
+		t.Fatal("the OpenAPI document has no paths")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// isBelowDepartment reports whether the department with the ID is the one with ancestorID or sits
+// somewhere under it, which would make ancestorID's parent a cycle
+func isBelowDepartment(departments []sqlc.Department, id, ancestorID int32) bool {
+	byID := make(map[int32]sqlc.Department, len(departments))
+	for _, d := range departments {
+		byID[d.ID] = d
+	}
+	for seen := 0; seen <= len(departments); seen++ {
+		if id == ancestorID {
+			return true
+		}
+		department, ok := byID[id]
+		if !ok || !department.ParentID.Valid {
+			return false
+		}
+		id = department.ParentID.Int32
+	}
+	return false
+}
+
+// buildOrgChart nests the departments under their parents with their members, the members of a
+// department whose name isn't on the chart, or of none, going to unassigned
+func buildOrgChart(departments []sqlc.Department, members []sqlc.ListOrgChartMembersRow) OrgChartResponse {
+	usernames := make(map[int32]string, len(members))
+	for _, m := range members {
+		usernames[m.UserID] = m.Username
+	}
+
+	nodes := make(map[int32]*OrgChartDepartment, len(departments))
+	byName := make(map[string]*OrgChartDepartment, len(departments))
+	for _, d := range departments {
+		node := &OrgChartDepartment{ID: d.ID, Name: d.Name, Members: []OrgChartMember{}}
+		if d.HeadUserID.Valid {
+			node.HeadUserID = &d.HeadUserID.Int32
+			node.HeadUsername = usernames[d.HeadUserID.Int32]
+		}
+		nodes[d.ID] = node
+		byName[d.Name] = node
+	}
+
+	response := OrgChartResponse{Departments: []OrgChartDepartment{}, Unassigned: []OrgChartMember{}}
+	for _, m := range members {
+		member := OrgChartMember{
+			UserID:        m.UserID,
+			Username:      m.Username,
+			PositionID:    int4Ptr(m.PositionID),
+			PositionTitle: m.PositionTitle.String,
+			ManagerID:     int4Ptr(m.ManagerID),
+		}
+		if m.ManagerID.Valid {
+			member.ManagerUsername = usernames[m.ManagerID.Int32]
+		}
+		if node, ok := byName[m.Department.String]; ok && m.Department.Valid {
+			node.Members = append(node.Members, member)
+		} else {
+			response.Unassigned = append(response.Unassigned, member)
+		}
+	}
+
+	// Departments come by name, so children stay sorted. A parent cycle has no top, so the
+	// departments on it are put at the top rather than lost.
+	var attach func(node *OrgChartDepartment, path []int32) OrgChartDepartment
+	attach = func(node *OrgChartDepartment, path []int32) OrgChartDepartment {
+		tree := *node
+		tree.Children = []OrgChartDepartment{}
+		for _, d := range departments {
+			if d.ParentID.Valid && d.ParentID.Int32 == node.ID && !slices.Contains(path, d.ID) {
+				tree.Children = append(tree.Children, attach(nodes[d.ID], append(path, d.ID)))
+			}
+		}
+		return tree
+	}
+	for _, d := range departments {
+		if _, hasParent := nodes[d.ParentID.Int32]; !d.ParentID.Valid || !hasParent || isBelowDepartment(departments, d.ParentID.Int32, d.ID) {
+			response.Departments = append(response.Departments, attach(nodes[d.ID], []int32{d.ID}))
+		}
+	}
+	return response
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// DepartmentRequest is the body of POST and PUT /api/departments
+type DepartmentRequest struct {
+	Name       string `json:"name" validate:"required,max=100"`
+	ParentID   *int32 `json:"parentId"`   // At the top of the chart when left out
+	HeadUserID *int32 `json:"headUserId"` // Who heads it, and may decide the leave of its members
+}
+
+// DepartmentResponse is a department of the org chart
+type DepartmentResponse struct {
+	ID         int32     `json:"id"`
+	Name       string    `json:"name"`
+	ParentID   *int32    `json:"parentId,omitempty"`
+	HeadUserID *int32    `json:"headUserId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// PositionRequest is the body of POST and PUT /api/positions
+type PositionRequest struct {
+	Title        string `json:"title" validate:"required,max=100"`
+	DepartmentID *int32 `json:"departmentId"` // Open to every department when left out
+}
+
+// PositionResponse is a position users can hold
+type PositionResponse struct {
+	ID           int32  `json:"id"`
+	Title        string `json:"title"`
+	DepartmentID *int32 `json:"departmentId,omitempty"`
+}
+
+// ReportingLineRequest is the body of PUT /api/users/{id}/reporting. Leaving a field out clears it.
+type ReportingLineRequest struct {
+	PositionID *int32 `json:"positionId"`
+	ManagerID  *int32 `json:"managerId"` // Who the user reports to
+}
+
+// ReportingLineResponse is the position a user holds and who they report to
+type ReportingLineResponse struct {
+	UserID     int32  `json:"userId"`
+	PositionID *int32 `json:"positionId,omitempty"`
+	ManagerID  *int32 `json:"managerId,omitempty"`
+}
+
+// OrgChartMember is a user on the org chart
+type OrgChartMember struct {
+	UserID          int32  `json:"userId"`
+	Username        string `json:"username"`
+	PositionID      *int32 `json:"positionId,omitempty"`
+	PositionTitle   string `json:"positionTitle,omitempty"`
+	ManagerID       *int32 `json:"managerId,omitempty"`
+	ManagerUsername string `json:"managerUsername,omitempty"`
+}
+
+// OrgChartDepartment is a department with its members and the departments under it
+type OrgChartDepartment struct {
+	ID           int32                `json:"id"`
+	Name         string               `json:"name"`
+	HeadUserID   *int32               `json:"headUserId,omitempty"`
+	HeadUsername string               `json:"headUsername,omitempty"`
+	Members      []OrgChartMember     `json:"members"`
+	Children     []OrgChartDepartment `json:"children"`
+}
+
+// OrgChartResponse is the org chart, from the departments at its top down
+type OrgChartResponse struct {
+	Departments []OrgChartDepartment `json:"departments"`
+	Unassigned  []OrgChartMember     `json:"unassigned"` // Users in no department of the chart
+}
+
+// LeaveCalendarEntry is a day of leave on the leave calendar
+type LeaveCalendarEntry struct {
+	LeaveLogID int32  `json:"leaveLogId"`
+	UserID     int32  `json:"userId"`
+	Username   string `json:"username"`
+	Department string `json:"department,omitempty"`
+	Type       string `json:"type"`
+	Date       string `json:"date"`
+	Note       string `json:"note,omitempty"`
+}
+
+func departmentToResponse(department sqlc.Department) DepartmentResponse {
+	return DepartmentResponse{
+		ID:         department.ID,
+		Name:       department.Name,
+		ParentID:   int4Ptr(department.ParentID),
+		HeadUserID: int4Ptr(department.HeadUserID),
+		CreatedAt:  department.CreatedAt.Time,
+		UpdatedAt:  department.UpdatedAt.Time,
+	}
+}
+
+func positionToResponse(position sqlc.Position) PositionResponse {
+	return PositionResponse{ID: position.ID, Title: position.Title, DepartmentID: int4Ptr(position.DepartmentID)}
+}
+
+// optionalID converts an ID left out of a request to NULL
+func optionalID(id *int32) pgtype.Int4 {
+	if id == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *id, Valid: true}
+}
+
+// authorizeOrgChartAdmin answers 403 to users other than admins and returns the admin otherwise
+func (s *Server) authorizeOrgChartAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can change the org chart")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getDepartments handles GET /api/departments, by name
+func (s *Server) getDepartments(w http.ResponseWriter, r *http.Request) {
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	departments, err := s.store.ListDepartments(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching departments: "+err.Error())
+		return
+	}
+	response := make([]DepartmentResponse, 0, len(departments))
+	for _, department := range departments {
+		response = append(response, departmentToResponse(department))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// validateDepartment checks the parent and head of a department exist, and for an existing
+// department, id, that the parent isn't the department itself or one under it
+func (s *Server) validateDepartment(r *http.Request, req DepartmentRequest, id int32) error {
+	errs := validate.Errors{}
+	if req.ParentID != nil {
+		departments, err := s.store.ListDepartments(r.Context())
+		if err != nil {
+			return err
+		}
+		if !departmentExists(departments, *req.ParentID) {
+			errs.Add("parentId", "department not found")
+		} else if id != 0 && isBelowDepartment(departments, *req.ParentID, id) {
+			errs.Add("parentId", "must not be the department itself or one under it")
+		}
+	}
+	if req.HeadUserID != nil {
+		if _, err := s.store.GetUser(r.Context(), *req.HeadUserID); err != nil {
+			errs.Add("headUserId", "user not found")
+		}
+	}
+	return errs.Err()
+}
+
+func departmentExists(departments []sqlc.Department, id int32) bool {
+	for _, department := range departments {
+		if department.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithDepartmentError answers 422 for invalid fields and 500 when they couldn't be checked
+func respondWithDepartmentError(w http.ResponseWriter, err error) {
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		respondWithValidationError(w, err)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Error validating department: "+err.Error())
+}
+
+// departmentNameTaken reports whether another department than id has the name
+func (s *Server) departmentNameTaken(r *http.Request, name string, id int32) (bool, error) {
+	existing, err := s.store.GetDepartmentByName(r.Context(), name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return existing.ID != id, nil
+}
+
+// createDepartment handles POST /api/departments
+func (s *Server) createDepartment(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	var req DepartmentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validateDepartment(r, req, 0); err != nil {
+		respondWithDepartmentError(w, err)
+		return
+	}
+	if taken, err := s.departmentNameTaken(r, req.Name, 0); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching department: "+err.Error())
+		return
+	} else if taken {
+		respondWithError(w, http.StatusConflict, "Department "+req.Name+" already exists")
+		return
+	}
+
+	department, err := s.store.CreateDepartment(r.Context(), sqlc.CreateDepartmentParams{
+		Name:       req.Name,
+		ParentID:   optionalID(req.ParentID),
+		HeadUserID: optionalID(req.HeadUserID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating department: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, departmentToResponse(department))
+}
+
+// updateDepartment handles PUT /api/departments/{id}. Renaming a department moves its users and
+// the task categories scoped to it along.
+func (s *Server) updateDepartment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid department ID")
+		return
+	}
+	current, err := s.store.GetDepartment(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Department not found")
+		return
+	}
+
+	var req DepartmentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validateDepartment(r, req, current.ID); err != nil {
+		respondWithDepartmentError(w, err)
+		return
+	}
+	if taken, err := s.departmentNameTaken(r, req.Name, current.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching department: "+err.Error())
+		return
+	} else if taken {
+		respondWithError(w, http.StatusConflict, "Department "+req.Name+" already exists")
+		return
+	}
+
+	var department sqlc.Department
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		var err error
+		department, err = qtx.UpdateDepartment(ctx, sqlc.UpdateDepartmentParams{
+			ID:         current.ID,
+			Name:       req.Name,
+			ParentID:   optionalID(req.ParentID),
+			HeadUserID: optionalID(req.HeadUserID),
+		})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error updating department: "+err.Error())
+		}
+		if department.Name == current.Name {
+			return nil
+		}
+		if err := qtx.RenameUserDepartments(ctx, sqlc.RenameUserDepartmentsParams{NewName: department.Name, OldName: current.Name}); err != nil {
+			return txError(http.StatusInternalServerError, "Error moving users to the department's new name: "+err.Error())
+		}
+		if err := qtx.RenameTaskCategoryDepartments(ctx, sqlc.RenameTaskCategoryDepartmentsParams{NewName: department.Name, OldName: current.Name}); err != nil {
+			return txError(http.StatusInternalServerError, "Error moving task categories to the department's new name: "+err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, departmentToResponse(department))
+}
+
+// deleteDepartment handles DELETE /api/departments/{id}, refusing while users are in it or
+// departments under it
+func (s *Server) deleteDepartment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid department ID")
+		return
+	}
+	department, err := s.store.GetDepartment(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Department not found")
+		return
+	}
+
+	members, err := s.store.CountDepartmentMembers(ctx, department.Name)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting department members: "+err.Error())
+		return
+	}
+	if members > 0 {
+		respondWithError(w, http.StatusConflict, "Move the users out of "+department.Name+" before deleting it")
+		return
+	}
+	children, err := s.store.CountSubDepartments(ctx, pgtype.Int4{Int32: department.ID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting sub-departments: "+err.Error())
+		return
+	}
+	if children > 0 {
+		respondWithError(w, http.StatusConflict, "Move or delete the departments under "+department.Name+" before deleting it")
+		return
+	}
+
+	if err := s.store.DeleteDepartment(ctx, department.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting department: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPositions handles GET /api/positions, by title
+func (s *Server) getPositions(w http.ResponseWriter, r *http.Request) {
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	positions, err := s.store.ListPositions(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching positions: "+err.Error())
+		return
+	}
+	response := make([]PositionResponse, 0, len(positions))
+	for _, position := range positions {
+		response = append(response, positionToResponse(position))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// decodePositionRequest reads and checks the body of a position, answering 400 or 422 otherwise
+func (s *Server) decodePositionRequest(w http.ResponseWriter, r *http.Request) (PositionRequest, bool) {
+	var req PositionRequest
+	if !decodeRequest(w, r, &req) {
+		return req, false
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.DepartmentID != nil {
+		if _, err := s.store.GetDepartment(r.Context(), *req.DepartmentID); err != nil {
+			errs := validate.Errors{}
+			errs.Add("departmentId", "department not found")
+			respondWithValidationError(w, errs.Err())
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// createPosition handles POST /api/positions
+func (s *Server) createPosition(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	req, ok := s.decodePositionRequest(w, r)
+	if !ok {
+		return
+	}
+	position, err := s.store.CreatePosition(r.Context(), sqlc.CreatePositionParams{
+		Title:        req.Title,
+		DepartmentID: optionalID(req.DepartmentID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating position: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, positionToResponse(position))
+}
+
+// updatePosition handles PUT /api/positions/{id}
+func (s *Server) updatePosition(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid position ID")
+		return
+	}
+	if _, err := s.store.GetPosition(r.Context(), int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Position not found")
+		return
+	}
+	req, ok := s.decodePositionRequest(w, r)
+	if !ok {
+		return
+	}
+	position, err := s.store.UpdatePosition(r.Context(), sqlc.UpdatePositionParams{
+		ID:           int32(id),
+		Title:        req.Title,
+		DepartmentID: optionalID(req.DepartmentID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating position: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, positionToResponse(position))
+}
+
+// deletePosition handles DELETE /api/positions/{id}, the users holding it are left without one
+func (s *Server) deletePosition(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid position ID")
+		return
+	}
+	if _, err := s.store.GetPosition(r.Context(), int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Position not found")
+		return
+	}
+	if err := s.store.DeletePosition(r.Context(), int32(id)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting position: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getReportingLine handles GET /api/users/{id}/reporting
+func (s *Server) getReportingLine(w http.ResponseWriter, r *http.Request) {
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if _, err := s.store.GetUser(r.Context(), int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	assignment, err := s.store.GetOrgAssignment(r.Context(), int32(id))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching reporting line: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, ReportingLineResponse{
+		UserID:     int32(id),
+		PositionID: int4Ptr(assignment.PositionID),
+		ManagerID:  int4Ptr(assignment.ManagerID),
+	})
+}
+
+// updateReportingLine handles PUT /api/users/{id}/reporting, setting the position a user holds
+// and who they report to. A manager the user is already above would make a reporting cycle and
+// is refused.
+func (s *Server) updateReportingLine(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, ok := s.authorizeOrgChartAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if _, err := s.store.GetUser(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	var req ReportingLineRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	errs := validate.Errors{}
+	if req.PositionID != nil {
+		if _, err := s.store.GetPosition(ctx, *req.PositionID); err != nil {
+			errs.Add("positionId", "position not found")
+		}
+	}
+	if req.ManagerID != nil {
+		if _, err := s.store.GetUser(ctx, *req.ManagerID); err != nil {
+			errs.Add("managerId", "user not found")
+		} else if *req.ManagerID == int32(id) {
+			errs.Add("managerId", "must not be the user themselves")
+		} else {
+			managers, err := approval.ManagerChain(ctx, s.store, *req.ManagerID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error fetching reporting lines: "+err.Error())
+				return
+			}
+			for _, managerID := range managers {
+				if managerID == int32(id) {
+					errs.Add("managerId", "reports to the user already, directly or not")
+					break
+				}
+			}
+		}
+	}
+	if err := errs.Err(); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	assignment, err := s.store.UpsertOrgAssignment(ctx, sqlc.UpsertOrgAssignmentParams{
+		UserID:     int32(id),
+		PositionID: optionalID(req.PositionID),
+		ManagerID:  optionalID(req.ManagerID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating reporting line: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, ReportingLineResponse{
+		UserID:     assignment.UserID,
+		PositionID: int4Ptr(assignment.PositionID),
+		ManagerID:  int4Ptr(assignment.ManagerID),
+	})
+}
+
+// getOrgChart handles GET /api/org-chart, the departments nested under their parents with their
+// head and members, each with their position and who they report to
+func (s *Server) getOrgChart(w http.ResponseWriter, r *http.Request) {
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	departments, err := s.store.ListDepartments(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching departments: "+err.Error())
+		return
+	}
+	members, err := s.store.ListOrgChartMembers(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching org chart members: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, buildOrgChart(departments, members))
+}
+
+// getLeaveCalendar handles GET /api/leave-calendar?from=&to=&department=, who is on leave when,
+// for everyone or a department and those under it. The range defaults to the current month in
+// the user's time zone.
+func (s *Server) getLeaveCalendar(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	today, err := s.userToday(r.Context(), currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	from := today.AddDate(0, 0, 1-today.Day())
+	to := from.AddDate(0, 1, -1)
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+			return
+		}
+	}
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	var department pgtype.Text
+	if value := r.URL.Query().Get("department"); value != "" {
+		department = pgtype.Text{String: value, Valid: true}
+	}
+
+	rows, err := s.store.ListLeaveCalendar(r.Context(), sqlc.ListLeaveCalendarParams{
+		DateFrom:   pgtype.Date{Time: from, Valid: true},
+		DateTo:     pgtype.Date{Time: to, Valid: true},
+		Department: department,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching leave calendar: "+err.Error())
+		return
+	}
+	response := make([]LeaveCalendarEntry, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, LeaveCalendarEntry{
+			LeaveLogID: row.ID,
+			UserID:     row.UserID,
+			Username:   row.Username,
+			Department: row.Department.String,
+			Type:       row.Type,
+			Date:       row.Date.Time.Format("2006-01-02"),
+			Note:       row.Note.String,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
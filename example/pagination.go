@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Page is the response of a listing paged by limit and offset. Total counts every item the
+// listing's filters match, not only the ones on this page.
+type Page[T any] struct {
+	Data   []T   `json:"data"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// newPage wraps one page of items, so an empty page is sent as [] rather than null
+func newPage[T any](data []T, total int64, limit, offset int) Page[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return Page[T]{Data: data, Total: total, Limit: limit, Offset: offset}
+}
+
+// pageOf pages a listing that is fetched whole, counting every item
+func pageOf[T any](items []T, limit, offset int) Page[T] {
+	data := items[min(offset, len(items)):]
+	data = data[:min(limit, len(data))]
+	return newPage(data, int64(len(items)), limit, offset)
+}
+
+// CursorPage is the response of a listing paged by cursor. NextCursor is passed back as
+// ?cursor= to get the following page, and is null on the last page.
+type CursorPage[T any] struct {
+	Data       []T     `json:"data"`
+	Total      int64   `json:"total"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// newCursorPage wraps one page of items like newPage
+func newCursorPage[T any](data []T, total int64, limit int, next *string) CursorPage[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return CursorPage[T]{Data: data, Total: total, Limit: limit, NextCursor: next}
+}
+
+// pageCursor is the (created_at, id) keyset of the last row of a page
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        int32
+}
+
+// encode returns the opaque cursor string handed to clients
+func (c pageCursor) encode() string {
+	raw := fmt.Sprintf("%d|%d", c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageCursor parses a cursor string, an empty one starts at the first page
+func decodePageCursor(value string) (*pageCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &pageCursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: int32(id)}, nil
+}
+
+// cursorRequested reports whether a listing should be paged by cursor rather than by offset.
+// Passing ?cursor= with an empty value asks for the first page.
+func cursorRequested(r *http.Request) bool {
+	return r.URL.Query().Has("cursor")
+}
+
+// keysetArgs returns the query arguments for the rows after the cursor, NULL for the first page
+func (c *pageCursor) keysetArgs() (pgtype.Timestamptz, int32) {
+	if c == nil {
+		return pgtype.Timestamptz{}, 0
+	}
+	return pgtype.Timestamptz{Time: c.CreatedAt, Valid: true}, c.ID
+}
+
+// nextCursor returns the cursor of the page after the given last row. Callers fetch limit+1
+// rows and only hand out a cursor when the extra row came back.
+func nextCursor(lastCreatedAt pgtype.Timestamptz, lastID int32) *string {
+	cursor := pageCursor{CreatedAt: lastCreatedAt.Time, ID: lastID}.encode()
+	return &cursor
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// An admin resets the password of a user who forgot theirs, like tablegctl user reset-password
+// does. The server generates the new password and shows it once, in the response, for the admin
+// to pass on; the user is notified with password_reset that their password changed.
+
+// PasswordResetResponse is the response of POST /api/users/{id}/reset-password
+type PasswordResetResponse struct {
+	Password string `json:"password"` // Shown only here
+}
+
+// newPassword returns a random password and its bcrypt hash
+func newPassword() (password, hash string, err error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	password = base64.RawURLEncoding.EncodeToString(buf)
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return password, string(hashed), nil
+}
+
+// resetUserPassword handles POST /api/users/{id}/reset-password
+func (s *Server) resetUserPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can reset passwords")
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching user: "+err.Error())
+		return
+	}
+
+	password, hash, err := newPassword()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error generating password: "+err.Error())
+		return
+	}
+	if _, err := s.store.UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:       user.ID,
+		Username: user.Username,
+		Password: hash,
+		UserType: user.UserType,
+		Email:    user.Email,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating user: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Password reset", "user_id", user.ID, "reset_by", currentUser.ID)
+
+	s.notify(ctx, user.ID, notificationPasswordReset, passwordResetEvent{ResetBy: currentUser.Username})
+	respondWithJSON(w, http.StatusOK, PasswordResetResponse{Password: password})
+}
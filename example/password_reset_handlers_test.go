@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestResetUserPassword(t *testing.T) {
+	s, store := newNotifyingTestServer(t)
+	handler := s.Handler()
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+	reset := fmt.Sprintf("/api/users/%d/reset-password", alice.ID)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, reset, "", nil), http.StatusUnauthorized, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, reset, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/users/9999/reset-password", tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+
+	var first, second PasswordResetResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, reset, tokenFor(admin.Username), nil), http.StatusOK, &first)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, reset, tokenFor(admin.Username), nil), http.StatusOK, &second)
+	if first.Password == "" || first.Password == second.Password {
+		t.Fatalf("reset passwords = %q then %q, want two different ones", first.Password, second.Password)
+	}
+
+	// Only the latest password logs in
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/login", "", LoginRequest{Username: alice.Username, Password: first.Password}), http.StatusUnauthorized, nil)
+	var login LoginResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/login", "", LoginRequest{Username: alice.Username, Password: second.Password}), http.StatusOK, &login)
+	if login.User.ID != alice.ID {
+		t.Errorf("logged in as %d, want %d", login.User.ID, alice.ID)
+	}
+
+	notifications := notificationsOf(t, store, alice, notificationPasswordReset)
+	if len(notifications) != 2 || notifications[0].Subject != "Your TableG password was reset" {
+		t.Fatalf("password_reset notifications = %+v, want two", notifications)
+	}
+	for _, notification := range notifications {
+		if notification.Body == "" || strings.Contains(notification.Body, first.Password) || strings.Contains(notification.Body, second.Password) {
+			t.Errorf("notification body = %q, want it without the password", notification.Body)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/payroll"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// payrollPeriodLocked is the status of a period once its export is stored
+const payrollPeriodLocked = "locked"
+
+// PayrollPeriodRequest is the body of POST /api/admin/payroll/periods
+type PayrollPeriodRequest struct {
+	Period    string `json:"period" validate:"required"` // YYYY-MM, the month paid
+	StartDate string `json:"startDate" validate:"date"`  // Defaults to the first day of the month
+	EndDate   string `json:"endDate" validate:"date"`    // Defaults to the last day of the month
+}
+
+// PayrollPeriodResponse is a pay period, without its export
+type PayrollPeriodResponse struct {
+	ID              int32      `json:"id"`
+	Period          string     `json:"period"`
+	StartDate       string     `json:"startDate"`
+	EndDate         string     `json:"endDate"`
+	Status          string     `json:"status"`             // open or locked
+	FileName        *string    `json:"fileName,omitempty"` // Of the export, while locked
+	LockedByUserID  *int32     `json:"lockedByUserId,omitempty"`
+	LockedAt        *time.Time `json:"lockedAt,omitempty"`
+	CreatedByUserID int32      `json:"createdByUserId"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// PayrollLineResponse is what payroll gets for one user in a period
+type PayrollLineResponse struct {
+	UserID          int32   `json:"userId"`
+	Username        string  `json:"username"`
+	Department      string  `json:"department,omitempty"`
+	PaidLeaveDays   float64 `json:"paidLeaveDays"`
+	UnpaidLeaveDays float64 `json:"unpaidLeaveDays"` // Of the PAYROLL_UNPAID_LEAVE_TYPES
+	OvertimeHours   float64 `json:"overtimeHours"`   // Checked in beyond a full day of their capacity, on days that aren't holidays
+	HolidayWorkDays float64 `json:"holidayWorkDays"` // Logged as work on a holiday
+}
+
+// PayrollPreviewResponse is what the export of a period would hold if it were locked now
+type PayrollPreviewResponse struct {
+	Period PayrollPeriodResponse `json:"period"`
+	Lines  []PayrollLineResponse `json:"lines"`
+}
+
+func payrollPeriodToResponse(period sqlc.PayrollPeriod) PayrollPeriodResponse {
+	return PayrollPeriodResponse{
+		ID:              period.ID,
+		Period:          period.Period,
+		StartDate:       period.StartDate.Time.Format("2006-01-02"),
+		EndDate:         period.EndDate.Time.Format("2006-01-02"),
+		Status:          period.Status,
+		FileName:        textPtr(period.FileName),
+		LockedByUserID:  int4Ptr(period.LockedByUserID),
+		LockedAt:        timestamptzPtr(period.LockedAt),
+		CreatedByUserID: period.CreatedByUserID,
+		CreatedAt:       period.CreatedAt.Time,
+	}
+}
+
+// authorizePayrollAdmin answers 403 to users other than admins and returns the admin otherwise
+func (s *Server) authorizePayrollAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can run payroll")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// payrollPeriodByName returns the period named by value, answering 400 or 404 otherwise
+func (s *Server) payrollPeriodByName(w http.ResponseWriter, r *http.Request, value string) (sqlc.PayrollPeriod, bool) {
+	if value == "" {
+		respondWithError(w, http.StatusBadRequest, "period is required, as YYYY-MM")
+		return sqlc.PayrollPeriod{}, false
+	}
+	period, err := s.store.GetPayrollPeriod(r.Context(), value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Payroll period not found")
+		return period, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching payroll period: "+err.Error())
+		return period, false
+	}
+	return period, true
+}
+
+// payrollSettings returns the settings in effect the export is worked out with
+func (s *Server) payrollSettings() payroll.Settings {
+	settings := s.settings.Config()
+	return payroll.Settings{
+		CompanyCode:      settings.Payroll.CompanyCode,
+		UnpaidLeaveTypes: settings.Payroll.UnpaidLeaveTypes,
+		HoursPerDay:      settings.Tasks.EstimateHoursPerDay,
+	}
+}
+
+func payrollPeriodOf(period sqlc.PayrollPeriod) payroll.Period {
+	return payroll.Period{Name: period.Period, Start: period.StartDate.Time, End: period.EndDate.Time}
+}
+
+// getPayrollPeriods handles GET /api/admin/payroll/periods, the latest first
+func (s *Server) getPayrollPeriods(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizePayrollAdmin(w, r); !ok {
+		return
+	}
+	periods, err := s.store.ListPayrollPeriods(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching payroll periods: "+err.Error())
+		return
+	}
+	response := make([]PayrollPeriodResponse, 0, len(periods))
+	for _, period := range periods {
+		response = append(response, payrollPeriodToResponse(period))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// createPayrollPeriod handles POST /api/admin/payroll/periods. A period covers its month unless
+// the pay cycle cuts off on other days, e.g. from the 26th to the 25th.
+func (s *Server) createPayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizePayrollAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req PayrollPeriodRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	errs := validate.Errors{}
+	month, err := payroll.ParseMonth(req.Period)
+	if err != nil {
+		errs.Add("period", "must be a month as YYYY-MM")
+	}
+	start, end := requestDate(req.StartDate), requestDate(req.EndDate)
+	if !start.Valid {
+		start = pgtype.Date{Time: month, Valid: true}
+	}
+	if !end.Valid {
+		end = pgtype.Date{Time: month.AddDate(0, 1, -1), Valid: true}
+	}
+	if end.Time.Before(start.Time) {
+		errs.Add("endDate", "must not be before startDate")
+	}
+	if err := errs.Err(); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	if _, err := s.store.GetPayrollPeriod(r.Context(), req.Period); err == nil {
+		respondWithError(w, http.StatusConflict, "Payroll period "+req.Period+" already exists")
+		return
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching payroll period: "+err.Error())
+		return
+	}
+
+	period, err := s.store.CreatePayrollPeriod(r.Context(), sqlc.CreatePayrollPeriodParams{
+		Period:          req.Period,
+		StartDate:       start,
+		EndDate:         end,
+		CreatedByUserID: currentUser.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating payroll period: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, payrollPeriodToResponse(period))
+}
+
+// previewPayroll handles GET /api/admin/payroll/preview?period=, what the export of the period
+// holds as of now, to check before locking it
+func (s *Server) previewPayroll(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizePayrollAdmin(w, r); !ok {
+		return
+	}
+	period, ok := s.payrollPeriodByName(w, r, r.URL.Query().Get("period"))
+	if !ok {
+		return
+	}
+
+	lines, err := payroll.Build(r.Context(), s.store, payrollPeriodOf(period), s.payrollSettings())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error working out payroll: "+err.Error())
+		return
+	}
+	response := PayrollPreviewResponse{Period: payrollPeriodToResponse(period), Lines: make([]PayrollLineResponse, 0, len(lines))}
+	for _, line := range lines {
+		response.Lines = append(response.Lines, PayrollLineResponse(line))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// lockPayrollPeriod handles POST /api/admin/payroll/periods/{period}/lock, storing the export of
+// the period as it is now so it is exported unchanged from then on
+func (s *Server) lockPayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizePayrollAdmin(w, r)
+	if !ok {
+		return
+	}
+	period, ok := s.payrollPeriodByName(w, r, mux.Vars(r)["period"])
+	if !ok {
+		return
+	}
+	if period.Status == payrollPeriodLocked {
+		respondWithError(w, http.StatusConflict, "Payroll period is already locked")
+		return
+	}
+
+	settings := s.payrollSettings()
+	lines, err := payroll.Build(r.Context(), s.store, payrollPeriodOf(period), settings)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error working out payroll: "+err.Error())
+		return
+	}
+	var file bytes.Buffer
+	if err := payroll.Write(&file, payrollPeriodOf(period), settings, lines, time.Now().In(s.timeZone)); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, "Payroll can't be exported: "+err.Error())
+		return
+	}
+
+	locked, err := s.store.LockPayrollPeriod(r.Context(), sqlc.LockPayrollPeriodParams{
+		ID:             period.ID,
+		FileName:       pgtype.Text{String: payroll.FileName(payrollPeriodOf(period), settings), Valid: true},
+		Content:        file.Bytes(),
+		LockedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Payroll period is already locked")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error locking payroll period: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, payrollPeriodToResponse(locked))
+}
+
+// unlockPayrollPeriod handles POST /api/admin/payroll/periods/{period}/unlock, throwing the
+// export away so the period can be corrected and locked again
+func (s *Server) unlockPayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizePayrollAdmin(w, r); !ok {
+		return
+	}
+	period, ok := s.payrollPeriodByName(w, r, mux.Vars(r)["period"])
+	if !ok {
+		return
+	}
+
+	unlocked, err := s.store.UnlockPayrollPeriod(r.Context(), period.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Payroll period isn't locked")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unlocking payroll period: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, payrollPeriodToResponse(unlocked))
+}
+
+// exportPayroll handles GET /api/admin/payroll/export?period=, downloading the file stored when
+// the period was locked
+func (s *Server) exportPayroll(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizePayrollAdmin(w, r); !ok {
+		return
+	}
+	period, ok := s.payrollPeriodByName(w, r, r.URL.Query().Get("period"))
+	if !ok {
+		return
+	}
+	if period.Status != payrollPeriodLocked {
+		respondWithError(w, http.StatusConflict, "Preview the payroll period and lock it before exporting it")
+		return
+	}
+	writeReportFile(w, "text/plain; charset=us-ascii", period.FileName.String, period.Content)
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/storage"
+)
+
+// Users download everything kept about them from /api/current-user/data-export, as JSON or, with
+// ?format=zip, a ZIP of the JSON and the files they uploaded or that belong to them. Once a user
+// is deleted, an admin anonymizes them at /api/admin/users/{id}/anonymize, or the
+// privacy-retention job does ANONYMIZE_DEPARTED_AFTER after the deletion: their name, email and
+// password are replaced, the notes of their logs, their check-in locations, notifications,
+// preferences, links and files go. Their leave, task, expense and attendance rows stay with their
+// dates and amounts, so balances and the reports of past years still add up. The job also clears
+// the IPs and coordinates of check-ins older than ATTENDANCE_LOCATION_RETENTION.
+
+// anonymizeBatch is how many departed users the privacy-retention job anonymizes per query
+const anonymizeBatch = 100
+
+// DataExport is everything the server keeps about a user
+type DataExport struct {
+	ExportedAt              time.Time                         `json:"exportedAt"`
+	User                    UserResponse                      `json:"user"`
+	AnnualRecords           []sqlc.ListAnnualRecordsByUserRow `json:"annualRecords"`
+	LeaveLogs               []sqlc.LeaveLog                   `json:"leaveLogs"`       // Deleted ones too
+	MedicalExpenses         []sqlc.MedicalExpense             `json:"medicalExpenses"` // Deleted ones too
+	TaskLogs                []sqlc.TaskLog                    `json:"taskLogs"`
+	TaskComments            []sqlc.TaskComment                `json:"taskComments"`
+	Attendance              []sqlc.AttendanceSession          `json:"attendance"`
+	ApprovalRequests        []sqlc.ApprovalRequest            `json:"approvalRequests"`
+	ApprovalDecisions       []sqlc.ApprovalDecision           `json:"approvalDecisions"`
+	Delegations             []sqlc.ApprovalDelegation         `json:"delegations"`
+	Notifications           []sqlc.Notification               `json:"notifications"`
+	NotificationPreferences []sqlc.NotificationPreference     `json:"notificationPreferences"`
+	Acknowledgments         []sqlc.AnnouncementAcknowledgment `json:"acknowledgments"`
+	PushDevices             []sqlc.PushDevice                 `json:"pushDevices"`
+	Language                *string                           `json:"language"`      // Null until picked
+	TimeZone                *string                           `json:"timeZone"`      // Null until picked
+	OrgAssignment           *sqlc.OrgAssignment               `json:"orgAssignment"` // Null outside the org chart
+	LineUserID              *string                           `json:"lineUserId"`    // Null until linked
+	ClickUpTeams            []string                          `json:"clickUpTeams"`  // The workspaces linked, not their tokens
+	Files                   []sqlc.File                       `json:"files"`         // In files/ of the ZIP
+}
+
+// getDataExport handles GET /api/current-user/data-export. The format parameter is json, the
+// default, or zip.
+func (s *Server) getDataExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		respondWithError(w, http.StatusBadRequest, "Invalid format. Use json or zip")
+		return
+	}
+
+	export, err := s.dataExport(ctx, currentUser)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error exporting personal data: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Personal data exported", "user_id", currentUser.ID, "format", format)
+	if format != "zip" {
+		respondWithJSON(w, http.StatusOK, export)
+		return
+	}
+
+	fileName := fmt.Sprintf("data-export-%s-%s.zip", currentUser.Username, export.ExportedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.WriteHeader(http.StatusOK)
+	// The status is sent, a failure from here on can only cut the ZIP short
+	if err := s.writeDataExportZip(ctx, w, export); err != nil {
+		slog.WarnContext(ctx, "Error sending data export", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+// dataExport collects everything kept about a user
+func (s *Server) dataExport(ctx context.Context, user sqlc.User) (DataExport, error) {
+	export := DataExport{ExportedAt: time.Now().In(s.timeZone), User: userToResponse(user)}
+	var err error
+	if export.AnnualRecords, err = s.store.ListAnnualRecordsByUser(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.LeaveLogs, err = s.store.ExportLeaveLogs(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.MedicalExpenses, err = s.store.ExportMedicalExpenses(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.TaskLogs, err = s.store.ExportTaskLogs(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.TaskComments, err = s.store.ExportTaskComments(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.Attendance, err = s.store.ExportAttendanceSessions(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.ApprovalRequests, err = s.store.ExportApprovalRequests(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.ApprovalDecisions, err = s.store.ExportApprovalDecisions(ctx, pgtype.Int4{Int32: user.ID, Valid: true}); err != nil {
+		return export, err
+	}
+	if export.Delegations, err = s.store.ListApprovalDelegationsByUser(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.Notifications, err = s.store.ExportNotifications(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.NotificationPreferences, err = s.store.ListNotificationPreferences(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.Acknowledgments, err = s.store.ListAnnouncementAcknowledgmentsByUser(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.PushDevices, err = s.store.ListPushDevicesByUser(ctx, user.ID); err != nil {
+		return export, err
+	}
+	if export.Files, err = s.store.ListFilesOfUser(ctx, user.ID); err != nil {
+		return export, err
+	}
+
+	language, err := optional(s.store.GetLanguagePreference(ctx, user.ID))
+	if err != nil {
+		return export, err
+	}
+	if language != nil {
+		export.Language = &language.Language
+	}
+	timeZone, err := optional(s.store.GetTimeZonePreference(ctx, user.ID))
+	if err != nil {
+		return export, err
+	}
+	if timeZone != nil {
+		export.TimeZone = &timeZone.TimeZone
+	}
+	if export.OrgAssignment, err = optional(s.store.GetOrgAssignment(ctx, user.ID)); err != nil {
+		return export, err
+	}
+	line, err := optional(s.store.GetLineLink(ctx, user.ID))
+	if err != nil {
+		return export, err
+	}
+	if line != nil && line.LineUserID.Valid {
+		export.LineUserID = &line.LineUserID.String
+	}
+	tokens, err := s.store.ListClickUpTokensByUser(ctx, user.ID)
+	if err != nil {
+		return export, err
+	}
+	export.ClickUpTeams = make([]string, len(tokens))
+	for i, token := range tokens {
+		export.ClickUpTeams[i] = token.TeamID
+	}
+	return export, nil
+}
+
+// writeDataExportZip writes the export as data.json and the stored files under files/, named by
+// their ID and the name they were uploaded with. A file missing from the storage is left out.
+func (s *Server) writeDataExportZip(ctx context.Context, w io.Writer, export DataExport) error {
+	archive := zip.NewWriter(w)
+	data, err := archive.Create("data.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(data)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	for _, file := range export.Files {
+		content, err := s.files.Get(ctx, file.StorageKey)
+		if errors.Is(err, storage.ErrNotFound) {
+			slog.WarnContext(ctx, "File missing from the storage, left out of the data export", "file_id", file.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entry, err := archive.Create("files/" + strconv.Itoa(int(file.ID)) + "-" + path.Base(file.FileName))
+		if err == nil {
+			_, err = io.Copy(entry, content)
+		}
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// anonymizeUser handles POST /api/admin/users/{id}/anonymize. Only deleted users are anonymized,
+// a live one is 409. Anonymizing a user again retries removing files that failed to go.
+func (s *Server) anonymizeUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only admin users can anonymize users")
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	_, err = s.store.GetUser(ctx, int32(id))
+	if err == nil {
+		respondWithError(w, http.StatusConflict, "Delete the user before anonymizing them")
+		return
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching user: "+err.Error())
+		return
+	}
+
+	anonymization, err := s.anonymize(ctx, int32(id), pgtype.Int4{Int32: currentUser.ID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error anonymizing user: "+err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "User anonymized", "user_id", id, "by", currentUser.ID)
+	respondWithJSON(w, http.StatusOK, anonymization)
+}
+
+// anonymize scrubs the personal data of a deleted user and removes the files of their avatar,
+// expenses and leave, by is null for the job. Files they uploaded for others stay with their
+// subjects. It fails with pgx.ErrNoRows when there is no deleted user with the ID.
+func (s *Server) anonymize(ctx context.Context, userID int32, by pgtype.Int4) (sqlc.Anonymization, error) {
+	files, err := s.personalFiles(ctx, userID)
+	if err != nil {
+		return sqlc.Anonymization{}, err
+	}
+
+	var anonymization sqlc.Anonymization
+	err = s.store.WithTx(ctx, func(q sqlc.Querier) error {
+		var err error
+		anonymization, err = q.AnonymizeUser(ctx, sqlc.AnonymizeUserParams{UserID: userID, AnonymizedBy: by})
+		if err != nil {
+			return err
+		}
+		return q.ScrubUserPersonalData(ctx, userID)
+	})
+	if err != nil {
+		return anonymization, err
+	}
+
+	for _, file := range files {
+		if err := s.removeFile(ctx, file); err != nil {
+			return anonymization, err
+		}
+	}
+	return anonymization, nil
+}
+
+// personalFiles returns the files of a user's avatar and of their medical expenses and leave
+// logs, deleted ones too, whoever uploaded them
+func (s *Server) personalFiles(ctx context.Context, userID int32) ([]sqlc.File, error) {
+	files, err := s.store.ListFilesOfUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.store.ExportMedicalExpenses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	leaveLogs, err := s.store.ExportLeaveLogs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := map[string]map[int32]bool{
+		filePurposeAvatar:               {userID: true},
+		filePurposeMedicalReceipt:       {},
+		filePurposeSickLeaveCertificate: {},
+	}
+	for _, expense := range expenses {
+		subjects[filePurposeMedicalReceipt][expense.ID] = true
+	}
+	for _, leaveLog := range leaveLogs {
+		subjects[filePurposeSickLeaveCertificate][leaveLog.ID] = true
+	}
+	personal := []sqlc.File{}
+	for _, file := range files {
+		if subjects[file.Purpose][file.SubjectID] {
+			personal = append(personal, file)
+		}
+	}
+	return personal, nil
+}
+
+// schedulePrivacyRetention anonymizes the users deleted longer than ANONYMIZE_DEPARTED_AFTER ago
+// and clears check-in locations older than ATTENDANCE_LOCATION_RETENTION every day. Both are
+// runtime settings, each run reads them.
+func (s *Server) schedulePrivacyRetention() {
+	s.scheduler.Register(scheduler.Job{
+		Name:        "privacy-retention",
+		Description: "Anonymize users deleted longer than ANONYMIZE_DEPARTED_AFTER ago and clear check-in locations older than ATTENDANCE_LOCATION_RETENTION",
+		Schedule:    scheduler.MustParse("45 4 * * *"),
+		Run: func(ctx context.Context) error {
+			settings := s.settings.Config().Privacy
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				if settings.AnonymizeDepartedAfter > 0 {
+					before := pgtype.Timestamptz{Time: time.Now().Add(-settings.AnonymizeDepartedAfter), Valid: true}
+					if err := s.anonymizeDeparted(ctx, before); err != nil {
+						slog.ErrorContext(ctx, "Error anonymizing departed users", "error", err)
+						errs = append(errs, err)
+					}
+				}
+				if settings.AttendanceLocationRetention > 0 {
+					before := pgtype.Timestamptz{Time: time.Now().Add(-settings.AttendanceLocationRetention), Valid: true}
+					cleared, err := s.store.ClearAttendanceLocations(ctx, before)
+					if err != nil {
+						slog.ErrorContext(ctx, "Error clearing check-in locations", "error", err)
+						errs = append(errs, err)
+					} else if cleared > 0 {
+						slog.InfoContext(ctx, "Check-in locations cleared", "sessions", cleared)
+					}
+				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
+
+// anonymizeDeparted anonymizes the users deleted before the cutoff, stopping at the first failure
+// so a user that can't be anonymized isn't tried over and over
+func (s *Server) anonymizeDeparted(ctx context.Context, before pgtype.Timestamptz) error {
+	anonymized := 0
+	for {
+		ids, err := s.store.ListUsersToAnonymize(ctx, sqlc.ListUsersToAnonymizeParams{DeletedBefore: before, RowLimit: anonymizeBatch})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := s.anonymize(ctx, id, pgtype.Int4{}); err != nil {
+				return fmt.Errorf("anonymizing user %d: %w", id, err)
+			}
+			anonymized++
+		}
+		if len(ids) < anonymizeBatch {
+			break
+		}
+	}
+	if anonymized > 0 {
+		slog.InfoContext(ctx, "Departed users anonymized", "users", anonymized)
+	}
+	return nil
+}
+
+// optional turns the pgx.ErrNoRows of a :one query into nil
+func optional[T any](row T, err error) (*T, error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+// foreignKeyViolation is the Postgres error code for a row still referenced by another table
+const foreignKeyViolation = "23503"
+
+// uniqueViolation is the Postgres error code for a row with the same key as another
+const uniqueViolation = "23505"
+
+// PurgeResponse reports how many soft deleted rows a purge removed for good. Rows other tables
+// still point at, like a user with task logs, are skipped and stay soft deleted.
+type PurgeResponse struct {
+	Kind    string `json:"kind"`
+	Purged  int64  `json:"purged"`
+	Skipped int64  `json:"skipped"`
+}
+
+// softDeletedKind lists, purges and restores the soft deleted rows of one table
+type softDeletedKind struct {
+	listPurgeable func(ctx context.Context, store db.Store, before pgtype.Timestamptz) ([]int32, error)
+	purge         func(ctx context.Context, store db.Store, id int32) (int64, error)
+	restore       func(ctx context.Context, store db.Store, id int32) (int64, error)
+	// countedIn returns the annual record a live row counts in, for the kinds that count in one
+	countedIn func(ctx context.Context, store db.Store, id int32) (AnnualRecordChange, error)
+}
+
+// softDeletedKinds are the tables that soft delete, by the name used in the purge and restore URLs
+var softDeletedKinds = map[string]softDeletedKind{
+	"users": {
+		listPurgeable: func(ctx context.Context, store db.Store, before pgtype.Timestamptz) ([]int32, error) {
+			return store.ListPurgeableUserIDs(ctx, before)
+		},
+		purge: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.PurgeUser(ctx, id)
+		},
+		restore: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.RestoreUser(ctx, id)
+		},
+	},
+	"tasks": {
+		listPurgeable: func(ctx context.Context, store db.Store, before pgtype.Timestamptz) ([]int32, error) {
+			return store.ListPurgeableTaskIDs(ctx, before)
+		},
+		purge: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.PurgeTask(ctx, id)
+		},
+		restore: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.RestoreTask(ctx, id)
+		},
+	},
+	"leave-logs": {
+		listPurgeable: func(ctx context.Context, store db.Store, before pgtype.Timestamptz) ([]int32, error) {
+			return store.ListPurgeableLeaveLogIDs(ctx, before)
+		},
+		purge: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.PurgeLeaveLog(ctx, id)
+		},
+		restore: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.RestoreLeaveLog(ctx, id)
+		},
+		countedIn: func(ctx context.Context, store db.Store, id int32) (AnnualRecordChange, error) {
+			leaveLog, err := store.GetLeaveLog(ctx, id)
+			return annualRecordChangeFor(leaveLog.UserID, leaveLog.Date.Time), err
+		},
+	},
+	"medical-expenses": {
+		listPurgeable: func(ctx context.Context, store db.Store, before pgtype.Timestamptz) ([]int32, error) {
+			return store.ListPurgeableMedicalExpenseIDs(ctx, before)
+		},
+		purge: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.PurgeMedicalExpense(ctx, id)
+		},
+		restore: func(ctx context.Context, store db.Store, id int32) (int64, error) {
+			return store.RestoreMedicalExpense(ctx, id)
+		},
+		countedIn: func(ctx context.Context, store db.Store, id int32) (AnnualRecordChange, error) {
+			expense, err := store.GetMedicalExpense(ctx, id)
+			return annualRecordChangeFor(expense.UserID, expense.ReceiptDate.Time), err
+		},
+	},
+}
+
+// purgeDeleted removes soft deleted rows of one kind for good. Only admins can purge. The optional
+// before parameter (yyyy-MM-dd) keeps rows deleted on or after that day, by default every soft
+// deleted row goes.
+func (s *Server) purgeDeleted(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := mux.Vars(r)["kind"]
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only admin users can purge deleted records")
+		return
+	}
+
+	kind, ok := softDeletedKinds[name]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown kind, use users, tasks, leave-logs or medical-expenses")
+		return
+	}
+
+	before := time.Now()
+	if value := r.URL.Query().Get("before"); value != "" {
+		before, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid before date. Use YYYY-MM-DD")
+			return
+		}
+	}
+
+	ids, err := kind.listPurgeable(ctx, s.store, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing deleted records: "+err.Error())
+		return
+	}
+
+	response := PurgeResponse{Kind: name}
+	for _, id := range ids {
+		purged, err := kind.purge(ctx, s.store, id)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			response.Skipped++
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error purging deleted records: "+err.Error())
+			return
+		}
+		response.Purged += purged
+	}
+
+	slog.InfoContext(r.Context(), "Purged deleted records", "user_id", currentUser.ID, "table", name, "purged", response.Purged, "skipped", response.Skipped)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// restoreDeleted brings a soft deleted row back, clearing its deleted_at. Only admins can
+// restore. Leave and medical expenses count in their annual record again; approval requests
+// cancelled when the row was deleted stay cancelled.
+func (s *Server) restoreDeleted(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only admin users can restore deleted records")
+		return
+	}
+
+	kind, ok := softDeletedKinds[vars["kind"]]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown kind, use users, tasks, leave-logs or medical-expenses")
+		return
+	}
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	restored, err := kind.restore(ctx, s.store, int32(id))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		respondWithError(w, http.StatusConflict, "A live user has the same username or email now")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error restoring deleted record: "+err.Error())
+		return
+	}
+	if restored == 0 {
+		// Never deleted, purged, or an anonymized user
+		respondWithError(w, http.StatusNotFound, "Deleted record not found")
+		return
+	}
+
+	if kind.countedIn != nil {
+		if change, err := kind.countedIn(ctx, s.store, int32(id)); err != nil {
+			slog.WarnContext(ctx, "Error fetching the restored record to sync its annual record", "table", vars["kind"], "id", id, "error", err)
+		} else {
+			s.events.Publish(ctx, change)
+		}
+	}
+
+	slog.InfoContext(ctx, "Restored deleted record", "user_id", currentUser.ID, "table", vars["kind"], "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+func TestRestoreDeleted(t *testing.T) {
+	ctx := context.Background()
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	day := pgtype.Date{Time: time.Date(2025, time.April, 14, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	tests := []struct {
+		kind   string
+		path   string                   // Where the row is read, with %d for its ID
+		create func(t *testing.T) int32 // Creates a live row, returning its ID
+		remove func(ctx context.Context, id int32) error
+	}{
+		{
+			kind: "users",
+			path: "/api/users/%d",
+			create: func(t *testing.T) int32 {
+				return dbtest.CreateUser(t, store, "bob", "user").ID
+			},
+			remove: store.DeleteUser,
+		},
+		{
+			kind: "tasks",
+			path: "/api/tasks/%d",
+			create: func(t *testing.T) int32 {
+				task, err := store.CreateTask(ctx, sqlc.CreateTaskParams{Title: pgtype.Text{String: "Payroll export", Valid: true}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return task.ID
+			},
+			remove: store.DeleteTask,
+		},
+		{
+			kind: "leave-logs",
+			path: "/api/leave-logs/%d",
+			create: func(t *testing.T) int32 {
+				leaveLog, err := store.CreateLeaveLog(ctx, sqlc.CreateLeaveLogParams{UserID: alice.ID, Type: "vacation", Date: day})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return leaveLog.ID
+			},
+			remove: store.DeleteLeaveLog,
+		},
+		{
+			kind: "medical-expenses",
+			path: "/api/medical-expenses/%d",
+			create: func(t *testing.T) int32 {
+				amount, err := pgconv.FromFloat(1200)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expense, err := store.CreateMedicalExpense(ctx, sqlc.CreateMedicalExpenseParams{UserID: alice.ID, Amount: amount, ReceiptDate: day})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return expense.ID
+			},
+			remove: store.DeleteMedicalExpense,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			id := tt.create(t)
+			restore := fmt.Sprintf("/api/admin/deleted/%s/%d/restore", tt.kind, id)
+
+			// Live rows have nothing to restore
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+
+			if err := tt.remove(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf(tt.path, id), tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, "", nil), http.StatusUnauthorized, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, tokenFor(alice.Username), nil), http.StatusForbidden, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, tokenFor(admin.Username), nil), http.StatusNoContent, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf(tt.path, id), tokenFor(admin.Username), nil), http.StatusOK, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+
+			// Purged rows are gone for good
+			if err := tt.remove(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, "/api/admin/deleted/"+tt.kind, tokenFor(admin.Username), nil), http.StatusOK, nil)
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, restore, tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+		})
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/deleted/notes/1/restore", tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/admin/deleted/users/abc/restore", tokenFor(admin.Username), nil), http.StatusBadRequest, nil)
+}
+
+func TestRestoreAnonymizedUser(t *testing.T) {
+	ctx := context.Background()
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+
+	if err := store.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AnonymizeUser(ctx, sqlc.AnonymizeUserParams{UserID: bob.ID}); err != nil {
+		t.Fatal(err)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, fmt.Sprintf("/api/admin/deleted/users/%d/restore", bob.ID), tokenFor(admin.Username), nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// PushDeviceRequest is the body of POST /api/current-user/devices, the FCM registration token of
+// the device the app runs on
+type PushDeviceRequest struct {
+	Token    string `json:"token" validate:"required,max=4096"`
+	Platform string `json:"platform" validate:"required,oneof=android ios web"`
+}
+
+// PushDeviceResponse is a device notifications are pushed to
+type PushDeviceResponse struct {
+	ID         int32     `json:"id"`
+	Token      string    `json:"token"`
+	Platform   string    `json:"platform"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"` // When the app last registered it
+}
+
+func pushDeviceToResponse(device sqlc.PushDevice) PushDeviceResponse {
+	return PushDeviceResponse{
+		ID:         device.ID,
+		Token:      device.Token,
+		Platform:   device.Platform,
+		CreatedAt:  device.CreatedAt.Time,
+		LastSeenAt: device.LastSeenAt.Time,
+	}
+}
+
+// authorizePushUser answers 401 without a signed in user and 503 while push notifications are
+// off, and returns the user otherwise
+func (s *Server) authorizePushUser(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if !s.config.FCM.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "Push notifications are disabled, set FCM_CREDENTIALS_FILE")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getPushDevices handles GET /api/current-user/devices, the last registered first
+func (s *Server) getPushDevices(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizePushUser(w, r)
+	if !ok {
+		return
+	}
+	devices, err := s.store.ListPushDevicesByUser(r.Context(), currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching devices: "+err.Error())
+		return
+	}
+	response := make([]PushDeviceResponse, len(devices))
+	for i, device := range devices {
+		response[i] = pushDeviceToResponse(device)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// registerPushDevice handles POST /api/current-user/devices. The app calls it after signing in
+// and whenever FCM gives it a new token; a token registered before moves to the current user.
+func (s *Server) registerPushDevice(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizePushUser(w, r)
+	if !ok {
+		return
+	}
+	var req PushDeviceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	device, err := s.store.RegisterPushDevice(r.Context(), sqlc.RegisterPushDeviceParams{
+		UserID:   currentUser.ID,
+		Token:    req.Token,
+		Platform: req.Platform,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error registering device: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, pushDeviceToResponse(device))
+}
+
+// deletePushDevice handles DELETE /api/current-user/devices/{id}, such as when the user signs out
+// of the app
+func (s *Server) deletePushDevice(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizePushUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid device ID")
+		return
+	}
+	deleted, err := s.store.DeletePushDevice(r.Context(), sqlc.DeletePushDeviceParams{ID: int32(id), UserID: currentUser.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting device: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Device not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/fcm"
+	"github.com/kengtableg/pkeng-tableg/queue"
+)
+
+// Notifications are pushed to the mobile app through Firebase Cloud Messaging, to every device
+// the user registered. The app registers the FCM token of the device after signing in and again
+// whenever FCM gives it a new one; tokens FCM says are gone are forgotten as pushes find them.
+
+// pushNotifier pushes notifications to the devices users registered. Its recipient is the user
+// ID, so a notification goes to the devices registered by the time it is sent.
+type pushNotifier struct {
+	store  db.Store
+	client *fcm.Client
+}
+
+func (n pushNotifier) Recipient(ctx context.Context, user sqlc.User) (string, error) {
+	devices, err := n.store.ListPushDevicesByUser(ctx, user.ID)
+	if err != nil || len(devices) == 0 {
+		return "", err
+	}
+	return strconv.Itoa(int(user.ID)), nil
+}
+
+// Send pushes the notification to each of the user's devices. It fails when no device took it,
+// permanently when none is left or every one failed for good.
+func (n pushNotifier) Send(ctx context.Context, recipient, subject, body string) error {
+	userID, err := strconv.Atoi(recipient)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("invalid push recipient %q", recipient))
+	}
+	devices, err := n.store.ListPushDevicesByUser(ctx, int32(userID))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	delivered, permanent := 0, true
+	for _, device := range devices {
+		err := n.client.Send(ctx, device.Token, subject, body)
+		if err == nil {
+			delivered++
+			continue
+		}
+		var apiErr *fcm.APIError
+		isAPIErr := errors.As(err, &apiErr)
+		if isAPIErr && apiErr.IsUnregistered() {
+			if _, err := n.store.DeletePushDeviceByToken(ctx, device.Token); err != nil {
+				slog.WarnContext(ctx, "Error forgetting an unregistered push device", "device_id", device.ID, "error", err)
+			}
+			continue
+		}
+		permanent = permanent && isAPIErr && apiErr.IsPermanent()
+		errs = append(errs, fmt.Errorf("device %d: %w", device.ID, err))
+	}
+
+	err = errors.Join(errs...)
+	switch {
+	case delivered > 0:
+		if err != nil {
+			slog.WarnContext(ctx, "Error pushing a notification to some devices", "user_id", userID, "error", err)
+		}
+		return nil
+	case len(errs) == 0:
+		return queue.Permanent(errors.New("the user has no registered devices left"))
+	case permanent:
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// newFCMClient returns a client sending as the service account of FCM_CREDENTIALS_FILE
+func newFCMClient(cfg config.FCM) (*fcm.Client, error) {
+	data, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := fcm.ParseCredentials(data)
+	if err != nil {
+		return nil, err
+	}
+	return fcm.NewClient(cfg.APIURL, cfg.ProjectID, credentials)
+}
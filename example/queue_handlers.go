@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/queue"
+)
+
+// listQueuedJobs handles GET /api/admin/queue, listing the queued jobs in a state, the dead ones
+// unless ?status= names another, newest first
+func (s *Server) listQueuedJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeQueueAdmin(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = queue.StatusDead
+	case queue.StatusPending, queue.StatusRunning, queue.StatusDone, queue.StatusDead:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid status, must be pending, running, done or dead")
+		return
+	}
+
+	limit := 20
+	offset := 0
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+
+	jobs, total, err := s.queue.List(r.Context(), status, int32(limit), int32(offset))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing queued jobs: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newPage(jobs, total, limit, offset))
+}
+
+// getQueuedJob handles GET /api/admin/queue/{id}
+func (s *Server) getQueuedJob(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeQueueAdmin(w, r) {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := s.queue.Get(r.Context(), int32(id))
+	if errors.Is(err, queue.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching queued job: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// retryQueuedJob handles POST /api/admin/queue/{id}/retry, running a dead job again with a fresh
+// set of attempts
+func (s *Server) retryQueuedJob(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeQueueAdmin(w, r) {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := s.queue.Retry(r.Context(), int32(id))
+	switch {
+	case errors.Is(err, queue.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, "Job not found")
+	case errors.Is(err, queue.ErrNotDead):
+		respondWithError(w, http.StatusConflict, "Only dead jobs can be retried, this one is still being tried")
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Error retrying queued job: "+err.Error())
+	default:
+		respondWithJSON(w, http.StatusOK, job)
+	}
+}
+
+// authorizeQueueAdmin lets admins through. Jobs belong to the tenant whose write queued them, so
+// with MULTI_TENANT=true each tenant's admins see their own.
+func (s *Server) authorizeQueueAdmin(w http.ResponseWriter, r *http.Request) bool {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only admin users can manage queued jobs")
+		return false
+	}
+	return true
+}
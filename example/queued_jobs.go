@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// The kinds of queued jobs
+const (
+	// jobClickUpTimeEntry tracks a new task log as time on its ClickUp task
+	jobClickUpTimeEntry = "clickup.time_entry"
+	// jobAnnualRecordSync syncs an annual record whose sync failed when it was written
+	jobAnnualRecordSync = "annual_record.sync"
+	// jobNotificationEmail emails a notification from the outbox
+	jobNotificationEmail = "notification.email"
+	// jobNotificationLINE pushes a notification from the outbox to LINE
+	jobNotificationLINE = "notification.line"
+	// jobNotificationPush pushes a notification from the outbox to the user's mobile devices
+	jobNotificationPush = "notification.push"
+	// jobSlackLeaveRequest posts leave to the approvals channel of a Slack workspace
+	jobSlackLeaveRequest = "slack.leave_request"
+	// jobSlackDirectMessage sends a Slack DM, such as about leave approved or rejected
+	jobSlackDirectMessage = "slack.direct_message"
+	// jobWebhookDelivery posts a change to a webhook
+	jobWebhookDelivery = "webhook.delivery"
+	// jobReportExport renders a report export too long to render while the request waits
+	jobReportExport = "report.export"
+)
+
+// clickUpTimeEntryJob is the payload of a clickup.time_entry job
+type clickUpTimeEntryJob struct {
+	UserID     int32     `json:"userId"`
+	TaskID     int32     `json:"taskId"`
+	WorkedDay  float64   `json:"workedDay"`
+	WorkedDate time.Time `json:"workedDate"`
+}
+
+// registerQueuedJobs sets the handlers of the queued job kinds
+func (s *Server) registerQueuedJobs() {
+	s.queue.Register(jobClickUpTimeEntry, func(ctx context.Context, payload json.RawMessage) error {
+		var job clickUpTimeEntryJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return queue.Permanent(err)
+		}
+		task, err := s.store.GetTask(ctx, job.TaskID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Deleted since, there is nothing to track the time on
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.pushTaskLogToClickUp(ctx, job.UserID, task, job.WorkedDay, job.WorkedDate)
+	})
+
+	s.queue.Register(jobAnnualRecordSync, func(ctx context.Context, payload json.RawMessage) error {
+		var change AnnualRecordChange
+		if err := json.Unmarshal(payload, &change); err != nil {
+			return queue.Permanent(err)
+		}
+		_, err := s.annualRecords.SyncUserRecordForYear(ctx, change.UserID, change.Year)
+		return err
+	})
+
+	s.queue.Register(jobNotificationEmail, s.sendNotification)
+	s.queue.Register(jobNotificationLINE, s.sendNotification)
+	s.queue.Register(jobNotificationPush, s.sendNotification)
+	s.queue.Register(jobSlackLeaveRequest, s.postSlackLeaveRequest)
+	s.queue.Register(jobSlackDirectMessage, s.sendSlackDirectMessage)
+	s.queue.Register(jobWebhookDelivery, s.deliverWebhook)
+	s.queue.Register(jobReportExport, s.renderReportExport)
+}
+
+// enqueue queues a side effect of a write that succeeded. The write stands either way, so a
+// failure is only logged, and the job is queued even when the client went away meanwhile.
+func (s *Server) enqueue(ctx context.Context, kind string, payload any) {
+	if _, err := s.queue.Enqueue(context.WithoutCancel(ctx), kind, payload); err != nil {
+		slog.ErrorContext(ctx, "Error queueing job", "kind", kind, "error", err)
+	}
+}
+
+// syncAnnualRecord syncs the annual record affected by a published change right away, so the
+// response shows the new totals, and queues another try when that fails
+func (s *Server) syncAnnualRecord(ctx context.Context, change AnnualRecordChange) {
+	if _, err := s.annualRecords.SyncUserRecordForYear(ctx, change.UserID, change.Year); err != nil {
+		slog.WarnContext(ctx, "Failed to sync annual record, queueing a retry", "user_id", change.UserID, "year", change.Year, "error", err)
+		s.enqueue(ctx, jobAnnualRecordSync, change)
+		return
+	}
+	slog.DebugContext(ctx, "Synced annual record", "user_id", change.UserID, "year", change.Year)
+}
+
+// pushTaskLogToClickUp tracks a new log as time on the linked ClickUp task, acting as the user
+// who logged it when they linked their account. Errors retrying won't fix are permanent.
+func (s *Server) pushTaskLogToClickUp(ctx context.Context, userID int32, task sqlc.Task, workedDay float64, workedDate time.Time) error {
+	clickupTaskID := clickup.ExtractTaskIDFromURL(task.Url.String)
+	if clickupTaskID == "" {
+		return nil
+	}
+
+	client := s.clickUp.ForUser(ctx, s.store, userID, task.ClickupTeamID.String)
+	if !client.Enabled() {
+		return nil
+	}
+
+	// Time entries are created per workspace, look it up for tasks not synced since linking
+	teamID := task.ClickupTeamID.String
+	if teamID == "" {
+		remote, err := client.GetTask(ctx, clickupTaskID)
+		if err != nil {
+			return clickUpJobError(fmt.Errorf("fetching ClickUp task %s to track time on: %w", clickupTaskID, err))
+		}
+		teamID = remote.TeamID
+	}
+
+	// Logs only carry a date, so the entry starts at the beginning of the worked day
+	hours := workedDay / daysPerEstimateUnit(s.settings.Config().Tasks, estimateUnitHours)
+	entry := clickup.NewTimeEntryRequest(clickupTaskID, workedDate, time.Duration(hours*float64(time.Hour)), "Logged in ngTableG")
+	if _, err := client.CreateTimeEntry(ctx, teamID, entry); err != nil {
+		return clickUpJobError(fmt.Errorf("tracking time in ClickUp: %w", err))
+	}
+	return nil
+}
+
+// clickUpJobError makes the errors of ClickUp requests it rejected permanent, apart from rate
+// limits. Network errors and server errors are retried.
+func clickUpJobError(err error) error {
+	var apiErr *clickup.APIError
+	if errors.As(err, &apiErr) && !apiErr.IsTransient() {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// scheduleQueuedJobCleanup deletes the queued jobs that succeeded more than QUEUE_RETENTION ago
+// every hour. Dead jobs are kept until an admin retries them.
+func (s *Server) scheduleQueuedJobCleanup() {
+	retention := s.config.Queue.Retention
+	s.scheduler.Register(scheduler.Job{
+		Name:        "queued-job-cleanup",
+		Description: "Delete the queued jobs that succeeded more than QUEUE_RETENTION ago",
+		Schedule:    scheduler.Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			// The delete spans every tenant
+			deleted, err := s.queue.DeleteFinished(db.WithAllTenants(ctx), time.Now().Add(-retention))
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Finished queued jobs deleted", "jobs", deleted)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/reports"
+)
+
+// ReportExportResponse is a report export rendered in the background
+type ReportExportResponse struct {
+	ID          int32      `json:"id"`
+	Report      string     `json:"report"`
+	Format      string     `json:"format"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	UserID      *int32     `json:"userId,omitempty"`
+	Department  *string    `json:"department,omitempty"`
+	Language    string     `json:"language"` // Of its titles, en or th
+	Status      string     `json:"status"`   // pending, done or failed
+	FileName    *string    `json:"fileName,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"` // Once it is done
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+func reportExportToResponse(export sqlc.ReportExport) ReportExportResponse {
+	response := ReportExportResponse{
+		ID:         export.ID,
+		Report:     export.ReportName,
+		Format:     export.Format,
+		From:       export.FromDate.Time.Format("2006-01-02"),
+		To:         export.ToDate.Time.Format("2006-01-02"),
+		UserID:     int4Ptr(export.UserID),
+		Department: textPtr(export.Department),
+		Language:   export.Language,
+		Status:     export.Status,
+		FileName:   textPtr(export.FileName),
+		Error:      textPtr(export.Error),
+		CreatedAt:  export.CreatedAt.Time,
+	}
+	if export.Status == reportExportDone {
+		response.DownloadURL = "/api/reports/exports/" + strconv.Itoa(int(export.ID)) + "/download"
+	}
+	if export.CompletedAt.Valid {
+		response.CompletedAt = &export.CompletedAt.Time
+	}
+	return response
+}
+
+// exportReport handles GET /api/reports/{name}/export, answering with the file for periods of up
+// to REPORT_ASYNC_DAYS and with a report export to poll for longer ones or when async=true.
+// Users other than admins only export their own data.
+func (s *Server) exportReport(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if !reports.Exists(name) {
+		respondWithError(w, http.StatusNotFound, "Report not found, must be one of "+strings.Join(reports.Names(), ", "))
+		return
+	}
+
+	query := r.URL.Query()
+	format := reports.XLSX
+	if value := query.Get("format"); value != "" {
+		format, err = reports.ParseFormat(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	from, err := time.Parse("2006-01-02", query.Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+		return
+	}
+	to, err := time.Parse("2006-01-02", query.Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+		return
+	}
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	params := reports.Params{From: from, To: to, Language: responseLanguage(w), Location: s.timeZone}
+	if value := query.Get("user_id"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		params.UserID = pgtype.Int4{Int32: int32(userID), Valid: true}
+	}
+	if value := query.Get("department"); value != "" {
+		params.Department = pgtype.Text{String: value, Valid: true}
+	}
+	if currentUser.UserType != "admin" {
+		if params.Department.Valid || params.UserID.Valid && params.UserID.Int32 != currentUser.ID {
+			respondWithError(w, http.StatusForbidden, "Only admins can export reports of other users")
+			return
+		}
+		params.UserID = pgtype.Int4{Int32: currentUser.ID, Valid: true}
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	async, _ := strconv.ParseBool(query.Get("async"))
+	if !async && days <= s.config.Reports.AsyncDays {
+		content, fileName, err := reports.Export(r.Context(), s.store, name, format, params, s.reportBranding())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error exporting report: "+err.Error())
+			return
+		}
+		writeReportFile(w, format.ContentType(), fileName, content)
+		return
+	}
+
+	export, err := s.store.CreateReportExport(r.Context(), sqlc.CreateReportExportParams{
+		ReportName:      name,
+		Format:          string(format),
+		FromDate:        pgtype.Date{Time: from, Valid: true},
+		ToDate:          pgtype.Date{Time: to, Valid: true},
+		UserID:          params.UserID,
+		Department:      params.Department,
+		CreatedByUserID: currentUser.ID,
+		Language:        string(params.Language),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating report export: "+err.Error())
+		return
+	}
+	if _, err := s.queue.Enqueue(r.Context(), jobReportExport, reportExportJob{ExportID: export.ID}); err != nil {
+		// Nothing would render it, so don't leave it pending
+		_ = s.store.FailReportExport(r.Context(), sqlc.FailReportExportParams{
+			ID:    export.ID,
+			Error: pgtype.Text{String: "Error queueing export: " + err.Error(), Valid: true},
+		})
+		respondWithError(w, http.StatusInternalServerError, "Error queueing report export: "+err.Error())
+		return
+	}
+	w.Header().Set("Location", "/api/reports/exports/"+strconv.Itoa(int(export.ID)))
+	respondWithJSON(w, http.StatusAccepted, reportExportToResponse(export))
+}
+
+// getReportExport handles GET /api/reports/exports/{id}
+func (s *Server) getReportExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := s.reportExportFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportExportToResponse(export))
+}
+
+// downloadReportExport handles GET /api/reports/exports/{id}/download, answering 409 until the
+// export is done
+func (s *Server) downloadReportExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := s.reportExportFromPath(w, r)
+	if !ok {
+		return
+	}
+	switch export.Status {
+	case reportExportPending:
+		respondWithError(w, http.StatusConflict, "Report export is still being rendered")
+		return
+	case reportExportFailed:
+		respondWithError(w, http.StatusConflict, "Report export failed: "+export.Error.String)
+		return
+	}
+	writeReportFile(w, reports.Format(export.Format).ContentType(), export.FileName.String, export.Content)
+}
+
+// reportExportFromPath returns the report export whose ID is in the path, when the current user
+// asked for it, answering 401, 400 or 404 otherwise
+func (s *Server) reportExportFromPath(w http.ResponseWriter, r *http.Request) (sqlc.ReportExport, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return sqlc.ReportExport{}, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report export ID")
+		return sqlc.ReportExport{}, false
+	}
+	export, err := s.store.GetReportExport(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && export.CreatedByUserID != currentUser.ID {
+		respondWithError(w, http.StatusNotFound, "Report export not found")
+		return export, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching report export: "+err.Error())
+		return export, false
+	}
+	return export, true
+}
+
+// writeReportFile answers with a rendered report as an attachment
+func writeReportFile(w http.ResponseWriter, contentType, fileName string, content []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
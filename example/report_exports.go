@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/reports"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// Reports are exported as XLSX workbooks or PDF documents with the company's name and colour.
+// Periods of up to REPORT_ASYNC_DAYS are rendered while the request waits. Longer ones are
+// recorded as a report export, with the language they were asked for in, and rendered by a
+// queued job, and the user downloads the file once it is done. Rendering doesn't get better by
+// trying again, so an export that fails stays failed and the user asks for a new one.
+
+// The statuses of a report export
+const (
+	reportExportPending = "pending"
+	reportExportDone    = "done"
+	reportExportFailed  = "failed"
+)
+
+// reportExportJob is the payload of a report.export job
+type reportExportJob struct {
+	ExportID int32 `json:"exportId"`
+}
+
+// reportBranding is the company's name and colour the exported reports carry
+func (s *Server) reportBranding() reports.Branding {
+	return reports.Branding{CompanyName: s.config.Reports.CompanyName, Color: s.config.Reports.BrandColor}
+}
+
+// renderReportExport runs a report.export job, rendering the export into its file
+func (s *Server) renderReportExport(ctx context.Context, payload json.RawMessage) error {
+	var job reportExportJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return queue.Permanent(err)
+	}
+	export, err := s.store.GetReportExport(ctx, job.ExportID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Deleted since
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status != reportExportPending {
+		return nil
+	}
+
+	params := reports.Params{
+		From:       export.FromDate.Time,
+		To:         export.ToDate.Time,
+		UserID:     export.UserID,
+		Department: export.Department,
+		Language:   i18n.Language(export.Language),
+		Location:   s.timeZone,
+	}
+	content, fileName, err := reports.Export(ctx, s.store, export.ReportName, reports.Format(export.Format), params, s.reportBranding())
+	if err != nil {
+		if failErr := s.store.FailReportExport(ctx, sqlc.FailReportExportParams{
+			ID:    export.ID,
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+		}); failErr != nil {
+			return failErr
+		}
+		return queue.Permanent(err)
+	}
+	return s.store.CompleteReportExport(ctx, sqlc.CompleteReportExportParams{
+		ID:       export.ID,
+		FileName: pgtype.Text{String: fileName, Valid: true},
+		Content:  content,
+	})
+}
+
+// scheduleReportExportCleanup deletes the report exports made more than REPORT_RETENTION ago
+// every day
+func (s *Server) scheduleReportExportCleanup() {
+	retention := s.config.Reports.Retention
+	s.scheduler.Register(scheduler.Job{
+		Name:        "report-export-cleanup",
+		Description: "Delete the report exports made more than REPORT_RETENTION ago",
+		Schedule:    scheduler.MustParse("45 3 * * *"),
+		Run: func(ctx context.Context) error {
+			// The delete spans every tenant
+			deleted, err := s.store.DeleteReportExportsBefore(db.WithAllTenants(ctx), pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true})
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Old report exports deleted", "exports", deleted)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// runtimeSettingsReloadInterval is how often each server instance reads the runtime settings
+// again, so a change made through another instance applies everywhere within it
+const runtimeSettingsReloadInterval = 30 * time.Second
+
+// RuntimeSettings is the configuration with the overrides admins saved in the runtime_settings
+// table applied on top of the settings read at startup. Handlers read the policy it holds, such
+// as the rules of estimates, through Config on every request, so a change applies without a
+// restart.
+type RuntimeSettings struct {
+	store   db.Store
+	base    *config.Config
+	current atomic.Pointer[config.Config]
+}
+
+// NewRuntimeSettings starts from the settings read at startup, until the overrides are loaded
+func NewRuntimeSettings(store db.Store, base *config.Config) *RuntimeSettings {
+	s := &RuntimeSettings{store: store, base: base}
+	s.current.Store(base)
+	return s
+}
+
+// Config returns the settings in effect now
+func (s *RuntimeSettings) Config() *config.Config {
+	return s.current.Load()
+}
+
+// Base returns the settings read at startup, which a setting goes back to when its override is
+// deleted
+func (s *RuntimeSettings) Base() *config.Config {
+	return s.base
+}
+
+// Start loads the overrides, then loads them again every runtimeSettingsReloadInterval until ctx
+// is cancelled
+func (s *RuntimeSettings) Start(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		slog.WarnContext(ctx, "Error loading the runtime settings, using the environment", "error", err)
+	}
+	go func() {
+		ticker := time.NewTicker(runtimeSettingsReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Reload(ctx); err != nil {
+					slog.WarnContext(ctx, "Error reloading the runtime settings, keeping the previous ones", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Reload reads the overrides again. When they are invalid, e.g. saved by a server with other
+// rules, the settings stay as they are.
+func (s *RuntimeSettings) Reload(ctx context.Context) error {
+	rows, err := s.store.ListRuntimeSettings(ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := s.base.WithOverrides(overrideValues(rows))
+	if err != nil {
+		return err
+	}
+	s.current.Store(updated)
+	return nil
+}
+
+// invalidSettingsError is returned by Update for changes that break the rules of the settings
+type invalidSettingsError struct {
+	err error
+}
+
+func (e *invalidSettingsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidSettingsError) Unwrap() error {
+	return e.err
+}
+
+// Update saves the changes, by environment variable name, as the user. A nil value deletes the
+// override, so the setting goes back to the environment. The settings with the changes applied
+// are checked like at startup first, and nothing is saved when they are invalid.
+func (s *RuntimeSettings) Update(ctx context.Context, changes map[string]*string, userID int32) error {
+	var updated *config.Config
+	err := s.store.WithTx(ctx, func(q sqlc.Querier) error {
+		rows, err := q.ListRuntimeSettings(ctx)
+		if err != nil {
+			return err
+		}
+		values := overrideValues(rows)
+		for key, value := range changes {
+			if value == nil {
+				delete(values, key)
+			} else {
+				values[key] = *value
+			}
+		}
+		if updated, err = s.base.WithOverrides(values); err != nil {
+			return &invalidSettingsError{err: err}
+		}
+
+		for key, value := range changes {
+			if value == nil {
+				_, err = q.DeleteRuntimeSetting(ctx, key)
+			} else {
+				_, err = q.SetRuntimeSetting(ctx, sqlc.SetRuntimeSettingParams{
+					Key:       key,
+					Value:     *value,
+					UpdatedBy: pgtype.Int4{Int32: userID, Valid: true},
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("saving %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.current.Store(updated)
+	return nil
+}
+
+func overrideValues(rows []sqlc.RuntimeSetting) map[string]string {
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row.Key] = row.Value
+	}
+	return values
+}
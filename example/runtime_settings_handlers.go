@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+)
+
+// RuntimeSettingResponse is a setting admins may change while the server runs
+type RuntimeSettingResponse struct {
+	Key        string     `json:"key"`        // The environment variable, e.g. ESTIMATE_HOURS_PER_DAY
+	Value      string     `json:"value"`      // In effect now
+	Default    string     `json:"default"`    // From the environment, used when not overridden
+	Overridden bool       `json:"overridden"` // Saved through the API
+	UpdatedBy  *int32     `json:"updatedBy,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+// RuntimeSettingsRequest is the body of PATCH /api/admin/settings, the new values by setting in
+// the format of the environment. null deletes the override, going back to the environment.
+type RuntimeSettingsRequest map[string]*string
+
+// getRuntimeSettings handles GET /api/admin/settings, listing the settings that can be changed
+// while the server runs with where their values come from
+func (s *Server) getRuntimeSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "change runtime settings") {
+		return
+	}
+	response, err := s.runtimeSettingsResponse(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading runtime settings: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// updateRuntimeSettings handles PATCH /api/admin/settings, overriding the settings in the body.
+// The change applies to this instance at once and to the others within
+// runtimeSettingsReloadInterval.
+func (s *Server) updateRuntimeSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDeploymentAdmin(w, r, "change runtime settings") {
+		return
+	}
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req RuntimeSettingsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if len(req) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload, send the settings to change as an object")
+		return
+	}
+	for key, value := range req {
+		if !config.IsRuntimeSetting(key) {
+			respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: "+key+" can't be changed while the server runs")
+			return
+		}
+		if value != nil && strings.TrimSpace(*value) == "" {
+			respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: "+key+" is empty, send null to go back to the environment")
+			return
+		}
+	}
+
+	err = s.settings.Update(r.Context(), req, currentUser.ID)
+	var invalid *invalidSettingsError
+	if errors.As(err, &invalid) {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: "+strings.ReplaceAll(invalid.Error(), "\n", "; "))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving runtime settings: "+err.Error())
+		return
+	}
+
+	response, err := s.runtimeSettingsResponse(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reading runtime settings: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// runtimeSettingsResponse lists the runtime settings in the order of config.RuntimeSettings
+func (s *Server) runtimeSettingsResponse(r *http.Request) ([]RuntimeSettingResponse, error) {
+	rows, err := s.store.ListRuntimeSettings(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	values := s.settings.Config().RuntimeValues()
+	defaults := s.settings.Base().RuntimeValues()
+
+	response := make([]RuntimeSettingResponse, 0, len(config.RuntimeSettings))
+	for _, key := range config.RuntimeSettings {
+		setting := RuntimeSettingResponse{Key: key, Value: values[key], Default: defaults[key]}
+		for _, row := range rows {
+			if row.Key == key {
+				setting.Overridden = true
+				setting.UpdatedBy = int4Ptr(row.UpdatedBy)
+				setting.UpdatedAt = &row.UpdatedAt.Time
+			}
+		}
+		response = append(response, setting)
+	}
+	return response, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestUpdateRuntimeSettingsRejectsBadPayloads(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	token := tokenFor(admin.Username)
+
+	tests := []struct {
+		name string
+		body interface{}
+		want int
+	}{
+		{name: "not an object", body: []string{"cache_ttl"}, want: http.StatusBadRequest},
+		{name: "no settings", body: RuntimeSettingsRequest{}, want: http.StatusBadRequest},
+		{name: "setting fixed at startup", body: RuntimeSettingsRequest{"database_url": ptr("postgres://elsewhere")}, want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPatch, "/api/admin/settings", token, tt.body), tt.want, nil)
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/example/jira"
+	"github.com/kengtableg/pkeng-tableg/example/line"
+	"github.com/kengtableg/pkeng-tableg/mailer"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+	"github.com/kengtableg/pkeng-tableg/storage"
+	"github.com/kengtableg/pkeng-tableg/tz"
+	"github.com/rs/cors"
+)
+
+// Server holds the settings and dependencies of the HTTP handlers and background jobs, so they
+// can run against any store and several servers can run in one process
+type Server struct {
+	config   *config.Config
+	store    db.Store
+	database *db.DB // nil with the in-memory database
+	authz    *TaskAuthzService
+
+	// TIME_ZONE, which day and year it is for the company and what its jobs' schedules are in
+	timeZone *time.Location
+
+	// Annual records are synced whenever a write publishes a change to events
+	events        *AnnualRecordEventBus
+	annualRecords *AnnualRecordSyncService
+
+	clickUp  *ClickUpClients
+	taskSync *ClickUpTaskSyncService
+	jira     *jira.Client
+
+	// Changes from the change feed are streamed to the clients of GET /api/events
+	liveEvents *LiveEventHub
+
+	// Runs the background jobs, on the elected leader when several instances share the database
+	scheduler *scheduler.Scheduler
+
+	// Runs side effects of writes, such as ClickUp time entries, with retries
+	queue *queue.Queue
+
+	// Turns features on for groups of users, and holds writes during maintenance
+	featureFlags *FeatureFlags
+
+	// The policy settings admins change without a restart, read from here rather than config
+	settings *RuntimeSettings
+
+	// Sends notifications by channel, holding only the channels that are set up
+	notifiers map[string]Notifier
+
+	// Keeps uploaded files, scanning them first when a scanner is set up
+	files         storage.Store
+	fileScanner   storage.Scanner
+	fileURLSecret []byte // Signs the download URLs of files kept in a local directory
+}
+
+// NewServer creates a server with the given settings, reading and writing through store. The
+// database is the Postgres connection behind store, or nil when store is kept in memory, which
+// skips everything that needs Postgres itself. The ClickUp override, when not nil, replaces every
+// ClickUp client, such as with the fake from the clickuptest package.
+func NewServer(cfg *config.Config, store db.Store, database *db.DB, clickUpOverride clickup.ClickUpAPI) *Server {
+	// Handlers read through the cache, the background services see every change as it is
+	cached := db.NewCachedStore(store, db.NewCache(cfg.Cache), cfg.Cache.TTL)
+	clickUpClients := NewClickUpClients(cfg.ClickUp, clickUpOverride)
+	s := &Server{
+		config:        cfg,
+		store:         cached,
+		database:      database,
+		authz:         NewTaskAuthzService(cached),
+		events:        NewAnnualRecordEventBus(),
+		annualRecords: NewAnnualRecordSyncService(store),
+		clickUp:       clickUpClients,
+		taskSync:      NewClickUpTaskSyncService(store, clickUpClients),
+		jira:          jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Email, cfg.Jira.APIToken),
+		liveEvents:    NewLiveEventHub(),
+		featureFlags:  NewFeatureFlags(cached),
+		settings:      NewRuntimeSettings(store, cfg),
+		notifiers:     make(map[string]Notifier),
+	}
+
+	if cfg.SMTP.Enabled() {
+		smtpMailer, err := mailer.NewSMTP(cfg.SMTP)
+		if err != nil {
+			fatal("Error setting up email", "error", err)
+		}
+		s.notifiers[notificationChannelEmail] = emailNotifier{mailer: smtpMailer}
+	}
+	if cfg.LINE.Enabled() {
+		s.notifiers[notificationChannelLINE] = lineNotifier{store: cached, client: line.NewClient(cfg.LINE.APIURL, cfg.LINE.ChannelAccessToken)}
+	}
+	if cfg.FCM.Enabled() {
+		fcmClient, err := newFCMClient(cfg.FCM)
+		if err != nil {
+			fatal("Error setting up push notifications", "error", err)
+		}
+		s.notifiers[notificationChannelPush] = pushNotifier{store: cached, client: fcmClient}
+	}
+
+	timeZone, err := tz.Load(cfg.Server.TimeZone)
+	if err != nil {
+		fatal("Error loading TIME_ZONE", "error", err)
+	}
+	s.timeZone = timeZone
+
+	s.setUpFileStorage()
+
+	s.queue = queue.New(store, db.Instance, queue.Options{
+		Workers:     cfg.Queue.Workers,
+		MaxAttempts: cfg.Queue.MaxAttempts,
+		JobTimeout:  cfg.Queue.JobTimeout,
+	})
+	s.registerQueuedJobs()
+
+	s.events.Subscribe(s.syncAnnualRecord)
+
+	var elector scheduler.Elector
+	if database != nil {
+		elector = db.NewLeaderElection(database.Pool, schedulerLockID)
+	}
+	s.scheduler = scheduler.New(store, elector, db.Instance, s.timeZone)
+	return s
+}
+
+// Handler returns the HTTP handler serving the API, with its middleware and CORS, and the
+// embedded UI with SERVE_UI=true
+func (s *Server) Handler() http.Handler {
+	r := mux.NewRouter()
+
+	// Give every request an ID for its log lines, then log it
+	r.Use(RequestIDMiddleware)
+	r.Use(LoggingMiddleware)
+
+	// Answer in the language the client prefers
+	r.Use(LanguageMiddleware(s.defaultLanguage()))
+
+	// Keep browsers on HTTPS once they reached it
+	if s.config.TLS.Enabled() && s.config.TLS.HSTSMaxAge > 0 {
+		r.Use(HSTSMiddleware(s.config.TLS.HSTSMaxAge))
+	}
+
+	// Compress large responses, year-long log listings run to megabytes of JSON
+	if s.config.Server.CompressionMinSize >= 0 {
+		r.Use(CompressionMiddleware(s.config.Server.CompressionMinSize))
+	}
+
+	// Run each request in its tenant
+	if s.config.Database.MultiTenant {
+		r.Use(NewTenantMiddleware(s.store).Middleware)
+	}
+
+	if s.database != nil {
+		// Trace the queries each request runs
+		s.database.SetSpanRecorder(recordQuerySpan)
+		r.Use(QueryTraceMiddleware(s.config.Server.QueryRepeatWarnThreshold))
+	}
+
+	// Hold writes while the server is under maintenance
+	r.Use(s.MaintenanceMiddleware)
+
+	// Replay the response to a retried write that carries the same Idempotency-Key
+	r.Use(s.IdempotencyMiddleware)
+
+	s.RegisterRoutes(r)
+
+	// Describe the routes registered above at /api/openapi.json, so register any new ones first
+	RegisterOpenAPIRoutes(r)
+
+	// Serve the embedded UI at every other path, last so it can't hide an API route
+	if s.config.Server.ServeUI {
+		s.registerStaticUI(r)
+	}
+
+	settings := s.config.CORS
+	return cors.New(cors.Options{
+		AllowOriginFunc:  s.corsOriginAllowed,
+		AllowedMethods:   settings.Methods,
+		AllowedHeaders:   append(slices.Clone(settings.Headers), tenantHeader, requestIDHeader, idempotencyKeyHeader),
+		ExposedHeaders:   []string{requestIDHeader, idempotentReplayedHeader},
+		AllowCredentials: settings.AllowCredentials,
+		MaxAge:           int(settings.MaxAge.Seconds()),
+	}).Handler(r)
+}
+
+// corsOriginAllowed reports whether pages of the origin may call the API, by CORS_ORIGINS as it
+// is now, which admins may change while the server runs
+func (s *Server) corsOriginAllowed(origin string) bool {
+	settings := s.settings.Config().CORS
+	return settings.AllowsAnyOrigin() || slices.Contains(settings.Origins, origin)
+}
+
+// startBackgroundJobs starts reloading the runtime settings and the job queue's workers, creates
+// the missing annual records of this year and starts the scheduled jobs. Those all need Postgres,
+// so only the settings and the queue run with the in-memory database.
+func (s *Server) startBackgroundJobs() {
+	s.settings.Start(context.Background())
+	s.queue.Start(context.Background())
+
+	if s.database == nil {
+		return
+	}
+
+	// Ensure current year records exist
+	s.forEachTenant(context.Background(), s.ensureCurrentYearRecords)
+
+	// Schedule next year records creation
+	s.scheduleNextYearRecordsCreation()
+
+	// Schedule database backups
+	s.scheduleDatabaseBackup()
+
+	// Pick up changes made by other instances and tools from the database change feed, or
+	// poll for them hourly when the feed is off
+	if s.config.Database.ChangeFeed {
+		s.startChangeFeed()
+	} else {
+		s.schedulePeriodicSync()
+	}
+
+	s.scheduleClickUpTaskSync()
+
+	s.scheduleIdempotencyKeyCleanup()
+
+	s.scheduleQueuedJobCleanup()
+
+	s.scheduleSlackOutToday()
+
+	s.scheduleWebhookDeliveryCleanup()
+
+	s.scheduleReportExportCleanup()
+
+	s.scheduleFileCleanup()
+
+	s.schedulePrivacyRetention()
+	s.scheduleAnomalyDetection()
+	s.scheduleApprovalReminders()
+
+	if err := s.scheduler.Start(context.Background()); err != nil {
+		fatal("Error starting the background jobs", "error", err)
+	}
+}
+
+// RegisterRoutes registers the HTTP routes for this server
+func (s *Server) RegisterRoutes(r *mux.Router) {
+	// Routes for annual record sync
+	r.HandleFunc("/api/annual-records/sync", s.syncUserRecord).Methods("POST")
+	r.HandleFunc("/api/annual-records/sync/all/{year}", s.syncAllRecords).Methods("POST")
+	r.HandleFunc("/api/annual-records/ensure/{user_id}/{year}", s.ensureAnnualRecord).Methods("POST")
+	r.HandleFunc("/api/annual-records/rollover", s.scheduleYearEndRollover).Methods("POST")
+
+	// Routes for the background jobs
+	r.HandleFunc("/api/admin/jobs", s.listJobs).Methods("GET")
+	r.HandleFunc("/api/admin/jobs/{name}/run", s.runJob).Methods("POST")
+	r.HandleFunc("/api/admin/jobs/{name}/pause", s.pauseJob).Methods("POST")
+	r.HandleFunc("/api/admin/jobs/{name}/resume", s.resumeJob).Methods("POST")
+
+	// Routes for feature flags and maintenance mode
+	r.HandleFunc("/api/feature-flags", s.getCurrentFeatureFlags).Methods("GET")
+	r.HandleFunc("/api/admin/feature-flags", s.listFeatureFlags).Methods("GET")
+	r.HandleFunc("/api/admin/feature-flags/{key}", s.setFeatureFlag).Methods("PUT")
+	r.HandleFunc("/api/admin/feature-flags/{key}", s.deleteFeatureFlag).Methods("DELETE")
+
+	// Routes for the runtime settings
+	r.HandleFunc("/api/admin/settings", s.getRuntimeSettings).Methods("GET")
+	r.HandleFunc("/api/admin/settings", s.updateRuntimeSettings).Methods("PATCH")
+
+	// Routes for the job queue's dead letters
+	r.HandleFunc("/api/admin/queue", s.listQueuedJobs).Methods("GET")
+	r.HandleFunc("/api/admin/queue/{id}", s.getQueuedJob).Methods("GET")
+	r.HandleFunc("/api/admin/queue/{id}/retry", s.retryQueuedJob).Methods("POST")
+
+	// Routes for ClickUp two-way task sync
+	r.HandleFunc("/api/tasks/{id}/clickup-sync", s.syncTask).Methods("POST")
+	r.HandleFunc("/api/tasks/{id}/sync-history", s.getSyncHistory).Methods("GET")
+	r.HandleFunc("/api/clickup/sync", s.syncAllTasks).Methods("POST")
+	r.HandleFunc("/api/clickup/workspaces/{team_id}/sync", s.syncWorkspaceTasks).Methods("POST")
+	r.HandleFunc("/api/clickup/workspaces/{team_id}/lists/{list_id}/import", s.importClickUpList).Methods("POST")
+	r.HandleFunc("/api/admin/integrations/clickup/status", s.getClickUpStatus).Methods("GET")
+
+	// Routes for the Slack integration
+	r.HandleFunc("/api/admin/slack/workspaces", s.getSlackWorkspaces).Methods("GET")
+	r.HandleFunc("/api/admin/slack/workspaces", s.connectSlackWorkspace).Methods("POST")
+	r.HandleFunc("/api/admin/slack/workspaces/{team_id}", s.disconnectSlackWorkspace).Methods("DELETE")
+	r.HandleFunc("/api/slack/interactions", s.handleSlackInteraction).Methods("POST")
+
+	// Routes for LINE notifications
+	r.HandleFunc("/api/current-user/line", s.getLineLink).Methods("GET")
+	r.HandleFunc("/api/current-user/line", s.unlinkLine).Methods("DELETE")
+	r.HandleFunc("/api/current-user/line/link-code", s.createLineLinkCode).Methods("POST")
+	r.HandleFunc("/api/line/webhook", s.handleLineWebhook).Methods("POST")
+
+	// Routes for push notifications to the mobile app
+	r.HandleFunc("/api/current-user/devices", s.getPushDevices).Methods("GET")
+	r.HandleFunc("/api/current-user/devices", s.registerPushDevice).Methods("POST")
+	r.HandleFunc("/api/current-user/devices/{id}", s.deletePushDevice).Methods("DELETE")
+
+	// Routes for outbound webhooks
+	r.HandleFunc("/api/admin/webhooks", s.getWebhooks).Methods("GET")
+	r.HandleFunc("/api/admin/webhooks", s.createWebhook).Methods("POST")
+	r.HandleFunc("/api/admin/webhooks/{id}", s.getWebhook).Methods("GET")
+	r.HandleFunc("/api/admin/webhooks/{id}", s.updateWebhook).Methods("PATCH")
+	r.HandleFunc("/api/admin/webhooks/{id}", s.deleteWebhook).Methods("DELETE")
+	r.HandleFunc("/api/admin/webhooks/{id}/ping", s.pingWebhook).Methods("POST")
+	r.HandleFunc("/api/admin/webhooks/{id}/deliveries", s.getWebhookDeliveries).Methods("GET")
+	r.HandleFunc("/api/admin/webhooks/{id}/deliveries/{delivery_id}", s.getWebhookDelivery).Methods("GET")
+	r.HandleFunc("/api/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver", s.redeliverWebhookDelivery).Methods("POST")
+
+	// Routes for API tokens and the availability lookup internal services call with them
+	r.HandleFunc("/api/admin/api-tokens", s.getAPITokens).Methods("GET")
+	r.HandleFunc("/api/admin/api-tokens", s.createAPIToken).Methods("POST")
+	r.HandleFunc("/api/admin/api-tokens/{id}", s.revokeAPIToken).Methods("DELETE")
+	r.HandleFunc("/api/availability", s.getAvailability).Methods("GET")
+	r.HandleFunc("/api/availability/{username}", s.getUserAvailability).Methods("GET")
+
+	// Route for importing legacy HR spreadsheets
+	r.HandleFunc("/api/admin/import", s.importWorkbook).Methods("POST")
+
+	// Routes for the balance events annual records are derived from
+	r.HandleFunc("/api/admin/balance-events", s.getBalanceEvents).Methods("GET")
+	r.HandleFunc("/api/admin/balance-events/replay", s.replayBalanceEvents).Methods("GET")
+	r.HandleFunc("/api/admin/balance-events/drift", s.getBalanceDrift).Methods("GET")
+	r.HandleFunc("/api/admin/balance-events/rebuild", s.rebuildAnnualRecords).Methods("POST")
+
+	// Routes for the payroll export
+	r.HandleFunc("/api/admin/payroll/periods", s.getPayrollPeriods).Methods("GET")
+	r.HandleFunc("/api/admin/payroll/periods", s.createPayrollPeriod).Methods("POST")
+	r.HandleFunc("/api/admin/payroll/periods/{period}/lock", s.lockPayrollPeriod).Methods("POST")
+	r.HandleFunc("/api/admin/payroll/periods/{period}/unlock", s.unlockPayrollPeriod).Methods("POST")
+	r.HandleFunc("/api/admin/payroll/preview", s.previewPayroll).Methods("GET")
+	r.HandleFunc("/api/admin/payroll/export", s.exportPayroll).Methods("GET")
+
+	// Liveness and readiness probes
+	r.HandleFunc("/healthz", s.live).Methods("GET")
+	r.HandleFunc("/readyz", s.ready).Methods("GET")
+
+	// Live updates for the dashboard
+	r.HandleFunc("/api/events", s.getEvents).Methods("GET")
+
+	// Routes for user management
+	r.HandleFunc("/api/users", s.getUsers).Methods("GET")
+	r.HandleFunc("/api/users/{id}", s.getUser).Methods("GET")
+	r.HandleFunc("/api/users", s.createUser).Methods("POST")
+	r.HandleFunc("/api/users/{id}", s.updateUser).Methods("PUT")
+	r.HandleFunc("/api/users/{id}", s.deleteUser).Methods("DELETE")
+	r.HandleFunc("/api/users/{id}/reset-password", s.resetUserPassword).Methods("POST")
+	r.HandleFunc("/api/login", s.loginHandler).Methods("POST")
+	r.HandleFunc("/api/current-user", s.getCurrentUser).Methods("GET")
+	r.HandleFunc("/api/dashboard", s.getDashboard).Methods("GET")
+	r.HandleFunc("/api/current-user/notification-preferences", s.getNotificationPreferences).Methods("GET")
+	r.HandleFunc("/api/current-user/notification-preferences", s.updateNotificationPreferences).Methods("PATCH")
+	r.HandleFunc("/api/current-user/language", s.getLanguagePreference).Methods("GET")
+	r.HandleFunc("/api/current-user/language", s.updateLanguagePreference).Methods("PUT")
+	r.HandleFunc("/api/current-user/time-zone", s.getTimeZonePreference).Methods("GET")
+	r.HandleFunc("/api/current-user/time-zone", s.updateTimeZonePreference).Methods("PUT")
+	r.HandleFunc("/api/current-user/data-export", s.getDataExport).Methods("GET")
+	r.HandleFunc("/api/current-user/year-summary", s.getYearSummary).Methods("GET")
+
+	// Routes for holidays
+	r.HandleFunc("/api/holidays", s.getHolidays).Methods("GET")
+	r.HandleFunc("/api/holidays/{id}", s.getHoliday).Methods("GET")
+	r.HandleFunc("/api/holidays", s.createHoliday).Methods("POST")
+	r.HandleFunc("/api/holidays/{id}", s.updateHoliday).Methods("PUT")
+	r.HandleFunc("/api/holidays/{id}", s.deleteHoliday).Methods("DELETE")
+
+	// Routes for annual records
+	r.HandleFunc("/api/annual-records", s.getAnnualRecords).Methods("GET")
+	r.HandleFunc("/api/annual-records/{id}", s.getAnnualRecord).Methods("GET")
+	r.HandleFunc("/api/annual-records", s.createAnnualRecord).Methods("POST")
+	r.HandleFunc("/api/annual-records/{id}", s.updateAnnualRecord).Methods("PUT")
+	r.HandleFunc("/api/annual-records/{id}", s.deleteAnnualRecord).Methods("DELETE")
+	r.HandleFunc("/api/users/{user_id}/annual-records", s.getUserAnnualRecords).Methods("GET")
+	r.HandleFunc("/api/current-user/annual-records", s.getCurrentUserAnnualRecords).Methods("GET")
+	r.HandleFunc("/api/users/{user_id}/annual-records/current-year", s.upsertAnnualRecordForUser).Methods("POST")
+	r.HandleFunc("/api/annual-records/quota-plan/{plan_id}/assign-to-all", s.assignQuotaPlanToAllUsers).Methods("POST")
+	r.HandleFunc("/api/annual-records/create-next-year", s.createNextYearAnnualRecords).Methods("POST")
+
+	// Routes for quota plans
+	r.HandleFunc("/api/quota-plans", s.getQuotaPlans).Methods("GET")
+	r.HandleFunc("/api/quota-plans/{id}", s.getQuotaPlan).Methods("GET")
+	r.HandleFunc("/api/quota-plans", s.createQuotaPlan).Methods("POST")
+	r.HandleFunc("/api/quota-plans/{id}", s.updateQuotaPlan).Methods("PUT")
+	r.HandleFunc("/api/quota-plans/{id}", s.deleteQuotaPlan).Methods("DELETE")
+	r.HandleFunc("/api/quota-plans/year/{year}", s.getQuotaPlansByYear).Methods("GET")
+
+	// Routes for medical expenses
+	r.HandleFunc("/api/medical-expenses", s.getMedicalExpenses).Methods("GET")
+	r.HandleFunc("/api/medical-expenses/{id}", s.getMedicalExpense).Methods("GET")
+	r.HandleFunc("/api/medical-expenses", s.createMedicalExpense).Methods("POST")
+	r.HandleFunc("/api/medical-expenses/{id}", s.updateMedicalExpense).Methods("PUT")
+	r.HandleFunc("/api/medical-expenses/{id}", s.deleteMedicalExpense).Methods("DELETE")
+	r.HandleFunc("/api/current-user/medical-expenses", s.getCurrentUserMedicalExpenses).Methods("GET")
+
+	// Routes for leave logs
+	r.HandleFunc("/api/leave-logs", s.getLeaveLogsList).Methods("GET")
+	r.HandleFunc("/api/leave-logs/{id}", s.getLeaveLog).Methods("GET")
+	r.HandleFunc("/api/leave-logs", s.createLeaveLog).Methods("POST")
+	r.HandleFunc("/api/leave-logs/{id}", s.updateLeaveLog).Methods("PUT")
+	r.HandleFunc("/api/leave-logs/{id}", s.deleteLeaveLog).Methods("DELETE")
+	r.HandleFunc("/api/current-user/leave-logs", s.getCurrentUserLeaveLogs).Methods("GET")
+	r.HandleFunc("/api/leave-calendar", s.getLeaveCalendar).Methods("GET")
+
+	// Routes for ClickUp OAuth
+	r.HandleFunc("/api/oauth/clickup", s.initiateOAuthHandler).Methods("GET")
+	r.HandleFunc("/api/oauth/callback", s.oauthCallbackHandler).Methods("GET")
+	r.HandleFunc("/api/oauth/token", s.getCurrentTokenHandler).Methods("GET")
+	r.HandleFunc("/api/oauth/token", s.deleteCurrentTokenHandler).Methods("DELETE")
+	r.HandleFunc("/api/current-user/clickup/link", s.linkClickUpAccountHandler).Methods("POST")
+	r.HandleFunc("/api/current-user/clickup/unlink", s.deleteCurrentTokenHandler).Methods("DELETE")
+
+	// Routes for browsing connected ClickUp workspaces
+	r.HandleFunc("/api/clickup/workspaces", s.getClickUpWorkspaces).Methods("GET")
+	r.HandleFunc("/api/clickup/workspaces/{team_id}/spaces", s.getClickUpSpaces).Methods("GET")
+	r.HandleFunc("/api/clickup/workspaces/{team_id}/spaces/{space_id}/lists", s.getClickUpLists).Methods("GET")
+
+	// Routes for task categories
+	r.HandleFunc("/api/task-categories", s.getTaskCategories).Methods("GET")
+	// Before /{id}, which would take hierarchical for an ID otherwise
+	r.HandleFunc("/api/task-categories/hierarchical", s.getHierarchicalTaskCategories).Methods("GET")
+	r.HandleFunc("/api/task-categories/{id}", s.getTaskCategory).Methods("GET")
+	r.HandleFunc("/api/task-categories", s.createTaskCategory).Methods("POST")
+	r.HandleFunc("/api/task-categories/reorder", s.reorderTaskCategories).Methods("POST")
+	r.HandleFunc("/api/task-categories/{id}", s.updateTaskCategory).Methods("PUT")
+	r.HandleFunc("/api/task-categories/{id}", s.deleteTaskCategory).Methods("DELETE")
+	r.HandleFunc("/api/task-categories/{id}/move", s.moveTaskCategory).Methods("POST")
+	r.HandleFunc("/api/task-categories/{id}/merge", s.mergeTaskCategory).Methods("POST")
+
+	// Routes for tasks
+	r.HandleFunc("/api/tasks", s.getTasks).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}", s.getTask).Methods("GET")
+	r.HandleFunc("/api/tasks", s.createTask).Methods("POST")
+	r.HandleFunc("/api/tasks/{id}", s.updateTask).Methods("PUT")
+	r.HandleFunc("/api/tasks/{id}", s.deleteTask).Methods("DELETE")
+
+	// Routes for task assignees
+	r.HandleFunc("/api/tasks/{id}/assignees", s.getTaskAssignees).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/assignees", s.assignTask).Methods("POST")
+	r.HandleFunc("/api/tasks/{id}/assignees/{user_id}", s.unassignTask).Methods("DELETE")
+	r.HandleFunc("/api/categories/{category_id}/tasks", s.getTasksByCategory).Methods("GET")
+
+	// Routes for subtasks
+	r.HandleFunc("/api/tasks/{id}/subtasks", s.getSubtasks).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/parent", s.setTaskParent).Methods("PUT")
+
+	// Routes for tags
+	r.HandleFunc("/api/tags", s.getTags).Methods("GET")
+	r.HandleFunc("/api/tags", s.createTag).Methods("POST")
+	r.HandleFunc("/api/tags/{id}", s.updateTag).Methods("PUT")
+	r.HandleFunc("/api/tags/{id}", s.deleteTag).Methods("DELETE")
+	r.HandleFunc("/api/tasks/{id}/tags", s.getTaskTags).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/tags", s.addTaskTag).Methods("POST")
+	r.HandleFunc("/api/tasks/{id}/tags/{tag_id}", s.removeTaskTag).Methods("DELETE")
+	r.HandleFunc("/api/reports/tags", s.getTagReport).Methods("GET")
+	r.HandleFunc("/api/reports/categories", s.getTaskCategoryReport).Methods("GET")
+	r.HandleFunc("/api/reports/estimates", s.getEstimateVarianceReport).Methods("GET")
+	r.HandleFunc("/api/reports/capacity", s.getTeamCapacityReport).Methods("GET")
+	r.HandleFunc("/api/reports/leave", s.getLeaveReport).Methods("GET")
+	r.HandleFunc("/api/reports/exports/{id}", s.getReportExport).Methods("GET")
+	r.HandleFunc("/api/reports/exports/{id}/download", s.downloadReportExport).Methods("GET")
+	r.HandleFunc("/api/reports/{name}/export", s.exportReport).Methods("GET")
+
+	// Routes for task comments and activity
+	r.HandleFunc("/api/tasks/{id}/comments", s.getTaskComments).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/comments", s.createTaskComment).Methods("POST")
+	r.HandleFunc("/api/task-comments/{id}", s.updateTaskComment).Methods("PUT")
+	r.HandleFunc("/api/task-comments/{id}", s.deleteTaskComment).Methods("DELETE")
+	r.HandleFunc("/api/tasks/{id}/activity", s.getTaskActivity).Methods("GET")
+
+	// Routes for task estimates
+	r.HandleFunc("/api/task-estimates", s.getTaskEstimates).Methods("GET")
+	r.HandleFunc("/api/task-estimates/{id}", s.getTaskEstimate).Methods("GET")
+	r.HandleFunc("/api/task-estimates", s.createTaskEstimate).Methods("POST")
+	r.HandleFunc("/api/task-estimates/{id}", s.updateTaskEstimate).Methods("PUT")
+	r.HandleFunc("/api/task-estimates/{id}", s.deleteTaskEstimate).Methods("DELETE")
+	r.HandleFunc("/api/task-estimates/{id}/supersede", s.supersedeTaskEstimate).Methods("POST")
+	r.HandleFunc("/api/tasks/{task_id}/estimates", s.getTaskEstimatesByTask).Methods("GET")
+	r.HandleFunc("/api/tasks/{task_id}/estimates/current", s.getCurrentTaskEstimate).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/burndown", s.getTaskBurndown).Methods("GET")
+
+	// Routes for estimation sessions
+	r.HandleFunc("/api/tasks/{id}/estimation-sessions", s.getTaskEstimationSessions).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}/estimation-sessions", s.createEstimationSession).Methods("POST")
+	r.HandleFunc("/api/estimation-sessions/{id}", s.getEstimationSession).Methods("GET")
+	r.HandleFunc("/api/estimation-sessions/{id}/vote", s.voteInEstimationSession).Methods("PUT")
+	r.HandleFunc("/api/estimation-sessions/{id}/finalize", s.finalizeEstimationSession).Methods("POST")
+
+	// Routes for task logs
+	r.HandleFunc("/api/task-logs/by-date-range", s.getTaskLogsByDateRange).Methods("GET")
+	r.HandleFunc("/api/task-logs", s.getTaskLogs).Methods("GET")
+	r.HandleFunc("/api/task-logs/{id}", s.getTaskLog).Methods("GET")
+	r.HandleFunc("/api/task-logs", s.createTaskLog).Methods("POST")
+	r.HandleFunc("/api/task-logs/{id}", s.updateTaskLog).Methods("PUT")
+	r.HandleFunc("/api/task-logs/{id}", s.deleteTaskLog).Methods("DELETE")
+	r.HandleFunc("/api/tasks/{task_id}/logs", s.getTaskLogsByTask).Methods("GET")
+
+	// Routes for attendance
+	r.HandleFunc("/api/attendance/check-in", s.checkIn).Methods("POST")
+	r.HandleFunc("/api/attendance/check-out", s.checkOut).Methods("POST")
+	r.HandleFunc("/api/attendance/me", s.getMyAttendance).Methods("GET")
+	r.HandleFunc("/api/attendance/daily", s.getDailyAttendance).Methods("GET")
+	r.HandleFunc("/api/attendance/suggestion", s.getAttendanceSuggestion).Methods("GET")
+
+	// Routes for the org chart
+	r.HandleFunc("/api/departments", s.getDepartments).Methods("GET")
+	r.HandleFunc("/api/departments", s.createDepartment).Methods("POST")
+	r.HandleFunc("/api/departments/{id}", s.updateDepartment).Methods("PUT")
+	r.HandleFunc("/api/departments/{id}", s.deleteDepartment).Methods("DELETE")
+	r.HandleFunc("/api/positions", s.getPositions).Methods("GET")
+	r.HandleFunc("/api/positions", s.createPosition).Methods("POST")
+	r.HandleFunc("/api/positions/{id}", s.updatePosition).Methods("PUT")
+	r.HandleFunc("/api/positions/{id}", s.deletePosition).Methods("DELETE")
+	r.HandleFunc("/api/users/{id}/reporting", s.getReportingLine).Methods("GET")
+	r.HandleFunc("/api/users/{id}/reporting", s.updateReportingLine).Methods("PUT")
+	r.HandleFunc("/api/org-chart", s.getOrgChart).Methods("GET")
+
+	// Routes for approvals
+	r.HandleFunc("/api/admin/approval-policies", s.getApprovalPolicies).Methods("GET")
+	r.HandleFunc("/api/admin/approval-policies/{kind}", s.putApprovalPolicy).Methods("PUT")
+	r.HandleFunc("/api/admin/approval-policies/{kind}", s.deleteApprovalPolicy).Methods("DELETE")
+	r.HandleFunc("/api/approvals/inbox", s.getApprovalInbox).Methods("GET")
+	r.HandleFunc("/api/approvals/mine", s.getMyApprovalRequests).Methods("GET")
+	r.HandleFunc("/api/approvals/{id}", s.getApprovalRequest).Methods("GET")
+	r.HandleFunc("/api/approvals/{id}/approve", s.approveApprovalRequest).Methods("POST")
+	r.HandleFunc("/api/approvals/{id}/reject", s.rejectApprovalRequest).Methods("POST")
+	r.HandleFunc("/api/approval-delegations", s.getApprovalDelegations).Methods("GET")
+	r.HandleFunc("/api/approval-delegations", s.createApprovalDelegation).Methods("POST")
+	r.HandleFunc("/api/approval-delegations/suggestions", s.getApprovalDelegationSuggestions).Methods("GET")
+	r.HandleFunc("/api/approval-delegations/{id}", s.getApprovalDelegation).Methods("GET")
+	r.HandleFunc("/api/approval-delegations/{id}", s.deleteApprovalDelegation).Methods("DELETE")
+
+	// Routes for uploaded files
+	r.HandleFunc("/api/files", s.uploadFile).Methods("POST")
+	r.HandleFunc("/api/files", s.getFiles).Methods("GET")
+	r.HandleFunc("/api/files/{id}", s.getFile).Methods("GET")
+	r.HandleFunc("/api/files/{id}", s.deleteFile).Methods("DELETE")
+	r.HandleFunc("/api/files/{id}/content", s.getFileContent).Methods("GET")
+	r.HandleFunc("/api/users/{id}/avatar", s.getUserAvatar).Methods("GET")
+
+	// Routes for purging and restoring soft deleted records
+	r.HandleFunc("/api/admin/deleted/{kind}", s.purgeDeleted).Methods("DELETE")
+	r.HandleFunc("/api/admin/deleted/{kind}/{id}/restore", s.restoreDeleted).Methods("POST")
+
+	// Routes for scrubbing the personal data of deleted users
+	r.HandleFunc("/api/admin/users/{id}/anonymize", s.anonymizeUser).Methods("POST")
+
+	// Routes for announcements
+	r.HandleFunc("/api/announcements", s.getAnnouncements).Methods("GET")
+	r.HandleFunc("/api/announcements/{id}/acknowledge", s.acknowledgeAnnouncement).Methods("POST")
+	r.HandleFunc("/api/admin/announcements", s.listAnnouncements).Methods("GET")
+	r.HandleFunc("/api/admin/announcements", s.createAnnouncement).Methods("POST")
+	r.HandleFunc("/api/admin/announcements/{id}", s.getAnnouncement).Methods("GET")
+	r.HandleFunc("/api/admin/announcements/{id}", s.updateAnnouncement).Methods("PUT")
+	r.HandleFunc("/api/admin/announcements/{id}", s.deleteAnnouncement).Methods("DELETE")
+	r.HandleFunc("/api/admin/announcements/{id}/acknowledgments", s.getAnnouncementAcknowledgments).Methods("GET")
+
+	// Routes for the review queue of the anomaly-detection job
+	r.HandleFunc("/api/anomalies", s.getAnomalies).Methods("GET")
+	r.HandleFunc("/api/anomalies/{id}", s.getAnomaly).Methods("GET")
+	r.HandleFunc("/api/anomalies/{id}/review", s.reviewAnomaly).Methods("POST")
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+)
+
+// newTestServer returns the API of a server keeping its data in a dbtest.Fake, and the fake.
+// clickUp replaces every ClickUp client when not nil.
+func newTestServer(t *testing.T, clickUp clickup.ClickUpAPI) (http.Handler, *dbtest.Fake) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Files.Location = t.TempDir()
+	store := dbtest.NewFake()
+	return NewServer(cfg, store, nil, clickUp).Handler(), store
+}
+
+// tokenFor returns the bearer token the server accepts for a username
+func tokenFor(username string) string {
+	return "dummy-token-" + username
+}
+
+// ptr returns a pointer to v, for the optional fields of requests
+func ptr[T any](v T) *T {
+	return &v
+}
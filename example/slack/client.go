@@ -0,0 +1,289 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// Client is a Slack Web API client acting as an app's bot in one workspace
+type Client struct {
+	BaseURL    string // Web API URL, e.g. https://slack.com/api
+	Token      string // Bot token, xoxb-...
+	HTTPClient *http.Client
+}
+
+// Identity is the bot and workspace a token belongs to, as auth.test returns them
+type Identity struct {
+	TeamID string `json:"team_id"`
+	Team   string `json:"team"`
+	UserID string `json:"user_id"`
+}
+
+// User is a member of the workspace
+type User struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Profile struct {
+		Email string `json:"email"`
+	} `json:"profile"`
+}
+
+// Message is a message to post, Text being what notifications show when there are blocks
+type Message struct {
+	Channel         string  `json:"channel,omitempty"` // Channel or user ID, a user's ID posts to the DM with the bot
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"` // Only for a response URL
+	ResponseType    string  `json:"response_type,omitempty"`    // Only for a response URL, "ephemeral" by default
+}
+
+// Block is a Block Kit layout block, a section or a row of buttons
+type Block struct {
+	Type     string   `json:"type"` // section or actions
+	BlockID  string   `json:"block_id,omitempty"`
+	Text     *Text    `json:"text,omitempty"`
+	Elements []Button `json:"elements,omitempty"`
+}
+
+// Text is a Block Kit text object
+type Text struct {
+	Type string `json:"type"` // mrkdwn or plain_text
+	Text string `json:"text"`
+}
+
+// Button is a Block Kit button. Clicking it sends an interaction with its action ID and value.
+type Button struct {
+	Type     string `json:"type"` // Always button
+	Text     Text   `json:"text"`
+	ActionID string `json:"action_id"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"` // primary, danger or empty
+}
+
+// Section returns a section block showing mrkdwn text
+func Section(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}}
+}
+
+// Actions returns a block with a row of buttons
+func Actions(blockID string, buttons ...Button) Block {
+	return Block{Type: "actions", BlockID: blockID, Elements: buttons}
+}
+
+// NewButton returns a button sending actionID and value when clicked
+func NewButton(text, actionID, value, style string) Button {
+	return Button{Type: "button", Text: Text{Type: "plain_text", Text: text}, ActionID: actionID, Value: value, Style: style}
+}
+
+// APIError is returned when Slack answers with a non-success status or with ok false
+type APIError struct {
+	Method     string
+	StatusCode int
+	Code       string // Slack's error code, such as channel_not_found
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("slack %s failed: %s", e.Method, e.Code)
+	}
+	return fmt.Sprintf("slack %s returned status %d", e.Method, e.StatusCode)
+}
+
+// IsTransient reports whether the same call may succeed later, such as when rate limited, rather
+// than failing the same way, such as for a channel the bot isn't in
+func (e *APIError) IsTransient() bool {
+	switch e.Code {
+	case "ratelimited", "internal_error", "fatal_error", "service_unavailable", "request_timeout":
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NewClient creates a client for the bot token at the given Web API URL
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		HTTPClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+	}
+}
+
+// AuthTest returns the bot and workspace of the token, checking it is valid
+func (c *Client) AuthTest(ctx context.Context) (*Identity, error) {
+	var identity Identity
+	if err := c.do(ctx, http.MethodPost, "auth.test", nil, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// PostMessage posts a message to a channel, or to a user's DM with the bot
+func (c *Client) PostMessage(ctx context.Context, msg Message) error {
+	return c.do(ctx, http.MethodPost, "chat.postMessage", msg, nil)
+}
+
+// LookupUserByEmail finds the member with an email address, failing with users_not_found for an
+// address no member has
+func (c *Client) LookupUserByEmail(ctx context.Context, email string) (*User, error) {
+	var response struct {
+		User User `json:"user"`
+	}
+	if err := c.do(ctx, http.MethodGet, "users.lookupByEmail?"+url.Values{"email": {email}}.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.User, nil
+}
+
+// UserInfo returns a member by ID, with the email address when the app may read it
+func (c *Client) UserInfo(ctx context.Context, userID string) (*User, error) {
+	var response struct {
+		User User `json:"user"`
+	}
+	if err := c.do(ctx, http.MethodGet, "users.info?"+url.Values{"user": {userID}}.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.User, nil
+}
+
+// do calls a Web API method, with payload as the JSON body when given, and decodes the response
+// into out when given
+func (c *Client) do(ctx context.Context, httpMethod, method string, payload interface{}, out interface{}) error {
+	apiMethod, _, _ := strings.Cut(method, "?")
+	var reqBody io.Reader
+	if payload != nil {
+		jsonBody, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, httpMethod, c.BaseURL+"/"+method, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	logging.SetRequestIDHeader(ctx, httpReq.Header)
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	body, err := c.send(httpReq, apiMethod)
+	if err != nil {
+		return err
+	}
+
+	// Slack answers errors with 200 and ok false
+	var envelope struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if !envelope.OK {
+		return &APIError{Method: apiMethod, StatusCode: http.StatusOK, Code: envelope.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// Respond posts a message to the response URL of an interaction, which replaces or follows up on
+// the message whose button was clicked. It needs no token.
+func (c *Client) Respond(ctx context.Context, responseURL string, msg Message) error {
+	jsonBody, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	_, err = c.send(httpReq, "response_url")
+	return err
+}
+
+// send sends a request and returns the body of a success response
+func (c *Client) send(httpReq *http.Request, method string) ([]byte, error) {
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{Method: method, StatusCode: resp.StatusCode}
+	}
+	return body, nil
+}
+
+// Interaction is the payload of a button click sent to the app's interactivity request URL
+type Interaction struct {
+	Type string `json:"type"` // block_actions for buttons
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// maxRequestAge is how old a signed request may be, so a captured one can't be replayed later
+const maxRequestAge = 5 * time.Minute
+
+// ErrInvalidSignature is returned by VerifyRequest for a request Slack didn't sign
+var ErrInvalidSignature = errors.New("invalid Slack signature")
+
+// VerifyRequest checks that a request to the app came from Slack, from its X-Slack-Signature,
+// an HMAC-SHA256 of the timestamp and body under the app's signing secret, within maxRequestAge
+// of now
+func VerifyRequest(signingSecret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
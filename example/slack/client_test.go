@@ -0,0 +1,66 @@
+package slack
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The example request of Slack's "Verifying requests from Slack" guide
+const (
+	exampleSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+	exampleTimestamp     = "1531420618"
+	exampleBody          = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	exampleSignature     = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	signedAt := time.Unix(1531420618, 0)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      string
+		now       time.Time
+		wantErr   bool
+	}{
+		{name: "Slack's example", now: signedAt.Add(time.Minute)},
+		{name: "just within the age", now: signedAt.Add(maxRequestAge)},
+		{name: "stale", now: signedAt.Add(maxRequestAge + time.Second), wantErr: true},
+		{name: "from the future", now: signedAt.Add(-maxRequestAge - time.Second), wantErr: true},
+		{name: "tampered body", body: exampleBody + "&text=hello", now: signedAt, wantErr: true},
+		{name: "other secret", secret: "another-secret", now: signedAt, wantErr: true},
+		{name: "other timestamp", timestamp: "1531420619", now: signedAt, wantErr: true},
+		{name: "no timestamp", timestamp: "-", now: signedAt, wantErr: true},
+		{name: "no signature", signature: "-", now: signedAt, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, timestamp, signature, body := exampleSigningSecret, exampleTimestamp, exampleSignature, exampleBody
+			if tt.secret != "" {
+				secret = tt.secret
+			}
+			if tt.timestamp == "-" {
+				timestamp = ""
+			} else if tt.timestamp != "" {
+				timestamp = tt.timestamp
+			}
+			if tt.signature == "-" {
+				signature = ""
+			}
+			if tt.body != "" {
+				body = tt.body
+			}
+
+			header := http.Header{}
+			header.Set("X-Slack-Request-Timestamp", timestamp)
+			header.Set("X-Slack-Signature", signature)
+			err := VerifyRequest(secret, header, []byte(body), tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyRequest = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
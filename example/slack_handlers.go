@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/slack"
+)
+
+// maxSlackInteractionSize caps the body of an interaction Slack sends, far above a button click
+const maxSlackInteractionSize = 1 << 20
+
+// SlackWorkspaceRequest is the body of POST /api/admin/slack/workspaces, connecting the workspace
+// of the bot token or replacing its settings
+type SlackWorkspaceRequest struct {
+	BotToken         string `json:"botToken" validate:"required"`         // xoxb-..., with chat:write, users:read and users:read.email
+	ApprovalsChannel string `json:"approvalsChannel" validate:"max=50"`   // Channel ID for leave requests, none when empty
+	OutTodayChannel  string `json:"outTodayChannel" validate:"max=50"`    // Channel ID for who is out today, none when empty
+	OutTodayHour     *int16 `json:"outTodayHour" validate:"min=0,max=23"` // Defaults to 9, in TIME_ZONE
+	NotifyUsers      *bool  `json:"notifyUsers"`                          // DM users when their leave is decided, defaults to true
+}
+
+// SlackWorkspaceResponse is a connected Slack workspace, without its token
+type SlackWorkspaceResponse struct {
+	TeamID            string    `json:"teamId"`
+	Name              string    `json:"name"`
+	ApprovalsChannel  string    `json:"approvalsChannel"`
+	OutTodayChannel   string    `json:"outTodayChannel"`
+	OutTodayHour      int16     `json:"outTodayHour"`
+	OutTodayPostedOn  *string   `json:"outTodayPostedOn,omitempty"`
+	NotifyUsers       bool      `json:"notifyUsers"`
+	ConnectedByUserID *int32    `json:"connectedByUserId,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+func slackWorkspaceToResponse(workspace sqlc.SlackWorkspace) SlackWorkspaceResponse {
+	response := SlackWorkspaceResponse{
+		TeamID:            workspace.TeamID,
+		Name:              workspace.Name,
+		ApprovalsChannel:  workspace.ApprovalsChannel,
+		OutTodayChannel:   workspace.OutTodayChannel,
+		OutTodayHour:      workspace.OutTodayHour,
+		NotifyUsers:       workspace.NotifyUsers,
+		ConnectedByUserID: int4Ptr(workspace.ConnectedByUserID),
+		CreatedAt:         workspace.CreatedAt.Time,
+		UpdatedAt:         workspace.UpdatedAt.Time,
+	}
+	if workspace.OutTodayPostedOn.Valid {
+		postedOn := workspace.OutTodayPostedOn.Time.Format("2006-01-02")
+		response.OutTodayPostedOn = &postedOn
+	}
+	return response
+}
+
+// authorizeSlackAdmin answers 503 while the integration is off and 403 to users other than
+// admins, and returns the admin otherwise
+func (s *Server) authorizeSlackAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can manage Slack workspaces")
+		return currentUser, false
+	}
+	if !s.config.Slack.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "Slack integration is disabled, set SLACK_SIGNING_SECRET and SLACK_TOKEN_ENCRYPTION_KEY")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// getSlackWorkspaces handles GET /api/admin/slack/workspaces
+func (s *Server) getSlackWorkspaces(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeSlackAdmin(w, r); !ok {
+		return
+	}
+	workspaces, err := s.store.ListSlackWorkspaces(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching Slack workspaces: "+err.Error())
+		return
+	}
+	response := make([]SlackWorkspaceResponse, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		response = append(response, slackWorkspaceToResponse(workspace))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// connectSlackWorkspace handles POST /api/admin/slack/workspaces. The token is checked with
+// Slack, which also tells the workspace it belongs to.
+func (s *Server) connectSlackWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser, ok := s.authorizeSlackAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req SlackWorkspaceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	identity, err := slack.NewClient(s.config.Slack.APIURL, req.BotToken).AuthTest(ctx)
+	var apiErr *slack.APIError
+	if errors.As(err, &apiErr) && !apiErr.IsTransient() {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: Slack rejected the bot token: "+apiErr.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Error checking the bot token with Slack: "+err.Error())
+		return
+	}
+
+	token, err := sealToken(s.config.Slack.TokenEncryptionKey, req.BotToken)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error encrypting the bot token: "+err.Error())
+		return
+	}
+	params := sqlc.UpsertSlackWorkspaceParams{
+		TeamID:            identity.TeamID,
+		Name:              identity.Team,
+		BotToken:          token,
+		ApprovalsChannel:  req.ApprovalsChannel,
+		OutTodayChannel:   req.OutTodayChannel,
+		OutTodayHour:      9,
+		NotifyUsers:       true,
+		ConnectedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	}
+	if req.OutTodayHour != nil {
+		params.OutTodayHour = *req.OutTodayHour
+	}
+	if req.NotifyUsers != nil {
+		params.NotifyUsers = *req.NotifyUsers
+	}
+	workspace, err := s.store.UpsertSlackWorkspace(ctx, params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving Slack workspace: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, slackWorkspaceToResponse(workspace))
+}
+
+// disconnectSlackWorkspace handles DELETE /api/admin/slack/workspaces/{team_id}. The buttons of
+// requests already posted stop working.
+func (s *Server) disconnectSlackWorkspace(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeSlackAdmin(w, r); !ok {
+		return
+	}
+	deleted, err := s.store.DeleteSlackWorkspace(r.Context(), mux.Vars(r)["team_id"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting Slack workspace: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Slack workspace not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSlackInteraction handles POST /api/slack/interactions, the interactivity request URL of
+// the Slack app, where Slack sends the clicks on the buttons of leave requests. Admins, anyone up
+// the user's reporting line and the heads of their department or one above it may decide, found
+// by the email address of their Slack profile. The outcome replaces the request.
+func (s *Server) handleSlackInteraction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !s.config.Slack.Enabled() {
+		respondWithError(w, http.StatusServiceUnavailable, "Slack integration is disabled")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackInteractionSize))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error reading request body")
+		return
+	}
+	if err := slack.VerifyRequest(s.config.Slack.SigningSecret, r.Header, body, time.Now()); err != nil {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	var interaction slack.Interaction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	action := interaction.Actions[0]
+	if action.ActionID != slackActionApproveLeave && action.ActionID != slackActionRejectLeave {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	leaveLogID, err := strconv.Atoi(action.Value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid leave log ID")
+		return
+	}
+
+	workspace, err := s.store.GetSlackWorkspace(ctx, interaction.Team.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Slack workspace not connected")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching Slack workspace: "+err.Error())
+		return
+	}
+	client, err := s.slackClient(workspace)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error decrypting the bot token: "+err.Error())
+		return
+	}
+
+	reply := slack.Message{ReplaceOriginal: true}
+	// Tell only the member who clicked when they may not decide, the request stays
+	notApprover := slack.Message{ResponseType: "ephemeral", Text: "Only TableG admins and the approvers of the step the leave waits on can approve or reject it, found by the email address of their Slack profile."}
+	approver, ok := s.slackMemberUser(r, client, interaction.User.ID)
+	if !ok {
+		reply = notApprover
+	} else {
+		var pending bool
+		reply.Text, pending, err = s.decideLeaveRequest(ctx, workspace, approver, int32(leaveLogID), action.ActionID == slackActionApproveLeave)
+		if pending {
+			reply.Blocks = slackLeaveRequestBlocks(reply.Text, int32(leaveLogID))
+		}
+		if errors.Is(err, approval.ErrNotApprover) {
+			reply = notApprover
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Error deciding leave from Slack", "leave_log_id", leaveLogID, "error", err)
+			reply = slack.Message{ResponseType: "ephemeral", Text: "TableG couldn't save the decision, try again."}
+		}
+	}
+	if err := client.Respond(ctx, interaction.ResponseURL, reply); err != nil {
+		slog.WarnContext(ctx, "Error responding to a Slack interaction", "error", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// slackMemberUser returns the TableG user with the email address of a workspace member, false
+// when the member isn't one
+func (s *Server) slackMemberUser(r *http.Request, client *slack.Client, memberID string) (sqlc.User, bool) {
+	member, err := client.UserInfo(r.Context(), memberID)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Error reading a Slack member's profile", "member_id", memberID, "error", err)
+		return sqlc.User{}, false
+	}
+	if member.Profile.Email == "" {
+		return sqlc.User{}, false
+	}
+	user, err := s.store.GetUserByEmail(r.Context(), member.Profile.Email)
+	if err != nil {
+		return sqlc.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/slack"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// Admins connect Slack workspaces the Slack app is installed in. Leave users record for
+// themselves that waits on approval is posted to the workspace's approvals channel with approve
+// and reject buttons, for admins and the approvers of the step it waits on to click, see
+// approvals.go. Leave counts from when it is recorded, there is no pending state, so approving
+// confirms it and rejecting deletes it like an admin deleting it. Approving a step other than the
+// last keeps the buttons for the next one. The user is sent a DM once the leave is decided. Once a day
+// the workspace's out today channel gets who is on leave.
+
+// The action IDs of the buttons on leave requests
+const (
+	slackActionApproveLeave = "leave_approve"
+	slackActionRejectLeave  = "leave_reject"
+)
+
+// slackOutTodayLimit caps the leave listed in one who is out today message
+const slackOutTodayLimit = 200
+
+// slackLeaveRequestJob is the payload of a slack.leave_request job
+type slackLeaveRequestJob struct {
+	TeamID     string `json:"teamId"`
+	LeaveLogID int32  `json:"leaveLogId"`
+}
+
+// slackDirectMessageJob is the payload of a slack.direct_message job, a DM to the member with
+// the email address
+type slackDirectMessageJob struct {
+	TeamID string `json:"teamId"`
+	Email  string `json:"email"`
+	Text   string `json:"text"`
+}
+
+// slackClient returns a client acting as the workspace's bot
+func (s *Server) slackClient(workspace sqlc.SlackWorkspace) (*slack.Client, error) {
+	token, err := openToken(s.config.Slack.TokenEncryptionKey, workspace.BotToken)
+	if err != nil {
+		return nil, err
+	}
+	return slack.NewClient(s.config.Slack.APIURL, token), nil
+}
+
+// requestLeaveApproval queues posting leave a user recorded for themselves to the approvals
+// channel of every connected workspace that has one
+func (s *Server) requestLeaveApproval(ctx context.Context, leaveLog sqlc.LeaveLog) {
+	if !s.config.Slack.Enabled() {
+		return
+	}
+	workspaces, err := s.store.ListSlackWorkspaces(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing Slack workspaces", "error", err)
+		return
+	}
+	for _, workspace := range workspaces {
+		if workspace.ApprovalsChannel != "" {
+			s.enqueue(ctx, jobSlackLeaveRequest, slackLeaveRequestJob{TeamID: workspace.TeamID, LeaveLogID: leaveLog.ID})
+		}
+	}
+}
+
+// postSlackLeaveRequest runs a slack.leave_request job
+func (s *Server) postSlackLeaveRequest(ctx context.Context, payload json.RawMessage) error {
+	var job slackLeaveRequestJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return queue.Permanent(err)
+	}
+	workspace, client, err := s.slackWorkspaceClient(ctx, job.TeamID)
+	if err != nil || client == nil || workspace.ApprovalsChannel == "" {
+		return err
+	}
+	leaveLog, err := s.store.GetLeaveLog(ctx, job.LeaveLogID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Deleted since, there is nothing to approve
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	user, err := s.store.GetUser(ctx, leaveLog.UserID)
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("%s recorded %s leave on %s", user.Username, leaveLog.Type, formatSlackDate(leaveLog.Date))
+	if leaveLog.Note.Valid && leaveLog.Note.String != "" {
+		text += "\n>" + leaveLog.Note.String
+	}
+	err = client.PostMessage(ctx, slack.Message{
+		Channel: workspace.ApprovalsChannel,
+		Text:    text,
+		Blocks:  slackLeaveRequestBlocks(text, leaveLog.ID),
+	})
+	return slackJobError(err)
+}
+
+// slackLeaveRequestBlocks lays out a leave request, the text above the approve and reject buttons
+func slackLeaveRequestBlocks(text string, leaveLogID int32) []slack.Block {
+	value := strconv.Itoa(int(leaveLogID))
+	return []slack.Block{
+		slack.Section(text),
+		slack.Actions("leave_"+value,
+			slack.NewButton("Approve", slackActionApproveLeave, value, "primary"),
+			slack.NewButton("Reject", slackActionRejectLeave, value, "danger"),
+		),
+	}
+}
+
+// sendSlackDirectMessage runs a slack.direct_message job
+func (s *Server) sendSlackDirectMessage(ctx context.Context, payload json.RawMessage) error {
+	var job slackDirectMessageJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return queue.Permanent(err)
+	}
+	_, client, err := s.slackWorkspaceClient(ctx, job.TeamID)
+	if err != nil || client == nil {
+		return err
+	}
+	member, err := client.LookupUserByEmail(ctx, job.Email)
+	if err != nil {
+		return slackJobError(err)
+	}
+	return slackJobError(client.PostMessage(ctx, slack.Message{Channel: member.ID, Text: job.Text}))
+}
+
+// slackWorkspaceClient returns a connected workspace and its bot's client for a job, or no
+// client when the workspace was disconnected since
+func (s *Server) slackWorkspaceClient(ctx context.Context, teamID string) (sqlc.SlackWorkspace, *slack.Client, error) {
+	workspace, err := s.store.GetSlackWorkspace(ctx, teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return workspace, nil, nil
+	}
+	if err != nil {
+		return workspace, nil, err
+	}
+	client, err := s.slackClient(workspace)
+	if err != nil {
+		return workspace, nil, queue.Permanent(err)
+	}
+	return workspace, client, nil
+}
+
+// decideLeaveRequest approves or rejects the step leave waits on from the buttons of its request.
+// It returns the text replacing the request and whether the leave waits on a later step still,
+// keeping the buttons, or approval.ErrNotApprover when approver may not decide the step.
+func (s *Server) decideLeaveRequest(ctx context.Context, workspace sqlc.SlackWorkspace, approver sqlc.User, leaveLogID int32, approve bool) (string, bool, error) {
+	leaveLog, err := s.store.GetLeaveLog(ctx, leaveLogID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "This leave was deleted meanwhile.", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	user, err := s.store.GetUser(ctx, leaveLog.UserID)
+	if err != nil {
+		return "", false, err
+	}
+	request, err := s.store.GetPendingApprovalRequestBySubject(ctx, sqlc.GetPendingApprovalRequestBySubjectParams{
+		Kind:      approval.KindLeave,
+		SubjectID: leaveLog.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "This leave was decided already.", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	decided, err := s.decideApproval(ctx, request, approver, approve, "")
+	if errors.Is(err, approval.ErrDecided) {
+		return "This leave was decided already.", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	slog.InfoContext(ctx, "Leave decided in Slack", "leave_log_id", leaveLog.ID, "status", decided.Status, "approver_id", approver.ID)
+
+	date := formatSlackDate(leaveLog.Date)
+	if step, pending := approval.Step(decided); pending {
+		return fmt.Sprintf("%s's %s leave on %s was approved by %s and waits on %s now.", user.Username, leaveLog.Type, date, approver.Username, approval.StepLabel(step)), true, nil
+	}
+	if workspace.NotifyUsers {
+		s.enqueue(ctx, jobSlackDirectMessage, slackDirectMessageJob{
+			TeamID: workspace.TeamID,
+			Email:  user.Email,
+			Text:   fmt.Sprintf("Your %s leave on %s was %s by %s.", leaveLog.Type, date, decided.Status, approver.Username),
+		})
+	}
+	return fmt.Sprintf("%s's %s leave on %s was %s by %s.", user.Username, leaveLog.Type, date, decided.Status, approver.Username), false, nil
+}
+
+// scheduleSlackOutToday posts who is out today to the workspaces that want it, checking every
+// hour which workspaces' hour has come
+func (s *Server) scheduleSlackOutToday() {
+	if !s.config.Slack.Enabled() {
+		return
+	}
+	s.scheduler.Register(scheduler.Job{
+		Name:        "slack-out-today",
+		Description: "Post who is on leave to the out today channel of the connected Slack workspaces",
+		Schedule:    scheduler.MustParse("0 * * * *"),
+		Run: func(ctx context.Context) error {
+			var errs []error
+			s.forEachTenant(ctx, func(ctx context.Context) {
+				if err := s.postSlackOutToday(ctx, time.Now().In(s.timeZone)); err != nil {
+					errs = append(errs, err)
+				}
+			})
+			return errors.Join(errs...)
+		},
+	})
+}
+
+// postSlackOutToday posts who is out on the day of now to each workspace whose hour has come and
+// that hasn't had it yet, skipping weekends and holidays. The day and hour are those of now's
+// location, the company's.
+func (s *Server) postSlackOutToday(ctx context.Context, now time.Time) error {
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return nil
+	}
+	today := pgtype.Date{Time: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), Valid: true}
+	if _, err := s.store.GetHolidayByDate(ctx, today); err == nil {
+		return nil
+	}
+
+	workspaces, err := s.store.ListSlackWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	var text string
+	var errs []error
+	for _, workspace := range workspaces {
+		if workspace.OutTodayChannel == "" || now.Hour() < int(workspace.OutTodayHour) ||
+			(workspace.OutTodayPostedOn.Valid && workspace.OutTodayPostedOn.Time.Equal(today.Time)) {
+			continue
+		}
+		if text == "" {
+			if text, err = s.slackOutTodayText(ctx, today); err != nil {
+				return err
+			}
+		}
+
+		client, err := s.slackClient(workspace)
+		if err == nil {
+			err = client.PostMessage(ctx, slack.Message{Channel: workspace.OutTodayChannel, Text: text})
+		}
+		if err == nil {
+			err = s.store.MarkSlackOutTodayPosted(ctx, sqlc.MarkSlackOutTodayPostedParams{TeamID: workspace.TeamID, OutTodayPostedOn: today})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Error posting who is out today to Slack", "team_id", workspace.TeamID, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// slackOutTodayText lists the users on leave on a day
+func (s *Server) slackOutTodayText(ctx context.Context, day pgtype.Date) (string, error) {
+	leaveLogs, err := s.store.ListLeaveLogsWithUsername(ctx, sqlc.ListLeaveLogsWithUsernameParams{
+		DateFrom: day,
+		DateTo:   day,
+		SortBy:   "date",
+		RowLimit: slackOutTodayLimit,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(leaveLogs) == 0 {
+		return fmt.Sprintf("Everyone is in today, %s.", formatSlackDate(day)), nil
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "*Out today, %s:*", formatSlackDate(day))
+	for _, leaveLog := range leaveLogs {
+		fmt.Fprintf(&text, "\n• %s, %s", leaveLog.Username, leaveLog.Type)
+	}
+	return text.String(), nil
+}
+
+// formatSlackDate formats a date like Mon 2 Nov 2026
+func formatSlackDate(date pgtype.Date) string {
+	return date.Time.Format("Mon 2 Jan 2006")
+}
+
+// slackJobError makes the Slack errors retrying won't fix, such as a channel the bot isn't in,
+// end a queued job
+func slackJobError(err error) error {
+	var apiErr *slack.APIError
+	if errors.As(err, &apiErr) && !apiErr.IsTransient() {
+		return queue.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kengtableg/pkeng-tableg/web"
+)
+
+// With SERVE_UI=true the server serves the front-end build embedded in it, so a small install
+// needs no separate web server. The app routes in the browser, so a path that isn't a file of the
+// build, like /tasks/12, gets index.html and the app shows the page. The build's static/ files
+// have the content hash in their names and are cached for a year; the rest, index.html above
+// all, carry an ETag and Cache-Control: no-cache, so a new release shows up on the next load.
+
+// uiImmutableCacheControl is the Cache-Control of the build's hashed static/ files
+const uiImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// registerStaticUI serves the embedded UI at every GET outside the API. Register it after the
+// API routes, it matches any path.
+func (s *Server) registerStaticUI(r *mux.Router) {
+	build, ok := web.Build()
+	if !ok {
+		slog.Warn("SERVE_UI is set but the server was built without the UI, run npm run build:embed before building it")
+		return
+	}
+	r.PathPrefix("/").Handler(staticUIHandler(build)).Methods("GET", "HEAD")
+}
+
+// staticUIHandler serves the files of build, and index.html for the paths of the app's pages
+func staticUIHandler(build fs.FS) http.Handler {
+	etags := uiETags(build)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" || strings.HasPrefix(r.URL.Path, "/api/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if info, err := fs.Stat(build, name); name == "" || err != nil || info.IsDir() {
+			if path.Ext(name) != "" {
+				// A missing file, such as an asset of an older release, rather than a page
+				http.NotFound(w, r)
+				return
+			}
+			name = "index.html"
+		}
+
+		if strings.HasPrefix(name, "static/") {
+			w.Header().Set("Cache-Control", uiImmutableCacheControl)
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", etags[name])
+		}
+		http.ServeFileFS(w, r, build, name)
+	})
+}
+
+// uiETags hashes the files of build outside static/, whose names don't change between releases
+func uiETags(build fs.FS) map[string]string {
+	etags := make(map[string]string)
+	fs.WalkDir(build, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(name, "static/") {
+			return nil
+		}
+		content, err := fs.ReadFile(build, name)
+		if err != nil {
+			return nil
+		}
+		sum := sha256.Sum256(content)
+		etags[name] = `"` + hex.EncodeToString(sum[:16]) + `"`
+		return nil
+	})
+	return etags
+}
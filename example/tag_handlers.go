@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// TagResponse is the response format for a tag
+type TagResponse struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// TagRequest represents the request body for creating or updating a tag
+type TagRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Color string `json:"color" validate:"max=20"`
+}
+
+// TaskTagRequest represents the request body for tagging a task, by tag ID or by name.
+// Tagging by name creates the tag when it does not exist yet.
+type TaskTagRequest struct {
+	TagID int32  `json:"tag_id"`
+	Name  string `json:"name" validate:"max=100"`
+}
+
+// TagReportEntry is the logged effort on tasks carrying a tag
+type TagReportEntry struct {
+	TagID     int32   `json:"tag_id"`
+	Name      string  `json:"name"`
+	Color     string  `json:"color,omitempty"`
+	TaskCount int64   `json:"task_count"`
+	WorkedDay float64 `json:"worked_day"`
+}
+
+// clickUpTagSyncEnabled reports whether task tags are mirrored to and from ClickUp
+func (s *Server) clickUpTagSyncEnabled() bool {
+	return s.settings.Config().ClickUp.SyncTags
+}
+
+func (s *Server) getTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tags, err := s.store.ListTags(ctx)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+
+	response := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, newTagResponse(tag))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	name := strings.TrimSpace(req.Name)
+
+	tag, err := s.store.CreateTag(ctx, sqlc.CreateTagParams{
+		Name:  name,
+		Color: pgtype.Text{String: req.Color, Valid: req.Color != ""},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating tag: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newTagResponse(tag))
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	// Tags are shared, so only admins can rename them
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can update tags")
+		return
+	}
+
+	var req TagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	name := strings.TrimSpace(req.Name)
+
+	if _, err := s.store.GetTag(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	tag, err := s.store.UpdateTag(ctx, sqlc.UpdateTagParams{
+		ID:    int32(id),
+		Name:  name,
+		Color: pgtype.Text{String: req.Color, Valid: req.Color != ""},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating tag: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newTagResponse(tag))
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can delete tags")
+		return
+	}
+
+	if _, err := s.store.GetTag(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	// Task links are removed by the foreign key cascade
+	if err := s.store.DeleteTag(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting tag: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (s *Server) getTaskTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	s.respondWithTaskTags(ctx, w, int32(taskID))
+}
+
+func (s *Server) addTaskTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	var req TaskTagRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	task, err := s.store.GetTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	// Resolve the tag by ID, or by name creating it on first use
+	var tag sqlc.Tag
+	switch name := strings.TrimSpace(req.Name); {
+	case req.TagID != 0:
+		tag, err = s.store.GetTag(ctx, req.TagID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+	case name != "":
+		tag, err = s.store.UpsertTagByName(ctx, sqlc.UpsertTagByNameParams{Name: name})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating tag: "+err.Error())
+			return
+		}
+	default:
+		respondWithValidationError(w, validate.Errors{"tag_id": "is required when name is empty"})
+		return
+	}
+
+	// Adding a tag the task already has is a no-op
+	err = s.store.AddTaskTag(ctx, sqlc.AddTaskTagParams{
+		TaskID: task.ID,
+		TagID:  tag.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error tagging task: "+err.Error())
+		return
+	}
+
+	s.syncTaskTagToClickUp(r.Context(), task, tag.Name, true)
+
+	s.respondWithTaskTags(ctx, w, task.ID)
+}
+
+func (s *Server) removeTaskTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	tagID, err := strconv.Atoi(vars["tag_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	task, err := s.store.GetTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	tag, err := s.store.GetTag(ctx, int32(tagID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	removed, err := s.store.RemoveTaskTag(ctx, sqlc.RemoveTaskTagParams{
+		TaskID: task.ID,
+		TagID:  tag.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error removing tag: "+err.Error())
+		return
+	}
+
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "Task does not have this tag")
+		return
+	}
+
+	s.syncTaskTagToClickUp(r.Context(), task, tag.Name, false)
+
+	s.respondWithTaskTags(ctx, w, task.ID)
+}
+
+// getTagReport sums the days logged between start_date and end_date per tag
+func (s *Server) getTagReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	startDate, err := time.Parse("2006-01-02", r.URL.Query().Get("start_date"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid start date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", r.URL.Query().Get("end_date"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid end date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rows, err := s.store.ListTagWorkedDays(ctx, sqlc.ListTagWorkedDaysParams{
+		StartDate: pgtype.Date{Time: startDate, Valid: true},
+		EndDate:   pgtype.Date{Time: endDate, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tag report: "+err.Error())
+		return
+	}
+
+	response := make([]TagReportEntry, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, TagReportEntry{
+			TagID:     row.ID,
+			Name:      row.Name,
+			Color:     row.Color.String,
+			TaskCount: row.TaskCount,
+			WorkedDay: numericToFloat64(row.WorkedDay, 0),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// respondWithTaskTags writes the current tag list of a task
+func (s *Server) respondWithTaskTags(ctx context.Context, w http.ResponseWriter, taskID int32) {
+	tags, err := s.store.ListTaskTags(ctx, taskID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+
+	response := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, newTagResponse(tag))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// attachTaskTags fills in the tags of each task with a single query
+func (s *Server) attachTaskTags(ctx context.Context, tasks []TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int32, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	rows, err := s.store.ListTaskTagsByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	tagsByTask := make(map[int32][]TagResponse)
+	for _, row := range rows {
+		tagsByTask[row.TaskID] = append(tagsByTask[row.TaskID], TagResponse{
+			ID:    row.ID,
+			Name:  row.Name,
+			Color: row.Color.String,
+		})
+	}
+
+	for i := range tasks {
+		if tags, ok := tagsByTask[tasks[i].ID]; ok {
+			tasks[i].Tags = tags
+		}
+	}
+
+	return nil
+}
+
+// syncTaskTagToClickUp mirrors a local tag change onto the linked ClickUp task when tag sync is enabled
+func (s *Server) syncTaskTagToClickUp(ctx context.Context, task sqlc.Task, tagName string, added bool) {
+	if !s.clickUpTagSyncEnabled() || !task.Url.Valid {
+		return
+	}
+
+	clickupTaskID := clickup.ExtractTaskIDFromURL(task.Url.String)
+	if clickupTaskID == "" {
+		return
+	}
+
+	client := s.clickUp.Shared()
+	if !client.Enabled() {
+		return
+	}
+	var err error
+	if added {
+		err = client.AddTagToTask(ctx, clickupTaskID, tagName)
+	} else {
+		err = client.RemoveTagFromTask(ctx, clickupTaskID, tagName)
+	}
+	if err != nil {
+		// The local change stands even when ClickUp rejects it
+		slog.WarnContext(ctx, "Failed to sync tag to ClickUp", "tag", tagName, "task_id", task.ID, "error", err)
+	}
+}
+
+func newTagResponse(tag sqlc.Tag) TagResponse {
+	return TagResponse{
+		ID:    tag.ID,
+		Name:  tag.Name,
+		Color: tag.Color.String,
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// Names of the supported task backends, as given in task requests
+const (
+	taskBackendClickUp = "clickup"
+	taskBackendJira    = "jira"
+)
+
+// RemoteTaskRequest describes a task to create in or update on a task backend
+type RemoteTaskRequest struct {
+	Title       string
+	Note        string
+	Status      string
+	ContainerID string // ClickUp list or Jira project key to create the task in
+	ParentID    string // Remote ID of the parent task, makes this a subtask
+	// Local custom field values, only pushed by backends that support them
+	CustomFields map[string]string
+}
+
+// RemoteTask is a task as stored by a task backend
+type RemoteTask struct {
+	ID        string
+	URL       string
+	Title     string
+	Note      string
+	Status    string
+	TeamID    string // ClickUp workspace, empty for other backends
+	UpdatedAt time.Time
+}
+
+// TaskBackend is an external tracker that local tasks can be linked to
+type TaskBackend interface {
+	// Name returns the backend name used in task requests
+	Name() string
+	// Enabled reports whether the backend is configured, tasks stay local only otherwise
+	Enabled() bool
+	// CreateTask creates a task in the tracker
+	CreateTask(ctx context.Context, req RemoteTaskRequest) (*RemoteTask, error)
+	// UpdateTask sends a local change to the linked task
+	UpdateTask(ctx context.Context, remoteID string, req RemoteTaskRequest) (*RemoteTask, error)
+	// ExtractID returns the remote ID of a task URL, or an empty string for URLs of other trackers
+	ExtractID(url string) string
+	// Sync reconciles a linked local task with the tracker
+	Sync(ctx context.Context, task db.Task) (*TaskSyncResult, error)
+}
+
+// taskBackendNamed returns the backend with the given name, acting as the user where the backend
+// supports per-user tokens. An empty name selects ClickUp.
+func (s *Server) taskBackendNamed(ctx context.Context, store db.Querier, name string, userID int32, clickupTeamID string) (TaskBackend, error) {
+	switch name {
+	case "", taskBackendClickUp:
+		client := s.clickUp.Shared()
+		if userID != 0 {
+			client = s.clickUp.ForUser(ctx, store, userID, clickupTeamID)
+		}
+		return NewClickUpTaskBackend(store, client, s.clickUp), nil
+	case taskBackendJira:
+		return NewJiraTaskBackend(store, s.jira, s.config.Jira.ProjectKey), nil
+	}
+	return nil, fmt.Errorf("unknown task backend %q", name)
+}
+
+// taskBackendForTask returns the backend the task's URL links to, or nil for local-only tasks
+func (s *Server) taskBackendForTask(ctx context.Context, store db.Querier, task db.Task, userID int32) TaskBackend {
+	if !task.Url.Valid || task.Url.String == "" {
+		return nil
+	}
+
+	for _, name := range []string{taskBackendJira, taskBackendClickUp} {
+		backend, err := s.taskBackendNamed(ctx, store, name, userID, task.ClickupTeamID.String)
+		if err == nil && backend.ExtractID(task.Url.String) != "" {
+			return backend
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// Task activity types
+const (
+	taskActivityStatusChanged   = "status_changed"
+	taskActivityEstimateAdded   = "estimate_added"
+	taskActivityEstimateChanged = "estimate_changed"
+	taskActivityEstimateDeleted = "estimate_deleted"
+	taskActivityLogAdded        = "log_added"
+	taskActivityCommentAdded    = "comment_added"
+)
+
+// TaskActivityResponse is the response format for a task activity feed entry
+type TaskActivityResponse struct {
+	ID           int32     `json:"id"`
+	TaskID       int32     `json:"task_id"`
+	UserID       *int32    `json:"user_id"` // Nil for changes made by the ClickUp sync
+	Username     string    `json:"username,omitempty"`
+	ActivityType string    `json:"activity_type"`
+	OldValue     *string   `json:"old_value,omitempty"`
+	NewValue     *string   `json:"new_value,omitempty"`
+	ReferenceID  *int32    `json:"reference_id,omitempty"` // Estimate, log or comment ID
+	CommentBody  *string   `json:"comment_body,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	RequestID    string    `json:"request_id,omitempty"` // The X-Request-ID of the request that made the change
+}
+
+func (s *Server) getTaskActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	// Parse pagination parameters
+	limit := 50
+	offset := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+	if offsetParam := r.URL.Query().Get("offset"); offsetParam != "" {
+		parsedOffset, err := strconv.Atoi(offsetParam)
+		if err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	activities, err := s.store.ListTaskActivities(ctx, sqlc.ListTaskActivitiesParams{
+		TaskID: int32(taskID),
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task activity: "+err.Error())
+		return
+	}
+	total, err := s.store.CountTaskActivities(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting task activity: "+err.Error())
+		return
+	}
+
+	response := make([]TaskActivityResponse, 0, len(activities))
+	for _, activity := range activities {
+		response = append(response, TaskActivityResponse{
+			ID:           activity.ID,
+			TaskID:       activity.TaskID,
+			UserID:       int4Ptr(activity.UserID),
+			Username:     activity.Username.String,
+			ActivityType: activity.ActivityType,
+			OldValue:     textPtr(activity.OldValue),
+			NewValue:     textPtr(activity.NewValue),
+			ReferenceID:  int4Ptr(activity.ReferenceID),
+			CommentBody:  textPtr(activity.CommentBody),
+			CreatedAt:    activity.CreatedAt.Time,
+			RequestID:    activity.RequestID.String,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
+}
+
+// recordTaskActivity adds an entry to a task's activity feed, logging rather than failing on error.
+// A zero userID records the change as made by the system, and the request ID of ctx which request
+// made it.
+func recordTaskActivity(ctx context.Context, store sqlc.Querier, taskID, userID int32, activityType, oldValue, newValue string, referenceID int32) {
+	requestID := logging.RequestID(ctx)
+	err := store.CreateTaskActivity(ctx, sqlc.CreateTaskActivityParams{
+		TaskID:       taskID,
+		UserID:       pgtype.Int4{Int32: userID, Valid: userID != 0},
+		ActivityType: activityType,
+		OldValue:     pgtype.Text{String: oldValue, Valid: oldValue != ""},
+		NewValue:     pgtype.Text{String: newValue, Valid: newValue != ""},
+		ReferenceID:  pgtype.Int4{Int32: referenceID, Valid: referenceID != 0},
+		RequestID:    pgtype.Text{String: requestID, Valid: requestID != ""},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record task activity", "activity", activityType, "task_id", taskID, "error", err)
+	}
+}
+
+// formatDays renders a day amount for the activity feed
+func formatDays(days float64) string {
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// int4Ptr converts a nullable integer to a pointer for JSON responses
+func int4Ptr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+// textPtr converts a nullable text to a pointer for JSON responses
+func textPtr(v pgtype.Text) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
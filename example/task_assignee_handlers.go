@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TaskAssigneeResponse is the response format for a user assigned to a task
+type TaskAssigneeResponse struct {
+	UserID     int32     `json:"user_id"`
+	Username   string    `json:"username"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// TaskAssigneeRequest represents the request body for assigning a user to a task
+type TaskAssigneeRequest struct {
+	UserID int32 `json:"user_id"`
+}
+
+func (s *Server) getTaskAssignees(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	s.respondWithTaskAssignees(ctx, w, int32(taskID))
+}
+
+func (s *Server) assignTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	var req TaskAssigneeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	// Check that the task exists and the current user may change it
+	task, err := s.store.GetTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	currentUser, ok := s.authorizeTaskChange(ctx, w, r, task)
+	if !ok {
+		return
+	}
+
+	// Default to assigning the current user
+	if req.UserID == 0 {
+		req.UserID = currentUser.ID
+	}
+
+	// Check that the user exists
+	if _, err := s.store.GetUser(ctx, req.UserID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	// Assigning an already assigned user is a no-op
+	err = s.store.AssignTask(ctx, sqlc.AssignTaskParams{
+		TaskID:           int32(taskID),
+		UserID:           req.UserID,
+		AssignedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error assigning task: "+err.Error())
+		return
+	}
+
+	if req.UserID != currentUser.ID {
+		s.notify(ctx, req.UserID, notificationTaskAssigned, taskAssignedEvent{
+			AssignedBy: currentUser.Username,
+			TaskID:     task.ID,
+			TaskTitle:  task.Title.String,
+		})
+	}
+
+	s.respondWithTaskAssignees(ctx, w, int32(taskID))
+}
+
+func (s *Server) unassignTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	userID, err := strconv.Atoi(vars["user_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	task, err := s.store.GetTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	removed, err := s.store.UnassignTask(ctx, sqlc.UnassignTaskParams{
+		TaskID: int32(taskID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unassigning task: "+err.Error())
+		return
+	}
+
+	if removed == 0 {
+		respondWithError(w, http.StatusNotFound, "User is not assigned to this task")
+		return
+	}
+
+	s.respondWithTaskAssignees(ctx, w, int32(taskID))
+}
+
+// respondWithTaskAssignees writes the current assignee list of a task
+func (s *Server) respondWithTaskAssignees(ctx context.Context, w http.ResponseWriter, taskID int32) {
+	assignees, err := s.store.ListTaskAssignees(ctx, taskID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
+	}
+
+	response := make([]TaskAssigneeResponse, 0, len(assignees))
+	for _, assignee := range assignees {
+		response = append(response, TaskAssigneeResponse{
+			UserID:     assignee.UserID,
+			Username:   assignee.Username,
+			AssignedAt: assignee.AssignedAt.Time,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// attachTaskAssignees fills in the assignees of each task with a single query
+func (s *Server) attachTaskAssignees(ctx context.Context, tasks []TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int32, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	rows, err := s.store.ListTaskAssigneesByTaskIDs(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	assigneesByTask := make(map[int32][]TaskAssigneeResponse)
+	for _, row := range rows {
+		assigneesByTask[row.TaskID] = append(assigneesByTask[row.TaskID], TaskAssigneeResponse{
+			UserID:     row.UserID,
+			Username:   row.Username,
+			AssignedAt: row.AssignedAt.Time,
+		})
+	}
+
+	for i := range tasks {
+		if assignees, ok := assigneesByTask[tasks[i].ID]; ok {
+			tasks[i].Assignees = assignees
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+)
+
+// BurndownPoint is one day of logged effort on a task
+type BurndownPoint struct {
+	Date          string   `json:"date"` // yyyy-MM-dd
+	WorkedDay     float64  `json:"worked_day"`
+	CumulativeDay float64  `json:"cumulative_day"`
+	RemainingDay  *float64 `json:"remaining_day,omitempty"` // Only when the task has an estimate
+}
+
+// TaskBurndownResponse compares a task's current estimate with its logged effort over time.
+// Estimates and logs of subtasks roll up into their parent.
+type TaskBurndownResponse struct {
+	TaskID                  int32           `json:"task_id"`
+	EstimateDay             *float64        `json:"estimate_day"`
+	EstimatedTaskCount      int64           `json:"estimated_task_count"` // The task and subtasks with an estimate
+	EstimateCreatedAt       *time.Time      `json:"estimate_created_at,omitempty"`
+	LoggedDay               float64         `json:"logged_day"`
+	RemainingDay            *float64        `json:"remaining_day"`
+	OverrunDay              float64         `json:"overrun_day"`
+	IsOverrun               bool            `json:"is_overrun"`
+	StartDate               *string         `json:"start_date"`
+	LastLoggedDate          *string         `json:"last_logged_date"`
+	AverageDailyBurn        float64         `json:"average_daily_burn"` // Logged days per calendar day since the first log
+	ProjectedCompletionDate *string         `json:"projected_completion_date"`
+	Points                  []BurndownPoint `json:"points"`
+}
+
+func (s *Server) getTaskBurndown(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	// The task's own current estimate, if any
+	current, err := s.store.GetCurrentTaskEstimate(ctx, int32(taskID))
+	hasCurrent := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task estimates: "+err.Error())
+		return
+	}
+
+	// Total of the latest estimates across the task and its subtasks
+	rollup, err := s.store.GetTaskEstimateRollup(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task estimates: "+err.Error())
+		return
+	}
+
+	totals, err := s.store.ListTaskLogDailyTotals(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
+		return
+	}
+
+	response := TaskBurndownResponse{
+		TaskID: int32(taskID),
+		Points: make([]BurndownPoint, 0, len(totals)),
+	}
+
+	var estimate float64
+	hasEstimate := rollup.EstimatedTaskCount > 0
+	if hasEstimate {
+		estimate = numericToFloat64(rollup.EstimateDay, 0)
+		response.EstimateDay = &estimate
+		response.EstimatedTaskCount = rollup.EstimatedTaskCount
+	}
+	if hasCurrent && current.CreatedAt.Valid {
+		createdAt := current.CreatedAt.Time
+		response.EstimateCreatedAt = &createdAt
+	}
+
+	// Build cumulative points and remember when the estimate was used up
+	var completedOn *time.Time
+	for _, total := range totals {
+		point := BurndownPoint{
+			Date:          total.WorkedDate.Time.Format("2006-01-02"),
+			WorkedDay:     numericToFloat64(total.WorkedDay, 0),
+			CumulativeDay: numericToFloat64(total.CumulativeDay, 0),
+		}
+		if hasEstimate {
+			remaining := math.Max(estimate-point.CumulativeDay, 0)
+			point.RemainingDay = &remaining
+			if completedOn == nil && point.CumulativeDay >= estimate {
+				date := total.WorkedDate.Time
+				completedOn = &date
+			}
+		}
+		response.Points = append(response.Points, point)
+	}
+
+	if len(totals) > 0 {
+		first := totals[0].WorkedDate.Time
+		last := totals[len(totals)-1].WorkedDate.Time
+		startDate := first.Format("2006-01-02")
+		lastLoggedDate := last.Format("2006-01-02")
+		response.StartDate = &startDate
+		response.LastLoggedDate = &lastLoggedDate
+		response.LoggedDay = response.Points[len(response.Points)-1].CumulativeDay
+
+		// Average burn over the calendar days the task has been worked on
+		spanDays := last.Sub(first).Hours()/24 + 1
+		response.AverageDailyBurn = response.LoggedDay / spanDays
+	}
+
+	if hasEstimate {
+		remaining := math.Max(estimate-response.LoggedDay, 0)
+		response.RemainingDay = &remaining
+		response.OverrunDay = math.Max(response.LoggedDay-estimate, 0)
+		response.IsOverrun = response.LoggedDay > estimate
+
+		// Project completion from the average burn, or report when the estimate was reached
+		switch {
+		case completedOn != nil:
+			projected := completedOn.Format("2006-01-02")
+			response.ProjectedCompletionDate = &projected
+		case response.AverageDailyBurn > 0:
+			last := totals[len(totals)-1].WorkedDate.Time
+			daysLeft := int(math.Ceil(remaining / response.AverageDailyBurn))
+			projected := last.AddDate(0, 0, daysLeft).Format("2006-01-02")
+			response.ProjectedCompletionDate = &projected
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
 )
 
@@ -17,6 +18,11 @@ type TaskCategoryResponse struct {
 	Name        string                 `json:"name"`
 	ParentID    *int32                 `json:"parent_id,omitempty"`
 	Description string                 `json:"description,omitempty"`
+	Depth       *int32                 `json:"depth,omitempty"` // Only set in the hierarchical listing
+	Path        []string               `json:"path,omitempty"`  // Category names from the root down
+	BudgetDay   *float64               `json:"budget_day,omitempty"`
+	Department  string                 `json:"department,omitempty"` // Only users of this department see the category and its subtree
+	SortOrder   int32                  `json:"sort_order"`
 	CreatedAt   pgtype.Timestamptz     `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz     `json:"updated_at"`
 	Children    []TaskCategoryResponse `json:"children,omitempty"`
@@ -24,13 +30,15 @@ type TaskCategoryResponse struct {
 
 // TaskCategoryRequest represents the request body for creating or updating a task category
 type TaskCategoryRequest struct {
-	Name        string `json:"name"`
-	ParentID    *int32 `json:"parent_id"`
-	Description string `json:"description"`
+	Name        string   `json:"name" validate:"required,max=255"`
+	ParentID    *int32   `json:"parent_id"`
+	Description string   `json:"description"`
+	BudgetDay   *float64 `json:"budget_day" validate:"min=0"`   // Optional effort budget in days for the whole subtree
+	Department  string   `json:"department" validate:"max=100"` // Optional, scopes the category and its subtree to one department
 }
 
-func getTaskCategories(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse pagination parameters
 	limit := 50
@@ -53,15 +61,31 @@ func getTaskCategories(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get task categories from database
-	categories, err := database.ListTaskCategories(ctx, sqlc.ListTaskCategoriesParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+	// Get the task categories visible to the current user from database
+	allDepartments, department := s.categoryVisibility(r)
+	if version, err := s.store.GetTaskCategoriesVersion(ctx); err != nil {
+		slog.WarnContext(ctx, "Error reading task categories version, answering without an ETag", "error", err)
+	} else if notModified(w, r, version.RowCount, version.LastUpdated, allDepartments, department.String) {
+		return
+	}
+	categories, err := s.store.ListTaskCategories(ctx, sqlc.ListTaskCategoriesParams{
+		AllDepartments: allDepartments,
+		Department:     department,
+		Limit:          int32(limit),
+		Offset:         int32(offset),
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task categories: "+err.Error())
 		return
 	}
+	total, err := s.store.CountTaskCategories(ctx, sqlc.CountTaskCategoriesParams{
+		AllDepartments: allDepartments,
+		Department:     department,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting task categories: "+err.Error())
+		return
+	}
 
 	// Convert to response format
 	response := make([]TaskCategoryResponse, 0, len(categories))
@@ -76,16 +100,19 @@ func getTaskCategories(w http.ResponseWriter, r *http.Request) {
 			Name:        category.Name,
 			ParentID:    parentID,
 			Description: category.Description.String,
+			BudgetDay:   numericPtr(category.BudgetDay),
+			Department:  category.Department.String,
+			SortOrder:   category.SortOrder,
 			CreatedAt:   category.CreatedAt,
 			UpdatedAt:   category.UpdatedAt,
 		})
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
 }
 
-func getTaskCategory(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -94,7 +121,7 @@ func getTaskCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	category, err := database.GetTaskCategory(ctx, int32(id))
+	category, err := s.store.GetTaskCategory(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task category not found")
 		return
@@ -110,6 +137,9 @@ func getTaskCategory(w http.ResponseWriter, r *http.Request) {
 		Name:        category.Name,
 		ParentID:    parentID,
 		Description: category.Description.String,
+		BudgetDay:   numericPtr(category.BudgetDay),
+		Department:  category.Department.String,
+		SortOrder:   category.SortOrder,
 		CreatedAt:   category.CreatedAt,
 		UpdatedAt:   category.UpdatedAt,
 	}
@@ -117,18 +147,11 @@ func getTaskCategory(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func createTaskCategory(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	var req TaskCategoryRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
-
-	// Validate request
-	if req.Name == "" {
-		respondWithError(w, http.StatusBadRequest, "Name is required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -136,6 +159,8 @@ func createTaskCategory(w http.ResponseWriter, r *http.Request) {
 	params := sqlc.CreateTaskCategoryParams{
 		Name:        req.Name,
 		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		BudgetDay:   categoryBudget(req.BudgetDay),
+		Department:  pgtype.Text{String: req.Department, Valid: req.Department != ""},
 	}
 
 	// Set parent_id if provided
@@ -144,7 +169,7 @@ func createTaskCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create task category in database
-	category, err := database.CreateTaskCategory(ctx, params)
+	category, err := s.store.CreateTaskCategory(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating task category: "+err.Error())
 		return
@@ -160,6 +185,9 @@ func createTaskCategory(w http.ResponseWriter, r *http.Request) {
 		Name:        category.Name,
 		ParentID:    parentID,
 		Description: category.Description.String,
+		BudgetDay:   numericPtr(category.BudgetDay),
+		Department:  category.Department.String,
+		SortOrder:   category.SortOrder,
 		CreatedAt:   category.CreatedAt,
 		UpdatedAt:   category.UpdatedAt,
 	}
@@ -167,8 +195,8 @@ func createTaskCategory(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, response)
 }
 
-func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -178,8 +206,7 @@ func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req TaskCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -188,6 +215,8 @@ func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
 		ID:          int32(id),
 		Name:        req.Name,
 		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		BudgetDay:   categoryBudget(req.BudgetDay),
+		Department:  pgtype.Text{String: req.Department, Valid: req.Department != ""},
 	}
 
 	// Set parent_id if provided
@@ -197,8 +226,19 @@ func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
 		params.ParentID = pgtype.Int4{Valid: false}
 	}
 
+	// Reparenting goes through the same checks as the move endpoint
+	rows, err := s.store.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{AllDepartments: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+		return
+	}
+	if err := validateTaskCategoryMove(rows, int32(id), req.ParentID, s.taskCategoryMaxDepth()); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Update task category in database
-	category, err := database.UpdateTaskCategory(ctx, params)
+	category, err := s.store.UpdateTaskCategory(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating task category: "+err.Error())
 		return
@@ -214,6 +254,9 @@ func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
 		Name:        category.Name,
 		ParentID:    parentID,
 		Description: category.Description.String,
+		BudgetDay:   numericPtr(category.BudgetDay),
+		Department:  category.Department.String,
+		SortOrder:   category.SortOrder,
 		CreatedAt:   category.CreatedAt,
 		UpdatedAt:   category.UpdatedAt,
 	}
@@ -221,8 +264,26 @@ func updateTaskCategory(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func deleteTaskCategory(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// Deletion modes for task categories that still have children or tasks
+const (
+	taskCategoryDeleteBlock    = "block"
+	taskCategoryDeleteArchive  = "archive"
+	taskCategoryDeleteReassign = "reassign"
+)
+
+// TaskCategoryInUseResponse is returned when a category cannot be deleted in block mode
+type TaskCategoryInUseResponse struct {
+	Error      string `json:"error"`
+	ChildCount int64  `json:"child_count"`
+	TaskCount  int64  `json:"task_count"`
+}
+
+// deleteTaskCategory removes a category according to the mode query parameter:
+// block (default) refuses while children or tasks exist, archive hides the whole
+// subtree and keeps its tasks, and reassign moves tasks and children to target_id
+// before deleting. All changes run in one transaction.
+func (s *Server) deleteTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -231,62 +292,558 @@ func deleteTaskCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteTaskCategory(ctx, int32(id)); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error deleting task category: "+err.Error())
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = taskCategoryDeleteBlock
+	}
+
+	var targetID int32
+	switch mode {
+	case taskCategoryDeleteBlock, taskCategoryDeleteArchive:
+	case taskCategoryDeleteReassign:
+		parsedTarget, err := strconv.Atoi(r.URL.Query().Get("target_id"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "target_id is required when mode is reassign")
+			return
+		}
+		targetID = int32(parsedTarget)
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid mode, expected block, archive or reassign")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
-}
+	if _, err := s.store.GetTaskCategory(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task category not found")
+		return
+	}
 
-func getHierarchicalTaskCategories(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		switch mode {
+		case taskCategoryDeleteBlock:
+			usage, err := qtx.CountTaskCategoryUsage(ctx, int32(id))
+			if err != nil {
+				return txError(http.StatusInternalServerError, "Error checking task category usage: "+err.Error())
+			}
+			if usage.ChildCount > 0 || usage.TaskCount > 0 {
+				return &txResponseError{code: http.StatusConflict, payload: TaskCategoryInUseResponse{
+					Error:      "Task category still has subcategories or tasks, use mode=archive or mode=reassign",
+					ChildCount: usage.ChildCount,
+					TaskCount:  usage.TaskCount,
+				}}
+			}
+
+			if err := qtx.DeleteTaskCategory(ctx, int32(id)); err != nil {
+				return txError(http.StatusInternalServerError, "Error deleting task category: "+err.Error())
+			}
+
+		case taskCategoryDeleteArchive:
+			if _, err := qtx.ArchiveTaskCategorySubtree(ctx, int32(id)); err != nil {
+				return txError(http.StatusInternalServerError, "Error archiving task category: "+err.Error())
+			}
+
+		case taskCategoryDeleteReassign:
+			rows, err := qtx.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{AllDepartments: true})
+			if err != nil {
+				return txError(http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+			}
+
+			if err := validateTaskCategoryReassign(rows, int32(id), targetID, s.taskCategoryMaxDepth()); err != nil {
+				return txError(http.StatusBadRequest, err.Error())
+			}
+
+			if _, err := qtx.ReassignTaskCategoryTasks(ctx, sqlc.ReassignTaskCategoryTasksParams{
+				TargetID:   targetID,
+				CategoryID: int32(id),
+			}); err != nil {
+				return txError(http.StatusInternalServerError, "Error reassigning tasks: "+err.Error())
+			}
+
+			if _, err := qtx.ReassignTaskCategoryChildren(ctx, sqlc.ReassignTaskCategoryChildrenParams{
+				TargetID:   targetID,
+				CategoryID: int32(id),
+			}); err != nil {
+				return txError(http.StatusInternalServerError, "Error reassigning subcategories: "+err.Error())
+			}
+
+			if err := qtx.DeleteTaskCategory(ctx, int32(id)); err != nil {
+				return txError(http.StatusInternalServerError, "Error deleting task category: "+err.Error())
+			}
+		}
 
-	// First, get all root categories (with no parent)
-	rootCategories, err := database.ListRootTaskCategories(ctx)
+		return nil
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error fetching root task categories: "+err.Error())
+		respondWithTxError(w, err)
 		return
 	}
 
-	// Then build hierarchical response
-	response := buildHierarchicalCategories(ctx, rootCategories)
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "mode": mode})
 }
 
-// Helper function to build hierarchical structure
-func buildHierarchicalCategories(ctx context.Context, categories []sqlc.TaskCategory) []TaskCategoryResponse {
-	result := make([]TaskCategoryResponse, 0, len(categories))
+// TaskCategoryReorderRequest represents the request body for reordering sibling categories
+type TaskCategoryReorderRequest struct {
+	ParentID    *int32  `json:"parent_id"`    // Nil for root categories
+	CategoryIDs []int32 `json:"category_ids"` // Every sibling, in the new order
+}
 
-	for _, category := range categories {
-		// Get children for this category
-		children, err := database.ListTaskCategoriesByParent(ctx, pgtype.Int4{Int32: category.ID, Valid: true})
-		if err != nil {
-			// Log error but continue
-			continue
-		}
+// reorderTaskCategories stores a new manual order for the children of one parent
+func (s *Server) reorderTaskCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-		var parentID *int32
-		if category.ParentID.Valid {
-			parentID = &category.ParentID.Int32
+	var req TaskCategoryReorderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	var siblings []sqlc.TaskCategory
+	var err error
+	if req.ParentID == nil {
+		siblings, err = s.store.ListRootTaskCategories(ctx)
+	} else {
+		siblings, err = s.store.ListTaskCategoriesByParent(ctx, pgtype.Int4{Int32: *req.ParentID, Valid: true})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task categories: "+err.Error())
+		return
+	}
+
+	// The new order has to name every sibling exactly once
+	pending := make(map[int32]bool, len(siblings))
+	for _, sibling := range siblings {
+		pending[sibling.ID] = true
+	}
+	if len(req.CategoryIDs) != len(siblings) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Expected %d category IDs, got %d", len(siblings), len(req.CategoryIDs)))
+		return
+	}
+	for _, id := range req.CategoryIDs {
+		if !pending[id] {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Category %d is not a sibling or is listed twice", id))
+			return
 		}
+		delete(pending, id)
+	}
+
+	if _, err := s.store.ReorderTaskCategories(ctx, req.CategoryIDs); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error reordering task categories: "+err.Error())
+		return
+	}
 
-		categoryResponse := TaskCategoryResponse{
+	// Respond with the siblings in their new order
+	if req.ParentID == nil {
+		siblings, err = s.store.ListRootTaskCategories(ctx)
+	} else {
+		siblings, err = s.store.ListTaskCategoriesByParent(ctx, pgtype.Int4{Int32: *req.ParentID, Valid: true})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task categories: "+err.Error())
+		return
+	}
+
+	response := make([]TaskCategoryResponse, 0, len(siblings))
+	for _, category := range siblings {
+		response = append(response, TaskCategoryResponse{
 			ID:          category.ID,
 			Name:        category.Name,
-			ParentID:    parentID,
+			ParentID:    int4Ptr(category.ParentID),
 			Description: category.Description.String,
+			BudgetDay:   numericPtr(category.BudgetDay),
+			Department:  category.Department.String,
+			SortOrder:   category.SortOrder,
 			CreatedAt:   category.CreatedAt,
 			UpdatedAt:   category.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// TaskCategoryMergeRequest represents the request body for merging one category into another
+type TaskCategoryMergeRequest struct {
+	TargetID int32 `json:"target_id" validate:"required"`
+	DryRun   bool  `json:"dry_run"` // Only report what would move
+}
+
+// TaskCategoryMergeResponse describes the outcome, or the planned outcome, of a merge
+type TaskCategoryMergeResponse struct {
+	SourceID        int32 `json:"source_id"`
+	TargetID        int32 `json:"target_id"`
+	DryRun          bool  `json:"dry_run"`
+	MovedTaskCount  int64 `json:"moved_task_count"`
+	MovedChildCount int64 `json:"moved_child_count"`
+}
+
+// mergeTaskCategory moves every task and subcategory of a category into the target
+// and deletes the emptied category in one transaction (admin only)
+func (s *Server) mergeTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can merge task categories")
+		return
+	}
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task category ID")
+		return
+	}
+
+	var req TaskCategoryMergeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	if _, err := s.store.GetTaskCategory(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task category not found")
+		return
+	}
+
+	response := TaskCategoryMergeResponse{
+		SourceID: int32(id),
+		TargetID: req.TargetID,
+		DryRun:   req.DryRun,
+	}
+
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		rows, err := qtx.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{AllDepartments: true})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+		}
+
+		if err := validateTaskCategoryReassign(rows, int32(id), req.TargetID, s.taskCategoryMaxDepth()); err != nil {
+			return txError(http.StatusBadRequest, err.Error())
 		}
 
-		// Recursively get children if there are any
-		if len(children) > 0 {
-			categoryResponse.Children = buildHierarchicalCategories(ctx, children)
+		// A dry run only counts what would move, so nothing is written
+		if req.DryRun {
+			usage, err := qtx.CountTaskCategoryUsage(ctx, int32(id))
+			if err != nil {
+				return txError(http.StatusInternalServerError, "Error checking task category usage: "+err.Error())
+			}
+			response.MovedTaskCount = usage.TaskCount
+			response.MovedChildCount = usage.ChildCount
+			return nil
 		}
 
-		result = append(result, categoryResponse)
+		response.MovedTaskCount, err = qtx.ReassignTaskCategoryTasks(ctx, sqlc.ReassignTaskCategoryTasksParams{
+			TargetID:   req.TargetID,
+			CategoryID: int32(id),
+		})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error moving tasks: "+err.Error())
+		}
+
+		response.MovedChildCount, err = qtx.ReassignTaskCategoryChildren(ctx, sqlc.ReassignTaskCategoryChildrenParams{
+			TargetID:   req.TargetID,
+			CategoryID: int32(id),
+		})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error moving subcategories: "+err.Error())
+		}
+
+		if err := qtx.DeleteTaskCategory(ctx, int32(id)); err != nil {
+			return txError(http.StatusInternalServerError, "Error deleting task category: "+err.Error())
+		}
+
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
+		return
 	}
 
-	return result
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// validateTaskCategoryReassign checks that the target can take over the tasks and
+// children of the category being deleted
+func validateTaskCategoryReassign(rows []sqlc.ListTaskCategoryTreeRow, id, targetID int32, maxDepth int32) error {
+	byID := make(map[int32]sqlc.ListTaskCategoryTreeRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	target, ok := byID[targetID]
+	if !ok {
+		return fmt.Errorf("target category not found")
+	}
+	if targetID == id || isCategoryDescendant(byID, target, id) {
+		return fmt.Errorf("target category cannot be the deleted category or one of its descendants")
+	}
+
+	// Each direct child moves under the target together with its own subtree
+	for _, row := range rows {
+		if row.ParentID.Valid && row.ParentID.Int32 == id {
+			if err := validateTaskCategoryMove(rows, row.ID, &targetID, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) getHierarchicalTaskCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Fetch the visible tree in one query, parents ordered before their children
+	allDepartments, department := s.categoryVisibility(r)
+	if version, err := s.store.GetTaskCategoriesVersion(ctx); err != nil {
+		slog.WarnContext(ctx, "Error reading task categories version, answering without an ETag", "error", err)
+	} else if notModified(w, r, version.RowCount, version.LastUpdated, allDepartments, department.String) {
+		return
+	}
+	rows, err := s.store.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{
+		AllDepartments: allDepartments,
+		Department:     department,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+		return
+	}
+
+	response := buildHierarchicalCategories(rows)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Helper function to nest the flat category tree rows under their parents
+func buildHierarchicalCategories(rows []sqlc.ListTaskCategoryTreeRow) []TaskCategoryResponse {
+	childrenByParent := make(map[int32][]sqlc.ListTaskCategoryTreeRow)
+	var roots []sqlc.ListTaskCategoryTreeRow
+	for _, row := range rows {
+		if row.ParentID.Valid {
+			childrenByParent[row.ParentID.Int32] = append(childrenByParent[row.ParentID.Int32], row)
+		} else {
+			roots = append(roots, row)
+		}
+	}
+
+	var build func(level []sqlc.ListTaskCategoryTreeRow) []TaskCategoryResponse
+	build = func(level []sqlc.ListTaskCategoryTreeRow) []TaskCategoryResponse {
+		result := make([]TaskCategoryResponse, 0, len(level))
+		for _, row := range level {
+			depth := row.Depth
+			categoryResponse := TaskCategoryResponse{
+				ID:          row.ID,
+				Name:        row.Name,
+				ParentID:    int4Ptr(row.ParentID),
+				Description: row.Description.String,
+				Depth:       &depth,
+				Path:        row.Path,
+				Department:  row.Department.String,
+				SortOrder:   row.SortOrder,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+			}
+
+			if children := childrenByParent[row.ID]; len(children) > 0 {
+				categoryResponse.Children = build(children)
+			}
+
+			result = append(result, categoryResponse)
+		}
+		return result
+	}
+
+	return build(roots)
+}
+
+// TaskCategoryMoveRequest represents the request body for moving a task category.
+// A null parent_id moves the category to the root.
+type TaskCategoryMoveRequest struct {
+	ParentID *int32 `json:"parent_id"`
+}
+
+// taskCategoryMaxDepth returns how many levels the category tree may have,
+// configurable via TASK_CATEGORY_MAX_DEPTH (default 5)
+func (s *Server) taskCategoryMaxDepth() int32 {
+	return int32(s.settings.Config().Tasks.CategoryMaxDepth)
+}
+
+// moveTaskCategory puts a category and its subtree under another parent, or at the root, checking
+// the tree against cycles and the depth limit while every category is locked (admin only)
+func (s *Server) moveTaskCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can move task categories")
+		return
+	}
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task category ID")
+		return
+	}
+
+	var req TaskCategoryMoveRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	if _, err := s.store.GetTaskCategory(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task category not found")
+		return
+	}
+
+	parentID := pgtype.Int4{Valid: false}
+	if req.ParentID != nil {
+		parentID = pgtype.Int4{Int32: *req.ParentID, Valid: true}
+	}
+
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		// A move checked against a tree another move is changing could close a cycle
+		if err := qtx.LockTaskCategories(ctx); err != nil {
+			return txError(http.StatusInternalServerError, "Error locking task categories: "+err.Error())
+		}
+
+		rows, err := qtx.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{AllDepartments: true})
+		if err != nil {
+			return txError(http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+		}
+
+		if err := validateTaskCategoryMove(rows, int32(id), req.ParentID, s.taskCategoryMaxDepth()); err != nil {
+			return txError(http.StatusBadRequest, err.Error())
+		}
+
+		if _, err := qtx.MoveTaskCategory(ctx, sqlc.MoveTaskCategoryParams{
+			ID:       int32(id),
+			ParentID: parentID,
+		}); err != nil {
+			return txError(http.StatusInternalServerError, "Error moving task category: "+err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
+		return
+	}
+
+	// Respond with the moved subtree as it now sits in the tree
+	rows, err := s.store.ListTaskCategoryTree(ctx, sqlc.ListTaskCategoryTreeParams{AllDepartments: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task category tree: "+err.Error())
+		return
+	}
+
+	subtree := findCategorySubtree(buildHierarchicalCategories(rows), int32(id))
+	if subtree == nil {
+		respondWithError(w, http.StatusInternalServerError, "Moved task category is missing from the tree")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subtree)
+}
+
+// categoryVisibility returns the department filter for category listings. Admins see
+// every department; other users see their own department and unscoped categories.
+func (s *Server) categoryVisibility(r *http.Request) (bool, pgtype.Text) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		return false, pgtype.Text{}
+	}
+	if currentUser.UserType == "admin" {
+		return true, pgtype.Text{}
+	}
+	return false, currentUser.Department
+}
+
+// categoryBudget converts an optional budget from a request into a nullable numeric. Numbers
+// decoded from JSON are always finite.
+func categoryBudget(budgetDay *float64) pgtype.Numeric {
+	if budgetDay == nil {
+		return pgtype.Numeric{}
+	}
+	return pgconv.MustFromFloat(*budgetDay)
+}
+
+// validateTaskCategoryMove checks that putting the category under parentID keeps the
+// tree acyclic and no deeper than maxDepth levels
+func validateTaskCategoryMove(rows []sqlc.ListTaskCategoryTreeRow, id int32, parentID *int32, maxDepth int32) error {
+	byID := make(map[int32]sqlc.ListTaskCategoryTreeRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	moved, ok := byID[id]
+	if !ok {
+		return fmt.Errorf("task category %d is not part of the category tree", id)
+	}
+
+	newDepth := int32(0)
+	if parentID != nil {
+		parent, ok := byID[*parentID]
+		if !ok {
+			return fmt.Errorf("parent category not found")
+		}
+
+		// Walk up from the new parent; reaching the moved category means a cycle
+		for current := parent; ; {
+			if current.ID == id {
+				return fmt.Errorf("a category cannot be moved under itself or one of its descendants")
+			}
+			if !current.ParentID.Valid {
+				break
+			}
+			current = byID[current.ParentID.Int32]
+		}
+
+		newDepth = parent.Depth + 1
+	}
+
+	// The deepest descendant moves by the same amount as the category itself
+	deepest := moved.Depth
+	for _, row := range rows {
+		if row.Depth > deepest && isCategoryDescendant(byID, row, id) {
+			deepest = row.Depth
+		}
+	}
+
+	if levels := newDepth + (deepest - moved.Depth) + 1; levels > maxDepth {
+		return fmt.Errorf("move would nest categories %d levels deep, the maximum is %d", levels, maxDepth)
+	}
+
+	return nil
+}
+
+// isCategoryDescendant reports whether row sits somewhere below the category with the given ID
+func isCategoryDescendant(byID map[int32]sqlc.ListTaskCategoryTreeRow, row sqlc.ListTaskCategoryTreeRow, id int32) bool {
+	for row.ParentID.Valid {
+		if row.ParentID.Int32 == id {
+			return true
+		}
+		row = byID[row.ParentID.Int32]
+	}
+	return false
+}
+
+// findCategorySubtree returns the nested category with the given ID
+func findCategorySubtree(categories []TaskCategoryResponse, id int32) *TaskCategoryResponse {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+		if found := findCategorySubtree(categories[i].Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestHierarchicalTaskCategories(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	token := tokenFor(admin.Username)
+
+	var parent, child TaskCategoryResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{
+		Name: "Engineering",
+	}), http.StatusCreated, &parent)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{
+		Name:     "Backend",
+		ParentID: &parent.ID,
+	}), http.StatusCreated, &child)
+
+	var tree []TaskCategoryResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/task-categories/hierarchical", token, nil), http.StatusOK, &tree)
+	if len(tree) != 1 || tree[0].ID != parent.ID {
+		t.Fatalf("tree = %+v, want Engineering at the root", tree)
+	}
+	if len(tree[0].Children) != 1 || tree[0].Children[0].ID != child.ID || *tree[0].Children[0].Depth != 1 {
+		t.Errorf("children = %+v, want Backend one level down", tree[0].Children)
+	}
+}
+
+func TestMoveTaskCategory(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(admin.Username)
+
+	var parent, child, other TaskCategoryResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{
+		Name: "Engineering",
+	}), http.StatusCreated, &parent)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{
+		Name:     "Backend",
+		ParentID: &parent.ID,
+	}), http.StatusCreated, &child)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{
+		Name: "Operations",
+	}), http.StatusCreated, &other)
+
+	path := fmt.Sprintf("/api/task-categories/%d/move", parent.ID)
+	move := TaskCategoryMoveRequest{ParentID: &other.ID}
+
+	// Only admins change the tree
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, "", move), http.StatusUnauthorized, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, tokenFor(user.Username), move), http.StatusForbidden, nil)
+
+	// Nor can a category go under its own subtree
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, token, TaskCategoryMoveRequest{ParentID: &child.ID}), http.StatusBadRequest, nil)
+
+	var moved TaskCategoryResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, token, move), http.StatusOK, &moved)
+	if moved.ParentID == nil || *moved.ParentID != other.ID || *moved.Depth != 1 {
+		t.Errorf("moved = %+v, want Engineering under Operations", moved)
+	}
+	if len(moved.Children) != 1 || moved.Children[0].ID != child.ID {
+		t.Errorf("children = %+v, want Backend moved along", moved.Children)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TaskCategoryReportEntry is the effort of a category and all its subcategories in a period
+type TaskCategoryReportEntry struct {
+	CategoryID          int32    `json:"category_id"`
+	Name                string   `json:"name"`
+	ParentID            *int32   `json:"parent_id,omitempty"`
+	TaskCount           int64    `json:"task_count"` // Tasks with logged work in the period
+	WorkedDay           float64  `json:"worked_day"`
+	EstimateDay         float64  `json:"estimate_day"`          // Latest estimates of the worked tasks
+	EstimateVarianceDay float64  `json:"estimate_variance_day"` // Worked minus estimated, positive means overrun
+	BudgetDay           *float64 `json:"budget_day"`
+	BudgetVarianceDay   *float64 `json:"budget_variance_day"` // Budget minus worked, negative means over budget
+	IsOverBudget        bool     `json:"is_over_budget"`
+}
+
+func (s *Server) getTaskCategoryReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to date must not be before from date")
+		return
+	}
+
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rows, err := s.store.ListTaskCategoryEffort(ctx, sqlc.ListTaskCategoryEffortParams{
+		StartDate: pgtype.Date{Time: from, Valid: true},
+		EndDate:   pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching category report: "+err.Error())
+		return
+	}
+
+	response := make([]TaskCategoryReportEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := TaskCategoryReportEntry{
+			CategoryID:  row.ID,
+			Name:        row.Name,
+			ParentID:    int4Ptr(row.ParentID),
+			TaskCount:   row.TaskCount,
+			WorkedDay:   numericToFloat64(row.WorkedDay, 0),
+			EstimateDay: numericToFloat64(row.EstimateDay, 0),
+			BudgetDay:   numericPtr(row.BudgetDay),
+		}
+		entry.EstimateVarianceDay = entry.WorkedDay - entry.EstimateDay
+
+		if entry.BudgetDay != nil {
+			variance := *entry.BudgetDay - entry.WorkedDay
+			entry.BudgetVarianceDay = &variance
+			entry.IsOverBudget = variance < 0
+		}
+
+		response = append(response, entry)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
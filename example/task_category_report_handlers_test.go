@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestTaskCategoryReportLeavesOutDeletedTasks(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	token := tokenFor(admin.Username)
+
+	var category TaskCategoryResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-categories", token, TaskCategoryRequest{Name: "Finance"}), http.StatusCreated, &category)
+
+	var tasks [2]TaskResponse
+	for i, title := range []string{"Close the books", "Renew the insurance"} {
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: title, TaskCategoryID: &category.ID}), http.StatusCreated, &tasks[i])
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/task-logs", token, TaskLogRequest{
+			TaskID:     tasks[i].ID,
+			WorkedDay:  0.5,
+			WorkedDate: "2026-03-02",
+		}), http.StatusCreated, nil)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, fmt.Sprintf("/api/tasks/%d", tasks[1].ID), token, nil), http.StatusOK, nil)
+
+	var report []TaskCategoryReportEntry
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/reports/categories?from=2026-03-01&to=2026-03-31", token, nil), http.StatusOK, &report)
+	if len(report) != 1 || report[0].TaskCount != 1 || report[0].WorkedDay != 0.5 {
+		t.Errorf("report = %+v, want only the work on the task that wasn't deleted", report)
+	}
+}
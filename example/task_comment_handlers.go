@@ -0,0 +1,209 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TaskCommentResponse is the response format for a task comment
+type TaskCommentResponse struct {
+	ID        int32     `json:"id"`
+	TaskID    int32     `json:"task_id"`
+	UserID    int32     `json:"user_id"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskCommentRequest represents the request body for creating or updating a task comment
+type TaskCommentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+func (s *Server) getTaskComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	comments, err := s.store.ListTaskCommentsByTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task comments: "+err.Error())
+		return
+	}
+
+	response := make([]TaskCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response = append(response, TaskCommentResponse{
+			ID:        comment.ID,
+			TaskID:    comment.TaskID,
+			UserID:    comment.UserID,
+			Username:  comment.Username,
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt.Time,
+			UpdatedAt: comment.UpdatedAt.Time,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) createTaskComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Get current user
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TaskCommentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	body := strings.TrimSpace(req.Body)
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	comment, err := s.store.CreateTaskComment(ctx, sqlc.CreateTaskCommentParams{
+		TaskID: int32(taskID),
+		UserID: currentUser.ID,
+		Body:   body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating task comment: "+err.Error())
+		return
+	}
+
+	recordTaskActivity(ctx, s.store, comment.TaskID, currentUser.ID, taskActivityCommentAdded, "", "", comment.ID)
+
+	respondWithJSON(w, http.StatusCreated, newTaskCommentResponse(comment, currentUser.Username))
+}
+
+func (s *Server) updateTaskComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task comment ID")
+		return
+	}
+
+	// Get current user
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TaskCommentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	body := strings.TrimSpace(req.Body)
+
+	// Check if comment exists and belongs to current user
+	existingComment, err := s.store.GetTaskComment(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task comment not found")
+		return
+	}
+
+	if existingComment.UserID != currentUser.ID {
+		respondWithError(w, http.StatusForbidden, "You can only update your own comments")
+		return
+	}
+
+	comment, err := s.store.UpdateTaskComment(ctx, sqlc.UpdateTaskCommentParams{
+		ID:   int32(id),
+		Body: body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating task comment: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newTaskCommentResponse(comment, currentUser.Username))
+}
+
+func (s *Server) deleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task comment ID")
+		return
+	}
+
+	// Get current user
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Authors and admins can delete comments
+	existingComment, err := s.store.GetTaskComment(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task comment not found")
+		return
+	}
+
+	if existingComment.UserID != currentUser.ID && currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "You can only delete your own comments")
+		return
+	}
+
+	if err := s.store.DeleteTaskComment(ctx, int32(id)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting task comment: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func newTaskCommentResponse(comment sqlc.TaskComment, username string) TaskCommentResponse {
+	return TaskCommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		UserID:    comment.UserID,
+		Username:  username,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt.Time,
+		UpdatedAt: comment.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+)
+
+func TestTaskComments(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(alice.Username)
+
+	var task TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: "Close the books"}), http.StatusCreated, &task)
+	commentsPath := fmt.Sprintf("/api/tasks/%d/comments", task.ID)
+
+	var comment TaskCommentResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, commentsPath, token, TaskCommentRequest{Body: "Waiting on the bank statement"}), http.StatusCreated, &comment)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/task-comments/%d", comment.ID), token, TaskCommentRequest{Body: "Statement received"}), http.StatusOK, nil)
+
+	var comments []TaskCommentResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, commentsPath, token, nil), http.StatusOK, &comments)
+	if len(comments) != 1 || comments[0].Body != "Statement received" || comments[0].Username != "alice" {
+		t.Errorf("comments = %+v, want alice's edited comment", comments)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, fmt.Sprintf("/api/task-comments/%d", comment.ID), token, nil), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, commentsPath, token, nil), http.StatusOK, &comments)
+	if len(comments) != 0 {
+		t.Errorf("comments = %+v, want none after deleting", comments)
+	}
+}
@@ -2,36 +2,164 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// Estimate units and confidence levels
+const (
+	estimateUnitDays   = "days"
+	estimateUnitHours  = "hours"
+	estimateUnitPoints = "points"
+
+	estimateConfidenceLow    = "low"
+	estimateConfidenceMedium = "medium"
+	estimateConfidenceHigh   = "high"
 )
 
 // TaskEstimateResponse is the response format for task estimate data
 type TaskEstimateResponse struct {
 	ID              int32              `json:"id"`
 	TaskID          int32              `json:"task_id"`
-	EstimateDay     float64            `json:"estimate_day"`
+	EstimateDay     float64            `json:"estimate_day"` // Always in days, whatever the unit
+	Estimate        float64            `json:"estimate"`     // In the unit it was given in
+	Unit            string             `json:"unit"`
+	Confidence      string             `json:"confidence,omitempty"`
 	Note            string             `json:"note,omitempty"`
 	CreatedByUserID int32              `json:"created_by_user_id"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	IsCurrent       bool               `json:"is_current"`              // The estimate used in roll-ups and reports
+	SupersedesID    *int32             `json:"supersedes_id,omitempty"` // The previous revision
+	SupersededAt    *time.Time         `json:"superseded_at,omitempty"`
 	Username        string             `json:"username,omitempty"`   // Added for response only
 	TaskTitle       string             `json:"task_title,omitempty"` // Added for response only
+	SessionID       *int32             `json:"session_id,omitempty"` // Estimation session the estimate was agreed in
 }
 
-// TaskEstimateRequest represents the request body for creating a task estimate
+// TaskEstimateRequest represents the request body for creating a task estimate.
+// The estimate can be given in days with estimate_day, or in any unit with estimate and unit.
 type TaskEstimateRequest struct {
-	TaskID      int32   `json:"task_id"`
-	EstimateDay float64 `json:"estimate_day"`
+	TaskID      int32   `json:"task_id"` // Only read when creating
+	EstimateDay float64 `json:"estimate_day" validate:"min=0"`
+	Estimate    float64 `json:"estimate" validate:"min=0"`
+	Unit        string  `json:"unit"`       // days (default), hours or points
+	Confidence  string  `json:"confidence"` // low, medium or high
 	Note        string  `json:"note"`
 }
 
-func getTaskEstimates(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// taskEstimateAmount is an estimate in the unit it was given in, with its value in days
+type taskEstimateAmount struct {
+	Days       float64
+	Value      float64
+	Unit       string
+	Confidence string
+}
+
+// estimateInDays is an amount given directly in days, without a confidence level
+func estimateInDays(days float64) taskEstimateAmount {
+	return taskEstimateAmount{Days: days, Value: days, Unit: estimateUnitDays}
+}
+
+// parseTaskEstimateAmount validates the unit and confidence of a request and converts the estimate
+// to days. Units and confidence levels are matched regardless of case, so they are checked here
+// rather than by tags, and problems are returned as validate.Errors.
+func parseTaskEstimateAmount(settings config.Tasks, req TaskEstimateRequest) (taskEstimateAmount, error) {
+	amount := taskEstimateAmount{
+		Value:      req.Estimate,
+		Unit:       strings.ToLower(strings.TrimSpace(req.Unit)),
+		Confidence: strings.ToLower(strings.TrimSpace(req.Confidence)),
+	}
+	if amount.Unit == "" {
+		amount.Unit = estimateUnitDays
+	}
+	if amount.Value == 0 && amount.Unit == estimateUnitDays {
+		amount.Value = req.EstimateDay
+	}
+
+	errs := validate.Errors{}
+	if !isEstimateUnit(amount.Unit) {
+		errs.Add("unit", "must be one of days, hours, points")
+	}
+	switch amount.Confidence {
+	case "", estimateConfidenceLow, estimateConfidenceMedium, estimateConfidenceHigh:
+	default:
+		errs.Add("confidence", "must be one of low, medium, high")
+	}
+	if amount.Value <= 0 {
+		errs.Add("estimate", "must be greater than 0")
+	}
+	if len(errs) > 0 {
+		return amount, errs
+	}
+
+	amount.Days = amount.Value * daysPerEstimateUnit(settings, amount.Unit)
+	if amount.Days >= 1000 || amount.Value >= 100000 {
+		errs.Add("estimate", "is too large")
+	}
+	return amount, errs.Err()
+}
+
+// isEstimateUnit reports whether unit is one of the supported estimate units
+func isEstimateUnit(unit string) bool {
+	return unit == estimateUnitDays || unit == estimateUnitHours || unit == estimateUnitPoints
+}
+
+// daysPerEstimateUnit returns how many days one unit is worth. Hours use ESTIMATE_HOURS_PER_DAY
+// (default 8) and story points ESTIMATE_DAYS_PER_POINT (default 1).
+func daysPerEstimateUnit(settings config.Tasks, unit string) float64 {
+	switch unit {
+	case estimateUnitHours:
+		return 1 / settings.EstimateHoursPerDay
+	case estimateUnitPoints:
+		return settings.EstimateDaysPerPoint
+	default:
+		return 1
+	}
+}
+
+// convertEstimateDays expresses a number of days in the given unit
+func convertEstimateDays(settings config.Tasks, days float64, unit string) float64 {
+	return days / daysPerEstimateUnit(settings, unit)
+}
+
+// newTaskEstimateResponse converts a task estimate to its response format
+func newTaskEstimateResponse(estimate sqlc.TaskEstimate, username string) TaskEstimateResponse {
+	response := TaskEstimateResponse{
+		ID:              estimate.ID,
+		TaskID:          estimate.TaskID,
+		EstimateDay:     numericToFloat64(estimate.EstimateDay, 0),
+		Unit:            estimate.Unit,
+		Confidence:      estimate.Confidence.String,
+		Note:            estimate.Note.String,
+		CreatedByUserID: estimate.CreatedByUserID,
+		CreatedAt:       estimate.CreatedAt,
+		IsCurrent:       estimate.IsCurrent,
+		SupersedesID:    int4Ptr(estimate.SupersedesID),
+		Username:        username,
+		SessionID:       int4Ptr(estimate.SessionID),
+	}
+	// Estimates from before units were added only have the value in days
+	response.Estimate = numericToFloat64(estimate.EstimateValue, response.EstimateDay)
+	if estimate.SupersededAt.Valid {
+		supersededAt := estimate.SupersededAt.Time
+		response.SupersededAt = &supersededAt
+	}
+	return response
+}
+
+func (s *Server) getTaskEstimates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse pagination parameters
 	limit := 50
@@ -55,14 +183,14 @@ func getTaskEstimates(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user from request to use for filtering
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Get task estimates from database for this user
-	estimates, err := database.ListTaskEstimatesByUser(ctx, sqlc.ListTaskEstimatesByUserParams{
+	estimates, err := s.store.ListTaskEstimatesByUser(ctx, sqlc.ListTaskEstimatesByUserParams{
 		CreatedByUserID: currentUser.ID,
 		Limit:           int32(limit),
 		Offset:          int32(offset),
@@ -71,29 +199,20 @@ func getTaskEstimates(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task estimates: "+err.Error())
 		return
 	}
+	total, err := s.store.CountTaskEstimatesByUser(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting task estimates: "+err.Error())
+		return
+	}
 
 	// Convert to response format with enriched data
 	response := make([]TaskEstimateResponse, 0, len(estimates))
 	for _, estimate := range estimates {
-		// Convert numeric to float64
-		estimateDay, _ := estimate.EstimateDay.Float64Value()
-		estimateDayValue := float64(0)
-		if estimateDay.Valid {
-			estimateDayValue = estimateDay.Float64
-		}
-
-		resp := TaskEstimateResponse{
-			ID:              estimate.ID,
-			TaskID:          estimate.TaskID,
-			EstimateDay:     estimateDayValue,
-			Note:            estimate.Note.String,
-			CreatedByUserID: estimate.CreatedByUserID,
-			CreatedAt:       estimate.CreatedAt,
-			Username:        currentUser.Username, // Set the current user's username
-		}
+		// Set the current user's username
+		resp := newTaskEstimateResponse(estimate, currentUser.Username)
 
 		// Get task info to enrich the response
-		task, err := database.GetTask(ctx, estimate.TaskID)
+		task, err := s.store.GetTask(ctx, estimate.TaskID)
 		if err == nil && task.Title.Valid {
 			resp.TaskTitle = task.Title.String
 		}
@@ -101,11 +220,11 @@ func getTaskEstimates(w http.ResponseWriter, r *http.Request) {
 		response = append(response, resp)
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
 }
 
-func getTaskEstimate(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -114,14 +233,33 @@ func getTaskEstimate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	estimate, err := database.GetTaskEstimate(ctx, int32(id))
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	estimate, err := s.store.GetTaskEstimate(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task estimate not found")
 		return
 	}
 
+	// Other participants' votes stay hidden until the session reveals them
+	if estimate.IsVote && estimate.CreatedByUserID != currentUser.ID {
+		revealed, err := s.estimationVoteRevealed(ctx, estimate)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error fetching estimation session: "+err.Error())
+			return
+		}
+		if !revealed {
+			respondWithError(w, http.StatusNotFound, "Task estimate not found")
+			return
+		}
+	}
+
 	// Get user who created this estimate
-	user, err := database.GetUser(ctx, estimate.CreatedByUserID)
+	user, err := s.store.GetUser(ctx, estimate.CreatedByUserID)
 	if err != nil {
 		// Continue even if we can't get the user
 		user = sqlc.User{
@@ -130,103 +268,238 @@ func getTaskEstimate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get task info
-	task, err := database.GetTask(ctx, estimate.TaskID)
+	task, err := s.store.GetTask(ctx, estimate.TaskID)
 	taskTitle := ""
 	if err == nil && task.Title.Valid {
 		taskTitle = task.Title.String
 	}
 
-	// Convert numeric to float64
-	estimateDay, _ := estimate.EstimateDay.Float64Value()
-	estimateDayValue := float64(0)
-	if estimateDay.Valid {
-		estimateDayValue = estimateDay.Float64
-	}
-
-	response := TaskEstimateResponse{
-		ID:              estimate.ID,
-		TaskID:          estimate.TaskID,
-		EstimateDay:     estimateDayValue,
-		Note:            estimate.Note.String,
-		CreatedByUserID: estimate.CreatedByUserID,
-		CreatedAt:       estimate.CreatedAt,
-		Username:        user.Username,
-		TaskTitle:       taskTitle,
-	}
+	response := newTaskEstimateResponse(estimate, user.Username)
+	response.TaskTitle = taskTitle
 
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func createTaskEstimate(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	var req TaskEstimateRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.TaskID == 0 {
+		respondWithValidationError(w, validate.Errors{"task_id": "is required"})
 		return
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Validate request
-	if req.EstimateDay <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Estimate day must be positive")
+	amount, err := parseTaskEstimateAmount(s.settings.Config().Tasks, req)
+	if err != nil {
+		respondWithValidationError(w, err)
 		return
 	}
 
 	// Check if task exists
-	_, err = database.GetTask(ctx, req.TaskID)
+	_, err = s.store.GetTask(ctx, req.TaskID)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Task not found")
 		return
 	}
 
-	// Prepare numeric value
-	estimateDay := pgtype.Numeric{}
-	estimateDay.Valid = true
-	estimateDay.Scan(strconv.FormatFloat(req.EstimateDay, 'f', -1, 64))
+	// A new estimate becomes the current revision of the task
+	estimate, previous, err := s.createTaskEstimateRevision(ctx, req.TaskID, currentUser.ID, amount, req.Note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating task estimate: "+err.Error())
+		return
+	}
 
-	// Create task estimate in database
-	params := sqlc.CreateTaskEstimateParams{
-		TaskID:          req.TaskID,
-		EstimateDay:     estimateDay,
-		Note:            pgtype.Text{String: req.Note, Valid: req.Note != ""},
-		CreatedByUserID: currentUser.ID,
+	s.recordTaskEstimateRevision(ctx, estimate, previous, currentUser.ID)
+
+	respondWithJSON(w, http.StatusCreated, newTaskEstimateResponse(estimate, currentUser.Username))
+}
+
+// supersedeTaskEstimate replaces the current estimate of a task with a new revision
+func (s *Server) supersedeTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task estimate ID")
+		return
+	}
+
+	var req TaskEstimateRequest
+	if !decodeRequest(w, r, &req) {
+		return
 	}
 
-	estimate, err := database.CreateTaskEstimate(ctx, params)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error creating task estimate: "+err.Error())
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Convert numeric to float64 for response
-	estimateDayValue, _ := estimate.EstimateDay.Float64Value()
-	estimateDayFloat := float64(0)
-	if estimateDayValue.Valid {
-		estimateDayFloat = estimateDayValue.Float64
+	amount, err := parseTaskEstimateAmount(s.settings.Config().Tasks, req)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
 	}
 
-	response := TaskEstimateResponse{
-		ID:              estimate.ID,
-		TaskID:          estimate.TaskID,
-		EstimateDay:     estimateDayFloat,
-		Note:            estimate.Note.String,
-		CreatedByUserID: estimate.CreatedByUserID,
-		CreatedAt:       estimate.CreatedAt,
-		Username:        currentUser.Username,
+	existingEstimate, err := s.store.GetTaskEstimate(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task estimate not found")
+		return
+	}
+
+	if !existingEstimate.IsCurrent {
+		respondWithError(w, http.StatusConflict, "Only the current estimate of a task can be superseded")
+		return
+	}
+
+	estimate, previous, err := s.createTaskEstimateRevision(ctx, existingEstimate.TaskID, currentUser.ID, amount, req.Note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error superseding task estimate: "+err.Error())
+		return
+	}
+
+	s.recordTaskEstimateRevision(ctx, estimate, previous, currentUser.ID)
+
+	respondWithJSON(w, http.StatusCreated, newTaskEstimateResponse(estimate, currentUser.Username))
+}
+
+// getCurrentTaskEstimate returns the estimate currently in effect for a task
+func (s *Server) getCurrentTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["task_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	estimate, err := s.store.GetCurrentTaskEstimate(ctx, int32(taskID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Task has no estimate")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task estimate: "+err.Error())
+		return
+	}
+
+	username := "Unknown"
+	if user, err := s.store.GetUser(ctx, estimate.CreatedByUserID); err == nil {
+		username = user.Username
+	}
+
+	respondWithJSON(w, http.StatusOK, newTaskEstimateResponse(estimate, username))
+}
+
+// createTaskEstimateRevision stores a new current estimate for a task in one transaction,
+// superseding the previous current estimate if there is one
+func (s *Server) createTaskEstimateRevision(ctx context.Context, taskID, userID int32, amount taskEstimateAmount, note string) (sqlc.TaskEstimate, *sqlc.TaskEstimate, error) {
+	var estimate sqlc.TaskEstimate
+	var previous *sqlc.TaskEstimate
+	err := s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		var err error
+		estimate, previous, err = insertTaskEstimateRevision(ctx, qtx, taskID, userID, amount, note, pgtype.Int4{Valid: false})
+		return err
+	})
+	if err != nil {
+		return sqlc.TaskEstimate{}, nil, err
+	}
+
+	return estimate, previous, nil
+}
+
+// insertTaskEstimateRevision supersedes the current estimate of a task and stores the new
+// one through the given store, so callers can run it inside their own transaction
+func insertTaskEstimateRevision(ctx context.Context, store sqlc.Querier, taskID, userID int32, amount taskEstimateAmount, note string, sessionID pgtype.Int4) (sqlc.TaskEstimate, *sqlc.TaskEstimate, error) {
+	var previous *sqlc.TaskEstimate
+	supersedesID := pgtype.Int4{Valid: false}
+	current, err := store.GetCurrentTaskEstimate(ctx, taskID)
+	switch {
+	case err == nil:
+		if err := store.SupersedeTaskEstimate(ctx, current.ID); err != nil {
+			return sqlc.TaskEstimate{}, nil, err
+		}
+		previous = &current
+		supersedesID = pgtype.Int4{Int32: current.ID, Valid: true}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return sqlc.TaskEstimate{}, nil, err
+	}
+
+	estimate, err := store.CreateTaskEstimate(ctx, sqlc.CreateTaskEstimateParams{
+		TaskID:          taskID,
+		EstimateDay:     estimateDayNumeric(amount.Days),
+		Note:            pgtype.Text{String: note, Valid: note != ""},
+		CreatedByUserID: userID,
+		SupersedesID:    supersedesID,
+		SessionID:       sessionID,
+		Unit:            amount.Unit,
+		EstimateValue:   estimateDayNumeric(amount.Value),
+		Confidence:      pgtype.Text{String: amount.Confidence, Valid: amount.Confidence != ""},
+	})
+	if err != nil {
+		return sqlc.TaskEstimate{}, nil, err
+	}
+
+	return estimate, previous, nil
+}
+
+// taskEstimateLockEnabled reports whether estimates are locked once work is logged on their task.
+// Set TASK_ESTIMATE_LOCK_AFTER_LOGS=false to allow editing them in place.
+func (s *Server) taskEstimateLockEnabled() bool {
+	return s.settings.Config().Tasks.EstimateLockAfterLogs
+}
+
+// ensureTaskEstimateUnlocked responds with a conflict when the estimate may no longer be changed in
+// place, so variance reports keep the estimate the work was measured against
+func (s *Server) ensureTaskEstimateUnlocked(ctx context.Context, w http.ResponseWriter, estimate sqlc.TaskEstimate) bool {
+	if !s.taskEstimateLockEnabled() {
+		return true
 	}
 
-	respondWithJSON(w, http.StatusCreated, response)
+	hasLogs, err := s.store.HasTaskLogs(ctx, estimate.TaskID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error checking task logs: "+err.Error())
+		return false
+	}
+
+	if hasLogs {
+		respondWithError(w, http.StatusConflict, "Work has been logged on this task, supersede the estimate with a new revision instead")
+		return false
+	}
+	return true
+}
+
+// estimateDayNumeric converts a number of days to the numeric type stored in the database. The
+// amounts are validated by parseTaskEstimateAmount, so they are always finite.
+func estimateDayNumeric(days float64) pgtype.Numeric {
+	return pgconv.MustFromFloat(days)
+}
+
+// recordTaskEstimateRevision adds a new revision to the task activity feed
+func (s *Server) recordTaskEstimateRevision(ctx context.Context, estimate sqlc.TaskEstimate, previous *sqlc.TaskEstimate, userID int32) {
+	newValue := formatDays(numericToFloat64(estimate.EstimateDay, 0))
+	if previous == nil {
+		recordTaskActivity(ctx, s.store, estimate.TaskID, userID, taskActivityEstimateAdded, "", newValue, estimate.ID)
+		return
+	}
+	recordTaskActivity(ctx, s.store, estimate.TaskID, userID, taskActivityEstimateChanged, formatDays(numericToFloat64(previous.EstimateDay, 0)), newValue, estimate.ID)
 }
 
-func updateTaskEstimate(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -236,20 +509,19 @@ func updateTaskEstimate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req TaskEstimateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Check if estimate exists and belongs to current user
-	existingEstimate, err := database.GetTaskEstimate(ctx, int32(id))
+	existingEstimate, err := s.store.GetTaskEstimate(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task estimate not found")
 		return
@@ -260,52 +532,51 @@ func updateTaskEstimate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate request
-	if req.EstimateDay <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Estimate day must be positive")
+	if existingEstimate.IsVote {
+		respondWithError(w, http.StatusConflict, "Estimation session votes are changed through the session")
 		return
 	}
 
-	// Prepare numeric value
-	estimateDay := pgtype.Numeric{}
-	estimateDay.Valid = true
-	estimateDay.Scan(strconv.FormatFloat(req.EstimateDay, 'f', -1, 64))
+	if !s.ensureTaskEstimateUnlocked(ctx, w, existingEstimate) {
+		return
+	}
+
+	// Validate request
+	amount, err := parseTaskEstimateAmount(s.settings.Config().Tasks, req)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
 
 	// Update task estimate in database
 	params := sqlc.UpdateTaskEstimateParams{
-		ID:          int32(id),
-		EstimateDay: estimateDay,
-		Note:        pgtype.Text{String: req.Note, Valid: req.Note != ""},
+		ID:            int32(id),
+		EstimateDay:   estimateDayNumeric(amount.Days),
+		Note:          pgtype.Text{String: req.Note, Valid: req.Note != ""},
+		Unit:          amount.Unit,
+		EstimateValue: estimateDayNumeric(amount.Value),
+		Confidence:    pgtype.Text{String: amount.Confidence, Valid: amount.Confidence != ""},
 	}
 
-	estimate, err := database.UpdateTaskEstimate(ctx, params)
+	estimate, err := s.store.UpdateTaskEstimate(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating task estimate: "+err.Error())
 		return
 	}
 
-	// Convert numeric to float64 for response
-	estimateDayValue, _ := estimate.EstimateDay.Float64Value()
-	estimateDayFloat := float64(0)
-	if estimateDayValue.Valid {
-		estimateDayFloat = estimateDayValue.Float64
-	}
+	response := newTaskEstimateResponse(estimate, currentUser.Username)
 
-	response := TaskEstimateResponse{
-		ID:              estimate.ID,
-		TaskID:          estimate.TaskID,
-		EstimateDay:     estimateDayFloat,
-		Note:            estimate.Note.String,
-		CreatedByUserID: estimate.CreatedByUserID,
-		CreatedAt:       estimate.CreatedAt,
-		Username:        currentUser.Username,
+	// Record the change only when the estimate itself moved
+	previousDay := numericToFloat64(existingEstimate.EstimateDay, 0)
+	if previousDay != response.EstimateDay {
+		recordTaskActivity(ctx, s.store, estimate.TaskID, currentUser.ID, taskActivityEstimateChanged, formatDays(previousDay), formatDays(response.EstimateDay), estimate.ID)
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func deleteTaskEstimate(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteTaskEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -315,14 +586,14 @@ func deleteTaskEstimate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Check if estimate exists and belongs to current user
-	existingEstimate, err := database.GetTaskEstimate(ctx, int32(id))
+	existingEstimate, err := s.store.GetTaskEstimate(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task estimate not found")
 		return
@@ -333,16 +604,40 @@ func deleteTaskEstimate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteTaskEstimate(ctx, int32(id)); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error deleting task estimate: "+err.Error())
+	if existingEstimate.IsVote {
+		respondWithError(w, http.StatusConflict, "Estimation session votes are changed through the session")
+		return
+	}
+
+	if !s.ensureTaskEstimateUnlocked(ctx, w, existingEstimate) {
+		return
+	}
+
+	err = s.store.WithTx(ctx, func(qtx sqlc.Querier) error {
+		if err := qtx.DeleteTaskEstimate(ctx, int32(id)); err != nil {
+			return txError(http.StatusInternalServerError, "Error deleting task estimate: "+err.Error())
+		}
+
+		// Deleting the current estimate makes the revision it replaced current again
+		if existingEstimate.IsCurrent && existingEstimate.SupersedesID.Valid {
+			if err := qtx.RestoreTaskEstimate(ctx, existingEstimate.SupersedesID.Int32); err != nil {
+				return txError(http.StatusInternalServerError, "Error restoring previous task estimate: "+err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithTxError(w, err)
 		return
 	}
 
+	recordTaskActivity(ctx, s.store, existingEstimate.TaskID, currentUser.ID, taskActivityEstimateDeleted, formatDays(numericToFloat64(existingEstimate.EstimateDay, 0)), "", existingEstimate.ID)
+
 	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
 
-func getTaskEstimatesByTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskEstimatesByTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	taskID, err := strconv.Atoi(vars["task_id"])
@@ -352,14 +647,14 @@ func getTaskEstimatesByTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if task exists
-	task, err := database.GetTask(ctx, int32(taskID))
+	task, err := s.store.GetTask(ctx, int32(taskID))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
 	// Get task estimates from database
-	estimates, err := database.ListTaskEstimatesByTask(ctx, int32(taskID))
+	estimates, err := s.store.ListTaskEstimatesByTask(ctx, int32(taskID))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task estimates: "+err.Error())
 		return
@@ -369,28 +664,13 @@ func getTaskEstimatesByTask(w http.ResponseWriter, r *http.Request) {
 	response := make([]TaskEstimateResponse, 0, len(estimates))
 	for _, estimate := range estimates {
 		// Get user info
-		user, err := database.GetUser(ctx, estimate.CreatedByUserID)
+		user, err := s.store.GetUser(ctx, estimate.CreatedByUserID)
 		username := "Unknown"
 		if err == nil {
 			username = user.Username
 		}
 
-		// Convert numeric to float64
-		estimateDay, _ := estimate.EstimateDay.Float64Value()
-		estimateDayValue := float64(0)
-		if estimateDay.Valid {
-			estimateDayValue = estimateDay.Float64
-		}
-
-		resp := TaskEstimateResponse{
-			ID:              estimate.ID,
-			TaskID:          estimate.TaskID,
-			EstimateDay:     estimateDayValue,
-			Note:            estimate.Note.String,
-			CreatedByUserID: estimate.CreatedByUserID,
-			CreatedAt:       estimate.CreatedAt,
-			Username:        username,
-		}
+		resp := newTaskEstimateResponse(estimate, username)
 
 		if task.Title.Valid {
 			resp.TaskTitle = task.Title.String
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// EstimateVarianceEntry compares the current estimate of a task with the days logged on it
+type EstimateVarianceEntry struct {
+	TaskID              int32   `json:"task_id"`
+	TaskTitle           string  `json:"task_title"`
+	Status              string  `json:"status,omitempty"`
+	EstimateID          int32   `json:"estimate_id"`
+	EstimateDay         float64 `json:"estimate_day"`
+	LoggedDay           float64 `json:"logged_day"` // Everything logged up to the end of the period
+	VarianceDay         float64 `json:"variance_day"`
+	VariancePercent     float64 `json:"variance_percent"` // Positive means more work than estimated
+	EstimatedByUserID   int32   `json:"estimated_by_user_id"`
+	EstimatedByUsername string  `json:"estimated_by_username"`
+	EstimateUnit        string  `json:"estimate_unit"` // The unit the estimate was given in
+	Confidence          string  `json:"confidence,omitempty"`
+	Unit                string  `json:"unit"` // The unit of estimate, logged and variance below
+	Estimate            float64 `json:"estimate"`
+	Logged              float64 `json:"logged"`
+	Variance            float64 `json:"variance"`
+}
+
+func (s *Server) getEstimateVarianceReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to date must not be before from date")
+		return
+	}
+
+	// Worst variance first unless asked otherwise
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "variance"
+	}
+	if sortBy != "variance" && sortBy != "task" {
+		respondWithError(w, http.StatusBadRequest, "Invalid sort, expected variance or task")
+		return
+	}
+
+	// Days unless the report is asked for in hours or story points
+	unit := strings.ToLower(r.URL.Query().Get("unit"))
+	if unit == "" {
+		unit = estimateUnitDays
+	}
+	if !isEstimateUnit(unit) {
+		respondWithError(w, http.StatusBadRequest, "Invalid unit, expected days, hours or points")
+		return
+	}
+
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rows, err := s.store.ListTaskEstimateVariance(ctx, sqlc.ListTaskEstimateVarianceParams{
+		StartDate: pgtype.Date{Time: from, Valid: true},
+		EndDate:   pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching estimate report: "+err.Error())
+		return
+	}
+
+	tasks := s.settings.Config().Tasks
+	response := make([]EstimateVarianceEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := EstimateVarianceEntry{
+			TaskID:              row.TaskID,
+			TaskTitle:           row.Title.String,
+			Status:              row.Status.String,
+			EstimateID:          row.EstimateID,
+			EstimateDay:         numericToFloat64(row.EstimateDay, 0),
+			LoggedDay:           numericToFloat64(row.LoggedDay, 0),
+			EstimatedByUserID:   row.EstimatedByUserID,
+			EstimatedByUsername: row.EstimatedByUsername,
+			EstimateUnit:        row.Unit,
+			Confidence:          row.Confidence.String,
+			Unit:                unit,
+		}
+		entry.VarianceDay = entry.LoggedDay - entry.EstimateDay
+		entry.Estimate = roundEstimate(convertEstimateDays(tasks, entry.EstimateDay, unit))
+		entry.Logged = roundEstimate(convertEstimateDays(tasks, entry.LoggedDay, unit))
+		entry.Variance = roundEstimate(convertEstimateDays(tasks, entry.VarianceDay, unit))
+		// Keep the estimate exactly as given when the report uses its unit
+		if unit == row.Unit && row.EstimateValue.Valid {
+			entry.Estimate = numericToFloat64(row.EstimateValue, entry.Estimate)
+		}
+		if entry.EstimateDay > 0 {
+			entry.VariancePercent = math.Round(entry.VarianceDay/entry.EstimateDay*10000) / 100
+		}
+		response = append(response, entry)
+	}
+
+	if sortBy == "variance" {
+		sort.SliceStable(response, func(i, j int) bool {
+			return math.Abs(response[i].VariancePercent) > math.Abs(response[j].VariancePercent)
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// roundEstimate rounds a converted estimate to two decimals
+func roundEstimate(value float64) float64 {
+	return math.Round(value*100) / 100
+}
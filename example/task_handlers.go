@@ -1,66 +1,70 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"io"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
-	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/validate"
 )
 
 // TaskResponse is the response format for task data
 type TaskResponse struct {
-	ID             int32              `json:"id"`
-	Url            string             `json:"url,omitempty"`
-	TaskCategoryID *int32             `json:"task_category_id,omitempty"`
-	Note           string             `json:"note,omitempty"`
-	Title          string             `json:"title,omitempty"`
-	Status         string             `json:"status,omitempty"`
-	StatusColor    string             `json:"status_color,omitempty"`
-	CategoryName   string             `json:"category_name,omitempty"`
-	CreatedAt      pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	ID              int32                  `json:"id"`
+	Url             string                 `json:"url,omitempty"`
+	TaskCategoryID  *int32                 `json:"task_category_id,omitempty"`
+	Note            string                 `json:"note,omitempty"`
+	Title           string                 `json:"title,omitempty"`
+	Status          string                 `json:"status,omitempty"`
+	StatusColor     string                 `json:"status_color,omitempty"`
+	CategoryName    string                 `json:"category_name,omitempty"`
+	DueDate         *string                `json:"due_date,omitempty"` // yyyy-MM-dd
+	Priority        *int32                 `json:"priority,omitempty"` // 1 (urgent) to 4 (low), as in ClickUp
+	ParentTaskID    *int32                 `json:"parent_task_id,omitempty"`
+	CreatedByUserID *int32                 `json:"created_by_user_id,omitempty"`
+	ClickupTeamID   string                 `json:"clickup_team_id,omitempty"` // ClickUp workspace of the linked task
+	Assignees       []TaskAssigneeResponse `json:"assignees"`
+	Tags            []TagResponse          `json:"tags"`
+	CustomFields    map[string]string      `json:"custom_fields,omitempty"` // Mapped from ClickUp, see CLICKUP_CUSTOM_FIELDS
+	CreatedAt       pgtype.Timestamptz     `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz     `json:"updated_at"`
+}
+
+// DuplicateTaskResponse is returned with a 409 when a new task matches an existing one
+type DuplicateTaskResponse struct {
+	Error        string       `json:"error"`
+	ExistingTask TaskResponse `json:"existing_task"`
 }
 
 // TaskRequest represents the request body for creating or updating a task
 type TaskRequest struct {
-	Title          string `json:"title"`
+	Title          string `json:"title"` // Required on creation
 	Note           string `json:"note"`
 	TaskCategoryID *int32 `json:"task_category_id"`
 	Status         string `json:"status"`
 	StatusColor    string `json:"status_color"`
-	ClickupListID  string `json:"clickup_list_id,omitempty"` // Only needed for creation
-}
-
-// getClickUpClient returns a new ClickUp client
-func getClickUpClient() *clickup.Client {
-	// Check if we have an OAuth token first
-	oauthToken := os.Getenv("CLICKUP_OAUTH_TOKEN")
-	if oauthToken != "" {
-		log.Printf("Using OAuth token (first %d chars): %s...", min(10, len(oauthToken)), oauthToken[:min(10, len(oauthToken))])
-		// Create a client with the OAuth token - add Bearer prefix
-		return clickup.NewClient("Bearer " + oauthToken)
-	}
-
-	// Fall back to personal API token
-	apiToken := os.Getenv("CLICKUP_API_TOKEN")
-	if apiToken != "" {
-		log.Printf("Using personal API token (first %d chars): %s...", min(10, len(apiToken)), apiToken[:min(10, len(apiToken))])
-		return clickup.NewClient(apiToken)
-	}
-
-	// No tokens available, use disabled mode
-	log.Printf("⚠️ ClickUp integration disabled - tasks will only be created locally")
-	log.Printf("To enable, set CLICKUP_OAUTH_TOKEN or CLICKUP_API_TOKEN environment variables")
-	return clickup.NewClient("")
+	DueDate        string `json:"due_date" validate:"date"`                        // yyyy-MM-dd, empty for none
+	Priority       *int32 `json:"priority" validate:"min=1,max=4"`                 // 1 (urgent) to 4 (low), null for none
+	ParentTaskID   *int32 `json:"parent_task_id,omitempty"`                        // Only used on creation, see PUT /api/tasks/{id}/parent
+	Backend        string `json:"backend,omitempty" validate:"oneof=clickup jira"` // Tracker to create the task in: clickup (default) or jira
+	ClickupListID  string `json:"clickup_list_id,omitempty"`                       // Only needed for creation
+	ClickupTeamID  string `json:"clickup_team_id,omitempty"`                       // Workspace of the list, picks the token to create with
+	JiraProjectKey string `json:"jira_project_key,omitempty"`                      // Only needed for creation, defaults to JIRA_PROJECT_KEY
+	Url            string `json:"url,omitempty"`                                   // Existing ClickUp or Jira task to import, only used on creation
+	Force          bool   `json:"force,omitempty"`                                 // Create even when a duplicate exists
+	// updated_at of the task the edit is based on, the update fails with 409 when it changed since
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// Custom field values by local key, an empty value removes the field
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
 }
 
 // min returns the smaller of a or b
@@ -79,8 +83,8 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen]
 }
 
-func getTasks(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse pagination parameters
 	limit := 50
@@ -103,37 +107,62 @@ func getTasks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Build filters and sorting from query parameters
+	params, err := s.parseTaskListFilters(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params.RowLimit = int32(limit)
+	params.RowOffset = int32(offset)
+
 	// Get tasks from database
-	tasks, err := database.ListTasks(ctx, sqlc.ListTasksParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	tasks, err := s.store.SearchTasks(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching tasks: "+err.Error())
 		return
 	}
+	total, err := s.store.CountSearchTasks(ctx, sqlc.CountSearchTasksParams{
+		Status:      params.Status,
+		CategoryID:  params.CategoryID,
+		AssigneeID:  params.AssigneeID,
+		TagID:       params.TagID,
+		OverdueOnly: params.OverdueOnly,
+		DueFrom:     params.DueFrom,
+		DueTo:       params.DueTo,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting tasks: "+err.Error())
+		return
+	}
 
-	// Convert to response format with category names
+	// Convert to response format, category names come from the same query
 	response := make([]TaskResponse, 0, len(tasks))
-	for _, task := range tasks {
-		resp := convertTaskToResponse(task)
-
-		// If task has a category, fetch its name
-		if task.TaskCategoryID.Valid {
-			category, err := database.GetTaskCategory(ctx, task.TaskCategoryID.Int32)
-			if err == nil {
-				resp.CategoryName = category.Name
-			}
-		}
-
+	for _, row := range tasks {
+		resp := convertTaskToResponse(row.Task)
+		resp.CategoryName = row.CategoryName.String
 		response = append(response, resp)
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	// Include assignees, tags and custom fields
+	if err := s.attachTaskAssignees(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
+	}
+	if err := s.attachTaskTags(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+	if err := s.attachTaskCustomFields(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching custom fields: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
 }
 
-func getTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -142,7 +171,7 @@ func getTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := database.GetTask(ctx, int32(id))
+	task, err := s.store.GetTask(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task not found")
 		return
@@ -152,71 +181,128 @@ func getTask(w http.ResponseWriter, r *http.Request) {
 
 	// If task has a category, fetch its name
 	if task.TaskCategoryID.Valid {
-		category, err := database.GetTaskCategory(ctx, task.TaskCategoryID.Int32)
+		category, err := s.store.GetTaskCategory(ctx, task.TaskCategoryID.Int32)
 		if err == nil {
 			response.CategoryName = category.Name
 		}
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	// Include assignees, tags and custom fields
+	responses := []TaskResponse{response}
+	if err := s.attachTaskAssignees(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
+	}
+	if err := s.attachTaskTags(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+	if err := s.attachTaskCustomFields(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching custom fields: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responses[0])
 }
 
-func createTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	var req TaskRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		respondWithValidationError(w, validate.Errors{"title": "is required"})
+		return
+	}
+	dueDate, priority := taskSchedule(req)
 
-	// Debug: Read the request body into a variable so we can log it
-	var bodyBytes []byte
-	if r.Body != nil {
-		bodyBytes, _ = io.ReadAll(r.Body)
-		// Restore the body for later use
-		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	// Refuse double imports unless the client insists
+	if !req.Force {
+		existing, err := s.store.FindDuplicateTask(ctx, sqlc.FindDuplicateTaskParams{
+			Url:   pgtype.Text{String: req.Url, Valid: req.Url != ""},
+			Title: req.Title,
+		})
+		if err == nil {
+			respondWithJSON(w, http.StatusConflict, DuplicateTaskResponse{
+				Error:        "A task with the same ClickUp URL or title already exists, set force to create it anyway",
+				ExistingTask: convertTaskToResponse(existing),
+			})
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Error checking for duplicate tasks: "+err.Error())
+			return
+		}
 	}
 
-	// Log the raw request body
-	println("Raw request body:", string(bodyBytes))
+	// Subtasks must point at an existing parent
+	var parentTask *sqlc.Task
+	if req.ParentTaskID != nil {
+		parent, err := s.store.GetTask(ctx, *req.ParentTaskID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Parent task not found")
+			return
+		}
+		parentTask = &parent
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		println("Error decoding JSON:", err.Error())
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
+	// Subtasks live in the parent's workspace and tracker
+	clickupTeamID := req.ClickupTeamID
+	if parentTask != nil && parentTask.ClickupTeamID.Valid {
+		clickupTeamID = parentTask.ClickupTeamID.String
 	}
 
-	// Log the parsed request
-	reqJSON, _ := json.Marshal(req)
-	println("Parsed request:", string(reqJSON))
+	// Act in the tracker as the current user when they have connected their account
+	var currentUserID int32
+	if currentUser, err := getCurrentUserFromRequest(s.store, r); err == nil {
+		currentUserID = currentUser.ID
+	}
 
-	// Validate request
-	if req.Title == "" {
-		respondWithError(w, http.StatusBadRequest, "Title is required")
-		return
+	var (
+		backend TaskBackend
+		err     error
+	)
+	if parentTask != nil {
+		backend = s.taskBackendForTask(ctx, s.store, *parentTask, currentUserID)
+	}
+	if backend == nil {
+		backend, err = s.taskBackendNamed(ctx, s.store, req.Backend, currentUserID, clickupTeamID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
-	// First, create the task in ClickUp if a list ID is provided
-	var clickupTaskURL string
-	if req.ClickupListID != "" {
-		client := getClickUpClient()
+	remoteReq := RemoteTaskRequest{
+		Title:       req.Title,
+		Note:        req.Note,
+		Status:      req.Status,
+		ContainerID: req.ClickupListID,
+	}
+	if backend.Name() == taskBackendJira {
+		remoteReq.ContainerID = req.JiraProjectKey
+	}
 
-		// Skip ClickUp integration if we're using a dummy client
-		if client.APIKey == "" {
-			println("Skipping ClickUp task creation (integration disabled)")
+	// A subtask of a linked task is created as a subtask of the remote parent
+	if parentTask != nil && parentTask.Url.Valid {
+		remoteReq.ParentID = backend.ExtractID(parentTask.Url.String)
+	}
+
+	// First, create the task in the tracker if a list or parent is given, otherwise link the given URL
+	taskURL := req.Url
+	var remoteTask *RemoteTask
+	if remoteReq.ContainerID != "" || remoteReq.ParentID != "" {
+		if !backend.Enabled() {
+			slog.InfoContext(ctx, "Skipping remote task creation, the integration is disabled", "backend", backend.Name())
 		} else {
-			println("Creating task in ClickUp with API key:", client.APIKey[:10]+"...")
-			println("ClickUp List ID:", req.ClickupListID)
-
-			clickupTask, err := client.CreateTask(clickup.CreateTaskRequest{
-				Name:        req.Title,
-				Description: req.Note,
-				Status:      req.Status,
-				ListID:      req.ClickupListID,
-			})
+			remoteTask, err = backend.CreateTask(r.Context(), remoteReq)
 			if err != nil {
-				println("ClickUp API error:", err.Error())
-				respondWithError(w, http.StatusInternalServerError, "Error creating task in ClickUp: "+err.Error())
+				respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating task in %s: %v", backend.Name(), err))
 				return
 			}
-			clickupTaskURL = clickupTask.URL
-			println("Successfully created task in ClickUp, URL:", clickupTaskURL)
+			taskURL = remoteTask.URL
+			slog.InfoContext(ctx, "Created remote task", "backend", backend.Name(), "url", taskURL)
 		}
 	}
 
@@ -226,7 +312,9 @@ func createTask(w http.ResponseWriter, r *http.Request) {
 		Note:        pgtype.Text{String: req.Note, Valid: req.Note != ""},
 		Status:      pgtype.Text{String: req.Status, Valid: req.Status != ""},
 		StatusColor: pgtype.Text{String: req.StatusColor, Valid: req.StatusColor != ""},
-		Url:         pgtype.Text{String: clickupTaskURL, Valid: clickupTaskURL != ""},
+		Url:         pgtype.Text{String: taskURL, Valid: taskURL != ""},
+		DueDate:     dueDate,
+		Priority:    priority,
 	}
 
 	// Set task_category_id if provided
@@ -234,20 +322,44 @@ func createTask(w http.ResponseWriter, r *http.Request) {
 		params.TaskCategoryID = pgtype.Int4{Int32: *req.TaskCategoryID, Valid: true}
 	}
 
+	if parentTask != nil {
+		params.ParentTaskID = pgtype.Int4{Int32: parentTask.ID, Valid: true}
+	}
+
+	// Record the creator as the task owner
+	if currentUser, err := getCurrentUserFromRequest(s.store, r); err == nil {
+		params.CreatedByUserID = pgtype.Int4{Int32: currentUser.ID, Valid: true}
+	}
+
 	// Create task in database
-	task, err := database.CreateTask(ctx, params)
+	task, err := s.store.CreateTask(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating task: "+err.Error())
 		return
 	}
 
+	for key, value := range req.CustomFields {
+		if err := saveTaskCustomField(ctx, s.store, task.ID, strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error storing custom fields: "+err.Error())
+			return
+		}
+	}
+
+	// Both sides start out identical, so record them as in sync
+	if remoteTask != nil {
+		s.markTaskRemoteSynced(ctx, task.ID, remoteTask)
+		if remoteTask.TeamID != "" {
+			task.ClickupTeamID = pgtype.Text{String: remoteTask.TeamID, Valid: true}
+		}
+	}
+
 	response := convertTaskToResponse(task)
 
 	respondWithJSON(w, http.StatusCreated, response)
 }
 
-func updateTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -257,37 +369,45 @@ func updateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req TaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
+	dueDate, priority := taskSchedule(req)
+
 	// First, get the existing task
-	existingTask, err := database.GetTask(ctx, int32(id))
+	existingTask, err := s.store.GetTask(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
-	// If the task has a ClickUp URL, update the task in ClickUp
-	if existingTask.Url.Valid && existingTask.Url.String != "" {
-		taskID := clickup.ExtractTaskIDFromURL(existingTask.Url.String)
-		if taskID != "" {
-			client := getClickUpClient()
-			updateData := map[string]interface{}{
-				"name":        req.Title,
-				"description": req.Note,
-			}
+	currentUser, ok := s.authorizeTaskChange(ctx, w, r, existingTask)
+	if !ok {
+		return
+	}
 
-			if req.Status != "" {
-				updateData["status"] = req.Status
-			}
+	// Refuse a stale edit before it reaches the tracker
+	if req.UpdatedAt != nil && !existingTask.UpdatedAt.Time.Equal(*req.UpdatedAt) {
+		respondWithConflict(w, "Task was changed by someone else", convertTaskToResponse(existingTask))
+		return
+	}
 
-			_, err := client.UpdateTask(taskID, updateData)
-			if err != nil {
-				// Log the error but continue with local update
-				// We don't want to block local updates if ClickUp sync fails
-			}
+	// If the task is linked to a tracker, update the task there too
+	var remoteTask *RemoteTask
+	if backend := s.taskBackendForTask(ctx, s.store, existingTask, currentUser.ID); backend != nil && backend.Enabled() {
+		remoteTask, err = backend.UpdateTask(r.Context(), backend.ExtractID(existingTask.Url.String), RemoteTaskRequest{
+			Title:        req.Title,
+			Note:         req.Note,
+			Status:       req.Status,
+			CustomFields: req.CustomFields,
+		})
+		if err != nil {
+			// Log the error but continue with local update
+			// We don't want to block local updates if the tracker sync fails
+			// The periodic task sync will push the change later
+			slog.WarnContext(ctx, "Failed to update remote task", "task_id", id, "backend", backend.Name(), "error", err)
+			remoteTask = nil
 		}
 	}
 
@@ -298,8 +418,11 @@ func updateTask(w http.ResponseWriter, r *http.Request) {
 		Note:        pgtype.Text{String: req.Note, Valid: req.Note != ""},
 		Status:      pgtype.Text{String: req.Status, Valid: req.Status != ""},
 		StatusColor: pgtype.Text{String: req.StatusColor, Valid: req.StatusColor != ""},
+		DueDate:     dueDate,
+		Priority:    priority,
 		// Keep the existing URL
-		Url: existingTask.Url,
+		Url:               existingTask.Url,
+		ExpectedUpdatedAt: expectedUpdatedAt(req.UpdatedAt),
 	}
 
 	// Set task_category_id if provided
@@ -310,19 +433,57 @@ func updateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update task in database
-	task, err := database.UpdateTask(ctx, params)
+	task, err := s.store.UpdateTask(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if current, err := s.store.GetTask(ctx, int32(id)); err == nil {
+			respondWithConflict(w, "Task was changed by someone else", convertTaskToResponse(current))
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating task: "+err.Error())
 		return
 	}
 
-	response := convertTaskToResponse(task)
+	for key, value := range req.CustomFields {
+		if err := saveTaskCustomField(ctx, s.store, task.ID, strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error updating custom fields: "+err.Error())
+			return
+		}
+	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	// The change already reached the tracker, so the next sync has nothing to push
+	if remoteTask != nil {
+		s.markTaskRemoteSynced(ctx, task.ID, remoteTask)
+	}
+
+	// Record status changes in the activity feed
+	if task.Status.String != existingTask.Status.String {
+		recordTaskActivity(ctx, s.store, task.ID, currentUser.ID, taskActivityStatusChanged, existingTask.Status.String, task.Status.String, 0)
+	}
+
+	// Include assignees, tags and custom fields
+	responses := []TaskResponse{convertTaskToResponse(task)}
+	if err := s.attachTaskAssignees(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
+	}
+	if err := s.attachTaskTags(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+	if err := s.attachTaskCustomFields(ctx, responses); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching custom fields: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responses[0])
 }
 
-func deleteTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -331,15 +492,24 @@ func deleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the task first to check if it has a ClickUp URL
-	task, err := database.GetTask(ctx, int32(id))
-	if err == nil && task.Url.Valid && task.Url.String != "" {
+	// Get the task first to check permissions and whether it has a ClickUp URL
+	task, err := s.store.GetTask(ctx, int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	if task.Url.Valid && task.Url.String != "" {
 		// If we wanted to delete in ClickUp too, we would do it here
 		// But ClickUp doesn't support DELETE for tasks, only archiving
 		// So we'll just delete locally
 	}
 
-	if err := database.DeleteTask(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteTask(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting task: "+err.Error())
 		return
 	}
@@ -347,8 +517,8 @@ func deleteTask(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
 
-func getTasksByCategory(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTasksByCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	categoryID, err := strconv.Atoi(vars["category_id"])
@@ -358,45 +528,39 @@ func getTasksByCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if the category exists
-	_, err = database.GetTaskCategory(ctx, int32(categoryID))
+	_, err = s.store.GetTaskCategory(ctx, int32(categoryID))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Category not found")
 		return
 	}
 
-	// Get tasks by category including all subcategories in a single query
-	tasks, err := database.ListTasksByCategoryWithSubcategories(ctx, int32(categoryID))
+	// Get tasks by category including all subcategories and their category names in a single query
+	tasks, err := s.store.ListTasksByCategoryWithSubcategories(ctx, int32(categoryID))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching tasks: "+err.Error())
 		return
 	}
 
-	// Get all categories to map IDs to names
-	allCategories, err := database.ListTaskCategories(ctx, sqlc.ListTaskCategoriesParams{})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error fetching categories: "+err.Error())
-		return
+	// Convert to response format, category names come from the same query
+	response := make([]TaskResponse, 0, len(tasks))
+	for _, row := range tasks {
+		resp := convertTaskToResponse(row.Task)
+		resp.CategoryName = row.CategoryName
+		response = append(response, resp)
 	}
 
-	// Create a map of category IDs to names
-	categoryMap := make(map[int32]string)
-	for _, cat := range allCategories {
-		categoryMap[cat.ID] = cat.Name
+	// Include assignees, tags and custom fields
+	if err := s.attachTaskAssignees(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
 	}
-
-	// Convert to response format with category name
-	response := make([]TaskResponse, 0, len(tasks))
-	for _, task := range tasks {
-		resp := convertTaskToResponse(task)
-
-		// Set the category name
-		if task.TaskCategoryID.Valid {
-			if name, ok := categoryMap[task.TaskCategoryID.Int32]; ok {
-				resp.CategoryName = name
-			}
-		}
-
-		response = append(response, resp)
+	if err := s.attachTaskTags(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+	if err := s.attachTaskCustomFields(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching custom fields: "+err.Error())
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
@@ -409,15 +573,115 @@ func convertTaskToResponse(task sqlc.Task) TaskResponse {
 		taskCategoryID = &task.TaskCategoryID.Int32
 	}
 
+	var dueDate *string
+	if task.DueDate.Valid {
+		formatted := task.DueDate.Time.Format("2006-01-02")
+		dueDate = &formatted
+	}
+
+	var priority *int32
+	if task.Priority.Valid {
+		priority = &task.Priority.Int32
+	}
+
+	var parentTaskID *int32
+	if task.ParentTaskID.Valid {
+		parentTaskID = &task.ParentTaskID.Int32
+	}
+
+	var createdByUserID *int32
+	if task.CreatedByUserID.Valid {
+		createdByUserID = &task.CreatedByUserID.Int32
+	}
+
 	return TaskResponse{
-		ID:             task.ID,
-		Url:            task.Url.String,
-		TaskCategoryID: taskCategoryID,
-		Note:           task.Note.String,
-		Title:          task.Title.String,
-		Status:         task.Status.String,
-		StatusColor:    task.StatusColor.String,
-		CreatedAt:      task.CreatedAt,
-		UpdatedAt:      task.UpdatedAt,
+		ID:              task.ID,
+		Url:             task.Url.String,
+		TaskCategoryID:  taskCategoryID,
+		Note:            task.Note.String,
+		Title:           task.Title.String,
+		Status:          task.Status.String,
+		StatusColor:     task.StatusColor.String,
+		DueDate:         dueDate,
+		Priority:        priority,
+		ParentTaskID:    parentTaskID,
+		CreatedByUserID: createdByUserID,
+		ClickupTeamID:   task.ClickupTeamID.String,
+		Assignees:       []TaskAssigneeResponse{},
+		Tags:            []TagResponse{},
+		CreatedAt:       task.CreatedAt,
+		UpdatedAt:       task.UpdatedAt,
 	}
 }
+
+// markTaskRemoteSynced records that a local task matches its tracker task after a push
+func (s *Server) markTaskRemoteSynced(ctx context.Context, taskID int32, remoteTask *RemoteTask) {
+	err := s.store.MarkTaskClickUpSynced(ctx, sqlc.MarkTaskClickUpSyncedParams{
+		ID:              taskID,
+		RemoteUpdatedAt: pgtype.Timestamptz{Time: remoteTask.UpdatedAt, Valid: !remoteTask.UpdatedAt.IsZero()},
+		TeamID:          pgtype.Text{String: remoteTask.TeamID, Valid: remoteTask.TeamID != ""},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to mark task as synced", "task_id", taskID, "error", err)
+	}
+}
+
+// taskSchedule converts the due date and priority of a validated task request, NULL when not set
+func taskSchedule(req TaskRequest) (pgtype.Date, pgtype.Int4) {
+	var priority pgtype.Int4
+	if req.Priority != nil {
+		priority = pgtype.Int4{Int32: *req.Priority, Valid: true}
+	}
+	return requestDate(req.DueDate), priority
+}
+
+// taskListSpec declares the filters and sort fields of GET /api/tasks. assignee is a user ID or
+// "me", and overdue=true keeps the open tasks past their due date.
+var taskListSpec = listSpec{
+	Filters: map[string]filterKind{
+		"status":      textFilter,
+		"category_id": intFilter,
+		"tag_id":      intFilter,
+		"assignee":    textFilter,
+		"overdue":     boolFilter,
+		"due_date":    dateFilter,
+	},
+	Sorts: []string{"created_at", "updated_at", "due_date", "priority", "title"},
+	Sort:  "-created_at",
+}
+
+// parseTaskListFilters reads the filter and sort query parameters of GET /api/tasks
+func (s *Server) parseTaskListFilters(r *http.Request) (sqlc.SearchTasksParams, error) {
+	query, err := parseListQuery(r.URL.Query(), taskListSpec)
+	if err != nil {
+		return sqlc.SearchTasksParams{}, err
+	}
+
+	params := sqlc.SearchTasksParams{
+		Status:      query.text("status"),
+		CategoryID:  query.int4("category_id"),
+		TagID:       query.int4("tag_id"),
+		OverdueOnly: query.boolean("overdue"),
+		SortBy:      query.SortBy,
+		SortDesc:    query.SortDesc,
+	}
+	params.DueFrom, params.DueTo = query.dates("due_date")
+
+	if assignee := query.text("assignee"); assignee.Valid {
+		if assignee.String == "me" {
+			currentUser, err := getCurrentUserFromRequest(s.store, r)
+			if err != nil {
+				return params, fmt.Errorf("authentication required for assignee=me")
+			}
+			params.AssigneeID = pgtype.Int4{Int32: currentUser.ID, Valid: true}
+		} else {
+			assigneeID, err := strconv.Atoi(assignee.String)
+			if err != nil {
+				return params, fmt.Errorf("invalid assignee")
+			}
+			params.AssigneeID = pgtype.Int4{Int32: int32(assigneeID), Valid: true}
+		}
+	}
+
+	return params, nil
+}
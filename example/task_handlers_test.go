@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/example/clickup/clickuptest"
+)
+
+func TestCreateTaskInClickUp(t *testing.T) {
+	fake := clickuptest.NewFake()
+	handler, store := newTestServer(t, fake)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+
+	var created TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", tokenFor(user.Username), TaskRequest{
+		Title:         "Write the report",
+		Note:          "Q3 numbers",
+		ClickupListID: "list-1",
+	}), http.StatusCreated, &created)
+
+	if created.Url == "" {
+		t.Fatal("the task isn't linked to ClickUp")
+	}
+	remote := fake.Task(clickup.ExtractTaskIDFromURL(created.Url))
+	if remote == nil {
+		t.Fatalf("no ClickUp task at %s", created.Url)
+	}
+	if remote.Name != "Write the report" || remote.Description != "Q3 numbers" || remote.ListID != "list-1" {
+		t.Errorf("ClickUp task = %q %q in %q, want the title and note in list-1", remote.Name, remote.Description, remote.ListID)
+	}
+}
+
+func TestUpdateTaskPushesToClickUp(t *testing.T) {
+	fake := clickuptest.NewFake()
+	remote := fake.AddTask(clickup.ClickUpTask{Name: "Old title"})
+	handler, store := newTestServer(t, fake)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+
+	var created TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{
+		Title: "Old title",
+		Url:   remote.URL,
+	}), http.StatusCreated, &created)
+
+	var updated TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/tasks/%d", created.ID), token, TaskRequest{
+		Title: "New title",
+		Note:  "More detail",
+	}), http.StatusOK, &updated)
+
+	if updated.Title != "New title" {
+		t.Errorf("local title = %q, want %q", updated.Title, "New title")
+	}
+	if got := fake.Task(remote.ID); got.Name != "New title" || got.Description != "More detail" {
+		t.Errorf("ClickUp task = %q %q, want the new title and note", got.Name, got.Description)
+	}
+}
+
+func TestDisabledClickUpKeepsTasksLocal(t *testing.T) {
+	fake := clickuptest.NewFake()
+	fake.Disabled = true
+	handler, store := newTestServer(t, fake)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+
+	var created TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{
+		Title:         "Local only",
+		ClickupListID: "list-1",
+	}), http.StatusCreated, &created)
+	if created.Url != "" {
+		t.Errorf("url = %q, want none while ClickUp is disabled", created.Url)
+	}
+
+	// A task linked before ClickUp was disabled is still updated locally
+	var linked TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{
+		Title: "Linked",
+		Url:   "https://app.clickup.com/t/abc123",
+	}), http.StatusCreated, &linked)
+	var updated TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/tasks/%d", linked.ID), token, TaskRequest{
+		Title: "Renamed",
+	}), http.StatusOK, &updated)
+	if updated.Title != "Renamed" {
+		t.Errorf("title = %q, want %q", updated.Title, "Renamed")
+	}
+
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("ClickUp was called while disabled: %v", calls)
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	user := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(user.Username)
+
+	var created TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{
+		Title:    "Plan the sprint",
+		DueDate:  "2025-03-14",
+		Priority: ptr(int32(2)),
+	}), http.StatusCreated, &created)
+	if created.CreatedByUserID == nil || *created.CreatedByUserID != user.ID {
+		t.Errorf("created_by_user_id = %v, want %d", created.CreatedByUserID, user.ID)
+	}
+	if created.DueDate == nil || *created.DueDate != "2025-03-14" {
+		t.Errorf("due_date = %v, want 2025-03-14", created.DueDate)
+	}
+
+	path := fmt.Sprintf("/api/tasks/%d", created.ID)
+	var got TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, token, nil), http.StatusOK, &got)
+	if got.Title != "Plan the sprint" {
+		t.Errorf("title = %q, want %q", got.Title, "Plan the sprint")
+	}
+
+	var list Page[TaskResponse]
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, "/api/tasks", token, nil), http.StatusOK, &list)
+	if list.Total != 1 || len(list.Data) != 1 || list.Data[0].ID != created.ID {
+		t.Errorf("listed %+v, want only task %d", list, created.ID)
+	}
+
+	var updated TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, token, TaskRequest{
+		Title:     "Plan the next sprint",
+		UpdatedAt: &got.UpdatedAt.Time,
+	}), http.StatusOK, &updated)
+	if updated.Title != "Plan the next sprint" {
+		t.Errorf("title = %q, want %q", updated.Title, "Plan the next sprint")
+	}
+
+	// An edit based on the task as it was before the update conflicts
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, token, TaskRequest{
+		Title:     "Stale edit",
+		UpdatedAt: &got.UpdatedAt.Time,
+	}), http.StatusConflict, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, token, nil), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, path, token, nil), http.StatusNotFound, nil)
+}
+
+func TestOnlyOwnersChangeTasks(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+
+	var owned TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", tokenFor(alice.Username), TaskRequest{Title: "Alice's task"}), http.StatusCreated, &owned)
+
+	// A task created before ownership was recorded has no owner
+	legacy, err := store.CreateTask(t.Context(), sqlc.CreateTaskParams{Title: pgtype.Text{String: "Legacy task", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int32{owned.ID, legacy.ID} {
+		path := fmt.Sprintf("/api/tasks/%d", id)
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, path, tokenFor(bob.Username), TaskRequest{Title: "Taken over"}), http.StatusForbidden, nil)
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodDelete, path, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/tasks/%d", legacy.ID), tokenFor(alice.Username), TaskRequest{Title: "Taken over"}), http.StatusForbidden, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/tasks/%d", legacy.ID), tokenFor(admin.Username), TaskRequest{Title: "Legacy task, renamed"}), http.StatusOK, nil)
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPut, fmt.Sprintf("/api/tasks/%d", owned.ID), tokenFor(alice.Username), TaskRequest{Title: "Alice's task, renamed"}), http.StatusOK, nil)
+}
+
+func TestCreateTaskValidation(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	token := tokenFor(dbtest.CreateUser(t, store, "alice", "user").Username)
+
+	tests := []struct {
+		name  string
+		req   TaskRequest
+		field string
+	}{
+		{name: "no title", req: TaskRequest{Title: "  "}, field: "title"},
+		{name: "bad due date", req: TaskRequest{Title: "x", DueDate: "14/03/2025"}, field: "due_date"},
+		{name: "priority out of range", req: TaskRequest{Title: "x", Priority: ptr(int32(5))}, field: "priority"},
+		{name: "unknown backend", req: TaskRequest{Title: "x", Backend: "trello"}, field: "backend"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response ValidationErrorResponse
+			dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, tt.req), http.StatusUnprocessableEntity, &response)
+			if _, ok := response.Fields[tt.field]; !ok {
+				t.Errorf("fields = %v, want an error for %s", response.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestCreateDuplicateTask(t *testing.T) {
+	handler, store := newTestServer(t, nil)
+	token := tokenFor(dbtest.CreateUser(t, store, "alice", "user").Username)
+
+	var first TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: "Fix the login page"}), http.StatusCreated, &first)
+
+	var duplicate DuplicateTaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: "fix the LOGIN page!"}), http.StatusConflict, &duplicate)
+	if duplicate.ExistingTask.ID != first.ID {
+		t.Errorf("existing task = %d, want %d", duplicate.ExistingTask.ID, first.ID)
+	}
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{Title: "fix the LOGIN page!", Force: true}), http.StatusCreated, nil)
+}
+
+func TestOnlyTaskChangersSyncTasks(t *testing.T) {
+	fake := clickuptest.NewFake()
+	remote := fake.AddTask(clickup.ClickUpTask{Name: "Remote title"})
+	handler, store := newTestServer(t, fake)
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	bob := dbtest.CreateUser(t, store, "bob", "user")
+
+	var created TaskResponse
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", tokenFor(alice.Username), TaskRequest{
+		Title: "Local title",
+		Url:   remote.URL,
+	}), http.StatusCreated, &created)
+	path := fmt.Sprintf("/api/tasks/%d/clickup-sync", created.ID)
+
+	calls := len(fake.Calls())
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, tokenFor(bob.Username), nil), http.StatusForbidden, nil)
+	if got := fake.Calls()[calls:]; len(got) != 0 {
+		t.Errorf("ClickUp was called for a forbidden sync: %v", got)
+	}
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, "", nil), http.StatusUnauthorized, nil)
+
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, tokenFor(alice.Username), nil), http.StatusOK, nil)
+}
+
+func TestImportClickUpList(t *testing.T) {
+	fake := clickuptest.NewFake()
+	fake.TaskPageSize = 2
+	server := clickuptest.NewServer(fake)
+	defer server.Close()
+	handler, store := newTestServer(t, clickuptest.NewClient(server))
+	admin := dbtest.CreateUser(t, store, "root", "admin")
+	alice := dbtest.CreateUser(t, store, "alice", "user")
+	token := tokenFor(admin.Username)
+
+	updated := time.Date(2026, time.April, 1, 8, 0, 0, 250e6, time.UTC)
+	var remotes []*clickup.ClickUpTask
+	for i := range 5 {
+		remotes = append(remotes, fake.AddTask(clickup.ClickUpTask{
+			Name:        fmt.Sprintf("Reconcile account %d", i),
+			ListID:      "901",
+			Status:      clickup.Status{Status: "to do"},
+			TeamID:      "team-1",
+			DateUpdated: clickup.Timestamp{Time: updated.Add(time.Duration(i) * time.Minute)},
+		}))
+	}
+
+	// A task already linked to one of them is left alone
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, "/api/tasks", token, TaskRequest{
+		Title: "Linked by hand",
+		Url:   remotes[2].URL,
+	}), http.StatusCreated, nil)
+
+	path := "/api/clickup/workspaces/team-1/lists/901/import"
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, tokenFor(alice.Username), nil), http.StatusForbidden, nil)
+
+	var summary TaskImportSummary
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, token, nil), http.StatusOK, &summary)
+	if summary.Total != 5 || summary.Imported != 4 || summary.Skipped != 1 || summary.Failed != 0 || len(summary.TaskIDs) != 4 {
+		t.Fatalf("summary = %+v, want 4 of the 5 tasks over 3 pages imported", summary)
+	}
+
+	for i, id := range summary.TaskIDs {
+		remote := remotes[i]
+		if i >= 2 {
+			remote = remotes[i+1]
+		}
+		task, err := store.GetTask(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Title.String != remote.Name || task.Url.String != remote.URL || task.Status.String != "to do" || task.ClickupTeamID.String != "team-1" {
+			t.Errorf("task %d = %q %q %q in %q, want it linked to %s", id, task.Title.String, task.Url.String, task.Status.String, task.ClickupTeamID.String, remote.URL)
+		}
+
+		// The millisecond dates ClickUp sent are kept exactly
+		var history []TaskSyncHistoryResponse
+		dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodGet, fmt.Sprintf("/api/tasks/%d/sync-history", id), token, nil), http.StatusOK, &history)
+		if len(history) != 1 || history[0].RemoteUpdatedAt == nil || !history[0].RemoteUpdatedAt.Equal(remote.DateUpdated.Time) {
+			t.Errorf("sync history of task %d = %+v, want the import at %v", id, history, remote.DateUpdated.Time)
+		}
+	}
+
+	// Importing again finds every task already there
+	dbtest.DecodeJSON(t, dbtest.DoJSON(t, handler, http.MethodPost, path, token, nil), http.StatusOK, &summary)
+	if summary.Imported != 0 || summary.Skipped != 5 {
+		t.Errorf("summary of the second import = %+v, want every task skipped", summary)
+	}
+}
@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/approval"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
 	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/example/clickup"
+	"github.com/kengtableg/pkeng-tableg/validate"
 )
 
 // TaskLogResponse is the response format for task log data
@@ -29,63 +32,96 @@ type TaskLogResponse struct {
 
 // TaskLogRequest represents the request body for creating or updating a task log
 type TaskLogRequest struct {
-	TaskID          int32   `json:"task_id"`
-	WorkedDay       float64 `json:"worked_day"`
-	WorkedDate      string  `json:"worked_date"` // Changed to string to match frontend format
+	TaskID          int32   `json:"task_id"` // Only read when creating
+	WorkedDay       float64 `json:"worked_day" validate:"gt=0"`
+	WorkedDate      string  `json:"worked_date" validate:"required,date"` // Changed to string to match frontend format
 	IsWorkOnHoliday bool    `json:"is_work_on_holiday"`
 }
 
-// Validate that total time logged for a date doesn't exceed 1 day
-func validateDayLimit(ctx context.Context, userID int32, date time.Time, workedDay float64, excludeLogID int32) error {
-	// Format the date as a string in the format needed for database queries
-	dateStr := date.Format("2006-01-02")
-
-	// Query task logs for this date and user (excluding the current log if updating)
-	query := `
-		SELECT COALESCE(SUM(CAST(worked_day AS float8)), 0)
-		FROM task_logs
-		WHERE 
-			created_by_user_id = $1 AND 
-			CAST(worked_date AS DATE) = $2 AND
-			($3 = 0 OR id != $3)
-	`
-	var taskLogsTotal float64
-	err := database.Pool.QueryRow(ctx, query, userID, dateStr, excludeLogID).Scan(&taskLogsTotal)
+// Validate that total time logged for a date doesn't exceed the user's daily capacity
+func (s *Server) validateDayLimit(ctx context.Context, userID int32, date time.Time, workedDay float64, excludeLogID int32) error {
+	// Get the user's daily capacity (1.0 for full-time staff)
+	capacity, err := s.getUserDailyCapacity(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	workedDate := pgtype.Date{Time: date, Valid: true}
+
+	// Sum the task logs for this date and user (excluding the current log if updating)
+	taskLogsTotal, err := s.store.SumTaskLogWorkedDaysForDate(ctx, sqlc.SumTaskLogWorkedDaysForDateParams{
+		UserID:     userID,
+		WorkedDate: workedDate,
+		ExcludeID:  excludeLogID,
+	})
 	if err != nil {
 		return fmt.Errorf("error querying task logs: %w", err)
 	}
 
-	// Query leave logs for this date and user
-	leaveQuery := `
-		SELECT COUNT(*)
-		FROM leave_logs
-		WHERE 
-			user_id = $1 AND 
-			CAST(date AS DATE) = $2
-	`
-	var leaveLogsCount int
-	err = database.Pool.QueryRow(ctx, leaveQuery, userID, dateStr).Scan(&leaveLogsCount)
+	// Count the leave logs for this date and user
+	leaveLogsCount, err := s.store.CountLeaveLogsForDate(ctx, sqlc.CountLeaveLogsForDateParams{
+		UserID: userID,
+		Date:   workedDate,
+	})
 	if err != nil {
 		return fmt.Errorf("error querying leave logs: %w", err)
 	}
 
-	// Each leave log counts as 1 day
-	leaveLogsTotal := float64(leaveLogsCount)
+	// Each leave log consumes a full day of capacity
+	leaveLogsTotal := float64(leaveLogsCount) * capacity
 
 	// Calculate total time
 	totalTime := taskLogsTotal + leaveLogsTotal + workedDay
 
-	// If total exceeds 1 day, return an error
-	if totalTime > 1.0 {
-		return fmt.Errorf("total time logged for this date would exceed 1 day (current: %.2f + new: %.2f = %.2f)",
-			taskLogsTotal+leaveLogsTotal, workedDay, totalTime)
+	// If total exceeds the daily capacity, return an error
+	if totalTime > capacity {
+		return fmt.Errorf("total time logged for this date would exceed daily capacity of %.2f day (current: %.2f + new: %.2f = %.2f)",
+			capacity, taskLogsTotal+leaveLogsTotal, workedDay, totalTime)
 	}
 
 	return nil
 }
 
-func getTaskLogs(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// getUserDailyCapacity returns how many days of work a user logs per calendar day
+func (s *Server) getUserDailyCapacity(ctx context.Context, userID int32) (float64, error) {
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching user: %w", err)
+	}
+
+	return numericToFloat64(user.DailyCapacity, 1.0), nil
+}
+
+// numericToFloat64 converts a numeric value to float64, falling back to def when it is NULL
+func numericToFloat64(n pgtype.Numeric, def float64) float64 {
+	value, err := pgconv.ToFloat(n)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// numericPtr returns the value of a nullable numeric, or nil when it is NULL
+func numericPtr(n pgtype.Numeric) *float64 {
+	value, err := pgconv.ToFloat(n)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// taskLogListSpec declares the filters and sort fields of GET /api/task-logs
+var taskLogListSpec = listSpec{
+	Filters: map[string]filterKind{
+		"task_id":     intFilter,
+		"worked_date": dateFilter,
+	},
+	Sorts: []string{"worked_date", "worked_day", "created_at"},
+	Sort:  "-worked_date",
+}
+
+func (s *Server) getTaskLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse pagination parameters
 	limit := 50
@@ -109,70 +145,120 @@ func getTaskLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user from request to use for filtering
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Get task logs from database for this user
-	logs, err := database.ListTaskLogsByUser(ctx, sqlc.ListTaskLogsByUserParams{
-		CreatedByUserID: currentUser.ID,
-		Limit:           int32(limit),
-		Offset:          int32(offset),
+	query, err := parseListQuery(r.URL.Query(), taskLogListSpec)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := sqlc.CountTaskLogsByUserParams{UserID: currentUser.ID, TaskID: query.int4("task_id")}
+	filter.WorkedFrom, filter.WorkedTo = query.dates("worked_date")
+
+	if cursorRequested(r) {
+		if query.Sorted {
+			respondWithError(w, http.StatusBadRequest, "sort can't be used with cursor, cursor pages are newest first")
+			return
+		}
+		s.getTaskLogsPage(w, r, filter, limit)
+		return
+	}
+
+	// Get task logs from database for this user, joined with task titles and usernames
+	logs, err := s.store.ListTaskLogsWithDetailsByUser(ctx, sqlc.ListTaskLogsWithDetailsByUserParams{
+		UserID:     filter.UserID,
+		TaskID:     filter.TaskID,
+		WorkedFrom: filter.WorkedFrom,
+		WorkedTo:   filter.WorkedTo,
+		SortBy:     query.SortBy,
+		SortDesc:   query.SortDesc,
+		RowLimit:   int32(limit),
+		RowOffset:  int32(offset),
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
 		return
 	}
+	total, err := s.store.CountTaskLogsByUser(ctx, filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting task logs: "+err.Error())
+		return
+	}
 
-	// Convert to response format with enriched data
+	// Convert to response format
 	response := make([]TaskLogResponse, 0, len(logs))
 	for _, log := range logs {
-		// Convert numeric to float64
-		workedDay, _ := log.WorkedDay.Float64Value()
-		workedDayValue := float64(0)
-		if workedDay.Valid {
-			workedDayValue = workedDay.Float64
-		}
+		response = append(response, newTaskLogResponse(sqlc.TaskLog{
+			ID:              log.ID,
+			TaskID:          log.TaskID,
+			WorkedDay:       log.WorkedDay,
+			CreatedByUserID: log.CreatedByUserID,
+			WorkedDate:      log.WorkedDate,
+			CreatedAt:       log.CreatedAt,
+			IsWorkOnHoliday: log.IsWorkOnHoliday,
+		}, log.Username, log.TaskTitle.String))
+	}
 
-		// Check if worked date is valid
-		var workedDate time.Time
-		if log.WorkedDate.Valid {
-			workedDate = log.WorkedDate.Time.UTC()
-		}
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
+}
 
-		// Check if holiday flag is valid
-		isWorkOnHoliday := false
-		if log.IsWorkOnHoliday.Valid {
-			isWorkOnHoliday = log.IsWorkOnHoliday.Bool
-		}
+// getTaskLogsPage answers getTaskLogs paged by cursor, newest logs first, keeping the logs filter
+// matches
+func (s *Server) getTaskLogsPage(w http.ResponseWriter, r *http.Request, filter sqlc.CountTaskLogsByUserParams, limit int) {
+	cursor, err := decodePageCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursor.keysetArgs()
+	logs, err := s.store.ListTaskLogsWithDetailsByUserAfter(r.Context(), sqlc.ListTaskLogsWithDetailsByUserAfterParams{
+		UserID:          filter.UserID,
+		TaskID:          filter.TaskID,
+		WorkedFrom:      filter.WorkedFrom,
+		WorkedTo:        filter.WorkedTo,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		RowLimit:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
+		return
+	}
+	total, err := s.store.CountTaskLogsByUser(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting task logs: "+err.Error())
+		return
+	}
+
+	var next *string
+	if len(logs) > limit {
+		logs = logs[:limit]
+		next = nextCursor(logs[limit-1].CreatedAt, logs[limit-1].ID)
+	}
 
-		resp := TaskLogResponse{
+	response := make([]TaskLogResponse, 0, len(logs))
+	for _, log := range logs {
+		response = append(response, newTaskLogResponse(sqlc.TaskLog{
 			ID:              log.ID,
 			TaskID:          log.TaskID,
-			WorkedDay:       workedDayValue,
+			WorkedDay:       log.WorkedDay,
 			CreatedByUserID: log.CreatedByUserID,
-			WorkedDate:      workedDate,
-			IsWorkOnHoliday: isWorkOnHoliday,
+			WorkedDate:      log.WorkedDate,
 			CreatedAt:       log.CreatedAt,
-			Username:        currentUser.Username, // Set the current user's username
-		}
-
-		// Get task info to enrich the response
-		task, err := database.GetTask(ctx, log.TaskID)
-		if err == nil && task.Title.Valid {
-			resp.TaskTitle = task.Title.String
-		}
-
-		response = append(response, resp)
+			IsWorkOnHoliday: log.IsWorkOnHoliday,
+		}, log.Username, log.TaskTitle.String))
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, newCursorPage(response, total, limit, next))
 }
 
-func getTaskLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -181,14 +267,14 @@ func getTaskLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log, err := database.GetTaskLog(ctx, int32(id))
+	log, err := s.store.GetTaskLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task log not found")
 		return
 	}
 
 	// Get user who created this log
-	user, err := database.GetUser(ctx, log.CreatedByUserID)
+	user, err := s.store.GetUser(ctx, log.CreatedByUserID)
 	if err != nil {
 		// Continue even if we can't get the user
 		user = sqlc.User{
@@ -197,7 +283,7 @@ func getTaskLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get task info
-	task, err := database.GetTask(ctx, log.TaskID)
+	task, err := s.store.GetTask(ctx, log.TaskID)
 	taskTitle := ""
 	if err == nil && task.Title.Valid {
 		taskTitle = task.Title.String
@@ -237,53 +323,47 @@ func getTaskLog(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func createTaskLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) createTaskLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	var req TaskLogRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.TaskID == 0 {
+		respondWithValidationError(w, validate.Errors{"task_id": "is required"})
 		return
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Validate request
-	if req.WorkedDay <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Worked day must be positive")
-		return
-	}
-
-	// Parse date from string (yyyy-MM-dd format)
-	workedDate, err := time.Parse("2006-01-02", req.WorkedDate)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Expected yyyy-MM-dd")
-		return
-	}
+	workedDate := requestDate(req.WorkedDate).Time
 
 	// Validate time limit for the day
-	err = validateDayLimit(ctx, currentUser.ID, workedDate, req.WorkedDay, 0)
+	err = s.validateDayLimit(ctx, currentUser.ID, workedDate, req.WorkedDay, 0)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Check if task exists
-	_, err = database.GetTask(ctx, req.TaskID)
+	task, err := s.store.GetTask(ctx, req.TaskID)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Task not found")
 		return
 	}
 
 	// Prepare numeric value
-	workedDay := pgtype.Numeric{}
-	workedDay.Valid = true
-	workedDay.Scan(strconv.FormatFloat(req.WorkedDay, 'f', -1, 64))
+	workedDay, err := pgconv.FromFloat(req.WorkedDay)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid worked day: "+err.Error())
+		return
+	}
 
 	// Create task log in database
 	params := sqlc.CreateTaskLogParams{
@@ -294,7 +374,7 @@ func createTaskLog(w http.ResponseWriter, r *http.Request) {
 		IsWorkOnHoliday: pgtype.Bool{Bool: req.IsWorkOnHoliday, Valid: true},
 	}
 
-	log, err := database.CreateTaskLog(ctx, params)
+	log, err := s.store.CreateTaskLog(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating task log: "+err.Error())
 		return
@@ -330,14 +410,41 @@ func createTaskLog(w http.ResponseWriter, r *http.Request) {
 		Username:        currentUser.Username,
 	}
 
-	// Add sync function to call after changes
-	syncTaskLogUser(ctx, currentUser.ID, workedDate)
+	recordTaskActivity(ctx, s.store, log.TaskID, currentUser.ID, taskActivityLogAdded, "", formatDays(workedDayFloat), log.ID)
+
+	// Submit the timesheet entry for approval, admins' own aside
+	if currentUser.UserType != "admin" {
+		s.submitApproval(ctx, approval.Submission{
+			Kind:        approval.KindTimesheet,
+			SubjectID:   log.ID,
+			RequesterID: currentUser.ID,
+			Amount:      workedDayFloat,
+		})
+	}
+
+	// Sync the annual record for the logged year
+	s.events.Publish(ctx, annualRecordChangeFor(currentUser.ID, workedDate))
+
+	// Track the time in ClickUp in the background, retrying while ClickUp is unreachable
+	if s.clickUpTimeSyncEnabled() && clickup.ExtractTaskIDFromURL(task.Url.String) != "" {
+		s.enqueue(ctx, jobClickUpTimeEntry, clickUpTimeEntryJob{
+			UserID:     currentUser.ID,
+			TaskID:     task.ID,
+			WorkedDay:  workedDayFloat,
+			WorkedDate: workedDate,
+		})
+	}
 
 	respondWithJSON(w, http.StatusCreated, response)
 }
 
-func updateTaskLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// clickUpTimeSyncEnabled reports whether new task logs are tracked as time in ClickUp
+func (s *Server) clickUpTimeSyncEnabled() bool {
+	return s.settings.Config().ClickUp.SyncTime
+}
+
+func (s *Server) updateTaskLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -347,20 +454,19 @@ func updateTaskLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req TaskLogRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Check if log exists and belongs to current user
-	existingLog, err := database.GetTaskLog(ctx, int32(id))
+	existingLog, err := s.store.GetTaskLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task log not found")
 		return
@@ -371,30 +477,21 @@ func updateTaskLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate request
-	if req.WorkedDay <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Worked day must be positive")
-		return
-	}
-
-	// Parse date from string (yyyy-MM-dd format)
-	workedDate, err := time.Parse("2006-01-02", req.WorkedDate)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format. Expected yyyy-MM-dd")
-		return
-	}
+	workedDate := requestDate(req.WorkedDate).Time
 
 	// Validate time limit for the day (excluding current log)
-	err = validateDayLimit(ctx, currentUser.ID, workedDate, req.WorkedDay, int32(id))
+	err = s.validateDayLimit(ctx, currentUser.ID, workedDate, req.WorkedDay, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Prepare numeric value
-	workedDay := pgtype.Numeric{}
-	workedDay.Valid = true
-	workedDay.Scan(strconv.FormatFloat(req.WorkedDay, 'f', -1, 64))
+	workedDay, err := pgconv.FromFloat(req.WorkedDay)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid worked day: "+err.Error())
+		return
+	}
 
 	// Update task log in database
 	params := sqlc.UpdateTaskLogParams{
@@ -404,7 +501,7 @@ func updateTaskLog(w http.ResponseWriter, r *http.Request) {
 		IsWorkOnHoliday: pgtype.Bool{Bool: req.IsWorkOnHoliday, Valid: true},
 	}
 
-	log, err := database.UpdateTaskLog(ctx, params)
+	log, err := s.store.UpdateTaskLog(ctx, params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating task log: "+err.Error())
 		return
@@ -429,8 +526,11 @@ func updateTaskLog(w http.ResponseWriter, r *http.Request) {
 		isWorkOnHoliday = log.IsWorkOnHoliday.Bool
 	}
 
-	// Add sync function to call after changes
-	syncTaskLogUser(ctx, currentUser.ID, workedDate)
+	// Sync both the previous and the new year in case the log moved across years
+	s.events.Publish(ctx,
+		annualRecordChangeFor(existingLog.CreatedByUserID, existingLog.WorkedDate.Time),
+		annualRecordChangeFor(log.CreatedByUserID, workedDate),
+	)
 
 	response := TaskLogResponse{
 		ID:              log.ID,
@@ -446,8 +546,8 @@ func updateTaskLog(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func deleteTaskLog(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) deleteTaskLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	id, err := strconv.Atoi(vars["id"])
@@ -457,14 +557,14 @@ func deleteTaskLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get current user
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Check if log exists and belongs to current user
-	existingLog, err := database.GetTaskLog(ctx, int32(id))
+	existingLog, err := s.store.GetTaskLog(ctx, int32(id))
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Task log not found")
 		return
@@ -475,19 +575,20 @@ func deleteTaskLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := database.DeleteTaskLog(ctx, int32(id)); err != nil {
+	if err := s.store.DeleteTaskLog(ctx, int32(id)); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error deleting task log: "+err.Error())
 		return
 	}
 
-	// Add sync function to call after changes
-	syncTaskLogUser(ctx, currentUser.ID, time.Now())
+	// Sync the annual record for the year of the deleted log
+	s.events.Publish(ctx, annualRecordChangeFor(existingLog.CreatedByUserID, existingLog.WorkedDate.Time))
+	s.cancelApprovals(ctx, approval.KindTimesheet, int32(id))
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
 
-func getTaskLogsByTask(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskLogsByTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 
 	taskID, err := strconv.Atoi(vars["task_id"])
@@ -497,71 +598,37 @@ func getTaskLogsByTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if task exists
-	task, err := database.GetTask(ctx, int32(taskID))
-	if err != nil {
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
 		respondWithError(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
-	// Get task logs from database
-	logs, err := database.ListTaskLogsByTask(ctx, int32(taskID))
+	// Get task logs from s.store, joined with task titles and usernames
+	logs, err := s.store.ListTaskLogsWithDetailsByTask(ctx, int32(taskID))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
 		return
 	}
 
-	// Convert to response format with usernames
+	// Convert to response format
 	response := make([]TaskLogResponse, 0, len(logs))
 	for _, log := range logs {
-		// Get user info
-		user, err := database.GetUser(ctx, log.CreatedByUserID)
-		username := "Unknown"
-		if err == nil {
-			username = user.Username
-		}
-
-		// Convert numeric to float64
-		workedDay, _ := log.WorkedDay.Float64Value()
-		workedDayValue := float64(0)
-		if workedDay.Valid {
-			workedDayValue = workedDay.Float64
-		}
-
-		// Check if worked date is valid
-		var workedDate time.Time
-		if log.WorkedDate.Valid {
-			workedDate = log.WorkedDate.Time.UTC()
-		}
-
-		// Check if holiday flag is valid
-		isWorkOnHoliday := false
-		if log.IsWorkOnHoliday.Valid {
-			isWorkOnHoliday = log.IsWorkOnHoliday.Bool
-		}
-
-		resp := TaskLogResponse{
+		response = append(response, newTaskLogResponse(sqlc.TaskLog{
 			ID:              log.ID,
 			TaskID:          log.TaskID,
-			WorkedDay:       workedDayValue,
+			WorkedDay:       log.WorkedDay,
 			CreatedByUserID: log.CreatedByUserID,
-			WorkedDate:      workedDate,
-			IsWorkOnHoliday: isWorkOnHoliday,
+			WorkedDate:      log.WorkedDate,
 			CreatedAt:       log.CreatedAt,
-			Username:        username,
-		}
-
-		if task.Title.Valid {
-			resp.TaskTitle = task.Title.String
-		}
-
-		response = append(response, resp)
+			IsWorkOnHoliday: log.IsWorkOnHoliday,
+		}, log.Username, log.TaskTitle.String))
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func getTaskLogsByDateRange(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+func (s *Server) getTaskLogsByDateRange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
 	// Parse date range parameters
 	startDateParam := r.URL.Query().Get("start_date")
@@ -572,106 +639,97 @@ func getTaskLogsByDateRange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("getTaskLogsByDateRange called with start_date=%s, end_date=%s", startDateParam, endDateParam)
-
 	startDate, err := time.Parse("2006-01-02", startDateParam)
 	if err != nil {
-		log.Printf("Invalid start date format: %v", err)
 		respondWithError(w, http.StatusBadRequest, "Invalid start date format (should be YYYY-MM-DD)")
 		return
 	}
 
 	endDate, err := time.Parse("2006-01-02", endDateParam)
 	if err != nil {
-		log.Printf("Invalid end date format: %v", err)
 		respondWithError(w, http.StatusBadRequest, "Invalid end date format (should be YYYY-MM-DD)")
 		return
 	}
 
 	// Get user from request
-	currentUser, err := getCurrentUserFromRequest(r)
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
 	if err != nil {
-		log.Printf("Unauthorized request: %v", err)
+		slog.DebugContext(ctx, "Unauthorized request", "error", err)
 		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	log.Printf("Fetching logs for user ID %d between %s and %s", currentUser.ID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	// Optionally narrow the logs to tasks with a tag
+	tagID := pgtype.Int4{Valid: false}
+	if tagParam := r.URL.Query().Get("tag_id"); tagParam != "" {
+		parsedTagID, err := strconv.Atoi(tagParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid tag ID")
+			return
+		}
+		tagID = pgtype.Int4{Int32: int32(parsedTagID), Valid: true}
+	}
 
-	// Get task logs by date range for current user
-	logs, err := database.ListTaskLogsByUserAndDateRange(ctx, sqlc.ListTaskLogsByUserAndDateRangeParams{
+	// Get task logs by date range for current user, joined with task titles
+	logs, err := s.store.ListTaskLogsWithDetailsByUserAndDateRange(ctx, sqlc.ListTaskLogsWithDetailsByUserAndDateRangeParams{
 		CreatedByUserID: currentUser.ID,
 		WorkedDate:      pgtype.Date{Time: startDate, Valid: true},
 		WorkedDate_2:    pgtype.Date{Time: endDate, Valid: true},
+		TagID:           tagID,
 	})
 	if err != nil {
-		log.Printf("Error fetching task logs: %v", err)
+		slog.ErrorContext(ctx, "Error fetching task logs", "error", err)
 		respondWithError(w, http.StatusInternalServerError, "Error fetching task logs: "+err.Error())
 		return
 	}
 
-	log.Printf("Found %d logs for date range", len(logs))
-
-	// Convert to response format with task titles
+	// Convert to response format
 	response := make([]TaskLogResponse, 0, len(logs))
 	for _, log := range logs {
-		// Convert numeric to float64
-		workedDay, _ := log.WorkedDay.Float64Value()
-		workedDayValue := float64(0)
-		if workedDay.Valid {
-			workedDayValue = workedDay.Float64
-		}
-
-		// Check if worked date is valid
-		var workedDate time.Time
-		if log.WorkedDate.Valid {
-			workedDate = log.WorkedDate.Time.UTC()
-		}
-
-		// Check if holiday flag is valid
-		isWorkOnHoliday := false
-		if log.IsWorkOnHoliday.Valid {
-			isWorkOnHoliday = log.IsWorkOnHoliday.Bool
-		}
-
-		resp := TaskLogResponse{
+		response = append(response, newTaskLogResponse(sqlc.TaskLog{
 			ID:              log.ID,
 			TaskID:          log.TaskID,
-			WorkedDay:       workedDayValue,
+			WorkedDay:       log.WorkedDay,
 			CreatedByUserID: log.CreatedByUserID,
-			WorkedDate:      workedDate,
-			IsWorkOnHoliday: isWorkOnHoliday,
+			WorkedDate:      log.WorkedDate,
 			CreatedAt:       log.CreatedAt,
-			Username:        currentUser.Username,
-		}
-
-		// Get task title
-		task, err := database.GetTask(ctx, log.TaskID)
-		if err == nil && task.Title.Valid {
-			resp.TaskTitle = task.Title.String
-		}
-
-		response = append(response, resp)
+			IsWorkOnHoliday: log.IsWorkOnHoliday,
+		}, log.Username, log.TaskTitle.String))
 	}
 
-	// Add sync function to call after changes
-	syncTaskLogUser(ctx, currentUser.ID, time.Now())
-
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// Add sync function to call after changes
-func syncTaskLogUser(ctx context.Context, userID int32, taskDate time.Time) {
-	year := time.Now().Year()
-	if taskDate.Year() > 0 {
-		year = taskDate.Year()
+// newTaskLogResponse converts a task log row into the API response format
+func newTaskLogResponse(log sqlc.TaskLog, username, taskTitle string) TaskLogResponse {
+	// Convert numeric to float64
+	workedDay, _ := log.WorkedDay.Float64Value()
+	workedDayValue := float64(0)
+	if workedDay.Valid {
+		workedDayValue = workedDay.Float64
 	}
 
-	syncService := NewAnnualRecordSyncService(database)
-	_, err := syncService.SyncUserRecordForYear(ctx, userID, int32(year))
-	if err != nil {
-		log.Printf("Warning: Failed to sync annual record for task log: %v", err)
-	} else {
-		log.Printf("Successfully synced annual record for user %d, year %d after task log change", userID, year)
+	// Check if worked date is valid
+	var workedDate time.Time
+	if log.WorkedDate.Valid {
+		workedDate = log.WorkedDate.Time.UTC()
+	}
+
+	// Check if holiday flag is valid
+	isWorkOnHoliday := false
+	if log.IsWorkOnHoliday.Valid {
+		isWorkOnHoliday = log.IsWorkOnHoliday.Bool
+	}
+
+	return TaskLogResponse{
+		ID:              log.ID,
+		TaskID:          log.TaskID,
+		WorkedDay:       workedDayValue,
+		CreatedByUserID: log.CreatedByUserID,
+		WorkedDate:      workedDate,
+		IsWorkOnHoliday: isWorkOnHoliday,
+		CreatedAt:       log.CreatedAt,
+		Username:        username,
+		TaskTitle:       taskTitle,
 	}
 }
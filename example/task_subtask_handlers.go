@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TaskParentRequest represents the request body for moving a task under a parent.
+// A null parent_task_id makes the task top-level again.
+type TaskParentRequest struct {
+	ParentTaskID *int32 `json:"parent_task_id"`
+}
+
+func (s *Server) getSubtasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	// Check if task exists
+	if _, err := s.store.GetTask(ctx, int32(taskID)); err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	subtasks, err := s.store.ListSubtasks(ctx, pgtype.Int4{Int32: int32(taskID), Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching subtasks: "+err.Error())
+		return
+	}
+
+	response := make([]TaskResponse, 0, len(subtasks))
+	for _, task := range subtasks {
+		response = append(response, convertTaskToResponse(task))
+	}
+
+	// Include assignees, tags and custom fields
+	if err := s.attachTaskAssignees(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching assignees: "+err.Error())
+		return
+	}
+	if err := s.attachTaskTags(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching tags: "+err.Error())
+		return
+	}
+	if err := s.attachTaskCustomFields(ctx, response); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching custom fields: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) setTaskParent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+
+	taskID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	var req TaskParentRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	defer r.Body.Close()
+
+	task, err := s.store.GetTask(ctx, int32(taskID))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+
+	if _, ok := s.authorizeTaskChange(ctx, w, r, task); !ok {
+		return
+	}
+
+	parentTaskID := pgtype.Int4{Valid: false}
+	if req.ParentTaskID != nil {
+		if _, err := s.store.GetTask(ctx, *req.ParentTaskID); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Parent task not found")
+			return
+		}
+
+		// The new parent must not be the task itself or one of its subtasks
+		ancestorIDs, err := s.store.ListTaskAncestorIDs(ctx, *req.ParentTaskID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error checking task hierarchy: "+err.Error())
+			return
+		}
+		for _, ancestorID := range ancestorIDs {
+			if ancestorID == int32(taskID) {
+				respondWithError(w, http.StatusBadRequest, "A task cannot be nested under itself or one of its subtasks")
+				return
+			}
+		}
+
+		parentTaskID = pgtype.Int4{Int32: *req.ParentTaskID, Valid: true}
+	}
+
+	task, err = s.store.SetTaskParent(ctx, sqlc.SetTaskParentParams{
+		ID:           int32(taskID),
+		ParentTaskID: parentTaskID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating parent task: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, convertTaskToResponse(task))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// TeamCapacityEntry compares the open work assigned to a user with their available days
+type TeamCapacityEntry struct {
+	UserID             int32   `json:"user_id"`
+	Username           string  `json:"username"`
+	Department         string  `json:"department,omitempty"`
+	DailyCapacity      float64 `json:"daily_capacity"`
+	WorkingDayCount    int64   `json:"working_day_count"` // Weekdays in the period that are not holidays
+	LeaveDayCount      int64   `json:"leave_day_count"`   // Leave taken on those working days
+	AvailableDay       float64 `json:"available_day"`
+	OpenTaskCount      int64   `json:"open_task_count"`
+	EstimateDay        float64 `json:"estimate_day"` // Shared tasks are split evenly between assignees
+	RemainingDay       float64 `json:"remaining_day"`
+	OverbookedDay      float64 `json:"overbooked_day"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+	IsOverbooked       bool    `json:"is_overbooked"`
+}
+
+// TeamCapacityResponse is the capacity plan of a team over a date range
+type TeamCapacityResponse struct {
+	From            string              `json:"from"` // yyyy-MM-dd
+	To              string              `json:"to"`   // yyyy-MM-dd
+	Department      string              `json:"department,omitempty"`
+	AvailableDay    float64             `json:"available_day"`
+	EstimateDay     float64             `json:"estimate_day"`
+	OverbookedCount int                 `json:"overbooked_count"`
+	Entries         []TeamCapacityEntry `json:"entries"`
+}
+
+func (s *Server) getTeamCapacityReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid from date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid to date format (should be YYYY-MM-DD)")
+		return
+	}
+
+	if to.Before(from) {
+		respondWithError(w, http.StatusBadRequest, "to date must not be before from date")
+		return
+	}
+
+	if _, err := getCurrentUserFromRequest(s.store, r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	department := strings.TrimSpace(r.URL.Query().Get("department"))
+
+	rows, err := s.store.ListAssigneeCapacity(ctx, sqlc.ListAssigneeCapacityParams{
+		StartDate:  pgtype.Date{Time: from, Valid: true},
+		EndDate:    pgtype.Date{Time: to, Valid: true},
+		Department: pgtype.Text{String: department, Valid: department != ""},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching team capacity: "+err.Error())
+		return
+	}
+
+	response := TeamCapacityResponse{
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Department: department,
+		Entries:    make([]TeamCapacityEntry, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		entry := TeamCapacityEntry{
+			UserID:          row.UserID,
+			Username:        row.Username,
+			Department:      row.Department.String,
+			DailyCapacity:   numericToFloat64(row.DailyCapacity, 1),
+			WorkingDayCount: row.WorkingDayCount,
+			LeaveDayCount:   row.LeaveDayCount,
+			OpenTaskCount:   row.OpenTaskCount,
+			EstimateDay:     math.Round(numericToFloat64(row.EstimateDay, 0)*100) / 100,
+		}
+		entry.AvailableDay = math.Round(float64(entry.WorkingDayCount-entry.LeaveDayCount)*entry.DailyCapacity*100) / 100
+		entry.RemainingDay = math.Max(entry.AvailableDay-entry.EstimateDay, 0)
+		entry.OverbookedDay = math.Max(entry.EstimateDay-entry.AvailableDay, 0)
+		entry.IsOverbooked = entry.EstimateDay > entry.AvailableDay
+		if entry.AvailableDay > 0 {
+			entry.UtilizationPercent = math.Round(entry.EstimateDay/entry.AvailableDay*10000) / 100
+		}
+
+		response.AvailableDay += entry.AvailableDay
+		response.EstimateDay += entry.EstimateDay
+		if entry.IsOverbooked {
+			response.OverbookedCount++
+		}
+		response.Entries = append(response.Entries, entry)
+	}
+
+	// Overbooked people first, most overbooked at the top
+	sort.SliceStable(response.Entries, func(i, j int) bool {
+		return response.Entries[i].OverbookedDay > response.Entries[j].OverbookedDay
+	})
+
+	respondWithJSON(w, http.StatusOK, response)
+}
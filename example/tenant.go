@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+// tenantHeader names the tenant of a request, taking precedence over the host name
+const tenantHeader = "X-Tenant"
+
+// defaultTenantSlug is the tenant of requests that name none, like a bare IP or localhost
+const defaultTenantSlug = "default"
+
+// TenantMiddleware resolves the tenant of every request when MULTI_TENANT=true and runs the
+// request in it, so the Store only sees and writes that tenant's rows
+type TenantMiddleware struct {
+	store db.Store
+
+	mu  sync.RWMutex
+	ids map[string]int32 // Tenants are never deleted, so resolved slugs stay valid
+}
+
+// NewTenantMiddleware creates a tenant middleware looking tenants up in the given store
+func NewTenantMiddleware(store db.Store) *TenantMiddleware {
+	return &TenantMiddleware{
+		store: store,
+		ids:   make(map[string]int32),
+	}
+}
+
+// Middleware answers 404 for a tenant that doesn't exist
+func (m *TenantMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug := tenantSlug(r)
+		tenantID, err := m.resolve(r.Context(), slug)
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Unknown tenant: "+slug)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error resolving tenant: "+err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(db.WithTenant(r.Context(), tenantID)))
+	})
+}
+
+// resolve returns the id of the tenant with the given slug. Unknown slugs aren't remembered,
+// so requests for made up host names can't grow the cache.
+func (m *TenantMiddleware) resolve(ctx context.Context, slug string) (int32, error) {
+	m.mu.RLock()
+	tenantID, ok := m.ids[slug]
+	m.mu.RUnlock()
+	if ok {
+		return tenantID, nil
+	}
+
+	tenant, err := m.store.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	m.ids[slug] = tenant.ID
+	m.mu.Unlock()
+	return tenant.ID, nil
+}
+
+// tenantSlug returns the tenant a request names: the X-Tenant header, or else the first label
+// of a host name like acme.tableg.example.com
+func tenantSlug(r *http.Request) string {
+	return tenantSlugOf(r.Header.Get(tenantHeader), r.Host)
+}
+
+// tenantSlugOf returns the tenant named by the X-Tenant header or x-tenant metadata of a call,
+// or else by its host
+func tenantSlugOf(named, host string) string {
+	if slug := strings.TrimSpace(named); slug != "" {
+		return strings.ToLower(slug)
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if net.ParseIP(host) != nil || strings.Count(host, ".") < 2 {
+		return defaultTenantSlug
+	}
+	return strings.ToLower(strings.SplitN(host, ".", 2)[0])
+}
+
+// forEachTenant runs a background job once per tenant, in that tenant, so the rows it creates
+// belong to it. With a single tenant it runs the job once in the context as it is.
+func (s *Server) forEachTenant(ctx context.Context, job func(ctx context.Context)) {
+	if !s.config.Database.MultiTenant {
+		job(ctx)
+		return
+	}
+
+	tenants, err := s.database.ListTenants(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing tenants", "error", err)
+		return
+	}
+	for _, tenant := range tenants {
+		slog.DebugContext(ctx, "Running for tenant", "tenant", tenant.Slug)
+		job(db.WithTenant(ctx, tenant.ID))
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/tz"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// Which day and year it is doesn't depend on the zone the server runs in. The company's day and
+// year are those of TIME_ZONE: jobs run on its clock, and the current year of annual records,
+// balances and reports is its year. A user's today, on the dashboard, when they check in and in
+// the date ranges that default to it, is that of the zone they picked at
+// /api/current-user/time-zone, TIME_ZONE until they pick one.
+
+// thisYear returns the year it is for the company
+func (s *Server) thisYear() int {
+	return tz.Year(s.timeZone)
+}
+
+// userTimeZone returns the zone a user picked, TIME_ZONE until they pick one
+func (s *Server) userTimeZone(ctx context.Context, userID int32) (*time.Location, error) {
+	pref, err := s.store.GetTimeZonePreference(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.timeZone, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	location, err := tz.Load(pref.TimeZone)
+	if err != nil {
+		// A zone picked on a server whose zone database knew it, the company's stands in
+		return s.timeZone, nil
+	}
+	return location, nil
+}
+
+// userToday returns the day it is for a user, as midnight UTC
+func (s *Server) userToday(ctx context.Context, userID int32) (time.Time, error) {
+	location, err := s.userTimeZone(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tz.Today(location), nil
+}
+
+// TimeZonePreferenceResponse is the time zone the user's today follows
+type TimeZonePreferenceResponse struct {
+	TimeZone        string `json:"timeZone"`        // An IANA zone such as Asia/Bangkok
+	Picked          bool   `json:"picked"`          // False while the user is in TIME_ZONE
+	CompanyTimeZone string `json:"companyTimeZone"` // TIME_ZONE
+	Today           string `json:"today"`           // YYYY-MM-DD in the zone
+}
+
+// TimeZonePreferenceRequest is the body of PUT /api/current-user/time-zone
+type TimeZonePreferenceRequest struct {
+	TimeZone string `json:"timeZone" validate:"required,max=64"`
+}
+
+// getTimeZonePreference handles GET /api/current-user/time-zone
+func (s *Server) getTimeZonePreference(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	pref, err := s.store.GetTimeZonePreference(r.Context(), currentUser.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithJSON(w, http.StatusOK, s.timeZonePreferenceResponse(s.timeZone, false))
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	location, err := tz.Load(pref.TimeZone)
+	if err != nil {
+		respondWithJSON(w, http.StatusOK, s.timeZonePreferenceResponse(s.timeZone, false))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, s.timeZonePreferenceResponse(location, true))
+}
+
+// updateTimeZonePreference handles PUT /api/current-user/time-zone
+func (s *Server) updateTimeZonePreference(w http.ResponseWriter, r *http.Request) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	var req TimeZonePreferenceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	location, err := tz.Load(req.TimeZone)
+	if err != nil {
+		respondWithValidationError(w, validate.Errors{"timeZone": "must be an IANA time zone such as Asia/Bangkok"})
+		return
+	}
+	if _, err := s.store.SetTimeZonePreference(r.Context(), sqlc.SetTimeZonePreferenceParams{UserID: currentUser.ID, TimeZone: req.TimeZone}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error saving time zone preference: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, s.timeZonePreferenceResponse(location, true))
+}
+
+func (s *Server) timeZonePreferenceResponse(location *time.Location, picked bool) TimeZonePreferenceResponse {
+	return TimeZonePreferenceResponse{
+		TimeZone:        location.String(),
+		Picked:          picked,
+		CompanyTimeZone: s.timeZone.String(),
+		Today:           tz.Today(location).Format(validate.DateLayout),
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// certificateCheckInterval is how often the certificate files are checked for a renewal
+const certificateCheckInterval = time.Minute
+
+// certificateReloader serves the certificate in a pair of PEM files and loads it again once the
+// files change, so a certificate renewed by certbot or another ACME client is picked up without
+// a restart
+type certificateReloader struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	loadedAt time.Time
+	checked  time.Time
+}
+
+// newCertificateReloader loads the certificate, failing when the files can't be read or don't
+// hold a matching certificate and key
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	c := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certificateReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate %s: %w", c.certFile, err)
+	}
+	c.cert, c.loadedAt = &cert, time.Now()
+	return nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (c *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= certificateCheckInterval {
+		c.checked = time.Now()
+		if c.changed() {
+			// Keep serving the old certificate while the new one is half written
+			if err := c.load(); err != nil {
+				slog.Warn("Error reloading TLS certificate, keeping the current one", "error", err)
+			} else {
+				slog.Info("TLS certificate reloaded", "file", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
+
+// changed reports whether either file was modified since the certificate was loaded
+func (c *certificateReloader) changed() bool {
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err == nil && info.ModTime().After(c.loadedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTLSConfig returns the TLS settings of the HTTPS server. net/http adds HTTP/2 to them.
+func newTLSConfig(certificates *certificateReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificates.GetCertificate,
+	}
+}
+
+// HSTSMiddleware tells browsers to use nothing but HTTPS for the host for maxAge
+func HSTSMiddleware(maxAge time.Duration) func(http.Handler) http.Handler {
+	value := "max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Browsers ignore the header over plain HTTP
+			if r.TLS != nil {
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// httpsRedirectHandler answers plain HTTP requests with a permanent redirect to the same URL on
+// the HTTPS port
+func httpsRedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
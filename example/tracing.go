@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kengtableg/pkeng-tableg/db"
+)
+
+// requestTrace collects the query spans of one request
+type requestTrace struct {
+	mu    sync.Mutex
+	spans []db.QuerySpan
+}
+
+type requestTraceKey struct{}
+
+// recordQuerySpan adds a query span to the trace of the request it ran for, queries outside a
+// request are dropped
+func recordQuerySpan(ctx context.Context, span db.QuerySpan) {
+	trace, ok := ctx.Value(requestTraceKey{}).(*requestTrace)
+	if !ok {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.spans = append(trace.spans, span)
+}
+
+// QueryTraceMiddleware returns a middleware tracing the queries each request runs and logging
+// how many ran and for how long, naming queries run threshold times or more as a likely N+1
+func QueryTraceMiddleware(threshold int) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace := &requestTrace{}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestTraceKey{}, trace)))
+
+			trace.mu.Lock()
+			defer trace.mu.Unlock()
+			if len(trace.spans) == 0 {
+				return
+			}
+
+			var total time.Duration
+			counts := make(map[string]int)
+			for _, span := range trace.spans {
+				total += span.Duration
+				counts[span.Name]++
+			}
+			slog.DebugContext(r.Context(), "Queries of request", "method", r.Method, "path", r.URL.Path, "count", len(trace.spans), "duration", total)
+			for name, count := range counts {
+				if name != "" && count >= threshold {
+					slog.WarnContext(r.Context(), "Query repeated in one request, likely an N+1", "query", name, "count", count, "method", r.Method, "path", r.URL.Path)
+				}
+			}
+		})
+	}
+}
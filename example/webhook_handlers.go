@@ -0,0 +1,440 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// WebhookRequest is the body of POST /api/admin/webhooks
+type WebhookRequest struct {
+	URL         string   `json:"url" validate:"required"`        // http or https
+	Secret      string   `json:"secret" validate:"max=100"`      // Generated when empty, at least 16 characters otherwise
+	Events      []string `json:"events"`                         // e.g. leave_log.created, every event when empty
+	Description string   `json:"description" validate:"max=255"` // What receives it, e.g. the HRIS
+	Active      *bool    `json:"active"`                         // Defaults to true
+}
+
+// WebhookUpdateRequest is the body of PATCH /api/admin/webhooks/{id}, the fields left out stay
+// as they are
+type WebhookUpdateRequest struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description" validate:"max=255"`
+	Active      *bool     `json:"active"`
+}
+
+// WebhookResponse is a webhook, with its secret only when it was just created
+type WebhookResponse struct {
+	ID              int32     `json:"id"`
+	URL             string    `json:"url"`
+	Secret          string    `json:"secret,omitempty"`
+	Events          []string  `json:"events"`
+	Description     string    `json:"description"`
+	Active          bool      `json:"active"`
+	CreatedByUserID *int32    `json:"createdByUserId,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// WebhookDeliveryResponse is one delivery of the log, with the payload as it was posted
+type WebhookDeliveryResponse struct {
+	ID             int32           `json:"id"`
+	WebhookID      int32           `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"` // pending, delivered or failed
+	Attempts       int32           `json:"attempts"`
+	ResponseStatus *int32          `json:"responseStatus,omitempty"` // Of the last attempt
+	LastError      *string         `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+func webhookToResponse(webhook sqlc.Webhook) WebhookResponse {
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookResponse{
+		ID:              webhook.ID,
+		URL:             webhook.Url,
+		Events:          events,
+		Description:     webhook.Description,
+		Active:          webhook.Active,
+		CreatedByUserID: int4Ptr(webhook.CreatedByUserID),
+		CreatedAt:       webhook.CreatedAt.Time,
+		UpdatedAt:       webhook.UpdatedAt.Time,
+	}
+}
+
+func webhookDeliveryToResponse(delivery sqlc.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: int4Ptr(delivery.ResponseStatus),
+		LastError:      textPtr(delivery.LastError),
+		CreatedAt:      delivery.CreatedAt.Time,
+	}
+	if delivery.DeliveredAt.Valid {
+		response.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return response
+}
+
+// validateWebhook checks the URL and events of a webhook, returning what is wrong with them
+func validateWebhook(rawURL string, events []string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "url must be an absolute http or https URL"
+	}
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return "unknown event " + event + ", must be one of " + strings.Join(webhookEvents, ", ")
+		}
+	}
+	return ""
+}
+
+// newWebhookSecret returns a random secret for a webhook created without one
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// authorizeWebhookAdmin answers 403 to users other than admins and returns the admin otherwise
+func (s *Server) authorizeWebhookAdmin(w http.ResponseWriter, r *http.Request) (sqlc.User, bool) {
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return currentUser, false
+	}
+	if currentUser.UserType != "admin" {
+		respondWithError(w, http.StatusForbidden, "Only administrators can manage webhooks")
+		return currentUser, false
+	}
+	return currentUser, true
+}
+
+// webhookFromPath returns the webhook whose ID is in the path, answering 400 or 404 otherwise
+func (s *Server) webhookFromPath(w http.ResponseWriter, r *http.Request) (sqlc.Webhook, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return sqlc.Webhook{}, false
+	}
+	webhook, err := s.store.GetWebhook(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return webhook, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching webhook: "+err.Error())
+		return webhook, false
+	}
+	return webhook, true
+}
+
+// getWebhooks handles GET /api/admin/webhooks
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	webhooks, err := s.store.ListWebhooks(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching webhooks: "+err.Error())
+		return
+	}
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, webhookToResponse(webhook))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getWebhook handles GET /api/admin/webhooks/{id}
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	webhook, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, webhookToResponse(webhook))
+}
+
+// createWebhook handles POST /api/admin/webhooks. The response is the only one with the secret.
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.authorizeWebhookAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req WebhookRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if problem := validateWebhook(req.URL, req.Events); problem != "" {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: "+problem)
+		return
+	}
+	if req.Secret != "" && len(req.Secret) < 16 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: secret must have at least 16 characters")
+		return
+	}
+	if req.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating webhook secret: "+err.Error())
+			return
+		}
+		req.Secret = secret
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+
+	webhook, err := s.store.CreateWebhook(r.Context(), sqlc.CreateWebhookParams{
+		Url:             req.URL,
+		Secret:          req.Secret,
+		Events:          req.Events,
+		Description:     req.Description,
+		Active:          req.Active == nil || *req.Active,
+		CreatedByUserID: pgtype.Int4{Int32: currentUser.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating webhook: "+err.Error())
+		return
+	}
+	response := webhookToResponse(webhook)
+	response.Secret = webhook.Secret
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// updateWebhook handles PATCH /api/admin/webhooks/{id}. The deliveries still pending for a
+// webhook that is deactivated fail at their next attempt.
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	webhook, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req WebhookUpdateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	params := sqlc.UpdateWebhookParams{
+		ID:          webhook.ID,
+		Url:         webhook.Url,
+		Events:      webhook.Events,
+		Description: webhook.Description,
+		Active:      webhook.Active,
+	}
+	if req.URL != nil {
+		params.Url = *req.URL
+	}
+	if req.Events != nil {
+		params.Events = *req.Events
+	}
+	if req.Description != nil {
+		params.Description = *req.Description
+	}
+	if req.Active != nil {
+		params.Active = *req.Active
+	}
+	if problem := validateWebhook(params.Url, params.Events); problem != "" {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid request: "+problem)
+		return
+	}
+	if params.Events == nil {
+		params.Events = []string{}
+	}
+
+	webhook, err := s.store.UpdateWebhook(r.Context(), params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating webhook: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, webhookToResponse(webhook))
+}
+
+// deleteWebhook handles DELETE /api/admin/webhooks/{id}, deleting its deliveries with it
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	deleted, err := s.store.DeleteWebhook(r.Context(), int32(id))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting webhook: "+err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pingWebhook handles POST /api/admin/webhooks/{id}/ping, queueing a ping delivery so admins can
+// check the receiver gets and verifies deliveries. Webhooks get pings whatever their events.
+func (s *Server) pingWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	webhook, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+	if !webhook.Active {
+		respondWithError(w, http.StatusConflict, "Webhook is inactive, activate it first")
+		return
+	}
+	body, err := json.Marshal(WebhookPayload{Event: webhookPingEvent, OccurredAt: time.Now()})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error encoding ping: "+err.Error())
+		return
+	}
+	delivery, err := s.queueWebhookDelivery(r.Context(), webhook, webhookPingEvent, body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error queueing ping: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, webhookDeliveryToResponse(delivery))
+}
+
+// getWebhookDeliveries handles GET /api/admin/webhooks/{id}/deliveries, the delivery log of a
+// webhook, newest first
+func (s *Server) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	webhook, ok := s.webhookFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 20
+	offset := 0
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 {
+		limit = min(parsed, 100)
+	}
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(r.Context(), sqlc.ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching webhook deliveries: "+err.Error())
+		return
+	}
+	total, err := s.store.CountWebhookDeliveries(r.Context(), webhook.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting webhook deliveries: "+err.Error())
+		return
+	}
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, webhookDeliveryToResponse(delivery))
+	}
+	respondWithJSON(w, http.StatusOK, newPage(response, total, limit, offset))
+}
+
+// getWebhookDelivery handles GET /api/admin/webhooks/{id}/deliveries/{delivery_id}
+func (s *Server) getWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	delivery, ok := s.webhookDeliveryFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, webhookDeliveryToResponse(delivery))
+}
+
+// redeliverWebhookDelivery handles POST /api/admin/webhooks/{id}/deliveries/{delivery_id}/redeliver,
+// posting the same payload again with a fresh set of attempts, such as after the receiver was
+// fixed
+func (s *Server) redeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authorizeWebhookAdmin(w, r); !ok {
+		return
+	}
+	delivery, ok := s.webhookDeliveryFromPath(w, r)
+	if !ok {
+		return
+	}
+	if delivery.Status == webhookDeliveryPending {
+		respondWithError(w, http.StatusConflict, "Delivery is still being attempted")
+		return
+	}
+	delivery, err := s.store.RequeueWebhookDelivery(r.Context(), delivery.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error requeueing delivery: "+err.Error())
+		return
+	}
+	if _, err := s.queue.Enqueue(r.Context(), jobWebhookDelivery, webhookDeliveryJob{DeliveryID: delivery.ID}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error queueing delivery: "+err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, webhookDeliveryToResponse(delivery))
+}
+
+// webhookDeliveryFromPath returns the delivery whose ID is in the path, when it belongs to the
+// webhook in the path, answering 400 or 404 otherwise
+func (s *Server) webhookDeliveryFromPath(w http.ResponseWriter, r *http.Request) (sqlc.WebhookDelivery, bool) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return sqlc.WebhookDelivery{}, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery ID")
+		return sqlc.WebhookDelivery{}, false
+	}
+	delivery, err := s.store.GetWebhookDelivery(r.Context(), int32(id))
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && delivery.WebhookID != int32(webhookID) {
+		respondWithError(w, http.StatusNotFound, "Webhook delivery not found")
+		return delivery, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching webhook delivery: "+err.Error())
+		return delivery, false
+	}
+	return delivery, true
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/logging"
+	"github.com/kengtableg/pkeng-tableg/queue"
+	"github.com/kengtableg/pkeng-tableg/scheduler"
+)
+
+// Admins add webhooks so other systems, such as an HRIS, hear about changes to leave, medical
+// expenses, tasks, task logs and annual records. The changes come from the change feed like the
+// live updates. Each server instance sends the changes it made, so a change is sent once however
+// many instances run, and changes made outside the server, such as with psql, aren't sent. Every
+// change is recorded as a delivery to each active webhook whose events include it, and a queued
+// job posts it, trying again like the other queued jobs until the receiver answers with a 2xx.
+
+// The statuses of a delivery
+const (
+	webhookDeliveryPending   = "pending"
+	webhookDeliveryDelivered = "delivered"
+	webhookDeliveryFailed    = "failed"
+)
+
+// webhookPingEvent is the event of the test deliveries admins send, which every webhook gets
+const webhookPingEvent = "ping"
+
+// maxWebhookResponseSize caps how much of a receiver's answer is read, which is thrown away
+const maxWebhookResponseSize = 64 << 10
+
+// webhookEvents are the events webhooks may subscribe to, a resource of liveEventTypes and what
+// happened to it
+var webhookEvents = func() []string {
+	var events []string
+	for _, resource := range liveEventTypes {
+		for _, action := range []string{"created", "updated", "deleted"} {
+			events = append(events, resource+"."+action)
+		}
+	}
+	slices.Sort(events)
+	return events
+}()
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	Event      string    `json:"event"` // e.g. leave_log.created
+	OccurredAt time.Time `json:"occurredAt"`
+	ID         int32     `json:"id,omitempty"`     // Of the leave log, task, ...
+	UserID     int32     `json:"userId,omitempty"` // The owner of the row, the creator of a task
+	Year       int32     `json:"year,omitempty"`   // Of the annual record the row counts towards
+	Data       any       `json:"data,omitempty"`   // The row after the change, none once deleted
+}
+
+// webhookDeliveryJob is the payload of a webhook.delivery job
+type webhookDeliveryJob struct {
+	DeliveryID int32 `json:"deliveryId"`
+}
+
+// webhookSubscribed reports whether an active webhook wants an event, every event unless its
+// events narrow them down
+func webhookSubscribed(webhook sqlc.Webhook, event string) bool {
+	return webhook.Active && (len(webhook.Events) == 0 || event == webhookPingEvent || slices.Contains(webhook.Events, event))
+}
+
+// publishWebhookChange records a change this instance made as a delivery to each webhook that
+// wants it, running in the tenant of the change
+func (s *Server) publishWebhookChange(ctx context.Context, change db.Change) {
+	resource, ok := liveEventTypes[change.Table]
+	if !ok || change.Origin != db.Instance {
+		return
+	}
+	if s.config.Database.MultiTenant && change.TenantID != 0 {
+		ctx = db.WithTenant(ctx, change.TenantID)
+	}
+	webhooks, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing webhooks", "error", err)
+		return
+	}
+	if !slices.ContainsFunc(webhooks, func(webhook sqlc.Webhook) bool { return webhook.Active }) {
+		return
+	}
+
+	payload := WebhookPayload{OccurredAt: time.Now(), ID: change.ID, UserID: change.UserID, Year: change.Year}
+	action := map[string]string{"INSERT": "created", "UPDATE": "updated", "DELETE": "deleted"}[change.Op]
+	if change.Op != "DELETE" {
+		payload.Data, err = s.webhookData(ctx, change.Table, change.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Soft deleted, or deleted since
+			action = "deleted"
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Error fetching the changed row for webhooks", "table", change.Table, "id", change.ID, "error", err)
+			return
+		}
+	}
+	payload.Event = resource + "." + action
+	s.queueWebhookDeliveries(ctx, webhooks, payload)
+}
+
+// webhookData returns the row a change was made to
+func (s *Server) webhookData(ctx context.Context, table string, id int32) (any, error) {
+	switch table {
+	case "leave_logs":
+		return s.store.GetLeaveLog(ctx, id)
+	case "task_logs":
+		return s.store.GetTaskLog(ctx, id)
+	case "medical_expenses":
+		return s.store.GetMedicalExpense(ctx, id)
+	case "annual_records":
+		return s.store.GetAnnualRecord(ctx, id)
+	case "tasks":
+		return s.store.GetTask(ctx, id)
+	}
+	return nil, fmt.Errorf("no webhook events for table %s", table)
+}
+
+// queueWebhookDeliveries records the payload as a delivery to each webhook that wants its event
+// and queues sending them. A failure is only logged, the change stands either way.
+func (s *Server) queueWebhookDeliveries(ctx context.Context, webhooks []sqlc.Webhook, payload WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding webhook payload", "event", payload.Event, "error", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhookSubscribed(webhook, payload.Event) {
+			continue
+		}
+		if _, err := s.queueWebhookDelivery(ctx, webhook, payload.Event, body); err != nil {
+			slog.ErrorContext(ctx, "Error queueing webhook delivery", "webhook_id", webhook.ID, "event", payload.Event, "error", err)
+		}
+	}
+}
+
+// queueWebhookDelivery records one delivery and queues the job sending it
+func (s *Server) queueWebhookDelivery(ctx context.Context, webhook sqlc.Webhook, event string, body []byte) (sqlc.WebhookDelivery, error) {
+	delivery, err := s.store.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		Event:     event,
+		Payload:   body,
+	})
+	if err != nil {
+		return delivery, err
+	}
+	_, err = s.queue.Enqueue(ctx, jobWebhookDelivery, webhookDeliveryJob{DeliveryID: delivery.ID})
+	return delivery, err
+}
+
+// deliverWebhook runs a webhook.delivery job, posting the payload signed with the webhook's
+// secret. Each attempt is recorded on the delivery, which fails for good with the job's last
+// attempt.
+func (s *Server) deliverWebhook(ctx context.Context, payload json.RawMessage) error {
+	var job webhookDeliveryJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return queue.Permanent(err)
+	}
+	delivery, err := s.store.GetWebhookDelivery(ctx, job.DeliveryID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The webhook was deleted since
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == webhookDeliveryDelivered {
+		return nil
+	}
+	webhook, err := s.store.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !webhook.Active {
+		return queue.Permanent(s.recordWebhookAttempt(ctx, delivery, 0, errors.New("the webhook was deactivated"), true))
+	}
+
+	statusCode, err := s.postWebhook(ctx, webhook, delivery)
+	return s.recordWebhookAttempt(ctx, delivery, statusCode, err, int(delivery.Attempts)+1 >= s.config.Queue.MaxAttempts)
+}
+
+// postWebhook posts a delivery and returns the status the receiver answered with, failing
+// unless it is a 2xx. Redirects aren't followed, they fail like any other answer.
+func (s *Server) postWebhook(ctx context.Context, webhook sqlc.Webhook, delivery sqlc.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TableG-Webhooks")
+	req.Header.Set("X-TableG-Event", delivery.Event)
+	req.Header.Set("X-TableG-Delivery", strconv.Itoa(int(delivery.ID)))
+	req.Header.Set("X-TableG-Timestamp", timestamp)
+	req.Header.Set("X-TableG-Signature", signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+	logging.SetRequestIDHeader(ctx, req.Header)
+
+	client := &http.Client{
+		Timeout: s.config.Webhooks.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("the receiver answered with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload returns the X-TableG-Signature of a payload: sha256= and the hex HMAC-SHA256
+// of the timestamp, a dot and the body under the webhook's secret
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordWebhookAttempt records how an attempt went and returns its error for the queue. A failed
+// attempt fails the delivery when it is the last one.
+func (s *Server) recordWebhookAttempt(ctx context.Context, delivery sqlc.WebhookDelivery, statusCode int, err error, last bool) error {
+	params := sqlc.RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         webhookDeliveryDelivered,
+		ResponseStatus: pgtype.Int4{Int32: int32(statusCode), Valid: statusCode != 0},
+	}
+	if err != nil {
+		params.Status = webhookDeliveryPending
+		params.LastError = pgtype.Text{String: err.Error(), Valid: true}
+		if last {
+			params.Status = webhookDeliveryFailed
+		}
+	}
+	if recordErr := s.store.RecordWebhookDeliveryAttempt(ctx, params); recordErr != nil {
+		slog.WarnContext(ctx, "Error recording a webhook delivery attempt", "delivery_id", delivery.ID, "error", recordErr)
+	}
+	return err
+}
+
+// scheduleWebhookDeliveryCleanup deletes the deliveries made more than
+// WEBHOOK_DELIVERY_RETENTION ago every day
+func (s *Server) scheduleWebhookDeliveryCleanup() {
+	retention := s.config.Webhooks.DeliveryRetention
+	s.scheduler.Register(scheduler.Job{
+		Name:        "webhook-delivery-cleanup",
+		Description: "Delete the webhook deliveries made more than WEBHOOK_DELIVERY_RETENTION ago",
+		Schedule:    scheduler.MustParse("30 3 * * *"),
+		Run: func(ctx context.Context) error {
+			// The delete spans every tenant
+			deleted, err := s.store.DeleteWebhookDeliveriesBefore(db.WithAllTenants(ctx), pgtype.Timestamptz{Time: time.Now().Add(-retention), Valid: true})
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Old webhook deliveries deleted", "deliveries", deleted)
+			}
+			return nil
+		},
+	})
+}
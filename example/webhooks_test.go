@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+	"github.com/kengtableg/pkeng-tableg/db/dbtest"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// What a receiver computes: echo -n '1700000000.{"event":"ping"}' | openssl dgst -sha256 -hmac whsec_test
+	got := signWebhookPayload("whsec_test", "1700000000", []byte(`{"event":"ping"}`))
+	want := "sha256=aa8efe37b751e71157c508c5ac4acb1e9fe5225db98355dfc00f4b680afbc447"
+	if got != want {
+		t.Errorf("signWebhookPayload = %s, want %s", got, want)
+	}
+}
+
+// newWebhookTestServer returns a server keeping its data in a dbtest.Fake with a webhook posting
+// to url and a pending delivery to it
+func newWebhookTestServer(t *testing.T, url string) (*Server, *dbtest.Fake, sqlc.WebhookDelivery) {
+	t.Helper()
+	ctx := context.Background()
+
+	cfg := config.Default()
+	cfg.Files.Location = t.TempDir()
+	cfg.Queue.MaxAttempts = 3
+	store := dbtest.NewFake()
+	s := NewServer(cfg, store, nil, nil)
+
+	webhook, err := store.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+		Url:    url,
+		Secret: "whsec_test",
+		Active: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := store.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		Event:     webhookPingEvent,
+		Payload:   []byte(`{"event":"ping"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, store, delivery
+}
+
+// runWebhookDelivery runs the webhook.delivery job of a delivery once and returns the delivery
+// as it is then
+func runWebhookDelivery(t *testing.T, s *Server, store *dbtest.Fake, delivery sqlc.WebhookDelivery) (sqlc.WebhookDelivery, error) {
+	t.Helper()
+	payload, err := json.Marshal(webhookDeliveryJob{DeliveryID: delivery.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runErr := s.deliverWebhook(context.Background(), payload)
+	delivery, err = store.GetWebhookDelivery(context.Background(), delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery, runErr
+}
+
+func TestDeliverWebhookSigns(t *testing.T) {
+	var received atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-TableG-Timestamp")
+		if got, want := r.Header.Get("X-TableG-Signature"), signWebhookPayload("whsec_test", timestamp, body); timestamp == "" || got != want {
+			t.Errorf("signature %q at %q, want %q", got, timestamp, want)
+		}
+		if got := r.Header.Get("X-TableG-Event"); got != webhookPingEvent {
+			t.Errorf("event %q, want %s", got, webhookPingEvent)
+		}
+		received.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	s, store, delivery := newWebhookTestServer(t, receiver.URL)
+	delivery, err := runWebhookDelivery(t, s, store, delivery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !received.Load() {
+		t.Fatal("the receiver got nothing")
+	}
+	if delivery.Status != webhookDeliveryDelivered || delivery.ResponseStatus.Int32 != http.StatusNoContent {
+		t.Errorf("delivery = %s with %d, want delivered with 204", delivery.Status, delivery.ResponseStatus.Int32)
+	}
+}
+
+func TestDeliverWebhookFailsAtMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		respond  func(w http.ResponseWriter, r *http.Request)
+		wantCode int32
+	}{
+		{
+			name:     "server error",
+			respond:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "redirect",
+			respond:  func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/elsewhere", http.StatusFound) },
+			wantCode: http.StatusFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var redirected atomic.Bool
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/elsewhere" {
+					redirected.Store(true)
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				tt.respond(w, r)
+			}))
+			defer receiver.Close()
+
+			s, store, delivery := newWebhookTestServer(t, receiver.URL)
+			for attempt := 1; attempt <= s.config.Queue.MaxAttempts; attempt++ {
+				var err error
+				delivery, err = runWebhookDelivery(t, s, store, delivery)
+				if err == nil {
+					t.Fatalf("attempt %d succeeded", attempt)
+				}
+				want := webhookDeliveryPending
+				if attempt == s.config.Queue.MaxAttempts {
+					want = webhookDeliveryFailed
+				}
+				if delivery.Status != want || int(delivery.Attempts) != attempt || delivery.ResponseStatus.Int32 != tt.wantCode {
+					t.Errorf("after attempt %d = %s after %d attempts with %d, want %s with %d", attempt, delivery.Status, delivery.Attempts, delivery.ResponseStatus.Int32, want, tt.wantCode)
+				}
+			}
+			if redirected.Load() {
+				t.Error("the redirect was followed")
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/reports"
+)
+
+// yearSummaryTopCategories caps the task categories in a year in review
+const yearSummaryTopCategories = 5
+
+// YearSummaryResponse is a user's year in review, for the end-of-year review screen
+type YearSummaryResponse struct {
+	Year            int32                  `json:"year"`
+	VacationByMonth []YearSummaryMonth     `json:"vacationByMonth"` // Every month of the year, in order
+	VacationDays    int64                  `json:"vacationDays"`
+	WorkedDays      float64                `json:"workedDays"` // All the work logged in the year
+	TopCategories   []YearSummaryCategory  `json:"topCategories"`
+	HolidayWork     YearSummaryHolidayWork `json:"holidayWork"`
+	Balances        *DashboardBalances     `json:"balances"` // What is left of the year's quotas, null without an annual record
+}
+
+// YearSummaryMonth is the vacation a user took in a month
+type YearSummaryMonth struct {
+	Month string `json:"month"` // YYYY-MM
+	Days  int64  `json:"days"`
+}
+
+// YearSummaryCategory is the work a user logged on tasks of a category
+type YearSummaryCategory struct {
+	TaskCategoryID *int32  `json:"taskCategoryId,omitempty"` // None for tasks without a category
+	Name           *string `json:"name,omitempty"`
+	WorkedDays     float64 `json:"workedDays"`
+	Share          float64 `json:"share"` // Percent of the year's work
+}
+
+// YearSummaryHolidayWork is the work a user logged on holidays and weekends
+type YearSummaryHolidayWork struct {
+	WorkedDays float64                 `json:"workedDays"`
+	Dates      []YearSummaryHolidayDay `json:"dates"`
+}
+
+// YearSummaryHolidayDay is a holiday or weekend day a user worked
+type YearSummaryHolidayDay struct {
+	Date       string  `json:"date"`              // YYYY-MM-DD
+	Holiday    *string `json:"holiday,omitempty"` // None on weekends
+	WorkedDays float64 `json:"workedDays"`
+}
+
+// getYearSummary handles GET /api/current-user/year-summary, summarizing the user's year, this year
+// unless year is given, as JSON or, with format=pdf, as a PDF to keep
+func (s *Server) getYearSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentUser, err := getCurrentUserFromRequest(s.store, r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	format := "json"
+	if value := query.Get("format"); value != "" {
+		format = value
+	}
+	if format != "json" && format != string(reports.PDF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid format. Use json or pdf")
+		return
+	}
+
+	today, err := s.userToday(ctx, currentUser.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching time zone preference: "+err.Error())
+		return
+	}
+	year := today.Year()
+	if yearParam := query.Get("year"); yearParam != "" {
+		year, err = strconv.Atoi(yearParam)
+		if err != nil || year <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid year")
+			return
+		}
+	}
+
+	summary, err := s.yearSummary(ctx, currentUser.ID, year)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching year summary: "+err.Error())
+		return
+	}
+	if format == "json" {
+		respondWithJSON(w, http.StatusOK, summary)
+		return
+	}
+
+	var buf bytes.Buffer
+	report := yearSummaryReport(summary, currentUser.Username, time.Now().In(s.timeZone))
+	if err := reports.Render(&buf, reports.PDF, report, s.reportBranding()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error exporting year summary: "+err.Error())
+		return
+	}
+	writeReportFile(w, reports.PDF.ContentType(), fmt.Sprintf("year-summary-%d.pdf", year), buf.Bytes())
+}
+
+// yearSummary gathers a user's year in review
+func (s *Server) yearSummary(ctx context.Context, userID int32, year int) (YearSummaryResponse, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	startDate := pgtype.Date{Time: start, Valid: true}
+	endDate := pgtype.Date{Time: start.AddDate(1, 0, -1), Valid: true}
+	summary := YearSummaryResponse{Year: int32(year)}
+
+	leave, err := s.store.SummarizeLeaveByMonth(ctx, sqlc.SummarizeLeaveByMonthParams{
+		UserID:    userID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		return summary, fmt.Errorf("fetching leave: %w", err)
+	}
+	summary.VacationByMonth = make([]YearSummaryMonth, 12)
+	for i := range summary.VacationByMonth {
+		summary.VacationByMonth[i].Month = start.AddDate(0, i, 0).Format("2006-01")
+	}
+	for _, row := range leave {
+		if row.Type == "vacation" && row.Month >= 1 && row.Month <= 12 {
+			summary.VacationByMonth[row.Month-1].Days += row.DayCount
+			summary.VacationDays += row.DayCount
+		}
+	}
+
+	categories, err := s.store.ListTaskCategoryWorkByUser(ctx, sqlc.ListTaskCategoryWorkByUserParams{
+		UserID:    userID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		return summary, fmt.Errorf("fetching work by category: %w", err)
+	}
+	for _, row := range categories {
+		summary.WorkedDays += numericToFloat64(row.WorkedDay, 0)
+	}
+	summary.TopCategories = make([]YearSummaryCategory, 0, min(len(categories), yearSummaryTopCategories))
+	for _, row := range categories[:min(len(categories), yearSummaryTopCategories)] {
+		category := YearSummaryCategory{
+			TaskCategoryID: int4Ptr(row.TaskCategoryID),
+			Name:           textPtr(row.CategoryName),
+			WorkedDays:     numericToFloat64(row.WorkedDay, 0),
+		}
+		if summary.WorkedDays > 0 {
+			category.Share = category.WorkedDays / summary.WorkedDays * 100
+		}
+		summary.TopCategories = append(summary.TopCategories, category)
+	}
+
+	holidayWork, err := s.store.ListHolidayWorkByUser(ctx, sqlc.ListHolidayWorkByUserParams{
+		UserID:    userID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		return summary, fmt.Errorf("fetching holiday work: %w", err)
+	}
+	summary.HolidayWork.Dates = make([]YearSummaryHolidayDay, 0, len(holidayWork))
+	for _, row := range holidayWork {
+		day := YearSummaryHolidayDay{
+			Date:       row.WorkedDate.Time.Format("2006-01-02"),
+			Holiday:    textPtr(row.HolidayName),
+			WorkedDays: numericToFloat64(row.WorkedDay, 0),
+		}
+		summary.HolidayWork.WorkedDays += day.WorkedDays
+		summary.HolidayWork.Dates = append(summary.HolidayWork.Dates, day)
+	}
+
+	balance, err := s.store.GetLeaveBalance(ctx, sqlc.GetLeaveBalanceParams{UserID: userID, Year: int32(year)})
+	switch {
+	case err == nil:
+		summary.Balances = leaveBalanceToResponse(balance)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return summary, fmt.Errorf("fetching balances: %w", err)
+	}
+	return summary, nil
+}
+
+// yearSummaryReport lays a year in review out as a report of sections, in English as PDFs are
+func yearSummaryReport(summary YearSummaryResponse, username string, generatedAt time.Time) reports.Report {
+	report := reports.Report{
+		Title:    "Year in review",
+		Subtitle: fmt.Sprintf("%s · %d", username, summary.Year),
+		Columns: []reports.Column{
+			{Title: "Section", Kind: reports.Text},
+			{Title: "Item", Kind: reports.Text},
+			{Title: "Days", Kind: reports.Number},
+		},
+		GeneratedAt: generatedAt,
+		Language:    i18n.English,
+	}
+	add := func(section, item string, days float64) {
+		report.Rows = append(report.Rows, []any{section, item, days})
+	}
+
+	for _, month := range summary.VacationByMonth {
+		date, _ := time.Parse("2006-01", month.Month)
+		add("Vacation", date.Format("January"), float64(month.Days))
+	}
+	add("Vacation", "Total", float64(summary.VacationDays))
+
+	for _, category := range summary.TopCategories {
+		name := "No category"
+		if category.Name != nil {
+			name = *category.Name
+		}
+		add("Top categories", fmt.Sprintf("%s (%.1f%%)", name, category.Share), category.WorkedDays)
+	}
+	add("Top categories", "All work", summary.WorkedDays)
+
+	for _, day := range summary.HolidayWork.Dates {
+		item := day.Date
+		if day.Holiday != nil {
+			item += " " + *day.Holiday
+		}
+		add("Holiday work", item, day.WorkedDays)
+	}
+	add("Holiday work", "Total", summary.HolidayWork.WorkedDays)
+
+	if balances := summary.Balances; balances != nil {
+		add("Balances", "Vacation quota", balances.VacationDays)
+		add("Balances", "Vacation used", balances.UsedVacationDays)
+		add("Balances", "Vacation remaining", balances.RemainingVacationDays)
+		add("Balances", "Sick leave used", balances.UsedSickLeaveDays)
+	}
+	return report
+}
@@ -61,4 +61,13 @@ instance.interceptors.response.use(
   }
 );
 
+// Envelope of a paged listing; total counts every matching item, not only this page
+export interface Page<T> {
+  data: T[];
+  total: number;
+  limit: number;
+  offset?: number;
+  next_cursor?: string | null;
+}
+
 export default instance; 
\ No newline at end of file
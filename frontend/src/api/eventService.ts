@@ -0,0 +1,37 @@
+import api from './axiosConfig';
+
+// A change streamed by GET /api/events, resync asks to fetch everything again
+export interface LiveEvent {
+  type: 'leave_log' | 'task_log' | 'medical_expense' | 'annual_record' | 'task' | 'resync';
+  op?: 'INSERT' | 'UPDATE' | 'DELETE';
+  id?: number;
+  userId?: number;
+  year?: number;
+}
+
+const eventTypes: LiveEvent['type'][] = ['leave_log', 'task_log', 'medical_expense', 'annual_record', 'task', 'resync'];
+
+const eventService = {
+  // Listen to live updates until the returned function is called. EventSource can't send
+  // headers, so the token goes in the URL, and it reconnects by itself.
+  subscribe: (onEvent: (event: LiveEvent) => void): (() => void) => {
+    const token = localStorage.getItem('auth_token');
+    if (!token) {
+      return () => {};
+    }
+
+    const source = new EventSource(`${api.defaults.baseURL}/api/events?access_token=${encodeURIComponent(token)}`);
+    const listener = (message: MessageEvent) => {
+      try {
+        onEvent(JSON.parse(message.data));
+      } catch (error) {
+        console.error('Error reading live event:', error);
+      }
+    };
+    eventTypes.forEach(type => source.addEventListener(type, listener));
+
+    return () => source.close();
+  },
+};
+
+export default eventService;
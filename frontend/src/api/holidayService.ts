@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface Holiday {
   id: number;
@@ -17,8 +17,8 @@ export interface CreateHolidayRequest {
 const holidayService = {
   // Get all holidays
   getAllHolidays: async (): Promise<Holiday[]> => {
-    const response = await api.get('/api/holidays');
-    return response.data;
+    const response = await api.get<Page<Holiday>>('/api/holidays');
+    return response.data.data;
   },
 
   // Get holiday by ID
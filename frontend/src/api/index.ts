@@ -9,12 +9,14 @@ import taskService from './taskService';
 import taskCategoryService from './taskCategoryService';
 import taskEstimateService from './taskEstimateService';
 import taskLogService from './taskLogService';
+import eventService from './eventService';
 
 // Types for task service
 import type { Task, TaskCreateRequest, TaskUpdateRequest } from './taskService';
 import type { TaskCategory, TaskCategoryCreateRequest } from './taskCategoryService';
 import type { TaskEstimate, TaskEstimateCreateRequest } from './taskEstimateService';
 import type { TaskLog, TaskLogCreateRequest } from './taskLogService';
+import type { LiveEvent } from './eventService';
 
 // Export the services
 export {
@@ -29,6 +31,7 @@ export {
   taskCategoryService,
   taskEstimateService,
   taskLogService,
+  eventService,
 };
 
 // Export the types
@@ -41,5 +44,6 @@ export type {
   TaskEstimate,
   TaskEstimateCreateRequest,
   TaskLog,
-  TaskLogCreateRequest
+  TaskLogCreateRequest,
+  LiveEvent
 }; 
\ No newline at end of file
@@ -67,7 +67,8 @@ const leaveLogService = {
         return [];
       }
       
-      return await response.json();
+      const page = await response.json();
+      return page.data;
     } catch (error) {
       console.error('Error fetching leave logs:', error);
       return [];
@@ -140,9 +141,9 @@ const leaveLogService = {
         return [];
       }
       
-      const data = await response.json();
-      console.log('Received leave logs:', data);
-      return data;
+      const page = await response.json();
+      console.log('Received leave logs:', page);
+      return page.data;
     } catch (error) {
       console.error('Error fetching current user leave logs:', error);
       return [];
@@ -72,7 +72,7 @@ const medicalExpenseService = {
         return [];
       }
       
-      const data = await response.json();
+      const { data } = await response.json();
       console.log('Server response data:', data);
       
       // If year is specified, filter the results
@@ -130,7 +130,7 @@ const medicalExpenseService = {
         return [];
       }
       
-      const data = await response.json();
+      const { data } = await response.json();
       console.log('MEDICAL EXPENSES DEBUG: Raw server data:', data);
       console.log('MEDICAL EXPENSES DEBUG: Data details:', {
         type: typeof data, 
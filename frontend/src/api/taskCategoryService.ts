@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface TaskCategory {
   id: number;
@@ -33,8 +33,8 @@ const taskCategoryService = {
    */
   async getAllTaskCategories(filter: TaskCategoryFilter = {}): Promise<TaskCategory[]> {
     const { limit = 50, offset = 0 } = filter;
-    const response = await api.get(`/api/task-categories?limit=${limit}&offset=${offset}`);
-    return response.data;
+    const response = await api.get<Page<TaskCategory>>(`/api/task-categories?limit=${limit}&offset=${offset}`);
+    return response.data.data;
   },
 
   /**
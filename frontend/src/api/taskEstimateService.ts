@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface TaskEstimate {
   id: number;
@@ -33,8 +33,8 @@ const taskEstimateService = {
    */
   async getAllTaskEstimates(filter: TaskEstimateFilter = {}): Promise<TaskEstimate[]> {
     const { limit = 50, offset = 0 } = filter;
-    const response = await api.get(`/api/task-estimates?limit=${limit}&offset=${offset}`);
-    return response.data;
+    const response = await api.get<Page<TaskEstimate>>(`/api/task-estimates?limit=${limit}&offset=${offset}`);
+    return response.data.data;
   },
 
   /**
@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface TaskLog {
   id: number;
@@ -41,8 +41,8 @@ const taskLogService = {
    */
   async getAllTaskLogs(filter: TaskLogFilter = {}): Promise<TaskLog[]> {
     const { limit = 50, offset = 0 } = filter;
-    const response = await api.get(`/api/task-logs?limit=${limit}&offset=${offset}`);
-    return response.data;
+    const response = await api.get<Page<TaskLog>>(`/api/task-logs?limit=${limit}&offset=${offset}`);
+    return response.data.data;
   },
 
   /**
@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface Task {
   id: number;
@@ -42,8 +42,8 @@ const taskService = {
    */
   async getAllTasks(filter: TaskFilter = {}): Promise<Task[]> {
     const { limit = 50, offset = 0 } = filter;
-    const response = await api.get(`/api/tasks?limit=${limit}&offset=${offset}`);
-    return response.data;
+    const response = await api.get<Page<Task>>(`/api/tasks?limit=${limit}&offset=${offset}`);
+    return response.data.data;
   },
 
   /**
@@ -1,4 +1,4 @@
-import api from './axiosConfig';
+import api, { Page } from './axiosConfig';
 
 export interface User {
   id: number;
@@ -24,8 +24,8 @@ export interface LoginRequest {
 const userService = {
   // Get all users
   getAllUsers: async (): Promise<User[]> => {
-    const response = await api.get('/api/users');
-    return response.data;
+    const response = await api.get<Page<User>>('/api/users');
+    return response.data.data;
   },
 
   // Get user by ID
@@ -17,7 +17,7 @@ import {
   Refresh as RefreshIcon
 } from '@mui/icons-material';
 import MainLayout from '../components/Layout';
-import { annualRecordService, quotaPlanService } from '../api';
+import { annualRecordService, quotaPlanService, eventService } from '../api';
 import { useAuth } from '../contexts/AuthContext';
 import { AnnualRecord } from '../api/annualRecordService';
 import { QuotaPlan } from '../api/quotaPlanService';
@@ -147,6 +147,20 @@ const Dashboard: React.FC = () => {
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [user, currentYear]);
 
+  // Fetch again whenever the annual record changes, instead of polling
+  useEffect(() => {
+    if (!user) {
+      return;
+    }
+    return eventService.subscribe(event => {
+      const ownRecord = event.type === 'annual_record' && event.userId === user.id && event.year === currentYear;
+      if (ownRecord || event.type === 'resync') {
+        fetchData();
+      }
+    });
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [user, currentYear]);
+
   // Calculate leave quota
   const calculateRemainingLeaveQuota = (): number => {
     if (!annualRecord || !quotaPlan) return 0;
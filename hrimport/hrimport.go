@@ -0,0 +1,635 @@
+// Package hrimport loads the users, historical leave, balances and medical expenses kept in a
+// legacy HR spreadsheet. A workbook has a sheet of each, named users, leave, balances and
+// expenses, with a header row naming the columns; any of them may be missing. It comes as XLSX or
+// as the same rows in JSON.
+//
+// Check goes through the whole workbook before anything is written: every row against its
+// sheet's rules, and every username, department and quota plan it refers to against the users
+// sheet and what the database holds already. Import writes the workbook in one transaction, and
+// only when the check finds no problem, so a workbook is loaded completely or not at all.
+package hrimport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/validate"
+)
+
+// dateLayout is the format of dates in JSON and in the text cells of a workbook
+const dateLayout = validate.DateLayout
+
+// The sheets of a workbook
+const (
+	SheetUsers    = "users"
+	SheetLeave    = "leave"
+	SheetBalances = "balances"
+	SheetExpenses = "expenses"
+)
+
+// Workbook is the content of a legacy HR spreadsheet
+type Workbook struct {
+	Users    []UserRow    `json:"users"`
+	Leave    []LeaveRow   `json:"leave"`
+	Balances []BalanceRow `json:"balances"`
+	Expenses []ExpenseRow `json:"expenses"`
+}
+
+// UserRow is a user to create. Usernames must be new.
+type UserRow struct {
+	Username      string   `json:"username" validate:"required,max=255"`
+	Email         string   `json:"email" validate:"required,email,max=255"`
+	UserType      string   `json:"userType" validate:"oneof=admin user"` // Defaults to user
+	Password      string   `json:"password" validate:"max=72"`           // Generated when empty
+	DailyCapacity *float64 `json:"dailyCapacity" validate:"gt=0,max=1"`  // Defaults to 1
+	Department    string   `json:"department" validate:"max=100"`        // Of the org chart
+
+	row int
+}
+
+// LeaveRow is a day of leave a user took, of a new user or one there already
+type LeaveRow struct {
+	Username string `json:"username" validate:"required,max=255"`
+	Type     string `json:"type" validate:"required,oneof=vacation sick personal unpaid other"`
+	Date     string `json:"date" validate:"required,date"`
+	Note     string `json:"note"`
+
+	row int
+}
+
+// BalanceRow is the annual record of a user for a year, under a quota plan of that year
+type BalanceRow struct {
+	Username               string  `json:"username" validate:"required,max=255"`
+	Year                   int32   `json:"year" validate:"required,min=2000,max=2100"`
+	QuotaPlan              string  `json:"quotaPlan" validate:"required,max=255"` // The plan's name
+	RolloverVacationDays   float64 `json:"rolloverVacationDays" validate:"min=0,max=999"`
+	UsedVacationDays       float64 `json:"usedVacationDays" validate:"min=0,max=999"`
+	UsedSickLeaveDays      float64 `json:"usedSickLeaveDays" validate:"min=0,max=999"`
+	UsedMedicalExpenseBaht float64 `json:"usedMedicalExpenseBaht" validate:"min=0,max=99999999"`
+
+	row int
+}
+
+// ExpenseRow is a medical expense a user claimed
+type ExpenseRow struct {
+	Username    string  `json:"username" validate:"required,max=255"`
+	Amount      float64 `json:"amount" validate:"required,gt=0,max=99999999"`
+	ReceiptDate string  `json:"receiptDate" validate:"required,date"`
+	ReceiptName string  `json:"receiptName" validate:"max=255"`
+	Note        string  `json:"note"`
+
+	row int
+}
+
+// Problem is something wrong with a row of a sheet
+type Problem struct {
+	// Row counts the header as row 1 in a workbook, and items from 1 in JSON
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problems lists the problems of each sheet, by sheet
+type Problems map[string][]Problem
+
+func (p Problems) add(sheet string, row int, field, message string) {
+	p[sheet] = append(p[sheet], Problem{Row: row, Field: field, Message: message})
+}
+
+// Result is what importing a workbook found or did
+type Result struct {
+	Rows      map[string]int    // Rows of each sheet
+	Problems  Problems          // None when the workbook is clean
+	Committed bool              // Whether the workbook was written
+	Passwords map[string]string // The passwords generated for users without one, by username
+	// SyncYears are the users and years imported leave or expenses fell in without a balance
+	// imported, whose annual records should be synced with them
+	SyncYears []UserYear
+}
+
+// UserYear is a year of a user
+type UserYear struct {
+	UserID int32
+	Year   int32
+}
+
+// errProblems rolls back an import whose check, made again in the transaction, found problems
+var errProblems = errors.New("the workbook has problems")
+
+// ReadXLSX reads a workbook from an XLSX file, reporting the cells it can't read as problems
+func ReadXLSX(data []byte) (Workbook, Problems, error) {
+	var workbook Workbook
+	problems := Problems{}
+	sheets, err := readXLSX(data)
+	if err != nil {
+		return workbook, problems, err
+	}
+	workbook.Users = fromSheet[UserRow](sheets, SheetUsers, problems)
+	workbook.Leave = fromSheet[LeaveRow](sheets, SheetLeave, problems)
+	workbook.Balances = fromSheet[BalanceRow](sheets, SheetBalances, problems)
+	workbook.Expenses = fromSheet[ExpenseRow](sheets, SheetExpenses, problems)
+	return workbook, problems, nil
+}
+
+// numberedRow is a pointer to a row type, numbered with its row in its sheet
+type numberedRow[T any] interface {
+	*T
+	setRow(number int)
+}
+
+func (r *UserRow) setRow(number int)    { r.row = number }
+func (r *LeaveRow) setRow(number int)   { r.row = number }
+func (r *BalanceRow) setRow(number int) { r.row = number }
+func (r *ExpenseRow) setRow(number int) { r.row = number }
+
+// fromSheet reads the rows of a sheet into T by the JSON names of its fields, matching headers
+// regardless of case, spaces, underscores and dashes. Columns T has no field for are ignored.
+func fromSheet[T any, P numberedRow[T]](sheets map[string][]sheetRow, sheet string, problems Problems) []T {
+	rows := sheets[sheet]
+	items := make([]T, 0, max(len(rows)-1, 0))
+	if len(rows) == 0 {
+		return items
+	}
+
+	t := reflect.TypeFor[T]()
+	columns := map[int]int{} // Field index by column
+	for column, header := range rows[0].cells {
+		name := normalizeHeader(header)
+		for i := 0; i < t.NumField(); i++ {
+			jsonName, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if jsonName != "" && strings.ToLower(jsonName) == name {
+				columns[column] = i
+			}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !strings.Contains(sf.Tag.Get("validate"), "required") {
+			continue
+		}
+		found := false
+		for _, index := range columns {
+			found = found || index == i
+		}
+		if !found {
+			problems.add(sheet, rows[0].number, jsonName, "the column is missing")
+		}
+	}
+
+	for _, row := range rows[1:] {
+		var item T
+		v := reflect.ValueOf(&item).Elem()
+		for column, text := range row.cells {
+			index, ok := columns[column]
+			if !ok || text == "" {
+				continue
+			}
+			sf := t.Field(index)
+			jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if message := setCell(v.Field(index), sf, text); message != "" {
+				problems.add(sheet, row.number, jsonName, message)
+			}
+		}
+		P(&item).setRow(row.number)
+		items = append(items, item)
+	}
+	return items
+}
+
+// setCell sets a field to the text of a cell, returning what is wrong with the text
+func setCell(field reflect.Value, sf reflect.StructField, text string) string {
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	switch field.Kind() {
+	case reflect.String:
+		if strings.Contains(sf.Tag.Get("validate"), "date") {
+			if date, ok := parseDate(text); ok {
+				text = date.Format(dateLayout)
+			}
+		}
+		field.SetString(text)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		field.SetFloat(number)
+	case reflect.Int32:
+		number, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return "must be a whole number"
+		}
+		field.SetInt(number)
+	}
+	return ""
+}
+
+// normalizeHeader lowercases a column header and drops its spaces, underscores and dashes
+func normalizeHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(header))
+}
+
+// Numbered numbers the rows of a workbook from JSON by their place in their sheet, from 1
+func (w *Workbook) Numbered() {
+	for i := range w.Users {
+		w.Users[i].setRow(i + 1)
+	}
+	for i := range w.Leave {
+		w.Leave[i].setRow(i + 1)
+	}
+	for i := range w.Balances {
+		w.Balances[i].setRow(i + 1)
+	}
+	for i := range w.Expenses {
+		w.Expenses[i].setRow(i + 1)
+	}
+}
+
+// Rows returns how many rows each sheet has
+func (w Workbook) Rows() map[string]int {
+	return map[string]int{
+		SheetUsers:    len(w.Users),
+		SheetLeave:    len(w.Leave),
+		SheetBalances: len(w.Balances),
+		SheetExpenses: len(w.Expenses),
+	}
+}
+
+// checked is what checking a workbook looked up, for writing it
+type checked struct {
+	userIDs    map[string]int32 // Of the users there already, 0 for those in the users sheet
+	quotaPlans map[string]int32 // By the plan's name and year
+}
+
+func planKey(name string, year int32) string {
+	return fmt.Sprintf("%s\x00%d", name, year)
+}
+
+// Check finds the problems of a workbook: rows breaking their sheet's rules, usernames taken or
+// no user has, departments and quota plans that don't exist, and leave, balances or users the
+// workbook has twice or the database has already
+func Check(ctx context.Context, q sqlc.Querier, workbook Workbook) (Problems, error) {
+	problems, _, err := check(ctx, q, workbook)
+	return problems, err
+}
+
+func check(ctx context.Context, q sqlc.Querier, workbook Workbook) (Problems, checked, error) {
+	problems := Problems{}
+	found := checked{userIDs: map[string]int32{}, quotaPlans: map[string]int32{}}
+	addInvalid := func(sheet string, row int, v any) {
+		var errs validate.Errors
+		if err := validate.Struct(v); errors.As(err, &errs) {
+			for _, field := range sortedKeys(errs) {
+				problems.add(sheet, row, field, errs[field])
+			}
+		}
+	}
+
+	departments := map[string]bool{}
+	for _, user := range workbook.Users {
+		addInvalid(SheetUsers, user.row, user)
+		username := strings.TrimSpace(user.Username)
+		if username == "" {
+			continue
+		}
+		if _, dup := found.userIDs[username]; dup {
+			problems.add(SheetUsers, user.row, "username", "the username is in the sheet more than once")
+			continue
+		}
+		_, err := q.GetUserByUsername(ctx, username)
+		switch {
+		case err == nil:
+			problems.add(SheetUsers, user.row, "username", "the username is taken")
+		case !errors.Is(err, pgx.ErrNoRows):
+			return nil, found, err
+		}
+		found.userIDs[username] = 0
+
+		department := strings.TrimSpace(user.Department)
+		if department == "" {
+			continue
+		}
+		exists, ok := departments[department]
+		if !ok {
+			_, err := q.GetDepartmentByName(ctx, department)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, found, err
+			}
+			exists = err == nil
+			departments[department] = exists
+		}
+		if !exists {
+			problems.add(SheetUsers, user.row, "department", "must be a department of the org chart")
+		}
+	}
+
+	// userID returns the ID of an existing user, 0 for a user of the users sheet, and whether
+	// anyone has the username
+	userID := func(username string) (int32, bool, error) {
+		username = strings.TrimSpace(username)
+		if id, ok := found.userIDs[username]; ok {
+			return id, true, nil
+		}
+		user, err := q.GetUserByUsername(ctx, username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		found.userIDs[username] = user.ID
+		return user.ID, true, nil
+	}
+	const unknownUser = "no user has the username, here or in the users sheet"
+
+	leaveDays := map[string]bool{}
+	for _, leave := range workbook.Leave {
+		addInvalid(SheetLeave, leave.row, leave)
+		if strings.TrimSpace(leave.Username) == "" {
+			continue
+		}
+		id, ok, err := userID(leave.Username)
+		if err != nil {
+			return nil, found, err
+		}
+		if !ok {
+			problems.add(SheetLeave, leave.row, "username", unknownUser)
+			continue
+		}
+		date, err := time.Parse(dateLayout, leave.Date)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSpace(leave.Username) + "\x00" + leave.Date
+		if leaveDays[key] {
+			problems.add(SheetLeave, leave.row, "date", "the user has leave on the day more than once in the sheet")
+			continue
+		}
+		leaveDays[key] = true
+		if id != 0 {
+			count, err := q.CountLeaveLogsForDate(ctx, sqlc.CountLeaveLogsForDateParams{
+				UserID: id,
+				Date:   pgtype.Date{Time: date, Valid: true},
+			})
+			if err != nil {
+				return nil, found, err
+			}
+			if count > 0 {
+				problems.add(SheetLeave, leave.row, "date", "the user has leave on the day already")
+			}
+		}
+	}
+
+	balanceYears := map[string]bool{}
+	for _, balance := range workbook.Balances {
+		addInvalid(SheetBalances, balance.row, balance)
+		if strings.TrimSpace(balance.Username) == "" || balance.Year == 0 {
+			continue
+		}
+		id, ok, err := userID(balance.Username)
+		if err != nil {
+			return nil, found, err
+		}
+		if !ok {
+			problems.add(SheetBalances, balance.row, "username", unknownUser)
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%d", strings.TrimSpace(balance.Username), balance.Year)
+		if balanceYears[key] {
+			problems.add(SheetBalances, balance.row, "year", "the user has a balance for the year more than once in the sheet")
+			continue
+		}
+		balanceYears[key] = true
+		if id != 0 {
+			_, err := q.GetAnnualRecordByUserAndYear(ctx, sqlc.GetAnnualRecordByUserAndYearParams{UserID: id, Year: balance.Year})
+			switch {
+			case err == nil:
+				problems.add(SheetBalances, balance.row, "year", "the user has a balance for the year already")
+			case !errors.Is(err, pgx.ErrNoRows):
+				return nil, found, err
+			}
+		}
+
+		plan := strings.TrimSpace(balance.QuotaPlan)
+		if plan == "" {
+			continue
+		}
+		if _, ok := found.quotaPlans[planKey(plan, balance.Year)]; !ok {
+			quotaPlan, err := q.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{PlanName: plan, Year: balance.Year})
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, found, err
+			}
+			found.quotaPlans[planKey(plan, balance.Year)] = quotaPlan.ID
+		}
+		if found.quotaPlans[planKey(plan, balance.Year)] == 0 {
+			problems.add(SheetBalances, balance.row, "quotaPlan", "the year has no quota plan of that name")
+		}
+	}
+
+	for _, expense := range workbook.Expenses {
+		addInvalid(SheetExpenses, expense.row, expense)
+		if strings.TrimSpace(expense.Username) == "" {
+			continue
+		}
+		if _, ok, err := userID(expense.Username); err != nil {
+			return nil, found, err
+		} else if !ok {
+			problems.add(SheetExpenses, expense.row, "username", unknownUser)
+		}
+	}
+	return problems, found, nil
+}
+
+// Import checks a workbook and, unless dryRun is set or the check finds problems, writes it in
+// one transaction. Balances are kept as given rather than worked out from the leave and expenses
+// imported with them: what they differ by is recorded as an adjustment by importedBy, the admin.
+func Import(ctx context.Context, store db.Store, workbook Workbook, importedBy int32, dryRun bool) (Result, error) {
+	result := Result{Rows: workbook.Rows()}
+	if dryRun {
+		problems, err := Check(ctx, store, workbook)
+		result.Problems = problems
+		return result, err
+	}
+
+	err := store.WithTx(ctx, func(q sqlc.Querier) error {
+		problems, found, err := check(ctx, q, workbook)
+		if err != nil {
+			return err
+		}
+		result.Problems = problems
+		if len(problems) > 0 {
+			return errProblems
+		}
+		return write(ctx, q, workbook, found, importedBy, &result)
+	})
+	if errors.Is(err, errProblems) {
+		return result, nil
+	}
+	if err != nil {
+		result.Passwords, result.SyncYears = nil, nil
+		return result, err
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// write creates the rows of a checked workbook
+func write(ctx context.Context, q sqlc.Querier, workbook Workbook, found checked, importedBy int32, result *Result) error {
+	result.Passwords = map[string]string{}
+	for _, row := range workbook.Users {
+		password := row.Password
+		if password == "" {
+			buf := make([]byte, 12)
+			if _, err := rand.Read(buf); err != nil {
+				return err
+			}
+			password = base64.RawURLEncoding.EncodeToString(buf)
+			result.Passwords[strings.TrimSpace(row.Username)] = password
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		params := sqlc.CreateUserParams{
+			Username: strings.TrimSpace(row.Username),
+			Password: string(hashed),
+			UserType: row.UserType,
+			Email:    row.Email,
+		}
+		if params.UserType == "" {
+			params.UserType = "user"
+		}
+		if row.DailyCapacity != nil {
+			if params.DailyCapacity, err = pgconv.FromFloat(*row.DailyCapacity); err != nil {
+				return err
+			}
+		}
+		user, err := q.CreateUser(ctx, params)
+		if err != nil {
+			return fmt.Errorf("creating user %s: %w", params.Username, err)
+		}
+		if department := strings.TrimSpace(row.Department); department != "" {
+			user, err = q.UpdateUser(ctx, sqlc.UpdateUserParams{
+				ID:            user.ID,
+				Username:      user.Username,
+				Password:      user.Password,
+				UserType:      user.UserType,
+				Email:         user.Email,
+				DailyCapacity: user.DailyCapacity,
+				Department:    pgtype.Text{String: department, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("setting the department of user %s: %w", params.Username, err)
+			}
+		}
+		found.userIDs[user.Username] = user.ID
+	}
+
+	// Leave and expenses are written first, so the balances imported can be recorded as the
+	// adjustments it takes for their annual records to keep them
+	balanceYears := map[UserYear]bool{}
+	for _, row := range workbook.Balances {
+		balanceYears[UserYear{UserID: found.userIDs[strings.TrimSpace(row.Username)], Year: row.Year}] = true
+	}
+	syncYear := func(key UserYear) {
+		if !balanceYears[key] {
+			balanceYears[key] = true
+			result.SyncYears = append(result.SyncYears, key)
+		}
+	}
+
+	leave := make([]sqlc.CreateLeaveLogsParams, 0, len(workbook.Leave))
+	for _, row := range workbook.Leave {
+		date, _ := time.Parse(dateLayout, row.Date)
+		params := sqlc.CreateLeaveLogsParams{
+			UserID: found.userIDs[strings.TrimSpace(row.Username)],
+			Type:   row.Type,
+			Date:   pgtype.Date{Time: date, Valid: true},
+			Note:   pgtype.Text{String: row.Note, Valid: row.Note != ""},
+		}
+		leave = append(leave, params)
+		syncYear(UserYear{UserID: params.UserID, Year: int32(date.Year())})
+	}
+	if len(leave) > 0 {
+		if _, err := q.CreateLeaveLogs(ctx, leave); err != nil {
+			return fmt.Errorf("creating leave: %w", err)
+		}
+	}
+
+	for _, row := range workbook.Expenses {
+		receiptDate, _ := time.Parse(dateLayout, row.ReceiptDate)
+		amount, err := pgconv.FromFloat(row.Amount)
+		if err != nil {
+			return err
+		}
+		expense, err := q.CreateMedicalExpense(ctx, sqlc.CreateMedicalExpenseParams{
+			UserID:      found.userIDs[strings.TrimSpace(row.Username)],
+			Amount:      amount,
+			ReceiptName: pgtype.Text{String: row.ReceiptName, Valid: row.ReceiptName != ""},
+			ReceiptDate: pgtype.Date{Time: receiptDate, Valid: true},
+			Note:        pgtype.Text{String: row.Note, Valid: row.Note != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("creating a medical expense of %s: %w", row.Username, err)
+		}
+		syncYear(UserYear{UserID: expense.UserID, Year: int32(receiptDate.Year())})
+	}
+
+	for _, row := range workbook.Balances {
+		record, err := q.CreateAnnualRecord(ctx, sqlc.CreateAnnualRecordParams{
+			UserID:                 found.userIDs[strings.TrimSpace(row.Username)],
+			Year:                   row.Year,
+			QuotaPlanID:            pgtype.Int4{Int32: found.quotaPlans[planKey(strings.TrimSpace(row.QuotaPlan), row.Year)], Valid: true},
+			RolloverVacationDay:    pgconv.MustFromFloat(row.RolloverVacationDays),
+			UsedVacationDay:        pgconv.MustFromFloat(row.UsedVacationDays),
+			UsedSickLeaveDay:       pgconv.MustFromFloat(row.UsedSickLeaveDays),
+			WorkedOnHolidayDay:     pgconv.MustFromFloat(0),
+			WorkedDay:              pgconv.MustFromFloat(0),
+			UsedMedicalExpenseBaht: pgconv.MustFromFloat(row.UsedMedicalExpenseBaht),
+		})
+		if err != nil {
+			return fmt.Errorf("creating the %d balance of %s: %w", row.Year, row.Username, err)
+		}
+		if err := q.AdjustBalance(ctx, sqlc.AdjustBalanceParams{
+			RecordedByUserID: pgtype.Int4{Int32: importedBy, Valid: true},
+			AnnualRecordID:   record.ID,
+		}); err != nil {
+			return fmt.Errorf("recording the %d balance of %s: %w", row.Year, row.Username, err)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the fields of validation errors in name order, so problems come out the same
+// every time
+func sortedKeys(errs validate.Errors) []string {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fields
+}
@@ -0,0 +1,237 @@
+package hrimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A workbook is read without a spreadsheet library: the zip's xl/workbook.xml names the sheets,
+// its relationships point at their XML, and cells hold numbers, shared strings or inline strings.
+// Formulas are read as the value Excel saved with them. Styles aren't read, so a date column's
+// cells are either text as YYYY-MM-DD or the day numbers Excel stores dates as.
+
+// maxSheetRows caps the rows read from one sheet, header included
+const maxSheetRows = 50000
+
+// excelEpoch is the day Excel counts dates from, with the 1900 leap year bug accounted for
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// sheetRow is a row of cell text, with its number in the sheet
+type sheetRow struct {
+	number int
+	cells  []string
+}
+
+// readXLSX returns the rows of each sheet of an XLSX workbook by lowercased sheet name, leaving
+// out empty rows
+func readXLSX(data []byte) (map[string][]sheetRow, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an XLSX workbook: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodeZipXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		shared = make([]string, len(sst.Items))
+		for i, item := range sst.Items {
+			shared[i] = item.String()
+		}
+	}
+
+	sheets := make(map[string][]sheetRow, len(workbook.Sheets))
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.RID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %q has no part in the workbook", sheet.Name)
+		}
+		rows, err := readSheet(files, target, shared)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", sheet.Name, err)
+		}
+		sheets[strings.ToLower(strings.TrimSpace(sheet.Name))] = rows
+	}
+	return sheets, nil
+}
+
+// xlsxText is the text of a shared or inline string, plain or made of formatted runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// readSheet returns the non-empty rows of a worksheet, each with a cell per column up to its last
+// cell
+func readSheet(files map[string]*zip.File, name string, shared []string) ([]sheetRow, error) {
+	var worksheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(files, name, &worksheet); err != nil {
+		return nil, err
+	}
+	if len(worksheet.Rows) > maxSheetRows {
+		return nil, fmt.Errorf("more than %d rows", maxSheetRows)
+	}
+
+	rows := make([]sheetRow, 0, len(worksheet.Rows))
+	for i, row := range worksheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+		var cells []string
+		empty := true
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				var err error
+				if column, err = columnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			var text string
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				text = shared[index]
+			case "inlineStr":
+				text = cell.Inline.String()
+			default:
+				text = cell.Value
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			cells[column] = text
+			empty = false
+		}
+		if !empty {
+			rows = append(rows, sheetRow{number: number, cells: cells})
+		}
+	}
+	return rows, nil
+}
+
+// columnIndex returns the 0-based column of a cell reference like AB12
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return column - 1, nil
+}
+
+// decodeZipXML decodes the XML part of the workbook with the given name
+func decodeZipXML(files map[string]*zip.File, name string, v any) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("not an XLSX workbook: %s is missing", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
+
+// maxPartSize caps the uncompressed size of a part of the workbook read, so a small upload can't
+// unzip into gigabytes
+const maxPartSize = 100 << 20
+
+// parseDate reads a date cell, as YYYY-MM-DD or as the day number Excel stores dates as
+func parseDate(text string) (time.Time, bool) {
+	if date, err := time.Parse(dateLayout, text); err == nil {
+		return date, true
+	}
+	days, err := strconv.ParseFloat(text, 64)
+	if err != nil || days < 1 || days > 2958465 {
+		return time.Time{}, false
+	}
+	return excelEpoch.AddDate(0, 0, int(days)), true
+}
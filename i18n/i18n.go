@@ -0,0 +1,192 @@
+// Package i18n translates what TableG tells people, API error messages, notifications and report
+// labels, into the language they read. Messages are written in English where they are made and
+// translated on the way out from a catalog per language. A catalog holds whole messages, and
+// patterns for the messages with a part that varies, such as "{word} not found"; a message a catalog
+// has neither for stays in English.
+package i18n
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Language is a language there is a catalog for, named by its ISO 639-1 code
+type Language string
+
+const (
+	English Language = "en"
+	Thai    Language = "th"
+)
+
+// Languages are the languages messages are translated into, English, which they are written in,
+// first
+var Languages = []Language{English, Thai}
+
+// catalogs are the catalogs of the languages other than English, by language
+var catalogs = map[Language]*catalog{
+	Thai: thai,
+}
+
+// Parse returns the language a tag names, such as th, th-TH or en-US, and false when there is no
+// catalog for it
+func Parse(tag string) (Language, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if slices.Contains(Languages, Language(tag)) {
+		return Language(tag), true
+	}
+	return "", false
+}
+
+// Negotiate returns the language of an Accept-Language header the client prefers most, such as
+// th for "th-TH,th;q=0.9,en;q=0.8", and fallback when it accepts none there is a catalog for
+func Negotiate(acceptLanguage string, fallback Language) Language {
+	best, bestQ := fallback, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		language, ok := Parse(tag)
+		if ok && q > bestQ {
+			best, bestQ = language, q
+		}
+	}
+	return best
+}
+
+// Translate returns message in the language, or message itself when the language's catalog has
+// no translation for it. A message such as "Error fetching tasks: connection refused" whose
+// beginning the catalog has is translated up to the colon, the rest is what went wrong and
+// stays as it is.
+func Translate(language Language, message string) string {
+	c, ok := catalogs[language]
+	if !ok {
+		return message
+	}
+	if translated, ok := c.translate(message); ok {
+		return translated
+	}
+	head, detail, found := strings.Cut(message, ": ")
+	if !found {
+		return message
+	}
+	translated, ok := c.translate(head)
+	if !ok {
+		return message
+	}
+	if translatedDetail, ok := c.translate(detail); ok {
+		detail = translatedDetail
+	}
+	return translated + ": " + detail
+}
+
+// catalog is the translations of one language
+type catalog struct {
+	// messages are translations of whole messages, by the English message
+	messages map[string]string
+	// patterns translate messages with parts that vary, tried in order
+	patterns []pattern
+	// words translate the {word} parts of patterns, by their English in lower case
+	words map[string]string
+}
+
+// pattern translates the messages that match an English template, such as "{word} not found",
+// in which {} stands for a value that is kept as it is, such as a number or a name, and {word}
+// for a part the catalog's words translate; a message whose {word} isn't one doesn't match. The
+// translation has a {} for each, in the same order.
+type pattern struct {
+	literals    []string // The template around its parts
+	words       []bool   // Whether each part is a {word}
+	translation []string
+}
+
+func newPattern(english, translation string) pattern {
+	var p pattern
+	for {
+		i := strings.IndexByte(english, '{')
+		if i < 0 {
+			break
+		}
+		placeholder := english[i : i+strings.IndexByte(english[i:], '}')+1]
+		p.literals = append(p.literals, english[:i])
+		p.words = append(p.words, placeholder == "{word}")
+		english = english[i+len(placeholder):]
+	}
+	p.literals = append(p.literals, english)
+	if len(p.words) == 0 {
+		panic("i18n: " + english + " has no parts that vary, it belongs with the messages")
+	}
+	p.translation = strings.Split(translation, "{}")
+	if len(p.literals) != len(p.translation) {
+		panic("i18n: the translation " + translation + " doesn't have a {} for each part")
+	}
+	return p
+}
+
+// match returns the parts of message that stand in for the pattern's placeholders, and false
+// when message doesn't match it
+func (p pattern) match(message string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(message, p.literals[0])
+	if !ok {
+		return nil, false
+	}
+	parts := make([]string, 0, len(p.words))
+	for i, literal := range p.literals[1:] {
+		var part string
+		if i == len(p.words)-1 {
+			if part, ok = strings.CutSuffix(rest, literal); !ok {
+				return nil, false
+			}
+		} else {
+			j := strings.Index(rest, literal)
+			if j < 0 || literal == "" {
+				return nil, false
+			}
+			part, rest = rest[:j], rest[j+len(literal):]
+		}
+		if part == "" {
+			return nil, false
+		}
+		parts = append(parts, part)
+	}
+	return parts, true
+}
+
+// translate returns the translation of a whole message, and false when the catalog has none
+func (c *catalog) translate(message string) (string, bool) {
+	if translated, ok := c.messages[message]; ok {
+		return translated, true
+	}
+patterns:
+	for _, p := range c.patterns {
+		parts, ok := p.match(message)
+		if !ok {
+			continue
+		}
+		for i, part := range parts {
+			if !p.words[i] {
+				continue
+			}
+			if parts[i], ok = c.words[strings.ToLower(part)]; !ok {
+				continue patterns
+			}
+		}
+		var sb strings.Builder
+		sb.WriteString(p.translation[0])
+		for i, part := range parts {
+			sb.WriteString(part)
+			sb.WriteString(p.translation[i+1])
+		}
+		return sb.String(), true
+	}
+	return "", false
+}
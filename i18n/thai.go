@@ -0,0 +1,393 @@
+package i18n
+
+// thai is the Thai catalog
+var thai = &catalog{
+	messages: map[string]string{
+		// Signing in
+		"Unauthorized":                    "ไม่ได้รับอนุญาต กรุณาเข้าสู่ระบบ",
+		"No authorization token provided": "ไม่พบโทเค็นยืนยันตัวตน",
+		"Invalid authorization format":    "รูปแบบการยืนยันตัวตนไม่ถูกต้อง",
+		"Invalid token":                   "โทเค็นไม่ถูกต้อง",
+		"Invalid username or token":       "ชื่อผู้ใช้หรือโทเค็นไม่ถูกต้อง",
+		"Invalid username or password":    "ชื่อผู้ใช้หรือรหัสผ่านไม่ถูกต้อง",
+		"Invalid API token":               "โทเค็น API ไม่ถูกต้อง",
+
+		// Requests
+		"Not found":                                        "ไม่พบข้อมูล",
+		"Invalid request":                                  "คำขอไม่ถูกต้อง",
+		"Invalid request payload":                          "ข้อมูลในคำขอไม่ถูกต้อง",
+		"Invalid multipart form":                           "ฟอร์ม multipart ไม่ถูกต้อง",
+		"Invalid year":                                     "ปีไม่ถูกต้อง",
+		"Invalid number":                                   "ตัวเลขไม่ถูกต้อง",
+		"Invalid amount":                                   "จำนวนเงินไม่ถูกต้อง",
+		"Invalid worked day":                               "จำนวนวันทำงานไม่ถูกต้อง",
+		"Invalid daily capacity":                           "กำลังงานต่อวันไม่ถูกต้อง",
+		"Invalid or expired OAuth state":                   "สถานะ OAuth ไม่ถูกต้องหรือหมดอายุแล้ว",
+		"Invalid date format (should be YYYY-MM-DD)":       "รูปแบบวันที่ไม่ถูกต้อง (ต้องเป็น YYYY-MM-DD)",
+		"Invalid from date format (should be YYYY-MM-DD)":  "รูปแบบวันที่เริ่มต้นไม่ถูกต้อง (ต้องเป็น YYYY-MM-DD)",
+		"Invalid to date format (should be YYYY-MM-DD)":    "รูปแบบวันที่สิ้นสุดไม่ถูกต้อง (ต้องเป็น YYYY-MM-DD)",
+		"Invalid start date format (should be YYYY-MM-DD)": "รูปแบบวันที่เริ่มต้นไม่ถูกต้อง (ต้องเป็น YYYY-MM-DD)",
+		"Invalid end date format (should be YYYY-MM-DD)":   "รูปแบบวันที่สิ้นสุดไม่ถูกต้อง (ต้องเป็น YYYY-MM-DD)",
+		"Invalid before date. Use YYYY-MM-DD":              "วันที่ before ไม่ถูกต้อง ต้องเป็น YYYY-MM-DD",
+		"Invalid format. Use json or zip":                  "รูปแบบไม่ถูกต้อง ต้องเป็น json หรือ zip",
+		"Invalid format. Use json or pdf":                  "รูปแบบไม่ถูกต้อง ต้องเป็น json หรือ pdf",
+		"to must not be before from":                       "to ต้องไม่อยู่ก่อน from",
+		"to date must not be before from date":             "วันที่สิ้นสุดต้องไม่อยู่ก่อนวันที่เริ่มต้น",
+		"Start date and end date are required":             "ต้องระบุวันที่เริ่มต้นและวันที่สิ้นสุด",
+		"purpose and subjectId are required":               "ต้องระบุ purpose และ subjectId",
+		"period is required, as YYYY-MM":                   "ต้องระบุ period ในรูปแบบ YYYY-MM",
+		"usernames is required":                            "ต้องระบุ usernames",
+		"user_id is required":                              "ต้องระบุ user_id",
+		"Invalid as_of. Use RFC 3339 or YYYY-MM-DD":        "as_of ไม่ถูกต้อง ต้องเป็น RFC 3339 หรือ YYYY-MM-DD",
+		"The server is under maintenance, changes can't be saved right now, try again later": "ระบบอยู่ระหว่างการปรับปรุง ยังบันทึกการเปลี่ยนแปลงไม่ได้ กรุณาลองใหม่ภายหลัง",
+		"Not supported by the in-memory database":                                            "ฐานข้อมูลในหน่วยความจำไม่รองรับ",
+		"Idempotency-Key is longer than 255 characters":                                      "Idempotency-Key ยาวเกิน 255 ตัวอักษร",
+		"Idempotency-Key was already used for a different request":                           "Idempotency-Key นี้ถูกใช้กับคำขออื่นไปแล้ว",
+		"A request with this Idempotency-Key is still in progress":                           "คำขอที่ใช้ Idempotency-Key นี้ยังดำเนินการอยู่",
+		"The first request with this Idempotency-Key just failed, retry it":                  "คำขอแรกที่ใช้ Idempotency-Key นี้เพิ่งล้มเหลว กรุณาส่งใหม่",
+
+		// Permissions
+		"You can only create leave logs for yourself":                                   "คุณบันทึกการลาได้เฉพาะของตัวเองเท่านั้น",
+		"You can only create medical expenses for your own account":                     "คุณบันทึกค่ารักษาพยาบาลได้เฉพาะของตัวเองเท่านั้น",
+		"You can only delegate your own approvals":                                      "คุณมอบหมายได้เฉพาะการอนุมัติของตัวเองเท่านั้น",
+		"You can only upload files of your own expenses, leave and avatar":              "คุณอัปโหลดไฟล์ได้เฉพาะของค่ารักษาพยาบาล การลา และรูปโปรไฟล์ของตัวเองเท่านั้น",
+		"You can't decide the step this request waits on":                               "คุณพิจารณาขั้นตอนที่คำขอนี้รออยู่ไม่ได้",
+		"Only admins can view everyone's attendance":                                    "เฉพาะผู้ดูแลระบบเท่านั้นที่ดูการลงเวลาของทุกคนได้",
+		"Only admins can export reports of other users":                                 "เฉพาะผู้ดูแลระบบเท่านั้นที่ส่งออกรายงานของผู้ใช้อื่นได้",
+		"Only admins can view the delegations of other users":                           "เฉพาะผู้ดูแลระบบเท่านั้นที่ดูการมอบหมายการอนุมัติของผู้ใช้อื่นได้",
+		"Only the task creator, its assignees or an administrator can modify this task": "เฉพาะผู้สร้างงาน ผู้รับผิดชอบ หรือผู้ดูแลระบบเท่านั้นที่แก้ไขงานนี้ได้",
+		"Only the session creator or an administrator can finalize the session":         "เฉพาะผู้สร้างรอบหรือผู้ดูแลระบบเท่านั้นที่สรุปรอบการประมาณงานได้",
+		"Only participants can vote in this estimation session":                         "เฉพาะผู้เข้าร่วมเท่านั้นที่ลงคะแนนในรอบการประมาณงานนี้ได้",
+		"Only administrators and the anomaly review department can review anomalies":    "เฉพาะผู้ดูแลระบบและแผนกที่ตรวจสอบความผิดปกติเท่านั้นที่ตรวจสอบความผิดปกติได้",
+
+		// Leave, attendance and payroll
+		"Already checked in, check out first":                        "ลงเวลาเข้างานแล้ว กรุณาลงเวลาออกก่อน",
+		"Not checked in":                                             "ยังไม่ได้ลงเวลาเข้างาน",
+		"Payroll period is already locked":                           "งวดเงินเดือนนี้ล็อกแล้ว",
+		"Payroll period isn't locked":                                "งวดเงินเดือนนี้ยังไม่ได้ล็อก",
+		"Preview the payroll period and lock it before exporting it": "กรุณาดูตัวอย่างงวดเงินเดือนและล็อกก่อนส่งออก",
+		"The request was decided already":                            "คำขอนี้ได้รับการพิจารณาแล้ว",
+		"The delegation was revoked already":                         "การมอบหมายการอนุมัตินี้ถูกยกเลิกไปแล้ว",
+		"The API token was revoked already":                          "โทเค็น API นี้ถูกเพิกถอนไปแล้ว",
+
+		// Tasks
+		"Task has no estimate":                                        "งานนี้ยังไม่มีการประมาณ",
+		"Task does not have this tag":                                 "งานนี้ไม่มีแท็กนี้",
+		"User is not assigned to this task":                           "ผู้ใช้ไม่ได้รับมอบหมายงานนี้",
+		"A task cannot be nested under itself or one of its subtasks": "งานอยู่ภายใต้ตัวเองหรืองานย่อยของตัวเองไม่ได้",
+		"Estimation session is closed":                                "รอบการประมาณงานนี้ปิดแล้ว",
+		"Not every participant has voted yet":                         "ผู้เข้าร่วมยังลงคะแนนไม่ครบ",
+
+		// Files and reports
+		"The download link is invalid or expired": "ลิงก์ดาวน์โหลดไม่ถูกต้องหรือหมดอายุแล้ว",
+		"The user has no avatar":                  "ผู้ใช้นี้ยังไม่มีรูปโปรไฟล์",
+		"Report export is still being rendered":   "รายงานยังสร้างไม่เสร็จ",
+		"Delete the user before anonymizing them": "กรุณาลบผู้ใช้ก่อนลบข้อมูลส่วนบุคคล",
+
+		// Announcements
+		"The announcement doesn't ask to be acknowledged": "ประกาศนี้ไม่ต้องกดรับทราบ",
+
+		// Integrations and jobs
+		"ClickUp integration is disabled":        "การเชื่อมต่อ ClickUp ปิดอยู่",
+		"ClickUp rejected the API token":         "ClickUp ไม่ยอมรับโทเค็น API",
+		"No ClickUp account is connected":        "ยังไม่ได้เชื่อมบัญชี ClickUp",
+		"Slack integration is disabled":          "การเชื่อมต่อ Slack ปิดอยู่",
+		"Slack workspace not connected":          "ยังไม่ได้เชื่อมเวิร์กสเปซ Slack",
+		"LINE notifications are disabled":        "การแจ้งเตือนทาง LINE ปิดอยู่",
+		"LINE account not linked":                "ยังไม่ได้เชื่อมบัญชี LINE",
+		"Push notifications are disabled":        "การแจ้งเตือนบนมือถือปิดอยู่",
+		"Webhook is inactive, activate it first": "เว็บฮุกนี้ปิดใช้งานอยู่ กรุณาเปิดใช้งานก่อน",
+		"Delivery is still being attempted":      "ยังอยู่ระหว่างการส่ง",
+		"Job is paused, resume it first":         "งานนี้หยุดชั่วคราวอยู่ กรุณาให้ทำงานต่อก่อน",
+
+		// Validation, see the validate package
+		"is required":                  "ต้องระบุ",
+		"is too large":                 "ใหญ่เกินไป",
+		"must be a date as YYYY-MM-DD": "ต้องเป็นวันที่ในรูปแบบ YYYY-MM-DD",
+		"must be a month as YYYY-MM":   "ต้องเป็นเดือนในรูปแบบ YYYY-MM",
+		"must be an email address":     "ต้องเป็นอีเมล",
+		"must be someone else":         "ต้องเป็นผู้อื่น",
+
+		"must be an IANA time zone such as Asia/Bangkok": "ต้องเป็นเขตเวลา IANA เช่น Asia/Bangkok",
+
+		// Imports, see the hrimport package
+		"Invalid workbook":                                                "ไฟล์สเปรดชีตไม่ถูกต้อง",
+		"the column is missing":                                           "ไม่พบคอลัมน์นี้",
+		"must be a number":                                                "ต้องเป็นตัวเลข",
+		"must be a whole number":                                          "ต้องเป็นจำนวนเต็ม",
+		"the username is taken":                                           "ชื่อผู้ใช้นี้ถูกใช้แล้ว",
+		"the username is in the sheet more than once":                     "ชื่อผู้ใช้นี้ซ้ำกันในชีต",
+		"must be a department of the org chart":                           "ต้องเป็นแผนกในผังองค์กร",
+		"no user has the username, here or in the users sheet":            "ไม่พบผู้ใช้ที่มีชื่อผู้ใช้นี้ ทั้งในระบบและในชีต users",
+		"the user has leave on the day more than once in the sheet":       "ผู้ใช้มีการลาในวันนี้ซ้ำกันในชีต",
+		"the user has leave on the day already":                           "ผู้ใช้มีการลาในวันนี้อยู่แล้ว",
+		"the user has a balance for the year more than once in the sheet": "ผู้ใช้มียอดคงเหลือของปีนี้ซ้ำกันในชีต",
+		"the user has a balance for the year already":                     "ผู้ใช้มียอดคงเหลือของปีนี้อยู่แล้ว",
+		"the year has no quota plan of that name":                         "ไม่พบแผนโควตาชื่อนี้ในปีนี้",
+
+		// Notifications
+		"An admin recorded leave for you":                                    "ผู้ดูแลระบบบันทึกการลาให้คุณ",
+		"A manager or admin approved or rejected leave you recorded":         "หัวหน้าหรือผู้ดูแลระบบอนุมัติหรือปฏิเสธการลาที่คุณบันทึก",
+		"An admin recorded a medical expense for you":                        "ผู้ดูแลระบบบันทึกค่ารักษาพยาบาลให้คุณ",
+		"An approver approved or rejected a medical expense you recorded":    "ผู้อนุมัติอนุมัติหรือปฏิเสธค่ารักษาพยาบาลที่คุณบันทึก",
+		"Someone assigned a task to you":                                     "มีผู้มอบหมายงานให้คุณ",
+		"An approver approved or rejected your timesheet or quota plan edit": "ผู้อนุมัติอนุมัติหรือปฏิเสธบันทึกเวลางานหรือการแก้ไขแผนโควตาของคุณ",
+		"Requests have waited on your approval for over a day":               "มีคำขอรอการอนุมัติจากคุณเกินหนึ่งวัน",
+		"An admin reset your password":                                       "ผู้ดูแลระบบรีเซ็ตรหัสผ่านของคุณ",
+		"approved":                                                           "อนุมัติ",
+		"rejected":                                                           "ปฏิเสธ",
+		"vacation":                                                           "ลาพักร้อน",
+		"sick":                                                               "ลาป่วย",
+		"personal":                                                           "ลากิจ",
+		"The edit was applied.":                                              "การแก้ไขมีผลแล้ว",
+		"The edit was not applied.":                                          "การแก้ไขไม่มีผล",
+		"It was removed from TableG.":                                        "รายการนี้ถูกลบออกจาก TableG แล้ว",
+
+		// Reports
+		"Leave summary":    "สรุปการลา",
+		"Timesheet":        "ใบบันทึกเวลางาน",
+		"Utilization":      "อัตราการใช้เวลางาน",
+		"Medical expenses": "ค่ารักษาพยาบาล",
+		"User":             "ผู้ใช้",
+		"Department":       "แผนก",
+		"Date":             "วันที่",
+		"Task":             "งาน",
+		"Vacation":         "ลาพักร้อน",
+		"Sick":             "ลาป่วย",
+		"Personal":         "ลากิจ",
+		"Total days":       "รวม (วัน)",
+		"Worked days":      "วันทำงานที่บันทึก",
+		"Working days":     "วันทำงาน",
+		"Leave days":       "วันลา",
+		"Available days":   "วันที่ว่างทำงาน",
+		"On a holiday":     "ทำงานวันหยุด",
+		"Receipt date":     "วันที่ในใบเสร็จ",
+		"Receipt":          "ใบเสร็จ",
+		"Note":             "หมายเหตุ",
+		"Amount (THB)":     "จำนวนเงิน (บาท)",
+		"Yes":              "ใช่",
+		"Total":            "รวม",
+		"Generated":        "สร้างเมื่อ",
+	},
+
+	patterns: []pattern{
+		newPattern("{word} not found", "ไม่พบ{}"),
+		newPattern("Invalid {word} ID", "รหัส{}ไม่ถูกต้อง"),
+		newPattern("Error fetching {word}", "เกิดข้อผิดพลาดในการดึงข้อมูล{}"),
+		newPattern("Error listing {word}", "เกิดข้อผิดพลาดในการดึงรายการ{}"),
+		newPattern("Error counting {word}", "เกิดข้อผิดพลาดในการนับ{}"),
+		newPattern("Error creating {word}", "เกิดข้อผิดพลาดในการสร้าง{}"),
+		newPattern("Error updating {word}", "เกิดข้อผิดพลาดในการแก้ไข{}"),
+		newPattern("Error deleting {word}", "เกิดข้อผิดพลาดในการลบ{}"),
+		newPattern("Error saving {word}", "เกิดข้อผิดพลาดในการบันทึก{}"),
+		newPattern("Error storing {word}", "เกิดข้อผิดพลาดในการจัดเก็บ{}"),
+		newPattern("Error reading {word}", "เกิดข้อผิดพลาดในการอ่าน{}"),
+		newPattern("Error checking {word}", "เกิดข้อผิดพลาดในการตรวจสอบ{}"),
+		newPattern("Error exporting {word}", "เกิดข้อผิดพลาดในการส่งออก{}"),
+		newPattern("Error anonymizing {word}", "เกิดข้อผิดพลาดในการลบข้อมูลส่วนบุคคลของ{}"),
+		newPattern("Error reviewing {word}", "เกิดข้อผิดพลาดในการตรวจสอบ{}"),
+		newPattern("Error registering {word}", "เกิดข้อผิดพลาดในการลงทะเบียน{}"),
+		newPattern("Error revoking {word}", "เกิดข้อผิดพลาดในการเพิกถอน{}"),
+		newPattern("Error importing {word}", "เกิดข้อผิดพลาดในการนำเข้า{}"),
+		newPattern("Error replaying {word}", "เกิดข้อผิดพลาดในการคำนวณซ้ำจาก{}"),
+		newPattern("Error rebuilding {word}", "เกิดข้อผิดพลาดในการสร้างใหม่ของ{}"),
+		newPattern("You don't have permission to view this {word}", "คุณไม่มีสิทธิ์ดู{}นี้"),
+		newPattern("You don't have permission to update this {word}", "คุณไม่มีสิทธิ์แก้ไข{}นี้"),
+		newPattern("You don't have permission to delete this {word}", "คุณไม่มีสิทธิ์ลบ{}นี้"),
+		newPattern("You can only update your own {word}", "คุณแก้ไขได้เฉพาะ{}ของตัวเองเท่านั้น"),
+		newPattern("You can only delete your own {word}", "คุณลบได้เฉพาะ{}ของตัวเองเท่านั้น"),
+		newPattern("Only administrators can {word}", "เฉพาะผู้ดูแลระบบเท่านั้นที่{}ได้"),
+		newPattern("Only admin users can {word}", "เฉพาะผู้ดูแลระบบเท่านั้นที่{}ได้"),
+		newPattern("Report not found, must be one of {}", "ไม่พบรายงาน ต้องเป็นหนึ่งใน {}"),
+		newPattern("Unknown kind of approval, must be one of {}", "ไม่รู้จักประเภทการอนุมัตินี้ ต้องเป็นหนึ่งใน {}"),
+		newPattern("File is larger than {} bytes", "ไฟล์มีขนาดเกิน {} ไบต์"),
+		newPattern("Too many usernames, at most {}", "ชื่อผู้ใช้มากเกินไป ได้ไม่เกิน {} ชื่อ"),
+		newPattern("The API token doesn't have the {} scope", "โทเค็น API นี้ไม่มีสิทธิ์ {}"),
+		newPattern("Department {} already exists", "มีแผนก {} อยู่แล้ว"),
+		newPattern("Payroll period {} already exists", "มีงวดเงินเดือน {} อยู่แล้ว"),
+		newPattern("Move the users out of {} before deleting it", "กรุณาย้ายผู้ใช้ออกจาก {} ก่อนลบ"),
+		newPattern("Move or delete the departments under {} before deleting it", "กรุณาย้ายหรือลบแผนกที่อยู่ภายใต้ {} ก่อนลบ"),
+
+		// Validation, see the validate package
+		newPattern("must be at least {}", "ต้องไม่น้อยกว่า {}"),
+		newPattern("must be at most {}", "ต้องไม่เกิน {}"),
+		newPattern("must have at least {} characters", "ต้องมีอย่างน้อย {} ตัวอักษร"),
+		newPattern("must have at most {} characters", "ต้องมีไม่เกิน {} ตัวอักษร"),
+		newPattern("must have at least {} items", "ต้องมีอย่างน้อย {} รายการ"),
+		newPattern("must have at most {} items", "ต้องมีไม่เกิน {} รายการ"),
+		newPattern("must be greater than {}", "ต้องมากกว่า {}"),
+		newPattern("must be one of {}", "ต้องเป็นหนึ่งใน {}"),
+		newPattern("must not be before {}", "ต้องไม่อยู่ก่อน {}"),
+		newPattern("must be after {}", "ต้องอยู่หลัง {}"),
+
+		// What approval requests are about, see loadApprovalSubject
+		newPattern("{word} leave on {}", "{} วันที่ {}"),
+		newPattern("medical expense of {} baht dated {}", "ค่ารักษาพยาบาล {} บาท ลงวันที่ {}"),
+		newPattern("{} days on task #{} on {}", "{} วัน ในงาน #{} วันที่ {}"),
+		newPattern("edit of quota plan {}", "การแก้ไขแผนโควตา {}"),
+
+		// What the anomaly rules found, see the anomaly package
+		newPattern("{} of {} sick days were Mondays", "ลาป่วยในวันจันทร์ {} จาก {} วัน"),
+		newPattern("Logged {} days of work on {} against a daily capacity of {}", "บันทึกงาน {} วัน ในวันที่ {} ขณะที่กำลังงานต่อวันคือ {}"),
+		newPattern("{} medical expenses just under the approval limit of {} baht", "ค่ารักษาพยาบาล {} รายการต่ำกว่าวงเงินที่ต้องขออนุมัติ {} บาทเพียงเล็กน้อย"),
+	},
+
+	words: map[string]string{
+		"user":                      "ผู้ใช้",
+		"users":                     "ผู้ใช้",
+		"username":                  "ชื่อผู้ใช้",
+		"task":                      "งาน",
+		"tasks":                     "งาน",
+		"recent tasks":              "งานล่าสุด",
+		"subtasks":                  "งานย่อย",
+		"parent task":               "งานหลัก",
+		"assignees":                 "ผู้รับผิดชอบ",
+		"tag":                       "แท็ก",
+		"tags":                      "แท็ก",
+		"tag report":                "รายงานแท็ก",
+		"category":                  "หมวดหมู่",
+		"task category":             "หมวดหมู่งาน",
+		"task categories":           "หมวดหมู่งาน",
+		"task category tree":        "โครงสร้างหมวดหมู่งาน",
+		"category report":           "รายงานหมวดหมู่",
+		"task comment":              "ความคิดเห็น",
+		"task comments":             "ความคิดเห็น",
+		"comments":                  "ความคิดเห็น",
+		"task activity":             "ความเคลื่อนไหวของงาน",
+		"custom fields":             "ฟิลด์เพิ่มเติม",
+		"task log":                  "บันทึกเวลางาน",
+		"task logs":                 "บันทึกเวลางาน",
+		"logs":                      "บันทึกเวลางาน",
+		"unlogged days":             "วันที่ยังไม่ได้บันทึกเวลางาน",
+		"task estimate":             "การประมาณงาน",
+		"task estimates":            "การประมาณงาน",
+		"estimates":                 "การประมาณงาน",
+		"estimate report":           "รายงานการประมาณงาน",
+		"estimation session":        "รอบการประมาณงาน",
+		"estimation sessions":       "รอบการประมาณงาน",
+		"participant":               "ผู้เข้าร่วม",
+		"participants":              "ผู้เข้าร่วม",
+		"team capacity":             "กำลังงานของทีม",
+		"sync history":              "ประวัติการซิงก์",
+		"leave":                     "การลา",
+		"leave log":                 "บันทึกการลา",
+		"leave logs":                "บันทึกการลา",
+		"leave report":              "รายงานการลา",
+		"leave calendar":            "ปฏิทินการลา",
+		"vacation":                  "ลาพักร้อน",
+		"sick":                      "ลาป่วย",
+		"personal":                  "ลากิจ",
+		"medical expense":           "ค่ารักษาพยาบาล",
+		"medical expenses":          "ค่ารักษาพยาบาล",
+		"expense":                   "ค่ารักษาพยาบาล",
+		"balances":                  "ยอดคงเหลือ",
+		"quota plan":                "แผนโควตา",
+		"quota plans":               "แผนโควตา",
+		"plan":                      "แผนโควตา",
+		"record":                    "บันทึก",
+		"annual record":             "บันทึกประจำปี",
+		"annual records":            "บันทึกประจำปี",
+		"next year records":         "บันทึกประจำปีของปีถัดไป",
+		"holiday":                   "วันหยุด",
+		"holidays":                  "วันหยุด",
+		"attendance":                "การลงเวลา",
+		"payroll":                   "เงินเดือน",
+		"payroll period":            "งวดเงินเดือน",
+		"payroll periods":           "งวดเงินเดือน",
+		"department":                "แผนก",
+		"departments":               "แผนก",
+		"sub-departments":           "แผนกย่อย",
+		"department members":        "สมาชิกของแผนก",
+		"position":                  "ตำแหน่ง",
+		"positions":                 "ตำแหน่ง",
+		"reporting line":            "สายการบังคับบัญชา",
+		"reporting lines":           "สายการบังคับบัญชา",
+		"org chart members":         "สมาชิกในผังองค์กร",
+		"approval request":          "คำขออนุมัติ",
+		"approval requests":         "คำขออนุมัติ",
+		"request":                   "คำขอ",
+		"request body":              "เนื้อหาคำขอ",
+		"approval policy":           "นโยบายการอนุมัติ",
+		"approval policies":         "นโยบายการอนุมัติ",
+		"approvers":                 "ผู้อนุมัติ",
+		"decisions":                 "ผลการพิจารณา",
+		"delegation":                "การมอบหมายการอนุมัติ",
+		"delegations":               "การมอบหมายการอนุมัติ",
+		"what the request is about": "รายการที่ขออนุมัติ",
+		"what requests are about":   "รายการที่ขออนุมัติ",
+		"file":                      "ไฟล์",
+		"files":                     "ไฟล์",
+		"the file":                  "ไฟล์",
+		"avatar":                    "รูปโปรไฟล์",
+		"what the file belongs to":  "รายการที่ไฟล์แนบอยู่",
+		"report":                    "รายงาน",
+		"report export":             "รายงานที่ส่งออก",
+		"workbook":                  "ไฟล์สเปรดชีต",
+		"balance events":            "เหตุการณ์ที่กระทบยอดคงเหลือ",
+		"balance drift":             "ยอดคงเหลือที่คลาดเคลื่อน",
+		"notification preferences":  "การตั้งค่าการแจ้งเตือน",
+		"language preference":       "ภาษาที่เลือก",
+		"time zone preference":      "เขตเวลาที่เลือก",
+		"personal data":             "ข้อมูลส่วนบุคคล",
+		"runtime settings":          "การตั้งค่าระบบ",
+		"feature flag":              "ฟีเจอร์แฟล็ก",
+		"feature flags":             "ฟีเจอร์แฟล็ก",
+		"job":                       "งานในคิว",
+		"jobs":                      "งานในคิว",
+		"queued job":                "งานในคิว",
+		"queued jobs":               "งานในคิว",
+		"deleted records":           "รายการที่ถูกลบ",
+		"webhook":                   "เว็บฮุก",
+		"webhooks":                  "เว็บฮุก",
+		"webhook delivery":          "การส่งเว็บฮุก",
+		"webhook deliveries":        "การส่งเว็บฮุก",
+		"delivery":                  "การส่งเว็บฮุก",
+		"slack workspace":           "เวิร์กสเปซ Slack",
+		"slack workspaces":          "เวิร์กสเปซ Slack",
+		"line link":                 "การเชื่อมบัญชี LINE",
+		"link code":                 "รหัสเชื่อมบัญชี",
+		"clickup token":             "โทเค็น ClickUp",
+		"clickup tokens":            "โทเค็น ClickUp",
+		"clickup workspaces":        "เวิร์กสเปซ ClickUp",
+		"clickup spaces":            "สเปซ ClickUp",
+		"clickup folders":           "โฟลเดอร์ ClickUp",
+		"clickup lists":             "ลิสต์ ClickUp",
+		"anomaly":                   "ความผิดปกติ",
+		"anomalies":                 "ความผิดปกติ",
+		"announcement":              "ประกาศ",
+		"announcements":             "ประกาศ",
+		"acknowledgment":            "การรับทราบ",
+		"acknowledgments":           "การรับทราบ",
+		"device":                    "อุปกรณ์",
+		"devices":                   "อุปกรณ์",
+		"year summary":              "สรุปประจำปี",
+		"api token":                 "โทเค็น API",
+		"api tokens":                "โทเค็น API",
+		"availability":              "สถานะการเข้างาน",
+
+		// What only administrators can do
+		"view the integration status": "ดูสถานะการเชื่อมต่อ",
+		"update tags":                 "แก้ไขแท็ก",
+		"delete tags":                 "ลบแท็ก",
+		"sync workspace tasks":        "ซิงก์งานของเวิร์กสเปซ",
+		"sync all tasks":              "ซิงก์งานทั้งหมด",
+		"run payroll":                 "ทำเงินเดือน",
+		"merge task categories":       "รวมหมวดหมู่งาน",
+		"manage webhooks":             "จัดการเว็บฮุก",
+		"manage slack workspaces":     "จัดการเวิร์กสเปซ Slack",
+		"change the org chart":        "แก้ไขผังองค์กร",
+		"change approval policies":    "แก้ไขนโยบายการอนุมัติ",
+		"view all medical expenses":   "ดูค่ารักษาพยาบาลของทุกคน",
+		"view all leave logs":         "ดูบันทึกการลาของทุกคน",
+		"create annual records":       "สร้างบันทึกประจำปี",
+		"update records":              "แก้ไขบันทึก",
+		"delete records":              "ลบบันทึก",
+		"purge deleted records":       "ล้างรายการที่ถูกลบ",
+		"manage queued jobs":          "จัดการงานในคิว",
+		"anonymize users":             "ลบข้อมูลส่วนบุคคลของผู้ใช้",
+		"manage announcements":        "จัดการประกาศ",
+		"manage api tokens":           "จัดการโทเค็น API",
+		"import data":                 "นำเข้าข้อมูล",
+		"view balance events":         "ดูเหตุการณ์ที่กระทบยอดคงเหลือ",
+	},
+}
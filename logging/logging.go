@@ -0,0 +1,87 @@
+// Package logging sets up the structured logger of the server. Every line goes through a handler
+// that masks tokens, passwords and email addresses, and lines logged with a request's context
+// carry its request ID, so the lines of one request can be found together. The ID travels on with
+// the request's calls to other services and the jobs it queues.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+)
+
+// Setup makes a logger with the given level and format the default of slog and of the log
+// package, writing to stderr
+func Setup(settings config.Log) *slog.Logger {
+	logger := New(os.Stderr, settings)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New returns a logger writing to w with the given level and format
+func New(w io.Writer, settings config.Log) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level:       settings.Level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if settings.Format == config.LogFormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// RequestIDHeader carries the request ID, on requests to the server and on the requests it
+// sends to other services while handling one
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key under which WithRequestID stores the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines carry the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID set with WithRequestID, or "" outside a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// SetRequestIDHeader passes the request ID of ctx on to a request to another service, so its
+// logs can be matched with ours. Outside a request it does nothing.
+func SetRequestIDHeader(ctx context.Context, header http.Header) {
+	if requestID := RequestID(ctx); requestID != "" {
+		header.Set(RequestIDHeader, requestID)
+	}
+}
+
+// contextHandler adds the request ID of the context to each record and masks secrets in the
+// message, which ReplaceAttr never sees
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.Message = Redact(record.Message)
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
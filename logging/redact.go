@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces a masked secret
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of attribute keys whose values are never logged
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key"}
+
+// secretPatterns find secrets inside free text, like a message or an error
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// Authorization headers
+	{regexp.MustCompile(`(?i)\bbearer\s+[^\s"',]+`), "Bearer " + redacted},
+	// ClickUp personal API tokens
+	{regexp.MustCompile(`\bpk_\d+_[A-Za-z0-9]+`), redacted},
+	// The server's own session tokens
+	{regexp.MustCompile(`dummy-token-[^\s"',]+`), "dummy-token-" + redacted},
+	// Secret fields of JSON bodies
+	{regexp.MustCompile(`"(password|token|access_token|api_token|secret|client_secret)"\s*:\s*"[^"]*"`), `"$1":"` + redacted + `"`},
+	// Secret query parameters and key=value pairs
+	{regexp.MustCompile(`(?i)\b(password|token|access_token|api_token|client_secret|code)=[^&\s]+`), "$1=" + redacted},
+	// Email addresses keep their first letter and domain
+	{regexp.MustCompile(`\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`), "$1***@$2"},
+}
+
+// Redact masks the tokens, passwords and email addresses in s
+func Redact(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+	return s
+}
+
+// redactAttr masks attributes with a sensitive key entirely and secrets inside the others. Values
+// that are neither strings nor errors keep their type unless they print a secret.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(Redact(err.Error()))
+			break
+		}
+		printed := fmt.Sprintf("%+v", attr.Value.Any())
+		if masked := Redact(printed); masked != printed {
+			attr.Value = slog.StringValue(masked)
+		}
+	}
+	return attr
+}
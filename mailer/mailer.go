@@ -0,0 +1,187 @@
+// Package mailer sends plain text emails, through an SMTP server in production. The Mailer
+// interface lets tests and the in-memory setup swap the server for something else.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/config"
+)
+
+// implicitTLSPort is the SMTP port spoken over TLS from the start, rather than upgraded with
+// STARTTLS
+const implicitTLSPort = 465
+
+// Message is a plain text email to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTP sends emails through an SMTP server, a new connection for each. The connection is
+// upgraded with STARTTLS when the server offers it, and it signs in when a username is set.
+type SMTP struct {
+	settings config.SMTP
+	from     *mail.Address
+}
+
+// NewSMTP creates a mailer sending through the server in settings, which Validate has checked
+func NewSMTP(settings config.SMTP) (*SMTP, error) {
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SMTP_FROM: %w", err)
+	}
+	return &SMTP{settings: settings, from: from}, nil
+}
+
+// Send sends msg, giving up when ctx is done
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return &PermanentError{Err: fmt.Errorf("parsing the recipient: %w", err)}
+	}
+	data, err := s.format(to, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.settings.Port != implicitTLSPort {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.settings.Host}); err != nil {
+				return fmt.Errorf("starting TLS: %w", err)
+			}
+		}
+	}
+	if s.settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.settings.Username, s.settings.Password, s.settings.Host)); err != nil {
+			return fmt.Errorf("signing in: %w", classify(err))
+		}
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("setting the sender: %w", classify(err))
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("setting the recipient: %w", classify(err))
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting the message: %w", classify(err))
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing the message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending the message: %w", classify(err))
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, over TLS on the implicit TLS port
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.settings.Host, strconv.Itoa(s.settings.Port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if s.settings.Port == implicitTLSPort {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.settings.Host}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.settings.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("greeting %s: %w", addr, err)
+	}
+	return client, nil
+}
+
+// format renders msg as a UTF-8 email with a quoted-printable body
+func (s *SMTP) format(to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", messageID(), s.settings.Host)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a random ID for the Message-ID header
+func messageID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// PermanentError is a failure sending again won't fix, such as an address the server rejects
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether sending the message again would fail the same way
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// classify makes the 5xx replies of the server permanent, the 4xx ones are worth another try
+func classify(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+// Package payroll works out what the payroll vendor needs of a pay period for every user, their
+// paid and unpaid leave, overtime and work on holidays, and writes it in the vendor's fixed-width
+// text format.
+//
+// The file is ASCII with CRLF line endings and every record 80 characters long, space padded:
+//
+//	H  company code (10)  period YYYYMM (6)  first day YYYYMMDD (8)  last day YYYYMMDD (8)
+//	   generated YYYYMMDD (8)  detail records (6)
+//	D  employee number (10)  name (30)  paid leave days (6)  unpaid leave days (6)
+//	   overtime hours (6)  holiday work days (6)
+//	T  detail records (6)  total paid leave days (9)  total unpaid leave days (9)
+//	   total overtime hours (9)  total holiday work days (9)
+//
+// Text is left aligned and space padded, numbers right aligned and zero padded. Days and hours
+// are in hundredths without a decimal point, 1.5 days is 000150. The employee number is the
+// user's ID and the name their username, cut to 30 characters with anything outside printable
+// ASCII replaced by '?'.
+package payroll
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// recordLength is the length of every record of the file, without its line ending
+const recordLength = 80
+
+// Settings are what the lines are worked out and the file written with
+type Settings struct {
+	CompanyCode      string   // PAYROLL_COMPANY_CODE
+	UnpaidLeaveTypes []string // PAYROLL_UNPAID_LEAVE_TYPES, every other leave type is paid
+	HoursPerDay      float64  // ESTIMATE_HOURS_PER_DAY, a full day's hours, beyond which is overtime
+}
+
+// Period is the pay period an export covers, a month paid for the days from Start to End
+type Period struct {
+	Name  string // YYYY-MM
+	Start time.Time
+	End   time.Time
+}
+
+// Line is what payroll needs of one user in a period
+type Line struct {
+	UserID          int32
+	Username        string
+	Department      string
+	PaidLeaveDays   float64
+	UnpaidLeaveDays float64
+	OvertimeHours   float64 // Checked in beyond a full day of their capacity, on days that aren't holidays
+	HolidayWorkDays float64 // Logged as work on a holiday
+}
+
+// ParseMonth reads a period name, YYYY-MM, returning the first day of the month
+func ParseMonth(name string) (time.Time, error) {
+	month, err := time.Parse("2006-01", name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("period %q is not a month as YYYY-MM", name)
+	}
+	return month, nil
+}
+
+// Build works out the line of every live user for the period, by username
+func Build(ctx context.Context, q sqlc.Querier, period Period, settings Settings) ([]Line, error) {
+	rows, err := q.SummarizePayroll(ctx, sqlc.SummarizePayrollParams{
+		StartDate:        pgtype.Date{Time: period.Start, Valid: true},
+		EndDate:          pgtype.Date{Time: period.End, Valid: true},
+		UnpaidLeaveTypes: settings.UnpaidLeaveTypes,
+		HoursPerDay:      settings.HoursPerDay,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarizing payroll: %w", err)
+	}
+
+	lines := make([]Line, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, Line{
+			UserID:          row.UserID,
+			Username:        row.Username,
+			Department:      row.Department.String,
+			PaidLeaveDays:   float64(row.PaidLeaveDays),
+			UnpaidLeaveDays: float64(row.UnpaidLeaveDays),
+			OvertimeHours:   round(row.OvertimeHours),
+			HolidayWorkDays: round(row.HolidayWorkDays),
+		})
+	}
+	return lines, nil
+}
+
+// FileName returns the name of the export of the period, e.g. PAYROLL_TABLEG_202610.txt
+func FileName(period Period, settings Settings) string {
+	return "PAYROLL_" + ascii(settings.CompanyCode) + "_" + period.Start.Format("200601") + ".txt"
+}
+
+// Write writes the export of the period, failing when an amount doesn't fit its field
+func Write(w io.Writer, period Period, settings Settings, lines []Line, generatedAt time.Time) error {
+	month, err := ParseMonth(period.Name)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+	var f fields
+	f.text("H", 1)
+	f.text(settings.CompanyCode, 10)
+	f.text(month.Format("200601"), 6)
+	f.text(period.Start.Format("20060102"), 8)
+	f.text(period.End.Format("20060102"), 8)
+	f.text(generatedAt.Format("20060102"), 8)
+	f.number(int64(len(lines)), 6, "record count")
+	f.writeTo(b)
+
+	var paid, unpaid, overtime, holiday int64
+	for _, line := range lines {
+		f.text("D", 1)
+		f.number(int64(line.UserID), 10, "employee number")
+		f.text(line.Username, 30)
+		f.number(hundredths(line.PaidLeaveDays), 6, "paid leave of "+line.Username)
+		f.number(hundredths(line.UnpaidLeaveDays), 6, "unpaid leave of "+line.Username)
+		f.number(hundredths(line.OvertimeHours), 6, "overtime of "+line.Username)
+		f.number(hundredths(line.HolidayWorkDays), 6, "holiday work of "+line.Username)
+		f.writeTo(b)
+
+		paid += hundredths(line.PaidLeaveDays)
+		unpaid += hundredths(line.UnpaidLeaveDays)
+		overtime += hundredths(line.OvertimeHours)
+		holiday += hundredths(line.HolidayWorkDays)
+	}
+
+	f.text("T", 1)
+	f.number(int64(len(lines)), 6, "record count")
+	f.number(paid, 9, "total paid leave")
+	f.number(unpaid, 9, "total unpaid leave")
+	f.number(overtime, 9, "total overtime")
+	f.number(holiday, 9, "total holiday work")
+	f.writeTo(b)
+
+	if f.err != nil {
+		return f.err
+	}
+	return b.Flush()
+}
+
+// fields builds a record field by field, keeping the first amount that didn't fit
+type fields struct {
+	record strings.Builder
+	err    error
+}
+
+// text adds a left aligned, space padded field, cut to its width
+func (f *fields) text(value string, width int) {
+	value = ascii(value)
+	if len(value) > width {
+		value = value[:width]
+	}
+	f.record.WriteString(value)
+	f.record.WriteString(strings.Repeat(" ", width-len(value)))
+}
+
+// number adds a right aligned, zero padded field
+func (f *fields) number(value int64, width int, name string) {
+	digits := fmt.Sprintf("%0*d", width, value)
+	if value < 0 || len(digits) > width {
+		if f.err == nil {
+			f.err = fmt.Errorf("%s doesn't fit the %d digits of its field", name, width)
+		}
+		digits = strings.Repeat("9", width)
+	}
+	f.record.WriteString(digits)
+}
+
+// writeTo pads the record to its length, ends it and starts the next one
+func (f *fields) writeTo(w *bufio.Writer) {
+	record := f.record.String()
+	_, _ = w.WriteString(record + strings.Repeat(" ", max(recordLength-len(record), 0)) + "\r\n")
+	f.record.Reset()
+}
+
+// ascii replaces whatever isn't printable ASCII with '?'
+func ascii(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, value)
+}
+
+// hundredths converts days or hours to the hundredths the file counts in
+func hundredths(value float64) int64 {
+	return int64(math.Round(value * 100))
+}
+
+// round rounds to the hundredths the file keeps
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,4 @@
+// Package tablegv1 holds the messages and service stubs generated from tableg.proto
+package tablegv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative tableg/v1/tableg.proto
@@ -0,0 +1,373 @@
+// Package queue runs side effects of writes, such as tracking time in ClickUp, in the background
+// with retries. Jobs are rows of the queued_jobs table, so they survive restarts and any server
+// instance may run them. A job that fails is tried again later, waiting longer after each
+// attempt, and one that used up its attempts stays as a dead letter until an admin retries it.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/logging"
+)
+
+// The states of a job
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+const (
+	// pollInterval is how often idle workers look for due jobs. Jobs enqueued on this instance
+	// start right away, those of other instances and retries within this interval.
+	pollInterval = time.Second
+	// minBackoff is the wait before the second attempt, it doubles with every attempt after that
+	minBackoff = 30 * time.Second
+	// maxBackoff caps the wait between attempts
+	maxBackoff = time.Hour
+)
+
+var (
+	// ErrNotFound is returned for a job that doesn't exist
+	ErrNotFound = errors.New("job not found")
+	// ErrNotDead is returned when retrying a job that hasn't failed for good
+	ErrNotDead = errors.New("job is not dead")
+)
+
+// Handler runs a job of one kind with the payload it was enqueued with
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// permanentError marks an error that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error a handler returns when trying again is pointless, such as a request
+// the remote API rejects. The job goes straight to the dead letters.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Store keeps the jobs, db.Store is one
+type Store interface {
+	EnqueueJob(ctx context.Context, arg sqlc.EnqueueJobParams) (sqlc.QueuedJob, error)
+	ClaimQueuedJobs(ctx context.Context, arg sqlc.ClaimQueuedJobsParams) ([]sqlc.QueuedJob, error)
+	CompleteQueuedJob(ctx context.Context, id int32) error
+	RetryQueuedJob(ctx context.Context, arg sqlc.RetryQueuedJobParams) error
+	FailQueuedJob(ctx context.Context, arg sqlc.FailQueuedJobParams) error
+	GetQueuedJob(ctx context.Context, id int32) (sqlc.QueuedJob, error)
+	ListQueuedJobs(ctx context.Context, arg sqlc.ListQueuedJobsParams) ([]sqlc.QueuedJob, error)
+	CountQueuedJobs(ctx context.Context, status string) (int64, error)
+	RequeueQueuedJob(ctx context.Context, id int32) (sqlc.QueuedJob, error)
+	DeleteFinishedQueuedJobs(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error)
+}
+
+// Options are how the queue runs jobs
+type Options struct {
+	// Workers is how many jobs this instance runs at once
+	Workers int
+	// MaxAttempts is how often a job is tried before it is left as a dead letter
+	MaxAttempts int
+	// JobTimeout bounds one attempt. A job still running after twice as long is taken to be lost
+	// with the instance that ran it, and another instance picks it up.
+	JobTimeout time.Duration
+}
+
+// Job is a queued job, as the admin API shows it
+type Job struct {
+	ID          int32           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"` // pending, running, done or dead
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"maxAttempts"`
+	// RunAt is when a pending job runs next
+	RunAt     time.Time `json:"runAt"`
+	LastError string    `json:"lastError,omitempty"`
+	LockedBy  string    `json:"lockedBy,omitempty"` // The server instance running the job
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	RequestID string    `json:"requestId,omitempty"` // The X-Request-ID of the request that queued the job
+}
+
+// Queue enqueues jobs and runs them with a pool of workers
+type Queue struct {
+	store    Store
+	instance string
+	options  Options
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	busy     int // Workers running a job
+	wake     chan struct{}
+}
+
+// New creates a queue keeping the jobs in store. The instance name is recorded on the jobs it
+// runs.
+func New(store Store, instance string, options Options) *Queue {
+	return &Queue{
+		store:    store,
+		instance: instance,
+		options:  options,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler of a kind of job, before Start
+func (q *Queue) Register(kind string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue adds a job running the handler of its kind with the payload as JSON. The job belongs
+// to the tenant of ctx, and runs in it. It keeps the request ID of ctx, which the log lines and
+// outbound requests of each attempt carry.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("encoding the payload of a %s job: %w", kind, err)
+	}
+	requestID := logging.RequestID(ctx)
+	job, err := q.store.EnqueueJob(ctx, sqlc.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: int32(q.options.MaxAttempts),
+		RunAt:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		RequestID:   pgtype.Text{String: requestID, Valid: requestID != ""},
+	})
+	if err != nil {
+		return Job{}, fmt.Errorf("enqueueing a %s job: %w", kind, err)
+	}
+	q.notify()
+	return view(job), nil
+}
+
+// Start runs due jobs until ctx is cancelled. Jobs running then are cancelled and tried again
+// later.
+func (q *Queue) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			q.runDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-q.wake:
+			}
+		}
+	}()
+}
+
+// runDue claims as many due jobs as there are idle workers and starts them
+func (q *Queue) runDue(ctx context.Context) {
+	q.mu.Lock()
+	idle := q.options.Workers - q.busy
+	q.mu.Unlock()
+	if idle <= 0 {
+		return
+	}
+
+	// The claim spans every tenant, each job then runs in its own
+	jobs, err := q.store.ClaimQueuedJobs(db.WithAllTenants(ctx), sqlc.ClaimQueuedJobsParams{
+		LockedBy:    pgtype.Text{String: q.instance, Valid: true},
+		StaleBefore: pgtype.Timestamptz{Time: time.Now().Add(-2 * q.options.JobTimeout), Valid: true},
+		RowLimit:    int32(idle),
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error claiming queued jobs", "error", err)
+		}
+		return
+	}
+	for _, job := range jobs {
+		q.start(ctx, job)
+	}
+}
+
+// start runs a claimed job on a worker and records how it went
+func (q *Queue) start(ctx context.Context, job sqlc.QueuedJob) {
+	q.mu.Lock()
+	q.busy++
+	handler, ok := q.handlers[job.Kind]
+	q.mu.Unlock()
+
+	go func() {
+		defer func() {
+			q.mu.Lock()
+			q.busy--
+			q.mu.Unlock()
+			q.notify()
+		}()
+
+		ctx := db.WithTenant(ctx, job.TenantID)
+		if job.RequestID.Valid {
+			ctx = logging.WithRequestID(ctx, job.RequestID.String)
+		}
+		var err error
+		switch {
+		case !ok:
+			err = Permanent(fmt.Errorf("no handler for jobs of kind %s", job.Kind))
+		case job.Attempts > job.MaxAttempts:
+			// Claimed again after its instance was lost during the last attempt
+			err = Permanent(errors.New("the server instance running the last attempt stopped"))
+		default:
+			start := time.Now()
+			err = q.run(ctx, handler, job)
+			slog.DebugContext(ctx, "Ran queued job", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts, "duration", time.Since(start), "error", err)
+		}
+		// Record the outcome even when shutting down, the attempt is over either way
+		q.finish(context.WithoutCancel(ctx), job, err)
+	}()
+}
+
+// run runs one attempt of a job, turning a panic into an error so it can't take the server down
+func (q *Queue) run(ctx context.Context, handler Handler, job sqlc.QueuedJob) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, q.options.JobTimeout)
+	defer cancel()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// finish marks a job done, schedules its next attempt or leaves it as a dead letter
+func (q *Queue) finish(ctx context.Context, job sqlc.QueuedJob, err error) {
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		err = q.store.CompleteQueuedJob(ctx, job.ID)
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		slog.ErrorContext(ctx, "Queued job failed for good", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		err = q.store.FailQueuedJob(ctx, sqlc.FailQueuedJobParams{
+			ID:        job.ID,
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		})
+	default:
+		delay := backoff(job.Attempts)
+		slog.WarnContext(ctx, "Queued job failed, retrying", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts, "retry_in", delay, "error", err)
+		err = q.store.RetryQueuedJob(ctx, sqlc.RetryQueuedJobParams{
+			ID:        job.ID,
+			RunAt:     pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true},
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording queued job outcome", "job_id", job.ID, "error", err)
+	}
+}
+
+// backoff returns the wait after the given attempt, doubling from minBackoff up to maxBackoff
+// with up to a tenth added at random, so jobs that failed together don't all retry together
+func backoff(attempts int32) time.Duration {
+	delay := maxBackoff
+	if shift := max(attempts-1, 0); shift < 20 {
+		delay = min(minBackoff<<shift, maxBackoff)
+	}
+	return delay + rand.N(delay/10)
+}
+
+// notify wakes the loop to look for jobs
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// List returns a page of the jobs in a state, newest first, with how many there are in all
+func (q *Queue) List(ctx context.Context, status string, limit, offset int32) ([]Job, int64, error) {
+	jobs, err := q.store.ListQueuedJobs(ctx, sqlc.ListQueuedJobsParams{
+		Status:    status,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := q.store.CountQueuedJobs(ctx, status)
+	if err != nil {
+		return nil, 0, err
+	}
+	views := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		views = append(views, view(job))
+	}
+	return views, total, nil
+}
+
+// Get returns a job
+func (q *Queue) Get(ctx context.Context, id int32) (Job, error) {
+	job, err := q.store.GetQueuedJob(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	return view(job), nil
+}
+
+// Retry runs a dead job again with a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, id int32) (Job, error) {
+	job, err := q.store.RequeueQueuedJob(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Tell a job that doesn't exist from one that isn't dead
+		if _, err := q.Get(ctx, id); err != nil {
+			return Job{}, err
+		}
+		return Job{}, ErrNotDead
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	q.notify()
+	return view(job), nil
+}
+
+// DeleteFinished deletes the jobs that succeeded before the given time, returning how many
+func (q *Queue) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	return q.store.DeleteFinishedQueuedJobs(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+}
+
+// view converts a stored job for the admin API
+func view(job sqlc.QueuedJob) Job {
+	return Job{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Payload:     json.RawMessage(job.Payload),
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt.Time,
+		LastError:   job.LastError.String,
+		LockedBy:    job.LockedBy.String,
+		CreatedAt:   job.CreatedAt.Time,
+		UpdatedAt:   job.UpdatedAt.Time,
+		RequestID:   job.RequestID.String,
+	}
+}
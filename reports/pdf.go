@@ -0,0 +1,352 @@
+package reports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Documents are A4 landscape. Every page has the company name in a band of the brand colour, the
+// title and the header row of the table, and ends with the page number. The text is set in
+// Helvetica, which every PDF reader has, encoded in Windows-1252: characters outside it, such as
+// Thai, print as ?. The workbook is the one to use for those.
+
+const (
+	pdfPageWidth   = 842.0
+	pdfPageHeight  = 595.0
+	pdfMargin      = 36.0
+	pdfBandHeight  = 30.0
+	pdfRowHeight   = 15.0
+	pdfFontSize    = 8.0
+	pdfCellPadding = 4.0
+	pdfMinColumn   = 40.0
+	pdfMaxColumn   = 260.0
+)
+
+// The fonts of the documents, by their resource names
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// pdfTableTop is where the header row of the table starts, under the band, title and subtitle
+const pdfTableTop = pdfPageHeight - pdfMargin - pdfBandHeight - 48
+
+// pdfRowsPerPage is how many rows of the table fit under its header row on a page
+var pdfRowsPerPage = int(math.Floor((pdfTableTop - pdfRowHeight - pdfMargin) / pdfRowHeight))
+
+func writePDF(w io.Writer, report Report, branding Branding) error {
+	r, g, b := parseColor(branding.Color)
+	brand := [3]float64{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+	widths := pdfColumnWidths(report)
+
+	// Every page has at least one row, the first one saying there is nothing when there isn't
+	rows := report.allRows()
+	pageCount := max(1, (len(rows)+pdfRowsPerPage-1)/pdfRowsPerPage)
+
+	var pages [][]byte
+	for page := 0; page < pageCount; page++ {
+		var content pdfContent
+		content.header(report, branding, brand, widths)
+
+		y := pdfTableTop - pdfRowHeight
+		if len(rows) == 0 {
+			content.fillColor(0.4, 0.4, 0.4)
+			content.text(pdfMargin+pdfCellPadding, y-pdfRowHeight+4.5, fontRegular, pdfFontSize, winAnsi("Nothing to report for this period."))
+		}
+		for i := page * pdfRowsPerPage; i < min(len(rows), (page+1)*pdfRowsPerPage); i++ {
+			total := report.Totals != nil && i == len(rows)-1
+			if !total && i%2 == 1 {
+				content.fillColor(0.95, 0.95, 0.95)
+				content.rect(pdfMargin, y-pdfRowHeight, sum(widths), pdfRowHeight)
+			}
+			if total {
+				content.strokeColor(0, 0, 0)
+				content.line(pdfMargin, y, pdfMargin+sum(widths), y, 0.8)
+			}
+			font := fontRegular
+			if total {
+				font = fontBold
+			}
+			content.fillColor(0, 0, 0)
+			content.row(rows[i], report.Columns, widths, y, font)
+			y -= pdfRowHeight
+		}
+
+		content.fillColor(0.4, 0.4, 0.4)
+		footer := winAnsi(fmt.Sprintf("Page %d of %d", page+1, pageCount))
+		content.text(pdfPageWidth-pdfMargin-textWidth(footer, fontRegular, 7), pdfMargin-16, fontRegular, 7, footer)
+		content.text(pdfMargin, pdfMargin-16, fontRegular, 7, winAnsi(branding.CompanyName+" · "+report.Title))
+
+		compressed, err := deflate(content.Bytes())
+		if err != nil {
+			return err
+		}
+		pages = append(pages, compressed)
+	}
+
+	var doc pdfDocument
+	doc.start()
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	doc.object("<< /Type /Catalog /Pages 2 0 R >>")
+	doc.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	doc.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	doc.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	doc.object(fmt.Sprintf("<< /Title %s /Author %s /Producer (TableG) /CreationDate (D:%s) >>",
+		pdfString(winAnsi(report.Title)), pdfString(winAnsi(branding.CompanyName)), report.GeneratedAt.UTC().Format("20060102150405Z")))
+	for i, page := range pages {
+		doc.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, fontRegular, fontBold, 7+2*i))
+		doc.object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(page), page))
+	}
+	doc.finish(5)
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// pdfColumnWidths returns the width of each column in points: enough for its widest value within
+// limits, then scaled to the width of the page
+func pdfColumnWidths(report Report) []float64 {
+	widths := make([]float64, len(report.Columns))
+	for i, column := range report.Columns {
+		widest := textWidth(winAnsi(column.Title), fontBold, pdfFontSize)
+		for _, row := range report.allRows() {
+			widest = math.Max(widest, textWidth(winAnsi(formatCell(row[i], column.Kind)), fontBold, pdfFontSize))
+		}
+		widths[i] = math.Min(math.Max(widest+2*pdfCellPadding, pdfMinColumn), pdfMaxColumn)
+	}
+	if total := sum(widths); total > 0 {
+		scale := (pdfPageWidth - 2*pdfMargin) / total
+		for i := range widths {
+			widths[i] *= scale
+		}
+	}
+	return widths
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// deflate compresses a content stream for /FlateDecode
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfDocument writes the objects of a document in order, numbering them from 1, and the cross
+// reference table pointing at them
+type pdfDocument struct {
+	bytes.Buffer
+	offsets []int
+}
+
+func (d *pdfDocument) start() {
+	// The comment of bytes above 127 tells tools the file is binary
+	d.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+}
+
+func (d *pdfDocument) object(body string) {
+	d.offsets = append(d.offsets, d.Len())
+	fmt.Fprintf(d, "%d 0 obj\n%s\nendobj\n", len(d.offsets), body)
+}
+
+// finish writes the cross reference table and the trailer, with info the number of the
+// document's information dictionary
+func (d *pdfDocument) finish(info int) {
+	xref := d.Len()
+	fmt.Fprintf(d, "xref\n0 %d\n0000000000 65535 f \n", len(d.offsets)+1)
+	for _, offset := range d.offsets {
+		fmt.Fprintf(d, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(d, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.offsets)+1, info, xref)
+}
+
+// pdfContent is the content stream of a page, drawn from the bottom left corner
+type pdfContent struct {
+	bytes.Buffer
+}
+
+// header draws the band with the company name, the title, the subtitle and the header row of the
+// table
+func (c *pdfContent) header(report Report, branding Branding, brand [3]float64, widths []float64) {
+	bandBottom := pdfPageHeight - pdfMargin - pdfBandHeight
+	c.fillColor(brand[0], brand[1], brand[2])
+	c.rect(pdfMargin, bandBottom, pdfPageWidth-2*pdfMargin, pdfBandHeight)
+	c.fillColor(1, 1, 1)
+	c.text(pdfMargin+10, bandBottom+10, fontBold, 14, winAnsi(branding.CompanyName))
+
+	c.fillColor(0, 0, 0)
+	c.text(pdfMargin, bandBottom-20, fontBold, 12, winAnsi(report.Title))
+	c.fillColor(0.4, 0.4, 0.4)
+	c.text(pdfMargin, bandBottom-34, fontRegular, pdfFontSize, winAnsi(report.subtitleLine()))
+
+	c.fillColor(brand[0], brand[1], brand[2])
+	c.rect(pdfMargin, pdfTableTop-pdfRowHeight, sum(widths), pdfRowHeight)
+	titles := make([]any, len(report.Columns))
+	for i, column := range report.Columns {
+		titles[i] = column.Title
+	}
+	c.fillColor(1, 1, 1)
+	c.row(titles, report.Columns, widths, pdfTableTop, fontBold)
+}
+
+// row draws the cells of a table row whose top is at y, numbers aligned right and the rest left,
+// each cut to the width of its column
+func (c *pdfContent) row(cells []any, columns []Column, widths []float64, y float64, font string) {
+	x := pdfMargin
+	for i, cell := range cells {
+		text := winAnsi(formatCell(cell, columns[i].Kind))
+		text = fitText(text, font, pdfFontSize, widths[i]-2*pdfCellPadding)
+		textX := x + pdfCellPadding
+		if kind := columns[i].Kind; kind == Number || kind == Integer || kind == Percent {
+			textX = x + widths[i] - pdfCellPadding - textWidth(text, font, pdfFontSize)
+		}
+		c.text(textX, y-pdfRowHeight+4.5, font, pdfFontSize, text)
+		x += widths[i]
+	}
+}
+
+func (c *pdfContent) fillColor(r, g, b float64) {
+	fmt.Fprintf(c, "%.3f %.3f %.3f rg\n", r, g, b)
+}
+
+func (c *pdfContent) strokeColor(r, g, b float64) {
+	fmt.Fprintf(c, "%.3f %.3f %.3f RG\n", r, g, b)
+}
+
+func (c *pdfContent) rect(x, y, width, height float64) {
+	fmt.Fprintf(c, "%.2f %.2f %.2f %.2f re f\n", x, y, width, height)
+}
+
+func (c *pdfContent) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(c, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+func (c *pdfContent) text(x, y float64, font string, size float64, text []byte) {
+	if len(text) == 0 {
+		return
+	}
+	fmt.Fprintf(c, "BT /%s %g Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// pdfString returns Windows-1252 text as a PDF literal string
+func pdfString(text []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, ch := range text {
+		switch {
+		case ch == '(' || ch == ')' || ch == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(ch)
+		case ch < 32 || ch > 126:
+			fmt.Fprintf(&sb, "\\%03o", ch)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// winAnsiSpecials are the characters Windows-1252 has at 0x80 to 0x9F, where Latin-1 has control
+// characters. From 0xA0 on the two are the same.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// winAnsi encodes text in Windows-1252, with ? for the characters it doesn't have and spaces for
+// control characters
+func winAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x20:
+			encoded = append(encoded, ' ')
+		case r < 0x7F || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				encoded = append(encoded, b)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+	return encoded
+}
+
+// fitText cuts text that is wider than width, ending it with an ellipsis
+func fitText(text []byte, font string, size, width float64) []byte {
+	if textWidth(text, font, size) <= width {
+		return text
+	}
+	ellipsis := textWidth([]byte{0x85}, font, size)
+	for len(text) > 0 && textWidth(text, font, size)+ellipsis > width {
+		text = text[:len(text)-1]
+	}
+	return append(text, 0x85)
+}
+
+// textWidth returns the width of Windows-1252 text in points
+func textWidth(text []byte, font string, size float64) float64 {
+	widths := &helveticaWidths
+	if font == fontBold {
+		widths = &helveticaBoldWidths
+	}
+	units := 0
+	for _, ch := range text {
+		switch {
+		case ch >= 32 && ch <= 126:
+			units += widths[ch-32]
+		case ch == 0x85 || ch == 0x89 || ch == 0x97:
+			units += 1000
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in Helvetica, in thousandths
+// of the font size, from its Adobe font metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// helveticaBoldWidths are the same for Helvetica-Bold
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0 to ?
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P to _
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // ` to o
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p to ~
+}
@@ -0,0 +1,191 @@
+package reports_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/i18n"
+	"github.com/kengtableg/pkeng-tableg/reports"
+)
+
+// update rewrites the golden files with what the writers render now: go test ./reports -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var testBranding = reports.Branding{CompanyName: "Keng & Co (Thailand)", Color: "#C0392B"}
+
+// testReport has a column of every kind, empty cells, text the PDF can't encode or has to escape,
+// a value too long for its column, and enough rows for the PDF to take two pages
+func testReport() reports.Report {
+	report := reports.Report{
+		Title:    "Leave summary",
+		Subtitle: "2025-01-01 to 2025-12-31",
+		Columns: []reports.Column{
+			{Title: "User", Kind: reports.Text},
+			{Title: "First day", Kind: reports.Date},
+			{Title: "Days", Kind: reports.Number},
+			{Title: "Requests", Kind: reports.Integer},
+			{Title: "Share", Kind: reports.Percent},
+		},
+		Rows: [][]any{
+			{"somchai (สมชาย)", time.Date(2025, time.April, 14, 0, 0, 0, 0, time.UTC), 2.5, int64(1), 12.5},
+			{"Crème <brûlée> & “quotes” €", nil, 1234567.891, int64(-4200), nil},
+			{"a name long enough to be cut down to the widest column the document allows, with some more words after it to be sure that it runs past the edge of its column and then some", nil, nil, nil, nil},
+		},
+		GeneratedAt: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC),
+		Language:    i18n.English,
+	}
+	for i := range 30 {
+		report.Rows = append(report.Rows, []any{
+			fmt.Sprintf("user%02d", i+1),
+			time.Date(2025, time.March, i+1, 0, 0, 0, 0, time.UTC),
+			float64(i) / 4,
+			int64(i),
+			float64(i) * 100 / 30,
+		})
+	}
+	report.Totals = []any{"Total", nil, 1234680.141, int64(-3764), 100.0}
+	return report
+}
+
+func TestRenderXLSX(t *testing.T) {
+	var out bytes.Buffer
+	if err := reports.Render(&out, reports.XLSX, testReport(), testBranding); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	// The parts are compared unzipped, so the golden file doesn't hang on how deflate compresses
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("the workbook isn't a zip: %v", err)
+	}
+	var parts bytes.Buffer
+	for _, file := range zr.File {
+		if !file.Modified.Equal(testReport().GeneratedAt) {
+			t.Errorf("%s modified %v, want the time of the report", file.Name, file.Modified)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", file.Name, err)
+		}
+		fmt.Fprintf(&parts, "== %s\n%s\n", file.Name, content)
+	}
+	assertGolden(t, "report.xlsx.golden", parts.Bytes())
+}
+
+// pdfStreamPattern matches the content stream of a page
+var pdfStreamPattern = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+
+// pdfObjectPattern matches the start of an object, with its number
+var pdfObjectPattern = regexp.MustCompile(`^(\d+) 0 obj\n`)
+
+func TestRenderPDF(t *testing.T) {
+	var out bytes.Buffer
+	if err := reports.Render(&out, reports.PDF, testReport(), testBranding); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	document := out.Bytes()
+
+	// Every object is where the cross reference table says it is
+	xrefAt := bytes.LastIndex(document, []byte("startxref\n"))
+	if xrefAt < 0 {
+		t.Fatal("the document has no startxref")
+	}
+	start, err := strconv.Atoi(string(bytes.Fields(document[xrefAt+len("startxref\n"):])[0]))
+	if err != nil || !bytes.HasPrefix(document[start:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the cross reference table", start)
+	}
+	lines := bytes.Split(document[start:], []byte("\n"))
+	count, _ := strconv.Atoi(string(bytes.Fields(lines[1])[1]))
+	for number := 1; number < count; number++ {
+		offset, _ := strconv.Atoi(string(lines[2+number][:10]))
+		match := pdfObjectPattern.FindSubmatch(document[offset:])
+		if match == nil || string(match[1]) != strconv.Itoa(number) {
+			t.Errorf("object %d isn't at offset %d", number, offset)
+		}
+	}
+
+	// The content streams are compared inflated, so the golden file doesn't hang on how deflate
+	// compresses
+	var inflateErr error
+	decoded := pdfStreamPattern.ReplaceAllFunc(document, func(stream []byte) []byte {
+		zr, err := zlib.NewReader(bytes.NewReader(pdfStreamPattern.FindSubmatch(stream)[1]))
+		if err != nil {
+			inflateErr = err
+			return stream
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			inflateErr = err
+		}
+		return append(append([]byte("stream\n"), content...), "endstream"...)
+	})
+	if inflateErr != nil {
+		t.Fatalf("inflating a content stream: %v", inflateErr)
+	}
+	if pages := bytes.Count(decoded, []byte("/Type /Page ")); pages != 2 {
+		t.Errorf("the document has %d pages, want 2", pages)
+	}
+	assertGolden(t, "report.pdf.golden", decoded)
+}
+
+func TestRenderEmptyPDF(t *testing.T) {
+	report := testReport()
+	report.Rows, report.Totals = nil, nil
+
+	var out bytes.Buffer
+	if err := reports.Render(&out, reports.PDF, report, testBranding); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if pages := bytes.Count(out.Bytes(), []byte("/Type /Page ")); pages != 1 {
+		t.Errorf("the document has %d pages, want 1", pages)
+	}
+}
+
+func TestRenderRejectsRaggedRows(t *testing.T) {
+	report := testReport()
+	report.Rows = append(report.Rows, []any{"short"})
+
+	for _, format := range []reports.Format{reports.XLSX, reports.PDF} {
+		if err := reports.Render(io.Discard, format, report, testBranding); err == nil {
+			t.Errorf("Render(%s) of a row missing cells succeeded", format)
+		}
+	}
+}
+
+// assertGolden compares got with the golden file of that name in testdata, or rewrites the file
+// with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the golden file: %v, run go test -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the output differs from %s, run go test -update and review the diff if the change is meant", path)
+	}
+}
@@ -0,0 +1,178 @@
+// Package reports fetches reports, such as a leave summary or a timesheet, through the Store and
+// renders them into XLSX workbooks and PDF documents that carry the company's name and colour.
+// Rendering needs nothing beyond the standard library: workbooks are zipped XML and documents use
+// the fonts every PDF reader has.
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/kengtableg/pkeng-tableg/i18n"
+)
+
+// Format is a file format reports are rendered into
+type Format string
+
+const (
+	XLSX Format = "xlsx"
+	PDF  Format = "pdf"
+)
+
+// ErrUnknownFormat is returned by ParseFormat for a format reports aren't rendered into
+var ErrUnknownFormat = errors.New("unknown report format")
+
+// ParseFormat returns the format named by s, xlsx or pdf
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case XLSX, PDF:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("%w %q, must be xlsx or pdf", ErrUnknownFormat, s)
+}
+
+// ContentType returns the media type of files in the format
+func (f Format) ContentType() string {
+	if f == PDF {
+		return "application/pdf"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// Kind is the kind of values a column holds, which picks how they are formatted and aligned
+type Kind int
+
+const (
+	// Text cells hold strings
+	Text Kind = iota
+	// Number cells hold float64s, shown with two decimals
+	Number
+	// Integer cells hold int64s
+	Integer
+	// Date cells hold time.Times, shown as yyyy-mm-dd
+	Date
+	// Percent cells hold float64s of 100 for all, shown with one decimal and a % sign
+	Percent
+)
+
+// label is a text cell that is part of the report rather than its data, such as the Total in
+// front of the totals, which Build translates with the titles into a string
+type label string
+
+// Column is a column of a report
+type Column struct {
+	Title string
+	Kind  Kind
+}
+
+// Report is a table with a title. A cell is nil when it is empty, and otherwise holds a value of
+// its column's kind.
+type Report struct {
+	Title       string // e.g. Leave summary
+	Subtitle    string // e.g. the period it covers
+	Columns     []Column
+	Rows        [][]any
+	Totals      []any // The row under the table in bold, none when nil
+	GeneratedAt time.Time
+	Language    i18n.Language
+}
+
+// Branding is what makes a report the company's
+type Branding struct {
+	CompanyName string
+	Color       string // #RRGGBB, of the header row and the title band
+}
+
+// Render writes the report to w in the format
+func Render(w io.Writer, format Format, report Report, branding Branding) error {
+	for i, row := range report.allRows() {
+		if len(row) != len(report.Columns) {
+			return fmt.Errorf("row %d has %d cells for %d columns", i, len(row), len(report.Columns))
+		}
+	}
+	switch format {
+	case XLSX:
+		return writeXLSX(w, report, branding)
+	case PDF:
+		return writePDF(w, report, branding)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// allRows returns the rows of the table followed by the totals, if any
+func (r Report) allRows() [][]any {
+	if r.Totals == nil {
+		return r.Rows
+	}
+	return append(slices.Clip(r.Rows), r.Totals)
+}
+
+// subtitleLine returns the subtitle followed by when the report was generated
+func (r Report) subtitleLine() string {
+	generated := i18n.Translate(r.Language, "Generated") + " " + r.GeneratedAt.Format("2006-01-02 15:04")
+	if r.Subtitle == "" {
+		return generated
+	}
+	return r.Subtitle + " · " + generated
+}
+
+// formatCell returns a cell as text, the way the PDF shows it
+func formatCell(value any, kind Kind) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if kind == Percent {
+			return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+		}
+		return groupThousands(strconv.FormatFloat(math.Round(v*100)/100, 'f', 2, 64))
+	case int64:
+		return groupThousands(strconv.FormatInt(v, 10))
+	case int:
+		return groupThousands(strconv.Itoa(v))
+	case time.Time:
+		return v.Format("2006-01-02")
+	}
+	return fmt.Sprint(value)
+}
+
+// groupThousands puts commas between the thousands of a formatted number, like #,##0 in Excel
+func groupThousands(number string) string {
+	sign, digits, fraction := "", number, ""
+	if len(digits) > 0 && digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	for i := range digits {
+		if digits[i] == '.' {
+			digits, fraction = digits[:i], digits[i:]
+			break
+		}
+	}
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, digits[i])
+	}
+	return sign + string(grouped) + fraction
+}
+
+// parseColor returns the red, green and blue of a #RRGGBB colour, dark blue when it isn't one
+func parseColor(color string) (r, g, b uint8) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0x1F, 0x4E, 0x79
+	}
+	value, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0x1F, 0x4E, 0x79
+	}
+	return uint8(value >> 16), uint8(value >> 8), uint8(value)
+}
@@ -0,0 +1,311 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+	"github.com/kengtableg/pkeng-tableg/i18n"
+)
+
+// ErrUnknownReport is returned by Build for a name none of the reports has
+var ErrUnknownReport = errors.New("unknown report")
+
+// Params narrow down what a report covers
+type Params struct {
+	From       time.Time
+	To         time.Time
+	UserID     pgtype.Int4    // Everyone when not valid
+	Department pgtype.Text    // Every department when not valid, otherwise the department and those under it
+	Language   i18n.Language  // Of the title and column titles, English when empty
+	Location   *time.Location // Of the time the report says it was generated at, UTC when nil
+}
+
+// sources fetch the rows of the reports, by the name they are asked for with
+var sources = map[string]func(ctx context.Context, q sqlc.Querier, params Params) (Report, error){
+	"leave":            leaveSummary,
+	"timesheet":        timesheet,
+	"utilization":      utilization,
+	"medical-expenses": medicalExpenses,
+}
+
+// Names returns the names of the reports Build fetches, sorted
+func Names() []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Exists reports whether Build knows the report
+func Exists(name string) bool {
+	_, ok := sources[name]
+	return ok
+}
+
+// Build fetches the named report for the period and whoever the params narrow it down to, with
+// the period as its subtitle, labelled in the params' language
+func Build(ctx context.Context, q sqlc.Querier, name string, params Params) (Report, error) {
+	source, ok := sources[name]
+	if !ok {
+		return Report{}, fmt.Errorf("%w %q", ErrUnknownReport, name)
+	}
+	report, err := source(ctx, q, params)
+	if err != nil {
+		return Report{}, err
+	}
+	location := params.Location
+	if location == nil {
+		location = time.UTC
+	}
+	report.GeneratedAt = time.Now().In(location)
+	report.Language = params.Language
+	report.Title = i18n.Translate(params.Language, report.Title)
+	for i := range report.Columns {
+		report.Columns[i].Title = i18n.Translate(params.Language, report.Columns[i].Title)
+	}
+	for _, row := range report.allRows() {
+		for i, cell := range row {
+			if label, ok := cell.(label); ok {
+				row[i] = i18n.Translate(params.Language, string(label))
+			}
+		}
+	}
+	report.Subtitle = params.From.Format("2 Jan 2006") + " – " + params.To.Format("2 Jan 2006")
+	if params.Department.Valid {
+		report.Subtitle += " · " + params.Department.String
+	}
+	return report, nil
+}
+
+// Export builds the named report and renders it in the format, returning the file and its name,
+// e.g. leave-2026-01-01-2026-03-31.xlsx. PDFs are in English whatever the params' language, the
+// fonts every reader has have no Thai letters.
+func Export(ctx context.Context, q sqlc.Querier, name string, format Format, params Params, branding Branding) ([]byte, string, error) {
+	if format == PDF {
+		params.Language = i18n.English
+	}
+	report, err := Build(ctx, q, name, params)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, format, report, branding); err != nil {
+		return nil, "", fmt.Errorf("rendering %s report: %w", name, err)
+	}
+	fileName := fmt.Sprintf("%s-%s-%s.%s", name, params.From.Format("2006-01-02"), params.To.Format("2006-01-02"), format)
+	return buf.Bytes(), fileName, nil
+}
+
+// leaveSummary is the leave days each user took in the period, a column per leave type
+func leaveSummary(ctx context.Context, q sqlc.Querier, params Params) (Report, error) {
+	rows, err := q.SummarizeLeaveLogsByDateRange(ctx, sqlc.SummarizeLeaveLogsByDateRangeParams{
+		StartDate:  pgtype.Date{Time: params.From, Valid: true},
+		EndDate:    pgtype.Date{Time: params.To, Valid: true},
+		UserID:     params.UserID,
+		Department: params.Department,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	var leaveTypes []string
+	for _, row := range rows {
+		if !slices.Contains(leaveTypes, row.Type) {
+			leaveTypes = append(leaveTypes, row.Type)
+		}
+	}
+	slices.Sort(leaveTypes)
+
+	report := Report{
+		Title:   "Leave summary",
+		Columns: []Column{{Title: "User"}, {Title: "Department"}},
+	}
+	for _, leaveType := range leaveTypes {
+		report.Columns = append(report.Columns, Column{Title: leaveTypeTitle(leaveType), Kind: Integer})
+	}
+	report.Columns = append(report.Columns, Column{Title: "Total days", Kind: Integer})
+
+	// Rows come ordered by user, one per leave type
+	totals := make([]int64, len(leaveTypes)+1)
+	var current []any
+	var currentUserID int32
+	for _, row := range rows {
+		if current == nil || row.UserID != currentUserID {
+			current = make([]any, len(report.Columns))
+			current[0], current[1] = row.Username, textCell(row.Department)
+			report.Rows = append(report.Rows, current)
+			currentUserID = row.UserID
+		}
+		i := slices.Index(leaveTypes, row.Type)
+		current[2+i] = row.DayCount
+		current[len(current)-1] = addInt64(current[len(current)-1], row.DayCount)
+		totals[i] += row.DayCount
+		totals[len(totals)-1] += row.DayCount
+	}
+
+	report.Totals = []any{label("Total"), nil}
+	for _, total := range totals {
+		report.Totals = append(report.Totals, total)
+	}
+	return report, nil
+}
+
+// leaveTypeTitle returns a leave type as a column title, e.g. Sick leave for sick_leave
+func leaveTypeTitle(leaveType string) string {
+	title := strings.ReplaceAll(leaveType, "_", " ")
+	if title == "" {
+		return title
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+func addInt64(value any, n int64) int64 {
+	total, _ := value.(int64)
+	return total + n
+}
+
+// timesheet is the work each user logged in the period, one row per log
+func timesheet(ctx context.Context, q sqlc.Querier, params Params) (Report, error) {
+	rows, err := q.ListTimesheetEntries(ctx, sqlc.ListTimesheetEntriesParams{
+		StartDate:  pgtype.Date{Time: params.From, Valid: true},
+		EndDate:    pgtype.Date{Time: params.To, Valid: true},
+		UserID:     params.UserID,
+		Department: params.Department,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Title: "Timesheet",
+		Columns: []Column{
+			{Title: "Date", Kind: Date},
+			{Title: "User"},
+			{Title: "Department"},
+			{Title: "Task"},
+			{Title: "Worked days", Kind: Number},
+			{Title: "On a holiday"},
+		},
+	}
+	workedDays := 0.0
+	for _, row := range rows {
+		worked := toFloat(row.WorkedDay, 0)
+		workedDays += worked
+		var holiday any
+		if row.IsWorkOnHoliday.Bool {
+			holiday = label("Yes")
+		}
+		report.Rows = append(report.Rows, []any{row.WorkedDate.Time, row.Username, textCell(row.Department), textCell(row.TaskTitle), worked, holiday})
+	}
+	report.Totals = []any{nil, label("Total"), nil, nil, workedDays, nil}
+	return report, nil
+}
+
+// utilization compares the work each user logged in the period with the days they were
+// available, the working days without their leave at their daily capacity
+func utilization(ctx context.Context, q sqlc.Querier, params Params) (Report, error) {
+	rows, err := q.ListUserUtilization(ctx, sqlc.ListUserUtilizationParams{
+		StartDate:  pgtype.Date{Time: params.From, Valid: true},
+		EndDate:    pgtype.Date{Time: params.To, Valid: true},
+		UserID:     params.UserID,
+		Department: params.Department,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Title: "Utilization",
+		Columns: []Column{
+			{Title: "User"},
+			{Title: "Department"},
+			{Title: "Working days", Kind: Integer},
+			{Title: "Leave days", Kind: Integer},
+			{Title: "Available days", Kind: Number},
+			{Title: "Worked days", Kind: Number},
+			{Title: "Utilization", Kind: Percent},
+		},
+	}
+	var leaveDays int64
+	var availableDays, workedDays float64
+	for _, row := range rows {
+		available := math.Round(float64(row.WorkingDayCount-row.LeaveDayCount)*toFloat(row.DailyCapacity, 1)*100) / 100
+		worked := toFloat(row.WorkedDay, 0)
+		leaveDays += row.LeaveDayCount
+		availableDays += available
+		workedDays += worked
+		report.Rows = append(report.Rows, []any{row.Username, textCell(row.Department), row.WorkingDayCount, row.LeaveDayCount, available, worked, utilizationPercent(worked, available)})
+	}
+	report.Totals = []any{label("Total"), nil, nil, leaveDays, availableDays, workedDays, utilizationPercent(workedDays, availableDays)}
+	return report, nil
+}
+
+// utilizationPercent returns worked days as a percentage of available days, nothing without any
+func utilizationPercent(worked, available float64) any {
+	if available <= 0 {
+		return nil
+	}
+	return math.Round(worked/available*1000) / 10
+}
+
+// medicalExpenses is the medical expenses with a receipt dated in the period
+func medicalExpenses(ctx context.Context, q sqlc.Querier, params Params) (Report, error) {
+	rows, err := q.ListMedicalExpensesByDateRange(ctx, sqlc.ListMedicalExpensesByDateRangeParams{
+		StartDate:  pgtype.Date{Time: params.From, Valid: true},
+		EndDate:    pgtype.Date{Time: params.To, Valid: true},
+		UserID:     params.UserID,
+		Department: params.Department,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Title: "Medical expenses",
+		Columns: []Column{
+			{Title: "Receipt date", Kind: Date},
+			{Title: "User"},
+			{Title: "Department"},
+			{Title: "Receipt"},
+			{Title: "Note"},
+			{Title: "Amount (THB)", Kind: Number},
+		},
+	}
+	amount := 0.0
+	for _, row := range rows {
+		rowAmount := toFloat(row.Amount, 0)
+		amount += rowAmount
+		report.Rows = append(report.Rows, []any{row.ReceiptDate.Time, row.Username, textCell(row.Department), textCell(row.ReceiptName), textCell(row.Note), rowAmount})
+	}
+	report.Totals = []any{nil, label("Total"), nil, nil, nil, amount}
+	return report, nil
+}
+
+// textCell returns a text column as a cell, empty when null
+func textCell(value pgtype.Text) any {
+	if !value.Valid {
+		return nil
+	}
+	return value.String
+}
+
+// toFloat returns a DECIMAL column as a float64, def when null
+func toFloat(n pgtype.Numeric, def float64) float64 {
+	value, err := pgconv.ToFloat(n)
+	if err != nil {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,306 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A workbook has one sheet: the company name, the title and the subtitle on top, a blank row,
+// then the table with its header row frozen in place. Strings are stored inline in their cells,
+// which spares keeping a shared strings table.
+
+// xlsxTableRow is the row number of the header row of the table
+const xlsxTableRow = 5
+
+// The indexes of the cell formats in styles.xml
+const (
+	styleDefault = iota
+	styleCompany
+	styleTitle
+	styleHeader
+	styleNumber
+	styleInteger
+	styleDate
+	stylePercent
+	styleTotal
+	styleTotalNumber
+	styleTotalInteger
+	styleTotalPercent
+)
+
+// excelEpoch is the day Excel counts dates from, with the 1900 leap year bug accounted for
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func writeXLSX(w io.Writer, report Report, branding Branding) error {
+	r, g, b := parseColor(branding.Color)
+	color := fmt.Sprintf("FF%02X%02X%02X", r, g, b)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"docProps/core.xml", xlsxCore(report, branding)},
+		{"xl/workbook.xml", xlsxWorkbook(report.Title)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", fmt.Sprintf(xlsxStyles, color, color)},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(report, branding)},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: report.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xlsxSheet returns the worksheet holding the report
+func xlsxSheet(report Report, branding Branding) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	fmt.Fprintf(&sb, `<pane ySplit="%d" topLeftCell="A%d" activePane="bottomLeft" state="frozen"/>`, xlsxTableRow, xlsxTableRow+1)
+	sb.WriteString(`</sheetView></sheetViews>`)
+
+	sb.WriteString(`<cols>`)
+	for i, width := range xlsxColumnWidths(report) {
+		fmt.Fprintf(&sb, `<col min="%d" max="%d" width="%.1f" customWidth="1"/>`, i+1, i+1, width)
+	}
+	sb.WriteString(`</cols>`)
+
+	sb.WriteString(`<sheetData>`)
+	writeXLSXTextRow(&sb, 1, branding.CompanyName, styleCompany)
+	writeXLSXTextRow(&sb, 2, report.Title, styleTitle)
+	writeXLSXTextRow(&sb, 3, report.subtitleLine(), styleDefault)
+
+	fmt.Fprintf(&sb, `<row r="%d">`, xlsxTableRow)
+	for i, column := range report.Columns {
+		writeXLSXCell(&sb, cellRef(i, xlsxTableRow), column.Title, styleHeader)
+	}
+	sb.WriteString(`</row>`)
+
+	rowNumber := xlsxTableRow
+	for _, row := range report.Rows {
+		rowNumber++
+		fmt.Fprintf(&sb, `<row r="%d">`, rowNumber)
+		for i, value := range row {
+			writeXLSXCell(&sb, cellRef(i, rowNumber), value, xlsxStyle(report.Columns[i].Kind, false))
+		}
+		sb.WriteString(`</row>`)
+	}
+	if report.Totals != nil {
+		rowNumber++
+		fmt.Fprintf(&sb, `<row r="%d">`, rowNumber)
+		for i, value := range report.Totals {
+			writeXLSXCell(&sb, cellRef(i, rowNumber), value, xlsxStyle(report.Columns[i].Kind, true))
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData>`)
+
+	if len(report.Columns) > 0 {
+		fmt.Fprintf(&sb, `<autoFilter ref="%s:%s"/>`, cellRef(0, xlsxTableRow), cellRef(len(report.Columns)-1, xlsxTableRow+len(report.Rows)))
+	}
+	sb.WriteString(`<pageMargins left="0.5" right="0.5" top="0.75" bottom="0.75" header="0.3" footer="0.3"/>`)
+	sb.WriteString(`<pageSetup orientation="landscape" fitToWidth="1" fitToHeight="0"/>`)
+	sb.WriteString(`</worksheet>`)
+	return sb.String()
+}
+
+// writeXLSXTextRow writes a row with text in its first cell
+func writeXLSXTextRow(sb *strings.Builder, rowNumber int, text string, style int) {
+	fmt.Fprintf(sb, `<row r="%d">`, rowNumber)
+	writeXLSXCell(sb, cellRef(0, rowNumber), text, style)
+	sb.WriteString(`</row>`)
+}
+
+// writeXLSXCell writes a cell, nothing when it has no value and the default style
+func writeXLSXCell(sb *strings.Builder, ref string, value any, style int) {
+	switch v := value.(type) {
+	case nil:
+		if style != styleDefault {
+			fmt.Fprintf(sb, `<c r="%s" s="%d"/>`, ref, style)
+		}
+	case string:
+		fmt.Fprintf(sb, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		_ = xml.EscapeText(sb, []byte(v))
+		sb.WriteString(`</t></is></c>`)
+	case float64:
+		fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+	case int64:
+		fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+	case int:
+		fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+	case time.Time:
+		day := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+		fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, int(day.Sub(excelEpoch).Hours()/24))
+	default:
+		writeXLSXCell(sb, ref, fmt.Sprint(value), style)
+	}
+}
+
+// xlsxStyle returns the cell format of a column's cells, in the table or the totals row
+func xlsxStyle(kind Kind, total bool) int {
+	switch kind {
+	case Number:
+		if total {
+			return styleTotalNumber
+		}
+		return styleNumber
+	case Integer:
+		if total {
+			return styleTotalInteger
+		}
+		return styleInteger
+	case Percent:
+		if total {
+			return styleTotalPercent
+		}
+		return stylePercent
+	case Date:
+		if total {
+			return styleTotal
+		}
+		return styleDate
+	}
+	if total {
+		return styleTotal
+	}
+	return styleDefault
+}
+
+// xlsxColumnWidths returns the width of each column in characters, enough for its longest value
+// within limits
+func xlsxColumnWidths(report Report) []float64 {
+	widths := make([]float64, len(report.Columns))
+	for i, column := range report.Columns {
+		longest := utf8.RuneCountInString(column.Title)
+		for _, row := range report.allRows() {
+			longest = max(longest, utf8.RuneCountInString(formatCell(row[i], column.Kind)))
+		}
+		widths[i] = float64(min(max(longest, 8), 60)) + 2
+	}
+	return widths
+}
+
+// cellRef returns the A1 reference of a cell from its zero based column and its row number
+func cellRef(column, row int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// xlsxSheetName returns a title as a sheet name, which is at most 31 characters without []:*?/\
+func xlsxSheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if strings.TrimSpace(name) == "" {
+		return "Report"
+	}
+	return name
+}
+
+func xlsxWorkbook(title string) string {
+	var name bytes.Buffer
+	_ = xml.EscapeText(&name, []byte(xlsxSheetName(title)))
+	return xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+func xlsxCore(report Report, branding Branding) string {
+	var title, creator bytes.Buffer
+	_ = xml.EscapeText(&title, []byte(report.Title))
+	_ = xml.EscapeText(&creator, []byte(branding.CompanyName))
+	created := report.GeneratedAt.UTC().Format(time.RFC3339)
+	return xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>` + title.String() + `</dc:title><dc:creator>` + creator.String() + `</dc:creator>` +
+		`<dcterms:created xsi:type="dcterms:W3CDTF">` + created + `</dcterms:created></cp:coreProperties>`
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles is the format of styles.xml, with the brand colour to fill in twice, for the company name and
+// the header fill. Its cellXfs are in the order of the style constants.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="3">` +
+	`<numFmt numFmtId="164" formatCode="yyyy-mm-dd"/>` +
+	`<numFmt numFmtId="165" formatCode="#,##0.00"/>` +
+	`<numFmt numFmtId="166" formatCode="0.0&quot;%%&quot;"/>` +
+	`</numFmts>` +
+	`<fonts count="5">` +
+	`<font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><color rgb="FFFFFFFF"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="16"/><color rgb="%s"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="13"/><name val="Calibri"/></font>` +
+	`</fonts>` +
+	`<fills count="3">` +
+	`<fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="%s"/><bgColor indexed="64"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="2">` +
+	`<border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top style="thin"/><bottom style="double"/><diagonal/></border>` +
+	`</borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="12">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="3" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="4" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="2" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="1" xfId="0" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="165" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="3" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`<xf numFmtId="166" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs next
+type Schedule interface {
+	// Next returns the first run after the given time
+	Next(after time.Time) time.Time
+	// String returns the schedule as Parse reads it
+	String() string
+}
+
+// Every returns a schedule running a job every interval, counted from the start of the last run
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+// Parse reads a schedule: "@every" followed by a duration like 1h30m, one of @hourly, @daily,
+// @weekly, @monthly and @yearly, or the five fields of a cron line, minute, hour, day of month,
+// month and day of week (0 or 7 is Sunday). Fields take numbers, *, ranges like 1-5, steps like
+// */15 and lists of these separated by commas. Times are in the scheduler's time zone.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if value, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be positive", spec)
+		}
+		return Every(interval), nil
+	}
+	if line, ok := macros[spec]; ok {
+		schedule, err := parseCron(line)
+		if err != nil {
+			return nil, err
+		}
+		schedule.spec = spec
+		return schedule, nil
+	}
+	return parseCron(spec)
+}
+
+// MustParse is Parse for schedules fixed in the code, it panics on an invalid one
+func MustParse(spec string) Schedule {
+	schedule, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// macros are the cron lines of the named schedules
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// cronSchedule holds the values each field of a cron line allows as bits
+type cronSchedule struct {
+	spec                                   string
+	minutes, hours, days, months, weekdays uint64
+	// With both restricted, a day matches when either the day of month or of week does
+	anyDay, anyWeekday bool
+}
+
+// cronField is the range of one field of a cron line
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseCron(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: want @every, a named schedule or 5 cron fields", spec)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		if bits[i], err = parseCronField(field, cronFields[i]); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	schedule := &cronSchedule{
+		spec:       spec,
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: the date never comes", spec)
+	}
+	return schedule, nil
+}
+
+// parseCronField returns the values a field allows as bits
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		span, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in the %s field", stepValue, field.name)
+			}
+		}
+
+		low, high := field.min, field.max
+		if span != "*" {
+			lowValue, highValue, isRange := strings.Cut(span, "-")
+			var err error
+			if low, err = strconv.Atoi(lowValue); err != nil {
+				return 0, fmt.Errorf("invalid value %q in the %s field", part, field.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highValue); err != nil {
+					return 0, fmt.Errorf("invalid value %q in the %s field", part, field.name)
+				}
+			} else if hasStep {
+				high = field.max
+			}
+		}
+		if low < field.min || high > field.max || low > high {
+			return 0, fmt.Errorf("%q is out of range %d-%d in the %s field", part, field.min, field.max, field.name)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next finds the next matching minute by skipping whole months, days and hours that don't match
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule Parse accepts matches within a few years, February 29th included
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	// Only a date that never comes, like February 30th, gets here, and Parse refuses those
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	day := c.days&(1<<t.Day()) != 0
+	weekday := c.weekdays&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func (c *cronSchedule) String() string {
+	return c.spec
+}
@@ -0,0 +1,372 @@
+// Package scheduler runs the server's background jobs on cron-like schedules. Their state, when
+// each last ran, how that went and when each runs next, lives in the scheduled_jobs table, so
+// restarts keep to the schedule and an admin can pause, resume or trigger a job from any server
+// instance. With several instances only the elected leader runs jobs, and a job never overlaps a
+// run of itself that is still going.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// pollInterval is how often the leader looks for due jobs, and how long a trigger sent to
+// another instance takes at most to be picked up
+const pollInterval = 15 * time.Second
+
+var (
+	// ErrUnknownJob is returned for a job that isn't registered
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrPaused is returned when triggering a paused job
+	ErrPaused = errors.New("job is paused")
+)
+
+// Job is a background job
+type Job struct {
+	Name        string
+	Description string
+	Schedule    Schedule
+	Run         func(ctx context.Context) error
+}
+
+// Store keeps the state of the jobs, db.Store is one
+type Store interface {
+	RegisterScheduledJob(ctx context.Context, arg sqlc.RegisterScheduledJobParams) error
+	ListScheduledJobs(ctx context.Context) ([]sqlc.ScheduledJob, error)
+	GetScheduledJob(ctx context.Context, name string) (sqlc.ScheduledJob, error)
+	ClaimScheduledJob(ctx context.Context, arg sqlc.ClaimScheduledJobParams) (sqlc.ScheduledJob, error)
+	FinishScheduledJob(ctx context.Context, arg sqlc.FinishScheduledJobParams) error
+	SetScheduledJobPaused(ctx context.Context, arg sqlc.SetScheduledJobPausedParams) (sqlc.ScheduledJob, error)
+	TriggerScheduledJob(ctx context.Context, name string) (sqlc.ScheduledJob, error)
+}
+
+// Elector decides which of several server instances runs the jobs, such as db.LeaderElection
+type Elector interface {
+	// IsLeader reports whether this instance runs the jobs, trying to become the leader when
+	// there is none
+	IsLeader(ctx context.Context) bool
+}
+
+// Status is a job and its state, as the admin API shows it
+type Status struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schedule    string `json:"schedule"`
+	Paused      bool   `json:"paused"`
+	// Running is set from the start of a run until it finishes, or until the next run starts
+	// when the instance running it died
+	Running        bool       `json:"running"`
+	NextRunAt      time.Time  `json:"nextRunAt"`
+	LastStartedAt  *time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"` // Empty when the last run succeeded
+	LastRunBy      string     `json:"lastRunBy,omitempty"` // The server instance of the last run
+}
+
+// Scheduler runs the registered jobs when they are due
+type Scheduler struct {
+	store    Store
+	elector  Elector // nil when this instance always leads
+	instance string
+	location *time.Location // Of the schedules
+
+	mu      sync.Mutex
+	jobs    map[string]Job
+	names   []string // In the order they were registered
+	running map[string]bool
+	wake    chan struct{}
+}
+
+// New creates a scheduler keeping the job state in store. The elector picks the instance that
+// runs jobs, with a nil one this instance always does. The instance name is recorded with each
+// run. Schedules are read in location, so a daily job at midnight runs at the company's midnight
+// whatever zone the server is in.
+func New(store Store, elector Elector, instance string, location *time.Location) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		elector:  elector,
+		instance: instance,
+		location: location,
+		jobs:     make(map[string]Job),
+		running:  make(map[string]bool),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register adds a job, before Start
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; !ok {
+		s.names = append(s.names, job.Name)
+	}
+	s.jobs[job.Name] = job
+	slog.Info("Job scheduled", "job", job.Name, "schedule", job.Schedule.String())
+}
+
+// Start records the registered jobs, keeping the state of those that ran before, and runs
+// them when they are due until ctx is cancelled. A job that was due while no instance ran
+// runs once right away.
+func (s *Scheduler) Start(ctx context.Context) error {
+	now := s.now()
+	for _, job := range s.registered() {
+		err := s.store.RegisterScheduledJob(ctx, sqlc.RegisterScheduledJobParams{
+			Name:      job.Name,
+			Schedule:  job.Schedule.String(),
+			NextRunAt: timestamptz(job.Schedule.Next(now)),
+		})
+		if err != nil {
+			return fmt.Errorf("registering job %s: %w", job.Name, err)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			s.runDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+	return nil
+}
+
+// runDue starts the jobs that are due when this instance leads
+func (s *Scheduler) runDue(ctx context.Context) {
+	if s.elector != nil && !s.elector.IsLeader(ctx) {
+		return
+	}
+	states, err := s.store.ListScheduledJobs(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing scheduled jobs", "error", err)
+		return
+	}
+
+	now := s.now()
+	for _, state := range states {
+		job, ok := s.job(state.Name)
+		if !ok || state.Paused || state.NextRunAt.Time.After(now) || s.isRunning(job.Name) {
+			continue
+		}
+		// Claiming moves the next run on, so only one instance runs it even while the leader
+		// changes
+		_, err := s.store.ClaimScheduledJob(ctx, sqlc.ClaimScheduledJobParams{
+			NextRunAt: timestamptz(job.Schedule.Next(now)),
+			RunBy:     pgtype.Text{String: s.instance, Valid: true},
+			Name:      job.Name,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Error starting scheduled job", "job", job.Name, "error", err)
+			continue
+		}
+		s.start(ctx, job)
+	}
+}
+
+// start runs a claimed job in the background and records how it went
+func (s *Scheduler) start(ctx context.Context, job Job) {
+	s.mu.Lock()
+	s.running[job.Name] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, job.Name)
+			s.mu.Unlock()
+		}()
+
+		slog.InfoContext(ctx, "Running scheduled job", "job", job.Name)
+		start := time.Now()
+		err := runJob(ctx, job)
+		var lastError pgtype.Text
+		if err != nil {
+			slog.ErrorContext(ctx, "Scheduled job failed", "job", job.Name, "duration", time.Since(start), "error", err)
+			lastError = pgtype.Text{String: err.Error(), Valid: true}
+		} else {
+			slog.InfoContext(ctx, "Scheduled job finished", "job", job.Name, "duration", time.Since(start))
+		}
+
+		// Record the outcome even when shutting down, the run is over either way
+		err = s.store.FinishScheduledJob(context.WithoutCancel(ctx), sqlc.FinishScheduledJobParams{
+			Name:      job.Name,
+			LastError: lastError,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error recording scheduled job run", "job", job.Name, "error", err)
+		}
+	}()
+}
+
+// runJob runs a job, turning a panic into an error so it can't take the server down
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// Jobs returns the registered jobs and their state
+func (s *Scheduler) Jobs(ctx context.Context) ([]Status, error) {
+	states, err := s.store.ListScheduledJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]sqlc.ScheduledJob, len(states))
+	for _, state := range states {
+		byName[state.Name] = state
+	}
+
+	statuses := []Status{}
+	for _, job := range s.registered() {
+		if state, ok := byName[job.Name]; ok {
+			statuses = append(statuses, status(job, state))
+		}
+	}
+	return statuses, nil
+}
+
+// Job returns a registered job and its state
+func (s *Scheduler) Job(ctx context.Context, name string) (Status, error) {
+	job, ok := s.job(name)
+	if !ok {
+		return Status{}, ErrUnknownJob
+	}
+	state, err := s.store.GetScheduledJob(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Status{}, ErrUnknownJob
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	return status(job, state), nil
+}
+
+// Trigger makes a job due now, the leader runs it within seconds, after the run still going if
+// there is one
+func (s *Scheduler) Trigger(ctx context.Context, name string) (Status, error) {
+	current, err := s.Job(ctx, name)
+	if err != nil {
+		return Status{}, err
+	}
+	if current.Paused {
+		return Status{}, ErrPaused
+	}
+	state, err := s.store.TriggerScheduledJob(ctx, name)
+	if err != nil {
+		return Status{}, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	job, _ := s.job(name)
+	return status(job, state), nil
+}
+
+// Pause stops a job from running until it is resumed, a run still going finishes
+func (s *Scheduler) Pause(ctx context.Context, name string) (Status, error) {
+	return s.setPaused(ctx, name, true, pgtype.Timestamptz{})
+}
+
+// Resume runs a paused job on its schedule again, skipping the runs missed while paused
+func (s *Scheduler) Resume(ctx context.Context, name string) (Status, error) {
+	job, ok := s.job(name)
+	if !ok {
+		return Status{}, ErrUnknownJob
+	}
+	return s.setPaused(ctx, name, false, timestamptz(job.Schedule.Next(s.now())))
+}
+
+// now is the time in the location of the schedules
+func (s *Scheduler) now() time.Time {
+	return time.Now().In(s.location)
+}
+
+func (s *Scheduler) setPaused(ctx context.Context, name string, paused bool, nextRunAt pgtype.Timestamptz) (Status, error) {
+	job, ok := s.job(name)
+	if !ok {
+		return Status{}, ErrUnknownJob
+	}
+	state, err := s.store.SetScheduledJobPaused(ctx, sqlc.SetScheduledJobPausedParams{
+		Paused:    paused,
+		NextRunAt: nextRunAt,
+		Name:      name,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Status{}, ErrUnknownJob
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	return status(job, state), nil
+}
+
+func (s *Scheduler) job(name string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+func (s *Scheduler) registered() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.names))
+	for _, name := range s.names {
+		jobs = append(jobs, s.jobs[name])
+	}
+	return jobs
+}
+
+func (s *Scheduler) isRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[name]
+}
+
+// status combines a job with its stored state
+func status(job Job, state sqlc.ScheduledJob) Status {
+	return Status{
+		Name:           job.Name,
+		Description:    job.Description,
+		Schedule:       state.Schedule,
+		Paused:         state.Paused,
+		Running:        state.LastStartedAt.Valid && (!state.LastFinishedAt.Valid || state.LastFinishedAt.Time.Before(state.LastStartedAt.Time)),
+		NextRunAt:      state.NextRunAt.Time,
+		LastStartedAt:  timePointer(state.LastStartedAt),
+		LastFinishedAt: timePointer(state.LastFinishedAt),
+		LastError:      state.LastError.String,
+		LastRunBy:      state.LastRunBy.String,
+	}
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func timePointer(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
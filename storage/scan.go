@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ErrInfected is returned by a Scanner that found a virus
+var ErrInfected = errors.New("file is infected")
+
+// Scanner checks a file for viruses before it is stored
+type Scanner interface {
+	// Scan reads the file from body and fails with ErrInfected when it holds a virus
+	Scan(ctx context.Context, body io.Reader) error
+}
+
+// CommandScanner scans files with a command reading them from stdin, following the exit codes of
+// ClamAV's clamdscan and clamscan: 0 when clean, 1 when infected and anything else when the scan
+// failed. For example: clamdscan --no-summary --stdout -
+type CommandScanner struct {
+	Command []string
+}
+
+// NewCommandScanner returns a scanner running command, split on spaces, or nil when command is
+// empty
+func NewCommandScanner(command string) *CommandScanner {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &CommandScanner{Command: fields}
+}
+
+func (c *CommandScanner) Scan(ctx context.Context, body io.Reader) error {
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stdin = body
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSpace(output.String()))
+	}
+	return fmt.Errorf("%s: %w: %s", c.Command[0], err, strings.TrimSpace(output.String()))
+}
@@ -0,0 +1,56 @@
+// Package storage keeps uploaded files, such as medical receipts, sick leave certificates and
+// avatars, in a Store: a local directory or an S3 bucket. Files are stored under keys the caller
+// makes with NewKey, and a Scanner may check them for viruses before they are stored.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no file is stored under a key
+var ErrNotFound = errors.New("file not found")
+
+// Store keeps files by key
+type Store interface {
+	// Put stores size bytes read from body under key
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string) error
+	// Get opens the file stored under key, failing with ErrNotFound when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key, if there is one
+	Delete(ctx context.Context, key string) error
+	String() string
+}
+
+// Presigner is a Store that hands out URLs clients download files from directly, without going
+// through the server
+type Presigner interface {
+	// PresignGet returns a URL that downloads the file under key as fileName until it expires
+	PresignGet(key, fileName string, expiry time.Duration) (string, error)
+}
+
+// Open returns the store at target, an s3://bucket/prefix URL signed for the account of s3 or a
+// local directory
+func Open(target string, s3 S3Config) (Store, error) {
+	if target == "" {
+		return nil, errors.New("no file storage location, set FILES_LOCATION")
+	}
+	if strings.HasPrefix(target, "s3://") {
+		return NewS3(target, s3)
+	}
+	return &localStore{dir: target}, nil
+}
+
+// NewKey returns a new random key under prefix, like receipts/2026/03/<32 hex digits>. The
+// month keeps directories of a local store small.
+func NewKey(prefix string, now time.Time) string {
+	var random [16]byte
+	_, _ = rand.Read(random[:])
+	return path.Join(prefix, now.UTC().Format("2006/01"), hex.EncodeToString(random[:]))
+}
@@ -0,0 +1,42 @@
+// Package tz decides which day and year it is for the company and its users. The server may run
+// in any time zone, UTC in most containers, while the company's day starts at midnight where the
+// company is: TIME_ZONE, Asia/Bangkok unless set. Users working elsewhere can pick their own zone,
+// which their "today" follows. Dates are kept the way the database returns them, midnight UTC of
+// the day, so they compare with dates parsed from the API and read from the database.
+package tz
+
+import (
+	"errors"
+	"time"
+
+	// The zone database is embedded, so zones load on hosts and images without one
+	_ "time/tzdata"
+)
+
+// Default is the zone TIME_ZONE defaults to, where the company is
+const Default = "Asia/Bangkok"
+
+// Load returns the zone an IANA name such as Asia/Bangkok or UTC names. Local, the zone the
+// server happens to run in, is refused, which day it is mustn't depend on the host.
+func Load(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return time.LoadLocation(name)
+}
+
+// Date returns the day t falls on in loc, as midnight UTC
+func Date(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the day it is in loc, as midnight UTC
+func Today(loc *time.Location) time.Time {
+	return Date(time.Now(), loc)
+}
+
+// Year returns the year it is in loc
+func Year(loc *time.Location) int {
+	return time.Now().In(loc).Year()
+}
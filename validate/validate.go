@@ -0,0 +1,341 @@
+// Package validate checks request bodies against the rules in their `validate` struct tags, so
+// handlers declare what a valid request looks like next to its fields instead of checking each one
+// by hand. Struct reports every broken rule at once, keyed by the field's JSON name:
+//
+//	type HolidayRequest struct {
+//		Date string `json:"date" validate:"required,date"`
+//		Name string `json:"name" validate:"required,max=255"`
+//	}
+//
+// The rules, separated by commas, are:
+//
+//	required   the field is set: a string that isn't blank, a non-nil pointer or slice, a number
+//	           other than 0, true
+//	min=N      a number is at least N, a string has at least N characters, a slice N items
+//	max=N      a number is at most N, a string has at most N characters, a slice N items
+//	gt=N       a number is greater than N
+//	oneof=a b  the value is one of the space-separated values
+//	date       a string is a date as YYYY-MM-DD
+//	email      a string looks like an email address
+//
+// Every rule but required passes a field that isn't set, so optional fields only need checking
+// when sent. Pointers are checked by the value they point at, and nested structs declared in the
+// same package by their own tags.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DateLayout is the format the date rule accepts, the format of every date in the API
+const DateLayout = "2006-01-02"
+
+// Errors maps the JSON name of each invalid field to what is wrong with it
+type Errors map[string]string
+
+// Error lists the fields in name order
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + e[field]
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add records a problem with a field, for rules that depend on more than one field or on how
+// the request is used, keeping the first problem found for each field
+func (e Errors) Add(field, message string) {
+	if _, ok := e[field]; !ok {
+		e[field] = message
+	}
+}
+
+// Err returns e, or nil when it is empty
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Struct checks v, a struct or a pointer to one, against its `validate` tags. It returns Errors
+// when a rule is broken and panics on a rule it doesn't know, which is a mistake in the tag.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", v))
+	}
+
+	errs := Errors{}
+	checkStruct(rv, "", errs)
+	return errs.Err()
+}
+
+// field is a struct field with a name in JSON and the rules from its tag
+type field struct {
+	index []int
+	name  string
+	rules []rule
+}
+
+// rule is one parsed rule of a tag
+type rule struct {
+	name  string
+	arg   string
+	num   float64
+	words []string
+}
+
+// fieldCache holds the parsed fields of each struct type checked so far
+var fieldCache sync.Map // reflect.Type -> []field
+
+// fieldsOf returns the fields of t to check, flattening embedded structs as encoding/json does
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if jsonName == "-" || (!sf.IsExported() && !sf.Anonymous) {
+			continue
+		}
+
+		if sf.Anonymous && jsonName == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for _, f := range fieldsOf(embedded) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+
+		if jsonName == "" {
+			jsonName = sf.Name
+		}
+		fields = append(fields, field{
+			index: []int{i},
+			name:  jsonName,
+			rules: parseRules(t, sf),
+		})
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// parseRules reads the validate tag of a field
+func parseRules(t reflect.Type, sf reflect.StructField) []rule {
+	tag := sf.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		r := rule{name: name, arg: arg}
+		switch name {
+		case "required", "date", "email":
+		case "min", "max", "gt":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: %s.%s: %s needs a number, got %q", t.Name(), sf.Name, name, arg))
+			}
+			r.num = n
+		case "oneof":
+			r.words = strings.Fields(arg)
+			if len(r.words) == 0 {
+				panic(fmt.Sprintf("validate: %s.%s: oneof needs values", t.Name(), sf.Name))
+			}
+		default:
+			panic(fmt.Sprintf("validate: %s.%s: unknown rule %q", t.Name(), sf.Name, name))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// checkStruct checks the fields of rv, naming them after prefix
+func checkStruct(rv reflect.Value, prefix string, errs Errors) {
+	for _, f := range fieldsOf(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		name := prefix + f.name
+		checkField(fv, name, f.rules, errs)
+
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) && fv.Type().PkgPath() == rv.Type().PkgPath() {
+			checkStruct(fv, name+".", errs)
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, reporting false instead of panicking on a nil
+// embedded pointer
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(i)
+	}
+	return rv, true
+}
+
+// checkField applies the rules of a field to its value, stopping at the first broken one
+func checkField(fv reflect.Value, name string, rules []rule, errs Errors) {
+	if len(rules) == 0 {
+		return
+	}
+
+	set := isSet(fv)
+	for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+
+	for _, r := range rules {
+		if r.name == "required" {
+			if !set {
+				errs.Add(name, "is required")
+				return
+			}
+			continue
+		}
+		if !set {
+			return
+		}
+		if msg := check(fv, r); msg != "" {
+			errs.Add(name, msg)
+			return
+		}
+	}
+}
+
+// isSet reports whether a value counts as sent for the required rule
+func isSet(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !v.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(v.String()) != ""
+	case reflect.Slice, reflect.Map:
+		return !v.IsNil() && v.Len() > 0
+	default:
+		return !v.IsZero()
+	}
+}
+
+// check applies one rule other than required, returning what is wrong or ""
+func check(v reflect.Value, r rule) string {
+	switch r.name {
+	case "min", "max":
+		size, isNumber, ok := sizeOf(v)
+		if !ok {
+			panic(fmt.Sprintf("validate: %s can't be applied to %s", r.name, v.Type()))
+		}
+		if r.name == "min" && size < r.num {
+			if isNumber {
+				return "must be at least " + r.arg
+			}
+			return "must have at least " + r.arg + " " + unitOf(v)
+		}
+		if r.name == "max" && size > r.num {
+			if isNumber {
+				return "must be at most " + r.arg
+			}
+			return "must have at most " + r.arg + " " + unitOf(v)
+		}
+	case "gt":
+		n, isNumber, ok := sizeOf(v)
+		if !ok || !isNumber {
+			panic(fmt.Sprintf("validate: gt can't be applied to %s", v.Type()))
+		}
+		if n <= r.num {
+			return "must be greater than " + r.arg
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, w := range r.words {
+			if s == w {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(r.words, ", ")
+	case "date":
+		if v.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate: date can't be applied to %s", v.Type()))
+		}
+		if _, err := time.Parse(DateLayout, v.String()); err != nil {
+			return "must be a date as YYYY-MM-DD"
+		}
+	case "email":
+		if v.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate: email can't be applied to %s", v.Type()))
+		}
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be an email address"
+		}
+	}
+	return ""
+}
+
+// sizeOf returns a number's value or the length of a string or slice
+func sizeOf(v reflect.Value) (size float64, isNumber, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), false, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), false, true
+	}
+	return 0, false, false
+}
+
+// unitOf names what the length of v counts
+func unitOf(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return "characters"
+	}
+	return "items"
+}
@@ -0,0 +1,24 @@
+// Package web embeds the front-end build, so small installs can have the server serve the UI
+// instead of running a separate web server. `npm run build:embed` builds the front end into
+// web/build before the server is built; a server built without it has no UI to serve.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:build
+var files embed.FS
+
+// Build returns the embedded front-end build, and false when the server was built without one
+func Build() (fs.FS, bool) {
+	build, err := fs.Sub(files, "build")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(build, "index.html"); err != nil {
+		return nil, false
+	}
+	return build, true
+}
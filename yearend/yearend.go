@@ -0,0 +1,96 @@
+// Package yearend closes a year of annual records: every user gets next year's record with their
+// unused vacation days rolled over, and next year gets a quota plan to assign. The server does it
+// on December 31st and tablegctl close-year does it on demand.
+package yearend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/kengtableg/pkeng-tableg/db/pgconv"
+	"github.com/kengtableg/pkeng-tableg/db/sqlc"
+)
+
+// defaultPlanName is the quota plan new annual records get
+const defaultPlanName = "Default"
+
+// Result is what closing a year created
+type Result struct {
+	NextYear int
+	// Records is how many users got an annual record for next year, none when they all had one
+	Records int
+	// QuotaPlan is the plan made for next year, nil when it already had a Default plan
+	QuotaPlan *sqlc.QuotaPlan
+}
+
+// Close creates next year's annual records for the users who have none, rolling over the vacation
+// days they left unused this year, and next year's Default quota plan with the quotas of this
+// year's Default or any other plan of this year, 10 days and 20,000 baht without one. It goes on
+// to the plan when the records fail, and returns both errors.
+func Close(ctx context.Context, q sqlc.Querier, thisYear int) (Result, error) {
+	result := Result{NextYear: thisYear + 1}
+	var errs []error
+
+	records, err := q.CreateNextYearAnnualRecords(ctx, sqlc.CreateNextYearAnnualRecordsParams{
+		ThisYear: int32(thisYear),
+		NextYear: int32(result.NextYear),
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating annual records of %d: %w", result.NextYear, err))
+	}
+	result.Records = len(records)
+
+	plan, err := ensureQuotaPlan(ctx, q, thisYear)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating the quota plan of %d: %w", result.NextYear, err))
+	}
+	result.QuotaPlan = plan
+	return result, errors.Join(errs...)
+}
+
+// ensureQuotaPlan creates next year's Default quota plan unless it has one, returning the plan
+// it created
+func ensureQuotaPlan(ctx context.Context, q sqlc.Querier, thisYear int) (*sqlc.QuotaPlan, error) {
+	nextYear := int32(thisYear + 1)
+	_, err := q.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{PlanName: defaultPlanName, Year: nextYear})
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	params := sqlc.CreateQuotaPlanParams{
+		PlanName:                defaultPlanName,
+		Year:                    nextYear,
+		QuotaVacationDay:        pgconv.MustFromFloat(10),
+		QuotaMedicalExpenseBaht: pgconv.MustFromFloat(20000),
+		CreatedByUserID:         pgtype.Int4{},
+	}
+	template, err := q.GetQuotaPlanByNameAndYear(ctx, sqlc.GetQuotaPlanByNameAndYearParams{PlanName: defaultPlanName, Year: int32(thisYear)})
+	if errors.Is(err, pgx.ErrNoRows) {
+		plans, listErr := q.ListQuotaPlansByYear(ctx, int32(thisYear))
+		if listErr != nil {
+			return nil, listErr
+		}
+		if len(plans) > 0 {
+			template, err = plans[0], nil
+		}
+	}
+	if err == nil {
+		params.QuotaVacationDay = template.QuotaVacationDay
+		params.QuotaMedicalExpenseBaht = template.QuotaMedicalExpenseBaht
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	plan, err := q.CreateQuotaPlan(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}